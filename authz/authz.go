@@ -44,6 +44,14 @@ p, *, *, POST, /api/signup, *, *
 p, *, *, GET, /api/get-email-and-phone, *, *
 p, *, *, POST, /api/login, *, *
 p, *, *, GET, /api/get-app-login, *, *
+p, *, *, GET, /api/get-app-login-config, *, *
+p, *, *, GET, /api/get-pending-requirements, *, *
+p, *, *, POST, /api/start-identity-verification, *, *
+p, *, *, GET, /api/get-identity-verification-state, *, *
+p, *, *, POST, /api/break-glass-login, *, *
+p, *, *, POST, /api/exchange-workload-token, *, *
+p, *, *, GET, /api/use-mfa-bypass, *, *
+p, *, *, POST, /api/use-mfa-bypass, *, *
 p, *, *, POST, /api/logout, *, *
 p, *, *, GET, /api/logout, *, *
 p, *, *, POST, /api/callback, *, *
@@ -54,6 +62,7 @@ p, *, *, GET, /api/health, *, *
 p, *, *, POST, /api/webhook, *, *
 p, *, *, GET, /api/get-webhook-event, *, *
 p, *, *, GET, /api/get-captcha-status, *, *
+p, *, *, GET, /api/get-bot-token, *, *
 p, *, *, *, /api/login/oauth, *, *
 p, *, *, GET, /api/get-application, *, *
 p, *, *, GET, /api/get-organization-applications, *, *
@@ -67,17 +76,64 @@ p, *, *, GET, /api/get-payment, *, *
 p, *, *, POST, /api/update-payment, *, *
 p, *, *, POST, /api/invoice-payment, *, *
 p, *, *, POST, /api/notify-payment, *, *
+p, *, *, POST, /api/notify-subscription, *, *
+p, *, *, POST, /api/notify-apple, *, *
+p, *, *, POST, /api/notify-sms, *, *
+p, *, *, POST, /api/notify-identity-verification, *, *
+p, *, *, POST, /api/email-bounce, *, *
 p, *, *, POST, /api/unlink, *, *
 p, *, *, POST, /api/set-password, *, *
 p, *, *, POST, /api/send-verification-code, *, *
 p, *, *, GET, /api/get-captcha, *, *
 p, *, *, POST, /api/verify-captcha, *, *
 p, *, *, POST, /api/verify-code, *, *
+p, *, *, POST, /api/start-account-recovery, *, *
+p, *, *, GET, /api/get-account-recovery-status, *, *
+p, *, *, POST, /api/send-account-recovery-code, *, *
+p, *, *, POST, /api/verify-account-recovery-factor, *, *
 p, *, *, POST, /api/reset-email-or-phone, *, *
+p, *, *, GET, /api/get-user-contacts, *, *
+p, *, *, POST, /api/add-user-contact, *, *
+p, *, *, POST, /api/verify-user-contact, *, *
+p, *, *, POST, /api/set-primary-user-contact, *, *
+p, *, *, POST, /api/delete-user-contact, *, *
+p, *, *, GET, /api/get-vault-credentials, *, *
+p, *, *, POST, /api/add-vault-credential, *, *
+p, *, *, POST, /api/update-vault-credential, *, *
+p, *, *, POST, /api/delete-vault-credential, *, *
+p, *, *, POST, /api/retrieve-vault-credential, *, *
+p, *, *, GET, /api/get-user-sessions, *, *
+p, *, *, POST, /api/set-session-device-name, *, *
+p, *, *, POST, /api/elevate, *, *
+p, *, *, GET, /api/get-access-requests, *, *
+p, *, *, GET, /api/get-access-request, *, *
+p, *, *, POST, /api/add-access-request, *, *
+p, *, *, POST, /api/approve-access-request, *, *
+p, *, *, POST, /api/reject-access-request, *, *
+p, *, *, POST, /api/cancel-access-request, *, *
+p, *, *, GET, /api/get-change-requests, *, *
+p, *, *, GET, /api/get-change-request, *, *
+p, *, *, POST, /api/approve-change-request, *, *
+p, *, *, POST, /api/reject-change-request, *, *
+p, *, *, POST, /api/cancel-change-request, *, *
+p, *, *, GET, /api/get-access-review-tasks, *, *
+p, *, *, POST, /api/certify-access-review-item, *, *
+p, *, *, POST, /api/revoke-access-review-item, *, *
 p, *, *, POST, /api/upload-resource, *, *
+p, *, *, GET, /api/get-shares, *, *
+p, *, *, POST, /api/add-share, *, *
+p, *, *, POST, /api/delete-share, *, *
+p, *, *, POST, /api/mfa/push/send, *, *
+p, *, *, POST, /api/mfa/approve, *, *
+p, *, *, POST, /api/regenerate-mfa-recovery-codes, *, *
 p, *, *, GET, /.well-known/openid-configuration, *, *
 p, *, *, *, /.well-known/jwks, *, *
+p, *, *, POST, /.well-known/est/simpleenroll, *, *
+p, *, *, POST, /.well-known/est/simplereenroll, *, *
+p, *, *, GET, /api/export-translations, *, *
+p, *, *, GET, /api/token-metadata, *, *
 p, *, *, GET, /api/get-saml-login, *, *
+p, *, *, GET, /api/get-web3-nonce, *, *
 p, *, *, POST, /api/acs, *, *
 p, *, *, GET, /api/saml/metadata, *, *
 p, *, *, *, /cas, *, *
@@ -86,12 +142,15 @@ p, *, *, *, /api/webauthn, *, *
 p, *, *, GET, /api/get-release, *, *
 p, *, *, GET, /api/get-default-application, *, *
 p, *, *, GET, /api/get-prometheus-info, *, *
+p, *, *, GET, /api/get-error-codes, *, *
 p, *, *, *, /api/metrics, *, *
 p, *, *, GET, /api/get-pricing, *, *
 p, *, *, GET, /api/get-plan, *, *
 p, *, *, GET, /api/get-subscription, *, *
 p, *, *, GET, /api/get-provider, *, *
 p, *, *, GET, /api/get-organization-names, *, *
+p, *, *, GET, /api/get-user-organizations, *, *
+p, *, *, POST, /api/switch-organization, *, *
 `
 
 		sa := stringadapter.NewAdapter(ruleText)
@@ -118,15 +177,15 @@ func IsAllowed(subOwner string, subName string, method string, urlPath string, o
 		}
 	}
 
+	if subOwner == "app" {
+		return true
+	}
+
 	user, err := object.GetUser(util.GetId(subOwner, subName))
 	if err != nil {
 		panic(err)
 	}
 
-	if subOwner == "app" {
-		return true
-	}
-
 	if user != nil {
 		if user.IsDeleted {
 			return false
@@ -135,6 +194,14 @@ func IsAllowed(subOwner string, subName string, method string, urlPath string, o
 		if user.IsAdmin && (subOwner == objOwner || (objOwner == "admin")) {
 			return true
 		}
+
+		// the admin of an organization also administers its sub-organizations
+		if user.IsAdmin && subOwner != "built-in" {
+			isInSubtree, err := object.IsOrganizationInSubtree(subOwner, objOwner)
+			if err == nil && isInSubtree {
+				return true
+			}
+		}
 	}
 
 	res, err := Enforcer.Enforce(subOwner, subName, method, urlPath, objOwner, objName)
@@ -149,7 +216,7 @@ func isAllowedInDemoMode(subOwner string, subName string, method string, urlPath
 	if method == "POST" {
 		if strings.HasPrefix(urlPath, "/api/login") || urlPath == "/api/logout" || urlPath == "/api/signup" || urlPath == "/api/callback" || urlPath == "/api/send-verification-code" || urlPath == "/api/send-email" || urlPath == "/api/verify-captcha" {
 			return true
-		} else if urlPath == "/api/update-user" {
+		} else if urlPath == "/api/update-user" || urlPath == "/api/patch-user" {
 			// Allow ordinary users to update their own information
 			if (subOwner == objOwner && subName == objName || subOwner == "app") && !(subOwner == "built-in" && subName == "admin") {
 				return true
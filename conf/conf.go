@@ -69,9 +69,7 @@ func GetConfigInt64(key string) (int64, error) {
 	return num, err
 }
 
-func GetConfigDataSourceName() string {
-	dataSourceName := GetConfigString("dataSourceName")
-
+func refineDataSourceNameForDocker(dataSourceName string) string {
 	runningInDocker := os.Getenv("RUNNING_IN_DOCKER")
 	if runningInDocker == "true" {
 		// https://stackoverflow.com/questions/48546124/what-is-linux-equivalent-of-host-docker-internal
@@ -85,6 +83,24 @@ func GetConfigDataSourceName() string {
 	return dataSourceName
 }
 
+func GetConfigDataSourceName() string {
+	dataSourceName := GetConfigString("dataSourceName")
+	return refineDataSourceNameForDocker(dataSourceName)
+}
+
+// GetConfigReplicaDataSourceNames returns the data source names of the read replicas,
+// they are separated by ";" in "replicaDataSourceNames" and share the same driverName and dbName with the primary.
+func GetConfigReplicaDataSourceNames() []string {
+	res := []string{}
+	for _, dataSourceName := range strings.Split(GetConfigString("replicaDataSourceNames"), ";") {
+		dataSourceName = strings.TrimSpace(dataSourceName)
+		if dataSourceName != "" {
+			res = append(res, refineDataSourceNameForDocker(dataSourceName))
+		}
+	}
+	return res
+}
+
 func GetLanguage(language string) string {
 	if language == "" || language == "*" {
 		return "en"
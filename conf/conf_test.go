@@ -125,3 +125,26 @@ func TestGetConfigLogs(t *testing.T) {
 		assert.Equal(t, scenery.expected, quota)
 	}
 }
+
+func TestGetConfigReplicaDataSourceNames(t *testing.T) {
+	scenarios := []struct {
+		description string
+		input       string
+		expected    []string
+	}{
+		{"No replica", "", []string{}},
+		{"One replica", "root:123456@tcp(replica1:3306)/", []string{"root:123456@tcp(replica1:3306)/"}},
+		{"Two replicas", "root:123456@tcp(replica1:3306)/; root:123456@tcp(replica2:3306)/;", []string{"root:123456@tcp(replica1:3306)/", "root:123456@tcp(replica2:3306)/"}},
+	}
+
+	err := beego.LoadAppConfig("ini", "app.conf")
+	assert.Nil(t, err)
+	for _, scenery := range scenarios {
+		t.Run(scenery.description, func(t *testing.T) {
+			os.Setenv("replicaDataSourceNames", scenery.input)
+			actual := GetConfigReplicaDataSourceNames()
+			assert.Equal(t, scenery.expected, actual)
+		})
+	}
+	os.Unsetenv("replicaDataSourceNames")
+}
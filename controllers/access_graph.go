@@ -0,0 +1,64 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/object"
+)
+
+// ExportAccessGraph
+// @Title ExportAccessGraph
+// @Tag Organization API
+// @Description export the graph of the users, groups, roles, permissions and resources of the organization with their memberships and grants, as JSON or as a GraphML file to be loaded into graph databases like Neo4j
+// @Param   owner     query    string  true        "The organization"
+// @Param   format    query    string  false       "The format: json (default) or graphml"
+// @Success 200 {object} object.AccessGraph The Response object
+// @router /export-access-graph [get]
+func (c *ApiController) ExportAccessGraph() {
+	organization, ok := c.getOrganizationForAdmin()
+	if !ok {
+		return
+	}
+
+	graph, err := object.GetAccessGraph(organization.Name)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	format := c.Input().Get("format")
+	switch format {
+	case "", "json":
+		c.ResponseOk(graph)
+	case "graphml":
+		data, err := graph.ToGraphml()
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.Ctx.Output.Header("Content-Type", "application/graphml+xml; charset=utf-8")
+		c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-access-graph.graphml\"", organization.Name))
+		err = c.Ctx.Output.Body(data)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+	default:
+		c.ResponseError(fmt.Sprintf(c.T("general:Unsupported format: %s"), format))
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetAccessRequests
+// @Title GetAccessRequests
+// @Tag Access Request API
+// @Description get the access requests submitted by the current user ( type = mine ), waiting for the current user's approval ( type = approval ), or all the requests of the organization for admins
+// @Param   owner     query    string  true        "The owner of access requests"
+// @Param   type      query    string  false       "mine, approval or empty"
+// @Success 200 {array} object.AccessRequest The Response object
+// @router /get-access-requests [get]
+func (c *ApiController) GetAccessRequests() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	requestType := c.Input().Get("type")
+	if requestType == "mine" {
+		accessRequests, err := object.GetUserAccessRequests(user.Owner, user.Name)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(accessRequests)
+		return
+	} else if requestType == "approval" {
+		accessRequests, err := object.GetPendingAccessRequestsForApprover(user)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(accessRequests)
+		return
+	}
+
+	owner := c.Input().Get("owner")
+	if !c.IsGlobalAdmin() && (!user.IsAdmin || user.Owner != owner) {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		accessRequests, err := object.GetAccessRequests(owner)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(accessRequests)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetAccessRequestCount(owner, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		accessRequests, err := object.GetPaginationAccessRequests(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(accessRequests, paginator.Nums())
+	}
+}
+
+func (c *ApiController) getAccessRequestFromContext(user *object.User) (*object.AccessRequest, bool) {
+	id := c.Input().Get("id")
+	accessRequest, err := object.GetAccessRequest(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+	if accessRequest == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The request: %s does not exist"), id))
+		return nil, false
+	}
+
+	isRequester := user.Owner == accessRequest.Owner && user.Name == accessRequest.User
+	if !isRequester && !accessRequest.CanBeApprovedBy(user) {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return nil, false
+	}
+
+	return accessRequest, true
+}
+
+// GetAccessRequest
+// @Title GetAccessRequest
+// @Tag Access Request API
+// @Description get an access request, only for the requester and the approvers
+// @Param   id     query    string  true        "The id ( owner/name ) of the access request"
+// @Success 200 {object} object.AccessRequest The Response object
+// @router /get-access-request [get]
+func (c *ApiController) GetAccessRequest() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	accessRequest, ok := c.getAccessRequestFromContext(user)
+	if !ok {
+		return
+	}
+
+	c.ResponseOk(accessRequest)
+}
+
+// AddAccessRequest
+// @Title AddAccessRequest
+// @Tag Access Request API
+// @Description request a role or a permission of the current user's organization
+// @Param   body    body   object.AccessRequest  true        "The type ( Role or Permission ), target, justification and optional expire time"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-access-request [post]
+func (c *ApiController) AddAccessRequest() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	var accessRequest object.AccessRequest
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &accessRequest)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddAccessRequest(&accessRequest, user, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+func (c *ApiController) reviewAccessRequest(isApproved bool) {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	accessRequest, ok := c.getAccessRequestFromContext(user)
+	if !ok {
+		return
+	}
+
+	comment := c.Input().Get("comment")
+	c.Data["json"] = wrapActionResponse(object.ReviewAccessRequest(accessRequest, user, isApproved, comment, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// ApproveAccessRequest
+// @Title ApproveAccessRequest
+// @Tag Access Request API
+// @Description approve an access request, the requester is added to the role or permission
+// @Param   id          query    string  true        "The id ( owner/name ) of the access request"
+// @Param   comment     query    string  false       "The comment of the approver"
+// @Success 200 {object} controllers.Response The Response object
+// @router /approve-access-request [post]
+func (c *ApiController) ApproveAccessRequest() {
+	c.reviewAccessRequest(true)
+}
+
+// RejectAccessRequest
+// @Title RejectAccessRequest
+// @Tag Access Request API
+// @Description reject an access request
+// @Param   id          query    string  true        "The id ( owner/name ) of the access request"
+// @Param   comment     query    string  false       "The comment of the approver"
+// @Success 200 {object} controllers.Response The Response object
+// @router /reject-access-request [post]
+func (c *ApiController) RejectAccessRequest() {
+	c.reviewAccessRequest(false)
+}
+
+// CancelAccessRequest
+// @Title CancelAccessRequest
+// @Tag Access Request API
+// @Description cancel a pending access request, only for the requester
+// @Param   id     query    string  true        "The id ( owner/name ) of the access request"
+// @Success 200 {object} controllers.Response The Response object
+// @router /cancel-access-request [post]
+func (c *ApiController) CancelAccessRequest() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	accessRequest, ok := c.getAccessRequestFromContext(user)
+	if !ok {
+		return
+	}
+
+	if user.Owner != accessRequest.Owner || user.Name != accessRequest.User {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.CancelAccessRequest(accessRequest, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
@@ -0,0 +1,308 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetAccessReviewCampaigns
+// @Title GetAccessReviewCampaigns
+// @Tag Access Review API
+// @Description get access review campaigns
+// @Param   owner     query    string  true        "The owner of access review campaigns"
+// @Success 200 {array} object.AccessReviewCampaign The Response object
+// @router /get-access-review-campaigns [get]
+func (c *ApiController) GetAccessReviewCampaigns() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		campaigns, err := object.GetAccessReviewCampaigns(owner)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(campaigns)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetAccessReviewCampaignCount(owner, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		campaigns, err := object.GetPaginationAccessReviewCampaigns(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(campaigns, paginator.Nums())
+	}
+}
+
+func (c *ApiController) getAccessReviewCampaignFromContext() (*object.AccessReviewCampaign, bool) {
+	id := c.Input().Get("id")
+	campaign, err := object.GetAccessReviewCampaign(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+
+	if campaign == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The access review campaign: %s does not exist"), id))
+		return nil, false
+	}
+
+	return campaign, true
+}
+
+// GetAccessReviewCampaign
+// @Title GetAccessReviewCampaign
+// @Tag Access Review API
+// @Description get access review campaign
+// @Param   id     query    string  true        "The id ( owner/name ) of the access review campaign"
+// @Success 200 {object} object.AccessReviewCampaign The Response object
+// @router /get-access-review-campaign [get]
+func (c *ApiController) GetAccessReviewCampaign() {
+	id := c.Input().Get("id")
+
+	campaign, err := object.GetAccessReviewCampaign(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(campaign)
+}
+
+// UpdateAccessReviewCampaign
+// @Title UpdateAccessReviewCampaign
+// @Tag Access Review API
+// @Description update access review campaign, the state of the rounds is not changed
+// @Param   id     query    string  true        "The id ( owner/name ) of the access review campaign"
+// @Param   body    body   object.AccessReviewCampaign  true        "The details of the access review campaign"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-access-review-campaign [post]
+func (c *ApiController) UpdateAccessReviewCampaign() {
+	id := c.Input().Get("id")
+
+	var campaign object.AccessReviewCampaign
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &campaign)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateAccessReviewCampaign(id, &campaign, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// AddAccessReviewCampaign
+// @Title AddAccessReviewCampaign
+// @Tag Access Review API
+// @Description add access review campaign, its first round starts at the start time or right away if empty
+// @Param   body    body   object.AccessReviewCampaign  true        "The details of the access review campaign"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-access-review-campaign [post]
+func (c *ApiController) AddAccessReviewCampaign() {
+	var campaign object.AccessReviewCampaign
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &campaign)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddAccessReviewCampaign(&campaign, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// DeleteAccessReviewCampaign
+// @Title DeleteAccessReviewCampaign
+// @Tag Access Review API
+// @Description delete access review campaign and its review items
+// @Param   body    body   object.AccessReviewCampaign  true        "The details of the access review campaign"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-access-review-campaign [post]
+func (c *ApiController) DeleteAccessReviewCampaign() {
+	var campaign object.AccessReviewCampaign
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &campaign)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteAccessReviewCampaign(&campaign))
+	c.ServeJSON()
+}
+
+// StartAccessReviewCampaign
+// @Title StartAccessReviewCampaign
+// @Tag Access Review API
+// @Description start a new round of the access review campaign right away
+// @Param   id     query    string  true        "The id ( owner/name ) of the access review campaign"
+// @Success 200 {object} controllers.Response The Response object
+// @router /start-access-review-campaign [post]
+func (c *ApiController) StartAccessReviewCampaign() {
+	campaign, ok := c.getAccessReviewCampaignFromContext()
+	if !ok {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.StartAccessReviewRound(campaign, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// GetAccessReviewItems
+// @Title GetAccessReviewItems
+// @Tag Access Review API
+// @Description get the review items of the access review campaign
+// @Param   id     query    string  true        "The id ( owner/name ) of the access review campaign"
+// @Param   round  query    string  false       "The round of the campaign, all the rounds if empty"
+// @Success 200 {array} object.AccessReviewItem The Response object
+// @router /get-access-review-items [get]
+func (c *ApiController) GetAccessReviewItems() {
+	campaign, ok := c.getAccessReviewCampaignFromContext()
+	if !ok {
+		return
+	}
+
+	items, err := object.GetAccessReviewItems(campaign, util.ParseInt(c.Input().Get("round")))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(items)
+}
+
+// ExportAccessReviewItems
+// @Title ExportAccessReviewItems
+// @Tag Access Review API
+// @Description export the review items of the access review campaign as CSV for the auditors
+// @Param   id     query    string  true        "The id ( owner/name ) of the access review campaign"
+// @Param   round  query    string  false       "The round of the campaign, all the rounds if empty"
+// @Success 200 {string} string "The CSV file"
+// @router /export-access-review-items [get]
+func (c *ApiController) ExportAccessReviewItems() {
+	campaign, ok := c.getAccessReviewCampaignFromContext()
+	if !ok {
+		return
+	}
+
+	items, err := object.GetAccessReviewItems(campaign, util.ParseInt(c.Input().Get("round")))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	err = writer.WriteAll(object.GetAccessReviewItemsCsv(items))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.csv\"", campaign.Name))
+	err = c.Ctx.Output.Body(buf.Bytes())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+}
+
+// GetAccessReviewTasks
+// @Title GetAccessReviewTasks
+// @Tag Access Review API
+// @Description get the pending review items that the current user is able to review
+// @Success 200 {array} object.AccessReviewItem The Response object
+// @router /get-access-review-tasks [get]
+func (c *ApiController) GetAccessReviewTasks() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	items, err := object.GetAccessReviewTasks(user)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(items)
+}
+
+func (c *ApiController) reviewAccessReviewItem(isCertified bool) {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	id := c.Input().Get("id")
+	item, err := object.GetAccessReviewItem(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if item == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The access review item: %s does not exist"), id))
+		return
+	}
+
+	comment := c.Input().Get("comment")
+	c.Data["json"] = wrapActionResponse(object.ReviewAccessReviewItem(item, user, isCertified, comment, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// CertifyAccessReviewItem
+// @Title CertifyAccessReviewItem
+// @Tag Access Review API
+// @Description certify that the user of the review item should keep the role or permission
+// @Param   id          query    string  true        "The id ( owner/name ) of the access review item"
+// @Param   comment     query    string  false       "The comment of the reviewer"
+// @Success 200 {object} controllers.Response The Response object
+// @router /certify-access-review-item [post]
+func (c *ApiController) CertifyAccessReviewItem() {
+	c.reviewAccessReviewItem(true)
+}
+
+// RevokeAccessReviewItem
+// @Title RevokeAccessReviewItem
+// @Tag Access Review API
+// @Description revoke the role or permission from the user of the review item
+// @Param   id          query    string  true        "The id ( owner/name ) of the access review item"
+// @Param   comment     query    string  false       "The comment of the reviewer"
+// @Success 200 {object} controllers.Response The Response object
+// @router /revoke-access-review-item [post]
+func (c *ApiController) RevokeAccessReviewItem() {
+	c.reviewAccessReviewItem(false)
+}
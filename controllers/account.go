@@ -37,6 +37,7 @@ const (
 type Response struct {
 	Status string      `json:"status"`
 	Msg    string      `json:"msg"`
+	Code   string      `json:"code,omitempty"`
 	Sub    string      `json:"sub"`
 	Name   string      `json:"name"`
 	Data   interface{} `json:"data"`
@@ -73,13 +74,13 @@ func (c *ApiController) Signup() {
 	var authForm form.AuthForm
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &authForm)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	application, err := object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -88,39 +89,75 @@ func (c *ApiController) Signup() {
 		return
 	}
 
+	if !c.checkBotDetection(application, object.BotActionSignup, authForm.BotToken) {
+		return
+	}
+
+	// the verification codes are sent for the application the user signs up with
+	scope := c.getVerificationScope(application.GetId(), SignupVerification)
+	isEmailVerified := application.IsSignupItemVisible("Email") && application.GetSignupItemRule("Email") != "No verification" && authForm.Email != ""
+
+	// the user with the email of a claimed domain is routed into the claiming organization if the email is verified
+	if isEmailVerified {
+		routedApplication, err := object.GetEmailDomainSignupApplication(application, authForm.Organization, authForm.Email, c.GetAcceptLanguage())
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+		if routedApplication != application {
+			application = routedApplication
+			authForm.Organization = application.Organization
+		}
+	} else {
+		err = object.CheckEmailDomainClaim(authForm.Organization, authForm.Email, c.GetAcceptLanguage())
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+	}
+
 	organization, err := object.GetOrganization(util.GetId("admin", authForm.Organization))
 	if err != nil {
 		c.ResponseError(c.T(err.Error()))
 		return
 	}
 
-	msg := object.CheckUserSignup(application, organization, &authForm, c.GetAcceptLanguage())
+	msg, fieldErrors, err := object.CheckSignupItems(application, organization, &authForm, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if msg != "" {
+		c.ResponseError(msg, fieldErrors)
+		return
+	}
+
+	msg = object.CheckUserSignup(application, organization, &authForm, c.GetAcceptLanguage())
 	if msg != "" {
 		c.ResponseError(msg)
 		return
 	}
 
-	if application.IsSignupItemVisible("Email") && application.GetSignupItemRule("Email") != "No verification" && authForm.Email != "" {
-		checkResult := object.CheckVerificationCode(authForm.Email, authForm.EmailCode, c.GetAcceptLanguage())
+	if isEmailVerified {
+		checkResult := object.CheckVerificationCode(scope, authForm.Email, authForm.EmailCode, c.GetAcceptLanguage())
 		if checkResult.Code != object.VerificationSuccess {
-			c.ResponseError(checkResult.Msg)
+			c.ResponseVerificationCodeError(checkResult.GetError())
 			return
 		}
 	}
 
-	var checkPhone string
 	if application.IsSignupItemVisible("Phone") && application.GetSignupItemRule("Phone") != "No verification" && authForm.Phone != "" {
-		checkPhone, _ = util.GetE164Number(authForm.Phone, authForm.CountryCode)
-		checkResult := object.CheckVerificationCode(checkPhone, authForm.PhoneCode, c.GetAcceptLanguage())
+		checkPhone, _ := util.GetE164Number(authForm.Phone, authForm.CountryCode)
+		checkResult := object.CheckVerificationCode(scope, checkPhone, authForm.PhoneCode, c.GetAcceptLanguage())
 		if checkResult.Code != object.VerificationSuccess {
-			c.ResponseError(checkResult.Msg)
+			c.ResponseVerificationCodeError(checkResult.GetError())
 			return
 		}
 	}
 
 	id, err := object.GenerateIdForNewUser(application)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -139,7 +176,7 @@ func (c *ApiController) Signup() {
 	if authForm.Plan != "" && authForm.Pricing != "" {
 		err = object.CheckPricingAndPlan(authForm.Organization, authForm.Pricing, authForm.Plan)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 		userType = "paid-user"
@@ -166,7 +203,7 @@ func (c *ApiController) Signup() {
 		IsForbidden:       false,
 		IsDeleted:         false,
 		SignupApplication: application.Name,
-		Properties:        map[string]string{},
+		Properties:        object.GetSignupItemProperties(application, &authForm),
 		Karma:             0,
 	}
 
@@ -187,7 +224,7 @@ func (c *ApiController) Signup() {
 
 	affected, err := object.AddUser(user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -198,31 +235,25 @@ func (c *ApiController) Signup() {
 
 	err = object.AddUserToOriginalDatabase(user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
-	if application.HasPromptPage() && user.Type == "normal-user" {
-		// The prompt page needs the user to be signed in
-		c.SetSessionUsername(user.GetId())
-	}
-
-	err = object.DisableVerificationCode(authForm.Email)
+	_, err = object.AcceptLegalDocuments(application, user, authForm.LegalDocuments, util.GetClientIpFromRequest(c.Ctx.Request))
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
-	err = object.DisableVerificationCode(checkPhone)
-	if err != nil {
-		c.ResponseError(err.Error())
-		return
+	if application.HasPromptPage() && user.Type == "normal-user" {
+		// The prompt page needs the user to be signed in
+		c.SetSessionUsername(user.GetId())
 	}
 
 	record := object.NewRecord(c.Ctx)
 	record.Organization = application.Organization
 	record.User = user.Name
-	util.SafeGoroutine(func() { object.AddRecord(record) })
+	object.AddRecord(record)
 
 	userId := user.GetId()
 	util.LogInfo(c.Ctx, "API: [%s] is signed up as new user", userId)
@@ -258,7 +289,7 @@ func (c *ApiController) Logout() {
 		owner, username := util.GetOwnerAndNameFromId(user)
 		_, err := object.DeleteSessionId(util.GetSessionId(owner, username, object.CasdoorApplication), c.Ctx.Input.CruSession.SessionID())
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -284,7 +315,7 @@ func (c *ApiController) Logout() {
 
 		_, application, token, err := object.ExpireTokenByAccessToken(accessToken)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 		if token == nil {
@@ -306,7 +337,7 @@ func (c *ApiController) Logout() {
 
 		_, err = object.DeleteSessionId(util.GetSessionId(owner, username, object.CasdoorApplication), c.Ctx.Input.CruSession.SessionID())
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -316,7 +347,7 @@ func (c *ApiController) Logout() {
 			c.ResponseOk()
 			return
 		} else {
-			if application.IsRedirectUriValid(redirectUri) {
+			if application.IsLogoutRedirectUriValid(redirectUri) {
 				redirectUrl := redirectUri
 				if state != "" {
 					if strings.Contains(redirectUri, "?") {
@@ -351,14 +382,14 @@ func (c *ApiController) GetAccount() {
 	if managedAccounts == "1" {
 		user, err = object.ExtendManagedAccountsWithUser(user)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	}
 
 	err = object.ExtendUserWithRolesAndPermissions(user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -370,14 +401,32 @@ func (c *ApiController) GetAccount() {
 
 	organization, err := object.GetMaskedOrganization(object.GetOrganizationByUser(user))
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
+	// the user switched to an organization it is a member of is returned as the member of it
+	if activeOrganization := c.getActiveOrganizationSession(); activeOrganization != "" && activeOrganization != user.Owner {
+		memberUser, err := object.GetMemberUser(activeOrganization, user)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		if memberUser != nil {
+			user = memberUser
+			organization, err = object.GetMaskedOrganization(object.GetOrganization(util.GetId("admin", activeOrganization)))
+			if err != nil {
+				c.ResponseErr(err)
+				return
+			}
+		}
+	}
+
 	isAdminOrSelf := c.IsAdminOrSelf(user)
 	u, err := object.GetMaskedUser(user, isAdminOrSelf)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -460,7 +509,7 @@ func (c *ApiController) GetCaptcha() {
 
 	captchaProvider, err := object.GetCaptchaProviderByApplication(applicationId, isCurrentProvider, c.GetAcceptLanguage())
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -468,7 +517,7 @@ func (c *ApiController) GetCaptcha() {
 		if captchaProvider.Type == "Default" {
 			id, img, err := object.GetCaptcha()
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
@@ -0,0 +1,253 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// getAccountRecovery returns the recovery of the ID, the response is written if it doesn't exist
+func (c *ApiController) getAccountRecovery(id string) (*object.AccountRecovery, bool) {
+	if !strings.Contains(id, "/") {
+		c.ResponseError(c.T("verification:The account recovery is invalid or expired"))
+		return nil, false
+	}
+
+	accountRecovery, err := object.GetAccountRecovery(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+	if accountRecovery == nil {
+		c.ResponseError(c.T("verification:The account recovery is invalid or expired"))
+		return nil, false
+	}
+
+	return accountRecovery, true
+}
+
+func (c *ApiController) responseAccountRecoveryStatus(accountRecovery *object.AccountRecovery) {
+	status, err := object.GetAccountRecoveryStatus(accountRecovery)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(status)
+}
+
+// StartAccountRecovery
+// @Title StartAccountRecovery
+// @Tag Account API
+// @Description start the recovery of the account requiring multiple verified factors, the primary Email of the user is notified
+// @Param   body    body   form.AccountRecoveryForm  true        "The application and the username (Email or phone)"
+// @Success 200 {object} object.AccountRecoveryStatus The Response object
+// @router /start-account-recovery [post]
+func (c *ApiController) StartAccountRecovery() {
+	var recoveryForm form.AccountRecoveryForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recoveryForm)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	application, err := object.GetApplication(recoveryForm.Application)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), recoveryForm.Application))
+		return
+	}
+
+	organization, err := object.GetOrganization(util.GetId("admin", application.Organization))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if organization == nil {
+		c.ResponseError(c.T("check:Organization does not exist"))
+		return
+	}
+
+	user, err := object.GetUserByFields(organization.Name, recoveryForm.Username)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), util.GetId(organization.Name, recoveryForm.Username)))
+		return
+	}
+
+	accountRecovery, err := object.StartAccountRecovery(organization, user, util.GetIPFromRequest(c.Ctx.Request), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.responseAccountRecoveryStatus(accountRecovery)
+}
+
+// GetAccountRecoveryStatus
+// @Title GetAccountRecoveryStatus
+// @Tag Account API
+// @Description get the progress of the account recovery
+// @Param   id     query    string  true        "The id ( owner/name ) of the account recovery"
+// @Success 200 {object} object.AccountRecoveryStatus The Response object
+// @router /get-account-recovery-status [get]
+func (c *ApiController) GetAccountRecoveryStatus() {
+	accountRecovery, ok := c.getAccountRecovery(c.Input().Get("id"))
+	if !ok {
+		return
+	}
+
+	c.responseAccountRecoveryStatus(accountRecovery)
+}
+
+// SendAccountRecoveryCode
+// @Title SendAccountRecoveryCode
+// @Tag Account API
+// @Description send the verification code of the factor of the account recovery
+// @Param   body    body   form.AccountRecoveryForm  true        "The application, the id of the account recovery and the factor"
+// @Success 200 {object} controllers.Response The Response object
+// @router /send-account-recovery-code [post]
+func (c *ApiController) SendAccountRecoveryCode() {
+	var recoveryForm form.AccountRecoveryForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recoveryForm)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	accountRecovery, ok := c.getAccountRecovery(recoveryForm.Id)
+	if !ok {
+		return
+	}
+
+	application, err := object.GetApplication(recoveryForm.Application)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if application == nil || application.Organization != accountRecovery.Owner {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), recoveryForm.Application))
+		return
+	}
+
+	err = object.SendAccountRecoveryCode(accountRecovery, recoveryForm.Factor, application, util.GetIPFromRequest(c.Ctx.Request), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk()
+}
+
+// VerifyAccountRecoveryFactor
+// @Title VerifyAccountRecoveryFactor
+// @Tag Account API
+// @Description verify a factor of the account recovery by the code sent to it or a recovery code, the password can be reset by /api/set-password with the recovery id once the required factors are verified
+// @Param   body    body   form.AccountRecoveryForm  true        "The id of the account recovery, the factor and the code"
+// @Success 200 {object} object.AccountRecoveryStatus The Response object
+// @router /verify-account-recovery-factor [post]
+func (c *ApiController) VerifyAccountRecoveryFactor() {
+	var recoveryForm form.AccountRecoveryForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recoveryForm)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	accountRecovery, ok := c.getAccountRecovery(recoveryForm.Id)
+	if !ok {
+		return
+	}
+
+	err = object.VerifyAccountRecoveryFactor(accountRecovery, recoveryForm.Factor, recoveryForm.Code, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.responseAccountRecoveryStatus(accountRecovery)
+}
+
+// ApproveAccountRecovery
+// @Title ApproveAccountRecovery
+// @Tag Account API
+// @Description approve the account recovery as its admin approval factor
+// @Param   id     query    string  true        "The id ( owner/name ) of the account recovery"
+// @Success 200 {object} controllers.Response The Response object
+// @router /approve-account-recovery [post]
+func (c *ApiController) ApproveAccountRecovery() {
+	accountRecovery, ok := c.getAccountRecovery(c.Input().Get("id"))
+	if !ok {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.ApproveAccountRecovery(accountRecovery, c.GetSessionUsername(), c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// GetAccountRecoveries
+// @Title GetAccountRecoveries
+// @Tag Account API
+// @Description get the account recoveries of the organization
+// @Param   owner     query    string  true        "The organization of the account recoveries"
+// @Success 200 {array} object.AccountRecovery The Response object
+// @router /get-account-recoveries [get]
+func (c *ApiController) GetAccountRecoveries() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" {
+		limit = "100"
+	}
+	if page == "" {
+		page = "1"
+	}
+	if sortField == "" {
+		sortField, sortOrder = "created_time", "descend"
+	}
+
+	count, err := object.GetAccountRecoveryCount(owner, field, value)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	paginator := pagination.SetPaginator(c.Ctx, util.ParseInt(limit), count)
+	accountRecoveries, err := object.GetPaginationAccountRecoveries(owner, paginator.Offset(), util.ParseInt(limit), field, value, sortField, sortOrder)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(accountRecoveries, paginator.Nums())
+}
@@ -17,6 +17,7 @@ package controllers
 import (
 	"encoding/json"
 	"fmt"
+	"net/http"
 
 	"github.com/beego/beego/utils/pagination"
 	"github.com/casdoor/casdoor/object"
@@ -28,6 +29,7 @@ import (
 // @Tag Application API
 // @Description get all applications
 // @Param   owner     query    string  true        "The owner of applications."
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {array} object.Application The Response object
 // @router /get-applications [get]
 func (c *ApiController) GetApplications() {
@@ -49,27 +51,27 @@ func (c *ApiController) GetApplications() {
 			applications, err = object.GetOrganizationApplications(owner, organization)
 		}
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
-		c.ResponseOk(object.GetMaskedApplications(applications, userId))
+		c.ResponseOkWithFields(object.GetMaskedApplications(applications, userId))
 	} else {
 		limit := util.ParseInt(limit)
 		count, err := object.GetApplicationCount(owner, field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		application, err := object.GetPaginationApplications(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		applications := object.GetMaskedApplications(application, userId)
-		c.ResponseOk(applications, paginator.Nums())
+		c.ResponseOkWithFields(applications, paginator.Nums())
 	}
 }
 
@@ -78,6 +80,7 @@ func (c *ApiController) GetApplications() {
 // @Tag Application API
 // @Description get the detail of an application
 // @Param   id     query    string  true        "The id ( owner/name ) of the application."
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {object} object.Application The Response object
 // @router /get-application [get]
 func (c *ApiController) GetApplication() {
@@ -86,21 +89,21 @@ func (c *ApiController) GetApplication() {
 
 	application, err := object.GetApplication(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	if c.Input().Get("withKey") != "" && application != nil && application.Cert != "" {
 		cert, err := object.GetCert(util.GetId(application.Owner, application.Cert))
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		if cert == nil {
 			cert, err = object.GetCert(util.GetId(application.Organization, application.Cert))
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 		}
@@ -110,7 +113,116 @@ func (c *ApiController) GetApplication() {
 		}
 	}
 
-	c.ResponseOk(object.GetMaskedApplication(application, userId))
+	if c.Input().Get("withLoginExperiment") != "" {
+		err = c.applyLoginExperiment(application)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+	}
+
+	err = object.ApplyCustomDomain(application, c.Ctx.Request.Host)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOkWithFields(object.GetMaskedApplication(application, userId))
+}
+
+// GetAppLoginConfig
+// @Title GetAppLoginConfig
+// @Tag Application API
+// @Description get the public configuration for a custom login UI of the application, including the signup items, providers, theme, password policy and the i18n strings. The response has an ETag, a request with the matching If-None-Match header gets 304 Not Modified
+// @Param   id     query    string  false        "The id ( owner/name ) of the application"
+// @Param   clientId     query    string  false        "The client id of the application, used if the id is empty"
+// @Param   lang     query    string  false        "The language of the i18n strings, default is from the Accept-Language header"
+// @Success 200 {object} object.AppLoginConfig The Response object
+// @router /get-app-login-config [get]
+func (c *ApiController) GetAppLoginConfig() {
+	id := c.Input().Get("id")
+	clientId := c.Input().Get("clientId")
+	language := c.Input().Get("lang")
+	if language == "" {
+		language = c.GetAcceptLanguage()
+	}
+
+	var application *object.Application
+	var err error
+	if id == "" && clientId != "" {
+		application, err = object.GetApplicationByClientId(clientId)
+		id = clientId
+	} else {
+		application, err = object.GetApplication(id)
+	}
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), id))
+		return
+	}
+
+	config, err := object.GetAppLoginConfig(application, language)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	resp := &Response{Status: "ok", Data: config}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	// the clients revalidate the config every time, but only download it again after it is changed
+	etag := fmt.Sprintf("\"%s\"", util.GetMd5Hash(string(body)))
+	c.Ctx.Output.Header("ETag", etag)
+	c.Ctx.Output.Header("Cache-Control", "no-cache")
+	if c.Ctx.Input.Header("If-None-Match") == etag {
+		c.Ctx.ResponseWriter.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	c.ResponseJsonData(resp)
+}
+
+// ValidateRedirectUri
+// @Title ValidateRedirectUri
+// @Tag Application API
+// @Description check whether the redirect URI is allowed by the application
+// @Param   id            query    string  true        "The id ( owner/name ) of the application."
+// @Param   redirectUri   query    string  true        "The redirect URI"
+// @Param   type          query    string  false       "The usage of the redirect URI: Login or Logout, default is Login"
+// @Success 200 {object} object.RedirectUriValidation The Response object
+// @router /validate-redirect-uri [get]
+func (c *ApiController) ValidateRedirectUri() {
+	id := c.Input().Get("id")
+	redirectUri := c.Input().Get("redirectUri")
+	usage := c.Input().Get("type")
+	if usage == "" {
+		usage = object.RedirectUriUsageLogin
+	}
+
+	if usage != object.RedirectUriUsageLogin && usage != object.RedirectUriUsageLogout {
+		c.ResponseError(fmt.Sprintf(c.T("general:Unknown type: %s"), usage))
+		return
+	}
+
+	application, err := object.GetApplication(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), id))
+		return
+	}
+
+	c.ResponseOk(application.ValidateRedirectUri(redirectUri, usage))
 }
 
 // GetUserApplication
@@ -118,6 +230,7 @@ func (c *ApiController) GetApplication() {
 // @Tag Application API
 // @Description get the detail of the user's application
 // @Param   id     query    string  true        "The id ( owner/name ) of the user"
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {object} object.Application The Response object
 // @router /get-user-application [get]
 func (c *ApiController) GetUserApplication() {
@@ -126,7 +239,7 @@ func (c *ApiController) GetUserApplication() {
 
 	user, err := object.GetUser(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	if user == nil {
@@ -136,11 +249,11 @@ func (c *ApiController) GetUserApplication() {
 
 	application, err := object.GetApplicationByUser(user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
-	c.ResponseOk(object.GetMaskedApplication(application, userId))
+	c.ResponseOkWithFields(object.GetMaskedApplication(application, userId))
 }
 
 // GetOrganizationApplications
@@ -148,6 +261,7 @@ func (c *ApiController) GetUserApplication() {
 // @Tag Application API
 // @Description get the detail of the organization's application
 // @Param   organization     query    string  true        "The organization name"
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {array} object.Application The Response object
 // @router /get-organization-applications [get]
 func (c *ApiController) GetOrganizationApplications() {
@@ -169,35 +283,35 @@ func (c *ApiController) GetOrganizationApplications() {
 	if limit == "" || page == "" {
 		applications, err := object.GetOrganizationApplications(owner, organization)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		applications, err = object.GetAllowedApplications(applications, userId)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
-		c.ResponseOk(object.GetMaskedApplications(applications, userId))
+		c.ResponseOkWithFields(object.GetMaskedApplications(applications, userId))
 	} else {
 		limit := util.ParseInt(limit)
 
 		count, err := object.GetOrganizationApplicationCount(owner, organization, field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		application, err := object.GetPaginationOrganizationApplications(owner, organization, paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		applications := object.GetMaskedApplications(application, userId)
-		c.ResponseOk(applications, paginator.Nums())
+		c.ResponseOkWithFields(applications, paginator.Nums())
 	}
 }
 
@@ -215,7 +329,11 @@ func (c *ApiController) UpdateApplication() {
 	var application object.Application
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &application)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
+		return
+	}
+
+	if c.submitChangeRequest(object.ChangeRequestTypeApplication, id, &application) {
 		return
 	}
 
@@ -234,18 +352,22 @@ func (c *ApiController) AddApplication() {
 	var application object.Application
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &application)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	count, err := object.GetApplicationCount("", "", "")
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	if err := checkQuotaForApplication(int(count)); err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
+		return
+	}
+
+	if c.submitObjectChangeRequest(object.ChangeRequestTypeApplication, object.ChangeRequestActionAdd, &application) {
 		return
 	}
 
@@ -253,6 +375,45 @@ func (c *ApiController) AddApplication() {
 	c.ServeJSON()
 }
 
+// CloneApplication
+// @Title CloneApplication
+// @Tag Application API
+// @Description clone an application as a template, the client ID and client secret are regenerated
+// @Param   id              query    string  true        "The id ( owner/name ) of the source application"
+// @Param   newName         query    string  true        "The name of the new application"
+// @Param   organization    query    string  false       "The organization of the new application, defaults to the source one"
+// @Success 200 {object} object.Application The Response object
+// @router /clone-application [post]
+func (c *ApiController) CloneApplication() {
+	id := c.Input().Get("id")
+	newName := c.Input().Get("newName")
+	organization := c.Input().Get("organization")
+
+	if newName == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	count, err := object.GetApplicationCount("", "", "")
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if err = checkQuotaForApplication(int(count)); err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	application, err := object.CloneApplication(id, newName, organization, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(application)
+}
+
 // DeleteApplication
 // @Title DeleteApplication
 // @Tag Application API
@@ -264,7 +425,7 @@ func (c *ApiController) DeleteApplication() {
 	var application object.Application
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &application)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -22,9 +22,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/casdoor/casdoor/captcha"
 	"github.com/casdoor/casdoor/conf"
@@ -33,7 +33,6 @@ import (
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/proxy"
 	"github.com/casdoor/casdoor/util"
-	"github.com/google/uuid"
 	"golang.org/x/oauth2"
 )
 
@@ -61,9 +60,33 @@ func tokenToResponse(token *object.Token) *Response {
 func (c *ApiController) HandleLoggedIn(application *object.Application, user *object.User, form *form.AuthForm) (resp *Response) {
 	userId := user.GetId()
 
+	err := object.CheckUserSuspended(user, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	err = object.CheckUserLifecycle(user, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	err = object.CheckUserBreakGlass(user, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	isMfaEnrollmentRequired, err := object.CheckUserActivation(user, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
 	allowed, err := object.CheckLoginPermission(userId, application)
 	if err != nil {
-		c.ResponseError(err.Error(), nil)
+		c.ResponseErr(err, nil)
 		return
 	}
 	if !allowed {
@@ -71,6 +94,48 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 		return
 	}
 
+	err = object.CheckSigninRestriction(application, user, util.GetClientIpFromRequest(c.Ctx.Request), "login", c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	accessResult, err := object.EvaluateConditionalAccess(c.getConditionalAccessContext(application, user))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if accessResult.Action == object.ConditionalAccessActionDeny {
+		c.ResponseError(fmt.Sprintf(c.T("auth:Sign-in is denied by the conditional access policy: %s"), accessResult.Policy))
+		return
+	}
+
+	if accessResult.Action == object.ConditionalAccessActionRequireMfa && c.getMfaVerifiedSession() != userId && !c.useMfaBypassSession(user) {
+		if user.IsTransient() {
+			c.ResponseError(fmt.Sprintf(c.T("auth:Sign-in is denied by the conditional access policy: %s"), accessResult.Policy))
+			return
+		}
+
+		if !user.IsMfaEnabled() {
+			// The prompt page needs the user to be signed in
+			c.SetSessionUsername(userId)
+			c.ResponseOk(object.RequiredMfa)
+			return
+		}
+
+		c.setMfaUserSession(userId)
+		c.ResponseOk(object.NextMfa, user.GetPreferredMfaProps(true))
+		return
+	}
+
+	if isMfaEnrollmentRequired {
+		// the pre-registered user enrolls MFA on the first login after the activation
+		c.SetSessionUsername(userId)
+		c.ResponseOk(object.RequiredMfa)
+		return
+	}
+
 	// check user's tag
 	if !user.IsGlobalAdmin() && !user.IsAdmin && len(application.Tags) > 0 {
 		// only users with the tag that is listed in the application tags can login
@@ -84,7 +149,7 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 	if user.Type == "paid-user" {
 		subscriptions, err := object.GetSubscriptionsByUser(user.Owner, user.Name)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 		existActiveSubscription := false
@@ -105,7 +170,7 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 			// paid-user does not have active or pending subscription, find the default pricing of application
 			pricing, err := object.GetApplicationDefaultPricing(application.Organization, application.Name)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 			if pricing == nil {
@@ -120,6 +185,52 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 		}
 	}
 
+	_, err = object.RunAuthHooks(object.AuthHookEventPostAuthentication, application, user, util.GetClientIpFromRequest(c.Ctx.Request), "", c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	object.RecordActiveUser(user.Owner, user.Name)
+
+	if user.IsTransient() {
+		// transient users of the broker mode have no local session or record to be updated
+		return c.handleBrokerLoggedIn(application, user, form)
+	}
+
+	pendingAttributes, err := object.SetUserRequiredAttributes(application, user, form.Attributes, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if len(pendingAttributes) > 0 {
+		// the user submits the pending attributes to the login API to continue the login
+		c.setAuthStepSession(application, userId, len(application.GetPostAuthSteps()))
+		c.ResponseOk(object.PendingRequirements, pendingAttributes)
+		return
+	}
+
+	pendingDocuments, err := object.AcceptLegalDocuments(application, user, form.LegalDocuments, util.GetClientIpFromRequest(c.Ctx.Request))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if len(pendingDocuments) > 0 {
+		// the user accepts the versions of the pending documents by the login API to continue the login
+		c.setAuthStepSession(application, userId, len(application.GetPostAuthSteps()))
+		c.ResponseOk(object.PendingLegalDocuments, pendingDocuments)
+		return
+	}
+
+	if object.IsIdentityVerificationRequired(application, user) {
+		// the user verifies the identity by /api/start-identity-verification and calls the login API again once it's verified
+		c.setAuthStepSession(application, userId, len(application.GetPostAuthSteps()))
+		c.ResponseOk(object.PendingIdentityVerification, object.GetIdentityVerificationState(user))
+		return
+	}
+
 	if form.Type == ResponseTypeLogin {
 		c.SetSessionUsername(userId)
 		util.LogInfo(c.Ctx, "API: [%s] signed in", userId)
@@ -140,7 +251,7 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 		}
 		code, err := object.GetOAuthCode(userId, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, c.Ctx.Request.Host, c.GetAcceptLanguage())
 		if err != nil {
-			c.ResponseError(err.Error(), nil)
+			c.ResponseErr(err, nil)
 			return
 		}
 
@@ -153,6 +264,8 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 	} else if form.Type == ResponseTypeToken || form.Type == ResponseTypeIdToken { // implicit flow
 		if !object.IsGrantTypeValid(form.Type, application.GrantTypes) {
 			resp = &Response{Status: "error", Msg: fmt.Sprintf("error: grant_type: %s is not supported in this application", form.Type), Data: ""}
+		} else if !application.IsResponseTypeAllowed(form.Type) {
+			resp = &Response{Status: "error", Msg: fmt.Sprintf("error: response_type: %s is not allowed in this application", form.Type), Data: ""}
 		} else {
 			scope := c.Input().Get("scope")
 			nonce := c.Input().Get("nonce")
@@ -162,7 +275,7 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 	} else if form.Type == ResponseTypeSaml { // saml flow
 		res, redirectUrl, method, err := object.GetSamlResponse(application, user, form.SamlRequest, c.Ctx.Request.Host)
 		if err != nil {
-			c.ResponseError(err.Error(), nil)
+			c.ResponseErr(err, nil)
 			return
 		}
 		resp = &Response{Status: "ok", Msg: "", Data: res, Data2: map[string]string{"redirectUrl": redirectUrl, "method": method}}
@@ -192,20 +305,42 @@ func (c *ApiController) HandleLoggedIn(application *object.Application, user *ob
 		resp = wrapErrorResponse(fmt.Errorf("unknown response type: %s", form.Type))
 	}
 
-	// if user did not check auto signin
-	if resp.Status == "ok" && !form.AutoSignin {
-		c.setExpireForSession()
+	if resp.Status == "ok" && accessResult.Action == object.ConditionalAccessActionLimitSession {
+		// the session lifetime limited by the conditional access policy takes precedence over auto signin
+		c.setExpireForSession(time.Duration(accessResult.SessionLifetime) * time.Minute)
+	} else if resp.Status == "ok" && !form.AutoSignin {
+		// if user did not check auto signin
+		c.setExpireForSession(24 * time.Hour)
 	}
 
 	if resp.Status == "ok" {
-		_, err = object.AddSession(&object.Session{
+		c.setMfaVerifiedSession("")
+		c.applySessionPolicy(application.SessionPolicy)
+
+		session := &object.Session{
 			Owner:       user.Owner,
 			Name:        user.Name,
 			Application: application.Name,
 			SessionId:   []string{c.Ctx.Input.CruSession.SessionID()},
-		})
+			Devices:     c.getSessionDevices(),
+		}
+		_, err = object.AddSession(session)
 		if err != nil {
-			c.ResponseError(err.Error(), nil)
+			c.ResponseErr(err, nil)
+			return
+		}
+
+		if application.SessionPolicy != nil {
+			err = object.LimitConcurrentSessions(session.GetId(), c.Ctx.Input.CruSession.SessionID(), application.SessionPolicy.MaxConcurrentSessions)
+			if err != nil {
+				c.ResponseErr(err, nil)
+				return
+			}
+		}
+
+		err = object.ConvertLoginExperiment(application, c.getLoginSubject(false))
+		if err != nil {
+			c.ResponseErr(err, nil)
 			return
 		}
 	}
@@ -239,13 +374,13 @@ func (c *ApiController) GetApplicationLogin() {
 	if loginType == "code" {
 		msg, application, err = object.CheckOAuthLogin(clientId, responseType, redirectUri, scope, state, c.GetAcceptLanguage())
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	} else if loginType == "cas" {
 		application, err = object.GetApplication(id)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 		if application == nil {
@@ -255,11 +390,23 @@ func (c *ApiController) GetApplicationLogin() {
 
 		err = object.CheckCasLogin(application, c.GetAcceptLanguage(), redirectUri)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	}
 
+	err = c.applyLoginExperiment(application)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	err = object.ApplyCustomDomain(application, c.Ctx.Request.Host)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
 	application = object.GetMaskedApplication(application, "")
 	if msg != "" {
 		c.ResponseError(msg, application)
@@ -268,6 +415,50 @@ func (c *ApiController) GetApplicationLogin() {
 	}
 }
 
+// GetPendingRequirements
+// @Title GetPendingRequirements
+// @Tag Login API
+// @Description get the required attributes that the user still needs to provide to sign in to the application
+// @Param   application    query    string  true        "The name of the application"
+// @Success 200 {array} object.RequiredAttribute The Response object
+// @router /get-pending-requirements [get]
+func (c *ApiController) GetPendingRequirements() {
+	applicationName := c.Input().Get("application")
+
+	userId, _ := c.getAuthStepSession()
+	if userId == "" {
+		userId = c.GetSessionUsername()
+	}
+	if userId == "" {
+		c.ResponseError(c.T("general:Please login first"))
+		return
+	}
+
+	user, err := object.GetUser(userId)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), userId))
+		return
+	}
+
+	application, err := object.GetApplication(fmt.Sprintf("admin/%s", applicationName))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), applicationName))
+		return
+	}
+
+	c.ResponseOk(object.GetPendingRequiredAttributes(application, user))
+}
+
 func setHttpClient(idProvider idp.IdProvider, providerType string) {
 	if isProxyProviderType(providerType) {
 		idProvider.SetHttpClient(proxy.ProxyHttpClient)
@@ -320,7 +511,7 @@ func (c *ApiController) Login() {
 	var authForm form.AuthForm
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &authForm)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -332,10 +523,37 @@ func (c *ApiController) Login() {
 			}
 		}
 
+		if authForm.Password == "" && authForm.Code == "" {
+			var application *object.Application
+			application, err = object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
+			if err != nil {
+				c.ResponseErr(err)
+				return
+			}
+
+			err = c.applyLoginExperiment(application)
+			if err != nil {
+				c.ResponseErr(err)
+				return
+			}
+
+			if application != nil && application.GetAuthStep(object.AuthStepIdentifier) != nil {
+				c.handleIdentifierStep(application, &authForm)
+				return
+			}
+		}
+
+		// the members of the organization from other organizations sign in with the accounts of their home organizations
+		authForm.Organization, err = object.GetMemberOrganization(authForm.Organization, authForm.Username)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
 		var user *object.User
 		if authForm.Password == "" {
 			if user, err = object.GetUserByFields(authForm.Organization, authForm.Username); err != nil {
-				c.ResponseError(err.Error(), nil)
+				c.ResponseErr(err, nil)
 				return
 			} else if user == nil {
 				c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), util.GetId(authForm.Organization, authForm.Username)))
@@ -354,23 +572,17 @@ func (c *ApiController) Login() {
 			}
 
 			// check result through Email or Phone
-			err = object.CheckSigninCode(user, checkDest, authForm.Code, c.GetAcceptLanguage())
+			scope := c.getVerificationScope(util.GetId("admin", authForm.Application), LoginVerification)
+			err = object.CheckSigninCode(scope, user, checkDest, authForm.Code, c.GetAcceptLanguage())
 			if err != nil {
 				c.ResponseError(fmt.Sprintf("%s - %s", verificationCodeType, err.Error()))
 				return
 			}
-
-			// disable the verification code
-			err = object.DisableVerificationCode(checkDest)
-			if err != nil {
-				c.ResponseError(err.Error(), nil)
-				return
-			}
 		} else {
 			var application *object.Application
 			application, err = object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
 			if err != nil {
-				c.ResponseError(err.Error(), nil)
+				c.ResponseErr(err, nil)
 				return
 			}
 
@@ -378,18 +590,28 @@ func (c *ApiController) Login() {
 				c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), authForm.Application))
 				return
 			}
+
+			err = c.applyLoginExperiment(application)
+			if err != nil {
+				c.ResponseErr(err)
+				return
+			}
+
 			if !application.EnablePassword {
 				c.ResponseError(c.T("auth:The login method: login with password is not enabled for the application"))
 				return
 			}
+			if !c.checkBotDetection(application, object.BotActionLogin, authForm.BotToken) {
+				return
+			}
 			var enableCaptcha bool
-			if enableCaptcha, err = object.CheckToEnableCaptcha(application, authForm.Organization, authForm.Username); err != nil {
-				c.ResponseError(err.Error())
+			if enableCaptcha, err = object.CheckToEnableCaptcha(application, authForm.Organization, authForm.Username, c.getCachedBotScore()); err != nil {
+				c.ResponseErr(err)
 				return
 			} else if enableCaptcha {
 				captchaProvider, err := object.GetCaptchaProviderByApplication(util.GetId(application.Owner, application.Name), "false", c.GetAcceptLanguage())
 				if err != nil {
-					c.ResponseError(err.Error())
+					c.ResponseErr(err)
 					return
 				}
 
@@ -400,7 +622,7 @@ func (c *ApiController) Login() {
 				var isHuman bool
 				isHuman, err = captcha.VerifyCaptchaByCaptchaType(authForm.CaptchaType, authForm.CaptchaToken, authForm.ClientSecret)
 				if err != nil {
-					c.ResponseError(err.Error())
+					c.ResponseErr(err)
 					return
 				}
 
@@ -415,13 +637,13 @@ func (c *ApiController) Login() {
 		}
 
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		} else {
 			var application *object.Application
 			application, err = object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
@@ -430,22 +652,13 @@ func (c *ApiController) Login() {
 				return
 			}
 
-			var organization *object.Organization
-			organization, err = object.GetOrganizationByUser(user)
+			err = c.applyLoginExperiment(application)
 			if err != nil {
-				c.ResponseError(err.Error())
-			}
-
-			if object.IsNeedPromptMfa(organization, user) {
-				// The prompt page needs the user to be signed in
-				c.SetSessionUsername(user.GetId())
-				c.ResponseOk(object.RequiredMfa)
+				c.ResponseErr(err)
 				return
 			}
 
-			if user.IsMfaEnabled() {
-				c.setMfaUserSession(user.GetId())
-				c.ResponseOk(object.NextMfa, user.GetPreferredMfaProps(true))
+			if !c.runAuthSteps(application, user, &authForm, 0) {
 				return
 			}
 
@@ -454,20 +667,20 @@ func (c *ApiController) Login() {
 			record := object.NewRecord(c.Ctx)
 			record.Organization = application.Organization
 			record.User = user.Name
-			util.SafeGoroutine(func() { object.AddRecord(record) })
+			object.AddRecord(record)
 		}
 	} else if authForm.Provider != "" {
 		var application *object.Application
 		if authForm.ClientId != "" {
 			application, err = object.GetApplicationByClientId(authForm.ClientId)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 		} else {
 			application, err = object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 		}
@@ -486,7 +699,7 @@ func (c *ApiController) Login() {
 		var provider *object.Provider
 		provider, err = object.GetProvider(util.GetId("admin", authForm.Provider))
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -500,7 +713,7 @@ func (c *ApiController) Login() {
 			// SAML
 			userInfo, err = object.ParseSamlResponse(authForm.SamlResponse, provider, c.Ctx.Request.Host)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 		} else if provider.Category == "OAuth" || provider.Category == "Web3" {
@@ -509,7 +722,7 @@ func (c *ApiController) Login() {
 			var idProvider idp.IdProvider
 			idProvider, err = idp.GetIdProvider(idpInfo, authForm.RedirectUri)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 			if idProvider == nil {
@@ -519,6 +732,11 @@ func (c *ApiController) Login() {
 
 			setHttpClient(idProvider, provider.Type)
 
+			if ethereumIdProvider, ok := idProvider.(*idp.EthereumIdProvider); ok {
+				ethereumIdProvider.Domain = object.GetFrontendHost(c.Ctx.Request.Host)
+				ethereumIdProvider.Nonce = c.takeWeb3Nonce()
+			}
+
 			if authForm.State != conf.GetConfigString("authState") && authForm.State != application.Name {
 				c.ResponseError(fmt.Sprintf(c.T("auth:State expected: %s, but got: %s"), conf.GetConfigString("authState"), authForm.State))
 				return
@@ -528,7 +746,7 @@ func (c *ApiController) Login() {
 			var token *oauth2.Token
 			token, err = idProvider.GetToken(authForm.Code)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
@@ -544,19 +762,64 @@ func (c *ApiController) Login() {
 			}
 		}
 
-		if authForm.Method == "signup" {
+		if application.EnableBrokerMode {
+			// Broker mode: the upstream identity is translated to the downstream protocol directly
+			var user *object.User
+			user, err = object.GetBrokerUser(application, organization, provider, userInfo)
+			if err != nil {
+				c.ResponseErr(err)
+				return
+			}
+
+			if user.IsForbidden {
+				c.ResponseError(c.T("check:The user is forbidden to sign in, please contact the administrator"))
+				return
+			}
+
+			resp = c.HandleLoggedIn(application, user, &authForm)
+
+			record := object.NewRecord(c.Ctx)
+			record.Organization = application.Organization
+			record.User = user.Name
+			object.AddRecord(record)
+		} else if authForm.Method == "signup" {
+			// the user is only routed by the email domain if the identity provider asserts the email is verified
+			organizationName := object.GetJitOrganization(provider, application, userInfo)
+			if userInfo.EmailVerified {
+				organizationName, err = object.GetEmailDomainOrganization(organizationName, userInfo.Email)
+			} else {
+				err = object.CheckEmailDomainClaim(organizationName, userInfo.Email, c.GetAcceptLanguage())
+			}
+			if err != nil {
+				c.ResponseErr(err)
+				return
+			}
+
+			if organizationName != application.Organization {
+				organization, err = object.GetOrganization(util.GetId("admin", organizationName))
+				if err != nil {
+					c.ResponseErr(err)
+					return
+				}
+			}
+
+			if organization == nil {
+				c.ResponseError(fmt.Sprintf(c.T("general:The organization: %s does not exist"), organizationName))
+				return
+			}
+
 			user := &object.User{}
 			if provider.Category == "SAML" {
 				// The userInfo.Id is the NameID in SAML response, it could be name / email / phone
-				user, err = object.GetUserByFields(application.Organization, userInfo.Id)
+				user, err = object.GetUserByFields(organization.Name, userInfo.Id)
 				if err != nil {
-					c.ResponseError(err.Error())
+					c.ResponseErr(err)
 					return
 				}
 			} else if provider.Category == "OAuth" || provider.Category == "Web3" {
-				user, err = object.GetUserByField(application.Organization, provider.Type, userInfo.Id)
+				user, err = object.GetUserByField(organization.Name, provider.Type, userInfo.Id)
 				if err != nil {
-					c.ResponseError(err.Error())
+					c.ResponseErr(err)
 					return
 				}
 			}
@@ -570,32 +833,32 @@ func (c *ApiController) Login() {
 				// sync info from 3rd-party if possible
 				_, err = object.SetUserOAuthProperties(organization, user, provider.Type, userInfo)
 				if err != nil {
-					c.ResponseError(err.Error())
+					c.ResponseErr(err)
 					return
 				}
 				resp = c.HandleLoggedIn(application, user, &authForm)
 
 				record := object.NewRecord(c.Ctx)
-				record.Organization = application.Organization
+				record.Organization = organization.Name
 				record.User = user.Name
-				util.SafeGoroutine(func() { object.AddRecord(record) })
-			} else if provider.Category == "OAuth" || provider.Category == "Web3" {
-				// Sign up via OAuth
+				object.AddRecord(record)
+			} else if provider.Category == "OAuth" || provider.Category == "Web3" || provider.Category == "SAML" {
+				// Sign up via OAuth or SAML
 				if application.EnableLinkWithEmail {
 					if userInfo.Email != "" {
 						// Find existing user with Email
-						user, err = object.GetUserByField(application.Organization, "email", userInfo.Email)
+						user, err = object.GetUserByField(organization.Name, "email", userInfo.Email)
 						if err != nil {
-							c.ResponseError(err.Error())
+							c.ResponseErr(err)
 							return
 						}
 					}
 
 					if user == nil && userInfo.Phone != "" {
 						// Find existing user with phone number
-						user, err = object.GetUserByField(application.Organization, "phone", userInfo.Phone)
+						user, err = object.GetUserByField(organization.Name, "phone", userInfo.Phone)
 						if err != nil {
-							c.ResponseError(err.Error())
+							c.ResponseErr(err)
 							return
 						}
 					}
@@ -612,118 +875,45 @@ func (c *ApiController) Login() {
 						return
 					}
 
-					// Handle username conflicts
-					var tmpUser *object.User
-					tmpUser, err = object.GetUser(util.GetId(application.Organization, userInfo.Username))
-					if err != nil {
-						c.ResponseError(err.Error())
+					if object.IsJitProvisioningBlocked(provider) {
+						c.ResponseError(fmt.Sprintf(c.T("auth:The account for provider: %s and username: %s (%s) does not exist, the provider only allows the pre-created accounts to sign in"), provider.Type, userInfo.Username, userInfo.DisplayName))
 						return
 					}
 
-					if tmpUser != nil {
-						var uid uuid.UUID
-						uid, err = uuid.NewRandom()
-						if err != nil {
-							c.ResponseError(err.Error())
-							return
-						}
-
-						uidStr := strings.Split(uid.String(), "-")
-						userInfo.Username = fmt.Sprintf("%s_%s", userInfo.Username, uidStr[1])
-					}
-
-					properties := map[string]string{}
-					var count int64
-					count, err = object.GetUserCount(application.Organization, "", "", "")
+					user, err = object.ProvisionJitUser(application, organization, provider, providerItem, userInfo, c.GetAcceptLanguage())
 					if err != nil {
-						c.ResponseError(err.Error())
+						c.ResponseErr(err)
 						return
 					}
-
-					properties["no"] = strconv.Itoa(int(count + 2))
-					var initScore int
-					initScore, err = organization.GetInitScore()
-					if err != nil {
-						c.ResponseError(fmt.Errorf(c.T("account:Get init score failed, error: %w"), err).Error())
-						return
-					}
-
-					userId := userInfo.Id
-					if userId == "" {
-						userId = util.GenerateId()
-					}
-
-					user = &object.User{
-						Owner:             application.Organization,
-						Name:              userInfo.Username,
-						CreatedTime:       util.GetCurrentTime(),
-						Id:                userId,
-						Type:              "normal-user",
-						DisplayName:       userInfo.DisplayName,
-						Avatar:            userInfo.AvatarUrl,
-						Address:           []string{},
-						Email:             userInfo.Email,
-						Phone:             userInfo.Phone,
-						CountryCode:       userInfo.CountryCode,
-						Region:            userInfo.CountryCode,
-						Score:             initScore,
-						IsAdmin:           false,
-						IsForbidden:       false,
-						IsDeleted:         false,
-						SignupApplication: application.Name,
-						Properties:        properties,
-					}
-
-					var affected bool
-					affected, err = object.AddUser(user)
-					if err != nil {
-						c.ResponseError(err.Error())
-						return
-					}
-
-					if !affected {
-						c.ResponseError(fmt.Sprintf(c.T("auth:Failed to create user, user information is invalid: %s"), util.StructToJson(user)))
-						return
-					}
-
-					if providerItem.SignupGroup != "" {
-						user.Groups = []string{providerItem.SignupGroup}
-						_, err = object.UpdateUser(user.GetId(), user, []string{"groups"}, false)
-						if err != nil {
-							c.ResponseError(err.Error())
-							return
-						}
-					}
 				}
 
 				// sync info from 3rd-party if possible
 				_, err = object.SetUserOAuthProperties(organization, user, provider.Type, userInfo)
 				if err != nil {
-					c.ResponseError(err.Error())
+					c.ResponseErr(err)
 					return
 				}
 
-				_, err = object.LinkUserAccount(user, provider.Type, userInfo.Id)
-				if err != nil {
-					c.ResponseError(err.Error())
-					return
+				if provider.Category != "SAML" {
+					_, err = object.LinkUserAccount(user, provider.Type, userInfo.Id)
+					if err != nil {
+						c.ResponseErr(err)
+						return
+					}
 				}
 
 				resp = c.HandleLoggedIn(application, user, &authForm)
 
 				record := object.NewRecord(c.Ctx)
-				record.Organization = application.Organization
+				record.Organization = organization.Name
 				record.User = user.Name
-				util.SafeGoroutine(func() { object.AddRecord(record) })
+				object.AddRecord(record)
 
 				record2 := object.NewRecord(c.Ctx)
 				record2.Action = "signup"
-				record2.Organization = application.Organization
+				record2.Organization = organization.Name
 				record2.User = user.Name
-				util.SafeGoroutine(func() { object.AddRecord(record2) })
-			} else if provider.Category == "SAML" {
-				// TODO: since we get the user info from SAML response, we can try to create the user
-				resp = &Response{Status: "error", Msg: fmt.Sprintf(c.T("general:The user: %s doesn't exist"), util.GetId(application.Organization, userInfo.Id))}
+				object.AddRecord(record2)
 			}
 			// resp = &Response{Status: "ok", Msg: "", Data: res}
 		} else { // authForm.Method != "signup"
@@ -736,7 +926,7 @@ func (c *ApiController) Login() {
 			var oldUser *object.User
 			oldUser, err = object.GetUserByField(application.Organization, provider.Type, userInfo.Id)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
@@ -748,21 +938,21 @@ func (c *ApiController) Login() {
 			var user *object.User
 			user, err = object.GetUser(userId)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
 			// sync info from 3rd-party if possible
 			_, err = object.SetUserOAuthProperties(organization, user, provider.Type, userInfo)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
 			var isLinked bool
 			isLinked, err = object.LinkUserAccount(user, provider.Type, userInfo.Id)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
@@ -776,7 +966,7 @@ func (c *ApiController) Login() {
 		var user *object.User
 		user, err = object.GetUser(c.getMfaUserSession())
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 		if user == nil {
@@ -785,7 +975,7 @@ func (c *ApiController) Login() {
 		}
 
 		if authForm.Passcode != "" {
-			mfaUtil := object.GetMfaUtil(authForm.MfaType, user.GetPreferredMfaProps(false))
+			mfaUtil := object.GetMfaUtil(authForm.MfaType, user.GetMfaProps(authForm.MfaType, false))
 			if mfaUtil == nil {
 				c.ResponseError("Invalid multi-factor authentication type")
 				return
@@ -793,13 +983,13 @@ func (c *ApiController) Login() {
 
 			err = mfaUtil.Verify(authForm.Passcode)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 		} else if authForm.RecoveryCode != "" {
 			err = object.MfaRecover(user, authForm.RecoveryCode)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 		} else {
@@ -810,7 +1000,7 @@ func (c *ApiController) Login() {
 		var application *object.Application
 		application, err = object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -819,20 +1009,30 @@ func (c *ApiController) Login() {
 			return
 		}
 
-		resp = c.HandleLoggedIn(application, user, &authForm)
 		c.setMfaUserSession("")
+		c.setMfaVerifiedSession(user.GetId())
+		if !c.runAuthSteps(application, user, &authForm, c.getAuthStepIndexAfterMfa(application, user)) {
+			return
+		}
+
+		resp = c.HandleLoggedIn(application, user, &authForm)
 
 		record := object.NewRecord(c.Ctx)
 		record.Organization = application.Organization
 		record.User = user.Name
-		util.SafeGoroutine(func() { object.AddRecord(record) })
+		object.AddRecord(record)
+	} else if userId, _ := c.getAuthStepSession(); userId != "" {
+		resp = c.handleAuthStepSession(&authForm)
+		if resp == nil {
+			return
+		}
 	} else {
 		if c.GetSessionUsername() != "" {
 			// user already signed in to Casdoor, so let the user click the avatar button to do the quick sign-in
 			var application *object.Application
 			application, err = object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
@@ -847,7 +1047,7 @@ func (c *ApiController) Login() {
 			record := object.NewRecord(c.Ctx)
 			record.Organization = application.Organization
 			record.User = user.Name
-			util.SafeGoroutine(func() { object.AddRecord(record) })
+			object.AddRecord(record)
 		} else {
 			c.ResponseError(fmt.Sprintf(c.T("auth:Unknown authentication type (not password or provider), form = %s"), util.StructToJson(authForm)))
 			return
@@ -863,7 +1063,7 @@ func (c *ApiController) GetSamlLogin() {
 	relayState := c.Input().Get("relayState")
 	authURL, method, err := object.GenerateSamlRequest(providerId, relayState, c.Ctx.Request.Host, c.GetAcceptLanguage())
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 	}
 	c.ResponseOk(authURL, method)
 }
@@ -873,7 +1073,7 @@ func (c *ApiController) HandleSamlLogin() {
 	samlResponse := c.Input().Get("SAMLResponse")
 	decode, err := base64.StdEncoding.DecodeString(relayState)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 	}
 	slice := strings.Split(string(decode), "&")
 	relayState = url.QueryEscape(relayState)
@@ -890,7 +1090,7 @@ func (c *ApiController) HandleSamlLogin() {
 func (c *ApiController) HandleOfficialAccountEvent() {
 	respBytes, err := ioutil.ReadAll(c.Ctx.Request.Body)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -901,7 +1101,7 @@ func (c *ApiController) HandleOfficialAccountEvent() {
 	}
 	err = xml.Unmarshal(respBytes, &data)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -935,14 +1135,17 @@ func (c *ApiController) GetWebhookEventType() {
 // @Tag Token API
 // @Description Get Login Error Counts
 // @Param   id     query    string  true        "The id ( owner/name ) of user"
+// @Param   application     query    string  false        "The name of the application whose bot detection scores the client"
+// @Param   botToken     query    string  false        "The signed fingerprint token of the bot detection"
 // @Success 200 {object} controllers.Response The Response object
 // @router /api/get-captcha-status [get]
 func (c *ApiController) GetCaptchaStatus() {
 	organization := c.Input().Get("organization")
 	userId := c.Input().Get("user_id")
+	applicationName := c.Input().Get("application")
 	user, err := object.GetUserByFields(organization, userId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -950,6 +1153,19 @@ func (c *ApiController) GetCaptchaStatus() {
 	if user != nil && user.SigninWrongTimes >= object.SigninWrongTimesLimit {
 		captchaEnabled = true
 	}
+
+	if !captchaEnabled && applicationName != "" {
+		application, err := object.GetApplication(util.GetId("admin", applicationName))
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		if botDetection := application.GetBotDetection(); botDetection != nil {
+			botScore := c.getBotScore(application, object.BotActionLogin, c.Input().Get("botToken"), true)
+			captchaEnabled = botDetection.IsCaptchaRequired(botScore)
+		}
+	}
 	c.ResponseOk(captchaEnabled)
 }
 
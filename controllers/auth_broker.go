@@ -0,0 +1,75 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/object"
+)
+
+// handleBrokerLoggedIn finishes the login of a transient user in the broker mode, the upstream identity
+// can only be translated to a downstream protocol since there is no local user to sign in.
+func (c *ApiController) handleBrokerLoggedIn(application *object.Application, user *object.User, form *form.AuthForm) (resp *Response) {
+	if form.Type == ResponseTypeCode {
+		clientId := c.Input().Get("clientId")
+		responseType := c.Input().Get("responseType")
+		redirectUri := c.Input().Get("redirectUri")
+		scope := c.Input().Get("scope")
+		state := c.Input().Get("state")
+		nonce := c.Input().Get("nonce")
+		challengeMethod := c.Input().Get("code_challenge_method")
+		codeChallenge := c.Input().Get("code_challenge")
+
+		if challengeMethod != "S256" && challengeMethod != "null" && challengeMethod != "" {
+			c.ResponseError(c.T("auth:Challenge method should be S256"))
+			return
+		}
+		code, err := object.GetOAuthCodeByUser(user, clientId, responseType, redirectUri, scope, state, nonce, codeChallenge, c.Ctx.Request.Host, c.GetAcceptLanguage())
+		if err != nil {
+			c.ResponseErr(err, nil)
+			return
+		}
+
+		resp = codeToResponse(code)
+	} else if form.Type == ResponseTypeToken || form.Type == ResponseTypeIdToken { // implicit flow
+		if !object.IsGrantTypeValid(form.Type, application.GrantTypes) {
+			resp = &Response{Status: "error", Msg: fmt.Sprintf("error: grant_type: %s is not supported in this application", form.Type), Data: ""}
+		} else if !application.IsResponseTypeAllowed(form.Type) {
+			resp = &Response{Status: "error", Msg: fmt.Sprintf("error: response_type: %s is not allowed in this application", form.Type), Data: ""}
+		} else {
+			scope := c.Input().Get("scope")
+			nonce := c.Input().Get("nonce")
+			token, err := object.GetTokenByUser(application, user, scope, nonce, c.Ctx.Request.Host)
+			if err != nil {
+				c.ResponseErr(err, nil)
+				return
+			}
+			resp = tokenToResponse(token)
+		}
+	} else if form.Type == ResponseTypeSaml { // saml flow
+		res, redirectUrl, method, err := object.GetSamlResponse(application, user, form.SamlRequest, c.Ctx.Request.Host)
+		if err != nil {
+			c.ResponseErr(err, nil)
+			return
+		}
+		resp = &Response{Status: "ok", Msg: "", Data: res, Data2: map[string]string{"redirectUrl": redirectUrl, "method": method}}
+	} else {
+		resp = &Response{Status: "error", Msg: fmt.Sprintf("error: the response type: %s is not supported in broker mode, please enable shadow users of the application", form.Type), Data: ""}
+	}
+
+	return resp
+}
@@ -0,0 +1,210 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/object"
+)
+
+// setAuthStepSession keeps the pending step of the login flow, the flow can only be resumed on the same application
+func (c *ApiController) setAuthStepSession(application *object.Application, userId string, index int) {
+	c.SetSession(object.AuthStepSessionApplication, application.GetId())
+	c.SetSession(object.AuthStepSessionUserId, userId)
+	c.SetSession(object.AuthStepSessionIndex, index)
+}
+
+func (c *ApiController) clearAuthStepSession() {
+	c.SetSession(object.AuthStepSessionApplication, "")
+	c.SetSession(object.AuthStepSessionUserId, "")
+	c.SetSession(object.AuthStepSessionIndex, 0)
+}
+
+func (c *ApiController) getAuthStepSessionApplication() string {
+	applicationId := c.GetSession(object.AuthStepSessionApplication)
+	if applicationId == nil {
+		return ""
+	}
+	return applicationId.(string)
+}
+
+func (c *ApiController) getAuthStepSession() (string, int) {
+	userId := c.GetSession(object.AuthStepSessionUserId)
+	index := c.GetSession(object.AuthStepSessionIndex)
+	if userId == nil || index == nil {
+		return "", 0
+	}
+	return userId.(string), index.(int)
+}
+
+// getAuthStepIndexAfterMfa returns the index of the post-auth step to continue with after the MFA is verified
+func (c *ApiController) getAuthStepIndexAfterMfa(application *object.Application, user *object.User) int {
+	userId, index := c.getAuthStepSession()
+	if userId == user.GetId() && c.getAuthStepSessionApplication() == application.GetId() {
+		return index
+	}
+
+	steps := application.GetPostAuthSteps()
+	for i, step := range steps {
+		if step.Name == object.AuthStepMfa {
+			return i + 1
+		}
+	}
+	return len(steps)
+}
+
+// handleIdentifierStep answers the identifier-first request that only has the username,
+// the response tells the next step without revealing whether the user exists.
+func (c *ApiController) handleIdentifierStep(application *object.Application, authForm *form.AuthForm) {
+	nextStep := application.GetNextAuthStep(object.AuthStepIdentifier)
+	if nextStep == nil {
+		nextStep = &object.AuthStep{Name: object.AuthStepPassword}
+	}
+
+	if !c.checkBotDetection(application, object.BotActionLogin, authForm.BotToken) {
+		return
+	}
+
+	if nextStep.Name == object.AuthStepCaptcha {
+		enableCaptcha, err := object.CheckToEnableCaptcha(application, authForm.Organization, authForm.Username, c.getCachedBotScore())
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		if !enableCaptcha {
+			nextStep = &object.AuthStep{Name: object.AuthStepPassword}
+		}
+	}
+
+	c.ResponseOk(object.NextAuthStep, nextStep)
+}
+
+// runAuthSteps runs the post-auth steps of the application from the given index for the authenticated user.
+// It returns false when a step still needs the user's input, and the response has been written then.
+func (c *ApiController) runAuthSteps(application *object.Application, user *object.User, authForm *form.AuthForm, start int) bool {
+	steps := application.GetPostAuthSteps()
+	for i := start; i < len(steps); i++ {
+		step := steps[i]
+		switch step.Name {
+		case object.AuthStepMfa:
+			organization, err := object.GetOrganizationByUser(user)
+			if err != nil {
+				c.ResponseErr(err)
+				return false
+			}
+
+			policyStatus, deadline := object.GetMfaPolicyStatus(organization, user)
+			if object.IsNeedPromptMfa(organization, user) || (step.Rule == "Required" && !user.IsMfaEnabled()) || policyStatus == object.MfaPolicyStatusRequired {
+				// The prompt page needs the user to be signed in
+				c.SetSessionUsername(user.GetId())
+				c.ResponseOk(object.RequiredMfa)
+				return false
+			}
+
+			if policyStatus == object.MfaPolicyStatusGrace {
+				// the user can set up MFA or skip it until the grace period ends,
+				// the login continues with the next step in both cases
+				c.SetSessionUsername(user.GetId())
+				c.setAuthStepSession(application, user.GetId(), i+1)
+				c.ResponseOk(object.PromptMfa, deadline.Format(time.RFC3339))
+				return false
+			}
+
+			if user.IsMfaEnabled() && !c.useMfaBypassSession(user) {
+				c.setAuthStepSession(application, user.GetId(), i+1)
+				c.setMfaUserSession(user.GetId())
+				c.ResponseOk(object.NextMfa, user.GetPreferredMfaProps(true))
+				return false
+			}
+		case object.AuthStepTerms:
+			if !authForm.TermsAccepted {
+				c.setAuthStepSession(application, user.GetId(), i)
+				c.ResponseOk(object.NextAuthStep, step)
+				return false
+			}
+		case object.AuthStepAttributes:
+			missingFields, err := object.SetUserAuthStepAttributes(user, step.Fields, authForm.Attributes)
+			if err != nil {
+				c.ResponseErr(err)
+				return false
+			}
+
+			if len(missingFields) > 0 {
+				c.setAuthStepSession(application, user.GetId(), i)
+				c.ResponseOk(object.NextAuthStep, &object.AuthStep{Name: step.Name, Rule: step.Rule, Fields: missingFields})
+				return false
+			}
+		}
+	}
+
+	c.clearAuthStepSession()
+	return true
+}
+
+// handleAuthStepSession continues the login flow of the authenticated user, who has submitted the input of the pending step
+func (c *ApiController) handleAuthStepSession(authForm *form.AuthForm) *Response {
+	userId, index := c.getAuthStepSession()
+	user, err := object.GetUser(userId)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil
+	}
+
+	if user == nil {
+		c.ResponseError("expired user session")
+		return nil
+	}
+
+	application, err := object.GetApplication(fmt.Sprintf("admin/%s", authForm.Application))
+	if err != nil {
+		c.ResponseErr(err)
+		return nil
+	}
+
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), authForm.Application))
+		return nil
+	}
+
+	// the pending steps of the application the flow started on can't be skipped by resuming on another application
+	if application.GetId() != c.getAuthStepSessionApplication() {
+		c.clearAuthStepSession()
+		c.ResponseError(c.T("auth:The login flow was started on another application, please sign in again"))
+		return nil
+	}
+
+	err = c.applyLoginExperiment(application)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil
+	}
+
+	if !c.runAuthSteps(application, user, authForm, index) {
+		return nil
+	}
+
+	resp := c.HandleLoggedIn(application, user, authForm)
+
+	record := object.NewRecord(c.Ctx)
+	record.Organization = application.Organization
+	record.User = user.Name
+	object.AddRecord(record)
+
+	return resp
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+func (c *ApiController) getBackupOptions() *object.BackupOptions {
+	options := &object.BackupOptions{
+		Password: c.Input().Get("password"),
+		Include:  object.ParseBackupTables(c.Input().Get("include")),
+		Exclude:  object.ParseBackupTables(c.Input().Get("exclude")),
+		Conflict: c.Input().Get("conflict"),
+	}
+
+	if table := c.Input().Get("cursorTable"); table != "" {
+		options.Cursor = &object.BackupCursor{Table: table, Offset: util.ParseInt(c.Input().Get("cursorOffset"))}
+	}
+	return options
+}
+
+func (c *ApiController) requireGlobalAdmin() bool {
+	isGlobalAdmin, _ := c.isGlobalAdmin()
+	if !isGlobalAdmin {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return false
+	}
+	return true
+}
+
+// ExportBackup
+// @Title ExportBackup
+// @Tag Backup API
+// @Description export the objects of all the organizations as an encrypted backup
+// @Param   password      formData   string  true    "The password to encrypt the backup"
+// @Param   include       formData   string  false   "The comma-separated tables to export, all of them if empty"
+// @Param   exclude       formData   string  false   "The comma-separated tables not to export"
+// @Param   cursorTable   formData   string  false   "The table to resume the export from"
+// @Param   cursorOffset  formData   string  false   "The offset in the table to resume the export from"
+// @Success 200 {string} string "The backup file"
+// @router /export-backup [post]
+func (c *ApiController) ExportBackup() {
+	if !c.requireGlobalAdmin() {
+		return
+	}
+
+	var buf bytes.Buffer
+	err := object.ExportBackup(&buf, c.getBackupOptions())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	filename := fmt.Sprintf("casdoor-backup-%s.bak", time.Now().Format("20060102150405"))
+	c.Ctx.Output.Header("Content-Type", "application/octet-stream")
+	c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	err = c.Ctx.Output.Body(buf.Bytes())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+}
+
+// ImportBackup
+// @Title ImportBackup
+// @Tag Backup API
+// @Description import the objects from an encrypted backup, the result has the cursor to resume a failed import from
+// @Param   file          formData   file    true    "The backup file"
+// @Param   password      formData   string  true    "The password of the backup"
+// @Param   conflict      formData   string  false   "How to import the existing objects: skip (default), overwrite or fail"
+// @Param   include       formData   string  false   "The comma-separated tables to import, all of them if empty"
+// @Param   exclude       formData   string  false   "The comma-separated tables not to import"
+// @Param   cursorTable   formData   string  false   "The table to resume the import from"
+// @Param   cursorOffset  formData   string  false   "The offset in the table to resume the import from"
+// @Success 200 {object} object.BackupImportResult The Response object
+// @router /import-backup [post]
+func (c *ApiController) ImportBackup() {
+	if !c.requireGlobalAdmin() {
+		return
+	}
+
+	file, _, err := c.GetFile("file")
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	defer file.Close()
+
+	result, err := object.ImportBackup(file, c.getBackupOptions())
+	if err != nil {
+		c.ResponseErr(err, result)
+		return
+	}
+
+	c.ResponseOk(result)
+}
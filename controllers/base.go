@@ -20,6 +20,7 @@ import (
 
 	"github.com/beego/beego"
 	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
 )
@@ -38,8 +39,15 @@ type RootController struct {
 
 type SessionData struct {
 	ExpireTime int64
+
+	// the session expires after being idle for the seconds, the last active time slides with the requests
+	IdleTimeout    int64
+	LastActiveTime int64
 }
 
+// the last active time of the session is written at most once a minute
+const sessionRenewalInterval = 60
+
 func (c *ApiController) IsGlobalAdmin() bool {
 	isGlobalAdmin, _ := c.isGlobalAdmin()
 
@@ -71,6 +79,23 @@ func (c *ApiController) IsAdminOrSelf(user2 *object.User) bool {
 	return false
 }
 
+// IsOrgAdminOrSelf checks whether the current user is the global admin, an admin of user2's organization or user2 itself
+func (c *ApiController) IsOrgAdminOrSelf(user2 *object.User) bool {
+	isGlobalAdmin, user := c.isGlobalAdmin()
+	if isGlobalAdmin {
+		return true
+	}
+
+	if user == nil || user2 == nil {
+		return false
+	}
+
+	if user.Owner == user2.Owner && (user.IsAdmin || user.Name == user2.Name) {
+		return true
+	}
+	return false
+}
+
 func (c *ApiController) isGlobalAdmin() (bool, *object.User) {
 	username := c.GetSessionUsername()
 	if strings.HasPrefix(username, "app/") {
@@ -95,7 +120,7 @@ func (c *ApiController) getCurrentUser() *object.User {
 	} else {
 		user, err = object.GetUser(userId)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return nil
 		}
 	}
@@ -105,11 +130,7 @@ func (c *ApiController) getCurrentUser() *object.User {
 // GetSessionUsername ...
 func (c *ApiController) GetSessionUsername() string {
 	// check if user session expired
-	sessionData := c.GetSessionData()
-
-	if sessionData != nil &&
-		sessionData.ExpireTime != 0 &&
-		sessionData.ExpireTime < time.Now().Unix() {
+	if !c.checkSessionData() {
 		c.ClearUserSession()
 		return ""
 	}
@@ -129,7 +150,7 @@ func (c *ApiController) GetSessionApplication() *object.Application {
 	}
 	application, err := object.GetApplicationByClientId(clientId.(string))
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return nil
 	}
 
@@ -139,13 +160,11 @@ func (c *ApiController) GetSessionApplication() *object.Application {
 func (c *ApiController) ClearUserSession() {
 	c.SetSessionUsername("")
 	c.SetSessionData(nil)
+	c.SetSession(activeOrganizationSession, "")
 }
 
 func (c *ApiController) GetSessionOidc() (string, string) {
-	sessionData := c.GetSessionData()
-	if sessionData != nil &&
-		sessionData.ExpireTime != 0 &&
-		sessionData.ExpireTime < time.Now().Unix() {
+	if !c.checkSessionData() {
 		c.ClearUserSession()
 		return "", ""
 	}
@@ -206,17 +225,65 @@ func (c *ApiController) getMfaUserSession() string {
 	return userId.(string)
 }
 
-func (c *ApiController) setExpireForSession() {
-	timestamp := time.Now().Unix()
-	timestamp += 3600 * 24
+func (c *ApiController) setExpireForSession(lifetime time.Duration) {
+	timestamp := time.Now().Add(lifetime).Unix()
 	c.SetSessionData(&SessionData{
 		ExpireTime: timestamp,
 	})
 }
 
+// checkSessionData returns false if the session has expired or been idle for too long,
+// otherwise the last active time of the session is renewed
+func (c *ApiController) checkSessionData() bool {
+	sessionData := c.GetSessionData()
+	if sessionData == nil {
+		return true
+	}
+
+	now := time.Now().Unix()
+	if sessionData.ExpireTime != 0 && sessionData.ExpireTime < now {
+		return false
+	}
+
+	if sessionData.IdleTimeout != 0 {
+		if sessionData.LastActiveTime+sessionData.IdleTimeout < now {
+			return false
+		}
+		if now-sessionData.LastActiveTime >= sessionRenewalInterval {
+			sessionData.LastActiveTime = now
+			c.SetSessionData(sessionData)
+		}
+	}
+
+	return true
+}
+
+// applySessionPolicy limits the lifetime and the idle time of the session signed in to the application
+func (c *ApiController) applySessionPolicy(policy *object.SessionPolicy) {
+	if policy == nil {
+		return
+	}
+
+	now := time.Now()
+	sessionData := c.GetSessionData()
+	if sessionData == nil {
+		sessionData = &SessionData{}
+	}
+
+	sessionData.ExpireTime = policy.GetExpireTime(sessionData.ExpireTime, now)
+	sessionData.IdleTimeout = int64(policy.GetIdleTimeout().Seconds())
+	sessionData.LastActiveTime = now.Unix()
+	c.SetSessionData(sessionData)
+}
+
+// getErrorCode returns the code of the error without the request, the messages are matched in English
+func getErrorCode(err error) string {
+	return object.GetErrorCode(conf.GetLanguage(""), err)
+}
+
 func wrapActionResponse(affected bool, e ...error) *Response {
 	if len(e) != 0 && e[0] != nil {
-		return &Response{Status: "error", Msg: e[0].Error()}
+		return &Response{Status: "error", Msg: e[0].Error(), Code: getErrorCode(e[0])}
 	} else if affected {
 		return &Response{Status: "ok", Msg: "", Data: "Affected"}
 	} else {
@@ -228,7 +295,7 @@ func wrapErrorResponse(err error) *Response {
 	if err == nil {
 		return &Response{Status: "ok", Msg: ""}
 	} else {
-		return &Response{Status: "error", Msg: err.Error()}
+		return &Response{Status: "error", Msg: err.Error(), Code: getErrorCode(err)}
 	}
 }
 
@@ -0,0 +1,102 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// the request data caching the bot score, so the attempt is scored once per request
+const botScoreData = "BotScore"
+
+// getBotScore scores the request for the bot detection of the application, 0 if it isn't enabled
+func (c *ApiController) getBotScore(application *object.Application, action string, token string, isPreview bool) int {
+	if score, ok := c.Ctx.Input.GetData(botScoreData).(int); ok {
+		return score
+	}
+
+	botScore := object.GetBotScore(application, &object.BotSignals{
+		Action:         action,
+		ClientIp:       util.GetClientIpFromRequest(c.Ctx.Request),
+		UserAgent:      c.Ctx.Request.UserAgent(),
+		AcceptLanguage: c.Ctx.Request.Header.Get("Accept-Language"),
+		Token:          token,
+		Time:           time.Now(),
+		IsPreview:      isPreview,
+	})
+	c.Ctx.Input.SetData(botScoreData, botScore.Score)
+	return botScore.Score
+}
+
+// getCachedBotScore returns the bot score of the request if it has been scored
+func (c *ApiController) getCachedBotScore() int {
+	score, _ := c.Ctx.Input.GetData(botScoreData).(int)
+	return score
+}
+
+// checkBotDetection blocks the attempt scoring the block score of the bot detection of the application,
+// the response is written if it is blocked
+func (c *ApiController) checkBotDetection(application *object.Application, action string, token string) bool {
+	botDetection := application.GetBotDetection()
+	if botDetection == nil {
+		return true
+	}
+
+	if botDetection.IsBlocked(c.getBotScore(application, action, token, false)) {
+		c.ResponseError(c.T("auth:The request is blocked as an automated one, please try again later"))
+		return false
+	}
+	return true
+}
+
+// GetBotToken
+// @Title GetBotToken
+// @Tag Login API
+// @Description get the signed fingerprint token of the login and signup pages for the bot detection of the application
+// @Param   application     query    string  true        "The name of the application"
+// @Param   fingerprint     query    string  true        "The fingerprint of the device"
+// @Success 200 {string} string The Response object
+// @router /get-bot-token [get]
+func (c *ApiController) GetBotToken() {
+	applicationName := c.Input().Get("application")
+	fingerprint := c.Input().Get("fingerprint")
+
+	application, err := object.GetApplication(util.GetId("admin", applicationName))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), applicationName))
+		return
+	}
+
+	if application.GetBotDetection() == nil {
+		c.ResponseOk("")
+		return
+	}
+
+	token, err := object.IssueBotToken(application, fingerprint, util.GetClientIpFromRequest(c.Ctx.Request), time.Now())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(token)
+}
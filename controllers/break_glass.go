@@ -0,0 +1,160 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// the session of the break-glass account is kept short since it bypasses the MFA
+const breakGlassSessionLifetime = time.Hour
+
+// BreakGlassLogin
+// @Title BreakGlassLogin
+// @Tag Login API
+// @Description sign in the break-glass account by its single-use key without the MFA, captcha or identity providers, the key is disabled after the use and the admins of the organization are alerted
+// @Param   body    body   form.BreakGlassForm  true        "The application, the username and the key of the break-glass account"
+// @Success 200 {object} controllers.Response The Response object
+// @router /break-glass-login [post]
+func (c *ApiController) BreakGlassLogin() {
+	var breakGlassForm form.BreakGlassForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &breakGlassForm)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	application, err := object.GetApplication(util.GetId("admin", breakGlassForm.Application))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), breakGlassForm.Application))
+		return
+	}
+
+	user, err := object.UseBreakGlassKey(application.Organization, breakGlassForm.Username, breakGlassForm.Key, util.GetClientIpFromRequest(c.Ctx.Request), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	userId := user.GetId()
+	c.SetSessionUsername(userId)
+	c.setExpireForSession(breakGlassSessionLifetime)
+
+	session := &object.Session{
+		Owner:       user.Owner,
+		Name:        user.Name,
+		Application: application.Name,
+		SessionId:   []string{c.Ctx.Input.CruSession.SessionID()},
+		Devices:     c.getSessionDevices(),
+	}
+	_, err = object.AddSession(session)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	util.LogInfo(c.Ctx, "API: [%s] signed in by the break-glass key", userId)
+	c.ResponseOk(userId)
+}
+
+// IssueBreakGlassKey
+// @Title IssueBreakGlassKey
+// @Tag User API
+// @Description issue a new single-use key of the break-glass account and revoke its active ones, the key is returned only once
+// @Param   id     query    string  true        "The id ( owner/name ) of the break-glass account"
+// @Success 200 {string} string The Response object
+// @router /issue-break-glass-key [post]
+func (c *ApiController) IssueBreakGlassKey() {
+	id := c.Input().Get("id")
+
+	user, err := object.GetUser(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), id))
+		return
+	}
+
+	// the break-glass account can't re-arm itself after its key is used
+	if c.GetSessionUsername() == user.GetId() {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	key, err := object.IssueBreakGlassKey(user, c.GetSessionUsername(), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(key)
+}
+
+// GetBreakGlassKeys
+// @Title GetBreakGlassKeys
+// @Tag User API
+// @Description get the keys of the break-glass accounts of the organization with their uses
+// @Param   owner     query    string  true        "The organization of the break-glass accounts"
+// @Param   user     query    string  false        "The name of the break-glass account, all the accounts if empty"
+// @Success 200 {array} object.BreakGlassKey The Response object
+// @router /get-break-glass-keys [get]
+func (c *ApiController) GetBreakGlassKeys() {
+	owner := c.Input().Get("owner")
+	user := c.Input().Get("user")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" {
+		limit = "100"
+	}
+	if page == "" {
+		page = "1"
+	}
+	if sortField == "" {
+		sortField, sortOrder = "created_time", "descend"
+	}
+
+	count, err := object.GetBreakGlassKeyCount(owner, user, field, value)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	paginator := pagination.SetPaginator(c.Ctx, util.ParseInt(limit), count)
+	breakGlassKeys, err := object.GetPaginationBreakGlassKeys(owner, user, paginator.Offset(), util.ParseInt(limit), field, value, sortField, sortOrder)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(breakGlassKeys, paginator.Nums())
+}
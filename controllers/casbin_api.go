@@ -16,7 +16,9 @@ package controllers
 
 import (
 	"encoding/json"
+	"strings"
 
+	"github.com/casbin/casbin/v2"
 	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
 )
@@ -45,31 +47,38 @@ func (c *ApiController) Enforce() {
 	var request object.CasbinRequest
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &request)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	if enforcerId != "" {
-		enforcer, err := object.GetInitializedEnforcer(enforcerId)
-		if err != nil {
-			c.ResponseError(err.Error())
+		enforcer, ok := c.getServingEnforcer(enforcerId)
+		if !ok {
 			return
 		}
 
 		res, err := enforcer.Enforce(request...)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
+		recordEnforceUsage(enforcerId, 1)
+
 		c.ResponseOk(res)
 		return
 	}
 
+	application, err := c.getEnforceApplication()
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
 	if permissionId != "" {
 		permission, err := object.GetPermission(permissionId)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -78,12 +87,14 @@ func (c *ApiController) Enforce() {
 		if permission == nil {
 			res = append(res, false)
 		} else {
-			enforceResult, err := object.Enforce(permission, &request)
+			enforceResult, err := object.Enforce(permission, application, &request)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
+			object.RecordEnforceUsage(permission.Owner, 1)
+
 			res = append(res, enforceResult)
 		}
 
@@ -96,13 +107,13 @@ func (c *ApiController) Enforce() {
 		owner, modelName := util.GetOwnerAndNameFromId(modelId)
 		permissions, err = object.GetPermissionsByModel(owner, modelName)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	} else if resourceId != "" {
 		permissions, err = object.GetPermissionsByResource(resourceId)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	} else {
@@ -110,19 +121,23 @@ func (c *ApiController) Enforce() {
 		return
 	}
 
+	if len(permissions) != 0 {
+		object.RecordEnforceUsage(permissions[0].Owner, 1)
+	}
+
 	res := []bool{}
 
 	listPermissionIdMap := object.GroupPermissionsByModelAdapter(permissions)
 	for _, permissionIds := range listPermissionIdMap {
 		firstPermission, err := object.GetPermission(permissionIds[0])
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
-		enforceResult, err := object.Enforce(firstPermission, &request, permissionIds...)
+		enforceResult, err := object.Enforce(firstPermission, application, &request, permissionIds...)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -135,7 +150,7 @@ func (c *ApiController) Enforce() {
 // BatchEnforce
 // @Title BatchEnforce
 // @Tag Enforce API
-// @Description Call Casbin BatchEnforce API
+// @Description Call Casbin BatchEnforce API, the data2 of the response has the error and the duration of each request
 // @Param   body    body   object.CasbinRequest  true   "array of casbin requests"
 // @Param   permissionId    query   string  false   "permission id"
 // @Param   modelId    query   string  false   "model id"
@@ -149,35 +164,39 @@ func (c *ApiController) BatchEnforce() {
 	var requests []object.CasbinRequest
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &requests)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	if enforcerId != "" {
-		enforcer, err := object.GetInitializedEnforcer(enforcerId)
-		if err != nil {
-			c.ResponseError(err.Error())
+		enforcer, ok := c.getServingEnforcer(enforcerId)
+		if !ok {
 			return
 		}
 
-		res, err := enforcer.BatchEnforce(requests)
-		if err != nil {
-			c.ResponseError(err.Error())
-			return
-		}
+		results := object.BatchEnforceRequests(enforcer, nil, requests)
 
-		c.ResponseOk(res)
+		recordEnforceUsage(enforcerId, len(requests))
+
+		c.ResponseOk(object.GetBatchEnforceDecisions(results), results)
+		return
+	}
+
+	application, err := c.getEnforceApplication()
+	if err != nil {
+		c.ResponseErr(err)
 		return
 	}
 
 	if permissionId != "" {
 		permission, err := object.GetPermission(permissionId)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		res := [][]bool{}
+		results := [][]*object.BatchEnforceResult{}
 
 		if permission == nil {
 			l := len(requests)
@@ -188,16 +207,19 @@ func (c *ApiController) BatchEnforce() {
 
 			res = append(res, resRequest)
 		} else {
-			enforceResult, err := object.BatchEnforce(permission, &requests)
+			enforceResults, err := object.BatchEnforce(permission, application, &requests)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
-			res = append(res, enforceResult)
+			object.RecordEnforceUsage(permission.Owner, len(requests))
+
+			res = append(res, object.GetBatchEnforceDecisions(enforceResults))
+			results = append(results, enforceResults)
 		}
 
-		c.ResponseOk(res)
+		c.ResponseOk(res, results)
 		return
 	}
 
@@ -206,7 +228,7 @@ func (c *ApiController) BatchEnforce() {
 		owner, modelName := util.GetOwnerAndNameFromId(modelId)
 		permissions, err = object.GetPermissionsByModel(owner, modelName)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	} else {
@@ -214,26 +236,65 @@ func (c *ApiController) BatchEnforce() {
 		return
 	}
 
+	if len(permissions) != 0 {
+		object.RecordEnforceUsage(permissions[0].Owner, len(requests))
+	}
+
 	res := [][]bool{}
+	results := [][]*object.BatchEnforceResult{}
 
 	listPermissionIdMap := object.GroupPermissionsByModelAdapter(permissions)
 	for _, permissionIds := range listPermissionIdMap {
 		firstPermission, err := object.GetPermission(permissionIds[0])
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
-		enforceResult, err := object.BatchEnforce(firstPermission, &requests, permissionIds...)
+		enforceResults, err := object.BatchEnforce(firstPermission, application, &requests, permissionIds...)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
-		res = append(res, enforceResult)
+		res = append(res, object.GetBatchEnforceDecisions(enforceResults))
+		results = append(results, enforceResults)
 	}
 
-	c.ResponseOk(res)
+	c.ResponseOk(res, results)
+}
+
+// getServingEnforcer returns the enforcer deciding the enforce calls, the decisions served from the local policy snapshot
+// while the database is unreachable are marked by the response headers
+func (c *ApiController) getServingEnforcer(enforcerId string) (*casbin.Enforcer, bool) {
+	enforcer, snapshotTime, err := object.GetServingEnforcer(enforcerId)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+
+	if snapshotTime != "" {
+		c.Ctx.Output.Header("X-Casdoor-Policy-Source", "snapshot")
+		c.Ctx.Output.Header("X-Casdoor-Policy-Snapshot-Time", snapshotTime)
+	}
+	return enforcer, true
+}
+
+// recordEnforceUsage meters the enforce calls to the organization owning the enforcer
+func recordEnforceUsage(enforcerId string, count int) {
+	owner, _ := util.GetOwnerAndNameFromIdNoCheck(enforcerId)
+	object.RecordEnforceUsage(owner, count)
+}
+
+// getEnforceApplication returns the application calling the enforce API with its client ID and secret,
+// whose external PDP is used for the permissions without one
+func (c *ApiController) getEnforceApplication() (*object.Application, error) {
+	username := c.GetSessionUsername()
+	if !strings.HasPrefix(username, "app/") {
+		return nil, nil
+	}
+
+	return object.GetApplication(util.GetId("admin", strings.TrimPrefix(username, "app/")))
 }
 
 func (c *ApiController) GetAllObjects() {
@@ -245,7 +306,7 @@ func (c *ApiController) GetAllObjects() {
 
 	objects, err := object.GetAllObjects(userId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -261,7 +322,7 @@ func (c *ApiController) GetAllActions() {
 
 	actions, err := object.GetAllActions(userId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -277,7 +338,7 @@ func (c *ApiController) GetAllRoles() {
 
 	roles, err := object.GetAllRoles(userId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
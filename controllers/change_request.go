@@ -0,0 +1,230 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// submitChangeRequest holds the change of the object for approval if its organization requires it,
+// it returns true if the change is held and responded
+func (c *ApiController) submitChangeRequest(changeType string, id string, obj interface{}) bool {
+	changeRequest, err := object.SubmitChangeRequest(changeType, id, obj, c.GetSessionUsername(), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return true
+	}
+	if changeRequest == nil {
+		return false
+	}
+
+	c.ResponseOk(object.ChangeRequestStatePending, changeRequest.GetId())
+	return true
+}
+
+// submitObjectChangeRequest holds the addition or deletion of the object for approval if its organization requires it,
+// it returns true if the change is held and responded
+func (c *ApiController) submitObjectChangeRequest(changeType string, action string, obj interface{}) bool {
+	changeRequest, err := object.SubmitObjectChangeRequest(changeType, action, obj, c.GetSessionUsername(), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return true
+	}
+	if changeRequest == nil {
+		return false
+	}
+
+	c.ResponseOk(object.ChangeRequestStatePending, changeRequest.GetId())
+	return true
+}
+
+// GetChangeRequests
+// @Title GetChangeRequests
+// @Tag Change Request API
+// @Description get the pending changes waiting for the current user's approval ( type = approval ), or all the changes of the organization for admins
+// @Param   owner     query    string  true        "The owner of change requests"
+// @Param   type      query    string  false       "approval or empty"
+// @Success 200 {array} object.ChangeRequest The Response object
+// @router /get-change-requests [get]
+func (c *ApiController) GetChangeRequests() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	if c.Input().Get("type") == "approval" {
+		changeRequests, err := object.GetPendingChangeRequestsForApprover(user)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(changeRequests)
+		return
+	}
+
+	owner := c.Input().Get("owner")
+	if !c.IsGlobalAdmin() && (!user.IsAdmin || user.Owner != owner) {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		changeRequests, err := object.GetChangeRequests(owner)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(changeRequests)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetChangeRequestCount(owner, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		changeRequests, err := object.GetPaginationChangeRequests(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(changeRequests, paginator.Nums())
+	}
+}
+
+func (c *ApiController) getChangeRequestFromContext(user *object.User) (*object.ChangeRequest, bool) {
+	id := c.Input().Get("id")
+	changeRequest, err := object.GetChangeRequest(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+	if changeRequest == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The request: %s does not exist"), id))
+		return nil, false
+	}
+
+	isRequester := user.GetId() == changeRequest.User
+	isAdmin := user.IsGlobalAdmin() || (user.IsAdmin && user.Owner == changeRequest.Owner)
+	if !isRequester && !isAdmin {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return nil, false
+	}
+
+	return changeRequest, true
+}
+
+// GetChangeRequest
+// @Title GetChangeRequest
+// @Tag Change Request API
+// @Description get a change request, only for the requester and the admins of the organization
+// @Param   id     query    string  true        "The id ( owner/name ) of the change request"
+// @Success 200 {object} object.ChangeRequest The Response object
+// @router /get-change-request [get]
+func (c *ApiController) GetChangeRequest() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	changeRequest, ok := c.getChangeRequestFromContext(user)
+	if !ok {
+		return
+	}
+
+	c.ResponseOk(changeRequest)
+}
+
+func (c *ApiController) reviewChangeRequest(isApproved bool) {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	changeRequest, ok := c.getChangeRequestFromContext(user)
+	if !ok {
+		return
+	}
+
+	comment := c.Input().Get("comment")
+	c.Data["json"] = wrapActionResponse(object.ReviewChangeRequest(changeRequest, user, isApproved, comment, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// ApproveChangeRequest
+// @Title ApproveChangeRequest
+// @Tag Change Request API
+// @Description approve a change request by another admin than the requester, the change is applied to the object
+// @Param   id          query    string  true        "The id ( owner/name ) of the change request"
+// @Param   comment     query    string  false       "The comment of the approver"
+// @Success 200 {object} controllers.Response The Response object
+// @router /approve-change-request [post]
+func (c *ApiController) ApproveChangeRequest() {
+	c.reviewChangeRequest(true)
+}
+
+// RejectChangeRequest
+// @Title RejectChangeRequest
+// @Tag Change Request API
+// @Description reject a change request
+// @Param   id          query    string  true        "The id ( owner/name ) of the change request"
+// @Param   comment     query    string  false       "The comment of the approver"
+// @Success 200 {object} controllers.Response The Response object
+// @router /reject-change-request [post]
+func (c *ApiController) RejectChangeRequest() {
+	c.reviewChangeRequest(false)
+}
+
+// CancelChangeRequest
+// @Title CancelChangeRequest
+// @Tag Change Request API
+// @Description cancel a pending change request, only for the requester
+// @Param   id     query    string  true        "The id ( owner/name ) of the change request"
+// @Success 200 {object} controllers.Response The Response object
+// @router /cancel-change-request [post]
+func (c *ApiController) CancelChangeRequest() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	changeRequest, ok := c.getChangeRequestFromContext(user)
+	if !ok {
+		return
+	}
+
+	if user.GetId() != changeRequest.User {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.CancelChangeRequest(changeRequest, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
@@ -0,0 +1,49 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"time"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+const mfaVerifiedSessionUserId = "MfaVerifiedSessionUserId"
+
+func (c *ApiController) getConditionalAccessContext(application *object.Application, user *object.User) *object.ConditionalAccessContext {
+	return &object.ConditionalAccessContext{
+		Application:     application,
+		User:            user,
+		ClientIp:        util.GetClientIpFromRequest(c.Ctx.Request),
+		IsDeviceTrusted: object.IsDeviceTrusted(c.Ctx.Request),
+		BotScore:        c.getCachedBotScore(),
+		Time:            time.Now(),
+	}
+}
+
+// setMfaVerifiedSession remembers the user who has passed MFA in the current login flow,
+// so that the conditional access policies requiring MFA are satisfied by the later steps of the flow
+func (c *ApiController) setMfaVerifiedSession(userId string) {
+	c.SetSession(mfaVerifiedSessionUserId, userId)
+}
+
+func (c *ApiController) getMfaVerifiedSession() string {
+	userId := c.Ctx.Input.CruSession.Get(mfaVerifiedSessionUserId)
+	if userId == nil {
+		return ""
+	}
+	return userId.(string)
+}
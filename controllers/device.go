@@ -0,0 +1,220 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetDevices
+// @Title GetDevices
+// @Tag Device API
+// @Description get devices
+// @Param   owner     query    string  true        "The owner of devices"
+// @Success 200 {array} object.Device The Response object
+// @router /get-devices [get]
+func (c *ApiController) GetDevices() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		devices, err := object.GetDevices(owner)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(devices)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetDeviceCount(owner, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		devices, err := object.GetPaginationDevices(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(devices, paginator.Nums())
+	}
+}
+
+// GetDevice
+// @Title GetDevice
+// @Tag Device API
+// @Description get device
+// @Param   id     query    string  true        "The id ( owner/name ) of the device"
+// @Success 200 {object} object.Device The Response object
+// @router /get-device [get]
+func (c *ApiController) GetDevice() {
+	id := c.Input().Get("id")
+
+	device, err := object.GetDevice(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(device)
+}
+
+// UpdateDevice
+// @Title UpdateDevice
+// @Tag Device API
+// @Description update device, the enrollment secret and the certificate are only changed by the enrollment
+// @Param   id     query    string  true        "The id ( owner/name ) of the device"
+// @Param   body    body   object.Device  true        "The details of the device"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-device [post]
+func (c *ApiController) UpdateDevice() {
+	id := c.Input().Get("id")
+
+	var device object.Device
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &device)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateDevice(id, &device, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// AddDevice
+// @Title AddDevice
+// @Tag Device API
+// @Description add device, the client id and the enrollment secret are generated
+// @Param   body    body   object.Device  true        "The details of the device"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-device [post]
+func (c *ApiController) AddDevice() {
+	var device object.Device
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &device)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddDevice(&device, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// DeleteDevice
+// @Title DeleteDevice
+// @Tag Device API
+// @Description delete device
+// @Param   body    body   object.Device  true        "The details of the device"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-device [post]
+func (c *ApiController) DeleteDevice() {
+	var device object.Device
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &device)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteDevice(&device))
+	c.ServeJSON()
+}
+
+// ResetDeviceEnrollment
+// @Title ResetDeviceEnrollment
+// @Tag Device API
+// @Description generate a new enrollment secret for the device and revoke its certificate, the device has to enroll again
+// @Param   body    body   object.Device  true        "The owner and name of the device"
+// @Success 200 {object} object.Device The Response object
+// @router /reset-device-enrollment [post]
+func (c *ApiController) ResetDeviceEnrollment() {
+	var device object.Device
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &device)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	res, err := object.ResetDeviceEnrollment(device.GetId())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if res == nil {
+		c.ResponseError(fmt.Sprintf(c.T("service:The device: %s does not exist"), device.GetId()))
+		return
+	}
+
+	c.ResponseOk(res)
+}
+
+func (c *ApiController) responseEstCertificate(certificate string, err error) {
+	if err != nil {
+		c.Ctx.Output.SetStatus(http.StatusUnauthorized)
+		c.Ctx.Output.Header("WWW-Authenticate", "Basic realm=\"EST\"")
+		c.Ctx.WriteString(err.Error())
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "application/pem-certificate-chain")
+	c.Ctx.WriteString(certificate)
+}
+
+// EstSimpleEnroll
+// @Title EstSimpleEnroll
+// @Tag Device API
+// @Description enroll the client certificate of a device like EST simpleenroll (RFC 7030), authenticated by the HTTP basic auth of the device id ( owner/name ) and its one-time enrollment secret, the body is the PKCS#10 request in PEM or base64-encoded DER, and the response is the PEM certificate
+// @Success 200 {string} string The PEM certificate
+// @router /.well-known/est/simpleenroll [post]
+func (c *ApiController) EstSimpleEnroll() {
+	id, enrollmentSecret, ok := c.Ctx.Request.BasicAuth()
+	if !ok {
+		c.responseEstCertificate("", fmt.Errorf(c.T("service:The device id or the enrollment secret is invalid")))
+		return
+	}
+
+	c.responseEstCertificate(object.EnrollDevice(id, enrollmentSecret, string(c.Ctx.Input.RequestBody), c.GetAcceptLanguage()))
+}
+
+// EstSimpleReenroll
+// @Title EstSimpleReenroll
+// @Tag Device API
+// @Description renew the client certificate of a device like EST simplereenroll (RFC 7030), authenticated by the current client certificate of the mutual TLS
+// @Success 200 {string} string The PEM certificate
+// @router /.well-known/est/simplereenroll [post]
+func (c *ApiController) EstSimpleReenroll() {
+	certThumbprint, err := object.GetClientCertificateThumbprint(c.Ctx.Request)
+	if err != nil {
+		c.responseEstCertificate("", err)
+		return
+	}
+
+	c.responseEstCertificate(object.ReenrollDevice(certThumbprint, string(c.Ctx.Input.RequestBody), c.GetAcceptLanguage()))
+}
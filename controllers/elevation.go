@@ -0,0 +1,74 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/object"
+)
+
+// requireElevation checks the elevation token of the request for the destructive admin operation,
+// the response is written if it fails
+func (c *ApiController) requireElevation() bool {
+	err := object.CheckElevation(c.GetSessionUsername(), c.Ctx.Request.Header.Get(object.ElevationTokenHeader), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return false
+	}
+
+	return true
+}
+
+// Elevate
+// @Title Elevate
+// @Tag Account API
+// @Description verify the MFA of the signed-in user again for the token of the destructive admin operations, the token is sent in the X-Elevation-Token header
+// @Param   body    body   form.ElevationForm  true        "The MFA type and the passcode, or the recovery code"
+// @Success 200 {object} object.Elevation The Response object
+// @router /elevate [post]
+func (c *ApiController) Elevate() {
+	userId, ok := c.RequireSignedIn()
+	if !ok {
+		return
+	}
+
+	var elevationForm form.ElevationForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &elevationForm)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	user, err := object.GetUser(userId)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), userId))
+		return
+	}
+
+	elevation, err := object.Elevate(user, &elevationForm, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(elevation)
+}
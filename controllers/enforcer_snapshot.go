@@ -0,0 +1,199 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/casdoor/casdoor/object"
+)
+
+// getEnforcerForAdmin returns the enforcer of the id if the current user is the global admin or an admin of its owner
+func (c *ApiController) getEnforcerForAdmin(id string) (*object.Enforcer, bool) {
+	owner, ok := c.RequireAdmin()
+	if !ok {
+		return nil, false
+	}
+
+	enforcer, err := object.GetEnforcer(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+	if enforcer == nil || (owner != "" && enforcer.Owner != owner) {
+		c.ResponseError(fmt.Sprintf(c.T("general:The enforcer: %s does not exist"), id))
+		return nil, false
+	}
+
+	return enforcer, true
+}
+
+// GetEnforcerSnapshots
+// @Title GetEnforcerSnapshots
+// @Tag Enforcer Snapshot API
+// @Description get the snapshots of an enforcer
+// @Param   owner     query    string  true        "The owner of enforcer snapshots"
+// @Param   enforcer     query    string  true        "The id ( owner/name ) of the enforcer"
+// @Success 200 {array} object.EnforcerSnapshot The Response object
+// @router /get-enforcer-snapshots [get]
+func (c *ApiController) GetEnforcerSnapshots() {
+	owner := c.Input().Get("owner")
+	enforcer := c.Input().Get("enforcer")
+
+	snapshots, err := object.GetEnforcerSnapshots(owner, enforcer)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(snapshots)
+}
+
+// GetEnforcerSnapshot
+// @Title GetEnforcerSnapshot
+// @Tag Enforcer Snapshot API
+// @Description get the enforcer snapshot with its policies
+// @Param   id     query    string  true        "The id ( owner/name ) of the enforcer snapshot"
+// @Success 200 {object} object.EnforcerSnapshot The Response object
+// @router /get-enforcer-snapshot [get]
+func (c *ApiController) GetEnforcerSnapshot() {
+	id := c.Input().Get("id")
+
+	snapshot, err := object.GetEnforcerSnapshot(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if snapshot == nil {
+		c.ResponseOk(nil)
+		return
+	}
+
+	policies, err := snapshot.GetPolicies()
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(snapshot, policies)
+}
+
+// AddEnforcerSnapshot
+// @Title AddEnforcerSnapshot
+// @Tag Enforcer Snapshot API
+// @Description snapshot the current policies of an enforcer
+// @Param   body    body   object.EnforcerSnapshot  true        "The owner, enforcer and description of the snapshot"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-enforcer-snapshot [post]
+func (c *ApiController) AddEnforcerSnapshot() {
+	var snapshot object.EnforcerSnapshot
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &snapshot)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	enforcer, ok := c.getEnforcerForAdmin(snapshot.Enforcer)
+	if !ok {
+		return
+	}
+	if enforcer.Owner != snapshot.Owner {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddEnforcerSnapshot(&snapshot))
+	c.ServeJSON()
+}
+
+// DeleteEnforcerSnapshot
+// @Title DeleteEnforcerSnapshot
+// @Tag Enforcer Snapshot API
+// @Description delete enforcer snapshot
+// @Param   body    body   object.EnforcerSnapshot  true        "The details of the enforcer snapshot"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-enforcer-snapshot [post]
+func (c *ApiController) DeleteEnforcerSnapshot() {
+	var snapshot object.EnforcerSnapshot
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &snapshot)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteEnforcerSnapshot(&snapshot))
+	c.ServeJSON()
+}
+
+// DiffEnforcerSnapshots
+// @Title DiffEnforcerSnapshots
+// @Tag Enforcer Snapshot API
+// @Description get the policies added and removed between two enforcer snapshots
+// @Param   id     query    string  true        "The id ( owner/name ) of the old enforcer snapshot"
+// @Param   newId     query    string  false        "The id ( owner/name ) of the new enforcer snapshot, empty means the current policies"
+// @Success 200 {object} object.PolicyDiff The Response object
+// @router /diff-enforcer-snapshots [get]
+func (c *ApiController) DiffEnforcerSnapshots() {
+	id := c.Input().Get("id")
+	newId := c.Input().Get("newId")
+
+	diff, err := object.DiffEnforcerSnapshots(id, newId)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(diff)
+}
+
+// RollbackEnforcerSnapshot
+// @Title RollbackEnforcerSnapshot
+// @Tag Enforcer Snapshot API
+// @Description replace the policies of the enforcer with the ones in the snapshot
+// @Param   body    body   object.EnforcerSnapshot  true        "The owner and name of the enforcer snapshot"
+// @Success 200 {object} controllers.Response The Response object
+// @router /rollback-enforcer-snapshot [post]
+func (c *ApiController) RollbackEnforcerSnapshot() {
+	var snapshot object.EnforcerSnapshot
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &snapshot)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	storedSnapshot, err := object.GetEnforcerSnapshot(snapshot.GetId())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if storedSnapshot == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The enforcer snapshot: %s does not exist"), snapshot.GetId()))
+		return
+	}
+
+	enforcer, ok := c.getEnforcerForAdmin(storedSnapshot.Enforcer)
+	if !ok {
+		return
+	}
+	if enforcer.Owner != storedSnapshot.Owner {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.RollbackEnforcerSnapshot(storedSnapshot.GetId()))
+	c.ServeJSON()
+}
@@ -0,0 +1,162 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetExportJobs
+// @Title GetExportJobs
+// @Tag Export Job API
+// @Description get the export jobs of the organization
+// @Param   owner     query    string  true        "The owner of export jobs"
+// @Success 200 {array} object.ExportJob The Response object
+// @router /get-export-jobs [get]
+func (c *ApiController) GetExportJobs() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" {
+		limit = "100"
+	}
+	if page == "" {
+		page = "1"
+	}
+	if sortField == "" {
+		sortField, sortOrder = "created_time", "descend"
+	}
+
+	count, err := object.GetExportJobCount(owner, field, value)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	paginator := pagination.SetPaginator(c.Ctx, util.ParseInt(limit), count)
+	exportJobs, err := object.GetPaginationExportJobs(owner, paginator.Offset(), util.ParseInt(limit), field, value, sortField, sortOrder)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(exportJobs, paginator.Nums())
+}
+
+// GetExportJob
+// @Title GetExportJob
+// @Tag Export Job API
+// @Description get the export job with its progress, the URL of the exported file is set once it is completed
+// @Param   id     query    string  true        "The id ( owner/name ) of the export job"
+// @Success 200 {object} object.ExportJob The Response object
+// @router /get-export-job [get]
+func (c *ApiController) GetExportJob() {
+	id := c.Input().Get("id")
+
+	exportJob, err := object.GetExportJob(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(exportJob)
+}
+
+// AddExportJob
+// @Title AddExportJob
+// @Tag Export Job API
+// @Description queue the export of the users, records or permissions of the organization, the response has the id of the job to poll
+// @Param   provider     query    string  false        "The storage provider of the exported file, the one of the application of the user if empty"
+// @Param   body    body   object.ExportJob  true        "The owner and the category of the export job"
+// @Success 200 {object} object.ExportJob The Response object
+// @router /add-export-job [post]
+func (c *ApiController) AddExportJob() {
+	var exportJob object.ExportJob
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &exportJob)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	provider, err := c.GetProviderFromContext("Storage")
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	exportJob.User = c.GetSessionUsername()
+	exportJob.Provider = provider.Name
+	_, err = object.AddExportJob(&exportJob)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(exportJob)
+}
+
+// RetryExportJob
+// @Title RetryExportJob
+// @Tag Export Job API
+// @Description resume the failed export job from its last saved batch
+// @Param   id     query    string  true        "The id ( owner/name ) of the export job"
+// @Success 200 {object} controllers.Response The Response object
+// @router /retry-export-job [post]
+func (c *ApiController) RetryExportJob() {
+	id := c.Input().Get("id")
+
+	exportJob, err := object.GetExportJob(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if exportJob == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The export job: %s does not exist"), id))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.RetryExportJob(exportJob))
+	c.ServeJSON()
+}
+
+// DeleteExportJob
+// @Title DeleteExportJob
+// @Tag Export Job API
+// @Description delete the export job, the exported resource is kept
+// @Param   body    body   object.ExportJob  true        "The details of the export job"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-export-job [post]
+func (c *ApiController) DeleteExportJob() {
+	var exportJob object.ExportJob
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &exportJob)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteExportJob(&exportJob))
+	c.ServeJSON()
+}
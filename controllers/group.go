@@ -41,7 +41,7 @@ func (c *ApiController) GetGroups() {
 	if limit == "" || page == "" {
 		groups, err := object.GetGroups(owner)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		} else {
 			if withTree == "true" {
@@ -54,14 +54,14 @@ func (c *ApiController) GetGroups() {
 		limit := util.ParseInt(limit)
 		count, err := object.GetGroupCount(owner, field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		groups, err := object.GetPaginationGroups(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		} else {
 			c.ResponseOk(groups, paginator.Nums())
@@ -81,7 +81,7 @@ func (c *ApiController) GetGroup() {
 
 	group, err := object.GetGroup(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	c.ResponseOk(group)
@@ -101,7 +101,7 @@ func (c *ApiController) UpdateGroup() {
 	var group object.Group
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &group)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -120,7 +120,7 @@ func (c *ApiController) AddGroup() {
 	var group object.Group
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &group)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -139,7 +139,7 @@ func (c *ApiController) DeleteGroup() {
 	var group object.Group
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &group)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -0,0 +1,159 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// getIdentityVerificationUser returns the user signing in or signed in to the application, the response is written if any of them doesn't exist
+func (c *ApiController) getIdentityVerificationUser(applicationName string) (*object.Application, *object.User, bool) {
+	userId, _ := c.getAuthStepSession()
+	if userId == "" {
+		userId = c.GetSessionUsername()
+	}
+	if userId == "" {
+		c.ResponseError(c.T("general:Please login first"))
+		return nil, nil, false
+	}
+
+	user, err := object.GetUser(userId)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, nil, false
+	}
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), userId))
+		return nil, nil, false
+	}
+
+	application, err := object.GetApplication(util.GetId("admin", applicationName))
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, nil, false
+	}
+	if application == nil || application.Organization != user.Owner {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), applicationName))
+		return nil, nil, false
+	}
+
+	return application, user, true
+}
+
+// StartIdentityVerification
+// @Title StartIdentityVerification
+// @Tag Login API
+// @Description start the ID-document verification of the user in the "Identity Verification" provider of the application, the user completes it in the hosted flow of the returned url
+// @Param   application    query    string  true        "The name of the application"
+// @Param   redirectUri    query    string  false       "The URL to return to after the hosted flow"
+// @Success 200 {object} object.IdentityVerification The Response object
+// @router /start-identity-verification [post]
+func (c *ApiController) StartIdentityVerification() {
+	application, user, ok := c.getIdentityVerificationUser(c.Input().Get("application"))
+	if !ok {
+		return
+	}
+
+	identityVerification, err := object.StartIdentityVerification(application, user, c.Input().Get("redirectUri"))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(identityVerification)
+}
+
+// GetIdentityVerificationState
+// @Title GetIdentityVerificationState
+// @Tag Login API
+// @Description get the state of the identity verification of the user: Unverified, Pending, Verified or Rejected
+// @Param   application    query    string  true        "The name of the application"
+// @Success 200 {string} string The Response object
+// @router /get-identity-verification-state [get]
+func (c *ApiController) GetIdentityVerificationState() {
+	_, user, ok := c.getIdentityVerificationUser(c.Input().Get("application"))
+	if !ok {
+		return
+	}
+
+	c.ResponseOk(object.GetIdentityVerificationState(user))
+}
+
+// NotifyIdentityVerification
+// @Title NotifyIdentityVerification
+// @Tag Login API
+// @Description the webhook for the identity verification provider to notify the result of a verification, Persona and Onfido are supported
+// @Param   owner     path    string  true        "The owner of the identity verification provider"
+// @Param   provider     path    string  true        "The name of the identity verification provider"
+// @Success 200 {object} controllers.Response The Response object
+// @router /notify-identity-verification/:owner/:provider [post]
+func (c *ApiController) NotifyIdentityVerification() {
+	owner := c.Ctx.Input.Param(":owner")
+	providerName := c.Ctx.Input.Param(":provider")
+
+	_, err := object.NotifyIdentityVerification(owner, providerName, c.Ctx.Request.Header, c.Ctx.Input.RequestBody)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk()
+}
+
+// GetIdentityVerifications
+// @Title GetIdentityVerifications
+// @Tag User API
+// @Description get the identity verifications of the users of the organization
+// @Param   owner     query    string  true        "The organization of the users"
+// @Success 200 {array} object.IdentityVerification The Response object
+// @router /get-identity-verifications [get]
+func (c *ApiController) GetIdentityVerifications() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" {
+		limit = "100"
+	}
+	if page == "" {
+		page = "1"
+	}
+	if sortField == "" {
+		sortField, sortOrder = "created_time", "descend"
+	}
+
+	count, err := object.GetIdentityVerificationCount(owner, field, value)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	paginator := pagination.SetPaginator(c.Ctx, util.ParseInt(limit), count)
+	identityVerifications, err := object.GetPaginationIdentityVerifications(owner, paginator.Offset(), util.ParseInt(limit), field, value, sortField, sortOrder)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(identityVerifications, paginator.Nums())
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetLegalAcceptances
+// @Title GetLegalAcceptances
+// @Tag Application API
+// @Description get the acceptances of the legal documents by the users of the organization
+// @Param   owner     query    string  true        "The organization of the users"
+// @Param   application     query    string  false        "The name of the application, all the applications if empty"
+// @Success 200 {array} object.LegalAcceptance The Response object
+// @router /get-legal-acceptances [get]
+func (c *ApiController) GetLegalAcceptances() {
+	owner := c.Input().Get("owner")
+	application := c.Input().Get("application")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" {
+		limit = "100"
+	}
+	if page == "" {
+		page = "1"
+	}
+	if sortField == "" {
+		sortField, sortOrder = "created_time", "descend"
+	}
+
+	count, err := object.GetLegalAcceptanceCount(owner, application, field, value)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	paginator := pagination.SetPaginator(c.Ctx, util.ParseInt(limit), count)
+	legalAcceptances, err := object.GetPaginationLegalAcceptances(owner, application, paginator.Offset(), util.ParseInt(limit), field, value, sortField, sortOrder)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(legalAcceptances, paginator.Nums())
+}
+
+// GetLegalAcceptanceReport
+// @Title GetLegalAcceptanceReport
+// @Tag Application API
+// @Description get how many users of the organization accepted the current versions of the legal documents of the application
+// @Param   owner     query    string  true        "The organization of the application"
+// @Param   application     query    string  true        "The name of the application"
+// @Success 200 {array} object.LegalAcceptanceReportItem The Response object
+// @router /get-legal-acceptance-report [get]
+func (c *ApiController) GetLegalAcceptanceReport() {
+	owner := c.Input().Get("owner")
+	applicationName := c.Input().Get("application")
+
+	application, err := object.GetApplication(util.GetId("admin", applicationName))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if application == nil || application.Organization != owner {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), applicationName))
+		return
+	}
+
+	report, err := object.GetLegalAcceptanceReport(application)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(report)
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// the browser cookie identifying the login subject, which keeps the variant of the login experiments sticky
+const loginSubjectCookie = "casdoor_login_subject"
+
+func (c *ApiController) getLoginSubject(isCreated bool) string {
+	subject := c.Ctx.GetCookie(loginSubjectCookie)
+	if subject == "" && isCreated {
+		subject = util.GenerateId()
+		c.Ctx.SetCookie(loginSubjectCookie, subject, 365*24*60*60, "/", "", c.Ctx.Input.IsSecure(), true)
+	}
+	return subject
+}
+
+// applyLoginExperiment routes the login to the variant of the running experiment of the application
+func (c *ApiController) applyLoginExperiment(application *object.Application) error {
+	if application == nil || application.LoginExperiment == nil || !application.LoginExperiment.IsEnabled {
+		return nil
+	}
+
+	variant, err := object.GetLoginVariant(application, c.getLoginSubject(true))
+	if err != nil {
+		return err
+	}
+
+	application.ApplyLoginVariant(variant)
+	return nil
+}
+
+// GetLoginExperimentMetrics
+// @Title GetLoginExperimentMetrics
+// @Tag Application API
+// @Description get the exposures and conversions of the variants of the login experiment of the application
+// @Param   id     query    string  true        "The id ( owner/name ) of the application"
+// @Success 200 {array} object.LoginExperimentMetric The Response object
+// @router /get-login-experiment-metrics [get]
+func (c *ApiController) GetLoginExperimentMetrics() {
+	id := c.Input().Get("id")
+
+	application, err := object.GetApplication(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), id))
+		return
+	}
+
+	metrics, err := object.GetLoginExperimentMetrics(application)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(metrics)
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/casdoor/casdoor/object"
+)
+
+func (c *ApiController) batchUpdateMembers(fn func(id string, users []string, isAdded bool, isTransactional bool) (*object.MembershipReport, error), isAdded bool) {
+	id := c.Input().Get("id")
+	isTransactional := c.Input().Get("transactional") == "true"
+
+	var users []string
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &users)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	report, err := fn(id, users, isAdded, isTransactional)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(report)
+}
+
+// AddRoleUsers
+// @Title AddRoleUsers
+// @Tag Role API
+// @Description add the users to the role in one call, the failed users are reported
+// @Param   id     query    string  true        "The id ( owner/name ) of the role"
+// @Param   transactional     query    string  false        "Add none of the users if any of them fails, true or false"
+// @Param   body    body   []string  true        "The ids ( owner/name ) of the users"
+// @Success 200 {object} object.MembershipReport The Response object
+// @router /add-role-users [post]
+func (c *ApiController) AddRoleUsers() {
+	c.batchUpdateMembers(object.BatchUpdateRoleUsers, true)
+}
+
+// RemoveRoleUsers
+// @Title RemoveRoleUsers
+// @Tag Role API
+// @Description remove the users from the role in one call
+// @Param   id     query    string  true        "The id ( owner/name ) of the role"
+// @Param   transactional     query    string  false        "Remove none of the users if any of them fails, true or false"
+// @Param   body    body   []string  true        "The ids ( owner/name ) of the users"
+// @Success 200 {object} object.MembershipReport The Response object
+// @router /remove-role-users [post]
+func (c *ApiController) RemoveRoleUsers() {
+	c.batchUpdateMembers(object.BatchUpdateRoleUsers, false)
+}
+
+// AddGroupUsers
+// @Title AddGroupUsers
+// @Tag Group API
+// @Description add the users to the group in one call, the failed users are reported
+// @Param   id     query    string  true        "The id ( owner/name ) of the group"
+// @Param   transactional     query    string  false        "Add none of the users if any of them fails, true or false"
+// @Param   body    body   []string  true        "The names or ids ( owner/name ) of the users"
+// @Success 200 {object} object.MembershipReport The Response object
+// @router /add-group-users [post]
+func (c *ApiController) AddGroupUsers() {
+	c.batchUpdateMembers(object.BatchUpdateGroupUsers, true)
+}
+
+// RemoveGroupUsers
+// @Title RemoveGroupUsers
+// @Tag Group API
+// @Description remove the users from the group in one call
+// @Param   id     query    string  true        "The id ( owner/name ) of the group"
+// @Param   transactional     query    string  false        "Remove none of the users if any of them fails, true or false"
+// @Param   body    body   []string  true        "The names or ids ( owner/name ) of the users"
+// @Success 200 {object} object.MembershipReport The Response object
+// @router /remove-group-users [post]
+func (c *ApiController) RemoveGroupUsers() {
+	c.batchUpdateMembers(object.BatchUpdateGroupUsers, false)
+}
@@ -48,7 +48,7 @@ func (c *ApiController) MfaSetupInitiate() {
 
 	user, err := object.GetUser(userId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -59,7 +59,7 @@ func (c *ApiController) MfaSetupInitiate() {
 
 	mfaProps, err := MfaUtil.Initiate(c.Ctx, user.GetId())
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -91,7 +91,7 @@ func (c *ApiController) MfaSetupVerify() {
 
 	err := mfaUtil.SetupVerify(c.Ctx, passcode)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 	} else {
 		c.ResponseOk(http.StatusText(http.StatusOK))
 	}
@@ -113,7 +113,7 @@ func (c *ApiController) MfaSetupEnable() {
 
 	user, err := object.GetUser(util.GetId(owner, name))
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -130,7 +130,7 @@ func (c *ApiController) MfaSetupEnable() {
 
 	err = mfaUtil.Enable(c.Ctx, user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -152,7 +152,7 @@ func (c *ApiController) DeleteMfa() {
 
 	user, err := object.GetUser(userId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	if user == nil {
@@ -162,7 +162,7 @@ func (c *ApiController) DeleteMfa() {
 
 	err = object.DisabledMultiFactorAuth(user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -186,7 +186,7 @@ func (c *ApiController) SetPreferredMfa() {
 
 	user, err := object.GetUser(userId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	if user == nil {
@@ -196,8 +196,69 @@ func (c *ApiController) SetPreferredMfa() {
 
 	err = object.SetPreferredMultiFactorAuth(user, mfaType)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	c.ResponseOk(object.GetAllMfaProps(user, true))
 }
+
+// MfaPushSend
+// @Title MfaPushSend
+// @Tag MFA API
+// @Description push a sign-in challenge to the enrolled device of the user in the MFA session, the returned number should be shown to the user to be entered in the mobile app, then the challenge ID is submitted to /api/login as the passcode of the "push" MFA type
+// @param application	form	string	true	"name of the application"
+// @Success 200 {object}  Response object
+// @router /mfa/push/send [post]
+func (c *ApiController) MfaPushSend() {
+	userId := c.getMfaUserSession()
+	if userId == "" {
+		c.ResponseError("expired user session")
+		return
+	}
+
+	user, err := object.GetUser(userId)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if user == nil {
+		c.ResponseError("expired user session")
+		return
+	}
+
+	challenge, err := object.SendPushChallenge(user, c.Ctx.Request.Form.Get("application"), util.GetClientIpFromRequest(c.Ctx.Request))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(challenge.Id, challenge.Number)
+}
+
+// MfaApprove
+// @Title MfaApprove
+// @Tag MFA API
+// @Description approve or deny a push sign-in challenge from the mobile app of the signed-in user, the number shown on the sign-in page is required to approve
+// @param challengeId	form	string	true	"ID of the push challenge"
+// @param number	form	int	false	"number shown on the sign-in page"
+// @param approved	form	bool	true	"whether the sign-in is approved"
+// @Success 200 {object}  Response object
+// @router /mfa/approve [post]
+func (c *ApiController) MfaApprove() {
+	userId, ok := c.RequireSignedIn()
+	if !ok {
+		return
+	}
+
+	challengeId := c.Ctx.Request.Form.Get("challengeId")
+	number := util.ParseInt(c.Ctx.Request.Form.Get("number"))
+	isApproved := c.Ctx.Request.Form.Get("approved") == "true"
+
+	err := object.ApprovePushChallenge(userId, challengeId, number, isApproved)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(http.StatusText(http.StatusOK))
+}
@@ -0,0 +1,190 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+const mfaBypassSessionId = "mfaBypassId"
+
+// the page of the MFA bypass link only posts the token back, so the link isn't consumed by the mail scanners and
+// the link previews that open it
+var mfaBypassConfirmTemplate = template.Must(template.New("mfaBypassConfirm").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Sign in without MFA</title>
+</head>
+<body>
+<p>Sign in to {{.Organization}} once without MFA? The link can only be used once.</p>
+<form method="post" action="/api/use-mfa-bypass">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+func (c *ApiController) requestMfaChange(changeType string) {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	id := c.Input().Get("id")
+	owner, _ := util.GetOwnerAndNameFromIdNoCheck(id)
+	if !user.IsGlobalAdmin() && (!user.IsAdmin || user.Owner != owner) {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	content := &object.MfaChangeContent{
+		Reason:          c.Input().Get("reason"),
+		ExpireInMinutes: util.ParseInt(c.Input().Get("expireInMinutes")),
+	}
+	changeRequest, err := object.SubmitMfaChangeRequest(changeType, id, user, content, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(object.ChangeRequestStatePending, changeRequest.GetId())
+}
+
+// RequestMfaReset
+// @Title RequestMfaReset
+// @Tag MFA API
+// @Description request to disable all the MFA of a locked-out user, it's applied once another admin approves the change request, the reason is recorded for the audit
+// @Param   id                query    string  true        "The id ( owner/name ) of the user"
+// @Param   reason            query    string  true        "The reason of the reset"
+// @Success 200 {object} controllers.Response The Response object
+// @router /request-mfa-reset [post]
+func (c *ApiController) RequestMfaReset() {
+	c.requestMfaChange(object.ChangeRequestTypeMfaReset)
+}
+
+// RequestMfaBypass
+// @Title RequestMfaBypass
+// @Tag MFA API
+// @Description request a time-boxed MFA bypass of a locked-out user, once another admin approves the change request, a single-use link signing in without MFA is sent to the Email of the user
+// @Param   id                query    string  true        "The id ( owner/name ) of the user"
+// @Param   reason            query    string  true        "The reason of the bypass"
+// @Param   expireInMinutes   query    string  false       "The minutes before the link expires, 60 by default"
+// @Success 200 {object} controllers.Response The Response object
+// @router /request-mfa-bypass [post]
+func (c *ApiController) RequestMfaBypass() {
+	c.requestMfaChange(object.ChangeRequestTypeMfaBypass)
+}
+
+// UseMfaBypass
+// @Title UseMfaBypass
+// @Tag MFA API
+// @Description open the MFA bypass link, the GET shows a page to confirm, then the POST uses the bypass, the next sign-in of the user in the browser skips MFA once and it redirects to the login page of the organization
+// @Param   token     query    string  true        "The token of the MFA bypass link"
+// @Success 302
+// @router /use-mfa-bypass [get,post]
+func (c *ApiController) UseMfaBypass() {
+	token := c.Input().Get("token")
+	if c.Ctx.Request.Method == http.MethodGet {
+		c.showMfaBypassConfirm(token)
+		return
+	}
+
+	mfaBypass, err := object.UseMfaBypass(token, util.GetClientIpFromRequest(c.Ctx.Request), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.SetSession(mfaBypassSessionId, mfaBypass.GetId())
+
+	c.Ctx.Redirect(http.StatusFound, fmt.Sprintf("/login/%s", mfaBypass.Owner))
+}
+
+func (c *ApiController) showMfaBypassConfirm(token string) {
+	mfaBypass, err := object.GetUsableMfaBypass(token, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	err = mfaBypassConfirmTemplate.Execute(&buf, map[string]string{"Organization": mfaBypass.Owner, "Token": token})
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "text/html; charset=utf-8")
+	c.Ctx.Output.Header("Cache-Control", "no-store")
+	c.Ctx.Output.Header("Referrer-Policy", "no-referrer")
+	c.Ctx.Output.Body(buf.Bytes())
+}
+
+// useMfaBypassSession skips the MFA of the user once if the user opened the MFA bypass link in the session,
+// the MFA is regarded as verified for the rest of the login flow
+func (c *ApiController) useMfaBypassSession(user *object.User) bool {
+	id, _ := c.GetSession(mfaBypassSessionId).(string)
+	if id == "" {
+		return false
+	}
+
+	mfaBypass, err := object.GetMfaBypass(id)
+	if err != nil || mfaBypass == nil || mfaBypass.IsExpired() || util.GetId(mfaBypass.Owner, mfaBypass.User) != user.GetId() {
+		return false
+	}
+
+	c.DelSession(mfaBypassSessionId)
+	c.setMfaVerifiedSession(user.GetId())
+	return true
+}
+
+// RegenerateMfaRecoveryCodes
+// @Title RegenerateMfaRecoveryCodes
+// @Tag MFA API
+// @Description replace the recovery codes of the signed-in user with a new set of single-use codes, which are returned only this time. The MFA should be verified again by the X-Elevation-Token header or the passcode
+// @Param   mfaType        formData    string  false       "The MFA type of the passcode, the preferred one by default"
+// @Param   passcode       formData    string  false       "The MFA passcode"
+// @Param   recoveryCode   formData    string  false       "One of the current recovery codes"
+// @Success 200 {array} string The Response object
+// @router /regenerate-mfa-recovery-codes [post]
+func (c *ApiController) RegenerateMfaRecoveryCodes() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	elevationForm := &form.ElevationForm{
+		MfaType:      c.Input().Get("mfaType"),
+		Passcode:     c.Input().Get("passcode"),
+		RecoveryCode: c.Input().Get("recoveryCode"),
+	}
+	elevationToken := c.Ctx.Request.Header.Get(object.ElevationTokenHeader)
+	recoveryCodes, err := object.RegenerateMfaRecoveryCodes(user, elevationToken, elevationForm, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(recoveryCodes)
+}
@@ -36,7 +36,7 @@ func (c *RootController) GetOidcDiscovery() {
 func (c *RootController) GetJwks() {
 	jwks, err := object.GetJsonWebKeySet()
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	c.Data["json"] = jwks
@@ -16,6 +16,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/beego/beego/utils/pagination"
 	"github.com/casdoor/casdoor/object"
@@ -51,7 +52,7 @@ func (c *ApiController) GetOrganizations() {
 		}
 
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -60,7 +61,7 @@ func (c *ApiController) GetOrganizations() {
 		if !isGlobalAdmin {
 			maskedOrganizations, err := object.GetMaskedOrganizations(object.GetOrganizations(owner, c.getCurrentUser().Owner))
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 			c.ResponseOk(maskedOrganizations)
@@ -68,14 +69,14 @@ func (c *ApiController) GetOrganizations() {
 			limit := util.ParseInt(limit)
 			count, err := object.GetOrganizationCount(owner, field, value)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
 			paginator := pagination.SetPaginator(c.Ctx, limit, count)
 			organizations, err := object.GetMaskedOrganizations(object.GetPaginationOrganizations(owner, organizationName, paginator.Offset(), limit, field, value, sortField, sortOrder))
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
@@ -95,7 +96,7 @@ func (c *ApiController) GetOrganization() {
 	id := c.Input().Get("id")
 	maskedOrganization, err := object.GetMaskedOrganization(object.GetOrganization(id))
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -116,7 +117,11 @@ func (c *ApiController) UpdateOrganization() {
 	var organization object.Organization
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &organization)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
+		return
+	}
+
+	if c.submitChangeRequest(object.ChangeRequestTypeOrganization, id, &organization) {
 		return
 	}
 
@@ -135,18 +140,18 @@ func (c *ApiController) AddOrganization() {
 	var organization object.Organization
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &organization)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	count, err := object.GetOrganizationCount("", "", "")
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	if err = checkQuotaForOrganization(int(count)); err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -154,18 +159,102 @@ func (c *ApiController) AddOrganization() {
 	c.ServeJSON()
 }
 
+// CloneOrganization ...
+// @Title CloneOrganization
+// @Tag Organization API
+// @Description clone an organization with its applications and providers as a template
+// @Param   id         query    string  true        "The id ( owner/name ) of the source organization"
+// @Param   newName    query    string  true        "The name of the new organization"
+// @Success 200 {object} object.Organization The Response object
+// @router /clone-organization [post]
+func (c *ApiController) CloneOrganization() {
+	id := c.Input().Get("id")
+	newName := c.Input().Get("newName")
+
+	if newName == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	count, err := object.GetOrganizationCount("", "", "")
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if err = checkQuotaForOrganization(int(count)); err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	organization, err := object.CloneOrganization(id, newName, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(organization)
+}
+
+// VerifyCustomDomain ...
+// @Title VerifyCustomDomain
+// @Tag Organization API
+// @Description verify the ownership of a custom domain of the organization by its DNS TXT record
+// @Param   id         query    string  true        "The id ( owner/name ) of the organization"
+// @Param   domain     query    string  true        "The custom domain"
+// @Success 200 {object} controllers.Response The Response object
+// @router /verify-custom-domain [post]
+func (c *ApiController) VerifyCustomDomain() {
+	id := c.Input().Get("id")
+	domain := c.Input().Get("domain")
+
+	if domain == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.VerifyCustomDomain(id, domain))
+	c.ServeJSON()
+}
+
+// VerifyClaimedDomain ...
+// @Title VerifyClaimedDomain
+// @Tag Organization API
+// @Description verify the ownership of an email domain claimed by the organization by its DNS TXT record
+// @Param   id         query    string  true        "The id ( owner/name ) of the organization"
+// @Param   domain     query    string  true        "The claimed email domain"
+// @Success 200 {object} controllers.Response The Response object
+// @router /verify-claimed-domain [post]
+func (c *ApiController) VerifyClaimedDomain() {
+	id := c.Input().Get("id")
+	domain := c.Input().Get("domain")
+
+	if domain == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.VerifyClaimedDomain(id, domain))
+	c.ServeJSON()
+}
+
 // DeleteOrganization ...
 // @Title DeleteOrganization
 // @Tag Organization API
 // @Description delete organization
 // @Param   body    body   object.Organization  true        "The details of the organization"
+// @Param   X-Elevation-Token    header    string  false        "The token of /api/elevate if the elevation is required"
 // @Success 200 {object} controllers.Response The Response object
 // @router /delete-organization [post]
 func (c *ApiController) DeleteOrganization() {
 	var organization object.Organization
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &organization)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
+		return
+	}
+
+	if !c.requireElevation() {
 		return
 	}
 
@@ -186,7 +275,21 @@ func (c *ApiController) GetDefaultApplication() {
 
 	application, err := object.GetDefaultApplication(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
+		return
+	}
+
+	if c.Input().Get("withLoginExperiment") != "" {
+		err = c.applyLoginExperiment(application)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+	}
+
+	err = object.ApplyCustomDomain(application, c.Ctx.Request.Host)
+	if err != nil {
+		c.ResponseErr(err)
 		return
 	}
 
@@ -205,9 +308,207 @@ func (c *ApiController) GetOrganizationNames() {
 	owner := c.Input().Get("owner")
 	organizationNames, err := object.GetOrganizationsByFields(owner, []string{"name", "display_name"}...)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	c.ResponseOk(organizationNames)
 }
+
+// GetOrganizationOnboardingStatus
+// @Title GetOrganizationOnboardingStatus
+// @Tag Organization API
+// @Description get the onboarding checklist of the organization with remediation hints
+// @Param   id     query    string  true        "organization id"
+// @Success 200 {object} object.OnboardingStatus The Response object
+// @router /get-organization-onboarding-status [get]
+func (c *ApiController) GetOrganizationOnboardingStatus() {
+	id := c.Input().Get("id")
+
+	status, err := object.GetOrganizationOnboardingStatus(id, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(status)
+}
+
+// GetMfaPolicyReport
+// @Title GetMfaPolicyReport
+// @Tag Organization API
+// @Description get the members that the MFA policy of the organization applies to but haven't set up MFA
+// @Param   owner     query    string  true        "The name of the organization"
+// @Success 200 {array} object.MfaPolicyUserStatus The Response object
+// @router /get-mfa-policy-report [get]
+func (c *ApiController) GetMfaPolicyReport() {
+	owner := c.Input().Get("owner")
+
+	organization, err := object.GetOrganization(util.GetId("admin", owner))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if organization == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The organization: %s does not exist"), owner))
+		return
+	}
+
+	report, err := object.GetMfaPolicyReport(organization)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(report)
+}
+
+// GetSiemExporterStatus
+// @Title GetSiemExporterStatus
+// @Tag Organization API
+// @Description get the status of the SIEM exporter streaming the records of the organization
+// @Param   owner     query    string  true        "The name of the organization"
+// @Success 200 {object} object.SiemExporterStatus The Response object
+// @router /get-siem-exporter-status [get]
+func (c *ApiController) GetSiemExporterStatus() {
+	owner := c.Input().Get("owner")
+
+	organization, err := object.GetOrganization(util.GetId("admin", owner))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if organization == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The organization: %s does not exist"), owner))
+		return
+	}
+
+	c.ResponseOk(object.GetSiemExporterStatus(organization))
+}
+
+// GetUsage
+// @Title GetUsage
+// @Tag Organization API
+// @Description get the usage (MAU, token issuance and enforce calls) of the organization in a monthly period
+// @Param   owner     query    string  true        "The name of the organization"
+// @Param   period    query    string  false       "The period like 2023-06, the current period if empty"
+// @Success 200 {object} object.Usage The Response object
+// @router /get-usage [get]
+func (c *ApiController) GetUsage() {
+	owner := c.Input().Get("owner")
+	period := c.Input().Get("period")
+
+	organization, err := object.GetOrganization(util.GetId("admin", owner))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if organization == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The organization: %s does not exist"), owner))
+		return
+	}
+
+	usage, err := object.GetUsage(owner, period)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(usage)
+}
+
+// getOrganizationForAdmin returns the organization of the owner query, the response is written if it can't be
+// administered by the current user
+func (c *ApiController) getOrganizationForAdmin() (*object.Organization, bool) {
+	owner := c.Input().Get("owner")
+
+	adminOwner, ok := c.RequireAdmin()
+	if !ok {
+		return nil, false
+	}
+	if adminOwner != "" && owner != adminOwner {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return nil, false
+	}
+
+	organization, err := object.GetOrganization(util.GetId("admin", owner))
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+	if organization == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The organization: %s does not exist"), owner))
+		return nil, false
+	}
+
+	return organization, true
+}
+
+// GetDataKey
+// @Title GetDataKey
+// @Tag Organization API
+// @Description get the data-encryption key of the organization without its key material, null if it has none
+// @Param   owner     query    string  true        "The name of the organization"
+// @Success 200 {object} object.DataKey The Response object
+// @router /get-data-key [get]
+func (c *ApiController) GetDataKey() {
+	organization, ok := c.getOrganizationForAdmin()
+	if !ok {
+		return
+	}
+
+	dataKey, err := object.GetDataKey(organization.Name)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(dataKey)
+}
+
+// CreateDataKey
+// @Title CreateDataKey
+// @Tag Organization API
+// @Description create the data-encryption key of the organization and encrypt the sensitive columns of its users by it
+// @Param   owner     query    string  true        "The name of the organization"
+// @Success 200 {object} object.DataKey The Response object
+// @router /create-data-key [post]
+func (c *ApiController) CreateDataKey() {
+	organization, ok := c.getOrganizationForAdmin()
+	if !ok {
+		return
+	}
+
+	dataKey, err := object.CreateDataKey(organization.Name, c.GetSessionUsername(), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(dataKey)
+}
+
+// ShredDataKey
+// @Title ShredDataKey
+// @Tag Organization API
+// @Description delete the data-encryption key of the organization, the sensitive columns of its users encrypted by it are shredded
+// @Param   owner     query    string  true        "The name of the organization"
+// @Param   X-Elevation-Token    header    string  false        "The token of /api/elevate if the elevation is required"
+// @Success 200 {object} controllers.Response The Response object
+// @router /shred-data-key [post]
+func (c *ApiController) ShredDataKey() {
+	organization, ok := c.getOrganizationForAdmin()
+	if !ok {
+		return
+	}
+
+	if !c.requireElevation() {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.ShredDataKey(organization.Name, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
@@ -0,0 +1,229 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+const activeOrganizationSession = "ActiveOrganization"
+
+// getActiveOrganizationSession returns the organization the signed-in user has switched to, empty for its home organization
+func (c *ApiController) getActiveOrganizationSession() string {
+	organization := c.Ctx.Input.CruSession.Get(activeOrganizationSession)
+	if organization == nil {
+		return ""
+	}
+	return organization.(string)
+}
+
+// GetOrganizationMemberships
+// @Title GetOrganizationMemberships
+// @Tag Organization API
+// @Description get the memberships of the users of other organizations in the organization
+// @Param   owner     query    string  true        "The organization of the memberships"
+// @Success 200 {array} object.OrganizationMembership The Response object
+// @router /get-organization-memberships [get]
+func (c *ApiController) GetOrganizationMemberships() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		memberships, err := object.GetOrganizationMemberships(owner)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(memberships)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetOrganizationMembershipCount(owner, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		memberships, err := object.GetPaginationOrganizationMemberships(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(memberships, paginator.Nums())
+	}
+}
+
+// GetOrganizationMembership
+// @Title GetOrganizationMembership
+// @Tag Organization API
+// @Description get organization membership
+// @Param   id     query    string  true        "The id ( owner/name ) of the membership"
+// @Success 200 {object} object.OrganizationMembership The Response object
+// @router /get-organization-membership [get]
+func (c *ApiController) GetOrganizationMembership() {
+	id := c.Input().Get("id")
+
+	membership, err := object.GetOrganizationMembership(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(membership)
+}
+
+// UpdateOrganizationMembership
+// @Title UpdateOrganizationMembership
+// @Tag Organization API
+// @Description update organization membership, the member is moved between the roles of the membership
+// @Param   id     query    string  true        "The id ( owner/name ) of the membership"
+// @Param   body    body   object.OrganizationMembership  true        "The details of the membership"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-organization-membership [post]
+func (c *ApiController) UpdateOrganizationMembership() {
+	id := c.Input().Get("id")
+
+	var membership object.OrganizationMembership
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &membership)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	oldMembership, err := object.GetOrganizationMembership(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	// only the global admin can make another user a member, as the user of another organization has no say
+	if oldMembership != nil && (membership.Owner != oldMembership.Owner || membership.User != oldMembership.User) && !c.IsGlobalAdmin() {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateOrganizationMembership(id, &membership, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// AddOrganizationMembership
+// @Title AddOrganizationMembership
+// @Tag Organization API
+// @Description add a user of another organization as a member of the organization, only for the global admin
+// @Param   body    body   object.OrganizationMembership  true        "The details of the membership"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-organization-membership [post]
+func (c *ApiController) AddOrganizationMembership() {
+	var membership object.OrganizationMembership
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &membership)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	// the admins of the organization can't add the users of other organizations by themselves
+	if !c.IsGlobalAdmin() {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddOrganizationMembership(&membership, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// DeleteOrganizationMembership
+// @Title DeleteOrganizationMembership
+// @Tag Organization API
+// @Description delete organization membership, the member is removed from the roles of the membership
+// @Param   body    body   object.OrganizationMembership  true        "The details of the membership"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-organization-membership [post]
+func (c *ApiController) DeleteOrganizationMembership() {
+	var membership object.OrganizationMembership
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &membership)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteOrganizationMembership(&membership))
+	c.ServeJSON()
+}
+
+// GetUserOrganizations
+// @Title GetUserOrganizations
+// @Tag Organization API
+// @Description get the organizations of the signed-in user, its home organization first, and the active one in data2
+// @Success 200 {array} string The Response object
+// @router /get-user-organizations [get]
+func (c *ApiController) GetUserOrganizations() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	organizations, err := object.GetUserOrganizations(user)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	activeOrganization := c.getActiveOrganizationSession()
+	if !util.InSlice(organizations, activeOrganization) {
+		activeOrganization = user.Owner
+	}
+
+	c.ResponseOk(organizations, activeOrganization)
+}
+
+// SwitchOrganization
+// @Title SwitchOrganization
+// @Tag Organization API
+// @Description switch the signed-in user to one of its organizations, /api/get-account returns the user as a member of it
+// @Param   organization     query    string  true        "The name of the organization"
+// @Success 200 {object} controllers.Response The Response object
+// @router /switch-organization [post]
+func (c *ApiController) SwitchOrganization() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	organization := c.Input().Get("organization")
+	memberUser, err := object.GetMemberUser(organization, user)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if memberUser == nil {
+		c.ResponseError(fmt.Sprintf(c.T("organization:The user: %s is not a member of the organization: %s"), user.GetId(), organization))
+		return
+	}
+
+	c.SetSession(activeOrganizationSession, organization)
+	c.ResponseOk(organization)
+}
@@ -41,7 +41,7 @@ func (c *ApiController) GetPayments() {
 	if limit == "" || page == "" {
 		payments, err := object.GetPayments(owner)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -50,14 +50,14 @@ func (c *ApiController) GetPayments() {
 		limit := util.ParseInt(limit)
 		count, err := object.GetPaymentCount(owner, field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		payments, err := object.GetPaginationPayments(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -80,7 +80,7 @@ func (c *ApiController) GetUserPayments() {
 
 	payments, err := object.GetUserPayments(owner, user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -99,7 +99,7 @@ func (c *ApiController) GetPayment() {
 
 	payment, err := object.GetPayment(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -120,7 +120,7 @@ func (c *ApiController) UpdatePayment() {
 	var payment object.Payment
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &payment)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -139,7 +139,7 @@ func (c *ApiController) AddPayment() {
 	var payment object.Payment
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &payment)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -158,7 +158,7 @@ func (c *ApiController) DeletePayment() {
 	var payment object.Payment
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &payment)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -181,13 +181,34 @@ func (c *ApiController) NotifyPayment() {
 
 	payment, err := object.NotifyPayment(body, owner, paymentName)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	c.ResponseOk(payment)
 }
 
+// NotifySubscription
+// @Title NotifySubscription
+// @Tag Payment API
+// @Description the webhook for the payment provider to notify the renewal, failed payment or cancellation of a recurring subscription
+// @Param   owner     path    string  true        "The owner of the payment provider"
+// @Param   provider     path    string  true        "The name of the payment provider"
+// @Success 200 {object} controllers.Response The Response object
+// @router /notify-subscription/:owner/:provider [post]
+func (c *ApiController) NotifySubscription() {
+	owner := c.Ctx.Input.Param(":owner")
+	providerName := c.Ctx.Input.Param(":provider")
+
+	_, err := object.NotifySubscription(owner, providerName, c.Ctx.Request.Header, c.Ctx.Input.RequestBody)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk()
+}
+
 // InvoicePayment
 // @Title InvoicePayment
 // @Tag Payment API
@@ -200,13 +221,13 @@ func (c *ApiController) InvoicePayment() {
 
 	payment, err := object.GetPayment(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	invoiceUrl, err := object.InvoicePayment(payment)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 	}
 	c.ResponseOk(invoiceUrl)
 }
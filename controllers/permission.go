@@ -27,6 +27,7 @@ import (
 // @Tag Permission API
 // @Description get permissions
 // @Param   owner     query    string  true        "The owner of permissions"
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {array} object.Permission The Response object
 // @router /get-permissions [get]
 func (c *ApiController) GetPermissions() {
@@ -41,27 +42,27 @@ func (c *ApiController) GetPermissions() {
 	if limit == "" || page == "" {
 		permissions, err := object.GetPermissions(owner)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
-		c.ResponseOk(permissions)
+		c.ResponseOkWithFields(permissions)
 	} else {
 		limit := util.ParseInt(limit)
 		count, err := object.GetPermissionCount(owner, field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		permissions, err := object.GetPaginationPermissions(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
-		c.ResponseOk(permissions, paginator.Nums())
+		c.ResponseOkWithFields(permissions, paginator.Nums())
 	}
 }
 
@@ -69,6 +70,7 @@ func (c *ApiController) GetPermissions() {
 // @Title GetPermissionsBySubmitter
 // @Tag Permission API
 // @Description get permissions by submitter
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {array} object.Permission The Response object
 // @router /get-permissions-by-submitter [get]
 func (c *ApiController) GetPermissionsBySubmitter() {
@@ -79,11 +81,11 @@ func (c *ApiController) GetPermissionsBySubmitter() {
 
 	permissions, err := object.GetPermissionsBySubmitter(user.Owner, user.Name)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
-	c.ResponseOk(permissions, len(permissions))
+	c.ResponseOkWithFields(permissions, len(permissions))
 }
 
 // GetPermissionsByRole
@@ -91,17 +93,18 @@ func (c *ApiController) GetPermissionsBySubmitter() {
 // @Tag Permission API
 // @Description get permissions by role
 // @Param   id     query    string  true        "The id ( owner/name ) of the role"
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {array} object.Permission The Response object
 // @router /get-permissions-by-role [get]
 func (c *ApiController) GetPermissionsByRole() {
 	id := c.Input().Get("id")
 	permissions, err := object.GetPermissionsByRole(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
-	c.ResponseOk(permissions, len(permissions))
+	c.ResponseOkWithFields(permissions, len(permissions))
 }
 
 // GetPermission
@@ -109,6 +112,7 @@ func (c *ApiController) GetPermissionsByRole() {
 // @Tag Permission API
 // @Description get permission
 // @Param   id     query    string  true        "The id ( owner/name ) of the permission"
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {object} object.Permission The Response object
 // @router /get-permission [get]
 func (c *ApiController) GetPermission() {
@@ -116,11 +120,11 @@ func (c *ApiController) GetPermission() {
 
 	permission, err := object.GetPermission(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
-	c.ResponseOk(permission)
+	c.ResponseOkWithFields(permission)
 }
 
 // UpdatePermission
@@ -137,7 +141,7 @@ func (c *ApiController) UpdatePermission() {
 	var permission object.Permission
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &permission)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -156,7 +160,7 @@ func (c *ApiController) AddPermission() {
 	var permission object.Permission
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &permission)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -175,10 +179,56 @@ func (c *ApiController) DeletePermission() {
 	var permission object.Permission
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &permission)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	c.Data["json"] = wrapActionResponse(object.DeletePermission(&permission))
 	c.ServeJSON()
 }
+
+// GetStalePolicies
+// @Title GetStalePolicies
+// @Tag Permission API
+// @Description get the policies and permissions that reference deleted users, roles, groups, models or adapters
+// @Param   owner     query    string  false        "The owner of permissions, empty means all organizations"
+// @Success 200 {object} object.PolicyGcReport The Response object
+// @router /get-stale-policies [get]
+func (c *ApiController) GetStalePolicies() {
+	owner := c.Input().Get("owner")
+	if owner == "" && !c.IsGlobalAdmin() {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	report, err := object.GetStalePolicies(owner)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(report)
+}
+
+// PruneStalePolicies
+// @Title PruneStalePolicies
+// @Tag Permission API
+// @Description prune the policies and permission references to deleted users, roles and groups
+// @Param   owner     query    string  false        "The owner of permissions, empty means all organizations"
+// @Success 200 {object} object.PolicyGcReport The Response object
+// @router /prune-stale-policies [post]
+func (c *ApiController) PruneStalePolicies() {
+	owner := c.Input().Get("owner")
+	if owner == "" && !c.IsGlobalAdmin() {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	report, err := object.PruneStalePolicies(owner)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(report)
+}
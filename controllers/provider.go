@@ -16,6 +16,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/beego/beego/utils/pagination"
 	"github.com/casdoor/casdoor/object"
@@ -46,7 +47,7 @@ func (c *ApiController) GetProviders() {
 	if limit == "" || page == "" {
 		providers, err := object.GetProviders(owner)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -55,14 +56,14 @@ func (c *ApiController) GetProviders() {
 		limit := util.ParseInt(limit)
 		count, err := object.GetProviderCount(owner, field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		paginationProviders, err := object.GetPaginationProviders(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -93,7 +94,7 @@ func (c *ApiController) GetGlobalProviders() {
 	if limit == "" || page == "" {
 		globalProviders, err := object.GetGlobalProviders()
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -102,14 +103,14 @@ func (c *ApiController) GetGlobalProviders() {
 		limit := util.ParseInt(limit)
 		count, err := object.GetGlobalProviderCount(field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		paginationGlobalProviders, err := object.GetPaginationGlobalProviders(paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -118,6 +119,23 @@ func (c *ApiController) GetGlobalProviders() {
 	}
 }
 
+// GetCatalogProviders
+// @Title GetCatalogProviders
+// @Tag Provider API
+// @Description get the providers shared by the admin for the organizations to adopt
+// @Param   owner     query    string  true        "The organization adopting the providers"
+// @Success 200 {array} object.Provider The Response object
+// @router /get-catalog-providers [get]
+func (c *ApiController) GetCatalogProviders() {
+	providers, err := object.GetCatalogProviders()
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(object.GetMaskedProviders(providers, true))
+}
+
 // GetProvider
 // @Title GetProvider
 // @Tag Provider API
@@ -132,9 +150,9 @@ func (c *ApiController) GetProvider() {
 	if !ok {
 		return
 	}
-	provider, err := object.GetProvider(id)
+	provider, err := object.GetRawProvider(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -147,6 +165,7 @@ func (c *ApiController) GetProvider() {
 // @Description update provider
 // @Param   id     query    string  true        "The id ( owner/name ) of the provider"
 // @Param   body    body   object.Provider  true        "The details of the provider"
+// @Param   X-Elevation-Token    header    string  false        "The token of /api/elevate if the elevation is required"
 // @Success 200 {object} controllers.Response The Response object
 // @router /update-provider [post]
 func (c *ApiController) UpdateProvider() {
@@ -155,7 +174,20 @@ func (c *ApiController) UpdateProvider() {
 	var provider object.Provider
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &provider)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
+		return
+	}
+
+	oldProvider, err := object.GetRawProvider(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if object.IsProviderSecretChanged(oldProvider, &provider) && !c.requireElevation() {
+		return
+	}
+
+	if c.submitChangeRequest(object.ChangeRequestTypeProvider, id, &provider) {
 		return
 	}
 
@@ -174,18 +206,18 @@ func (c *ApiController) AddProvider() {
 	var provider object.Provider
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &provider)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	count, err := object.GetProviderCount("", "", "")
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	if err := checkQuotaForProvider(int(count)); err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -204,10 +236,191 @@ func (c *ApiController) DeleteProvider() {
 	var provider object.Provider
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &provider)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
+		return
+	}
+
+	if c.submitObjectChangeRequest(object.ChangeRequestTypeProvider, object.ChangeRequestActionDelete, &provider) {
 		return
 	}
 
 	c.Data["json"] = wrapActionResponse(object.DeleteProvider(&provider))
 	c.ServeJSON()
 }
+
+// NotifyApple
+// @Title NotifyApple
+// @Tag Provider API
+// @Description receive the server-to-server notification of Apple for the Apple provider
+// @Param   body    body   string  true        "The notification with the signed payload"
+// @Success 200 {object} controllers.Response The Response object
+// @router /notify-apple [post]
+func (c *ApiController) NotifyApple() {
+	owner := c.Ctx.Input.Param(":owner")
+	providerName := c.Ctx.Input.Param(":provider")
+
+	var notification struct {
+		Payload string `json:"payload"`
+	}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &notification)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	provider, err := object.GetProvider(util.GetId(owner, providerName))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if provider == nil {
+		c.ResponseError(fmt.Sprintf(c.T("provider:the provider: %s does not exist"), util.GetId(owner, providerName)))
+		return
+	}
+
+	event, err := object.HandleAppleNotification(provider, notification.Payload, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(event)
+}
+
+func (c *ApiController) getProviderForAdmin() (*object.Provider, bool) {
+	id := c.Input().Get("id")
+
+	owner, ok := c.RequireAdmin()
+	if !ok {
+		return nil, false
+	}
+
+	provider, err := object.GetProvider(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+	if provider == nil || (owner != "" && provider.Owner != owner) {
+		c.ResponseError(fmt.Sprintf(c.T("util:The provider: %s is not found"), id))
+		return nil, false
+	}
+
+	return provider, true
+}
+
+// GetDkimRecord
+// @Title GetDkimRecord
+// @Tag Provider API
+// @Description get the DNS TXT record to publish for the DKIM key of the Email provider
+// @Param   id     query    string  true        "The id ( owner/name ) of the provider"
+// @Success 200 {object} object.DkimRecord The Response object
+// @router /get-dkim-record [get]
+func (c *ApiController) GetDkimRecord() {
+	provider, ok := c.getProviderForAdmin()
+	if !ok {
+		return
+	}
+
+	record, err := object.GetDkimRecord(provider)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(record)
+}
+
+// RotateDkimKey
+// @Title RotateDkimKey
+// @Tag Provider API
+// @Description generate a new DKIM key with a new selector for the Email provider, the returned DNS record should be published
+// @Param   id     query    string  true        "The id ( owner/name ) of the provider"
+// @Success 200 {object} object.DkimRecord The Response object
+// @router /rotate-dkim-key [post]
+func (c *ApiController) RotateDkimKey() {
+	provider, ok := c.getProviderForAdmin()
+	if !ok {
+		return
+	}
+
+	record, err := object.RotateDkimKey(provider)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(record)
+}
+
+// GetProviderHealth
+// @Title GetProviderHealth
+// @Tag Provider API
+// @Description get the results of the periodic health checks of the providers of the owner
+// @Param   owner     query    string  true        "The owner of the providers"
+// @Success 200 {array} object.ProviderHealth The Response object
+// @router /get-provider-health [get]
+func (c *ApiController) GetProviderHealth() {
+	owner := c.Input().Get("owner")
+
+	adminOwner, ok := c.RequireAdmin()
+	if !ok {
+		return
+	}
+	if adminOwner != "" && owner != adminOwner {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	providerHealths, err := object.GetProviderHealths(owner)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(providerHealths)
+}
+
+// CheckProviderHealth
+// @Title CheckProviderHealth
+// @Tag Provider API
+// @Description check the health of the provider now
+// @Param   id     query    string  true        "The id ( owner/name ) of the provider"
+// @Success 200 {object} object.ProviderHealth The Response object
+// @router /check-provider-health [post]
+func (c *ApiController) CheckProviderHealth() {
+	provider, ok := c.getProviderForAdmin()
+	if !ok {
+		return
+	}
+
+	providerHealth, err := object.CheckProviderHealth(provider)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(providerHealth)
+}
+
+// RefreshSamlMetadata
+// @Title RefreshSamlMetadata
+// @Tag Provider API
+// @Description fetch the metadata URL of the SAML provider now, the IdP certificates are validated against the pinned ones
+// @Param   id     query    string  true        "The id ( owner/name ) of the provider"
+// @Success 200 {object} object.Provider The Response object
+// @router /refresh-saml-metadata [post]
+func (c *ApiController) RefreshSamlMetadata() {
+	provider, ok := c.getProviderForAdmin()
+	if !ok {
+		return
+	}
+
+	provider, err := object.RefreshSamlMetadata(provider.GetId(), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(object.GetMaskedProvider(provider, true))
+}
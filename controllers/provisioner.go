@@ -0,0 +1,176 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetProvisioners
+// @Title GetProvisioners
+// @Tag Provisioner API
+// @Description get provisioners
+// @Param   owner     query    string  true        "The owner of provisioners"
+// @Success 200 {array} object.Provisioner The Response object
+// @router /get-provisioners [get]
+func (c *ApiController) GetProvisioners() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	organization := c.Input().Get("organization")
+
+	if limit == "" || page == "" {
+		provisioners, err := object.GetProvisioners(owner, organization)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(object.GetMaskedProvisioners(provisioners))
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetProvisionerCount(owner, organization, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		provisioners, err := object.GetPaginationProvisioners(owner, organization, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(object.GetMaskedProvisioners(provisioners), paginator.Nums())
+	}
+}
+
+// GetProvisioner
+// @Title GetProvisioner
+// @Tag Provisioner API
+// @Description get provisioner
+// @Param   id     query    string  true        "The id ( owner/name ) of the provisioner"
+// @Success 200 {object} object.Provisioner The Response object
+// @router /get-provisioner [get]
+func (c *ApiController) GetProvisioner() {
+	id := c.Input().Get("id")
+
+	provisioner, err := object.GetProvisioner(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(object.GetMaskedProvisioner(provisioner))
+}
+
+// UpdateProvisioner
+// @Title UpdateProvisioner
+// @Tag Provisioner API
+// @Description update provisioner
+// @Param   id     query    string  true        "The id ( owner/name ) of the provisioner"
+// @Param   body    body   object.Provisioner  true        "The details of the provisioner"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-provisioner [post]
+func (c *ApiController) UpdateProvisioner() {
+	id := c.Input().Get("id")
+
+	var provisioner object.Provisioner
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &provisioner)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateProvisioner(id, &provisioner))
+	c.ServeJSON()
+}
+
+// AddProvisioner
+// @Title AddProvisioner
+// @Tag Provisioner API
+// @Description add provisioner
+// @Param   body    body   object.Provisioner  true        "The details of the provisioner"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-provisioner [post]
+func (c *ApiController) AddProvisioner() {
+	var provisioner object.Provisioner
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &provisioner)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddProvisioner(&provisioner))
+	c.ServeJSON()
+}
+
+// DeleteProvisioner
+// @Title DeleteProvisioner
+// @Tag Provisioner API
+// @Description delete provisioner
+// @Param   body    body   object.Provisioner  true        "The details of the provisioner"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-provisioner [post]
+func (c *ApiController) DeleteProvisioner() {
+	var provisioner object.Provisioner
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &provisioner)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteProvisioner(&provisioner))
+	c.ServeJSON()
+}
+
+// RunProvisioner
+// @Title RunProvisioner
+// @Tag Provisioner API
+// @Description run the reconciliation of the provisioner, which pushes all the users and groups in its scope
+// @Param   id     query    string  true        "The id ( owner/name ) of the provisioner"
+// @Success 200 {object} object.ProvisionerReport The Response object
+// @router /run-provisioner [get]
+func (c *ApiController) RunProvisioner() {
+	id := c.Input().Get("id")
+
+	provisioner, err := object.GetProvisioner(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if provisioner == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The provisioner: %s does not exist"), id))
+		return
+	}
+
+	report, err := object.ReconcileProvisioner(provisioner)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(report)
+}
@@ -0,0 +1,145 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetRadiusClients
+// @Title GetRadiusClients
+// @Tag RADIUS Client API
+// @Description get RADIUS clients
+// @Param   owner     query    string  true        "The owner of RADIUS clients"
+// @Success 200 {array} object.RadiusClient The Response object
+// @router /get-radius-clients [get]
+func (c *ApiController) GetRadiusClients() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		radiusClients, err := object.GetMaskedRadiusClients(object.GetRadiusClients(owner))
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(radiusClients)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetRadiusClientCount(owner, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		radiusClients, err := object.GetMaskedRadiusClients(object.GetPaginationRadiusClients(owner, paginator.Offset(), limit, field, value, sortField, sortOrder))
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(radiusClients, paginator.Nums())
+	}
+}
+
+// GetRadiusClient
+// @Title GetRadiusClient
+// @Tag RADIUS Client API
+// @Description get RADIUS client
+// @Param   id     query    string  true        "The id ( owner/name ) of the RADIUS client"
+// @Success 200 {object} object.RadiusClient The Response object
+// @router /get-radius-client [get]
+func (c *ApiController) GetRadiusClient() {
+	id := c.Input().Get("id")
+
+	radiusClient, err := object.GetMaskedRadiusClient(object.GetRadiusClient(id))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(radiusClient)
+}
+
+// UpdateRadiusClient
+// @Title UpdateRadiusClient
+// @Tag RADIUS Client API
+// @Description update RADIUS client
+// @Param   id     query    string  true        "The id ( owner/name ) of the RADIUS client"
+// @Param   body    body   object.RadiusClient  true        "The details of the RADIUS client"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-radius-client [post]
+func (c *ApiController) UpdateRadiusClient() {
+	id := c.Input().Get("id")
+
+	var radiusClient object.RadiusClient
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &radiusClient)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateRadiusClient(id, &radiusClient))
+	c.ServeJSON()
+}
+
+// AddRadiusClient
+// @Title AddRadiusClient
+// @Tag RADIUS Client API
+// @Description add RADIUS client
+// @Param   body    body   object.RadiusClient  true        "The details of the RADIUS client"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-radius-client [post]
+func (c *ApiController) AddRadiusClient() {
+	var radiusClient object.RadiusClient
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &radiusClient)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddRadiusClient(&radiusClient))
+	c.ServeJSON()
+}
+
+// DeleteRadiusClient
+// @Title DeleteRadiusClient
+// @Tag RADIUS Client API
+// @Description delete RADIUS client
+// @Param   body    body   object.RadiusClient  true        "The details of the RADIUS client"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-radius-client [post]
+func (c *ApiController) DeleteRadiusClient() {
+	var radiusClient object.RadiusClient
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &radiusClient)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteRadiusClient(&radiusClient))
+	c.ServeJSON()
+}
@@ -0,0 +1,347 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+)
+
+// GetRecordQueries
+// @Title GetRecordQueries
+// @Tag Record Query API
+// @Description get record queries that are created by the current user or shared within the organization
+// @Param   owner     query    string  true        "The owner of record queries"
+// @Success 200 {array} object.RecordQuery The Response object
+// @router /get-record-queries [get]
+func (c *ApiController) GetRecordQueries() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	user := c.GetSessionUsername()
+	if c.IsGlobalAdmin() {
+		user = ""
+	}
+
+	if limit == "" || page == "" {
+		recordQueries, err := object.GetRecordQueries(owner, user)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(recordQueries)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetRecordQueryCount(owner, user, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		recordQueries, err := object.GetPaginationRecordQueries(owner, user, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(recordQueries, paginator.Nums())
+	}
+}
+
+// GetRecordQuery
+// @Title GetRecordQuery
+// @Tag Record Query API
+// @Description get record query
+// @Param   id     query    string  true        "The id ( owner/name ) of the record query"
+// @Success 200 {object} object.RecordQuery The Response object
+// @router /get-record-query [get]
+func (c *ApiController) GetRecordQuery() {
+	id := c.Input().Get("id")
+
+	recordQuery, err := object.GetRecordQuery(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(recordQuery)
+}
+
+// UpdateRecordQuery
+// @Title UpdateRecordQuery
+// @Tag Record Query API
+// @Description update record query
+// @Param   id     query    string  true        "The id ( owner/name ) of the record query"
+// @Param   body    body   object.RecordQuery  true        "The details of the record query"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-record-query [post]
+func (c *ApiController) UpdateRecordQuery() {
+	id := c.Input().Get("id")
+
+	var recordQuery object.RecordQuery
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recordQuery)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateRecordQuery(id, &recordQuery))
+	c.ServeJSON()
+}
+
+// AddRecordQuery
+// @Title AddRecordQuery
+// @Tag Record Query API
+// @Description add record query
+// @Param   body    body   object.RecordQuery  true        "The details of the record query"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-record-query [post]
+func (c *ApiController) AddRecordQuery() {
+	var recordQuery object.RecordQuery
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recordQuery)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if recordQuery.User == "" {
+		recordQuery.User = c.GetSessionUsername()
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddRecordQuery(&recordQuery))
+	c.ServeJSON()
+}
+
+// DeleteRecordQuery
+// @Title DeleteRecordQuery
+// @Tag Record Query API
+// @Description delete record query
+// @Param   body    body   object.RecordQuery  true        "The details of the record query"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-record-query [post]
+func (c *ApiController) DeleteRecordQuery() {
+	var recordQuery object.RecordQuery
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &recordQuery)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteRecordQuery(&recordQuery))
+	c.ServeJSON()
+}
+
+func (c *ApiController) getRecordQueryFromContext() (*object.RecordQuery, bool) {
+	id := c.Input().Get("id")
+	recordQuery, err := object.GetRecordQuery(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+
+	if recordQuery == nil {
+		c.ResponseError(fmt.Sprintf(c.T("record:The record query: %s does not exist"), id))
+		return nil, false
+	}
+
+	if !recordQuery.IsShared && recordQuery.User != c.GetSessionUsername() && !c.IsGlobalAdmin() {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return nil, false
+	}
+
+	return recordQuery, true
+}
+
+// RunRecordQuery
+// @Title RunRecordQuery
+// @Tag Record Query API
+// @Description execute the saved record query and get the matched records
+// @Param   id     query    string  true        "The id ( owner/name ) of the record query"
+// @Param   pageSize     query    string  false        "The size of each page"
+// @Param   p     query    string  false        "The number of the page"
+// @Success 200 {object} controllers.Response The Response object
+// @router /run-record-query [get]
+func (c *ApiController) RunRecordQuery() {
+	recordQuery, ok := c.getRecordQueryFromContext()
+	if !ok {
+		return
+	}
+
+	records, err := object.SearchRecords(recordQuery)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.responseRecords(records)
+}
+
+// getRecordQueryFromParams returns the saved record query of the "id" parameter, or the ad-hoc query of the
+// organization by the filter parameters
+func (c *ApiController) getRecordQueryFromParams() (*object.RecordQuery, bool) {
+	if c.Input().Get("id") != "" {
+		return c.getRecordQueryFromContext()
+	}
+
+	owner := c.Input().Get("owner")
+	if owner == "" {
+		c.ResponseError(c.T("general:Missing parameter") + ": owner")
+		return nil, false
+	}
+
+	recordQuery := &object.RecordQuery{
+		Owner:     owner,
+		Actor:     c.Input().Get("user"),
+		Object:    c.Input().Get("object"),
+		ClientIp:  c.Input().Get("clientIp"),
+		Method:    c.Input().Get("method"),
+		StartTime: c.Input().Get("startTime"),
+		EndTime:   c.Input().Get("endTime"),
+		Text:      c.Input().Get("text"),
+	}
+	if actions := c.Input().Get("actions"); actions != "" {
+		recordQuery.Actions = strings.Split(actions, ",")
+	}
+	return recordQuery, true
+}
+
+// SearchRecords
+// @Title SearchRecords
+// @Tag Record Query API
+// @Description search the records of the organization by the filters and the full-text search on the record detail
+// @Param   owner     query    string  true        "The organization of the records"
+// @Param   actions     query    string  false        "The comma-separated actions"
+// @Param   user     query    string  false        "The user of the records"
+// @Param   startTime     query    string  false        "The start time in RFC3339 format"
+// @Param   endTime     query    string  false        "The end time in RFC3339 format"
+// @Param   text     query    string  false        "The words that the record detail should all contain"
+// @Param   pageSize     query    string  false        "The size of each page"
+// @Param   p     query    string  false        "The number of the page"
+// @Success 200 {object} controllers.Response The Response object
+// @router /search-records [get]
+func (c *ApiController) SearchRecords() {
+	recordQuery, ok := c.getRecordQueryFromParams()
+	if !ok {
+		return
+	}
+
+	records, err := object.SearchRecords(recordQuery)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.responseRecords(records)
+}
+
+// GetRecordAggregation
+// @Title GetRecordAggregation
+// @Tag Record Query API
+// @Description count the records of the saved record query or the filters by action, application and hour, and the unique users
+// @Param   id     query    string  false        "The id ( owner/name ) of the record query"
+// @Param   owner     query    string  false        "The organization of the records if the id is not given"
+// @Param   actions     query    string  false        "The comma-separated actions"
+// @Param   startTime     query    string  false        "The start time in RFC3339 format"
+// @Param   endTime     query    string  false        "The end time in RFC3339 format"
+// @Param   text     query    string  false        "The words that the record detail should all contain"
+// @Success 200 {object} object.RecordAggregation The Response object
+// @router /get-record-aggregation [get]
+func (c *ApiController) GetRecordAggregation() {
+	recordQuery, ok := c.getRecordQueryFromParams()
+	if !ok {
+		return
+	}
+
+	aggregation, err := object.GetRecordAggregation(recordQuery)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(aggregation)
+}
+
+func (c *ApiController) responseRecords(records []*casvisorsdk.Record) {
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	if limit == "" || page == "" {
+		c.ResponseOk(records)
+		return
+	}
+
+	limitInt := util.ParseInt(limit)
+	paginator := pagination.SetPaginator(c.Ctx, limitInt, int64(len(records)))
+	start := paginator.Offset()
+	end := start + limitInt
+	if start > len(records) {
+		start = len(records)
+	}
+	if end > len(records) {
+		end = len(records)
+	}
+
+	c.ResponseOk(records[start:end], paginator.Nums())
+}
+
+// ExportRecordQuery
+// @Title ExportRecordQuery
+// @Tag Record Query API
+// @Description execute the saved record query and export the matched records as CSV
+// @Param   id     query    string  true        "The id ( owner/name ) of the record query"
+// @Success 200 {string} string "The CSV file"
+// @router /export-record-query [get]
+func (c *ApiController) ExportRecordQuery() {
+	recordQuery, ok := c.getRecordQueryFromContext()
+	if !ok {
+		return
+	}
+
+	records, err := object.SearchRecords(recordQuery)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	err = writer.WriteAll(object.GetRecordsCsv(records))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Ctx.Output.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Ctx.Output.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s.csv\"", recordQuery.Name))
+	err = c.Ctx.Output.Body(buf.Bytes())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+}
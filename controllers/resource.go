@@ -55,14 +55,14 @@ func (c *ApiController) GetResources() {
 	if sortField == "Direct" {
 		provider, err := c.GetProviderFromContext("Storage")
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		prefix := sortOrder
 		resources, err := object.GetDirectResources(owner, user, provider, prefix, c.GetAcceptLanguage())
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -70,7 +70,7 @@ func (c *ApiController) GetResources() {
 	} else if limit == "" || page == "" {
 		resources, err := object.GetResources(owner, user)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -79,14 +79,14 @@ func (c *ApiController) GetResources() {
 		limit := util.ParseInt(limit)
 		count, err := object.GetResourceCount(owner, user, field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		resources, err := object.GetPaginationResources(owner, user, paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -106,7 +106,7 @@ func (c *ApiController) GetResource() {
 
 	resource, err := object.GetResource(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -127,7 +127,7 @@ func (c *ApiController) UpdateResource() {
 	var resource object.Resource
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &resource)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -145,7 +145,7 @@ func (c *ApiController) AddResource() {
 	var resource object.Resource
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &resource)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -163,7 +163,7 @@ func (c *ApiController) DeleteResource() {
 	var resource object.Resource
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &resource)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -173,14 +173,14 @@ func (c *ApiController) DeleteResource() {
 	c.Input().Set("fullFilePath", resource.Name)
 	provider, err := c.GetProviderFromContext("Storage")
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	_, resource.Name = refineFullFilePath(resource.Name)
 
 	err = object.DeleteFile(provider, resource.Name, c.GetAcceptLanguage())
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -199,6 +199,7 @@ func (c *ApiController) DeleteResource() {
 // @Param     fullFilePath    query   	string    			true     			"Full File Path"
 // @Param     createdTime     query   	string    			false     			"Created Time"
 // @Param     description     query   	string    			false     			"Description"
+// @Param     tags            query   	string    			false     			"Tags separated by commas"
 // @Param     file            formData 	file      			true      			"Resource file"
 // @Success   200             {object}  object.Resource  	FileUrl, objectKey
 // @router /upload-resource [post]
@@ -211,10 +212,14 @@ func (c *ApiController) UploadResource() {
 	fullFilePath := c.Input().Get("fullFilePath")
 	createdTime := c.Input().Get("createdTime")
 	description := c.Input().Get("description")
+	tags := []string{}
+	if c.Input().Get("tags") != "" {
+		tags = strings.Split(c.Input().Get("tags"), ",")
+	}
 
 	file, header, err := c.GetFile("file")
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	defer file.Close()
@@ -227,20 +232,44 @@ func (c *ApiController) UploadResource() {
 	filename := filepath.Base(fullFilePath)
 	fileBuffer := bytes.NewBuffer(nil)
 	if _, err = io.Copy(fileBuffer, file); err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
+		return
+	}
+
+	scanProvider, err := c.getScanProviderFromContext(application, util.GetId(owner, username))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	err = object.ScanUploadedFile(scanProvider, owner, filename, fileBuffer.Bytes(), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		if mimeType := mime.TypeByExtension(filepath.Ext(filename)); mimeType != "" {
+			contentType = mimeType
+		}
+	}
+
+	expireTime, err := object.CheckResourceUpload(application, contentType, header.Size, append([]string{tag}, tags...), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
 		return
 	}
 
 	provider, err := c.GetProviderFromContext("Storage")
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	_, fullFilePath = refineFullFilePath(fullFilePath)
 
 	fileType := "unknown"
-	contentType := header.Header.Get("Content-Type")
-	fileType, _ = util.GetOwnerAndNameFromIdNoCheck(contentType + "/")
+	fileType, _ = util.GetOwnerAndNameFromIdNoCheck(header.Header.Get("Content-Type") + "/")
 
 	if fileType != "image" && fileType != "video" {
 		ext := filepath.Ext(filename)
@@ -255,7 +284,7 @@ func (c *ApiController) UploadResource() {
 		for i := 1; ; i++ {
 			_, objectKey := object.GetUploadFileUrl(provider, fullFilePath, true)
 			if count, err := object.GetResourceCount(owner, username, "name", objectKey); err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			} else if count == 0 {
 				break
@@ -268,7 +297,7 @@ func (c *ApiController) UploadResource() {
 
 	fileUrl, objectKey, err := object.UploadFileSafe(provider, fullFilePath, fileBuffer, c.GetAcceptLanguage())
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -297,10 +326,12 @@ func (c *ApiController) UploadResource() {
 		FileSize:    fileSize,
 		Url:         fileUrl,
 		Description: description,
+		Tags:        tags,
+		ExpireTime:  expireTime,
 	}
 	_, err = object.AddOrUpdateResource(resource)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -308,7 +339,7 @@ func (c *ApiController) UploadResource() {
 	case "avatar":
 		user, err := object.GetUserNoCheck(util.GetId(owner, username))
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -320,14 +351,14 @@ func (c *ApiController) UploadResource() {
 		user.Avatar = fileUrl
 		_, err = object.UpdateUser(user.GetId(), user, []string{"avatar"}, false)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 	case "termsOfUse":
 		user, err := object.GetUserNoCheck(util.GetId(owner, username))
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -344,20 +375,20 @@ func (c *ApiController) UploadResource() {
 		_, applicationId := util.GetOwnerAndNameFromIdNoCheck(strings.TrimSuffix(fullFilePath, ".html"))
 		applicationObj, err := object.GetApplication(applicationId)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		applicationObj.TermsOfUse = fileUrl
 		_, err = object.UpdateApplication(applicationId, applicationObj)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	case "idCardFront", "idCardBack", "idCardWithPerson":
 		user, err := object.GetUserNoCheck(util.GetId(owner, username))
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -373,10 +404,44 @@ func (c *ApiController) UploadResource() {
 		user.Properties["isIdCardVerified"] = "false"
 		_, err = object.UpdateUser(user.GetId(), user, []string{"properties"}, false)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	}
 
 	c.ResponseOk(fileUrl, objectKey)
 }
+
+// GetResourceUsage
+// @Title GetResourceUsage
+// @Tag Resource API
+// @Description get the storage used by the resources of the application against its quota
+// @Param   id     query    string  true        "The id ( owner/name ) of the application"
+// @Success 200 {object} object.ResourceUsage The Response object
+// @router /get-resource-usage [get]
+func (c *ApiController) GetResourceUsage() {
+	id := c.Input().Get("id")
+
+	owner, ok := c.RequireAdmin()
+	if !ok {
+		return
+	}
+
+	application, err := object.GetApplication(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if application == nil || (owner != "" && application.Organization != owner) {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), id))
+		return
+	}
+
+	usage, err := object.GetResourceUsage(application)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(usage)
+}
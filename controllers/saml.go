@@ -25,7 +25,7 @@ func (c *ApiController) GetSamlMeta() {
 	paramApp := c.Input().Get("application")
 	application, err := object.GetApplication(paramApp)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -36,7 +36,7 @@ func (c *ApiController) GetSamlMeta() {
 
 	metadata, err := object.GetSamlMeta(application, host)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -58,7 +58,7 @@ func (c *ApiController) SendEmail() {
 
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &emailForm)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -67,7 +67,7 @@ func (c *ApiController) SendEmail() {
 		// called by frontend's TestEmailWidget, provider name is set by frontend
 		provider, err = object.GetProvider(util.GetId("admin", emailForm.Provider))
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -75,7 +75,7 @@ func (c *ApiController) SendEmail() {
 		// called by Casdoor SDK via Client ID & Client Secret, so the used Email provider will be the application' Email provider or the default Email provider
 		provider, err = c.GetProviderFromContext("Email")
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	}
@@ -84,7 +84,7 @@ func (c *ApiController) SendEmail() {
 	if len(emailForm.Receivers) == 1 && emailForm.Receivers[0] == "TestSmtpServer" {
 		err := object.DailSmtpServer(provider)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 		c.ResponseOk()
@@ -113,7 +113,7 @@ func (c *ApiController) SendEmail() {
 	for _, receiver := range emailForm.Receivers {
 		err = object.SendEmail(provider, emailForm.Title, content, receiver, emailForm.Sender)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	}
@@ -121,6 +121,37 @@ func (c *ApiController) SendEmail() {
 	c.ResponseOk()
 }
 
+// HandleEmailBounce
+// @Title HandleEmailBounce
+// @Tag Service API
+// @Description The webhook for the email services to report the hard bounces and spam complaints, the emails are marked as undeliverable for the users. Amazon SES (via SNS), SendGrid and the generic format: {"type": "bounce", "emails": [...]} are supported.
+// @Param   provider    query    string  true        "The id ( owner/name ) of the Email provider"
+// @Param   secret      query    string  true        "The bounce secret of the Email provider"
+// @Success 200 {object}  Response object
+// @router /api/email-bounce [post]
+func (c *ApiController) HandleEmailBounce() {
+	providerId := c.Input().Get("provider")
+	secret := c.Input().Get("secret")
+
+	provider, err := object.GetProvider(providerId)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if provider == nil || provider.Category != "Email" || !object.IsEmailBounceSecretValid(provider, secret) {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	affected, err := object.HandleEmailBounces(provider, c.Ctx.Input.RequestBody)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(affected)
+}
+
 // SendSms
 // @Title SendSms
 // @Tag Service API
@@ -133,14 +164,14 @@ func (c *ApiController) SendEmail() {
 func (c *ApiController) SendSms() {
 	provider, err := c.GetProviderFromContext("SMS")
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	var smsForm SmsForm
 	err = json.Unmarshal(c.Ctx.Input.RequestBody, &smsForm)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -154,7 +185,7 @@ func (c *ApiController) SendSms() {
 
 	err = object.SendSms(provider, smsForm.Content, smsForm.Receivers...)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -171,20 +202,20 @@ func (c *ApiController) SendSms() {
 func (c *ApiController) SendNotification() {
 	provider, err := c.GetProviderFromContext("Notification")
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	var notificationForm NotificationForm
 	err = json.Unmarshal(c.Ctx.Input.RequestBody, &notificationForm)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	err = object.SendNotification(provider, notificationForm.Content)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
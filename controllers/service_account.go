@@ -0,0 +1,204 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetServiceAccounts
+// @Title GetServiceAccounts
+// @Tag Service Account API
+// @Description get service accounts
+// @Param   owner     query    string  true        "The owner of service accounts"
+// @Success 200 {array} object.ServiceAccount The Response object
+// @router /get-service-accounts [get]
+func (c *ApiController) GetServiceAccounts() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		serviceAccounts, err := object.GetServiceAccounts(owner)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(serviceAccounts)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetServiceAccountCount(owner, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		serviceAccounts, err := object.GetPaginationServiceAccounts(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(serviceAccounts, paginator.Nums())
+	}
+}
+
+// GetServiceAccount
+// @Title GetServiceAccount
+// @Tag Service Account API
+// @Description get service account
+// @Param   id     query    string  true        "The id ( owner/name ) of the service account"
+// @Success 200 {object} object.ServiceAccount The Response object
+// @router /get-service-account [get]
+func (c *ApiController) GetServiceAccount() {
+	id := c.Input().Get("id")
+
+	serviceAccount, err := object.GetServiceAccount(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(serviceAccount)
+}
+
+// UpdateServiceAccount
+// @Title UpdateServiceAccount
+// @Tag Service Account API
+// @Description update service account, the client secret is only changed by the rotation
+// @Param   id     query    string  true        "The id ( owner/name ) of the service account"
+// @Param   body    body   object.ServiceAccount  true        "The details of the service account"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-service-account [post]
+func (c *ApiController) UpdateServiceAccount() {
+	id := c.Input().Get("id")
+
+	var serviceAccount object.ServiceAccount
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &serviceAccount)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateServiceAccount(id, &serviceAccount, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// AddServiceAccount
+// @Title AddServiceAccount
+// @Tag Service Account API
+// @Description add service account, the client id and secret are generated
+// @Param   body    body   object.ServiceAccount  true        "The details of the service account"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-service-account [post]
+func (c *ApiController) AddServiceAccount() {
+	var serviceAccount object.ServiceAccount
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &serviceAccount)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddServiceAccount(&serviceAccount, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// DeleteServiceAccount
+// @Title DeleteServiceAccount
+// @Tag Service Account API
+// @Description delete service account
+// @Param   body    body   object.ServiceAccount  true        "The details of the service account"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-service-account [post]
+func (c *ApiController) DeleteServiceAccount() {
+	var serviceAccount object.ServiceAccount
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &serviceAccount)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteServiceAccount(&serviceAccount))
+	c.ServeJSON()
+}
+
+// RotateServiceAccountSecret
+// @Title RotateServiceAccountSecret
+// @Tag Service Account API
+// @Description generate a new client secret for the service account, the old secret stops working at once
+// @Param   body    body   object.ServiceAccount  true        "The owner and name of the service account"
+// @Success 200 {object} object.ServiceAccount The Response object
+// @router /rotate-service-account-secret [post]
+func (c *ApiController) RotateServiceAccountSecret() {
+	var serviceAccount object.ServiceAccount
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &serviceAccount)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	res, err := object.RotateServiceAccountSecret(serviceAccount.GetId())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if res == nil {
+		c.ResponseError(fmt.Sprintf(c.T("service:The service account: %s does not exist"), serviceAccount.GetId()))
+		return
+	}
+
+	c.ResponseOk(res)
+}
+
+// RotateServiceAccountKey
+// @Title RotateServiceAccountKey
+// @Tag Service Account API
+// @Description replace the public key that verifies the client assertions of the service account
+// @Param   body    body   object.ServiceAccount  true        "The owner, name and public key of the service account"
+// @Success 200 {object} object.ServiceAccount The Response object
+// @router /rotate-service-account-key [post]
+func (c *ApiController) RotateServiceAccountKey() {
+	var serviceAccount object.ServiceAccount
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &serviceAccount)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	res, err := object.RotateServiceAccountKey(serviceAccount.GetId(), serviceAccount.PublicKey)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if res == nil {
+		c.ResponseError(fmt.Sprintf(c.T("service:The service account: %s does not exist"), serviceAccount.GetId()))
+		return
+	}
+
+	c.ResponseOk(res)
+}
@@ -22,6 +22,12 @@ import (
 	"github.com/casdoor/casdoor/util"
 )
 
+// getSessionDevices returns the device of the current session signing in
+func (c *ApiController) getSessionDevices() map[string]*object.SessionDevice {
+	device := object.NewSessionDevice(c.Ctx.Request.Header, util.GetClientIpFromRequest(c.Ctx.Request))
+	return map[string]*object.SessionDevice{c.Ctx.Input.CruSession.SessionID(): device}
+}
+
 // GetSessions
 // @Title GetSessions
 // @Tag Session API
@@ -41,7 +47,7 @@ func (c *ApiController) GetSessions() {
 	if limit == "" || page == "" {
 		sessions, err := object.GetSessions(owner)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -50,13 +56,13 @@ func (c *ApiController) GetSessions() {
 		limit := util.ParseInt(limit)
 		count, err := object.GetSessionCount(owner, field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		sessions, err := object.GetPaginationSessions(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -76,7 +82,7 @@ func (c *ApiController) GetSingleSession() {
 
 	session, err := object.GetSingleSession(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -94,7 +100,7 @@ func (c *ApiController) UpdateSession() {
 	var session object.Session
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &session)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -114,7 +120,7 @@ func (c *ApiController) AddSession() {
 	var session object.Session
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &session)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -133,7 +139,7 @@ func (c *ApiController) DeleteSession() {
 	var session object.Session
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &session)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -155,9 +161,52 @@ func (c *ApiController) IsSessionDuplicated() {
 
 	isUserSessionDuplicated, err := object.IsSessionDuplicated(id, sessionId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	c.ResponseOk(isUserSessionDuplicated)
 }
+
+// GetUserSessions
+// @Title GetUserSessions
+// @Tag Session API
+// @Description get the sessions of the current user or the specified user with the devices signed in, the current session ID is in data2
+// @Param   userId     query    string  false        "The id ( owner/name ) of the user, only for admins"
+// @Success 200 {array} object.Session The Response object
+// @router /get-user-sessions [get]
+func (c *ApiController) GetUserSessions() {
+	user, ok := c.getContactUser()
+	if !ok {
+		return
+	}
+
+	sessions, err := object.GetUserSessions(user.Owner, user.Name)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(sessions, c.Ctx.Input.CruSession.SessionID())
+}
+
+// SetSessionDeviceName
+// @Title SetSessionDeviceName
+// @Tag Session API
+// @Description name the device of a session ID of the session to recognize it
+// @Param   id            query    string  true        "The id(organization/user/application) of session"
+// @Param   sessionId     query    string  true        "The session ID of the device"
+// @Param   displayName   query    string  true        "The name of the device"
+// @Success 200 {object} controllers.Response The Response object
+// @router /set-session-device-name [post]
+func (c *ApiController) SetSessionDeviceName() {
+	id := c.Input().Get("id")
+	owner, name, _ := util.GetOwnerAndNameAndOtherFromId(id)
+	if !c.IsAdminOrSelf(&object.User{Owner: owner, Name: name}) {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.SetSessionDeviceName(id, c.Input().Get("sessionId"), c.Input().Get("displayName")))
+	c.ServeJSON()
+}
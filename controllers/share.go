@@ -0,0 +1,118 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/casdoor/casdoor/object"
+)
+
+// GetShares
+// @Title GetShares
+// @Tag Share API
+// @Description get the outgoing or incoming shares of the current user
+// @Param   type     query    string  true        "outgoing or incoming"
+// @Success 200 {array} object.Share The Response object
+// @router /get-shares [get]
+func (c *ApiController) GetShares() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	shareType := c.Input().Get("type")
+
+	var shares []*object.Share
+	var err error
+	if shareType == "incoming" {
+		shares, err = object.GetIncomingShares(user.GetId())
+	} else {
+		shares, err = object.GetOutgoingShares(user.GetId())
+	}
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(shares)
+}
+
+// AddShare
+// @Title AddShare
+// @Tag Share API
+// @Description grant another user of the organization access to a resource owned by the current user
+// @Param   body    body   object.Share  true        "The grantee, resource, actions and expire time of the share"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-share [post]
+func (c *ApiController) AddShare() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	var share object.Share
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &share)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddShare(&share, user))
+	c.ServeJSON()
+}
+
+// DeleteShare
+// @Title DeleteShare
+// @Tag Share API
+// @Description revoke a share, it can be done by the grantor, the grantee or the admin
+// @Param   body    body   object.Share  true        "The owner and name of the share"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-share [post]
+func (c *ApiController) DeleteShare() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	var form object.Share
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &form)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	share, err := object.GetShare(form.GetId())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if share == nil {
+		c.Data["json"] = wrapActionResponse(false)
+		c.ServeJSON()
+		return
+	}
+
+	userId := user.GetId()
+	isOrgAdmin := user.IsAdmin && user.Owner == share.Owner
+	if share.Grantor != userId && share.Grantee != userId && !isOrgAdmin && !c.IsGlobalAdmin() {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteShare(share))
+	c.ServeJSON()
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetSmsMessages
+// @Title GetSmsMessages
+// @Tag SMS Message API
+// @Description get the sent SMS messages and their delivery states
+// @Param   owner     query    string  true        "The owner of SMS messages"
+// @Success 200 {array} object.SmsMessage The Response object
+// @router /get-sms-messages [get]
+func (c *ApiController) GetSmsMessages() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		smsMessages, err := object.GetSmsMessages(owner)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(smsMessages)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetSmsMessageCount(owner, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		smsMessages, err := object.GetPaginationSmsMessages(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(smsMessages, paginator.Nums())
+	}
+}
+
+// GetSmsMessage
+// @Title GetSmsMessage
+// @Tag SMS Message API
+// @Description get the delivery state of an SMS message
+// @Param   id     query    string  true        "The id ( owner/name ) of the SMS message"
+// @Success 200 {object} object.SmsMessage The Response object
+// @router /get-sms-message [get]
+func (c *ApiController) GetSmsMessage() {
+	id := c.Input().Get("id")
+
+	smsMessage, err := object.GetSmsMessage(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(smsMessage)
+}
+
+// NotifySms
+// @Title NotifySms
+// @Tag SMS Message API
+// @Description receive the delivery receipts of the SMS messages sent by the provider
+// @Param   body    body   string  true        "The delivery receipts in the format of the provider"
+// @Success 200 {object} controllers.Response The Response object
+// @router /notify-sms [post]
+func (c *ApiController) NotifySms() {
+	owner := c.Ctx.Input.Param(":owner")
+	providerName := c.Ctx.Input.Param(":provider")
+
+	provider, err := object.GetProvider(util.GetId(owner, providerName))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if provider == nil || provider.Category != "SMS" {
+		c.ResponseError(fmt.Sprintf(c.T("provider:the provider: %s does not exist"), util.GetId(owner, providerName)))
+		return
+	}
+
+	count, err := object.NotifySms(provider, c.Ctx.Input.RequestBody)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(count)
+}
@@ -15,6 +15,7 @@
 package controllers
 
 import (
+	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
 )
 
@@ -32,7 +33,7 @@ func (c *ApiController) GetSystemInfo() {
 
 	systemInfo, err := util.GetSystemInfo()
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -54,7 +55,7 @@ func (c *ApiController) GetVersionInfo() {
 
 	versionInfo, err = util.GetVersionInfoFromFile()
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	c.ResponseOk(versionInfo)
@@ -69,3 +70,13 @@ func (c *ApiController) GetVersionInfo() {
 func (c *ApiController) Health() {
 	c.ResponseOk()
 }
+
+// GetErrorCodes
+// @Title GetErrorCodes
+// @Tag System API
+// @Description get the catalog of the machine-readable codes in the "code" field of the error responses
+// @Success 200 {array} object.ErrorCodeItem The Response object
+// @router /get-error-codes [get]
+func (c *ApiController) GetErrorCodes() {
+	c.ResponseOk(object.GetErrorCatalog())
+}
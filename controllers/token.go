@@ -16,6 +16,9 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/beego/beego/utils/pagination"
 	"github.com/casdoor/casdoor/object"
@@ -43,7 +46,7 @@ func (c *ApiController) GetTokens() {
 	if limit == "" || page == "" {
 		token, err := object.GetTokens(owner, organization)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -52,14 +55,14 @@ func (c *ApiController) GetTokens() {
 		limit := util.ParseInt(limit)
 		count, err := object.GetTokenCount(owner, organization, field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		tokens, err := object.GetPaginationTokens(owner, organization, paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -78,7 +81,7 @@ func (c *ApiController) GetToken() {
 	id := c.Input().Get("id")
 	token, err := object.GetToken(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -99,7 +102,7 @@ func (c *ApiController) UpdateToken() {
 	var token object.Token
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &token)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -118,7 +121,7 @@ func (c *ApiController) AddToken() {
 	var token object.Token
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &token)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -137,7 +140,7 @@ func (c *ApiController) DeleteToken() {
 	var token object.Token
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &token)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -153,6 +156,7 @@ func (c *ApiController) DeleteToken() {
 // @Param   client_id     query    string  true        "OAuth client id"
 // @Param   client_secret     query    string  true        "OAuth client secret"
 // @Param   code     query    string  true        "OAuth code"
+// @Param   audience     query    string  false        "The client ID of the application that the client credentials token is for"
 // @Success 200 {object} object.TokenWrapper The Response object
 // @Success 400 {object} object.TokenError The Response object
 // @Success 401 {object} object.TokenError The Response object
@@ -169,6 +173,9 @@ func (c *ApiController) GetOAuthToken() {
 	tag := c.Input().Get("tag")
 	avatar := c.Input().Get("avatar")
 	refreshToken := c.Input().Get("refresh_token")
+	subjectToken := c.Input().Get("subject_token")
+	subjectTokenType := c.Input().Get("subject_token_type")
+	audience := c.Input().Get("audience")
 
 	if clientId == "" && clientSecret == "" {
 		clientId, clientSecret, _ = c.Ctx.Request.BasicAuth()
@@ -212,13 +219,90 @@ func (c *ApiController) GetOAuthToken() {
 			if refreshToken == "" {
 				refreshToken = tokenRequest.RefreshToken
 			}
+			if subjectToken == "" {
+				subjectToken = tokenRequest.SubjectToken
+			}
+			if subjectTokenType == "" {
+				subjectTokenType = tokenRequest.SubjectTokenType
+			}
+			if audience == "" {
+				audience = tokenRequest.Audience
+			}
 		}
 	}
 
 	host := c.Ctx.Request.Host
-	token, err := object.GetOAuthToken(grantType, clientId, clientSecret, code, verifier, scope, username, password, host, refreshToken, tag, avatar, c.GetAcceptLanguage())
+
+	if grantType == "client_credentials" {
+		clientAssertionType := c.Input().Get("client_assertion_type")
+		clientAssertion := c.Input().Get("client_assertion")
+		if clientId == "" && clientAssertion != "" {
+			clientId = object.GetClientIdFromClientAssertion(clientAssertion)
+		}
+
+		serviceAccount, err := object.GetServiceAccountByClientId(clientId)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		if serviceAccount != nil {
+			token, tokenError, err := object.GetServiceAccountToken(serviceAccount, clientSecret, clientAssertionType, clientAssertion, scope, host)
+			if err != nil {
+				c.ResponseErr(err)
+				return
+			}
+
+			if tokenError != nil {
+				c.Data["json"] = tokenError
+			} else {
+				c.Data["json"] = token
+			}
+			c.SetTokenErrorHttpStatus()
+			c.ServeJSON()
+			return
+		}
+
+		device, err := object.GetDeviceByClientId(clientId)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		if device != nil {
+			certThumbprint, err := object.GetClientCertificateThumbprint(c.Ctx.Request)
+			if err != nil {
+				c.ResponseErr(err)
+				return
+			}
+
+			token, tokenError, err := object.GetDeviceToken(device, certThumbprint, scope, host)
+			if err != nil {
+				c.ResponseErr(err)
+				return
+			}
+
+			if tokenError != nil {
+				c.Data["json"] = tokenError
+			} else {
+				c.Data["json"] = token
+			}
+			c.SetTokenErrorHttpStatus()
+			c.ServeJSON()
+			return
+		}
+	}
+
+	certThumbprint, err := object.GetClientCertificateThumbprint(c.Ctx.Request)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	clientIp := util.GetClientIpFromRequest(c.Ctx.Request)
+	token, err := object.GetOAuthToken(grantType, clientId, clientSecret, code, verifier, scope, username, password, host, refreshToken, tag, avatar, clientIp, certThumbprint, subjectToken, subjectTokenType, audience, c.GetAcceptLanguage())
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -260,9 +344,16 @@ func (c *ApiController) RefreshToken() {
 		}
 	}
 
-	refreshToken2, err := object.RefreshToken(grantType, refreshToken, scope, clientId, clientSecret, host)
+	certThumbprint, err := object.GetClientCertificateThumbprint(c.Ctx.Request)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
+		return
+	}
+
+	clientIp := util.GetClientIpFromRequest(c.Ctx.Request)
+	refreshToken2, err := object.RefreshToken(grantType, refreshToken, scope, clientId, clientSecret, host, clientIp, certThumbprint, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
 		return
 	}
 
@@ -303,7 +394,7 @@ func (c *ApiController) IntrospectToken() {
 	}
 	application, err := object.GetApplicationByClientId(clientId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -317,25 +408,37 @@ func (c *ApiController) IntrospectToken() {
 	}
 	token, err := object.GetTokenByTokenAndApplication(tokenValue, application.Name)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
+	// the inactive results shouldn't be cached, the active ones can be cached for a short while by the gateways
+	c.Ctx.Output.Header("Cache-Control", "no-store")
 	if token == nil {
 		c.Data["json"] = &object.IntrospectionResponse{Active: false}
 		c.ServeJSON()
 		return
 	}
 	jwtToken, err := object.ParseJwtTokenByApplication(tokenValue, application)
-	if err != nil || jwtToken.Valid() != nil {
-		// and token revoked case. but we not implement
-		// TODO: 2022-03-03 add token revoked check, when we implemented the Token Revocation(rfc7009) Specs.
-		// refs: https://tools.ietf.org/html/rfc7009
+	if err != nil || jwtToken.Valid() != nil || object.IsJwtTokenRevoked(token, jwtToken) {
 		c.Data["json"] = &object.IntrospectionResponse{Active: false}
 		c.ServeJSON()
 		return
 	}
 
+	maxAge := int(time.Until(jwtToken.ExpiresAt.Time).Seconds())
+	if maxAge > object.IntrospectionMaxCacheSeconds {
+		maxAge = object.IntrospectionMaxCacheSeconds
+	}
+	if maxAge > 0 {
+		c.Ctx.Output.Header("Cache-Control", fmt.Sprintf("max-age=%d", maxAge))
+	}
+
+	var cnf *object.ClaimsConfirmation
+	if token.CertThumbprint != "" {
+		cnf = &object.ClaimsConfirmation{X5tS256: token.CertThumbprint}
+	}
+
 	c.Data["json"] = &object.IntrospectionResponse{
 		Active:    true,
 		Scope:     jwtToken.Scope,
@@ -349,6 +452,98 @@ func (c *ApiController) IntrospectToken() {
 		Aud:       jwtToken.Audience,
 		Iss:       jwtToken.Issuer,
 		Jti:       jwtToken.ID,
+		Cnf:       cnf,
+		Act:       jwtToken.Act,
 	}
 	c.ServeJSON()
 }
+
+// RevokeToken
+// @Title RevokeToken
+// @Description The revocation endpoint (RFC 7009) revokes an access token or a refresh token of the client,
+// revoking a refresh token also revokes the access token issued with it.
+// The client authenticates with Basic Authorization or the client_id and client_secret parameters.
+//
+// @Param token formData string true "access_token's value or refresh_token's value"
+// @Param token_type_hint formData string false "the token type access_token or refresh_token"
+// @Success 200 {string} string "The token is revoked or unknown"
+// @Success 400 {object} object.TokenError The Response object
+// @Success 401 {object} object.TokenError The Response object
+// @router /login/oauth/revoke [post]
+func (c *ApiController) RevokeToken() {
+	tokenValue := c.Input().Get("token")
+	tokenTypeHint := c.Input().Get("token_type_hint")
+	clientId, clientSecret, ok := c.Ctx.Request.BasicAuth()
+	if !ok {
+		clientId = c.Input().Get("client_id")
+		clientSecret = c.Input().Get("client_secret")
+	}
+
+	application, err := object.GetApplicationByClientId(clientId)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if application == nil || clientSecret == "" || application.ClientSecret != clientSecret {
+		c.Data["json"] = &object.TokenError{
+			Error:            object.InvalidClient,
+			ErrorDescription: "client_id or client_secret is invalid",
+		}
+		c.SetTokenErrorHttpStatus()
+		c.ServeJSON()
+		return
+	}
+
+	if tokenValue == "" {
+		c.Data["json"] = &object.TokenError{
+			Error:            object.InvalidRequest,
+			ErrorDescription: "token is missing",
+		}
+		c.SetTokenErrorHttpStatus()
+		c.ServeJSON()
+		return
+	}
+
+	tokenError, err := object.RevokeToken(application, tokenValue, tokenTypeHint)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if tokenError != nil {
+		c.Data["json"] = tokenError
+		c.SetTokenErrorHttpStatus()
+		c.ServeJSON()
+		return
+	}
+
+	c.Ctx.Output.SetStatus(200)
+}
+
+// GetTokenMetadata
+// @Title GetTokenMetadata
+// @Tag Token API
+// @Description get the signing keys and the revocation epochs of the subjects for validating the JWTs offline,
+// the result can be cached by the resource servers for a short while
+// @Param   sub     query    string  false        "The comma-separated subjects (the sub claims) of the tokens"
+// @Success 200 {object} object.TokenMetadata The Response object
+// @router /token-metadata [get]
+func (c *ApiController) GetTokenMetadata() {
+	subjects := []string{}
+	for _, subject := range strings.Split(c.Input().Get("sub"), ",") {
+		subject = strings.TrimSpace(subject)
+		if subject != "" {
+			subjects = append(subjects, subject)
+		}
+	}
+
+	tokenMetadata, err := object.GetTokenMetadata(c.Ctx.Request.Host, subjects)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Ctx.Output.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", object.TokenMetadataMaxCacheSeconds))
+	c.Data["json"] = tokenMetadata
+	c.ServeJSON()
+}
@@ -0,0 +1,192 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetTranslations
+// @Title GetTranslations
+// @Tag Translation API
+// @Description get the translations overridden or added by the organization
+// @Param   owner     query    string  true        "The owner of translations"
+// @Success 200 {array} object.Translation The Response object
+// @router /get-translations [get]
+func (c *ApiController) GetTranslations() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		translations, err := object.GetTranslations(owner)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(translations)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetTranslationCount(owner, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		translations, err := object.GetPaginationTranslations(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(translations, paginator.Nums())
+	}
+}
+
+// GetTranslation
+// @Title GetTranslation
+// @Tag Translation API
+// @Description get translation
+// @Param   id     query    string  true        "The id ( owner/name ) of the translation"
+// @Success 200 {object} object.Translation The Response object
+// @router /get-translation [get]
+func (c *ApiController) GetTranslation() {
+	id := c.Input().Get("id")
+
+	translation, err := object.GetTranslation(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(translation)
+}
+
+// UpdateTranslation
+// @Title UpdateTranslation
+// @Tag Translation API
+// @Description update translation
+// @Param   id     query    string  true        "The id ( owner/name ) of the translation"
+// @Param   body    body   object.Translation  true        "The details of the translation"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-translation [post]
+func (c *ApiController) UpdateTranslation() {
+	id := c.Input().Get("id")
+
+	var translation object.Translation
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &translation)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateTranslation(id, &translation))
+	c.ServeJSON()
+}
+
+// AddTranslation
+// @Title AddTranslation
+// @Tag Translation API
+// @Description add a translation overriding the one of the locale files, or of a new key
+// @Param   body    body   object.Translation  true        "The details of the translation"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-translation [post]
+func (c *ApiController) AddTranslation() {
+	var translation object.Translation
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &translation)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddTranslation(&translation))
+	c.ServeJSON()
+}
+
+// DeleteTranslation
+// @Title DeleteTranslation
+// @Tag Translation API
+// @Description delete translation, the key falls back to the locale files
+// @Param   body    body   object.Translation  true        "The details of the translation"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-translation [post]
+func (c *ApiController) DeleteTranslation() {
+	var translation object.Translation
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &translation)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteTranslation(&translation))
+	c.ServeJSON()
+}
+
+// ExportTranslations
+// @Title ExportTranslations
+// @Tag Translation API
+// @Description export the translations of the organization, which are also loaded by the web UI
+// @Param   owner     query    string  true        "The owner of translations"
+// @Param   category     query    string  true        "The category of translations, backend or frontend"
+// @Param   language     query    string  true        "The language of translations"
+// @Success 200 {object} object.TranslationPack The Response object
+// @router /export-translations [get]
+func (c *ApiController) ExportTranslations() {
+	owner := c.Input().Get("owner")
+	category := c.Input().Get("category")
+	language := c.Input().Get("language")
+
+	pack, err := object.ExportTranslations(owner, category, language)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(pack)
+}
+
+// ImportTranslations
+// @Title ImportTranslations
+// @Tag Translation API
+// @Description import the translation pack into the organization, the existing keys are overridden
+// @Param   body    body   object.TranslationPack  true        "The translation pack"
+// @Success 200 {object} controllers.Response The Response object
+// @router /import-translations [post]
+func (c *ApiController) ImportTranslations() {
+	var pack object.TranslationPack
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &pack)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	count, err := object.ImportTranslations(&pack)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(count)
+}
@@ -26,4 +26,8 @@ type TokenRequest struct {
 	Tag          string `json:"tag"`
 	Avatar       string `json:"avatar"`
 	RefreshToken string `json:"refresh_token"`
+
+	SubjectToken     string `json:"subject_token"`
+	SubjectTokenType string `json:"subject_token_type"`
+	Audience         string `json:"audience"`
 }
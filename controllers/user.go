@@ -28,6 +28,7 @@ import (
 // @Title GetGlobalUsers
 // @Tag User API
 // @Description get global users
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {array} object.User The Response object
 // @router /get-global-users [get]
 func (c *ApiController) GetGlobalUsers() {
@@ -41,33 +42,33 @@ func (c *ApiController) GetGlobalUsers() {
 	if limit == "" || page == "" {
 		maskedUsers, err := object.GetMaskedUsers(object.GetGlobalUsers())
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
-		c.ResponseOk(maskedUsers)
+		c.ResponseOkWithFields(maskedUsers)
 	} else {
 		limit := util.ParseInt(limit)
 		count, err := object.GetGlobalUserCount(field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		users, err := object.GetPaginationGlobalUsers(paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		users, err = object.GetMaskedUsers(users)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
-		c.ResponseOk(users, paginator.Nums())
+		c.ResponseOkWithFields(users, paginator.Nums())
 	}
 }
 
@@ -76,6 +77,7 @@ func (c *ApiController) GetGlobalUsers() {
 // @Tag User API
 // @Description
 // @Param   owner     query    string  true        "The owner of users"
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {array} object.User The Response object
 // @router /get-users [get]
 func (c *ApiController) GetUsers() {
@@ -92,42 +94,42 @@ func (c *ApiController) GetUsers() {
 		if groupName != "" {
 			maskedUsers, err := object.GetMaskedUsers(object.GetGroupUsers(util.GetId(owner, groupName)))
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
-			c.ResponseOk(maskedUsers)
+			c.ResponseOkWithFields(maskedUsers)
 			return
 		}
 
 		maskedUsers, err := object.GetMaskedUsers(object.GetUsers(owner))
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
-		c.ResponseOk(maskedUsers)
+		c.ResponseOkWithFields(maskedUsers)
 	} else {
 		limit := util.ParseInt(limit)
 		count, err := object.GetUserCount(owner, field, value, groupName)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		paginator := pagination.SetPaginator(c.Ctx, limit, count)
 		users, err := object.GetPaginationUsers(owner, paginator.Offset(), limit, field, value, sortField, sortOrder, groupName)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
 		users, err = object.GetMaskedUsers(users)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
-		c.ResponseOk(users, paginator.Nums())
+		c.ResponseOkWithFields(users, paginator.Nums())
 	}
 }
 
@@ -140,6 +142,7 @@ func (c *ApiController) GetUsers() {
 // @Param   email  query    string  false 	     "The email of the user"
 // @Param   phone  query    string  false 	     "The phone of the user"
 // @Param   userId query    string  false 	     "The userId of the user"
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {object} object.User The Response object
 // @router /get-user [get]
 func (c *ApiController) GetUser() {
@@ -153,7 +156,7 @@ func (c *ApiController) GetUser() {
 	if userId != "" && owner != "" {
 		userFromUserId, err = object.GetUserByUserId(owner, userId)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -178,7 +181,7 @@ func (c *ApiController) GetUser() {
 		var organization *object.Organization
 		organization, err = object.GetOrganization(util.GetId("admin", owner))
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 		if organization == nil {
@@ -190,7 +193,7 @@ func (c *ApiController) GetUser() {
 			requestUserId := c.GetSessionUsername()
 			hasPermission, err := object.CheckUserPermission(requestUserId, id, false, c.GetAcceptLanguage())
 			if !hasPermission {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 		}
@@ -208,7 +211,7 @@ func (c *ApiController) GetUser() {
 	}
 
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -218,18 +221,18 @@ func (c *ApiController) GetUser() {
 
 	err = object.ExtendUserWithRolesAndPermissions(user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	isAdminOrSelf := c.IsAdminOrSelf(user)
 	maskedUser, err := object.GetMaskedUser(user, isAdminOrSelf)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
-	c.ResponseOk(maskedUser)
+	c.ResponseOkWithFields(maskedUser)
 }
 
 // UpdateUser
@@ -247,7 +250,7 @@ func (c *ApiController) UpdateUser() {
 	var user object.User
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -260,7 +263,7 @@ func (c *ApiController) UpdateUser() {
 	}
 	oldUser, err := object.GetUser(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -281,12 +284,12 @@ func (c *ApiController) UpdateUser() {
 		}
 	}
 
-	if msg := object.CheckUpdateUser(oldUser, &user, c.GetAcceptLanguage()); msg != "" {
+	isAdmin := c.IsAdmin()
+	if msg := object.CheckUpdateUser(oldUser, &user, isAdmin, c.GetAcceptLanguage()); msg != "" {
 		c.ResponseError(msg)
 		return
 	}
 
-	isAdmin := c.IsAdmin()
 	if pass, err := object.CheckPermissionForUpdateUser(oldUser, &user, isAdmin, c.GetAcceptLanguage()); !pass {
 		c.ResponseError(err)
 		return
@@ -299,14 +302,95 @@ func (c *ApiController) UpdateUser() {
 
 	affected, err := object.UpdateUser(id, &user, columns, isAdmin)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	if affected {
 		err = object.UpdateUserToOriginalDatabase(&user)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
+			return
+		}
+	}
+
+	c.Data["json"] = wrapActionResponse(affected)
+	c.ServeJSON()
+}
+
+// PatchUser
+// @Title PatchUser
+// @Tag User API
+// @Description update the fields of the user with a JSON Patch document (RFC 6902), the other fields are not overwritten
+// @Param   id     query    string  true        "The id ( owner/name ) of the user"
+// @Param   body    body   []util.JsonPatchOperation  true        "The JSON Patch operations"
+// @Success 200 {object} controllers.Response The Response object
+// @router /patch-user [post]
+func (c *ApiController) PatchUser() {
+	id := c.Input().Get("id")
+	if id == "" {
+		id = c.GetSessionUsername()
+		if id == "" {
+			c.ResponseError(c.T("general:Missing parameter"))
+			return
+		}
+	}
+
+	var operations []*util.JsonPatchOperation
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &operations)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	oldUser, err := object.GetUser(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if oldUser == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), id))
+		return
+	}
+
+	isAdmin := c.IsAdmin()
+	user, columns, err := object.GetPatchedUser(oldUser, operations, isAdmin, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if oldUser.Owner == "built-in" && oldUser.Name == "admin" && (user.Owner != "built-in" || user.Name != "admin") {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	if user.DisplayName == "" {
+		c.ResponseError(c.T("user:Display name cannot be empty"))
+		return
+	}
+
+	if msg := object.CheckUpdateUser(oldUser, user, isAdmin, c.GetAcceptLanguage()); msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	if pass, err := object.CheckPermissionForUpdateUser(oldUser, user, isAdmin, c.GetAcceptLanguage()); !pass {
+		c.ResponseError(err)
+		return
+	}
+
+	affected, err := object.UpdateUser(id, user, columns, isAdmin)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if affected {
+		err = object.UpdateUserToOriginalDatabase(user)
+		if err != nil {
+			c.ResponseErr(err)
 			return
 		}
 	}
@@ -326,22 +410,28 @@ func (c *ApiController) AddUser() {
 	var user object.User
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	count, err := object.GetUserCount("", "", "", "")
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	if err := checkQuotaForUser(int(count)); err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
+		return
+	}
+
+	organization, err := object.GetOrganization(util.GetId("admin", user.Owner))
+	if err != nil {
+		c.ResponseErr(err)
 		return
 	}
 
-	msg := object.CheckUsername(user.Name, c.GetAcceptLanguage())
+	msg := object.CheckUsernameByOrg(organization, user.Name, true, c.GetAcceptLanguage())
 	if msg != "" {
 		c.ResponseError(msg)
 		return
@@ -362,7 +452,7 @@ func (c *ApiController) DeleteUser() {
 	var user object.User
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -375,6 +465,73 @@ func (c *ApiController) DeleteUser() {
 	c.ServeJSON()
 }
 
+// SuspendUser
+// @Title SuspendUser
+// @Tag User API
+// @Description suspend the user, which blocks the authentication and revokes the sessions and tokens of the user until it is reactivated
+// @Param   body    body   object.User  true        "The owner, name, suspendedReason and optional reactivationTime (RFC3339) of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /suspend-user [post]
+func (c *ApiController) SuspendUser() {
+	var user object.User
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &user)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if user.Owner == "built-in" && user.Name == "admin" {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.SuspendUser(user.GetId(), user.SuspendedReason, user.ReactivationTime))
+	c.ServeJSON()
+}
+
+// ReactivateUser
+// @Title ReactivateUser
+// @Tag User API
+// @Description end the suspension of the user
+// @Param   body    body   object.User  true        "The owner and name of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /reactivate-user [post]
+func (c *ApiController) ReactivateUser() {
+	var user object.User
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &user)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.ReactivateUser(user.GetId()))
+	c.ServeJSON()
+}
+
+// SetUserLifecycleState
+// @Title SetUserLifecycleState
+// @Tag User API
+// @Description move the user to the lifecycle state allowed by the lifecycle policy of the organization and run the actions of the state
+// @Param   body    body   object.User  true        "The owner, name, lifecycleState and optional lifecycleReason of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /set-user-lifecycle-state [post]
+func (c *ApiController) SetUserLifecycleState() {
+	var user object.User
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &user)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if user.Owner == "built-in" && user.Name == "admin" {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.SetUserLifecycleState(user.GetId(), user.LifecycleState, user.LifecycleReason))
+	c.ServeJSON()
+}
+
 // GetEmailAndPhone
 // @Title GetEmailAndPhone
 // @Tag User API
@@ -389,7 +546,7 @@ func (c *ApiController) GetEmailAndPhone() {
 
 	user, err := object.GetUserByFields(organization, username)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -424,6 +581,9 @@ func (c *ApiController) GetEmailAndPhone() {
 // @Param   userName   formData    string  true        "The name of the user"
 // @Param   oldPassword   formData    string  true        "The old password of the user"
 // @Param   newPassword   formData    string  true        "The new password of the user"
+// @Param   code   formData    string  false        "The verification token from /api/verify-code, instead of signing in"
+// @Param   recoveryId   formData    string  false        "The id of the verified account recovery, instead of signing in"
+// @Param   activationCode   formData    string  false        "The code of the activation link of the pre-registered user, instead of signing in"
 // @Success 200 {object} controllers.Response The Response object
 // @router /set-password [post]
 func (c *ApiController) SetPassword() {
@@ -432,6 +592,8 @@ func (c *ApiController) SetPassword() {
 	oldPassword := c.Ctx.Request.Form.Get("oldPassword")
 	newPassword := c.Ctx.Request.Form.Get("newPassword")
 	code := c.Ctx.Request.Form.Get("code")
+	recoveryId := c.Ctx.Request.Form.Get("recoveryId")
+	activationCode := c.Ctx.Request.Form.Get("activationCode")
 
 	//if userOwner == "built-in" && userName == "admin" {
 	//	c.ResponseError(c.T("auth:Unauthorized operation"))
@@ -446,21 +608,25 @@ func (c *ApiController) SetPassword() {
 	userId := util.GetId(userOwner, userName)
 
 	requestUserId := c.GetSessionUsername()
-	if requestUserId == "" && code == "" {
+	var verificationRecord *object.VerificationRecord
+	if requestUserId == "" && code == "" && recoveryId == "" && activationCode == "" {
 		c.ResponseError(c.T("general:Please login first"), "Please login first")
 		return
+	} else if recoveryId != "" || activationCode != "" {
+		// the recovery or activation is used up after the new password is checked
 	} else if code == "" {
 		hasPermission, err := object.CheckUserPermission(requestUserId, userId, true, c.GetAcceptLanguage())
 		if !hasPermission {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	} else {
-		if code != c.GetSession("verifiedCode") {
-			c.ResponseError(c.T("general:Missing parameter"))
+		var err error
+		verificationRecord, err = object.ConsumeVerificationToken(c.getVerificationScope("", ForgetVerification), code, c.GetAcceptLanguage())
+		if err != nil {
+			c.ResponseErr(err)
 			return
 		}
-		c.SetSession("verifiedCode", "")
 	}
 
 	targetUser, err := object.GetUser(userId)
@@ -469,23 +635,42 @@ func (c *ApiController) SetPassword() {
 		return
 	}
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
+	// the token only proves the ownership of the Email or phone of the user it is verified for
+	if verificationRecord != nil && !targetUser.IsVerificationDest(verificationRecord.Receiver) {
+		c.ResponseError(c.T("verification:The verification token doesn't belong to the user"))
+		return
+	}
+
+	// the organizations with the recovery policy only reset the forgotten passwords by the account recovery
+	if verificationRecord != nil {
+		organization, err := object.GetOrganizationByUser(targetUser)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+		if object.GetRecoveryPolicy(organization) != nil {
+			c.ResponseError(c.T("verification:The organization requires the account recovery to reset the password"))
+			return
+		}
+	}
+
 	isAdmin := c.IsAdmin()
 	if isAdmin {
 		if oldPassword != "" {
 			err = object.CheckPassword(targetUser, oldPassword, c.GetAcceptLanguage())
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 		}
-	} else if code == "" {
+	} else if code == "" && recoveryId == "" && activationCode == "" {
 		err = object.CheckPassword(targetUser, oldPassword, c.GetAcceptLanguage())
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	}
@@ -496,10 +681,26 @@ func (c *ApiController) SetPassword() {
 		return
 	}
 
+	if recoveryId != "" {
+		err = object.CompleteAccountRecovery(recoveryId, targetUser, c.GetAcceptLanguage())
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+	}
+
+	if activationCode != "" {
+		err = object.CompleteUserActivation(targetUser, activationCode, c.GetAcceptLanguage())
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+	}
+
 	targetUser.Password = newPassword
 	_, err = object.SetUserField(targetUser, "password", targetUser.Password)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -514,13 +715,13 @@ func (c *ApiController) CheckUserPassword() {
 	var user object.User
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	_, err = object.CheckUserPassword(user.Owner, user.Name, user.Password, c.GetAcceptLanguage())
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 	} else {
 		c.ResponseOk()
 	}
@@ -533,6 +734,7 @@ func (c *ApiController) CheckUserPassword() {
 // @Param   owner     query    string  true        "The owner of users"
 // @Param   sorter     query    string  true        "The DB column name to sort by, e.g., created_time"
 // @Param   limit     query    string  true        "The count of users to return, e.g., 25"
+// @Param   fields    query    string  false        "The comma separated fields of the response, like name,displayName"
 // @Success 200 {array} object.User The Response object
 // @router /get-sorted-users [get]
 func (c *ApiController) GetSortedUsers() {
@@ -542,11 +744,11 @@ func (c *ApiController) GetSortedUsers() {
 
 	maskedUsers, err := object.GetMaskedUsers(object.GetSortedUsers(owner, sorter, limit))
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
-	c.ResponseOk(maskedUsers)
+	c.ResponseOkWithFields(maskedUsers)
 }
 
 // GetUserCount
@@ -569,7 +771,7 @@ func (c *ApiController) GetUserCount() {
 		count, err = object.GetOnlineUserCount(owner, util.ParseInt(isOnline))
 	}
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -584,14 +786,14 @@ func (c *ApiController) AddUserKeys() {
 	var user object.User
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	isAdmin := c.IsAdmin()
 	affected, err := object.AddUserKeys(&user, isAdmin)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -616,9 +818,116 @@ func (c *ApiController) RemoveUserFromGroup() {
 
 	affected, err := object.DeleteGroupForUser(util.GetId(owner, name), groupName)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	c.ResponseOk(affected)
 }
+
+// GetUserAccess
+// @Title GetUserAccess
+// @Tag User API
+// @Description get the roles (direct and inherited via groups and roles), permissions, enforcers and the effective allowed actions per resource type of the user for the access reviews, the permissions are paginated
+// @Param   id        query    string  true        "The id ( owner/name ) of the user"
+// @Param   pageSize  query    string  false       "The size of the page of the permissions"
+// @Param   p         query    string  false       "The page of the permissions"
+// @Success 200 {object} object.UserAccess The Response object
+// @router /get-user-access [get]
+func (c *ApiController) GetUserAccess() {
+	id := c.Input().Get("id")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+
+	owner, ok := c.RequireAdmin()
+	if !ok {
+		return
+	}
+
+	user, err := object.GetUser(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if user == nil || (owner != "" && user.Owner != owner) {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), id))
+		return
+	}
+
+	offset, pageSize := 0, 0
+	if limit != "" && page != "" {
+		pageSize = util.ParseInt(limit)
+		offset = (util.ParseInt(page) - 1) * pageSize
+		if offset < 0 {
+			offset = 0
+		}
+	}
+
+	access, err := object.GetUserAccess(user, offset, pageSize)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(access, access.PermissionsCount)
+}
+
+// GetUserTimeline
+// @Title GetUserTimeline
+// @Tag User API
+// @Description get the activity timeline of the user merging the logins, profile changes, MFA events, token grants and permission changes, newest first. The events other than the token grants come from the records and are empty if Casvisor is not configured
+// @Param   id        query    string  true        "The id ( owner/name ) of the user"
+// @Param   types     query    string  false       "The comma-separated event types: Login, Profile change, MFA, Token grant and Permission change"
+// @Param   pageSize  query    string  false       "The size of each page"
+// @Param   p         query    string  false       "The number of the page"
+// @Success 200 {array} object.TimelineEvent The Response object
+// @router /get-user-timeline [get]
+func (c *ApiController) GetUserTimeline() {
+	id := c.Input().Get("id")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+
+	owner, ok := c.RequireAdmin()
+	if !ok {
+		return
+	}
+
+	user, err := object.GetUser(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if user == nil || (owner != "" && user.Owner != owner) {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), id))
+		return
+	}
+
+	types := []string{}
+	if c.Input().Get("types") != "" {
+		types = strings.Split(c.Input().Get("types"), ",")
+	}
+
+	events, err := object.GetUserTimeline(user, types)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if limit == "" || page == "" {
+		c.ResponseOk(events)
+		return
+	}
+
+	limitInt := util.ParseInt(limit)
+	paginator := pagination.SetPaginator(c.Ctx, limitInt, int64(len(events)))
+	start := paginator.Offset()
+	end := start + limitInt
+	if start > len(events) {
+		start = len(events)
+	}
+	if end > len(events) {
+		end = len(events)
+	}
+
+	c.ResponseOk(events[start:end], paginator.Nums())
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// PreRegisterUser
+// @Title PreRegisterUser
+// @Tag User API
+// @Description add a user without a password and send it the activation link by Email to set its own password, the user can't sign in until it is activated and is forbidden if the link expires
+// @Param   application     query    string  true        "The name of the application of the activation link"
+// @Param   expireInDays    query    int     false       "The days before the activation link expires, 7 by default"
+// @Param   requireMfa      query    bool    false       "Whether the user has to enroll MFA on the first login"
+// @Param   body    body   object.User  true        "The details of the user"
+// @Success 200 {object} object.UserActivation The Response object
+// @router /pre-register-user [post]
+func (c *ApiController) PreRegisterUser() {
+	var user object.User
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &user)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	count, err := object.GetUserCount("", "", "", "")
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if err := checkQuotaForUser(int(count)); err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	organization, err := object.GetOrganization(util.GetId("admin", user.Owner))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	msg := object.CheckUsernameByOrg(organization, user.Name, true, c.GetAcceptLanguage())
+	if msg != "" {
+		c.ResponseError(msg)
+		return
+	}
+
+	applicationName := c.Input().Get("application")
+	application, err := object.GetApplication(util.GetId("admin", applicationName))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), applicationName))
+		return
+	}
+
+	expireInDays := util.ParseInt(c.Input().Get("expireInDays"))
+	requireMfa := c.Input().Get("requireMfa") == "true"
+	userActivation, err := object.PreRegisterUser(&user, application, c.GetSessionUsername(), expireInDays, requireMfa, c.Ctx.Request.Host, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(userActivation)
+}
+
+// GetUserActivations
+// @Title GetUserActivations
+// @Tag User API
+// @Description get the activations of the pre-registered users
+// @Param   owner     query    string  true        "The organization of the users"
+// @Success 200 {array} object.UserActivation The Response object
+// @router /get-user-activations [get]
+func (c *ApiController) GetUserActivations() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" {
+		limit = "100"
+	}
+	if page == "" {
+		page = "1"
+	}
+	if sortField == "" {
+		sortField, sortOrder = "created_time", "descend"
+	}
+
+	count, err := object.GetUserActivationCount(owner, field, value)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	paginator := pagination.SetPaginator(c.Ctx, util.ParseInt(limit), count)
+	userActivations, err := object.GetPaginationUserActivations(owner, paginator.Offset(), util.ParseInt(limit), field, value, sortField, sortOrder)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(userActivations, paginator.Nums())
+}
+
+// ResendUserActivation
+// @Title ResendUserActivation
+// @Tag User API
+// @Description send a new activation link to the pre-registered user that hasn't been activated, the expired user is allowed again
+// @Param   id     query    string  true        "The id ( owner/name ) of the user"
+// @Success 200 {object} controllers.Response The Response object
+// @router /resend-user-activation [post]
+func (c *ApiController) ResendUserActivation() {
+	id := c.Input().Get("id")
+	userActivation, err := object.GetUserActivation(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if userActivation == nil {
+		c.ResponseError(fmt.Sprintf(c.T("user:The activation of the user: %s does not exist"), id))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.ResendUserActivation(userActivation, c.Ctx.Request.Host, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
@@ -0,0 +1,172 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/casdoor/casdoor/object"
+)
+
+// getContactUser returns the user whose contacts are managed, the admins can manage the contacts of the users in their organizations
+func (c *ApiController) getContactUser() (*object.User, bool) {
+	userId := c.Input().Get("userId")
+	if userId == "" {
+		return c.RequireSignedInUser()
+	}
+
+	user, err := object.GetUser(userId)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+	if user == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), userId))
+		return nil, false
+	}
+
+	if !c.IsOrgAdminOrSelf(user) {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (c *ApiController) getUserContactFromContext() (*object.UserContact, bool) {
+	id := c.Input().Get("id")
+	contact, err := object.GetUserContact(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+	if contact == nil {
+		c.ResponseError(fmt.Sprintf(c.T("user:The contact: %s does not exist"), id))
+		return nil, false
+	}
+
+	if !c.IsOrgAdminOrSelf(&object.User{Owner: contact.Owner, Name: contact.User}) {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return nil, false
+	}
+
+	return contact, true
+}
+
+// GetUserContacts
+// @Title GetUserContacts
+// @Tag User API
+// @Description get the additional emails and phones of the current user or the specified user
+// @Param   userId     query    string  false        "The id ( owner/name ) of the user, only for the admins of its organization"
+// @Success 200 {array} object.UserContact The Response object
+// @router /get-user-contacts [get]
+func (c *ApiController) GetUserContacts() {
+	user, ok := c.getContactUser()
+	if !ok {
+		return
+	}
+
+	contacts, err := object.GetUserContacts(user.Owner, user.Name)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(contacts)
+}
+
+// AddUserContact
+// @Title AddUserContact
+// @Tag User API
+// @Description add an additional email or phone, it should be verified by /api/verify-user-contact before being used for login
+// @Param   userId     query    string                 false       "The id ( owner/name ) of the user, only for the admins of its organization"
+// @Param   body       body     object.UserContact     true        "The type ( email or phone ), value and country code of the contact"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-user-contact [post]
+func (c *ApiController) AddUserContact() {
+	user, ok := c.getContactUser()
+	if !ok {
+		return
+	}
+
+	var contact object.UserContact
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &contact)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddUserContact(user, &contact, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// VerifyUserContact
+// @Title VerifyUserContact
+// @Tag User API
+// @Description verify an additional email or phone with the code sent by /api/send-verification-code with the method "contact" in the same session
+// @Param   id       query    string  true        "The id ( owner/name ) of the contact"
+// @Param   code     query    string  true        "The verification code"
+// @Success 200 {object} controllers.Response The Response object
+// @router /verify-user-contact [post]
+func (c *ApiController) VerifyUserContact() {
+	contact, ok := c.getUserContactFromContext()
+	if !ok {
+		return
+	}
+
+	code := c.Input().Get("code")
+	if code == "" {
+		c.ResponseError(c.T("general:Missing parameter"))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.VerifyUserContact(contact, c.Ctx.Input.CruSession.SessionID(), code, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// SetPrimaryUserContact
+// @Title SetPrimaryUserContact
+// @Tag User API
+// @Description make a verified additional email or phone the primary one of the user
+// @Param   id     query    string  true        "The id ( owner/name ) of the contact"
+// @Success 200 {object} controllers.Response The Response object
+// @router /set-primary-user-contact [post]
+func (c *ApiController) SetPrimaryUserContact() {
+	contact, ok := c.getUserContactFromContext()
+	if !ok {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.SetPrimaryUserContact(contact, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// DeleteUserContact
+// @Title DeleteUserContact
+// @Tag User API
+// @Description delete an additional email or phone
+// @Param   id     query    string  true        "The id ( owner/name ) of the contact"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-user-contact [post]
+func (c *ApiController) DeleteUserContact() {
+	contact, ok := c.getUserContactFromContext()
+	if !ok {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteUserContact(contact))
+	c.ServeJSON()
+}
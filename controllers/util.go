@@ -15,7 +15,9 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/casdoor/casdoor/conf"
@@ -43,14 +45,56 @@ func (c *ApiController) ResponseOk(data ...interface{}) {
 	c.ResponseJsonData(resp, data...)
 }
 
+// ResponseOkWithFields responds the data with only the fields of the "fields" query, so the list calls don't
+// serialize the whole objects
+func (c *ApiController) ResponseOkWithFields(data ...interface{}) {
+	if len(data) != 0 {
+		fields := util.ParseJsonFields(c.Input().Get("fields"))
+		res, err := util.SelectJsonFields(data[0], fields)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+		data[0] = res
+	}
+
+	c.ResponseOk(data...)
+}
+
 // ResponseError ...
 func (c *ApiController) ResponseError(error string, data ...interface{}) {
-	resp := &Response{Status: "error", Msg: error}
+	resp := &Response{Status: "error", Msg: error, Code: object.GetErrorCodeByMsg(c.GetAcceptLanguage(), error)}
+	c.ResponseJsonData(resp, data...)
+}
+
+// ResponseErr responds the error with the code of the error catalog if it's a CodedError
+func (c *ApiController) ResponseErr(err error, data ...interface{}) {
+	resp := &Response{Status: "error", Msg: err.Error(), Code: object.GetErrorCode(c.GetAcceptLanguage(), err)}
 	c.ResponseJsonData(resp, data...)
 }
 
+// ResponseVerificationCodeError responds the error of sending or checking a verification code, with the remaining
+// attempts and the seconds to retry after as the data if the error has them
+func (c *ApiController) ResponseVerificationCodeError(err error) {
+	var codeErr *object.VerificationCodeError
+	if !errors.As(err, &codeErr) {
+		c.ResponseErr(err)
+		return
+	}
+
+	if codeErr.RetryAfter > 0 {
+		c.Ctx.Output.Header("Retry-After", strconv.FormatInt(codeErr.RetryAfter, 10))
+	}
+	c.ResponseErr(codeErr, codeErr)
+}
+
+// T translates the text with the translations of the signed-in user's organization if there are any
 func (c *ApiController) T(error string) string {
-	return i18n.Translate(c.GetAcceptLanguage(), error)
+	organization := ""
+	if username, ok := c.GetSession("username").(string); ok && strings.Contains(username, "/") {
+		organization, _ = util.GetOwnerAndNameFromIdNoCheck(username)
+	}
+	return i18n.TranslateByOrg(organization, c.GetAcceptLanguage(), error)
 }
 
 // GetAcceptLanguage ...
@@ -105,7 +149,7 @@ func (c *ApiController) RequireSignedInUser() (*object.User, bool) {
 
 	user, err := object.GetUser(userId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return nil, false
 	}
 
@@ -215,6 +259,44 @@ func (c *ApiController) GetProviderFromContext(category string) (*object.Provide
 	return provider, nil
 }
 
+// getScanProviderFromContext returns the optional "Scan" provider of the application receiving the upload, or of the
+// application of the uploading user if no application is given. It doesn't depend on how the request is authenticated,
+// and an application or user that can't be resolved is an error instead of skipping the scan.
+func (c *ApiController) getScanProviderFromContext(applicationName string, userId string) (*object.Provider, error) {
+	var application *object.Application
+	if applicationName != "" {
+		var err error
+		application, err = object.GetApplication(util.GetId("admin", applicationName))
+		if err != nil {
+			return nil, err
+		}
+
+		if application == nil {
+			return nil, fmt.Errorf(c.T("auth:The application: %s does not exist"), applicationName)
+		}
+	} else {
+		user, err := object.GetUser(userId)
+		if err != nil {
+			return nil, err
+		}
+
+		if user == nil {
+			return nil, fmt.Errorf(c.T("general:The user: %s doesn't exist"), userId)
+		}
+
+		application, err = object.GetApplicationByUser(user)
+		if err != nil {
+			return nil, err
+		}
+
+		if application == nil {
+			return nil, fmt.Errorf(c.T("util:No application is found for userId: %s"), userId)
+		}
+	}
+
+	return application.GetProviderByCategory("Scan")
+}
+
 func checkQuotaForApplication(count int) error {
 	quota := conf.GetConfigQuota().Application
 	if quota == -1 {
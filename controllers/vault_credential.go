@@ -0,0 +1,165 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+func (c *ApiController) getVaultCredentialFromContext() (*object.VaultCredential, bool) {
+	id := c.Input().Get("id")
+	credential, err := object.GetVaultCredential(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return nil, false
+	}
+	if credential == nil {
+		c.ResponseError(fmt.Sprintf(c.T("user:The vault credential: %s does not exist"), id))
+		return nil, false
+	}
+
+	if !c.IsOrgAdminOrSelf(&object.User{Owner: credential.Owner, Name: credential.User}) {
+		c.ResponseError(c.T("auth:Unauthorized operation"))
+		return nil, false
+	}
+
+	return credential, true
+}
+
+// GetVaultCredentials
+// @Title GetVaultCredentials
+// @Tag User API
+// @Description get the vault credentials of the legacy applications of the current user or the specified user, the passwords are masked
+// @Param   userId     query    string  false        "The id ( owner/name ) of the user, only for the admins of its organization"
+// @Success 200 {array} object.VaultCredential The Response object
+// @router /get-vault-credentials [get]
+func (c *ApiController) GetVaultCredentials() {
+	user, ok := c.getContactUser()
+	if !ok {
+		return
+	}
+
+	credentials, err := object.GetVaultCredentials(user.Owner, user.Name)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(credentials)
+}
+
+// AddVaultCredential
+// @Title AddVaultCredential
+// @Tag User API
+// @Description add the credential of a legacy application to the vault of the user
+// @Param   userId     query    string                     false       "The id ( owner/name ) of the user, only for the admins of its organization"
+// @Param   body       body     object.VaultCredential     true        "The application, the username and the password"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-vault-credential [post]
+func (c *ApiController) AddVaultCredential() {
+	user, ok := c.getContactUser()
+	if !ok {
+		return
+	}
+
+	var credential object.VaultCredential
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &credential)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddVaultCredential(user, &credential, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// UpdateVaultCredential
+// @Title UpdateVaultCredential
+// @Tag User API
+// @Description update the vault credential, the password is kept if it is empty
+// @Param   id     query    string                     true        "The id ( owner/name ) of the vault credential"
+// @Param   body   body     object.VaultCredential     true        "The application, the username and the password"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-vault-credential [post]
+func (c *ApiController) UpdateVaultCredential() {
+	oldCredential, ok := c.getVaultCredentialFromContext()
+	if !ok {
+		return
+	}
+
+	var credential object.VaultCredential
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &credential)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateVaultCredential(oldCredential, &credential, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// DeleteVaultCredential
+// @Title DeleteVaultCredential
+// @Tag User API
+// @Description delete the vault credential
+// @Param   id     query    string  true        "The id ( owner/name ) of the vault credential"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-vault-credential [post]
+func (c *ApiController) DeleteVaultCredential() {
+	credential, ok := c.getVaultCredentialFromContext()
+	if !ok {
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteVaultCredential(credential))
+	c.ServeJSON()
+}
+
+// RetrieveVaultCredential
+// @Title RetrieveVaultCredential
+// @Tag User API
+// @Description retrieve the credential of the signed-in user for the legacy application with the password to fill its login form, for the browser extension or the proxy after the SSO
+// @Param   application     query    string  true        "The name of the application"
+// @Success 200 {object} object.VaultCredential The Response object
+// @router /retrieve-vault-credential [post]
+func (c *ApiController) RetrieveVaultCredential() {
+	user, ok := c.RequireSignedInUser()
+	if !ok {
+		return
+	}
+
+	applicationName := c.Input().Get("application")
+	application, err := object.GetApplication(util.GetId("admin", applicationName))
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+	if application == nil {
+		c.ResponseError(fmt.Sprintf(c.T("auth:The application: %s does not exist"), applicationName))
+		return
+	}
+
+	credential, err := object.RetrieveVaultCredential(user, application, c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(credential)
+}
@@ -31,10 +31,20 @@ const (
 	ResetVerification    = "reset"
 	LoginVerification    = "login"
 	ForgetVerification   = "forget"
-	MfaSetupVerification = "mfaSetup"
-	MfaAuthVerification  = "mfaAuth"
+	MfaSetupVerification = object.MfaSetupVerification
+	MfaAuthVerification  = object.MfaAuthVerification
 )
 
+// getVerificationScope returns the scope binding the verification codes to the application, the method and the
+// current session
+func (c *ApiController) getVerificationScope(application string, method string) *object.VerificationScope {
+	return &object.VerificationScope{
+		Application: application,
+		Method:      method,
+		Session:     c.Ctx.Input.CruSession.SessionID(),
+	}
+}
+
 // SendVerificationCode ...
 // @Title SendVerificationCode
 // @Tag Verification API
@@ -43,7 +53,7 @@ func (c *ApiController) SendVerificationCode() {
 	var vform form.VerificationForm
 	err := c.ParseForm(&vform)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	remoteAddr := util.GetIPFromRequest(c.Ctx.Request)
@@ -55,7 +65,7 @@ func (c *ApiController) SendVerificationCode() {
 
 	provider, err := object.GetCaptchaProviderByApplication(vform.ApplicationId, "false", c.GetAcceptLanguage())
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -74,7 +84,7 @@ func (c *ApiController) SendVerificationCode() {
 				c.ResponseError(c.T("general:don't support captchaProvider: ") + vform.CaptchaType)
 				return
 			} else if isHuman, err := captchaProvider.VerifyCaptcha(vform.CaptchaToken, vform.ClientSecret); err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			} else if !isHuman {
 				c.ResponseError(c.T("verification:Turing test failed."))
@@ -85,7 +95,7 @@ func (c *ApiController) SendVerificationCode() {
 
 	application, err := object.GetApplication(vform.ApplicationId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -105,7 +115,7 @@ func (c *ApiController) SendVerificationCode() {
 		owner := application.Organization
 		user, err = object.GetUser(util.GetId(owner, vform.CheckUser))
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	}
@@ -114,12 +124,13 @@ func (c *ApiController) SendVerificationCode() {
 	if mfaUserSession := c.getMfaUserSession(); mfaUserSession != "" {
 		user, err = object.GetUser(mfaUserSession)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	}
 
 	sendResp := errors.New("invalid dest type")
+	scope := c.getVerificationScope(application.GetId(), vform.Method)
 
 	switch vform.Type {
 	case object.VerifyTypeEmail:
@@ -135,7 +146,7 @@ func (c *ApiController) SendVerificationCode() {
 
 			user, err = object.GetUserByEmail(organization.Name, vform.Dest)
 			if err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			}
 
@@ -154,9 +165,9 @@ func (c *ApiController) SendVerificationCode() {
 			c.SetSession(object.MfaDestSession, vform.Dest)
 		}
 
-		provider, err := application.GetEmailProvider()
+		provider, err := object.GetOrganizationEmailProvider(organization, application)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 		if provider == nil {
@@ -164,7 +175,7 @@ func (c *ApiController) SendVerificationCode() {
 			return
 		}
 
-		sendResp = object.SendVerificationCodeToEmail(organization, user, provider, remoteAddr, vform.Dest)
+		sendResp = object.SendVerificationCodeToEmail(organization, user, provider, remoteAddr, vform.Dest, scope)
 	case object.VerifyTypePhone:
 		if vform.Method == LoginVerification || vform.Method == ForgetVerification {
 			if user != nil && util.GetMaskedPhone(user.Phone) == vform.Dest {
@@ -172,7 +183,7 @@ func (c *ApiController) SendVerificationCode() {
 			}
 
 			if user, err = object.GetUserByPhone(organization.Name, vform.Dest); err != nil {
-				c.ResponseError(err.Error())
+				c.ResponseErr(err)
 				return
 			} else if user == nil {
 				c.ResponseError(c.T("verification:the user does not exist, please sign up first"))
@@ -202,7 +213,7 @@ func (c *ApiController) SendVerificationCode() {
 
 		provider, err := application.GetSmsProvider()
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 		if provider == nil {
@@ -214,12 +225,12 @@ func (c *ApiController) SendVerificationCode() {
 			c.ResponseError(fmt.Sprintf(c.T("verification:Phone number is invalid in your region %s"), vform.CountryCode))
 			return
 		} else {
-			sendResp = object.SendVerificationCodeToPhone(organization, user, provider, remoteAddr, phone)
+			sendResp = object.SendVerificationCodeToPhone(organization, user, provider, remoteAddr, phone, scope)
 		}
 	}
 
 	if sendResp != nil {
-		c.ResponseError(sendResp.Error())
+		c.ResponseVerificationCodeError(sendResp)
 	} else {
 		c.ResponseOk()
 	}
@@ -233,7 +244,7 @@ func (c *ApiController) VerifyCaptcha() {
 	var vform form.VerificationForm
 	err := c.ParseForm(&vform)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -244,7 +255,7 @@ func (c *ApiController) VerifyCaptcha() {
 
 	captchaProvider, err := object.GetCaptchaProviderByOwnerName(vform.ApplicationId, c.GetAcceptLanguage())
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -260,7 +271,7 @@ func (c *ApiController) VerifyCaptcha() {
 
 	isValid, err := provider.VerifyCaptcha(vform.CaptchaToken, vform.ClientSecret)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -331,8 +342,8 @@ func (c *ApiController) ResetEmailOrPhone() {
 		}
 	}
 
-	if result := object.CheckVerificationCode(checkDest, code, c.GetAcceptLanguage()); result.Code != object.VerificationSuccess {
-		c.ResponseError(result.Msg)
+	if result := object.CheckVerificationCode(c.getVerificationScope("", ResetVerification), checkDest, code, c.GetAcceptLanguage()); result.Code != object.VerificationSuccess {
+		c.ResponseVerificationCodeError(result.GetError())
 		return
 	}
 
@@ -348,13 +359,7 @@ func (c *ApiController) ResetEmailOrPhone() {
 		return
 	}
 	if err != nil {
-		c.ResponseError(err.Error())
-		return
-	}
-
-	err = object.DisableVerificationCode(checkDest)
-	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -364,12 +369,15 @@ func (c *ApiController) ResetEmailOrPhone() {
 // VerifyCode
 // @Tag Verification API
 // @Title VerifyCode
+// @Description verify the code sent for the "forget" method, the data is a single-use verification token to present as the code of /api/set-password in the same session
+// @Param   body    body   form.AuthForm  true        "The organization, application, username (Email or phone), name and code"
+// @Success 200 {object} controllers.Response The Response object
 // @router /api/verify-code [post]
 func (c *ApiController) VerifyCode() {
 	var authForm form.AuthForm
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &authForm)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -377,7 +385,7 @@ func (c *ApiController) VerifyCode() {
 	if authForm.Name != "" {
 		user, err = object.GetUserByFields(authForm.Organization, authForm.Name)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 	}
@@ -395,7 +403,7 @@ func (c *ApiController) VerifyCode() {
 	}
 
 	if user, err = object.GetUserByFields(authForm.Organization, authForm.Username); err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	} else if user == nil {
 		c.ResponseError(fmt.Sprintf(c.T("general:The user: %s doesn't exist"), util.GetId(authForm.Organization, authForm.Username)))
@@ -412,16 +420,18 @@ func (c *ApiController) VerifyCode() {
 		}
 	}
 
-	if result := object.CheckVerificationCode(checkDest, authForm.Code, c.GetAcceptLanguage()); result.Code != object.VerificationSuccess {
-		c.ResponseError(result.Msg)
+	scope := c.getVerificationScope(util.GetId("admin", authForm.Application), ForgetVerification)
+	result := object.CheckVerificationCode(scope, checkDest, authForm.Code, c.GetAcceptLanguage())
+	if result.Code != object.VerificationSuccess {
+		c.ResponseVerificationCodeError(result.GetError())
 		return
 	}
-	err = object.DisableVerificationCode(checkDest)
+
+	token, err := object.IssueVerificationToken(result)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
-	c.SetSession("verifiedCode", authForm.Code)
 
-	c.ResponseOk()
+	c.ResponseOk(token)
 }
@@ -0,0 +1,97 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+const watchHeartbeatInterval = 30 * time.Second
+
+// Watch
+// @Title Watch
+// @Tag Watch API
+// @Description stream the changes of policies, permissions and user groups as server-sent events, the stream ends with a "reset" event if the client can't keep up and should reload
+// @Param   types     query    string  false       "The comma-separated types to watch: policy, permission, user-group, all types if empty"
+// @Param   owner     query    string  false       "The organization of the changed objects"
+// @Param   enforcer  query    string  false       "The id ( owner/name ) of the enforcer whose policies are watched"
+// @Param   group     query    string  false       "The id ( owner/name ) of the group whose users are watched"
+// @Success 200 {string} string "The event stream"
+// @router /watch [get]
+func (c *ApiController) Watch() {
+	owner, ok := c.RequireAdmin()
+	if !ok {
+		return
+	}
+
+	filter := &object.WatchFilter{
+		Owner:    c.Input().Get("owner"),
+		Enforcer: c.Input().Get("enforcer"),
+		Group:    c.Input().Get("group"),
+	}
+	if owner != "" {
+		filter.Owner = owner
+	}
+
+	types := c.Input().Get("types")
+	if types != "" {
+		filter.Types = strings.Split(types, ",")
+	}
+
+	watcher := object.AddWatcher(filter)
+	defer watcher.Close()
+
+	writer := c.Ctx.ResponseWriter
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.Header().Set("Connection", "keep-alive")
+	writer.Header().Set("X-Accel-Buffering", "no")
+	writer.WriteHeader(200)
+	writer.Flush()
+
+	ticker := time.NewTicker(watchHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Ctx.Request.Context().Done():
+			return
+		case <-ticker.C:
+			_, err := fmt.Fprint(writer, ": heartbeat\n\n")
+			if err != nil {
+				return
+			}
+		case event, ok := <-watcher.Events:
+			if !ok {
+				if watcher.IsOverflowed {
+					fmt.Fprint(writer, "event: reset\ndata: {}\n\n")
+					writer.Flush()
+				}
+				return
+			}
+
+			_, err := fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, util.StructToJson(event))
+			if err != nil {
+				return
+			}
+		}
+		writer.Flush()
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const web3NonceSession = "web3Nonce"
+
+// GetWeb3Nonce
+// @Title GetWeb3Nonce
+// @Tag Login API
+// @Description get the nonce of the Sign-In with Ethereum message, which can be used only once in the session
+// @Success 200 {object} controllers.Response The Response object
+// @router /get-web3-nonce [get]
+func (c *ApiController) GetWeb3Nonce() {
+	// the nonce of EIP-4361 must be alphanumeric
+	nonce := strings.ReplaceAll(util.GenerateId(), "-", "")
+	c.SetSession(web3NonceSession, nonce)
+
+	c.ResponseOk(nonce)
+}
+
+// takeWeb3Nonce returns the nonce of the session and removes it, so that a signed message can't be replayed
+func (c *ApiController) takeWeb3Nonce() string {
+	nonce := c.GetSession(web3NonceSession)
+	if nonce == nil {
+		return ""
+	}
+
+	c.DelSession(web3NonceSession)
+	return nonce.(string)
+}
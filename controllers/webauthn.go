@@ -35,7 +35,7 @@ import (
 func (c *ApiController) WebAuthnSignupBegin() {
 	webauthnObj, err := object.GetWebAuthnObject(c.Ctx.Request.Host)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -53,7 +53,7 @@ func (c *ApiController) WebAuthnSignupBegin() {
 		registerOptions,
 	)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	c.SetSession("registration", *sessionData)
@@ -71,7 +71,7 @@ func (c *ApiController) WebAuthnSignupBegin() {
 func (c *ApiController) WebAuthnSignupFinish() {
 	webauthnObj, err := object.GetWebAuthnObject(c.Ctx.Request.Host)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -90,13 +90,13 @@ func (c *ApiController) WebAuthnSignupFinish() {
 
 	credential, err := webauthnObj.FinishRegistration(user, sessionData, c.Ctx.Request)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	isGlobalAdmin := c.IsGlobalAdmin()
 	_, err = user.AddCredentials(*credential, isGlobalAdmin)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -114,7 +114,7 @@ func (c *ApiController) WebAuthnSignupFinish() {
 func (c *ApiController) WebAuthnSigninBegin() {
 	webauthnObj, err := object.GetWebAuthnObject(c.Ctx.Request.Host)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -122,7 +122,7 @@ func (c *ApiController) WebAuthnSigninBegin() {
 	userName := c.Input().Get("name")
 	user, err := object.GetUserByFields(userOwner, userName)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -137,7 +137,7 @@ func (c *ApiController) WebAuthnSigninBegin() {
 
 	options, sessionData, err := webauthnObj.BeginLogin(user)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	c.SetSession("authentication", *sessionData)
@@ -157,7 +157,7 @@ func (c *ApiController) WebAuthnSigninFinish() {
 	clientId := c.Input().Get("clientId")
 	webauthnObj, err := object.GetWebAuthnObject(c.Ctx.Request.Host)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -171,13 +171,13 @@ func (c *ApiController) WebAuthnSigninFinish() {
 	userId := string(sessionData.UserID)
 	user, err := object.GetUser(userId)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	_, err = webauthnObj.FinishLogin(user, sessionData, c.Ctx.Request)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 	c.SetSessionUsername(userId)
@@ -191,7 +191,7 @@ func (c *ApiController) WebAuthnSigninFinish() {
 		application, err = object.GetApplicationByUser(user)
 	}
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
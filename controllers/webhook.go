@@ -16,6 +16,7 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/beego/beego/utils/pagination"
 	"github.com/casdoor/casdoor/object"
@@ -43,7 +44,7 @@ func (c *ApiController) GetWebhooks() {
 	if limit == "" || page == "" {
 		webhooks, err := object.GetWebhooks(owner, organization)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -52,7 +53,7 @@ func (c *ApiController) GetWebhooks() {
 		limit := util.ParseInt(limit)
 		count, err := object.GetWebhookCount(owner, organization, field, value)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -60,7 +61,7 @@ func (c *ApiController) GetWebhooks() {
 
 		webhooks, err := object.GetPaginationWebhooks(owner, organization, paginator.Offset(), limit, field, value, sortField, sortOrder)
 		if err != nil {
-			c.ResponseError(err.Error())
+			c.ResponseErr(err)
 			return
 		}
 
@@ -80,7 +81,7 @@ func (c *ApiController) GetWebhook() {
 
 	webhook, err := object.GetWebhook(id)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -101,7 +102,7 @@ func (c *ApiController) UpdateWebhook() {
 	var webhook object.Webhook
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &webhook)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -120,7 +121,7 @@ func (c *ApiController) AddWebhook() {
 	var webhook object.Webhook
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &webhook)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
@@ -139,10 +140,103 @@ func (c *ApiController) DeleteWebhook() {
 	var webhook object.Webhook
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &webhook)
 	if err != nil {
-		c.ResponseError(err.Error())
+		c.ResponseErr(err)
 		return
 	}
 
 	c.Data["json"] = wrapActionResponse(object.DeleteWebhook(&webhook))
 	c.ServeJSON()
 }
+
+// RotateWebhookSecret
+// @Title RotateWebhookSecret
+// @Tag Webhook API
+// @Description generate a new signing secret for the webhook, the previous one still signs the payloads for 24 hours
+// @Param   id     query    string  built-in/admin true        "The id ( owner/name ) of the webhook"
+// @Success 200 {object} object.Webhook The Response object
+// @router /rotate-webhook-secret [post]
+func (c *ApiController) RotateWebhookSecret() {
+	id := c.Input().Get("id")
+
+	webhook, err := object.RotateWebhookSecret(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if webhook == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The webhook: %s does not exist"), id))
+		return
+	}
+
+	c.ResponseOk(webhook)
+}
+
+// GetWebhookEvents
+// @Title GetWebhookEvents
+// @Tag Webhook API
+// @Description get the events of the webhook outbox
+// @Param   owner     query    string  true        "The owner of webhooks"
+// @Param   organization     query    string  true        "The organization of the events"
+// @Success 200 {array} object.WebhookEvent The Response object
+// @router /get-webhook-events [get]
+func (c *ApiController) GetWebhookEvents() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+	organization := c.Input().Get("organization")
+
+	if limit == "" {
+		limit = "100"
+	}
+	if page == "" {
+		page = "1"
+	}
+	if sortField == "" {
+		sortField, sortOrder = "created_time", "descend"
+	}
+
+	count, err := object.GetWebhookEventCount(owner, organization, field, value)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	paginator := pagination.SetPaginator(c.Ctx, util.ParseInt(limit), count)
+	webhookEvents, err := object.GetPaginationWebhookEvents(owner, organization, paginator.Offset(), util.ParseInt(limit), field, value, sortField, sortOrder)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(webhookEvents, paginator.Nums())
+}
+
+// RetryWebhookEvent
+// @Title RetryWebhookEvent
+// @Tag Webhook API
+// @Description deliver the failed webhook event again
+// @Param   id     query    string  true        "The id ( owner/name ) of the webhook event"
+// @Success 200 {object} controllers.Response The Response object
+// @router /retry-webhook-event [post]
+func (c *ApiController) RetryWebhookEvent() {
+	id := c.Input().Get("id")
+
+	webhookEvent, err := object.GetWebhookEvent(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	if webhookEvent == nil {
+		c.ResponseError(fmt.Sprintf(c.T("general:The webhook event: %s does not exist"), id))
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.RetryWebhookEvent(webhookEvent))
+	c.ServeJSON()
+}
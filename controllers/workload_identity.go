@@ -0,0 +1,214 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controllers
+
+import (
+	"encoding/json"
+
+	"github.com/beego/beego/utils/pagination"
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+)
+
+// GetWorkloadIdentities
+// @Title GetWorkloadIdentities
+// @Tag Workload Identity API
+// @Description get workload identities
+// @Param   owner     query    string  true        "The owner of workload identities"
+// @Success 200 {array} object.WorkloadIdentity The Response object
+// @router /get-workload-identities [get]
+func (c *ApiController) GetWorkloadIdentities() {
+	owner := c.Input().Get("owner")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" || page == "" {
+		workloadIdentities, err := object.GetWorkloadIdentities(owner)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(workloadIdentities)
+	} else {
+		limit := util.ParseInt(limit)
+		count, err := object.GetWorkloadIdentityCount(owner, field, value)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		paginator := pagination.SetPaginator(c.Ctx, limit, count)
+		workloadIdentities, err := object.GetPaginationWorkloadIdentities(owner, paginator.Offset(), limit, field, value, sortField, sortOrder)
+		if err != nil {
+			c.ResponseErr(err)
+			return
+		}
+
+		c.ResponseOk(workloadIdentities, paginator.Nums())
+	}
+}
+
+// GetWorkloadIdentity
+// @Title GetWorkloadIdentity
+// @Tag Workload Identity API
+// @Description get workload identity
+// @Param   id     query    string  true        "The id ( owner/name ) of the workload identity"
+// @Success 200 {object} object.WorkloadIdentity The Response object
+// @router /get-workload-identity [get]
+func (c *ApiController) GetWorkloadIdentity() {
+	id := c.Input().Get("id")
+
+	workloadIdentity, err := object.GetWorkloadIdentity(id)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(workloadIdentity)
+}
+
+// UpdateWorkloadIdentity
+// @Title UpdateWorkloadIdentity
+// @Tag Workload Identity API
+// @Description update workload identity
+// @Param   id     query    string  true        "The id ( owner/name ) of the workload identity"
+// @Param   body    body   object.WorkloadIdentity  true        "The details of the workload identity"
+// @Success 200 {object} controllers.Response The Response object
+// @router /update-workload-identity [post]
+func (c *ApiController) UpdateWorkloadIdentity() {
+	id := c.Input().Get("id")
+
+	var workloadIdentity object.WorkloadIdentity
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &workloadIdentity)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.UpdateWorkloadIdentity(id, &workloadIdentity, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// AddWorkloadIdentity
+// @Title AddWorkloadIdentity
+// @Tag Workload Identity API
+// @Description add workload identity
+// @Param   body    body   object.WorkloadIdentity  true        "The details of the workload identity"
+// @Success 200 {object} controllers.Response The Response object
+// @router /add-workload-identity [post]
+func (c *ApiController) AddWorkloadIdentity() {
+	var workloadIdentity object.WorkloadIdentity
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &workloadIdentity)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.AddWorkloadIdentity(&workloadIdentity, c.GetAcceptLanguage()))
+	c.ServeJSON()
+}
+
+// DeleteWorkloadIdentity
+// @Title DeleteWorkloadIdentity
+// @Tag Workload Identity API
+// @Description delete workload identity, its tokens stop working at once
+// @Param   body    body   object.WorkloadIdentity  true        "The details of the workload identity"
+// @Success 200 {object} controllers.Response The Response object
+// @router /delete-workload-identity [post]
+func (c *ApiController) DeleteWorkloadIdentity() {
+	var workloadIdentity object.WorkloadIdentity
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &workloadIdentity)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.Data["json"] = wrapActionResponse(object.DeleteWorkloadIdentity(&workloadIdentity))
+	c.ServeJSON()
+}
+
+// ExchangeWorkloadToken
+// @Title ExchangeWorkloadToken
+// @Tag Workload Identity API
+// @Description exchange the OIDC token of a CI job (e.g., GitHub Actions or GitLab CI) for a short-lived management token limited to the APIs of the workload identity, the token is used as the bearer token
+// @Param   body    body   form.WorkloadTokenForm  true        "The id ( owner/name ) of the workload identity and the OIDC token"
+// @Success 200 {object} object.TokenWrapper The Response object
+// @router /exchange-workload-token [post]
+func (c *ApiController) ExchangeWorkloadToken() {
+	var workloadTokenForm form.WorkloadTokenForm
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, &workloadTokenForm)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	token, err := object.ExchangeWorkloadToken(workloadTokenForm.WorkloadIdentity, workloadTokenForm.Token, util.GetIPFromRequest(c.Ctx.Request), c.GetAcceptLanguage())
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(token)
+}
+
+// GetWorkloadTokens
+// @Title GetWorkloadTokens
+// @Tag Workload Identity API
+// @Description get the tokens issued for the workload identities of the organization
+// @Param   owner     query    string  true        "The organization of the tokens"
+// @Param   workloadIdentity     query    string  false        "The name of the workload identity"
+// @Success 200 {array} object.WorkloadToken The Response object
+// @router /get-workload-tokens [get]
+func (c *ApiController) GetWorkloadTokens() {
+	owner := c.Input().Get("owner")
+	workloadIdentity := c.Input().Get("workloadIdentity")
+	limit := c.Input().Get("pageSize")
+	page := c.Input().Get("p")
+	field := c.Input().Get("field")
+	value := c.Input().Get("value")
+	sortField := c.Input().Get("sortField")
+	sortOrder := c.Input().Get("sortOrder")
+
+	if limit == "" {
+		limit = "100"
+	}
+	if page == "" {
+		page = "1"
+	}
+	if sortField == "" {
+		sortField, sortOrder = "created_time", "descend"
+	}
+
+	count, err := object.GetWorkloadTokenCount(owner, workloadIdentity, field, value)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	paginator := pagination.SetPaginator(c.Ctx, util.ParseInt(limit), count)
+	workloadTokens, err := object.GetPaginationWorkloadTokens(owner, workloadIdentity, paginator.Offset(), util.ParseInt(limit), field, value, sortField, sortOrder)
+	if err != nil {
+		c.ResponseErr(err)
+		return
+	}
+
+	c.ResponseOk(workloadTokens, paginator.Nums())
+}
@@ -0,0 +1,169 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// dkimSignedHeaders are signed if they are in the message, From is always required
+var dkimSignedHeaders = []string{"From", "To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type", "Reply-To", "Cc"}
+
+var dkimWhitespaceRegex = regexp.MustCompile(`[ \t]+`)
+
+// DkimSigner signs the messages with rsa-sha256 and the relaxed/relaxed canonicalization (RFC 6376)
+type DkimSigner struct {
+	Domain     string
+	Selector   string
+	PrivateKey *rsa.PrivateKey
+}
+
+func NewDkimSigner(domain string, selector string, privateKeyPem string) (*DkimSigner, error) {
+	block, _ := pem.Decode([]byte(privateKeyPem))
+	if block == nil {
+		return nil, fmt.Errorf("the DKIM private key is not in PEM format")
+	}
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DkimSigner{Domain: domain, Selector: selector, PrivateKey: privateKey}, nil
+}
+
+// GenerateDkimKey returns a new RSA private key in PEM and the base64 public key for the DNS record
+func GenerateDkimKey() (string, string, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	privateKeyPem := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	return string(privateKeyPem), base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+// GetDkimPublicKey returns the base64 public key of the PEM private key for the DNS record
+func GetDkimPublicKey(privateKeyPem string) (string, error) {
+	signer, err := NewDkimSigner("", "", privateKeyPem)
+	if err != nil {
+		return "", err
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&signer.PrivateKey.PublicKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(publicKey), nil
+}
+
+func canonicalizeDkimHeader(field string) string {
+	i := strings.Index(field, ":")
+	name := strings.ToLower(strings.TrimSpace(field[:i]))
+	value := strings.ReplaceAll(field[i+1:], "\r\n", "")
+	value = strings.TrimSpace(dkimWhitespaceRegex.ReplaceAllString(value, " "))
+	return name + ":" + value
+}
+
+func canonicalizeDkimBody(body []byte) []byte {
+	lines := strings.Split(strings.ReplaceAll(string(body), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(dkimWhitespaceRegex.ReplaceAllString(line, " "), " ")
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) == 0 {
+		return []byte{}
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// splitDkimHeaders returns the header fields with their folded lines, and the body of the message
+func splitDkimHeaders(message []byte) ([]string, []byte) {
+	s := strings.ReplaceAll(string(message), "\r\n", "\n")
+	header, body := s, ""
+	if i := strings.Index(s, "\n\n"); i != -1 {
+		header, body = s[:i], s[i+2:]
+	}
+
+	fields := []string{}
+	for _, line := range strings.Split(header, "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(fields) != 0 {
+			fields[len(fields)-1] += "\r\n" + line
+		} else if line != "" {
+			fields = append(fields, line)
+		}
+	}
+	return fields, []byte(strings.ReplaceAll(body, "\n", "\r\n"))
+}
+
+// Sign returns the message with the DKIM-Signature header
+func (s *DkimSigner) Sign(message []byte) ([]byte, error) {
+	fields, body := splitDkimHeaders(message)
+
+	bodyHash := sha256.Sum256(canonicalizeDkimBody(body))
+
+	names := []string{}
+	signedFields := []string{}
+	for _, name := range dkimSignedHeaders {
+		// the last instance of a header field is signed first
+		for i := len(fields) - 1; i >= 0; i-- {
+			if strings.EqualFold(strings.TrimSpace(strings.SplitN(fields[i], ":", 2)[0]), name) {
+				names = append(names, strings.ToLower(name))
+				signedFields = append(signedFields, fields[i])
+				break
+			}
+		}
+	}
+	if len(names) == 0 || names[0] != "from" {
+		return nil, fmt.Errorf("the message to be signed by DKIM has no From header")
+	}
+
+	signature := fmt.Sprintf("DKIM-Signature: v=1; a=rsa-sha256; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		s.Domain, s.Selector, time.Now().Unix(), strings.Join(names, ":"), base64.StdEncoding.EncodeToString(bodyHash[:]))
+
+	var data bytes.Buffer
+	for _, field := range signedFields {
+		data.WriteString(canonicalizeDkimHeader(field) + "\r\n")
+	}
+	data.WriteString(canonicalizeDkimHeader(signature))
+
+	hash := sha256.Sum256(data.Bytes())
+	b, err := rsa.SignPKCS1v15(rand.Reader, s.PrivateKey, crypto.SHA256, hash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	res := []byte(signature + base64.StdEncoding.EncodeToString(b) + "\r\n")
+	return append(res, message...), nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package email
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDkimCanonicalization(t *testing.T) {
+	assert.Equal(t, "subject:Hello World", canonicalizeDkimHeader("Subject:  Hello \r\n\t World  "))
+	assert.Equal(t, "a b\r\n\r\nc\r\n", string(canonicalizeDkimBody([]byte("a  b \r\n\r\nc\r\n\r\n\r\n"))))
+	assert.Equal(t, "", string(canonicalizeDkimBody([]byte("\r\n\r\n"))))
+}
+
+func TestDkimSign(t *testing.T) {
+	privateKeyPem, publicKey, err := GenerateDkimKey()
+	assert.Nil(t, err)
+
+	key, err := GetDkimPublicKey(privateKeyPem)
+	assert.Nil(t, err)
+	assert.Equal(t, publicKey, key)
+
+	signer, err := NewDkimSigner("example.com", "casdoor", privateKeyPem)
+	assert.Nil(t, err)
+
+	message := "From: Casdoor <noreply@example.com>\r\nTo: alice@example.org\r\nSubject: Verification\r\n code\r\n\r\nYour code is 123456\r\n"
+	signed, err := signer.Sign([]byte(message))
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(string(signed), message))
+
+	fields, body := splitDkimHeaders(signed)
+	signature := fields[0]
+	assert.True(t, strings.HasPrefix(signature, "DKIM-Signature: v=1; a=rsa-sha256; c=relaxed/relaxed; d=example.com; s=casdoor;"))
+	assert.Contains(t, signature, "h=from:to:subject;")
+
+	bodyHash := sha256.Sum256(canonicalizeDkimBody(body))
+	assert.Contains(t, signature, "bh="+base64.StdEncoding.EncodeToString(bodyHash[:])+";")
+
+	i := strings.LastIndex(signature, "b=")
+	b, err := base64.StdEncoding.DecodeString(signature[i+2:])
+	assert.Nil(t, err)
+
+	data := ""
+	for _, field := range fields[1:4] {
+		data += canonicalizeDkimHeader(field) + "\r\n"
+	}
+	data += canonicalizeDkimHeader(signature[:i+2])
+	hash := sha256.Sum256([]byte(data))
+
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+	assert.Nil(t, err)
+	pub, err := x509.ParsePKIXPublicKey(der)
+	assert.Nil(t, err)
+	assert.Nil(t, rsa.VerifyPKCS1v15(pub.(*rsa.PublicKey), crypto.SHA256, hash[:], b))
+
+	_, err = signer.Sign([]byte("To: alice@example.org\r\n\r\nHello"))
+	assert.NotNil(t, err)
+}
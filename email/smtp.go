@@ -15,6 +15,7 @@
 package email
 
 import (
+	"bytes"
 	"crypto/tls"
 
 	"github.com/casdoor/gomail/v2"
@@ -22,6 +23,8 @@ import (
 
 type SmtpEmailProvider struct {
 	Dialer *gomail.Dialer
+	// Dkim signs the messages if it is set
+	Dkim *DkimSigner
 }
 
 func NewSmtpEmailProvider(userName string, password string, host string, port int, typ string, disableSsl bool) *SmtpEmailProvider {
@@ -45,5 +48,26 @@ func (s *SmtpEmailProvider) Send(fromAddress string, fromName string, toAddress
 	message.SetBody("text/html", content)
 
 	message.SkipUsernameCheck = true
-	return s.Dialer.DialAndSend(message)
+	if s.Dkim == nil {
+		return s.Dialer.DialAndSend(message)
+	}
+
+	var buf bytes.Buffer
+	_, err := message.WriteTo(&buf)
+	if err != nil {
+		return err
+	}
+
+	signed, err := s.Dkim.Sign(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	sender, err := s.Dialer.Dial()
+	if err != nil {
+		return err
+	}
+	defer sender.Close()
+
+	return sender.Send(fromAddress, []string{toAddress}, bytes.NewReader(signed))
 }
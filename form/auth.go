@@ -51,6 +51,7 @@ type AuthForm struct {
 	CaptchaType  string `json:"captchaType"`
 	CaptchaToken string `json:"captchaToken"`
 	ClientSecret string `json:"clientSecret"`
+	BotToken     string `json:"botToken"`
 
 	MfaType      string `json:"mfaType"`
 	Passcode     string `json:"passcode"`
@@ -58,4 +59,9 @@ type AuthForm struct {
 
 	Plan    string `json:"plan"`
 	Pricing string `json:"pricing"`
+
+	TermsAccepted bool              `json:"termsAccepted"`
+	Attributes    map[string]string `json:"attributes"`
+	// LegalDocuments are the versions of the legal documents of the application accepted by the user, keyed by the types
+	LegalDocuments map[string]string `json:"legalDocuments"`
 }
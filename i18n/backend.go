@@ -0,0 +1,41 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import "strings"
+
+// Backend provides the translations of the organizations overriding the ones of the locale files,
+// e.g. the translations managed at runtime in the database
+type Backend interface {
+	GetTranslation(organization string, language string, namespace string, key string) (string, bool)
+}
+
+var backend Backend
+
+func SetBackend(b Backend) {
+	backend = b
+}
+
+// TranslateByOrg translates the text with the translations of the organization, and falls back to the locale files
+func TranslateByOrg(organization string, language string, errorText string) string {
+	tokens := strings.SplitN(errorText, ":", 2)
+	if backend != nil && organization != "" && len(tokens) == 2 {
+		if res, ok := backend.GetTranslation(organization, language, tokens[0], tokens[1]); ok {
+			return res
+		}
+	}
+
+	return Translate(language, errorText)
+}
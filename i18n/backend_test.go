@@ -0,0 +1,42 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testBackend map[string]I18nData
+
+func (b testBackend) GetTranslation(organization string, language string, namespace string, key string) (string, bool) {
+	res, ok := b[organization+"/"+language][namespace][key]
+	return res, ok
+}
+
+func TestTranslateByOrg(t *testing.T) {
+	defer SetBackend(nil)
+
+	assert.Equal(t, "Please login first", TranslateByOrg("acme", "en", "general:Please login first"))
+
+	SetBackend(testBackend{
+		"acme/en": {"general": {"Please login first": "Please sign in to your company account first"}},
+	})
+	assert.Equal(t, "Please sign in to your company account first", TranslateByOrg("acme", "en", "general:Please login first"))
+	assert.Equal(t, "Please login first", TranslateByOrg("other", "en", "general:Please login first"))
+	assert.Equal(t, "Please login first", TranslateByOrg("", "en", "general:Please login first"))
+	assert.Equal(t, Translate("zh", "general:Please login first"), TranslateByOrg("acme", "zh", "general:Please login first"))
+}
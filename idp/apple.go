@@ -0,0 +1,115 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+)
+
+const (
+	appleIssuer             = "https://appleid.apple.com"
+	appleKeysUrl            = "https://appleid.apple.com/auth/keys"
+	applePrivateRelayDomain = "privaterelay.appleid.com"
+)
+
+// The event types of Apple's server-to-server notifications
+const (
+	AppleEventEmailDisabled  = "email-disabled"
+	AppleEventEmailEnabled   = "email-enabled"
+	AppleEventConsentRevoked = "consent-revoked"
+	AppleEventAccountDelete  = "account-delete"
+)
+
+type AppleNotificationEvent struct {
+	Type      string `json:"type"`
+	Sub       string `json:"sub"`
+	Email     string `json:"email"`
+	EventTime int64  `json:"event_time"`
+}
+
+// IsApplePrivateRelayEmail tells whether the email is an address of the "Hide My Email" relay service,
+// which forwards the emails to the real address of the user.
+func IsApplePrivateRelayEmail(email string) bool {
+	return strings.HasSuffix(strings.ToLower(email), "@"+applePrivateRelayDomain)
+}
+
+func setAppleUserInfo(user *UserInfo) {
+	if !IsApplePrivateRelayEmail(user.Email) {
+		user.Username = util.GetUsernameFromEmail(user.Email)
+		return
+	}
+
+	// the local part of a relay address is random, so it isn't used as the username
+	if user.Username == "" {
+		user.Username = user.Id
+	}
+	if user.DisplayName == "" {
+		user.DisplayName = user.Username
+	}
+
+	if user.Extra == nil {
+		user.Extra = map[string]string{}
+	}
+	user.Extra["isPrivateEmail"] = "true"
+}
+
+// ParseAppleNotification verifies the payload of Apple's server-to-server notification with Apple's public keys,
+// and returns the event in it. The clientId is the Services ID (or App ID) that the notification is sent for.
+func ParseAppleNotification(payload string, clientId string) (*AppleNotificationEvent, error) {
+	keySet, err := jwk.Fetch(context.Background(), appleKeysUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse([]byte(payload), jwt.WithKeySet(keySet), jwt.WithValidate(true), jwt.WithIssuer(appleIssuer), jwt.WithAudience(clientId))
+	if err != nil {
+		return nil, err
+	}
+
+	events, ok := token.Get("events")
+	if !ok {
+		return nil, fmt.Errorf("the Apple notification has no events")
+	}
+
+	// Apple sends the events as a JSON string
+	var data []byte
+	if s, ok := events.(string); ok {
+		data = []byte(s)
+	} else {
+		data, err = json.Marshal(events)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	event := &AppleNotificationEvent{}
+	err = json.Unmarshal(data, event)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.Sub == "" {
+		return nil, fmt.Errorf("the Apple notification has no subject")
+	}
+
+	return event, nil
+}
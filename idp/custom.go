@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/casdoor/casdoor/util"
 	"github.com/mitchellh/mapstructure"
@@ -44,17 +46,35 @@ func NewCustomIdProvider(idpInfo *ProviderInfo, redirectUrl string) *CustomIdPro
 		ClientID:     idpInfo.ClientId,
 		ClientSecret: idpInfo.ClientSecret,
 		RedirectURL:  redirectUrl,
+		Scopes:       idpInfo.Scopes,
 		Endpoint: oauth2.Endpoint{
-			AuthURL:  idpInfo.AuthURL,
-			TokenURL: idpInfo.TokenURL,
+			AuthURL:   idpInfo.AuthURL,
+			TokenURL:  idpInfo.TokenURL,
+			AuthStyle: getCustomAuthStyle(idpInfo.AuthMethod),
 		},
 	}
 	idp.UserInfoURL = idpInfo.UserInfoURL
+	idp.TokenURL = idpInfo.TokenURL
+	idp.AuthURL = idpInfo.AuthURL
 	idp.UserMapping = idpInfo.UserMapping
+	idp.Scopes = idpInfo.Scopes
 
 	return idp
 }
 
+// getCustomAuthStyle maps the token endpoint auth method configured for the provider
+// (the same names as "token_endpoint_auth_method" in OIDC discovery) to the oauth2 auth style
+func getCustomAuthStyle(authMethod string) oauth2.AuthStyle {
+	switch authMethod {
+	case "client_secret_basic":
+		return oauth2.AuthStyleInHeader
+	case "client_secret_post":
+		return oauth2.AuthStyleInParams
+	default:
+		return oauth2.AuthStyleAutoDetect
+	}
+}
+
 func (idp *CustomIdProvider) SetHttpClient(client *http.Client) {
 	idp.Client = client
 }
@@ -69,9 +89,41 @@ type CustomUserInfo struct {
 	Username    string `mapstructure:"username"`
 	DisplayName string `mapstructure:"displayName"`
 	Email       string `mapstructure:"email"`
+	Phone       string `mapstructure:"phone"`
 	AvatarUrl   string `mapstructure:"avatarUrl"`
 }
 
+// getValueByPath gets the value from the userinfo payload by a JSON path like "data.user.email" or "emails.0.value",
+// a leading "$." is allowed so that paths copied from JSONPath tools also work
+func getValueByPath(data map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := data[path]; ok {
+		return value, true
+	}
+
+	path = strings.TrimPrefix(path, "$.")
+	var current interface{} = data
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
 func (idp *CustomIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error) {
 	accessToken := token.AccessToken
 	request, err := http.NewRequest("GET", idp.UserInfoURL, nil)
@@ -100,11 +152,11 @@ func (idp *CustomIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 
 	// map user info
 	for k, v := range idp.UserMapping {
-		_, ok := dataMap[v]
+		value, ok := getValueByPath(dataMap, v)
 		if !ok {
 			return nil, fmt.Errorf("cannot find %s in user from castom provider", v)
 		}
-		dataMap[k] = dataMap[v]
+		dataMap[k] = value
 	}
 
 	// try to parse id to string
@@ -125,7 +177,11 @@ func (idp *CustomIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 		Username:    customUserinfo.Username,
 		DisplayName: customUserinfo.DisplayName,
 		Email:       customUserinfo.Email,
+		Phone:       customUserinfo.Phone,
 		AvatarUrl:   customUserinfo.AvatarUrl,
+
+		// the standard OIDC claim of the userinfo
+		EmailVerified: fmt.Sprintf("%v", dataMap["email_verified"]) == "true",
 	}
 	return userInfo, nil
 }
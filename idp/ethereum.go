@@ -0,0 +1,441 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+	"golang.org/x/oauth2"
+)
+
+const SiweMessageKey = "siweMessage"
+
+const (
+	siweHeaderSuffix = " wants you to sign in with your Ethereum account:"
+	// the message must be signed shortly after it is issued
+	siweMaxAge    = 10 * time.Minute
+	siweClockSkew = time.Minute
+
+	ensRegistryAddress = "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"
+	ensResolverMethod  = "0178b8bf"
+	ensNameMethod      = "691f3431"
+	ensAddrMethod      = "3b3b57de"
+)
+
+var (
+	ethereumAddressRegex = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	siweNonceRegex       = regexp.MustCompile(`^[a-zA-Z0-9]{8,}$`)
+)
+
+// SiweMessage is an EIP-4361 (Sign-In with Ethereum) message
+type SiweMessage struct {
+	Domain         string
+	Address        string
+	Statement      string
+	Uri            string
+	Version        string
+	ChainId        int
+	Nonce          string
+	IssuedAt       time.Time
+	ExpirationTime time.Time
+	NotBefore      time.Time
+	RequestId      string
+	Resources      []string
+}
+
+// EthereumAuthToken is the code of the Ethereum provider, the SIWE message signed by the wallet with personal_sign
+type EthereumAuthToken struct {
+	Address   string `json:"address"`
+	Message   string `json:"message"`
+	Signature string `json:"signature"`
+}
+
+// EthereumIdProvider signs in with Ethereum wallets by EIP-4361, the display names are resolved from ENS if
+// the RPC URL of an Ethereum node is set
+type EthereumIdProvider struct {
+	Client *http.Client
+	RpcUrl string
+
+	// Domain and Nonce are the values expected in the message, set by the caller before getting the token
+	Domain string
+	Nonce  string
+}
+
+func NewEthereumIdProvider(rpcUrl string) *EthereumIdProvider {
+	idp := &EthereumIdProvider{RpcUrl: rpcUrl}
+	return idp
+}
+
+func (idp *EthereumIdProvider) SetHttpClient(client *http.Client) {
+	idp.Client = client
+}
+
+func (idp *EthereumIdProvider) GetToken(code string) (*oauth2.Token, error) {
+	authToken := EthereumAuthToken{}
+	if err := json.Unmarshal([]byte(code), &authToken); err != nil {
+		return nil, err
+	}
+
+	message, err := ParseSiweMessage(authToken.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	err = idp.checkSiweMessage(message, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := RecoverEthereumAddress(authToken.Message, authToken.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(address, message.Address) {
+		return nil, fmt.Errorf("the signature is signed by: %s instead of: %s", address, message.Address)
+	}
+
+	token := &oauth2.Token{
+		AccessToken: authToken.Signature,
+		TokenType:   "Bearer",
+		Expiry:      time.Now().AddDate(0, 1, 0),
+	}
+
+	token = token.WithExtra(map[string]interface{}{
+		SiweMessageKey: message,
+	})
+	return token, nil
+}
+
+func (idp *EthereumIdProvider) checkSiweMessage(message *SiweMessage, now time.Time) error {
+	if idp.Domain == "" || !strings.EqualFold(message.Domain, idp.Domain) {
+		return fmt.Errorf("the domain of the message: %s doesn't match: %s", message.Domain, idp.Domain)
+	}
+	if idp.Nonce == "" || message.Nonce != idp.Nonce {
+		return errors.New("the nonce of the message is invalid or already used")
+	}
+
+	if message.IssuedAt.After(now.Add(siweClockSkew)) || message.IssuedAt.Before(now.Add(-siweMaxAge)) {
+		return errors.New("the message is issued too long ago or in the future")
+	}
+	if !message.ExpirationTime.IsZero() && !now.Before(message.ExpirationTime) {
+		return errors.New("the message has expired")
+	}
+	if !message.NotBefore.IsZero() && now.Before(message.NotBefore) {
+		return errors.New("the message is not valid yet")
+	}
+	return nil
+}
+
+func (idp *EthereumIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error) {
+	message, ok := token.Extra(SiweMessageKey).(*SiweMessage)
+	if !ok {
+		return nil, errors.New("invalid siweMessage")
+	}
+
+	address := ToChecksumAddress(message.Address)
+	displayName := address
+	if idp.RpcUrl != "" {
+		name, err := idp.resolveEnsName(address)
+		if err != nil {
+			return nil, err
+		}
+		if name != "" {
+			displayName = name
+		}
+	}
+
+	userInfo := &UserInfo{
+		Id:          address,
+		Username:    address,
+		DisplayName: displayName,
+		AvatarUrl:   fmt.Sprintf("metamask:%v", address),
+		Extra: map[string]string{
+			"chainId": strconv.Itoa(message.ChainId),
+		},
+	}
+	return userInfo, nil
+}
+
+// ParseSiweMessage parses the EIP-4361 message
+func ParseSiweMessage(message string) (*SiweMessage, error) {
+	lines := strings.Split(strings.ReplaceAll(message, "\r\n", "\n"), "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], siweHeaderSuffix) {
+		return nil, errors.New("the message is not a Sign-In with Ethereum message")
+	}
+
+	res := &SiweMessage{}
+	res.Domain = strings.TrimSuffix(lines[0], siweHeaderSuffix)
+	if i := strings.Index(res.Domain, "://"); i != -1 {
+		res.Domain = res.Domain[i+3:]
+	}
+
+	res.Address = lines[1]
+	if !ethereumAddressRegex.MatchString(res.Address) {
+		return nil, fmt.Errorf("the address: %s is invalid", res.Address)
+	}
+
+	// the statement is between the address and the fields, surrounded by empty lines
+	i := 2
+	statement := []string{}
+	for ; i < len(lines) && !strings.HasPrefix(lines[i], "URI: "); i++ {
+		if lines[i] != "" {
+			statement = append(statement, lines[i])
+		}
+	}
+	res.Statement = strings.Join(statement, "\n")
+
+	var err error
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "- ") {
+			res.Resources = append(res.Resources, strings.TrimPrefix(line, "- "))
+			continue
+		}
+		if line == "Resources:" {
+			continue
+		}
+
+		tokens := strings.SplitN(line, ": ", 2)
+		if len(tokens) != 2 {
+			return nil, fmt.Errorf("the line: %s of the message is invalid", line)
+		}
+
+		key, value := tokens[0], tokens[1]
+		switch key {
+		case "URI":
+			res.Uri = value
+		case "Version":
+			res.Version = value
+		case "Chain ID":
+			res.ChainId, err = strconv.Atoi(value)
+		case "Nonce":
+			res.Nonce = value
+		case "Issued At":
+			res.IssuedAt, err = time.Parse(time.RFC3339, value)
+		case "Expiration Time":
+			res.ExpirationTime, err = time.Parse(time.RFC3339, value)
+		case "Not Before":
+			res.NotBefore, err = time.Parse(time.RFC3339, value)
+		case "Request ID":
+			res.RequestId = value
+		default:
+			return nil, fmt.Errorf("the field: %s of the message is not supported", key)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("the field: %s of the message is invalid: %s", key, err.Error())
+		}
+	}
+
+	if res.Version != "1" {
+		return nil, fmt.Errorf("the version: %s of the message is not supported", res.Version)
+	}
+	if res.Uri == "" || res.ChainId == 0 || res.IssuedAt.IsZero() {
+		return nil, errors.New("the URI, Chain ID and Issued At of the message are required")
+	}
+	if !siweNonceRegex.MatchString(res.Nonce) {
+		return nil, errors.New("the nonce of the message should be at least 8 alphanumeric characters")
+	}
+	return res, nil
+}
+
+func keccak256(data ...[]byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	for _, b := range data {
+		hash.Write(b)
+	}
+	return hash.Sum(nil)
+}
+
+// ToChecksumAddress returns the EIP-55 mixed-case checksum encoding of the address
+func ToChecksumAddress(address string) string {
+	address = strings.ToLower(strings.TrimPrefix(address, "0x"))
+	hash := hex.EncodeToString(keccak256([]byte(address)))
+
+	res := []byte(address)
+	for i, ch := range res {
+		if ch >= 'a' && hash[i] >= '8' {
+			res[i] = ch - 'a' + 'A'
+		}
+	}
+	return "0x" + string(res)
+}
+
+// RecoverEthereumAddress returns the address signing the message with personal_sign (EIP-191)
+func RecoverEthereumAddress(message string, signature string) (string, error) {
+	sig, err := hex.DecodeString(strings.TrimPrefix(signature, "0x"))
+	if err != nil || len(sig) != 65 {
+		return "", errors.New("the signature should be 65 bytes in hex")
+	}
+
+	// the recovery id is 0 / 1 or 27 / 28 at the end, while the compact signature has it at the beginning
+	recoveryId := sig[64]
+	if recoveryId >= 27 {
+		recoveryId -= 27
+	}
+	if recoveryId > 1 {
+		return "", errors.New("the recovery id of the signature is invalid")
+	}
+	compactSig := append([]byte{27 + recoveryId}, sig[:64]...)
+
+	hash := keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+	publicKey, _, err := ecdsa.RecoverCompact(compactSig, hash)
+	if err != nil {
+		return "", err
+	}
+
+	address := keccak256(publicKey.SerializeUncompressed()[1:])[12:]
+	return ToChecksumAddress(hex.EncodeToString(address)), nil
+}
+
+// ensNamehash returns the EIP-137 namehash of the ENS name
+func ensNamehash(name string) []byte {
+	node := make([]byte, 32)
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(strings.ToLower(name), ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		node = keccak256(node, keccak256([]byte(labels[i])))
+	}
+	return node
+}
+
+func (idp *EthereumIdProvider) ethCall(to string, method string, node []byte) ([]byte, error) {
+	data := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "eth_call",
+		"params": []interface{}{
+			map[string]string{"to": to, "data": "0x" + method + hex.EncodeToString(node)},
+			"latest",
+		},
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	client := idp.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Post(idp.RpcUrl, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var rpcResp struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err = json.Unmarshal(respBody, &rpcResp)
+	if err != nil {
+		return nil, err
+	}
+	if rpcResp.Error != nil {
+		return nil, fmt.Errorf("eth_call failed: %s", rpcResp.Error.Message)
+	}
+
+	return hex.DecodeString(strings.TrimPrefix(rpcResp.Result, "0x"))
+}
+
+func (idp *EthereumIdProvider) getEnsResolver(node []byte) (string, error) {
+	res, err := idp.ethCall(ensRegistryAddress, ensResolverMethod, node)
+	if err != nil || len(res) < 32 {
+		return "", err
+	}
+
+	resolver := hex.EncodeToString(res[12:32])
+	if strings.Trim(resolver, "0") == "" {
+		return "", nil
+	}
+	return "0x" + resolver, nil
+}
+
+// resolveEnsName returns the primary ENS name of the address, empty if it has no name or the name doesn't
+// resolve back to the address
+func (idp *EthereumIdProvider) resolveEnsName(address string) (string, error) {
+	reverseNode := ensNamehash(strings.ToLower(strings.TrimPrefix(address, "0x")) + ".addr.reverse")
+	resolver, err := idp.getEnsResolver(reverseNode)
+	if err != nil || resolver == "" {
+		return "", err
+	}
+
+	res, err := idp.ethCall(resolver, ensNameMethod, reverseNode)
+	if err != nil {
+		return "", err
+	}
+	name := decodeAbiString(res)
+	if name == "" {
+		return "", nil
+	}
+
+	// the reverse record can be set to any name, so it is trusted only if the name resolves to the address
+	node := ensNamehash(name)
+	resolver, err = idp.getEnsResolver(node)
+	if err != nil || resolver == "" {
+		return "", err
+	}
+
+	res, err = idp.ethCall(resolver, ensAddrMethod, node)
+	if err != nil || len(res) < 32 {
+		return "", err
+	}
+	if !strings.EqualFold("0x"+hex.EncodeToString(res[12:32]), address) {
+		return "", nil
+	}
+	return name, nil
+}
+
+func decodeAbiString(data []byte) string {
+	if len(data) < 64 {
+		return ""
+	}
+
+	offset, err := strconv.ParseUint(hex.EncodeToString(data[24:32]), 16, 64)
+	if err != nil || offset+32 > uint64(len(data)) {
+		return ""
+	}
+
+	length, err := strconv.ParseUint(hex.EncodeToString(data[offset+24:offset+32]), 16, 64)
+	if err != nil || offset+32+length > uint64(len(data)) {
+		return ""
+	}
+	return string(data[offset+32 : offset+32+length])
+}
@@ -0,0 +1,126 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idp
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+// the address of the private key 0x01
+const testEthereumAddress = "0x7E5F4552091A69125d5DfCb7b8C2659029395Bdf"
+
+func signEthereumMessage(message string) string {
+	privateKey := secp256k1.PrivKeyFromBytes([]byte{1})
+	hash := keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(message), message)))
+	compactSig := ecdsa.SignCompact(privateKey, hash, false)
+	return "0x" + hex.EncodeToString(append(compactSig[1:], compactSig[0]))
+}
+
+func getSiweMessage(address string, nonce string, issuedAt time.Time) string {
+	return fmt.Sprintf(`door.casdoor.com wants you to sign in with your Ethereum account:
+%s
+
+Sign in to Casdoor
+
+URI: https://door.casdoor.com/login
+Version: 1
+Chain ID: 1
+Nonce: %s
+Issued At: %s
+Resources:
+- https://door.casdoor.com/terms`, address, nonce, issuedAt.UTC().Format(time.RFC3339))
+}
+
+func TestToChecksumAddress(t *testing.T) {
+	assert.Equal(t, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ToChecksumAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+	assert.Equal(t, "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", ToChecksumAddress("FB6916095CA1DF60BB79CE92CE3EA74C37C5D359"))
+}
+
+func TestEnsNamehash(t *testing.T) {
+	assert.Equal(t, "0000000000000000000000000000000000000000000000000000000000000000", hex.EncodeToString(ensNamehash("")))
+	assert.Equal(t, "93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae", hex.EncodeToString(ensNamehash("eth")))
+	assert.Equal(t, "de9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f", hex.EncodeToString(ensNamehash("foo.eth")))
+}
+
+func TestParseSiweMessage(t *testing.T) {
+	issuedAt := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	message, err := ParseSiweMessage(getSiweMessage(testEthereumAddress, "abcdef1234", issuedAt))
+	assert.Nil(t, err)
+	assert.Equal(t, &SiweMessage{
+		Domain:    "door.casdoor.com",
+		Address:   testEthereumAddress,
+		Statement: "Sign in to Casdoor",
+		Uri:       "https://door.casdoor.com/login",
+		Version:   "1",
+		ChainId:   1,
+		Nonce:     "abcdef1234",
+		IssuedAt:  issuedAt,
+		Resources: []string{"https://door.casdoor.com/terms"},
+	}, message)
+
+	_, err = ParseSiweMessage(getSiweMessage("0x1234", "abcdef1234", issuedAt))
+	assert.NotNil(t, err)
+	_, err = ParseSiweMessage(getSiweMessage(testEthereumAddress, "abc", issuedAt))
+	assert.NotNil(t, err)
+	_, err = ParseSiweMessage("Sign in to Casdoor")
+	assert.NotNil(t, err)
+}
+
+func TestEthereumIdProvider(t *testing.T) {
+	message := getSiweMessage(testEthereumAddress, "abcdef1234", time.Now())
+	signature := signEthereumMessage(message)
+
+	address, err := RecoverEthereumAddress(message, signature)
+	assert.Nil(t, err)
+	assert.Equal(t, testEthereumAddress, address)
+
+	code, _ := json.Marshal(EthereumAuthToken{Address: testEthereumAddress, Message: message, Signature: signature})
+	idp := NewEthereumIdProvider("")
+	idp.Domain = "door.casdoor.com"
+	idp.Nonce = "abcdef1234"
+
+	token, err := idp.GetToken(string(code))
+	assert.Nil(t, err)
+	userInfo, err := idp.GetUserInfo(token)
+	assert.Nil(t, err)
+	assert.Equal(t, testEthereumAddress, userInfo.Id)
+	assert.Equal(t, testEthereumAddress, userInfo.DisplayName)
+
+	// the nonce is issued by the session
+	idp.Nonce = "1234abcdef"
+	_, err = idp.GetToken(string(code))
+	assert.NotNil(t, err)
+
+	// the message is signed by another account
+	idp.Nonce = "abcdef1234"
+	otherMessage := getSiweMessage("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "abcdef1234", time.Now())
+	code, _ = json.Marshal(EthereumAuthToken{Message: otherMessage, Signature: signEthereumMessage(otherMessage)})
+	_, err = idp.GetToken(string(code))
+	assert.NotNil(t, err)
+
+	// the message is too old
+	oldMessage := getSiweMessage(testEthereumAddress, "abcdef1234", time.Now().Add(-time.Hour))
+	code, _ = json.Marshal(EthereumAuthToken{Message: oldMessage, Signature: signEthereumMessage(oldMessage)})
+	_, err = idp.GetToken(string(code))
+	assert.NotNil(t, err)
+}
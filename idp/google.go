@@ -142,6 +142,8 @@ func (idp *GoogleIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 			DisplayName: googleIdToken.Name,
 			Email:       googleIdToken.Email,
 			AvatarUrl:   googleIdToken.Picture,
+
+			EmailVerified: googleIdToken.EmailVerified == "true",
 		}
 		return &userInfo, nil
 	}
@@ -173,6 +175,8 @@ func (idp *GoogleIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error)
 		DisplayName: googleUserInfo.Name,
 		Email:       googleUserInfo.Email,
 		AvatarUrl:   googleUserInfo.Picture,
+
+		EmailVerified: googleUserInfo.VerifiedEmail,
 	}
 	return &userInfo, nil
 }
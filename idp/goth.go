@@ -491,7 +491,7 @@ func getUser(gothUser goth.User, provider string) *UserInfo {
 		user.Username = user.Id
 		user.Email = ""
 	} else if provider == "apple" {
-		user.Username = util.GetUsernameFromEmail(user.Email)
+		setAppleUserInfo(&user)
 	}
 	return &user
 }
@@ -156,6 +156,7 @@ func (idp *OktaIdProvider) GetToken(code string) (*oauth2.Token, error) {
 
 type OktaUserInfo struct {
 	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
 	Name              string `json:"name"`
 	PreferredUsername string `json:"preferred_username"`
 	Picture           string `json:"picture"`
@@ -195,6 +196,8 @@ func (idp *OktaIdProvider) GetUserInfo(token *oauth2.Token) (*UserInfo, error) {
 		DisplayName: oktaUserInfo.Name,
 		Email:       oktaUserInfo.Email,
 		AvatarUrl:   oktaUserInfo.Picture,
+
+		EmailVerified: oktaUserInfo.EmailVerified,
 	}
 	return &userInfo, nil
 }
@@ -32,6 +32,9 @@ type UserInfo struct {
 	CountryCode string
 	AvatarUrl   string
 	Extra       map[string]string
+
+	// EmailVerified is set if the identity provider asserts that the user owns the email
+	EmailVerified bool
 }
 
 type ProviderInfo struct {
@@ -49,6 +52,8 @@ type ProviderInfo struct {
 	AuthURL     string
 	UserInfoURL string
 	UserMapping map[string]string
+	Scopes      []string
+	AuthMethod  string
 }
 
 type IdProvider interface {
@@ -117,6 +122,8 @@ func GetIdProvider(idpInfo *ProviderInfo, redirectUrl string) (IdProvider, error
 		return NewMetaMaskIdProvider(), nil
 	case "Web3Onboard":
 		return NewWeb3OnboardIdProvider(), nil
+	case "Ethereum":
+		return NewEthereumIdProvider(idpInfo.HostUrl), nil
 	default:
 		if isGothSupport(idpInfo.Type) {
 			return NewGothIdProvider(idpInfo.Type, idpInfo.ClientId, idpInfo.ClientSecret, idpInfo.ClientId2, idpInfo.ClientSecret2, redirectUrl, idpInfo.HostUrl)
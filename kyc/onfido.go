@@ -0,0 +1,126 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kyc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const onfidoDefaultEndpoint = "https://api.onfido.com/v3.6"
+
+type OnfidoVerificationProvider struct {
+	ApiToken   string
+	WorkflowId string
+	Endpoint   string
+}
+
+func NewOnfidoVerificationProvider(apiToken string, workflowId string, endpoint string) *OnfidoVerificationProvider {
+	if endpoint == "" {
+		endpoint = onfidoDefaultEndpoint
+	}
+
+	return &OnfidoVerificationProvider{
+		ApiToken:   apiToken,
+		WorkflowId: workflowId,
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+	}
+}
+
+type onfidoApplicant struct {
+	Id string `json:"id"`
+}
+
+type onfidoWorkflowRun struct {
+	Id   string `json:"id"`
+	Link struct {
+		Url string `json:"url"`
+	} `json:"link"`
+}
+
+type onfidoEvent struct {
+	Payload struct {
+		ResourceType string `json:"resource_type"`
+		Action       string `json:"action"`
+		Object       struct {
+			Id     string `json:"id"`
+			Status string `json:"status"`
+		} `json:"object"`
+	} `json:"payload"`
+}
+
+func (p *OnfidoVerificationProvider) CreateSession(referenceId string, firstName string, lastName string, redirectUrl string) (*VerificationSession, error) {
+	header := map[string]string{"Authorization": "Token token=" + p.ApiToken}
+
+	var applicant onfidoApplicant
+	err := doJsonRequest("POST", p.Endpoint+"/applicants", header, map[string]string{"first_name": firstName, "last_name": lastName}, &applicant)
+	if err != nil {
+		return nil, err
+	}
+
+	body := map[string]interface{}{
+		"workflow_id":      p.WorkflowId,
+		"applicant_id":     applicant.Id,
+		"customer_user_id": referenceId,
+	}
+	if redirectUrl != "" {
+		body["link"] = map[string]string{"completed_redirect_url": redirectUrl}
+	}
+
+	var workflowRun onfidoWorkflowRun
+	err = doJsonRequest("POST", p.Endpoint+"/workflow_runs", header, body, &workflowRun)
+	if err != nil {
+		return nil, err
+	}
+	if workflowRun.Id == "" || workflowRun.Link.Url == "" {
+		return nil, fmt.Errorf("the workflow run returned by Onfido has no link")
+	}
+
+	return &VerificationSession{
+		Id:  workflowRun.Id,
+		Url: workflowRun.Link.Url,
+	}, nil
+}
+
+func (p *OnfidoVerificationProvider) ParseCallback(header http.Header, body []byte, secret string) (*VerificationResult, error) {
+	if !isSignatureValid(secret, body, header.Get("X-SHA2-Signature")) {
+		return nil, fmt.Errorf("the signature of the Onfido callback is invalid")
+	}
+
+	var event onfidoEvent
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.Payload.ResourceType != "workflow_run" || event.Payload.Action != "workflow_run.completed" {
+		return nil, nil
+	}
+
+	// the runs to review manually are completed later with the final status
+	res := &VerificationResult{SessionId: event.Payload.Object.Id}
+	switch event.Payload.Object.Status {
+	case "approved":
+		res.Status = VerificationStatusApproved
+	case "declined", "abandoned", "error":
+		res.Status = VerificationStatusDeclined
+		res.Reason = fmt.Sprintf("the workflow run is %s", event.Payload.Object.Status)
+	default:
+		return nil, nil
+	}
+	return res, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kyc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const personaDefaultEndpoint = "https://withpersona.com/api/v1"
+
+type PersonaVerificationProvider struct {
+	ApiToken   string
+	TemplateId string
+	Endpoint   string
+}
+
+func NewPersonaVerificationProvider(apiToken string, templateId string, endpoint string) *PersonaVerificationProvider {
+	if endpoint == "" {
+		endpoint = personaDefaultEndpoint
+	}
+
+	return &PersonaVerificationProvider{
+		ApiToken:   apiToken,
+		TemplateId: templateId,
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+	}
+}
+
+type personaInquiry struct {
+	Data struct {
+		Id         string `json:"id"`
+		Attributes struct {
+			ReferenceId string `json:"reference-id"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+type personaEvent struct {
+	Data struct {
+		Attributes struct {
+			Name    string         `json:"name"`
+			Payload personaInquiry `json:"payload"`
+		} `json:"attributes"`
+	} `json:"data"`
+}
+
+func (p *PersonaVerificationProvider) CreateSession(referenceId string, firstName string, lastName string, redirectUrl string) (*VerificationSession, error) {
+	attributes := map[string]interface{}{
+		"inquiry-template-id": p.TemplateId,
+		"reference-id":        referenceId,
+		"fields": map[string]string{
+			"name-first": firstName,
+			"name-last":  lastName,
+		},
+	}
+	if redirectUrl != "" {
+		attributes["redirect-uri"] = redirectUrl
+	}
+
+	header := map[string]string{"Authorization": "Bearer " + p.ApiToken}
+	body := map[string]interface{}{"data": map[string]interface{}{"attributes": attributes}}
+	var inquiry personaInquiry
+	err := doJsonRequest("POST", p.Endpoint+"/inquiries", header, body, &inquiry)
+	if err != nil {
+		return nil, err
+	}
+	if inquiry.Data.Id == "" {
+		return nil, fmt.Errorf("the inquiry id returned by Persona is empty")
+	}
+
+	return &VerificationSession{
+		Id:  inquiry.Data.Id,
+		Url: "https://withpersona.com/verify?inquiry-id=" + url.QueryEscape(inquiry.Data.Id),
+	}, nil
+}
+
+// parsePersonaSignature returns the timestamp and the signatures of the Persona-Signature header: "t=<timestamp>,v1=<signature>",
+// more than one signature is sent while the webhook secret is rotated
+func parsePersonaSignature(header string) (string, []string) {
+	timestamp := ""
+	signatures := []string{}
+	for _, part := range strings.Split(header, ",") {
+		pair := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+
+		if pair[0] == "t" {
+			timestamp = pair[1]
+		} else if pair[0] == "v1" {
+			signatures = append(signatures, strings.Split(pair[1], " ")...)
+		}
+	}
+	return timestamp, signatures
+}
+
+func (p *PersonaVerificationProvider) ParseCallback(header http.Header, body []byte, secret string) (*VerificationResult, error) {
+	timestamp, signatures := parsePersonaSignature(header.Get("Persona-Signature"))
+	if timestamp == "" {
+		return nil, fmt.Errorf("the Persona-Signature header is invalid")
+	}
+
+	data := []byte(timestamp + "." + string(body))
+	isValid := false
+	for _, signature := range signatures {
+		if isSignatureValid(secret, data, signature) {
+			isValid = true
+			break
+		}
+	}
+	if !isValid {
+		return nil, fmt.Errorf("the signature of the Persona callback is invalid")
+	}
+
+	var event personaEvent
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	inquiry := event.Data.Attributes.Payload.Data
+	res := &VerificationResult{
+		SessionId:   inquiry.Id,
+		ReferenceId: inquiry.Attributes.ReferenceId,
+	}
+
+	name := event.Data.Attributes.Name
+	switch name {
+	case "inquiry.approved":
+		res.Status = VerificationStatusApproved
+	case "inquiry.declined", "inquiry.failed":
+		res.Status = VerificationStatusDeclined
+		res.Reason = fmt.Sprintf("the inquiry is %s", strings.TrimPrefix(name, "inquiry."))
+	default:
+		return nil, nil
+	}
+	return res, nil
+}
@@ -0,0 +1,118 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kyc
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// the results of the verifications reported by the callbacks of the providers
+const (
+	VerificationStatusApproved = "Approved"
+	VerificationStatusDeclined = "Declined"
+)
+
+const verificationTimeout = 30 * time.Second
+
+// VerificationSession is the session of the ID-document verification created in the provider, the user completes
+// the verification in the hosted flow of the Url
+type VerificationSession struct {
+	Id  string
+	Url string
+}
+
+// VerificationResult is the final result of the session reported by the callback of the provider
+type VerificationResult struct {
+	SessionId   string
+	ReferenceId string
+	Status      string
+	Reason      string
+}
+
+type VerificationProvider interface {
+	// CreateSession creates the session of the user identified by the reference id
+	CreateSession(referenceId string, firstName string, lastName string, redirectUrl string) (*VerificationSession, error)
+	// ParseCallback verifies the callback with the secret and returns the result, nil for the other events
+	ParseCallback(header http.Header, body []byte, secret string) (*VerificationResult, error)
+}
+
+// GetVerificationProvider returns the provider of the type, the api token authenticates the API calls and the
+// template id is the inquiry template (Persona) or workflow (Onfido) of the verification
+func GetVerificationProvider(providerType string, apiToken string, templateId string, endpoint string) VerificationProvider {
+	switch providerType {
+	case "Persona":
+		return NewPersonaVerificationProvider(apiToken, templateId, endpoint)
+	case "Onfido":
+		return NewOnfidoVerificationProvider(apiToken, templateId, endpoint)
+	}
+
+	return nil
+}
+
+func getHmacSha256(secret string, data []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// isSignatureValid compares the hex signature with the HMAC-SHA256 of the data in constant time
+func isSignatureValid(secret string, data []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, getHmacSha256(secret, data))
+}
+
+func doJsonRequest(method string, url string, header map[string]string, body interface{}, res interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{Timeout: verificationTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the verification provider returned status: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	return json.Unmarshal(respBody, res)
+}
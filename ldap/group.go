@@ -0,0 +1,194 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/casdoor/casdoor/util"
+	ldap "github.com/forestmgy/ldapserver"
+	"github.com/lor00x/goldap/message"
+)
+
+var (
+	reGroupObjectClass = regexp.MustCompile(`(?i)\(objectClass=(groupOfNames|groupOfUniqueNames|posixGroup|group)\)`)
+	reGroupName        = regexp.MustCompile(`(?i)\(cn=([^)]*)\)`)
+	reGroupMember      = regexp.MustCompile(`(?i)\((member|uniqueMember|memberUid)=([^)]*)\)`)
+)
+
+func isGroupSearch(filter string) bool {
+	return reGroupObjectClass.MatchString(filter)
+}
+
+// getMemberName returns the username of the member given by its DN or by its name
+func getMemberName(member string) string {
+	if !strings.Contains(member, "=") {
+		return member
+	}
+
+	params := map[string]string{}
+	for _, rdn := range strings.Split(member, ",") {
+		tokens := strings.SplitN(strings.TrimSpace(rdn), "=", 2)
+		if len(tokens) == 2 {
+			params[strings.ToLower(tokens[0])] = tokens[1]
+		}
+	}
+
+	if params["cn"] != "" {
+		return params["cn"]
+	}
+	return params["uid"]
+}
+
+// getUserDn returns the DN of the user entry, which is under "ou=people" of the base DN of the organization if it has one
+func getUserDn(user *object.User, baseObject string, organization *object.Organization) string {
+	if organization != nil && organization.LdapBaseDn != "" {
+		return fmt.Sprintf("uid=%s,ou=people,%s", user.Name, organization.LdapBaseDn)
+	}
+	return fmt.Sprintf("uid=%s,cn=%s,%s", user.Id, user.Name, baseObject)
+}
+
+func getGroupDn(group *object.Group, baseObject string, organization *object.Organization) string {
+	if organization != nil && organization.LdapBaseDn != "" {
+		return fmt.Sprintf("cn=%s,ou=groups,%s", group.Name, organization.LdapBaseDn)
+	}
+	return fmt.Sprintf("cn=%s,%s", group.Name, baseObject)
+}
+
+func getGroupMembers(group *object.Group, users []*object.User) []*object.User {
+	res := []*object.User{}
+	for _, user := range users {
+		if util.InSlice(user.Groups, group.GetId()) {
+			res = append(res, user)
+		}
+	}
+	return res
+}
+
+// getFilteredGroups returns the groups of the organization matched by the name and the member of the filter,
+// the users who aren't admins only see their own groups
+func getFilteredGroups(m *ldap.Message, org string) ([]*object.Group, []*object.User, int) {
+	if !m.Client.IsGlobalAdmin && m.Client.OrgName != org {
+		return nil, nil, ldap.LDAPResultInsufficientAccessRights
+	}
+
+	groups, err := object.GetGroups(org)
+	if err != nil {
+		panic(err)
+	}
+
+	users, err := object.GetUsers(org)
+	if err != nil {
+		panic(err)
+	}
+
+	r := m.GetSearchRequest()
+	filter := r.FilterString()
+	name := ""
+	if match := reGroupName.FindStringSubmatch(filter); match != nil && match[1] != "*" {
+		name = match[1]
+	}
+
+	member := ""
+	if match := reGroupMember.FindStringSubmatch(filter); match != nil {
+		member = getMemberName(match[2])
+	}
+	if !m.Client.IsOrgAdmin {
+		if member != "" && member != m.Client.UserName {
+			return nil, nil, ldap.LDAPResultSuccess
+		}
+		member = m.Client.UserName
+	}
+
+	res := []*object.Group{}
+	for _, group := range groups {
+		if name != "" && !strings.EqualFold(group.Name, name) {
+			continue
+		}
+
+		if member != "" {
+			isMember := false
+			for _, user := range getGroupMembers(group, users) {
+				if user.Name == member {
+					isMember = true
+				}
+			}
+			if !isMember {
+				continue
+			}
+		}
+
+		res = append(res, group)
+	}
+	return res, users, ldap.LDAPResultSuccess
+}
+
+func handleGroupSearch(w ldap.ResponseWriter, m *ldap.Message, org string, organization *object.Organization) {
+	res := ldap.NewSearchResultDoneResponse(ldap.LDAPResultSuccess)
+	r := m.GetSearchRequest()
+	baseObject := string(r.BaseObject())
+
+	groups, users, code := getFilteredGroups(m, org)
+	if code != ldap.LDAPResultSuccess {
+		res.SetResultCode(code)
+		w.Write(res)
+		return
+	}
+
+	for _, group := range groups {
+		e := ldap.NewSearchResultEntry(getGroupDn(group, baseObject, organization))
+		e.AddAttribute("objectClass", "top", "groupOfNames", "posixGroup")
+		e.AddAttribute("cn", message.AttributeValue(group.Name))
+		e.AddAttribute("gidNumber", message.AttributeValue(fmt.Sprintf("%v", hash(group.GetId()))))
+		if group.DisplayName != "" {
+			e.AddAttribute("description", message.AttributeValue(group.DisplayName))
+		}
+
+		members := getGroupMembers(group, users)
+		if len(members) != 0 {
+			memberDns := []message.AttributeValue{}
+			memberUids := []message.AttributeValue{}
+			for _, member := range members {
+				memberDns = append(memberDns, message.AttributeValue(getUserDn(member, baseObject, organization)))
+				memberUids = append(memberUids, message.AttributeValue(member.Name))
+			}
+			e.AddAttribute("member", memberDns...)
+			e.AddAttribute("memberUid", memberUids...)
+		}
+
+		w.Write(e)
+	}
+	w.Write(res)
+}
+
+// getMemberOf returns the DNs of the groups of the user
+func getMemberOf(user *object.User, baseObject string, organization *object.Organization) []message.AttributeValue {
+	res := []message.AttributeValue{}
+	for _, groupId := range user.Groups {
+		if !strings.Contains(groupId, "/") {
+			continue
+		}
+
+		owner, name := util.GetOwnerAndNameFromIdNoCheck(groupId)
+		if owner != user.Owner {
+			continue
+		}
+		res = append(res, message.AttributeValue(getGroupDn(&object.Group{Owner: owner, Name: name}, baseObject, organization)))
+	}
+	return res
+}
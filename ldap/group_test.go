@@ -0,0 +1,36 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMemberName(t *testing.T) {
+	assert.Equal(t, "alice", getMemberName("alice"))
+	assert.Equal(t, "alice", getMemberName("uid=alice,ou=people,dc=example,dc=com"))
+	assert.Equal(t, "alice", getMemberName("uid=5a3f,cn=alice,ou=built-in,dc=example,dc=com"))
+	assert.Equal(t, "", getMemberName("ou=people,dc=example,dc=com"))
+}
+
+func TestIsGroupSearch(t *testing.T) {
+	assert.True(t, isGroupSearch("(&(objectClass=groupOfNames)(member=uid=alice,ou=people,dc=example,dc=com))"))
+	assert.True(t, isGroupSearch("(&(objectclass=posixGroup)(memberUid=alice))"))
+	assert.False(t, isGroupSearch("(&(objectClass=inetOrgPerson)(uid=alice))"))
+}
+
+func TestGetGroupMembers(t *testing.T) {
+	organization := &object.Organization{Name: "example", LdapBaseDn: "dc=example,dc=com"}
+	group := &object.Group{Owner: "example", Name: "vpn"}
+	users := []*object.User{
+		{Owner: "example", Name: "alice", Groups: []string{"example/vpn"}},
+		{Owner: "example", Name: "bob", Groups: []string{"example/nas"}},
+	}
+
+	members := getGroupMembers(group, users)
+	assert.Equal(t, 1, len(members))
+	assert.Equal(t, "uid=alice,ou=people,dc=example,dc=com", getUserDn(members[0], "dc=example,dc=com", organization))
+	assert.Equal(t, "cn=vpn,ou=groups,dc=example,dc=com", getGroupDn(group, "dc=example,dc=com", organization))
+	assert.Equal(t, "cn=vpn,ou=example,dc=example,dc=com", getGroupDn(group, "ou=example,dc=example,dc=com", nil))
+}
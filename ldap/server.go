@@ -15,9 +15,11 @@
 package ldap
 
 import (
+	"crypto/tls"
 	"fmt"
 	"hash/fnv"
 	"log"
+	"strings"
 
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/object"
@@ -25,12 +27,7 @@ import (
 	"github.com/lor00x/goldap/message"
 )
 
-func StartLdapServer() {
-	ldapServerPort := conf.GetConfigString("ldapServerPort")
-	if ldapServerPort == "" || ldapServerPort == "0" {
-		return
-	}
-
+func newLdapServer() *ldap.Server {
 	server := ldap.NewServer()
 	routes := ldap.NewRouteMux()
 
@@ -38,12 +35,60 @@ func StartLdapServer() {
 	routes.Search(handleSearch).Label(" SEARCH****")
 
 	server.Handle(routes)
+	return server
+}
+
+func StartLdapServer() {
+	ldapServerPort := conf.GetConfigString("ldapServerPort")
+	if ldapServerPort == "" || ldapServerPort == "0" {
+		return
+	}
+
+	server := newLdapServer()
 	err := server.ListenAndServe("0.0.0.0:" + ldapServerPort)
 	if err != nil {
 		log.Printf("StartLdapServer() failed, err = %s", err.Error())
 	}
 }
 
+// getLdapsCertificate loads the TLS certificate of the LDAPS server from the cert of the "ldapsCertId" config,
+// on each handshake so the renewed cert is used without a restart
+func getLdapsCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	certId := conf.GetConfigString("ldapsCertId")
+	cert, err := object.GetCert(certId)
+	if err != nil {
+		return nil, err
+	}
+	if cert == nil {
+		return nil, fmt.Errorf("the cert: %s of the LDAPS server does not exist", certId)
+	}
+
+	res, err := tls.X509KeyPair([]byte(cert.Certificate), []byte(cert.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// StartLdapsServer serves LDAP over TLS on the "ldapsServerPort" config
+func StartLdapsServer() {
+	ldapsServerPort := conf.GetConfigString("ldapsServerPort")
+	if ldapsServerPort == "" || ldapsServerPort == "0" {
+		return
+	}
+
+	server := newLdapServer()
+	err := server.ListenAndServe("0.0.0.0:"+ldapsServerPort, func(s *ldap.Server) {
+		s.Listener = tls.NewListener(s.Listener, &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: getLdapsCertificate,
+		})
+	})
+	if err != nil {
+		log.Printf("StartLdapsServer() failed, err = %s", err.Error())
+	}
+}
+
 func handleBind(w ldap.ResponseWriter, m *ldap.Message) {
 	r := m.GetBindRequest()
 	res := ldap.NewBindResponse(ldap.LDAPResultSuccess)
@@ -106,6 +151,30 @@ func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 	default:
 	}
 
+	baseObject := string(r.BaseObject())
+	organization, err := object.GetOrganizationByLdapDn(baseObject)
+	if err != nil {
+		panic(err)
+	}
+
+	if isGroupSearch(r.FilterString()) {
+		org := ""
+		if organization != nil {
+			org = organization.Name
+		} else {
+			var code int
+			_, org, code = getNameAndOrgFromFilter(baseObject, "")
+			if code != ldap.LDAPResultSuccess {
+				res.SetResultCode(code)
+				w.Write(res)
+				return
+			}
+		}
+
+		handleGroupSearch(w, m, org, organization)
+		return
+	}
+
 	users, code := GetFilteredUsers(m)
 	if code != ldap.LDAPResultSuccess {
 		res.SetResultCode(code)
@@ -114,8 +183,8 @@ func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 	}
 
 	for _, user := range users {
-		dn := fmt.Sprintf("uid=%s,cn=%s,%s", user.Id, user.Name, string(r.BaseObject()))
-		e := ldap.NewSearchResultEntry(dn)
+		e := ldap.NewSearchResultEntry(getUserDn(user, baseObject, organization))
+		e.AddAttribute("objectClass", "top", "person", "organizationalPerson", "inetOrgPerson", "posixAccount")
 		uidNumberStr := fmt.Sprintf("%v", hash(user.Name))
 		e.AddAttribute("uidNumber", message.AttributeValue(uidNumberStr))
 		e.AddAttribute("gidNumber", message.AttributeValue(uidNumberStr))
@@ -123,6 +192,14 @@ func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 		e.AddAttribute("cn", message.AttributeValue(user.Name))
 		e.AddAttribute("uid", message.AttributeValue(user.Id))
 		attrs := r.Attributes()
+		for _, attr := range attrs {
+			if string(attr) == "*" || strings.EqualFold(string(attr), "memberOf") {
+				if memberOf := getMemberOf(user, baseObject, organization); len(memberOf) != 0 {
+					e.AddAttribute("memberOf", memberOf...)
+				}
+				break
+			}
+		}
 		for _, attr := range attrs {
 			if string(attr) == "*" {
 				attrs = AdditionalLdapAttributes
@@ -130,6 +207,9 @@ func handleSearch(w ldap.ResponseWriter, m *ldap.Message) {
 			}
 		}
 		for _, attr := range attrs {
+			if strings.EqualFold(string(attr), "memberOf") {
+				continue
+			}
 			e.AddAttribute(message.AttributeDescription(attr), getAttribute(string(attr), user))
 			if string(attr) == "cn" {
 				e.AddAttribute(message.AttributeDescription(attr), getAttribute("title", user))
@@ -100,6 +100,18 @@ func getNameAndOrgFromDN(DN string) (string, string, error) {
 		}
 	}
 
+	organization, err := object.GetOrganizationByLdapDn(DN)
+	if err != nil {
+		return "", "", err
+	}
+	if organization != nil {
+		name := getMemberName(DN)
+		if name == "" {
+			return "", "", fmt.Errorf("please use the DN format like uid=xxx,ou=people,%s", organization.LdapBaseDn)
+		}
+		return name, organization.Name, nil
+	}
+
 	if params["cn"] == "" {
 		return "", "", fmt.Errorf("please use Admin Name format like cn=xxx,ou=xxx,dc=example,dc=com")
 	}
@@ -110,7 +122,12 @@ func getNameAndOrgFromDN(DN string) (string, string, error) {
 }
 
 func getNameAndOrgFromFilter(baseDN, filter string) (string, string, int) {
-	if !strings.Contains(baseDN, "ou=") {
+	organization, err := object.GetOrganizationByLdapDn(baseDN)
+	if err != nil {
+		panic(err)
+	}
+
+	if organization == nil && !strings.Contains(baseDN, "ou=") {
 		return "", "", ldap.LDAPResultInvalidDNSyntax
 	}
 
@@ -34,6 +34,7 @@ func main() {
 	object.InitFlag()
 	object.InitAdapter()
 	object.CreateTables()
+	object.RunBackupCommand()
 
 	object.InitDb()
 	object.InitFromFile()
@@ -43,8 +44,30 @@ func main() {
 	authz.InitApi()
 	object.InitUserManager()
 	object.InitCasvisorConfig()
+	object.InitRecordWriter()
+	object.InitTranslationBackend()
 
 	util.SafeGoroutine(func() { object.RunSyncUsersJob() })
+	util.SafeGoroutine(func() { object.RunPolicyGcJob() })
+	util.SafeGoroutine(func() { object.RunCertRotationJob() })
+	util.SafeGoroutine(func() { object.RunShareExpirationJob() })
+	util.SafeGoroutine(func() { object.RunAccessRequestExpirationJob() })
+	util.SafeGoroutine(func() { object.RunAccessReviewJob() })
+	util.SafeGoroutine(func() { object.RunCacheInvalidationJob() })
+	util.SafeGoroutine(func() { object.RunRecordWriterJob() })
+	util.SafeGoroutine(func() { object.RunWebhookDispatcherJob() })
+	util.SafeGoroutine(func() { object.RunExportWorkerJob() })
+	util.SafeGoroutine(func() { object.RunUserReactivationJob() })
+	util.SafeGoroutine(func() { object.RunProvisionerReconcileJob() })
+	util.SafeGoroutine(func() { object.RunUserLifecycleJob() })
+	util.SafeGoroutine(func() { object.RunSubscriptionDunningJob() })
+	util.SafeGoroutine(func() { object.RunUsageJob() })
+	util.SafeGoroutine(func() { object.RunRetentionJob() })
+	util.SafeGoroutine(func() { object.RunProviderHealthJob() })
+	util.SafeGoroutine(func() { object.RunResourceLifecycleJob() })
+	util.SafeGoroutine(func() { object.RunEnforceSnapshotJob() })
+	util.SafeGoroutine(func() { object.RunUserActivationJob() })
+	util.SafeGoroutine(func() { object.RunSamlMetadataRefreshJob() })
 
 	// beego.DelStaticPath("/static")
 	// beego.SetStaticPath("/static", "web/build/static")
@@ -56,6 +79,7 @@ func main() {
 	beego.InsertFilter("*", beego.BeforeRouter, routers.StaticFilter)
 	beego.InsertFilter("*", beego.BeforeRouter, routers.AutoSigninFilter)
 	beego.InsertFilter("*", beego.BeforeRouter, routers.CorsFilter)
+	beego.InsertFilter("*", beego.BeforeRouter, routers.CsrfFilter)
 	beego.InsertFilter("*", beego.BeforeRouter, routers.ApiFilter)
 	beego.InsertFilter("*", beego.BeforeRouter, routers.PrometheusFilter)
 	beego.InsertFilter("*", beego.BeforeRouter, routers.RecordMessage)
@@ -81,7 +105,9 @@ func main() {
 	logs.SetLogFuncCall(false)
 
 	go ldap.StartLdapServer()
+	go ldap.StartLdapsServer()
 	go radius.StartRadiusServer()
+	go routers.StartAcmeServer()
 	go object.ClearThroughputPerSecond()
 
 	beego.Run(fmt.Sprintf(":%v", port))
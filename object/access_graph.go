@@ -0,0 +1,257 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	AccessGraphNodeUser       = "user"
+	AccessGraphNodeGroup      = "group"
+	AccessGraphNodeRole       = "role"
+	AccessGraphNodePermission = "permission"
+	AccessGraphNodeResource   = "resource"
+
+	AccessGraphEdgeMemberOf  = "member_of"
+	AccessGraphEdgeChildOf   = "child_of"
+	AccessGraphEdgeGranted   = "granted"
+	AccessGraphEdgeAppliesTo = "applies_to"
+)
+
+type AccessGraphNode struct {
+	Id          string            `json:"id"`
+	Type        string            `json:"type"`
+	Name        string            `json:"name"`
+	DisplayName string            `json:"displayName"`
+	Properties  map[string]string `json:"properties,omitempty"`
+}
+
+type AccessGraphEdge struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Type   string `json:"type"`
+}
+
+// AccessGraph is the graph of the access relationships of the organization, the users, groups, roles, permissions and
+// resources are the nodes, and the memberships and grants are the edges pointing to the node giving the access.
+// The users of other organizations in the roles and permissions are included as nodes too.
+type AccessGraph struct {
+	Organization string             `json:"organization"`
+	Nodes        []*AccessGraphNode `json:"nodes"`
+	Edges        []*AccessGraphEdge `json:"edges"`
+
+	nodeMap map[string]*AccessGraphNode
+}
+
+func getAccessGraphNodeId(nodeType string, name string) string {
+	return nodeType + ":" + name
+}
+
+// addNode adds the node once, the display name and properties of the first addition are kept
+func (graph *AccessGraph) addNode(nodeType string, name string, displayName string, properties map[string]string) string {
+	id := getAccessGraphNodeId(nodeType, name)
+	if graph.nodeMap[id] == nil {
+		node := &AccessGraphNode{Id: id, Type: nodeType, Name: name, DisplayName: displayName, Properties: properties}
+		graph.nodeMap[id] = node
+		graph.Nodes = append(graph.Nodes, node)
+	}
+	return id
+}
+
+func (graph *AccessGraph) addEdge(source string, target string, edgeType string) {
+	graph.Edges = append(graph.Edges, &AccessGraphEdge{Source: source, Target: target, Type: edgeType})
+}
+
+// addSubjectEdges adds the edges from the users, groups and roles of a role or permission to its node
+func (graph *AccessGraph) addSubjectEdges(owner string, target string, users []string, groups []string, roles []string, edgeType string) {
+	for _, userId := range users {
+		if userId == "*" {
+			userId = util.GetId(owner, "*")
+		}
+		graph.addEdge(graph.addNode(AccessGraphNodeUser, userId, "", nil), target, edgeType)
+	}
+	for _, groupId := range groups {
+		graph.addEdge(graph.addNode(AccessGraphNodeGroup, groupId, "", nil), target, edgeType)
+	}
+	for _, roleId := range roles {
+		graph.addEdge(graph.addNode(AccessGraphNodeRole, roleId, "", nil), target, edgeType)
+	}
+}
+
+func newAccessGraph(organization string, users []*User, groups []*Group, roles []*Role, permissions []*Permission) *AccessGraph {
+	graph := &AccessGraph{
+		Organization: organization,
+		Nodes:        []*AccessGraphNode{},
+		Edges:        []*AccessGraphEdge{},
+		nodeMap:      map[string]*AccessGraphNode{},
+	}
+
+	for _, user := range users {
+		graph.addNode(AccessGraphNodeUser, user.GetId(), user.DisplayName, nil)
+	}
+	for _, group := range groups {
+		graph.addNode(AccessGraphNodeGroup, group.GetId(), group.DisplayName, nil)
+	}
+	for _, role := range roles {
+		graph.addNode(AccessGraphNodeRole, role.GetId(), role.DisplayName, map[string]string{"isEnabled": util.BoolToString(role.IsEnabled)})
+	}
+	for _, permission := range permissions {
+		graph.addNode(AccessGraphNodePermission, permission.GetId(), permission.DisplayName, map[string]string{
+			"actions":   strings.Join(permission.Actions, ","),
+			"effect":    permission.Effect,
+			"isEnabled": util.BoolToString(permission.IsEnabled),
+		})
+	}
+
+	for _, user := range users {
+		for _, groupId := range user.Groups {
+			graph.addEdge(getAccessGraphNodeId(AccessGraphNodeUser, user.GetId()), graph.addNode(AccessGraphNodeGroup, groupId, "", nil), AccessGraphEdgeMemberOf)
+		}
+	}
+
+	for _, group := range groups {
+		if group.IsTopGroup || group.ParentId == "" {
+			continue
+		}
+		graph.addEdge(getAccessGraphNodeId(AccessGraphNodeGroup, group.GetId()), graph.addNode(AccessGraphNodeGroup, util.GetId(group.Owner, group.ParentId), "", nil), AccessGraphEdgeChildOf)
+	}
+
+	for _, role := range roles {
+		graph.addSubjectEdges(role.Owner, getAccessGraphNodeId(AccessGraphNodeRole, role.GetId()), role.Users, role.Groups, role.Roles, AccessGraphEdgeMemberOf)
+	}
+
+	for _, permission := range permissions {
+		permissionId := getAccessGraphNodeId(AccessGraphNodePermission, permission.GetId())
+		graph.addSubjectEdges(permission.Owner, permissionId, permission.Users, permission.Groups, permission.Roles, AccessGraphEdgeGranted)
+
+		for _, resource := range permission.Resources {
+			resourceId := graph.addNode(AccessGraphNodeResource, util.GetId(permission.ResourceType, resource), resource, map[string]string{"resourceType": permission.ResourceType})
+			graph.addEdge(permissionId, resourceId, AccessGraphEdgeAppliesTo)
+		}
+	}
+
+	return graph
+}
+
+// GetAccessGraph builds the access graph of the organization
+func GetAccessGraph(organization string) (*AccessGraph, error) {
+	users, err := GetUsers(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := GetGroups(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	roles, err := GetRoles(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := GetPermissions(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	return newAccessGraph(organization, users, groups, roles, permissions), nil
+}
+
+type graphmlKey struct {
+	Id       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphmlData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphmlNode struct {
+	Id   string        `xml:"id,attr"`
+	Data []graphmlData `xml:"data"`
+}
+
+type graphmlEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphmlData `xml:"data"`
+}
+
+type graphmlGraph struct {
+	Id          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphmlNode `xml:"node"`
+	Edges       []graphmlEdge `xml:"edge"`
+}
+
+type graphmlDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphmlKey `xml:"key"`
+	Graph   graphmlGraph `xml:"graph"`
+}
+
+var accessGraphNodeKeys = []string{"name", "displayName", "actions", "effect", "isEnabled", "resourceType"}
+
+// ToGraphml encodes the graph in GraphML, the node type is the "labels" data and the edge type is the "label" data,
+// which are the node labels and relationship types of Neo4j when imported by APOC
+func (graph *AccessGraph) ToGraphml() ([]byte, error) {
+	document := graphmlDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphmlKey{
+			{Id: "labels", For: "node", AttrName: "labels", AttrType: "string"},
+			{Id: "label", For: "edge", AttrName: "label", AttrType: "string"},
+		},
+		Graph: graphmlGraph{Id: graph.Organization, EdgeDefault: "directed"},
+	}
+	for _, key := range accessGraphNodeKeys {
+		document.Keys = append(document.Keys, graphmlKey{Id: key, For: "node", AttrName: key, AttrType: "string"})
+	}
+
+	for _, node := range graph.Nodes {
+		graphmlNode := graphmlNode{Id: node.Id, Data: []graphmlData{{Key: "labels", Value: ":" + node.Type}}}
+		for _, key := range accessGraphNodeKeys {
+			value := node.Properties[key]
+			if key == "name" {
+				value = node.Name
+			} else if key == "displayName" {
+				value = node.DisplayName
+			}
+
+			if value != "" {
+				graphmlNode.Data = append(graphmlNode.Data, graphmlData{Key: key, Value: value})
+			}
+		}
+		document.Graph.Nodes = append(document.Graph.Nodes, graphmlNode)
+	}
+
+	for _, edge := range graph.Edges {
+		document.Graph.Edges = append(document.Graph.Edges, graphmlEdge{Source: edge.Source, Target: edge.Target, Data: []graphmlData{{Key: "label", Value: edge.Type}}})
+	}
+
+	data, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
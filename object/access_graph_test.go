@@ -0,0 +1,69 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAccessGraph(t *testing.T) {
+	users := []*User{{Owner: "acme", Name: "alice", Groups: []string{"acme/dev"}}}
+	groups := []*Group{{Owner: "acme", Name: "dev", ParentId: "eng"}, {Owner: "acme", Name: "eng", ParentId: "acme", IsTopGroup: true}}
+	roles := []*Role{{Owner: "acme", Name: "admin", Users: []string{"partner/bob"}, Groups: []string{"acme/eng"}}}
+	permissions := []*Permission{{Owner: "acme", Name: "erp", Roles: []string{"acme/admin"}, Users: []string{"*"}, ResourceType: "Application", Resources: []string{"erp"}, Actions: []string{"Read", "Write"}, Effect: "Allow"}}
+
+	graph := newAccessGraph("acme", users, groups, roles, permissions)
+
+	nodeTypes := map[string]string{}
+	for _, node := range graph.Nodes {
+		nodeTypes[node.Id] = node.Type
+	}
+	assert.Equal(t, AccessGraphNodeUser, nodeTypes["user:partner/bob"])
+	assert.Equal(t, AccessGraphNodeUser, nodeTypes["user:acme/*"])
+	assert.Equal(t, AccessGraphNodeResource, nodeTypes["resource:Application/erp"])
+	assert.Equal(t, 8, len(graph.Nodes))
+
+	edges := []string{}
+	for _, edge := range graph.Edges {
+		edges = append(edges, edge.Source+" -"+edge.Type+"-> "+edge.Target)
+	}
+	assert.ElementsMatch(t, []string{
+		"user:acme/alice -member_of-> group:acme/dev",
+		"group:acme/dev -child_of-> group:acme/eng",
+		"user:partner/bob -member_of-> role:acme/admin",
+		"group:acme/eng -member_of-> role:acme/admin",
+		"user:acme/* -granted-> permission:acme/erp",
+		"role:acme/admin -granted-> permission:acme/erp",
+		"permission:acme/erp -applies_to-> resource:Application/erp",
+	}, edges)
+}
+
+func TestAccessGraphToGraphml(t *testing.T) {
+	permissions := []*Permission{{Owner: "acme", Name: "erp", Users: []string{"acme/alice"}, ResourceType: "Application", Resources: []string{"erp"}, Actions: []string{"Read"}, Effect: "Allow"}}
+	graph := newAccessGraph("acme", nil, nil, nil, permissions)
+
+	data, err := graph.ToGraphml()
+	assert.Nil(t, err)
+
+	graphml := string(data)
+	assert.True(t, strings.HasPrefix(graphml, "<?xml"))
+	assert.Contains(t, graphml, `<graph id="acme" edgedefault="directed">`)
+	assert.Contains(t, graphml, `<data key="labels">:permission</data>`)
+	assert.Contains(t, graphml, `<data key="actions">Read</data>`)
+	assert.Contains(t, graphml, `<edge source="user:acme/alice" target="permission:acme/erp">`)
+}
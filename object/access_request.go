@@ -0,0 +1,440 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
+)
+
+const (
+	AccessRequestTypeRole       = "Role"
+	AccessRequestTypePermission = "Permission"
+
+	AccessRequestStatePending   = "Pending"
+	AccessRequestStateApproved  = "Approved"
+	AccessRequestStateRejected  = "Rejected"
+	AccessRequestStateCancelled = "Cancelled"
+	AccessRequestStateExpired   = "Expired"
+)
+
+// AccessRequest is a self-service request of a user to join a role or a permission. It is routed
+// to the approvers of the role or permission (or the organization admins if there is none),
+// and the user is added to the role or permission once it is approved.
+type AccessRequest struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User          string   `xorm:"varchar(100) index" json:"user"`
+	Type          string   `xorm:"varchar(100)" json:"type"`
+	Target        string   `xorm:"varchar(100)" json:"target"`
+	Justification string   `xorm:"varchar(1000)" json:"justification"`
+	Approvers     []string `xorm:"mediumtext" json:"approvers"`
+	ExpireTime    string   `xorm:"varchar(100)" json:"expireTime"`
+
+	State       string `xorm:"varchar(100) index" json:"state"`
+	Approver    string `xorm:"varchar(100)" json:"approver"`
+	ApproveTime string `xorm:"varchar(100)" json:"approveTime"`
+	Comment     string `xorm:"varchar(1000)" json:"comment"`
+}
+
+func GetAccessRequestCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&AccessRequest{})
+}
+
+func GetAccessRequests(owner string) ([]*AccessRequest, error) {
+	accessRequests := []*AccessRequest{}
+	err := ormer.Engine.Desc("created_time").Find(&accessRequests, &AccessRequest{Owner: owner})
+	if err != nil {
+		return accessRequests, err
+	}
+
+	return accessRequests, nil
+}
+
+func GetPaginationAccessRequests(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*AccessRequest, error) {
+	accessRequests := []*AccessRequest{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&accessRequests)
+	if err != nil {
+		return accessRequests, err
+	}
+
+	return accessRequests, nil
+}
+
+// GetUserAccessRequests returns the requests submitted by the user
+func GetUserAccessRequests(owner string, user string) ([]*AccessRequest, error) {
+	accessRequests := []*AccessRequest{}
+	err := ormer.Engine.Desc("created_time").Find(&accessRequests, &AccessRequest{Owner: owner, User: user})
+	if err != nil {
+		return accessRequests, err
+	}
+
+	return accessRequests, nil
+}
+
+// GetPendingAccessRequestsForApprover returns the pending requests that the user is able to approve
+func GetPendingAccessRequestsForApprover(approver *User) ([]*AccessRequest, error) {
+	accessRequests := []*AccessRequest{}
+	session := ormer.Engine.Where("state = ?", AccessRequestStatePending)
+	if !approver.IsGlobalAdmin() {
+		session = session.And("owner = ?", approver.Owner)
+	}
+
+	err := session.Desc("created_time").Find(&accessRequests)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*AccessRequest{}
+	for _, accessRequest := range accessRequests {
+		if accessRequest.CanBeApprovedBy(approver) {
+			res = append(res, accessRequest)
+		}
+	}
+	return res, nil
+}
+
+func getAccessRequest(owner string, name string) (*AccessRequest, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	accessRequest := AccessRequest{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&accessRequest)
+	if err != nil {
+		return &accessRequest, err
+	}
+
+	if existed {
+		return &accessRequest, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetAccessRequest(id string) (*AccessRequest, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getAccessRequest(owner, name)
+}
+
+func (accessRequest *AccessRequest) GetId() string {
+	return fmt.Sprintf("%s/%s", accessRequest.Owner, accessRequest.Name)
+}
+
+func (accessRequest *AccessRequest) getUserId() string {
+	return util.GetId(accessRequest.Owner, accessRequest.User)
+}
+
+func (accessRequest *AccessRequest) getTargetId() string {
+	return util.GetId(accessRequest.Owner, accessRequest.Target)
+}
+
+func (accessRequest *AccessRequest) IsExpired() bool {
+	if accessRequest.ExpireTime == "" {
+		return false
+	}
+
+	expireTime, err := time.Parse(time.RFC3339, accessRequest.ExpireTime)
+	if err != nil {
+		return true
+	}
+	return time.Now().After(expireTime)
+}
+
+// CanBeApprovedBy reports whether the user is one of the approvers or an admin of the organization,
+// users are never allowed to approve their own requests.
+func (accessRequest *AccessRequest) CanBeApprovedBy(user *User) bool {
+	if user == nil || (user.Owner == accessRequest.Owner && user.Name == accessRequest.User) {
+		return false
+	}
+
+	if user.IsGlobalAdmin() || (user.IsAdmin && user.Owner == accessRequest.Owner) {
+		return true
+	}
+
+	return util.InSlice(accessRequest.Approvers, user.GetId())
+}
+
+// getAccessTarget returns the users and the approvers of the role or permission
+func getAccessTarget(targetType string, targetId string, lang string) ([]string, []string, error) {
+	switch targetType {
+	case AccessRequestTypeRole:
+		role, err := GetRole(targetId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if role == nil {
+			return nil, nil, fmt.Errorf(i18n.Translate(lang, "general:The role: %s does not exist"), targetId)
+		}
+		return role.Users, role.Approvers, nil
+	case AccessRequestTypePermission:
+		permission, err := GetPermission(targetId)
+		if err != nil {
+			return nil, nil, err
+		}
+		if permission == nil {
+			return nil, nil, fmt.Errorf(i18n.Translate(lang, "general:The permission: %s does not exist"), targetId)
+		}
+		return permission.Users, permission.Approvers, nil
+	}
+
+	return nil, nil, fmt.Errorf(i18n.Translate(lang, "general:Unknown type: %s"), targetType)
+}
+
+// getAccessRequestTarget returns the users and the approvers of the requested role or permission
+func getAccessRequestTarget(accessRequest *AccessRequest, lang string) ([]string, []string, error) {
+	return getAccessTarget(accessRequest.Type, accessRequest.getTargetId(), lang)
+}
+
+func getAccessRequestRecord(accessRequest *AccessRequest, action string) *casvisorsdk.Record {
+	return &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: accessRequest.Owner,
+		User:         accessRequest.User,
+		Method:       "POST",
+		Action:       action,
+		Object:       util.StructToJson(accessRequest),
+	}
+}
+
+// hasPendingAccessRequest checks whether the user already has a pending request for the same role or permission
+func hasPendingAccessRequest(accessRequest *AccessRequest) (bool, error) {
+	count, err := ormer.Engine.Count(&AccessRequest{
+		Owner:  accessRequest.Owner,
+		User:   accessRequest.User,
+		Type:   accessRequest.Type,
+		Target: accessRequest.Target,
+		State:  AccessRequestStatePending,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func AddAccessRequest(accessRequest *AccessRequest, user *User, lang string) (bool, error) {
+	accessRequest.Owner = user.Owner
+	accessRequest.Name = util.GenerateId()
+	accessRequest.CreatedTime = util.GetCurrentTime()
+	accessRequest.User = user.Name
+	accessRequest.State = AccessRequestStatePending
+	accessRequest.Approver = ""
+	accessRequest.ApproveTime = ""
+	accessRequest.Comment = ""
+
+	if accessRequest.ExpireTime != "" {
+		if _, err := time.Parse(time.RFC3339, accessRequest.ExpireTime); err != nil {
+			return false, fmt.Errorf(i18n.Translate(lang, "general:The time: %s is not in RFC3339 format"), accessRequest.ExpireTime)
+		}
+		if accessRequest.IsExpired() {
+			return false, fmt.Errorf(i18n.Translate(lang, "general:The expire time: %s is in the past"), accessRequest.ExpireTime)
+		}
+	}
+
+	users, approvers, err := getAccessRequestTarget(accessRequest, lang)
+	if err != nil {
+		return false, err
+	}
+	if util.InSlice(users, accessRequest.getUserId()) {
+		return false, fmt.Errorf(i18n.Translate(lang, "general:The user: %s already has the %s: %s"), accessRequest.getUserId(), accessRequest.Type, accessRequest.Target)
+	}
+
+	hasPending, err := hasPendingAccessRequest(accessRequest)
+	if err != nil {
+		return false, err
+	}
+	if hasPending {
+		return false, fmt.Errorf(i18n.Translate(lang, "general:There is already a pending request for the %s: %s"), accessRequest.Type, accessRequest.Target)
+	}
+
+	accessRequest.Approvers = approvers
+	if accessRequest.Approvers == nil {
+		accessRequest.Approvers = []string{}
+	}
+
+	return runWithRecord(getAccessRequestRecord(accessRequest, "access-requested"), func(session *xorm.Session) (bool, error) {
+		affected, err := session.Insert(accessRequest)
+		return affected != 0, err
+	})
+}
+
+// updateAccessTargetUsers adds the user to the role or permission, or removes the user from it
+func updateAccessTargetUsers(targetType string, targetId string, userId string, isAdding bool) error {
+	getUsers := func(users []string) []string {
+		if isAdding {
+			if util.InSlice(users, userId) {
+				return users
+			}
+			return append(users, userId)
+		}
+		return util.DeleteVal(users, userId)
+	}
+
+	switch targetType {
+	case AccessRequestTypeRole:
+		role, err := GetRole(targetId)
+		if err != nil || role == nil {
+			return err
+		}
+
+		role.Users = getUsers(role.Users)
+		_, err = UpdateRole(role.GetId(), role)
+		return err
+	case AccessRequestTypePermission:
+		permission, err := GetPermission(targetId)
+		if err != nil || permission == nil {
+			return err
+		}
+
+		permission.Users = getUsers(permission.Users)
+		_, err = UpdatePermission(permission.GetId(), permission)
+		return err
+	}
+
+	return nil
+}
+
+// updateAccessRequestTargetUsers adds the requester to the role or permission, or removes the requester from it
+func updateAccessRequestTargetUsers(accessRequest *AccessRequest, isAdding bool) error {
+	return updateAccessTargetUsers(accessRequest.Type, accessRequest.getTargetId(), accessRequest.getUserId(), isAdding)
+}
+
+func updateAccessRequestState(db xorm.Interface, accessRequest *AccessRequest) (bool, error) {
+	affected, err := db.ID(core.PK{accessRequest.Owner, accessRequest.Name}).Cols("state", "approver", "approve_time", "comment").Update(accessRequest)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// review moves the pending request to the approved or rejected state
+func (accessRequest *AccessRequest) review(approver *User, isApproved bool, comment string, lang string) error {
+	if accessRequest.State != AccessRequestStatePending {
+		return fmt.Errorf(i18n.Translate(lang, "general:The request: %s is already %s"), accessRequest.GetId(), accessRequest.State)
+	}
+
+	if !accessRequest.CanBeApprovedBy(approver) {
+		return fmt.Errorf(i18n.Translate(lang, "auth:Unauthorized operation"))
+	}
+
+	if isApproved && accessRequest.IsExpired() {
+		return fmt.Errorf(i18n.Translate(lang, "general:The expire time: %s is in the past"), accessRequest.ExpireTime)
+	}
+
+	accessRequest.Approver = approver.GetId()
+	accessRequest.ApproveTime = util.GetCurrentTime()
+	accessRequest.Comment = comment
+	accessRequest.State = AccessRequestStateRejected
+	if isApproved {
+		accessRequest.State = AccessRequestStateApproved
+	}
+	return nil
+}
+
+// ReviewAccessRequest approves or rejects the pending request, the requester is added to the role
+// or permission when it is approved.
+func ReviewAccessRequest(accessRequest *AccessRequest, approver *User, isApproved bool, comment string, lang string) (bool, error) {
+	err := accessRequest.review(approver, isApproved, comment, lang)
+	if err != nil {
+		return false, err
+	}
+
+	if accessRequest.State == AccessRequestStateApproved {
+		err = updateAccessRequestTargetUsers(accessRequest, true)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return runWithRecord(getAccessRequestRecord(accessRequest, "access-request-reviewed"), func(session *xorm.Session) (bool, error) {
+		return updateAccessRequestState(session, accessRequest)
+	})
+}
+
+func CancelAccessRequest(accessRequest *AccessRequest, lang string) (bool, error) {
+	if accessRequest.State != AccessRequestStatePending {
+		return false, fmt.Errorf(i18n.Translate(lang, "general:The request: %s is already %s"), accessRequest.GetId(), accessRequest.State)
+	}
+
+	accessRequest.State = AccessRequestStateCancelled
+	return updateAccessRequestState(ormer.Engine, accessRequest)
+}
+
+// getExpiredAccessRequests returns the approved requests whose expire time has passed
+func getExpiredAccessRequests() ([]*AccessRequest, error) {
+	accessRequests := []*AccessRequest{}
+	err := ormer.Engine.Where("expire_time != ?", "").Find(&accessRequests, &AccessRequest{State: AccessRequestStateApproved})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*AccessRequest{}
+	for _, accessRequest := range accessRequests {
+		if accessRequest.IsExpired() {
+			res = append(res, accessRequest)
+		}
+	}
+	return res, nil
+}
+
+func expireAccessRequests() error {
+	accessRequests, err := getExpiredAccessRequests()
+	if err != nil {
+		return err
+	}
+
+	for _, accessRequest := range accessRequests {
+		err = updateAccessRequestTargetUsers(accessRequest, false)
+		if err != nil {
+			return err
+		}
+
+		accessRequest.State = AccessRequestStateExpired
+		_, err = runWithRecord(getAccessRequestRecord(accessRequest, "access-request-expired"), func(session *xorm.Session) (bool, error) {
+			return updateAccessRequestState(session, accessRequest)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunAccessRequestExpirationJob removes the users from the roles and permissions whose approved requests have expired
+func RunAccessRequestExpirationJob() {
+	for {
+		err := expireAccessRequests()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("access request expiration failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
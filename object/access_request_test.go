@@ -0,0 +1,89 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewAccessRequest(t *testing.T) {
+	approver := &User{Owner: "org", Name: "bob", IsAdmin: true}
+
+	accessRequest := &AccessRequest{Owner: "org", Name: "r1", User: "alice", State: AccessRequestStatePending}
+	assert.Nil(t, accessRequest.review(approver, true, "ok", "en"))
+	assert.Equal(t, AccessRequestStateApproved, accessRequest.State)
+	assert.Equal(t, "org/bob", accessRequest.Approver)
+	assert.Equal(t, "ok", accessRequest.Comment)
+
+	// the reviewed request can't be reviewed again
+	assert.NotNil(t, accessRequest.review(approver, false, "", "en"))
+	assert.Equal(t, AccessRequestStateApproved, accessRequest.State)
+
+	accessRequest = &AccessRequest{Owner: "org", Name: "r2", User: "alice", State: AccessRequestStatePending}
+	assert.Nil(t, accessRequest.review(approver, false, "no", "en"))
+	assert.Equal(t, AccessRequestStateRejected, accessRequest.State)
+
+	// the requester can't approve the own request
+	accessRequest = &AccessRequest{Owner: "org", Name: "r3", User: "alice", State: AccessRequestStatePending}
+	assert.NotNil(t, accessRequest.review(&User{Owner: "org", Name: "alice", IsAdmin: true}, true, "", "en"))
+	assert.Equal(t, AccessRequestStatePending, accessRequest.State)
+
+	// the expired request can only be rejected
+	accessRequest = &AccessRequest{Owner: "org", Name: "r4", User: "alice", State: AccessRequestStatePending, ExpireTime: time.Now().Add(-time.Hour).Format(time.RFC3339)}
+	assert.NotNil(t, accessRequest.review(approver, true, "", "en"))
+	assert.Nil(t, accessRequest.review(approver, false, "", "en"))
+	assert.Equal(t, AccessRequestStateRejected, accessRequest.State)
+}
+
+func TestAccessRequestStates(t *testing.T) {
+	setTestOrmer(t, new(AccessRequest))
+
+	past := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	accessRequests := []*AccessRequest{
+		{Owner: "org", Name: "pending", User: "alice", Type: AccessRequestTypeRole, Target: "viewer", State: AccessRequestStatePending},
+		{Owner: "org", Name: "expired", User: "alice", Type: AccessRequestTypeRole, Target: "editor", State: AccessRequestStateApproved, ExpireTime: past},
+		{Owner: "org", Name: "active", User: "alice", Type: AccessRequestTypeRole, Target: "admin", State: AccessRequestStateApproved, ExpireTime: future},
+		{Owner: "org", Name: "permanent", User: "bob", Type: AccessRequestTypeRole, Target: "editor", State: AccessRequestStateApproved},
+		{Owner: "org", Name: "rejected", User: "bob", Type: AccessRequestTypeRole, Target: "viewer", State: AccessRequestStateRejected, ExpireTime: past},
+	}
+	_, err := ormer.Engine.Insert(&accessRequests)
+	assert.Nil(t, err)
+
+	hasPending, err := hasPendingAccessRequest(&AccessRequest{Owner: "org", User: "alice", Type: AccessRequestTypeRole, Target: "viewer"})
+	assert.Nil(t, err)
+	assert.True(t, hasPending)
+
+	hasPending, err = hasPendingAccessRequest(&AccessRequest{Owner: "org", User: "bob", Type: AccessRequestTypeRole, Target: "viewer"})
+	assert.Nil(t, err)
+	assert.False(t, hasPending)
+
+	// only the approved requests past their expire time are expired
+	expiredRequests, err := getExpiredAccessRequests()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(expiredRequests))
+	assert.Equal(t, "expired", expiredRequests[0].Name)
+
+	expiredRequests[0].State = AccessRequestStateExpired
+	_, err = updateAccessRequestState(ormer.Engine, expiredRequests[0])
+	assert.Nil(t, err)
+
+	expiredRequests, err = getExpiredAccessRequests()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(expiredRequests))
+}
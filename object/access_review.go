@@ -0,0 +1,599 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/xorm-io/core"
+)
+
+const (
+	AccessReviewStateIdle       = "Idle"
+	AccessReviewStateInProgress = "InProgress"
+	AccessReviewStateFinished   = "Finished"
+
+	AccessReviewItemStatePending    = "Pending"
+	AccessReviewItemStateCertified  = "Certified"
+	AccessReviewItemStateRevoked    = "Revoked"
+	AccessReviewItemStateExpired    = "Expired"
+	AccessReviewItemStateUnreviewed = "Unreviewed"
+)
+
+// AccessReviewCampaign is a periodic review of the users assigned to the roles and permissions of the organization.
+// Each round creates a review item for every assignment, the reviewers certify or revoke them before the round ends,
+// and the assignments that are not reviewed in time are revoked if AutoRevoke is enabled.
+type AccessReviewCampaign struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	// Types are "Role" and "Permission", Targets are the ids of the reviewed ones, all of the types if empty
+	Types   []string `xorm:"mediumtext" json:"types"`
+	Targets []string `xorm:"mediumtext" json:"targets"`
+	// Reviewers are the user ids of the reviewers, the approvers of the roles and permissions
+	// (or the organization admins if there is none) review them if empty
+	Reviewers []string `xorm:"mediumtext" json:"reviewers"`
+
+	// StartTime is when the first round starts, IntervalDays is between the starts of the rounds (0 for a one-off campaign)
+	StartTime    string `xorm:"varchar(100)" json:"startTime"`
+	IntervalDays int    `json:"intervalDays"`
+	DurationDays int    `json:"durationDays"`
+	AutoRevoke   bool   `json:"autoRevoke"`
+	IsEnabled    bool   `json:"isEnabled"`
+
+	State          string `xorm:"varchar(100)" json:"state"`
+	Round          int    `json:"round"`
+	RoundStartTime string `xorm:"varchar(100)" json:"roundStartTime"`
+	RoundEndTime   string `xorm:"varchar(100)" json:"roundEndTime"`
+	NextRunTime    string `xorm:"varchar(100)" json:"nextRunTime"`
+}
+
+// AccessReviewItem is the review of a user assigned to a role or permission in a round of the campaign
+type AccessReviewItem struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Campaign  string   `xorm:"varchar(100) index" json:"campaign"`
+	Round     int      `json:"round"`
+	Type      string   `xorm:"varchar(100)" json:"type"`
+	Target    string   `xorm:"varchar(100)" json:"target"`
+	User      string   `xorm:"varchar(100)" json:"user"`
+	Reviewers []string `xorm:"mediumtext" json:"reviewers"`
+	DueTime   string   `xorm:"varchar(100)" json:"dueTime"`
+
+	State      string `xorm:"varchar(100) index" json:"state"`
+	Reviewer   string `xorm:"varchar(100)" json:"reviewer"`
+	ReviewTime string `xorm:"varchar(100)" json:"reviewTime"`
+	Comment    string `xorm:"varchar(1000)" json:"comment"`
+}
+
+func GetAccessReviewCampaignCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&AccessReviewCampaign{})
+}
+
+func GetAccessReviewCampaigns(owner string) ([]*AccessReviewCampaign, error) {
+	campaigns := []*AccessReviewCampaign{}
+	err := ormer.Engine.Desc("created_time").Find(&campaigns, &AccessReviewCampaign{Owner: owner})
+	if err != nil {
+		return campaigns, err
+	}
+
+	return campaigns, nil
+}
+
+func GetPaginationAccessReviewCampaigns(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*AccessReviewCampaign, error) {
+	campaigns := []*AccessReviewCampaign{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&campaigns)
+	if err != nil {
+		return campaigns, err
+	}
+
+	return campaigns, nil
+}
+
+func getAccessReviewCampaign(owner string, name string) (*AccessReviewCampaign, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	campaign := AccessReviewCampaign{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&campaign)
+	if err != nil {
+		return &campaign, err
+	}
+
+	if existed {
+		return &campaign, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetAccessReviewCampaign(id string) (*AccessReviewCampaign, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getAccessReviewCampaign(owner, name)
+}
+
+func (campaign *AccessReviewCampaign) GetId() string {
+	return fmt.Sprintf("%s/%s", campaign.Owner, campaign.Name)
+}
+
+func (campaign *AccessReviewCampaign) check(lang string) error {
+	for _, t := range campaign.Types {
+		if t != AccessRequestTypeRole && t != AccessRequestTypePermission {
+			return fmt.Errorf(i18n.Translate(lang, "general:Unknown type: %s"), t)
+		}
+	}
+
+	if campaign.DurationDays <= 0 || campaign.IntervalDays < 0 {
+		return fmt.Errorf(i18n.Translate(lang, "general:The duration of the access review campaign should be positive"))
+	}
+
+	if campaign.StartTime != "" {
+		if _, err := time.Parse(time.RFC3339, campaign.StartTime); err != nil {
+			return fmt.Errorf(i18n.Translate(lang, "general:The time: %s is not in RFC3339 format"), campaign.StartTime)
+		}
+	}
+	return nil
+}
+
+func AddAccessReviewCampaign(campaign *AccessReviewCampaign, lang string) (bool, error) {
+	err := campaign.check(lang)
+	if err != nil {
+		return false, err
+	}
+
+	campaign.State = AccessReviewStateIdle
+	campaign.Round = 0
+	campaign.RoundStartTime = ""
+	campaign.RoundEndTime = ""
+	campaign.NextRunTime = campaign.StartTime
+	if campaign.NextRunTime == "" {
+		campaign.NextRunTime = util.GetCurrentTime()
+	}
+
+	affected, err := ormer.Engine.Insert(campaign)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// UpdateAccessReviewCampaign updates the settings of the campaign, the state of the rounds is kept
+func UpdateAccessReviewCampaign(id string, campaign *AccessReviewCampaign, lang string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	oldCampaign, err := getAccessReviewCampaign(owner, name)
+	if err != nil {
+		return false, err
+	} else if oldCampaign == nil {
+		return false, nil
+	}
+
+	err = campaign.check(lang)
+	if err != nil {
+		return false, err
+	}
+
+	campaign.State = oldCampaign.State
+	campaign.Round = oldCampaign.Round
+	campaign.RoundStartTime = oldCampaign.RoundStartTime
+	campaign.RoundEndTime = oldCampaign.RoundEndTime
+	campaign.NextRunTime = oldCampaign.NextRunTime
+	if campaign.StartTime != oldCampaign.StartTime && campaign.State != AccessReviewStateInProgress {
+		campaign.State = AccessReviewStateIdle
+		campaign.NextRunTime = campaign.StartTime
+	}
+
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(campaign)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func DeleteAccessReviewCampaign(campaign *AccessReviewCampaign) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{campaign.Owner, campaign.Name}).Delete(&AccessReviewCampaign{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = ormer.Engine.Delete(&AccessReviewItem{Owner: campaign.Owner, Campaign: campaign.Name})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func updateAccessReviewCampaignState(campaign *AccessReviewCampaign) error {
+	_, err := ormer.Engine.ID(core.PK{campaign.Owner, campaign.Name}).Cols("state", "round", "round_start_time", "round_end_time", "next_run_time").Update(campaign)
+	return err
+}
+
+func notifyAccessReview(owner string, user string, action string, object interface{}) {
+	record := &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: owner,
+		User:         user,
+		Method:       "POST",
+		Action:       action,
+		Object:       util.StructToJson(object),
+	}
+	util.SafeGoroutine(func() { AddRecord(record) })
+}
+
+// getAccessReviewTargets returns the ids of the reviewed roles and permissions of the campaign by type
+func getAccessReviewTargets(campaign *AccessReviewCampaign) (map[string][]string, error) {
+	types := campaign.Types
+	if len(types) == 0 {
+		types = []string{AccessRequestTypeRole, AccessRequestTypePermission}
+	}
+
+	res := map[string][]string{}
+	for _, t := range types {
+		ids := []string{}
+		if t == AccessRequestTypeRole {
+			roles, err := GetRoles(campaign.Owner)
+			if err != nil {
+				return nil, err
+			}
+			for _, role := range roles {
+				ids = append(ids, role.GetId())
+			}
+		} else {
+			permissions, err := GetPermissions(campaign.Owner)
+			if err != nil {
+				return nil, err
+			}
+			for _, permission := range permissions {
+				ids = append(ids, permission.GetId())
+			}
+		}
+
+		for _, id := range ids {
+			if len(campaign.Targets) == 0 || util.InSlice(campaign.Targets, id) {
+				res[t] = append(res[t], id)
+			}
+		}
+	}
+	return res, nil
+}
+
+// newAccessReviewItems returns a pending item for every user assigned to the targets, the wildcard
+// assignments like "org/*" are not reviewed as they don't belong to a user
+func newAccessReviewItems(campaign *AccessReviewCampaign, targetType string, targetId string, users []string, approvers []string) []*AccessReviewItem {
+	reviewers := campaign.Reviewers
+	if len(reviewers) == 0 {
+		reviewers = approvers
+	}
+	if reviewers == nil {
+		reviewers = []string{}
+	}
+
+	res := []*AccessReviewItem{}
+	for _, user := range users {
+		if strings.HasSuffix(user, "/*") {
+			continue
+		}
+
+		res = append(res, &AccessReviewItem{
+			Owner:       campaign.Owner,
+			Name:        util.GenerateId(),
+			CreatedTime: util.GetCurrentTime(),
+			Campaign:    campaign.Name,
+			Round:       campaign.Round,
+			Type:        targetType,
+			Target:      targetId,
+			User:        user,
+			Reviewers:   reviewers,
+			DueTime:     campaign.RoundEndTime,
+			State:       AccessReviewItemStatePending,
+		})
+	}
+	return res
+}
+
+// StartAccessReviewRound starts a new round of the campaign and creates the review items of the current assignments
+func StartAccessReviewRound(campaign *AccessReviewCampaign, lang string) (bool, error) {
+	if campaign.State == AccessReviewStateInProgress {
+		return false, fmt.Errorf(i18n.Translate(lang, "general:The access review campaign: %s is already in progress"), campaign.GetId())
+	}
+
+	targets, err := getAccessReviewTargets(campaign)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	campaign.State = AccessReviewStateInProgress
+	campaign.Round++
+	campaign.RoundStartTime = now.Format(time.RFC3339)
+	campaign.RoundEndTime = now.AddDate(0, 0, campaign.DurationDays).Format(time.RFC3339)
+	campaign.NextRunTime = ""
+
+	items := []*AccessReviewItem{}
+	for targetType, ids := range targets {
+		for _, id := range ids {
+			users, approvers, err := getAccessTarget(targetType, id, lang)
+			if err != nil {
+				return false, err
+			}
+			items = append(items, newAccessReviewItems(campaign, targetType, id, users, approvers)...)
+		}
+	}
+
+	if len(items) != 0 {
+		_, err = ormer.Engine.Insert(items)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = updateAccessReviewCampaignState(campaign)
+	if err != nil {
+		return false, err
+	}
+
+	notifyAccessReview(campaign.Owner, "", "access-review-started", campaign)
+	return true, nil
+}
+
+// getNextAccessReviewRunTime returns the start of the next round, or empty for a one-off campaign
+func getNextAccessReviewRunTime(campaign *AccessReviewCampaign) string {
+	if campaign.IntervalDays <= 0 {
+		return ""
+	}
+
+	start, err := time.Parse(time.RFC3339, campaign.RoundStartTime)
+	if err != nil {
+		start = time.Now()
+	}
+	return start.AddDate(0, 0, campaign.IntervalDays).Format(time.RFC3339)
+}
+
+// finishAccessReviewRound ends the current round, the pending items are revoked if AutoRevoke is enabled
+func finishAccessReviewRound(campaign *AccessReviewCampaign) error {
+	items := []*AccessReviewItem{}
+	err := ormer.Engine.Find(&items, &AccessReviewItem{Owner: campaign.Owner, Campaign: campaign.Name, Round: campaign.Round, State: AccessReviewItemStatePending})
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		item.State = AccessReviewItemStateUnreviewed
+		if campaign.AutoRevoke {
+			err = updateAccessTargetUsers(item.Type, item.Target, item.User, false)
+			if err != nil {
+				return err
+			}
+			item.State = AccessReviewItemStateExpired
+		}
+
+		_, err = updateAccessReviewItemState(item)
+		if err != nil {
+			return err
+		}
+	}
+
+	campaign.NextRunTime = getNextAccessReviewRunTime(campaign)
+	campaign.State = AccessReviewStateIdle
+	if campaign.NextRunTime == "" {
+		campaign.State = AccessReviewStateFinished
+	}
+
+	err = updateAccessReviewCampaignState(campaign)
+	if err != nil {
+		return err
+	}
+
+	notifyAccessReview(campaign.Owner, "", "access-review-finished", campaign)
+	return nil
+}
+
+func isTimeReached(t string, now time.Time) bool {
+	if t == "" {
+		return false
+	}
+
+	parsed, err := time.Parse(time.RFC3339, t)
+	return err == nil && !now.Before(parsed)
+}
+
+func runAccessReviewCampaigns() error {
+	campaigns := []*AccessReviewCampaign{}
+	err := ormer.Engine.Where("is_enabled = ?", true).Find(&campaigns)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, campaign := range campaigns {
+		if campaign.State == AccessReviewStateInProgress && isTimeReached(campaign.RoundEndTime, now) {
+			err = finishAccessReviewRound(campaign)
+		} else if campaign.State == AccessReviewStateIdle && isTimeReached(campaign.NextRunTime, now) {
+			_, err = StartAccessReviewRound(campaign, "en")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunAccessReviewJob starts the scheduled rounds of the access review campaigns and ends the due ones
+func RunAccessReviewJob() {
+	for {
+		err := runAccessReviewCampaigns()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("access review failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
+
+func getAccessReviewItem(owner string, name string) (*AccessReviewItem, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	item := AccessReviewItem{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&item)
+	if err != nil {
+		return &item, err
+	}
+
+	if existed {
+		return &item, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetAccessReviewItem(id string) (*AccessReviewItem, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getAccessReviewItem(owner, name)
+}
+
+func (item *AccessReviewItem) GetId() string {
+	return fmt.Sprintf("%s/%s", item.Owner, item.Name)
+}
+
+// GetAccessReviewItems returns the items of the round of the campaign, or of all the rounds if the round is 0
+func GetAccessReviewItems(campaign *AccessReviewCampaign, round int) ([]*AccessReviewItem, error) {
+	items := []*AccessReviewItem{}
+	err := ormer.Engine.Asc("round").Asc("type").Asc("target").Find(&items, &AccessReviewItem{Owner: campaign.Owner, Campaign: campaign.Name, Round: round})
+	if err != nil {
+		return items, err
+	}
+
+	return items, nil
+}
+
+// CanBeReviewedBy reports whether the user is one of the reviewers or an admin of the organization,
+// users are never allowed to review their own assignments.
+func (item *AccessReviewItem) CanBeReviewedBy(user *User) bool {
+	if user == nil || user.GetId() == item.User {
+		return false
+	}
+
+	if user.IsGlobalAdmin() || (user.IsAdmin && user.Owner == item.Owner) {
+		return true
+	}
+
+	return util.InSlice(item.Reviewers, user.GetId())
+}
+
+// GetAccessReviewTasks returns the pending review items that the user is able to review
+func GetAccessReviewTasks(reviewer *User) ([]*AccessReviewItem, error) {
+	items := []*AccessReviewItem{}
+	session := ormer.Engine.Where("state = ?", AccessReviewItemStatePending)
+	if !reviewer.IsGlobalAdmin() {
+		session = session.And("owner = ?", reviewer.Owner)
+	}
+
+	err := session.Asc("due_time").Find(&items)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*AccessReviewItem{}
+	for _, item := range items {
+		if item.CanBeReviewedBy(reviewer) {
+			res = append(res, item)
+		}
+	}
+	return res, nil
+}
+
+func updateAccessReviewItemState(item *AccessReviewItem) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{item.Owner, item.Name}).Cols("state", "reviewer", "review_time", "comment").Update(item)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// ReviewAccessReviewItem certifies the assignment of the pending item, or revokes it by removing the user
+// from the role or permission
+func ReviewAccessReviewItem(item *AccessReviewItem, reviewer *User, isCertified bool, comment string, lang string) (bool, error) {
+	if item.State != AccessReviewItemStatePending {
+		return false, fmt.Errorf(i18n.Translate(lang, "general:The access review item: %s is already %s"), item.GetId(), item.State)
+	}
+
+	if !item.CanBeReviewedBy(reviewer) {
+		return false, fmt.Errorf(i18n.Translate(lang, "auth:Unauthorized operation"))
+	}
+
+	item.Reviewer = reviewer.GetId()
+	item.ReviewTime = util.GetCurrentTime()
+	item.Comment = comment
+	item.State = AccessReviewItemStateCertified
+	if !isCertified {
+		err := updateAccessTargetUsers(item.Type, item.Target, item.User, false)
+		if err != nil {
+			return false, err
+		}
+		item.State = AccessReviewItemStateRevoked
+	}
+
+	affected, err := updateAccessReviewItemState(item)
+	if err != nil {
+		return false, err
+	}
+
+	if affected {
+		notifyAccessReview(item.Owner, reviewer.Name, "access-review-item-reviewed", item)
+	}
+	return affected, nil
+}
+
+// GetAccessReviewItemsCsv returns the rows of the review items to be exported for the auditors
+func GetAccessReviewItemsCsv(items []*AccessReviewItem) [][]string {
+	res := [][]string{{"campaign", "round", "type", "target", "user", "reviewers", "dueTime", "state", "reviewer", "reviewTime", "comment"}}
+	for _, item := range items {
+		res = append(res, []string{
+			item.Campaign,
+			strconv.Itoa(item.Round),
+			item.Type,
+			item.Target,
+			item.User,
+			strings.Join(item.Reviewers, " "),
+			item.DueTime,
+			item.State,
+			item.Reviewer,
+			item.ReviewTime,
+			item.Comment,
+		})
+	}
+	return res
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessReviewCampaignCheck(t *testing.T) {
+	campaign := &AccessReviewCampaign{Types: []string{AccessRequestTypeRole}, DurationDays: 7, IntervalDays: 90}
+	assert.Nil(t, campaign.check("en"))
+
+	campaign.Types = []string{"Group"}
+	assert.NotNil(t, campaign.check("en"))
+
+	campaign.Types = nil
+	campaign.DurationDays = 0
+	assert.NotNil(t, campaign.check("en"))
+
+	campaign.DurationDays = 7
+	campaign.StartTime = "2023-01-01"
+	assert.NotNil(t, campaign.check("en"))
+}
+
+func TestNewAccessReviewItems(t *testing.T) {
+	campaign := &AccessReviewCampaign{Owner: "org1", Name: "quarterly", Round: 2, RoundEndTime: "2023-04-08T00:00:00Z"}
+
+	items := newAccessReviewItems(campaign, AccessRequestTypeRole, "org1/admin", []string{"org1/alice", "org1/*", "org1/bob"}, []string{"org1/carol"})
+	assert.Equal(t, 2, len(items))
+	assert.Equal(t, "org1/alice", items[0].User)
+	assert.Equal(t, "org1/bob", items[1].User)
+	for _, item := range items {
+		assert.Equal(t, 2, item.Round)
+		assert.Equal(t, AccessReviewItemStatePending, item.State)
+		assert.Equal(t, []string{"org1/carol"}, item.Reviewers)
+		assert.Equal(t, campaign.RoundEndTime, item.DueTime)
+	}
+
+	campaign.Reviewers = []string{"org1/dave"}
+	items = newAccessReviewItems(campaign, AccessRequestTypeRole, "org1/admin", []string{"org1/alice"}, []string{"org1/carol"})
+	assert.Equal(t, []string{"org1/dave"}, items[0].Reviewers)
+}
+
+func TestGetNextAccessReviewRunTime(t *testing.T) {
+	campaign := &AccessReviewCampaign{RoundStartTime: "2023-01-01T00:00:00Z"}
+	assert.Equal(t, "", getNextAccessReviewRunTime(campaign))
+
+	campaign.IntervalDays = 90
+	assert.Equal(t, "2023-04-01T00:00:00Z", getNextAccessReviewRunTime(campaign))
+}
+
+func TestIsTimeReached(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2023-01-02T00:00:00Z")
+	assert.True(t, isTimeReached("2023-01-01T00:00:00Z", now))
+	assert.True(t, isTimeReached("2023-01-02T00:00:00Z", now))
+	assert.False(t, isTimeReached("2023-01-03T00:00:00Z", now))
+	assert.False(t, isTimeReached("", now))
+}
+
+func TestAccessReviewItemCanBeReviewedBy(t *testing.T) {
+	item := &AccessReviewItem{Owner: "org1", User: "org1/alice", Reviewers: []string{"org1/carol"}}
+
+	assert.True(t, item.CanBeReviewedBy(&User{Owner: "org1", Name: "carol"}))
+	assert.True(t, item.CanBeReviewedBy(&User{Owner: "org1", Name: "dave", IsAdmin: true}))
+	assert.False(t, item.CanBeReviewedBy(&User{Owner: "org2", Name: "dave", IsAdmin: true}))
+	assert.False(t, item.CanBeReviewedBy(&User{Owner: "org1", Name: "bob"}))
+	assert.False(t, item.CanBeReviewedBy(&User{Owner: "org1", Name: "alice", IsAdmin: true}))
+	assert.False(t, item.CanBeReviewedBy(nil))
+}
+
+func TestGetAccessReviewItemsCsv(t *testing.T) {
+	items := []*AccessReviewItem{
+		{Campaign: "quarterly", Round: 1, Type: AccessRequestTypeRole, Target: "org1/admin", User: "org1/alice", Reviewers: []string{"org1/carol", "org1/dave"}, State: AccessReviewItemStateRevoked, Reviewer: "org1/carol"},
+	}
+
+	rows := GetAccessReviewItemsCsv(items)
+	assert.Equal(t, 2, len(rows))
+	assert.Equal(t, len(rows[0]), len(rows[1]))
+	assert.Equal(t, []string{"quarterly", "1", AccessRequestTypeRole, "org1/admin", "org1/alice", "org1/carol org1/dave", "", AccessReviewItemStateRevoked, "org1/carol", "", ""}, rows[1])
+}
@@ -0,0 +1,561 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	RecoveryFactorEmail         = "email"
+	RecoveryFactorPhone         = "phone"
+	RecoveryFactorBackupEmail   = "backupEmail"
+	RecoveryFactorRecoveryCode  = "recoveryCode"
+	RecoveryFactorAdminApproval = "adminApproval"
+
+	AccountRecoveryStatePending   = "Pending"
+	AccountRecoveryStateVerified  = "Verified"
+	AccountRecoveryStateCompleted = "Completed"
+
+	// the method of the verification codes sent for the recovery, the codes are bound to the recovery by the scope
+	RecoveryVerification = "recovery"
+
+	defaultRecoveryMaxFailedAttempts = 5
+	defaultRecoveryLockoutMinutes    = 30
+	defaultRecoveryExpireInMinutes   = 60
+)
+
+var recoveryFactors = []string{RecoveryFactorEmail, RecoveryFactorPhone, RecoveryFactorBackupEmail, RecoveryFactorRecoveryCode, RecoveryFactorAdminApproval}
+
+// RecoveryPolicy replaces the single Email code reset of the password by the recovery requiring the users to verify
+// RequiredFactors of the Factors, e.g. 2 of the Email, the phone and the admin approval. The failed verifications
+// lock the recovery of the user out, and every recovery attempt is notified to the primary Email of the user.
+type RecoveryPolicy struct {
+	IsEnabled         bool     `json:"isEnabled"`
+	Factors           []string `json:"factors"`
+	RequiredFactors   int      `json:"requiredFactors"`
+	MaxFailedAttempts int      `json:"maxFailedAttempts"`
+	LockoutMinutes    int      `json:"lockoutMinutes"`
+	ExpireInMinutes   int      `json:"expireInMinutes"`
+}
+
+// AccountRecovery is a recovery attempt of a user, its name is the secret ID held by the client running the recovery
+type AccountRecovery struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User       string `xorm:"varchar(100) index" json:"user"`
+	RemoteAddr string `xorm:"varchar(100)" json:"remoteAddr"`
+	State      string `xorm:"varchar(100)" json:"state"`
+	ExpireTime string `xorm:"varchar(100)" json:"expireTime"`
+
+	Factors         []string `xorm:"varchar(500)" json:"factors"`
+	RequiredFactors int      `json:"requiredFactors"`
+	VerifiedFactors []string `xorm:"varchar(500)" json:"verifiedFactors"`
+	FailedAttempts  int      `json:"failedAttempts"`
+	LastFailedTime  string   `xorm:"varchar(100)" json:"lastFailedTime"`
+	Approver        string   `xorm:"varchar(100)" json:"approver"`
+	CompletedTime   string   `xorm:"varchar(100)" json:"completedTime"`
+}
+
+func checkRecoveryPolicy(policy *RecoveryPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	factors := map[string]bool{}
+	for _, factor := range policy.Factors {
+		if !util.InSlice(recoveryFactors, factor) {
+			return fmt.Errorf("the recovery factor: %s is not supported", factor)
+		}
+		if factors[factor] {
+			return fmt.Errorf("the recovery factor: %s is duplicated", factor)
+		}
+		factors[factor] = true
+	}
+
+	if policy.MaxFailedAttempts < 0 || policy.LockoutMinutes < 0 || policy.ExpireInMinutes < 0 {
+		return fmt.Errorf("the limits of the recovery policy should not be negative")
+	}
+	if policy.IsEnabled && (policy.RequiredFactors <= 0 || policy.RequiredFactors > len(policy.Factors)) {
+		return fmt.Errorf("the required recovery factors: %d should be between 1 and the count of the factors: %d", policy.RequiredFactors, len(policy.Factors))
+	}
+	return nil
+}
+
+func (policy *RecoveryPolicy) getMaxFailedAttempts() int {
+	if policy.MaxFailedAttempts == 0 {
+		return defaultRecoveryMaxFailedAttempts
+	}
+	return policy.MaxFailedAttempts
+}
+
+func (policy *RecoveryPolicy) getLockoutDuration() time.Duration {
+	if policy.LockoutMinutes == 0 {
+		return defaultRecoveryLockoutMinutes * time.Minute
+	}
+	return time.Duration(policy.LockoutMinutes) * time.Minute
+}
+
+func (policy *RecoveryPolicy) getExpireDuration() time.Duration {
+	if policy.ExpireInMinutes == 0 {
+		return defaultRecoveryExpireInMinutes * time.Minute
+	}
+	return time.Duration(policy.ExpireInMinutes) * time.Minute
+}
+
+// GetRecoveryPolicy returns the enabled recovery policy of the organization, nil if the password is reset by
+// a single Email or phone code
+func GetRecoveryPolicy(organization *Organization) *RecoveryPolicy {
+	if organization == nil || organization.RecoveryPolicy == nil || !organization.RecoveryPolicy.IsEnabled {
+		return nil
+	}
+	return organization.RecoveryPolicy
+}
+
+// getRecoveryFactorDest returns the destination of the verification code of the factor, "" for the factors without codes
+func getRecoveryFactorDest(user *User, factor string) string {
+	switch factor {
+	case RecoveryFactorEmail:
+		return user.Email
+	case RecoveryFactorBackupEmail:
+		return user.BackupEmail
+	case RecoveryFactorPhone:
+		if user.Phone == "" {
+			return ""
+		}
+		phone, ok := util.GetE164Number(user.Phone, user.GetCountryCode(""))
+		if !ok {
+			return ""
+		}
+		return phone
+	}
+	return ""
+}
+
+// getAvailableRecoveryFactors returns the factors of the policy the user has set up
+func getAvailableRecoveryFactors(policy *RecoveryPolicy, user *User) []string {
+	res := []string{}
+	for _, factor := range policy.Factors {
+		switch factor {
+		case RecoveryFactorRecoveryCode:
+			if len(user.RecoveryCodes) == 0 {
+				continue
+			}
+		case RecoveryFactorAdminApproval:
+		default:
+			if getRecoveryFactorDest(user, factor) == "" {
+				continue
+			}
+		}
+		res = append(res, factor)
+	}
+	return res
+}
+
+// GetMaskedRecoveryFactorDest returns the masked destination of the factor for the users to choose the factors
+func GetMaskedRecoveryFactorDest(user *User, factor string) string {
+	dest := getRecoveryFactorDest(user, factor)
+	if factor == RecoveryFactorPhone {
+		return util.GetMaskedPhone(dest)
+	}
+	return util.GetMaskedEmail(dest)
+}
+
+// getRecoveryFailedAttempts returns the failed verifications of the recoveries of the user within the lockout duration
+func getRecoveryFailedAttempts(policy *RecoveryPolicy, user *User, now time.Time) (int, error) {
+	accountRecoveries := []*AccountRecovery{}
+	err := ormer.Engine.Where("failed_attempts > 0").Find(&accountRecoveries, &AccountRecovery{Owner: user.Owner, User: user.Name})
+	if err != nil {
+		return 0, err
+	}
+
+	res := 0
+	for _, accountRecovery := range accountRecoveries {
+		lastFailedTime, err := time.Parse(time.RFC3339, accountRecovery.LastFailedTime)
+		if err == nil && now.Sub(lastFailedTime) < policy.getLockoutDuration() {
+			res += accountRecovery.FailedAttempts
+		}
+	}
+	return res, nil
+}
+
+func checkRecoveryLockout(policy *RecoveryPolicy, user *User, now time.Time, lang string) error {
+	failedAttempts, err := getRecoveryFailedAttempts(policy, user, now)
+	if err != nil {
+		return err
+	}
+
+	if failedAttempts >= policy.getMaxFailedAttempts() {
+		return fmt.Errorf(i18n.Translate(lang, "verification:The account recovery is locked because of too many failed attempts, please try again in %d minutes"), int(policy.getLockoutDuration().Minutes()))
+	}
+	return nil
+}
+
+// notifyAccountRecovery notifies the primary Email of the user of the recovery, so the owner notices the recoveries
+// attempted by the others
+func notifyAccountRecovery(organization *Organization, user *User, accountRecovery *AccountRecovery, event string) {
+	if user.Email == "" {
+		return
+	}
+
+	err := func() error {
+		application, err := GetDefaultApplication(util.GetId("admin", organization.Name))
+		if err != nil {
+			return err
+		}
+
+		provider, err := GetOrganizationEmailProvider(organization, application)
+		if err != nil {
+			return err
+		}
+		if provider == nil {
+			return fmt.Errorf("the organization: %s has no Email provider", organization.Name)
+		}
+
+		title := fmt.Sprintf("%s: account recovery %s", organization.DisplayName, event)
+		content := fmt.Sprintf("The recovery of your account %s is %s from the IP address %s at %s. If it isn't you, please contact your administrator.",
+			user.GetId(), event, accountRecovery.RemoteAddr, util.GetCurrentTime())
+		return SendEmail(provider, title, content, user.Email, organization.DisplayName)
+	}()
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to notify the account recovery: %s, error: %s", accountRecovery.GetId(), err.Error()))
+	}
+}
+
+// RecoveryFactorItem is a factor of the recovery shown to the user with its masked destination
+type RecoveryFactorItem struct {
+	Name       string `json:"name"`
+	Dest       string `json:"dest"`
+	IsVerified bool   `json:"isVerified"`
+}
+
+// AccountRecoveryStatus is the progress of the recovery returned to the client running it
+type AccountRecoveryStatus struct {
+	Id              string                `json:"id"`
+	State           string                `json:"state"`
+	ExpireTime      string                `json:"expireTime"`
+	Factors         []*RecoveryFactorItem `json:"factors"`
+	RequiredFactors int                   `json:"requiredFactors"`
+}
+
+func GetAccountRecoveryStatus(accountRecovery *AccountRecovery) (*AccountRecoveryStatus, error) {
+	user, err := getUser(accountRecovery.Owner, accountRecovery.User)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("the user: %s is not found", util.GetId(accountRecovery.Owner, accountRecovery.User))
+	}
+
+	factors := []*RecoveryFactorItem{}
+	for _, factor := range accountRecovery.Factors {
+		factors = append(factors, &RecoveryFactorItem{
+			Name:       factor,
+			Dest:       GetMaskedRecoveryFactorDest(user, factor),
+			IsVerified: util.InSlice(accountRecovery.VerifiedFactors, factor),
+		})
+	}
+
+	return &AccountRecoveryStatus{
+		Id:              accountRecovery.GetId(),
+		State:           accountRecovery.State,
+		ExpireTime:      accountRecovery.ExpireTime,
+		Factors:         factors,
+		RequiredFactors: accountRecovery.RequiredFactors,
+	}, nil
+}
+
+func GetAccountRecoveryCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&AccountRecovery{})
+}
+
+func GetPaginationAccountRecoveries(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*AccountRecovery, error) {
+	accountRecoveries := []*AccountRecovery{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&accountRecoveries)
+	if err != nil {
+		return accountRecoveries, err
+	}
+
+	return accountRecoveries, nil
+}
+
+func GetAccountRecovery(id string) (*AccountRecovery, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	accountRecovery := AccountRecovery{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&accountRecovery)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &accountRecovery, nil
+	}
+	return nil, nil
+}
+
+func (accountRecovery *AccountRecovery) GetId() string {
+	return fmt.Sprintf("%s/%s", accountRecovery.Owner, accountRecovery.Name)
+}
+
+func (accountRecovery *AccountRecovery) isExpired(now time.Time) bool {
+	expireTime, err := time.Parse(time.RFC3339, accountRecovery.ExpireTime)
+	return err != nil || now.After(expireTime)
+}
+
+// getRecoveryContext returns the organization, the policy and the user of the pending recovery
+func getRecoveryContext(accountRecovery *AccountRecovery, lang string) (*Organization, *RecoveryPolicy, *User, error) {
+	if accountRecovery.State != AccountRecoveryStatePending || accountRecovery.isExpired(time.Now()) {
+		return nil, nil, nil, fmt.Errorf(i18n.Translate(lang, "verification:The account recovery is invalid or expired"))
+	}
+
+	organization, err := getOrganization("admin", accountRecovery.Owner)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	policy := GetRecoveryPolicy(organization)
+	if policy == nil {
+		return nil, nil, nil, fmt.Errorf(i18n.Translate(lang, "verification:The account recovery is not enabled for the organization"))
+	}
+
+	user, err := getUser(accountRecovery.Owner, accountRecovery.User)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if user == nil {
+		return nil, nil, nil, fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), util.GetId(accountRecovery.Owner, accountRecovery.User))
+	}
+
+	return organization, policy, user, nil
+}
+
+// StartAccountRecovery starts the recovery of the user with the factors the user has set up
+func StartAccountRecovery(organization *Organization, user *User, remoteAddr string, lang string) (*AccountRecovery, error) {
+	policy := GetRecoveryPolicy(organization)
+	if policy == nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "verification:The account recovery is not enabled for the organization"))
+	}
+
+	now := time.Now()
+	err := checkRecoveryLockout(policy, user, now, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	factors := getAvailableRecoveryFactors(policy, user)
+	if len(factors) < policy.RequiredFactors {
+		return nil, fmt.Errorf(i18n.Translate(lang, "verification:You don't have enough recovery factors, please contact your administrator"))
+	}
+
+	accountRecovery := &AccountRecovery{
+		Owner:           user.Owner,
+		Name:            util.GenerateId(),
+		CreatedTime:     util.GetCurrentTime(),
+		User:            user.Name,
+		RemoteAddr:      remoteAddr,
+		State:           AccountRecoveryStatePending,
+		ExpireTime:      now.Add(policy.getExpireDuration()).Format(time.RFC3339),
+		Factors:         factors,
+		RequiredFactors: policy.RequiredFactors,
+		VerifiedFactors: []string{},
+	}
+	_, err = ormer.Engine.Insert(accountRecovery)
+	if err != nil {
+		return nil, err
+	}
+
+	notifyAccountRecovery(organization, user, accountRecovery, "started")
+	return accountRecovery, nil
+}
+
+func getRecoveryScope(accountRecovery *AccountRecovery) *VerificationScope {
+	return &VerificationScope{Method: RecoveryVerification, Session: accountRecovery.Name}
+}
+
+// SendAccountRecoveryCode sends the verification code of the factor to the destination of the user
+func SendAccountRecoveryCode(accountRecovery *AccountRecovery, factor string, application *Application, remoteAddr string, lang string) error {
+	organization, _, user, err := getRecoveryContext(accountRecovery, lang)
+	if err != nil {
+		return err
+	}
+
+	dest := getRecoveryFactorDest(user, factor)
+	if !util.InSlice(accountRecovery.Factors, factor) || dest == "" {
+		return fmt.Errorf(i18n.Translate(lang, "verification:The recovery factor: %s is not available"), factor)
+	}
+
+	scope := getRecoveryScope(accountRecovery)
+	if factor == RecoveryFactorPhone {
+		provider, err := application.GetSmsProvider()
+		if err != nil {
+			return err
+		}
+		if provider == nil {
+			return fmt.Errorf("please add a SMS provider to the \"Providers\" list for the application: %s", application.Name)
+		}
+		return SendVerificationCodeToPhone(organization, user, provider, remoteAddr, dest, scope)
+	}
+
+	provider, err := GetOrganizationEmailProvider(organization, application)
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return fmt.Errorf("please add an Email provider to the \"Providers\" list for the application: %s", application.Name)
+	}
+	return SendVerificationCodeToEmail(organization, user, provider, remoteAddr, dest, scope)
+}
+
+// addRecoveryFactor marks the factor as verified, the recovery is verified once it has the required factors
+func addRecoveryFactor(accountRecovery *AccountRecovery, factor string) (bool, error) {
+	accountRecovery.VerifiedFactors = append(accountRecovery.VerifiedFactors, factor)
+	if len(accountRecovery.VerifiedFactors) >= accountRecovery.RequiredFactors {
+		accountRecovery.State = AccountRecoveryStateVerified
+	}
+
+	affected, err := ormer.Engine.ID(core.PK{accountRecovery.Owner, accountRecovery.Name}).Where("state = ?", AccountRecoveryStatePending).
+		Cols("verified_factors", "state", "approver").Update(accountRecovery)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func addRecoveryFailedAttempt(organization *Organization, policy *RecoveryPolicy, user *User, accountRecovery *AccountRecovery) error {
+	accountRecovery.FailedAttempts += 1
+	accountRecovery.LastFailedTime = time.Now().Format(time.RFC3339)
+	_, err := ormer.Engine.ID(core.PK{accountRecovery.Owner, accountRecovery.Name}).Cols("failed_attempts", "last_failed_time").Update(accountRecovery)
+	if err != nil {
+		return err
+	}
+
+	failedAttempts, err := getRecoveryFailedAttempts(policy, user, time.Now())
+	if err != nil {
+		return err
+	}
+	if failedAttempts == policy.getMaxFailedAttempts() {
+		notifyAccountRecovery(organization, user, accountRecovery, "locked after too many failed attempts")
+	}
+	return nil
+}
+
+// VerifyAccountRecoveryFactor verifies the code of the factor sent to the user, or the recovery code of the user
+func VerifyAccountRecoveryFactor(accountRecovery *AccountRecovery, factor string, code string, lang string) error {
+	organization, policy, user, err := getRecoveryContext(accountRecovery, lang)
+	if err != nil {
+		return err
+	}
+
+	err = checkRecoveryLockout(policy, user, time.Now(), lang)
+	if err != nil {
+		return err
+	}
+
+	if !util.InSlice(accountRecovery.Factors, factor) || factor == RecoveryFactorAdminApproval {
+		return fmt.Errorf(i18n.Translate(lang, "verification:The recovery factor: %s is not available"), factor)
+	}
+	if util.InSlice(accountRecovery.VerifiedFactors, factor) {
+		return fmt.Errorf(i18n.Translate(lang, "verification:The recovery factor: %s has been verified"), factor)
+	}
+
+	var verifyErr error
+	if factor == RecoveryFactorRecoveryCode {
+		verifyErr = MfaRecover(user, code)
+	} else {
+		result := CheckVerificationCode(getRecoveryScope(accountRecovery), getRecoveryFactorDest(user, factor), code, lang)
+		if result.Code != VerificationSuccess {
+			verifyErr = fmt.Errorf(result.Msg)
+		}
+	}
+	if verifyErr != nil {
+		err = addRecoveryFailedAttempt(organization, policy, user, accountRecovery)
+		if err != nil {
+			return err
+		}
+		return verifyErr
+	}
+
+	_, err = addRecoveryFactor(accountRecovery, factor)
+	return err
+}
+
+// ApproveAccountRecovery verifies the admin approval factor of the recovery by the admin
+func ApproveAccountRecovery(accountRecovery *AccountRecovery, approver string, lang string) (bool, error) {
+	_, _, user, err := getRecoveryContext(accountRecovery, lang)
+	if err != nil {
+		return false, err
+	}
+
+	if !util.InSlice(accountRecovery.Factors, RecoveryFactorAdminApproval) {
+		return false, fmt.Errorf(i18n.Translate(lang, "verification:The recovery factor: %s is not available"), RecoveryFactorAdminApproval)
+	}
+	if util.InSlice(accountRecovery.VerifiedFactors, RecoveryFactorAdminApproval) {
+		return false, nil
+	}
+	if approver == user.GetId() {
+		return false, fmt.Errorf(i18n.Translate(lang, "verification:The account recovery can't be approved by the user itself"))
+	}
+
+	accountRecovery.Approver = approver
+	return addRecoveryFactor(accountRecovery, RecoveryFactorAdminApproval)
+}
+
+// CompleteAccountRecovery uses up the verified recovery of the user to reset the password
+func CompleteAccountRecovery(id string, user *User, lang string) error {
+	invalidErr := fmt.Errorf(i18n.Translate(lang, "verification:The account recovery is invalid or expired"))
+	if !strings.Contains(id, "/") || user == nil {
+		return invalidErr
+	}
+
+	accountRecovery, err := GetAccountRecovery(id)
+	if err != nil {
+		return err
+	}
+	if accountRecovery == nil || accountRecovery.Owner != user.Owner || accountRecovery.User != user.Name || accountRecovery.State != AccountRecoveryStateVerified || accountRecovery.isExpired(time.Now()) {
+		return invalidErr
+	}
+
+	accountRecovery.State = AccountRecoveryStateCompleted
+	accountRecovery.CompletedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{accountRecovery.Owner, accountRecovery.Name}).Where("state = ?", AccountRecoveryStateVerified).
+		Cols("state", "completed_time").Update(accountRecovery)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return invalidErr
+	}
+
+	organization, err := getOrganization("admin", user.Owner)
+	if err != nil {
+		return err
+	}
+	if organization != nil {
+		notifyAccountRecovery(organization, user, accountRecovery, "completed and the password is reset")
+	}
+	return nil
+}
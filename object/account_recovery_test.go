@@ -0,0 +1,74 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRecoveryPolicy(t *testing.T) {
+	assert.Nil(t, checkRecoveryPolicy(nil))
+
+	policy := &RecoveryPolicy{IsEnabled: true, Factors: []string{RecoveryFactorEmail, RecoveryFactorAdminApproval}, RequiredFactors: 2}
+	assert.Nil(t, checkRecoveryPolicy(policy))
+
+	policy.RequiredFactors = 3
+	assert.NotNil(t, checkRecoveryPolicy(policy))
+
+	policy.RequiredFactors = 0
+	assert.NotNil(t, checkRecoveryPolicy(policy))
+
+	policy.IsEnabled = false
+	assert.Nil(t, checkRecoveryPolicy(policy))
+
+	assert.NotNil(t, checkRecoveryPolicy(&RecoveryPolicy{Factors: []string{"password"}}))
+	assert.NotNil(t, checkRecoveryPolicy(&RecoveryPolicy{Factors: []string{RecoveryFactorEmail, RecoveryFactorEmail}}))
+	assert.NotNil(t, checkRecoveryPolicy(&RecoveryPolicy{LockoutMinutes: -1}))
+}
+
+func TestGetAvailableRecoveryFactors(t *testing.T) {
+	policy := &RecoveryPolicy{Factors: []string{RecoveryFactorEmail, RecoveryFactorPhone, RecoveryFactorBackupEmail, RecoveryFactorRecoveryCode, RecoveryFactorAdminApproval}}
+
+	user := &User{Email: "alice@example.com"}
+	assert.Equal(t, []string{RecoveryFactorEmail, RecoveryFactorAdminApproval}, getAvailableRecoveryFactors(policy, user))
+
+	user.BackupEmail = "alice@backup.com"
+	user.RecoveryCodes = []string{"code"}
+	assert.Equal(t, []string{RecoveryFactorEmail, RecoveryFactorBackupEmail, RecoveryFactorRecoveryCode, RecoveryFactorAdminApproval}, getAvailableRecoveryFactors(policy, user))
+
+	assert.Equal(t, "alice@backup.com", getRecoveryFactorDest(user, RecoveryFactorBackupEmail))
+	assert.Equal(t, "", getRecoveryFactorDest(user, RecoveryFactorRecoveryCode))
+	assert.Equal(t, "", GetMaskedRecoveryFactorDest(user, RecoveryFactorAdminApproval))
+}
+
+func TestRecoveryPolicyDefaults(t *testing.T) {
+	policy := &RecoveryPolicy{}
+	assert.Equal(t, defaultRecoveryMaxFailedAttempts, policy.getMaxFailedAttempts())
+	assert.Equal(t, defaultRecoveryLockoutMinutes*time.Minute, policy.getLockoutDuration())
+
+	policy.ExpireInMinutes = 5
+	assert.Equal(t, 5*time.Minute, policy.getExpireDuration())
+}
+
+func TestAccountRecoveryIsExpired(t *testing.T) {
+	now := time.Now()
+	accountRecovery := &AccountRecovery{ExpireTime: now.Add(time.Minute).Format(time.RFC3339)}
+	assert.False(t, accountRecovery.isExpired(now))
+	assert.True(t, accountRecovery.isExpired(now.Add(2*time.Minute)))
+	assert.True(t, (&AccountRecovery{}).isExpired(now))
+}
@@ -41,6 +41,9 @@ type Adapter struct {
 	Database     string `xorm:"varchar(100)" json:"database"`
 
 	*xormadapter.Adapter `xorm:"-" json:"-"`
+
+	engine      *xorm.Engine
+	policyTable string
 }
 
 func GetAdapterCount(owner, field, value string) (int64, error) {
@@ -202,6 +205,8 @@ func (adapter *Adapter) InitAdapter() error {
 		return err
 	}
 
+	adapter.engine = engine
+	adapter.policyTable = tableName
 	return nil
 }
 
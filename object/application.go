@@ -16,8 +16,6 @@ package object
 
 import (
 	"fmt"
-	"regexp"
-	"strings"
 
 	"github.com/casdoor/casdoor/idp"
 	"github.com/casdoor/casdoor/util"
@@ -32,6 +30,13 @@ type SignupItem struct {
 	Label       string `json:"label"`
 	Placeholder string `json:"placeholder"`
 	Rule        string `json:"rule"`
+
+	Regex       string               `json:"regex"`
+	MinLength   int                  `json:"minLength"`
+	MaxLength   int                  `json:"maxLength"`
+	Normalizers []string             `json:"normalizers"`
+	Unique      bool                 `json:"unique"`
+	VisibleIf   *SignupItemCondition `json:"visibleIf"`
 }
 
 type SamlItem struct {
@@ -63,7 +68,8 @@ type Application struct {
 	OrgChoiceMode       string          `json:"orgChoiceMode"`
 	SamlReplyUrl        string          `xorm:"varchar(100)" json:"samlReplyUrl"`
 	Providers           []*ProviderItem `xorm:"mediumtext" json:"providers"`
-	SignupItems         []*SignupItem   `xorm:"varchar(2000)" json:"signupItems"`
+	SignupItems         []*SignupItem   `xorm:"mediumtext" json:"signupItems"`
+	AuthSteps           []*AuthStep     `xorm:"mediumtext" json:"authSteps"`
 	GrantTypes          []string        `xorm:"varchar(1000)" json:"grantTypes"`
 	OrganizationObj     *Organization   `xorm:"-" json:"organizationObj"`
 	CertPublicKey       string          `xorm:"-" json:"certPublicKey"`
@@ -71,25 +77,60 @@ type Application struct {
 	InvitationCodes     []string        `xorm:"varchar(200)" json:"invitationCodes"`
 	SamlAttributes      []*SamlItem     `xorm:"varchar(1000)" json:"samlAttributes"`
 
-	ClientId             string     `xorm:"varchar(100)" json:"clientId"`
-	ClientSecret         string     `xorm:"varchar(100)" json:"clientSecret"`
-	RedirectUris         []string   `xorm:"varchar(1000)" json:"redirectUris"`
-	TokenFormat          string     `xorm:"varchar(100)" json:"tokenFormat"`
-	ExpireInHours        int        `json:"expireInHours"`
-	RefreshExpireInHours int        `json:"refreshExpireInHours"`
-	SignupUrl            string     `xorm:"varchar(200)" json:"signupUrl"`
-	SigninUrl            string     `xorm:"varchar(200)" json:"signinUrl"`
-	ForgetUrl            string     `xorm:"varchar(200)" json:"forgetUrl"`
-	AffiliationUrl       string     `xorm:"varchar(100)" json:"affiliationUrl"`
-	TermsOfUse           string     `xorm:"varchar(100)" json:"termsOfUse"`
-	SignupHtml           string     `xorm:"mediumtext" json:"signupHtml"`
-	SigninHtml           string     `xorm:"mediumtext" json:"signinHtml"`
-	ThemeData            *ThemeData `xorm:"json" json:"themeData"`
-	FormCss              string     `xorm:"text" json:"formCss"`
-	FormCssMobile        string     `xorm:"text" json:"formCssMobile"`
-	FormOffset           int        `json:"formOffset"`
-	FormSideHtml         string     `xorm:"mediumtext" json:"formSideHtml"`
-	FormBackgroundUrl    string     `xorm:"varchar(200)" json:"formBackgroundUrl"`
+	ClientId             string             `xorm:"varchar(100)" json:"clientId"`
+	ClientSecret         string             `xorm:"varchar(100)" json:"clientSecret"`
+	RedirectUris         []string           `xorm:"varchar(1000)" json:"redirectUris"`
+	RedirectUriItems     []*RedirectUriItem `xorm:"mediumtext" json:"redirectUriItems"`
+	ResponseTypes        []string           `xorm:"varchar(1000)" json:"responseTypes"`
+	RequirePkce          bool               `json:"requirePkce"`
+	TokenFormat          string             `xorm:"varchar(100)" json:"tokenFormat"`
+	ExpireInHours        int                `json:"expireInHours"`
+	RefreshExpireInHours int                `json:"refreshExpireInHours"`
+	SignupUrl            string             `xorm:"varchar(200)" json:"signupUrl"`
+	SigninUrl            string             `xorm:"varchar(200)" json:"signinUrl"`
+	ForgetUrl            string             `xorm:"varchar(200)" json:"forgetUrl"`
+	AffiliationUrl       string             `xorm:"varchar(100)" json:"affiliationUrl"`
+	TermsOfUse           string             `xorm:"varchar(100)" json:"termsOfUse"`
+	SignupHtml           string             `xorm:"mediumtext" json:"signupHtml"`
+	SigninHtml           string             `xorm:"mediumtext" json:"signinHtml"`
+	ThemeData            *ThemeData         `xorm:"json" json:"themeData"`
+	FormCss              string             `xorm:"text" json:"formCss"`
+	FormCssMobile        string             `xorm:"text" json:"formCssMobile"`
+	FormOffset           int                `json:"formOffset"`
+	FormSideHtml         string             `xorm:"mediumtext" json:"formSideHtml"`
+	FormBackgroundUrl    string             `xorm:"varchar(200)" json:"formBackgroundUrl"`
+
+	SigninRestriction  *SigninRestriction   `xorm:"json" json:"signinRestriction"`
+	RequiredAttributes []*RequiredAttribute `xorm:"mediumtext" json:"requiredAttributes"`
+	EnableBrokerMode   bool                 `json:"enableBrokerMode"`
+	EnableShadowUser   bool                 `json:"enableShadowUser"`
+	Scopes             []*ScopeItem         `xorm:"mediumtext" json:"scopes"`
+
+	ExternalPdpUrl        string `xorm:"varchar(200)" json:"externalPdpUrl"`
+	PdpCombiningAlgorithm string `xorm:"varchar(100)" json:"pdpCombiningAlgorithm"`
+
+	// EnableCertificateBoundTokens binds the access tokens to the client certificates of the mutual TLS (RFC 8705)
+	EnableCertificateBoundTokens bool `json:"enableCertificateBoundTokens"`
+	BoundTokenExpireInSeconds    int  `json:"boundTokenExpireInSeconds"`
+
+	LoginExperiment *LoginExperiment `xorm:"json" json:"loginExperiment"`
+	LoginVariant    string           `xorm:"-" json:"loginVariant"`
+
+	// DelegationRules are the applications whose users this application can act for by the token exchange (RFC 8693)
+	DelegationRules []*DelegationRule `xorm:"mediumtext" json:"delegationRules"`
+	// AudienceRules are the applications this application can request the client credentials tokens for
+	AudienceRules []*AudienceRule `xorm:"mediumtext" json:"audienceRules"`
+
+	SessionPolicy *SessionPolicy `xorm:"json" json:"sessionPolicy"`
+	BotDetection  *BotDetection  `xorm:"json" json:"botDetection"`
+
+	LegalDocuments []*LegalDocument `xorm:"mediumtext" json:"legalDocuments"`
+
+	// RequireIdentityVerification denies the sign-ins and tokens of the users whose identity isn't verified by the
+	// "Identity Verification" provider of the application
+	RequireIdentityVerification bool `json:"requireIdentityVerification"`
+
+	ResourcePolicy *ResourcePolicy `xorm:"json" json:"resourcePolicy"`
 }
 
 func GetApplicationCount(owner, field, value string) (int64, error) {
@@ -153,7 +194,7 @@ func getProviderMap(owner string) (m map[string]*Provider, err error) {
 	m = map[string]*Provider{}
 	for _, provider := range providers {
 		// Get QRCode only once
-		if provider.Type == "WeChat" && provider.DisableSsl && provider.Content == "" {
+		if provider.Type == "WeChat" && provider.DisableSsl && provider.Content == "" && provider.BaseProvider == "" {
 			provider.Content, err = idp.GetWechatOfficialAccountQRCode(provider.ClientId2, provider.ClientSecret2)
 			if err != nil {
 				return
@@ -184,7 +225,11 @@ func extendApplicationWithProviders(application *Application) (err error) {
 
 func extendApplicationWithOrg(application *Application) (err error) {
 	organization, err := getOrganization(application.Owner, application.Organization)
-	application.OrganizationObj = organization
+	if err != nil {
+		return err
+	}
+
+	application.OrganizationObj, err = GetInheritedOrganization(organization)
 	return
 }
 
@@ -403,6 +448,61 @@ func UpdateApplication(id string, application *Application) (bool, error) {
 		return false, err
 	}
 
+	err = checkAuthSteps(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkLoginExperiment(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkApplicationScopes(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkDelegationRules(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkAudienceRules(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkSessionPolicy(application.SessionPolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkBotDetection(application.BotDetection)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkLegalDocuments(application.LegalDocuments)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkResourcePolicy(application.ResourcePolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkSignupItems(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkRedirectUriItems(application)
+	if err != nil {
+		return false, err
+	}
+
 	for _, providerItem := range application.Providers {
 		providerItem.Provider = nil
 	}
@@ -416,6 +516,10 @@ func UpdateApplication(id string, application *Application) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		publishCacheInvalidation(CacheTypeApplication, id)
+	}
+
 	return affected != 0, nil
 }
 
@@ -442,6 +546,61 @@ func AddApplication(application *Application) (bool, error) {
 		return false, nil
 	}
 
+	err = checkAuthSteps(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkLoginExperiment(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkApplicationScopes(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkDelegationRules(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkAudienceRules(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkSessionPolicy(application.SessionPolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkBotDetection(application.BotDetection)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkLegalDocuments(application.LegalDocuments)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkResourcePolicy(application.ResourcePolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkSignupItems(application)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkRedirectUriItems(application)
+	if err != nil {
+		return false, err
+	}
+
 	for _, providerItem := range application.Providers {
 		providerItem.Provider = nil
 	}
@@ -464,6 +623,10 @@ func DeleteApplication(application *Application) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		publishCacheInvalidation(CacheTypeApplication, application.GetId())
+	}
+
 	return affected != 0, nil
 }
 
@@ -471,15 +634,14 @@ func (application *Application) GetId() string {
 	return fmt.Sprintf("%s/%s", application.Owner, application.Name)
 }
 
-func (application *Application) IsRedirectUriValid(redirectUri string) bool {
-	redirectUris := append([]string{"http://localhost:", "https://localhost:", "http://127.0.0.1:", "http://casdoor-app"}, application.RedirectUris...)
-	for _, targetUri := range redirectUris {
-		targetUriRegex := regexp.MustCompile(targetUri)
-		if targetUriRegex.MatchString(redirectUri) || strings.Contains(redirectUri, targetUri) {
-			return true
-		}
+// IsResponseTypeAllowed checks the response type against the allowed response types of the application,
+// all the response types are allowed when no response type is configured
+func (application *Application) IsResponseTypeAllowed(responseType string) bool {
+	if len(application.ResponseTypes) == 0 {
+		return true
 	}
-	return false
+
+	return util.InSlice(application.ResponseTypes, responseType)
 }
 
 func IsOriginAllowed(origin string) (bool, error) {
@@ -489,7 +651,7 @@ func IsOriginAllowed(origin string) (bool, error) {
 	}
 
 	for _, application := range applications {
-		if application.IsRedirectUriValid(origin) {
+		if application.isOriginAllowed(origin) {
 			return true, nil
 		}
 	}
@@ -0,0 +1,128 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// InvalidTarget is the token error (RFC 8707) of the audience that can't be requested
+const InvalidTarget = "invalid_target"
+
+// AudienceRule allows the application to request the client credentials tokens for the audience application,
+// the tokens are constrained to the scopes of the rule. The rule without scopes allows the scopes defined
+// by the audience application, or any scope if it defines none.
+type AudienceRule struct {
+	Audience string   `json:"audience"`
+	Scopes   []string `json:"scopes"`
+}
+
+func (application *Application) getAudienceRule(audience string) *AudienceRule {
+	for _, rule := range application.AudienceRules {
+		if rule.Audience == audience {
+			return rule
+		}
+	}
+	return nil
+}
+
+func checkAudienceRules(application *Application) error {
+	audiences := map[string]bool{}
+	for _, rule := range application.AudienceRules {
+		if rule.Audience == "" {
+			return fmt.Errorf("the audience of the audience rule should not be empty")
+		}
+		if rule.Audience == application.Name {
+			return fmt.Errorf("the audience rule of the application itself is not needed")
+		}
+		if audiences[rule.Audience] {
+			return fmt.Errorf("the audience rule of the application: %s is duplicated", rule.Audience)
+		}
+		audiences[rule.Audience] = true
+	}
+	return nil
+}
+
+// getAudienceScope returns the requested scope if the rule allows it, all the allowed scopes are granted
+// if none is requested
+func getAudienceScope(rule *AudienceRule, audienceApplication *Application, scope string) (string, error) {
+	allowedScopes := rule.Scopes
+	if len(allowedScopes) == 0 {
+		for _, scopeItem := range audienceApplication.Scopes {
+			allowedScopes = append(allowedScopes, scopeItem.Name)
+		}
+	}
+
+	if scope == "" {
+		return strings.Join(allowedScopes, " "), nil
+	}
+
+	res := []string{}
+	for _, name := range strings.Fields(scope) {
+		if len(allowedScopes) != 0 && !util.InSlice(allowedScopes, name) {
+			return "", fmt.Errorf("the scope: %s is not allowed for the audience: %s", name, audienceApplication.Name)
+		}
+		if !util.InSlice(res, name) {
+			res = append(res, name)
+		}
+	}
+	return strings.Join(res, " "), nil
+}
+
+// checkAudienceApplication returns the rule allowing the application to call the audience application, the
+// audience application should belong to the same organization, so the rules can't reach the APIs of other ones
+func checkAudienceApplication(application *Application, audienceApplication *Application) (*AudienceRule, *TokenError) {
+	if audienceApplication.Organization != application.Organization {
+		return nil, &TokenError{
+			Error:            UnauthorizedClient,
+			ErrorDescription: fmt.Sprintf("the audience: %s doesn't belong to the organization of the application: %s", audienceApplication.Name, application.Name),
+		}
+	}
+
+	rule := application.getAudienceRule(audienceApplication.Name)
+	if rule == nil {
+		return nil, &TokenError{
+			Error:            UnauthorizedClient,
+			ErrorDescription: fmt.Sprintf("the application: %s is not allowed to call the audience: %s", application.Name, audienceApplication.Name),
+		}
+	}
+
+	return rule, nil
+}
+
+// getAudienceApplication returns the application of the audience (its client ID) and the rule allowing
+// the application to call it
+func getAudienceApplication(application *Application, audience string) (*Application, *AudienceRule, *TokenError, error) {
+	audienceApplication, err := GetApplicationByClientId(audience)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if audienceApplication == nil {
+		return nil, nil, &TokenError{
+			Error:            InvalidTarget,
+			ErrorDescription: fmt.Sprintf("the audience: %s is not a registered application", audience),
+		}, nil
+	}
+
+	rule, tokenError := checkAudienceApplication(application, audienceApplication)
+	if tokenError != nil {
+		return nil, nil, tokenError, nil
+	}
+
+	return audienceApplication, rule, nil, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAudienceRules(t *testing.T) {
+	assert.Nil(t, checkAudienceRules(&Application{Name: "app", AudienceRules: []*AudienceRule{{Audience: "api-1"}, {Audience: "api-2", Scopes: []string{"read"}}}}))
+	assert.NotNil(t, checkAudienceRules(&Application{Name: "app", AudienceRules: []*AudienceRule{{Audience: ""}}}))
+	assert.NotNil(t, checkAudienceRules(&Application{Name: "app", AudienceRules: []*AudienceRule{{Audience: "app"}}}))
+	assert.NotNil(t, checkAudienceRules(&Application{Name: "app", AudienceRules: []*AudienceRule{{Audience: "api-1"}, {Audience: "api-1"}}}))
+}
+
+func TestCheckAudienceApplication(t *testing.T) {
+	application := &Application{Name: "app", Organization: "org", AudienceRules: []*AudienceRule{{Audience: "api"}}}
+
+	rule, tokenError := checkAudienceApplication(application, &Application{Name: "api", Organization: "org"})
+	assert.Nil(t, tokenError)
+	assert.Equal(t, "api", rule.Audience)
+
+	_, tokenError = checkAudienceApplication(application, &Application{Name: "other-api", Organization: "org"})
+	assert.Equal(t, UnauthorizedClient, tokenError.Error)
+
+	// the audience of another organization can't be called even with a rule
+	_, tokenError = checkAudienceApplication(application, &Application{Name: "api", Organization: "other-org"})
+	assert.Equal(t, UnauthorizedClient, tokenError.Error)
+}
+
+func TestGetAudienceScope(t *testing.T) {
+	audienceApplication := &Application{Name: "api", Scopes: []*ScopeItem{{Name: "orders:read"}, {Name: "orders:write"}}}
+
+	rule := &AudienceRule{Audience: "api", Scopes: []string{"orders:read"}}
+	scope, err := getAudienceScope(rule, audienceApplication, "")
+	assert.Nil(t, err)
+	assert.Equal(t, "orders:read", scope)
+
+	scope, err = getAudienceScope(rule, audienceApplication, "orders:read orders:read")
+	assert.Nil(t, err)
+	assert.Equal(t, "orders:read", scope)
+
+	_, err = getAudienceScope(rule, audienceApplication, "orders:write")
+	assert.NotNil(t, err)
+
+	// the rule without scopes allows the scopes of the audience application
+	rule = &AudienceRule{Audience: "api"}
+	scope, err = getAudienceScope(rule, audienceApplication, "orders:write")
+	assert.Nil(t, err)
+	assert.Equal(t, "orders:write", scope)
+
+	_, err = getAudienceScope(rule, audienceApplication, "admin")
+	assert.NotNil(t, err)
+
+	scope, err = getAudienceScope(rule, &Application{Name: "api"}, "anything")
+	assert.Nil(t, err)
+	assert.Equal(t, "anything", scope)
+}
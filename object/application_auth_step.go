@@ -0,0 +1,161 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// AuthStep is a step of the login flow, the steps of an application are interpreted by the login API in order.
+// The Identifier, Password and Captcha steps happen before the user is authenticated,
+// the MFA, Terms and Attributes steps happen after that and before the user is signed in.
+type AuthStep struct {
+	Name string `json:"name"`
+	// If the step is Captcha, Rule is one of "Always", "Dynamic" and "None",
+	// if the step is MFA, Rule "Required" makes the users without MFA set it up first.
+	Rule string `json:"rule"`
+	// Fields are the user attributes collected by the Attributes step, like "displayName" and "affiliation"
+	Fields []string `json:"fields"`
+}
+
+const (
+	AuthStepIdentifier = "Identifier"
+	AuthStepPassword   = "Password"
+	AuthStepCaptcha    = "Captcha"
+	AuthStepMfa        = "MFA"
+	AuthStepTerms      = "Terms"
+	AuthStepAttributes = "Attributes"
+)
+
+const (
+	AuthStepSessionApplication = "AuthStepSessionApplication"
+	AuthStepSessionUserId      = "AuthStepSessionUserId"
+	AuthStepSessionIndex       = "AuthStepSessionIndex"
+	NextAuthStep               = "NextAuthStep"
+)
+
+var authStepAttributeFields = map[string]string{
+	"displayName": "DisplayName",
+	"firstName":   "FirstName",
+	"lastName":    "LastName",
+	"region":      "Region",
+	"location":    "Location",
+	"affiliation": "Affiliation",
+	"title":       "Title",
+	"idCard":      "IdCard",
+	"homepage":    "Homepage",
+	"bio":         "Bio",
+	"language":    "Language",
+	"gender":      "Gender",
+	"birthday":    "Birthday",
+	"education":   "Education",
+}
+
+func (application *Application) GetAuthStep(name string) *AuthStep {
+	for _, step := range application.AuthSteps {
+		if step.Name == name {
+			return step
+		}
+	}
+	return nil
+}
+
+// GetNextAuthStep returns the step after the given one, or nil if it is the last step.
+func (application *Application) GetNextAuthStep(name string) *AuthStep {
+	for i, step := range application.AuthSteps {
+		if step.Name == name && i+1 < len(application.AuthSteps) {
+			return application.AuthSteps[i+1]
+		}
+	}
+	return nil
+}
+
+// GetPostAuthSteps returns the steps that run after the user is authenticated, the MFA step comes first if
+// the customized flow doesn't have it, so the MFA of the user and the organization is always enforced.
+func (application *Application) GetPostAuthSteps() []*AuthStep {
+	res := []*AuthStep{}
+	hasMfa := false
+	for _, step := range application.AuthSteps {
+		if step.Name == AuthStepMfa || step.Name == AuthStepTerms || step.Name == AuthStepAttributes {
+			res = append(res, step)
+		}
+		if step.Name == AuthStepMfa {
+			hasMfa = true
+		}
+	}
+
+	if !hasMfa {
+		res = append([]*AuthStep{{Name: AuthStepMfa}}, res...)
+	}
+	return res
+}
+
+func checkAuthSteps(application *Application) error {
+	names := map[string]bool{}
+	for _, step := range application.AuthSteps {
+		switch step.Name {
+		case AuthStepIdentifier, AuthStepPassword, AuthStepCaptcha, AuthStepMfa, AuthStepTerms:
+		case AuthStepAttributes:
+			for _, field := range step.Fields {
+				if _, ok := authStepAttributeFields[field]; !ok {
+					return fmt.Errorf("the field: %s is not supported by the auth step: %s", field, step.Name)
+				}
+			}
+		default:
+			return fmt.Errorf("unknown auth step: %s", step.Name)
+		}
+
+		if names[step.Name] {
+			return fmt.Errorf("the auth step: %s is duplicated", step.Name)
+		}
+		names[step.Name] = true
+	}
+
+	if len(application.AuthSteps) > 0 && application.AuthSteps[0].Name != AuthStepIdentifier && names[AuthStepIdentifier] {
+		return fmt.Errorf("the auth step: %s should be the first step", AuthStepIdentifier)
+	}
+
+	return checkRequiredAttributes(application)
+}
+
+// SetUserAuthStepAttributes saves the attributes submitted in the Attributes step,
+// and returns the fields that are still empty for the user.
+func SetUserAuthStepAttributes(user *User, fields []string, attributes map[string]string) ([]string, error) {
+	missingFields := []string{}
+	for _, field := range fields {
+		goField, ok := authStepAttributeFields[field]
+		if !ok {
+			continue
+		}
+
+		value := attributes[field]
+		if value != "" {
+			_, err := SetUserField(user, util.SnakeString(goField), value)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if GetUserField(user, goField) == "" {
+			missingFields = append(missingFields, field)
+		}
+	}
+
+	return missingFields, nil
+}
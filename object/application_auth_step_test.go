@@ -0,0 +1,43 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPostAuthSteps(t *testing.T) {
+	getNames := func(steps []*AuthStep) []string {
+		res := []string{}
+		for _, step := range steps {
+			res = append(res, step.Name)
+		}
+		return res
+	}
+
+	application := &Application{}
+	assert.Equal(t, []string{AuthStepMfa}, getNames(application.GetPostAuthSteps()))
+
+	application.AuthSteps = []*AuthStep{{Name: AuthStepPassword}, {Name: AuthStepTerms}, {Name: AuthStepMfa, Rule: "Required"}}
+	steps := application.GetPostAuthSteps()
+	assert.Equal(t, []string{AuthStepTerms, AuthStepMfa}, getNames(steps))
+	assert.Equal(t, "Required", steps[1].Rule)
+
+	// the MFA can't be dropped by a customized flow without it
+	application.AuthSteps = []*AuthStep{{Name: AuthStepPassword}, {Name: AuthStepAttributes, Fields: []string{"title"}}}
+	assert.Equal(t, []string{AuthStepMfa, AuthStepAttributes}, getNames(application.GetPostAuthSteps()))
+}
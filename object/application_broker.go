@@ -0,0 +1,128 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/idp"
+	"github.com/casdoor/casdoor/util"
+)
+
+// UserTypeTransient is the type of the users that only live in the login flow of a broker mode application,
+// they are built from the upstream identity and never stored in the database.
+const UserTypeTransient = "transient-user"
+
+func (user *User) IsTransient() bool {
+	return user.Type == UserTypeTransient
+}
+
+func getBrokerUserName(userInfo *idp.UserInfo) string {
+	if userInfo.Username != "" {
+		return userInfo.Username
+	}
+	return userInfo.Id
+}
+
+func newBrokerUser(application *Application, provider *Provider, userInfo *idp.UserInfo) *User {
+	userId := userInfo.Id
+	if userId == "" {
+		userId = util.GenerateId()
+	}
+
+	return &User{
+		Owner:             application.Organization,
+		Name:              getBrokerUserName(userInfo),
+		CreatedTime:       util.GetCurrentTime(),
+		Id:                userId,
+		Type:              UserTypeTransient,
+		DisplayName:       userInfo.DisplayName,
+		Avatar:            userInfo.AvatarUrl,
+		Address:           []string{},
+		Email:             userInfo.Email,
+		Phone:             userInfo.Phone,
+		CountryCode:       userInfo.CountryCode,
+		Region:            userInfo.CountryCode,
+		SignupApplication: application.Name,
+		Properties:        map[string]string{"brokerProvider": provider.Name},
+	}
+}
+
+func getBrokerShadowUser(application *Application, provider *Provider, userInfo *idp.UserInfo) (*User, error) {
+	if provider.Category == "SAML" {
+		// The userInfo.Id is the NameID in SAML response, it could be name / email / phone
+		return GetUserByFields(application.Organization, userInfo.Id)
+	}
+	return GetUserByField(application.Organization, provider.Type, userInfo.Id)
+}
+
+// GetBrokerUser returns the user for a login of a broker mode application via the upstream provider.
+// Without shadow users, the user is a transient one built from the upstream identity only,
+// otherwise a local shadow user is created or refreshed just in time.
+func GetBrokerUser(application *Application, organization *Organization, provider *Provider, userInfo *idp.UserInfo) (*User, error) {
+	if getBrokerUserName(userInfo) == "" {
+		return nil, fmt.Errorf("the upstream provider: %s returns no user identity", provider.Name)
+	}
+
+	if !application.EnableShadowUser {
+		return newBrokerUser(application, provider, userInfo), nil
+	}
+
+	user, err := getBrokerShadowUser(application, provider, userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if user != nil && !user.IsDeleted {
+		// sync info from the upstream provider
+		_, err = SetUserOAuthProperties(organization, user, provider.Type, userInfo)
+		if err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	tmpUser, err := getUser(application.Organization, getBrokerUserName(userInfo))
+	if err != nil {
+		return nil, err
+	}
+	if tmpUser != nil {
+		return nil, fmt.Errorf("the shadow user: %s conflicts with an existing user", util.GetId(application.Organization, getBrokerUserName(userInfo)))
+	}
+
+	user = newBrokerUser(application, provider, userInfo)
+	user.Type = "normal-user"
+	affected, err := AddUser(user)
+	if err != nil {
+		return nil, err
+	}
+	if !affected {
+		return nil, fmt.Errorf("failed to create the shadow user: %s", user.GetId())
+	}
+
+	_, err = SetUserOAuthProperties(organization, user, provider.Type, userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider.Category != "SAML" {
+		_, err = LinkUserAccount(user, provider.Type, userInfo.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
@@ -0,0 +1,279 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	// TokenExchangeGrantType is the grant type of the token exchange (RFC 8693) issuing the delegation tokens
+	TokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	AccessTokenType        = "urn:ietf:params:oauth:token-type:access_token"
+
+	defaultDelegationExpireInMinutes = 15
+)
+
+// DelegationRule allows the application to act for the users of the subject application, the delegation tokens
+// are constrained to the scopes of the rule and last for the minutes of the rule at most.
+type DelegationRule struct {
+	SubjectApplication string   `json:"subjectApplication"`
+	Scopes             []string `json:"scopes"`
+	ExpireInMinutes    int      `json:"expireInMinutes"`
+}
+
+// ActorClaims is the "act" claim (RFC 8693) identifying the application acting for the subject of the token
+type ActorClaims struct {
+	Subject  string `json:"sub"`
+	ClientId string `json:"client_id,omitempty"`
+}
+
+func (application *Application) getDelegationRule(subjectApplication string) *DelegationRule {
+	for _, rule := range application.DelegationRules {
+		if rule.SubjectApplication == subjectApplication {
+			return rule
+		}
+	}
+	return nil
+}
+
+func checkDelegationRules(application *Application) error {
+	subjectApplications := map[string]bool{}
+	for _, rule := range application.DelegationRules {
+		if rule.SubjectApplication == "" {
+			return fmt.Errorf("the subject application of the delegation rule should not be empty")
+		}
+		if subjectApplications[rule.SubjectApplication] {
+			return fmt.Errorf("the delegation rule of the subject application: %s is duplicated", rule.SubjectApplication)
+		}
+		if rule.ExpireInMinutes < 0 {
+			return fmt.Errorf("the expire minutes of the delegation rule: %s should not be negative", rule.SubjectApplication)
+		}
+		subjectApplications[rule.SubjectApplication] = true
+
+		// the application can only act for the users of the applications of its own organization
+		subjectApplication, err := getApplication("admin", rule.SubjectApplication)
+		if err != nil {
+			return err
+		}
+		if subjectApplication == nil {
+			return fmt.Errorf("the subject application: %s of the delegation rule doesn't exist", rule.SubjectApplication)
+		}
+		if subjectApplication.Organization != application.Organization {
+			return fmt.Errorf("the subject application: %s of the delegation rule should belong to the organization: %s", rule.SubjectApplication, application.Organization)
+		}
+	}
+	return nil
+}
+
+// getDelegatedScope narrows the requested scope to the scopes of both the rule and the subject token,
+// the rule without scopes allows all the scopes of the subject token
+func getDelegatedScope(rule *DelegationRule, subjectScope string, scope string) (string, error) {
+	allowedScopes := []string{}
+	for _, name := range strings.Fields(subjectScope) {
+		if len(rule.Scopes) == 0 || util.InSlice(rule.Scopes, name) {
+			allowedScopes = append(allowedScopes, name)
+		}
+	}
+
+	if scope == "" {
+		return strings.Join(allowedScopes, " "), nil
+	}
+
+	res := []string{}
+	for _, name := range strings.Fields(scope) {
+		if !util.InSlice(allowedScopes, name) {
+			return "", fmt.Errorf("the scope: %s cannot be delegated", name)
+		}
+		if !util.InSlice(res, name) {
+			res = append(res, name)
+		}
+	}
+	return strings.Join(res, " "), nil
+}
+
+// getDelegationExpireTime returns the expire time of the delegation token, which never outlives the subject token
+func getDelegationExpireTime(application *Application, rule *DelegationRule, subjectExpireTime time.Time, now time.Time) time.Time {
+	expireInMinutes := rule.ExpireInMinutes
+	if expireInMinutes == 0 {
+		expireInMinutes = defaultDelegationExpireInMinutes
+	}
+
+	expireTime := now.Add(time.Duration(expireInMinutes) * time.Minute)
+	if applicationExpireTime := now.Add(time.Duration(application.ExpireInHours) * time.Hour); applicationExpireTime.Before(expireTime) {
+		expireTime = applicationExpireTime
+	}
+	if subjectExpireTime.Before(expireTime) {
+		expireTime = subjectExpireTime
+	}
+	return expireTime
+}
+
+// GetDelegationToken
+// Token exchange flow (RFC 8693), the application exchanges the access token of the user issued to
+// the subject application for a delegation token carrying both identities in the "sub" and "act" claims
+func GetDelegationToken(application *Application, clientSecret string, subjectToken string, subjectTokenType string, scope string, host string) (*Token, *TokenError, error) {
+	if application.ClientSecret != clientSecret {
+		return nil, &TokenError{
+			Error:            InvalidClient,
+			ErrorDescription: "client_secret is invalid",
+		}, nil
+	}
+
+	if subjectTokenType != "" && subjectTokenType != AccessTokenType {
+		return nil, &TokenError{
+			Error:            InvalidRequest,
+			ErrorDescription: fmt.Sprintf("subject_token_type: %s is not supported", subjectTokenType),
+		}, nil
+	}
+
+	if subjectToken == "" {
+		return nil, &TokenError{
+			Error:            InvalidRequest,
+			ErrorDescription: "subject_token should not be empty",
+		}, nil
+	}
+
+	token, err := GetTokenByAccessToken(subjectToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	if token == nil {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the subject token is invalid, expired or revoked",
+		}, nil
+	}
+
+	if token.Actor != "" {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the delegation token cannot be delegated again",
+		}, nil
+	}
+
+	rule := application.getDelegationRule(token.Application)
+	if rule == nil {
+		return nil, &TokenError{
+			Error:            UnauthorizedClient,
+			ErrorDescription: fmt.Sprintf("the application: %s is not allowed to act for the users of the application: %s", application.Name, token.Application),
+		}, nil
+	}
+
+	subjectApplication, err := getApplication(token.Owner, token.Application)
+	if err != nil {
+		return nil, nil, err
+	}
+	if subjectApplication == nil {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: fmt.Sprintf("the application: %s doesn't exist", token.Application),
+		}, nil
+	}
+	if subjectApplication.Organization != application.Organization {
+		return nil, &TokenError{
+			Error:            UnauthorizedClient,
+			ErrorDescription: fmt.Sprintf("the application: %s is not allowed to act for the users of another organization", application.Name),
+		}, nil
+	}
+
+	subjectClaims, err := ParseJwtTokenByApplication(subjectToken, subjectApplication)
+	if err != nil || subjectClaims.Valid() != nil || IsJwtTokenRevoked(token, subjectClaims) {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the subject token is invalid, expired or revoked",
+		}, nil
+	}
+
+	// the tokens of the client credentials flow have no user to act for
+	user, err := getUser(token.Organization, token.User)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user == nil {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the subject of the token is not a user",
+		}, nil
+	}
+
+	if user.IsForbidden {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the user is forbidden to sign in, please contact the administrator",
+		}, nil
+	}
+
+	if user.IsSuspensionActive() {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the user is suspended, please contact the administrator",
+		}, nil
+	}
+
+	scope, err = getDelegatedScope(rule, token.Scope, scope)
+	if err != nil {
+		return nil, &TokenError{
+			Error:            InvalidScope,
+			ErrorDescription: err.Error(),
+		}, nil
+	}
+
+	err = ExtendUserWithRolesAndPermissions(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nowTime := time.Now()
+	subjectExpireTime := nowTime.Add(time.Duration(subjectApplication.ExpireInHours) * time.Hour)
+	if subjectClaims.ExpiresAt != nil {
+		subjectExpireTime = subjectClaims.ExpiresAt.Time
+	}
+	expireTime := getDelegationExpireTime(application, rule, subjectExpireTime, nowTime)
+	actor := &ActorClaims{Subject: application.GetId(), ClientId: application.ClientId}
+	accessToken, tokenName, err := generateDelegationJwtToken(application, user, actor, scope, host, expireTime)
+	if err != nil {
+		return nil, &TokenError{
+			Error:            EndpointError,
+			ErrorDescription: fmt.Sprintf("generate jwt token error: %s", err.Error()),
+		}, nil
+	}
+
+	delegationToken := &Token{
+		Owner:        application.Owner,
+		Name:         tokenName,
+		CreatedTime:  util.GetCurrentTime(),
+		Application:  application.Name,
+		Organization: user.Owner,
+		User:         user.Name,
+		Code:         util.GenerateClientId(),
+		AccessToken:  accessToken,
+		ExpiresIn:    int(expireTime.Sub(nowTime).Seconds()),
+		Scope:        scope,
+		TokenType:    "Bearer",
+		CodeIsUsed:   true,
+		Actor:        actor.Subject,
+	}
+	_, err = AddToken(delegationToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return delegationToken, nil, nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDelegatedScope(t *testing.T) {
+	rule := &DelegationRule{SubjectApplication: "app-1", Scopes: []string{"openid", "read"}}
+
+	scope, err := getDelegatedScope(rule, "openid profile read write", "")
+	assert.Nil(t, err)
+	assert.Equal(t, "openid read", scope)
+
+	scope, err = getDelegatedScope(rule, "openid profile read write", "read read")
+	assert.Nil(t, err)
+	assert.Equal(t, "read", scope)
+
+	// the scope is neither in the rule nor in the subject token
+	_, err = getDelegatedScope(rule, "openid profile read write", "write")
+	assert.NotNil(t, err)
+	_, err = getDelegatedScope(rule, "openid", "read")
+	assert.NotNil(t, err)
+
+	scope, err = getDelegatedScope(&DelegationRule{SubjectApplication: "app-1"}, "openid write", "write")
+	assert.Nil(t, err)
+	assert.Equal(t, "write", scope)
+}
+
+func TestGetDelegationExpireTime(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	application := &Application{ExpireInHours: 1}
+
+	assert.Equal(t, now.Add(15*time.Minute), getDelegationExpireTime(application, &DelegationRule{}, now.Add(time.Hour), now))
+	assert.Equal(t, now.Add(5*time.Minute), getDelegationExpireTime(application, &DelegationRule{ExpireInMinutes: 5}, now.Add(time.Hour), now))
+	assert.Equal(t, now.Add(time.Hour), getDelegationExpireTime(application, &DelegationRule{ExpireInMinutes: 120}, now.Add(2*time.Hour), now))
+	assert.Equal(t, now.Add(time.Minute), getDelegationExpireTime(application, &DelegationRule{ExpireInMinutes: 5}, now.Add(time.Minute), now))
+}
+
+func TestCheckDelegationRules(t *testing.T) {
+	setTestOrmer(t, &Application{}, &Organization{}, &Provider{})
+	for _, application := range []*Application{
+		{Owner: "admin", Name: "app-1", Organization: "org"},
+		{Owner: "admin", Name: "app-2", Organization: "org"},
+		{Owner: "admin", Name: "app-other", Organization: "other"},
+	} {
+		_, err := ormer.Engine.Insert(application)
+		assert.Nil(t, err)
+	}
+
+	assert.Nil(t, checkDelegationRules(&Application{Organization: "org", DelegationRules: []*DelegationRule{{SubjectApplication: "app-1"}, {SubjectApplication: "app-2", ExpireInMinutes: 5}}}))
+	assert.NotNil(t, checkDelegationRules(&Application{Organization: "org", DelegationRules: []*DelegationRule{{SubjectApplication: ""}}}))
+	assert.NotNil(t, checkDelegationRules(&Application{Organization: "org", DelegationRules: []*DelegationRule{{SubjectApplication: "app-1"}, {SubjectApplication: "app-1"}}}))
+	assert.NotNil(t, checkDelegationRules(&Application{Organization: "org", DelegationRules: []*DelegationRule{{SubjectApplication: "app-1", ExpireInMinutes: -1}}}))
+
+	// the subject application should exist in the same organization
+	assert.NotNil(t, checkDelegationRules(&Application{Organization: "org", DelegationRules: []*DelegationRule{{SubjectApplication: "app-missing"}}}))
+	assert.NotNil(t, checkDelegationRules(&Application{Organization: "org", DelegationRules: []*DelegationRule{{SubjectApplication: "app-other"}}}))
+}
+
+func TestActorClaim(t *testing.T) {
+	claims := Claims{User: &User{Owner: "org-1", Name: "alice"}, Act: &ActorClaims{Subject: "admin/service-a", ClientId: "client-a"}}
+
+	data, err := json.Marshal(getClaimsWithoutThirdIdp(claims))
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"act":{"sub":"admin/service-a","client_id":"client-a"}`)
+
+	parsed := Claims{}
+	assert.Nil(t, json.Unmarshal(data, &parsed))
+	assert.Equal(t, claims.Act, parsed.Act)
+
+	data, err = json.Marshal(getShortClaims(Claims{User: &User{}}))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), `"act"`)
+}
@@ -0,0 +1,204 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"github.com/casdoor/casdoor/web"
+)
+
+// the frontend namespaces needed by the login, signup and forget password pages
+var appLoginConfigNamespaces = []string{"general", "login", "signup", "forget", "code", "mfa"}
+
+// AppLoginProvider is the public part of a provider of the application, without any secret
+type AppLoginProvider struct {
+	Name          string `json:"name"`
+	DisplayName   string `json:"displayName"`
+	Category      string `json:"category"`
+	Type          string `json:"type"`
+	SubType       string `json:"subType"`
+	ClientId      string `json:"clientId"`
+	CustomAuthUrl string `json:"customAuthUrl"`
+	CustomLogo    string `json:"customLogo"`
+	Scopes        string `json:"scopes"`
+
+	CanSignUp bool   `json:"canSignUp"`
+	CanSignIn bool   `json:"canSignIn"`
+	Prompted  bool   `json:"prompted"`
+	Rule      string `json:"rule"`
+}
+
+// PasswordPolicyItem describes a password option of the organization
+type PasswordPolicyItem struct {
+	Option      string `json:"option"`
+	Description string `json:"description"`
+}
+
+// AppLoginConfig is everything a custom login UI needs to render the login and signup pages of an application,
+// it is public so it must not contain any secret
+type AppLoginConfig struct {
+	Owner                   string `json:"owner"`
+	Name                    string `json:"name"`
+	DisplayName             string `json:"displayName"`
+	Logo                    string `json:"logo"`
+	HomepageUrl             string `json:"homepageUrl"`
+	ClientId                string `json:"clientId"`
+	Organization            string `json:"organization"`
+	OrganizationDisplayName string `json:"organizationDisplayName"`
+	Favicon                 string `json:"favicon"`
+	WebsiteUrl              string `json:"websiteUrl"`
+
+	EnablePassword   bool   `json:"enablePassword"`
+	EnableSignUp     bool   `json:"enableSignUp"`
+	EnableAutoSignin bool   `json:"enableAutoSignin"`
+	EnableCodeSignin bool   `json:"enableCodeSignin"`
+	EnableWebAuthn   bool   `json:"enableWebAuthn"`
+	OrgChoiceMode    string `json:"orgChoiceMode"`
+	SignupUrl        string `json:"signupUrl"`
+	SigninUrl        string `json:"signinUrl"`
+	ForgetUrl        string `json:"forgetUrl"`
+	TermsOfUse       string `json:"termsOfUse"`
+
+	SignupItems    []*SignupItem         `json:"signupItems"`
+	AuthSteps      []*AuthStep           `json:"authSteps"`
+	Providers      []*AppLoginProvider   `json:"providers"`
+	PasswordPolicy []*PasswordPolicyItem `json:"passwordPolicy"`
+	CountryCodes   []string              `json:"countryCodes"`
+
+	ThemeData         *ThemeData `json:"themeData"`
+	SigninHtml        string     `json:"signinHtml"`
+	SignupHtml        string     `json:"signupHtml"`
+	FormCss           string     `json:"formCss"`
+	FormCssMobile     string     `json:"formCssMobile"`
+	FormOffset        int        `json:"formOffset"`
+	FormSideHtml      string     `json:"formSideHtml"`
+	FormBackgroundUrl string     `json:"formBackgroundUrl"`
+
+	Language  string                       `json:"language"`
+	Languages []string                     `json:"languages"`
+	I18n      map[string]map[string]string `json:"i18n"`
+}
+
+func getPasswordPolicy(options []string, language string) ([]*PasswordPolicyItem, error) {
+	if len(options) == 0 {
+		options = []string{"AtLeast6"}
+	}
+
+	locale, err := web.GetLocale(language, []string{"user"})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*PasswordPolicyItem{}
+	for _, option := range options {
+		description, ok := passwordOptionDescriptions[option]
+		if !ok {
+			continue
+		}
+		if translation := locale["user"][description]; translation != "" {
+			description = translation
+		}
+		res = append(res, &PasswordPolicyItem{Option: option, Description: description})
+	}
+	return res, nil
+}
+
+// GetAppLoginConfig returns the public configuration of the application extended with its organization and
+// providers, the strings are in the language
+func GetAppLoginConfig(application *Application, language string) (*AppLoginConfig, error) {
+	language = web.GetLanguage(language)
+
+	config := &AppLoginConfig{
+		Owner:             application.Owner,
+		Name:              application.Name,
+		DisplayName:       application.DisplayName,
+		Logo:              application.Logo,
+		HomepageUrl:       application.HomepageUrl,
+		ClientId:          application.ClientId,
+		Organization:      application.Organization,
+		EnablePassword:    application.EnablePassword,
+		EnableSignUp:      application.EnableSignUp,
+		EnableAutoSignin:  application.EnableAutoSignin,
+		EnableCodeSignin:  application.EnableCodeSignin,
+		EnableWebAuthn:    application.EnableWebAuthn,
+		OrgChoiceMode:     application.OrgChoiceMode,
+		SignupUrl:         application.SignupUrl,
+		SigninUrl:         application.SigninUrl,
+		ForgetUrl:         application.ForgetUrl,
+		TermsOfUse:        application.TermsOfUse,
+		SignupItems:       application.SignupItems,
+		AuthSteps:         application.AuthSteps,
+		Providers:         []*AppLoginProvider{},
+		ThemeData:         application.ThemeData,
+		SigninHtml:        application.SigninHtml,
+		SignupHtml:        application.SignupHtml,
+		FormCss:           application.FormCss,
+		FormCssMobile:     application.FormCssMobile,
+		FormOffset:        application.FormOffset,
+		FormSideHtml:      application.FormSideHtml,
+		FormBackgroundUrl: application.FormBackgroundUrl,
+		Language:          language,
+	}
+
+	for _, providerItem := range application.Providers {
+		provider := providerItem.Provider
+		if provider == nil {
+			continue
+		}
+
+		config.Providers = append(config.Providers, &AppLoginProvider{
+			Name:          provider.Name,
+			DisplayName:   provider.DisplayName,
+			Category:      provider.Category,
+			Type:          provider.Type,
+			SubType:       provider.SubType,
+			ClientId:      provider.ClientId,
+			CustomAuthUrl: provider.CustomAuthUrl,
+			CustomLogo:    provider.CustomLogo,
+			Scopes:        provider.Scopes,
+			CanSignUp:     providerItem.CanSignUp,
+			CanSignIn:     providerItem.CanSignIn,
+			Prompted:      providerItem.Prompted,
+			Rule:          providerItem.Rule,
+		})
+	}
+
+	var passwordOptions []string
+	if organization := application.OrganizationObj; organization != nil {
+		config.OrganizationDisplayName = organization.DisplayName
+		config.Favicon = organization.Favicon
+		config.WebsiteUrl = organization.WebsiteUrl
+		config.CountryCodes = organization.CountryCodes
+		config.Languages = organization.Languages
+		passwordOptions = organization.PasswordOptions
+
+		// the theme of the application overrides the one of the organization only if enabled
+		if config.ThemeData == nil || !config.ThemeData.IsEnabled {
+			config.ThemeData = organization.ThemeData
+		}
+	}
+
+	var err error
+	config.PasswordPolicy, err = getPasswordPolicy(passwordOptions, language)
+	if err != nil {
+		return nil, err
+	}
+
+	config.I18n, err = web.GetLocale(language, appLoginConfigNamespaces)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAppLoginConfig(t *testing.T) {
+	application := &Application{
+		Owner:        "admin",
+		Name:         "app-1",
+		Organization: "org-1",
+		ClientSecret: "secret",
+		EnableSignUp: true,
+		SignupItems:  []*SignupItem{{Name: "Username", Visible: true, Required: true}},
+		Providers: []*ProviderItem{
+			{Name: "github", CanSignIn: true, Provider: &Provider{Name: "github", Category: "OAuth", Type: "GitHub", ClientId: "id-1", ClientSecret: "secret-1"}},
+			{Name: "missing", CanSignIn: true},
+		},
+		ThemeData: &ThemeData{ColorPrimary: "#000000"},
+		OrganizationObj: &Organization{
+			DisplayName:     "Organization 1",
+			PasswordOptions: []string{"AtLeast8", "Unknown", "SpecialChar"},
+			ThemeData:       &ThemeData{ColorPrimary: "#ffffff", IsEnabled: true},
+			MasterPassword:  "master",
+		},
+	}
+
+	config, err := GetAppLoginConfig(application, "zh-CN")
+	assert.Nil(t, err)
+	assert.Equal(t, "zh", config.Language)
+	assert.Equal(t, "Organization 1", config.OrganizationDisplayName)
+	assert.Equal(t, application.SignupItems, config.SignupItems)
+
+	assert.Len(t, config.Providers, 1)
+	assert.Equal(t, &AppLoginProvider{Name: "github", Category: "OAuth", Type: "GitHub", ClientId: "id-1", CanSignIn: true}, config.Providers[0])
+
+	// the theme of the application is not enabled
+	assert.Equal(t, "#ffffff", config.ThemeData.ColorPrimary)
+
+	assert.Len(t, config.PasswordPolicy, 2)
+	assert.Equal(t, "AtLeast8", config.PasswordPolicy[0].Option)
+	assert.NotEqual(t, passwordOptionDescriptions["AtLeast8"], config.PasswordPolicy[0].Description)
+	assert.Equal(t, "SpecialChar", config.PasswordPolicy[1].Option)
+
+	assert.Contains(t, config.I18n, "login")
+	assert.NotEmpty(t, config.I18n["login"]["Sign In"])
+}
+
+func TestGetAppLoginConfigDefaults(t *testing.T) {
+	config, err := GetAppLoginConfig(&Application{Owner: "admin", Name: "app-1"}, "unknown")
+	assert.Nil(t, err)
+	assert.Equal(t, "en", config.Language)
+	assert.Equal(t, []*AppLoginProvider{}, config.Providers)
+	assert.Equal(t, []*PasswordPolicyItem{{Option: "AtLeast6", Description: passwordOptionDescriptions["AtLeast6"]}}, config.PasswordPolicy)
+	assert.Equal(t, "Sign In", config.I18n["login"]["Sign In"])
+}
@@ -0,0 +1,243 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// LoginVariantControl is the variant of the logins going through the login flow of the application itself
+const LoginVariantControl = "control"
+
+// LoginExperimentVariant is an alternate login flow, like passkey-first vs password-first, that the given percentage
+// of the logins go through
+type LoginExperimentVariant struct {
+	Name       string      `json:"name"`
+	Percentage int         `json:"percentage"`
+	AuthSteps  []*AuthStep `json:"authSteps"`
+}
+
+// LoginExperiment routes the logins of the application to its variants, the rest of the logins are the control.
+// A login subject keeps its variant until the experiment is renamed.
+type LoginExperiment struct {
+	Name      string                    `json:"name"`
+	IsEnabled bool                      `json:"isEnabled"`
+	Variants  []*LoginExperimentVariant `json:"variants"`
+}
+
+// LoginExperimentAssignment is the sticky variant of a login subject, it is converted when the subject signs in
+type LoginExperimentAssignment struct {
+	Application string `xorm:"varchar(100) notnull pk" json:"application"`
+	Experiment  string `xorm:"varchar(100) notnull pk" json:"experiment"`
+	Subject     string `xorm:"varchar(100) notnull pk" json:"subject"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Variant       string `xorm:"varchar(100) index" json:"variant"`
+	ConvertedTime string `xorm:"varchar(100)" json:"convertedTime"`
+}
+
+type LoginExperimentMetric struct {
+	Variant        string  `json:"variant"`
+	Exposures      int64   `json:"exposures"`
+	Conversions    int64   `json:"conversions"`
+	ConversionRate float64 `json:"conversionRate"`
+}
+
+func (application *Application) isLoginExperimentEnabled() bool {
+	return application.LoginExperiment != nil && application.LoginExperiment.IsEnabled
+}
+
+func (experiment *LoginExperiment) getVariant(name string) *LoginExperimentVariant {
+	for _, variant := range experiment.Variants {
+		if variant.Name == name {
+			return variant
+		}
+	}
+	return nil
+}
+
+// pickVariant assigns the subject to a variant by the hash of the subject, so that the same subject gets the same
+// variant even without the stored assignment
+func (experiment *LoginExperiment) pickVariant(subject string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(fmt.Sprintf("%s:%s", experiment.Name, subject)))
+	bucket := int(h.Sum32() % 100)
+
+	total := 0
+	for _, variant := range experiment.Variants {
+		total += variant.Percentage
+		if bucket < total {
+			return variant.Name
+		}
+	}
+	return LoginVariantControl
+}
+
+func getLoginExperimentAssignment(application *Application, subject string) (*LoginExperimentAssignment, error) {
+	assignment := LoginExperimentAssignment{Application: application.GetId(), Experiment: application.LoginExperiment.Name, Subject: subject}
+	existed, err := ormer.Engine.Get(&assignment)
+	if err != nil {
+		return nil, err
+	}
+	if !existed {
+		return nil, nil
+	}
+	return &assignment, nil
+}
+
+// GetLoginVariant returns the sticky variant of the login subject, the subject is assigned to a variant on its first
+// login, an empty string means the application has no running experiment
+func GetLoginVariant(application *Application, subject string) (string, error) {
+	if application == nil || !application.isLoginExperimentEnabled() || subject == "" {
+		return "", nil
+	}
+
+	experiment := application.LoginExperiment
+	assignment, err := getLoginExperimentAssignment(application, subject)
+	if err != nil {
+		return "", err
+	}
+
+	if assignment != nil {
+		// the variants removed from the running experiment fall back to the control
+		if assignment.Variant != LoginVariantControl && experiment.getVariant(assignment.Variant) == nil {
+			return LoginVariantControl, nil
+		}
+		return assignment.Variant, nil
+	}
+
+	assignment = &LoginExperimentAssignment{
+		Application: application.GetId(),
+		Experiment:  experiment.Name,
+		Subject:     subject,
+		CreatedTime: util.GetCurrentTime(),
+		Variant:     experiment.pickVariant(subject),
+	}
+	_, err = ormer.Engine.Insert(assignment)
+	if err != nil {
+		return "", err
+	}
+
+	return assignment.Variant, nil
+}
+
+// ApplyLoginVariant replaces the login flow of the application with the one of the variant
+func (application *Application) ApplyLoginVariant(variantName string) {
+	if variantName == "" {
+		return
+	}
+
+	application.LoginVariant = variantName
+	if variant := application.LoginExperiment.getVariant(variantName); variant != nil {
+		application.AuthSteps = variant.AuthSteps
+	}
+}
+
+// ConvertLoginExperiment records the sign-in of the login subject for the conversion metrics
+func ConvertLoginExperiment(application *Application, subject string) error {
+	if application == nil || !application.isLoginExperimentEnabled() || subject == "" {
+		return nil
+	}
+
+	assignment, err := getLoginExperimentAssignment(application, subject)
+	if err != nil {
+		return err
+	}
+	if assignment == nil || assignment.ConvertedTime != "" {
+		return nil
+	}
+
+	assignment.ConvertedTime = util.GetCurrentTime()
+	_, err = ormer.Engine.ID(core.PK{assignment.Application, assignment.Experiment, assignment.Subject}).Cols("converted_time").Update(assignment)
+	return err
+}
+
+// GetLoginExperimentMetrics returns the exposures, i.e. the login subjects, and the conversions of each variant of
+// the current experiment of the application
+func GetLoginExperimentMetrics(application *Application) ([]*LoginExperimentMetric, error) {
+	metrics := []*LoginExperimentMetric{}
+	if application.LoginExperiment == nil {
+		return metrics, nil
+	}
+
+	variants := []string{LoginVariantControl}
+	for _, variant := range application.LoginExperiment.Variants {
+		variants = append(variants, variant.Name)
+	}
+
+	for _, variant := range variants {
+		condition := &LoginExperimentAssignment{Application: application.GetId(), Experiment: application.LoginExperiment.Name, Variant: variant}
+		exposures, err := ormer.Engine.Count(condition)
+		if err != nil {
+			return nil, err
+		}
+
+		conversions, err := ormer.Engine.Where("converted_time != ?", "").Count(condition)
+		if err != nil {
+			return nil, err
+		}
+
+		metric := &LoginExperimentMetric{Variant: variant, Exposures: exposures, Conversions: conversions}
+		if exposures != 0 {
+			metric.ConversionRate = float64(conversions) / float64(exposures)
+		}
+		metrics = append(metrics, metric)
+	}
+
+	return metrics, nil
+}
+
+func checkLoginExperiment(application *Application) error {
+	experiment := application.LoginExperiment
+	if experiment == nil {
+		return nil
+	}
+
+	if experiment.IsEnabled && experiment.Name == "" {
+		return fmt.Errorf("the name of the login experiment should not be empty")
+	}
+
+	total := 0
+	names := map[string]bool{}
+	for _, variant := range experiment.Variants {
+		if variant.Name == "" || variant.Name == LoginVariantControl {
+			return fmt.Errorf("the login experiment variant name: \"%s\" is invalid", variant.Name)
+		}
+		if names[variant.Name] {
+			return fmt.Errorf("the login experiment variant: %s is duplicated", variant.Name)
+		}
+		names[variant.Name] = true
+
+		if variant.Percentage < 0 {
+			return fmt.Errorf("the percentage of the login experiment variant: %s should not be negative", variant.Name)
+		}
+		total += variant.Percentage
+
+		err := checkAuthSteps(&Application{AuthSteps: variant.AuthSteps})
+		if err != nil {
+			return fmt.Errorf("the login experiment variant: %s is invalid: %s", variant.Name, err.Error())
+		}
+	}
+
+	if total > 100 {
+		return fmt.Errorf("the total percentage of the login experiment variants should not exceed 100")
+	}
+
+	return nil
+}
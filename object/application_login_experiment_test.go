@@ -0,0 +1,80 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginExperimentPickVariant(t *testing.T) {
+	experiment := &LoginExperiment{
+		Name: "passkey-first",
+		Variants: []*LoginExperimentVariant{
+			{Name: "passkey", Percentage: 30},
+		},
+	}
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		subject := fmt.Sprintf("subject-%d", i)
+		variant := experiment.pickVariant(subject)
+		assert.Equal(t, variant, experiment.pickVariant(subject))
+		counts[variant]++
+	}
+
+	assert.InDelta(t, 3000, counts["passkey"], 300)
+	assert.InDelta(t, 7000, counts[LoginVariantControl], 300)
+
+	experiment.Variants[0].Percentage = 0
+	assert.Equal(t, LoginVariantControl, experiment.pickVariant("subject-1"))
+}
+
+func TestApplyLoginVariant(t *testing.T) {
+	application := &Application{
+		AuthSteps: []*AuthStep{{Name: AuthStepIdentifier}, {Name: AuthStepPassword}},
+		LoginExperiment: &LoginExperiment{
+			Name:      "passkey-first",
+			IsEnabled: true,
+			Variants: []*LoginExperimentVariant{
+				{Name: "passkey", Percentage: 50, AuthSteps: []*AuthStep{{Name: AuthStepIdentifier}, {Name: AuthStepMfa}}},
+			},
+		},
+	}
+
+	application.ApplyLoginVariant(LoginVariantControl)
+	assert.Equal(t, AuthStepPassword, application.AuthSteps[1].Name)
+	assert.Equal(t, LoginVariantControl, application.LoginVariant)
+
+	application.ApplyLoginVariant("passkey")
+	assert.Equal(t, AuthStepMfa, application.AuthSteps[1].Name)
+	assert.Equal(t, "passkey", application.LoginVariant)
+}
+
+func TestCheckLoginExperiment(t *testing.T) {
+	newApplication := func(variants ...*LoginExperimentVariant) *Application {
+		return &Application{LoginExperiment: &LoginExperiment{Name: "test", IsEnabled: true, Variants: variants}}
+	}
+
+	assert.Nil(t, checkLoginExperiment(&Application{}))
+	assert.Nil(t, checkLoginExperiment(newApplication(&LoginExperimentVariant{Name: "a", Percentage: 40}, &LoginExperimentVariant{Name: "b", Percentage: 60})))
+	assert.NotNil(t, checkLoginExperiment(&Application{LoginExperiment: &LoginExperiment{IsEnabled: true}}))
+	assert.NotNil(t, checkLoginExperiment(newApplication(&LoginExperimentVariant{Name: "a", Percentage: 60}, &LoginExperimentVariant{Name: "b", Percentage: 60})))
+	assert.NotNil(t, checkLoginExperiment(newApplication(&LoginExperimentVariant{Name: LoginVariantControl, Percentage: 10})))
+	assert.NotNil(t, checkLoginExperiment(newApplication(&LoginExperimentVariant{Name: "a"}, &LoginExperimentVariant{Name: "a"})))
+	assert.NotNil(t, checkLoginExperiment(newApplication(&LoginExperimentVariant{Name: "a", AuthSteps: []*AuthStep{{Name: "Unknown"}}})))
+}
@@ -0,0 +1,275 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	RedirectUriUsageLogin  = "Login"
+	RedirectUriUsageLogout = "Logout"
+
+	redirectUriAnyPort = "0"
+)
+
+// RedirectUriItem is an allowed redirect URI of the application. The URI is matched exactly, except the
+// wildcards: "*" in a host label matches the characters of a DNS label (e.g. https://pr-*.preview.example.com),
+// ":*" matches any port, and a path ending with "*" matches the paths with the prefix.
+type RedirectUriItem struct {
+	Uri       string `json:"uri"`
+	IsEnabled bool   `json:"isEnabled"`
+	// Usage is "Login", "Logout" or empty for both
+	Usage string `json:"usage"`
+}
+
+// RedirectUriValidation is the result of validating a redirect URI against the application
+type RedirectUriValidation struct {
+	IsValid    bool   `json:"isValid"`
+	MatchedUri string `json:"matchedUri"`
+	Reason     string `json:"reason"`
+}
+
+var (
+	defaultRedirectUris      = []string{"http://localhost:", "https://localhost:", "http://127.0.0.1:", "http://casdoor-app"}
+	unsafeRedirectUriSchemes = []string{"javascript", "data", "vbscript", "file"}
+)
+
+// parseRedirectUri parses the redirect URI and rejects the forms that browsers and servers could interpret
+// differently, which are used for the open redirects
+func parseRedirectUri(redirectUri string) (*url.URL, error) {
+	if redirectUri == "" {
+		return nil, fmt.Errorf("the redirect URI is empty")
+	}
+	if strings.Contains(redirectUri, "\\") {
+		return nil, fmt.Errorf("the redirect URI should not contain backslashes")
+	}
+	for _, r := range redirectUri {
+		if r <= 0x20 || r == 0x7f {
+			return nil, fmt.Errorf("the redirect URI should not contain whitespaces or control characters")
+		}
+	}
+	if strings.HasPrefix(redirectUri, "//") {
+		return nil, fmt.Errorf("the redirect URI should have a scheme")
+	}
+
+	u, err := url.Parse(redirectUri)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	switch {
+	case scheme == "":
+		return nil, fmt.Errorf("the redirect URI should have a scheme")
+	case u.User != nil:
+		return nil, fmt.Errorf("the redirect URI should not contain user info")
+	case u.Fragment != "" || strings.Contains(redirectUri, "#"):
+		return nil, fmt.Errorf("the redirect URI should not contain a fragment")
+	case (scheme == "http" || scheme == "https") && u.Host == "":
+		return nil, fmt.Errorf("the redirect URI should have a host")
+	}
+
+	for _, unsafeScheme := range unsafeRedirectUriSchemes {
+		if scheme == unsafeScheme {
+			return nil, fmt.Errorf("the scheme: %s of the redirect URI is not allowed", scheme)
+		}
+	}
+
+	for _, segment := range strings.Split(u.Path, "/") {
+		if segment == ".." || segment == "." {
+			return nil, fmt.Errorf("the redirect URI should not contain dot segments in the path")
+		}
+	}
+
+	return u, nil
+}
+
+func getRedirectUriPort(u *url.URL) string {
+	port := u.Port()
+	if port != "" {
+		return port
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
+
+func isRedirectUriHostMatched(pattern string, host string) bool {
+	patternLabels := strings.Split(strings.ToLower(pattern), ".")
+	hostLabels := strings.Split(strings.ToLower(host), ".")
+	if len(patternLabels) != len(hostLabels) {
+		return false
+	}
+
+	for i, label := range patternLabels {
+		if !strings.Contains(label, "*") {
+			if label != hostLabels[i] {
+				return false
+			}
+			continue
+		}
+
+		re := regexp.MustCompile("^" + strings.Replace(regexp.QuoteMeta(label), `\*`, "[a-z0-9-]*", -1) + "$")
+		if !re.MatchString(hostLabels[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRedirectUriMatched matches the parsed redirect URI against the pattern, only the scheme, host and port
+// are matched for an origin
+func isRedirectUriMatched(pattern *url.URL, u *url.URL, isOrigin bool) bool {
+	if !strings.EqualFold(pattern.Scheme, u.Scheme) || !isRedirectUriHostMatched(pattern.Hostname(), u.Hostname()) {
+		return false
+	}
+
+	if pattern.Port() != redirectUriAnyPort && getRedirectUriPort(pattern) != getRedirectUriPort(u) {
+		return false
+	}
+
+	if isOrigin {
+		return true
+	}
+
+	patternPath := strings.TrimSuffix(pattern.EscapedPath(), "/")
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	if strings.HasSuffix(patternPath, "*") {
+		if !strings.HasPrefix(path, strings.TrimSuffix(patternPath, "*")) {
+			return false
+		}
+	} else if patternPath != path {
+		return false
+	}
+
+	return pattern.RawQuery == "" || pattern.RawQuery == u.RawQuery
+}
+
+// parseRedirectUriPattern parses the pattern of the redirect URI item, the wildcard port is parsed as the
+// port 0, which no redirect URI can have
+func parseRedirectUriPattern(pattern string) (*url.URL, error) {
+	return parseRedirectUri(strings.Replace(pattern, ":*", ":"+redirectUriAnyPort, 1))
+}
+
+func (item *RedirectUriItem) isUsedFor(usage string) bool {
+	return item.IsEnabled && (item.Usage == "" || item.Usage == usage)
+}
+
+func (application *Application) isLegacyRedirectUriValid(redirectUri string) (string, bool) {
+	redirectUris := append(append([]string{}, defaultRedirectUris...), application.RedirectUris...)
+	for _, targetUri := range redirectUris {
+		targetUriRegex, err := regexp.Compile(targetUri)
+		if (err == nil && targetUriRegex.MatchString(redirectUri)) || strings.Contains(redirectUri, targetUri) {
+			return targetUri, true
+		}
+	}
+	return "", false
+}
+
+// ValidateRedirectUri checks the redirect URI for the usage against the redirect URI items of the application,
+// then the legacy redirect URIs
+func (application *Application) ValidateRedirectUri(redirectUri string, usage string) *RedirectUriValidation {
+	u, err := parseRedirectUri(redirectUri)
+	if err != nil {
+		return &RedirectUriValidation{Reason: err.Error()}
+	}
+
+	for _, item := range application.RedirectUriItems {
+		if !item.isUsedFor(usage) {
+			continue
+		}
+
+		pattern, err := parseRedirectUriPattern(item.Uri)
+		if err != nil {
+			continue
+		}
+
+		if isRedirectUriMatched(pattern, u, false) {
+			return &RedirectUriValidation{IsValid: true, MatchedUri: item.Uri}
+		}
+	}
+
+	if targetUri, ok := application.isLegacyRedirectUriValid(redirectUri); ok {
+		return &RedirectUriValidation{IsValid: true, MatchedUri: targetUri}
+	}
+
+	return &RedirectUriValidation{Reason: fmt.Sprintf("the redirect URI: %s doesn't match any allowed redirect URI of the application: %s", redirectUri, application.Name)}
+}
+
+func (application *Application) IsRedirectUriValid(redirectUri string) bool {
+	return application.ValidateRedirectUri(redirectUri, RedirectUriUsageLogin).IsValid
+}
+
+func (application *Application) IsLogoutRedirectUriValid(redirectUri string) bool {
+	return application.ValidateRedirectUri(redirectUri, RedirectUriUsageLogout).IsValid
+}
+
+func (application *Application) isOriginAllowed(origin string) bool {
+	u, err := parseRedirectUri(origin)
+	if err != nil {
+		return false
+	}
+
+	for _, item := range application.RedirectUriItems {
+		if !item.IsEnabled {
+			continue
+		}
+
+		pattern, err := parseRedirectUriPattern(item.Uri)
+		if err == nil && isRedirectUriMatched(pattern, u, true) {
+			return true
+		}
+	}
+
+	_, ok := application.isLegacyRedirectUriValid(origin)
+	return ok
+}
+
+func checkRedirectUriItems(application *Application) error {
+	for _, item := range application.RedirectUriItems {
+		if item.Usage != "" && item.Usage != RedirectUriUsageLogin && item.Usage != RedirectUriUsageLogout {
+			return fmt.Errorf("the usage: %s of the redirect URI: %s is not supported", item.Usage, item.Uri)
+		}
+
+		u, err := parseRedirectUriPattern(item.Uri)
+		if err != nil {
+			return fmt.Errorf("the redirect URI: %s is invalid: %s", item.Uri, err.Error())
+		}
+
+		path := strings.TrimSuffix(u.Path, "*")
+		if strings.Contains(path, "*") || strings.Contains(u.RawQuery, "*") {
+			return fmt.Errorf("the redirect URI: %s can only have a wildcard at the end of the path", item.Uri)
+		}
+
+		// the wildcards can't be in the registrable domain, e.g. https://*.com or https://example.*
+		labels := strings.Split(u.Hostname(), ".")
+		for i, label := range labels {
+			if strings.Contains(label, "*") && i >= len(labels)-2 {
+				return fmt.Errorf("the redirect URI: %s should not have a wildcard in the top two levels of the domain", item.Uri)
+			}
+		}
+	}
+
+	return nil
+}
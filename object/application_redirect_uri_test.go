@@ -0,0 +1,76 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRedirectUri(t *testing.T) {
+	application := &Application{
+		Name: "app",
+		RedirectUriItems: []*RedirectUriItem{
+			{Uri: "https://pr-*.preview.example.com/callback", IsEnabled: true, Usage: RedirectUriUsageLogin},
+			{Uri: "https://example.com/logout/*", IsEnabled: true, Usage: RedirectUriUsageLogout},
+			{Uri: "https://app.example.com:*/", IsEnabled: true},
+			{Uri: "https://disabled.example.com/", IsEnabled: false},
+		},
+	}
+
+	tests := []struct {
+		uri   string
+		usage string
+		valid bool
+	}{
+		{"https://pr-12.preview.example.com/callback", RedirectUriUsageLogin, true},
+		{"https://pr-12.preview.example.com/callback?code=1", RedirectUriUsageLogin, true},
+		{"https://pr-12.preview.example.com/callback", RedirectUriUsageLogout, false},
+		{"https://a.pr-12.preview.example.com/callback", RedirectUriUsageLogin, false},
+		{"https://pr-12.preview.example.com/other", RedirectUriUsageLogin, false},
+		{"https://example.com/logout/done", RedirectUriUsageLogout, true},
+		{"https://example.com/login", RedirectUriUsageLogout, false},
+		{"https://app.example.com:8443", RedirectUriUsageLogout, true},
+		{"https://disabled.example.com/", RedirectUriUsageLogin, false},
+		{"http://localhost:3000/callback", RedirectUriUsageLogin, true},
+		{"https://example.com\\@evil.com/logout/", RedirectUriUsageLogout, false},
+		{"https://example.com@evil.com/logout/", RedirectUriUsageLogout, false},
+		{"//evil.com/logout/", RedirectUriUsageLogout, false},
+		{"https://example.com/logout/../admin", RedirectUriUsageLogout, false},
+		{"javascript:alert(1)//http://localhost:", RedirectUriUsageLogin, false},
+		{"https://example.com/logout/x#https://evil.com", RedirectUriUsageLogout, false},
+	}
+
+	for _, test := range tests {
+		res := application.ValidateRedirectUri(test.uri, test.usage)
+		assert.Equal(t, test.valid, res.IsValid, test.uri)
+		if !res.IsValid {
+			assert.NotEmpty(t, res.Reason, test.uri)
+		}
+	}
+
+	assert.True(t, application.isOriginAllowed("https://app.example.com:9000"))
+	assert.False(t, application.isOriginAllowed("https://evil.com"))
+}
+
+func TestCheckRedirectUriItems(t *testing.T) {
+	assert.Nil(t, checkRedirectUriItems(&Application{RedirectUriItems: []*RedirectUriItem{{Uri: "https://*.dev.example.com/*"}, {Uri: "myapp://callback", Usage: RedirectUriUsageLogout}}}))
+	assert.NotNil(t, checkRedirectUriItems(&Application{RedirectUriItems: []*RedirectUriItem{{Uri: "https://*.com/"}}}))
+	assert.NotNil(t, checkRedirectUriItems(&Application{RedirectUriItems: []*RedirectUriItem{{Uri: "https://example.*/"}}}))
+	assert.NotNil(t, checkRedirectUriItems(&Application{RedirectUriItems: []*RedirectUriItem{{Uri: "https://a.example.com/*/callback"}}}))
+	assert.NotNil(t, checkRedirectUriItems(&Application{RedirectUriItems: []*RedirectUriItem{{Uri: "https://user@a.example.com/"}}}))
+	assert.NotNil(t, checkRedirectUriItems(&Application{RedirectUriItems: []*RedirectUriItem{{Uri: "https://a.example.com/", Usage: "Other"}}}))
+}
@@ -0,0 +1,166 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+)
+
+// RequiredAttribute is a user attribute that the application requires before the user is signed in,
+// the users who miss it are asked for it after they are authenticated.
+type RequiredAttribute struct {
+	// Name is one of the Attributes step fields like "affiliation", or "properties.<key>" for a custom property
+	Name  string `json:"name"`
+	Label string `json:"label"`
+	// If Value is set, the attribute must be equal to it, e.g. the version of the terms the users must accept
+	Value string `json:"value"`
+	// Regex is the pattern that the submitted value should match
+	Regex string `json:"regex"`
+}
+
+const (
+	PendingRequirements     = "PendingRequirements"
+	requiredAttributePrefix = "properties."
+)
+
+func (attribute *RequiredAttribute) getPropertyKey() (string, bool) {
+	if !strings.HasPrefix(attribute.Name, requiredAttributePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(attribute.Name, requiredAttributePrefix), true
+}
+
+func (attribute *RequiredAttribute) getLabel() string {
+	if attribute.Label != "" {
+		return attribute.Label
+	}
+	return attribute.Name
+}
+
+func (attribute *RequiredAttribute) isSatisfiedBy(value string) bool {
+	if attribute.Value != "" {
+		return value == attribute.Value
+	}
+
+	if value == "" {
+		return false
+	}
+
+	if attribute.Regex != "" {
+		re, err := regexp.Compile(attribute.Regex)
+		if err != nil {
+			return false
+		}
+		return re.MatchString(value)
+	}
+
+	return true
+}
+
+func getUserRequiredAttribute(user *User, attribute *RequiredAttribute) string {
+	if key, ok := attribute.getPropertyKey(); ok {
+		return user.Properties[key]
+	}
+
+	goField, ok := authStepAttributeFields[attribute.Name]
+	if !ok {
+		return ""
+	}
+	return GetUserField(user, goField)
+}
+
+func checkRequiredAttributes(application *Application) error {
+	names := map[string]bool{}
+	for _, attribute := range application.RequiredAttributes {
+		if key, ok := attribute.getPropertyKey(); ok {
+			if key == "" {
+				return fmt.Errorf("the property name of the required attribute: %s is invalid", attribute.Name)
+			}
+		} else if _, ok = authStepAttributeFields[attribute.Name]; !ok {
+			return fmt.Errorf("the required attribute: %s is not supported", attribute.Name)
+		}
+
+		if attribute.Regex != "" {
+			_, err := regexp.Compile(attribute.Regex)
+			if err != nil {
+				return fmt.Errorf("the regex of the required attribute: %s is invalid: %s", attribute.Name, err.Error())
+			}
+		}
+
+		if names[attribute.Name] {
+			return fmt.Errorf("the required attribute: %s is duplicated", attribute.Name)
+		}
+		names[attribute.Name] = true
+	}
+
+	return nil
+}
+
+// GetPendingRequiredAttributes returns the required attributes of the application that the user hasn't provided yet
+func GetPendingRequiredAttributes(application *Application, user *User) []*RequiredAttribute {
+	res := []*RequiredAttribute{}
+	if application == nil || user == nil {
+		return res
+	}
+
+	for _, attribute := range application.RequiredAttributes {
+		if !attribute.isSatisfiedBy(getUserRequiredAttribute(user, attribute)) {
+			res = append(res, attribute)
+		}
+	}
+	return res
+}
+
+// SetUserRequiredAttributes saves the submitted values of the pending required attributes,
+// and returns the attributes that are still pending for the user.
+func SetUserRequiredAttributes(application *Application, user *User, values map[string]string, lang string) ([]*RequiredAttribute, error) {
+	columns := []string{}
+	for _, attribute := range GetPendingRequiredAttributes(application, user) {
+		value := values[attribute.Name]
+		if value == "" {
+			continue
+		}
+
+		if !attribute.isSatisfiedBy(value) {
+			return nil, fmt.Errorf(i18n.Translate(lang, "auth:The value of the attribute: %s is invalid"), attribute.getLabel())
+		}
+
+		if key, ok := attribute.getPropertyKey(); ok {
+			setUserProperty(user, key, value)
+			if !util.InSlice(columns, "properties") {
+				columns = append(columns, "properties")
+			}
+		} else {
+			goField := authStepAttributeFields[attribute.Name]
+			reflect.ValueOf(user).Elem().FieldByName(goField).SetString(value)
+			columns = append(columns, util.SnakeString(goField))
+		}
+	}
+
+	if len(columns) > 0 {
+		_, err := UpdateUser(user.GetId(), user, columns, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return GetPendingRequiredAttributes(application, user), nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPendingRequiredAttributes(t *testing.T) {
+	application := &Application{
+		RequiredAttributes: []*RequiredAttribute{
+			{Name: "affiliation"},
+			{Name: "properties.department", Regex: "^[A-Z]+$"},
+			{Name: "properties.termsVersion", Value: "v2"},
+		},
+	}
+
+	user := &User{}
+	assert.Len(t, GetPendingRequiredAttributes(application, user), 3)
+
+	user.Affiliation = "Casbin"
+	user.Properties = map[string]string{"department": "rd", "termsVersion": "v1"}
+	pending := GetPendingRequiredAttributes(application, user)
+	assert.Len(t, pending, 2)
+	assert.Equal(t, "properties.department", pending[0].Name)
+	assert.Equal(t, "properties.termsVersion", pending[1].Name)
+
+	user.Properties = map[string]string{"department": "RD", "termsVersion": "v2"}
+	assert.Empty(t, GetPendingRequiredAttributes(application, user))
+}
+
+func TestCheckRequiredAttributes(t *testing.T) {
+	assert.Nil(t, checkRequiredAttributes(&Application{RequiredAttributes: []*RequiredAttribute{{Name: "title"}, {Name: "properties.team"}}}))
+	assert.NotNil(t, checkRequiredAttributes(&Application{RequiredAttributes: []*RequiredAttribute{{Name: "password"}}}))
+	assert.NotNil(t, checkRequiredAttributes(&Application{RequiredAttributes: []*RequiredAttribute{{Name: "properties."}}}))
+	assert.NotNil(t, checkRequiredAttributes(&Application{RequiredAttributes: []*RequiredAttribute{{Name: "title", Regex: "("}}}))
+	assert.NotNil(t, checkRequiredAttributes(&Application{RequiredAttributes: []*RequiredAttribute{{Name: "title"}, {Name: "title"}}}))
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// ScopeItem is a custom OAuth scope defined by the application, the scope is only granted to the users
+// who have one of its permissions or roles, or to everyone if it is mapped to neither.
+type ScopeItem struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"displayName"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+	Roles       []string `json:"roles"`
+}
+
+// standardScopes are the OIDC scopes that are always granted, they are not subject to the application scopes
+var standardScopes = map[string]bool{
+	"openid":         true,
+	"profile":        true,
+	"email":          true,
+	"phone":          true,
+	"address":        true,
+	"offline_access": true,
+}
+
+func (scopeItem *ScopeItem) isRestricted() bool {
+	return len(scopeItem.Permissions) != 0 || len(scopeItem.Roles) != 0
+}
+
+func (scopeItem *ScopeItem) isGrantedTo(permissions []*Permission, roles []*Role) bool {
+	for _, permission := range permissions {
+		if util.InSlice(scopeItem.Permissions, util.GetId(permission.Owner, permission.Name)) {
+			return true
+		}
+	}
+	for _, role := range roles {
+		if util.InSlice(scopeItem.Roles, role.GetId()) {
+			return true
+		}
+	}
+	return false
+}
+
+func (application *Application) getScopeItem(name string) *ScopeItem {
+	for _, scopeItem := range application.Scopes {
+		if scopeItem.Name == name {
+			return scopeItem
+		}
+	}
+	return nil
+}
+
+func checkApplicationScopes(application *Application) error {
+	names := map[string]bool{}
+	for _, scopeItem := range application.Scopes {
+		if scopeItem.Name == "" || strings.ContainsAny(scopeItem.Name, " \t\"\\") {
+			return fmt.Errorf("the scope: \"%s\" is invalid", scopeItem.Name)
+		}
+		if standardScopes[scopeItem.Name] {
+			return fmt.Errorf("the scope: %s is a standard OIDC scope", scopeItem.Name)
+		}
+		if names[scopeItem.Name] {
+			return fmt.Errorf("the scope: %s is duplicated", scopeItem.Name)
+		}
+		names[scopeItem.Name] = true
+	}
+	return nil
+}
+
+// getGrantedScope returns the part of the requested scope that the user is allowed to receive,
+// the scopes not defined by the application are dropped. The requested scope is returned as it is
+// if the application defines no scopes. The user should have been extended with its roles and permissions.
+func getGrantedScope(application *Application, user *User, scope string) string {
+	if len(application.Scopes) == 0 {
+		return scope
+	}
+
+	res := []string{}
+	for _, name := range strings.Fields(scope) {
+		if util.InSlice(res, name) {
+			continue
+		}
+
+		if !standardScopes[name] {
+			scopeItem := application.getScopeItem(name)
+			if scopeItem == nil {
+				continue
+			}
+			if scopeItem.isRestricted() && !scopeItem.isGrantedTo(user.Permissions, user.Roles) {
+				continue
+			}
+		}
+
+		res = append(res, name)
+	}
+
+	return strings.Join(res, " ")
+}
@@ -0,0 +1,47 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetGrantedScope(t *testing.T) {
+	application := &Application{}
+	user := &User{}
+	assert.Equal(t, "openid anything", getGrantedScope(application, user, "openid anything"))
+
+	application.Scopes = []*ScopeItem{
+		{Name: "read"},
+		{Name: "write", Permissions: []string{"built-in/permission-write"}},
+		{Name: "admin", Roles: []string{"built-in/role-admin"}},
+	}
+	assert.Equal(t, "openid read", getGrantedScope(application, user, "openid read write admin unknown read"))
+
+	user.Permissions = []*Permission{{Owner: "built-in", Name: "permission-write"}}
+	assert.Equal(t, "read write", getGrantedScope(application, user, "read write admin"))
+
+	user.Roles = []*Role{{Owner: "built-in", Name: "role-admin"}}
+	assert.Equal(t, "profile write admin", getGrantedScope(application, user, "profile write admin"))
+}
+
+func TestCheckApplicationScopes(t *testing.T) {
+	assert.Nil(t, checkApplicationScopes(&Application{Scopes: []*ScopeItem{{Name: "read"}, {Name: "write"}}}))
+	assert.NotNil(t, checkApplicationScopes(&Application{Scopes: []*ScopeItem{{Name: "read write"}}}))
+	assert.NotNil(t, checkApplicationScopes(&Application{Scopes: []*ScopeItem{{Name: "openid"}}}))
+	assert.NotNil(t, checkApplicationScopes(&Application{Scopes: []*ScopeItem{{Name: "read"}, {Name: "read"}}}))
+}
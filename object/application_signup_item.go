@@ -0,0 +1,306 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	SignupItemNormalizerTrim      = "Trim"
+	SignupItemNormalizerLowercase = "Lowercase"
+)
+
+// SignupItemCondition makes a custom signup item visible only when another signup item has the given value
+type SignupItemCondition struct {
+	Item  string `json:"item"`
+	Value string `json:"value"`
+}
+
+type signupFormField struct {
+	FormField string
+	Column    string
+}
+
+// signupFormFields are the built-in signup items that support the validation rules, with their fields of the
+// signup form and their columns of the user table
+var signupFormFields = map[string]signupFormField{
+	"Username":       {"Username", "name"},
+	"Display name":   {"Name", "display_name"},
+	"Email":          {"Email", "email"},
+	"Phone":          {"Phone", "phone"},
+	"Affiliation":    {"Affiliation", "affiliation"},
+	"ID card":        {"IdCard", "id_card"},
+	"Country/Region": {"Region", "region"},
+}
+
+// getPropertyKey returns the user property of the custom signup item, whose name is "properties.<key>"
+func (si *SignupItem) getPropertyKey() (string, bool) {
+	if !strings.HasPrefix(si.Name, requiredAttributePrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(si.Name, requiredAttributePrefix), true
+}
+
+func (si *SignupItem) getLabel() string {
+	if si.Label != "" {
+		return si.Label
+	}
+	return si.Name
+}
+
+func (si *SignupItem) normalize(value string) string {
+	for _, normalizer := range si.Normalizers {
+		switch normalizer {
+		case SignupItemNormalizerTrim:
+			value = strings.TrimSpace(value)
+		case SignupItemNormalizerLowercase:
+			value = strings.ToLower(value)
+		}
+	}
+	return value
+}
+
+func getSignupItemValue(form *form.AuthForm, signupItem *SignupItem) string {
+	if _, ok := signupItem.getPropertyKey(); ok {
+		return form.Attributes[signupItem.Name]
+	}
+
+	field, ok := signupFormFields[signupItem.Name]
+	if !ok {
+		return ""
+	}
+	return reflect.ValueOf(form).Elem().FieldByName(field.FormField).String()
+}
+
+func setSignupItemValue(form *form.AuthForm, signupItem *SignupItem, value string) {
+	if _, ok := signupItem.getPropertyKey(); ok {
+		if value == "" {
+			delete(form.Attributes, signupItem.Name)
+		} else {
+			form.Attributes[signupItem.Name] = value
+		}
+		return
+	}
+
+	if field, ok := signupFormFields[signupItem.Name]; ok {
+		reflect.ValueOf(form).Elem().FieldByName(field.FormField).SetString(value)
+	}
+}
+
+// isSignupItemShown checks whether the signup item is shown to the user with the submitted signup form
+func (application *Application) isSignupItemShown(signupItem *SignupItem, form *form.AuthForm) bool {
+	if !signupItem.Visible {
+		return false
+	}
+
+	if signupItem.VisibleIf != nil {
+		conditionItem := application.getSignupItem(signupItem.VisibleIf.Item)
+		if conditionItem == nil || !application.isSignupItemShown(conditionItem, form) {
+			return false
+		}
+		return getSignupItemValue(form, conditionItem) == signupItem.VisibleIf.Value
+	}
+	return true
+}
+
+func hasUserByProperty(organizationName string, key string, value string) (bool, error) {
+	pattern, err := json.Marshal(map[string]string{key: value})
+	if err != nil {
+		return false, err
+	}
+
+	// the LIKE query narrows the candidates down, the property itself is compared for the wildcards in the value
+	users := []*User{}
+	err = ormer.Engine.Where("owner = ? and properties like ?", organizationName, "%"+strings.Trim(string(pattern), "{}")+"%").Find(&users)
+	if err != nil {
+		return false, err
+	}
+
+	for _, user := range users {
+		if user.Properties[key] == value {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isSignupItemValueUnique(organizationName string, signupItem *SignupItem, value string) (bool, error) {
+	if key, ok := signupItem.getPropertyKey(); ok {
+		existed, err := hasUserByProperty(organizationName, key, value)
+		return !existed, err
+	}
+
+	user, err := GetUserByField(organizationName, signupFormFields[signupItem.Name].Column, value)
+	return user == nil, err
+}
+
+func checkSignupItemValue(organization *Organization, signupItem *SignupItem, value string, lang string) (string, error) {
+	if value == "" {
+		if _, ok := signupItem.getPropertyKey(); ok && signupItem.Required {
+			return fmt.Sprintf(i18n.Translate(lang, "check:%s cannot be blank"), signupItem.getLabel()), nil
+		}
+		return "", nil
+	}
+
+	length := utf8.RuneCountInString(value)
+	if signupItem.MinLength > 0 && length < signupItem.MinLength {
+		return fmt.Sprintf(i18n.Translate(lang, "check:%s must have at least %d characters"), signupItem.getLabel(), signupItem.MinLength), nil
+	}
+	if signupItem.MaxLength > 0 && length > signupItem.MaxLength {
+		return fmt.Sprintf(i18n.Translate(lang, "check:%s must have at most %d characters"), signupItem.getLabel(), signupItem.MaxLength), nil
+	}
+
+	if signupItem.Regex != "" {
+		re, err := regexp.Compile(signupItem.Regex)
+		if err != nil {
+			return "", err
+		}
+		if !re.MatchString(value) {
+			return fmt.Sprintf(i18n.Translate(lang, "check:%s is invalid"), signupItem.getLabel()), nil
+		}
+	}
+
+	if signupItem.Unique {
+		isUnique, err := isSignupItemValueUnique(organization.Name, signupItem, value)
+		if err != nil {
+			return "", err
+		}
+		if !isUnique {
+			return fmt.Sprintf(i18n.Translate(lang, "check:%s already exists"), signupItem.getLabel()), nil
+		}
+	}
+
+	return "", nil
+}
+
+// CheckSignupItems normalizes the submitted values of the signup items and validates them with the rules of
+// the items. It returns the first error message and the error messages keyed by the names of the items.
+// The values of the custom items that are not shown to the user are dropped.
+func CheckSignupItems(application *Application, organization *Organization, form *form.AuthForm, lang string) (string, map[string]string, error) {
+	if organization == nil {
+		return i18n.Translate(lang, "check:Organization does not exist"), nil, nil
+	}
+	if form.Attributes == nil {
+		form.Attributes = map[string]string{}
+	}
+
+	// the values are normalized before any condition is evaluated
+	for _, signupItem := range application.SignupItems {
+		setSignupItemValue(form, signupItem, signupItem.normalize(getSignupItemValue(form, signupItem)))
+	}
+
+	msg := ""
+	errors := map[string]string{}
+	for _, signupItem := range application.SignupItems {
+		_, isCustom := signupItem.getPropertyKey()
+		if !isCustom && signupFormFields[signupItem.Name].FormField == "" {
+			continue
+		}
+
+		if !application.isSignupItemShown(signupItem, form) {
+			if isCustom {
+				setSignupItemValue(form, signupItem, "")
+			}
+			continue
+		}
+
+		itemMsg, err := checkSignupItemValue(organization, signupItem, getSignupItemValue(form, signupItem), lang)
+		if err != nil {
+			return "", nil, err
+		}
+		if itemMsg != "" {
+			errors[signupItem.Name] = itemMsg
+			if msg == "" {
+				msg = itemMsg
+			}
+		}
+	}
+
+	return msg, errors, nil
+}
+
+// GetSignupItemProperties returns the user properties of the custom signup items in the checked signup form
+func GetSignupItemProperties(application *Application, form *form.AuthForm) map[string]string {
+	res := map[string]string{}
+	for _, signupItem := range application.SignupItems {
+		if key, ok := signupItem.getPropertyKey(); ok && form.Attributes[signupItem.Name] != "" {
+			res[key] = form.Attributes[signupItem.Name]
+		}
+	}
+	return res
+}
+
+func checkSignupItems(application *Application) error {
+	names := map[string]bool{}
+	for _, signupItem := range application.SignupItems {
+		key, isCustom := signupItem.getPropertyKey()
+		if isCustom && key == "" {
+			return fmt.Errorf("the property name of the signup item: %s is invalid", signupItem.Name)
+		}
+		if names[signupItem.Name] {
+			return fmt.Errorf("the signup item: %s is duplicated", signupItem.Name)
+		}
+		names[signupItem.Name] = true
+
+		hasRules := signupItem.Regex != "" || signupItem.MinLength != 0 || signupItem.MaxLength != 0 || len(signupItem.Normalizers) > 0 || signupItem.Unique
+		if hasRules && !isCustom && signupFormFields[signupItem.Name].FormField == "" {
+			return fmt.Errorf("the signup item: %s doesn't support the validation rules", signupItem.Name)
+		}
+
+		if signupItem.Regex != "" {
+			_, err := regexp.Compile(signupItem.Regex)
+			if err != nil {
+				return fmt.Errorf("the regex of the signup item: %s is invalid: %s", signupItem.Name, err.Error())
+			}
+		}
+
+		if signupItem.MinLength < 0 || signupItem.MaxLength < 0 || (signupItem.MaxLength > 0 && signupItem.MinLength > signupItem.MaxLength) {
+			return fmt.Errorf("the length range of the signup item: %s is invalid", signupItem.Name)
+		}
+
+		for _, normalizer := range signupItem.Normalizers {
+			if !util.InSlice([]string{SignupItemNormalizerTrim, SignupItemNormalizerLowercase}, normalizer) {
+				return fmt.Errorf("the normalizer: %s of the signup item: %s is not supported", normalizer, signupItem.Name)
+			}
+		}
+
+		// the built-in items have their own checks for the required values, so only the custom ones can be conditional
+		if signupItem.VisibleIf != nil && !isCustom {
+			return fmt.Errorf("the signup item: %s can't be conditionally visible", signupItem.Name)
+		}
+	}
+
+	for _, signupItem := range application.SignupItems {
+		visited := map[string]bool{}
+		for item := signupItem; item.VisibleIf != nil; item = application.getSignupItem(item.VisibleIf.Item) {
+			visited[item.Name] = true
+			if !names[item.VisibleIf.Item] || visited[item.VisibleIf.Item] {
+				return fmt.Errorf("the condition item: %s of the signup item: %s is invalid", item.VisibleIf.Item, item.Name)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/form"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckSignupItems(t *testing.T) {
+	application := &Application{SignupItems: []*SignupItem{
+		{Name: "Email", Visible: true, Normalizers: []string{SignupItemNormalizerTrim, SignupItemNormalizerLowercase}},
+		{Name: "Affiliation", Visible: true, MaxLength: 5},
+		{Name: "properties.type", Visible: true, Required: true, Normalizers: []string{SignupItemNormalizerLowercase}},
+		{Name: "properties.company", Label: "Company", Visible: true, Required: true, MinLength: 2, VisibleIf: &SignupItemCondition{Item: "properties.type", Value: "business"}},
+		{Name: "properties.employeeId", Visible: true, Regex: "^E[0-9]+$"},
+	}}
+	organization := &Organization{Name: "org"}
+
+	authForm := &form.AuthForm{Email: " Alice@Example.COM ", Affiliation: "abc", Attributes: map[string]string{"properties.type": "Personal", "properties.company": "x"}}
+	msg, errors, err := CheckSignupItems(application, organization, authForm, "en")
+	assert.Nil(t, err)
+	assert.Equal(t, "", msg)
+	assert.Empty(t, errors)
+	assert.Equal(t, "alice@example.com", authForm.Email)
+	assert.Equal(t, map[string]string{"type": "personal"}, GetSignupItemProperties(application, authForm))
+
+	authForm = &form.AuthForm{Affiliation: "abcdef", Attributes: map[string]string{"properties.type": "Business", "properties.employeeId": "123"}}
+	msg, errors, err = CheckSignupItems(application, organization, authForm, "en")
+	assert.Nil(t, err)
+	assert.Equal(t, "Affiliation must have at most 5 characters", msg)
+	assert.Equal(t, map[string]string{
+		"Affiliation":           "Affiliation must have at most 5 characters",
+		"properties.company":    "Company cannot be blank",
+		"properties.employeeId": "properties.employeeId is invalid",
+	}, errors)
+}
+
+func TestCheckSignupItemRules(t *testing.T) {
+	assert.Nil(t, checkSignupItems(&Application{SignupItems: []*SignupItem{{Name: "Email", Unique: true}, {Name: "properties.a"}, {Name: "properties.b", VisibleIf: &SignupItemCondition{Item: "properties.a"}}}}))
+	assert.NotNil(t, checkSignupItems(&Application{SignupItems: []*SignupItem{{Name: "Password", Regex: "a"}}}))
+	assert.NotNil(t, checkSignupItems(&Application{SignupItems: []*SignupItem{{Name: "properties.a", Regex: "("}}}))
+	assert.NotNil(t, checkSignupItems(&Application{SignupItems: []*SignupItem{{Name: "properties.a", MinLength: 5, MaxLength: 2}}}))
+	assert.NotNil(t, checkSignupItems(&Application{SignupItems: []*SignupItem{{Name: "properties.a", Normalizers: []string{"Upper"}}}}))
+	assert.NotNil(t, checkSignupItems(&Application{SignupItems: []*SignupItem{{Name: "Email", VisibleIf: &SignupItemCondition{Item: "Phone"}}, {Name: "Phone"}}}))
+	assert.NotNil(t, checkSignupItems(&Application{SignupItems: []*SignupItem{{Name: "properties.a", VisibleIf: &SignupItemCondition{Item: "properties.b"}}, {Name: "properties.b", VisibleIf: &SignupItemCondition{Item: "properties.a"}}}}))
+	assert.NotNil(t, checkSignupItems(&Application{SignupItems: []*SignupItem{{Name: "properties.a", VisibleIf: &SignupItemCondition{Item: "properties.c"}}}}))
+}
@@ -0,0 +1,364 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Knetic/govaluate"
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/proxy"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	AuthHookEventPostAuthentication = "PostAuthentication"
+	AuthHookEventPreTokenIssuance   = "PreTokenIssuance"
+
+	authHookTimeout = 5 * time.Second
+)
+
+// AuthHook is a hook point of the authentication pipeline of the organization, the enabled hooks of the event are
+// run in order. A hook posts the context to the webhook of the organization and applies the directive responded,
+// or applies its own directive if its expression is true for the context.
+type AuthHook struct {
+	Name      string `json:"name"`
+	IsEnabled bool   `json:"isEnabled"`
+	// Event is "PostAuthentication" after the credentials and MFA are verified, or "PreTokenIssuance"
+	// before the tokens of the user are signed
+	Event string `json:"event"`
+	// Applications are the names of the applications of the hook, empty for all the applications
+	Applications []string `json:"applications"`
+
+	Webhook    string             `json:"webhook"`
+	Expression string             `json:"expression"`
+	Directive  *AuthHookDirective `json:"directive"`
+	// IsFailOpen continues the authentication if the webhook fails, otherwise the authentication is denied
+	IsFailOpen bool `json:"isFailOpen"`
+}
+
+// AuthHookDirective denies the authentication, adds the claims to the tokens (for "PreTokenIssuance" only)
+// or enrolls the user into the groups of the organization
+type AuthHookDirective struct {
+	Deny    bool                   `json:"deny"`
+	Message string                 `json:"message"`
+	Claims  map[string]interface{} `json:"claims"`
+	Groups  []string               `json:"groups"`
+}
+
+// AuthHookContext is posted to the webhook of the hook
+type AuthHookContext struct {
+	Event        string `json:"event"`
+	Organization string `json:"organization"`
+	Application  string `json:"application"`
+	User         *User  `json:"user"`
+	ClientIp     string `json:"clientIp"`
+	Scope        string `json:"scope"`
+}
+
+func checkAuthHooks(hooks []*AuthHook) error {
+	for _, hook := range hooks {
+		if hook.Event != AuthHookEventPostAuthentication && hook.Event != AuthHookEventPreTokenIssuance {
+			return fmt.Errorf("the event: %s of the auth hook: %s is not supported", hook.Event, hook.Name)
+		}
+
+		if (hook.Webhook == "") == (hook.Expression == "") {
+			return fmt.Errorf("the auth hook: %s should have either a webhook or an expression", hook.Name)
+		}
+
+		if hook.Expression != "" {
+			_, err := govaluate.NewEvaluableExpression(hook.Expression)
+			if err != nil {
+				return fmt.Errorf("the expression of the auth hook: %s is invalid: %s", hook.Name, err.Error())
+			}
+			if hook.Directive == nil {
+				return fmt.Errorf("the auth hook: %s should have a directive for its expression", hook.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// getAuthHookParameters returns the parameters of the expressions, like "email" or "'admins' in groups"
+func getAuthHookParameters(hookContext *AuthHookContext) map[string]interface{} {
+	user := hookContext.User
+	groups := []interface{}{}
+	for _, group := range user.Groups {
+		groups = append(groups, group)
+	}
+
+	return map[string]interface{}{
+		"event":        hookContext.Event,
+		"organization": hookContext.Organization,
+		"application":  hookContext.Application,
+		"clientIp":     hookContext.ClientIp,
+		"scope":        hookContext.Scope,
+		"name":         user.Name,
+		"email":        user.Email,
+		"phone":        user.Phone,
+		"type":         user.Type,
+		"tag":          user.Tag,
+		"affiliation":  user.Affiliation,
+		"isAdmin":      user.IsAdmin,
+		"groups":       groups,
+	}
+}
+
+func evaluateAuthHookExpression(hook *AuthHook, hookContext *AuthHookContext) (*AuthHookDirective, error) {
+	expression, err := govaluate.NewEvaluableExpression(hook.Expression)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := expression.Evaluate(getAuthHookParameters(hookContext))
+	if err != nil {
+		return nil, err
+	}
+
+	matched, ok := result.(bool)
+	if !ok {
+		return nil, fmt.Errorf("the expression of the auth hook: %s returns a non-boolean result: %v", hook.Name, result)
+	}
+	if !matched {
+		return nil, nil
+	}
+	return hook.Directive, nil
+}
+
+func callAuthHookWebhook(hook *AuthHook, hookContext *AuthHookContext) (*AuthHookDirective, error) {
+	webhook := Webhook{Organization: hookContext.Organization, Name: hook.Webhook}
+	existed, err := ormer.Engine.Get(&webhook)
+	if err != nil {
+		return nil, err
+	}
+	if !existed || !webhook.IsEnabled {
+		return nil, fmt.Errorf("the webhook: %s of the auth hook: %s does not exist or is disabled", hook.Webhook, hook.Name)
+	}
+
+	user, err := GetMaskedUser(hookContext.User, false)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(&AuthHookContext{
+		Event:        hookContext.Event,
+		Organization: hookContext.Organization,
+		Application:  hookContext.Application,
+		User:         user,
+		ClientIp:     hookContext.ClientIp,
+		Scope:        hookContext.Scope,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), authHookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", webhook.Url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, hookContext.Event)
+	for _, header := range webhook.Headers {
+		req.Header.Set(header.Name, header.Value)
+	}
+
+	now := time.Now()
+	if secrets := webhook.getSigningSecrets(now); len(secrets) != 0 {
+		req.Header.Set(webhookSignatureHeader, getWebhookSignature(secrets, now.Unix(), payload))
+	}
+
+	resp, err := proxy.DefaultHttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the webhook: %s of the auth hook: %s responded with the status: %s", hook.Webhook, hook.Name, resp.Status)
+	}
+
+	directive := &AuthHookDirective{}
+	if len(bytes.TrimSpace(body)) != 0 {
+		err = json.Unmarshal(body, directive)
+		if err != nil {
+			return nil, fmt.Errorf("the webhook: %s of the auth hook: %s responded with an invalid directive: %s", hook.Webhook, hook.Name, err.Error())
+		}
+	}
+	return directive, nil
+}
+
+// mergeAuthHookDirective merges the directive of a hook into the result, the claims of the earlier hooks win
+func mergeAuthHookDirective(res *AuthHookDirective, directive *AuthHookDirective) {
+	if directive.Deny {
+		res.Deny = true
+		res.Message = directive.Message
+	}
+
+	for key, value := range directive.Claims {
+		if res.Claims == nil {
+			res.Claims = map[string]interface{}{}
+		}
+		if _, ok := res.Claims[key]; !ok {
+			res.Claims[key] = value
+		}
+	}
+
+	for _, group := range directive.Groups {
+		if !util.InSlice(res.Groups, group) {
+			res.Groups = append(res.Groups, group)
+		}
+	}
+}
+
+func getAuthHooks(organization *Organization, event string, application string) []*AuthHook {
+	res := []*AuthHook{}
+	if organization == nil {
+		return res
+	}
+
+	for _, hook := range organization.AuthHooks {
+		if !hook.IsEnabled || hook.Event != event {
+			continue
+		}
+		if len(hook.Applications) > 0 && !util.InSlice(hook.Applications, application) {
+			continue
+		}
+		res = append(res, hook)
+	}
+	return res
+}
+
+// runAuthHooks runs the hooks of the event and returns their merged directive, the run stops at the first denial
+func runAuthHooks(hooks []*AuthHook, hookContext *AuthHookContext) *AuthHookDirective {
+	res := &AuthHookDirective{}
+	for _, hook := range hooks {
+		var directive *AuthHookDirective
+		var err error
+		if hook.Webhook != "" {
+			directive, err = callAuthHookWebhook(hook, hookContext)
+		} else {
+			directive, err = evaluateAuthHookExpression(hook, hookContext)
+		}
+
+		if err != nil {
+			logs.Warning(fmt.Sprintf("the auth hook: %s of the organization: %s failed: %s", hook.Name, hookContext.Organization, err.Error()))
+			if hook.IsFailOpen {
+				continue
+			}
+			return &AuthHookDirective{Deny: true, Message: hook.Name}
+		}
+		if directive == nil {
+			continue
+		}
+
+		mergeAuthHookDirective(res, directive)
+		if res.Deny {
+			if res.Message == "" {
+				res.Message = hook.Name
+			}
+			break
+		}
+	}
+	return res
+}
+
+// getAuthHookGroupId returns the id of the group of the directive, which is a group name or a group id of the user's
+// organization, false is returned for a group of another organization
+func getAuthHookGroupId(user *User, group string) (string, bool) {
+	if !strings.Contains(group, "/") {
+		return util.GetId(user.Owner, group), true
+	}
+
+	owner, _ := util.GetOwnerAndNameFromIdNoCheck(group)
+	return group, owner == user.Owner
+}
+
+// enrollAuthHookGroups adds the user into the groups of the directive, the groups are of the user's organization
+func enrollAuthHookGroups(user *User, groups []string) {
+	for _, group := range groups {
+		groupId, ok := getAuthHookGroupId(user, group)
+		if !ok {
+			logs.Warning(fmt.Sprintf("the auth hook can't enroll the user: %s into the group: %s of another organization", user.GetId(), group))
+			continue
+		}
+
+		report, err := BatchUpdateGroupUsers(groupId, []string{user.GetId()}, true, false)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to enroll the user: %s into the group: %s by the auth hook: %s", user.GetId(), groupId, err.Error()))
+			continue
+		}
+		for _, reportError := range report.Errors {
+			logs.Warning(fmt.Sprintf("failed to enroll the user into the group: %s by the auth hook: %s", groupId, reportError))
+		}
+		if report.IsApplied && !util.InSlice(user.Groups, groupId) {
+			user.Groups = append(user.Groups, groupId)
+		}
+	}
+}
+
+// RunAuthHooks runs the hooks of the event for the user signing in to the application, an error is returned if
+// a hook denies the authentication. The groups of the directive are enrolled and its claims are returned.
+func RunAuthHooks(event string, application *Application, user *User, clientIp string, scope string, lang string) (map[string]interface{}, error) {
+	if user == nil || user.Type == "application" {
+		return nil, nil
+	}
+
+	organization, err := getOrganization("admin", user.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	applicationName := ""
+	if application != nil {
+		applicationName = application.Name
+	}
+
+	hooks := getAuthHooks(organization, event, applicationName)
+	if len(hooks) == 0 {
+		return nil, nil
+	}
+
+	directive := runAuthHooks(hooks, &AuthHookContext{
+		Event:        event,
+		Organization: user.Owner,
+		Application:  applicationName,
+		User:         user,
+		ClientIp:     clientIp,
+		Scope:        scope,
+	})
+	if directive.Deny {
+		return nil, fmt.Errorf(i18n.Translate(lang, "auth:The authentication is denied by the hook: %s"), directive.Message)
+	}
+
+	if len(directive.Groups) > 0 && !user.IsTransient() {
+		enrollAuthHookGroups(user, directive.Groups)
+	}
+	return directive.Claims, nil
+}
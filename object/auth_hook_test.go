@@ -0,0 +1,119 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckAuthHooks(t *testing.T) {
+	assert.Nil(t, checkAuthHooks([]*AuthHook{
+		{Name: "deny-contractors", Event: AuthHookEventPostAuthentication, Expression: "type == 'contractor'", Directive: &AuthHookDirective{Deny: true}},
+		{Name: "claims", Event: AuthHookEventPreTokenIssuance, Webhook: "claims-webhook"},
+	}))
+
+	assert.NotNil(t, checkAuthHooks([]*AuthHook{{Name: "hook", Event: "PreSignup", Webhook: "webhook"}}))
+	assert.NotNil(t, checkAuthHooks([]*AuthHook{{Name: "hook", Event: AuthHookEventPostAuthentication}}))
+	assert.NotNil(t, checkAuthHooks([]*AuthHook{{Name: "hook", Event: AuthHookEventPostAuthentication, Webhook: "webhook", Expression: "true"}}))
+	assert.NotNil(t, checkAuthHooks([]*AuthHook{{Name: "hook", Event: AuthHookEventPostAuthentication, Expression: "type ==", Directive: &AuthHookDirective{}}}))
+	assert.NotNil(t, checkAuthHooks([]*AuthHook{{Name: "hook", Event: AuthHookEventPostAuthentication, Expression: "true"}}))
+}
+
+func TestGetAuthHooks(t *testing.T) {
+	organization := &Organization{AuthHooks: []*AuthHook{
+		{Name: "all", IsEnabled: true, Event: AuthHookEventPostAuthentication},
+		{Name: "app1", IsEnabled: true, Event: AuthHookEventPostAuthentication, Applications: []string{"app1"}},
+		{Name: "disabled", Event: AuthHookEventPostAuthentication},
+		{Name: "token", IsEnabled: true, Event: AuthHookEventPreTokenIssuance},
+	}}
+
+	hooks := getAuthHooks(organization, AuthHookEventPostAuthentication, "app2")
+	assert.Equal(t, 1, len(hooks))
+	assert.Equal(t, "all", hooks[0].Name)
+
+	hooks = getAuthHooks(organization, AuthHookEventPostAuthentication, "app1")
+	assert.Equal(t, 2, len(hooks))
+	assert.Equal(t, 0, len(getAuthHooks(nil, AuthHookEventPostAuthentication, "app1")))
+}
+
+func TestRunAuthHooks(t *testing.T) {
+	hookContext := &AuthHookContext{
+		Event:       AuthHookEventPreTokenIssuance,
+		Application: "app1",
+		User:        &User{Owner: "org", Name: "alice", Type: "employee", Groups: []string{"org/admins"}},
+	}
+
+	hooks := []*AuthHook{
+		{Name: "admins", Expression: "'org/admins' in groups", Directive: &AuthHookDirective{Claims: map[string]interface{}{"level": "admin"}, Groups: []string{"audited"}}},
+		{Name: "employees", Expression: "type == 'employee'", Directive: &AuthHookDirective{Claims: map[string]interface{}{"level": "employee", "department": "rd"}}},
+		{Name: "contractors", Expression: "type == 'contractor'", Directive: &AuthHookDirective{Deny: true}},
+	}
+	directive := runAuthHooks(hooks, hookContext)
+	assert.False(t, directive.Deny)
+	assert.Equal(t, map[string]interface{}{"level": "admin", "department": "rd"}, directive.Claims)
+	assert.Equal(t, []string{"audited"}, directive.Groups)
+
+	// the run stops at the denial
+	hookContext.User.Type = "contractor"
+	hooks = append([]*AuthHook{hooks[2]}, hooks[0])
+	directive = runAuthHooks(hooks, hookContext)
+	assert.True(t, directive.Deny)
+	assert.Equal(t, "contractors", directive.Message)
+	assert.Nil(t, directive.Claims)
+
+	// the failed hook denies unless it fails open
+	hooks = []*AuthHook{{Name: "broken", Expression: "name", Directive: &AuthHookDirective{}}}
+	assert.True(t, runAuthHooks(hooks, hookContext).Deny)
+	hooks[0].IsFailOpen = true
+	assert.False(t, runAuthHooks(hooks, hookContext).Deny)
+}
+
+func TestAddHookClaims(t *testing.T) {
+	claims := ClaimsShort{
+		UserShort: &UserShort{Owner: "org", Name: "alice"},
+		TokenType: "access-token",
+	}
+
+	res, err := addHookClaims(claims, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, claims, res)
+
+	// the claims of the token are not overridden by the hooks
+	res, err = addHookClaims(claims, map[string]interface{}{"department": "rd", "name": "bob"})
+	assert.Nil(t, err)
+	mapClaims := res.(jwt.MapClaims)
+	assert.Equal(t, "rd", mapClaims["department"])
+	assert.Equal(t, "alice", mapClaims["name"])
+	assert.Equal(t, "access-token", mapClaims["tokenType"])
+}
+
+func TestGetAuthHookGroupId(t *testing.T) {
+	user := &User{Owner: "org", Name: "alice"}
+
+	groupId, ok := getAuthHookGroupId(user, "staff")
+	assert.True(t, ok)
+	assert.Equal(t, "org/staff", groupId)
+
+	groupId, ok = getAuthHookGroupId(user, "org/staff")
+	assert.True(t, ok)
+	assert.Equal(t, "org/staff", groupId)
+
+	// the webhook can't enroll the user into the groups of another organization
+	_, ok = getAuthHookGroupId(user, "other-org/staff")
+	assert.False(t, ok)
+}
@@ -0,0 +1,594 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	BackupConflictSkip      = "skip"
+	BackupConflictOverwrite = "overwrite"
+	BackupConflictFail      = "fail"
+)
+
+const (
+	backupMagic          = "CASDOOR-BACKUP-V1\n"
+	backupSaltSize       = 16
+	backupKeyIterations  = 100000
+	backupChunkSize      = 500
+	backupMaxChunkLength = 256 << 20
+)
+
+// backupTable is a kind of objects in the backup, the objects of the tables with policies are imported by their
+// add and update functions, the others are written to the database as they are
+type backupTable struct {
+	Name      string
+	CacheType string
+	NewSlice  func() interface{}
+	Add       func(item interface{}) (bool, error)
+	Update    func(id string, item interface{}) (bool, error)
+}
+
+// backupTables are in the order to be imported, the organizations come first as the other objects belong to them
+var backupTables = []*backupTable{
+	{Name: "organizations", CacheType: CacheTypeOrganization, NewSlice: func() interface{} { return &[]*Organization{} }},
+	{Name: "certs", CacheType: CacheTypeCert, NewSlice: func() interface{} { return &[]*Cert{} }},
+	{Name: "providers", NewSlice: func() interface{} { return &[]*Provider{} }},
+	{Name: "applications", CacheType: CacheTypeApplication, NewSlice: func() interface{} { return &[]*Application{} }},
+	{Name: "groups", NewSlice: func() interface{} { return &[]*Group{} }},
+	{Name: "users", NewSlice: func() interface{} { return &[]*User{} }},
+	{Name: "models", NewSlice: func() interface{} { return &[]*Model{} }},
+	{Name: "adapters", NewSlice: func() interface{} { return &[]*Adapter{} }},
+	{Name: "enforcers", CacheType: CacheTypeEnforcer, NewSlice: func() interface{} { return &[]*Enforcer{} }},
+	{
+		Name:     "roles",
+		NewSlice: func() interface{} { return &[]*Role{} },
+		Add:      func(item interface{}) (bool, error) { return AddRole(item.(*Role)) },
+		Update:   func(id string, item interface{}) (bool, error) { return UpdateRole(id, item.(*Role)) },
+	},
+	{
+		Name:     "permissions",
+		NewSlice: func() interface{} { return &[]*Permission{} },
+		Add:      func(item interface{}) (bool, error) { return AddPermission(item.(*Permission)) },
+		Update:   func(id string, item interface{}) (bool, error) { return UpdatePermission(id, item.(*Permission)) },
+	},
+}
+
+// BackupCursor is the position of a chunk in the backup, an interrupted export or import is resumed from it
+type BackupCursor struct {
+	Table  string `json:"table"`
+	Offset int    `json:"offset"`
+}
+
+type BackupOptions struct {
+	Password string
+	Include  []string
+	Exclude  []string
+	Cursor   *BackupCursor
+	// Conflict is how an existing object is imported: "skip" (the default), "overwrite" or "fail"
+	Conflict string
+}
+
+// BackupImportResult is the number of the imported objects per table, Cursor is the next chunk to be imported
+type BackupImportResult struct {
+	Added       map[string]int `json:"added"`
+	Overwritten map[string]int `json:"overwritten"`
+	Skipped     map[string]int `json:"skipped"`
+	Cursor      *BackupCursor  `json:"cursor"`
+	IsComplete  bool           `json:"isComplete"`
+}
+
+type backupChunk struct {
+	CreatedTime string          `json:"createdTime,omitempty"`
+	Table       string          `json:"table,omitempty"`
+	Offset      int             `json:"offset"`
+	Items       json.RawMessage `json:"items,omitempty"`
+	IsEnd       bool            `json:"isEnd,omitempty"`
+}
+
+func GetBackupTableNames() []string {
+	res := []string{}
+	for _, table := range backupTables {
+		res = append(res, table.Name)
+	}
+	return res
+}
+
+func getBackupTableIndex(name string) int {
+	for i, table := range backupTables {
+		if table.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (options *BackupOptions) check() error {
+	if options.Password == "" {
+		return fmt.Errorf("the password of the backup should not be empty")
+	}
+
+	for _, name := range append(append([]string{}, options.Include...), options.Exclude...) {
+		if getBackupTableIndex(name) == -1 {
+			return fmt.Errorf("the backup table: %s is not supported, it should be one of: %v", name, GetBackupTableNames())
+		}
+	}
+
+	if options.Cursor != nil && options.Cursor.Table != "" && getBackupTableIndex(options.Cursor.Table) == -1 {
+		return fmt.Errorf("the backup table: %s is not supported, it should be one of: %v", options.Cursor.Table, GetBackupTableNames())
+	}
+
+	if options.Conflict != "" && options.Conflict != BackupConflictSkip && options.Conflict != BackupConflictOverwrite && options.Conflict != BackupConflictFail {
+		return fmt.Errorf("the conflict strategy: %s is not supported", options.Conflict)
+	}
+	return nil
+}
+
+func (options *BackupOptions) isTableIncluded(name string) bool {
+	if len(options.Include) != 0 && !util.InSlice(options.Include, name) {
+		return false
+	}
+	return !util.InSlice(options.Exclude, name)
+}
+
+// isBeforeCursor returns whether the chunk has been processed before the cursor
+func (options *BackupOptions) isBeforeCursor(name string, offset int) bool {
+	if options.Cursor == nil || options.Cursor.Table == "" {
+		return false
+	}
+
+	index, cursorIndex := getBackupTableIndex(name), getBackupTableIndex(options.Cursor.Table)
+	return index < cursorIndex || (index == cursorIndex && offset < options.Cursor.Offset)
+}
+
+func getBackupAead(password string, salt []byte) (cipher.AEAD, error) {
+	key := pbkdf2.Key([]byte(password), salt, backupKeyIterations, 32, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// backupWriter seals every chunk with AES-GCM, the index of the chunk is authenticated so that
+// the chunks can't be reordered or dropped from the middle of the archive
+type backupWriter struct {
+	writer io.Writer
+	aead   cipher.AEAD
+	index  uint64
+}
+
+func newBackupWriter(writer io.Writer, password string) (*backupWriter, error) {
+	salt := make([]byte, backupSaltSize)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := getBackupAead(password, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = writer.Write(append([]byte(backupMagic), salt...))
+	if err != nil {
+		return nil, err
+	}
+	return &backupWriter{writer: writer, aead: aead}, nil
+}
+
+func getBackupChunkAad(index uint64) []byte {
+	res := make([]byte, 8)
+	binary.BigEndian.PutUint64(res, index)
+	return res
+}
+
+func (w *backupWriter) WriteChunk(chunk *backupChunk) error {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, w.aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return err
+	}
+
+	sealed := w.aead.Seal(nonce, nonce, data, getBackupChunkAad(w.index))
+	w.index++
+
+	length := make([]byte, 4)
+	binary.BigEndian.PutUint32(length, uint32(len(sealed)))
+	_, err = w.writer.Write(append(length, sealed...))
+	return err
+}
+
+type backupReader struct {
+	reader io.Reader
+	aead   cipher.AEAD
+	index  uint64
+}
+
+func newBackupReader(reader io.Reader, password string) (*backupReader, error) {
+	header := make([]byte, len(backupMagic)+backupSaltSize)
+	_, err := io.ReadFull(reader, header)
+	if err != nil || string(header[:len(backupMagic)]) != backupMagic {
+		return nil, fmt.Errorf("the file is not a Casdoor backup")
+	}
+
+	aead, err := getBackupAead(password, header[len(backupMagic):])
+	if err != nil {
+		return nil, err
+	}
+	return &backupReader{reader: reader, aead: aead}, nil
+}
+
+func (r *backupReader) ReadChunk() (*backupChunk, error) {
+	length := make([]byte, 4)
+	_, err := io.ReadFull(r.reader, length)
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("the backup is truncated")
+		}
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(length)
+	if size > backupMaxChunkLength || int(size) < r.aead.NonceSize() {
+		return nil, fmt.Errorf("the backup is corrupted")
+	}
+
+	sealed := make([]byte, size)
+	_, err = io.ReadFull(r.reader, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("the backup is truncated")
+	}
+
+	nonceSize := r.aead.NonceSize()
+	data, err := r.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], getBackupChunkAad(r.index))
+	if err != nil {
+		return nil, fmt.Errorf("the password of the backup is wrong or the backup is corrupted")
+	}
+	r.index++
+
+	chunk := &backupChunk{}
+	err = json.Unmarshal(data, chunk)
+	if err != nil {
+		return nil, err
+	}
+	return chunk, nil
+}
+
+// ExportBackup writes the objects of the included tables as an encrypted archive, the export starts from
+// the cursor if any, so that an interrupted export can be continued by another archive
+func ExportBackup(writer io.Writer, options *BackupOptions) error {
+	err := options.check()
+	if err != nil {
+		return err
+	}
+
+	w, err := newBackupWriter(writer, options.Password)
+	if err != nil {
+		return err
+	}
+
+	err = w.WriteChunk(&backupChunk{CreatedTime: util.GetCurrentTime()})
+	if err != nil {
+		return err
+	}
+
+	for _, table := range backupTables {
+		if !options.isTableIncluded(table.Name) {
+			continue
+		}
+
+		offset := 0
+		if options.Cursor != nil && options.Cursor.Table == table.Name {
+			offset = options.Cursor.Offset
+		} else if options.isBeforeCursor(table.Name, 0) {
+			continue
+		}
+
+		for {
+			items := table.NewSlice()
+			err = ormer.Engine.Asc("owner", "name").Limit(backupChunkSize, offset).Find(items)
+			if err != nil {
+				return err
+			}
+
+			count := reflect.ValueOf(items).Elem().Len()
+			if count == 0 {
+				break
+			}
+
+			// the sensitive columns of the users stay encrypted in the backup, so shredding the data keys covers it
+			if users, ok := items.(*[]*User); ok {
+				for _, user := range *users {
+					err = user.encryptSensitiveFields()
+					if err != nil {
+						return err
+					}
+				}
+			}
+
+			data, err := json.Marshal(items)
+			if err != nil {
+				return err
+			}
+
+			err = w.WriteChunk(&backupChunk{Table: table.Name, Offset: offset, Items: data})
+			if err != nil {
+				return err
+			}
+
+			offset += count
+			if count < backupChunkSize {
+				break
+			}
+		}
+	}
+
+	return w.WriteChunk(&backupChunk{IsEnd: true})
+}
+
+func getBackupItemOwnerAndName(item interface{}) (string, string) {
+	value := reflect.ValueOf(item).Elem()
+	return value.FieldByName("Owner").String(), value.FieldByName("Name").String()
+}
+
+// importBackupItem returns whether the object is added, overwritten or skipped
+func importBackupItem(table *backupTable, item interface{}, conflict string) (string, error) {
+	owner, name := getBackupItemOwnerAndName(item)
+	if user, ok := item.(*User); ok {
+		err := user.encryptSensitiveFields()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	bean := reflect.New(reflect.TypeOf(item).Elem()).Interface()
+	existed, err := ormer.Engine.ID(core.PK{owner, name}).Exist(bean)
+	if err != nil {
+		return "", err
+	}
+
+	if !existed {
+		if table.Add != nil {
+			_, err = table.Add(item)
+		} else {
+			_, err = ormer.Engine.Insert(item)
+		}
+		return "added", err
+	}
+
+	if conflict == BackupConflictFail {
+		return "", fmt.Errorf("the %s: %s already exists", table.Name, util.GetId(owner, name))
+	}
+	if conflict != BackupConflictOverwrite {
+		return "skipped", nil
+	}
+
+	if table.Update != nil {
+		_, err = table.Update(util.GetId(owner, name), item)
+	} else {
+		_, err = ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(item)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if table.CacheType != "" {
+		invalidateLocalCache(table.CacheType, util.GetId(owner, name))
+		publishCacheInvalidation(table.CacheType, util.GetId(owner, name))
+	}
+	return "overwritten", nil
+}
+
+// ImportBackup imports the objects of the included tables from the encrypted archive. The chunks before the cursor
+// are skipped, and the result has the cursor of the next chunk, so a failed import can be resumed from it.
+func ImportBackup(reader io.Reader, options *BackupOptions) (*BackupImportResult, error) {
+	res := &BackupImportResult{
+		Added:       map[string]int{},
+		Overwritten: map[string]int{},
+		Skipped:     map[string]int{},
+		Cursor:      options.Cursor,
+	}
+
+	err := options.check()
+	if err != nil {
+		return res, err
+	}
+
+	r, err := newBackupReader(reader, options.Password)
+	if err != nil {
+		return res, err
+	}
+
+	for {
+		chunk, err := r.ReadChunk()
+		if err != nil {
+			return res, err
+		}
+
+		if chunk.IsEnd {
+			res.IsComplete = true
+			return res, nil
+		}
+
+		if chunk.Table == "" {
+			continue
+		}
+
+		index := getBackupTableIndex(chunk.Table)
+		if index == -1 {
+			return res, fmt.Errorf("the backup table: %s is not supported", chunk.Table)
+		}
+
+		table := backupTables[index]
+		if !options.isTableIncluded(table.Name) {
+			continue
+		}
+
+		items := table.NewSlice()
+		err = json.Unmarshal(chunk.Items, items)
+		if err != nil {
+			return res, err
+		}
+
+		list := reflect.ValueOf(items).Elem()
+		for i := 0; i < list.Len(); i++ {
+			if options.isBeforeCursor(table.Name, chunk.Offset+i) {
+				continue
+			}
+
+			result, err := importBackupItem(table, list.Index(i).Interface(), options.Conflict)
+			if err != nil {
+				res.Cursor = &BackupCursor{Table: table.Name, Offset: chunk.Offset + i}
+				return res, err
+			}
+
+			switch result {
+			case "added":
+				res.Added[table.Name]++
+			case "overwritten":
+				res.Overwritten[table.Name]++
+			default:
+				res.Skipped[table.Name]++
+			}
+		}
+
+		if !options.isBeforeCursor(table.Name, chunk.Offset+list.Len()) {
+			res.Cursor = &BackupCursor{Table: table.Name, Offset: chunk.Offset + list.Len()}
+		}
+	}
+}
+
+var backupFlags struct {
+	Export   string
+	Import   string
+	Include  string
+	Exclude  string
+	Cursor   string
+	Conflict string
+}
+
+func initBackupFlags() {
+	flag.StringVar(&backupFlags.Export, "exportBackup", "", "the file to export the encrypted backup to with the \"backupPassword\" config, then Casdoor exits")
+	flag.StringVar(&backupFlags.Import, "importBackup", "", "the encrypted backup file to import with the \"backupPassword\" config, then Casdoor exits")
+	flag.StringVar(&backupFlags.Include, "backupInclude", "", "the comma-separated tables to export or import, all of them if empty")
+	flag.StringVar(&backupFlags.Exclude, "backupExclude", "", "the comma-separated tables not to export or import")
+	flag.StringVar(&backupFlags.Cursor, "backupCursor", "", "the \"table:offset\" to resume the export or import from")
+	flag.StringVar(&backupFlags.Conflict, "backupConflict", BackupConflictSkip, "how to import the existing objects: skip, overwrite or fail")
+}
+
+// ParseBackupTables parses the comma-separated table names
+func ParseBackupTables(s string) []string {
+	res := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+func getBackupFlagOptions() (*BackupOptions, error) {
+	options := &BackupOptions{
+		Password: conf.GetConfigString("backupPassword"),
+		Include:  ParseBackupTables(backupFlags.Include),
+		Exclude:  ParseBackupTables(backupFlags.Exclude),
+		Conflict: backupFlags.Conflict,
+	}
+
+	if backupFlags.Cursor != "" {
+		tokens := strings.SplitN(backupFlags.Cursor, ":", 2)
+		offset := 0
+		if len(tokens) == 2 {
+			var err error
+			offset, err = strconv.Atoi(tokens[1])
+			if err != nil {
+				return nil, fmt.Errorf("the backup cursor: %s should be like \"users:500\"", backupFlags.Cursor)
+			}
+		}
+		options.Cursor = &BackupCursor{Table: tokens[0], Offset: offset}
+	}
+	return options, nil
+}
+
+// RunBackupCommand exports or imports the backup given by the command line flags and exits,
+// it does nothing if neither -exportBackup nor -importBackup is set
+func RunBackupCommand() {
+	if backupFlags.Export == "" && backupFlags.Import == "" {
+		return
+	}
+
+	options, err := getBackupFlagOptions()
+	if err != nil {
+		panic(err)
+	}
+
+	if backupFlags.Export != "" {
+		file, err := os.Create(backupFlags.Export)
+		if err != nil {
+			panic(err)
+		}
+
+		err = ExportBackup(file, options)
+		file.Close()
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("The backup has been exported to: %s\n", backupFlags.Export)
+		os.Exit(0)
+	}
+
+	file, err := os.Open(backupFlags.Import)
+	if err != nil {
+		panic(err)
+	}
+
+	result, err := ImportBackup(file, options)
+	file.Close()
+	fmt.Printf("Added: %v, overwritten: %v, skipped: %v\n", result.Added, result.Overwritten, result.Skipped)
+	if err != nil {
+		if result.Cursor != nil {
+			fmt.Printf("The import can be resumed with: -backupCursor %s:%d\n", result.Cursor.Table, result.Cursor.Offset)
+		}
+		panic(err)
+	}
+
+	fmt.Printf("The backup has been imported from: %s\n", backupFlags.Import)
+	os.Exit(0)
+}
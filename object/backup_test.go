@@ -0,0 +1,80 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackupChunks(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newBackupWriter(&buf, "123456")
+	assert.Nil(t, err)
+
+	assert.Nil(t, w.WriteChunk(&backupChunk{Table: "users", Offset: 0, Items: []byte(`[{"owner":"org1","name":"alice"}]`)}))
+	assert.Nil(t, w.WriteChunk(&backupChunk{IsEnd: true}))
+	data := buf.Bytes()
+
+	r, err := newBackupReader(bytes.NewReader(data), "123456")
+	assert.Nil(t, err)
+	chunk, err := r.ReadChunk()
+	assert.Nil(t, err)
+	assert.Equal(t, "users", chunk.Table)
+	assert.JSONEq(t, `[{"owner":"org1","name":"alice"}]`, string(chunk.Items))
+	chunk, err = r.ReadChunk()
+	assert.Nil(t, err)
+	assert.True(t, chunk.IsEnd)
+
+	r, err = newBackupReader(bytes.NewReader(data), "wrong password")
+	assert.Nil(t, err)
+	_, err = r.ReadChunk()
+	assert.NotNil(t, err)
+
+	r, err = newBackupReader(bytes.NewReader(data[:len(data)-10]), "123456")
+	assert.Nil(t, err)
+	_, err = r.ReadChunk()
+	assert.Nil(t, err)
+	_, err = r.ReadChunk()
+	assert.NotNil(t, err)
+
+	_, err = newBackupReader(bytes.NewReader([]byte("not a backup")), "123456")
+	assert.NotNil(t, err)
+}
+
+func TestBackupOptions(t *testing.T) {
+	options := &BackupOptions{Password: "123456", Include: []string{"users", "roles"}, Exclude: []string{"roles"}}
+	assert.Nil(t, options.check())
+	assert.True(t, options.isTableIncluded("users"))
+	assert.False(t, options.isTableIncluded("roles"))
+	assert.False(t, options.isTableIncluded("applications"))
+
+	assert.NotNil(t, (&BackupOptions{}).check())
+	assert.NotNil(t, (&BackupOptions{Password: "123456", Include: []string{"tokens"}}).check())
+	assert.NotNil(t, (&BackupOptions{Password: "123456", Conflict: "merge"}).check())
+
+	options = &BackupOptions{Password: "123456", Cursor: &BackupCursor{Table: "users", Offset: 500}}
+	assert.True(t, options.isBeforeCursor("organizations", 1000))
+	assert.True(t, options.isBeforeCursor("users", 499))
+	assert.False(t, options.isBeforeCursor("users", 500))
+	assert.False(t, options.isBeforeCursor("permissions", 0))
+}
+
+func TestParseBackupTables(t *testing.T) {
+	assert.Equal(t, []string{"users", "roles"}, ParseBackupTables(" users, ,roles "))
+	assert.Equal(t, []string{}, ParseBackupTables(""))
+}
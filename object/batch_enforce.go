@@ -0,0 +1,99 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+)
+
+// BatchEnforceResult is the decision of one request of the batch, a malformed request only fails its own item
+type BatchEnforceResult struct {
+	Allowed bool   `json:"allowed"`
+	Error   string `json:"error,omitempty"`
+	// Duration is the time spent on the request in microseconds
+	Duration int64 `json:"duration"`
+}
+
+// getBatchEnforceWorkers returns the number of the requests of a batch enforced concurrently,
+// set by the "batchEnforceWorkers" config
+func getBatchEnforceWorkers() int {
+	return getRecordWriterConfigInt("batchEnforceWorkers", runtime.NumCPU())
+}
+
+func enforceBatchItem(enforcer *casbin.Enforcer, pdp *ExternalPdp, request CasbinRequest) (result *BatchEnforceResult) {
+	start := time.Now()
+	result = &BatchEnforceResult{}
+	defer func() {
+		if r := recover(); r != nil {
+			result.Allowed = false
+			result.Error = fmt.Sprintf("%v", r)
+		}
+		result.Duration = time.Since(start).Microseconds()
+	}()
+
+	res, err := enforcer.Enforce(request...)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Allowed = combinePdpDecisions(pdp, request, res, queryExternalPdp)
+	return result
+}
+
+// BatchEnforceRequests enforces the requests concurrently by a bounded pool of workers, the results are
+// in the order of the requests
+func BatchEnforceRequests(enforcer *casbin.Enforcer, pdp *ExternalPdp, requests []CasbinRequest) []*BatchEnforceResult {
+	res := make([]*BatchEnforceResult, len(requests))
+
+	workers := getBatchEnforceWorkers()
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				res[index] = enforceBatchItem(enforcer, pdp, requests[index])
+			}
+		}()
+	}
+
+	for i := range requests {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return res
+}
+
+// GetBatchEnforceDecisions returns the decisions of the results, the failed requests are denied
+func GetBatchEnforceDecisions(results []*BatchEnforceResult) []bool {
+	res := make([]bool, len(results))
+	for i, result := range results {
+		res[i] = result.Allowed
+	}
+	return res
+}
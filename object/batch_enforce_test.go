@@ -0,0 +1,67 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchEnforceRequests(t *testing.T) {
+	m, err := model.NewModelFromString(`
+[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == p.sub && r.obj == p.obj && r.act == p.act`)
+	assert.Nil(t, err)
+
+	enforcer, err := casbin.NewEnforcer(m)
+	assert.Nil(t, err)
+	_, err = enforcer.AddPolicy("alice", "data1", "read")
+	assert.Nil(t, err)
+
+	requests := []CasbinRequest{}
+	for i := 0; i < 1000; i++ {
+		requests = append(requests, CasbinRequest{"alice", "data1", "read"}, CasbinRequest{"bob", "data1", "read"})
+	}
+	requests = append(requests, CasbinRequest{"alice", "data1"})
+
+	results := BatchEnforceRequests(enforcer, nil, requests)
+	assert.Equal(t, len(requests), len(results))
+	for i := 0; i < 2000; i += 2 {
+		assert.True(t, results[i].Allowed)
+		assert.Equal(t, "", results[i].Error)
+		assert.False(t, results[i+1].Allowed)
+	}
+
+	malformed := results[len(results)-1]
+	assert.False(t, malformed.Allowed)
+	assert.NotEqual(t, "", malformed.Error)
+
+	decisions := GetBatchEnforceDecisions(results[:3])
+	assert.Equal(t, []bool{true, false, true}, decisions)
+
+	assert.Equal(t, []*BatchEnforceResult{}, BatchEnforceRequests(enforcer, nil, []CasbinRequest{}))
+}
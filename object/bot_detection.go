@@ -0,0 +1,254 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	BotActionLogin  = "login"
+	BotActionSignup = "signup"
+
+	defaultBotBlockScore           = 80
+	defaultBotCaptchaScore         = 40
+	defaultBotMaxAttemptsPerMinute = 10
+
+	botVelocityWindow = time.Minute
+	// the humans take a while to fill in the forms after the pages issue the tokens
+	botTokenMinAge = 2 * time.Second
+	botTokenMaxAge = time.Hour
+)
+
+var reBotUserAgent = regexp.MustCompile(`(?i)(bot|crawler|spider|curl|wget|python|go-http-client|java/|okhttp|headless|phantomjs|selenium|puppeteer|playwright)`)
+
+// BotDetection scores the sign-ins and the signups of the application from 0 (human) to 100 (bot) by the velocity
+// of the attempts, the headers and the signed fingerprint token of the login page. The attempts scoring BlockScore
+// are blocked, the "Dynamic" captcha is shown from CaptchaScore, and the conditional access policies see the score.
+type BotDetection struct {
+	IsEnabled            bool `json:"isEnabled"`
+	BlockScore           int  `json:"blockScore"`
+	CaptchaScore         int  `json:"captchaScore"`
+	MaxAttemptsPerMinute int  `json:"maxAttemptsPerMinute"`
+}
+
+// BotSignals are the signals of the request scored by the bot detection
+type BotSignals struct {
+	Action         string
+	ClientIp       string
+	UserAgent      string
+	AcceptLanguage string
+	Token          string
+	Time           time.Time
+	// IsPreview scores the request without recording the attempt, like the check for the captcha before the sign-in
+	IsPreview bool
+}
+
+type BotScore struct {
+	Score   int      `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+type botToken struct {
+	Fingerprint string `json:"f"`
+	ClientIp    string `json:"i"`
+	IssuedTime  int64  `json:"t"`
+}
+
+type botVelocity struct {
+	lock     sync.Mutex
+	attempts map[string][]time.Time
+}
+
+var botAttempts = &botVelocity{attempts: map[string][]time.Time{}}
+
+func checkBotDetection(botDetection *BotDetection) error {
+	if botDetection == nil {
+		return nil
+	}
+
+	for _, score := range []int{botDetection.BlockScore, botDetection.CaptchaScore} {
+		if score < 0 || score > 100 {
+			return fmt.Errorf("the bot scores should be between 0 and 100")
+		}
+	}
+	if botDetection.MaxAttemptsPerMinute < 0 {
+		return fmt.Errorf("the max attempts per minute of the bot detection should not be negative")
+	}
+	return nil
+}
+
+func (botDetection *BotDetection) IsBlocked(score int) bool {
+	blockScore := botDetection.BlockScore
+	if blockScore == 0 {
+		blockScore = defaultBotBlockScore
+	}
+	return score >= blockScore
+}
+
+func (botDetection *BotDetection) IsCaptchaRequired(score int) bool {
+	captchaScore := botDetection.CaptchaScore
+	if captchaScore == 0 {
+		captchaScore = defaultBotCaptchaScore
+	}
+	return score >= captchaScore
+}
+
+// GetBotDetection returns the enabled bot detection of the application
+func (application *Application) GetBotDetection() *BotDetection {
+	if application == nil || application.BotDetection == nil || !application.BotDetection.IsEnabled {
+		return nil
+	}
+	return application.BotDetection
+}
+
+// add records the attempt of the key if isRecorded and returns the attempts of the key within the window
+func (velocity *botVelocity) add(key string, now time.Time, isRecorded bool) int {
+	velocity.lock.Lock()
+	defer velocity.lock.Unlock()
+
+	res := []time.Time{}
+	for _, t := range velocity.attempts[key] {
+		if now.Sub(t) < botVelocityWindow {
+			res = append(res, t)
+		}
+	}
+	if !isRecorded {
+		return len(res) + 1
+	}
+
+	res = append(res, now)
+	velocity.attempts[key] = res
+
+	// the idle keys are dropped once in a while to bound the memory
+	if len(velocity.attempts) > 10000 {
+		for k, times := range velocity.attempts {
+			if now.Sub(times[len(times)-1]) >= botVelocityWindow {
+				delete(velocity.attempts, k)
+			}
+		}
+	}
+	return len(res)
+}
+
+// IssueBotToken signs the fingerprint of the login page by the client secret of the application
+func IssueBotToken(application *Application, fingerprint string, clientIp string, now time.Time) (string, error) {
+	if fingerprint == "" || len(fingerprint) > 128 {
+		return "", fmt.Errorf("the fingerprint should have 1 to 128 characters")
+	}
+
+	payload, err := json.Marshal(&botToken{Fingerprint: fingerprint, ClientIp: clientIp, IssuedTime: now.Unix()})
+	if err != nil {
+		return "", err
+	}
+
+	data := base64.RawURLEncoding.EncodeToString(payload)
+	return data + "." + util.GetHmacSha256(application.ClientSecret, data), nil
+}
+
+func parseBotToken(application *Application, token string) (*botToken, error) {
+	tokens := strings.Split(token, ".")
+	if len(tokens) != 2 || subtle.ConstantTimeCompare([]byte(util.GetHmacSha256(application.ClientSecret, tokens[0])), []byte(tokens[1])) != 1 {
+		return nil, fmt.Errorf("the bot token is invalid")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(tokens[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var res botToken
+	err = json.Unmarshal(payload, &res)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func getVelocityScore(attempts int, maxAttempts int) int {
+	if attempts <= maxAttempts {
+		return 0
+	}
+	score := (attempts - maxAttempts) * 10
+	if score > 40 {
+		return 40
+	}
+	return score
+}
+
+// GetBotScore scores the signals of the request for the bot detection of the application, the attempt is recorded
+// for the velocity of its IP and fingerprint
+func GetBotScore(application *Application, signals *BotSignals) *BotScore {
+	botDetection := application.GetBotDetection()
+	if botDetection == nil {
+		return &BotScore{Reasons: []string{}}
+	}
+
+	res := &BotScore{Reasons: []string{}}
+	addScore := func(score int, reason string) {
+		if score > 0 {
+			res.Score += score
+			res.Reasons = append(res.Reasons, reason)
+		}
+	}
+
+	if signals.UserAgent == "" {
+		addScore(30, "missing user agent")
+	} else if reBotUserAgent.MatchString(signals.UserAgent) {
+		addScore(40, "automation user agent")
+	}
+	if signals.AcceptLanguage == "" {
+		addScore(15, "missing accept language")
+	}
+
+	maxAttempts := botDetection.MaxAttemptsPerMinute
+	if maxAttempts == 0 {
+		maxAttempts = defaultBotMaxAttemptsPerMinute
+	}
+	ipAttempts := botAttempts.add(fmt.Sprintf("%s/%s/ip/%s", application.Name, signals.Action, signals.ClientIp), signals.Time, !signals.IsPreview)
+	addScore(getVelocityScore(ipAttempts, maxAttempts), "too many attempts from the IP")
+
+	token, err := parseBotToken(application, signals.Token)
+	if err != nil {
+		addScore(30, "missing or invalid fingerprint token")
+	} else {
+		age := signals.Time.Sub(time.Unix(token.IssuedTime, 0))
+		if age < botTokenMinAge {
+			addScore(20, "submitted too fast")
+		} else if age > botTokenMaxAge {
+			addScore(20, "expired fingerprint token")
+		}
+		if token.ClientIp != signals.ClientIp {
+			addScore(10, "fingerprint token from another IP")
+		}
+
+		fingerprintAttempts := botAttempts.add(fmt.Sprintf("%s/%s/fingerprint/%s", application.Name, signals.Action, token.Fingerprint), signals.Time, !signals.IsPreview)
+		addScore(getVelocityScore(fingerprintAttempts, maxAttempts), "too many attempts from the device")
+	}
+
+	if res.Score > 100 {
+		res.Score = 100
+	}
+	return res
+}
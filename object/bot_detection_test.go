@@ -0,0 +1,88 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestBotSignals(token string, now time.Time) *BotSignals {
+	return &BotSignals{
+		Action:         BotActionLogin,
+		ClientIp:       "192.0.2.1",
+		UserAgent:      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+		AcceptLanguage: "en-US,en;q=0.9",
+		Token:          token,
+		Time:           now,
+	}
+}
+
+func TestGetBotScore(t *testing.T) {
+	botAttempts = &botVelocity{attempts: map[string][]time.Time{}}
+	application := &Application{Name: "app-bot", ClientSecret: "secret", BotDetection: &BotDetection{IsEnabled: true, MaxAttemptsPerMinute: 2}}
+	now := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+
+	token, err := IssueBotToken(application, "fp1", "192.0.2.1", now.Add(-10*time.Second))
+	assert.Nil(t, err)
+
+	// a browser with a valid token scores 0
+	score := GetBotScore(application, getTestBotSignals(token, now))
+	assert.Equal(t, 0, score.Score)
+
+	// the previews don't count as the attempts
+	signals := getTestBotSignals(token, now)
+	signals.IsPreview = true
+	assert.Equal(t, 0, GetBotScore(application, signals).Score)
+	assert.Equal(t, 0, GetBotScore(application, getTestBotSignals(token, now)).Score)
+	assert.Equal(t, 20, GetBotScore(application, getTestBotSignals(token, now)).Score)
+
+	// a script without the headers or the token
+	botAttempts = &botVelocity{attempts: map[string][]time.Time{}}
+	score = GetBotScore(application, &BotSignals{Action: BotActionSignup, ClientIp: "192.0.2.2", UserAgent: "python-requests/2.31", Time: now})
+	assert.Equal(t, 85, score.Score)
+	assert.Equal(t, []string{"automation user agent", "missing accept language", "missing or invalid fingerprint token"}, score.Reasons)
+	assert.True(t, application.BotDetection.IsBlocked(score.Score))
+
+	// the tampered, fast and relayed tokens
+	botAttempts = &botVelocity{attempts: map[string][]time.Time{}}
+	application.BotDetection.MaxAttemptsPerMinute = 0
+	signals = getTestBotSignals(token+"x", now)
+	assert.Equal(t, 30, GetBotScore(application, signals).Score)
+	fastToken, _ := IssueBotToken(application, "fp2", "192.0.2.1", now)
+	assert.Equal(t, 20, GetBotScore(application, getTestBotSignals(fastToken, now)).Score)
+	otherToken, _ := IssueBotToken(application, "fp3", "198.51.100.1", now.Add(-10*time.Second))
+	assert.Equal(t, 10, GetBotScore(application, getTestBotSignals(otherToken, now)).Score)
+
+	// the disabled detection doesn't score
+	assert.Equal(t, 0, GetBotScore(&Application{Name: "app-bot"}, &BotSignals{Time: now}).Score)
+}
+
+func TestBotDetectionScores(t *testing.T) {
+	botDetection := &BotDetection{IsEnabled: true}
+	assert.False(t, botDetection.IsCaptchaRequired(39))
+	assert.True(t, botDetection.IsCaptchaRequired(40))
+	assert.False(t, botDetection.IsBlocked(79))
+	assert.True(t, botDetection.IsBlocked(80))
+
+	assert.Nil(t, checkBotDetection(nil))
+	assert.Nil(t, checkBotDetection(&BotDetection{IsEnabled: true, BlockScore: 90, CaptchaScore: 30}))
+	assert.NotNil(t, checkBotDetection(&BotDetection{BlockScore: 101}))
+	assert.NotNil(t, checkBotDetection(&BotDetection{MaxAttemptsPerMinute: -1}))
+	_, err := IssueBotToken(&Application{}, "", "192.0.2.1", time.Now())
+	assert.NotNil(t, err)
+}
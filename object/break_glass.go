@@ -0,0 +1,256 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/xorm-io/xorm"
+)
+
+// UserTypeBreakGlass is the type of the emergency access accounts, they sign in only by their single-use keys without
+// the MFA, captcha, LDAP or identity providers, so the organization can be recovered while these are down
+const UserTypeBreakGlass = "break-glass-user"
+
+const (
+	BreakGlassKeyStateActive  = "Active"
+	BreakGlassKeyStateUsed    = "Used"
+	BreakGlassKeyStateRevoked = "Revoked"
+)
+
+// BreakGlassKey is a single-use key of a break-glass account, only its hash is stored and the key is shown once
+// when it's issued. The account is disabled after the key is used until a new key is issued.
+type BreakGlassKey struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User      string `xorm:"varchar(100) index" json:"user"`
+	KeyHash   string `xorm:"varchar(100) index" json:"-"`
+	CreatedBy string `xorm:"varchar(100)" json:"createdBy"`
+	State     string `xorm:"varchar(100)" json:"state"`
+	UsedTime  string `xorm:"varchar(100)" json:"usedTime"`
+	UsedIp    string `xorm:"varchar(100)" json:"usedIp"`
+}
+
+func (user *User) IsBreakGlass() bool {
+	return user.Type == UserTypeBreakGlass
+}
+
+func GetBreakGlassKeyCount(owner, user, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&BreakGlassKey{User: user})
+}
+
+func GetPaginationBreakGlassKeys(owner, user string, offset, limit int, field, value, sortField, sortOrder string) ([]*BreakGlassKey, error) {
+	breakGlassKeys := []*BreakGlassKey{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&breakGlassKeys, &BreakGlassKey{User: user})
+	if err != nil {
+		return breakGlassKeys, err
+	}
+
+	return breakGlassKeys, nil
+}
+
+// CheckUserBreakGlass returns an error if the break-glass account tries to authenticate in the other ways than its key,
+// so every use of the account is alerted
+func CheckUserBreakGlass(user *User, lang string) error {
+	if user != nil && user.IsBreakGlass() {
+		return fmt.Errorf(i18n.Translate(lang, "auth:The break-glass account can only sign in with its break-glass key"))
+	}
+	return nil
+}
+
+func newBreakGlassRecord(user *User, action string, clientIp string, object interface{}) *casvisorsdk.Record {
+	return &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: user.Owner,
+		User:         user.Name,
+		ClientIp:     clientIp,
+		Method:       "POST",
+		Action:       action,
+		Object:       util.StructToJson(object),
+	}
+}
+
+// revokeBreakGlassKeys revokes the active keys of the break-glass account
+func revokeBreakGlassKeys(db xorm.Interface, user *User) error {
+	_, err := db.Cols("state").Update(&BreakGlassKey{State: BreakGlassKeyStateRevoked}, &BreakGlassKey{Owner: user.Owner, User: user.Name, State: BreakGlassKeyStateActive})
+	return err
+}
+
+// IssueBreakGlassKey issues a new key of the break-glass account and revokes its active ones, the key is returned
+// only this time
+func IssueBreakGlassKey(user *User, createdBy string, lang string) (string, error) {
+	if !user.IsBreakGlass() {
+		return "", fmt.Errorf(i18n.Translate(lang, "auth:The user: %s is not a break-glass account"), user.GetId())
+	}
+
+	key := fmt.Sprintf("bgk_%s%s", util.GenerateClientSecret(), util.GenerateClientSecret())
+	breakGlassKey := &BreakGlassKey{
+		Owner:       user.Owner,
+		Name:        util.GenerateId(),
+		CreatedTime: util.GetCurrentTime(),
+		User:        user.Name,
+		KeyHash:     getTokenHash(key),
+		CreatedBy:   createdBy,
+		State:       BreakGlassKeyStateActive,
+	}
+
+	record := newBreakGlassRecord(user, "issue-break-glass-key", "", breakGlassKey)
+	_, err := runWithRecord(record, func(session *xorm.Session) (bool, error) {
+		err := revokeBreakGlassKeys(session, user)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = session.Insert(breakGlassKey)
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// UseBreakGlassKey signs in the break-glass account by its key, the key is consumed and the admins of the organization
+// are alerted. The failed attempts are recorded as well.
+func UseBreakGlassKey(organization string, username string, key string, clientIp string, lang string) (*User, error) {
+	invalidErr := fmt.Errorf(i18n.Translate(lang, "auth:The break-glass key is invalid or has been used"))
+
+	user, err := getUser(organization, username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsBreakGlass() || user.IsDeleted {
+		return nil, invalidErr
+	}
+
+	breakGlassKey := BreakGlassKey{Owner: user.Owner, User: user.Name, KeyHash: getTokenHash(key), State: BreakGlassKeyStateActive}
+	existed, err := ormer.Engine.Get(&breakGlassKey)
+	if err != nil {
+		return nil, err
+	}
+	if !existed || user.IsForbidden {
+		record := newBreakGlassRecord(user, "break-glass-login-failed", clientIp, user.GetId())
+		util.SafeGoroutine(func() { AddRecord(record) })
+		return nil, invalidErr
+	}
+
+	breakGlassKey.State = BreakGlassKeyStateUsed
+	breakGlassKey.UsedTime = util.GetCurrentTime()
+	breakGlassKey.UsedIp = clientIp
+
+	// the key is consumed by a conditional update, so it can't be used twice by the concurrent requests
+	record := newBreakGlassRecord(user, "break-glass-login", clientIp, breakGlassKey)
+	affected, err := runWithRecord(record, func(session *xorm.Session) (bool, error) {
+		affected, err := session.Where("owner = ? and name = ? and state = ?", breakGlassKey.Owner, breakGlassKey.Name, BreakGlassKeyStateActive).
+			Cols("state", "used_time", "used_ip").Update(&breakGlassKey)
+		return affected != 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !affected {
+		return nil, invalidErr
+	}
+
+	util.SafeGoroutine(func() { alertBreakGlassLogin(user, &breakGlassKey) })
+	return user, nil
+}
+
+// getBreakGlassAlertContent returns the high-priority alert of the use of the break-glass account
+func getBreakGlassAlertContent(user *User, breakGlassKey *BreakGlassKey) string {
+	return fmt.Sprintf("[HIGH PRIORITY] The break-glass account %s signed in from the IP address %s at %s. Its key is disabled after the use, "+
+		"please review the actions of the account and issue a new key to re-arm it.", user.GetId(), breakGlassKey.UsedIp, breakGlassKey.UsedTime)
+}
+
+// alertBreakGlassLogin alerts the Notification providers and the admins of the organization by Email, each channel is
+// tried even if the others fail since some of them may be down during the emergency
+func alertBreakGlassLogin(user *User, breakGlassKey *BreakGlassKey) {
+	content := getBreakGlassAlertContent(user, breakGlassKey)
+	logs.Warning(content)
+
+	providers, err := GetProviders(user.Owner)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to get the providers of the organization: %s, error: %s", user.Owner, err.Error()))
+	}
+	for _, provider := range providers {
+		if provider.Category != "Notification" {
+			continue
+		}
+
+		err = SendNotification(provider, content)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to send the break-glass alert by the provider: %s, error: %s", provider.Name, err.Error()))
+		}
+	}
+
+	err = sendBreakGlassAlertEmails(user, content)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to send the break-glass alert Emails of the organization: %s, error: %s", user.Owner, err.Error()))
+	}
+}
+
+func sendBreakGlassAlertEmails(user *User, content string) error {
+	organization, err := getOrganization("admin", user.Owner)
+	if err != nil {
+		return err
+	}
+	if organization == nil {
+		return fmt.Errorf("the organization: %s does not exist", user.Owner)
+	}
+
+	application, err := GetDefaultApplication(util.GetId("admin", organization.Name))
+	if err != nil {
+		return err
+	}
+
+	provider, err := GetOrganizationEmailProvider(organization, application)
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return fmt.Errorf("the organization: %s has no Email provider", organization.Name)
+	}
+
+	admins := []*User{}
+	err = ormer.Engine.Where("owner = ? and is_admin = ? and is_deleted = ? and email != ?", organization.Name, true, false, "").Find(&admins)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("%s: break-glass account %s signed in", organization.DisplayName, user.Name)
+	for _, admin := range admins {
+		if admin.Name == user.Name {
+			continue
+		}
+
+		err = SendEmail(provider, title, content, admin.Email, organization.DisplayName)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to send the break-glass alert Email to: %s, error: %s", admin.GetId(), err.Error()))
+		}
+	}
+	return nil
+}
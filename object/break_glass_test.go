@@ -0,0 +1,67 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckUserBreakGlass(t *testing.T) {
+	assert.Nil(t, CheckUserBreakGlass(nil, "en"))
+	assert.Nil(t, CheckUserBreakGlass(&User{Type: "normal-user"}, "en"))
+	assert.NotNil(t, CheckUserBreakGlass(&User{Type: UserTypeBreakGlass}, "en"))
+}
+
+func TestGetBreakGlassAlertContent(t *testing.T) {
+	user := &User{Owner: "org", Name: "emergency", Type: UserTypeBreakGlass}
+	breakGlassKey := &BreakGlassKey{UsedIp: "10.0.0.1", UsedTime: "2023-01-01T00:00:00Z"}
+
+	content := getBreakGlassAlertContent(user, breakGlassKey)
+	assert.True(t, strings.HasPrefix(content, "[HIGH PRIORITY]"))
+	assert.Contains(t, content, "org/emergency")
+	assert.Contains(t, content, "10.0.0.1")
+}
+
+func TestRevokeBreakGlassKeys(t *testing.T) {
+	setTestOrmer(t, new(BreakGlassKey))
+
+	user := &User{Owner: "org", Name: "emergency", Type: UserTypeBreakGlass}
+	keys := []*BreakGlassKey{
+		{Owner: "org", Name: "old-key", User: "emergency", State: BreakGlassKeyStateActive},
+		{Owner: "org", Name: "used-key", User: "emergency", State: BreakGlassKeyStateUsed},
+		{Owner: "org", Name: "other-key", User: "other", State: BreakGlassKeyStateActive},
+	}
+	_, err := ormer.Engine.Insert(&keys)
+	assert.Nil(t, err)
+
+	err = revokeBreakGlassKeys(ormer.Engine, user)
+	assert.Nil(t, err)
+
+	getState := func(name string) string {
+		key := BreakGlassKey{Owner: "org", Name: name}
+		existed, err := ormer.Engine.Get(&key)
+		assert.Nil(t, err)
+		assert.True(t, existed)
+		return key.State
+	}
+
+	// the old key of the user is revoked, the used key and the keys of the other users are kept
+	assert.Equal(t, BreakGlassKeyStateRevoked, getState("old-key"))
+	assert.Equal(t, BreakGlassKeyStateUsed, getState("used-key"))
+	assert.Equal(t, BreakGlassKeyStateActive, getState("other-key"))
+}
@@ -0,0 +1,206 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+	"github.com/lib/pq"
+)
+
+const (
+	CacheTypeOrganization = "organization"
+	CacheTypeApplication  = "application"
+	CacheTypeCert         = "cert"
+	CacheTypeEnforcer     = "enforcer"
+	CacheTypeUserGroup    = "user-group"
+
+	cacheInvalidationChannel      = "casdoor_cache_invalidation"
+	cacheInvalidationPollInterval = 3 * time.Second
+	cacheInvalidationRetention    = time.Hour
+)
+
+// CacheInvalidation is a change made by one of the Casdoor instances sharing the database.
+// It is broadcast by LISTEN/NOTIFY for Postgres and by polling this table for the other databases.
+type CacheInvalidation struct {
+	Id          int64  `xorm:"pk autoincr" json:"id"`
+	CreatedTime string `xorm:"varchar(100) index" json:"createdTime"`
+	Instance    string `xorm:"varchar(100)" json:"instance"`
+	Type        string `xorm:"varchar(100)" json:"type"`
+	Key         string `xorm:"varchar(255)" json:"key"`
+}
+
+var (
+	cacheInstanceId           = util.GenerateId()
+	cacheInvalidationHandlers = map[string][]func(key string){}
+	cacheInvalidationMutex    sync.RWMutex
+)
+
+func isCacheInvalidationEnabled() bool {
+	return conf.GetConfigBool("enableCacheInvalidation")
+}
+
+// RegisterCacheInvalidationHandler registers the handler that drops the cached objects of the type
+// changed by the other instances, an empty key means that all the objects of the type should be dropped.
+func RegisterCacheInvalidationHandler(cacheType string, handler func(key string)) {
+	cacheInvalidationMutex.Lock()
+	defer cacheInvalidationMutex.Unlock()
+
+	cacheInvalidationHandlers[cacheType] = append(cacheInvalidationHandlers[cacheType], handler)
+}
+
+func invalidateLocalCache(cacheType string, key string) {
+	cacheInvalidationMutex.RLock()
+	handlers := cacheInvalidationHandlers[cacheType]
+	cacheInvalidationMutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(key)
+	}
+}
+
+func getCacheInvalidationTypes() []string {
+	cacheInvalidationMutex.RLock()
+	defer cacheInvalidationMutex.RUnlock()
+
+	res := []string{}
+	for cacheType := range cacheInvalidationHandlers {
+		res = append(res, cacheType)
+	}
+	return res
+}
+
+// publishCacheInvalidation notifies the other instances that the object has been changed by this instance
+func publishCacheInvalidation(cacheType string, key string) {
+	if !isCacheInvalidationEnabled() {
+		return
+	}
+
+	var err error
+	if ormer.driverName == "postgres" {
+		payload := strings.Join([]string{cacheInstanceId, cacheType, key}, "|")
+		_, err = ormer.Engine.Exec("SELECT pg_notify(?, ?)", cacheInvalidationChannel, payload)
+	} else {
+		_, err = ormer.Engine.Insert(&CacheInvalidation{
+			CreatedTime: util.GetCurrentTime(),
+			Instance:    cacheInstanceId,
+			Type:        cacheType,
+			Key:         key,
+		})
+	}
+	if err != nil {
+		logs.Warning(fmt.Sprintf("publishCacheInvalidation() error: %s", err.Error()))
+	}
+}
+
+func handleCacheInvalidation(instance string, cacheType string, key string) {
+	if instance == cacheInstanceId {
+		return
+	}
+
+	invalidateLocalCache(cacheType, key)
+}
+
+func listenCacheInvalidations() error {
+	listener := pq.NewListener(ormer.dataSourceName, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logs.Warning(fmt.Sprintf("listenCacheInvalidations() error: %s", err.Error()))
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(cacheInvalidationChannel)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case notification := <-listener.Notify:
+			// a nil notification means the connection was re-established and some notifications may be lost
+			if notification == nil {
+				for _, cacheType := range getCacheInvalidationTypes() {
+					invalidateLocalCache(cacheType, "")
+				}
+				continue
+			}
+
+			tokens := strings.SplitN(notification.Extra, "|", 3)
+			if len(tokens) == 3 {
+				handleCacheInvalidation(tokens[0], tokens[1], tokens[2])
+			}
+		case <-time.After(time.Minute):
+			err = listener.Ping()
+			if err != nil {
+				logs.Warning(fmt.Sprintf("listenCacheInvalidations() ping error: %s", err.Error()))
+			}
+		}
+	}
+}
+
+func pollCacheInvalidations() error {
+	lastInvalidation := &CacheInvalidation{}
+	_, err := ormer.Engine.Desc("id").Get(lastInvalidation)
+	if err != nil {
+		return err
+	}
+
+	lastId := lastInvalidation.Id
+	for {
+		time.Sleep(cacheInvalidationPollInterval)
+
+		invalidations := []*CacheInvalidation{}
+		err = ormer.Engine.Where("id > ?", lastId).Asc("id").Find(&invalidations)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("pollCacheInvalidations() error: %s", err.Error()))
+			continue
+		}
+
+		for _, invalidation := range invalidations {
+			handleCacheInvalidation(invalidation.Instance, invalidation.Type, invalidation.Key)
+			lastId = invalidation.Id
+		}
+
+		expiredTime := time.Now().Add(-cacheInvalidationRetention).Format(time.RFC3339)
+		_, err = ormer.Engine.Where("created_time < ?", expiredTime).Delete(&CacheInvalidation{})
+		if err != nil {
+			logs.Warning(fmt.Sprintf("pollCacheInvalidations() error: %s", err.Error()))
+		}
+	}
+}
+
+// RunCacheInvalidationJob receives the changes made by the other Casdoor instances
+func RunCacheInvalidationJob() {
+	if !isCacheInvalidationEnabled() {
+		return
+	}
+
+	var err error
+	if ormer.driverName == "postgres" {
+		err = listenCacheInvalidations()
+	} else {
+		err = pollCacheInvalidations()
+	}
+
+	if err != nil {
+		logs.Warning(fmt.Sprintf("RunCacheInvalidationJob() error: %s", err.Error()))
+	}
+}
@@ -35,6 +35,22 @@ type Cert struct {
 
 	Certificate string `xorm:"mediumtext" json:"certificate"`
 	PrivateKey  string `xorm:"mediumtext" json:"privateKey"`
+
+	KeyId               string `xorm:"varchar(100)" json:"keyId"`
+	RotationInterval    int    `json:"rotationInterval"`
+	GracePeriod         int    `json:"gracePeriod"`
+	RotatedTime         string `xorm:"varchar(100)" json:"rotatedTime"`
+	PreviousKeyId       string `xorm:"varchar(100)" json:"previousKeyId"`
+	PreviousCertificate string `xorm:"mediumtext" json:"previousCertificate"`
+	PreviousExpireTime  string `xorm:"varchar(100)" json:"previousExpireTime"`
+
+	// KeyBackend is where the private key is held: "Local" (the PrivateKey field), "AWS KMS", "GCP KMS",
+	// "Azure Key Vault" or "PKCS#11", the certs of the external keys only keep the certificate
+	KeyBackend      string `xorm:"varchar(100)" json:"keyBackend"`
+	KeyUri          string `xorm:"varchar(500)" json:"keyUri"`
+	KeyRegion       string `xorm:"varchar(100)" json:"keyRegion"`
+	KeyClientId     string `xorm:"varchar(100)" json:"keyClientId"`
+	KeyClientSecret string `xorm:"mediumtext" json:"keyClientSecret"`
 }
 
 func GetMaskedCert(cert *Cert) *Cert {
@@ -42,6 +58,9 @@ func GetMaskedCert(cert *Cert) *Cert {
 		return nil
 	}
 
+	if cert.KeyClientSecret != "" {
+		cert.KeyClientSecret = "***"
+	}
 	return cert
 }
 
@@ -164,21 +183,39 @@ func UpdateCert(id string, cert *Cert) (bool, error) {
 		}
 	}
 
-	err := cert.populateContent()
+	err := checkCertKeyBackend(cert)
 	if err != nil {
 		return false, err
 	}
 
-	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(cert)
+	err = cert.populateContent()
 	if err != nil {
 		return false, err
 	}
 
+	session := ormer.Engine.ID(core.PK{owner, name}).AllCols()
+	if cert.KeyClientSecret == "***" {
+		session.Omit("key_client_secret")
+	}
+	affected, err := session.Update(cert)
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 0 {
+		publishCacheInvalidation(CacheTypeCert, id)
+	}
+
 	return affected != 0, nil
 }
 
 func AddCert(cert *Cert) (bool, error) {
-	err := cert.populateContent()
+	err := checkCertKeyBackend(cert)
+	if err != nil {
+		return false, err
+	}
+
+	err = cert.populateContent()
 	if err != nil {
 		return false, err
 	}
@@ -197,6 +234,10 @@ func DeleteCert(cert *Cert) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		publishCacheInvalidation(CacheTypeCert, cert.GetId())
+	}
+
 	return affected != 0, nil
 }
 
@@ -205,6 +246,11 @@ func (p *Cert) GetId() string {
 }
 
 func (p *Cert) populateContent() error {
+	if p.isExternalKey() {
+		p.PrivateKey = ""
+		return nil
+	}
+
 	if p.Certificate == "" || p.PrivateKey == "" {
 		certificate, privateKey, err := generateRsaKeys(p.BitSize, p.ExpireInYears, p.Name, p.Owner)
 		if err != nil {
@@ -0,0 +1,227 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/xorm-io/core"
+)
+
+const defaultCertGracePeriod = 24 // in hours
+
+// GetKeyId returns the "kid" of the current signing key, the certs that have never
+// been rotated use the cert name, which is what the issued tokens already carry.
+func (p *Cert) GetKeyId() string {
+	if p.KeyId == "" {
+		return p.Name
+	}
+	return p.KeyId
+}
+
+func (p *Cert) isPreviousKeyValid() bool {
+	if p.PreviousCertificate == "" || p.PreviousExpireTime == "" {
+		return false
+	}
+
+	expireTime, err := time.Parse(time.RFC3339, p.PreviousExpireTime)
+	if err != nil {
+		return false
+	}
+	return time.Now().Before(expireTime)
+}
+
+func (p *Cert) getCertificateByKeyId(keyId string) string {
+	if keyId != "" && keyId == p.PreviousKeyId && p.isPreviousKeyValid() {
+		return p.PreviousCertificate
+	}
+	return p.Certificate
+}
+
+func (p *Cert) isRotationDue() bool {
+	if p.RotationInterval <= 0 || p.Type != "x509" || p.isExternalKey() {
+		return false
+	}
+
+	lastTime := p.RotatedTime
+	if lastTime == "" {
+		lastTime = p.CreatedTime
+	}
+
+	t, err := time.Parse(time.RFC3339, lastTime)
+	if err != nil {
+		return true
+	}
+	return time.Now().After(t.Add(time.Duration(p.RotationInterval) * 24 * time.Hour))
+}
+
+func getApplicationsByCert(certName string) ([]*Application, error) {
+	applications := []*Application{}
+	err := ormer.Engine.Where("cert = ?", certName).Find(&applications)
+	if err != nil {
+		return nil, err
+	}
+
+	return applications, nil
+}
+
+// notifyCertRotated sends the "cert-rotated" webhooks of the organizations whose applications use the cert
+func notifyCertRotated(cert *Cert) error {
+	applications, err := getApplicationsByCert(cert.Name)
+	if err != nil {
+		return err
+	}
+
+	if cert.Owner == "admin" && cert.Name == "cert-built-in" {
+		defaultApplications, err := getApplicationsByCert("")
+		if err != nil {
+			return err
+		}
+		applications = append(applications, defaultApplications...)
+	}
+
+	data := util.StructToJson(map[string]string{
+		"cert":               cert.GetId(),
+		"keyId":              cert.GetKeyId(),
+		"previousKeyId":      cert.PreviousKeyId,
+		"previousExpireTime": cert.PreviousExpireTime,
+	})
+
+	organizations := map[string]bool{}
+	for _, application := range applications {
+		if organizations[application.Organization] {
+			continue
+		}
+		organizations[application.Organization] = true
+
+		record := &casvisorsdk.Record{
+			Name:         util.GenerateId(),
+			CreatedTime:  util.GetCurrentTime(),
+			Organization: application.Organization,
+			Method:       "POST",
+			Action:       "cert-rotated",
+			Object:       data,
+		}
+		util.SafeGoroutine(func() { AddRecord(record) })
+	}
+
+	return nil
+}
+
+// RotateCert generates a new key pair for the cert and keeps the old certificate published
+// in the JWKS for the grace period. The cert name doesn't change, so the applications are not affected.
+func RotateCert(id string) (bool, error) {
+	cert, err := GetCert(id)
+	if err != nil {
+		return false, err
+	} else if cert == nil {
+		return false, fmt.Errorf("the cert: %s does not exist", id)
+	}
+
+	if cert.Type != "x509" {
+		return false, fmt.Errorf("the cert: %s with type: %s can't be rotated", id, cert.Type)
+	}
+	if cert.isExternalKey() {
+		return false, fmt.Errorf("the cert: %s with the key backend: %s should be rotated in the key backend", id, cert.KeyBackend)
+	}
+
+	certificate, privateKey, err := generateRsaKeys(cert.BitSize, cert.ExpireInYears, cert.Name, cert.Owner)
+	if err != nil {
+		return false, err
+	}
+
+	gracePeriod := cert.GracePeriod
+	if gracePeriod <= 0 {
+		gracePeriod = defaultCertGracePeriod
+	}
+
+	now := time.Now()
+	cert.PreviousKeyId = cert.GetKeyId()
+	cert.PreviousCertificate = cert.Certificate
+	cert.PreviousExpireTime = util.Time2String(now.Add(time.Duration(gracePeriod) * time.Hour))
+	cert.KeyId = fmt.Sprintf("%s-%d", cert.Name, now.Unix())
+	cert.Certificate = certificate
+	cert.PrivateKey = privateKey
+	cert.RotatedTime = util.Time2String(now)
+
+	affected, err := ormer.Engine.ID(core.PK{cert.Owner, cert.Name}).
+		Cols("certificate", "private_key", "key_id", "rotated_time", "previous_key_id", "previous_certificate", "previous_expire_time").
+		Update(cert)
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 0 {
+		err = notifyCertRotated(cert)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return affected != 0, nil
+}
+
+func retirePreviousKey(cert *Cert) error {
+	cert.PreviousKeyId = ""
+	cert.PreviousCertificate = ""
+	cert.PreviousExpireTime = ""
+
+	_, err := ormer.Engine.ID(core.PK{cert.Owner, cert.Name}).
+		Cols("previous_key_id", "previous_certificate", "previous_expire_time").
+		Update(cert)
+	return err
+}
+
+func rotateCerts() error {
+	certs, err := GetGlobalCerts()
+	if err != nil {
+		return err
+	}
+
+	for _, cert := range certs {
+		if cert.PreviousCertificate != "" && !cert.isPreviousKeyValid() {
+			err = retirePreviousKey(cert)
+			if err != nil {
+				return err
+			}
+		}
+
+		if cert.isRotationDue() {
+			_, err = RotateCert(cert.GetId())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunCertRotationJob rotates the certs whose rotation interval (in days) has passed,
+// and retires the previous keys whose grace period (in hours) has ended.
+func RunCertRotationJob() {
+	for {
+		err := rotateCerts()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("cert rotation failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Hour)
+	}
+}
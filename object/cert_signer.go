@@ -0,0 +1,252 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"sync"
+
+	"github.com/beevik/etree"
+	"github.com/golang-jwt/jwt/v4"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/russellhaering/goxmldsig/etreeutils"
+)
+
+const (
+	KeyBackendLocal         = "Local"
+	KeyBackendAwsKms        = "AWS KMS"
+	KeyBackendGcpKms        = "GCP KMS"
+	KeyBackendAzureKeyVault = "Azure Key Vault"
+	KeyBackendPkcs11        = "PKCS#11"
+)
+
+// SigningBackend creates the signer of the cert whose private key is held by a KMS or HSM, the signer signs
+// the digests with the RSA PKCS #1 v1.5 scheme and never exposes the private key
+type SigningBackend interface {
+	GetSigner(cert *Cert, publicKey *rsa.PublicKey) (crypto.Signer, error)
+}
+
+var (
+	signingBackends      = map[string]SigningBackend{}
+	signingBackendsMutex sync.RWMutex
+)
+
+// RegisterSigningBackend registers the signing backend for the certs with the key backend of the name, the
+// builds linking an HSM library register their PKCS#11 backend by it
+func RegisterSigningBackend(name string, backend SigningBackend) {
+	signingBackendsMutex.Lock()
+	defer signingBackendsMutex.Unlock()
+
+	signingBackends[name] = backend
+}
+
+func getSigningBackend(name string) SigningBackend {
+	signingBackendsMutex.RLock()
+	defer signingBackendsMutex.RUnlock()
+
+	return signingBackends[name]
+}
+
+func init() {
+	RegisterSigningBackend(KeyBackendAwsKms, &awsKmsSigningBackend{})
+	RegisterSigningBackend(KeyBackendGcpKms, &gcpKmsSigningBackend{})
+	RegisterSigningBackend(KeyBackendAzureKeyVault, &azureKeyVaultSigningBackend{})
+}
+
+// isExternalKey checks whether the private key of the cert is held outside Casdoor, such a cert only has the
+// certificate of the key
+func (p *Cert) isExternalKey() bool {
+	return p.KeyBackend != "" && p.KeyBackend != KeyBackendLocal
+}
+
+func checkCertKeyBackend(cert *Cert) error {
+	if !cert.isExternalKey() {
+		return nil
+	}
+
+	if cert.KeyBackend != KeyBackendPkcs11 && getSigningBackend(cert.KeyBackend) == nil {
+		return fmt.Errorf("unknown key backend: %s", cert.KeyBackend)
+	}
+	if cert.KeyUri == "" {
+		return fmt.Errorf("the key URI of the cert: %s should not be empty for the key backend: %s", cert.Name, cert.KeyBackend)
+	}
+	if cert.Certificate == "" {
+		return fmt.Errorf("the certificate of the cert: %s should be provided for the key backend: %s", cert.Name, cert.KeyBackend)
+	}
+
+	_, err := getCertPublicKey(cert.Certificate)
+	return err
+}
+
+func getCertPublicKey(certificatePem string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(certificatePem))
+	if block == nil {
+		return nil, fmt.Errorf("the certificate is not in PEM format")
+	}
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, ok := certificate.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("the public key of the certificate should be an RSA key")
+	}
+	return publicKey, nil
+}
+
+// getCertSigner returns the signer of the cert, which is the RSA private key for the local keys
+func getCertSigner(cert *Cert) (crypto.Signer, error) {
+	if !cert.isExternalKey() {
+		return jwt.ParseRSAPrivateKeyFromPEM([]byte(cert.PrivateKey))
+	}
+
+	backend := getSigningBackend(cert.KeyBackend)
+	if backend == nil {
+		return nil, fmt.Errorf("the key backend: %s of the cert: %s is not available in this build", cert.KeyBackend, cert.GetId())
+	}
+
+	publicKey, err := getCertPublicKey(cert.Certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	return backend.GetSigner(cert, publicKey)
+}
+
+// signerSigningMethod is the RS256 method of the JWTs signed by the crypto.Signer of the external keys
+type signerSigningMethod struct{}
+
+var signingMethodSignerRS256 = &signerSigningMethod{}
+
+func (m *signerSigningMethod) Alg() string {
+	return jwt.SigningMethodRS256.Alg()
+}
+
+func (m *signerSigningMethod) Verify(signingString string, signature string, key interface{}) error {
+	return jwt.SigningMethodRS256.Verify(signingString, signature, key)
+}
+
+func (m *signerSigningMethod) Sign(signingString string, key interface{}) (string, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	hasher := crypto.SHA256.New()
+	hasher.Write([]byte(signingString))
+	signature, err := signer.Sign(rand.Reader, hasher.Sum(nil), crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+
+	return jwt.EncodeSegment(signature), nil
+}
+
+func getJwtSigningMethod(signer crypto.Signer) jwt.SigningMethod {
+	if _, ok := signer.(*rsa.PrivateKey); ok {
+		return jwt.SigningMethodRS256
+	}
+	return signingMethodSignerRS256
+}
+
+var (
+	placeholderXmlKey     *rsa.PrivateKey
+	placeholderXmlKeyOnce sync.Once
+)
+
+// signerKeyStore lets goxmldsig build the signatures of the external keys, goxmldsig only signs with an RSA private
+// key, so a placeholder key is given to it and the signature value is replaced by the one of the signer
+type signerKeyStore struct {
+	signer      crypto.Signer
+	certificate []byte
+}
+
+func (ks *signerKeyStore) GetKeyPair() (*rsa.PrivateKey, []byte, error) {
+	if key, ok := ks.signer.(*rsa.PrivateKey); ok {
+		return key, ks.certificate, nil
+	}
+
+	var err error
+	placeholderXmlKeyOnce.Do(func() {
+		placeholderXmlKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return placeholderXmlKey, ks.certificate, nil
+}
+
+// constructXmlSignature builds the enveloped signature of the element with the key store
+func constructXmlSignature(ctx *dsig.SigningContext, ks *signerKeyStore, el *etree.Element) (*etree.Element, error) {
+	sig, err := ctx.ConstructSignature(el, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := ks.signer.(*rsa.PrivateKey); ok {
+		return sig, nil
+	}
+
+	// canonicalize the SignedInfo in the same namespace scope as goxmldsig does
+	prefix := ""
+	if ctx.Prefix != "" {
+		prefix = ctx.Prefix + ":"
+	}
+	signedInfo := sig.FindElement("./" + prefix + dsig.SignedInfoTag)
+	signatureValue := sig.FindElement("./" + prefix + dsig.SignatureValueTag)
+	if signedInfo == nil || signatureValue == nil {
+		return nil, fmt.Errorf("the XML signature is incomplete")
+	}
+
+	rootNSCtx, err := etreeutils.NSBuildParentContext(el)
+	if err != nil {
+		return nil, err
+	}
+	elNSCtx, err := rootNSCtx.SubContext(el)
+	if err != nil {
+		return nil, err
+	}
+	sigNSCtx, err := elNSCtx.SubContext(sig)
+	if err != nil {
+		return nil, err
+	}
+	detachedSignedInfo, err := etreeutils.NSDetatch(sigNSCtx, signedInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	canonical, err := ctx.Canonicalizer.Canonicalize(detachedSignedInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := ctx.Hash.New()
+	hasher.Write(canonical)
+	signature, err := ks.signer.Sign(rand.Reader, hasher.Sum(nil), ctx.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	signatureValue.SetText(base64.StdEncoding.EncodeToString(signature))
+	return sig, nil
+}
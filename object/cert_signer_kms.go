@@ -0,0 +1,284 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"google.golang.org/api/cloudkms/v1"
+	"google.golang.org/api/option"
+)
+
+const kmsTimeout = 10 * time.Second
+
+// AWS KMS: KeyUri is the key ID or ARN, KeyRegion is the region, KeyClientId and KeyClientSecret are the access key,
+// the default credential chain like the instance role is used if they are empty
+
+var awsKmsSigningAlgorithms = map[crypto.Hash]string{
+	crypto.SHA256: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha256,
+	crypto.SHA384: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha384,
+	crypto.SHA512: kms.SigningAlgorithmSpecRsassaPkcs1V15Sha512,
+}
+
+type awsKmsSigningBackend struct{}
+
+type awsKmsSigner struct {
+	client    *kms.KMS
+	keyId     string
+	publicKey *rsa.PublicKey
+}
+
+func (b *awsKmsSigningBackend) GetSigner(cert *Cert, publicKey *rsa.PublicKey) (crypto.Signer, error) {
+	config := &aws.Config{
+		Region:     aws.String(cert.KeyRegion),
+		HTTPClient: &http.Client{Timeout: kmsTimeout},
+	}
+	if cert.KeyClientId != "" {
+		config.Credentials = credentials.NewStaticCredentials(cert.KeyClientId, cert.KeyClientSecret, "")
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &awsKmsSigner{client: kms.New(sess), keyId: cert.KeyUri, publicKey: publicKey}, nil
+}
+
+func (s *awsKmsSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *awsKmsSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	algorithm, ok := awsKmsSigningAlgorithms[opts.HashFunc()]
+	if !ok {
+		return nil, fmt.Errorf("the hash: %s is not supported by AWS KMS", opts.HashFunc().String())
+	}
+
+	output, err := s.client.Sign(&kms.SignInput{
+		KeyId:            aws.String(s.keyId),
+		Message:          digest,
+		MessageType:      aws.String(kms.MessageTypeDigest),
+		SigningAlgorithm: aws.String(algorithm),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Signature, nil
+}
+
+// GCP KMS: KeyUri is the resource name of the key version like
+// "projects/<project>/locations/<location>/keyRings/<ring>/cryptoKeys/<key>/cryptoKeyVersions/1",
+// KeyClientSecret is the JSON of the service account, the application default credentials are used if it is empty
+
+type gcpKmsSigningBackend struct{}
+
+type gcpKmsSigner struct {
+	service   *cloudkms.Service
+	name      string
+	publicKey *rsa.PublicKey
+}
+
+func (b *gcpKmsSigningBackend) GetSigner(cert *Cert, publicKey *rsa.PublicKey) (crypto.Signer, error) {
+	options := []option.ClientOption{}
+	if cert.KeyClientSecret != "" {
+		options = append(options, option.WithCredentialsJSON([]byte(cert.KeyClientSecret)))
+	}
+
+	service, err := cloudkms.NewService(context.Background(), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcpKmsSigner{service: service, name: cert.KeyUri, publicKey: publicKey}, nil
+}
+
+func (s *gcpKmsSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *gcpKmsSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	value := base64.StdEncoding.EncodeToString(digest)
+	d := &cloudkms.Digest{}
+	switch opts.HashFunc() {
+	case crypto.SHA256:
+		d.Sha256 = value
+	case crypto.SHA384:
+		d.Sha384 = value
+	case crypto.SHA512:
+		d.Sha512 = value
+	default:
+		return nil, fmt.Errorf("the hash: %s is not supported by GCP KMS", opts.HashFunc().String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kmsTimeout)
+	defer cancel()
+
+	resp, err := s.service.Projects.Locations.KeyRings.CryptoKeys.CryptoKeyVersions.AsymmetricSign(s.name, &cloudkms.AsymmetricSignRequest{Digest: d}).Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	return base64.StdEncoding.DecodeString(resp.Signature)
+}
+
+// Azure Key Vault: KeyUri is the key identifier like "https://<vault>.vault.azure.net/keys/<key>/<version>",
+// KeyRegion is the tenant ID, KeyClientId and KeyClientSecret are the client credentials of the app registration
+
+var azureKeyVaultAlgorithms = map[crypto.Hash]string{
+	crypto.SHA256: "RS256",
+	crypto.SHA384: "RS384",
+	crypto.SHA512: "RS512",
+}
+
+type azureAccessToken struct {
+	token      string
+	expireTime time.Time
+}
+
+var (
+	azureAccessTokens      = map[string]*azureAccessToken{}
+	azureAccessTokensMutex sync.Mutex
+)
+
+type azureKeyVaultSigningBackend struct{}
+
+type azureKeyVaultSigner struct {
+	cert      *Cert
+	publicKey *rsa.PublicKey
+}
+
+func (b *azureKeyVaultSigningBackend) GetSigner(cert *Cert, publicKey *rsa.PublicKey) (crypto.Signer, error) {
+	if cert.KeyRegion == "" || cert.KeyClientId == "" || cert.KeyClientSecret == "" {
+		return nil, fmt.Errorf("the tenant ID, client ID and client secret of Azure Key Vault should not be empty")
+	}
+
+	return &azureKeyVaultSigner{cert: cert, publicKey: publicKey}, nil
+}
+
+func (s *azureKeyVaultSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+func (s *azureKeyVaultSigner) getAccessToken(client *http.Client) (string, error) {
+	key := fmt.Sprintf("%s/%s", s.cert.KeyRegion, s.cert.KeyClientId)
+
+	azureAccessTokensMutex.Lock()
+	defer azureAccessTokensMutex.Unlock()
+
+	if token, ok := azureAccessTokens[key]; ok && time.Now().Before(token.expireTime) {
+		return token.token, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", s.cert.KeyClientId)
+	form.Set("client_secret", s.cert.KeyClientSecret)
+	form.Set("scope", "https://vault.azure.net/.default")
+
+	tokenUrl := fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", url.PathEscape(s.cert.KeyRegion))
+	resp, err := client.PostForm(tokenUrl, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		ErrorDescription string `json:"error_description"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return "", err
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("failed to get the access token of Azure Key Vault: %s", result.ErrorDescription)
+	}
+
+	// renew the token a minute before it expires
+	azureAccessTokens[key] = &azureAccessToken{
+		token:      result.AccessToken,
+		expireTime: time.Now().Add(time.Duration(result.ExpiresIn-60) * time.Second),
+	}
+	return result.AccessToken, nil
+}
+
+func (s *azureKeyVaultSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	algorithm, ok := azureKeyVaultAlgorithms[opts.HashFunc()]
+	if !ok {
+		return nil, fmt.Errorf("the hash: %s is not supported by Azure Key Vault", opts.HashFunc().String())
+	}
+
+	client := &http.Client{Timeout: kmsTimeout}
+	token, err := s.getAccessToken(client)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"alg":   algorithm,
+		"value": base64.RawURLEncoding.EncodeToString(digest),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(s.cert.KeyUri, "/")+"/sign?api-version=7.4", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Value string `json:"value"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		return nil, err
+	}
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to sign with Azure Key Vault: %s", result.Error.Message)
+	}
+
+	return base64.RawURLEncoding.DecodeString(result.Value)
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"testing"
+
+	"github.com/beevik/etree"
+	"github.com/golang-jwt/jwt/v4"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+)
+
+// testExternalSigner hides the RSA private key behind crypto.Signer like the KMS signers do
+type testExternalSigner struct {
+	key *rsa.PrivateKey
+}
+
+func (s *testExternalSigner) Public() crypto.PublicKey {
+	return &s.key.PublicKey
+}
+
+func (s *testExternalSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.key.Sign(rand, digest, opts)
+}
+
+type testSigningBackend struct {
+	key *rsa.PrivateKey
+}
+
+func (b *testSigningBackend) GetSigner(cert *Cert, publicKey *rsa.PublicKey) (crypto.Signer, error) {
+	return &testExternalSigner{key: b.key}, nil
+}
+
+func newTestExternalCert(t *testing.T) (*Cert, *rsa.PrivateKey) {
+	certificate, privateKey, err := generateRsaKeys(2048, 1, "test", "test")
+	assert.Nil(t, err)
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKey))
+	assert.Nil(t, err)
+
+	RegisterSigningBackend("Test", &testSigningBackend{key: key})
+	cert := &Cert{Owner: "admin", Name: "cert-kms", Type: "x509", Certificate: certificate, KeyBackend: "Test", KeyUri: "test-key"}
+	return cert, key
+}
+
+func TestSignJwtWithExternalKey(t *testing.T) {
+	cert, key := newTestExternalCert(t)
+
+	signer, err := getCertSigner(cert)
+	assert.Nil(t, err)
+	assert.Equal(t, signingMethodSignerRS256, getJwtSigningMethod(signer))
+	assert.Equal(t, jwt.SigningMethodRS256, getJwtSigningMethod(key))
+
+	tokenString, err := jwt.NewWithClaims(getJwtSigningMethod(signer), jwt.MapClaims{"sub": "alice"}).SignedString(signer)
+	assert.Nil(t, err)
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return &key.PublicKey, nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, token.Valid)
+	assert.Equal(t, "RS256", token.Header["alg"])
+}
+
+func TestConstructXmlSignatureWithExternalKey(t *testing.T) {
+	cert, _ := newTestExternalCert(t)
+
+	signer, err := getCertSigner(cert)
+	assert.Nil(t, err)
+
+	block, _ := pem.Decode([]byte(cert.Certificate))
+	keyStore := &signerKeyStore{signer: signer, certificate: block.Bytes}
+	ctx := dsig.NewDefaultSigningContext(keyStore)
+	ctx.Hash = crypto.SHA256
+
+	el := etree.NewElement("samlp:Response")
+	el.CreateAttr("xmlns:samlp", "urn:oasis:names:tc:SAML:2.0:protocol")
+	el.CreateAttr("xmlns:saml", "urn:oasis:names:tc:SAML:2.0:assertion")
+	el.CreateAttr("ID", "_response")
+	el.CreateElement("saml:Issuer").SetText("casdoor")
+
+	sig, err := constructXmlSignature(ctx, keyStore, el)
+	assert.Nil(t, err)
+	signed := el.Copy()
+	signed.Child = append(signed.Child, sig)
+
+	// validate the response as received by the service provider
+	doc := etree.NewDocument()
+	doc.SetRoot(signed)
+	xmlBytes, err := doc.WriteToBytes()
+	assert.Nil(t, err)
+	doc = etree.NewDocument()
+	assert.Nil(t, doc.ReadFromBytes(xmlBytes))
+
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	assert.Nil(t, err)
+	validationContext := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{certificate}})
+	_, err = validationContext.Validate(doc.Root())
+	assert.Nil(t, err)
+}
+
+func TestCheckCertKeyBackend(t *testing.T) {
+	cert, _ := newTestExternalCert(t)
+	assert.Nil(t, checkCertKeyBackend(cert))
+	assert.Nil(t, checkCertKeyBackend(&Cert{KeyBackend: KeyBackendLocal}))
+
+	assert.NotNil(t, checkCertKeyBackend(&Cert{KeyBackend: "Unknown", KeyUri: "key", Certificate: cert.Certificate}))
+	assert.NotNil(t, checkCertKeyBackend(&Cert{KeyBackend: KeyBackendAwsKms, Certificate: cert.Certificate}))
+	assert.NotNil(t, checkCertKeyBackend(&Cert{KeyBackend: KeyBackendAwsKms, KeyUri: "key"}))
+
+	_, err := getCertSigner(&Cert{KeyBackend: KeyBackendPkcs11, KeyUri: "pkcs11:object=key", Certificate: cert.Certificate})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,541 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
+)
+
+const (
+	ChangeRequestTypeOrganization = "Organization"
+	ChangeRequestTypeApplication  = "Application"
+	ChangeRequestTypeCert         = "Cert"
+	ChangeRequestTypeProvider     = "Provider"
+	// the MFA reset and bypass of a user always need the approval, whatever the setting of the organization
+	ChangeRequestTypeMfaReset  = "MFA reset"
+	ChangeRequestTypeMfaBypass = "MFA bypass"
+
+	// the changes are updates of the object unless the action is set
+	ChangeRequestActionAdd    = "Add"
+	ChangeRequestActionDelete = "Delete"
+
+	ChangeRequestStatePending   = "Pending"
+	ChangeRequestStateApproved  = "Approved"
+	ChangeRequestStateRejected  = "Rejected"
+	ChangeRequestStateCancelled = "Cancelled"
+)
+
+// the fields whose changes need the approval, all the fields of the certs and providers are sensitive
+var sensitiveChangeFields = map[string][]string{
+	ChangeRequestTypeOrganization: {
+		"enableChangeApproval", "passwordType", "passwordSalt", "masterPassword", "defaultPassword", "masterVerificationCode",
+		"mfaItems", "mfaPolicy", "customDomains", "claimedDomains",
+	},
+	ChangeRequestTypeApplication: {
+		"cert", "clientSecret", "redirectUris", "redirectUriItems", "grantTypes", "requirePkce", "tokenFormat", "audienceRules",
+		"enableCertificateBoundTokens", "externalPdpUrl", "samlReplyUrl", "enableSamlCompress", "enableSamlC14n10",
+	},
+}
+
+// ChangeRequest is a change of a sensitive object held for the approval of a second admin when the
+// organization enables the change approval (four-eyes principle), the changed fields are applied to
+// the object once it is approved.
+type ChangeRequest struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User    string   `xorm:"varchar(100) index" json:"user"`
+	Type    string   `xorm:"varchar(100)" json:"type"`
+	Action  string   `xorm:"varchar(100)" json:"action"`
+	Target  string   `xorm:"varchar(200)" json:"target"`
+	Fields  []string `xorm:"mediumtext" json:"fields"`
+	Content string   `xorm:"mediumtext" json:"content"`
+
+	State       string `xorm:"varchar(100) index" json:"state"`
+	Approver    string `xorm:"varchar(100)" json:"approver"`
+	ApproveTime string `xorm:"varchar(100)" json:"approveTime"`
+	Comment     string `xorm:"varchar(1000)" json:"comment"`
+}
+
+func GetChangeRequestCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&ChangeRequest{})
+}
+
+func GetChangeRequests(owner string) ([]*ChangeRequest, error) {
+	changeRequests := []*ChangeRequest{}
+	err := ormer.Engine.Desc("created_time").Find(&changeRequests, &ChangeRequest{Owner: owner})
+	if err != nil {
+		return changeRequests, err
+	}
+
+	return changeRequests, nil
+}
+
+func GetPaginationChangeRequests(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*ChangeRequest, error) {
+	changeRequests := []*ChangeRequest{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&changeRequests)
+	if err != nil {
+		return changeRequests, err
+	}
+
+	return changeRequests, nil
+}
+
+// GetPendingChangeRequestsForApprover returns the pending changes that the user is able to approve
+func GetPendingChangeRequestsForApprover(approver *User) ([]*ChangeRequest, error) {
+	changeRequests := []*ChangeRequest{}
+	session := ormer.Engine.Where("state = ?", ChangeRequestStatePending)
+	if !approver.IsGlobalAdmin() {
+		session = session.And("owner = ?", approver.Owner)
+	}
+
+	err := session.Desc("created_time").Find(&changeRequests)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*ChangeRequest{}
+	for _, changeRequest := range changeRequests {
+		if changeRequest.CanBeApprovedBy(approver) {
+			res = append(res, changeRequest)
+		}
+	}
+	return res, nil
+}
+
+func getChangeRequest(owner string, name string) (*ChangeRequest, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	changeRequest := ChangeRequest{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&changeRequest)
+	if err != nil {
+		return &changeRequest, err
+	}
+
+	if existed {
+		return &changeRequest, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetChangeRequest(id string) (*ChangeRequest, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getChangeRequest(owner, name)
+}
+
+func (changeRequest *ChangeRequest) GetId() string {
+	return fmt.Sprintf("%s/%s", changeRequest.Owner, changeRequest.Name)
+}
+
+// CanBeApprovedBy reports whether the user is an admin of the organization, the requester is never
+// allowed to approve the own change, nor the target user the reset or bypass of the own MFA.
+func (changeRequest *ChangeRequest) CanBeApprovedBy(user *User) bool {
+	if user == nil || user.GetId() == changeRequest.User {
+		return false
+	}
+	if isMfaChangeRequestType(changeRequest.Type) && user.GetId() == changeRequest.Target {
+		return false
+	}
+
+	return user.IsGlobalAdmin() || (user.IsAdmin && user.Owner == changeRequest.Owner)
+}
+
+// getChangedFields returns the JSON fields of the object changed by the new object, the masked values are not changes
+func getChangedFields(oldObj interface{}, newObj interface{}) ([]string, error) {
+	oldFields := map[string]interface{}{}
+	err := json.Unmarshal([]byte(util.StructToJson(oldObj)), &oldFields)
+	if err != nil {
+		return nil, err
+	}
+
+	newFields := map[string]interface{}{}
+	err = json.Unmarshal([]byte(util.StructToJson(newObj)), &newFields)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	for field, value := range newFields {
+		if value == "***" || reflect.DeepEqual(oldFields[field], value) {
+			continue
+		}
+		res = append(res, field)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+func isSensitiveChange(changeType string, fields []string) bool {
+	sensitiveFields, ok := sensitiveChangeFields[changeType]
+	if !ok {
+		return len(fields) != 0
+	}
+
+	for _, field := range fields {
+		if util.InSlice(sensitiveFields, field) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyChangedFields sets the changed fields of the content to the current object
+func applyChangedFields(obj interface{}, content string, fields []string) error {
+	objFields := map[string]interface{}{}
+	err := json.Unmarshal([]byte(util.StructToJson(obj)), &objFields)
+	if err != nil {
+		return err
+	}
+
+	contentFields := map[string]interface{}{}
+	err = json.Unmarshal([]byte(content), &contentFields)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		objFields[field] = contentFields[field]
+	}
+
+	return json.Unmarshal([]byte(util.StructToJson(objFields)), obj)
+}
+
+// getChangeOrganization returns the organization owning the object, whose change approval setting applies
+func getChangeOrganization(owner string) string {
+	if owner == "admin" {
+		return "built-in"
+	}
+	return owner
+}
+
+// getChangeTarget returns the current object of the change and the organization owning it
+func getChangeTarget(changeType string, id string, lang string) (interface{}, string, error) {
+	switch changeType {
+	case ChangeRequestTypeOrganization:
+		organization, err := GetOrganization(id)
+		if err != nil || organization == nil {
+			return nil, "", err
+		}
+		return organization, organization.Name, nil
+	case ChangeRequestTypeApplication:
+		application, err := GetApplication(id)
+		if err != nil || application == nil {
+			return nil, "", err
+		}
+		return application, application.Organization, nil
+	case ChangeRequestTypeCert:
+		cert, err := GetCert(id)
+		if err != nil || cert == nil {
+			return nil, "", err
+		}
+		return cert, getChangeOrganization(cert.Owner), nil
+	case ChangeRequestTypeProvider:
+		provider, err := GetRawProvider(id)
+		if err != nil || provider == nil {
+			return nil, "", err
+		}
+		return provider, getChangeOrganization(provider.Owner), nil
+	}
+
+	return nil, "", fmt.Errorf(i18n.Translate(lang, "general:Unknown type: %s"), changeType)
+}
+
+func updateChangeTarget(changeType string, id string, obj interface{}) (bool, error) {
+	switch changeType {
+	case ChangeRequestTypeOrganization:
+		return UpdateOrganization(id, obj.(*Organization))
+	case ChangeRequestTypeApplication:
+		return UpdateApplication(id, obj.(*Application))
+	case ChangeRequestTypeCert:
+		return UpdateCert(id, obj.(*Cert))
+	case ChangeRequestTypeProvider:
+		return UpdateProvider(id, obj.(*Provider))
+	}
+	return false, nil
+}
+
+func newChangeObject(changeType string) interface{} {
+	switch changeType {
+	case ChangeRequestTypeApplication:
+		return &Application{}
+	case ChangeRequestTypeCert:
+		return &Cert{}
+	case ChangeRequestTypeProvider:
+		return &Provider{}
+	}
+	return nil
+}
+
+// applyObjectChangeRequest adds the object of the approved addition or deletes the target of the approved deletion
+func applyObjectChangeRequest(changeRequest *ChangeRequest, lang string) error {
+	if changeRequest.Action == ChangeRequestActionAdd {
+		obj := newChangeObject(changeRequest.Type)
+		if obj == nil {
+			return fmt.Errorf(i18n.Translate(lang, "general:Unknown type: %s"), changeRequest.Type)
+		}
+
+		err := json.Unmarshal([]byte(changeRequest.Content), obj)
+		if err != nil {
+			return err
+		}
+
+		switch o := obj.(type) {
+		case *Application:
+			_, err = AddApplication(o)
+		case *Cert:
+			_, err = AddCert(o)
+		case *Provider:
+			_, err = AddProvider(o)
+		}
+		return err
+	}
+
+	target, _, err := getChangeTarget(changeRequest.Type, changeRequest.Target, lang)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return fmt.Errorf(i18n.Translate(lang, "general:The %s: %s does not exist"), changeRequest.Type, changeRequest.Target)
+	}
+
+	switch o := target.(type) {
+	case *Application:
+		_, err = DeleteApplication(o)
+	case *Cert:
+		_, err = DeleteCert(o)
+	case *Provider:
+		_, err = DeleteProvider(o)
+	default:
+		err = fmt.Errorf(i18n.Translate(lang, "general:Unknown type: %s"), changeRequest.Type)
+	}
+	return err
+}
+
+func isChangeApprovalEnabled(organizationName string) (bool, error) {
+	organization, err := getOrganization("admin", organizationName)
+	if err != nil || organization == nil {
+		return false, err
+	}
+	return organization.EnableChangeApproval, nil
+}
+
+func getChangeRequestRecord(changeRequest *ChangeRequest, action string) *casvisorsdk.Record {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(changeRequest.User)
+	if owner != changeRequest.Owner {
+		name = changeRequest.User
+	}
+
+	return &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: changeRequest.Owner,
+		User:         name,
+		Method:       "POST",
+		Action:       action,
+		Object:       util.StructToJson(changeRequest),
+	}
+}
+
+// SubmitChangeRequest holds the change of the object for approval if its organization enables the change approval
+// and any sensitive field is changed, it returns nil if the change can be applied directly
+func SubmitChangeRequest(changeType string, id string, obj interface{}, userId string, lang string) (*ChangeRequest, error) {
+	target, organization, err := getChangeTarget(changeType, id, lang)
+	if err != nil || target == nil {
+		return nil, err
+	}
+
+	isEnabled, err := isChangeApprovalEnabled(organization)
+	if err != nil || !isEnabled {
+		return nil, err
+	}
+
+	fields, err := getChangedFields(target, obj)
+	if err != nil {
+		return nil, err
+	}
+	if !isSensitiveChange(changeType, fields) {
+		return nil, nil
+	}
+
+	changeRequest := &ChangeRequest{
+		Owner:       organization,
+		Name:        util.GenerateId(),
+		CreatedTime: util.GetCurrentTime(),
+		User:        userId,
+		Type:        changeType,
+		Target:      id,
+		Fields:      fields,
+		Content:     util.StructToJson(obj),
+		State:       ChangeRequestStatePending,
+	}
+	return addChangeRequest(changeRequest, lang)
+}
+
+// getChangeObjectOrganization returns the id of the added object and the organization owning it
+func getChangeObjectOrganization(changeType string, obj interface{}, lang string) (string, string, error) {
+	switch o := obj.(type) {
+	case *Application:
+		return o.GetId(), o.Organization, nil
+	case *Cert:
+		return o.GetId(), getChangeOrganization(o.Owner), nil
+	case *Provider:
+		return o.GetId(), getChangeOrganization(o.Owner), nil
+	}
+
+	return "", "", fmt.Errorf(i18n.Translate(lang, "general:Unknown type: %s"), changeType)
+}
+
+// SubmitObjectChangeRequest holds the addition or deletion of the object for approval if its organization enables
+// the change approval, it returns nil if the change can be applied directly
+func SubmitObjectChangeRequest(changeType string, action string, obj interface{}, userId string, lang string) (*ChangeRequest, error) {
+	id, organization, err := getChangeObjectOrganization(changeType, obj, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	// the deleted object is the stored one, whatever the request body has
+	if action == ChangeRequestActionDelete {
+		obj, organization, err = getChangeTarget(changeType, id, lang)
+		if err != nil || obj == nil {
+			return nil, err
+		}
+	}
+
+	isEnabled, err := isChangeApprovalEnabled(organization)
+	if err != nil || !isEnabled {
+		return nil, err
+	}
+
+	changeRequest := &ChangeRequest{
+		Owner:       organization,
+		Name:        util.GenerateId(),
+		CreatedTime: util.GetCurrentTime(),
+		User:        userId,
+		Type:        changeType,
+		Action:      action,
+		Target:      id,
+		Fields:      []string{},
+		Content:     util.StructToJson(obj),
+		State:       ChangeRequestStatePending,
+	}
+	return addChangeRequest(changeRequest, lang)
+}
+
+func addChangeRequest(changeRequest *ChangeRequest, lang string) (*ChangeRequest, error) {
+	count, err := ormer.Engine.Where("owner = ? and type = ? and target = ? and state = ?",
+		changeRequest.Owner, changeRequest.Type, changeRequest.Target, ChangeRequestStatePending).Count(&ChangeRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf(i18n.Translate(lang, "general:There is already a pending change for the %s: %s"), changeRequest.Type, changeRequest.Target)
+	}
+
+	_, err = runWithRecord(getChangeRequestRecord(changeRequest, "change-requested"), func(session *xorm.Session) (bool, error) {
+		affected, err := session.Insert(changeRequest)
+		return affected != 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changeRequest, nil
+}
+
+func updateChangeRequestState(db xorm.Interface, changeRequest *ChangeRequest) (bool, error) {
+	affected, err := db.ID(core.PK{changeRequest.Owner, changeRequest.Name}).Cols("state", "approver", "approve_time", "comment").Update(changeRequest)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// ReviewChangeRequest approves or rejects the pending change, the changed fields are applied to the current
+// object when it is approved, so the other changes made to the object meanwhile are kept
+func ReviewChangeRequest(changeRequest *ChangeRequest, approver *User, isApproved bool, comment string, lang string) (bool, error) {
+	if changeRequest.State != ChangeRequestStatePending {
+		return false, fmt.Errorf(i18n.Translate(lang, "general:The request: %s is already %s"), changeRequest.GetId(), changeRequest.State)
+	}
+
+	if !changeRequest.CanBeApprovedBy(approver) {
+		return false, fmt.Errorf(i18n.Translate(lang, "auth:Unauthorized operation"))
+	}
+
+	changeRequest.Approver = approver.GetId()
+	changeRequest.ApproveTime = util.GetCurrentTime()
+	changeRequest.Comment = comment
+	changeRequest.State = ChangeRequestStateRejected
+	if isApproved && isMfaChangeRequestType(changeRequest.Type) {
+		err := applyMfaChangeRequest(changeRequest, lang)
+		if err != nil {
+			return false, err
+		}
+		changeRequest.State = ChangeRequestStateApproved
+	} else if isApproved && changeRequest.Action != "" {
+		err := applyObjectChangeRequest(changeRequest, lang)
+		if err != nil {
+			return false, err
+		}
+		changeRequest.State = ChangeRequestStateApproved
+	} else if isApproved {
+		target, _, err := getChangeTarget(changeRequest.Type, changeRequest.Target, lang)
+		if err != nil {
+			return false, err
+		}
+		if target == nil {
+			return false, fmt.Errorf(i18n.Translate(lang, "general:The %s: %s does not exist"), changeRequest.Type, changeRequest.Target)
+		}
+
+		err = applyChangedFields(target, changeRequest.Content, changeRequest.Fields)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = updateChangeTarget(changeRequest.Type, changeRequest.Target, target)
+		if err != nil {
+			return false, err
+		}
+		changeRequest.State = ChangeRequestStateApproved
+	}
+
+	return runWithRecord(getChangeRequestRecord(changeRequest, "change-request-reviewed"), func(session *xorm.Session) (bool, error) {
+		return updateChangeRequestState(session, changeRequest)
+	})
+}
+
+func CancelChangeRequest(changeRequest *ChangeRequest, lang string) (bool, error) {
+	if changeRequest.State != ChangeRequestStatePending {
+		return false, fmt.Errorf(i18n.Translate(lang, "general:The request: %s is already %s"), changeRequest.GetId(), changeRequest.State)
+	}
+
+	changeRequest.State = ChangeRequestStateCancelled
+	return updateChangeRequestState(ormer.Engine, changeRequest)
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetChangedFields(t *testing.T) {
+	oldApplication := &Application{Owner: "admin", Name: "app", DisplayName: "App", ClientSecret: "secret", RedirectUris: []string{"https://a.com/callback"}}
+	newApplication := &Application{Owner: "admin", Name: "app", DisplayName: "New App", ClientSecret: "***", RedirectUris: []string{"https://a.com/callback"}}
+
+	// the masked secret is not a change
+	fields, err := getChangedFields(oldApplication, newApplication)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"displayName"}, fields)
+	assert.False(t, isSensitiveChange(ChangeRequestTypeApplication, fields))
+
+	newApplication.RedirectUris = []string{"https://a.com/callback", "https://evil.com/callback"}
+	fields, err = getChangedFields(oldApplication, newApplication)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"displayName", "redirectUris"}, fields)
+	assert.True(t, isSensitiveChange(ChangeRequestTypeApplication, fields))
+
+	// all the fields of the certs are sensitive
+	assert.True(t, isSensitiveChange(ChangeRequestTypeCert, []string{"displayName"}))
+	assert.False(t, isSensitiveChange(ChangeRequestTypeCert, []string{}))
+}
+
+func TestSensitiveChangeFields(t *testing.T) {
+	assert.True(t, isSensitiveChange(ChangeRequestTypeOrganization, []string{"displayName", "masterPassword"}))
+	assert.True(t, isSensitiveChange(ChangeRequestTypeOrganization, []string{"claimedDomains"}))
+	assert.False(t, isSensitiveChange(ChangeRequestTypeOrganization, []string{"displayName", "websiteUrl"}))
+	assert.True(t, isSensitiveChange(ChangeRequestTypeApplication, []string{"grantTypes"}))
+	assert.True(t, isSensitiveChange(ChangeRequestTypeApplication, []string{"redirectUriItems"}))
+}
+
+func TestGetChangeObjectOrganization(t *testing.T) {
+	id, organization, err := getChangeObjectOrganization(ChangeRequestTypeApplication, &Application{Owner: "admin", Name: "app", Organization: "org"}, "en")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin/app", id)
+	assert.Equal(t, "org", organization)
+
+	// the global objects are owned by the built-in organization
+	id, organization, err = getChangeObjectOrganization(ChangeRequestTypeCert, &Cert{Owner: "admin", Name: "cert"}, "en")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin/cert", id)
+	assert.Equal(t, "built-in", organization)
+
+	_, organization, err = getChangeObjectOrganization(ChangeRequestTypeProvider, &Provider{Owner: "org", Name: "provider"}, "en")
+	assert.Nil(t, err)
+	assert.Equal(t, "org", organization)
+
+	_, _, err = getChangeObjectOrganization(ChangeRequestTypeOrganization, &Organization{Owner: "admin", Name: "org"}, "en")
+	assert.NotNil(t, err)
+}
+
+func TestApplyChangedFields(t *testing.T) {
+	newApplication := &Application{Owner: "admin", Name: "app", DisplayName: "New App", RedirectUris: []string{"https://b.com/callback"}}
+	currentApplication := &Application{Owner: "admin", Name: "app", DisplayName: "App", Description: "changed meanwhile", RedirectUris: []string{"https://a.com/callback"}}
+
+	err := applyChangedFields(currentApplication, util.StructToJson(newApplication), []string{"redirectUris"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"https://b.com/callback"}, currentApplication.RedirectUris)
+	assert.Equal(t, "App", currentApplication.DisplayName)
+	assert.Equal(t, "changed meanwhile", currentApplication.Description)
+}
+
+func TestChangeRequestCanBeApprovedBy(t *testing.T) {
+	changeRequest := &ChangeRequest{Owner: "org", User: "org/alice"}
+
+	assert.False(t, changeRequest.CanBeApprovedBy(&User{Owner: "org", Name: "alice", IsAdmin: true}))
+	assert.True(t, changeRequest.CanBeApprovedBy(&User{Owner: "org", Name: "bob", IsAdmin: true}))
+	assert.False(t, changeRequest.CanBeApprovedBy(&User{Owner: "org", Name: "carol"}))
+	assert.False(t, changeRequest.CanBeApprovedBy(&User{Owner: "other", Name: "dave", IsAdmin: true}))
+	assert.True(t, changeRequest.CanBeApprovedBy(&User{Owner: "built-in", Name: "admin", IsAdmin: true}))
+	assert.False(t, changeRequest.CanBeApprovedBy(nil))
+}
@@ -51,11 +51,11 @@ func CheckUserSignup(application *Application, organization *Organization, form
 			return i18n.Translate(lang, "check:Username cannot contain white spaces")
 		}
 
-		if msg := CheckUsername(form.Username, lang); msg != "" {
+		if msg := CheckUsernameByOrg(organization, form.Username, false, lang); msg != "" {
 			return msg
 		}
 
-		if HasUserByField(organization.Name, "name", form.Username) {
+		if HasUserByField(organization.Name, "name", form.Username) || isUsernameTaken(organization, form.Username, "") {
 			return i18n.Translate(lang, "check:Username already exists")
 		}
 		if HasUserByField(organization.Name, "email", form.Email) {
@@ -150,7 +150,7 @@ func checkSigninErrorTimes(user *User, lang string) error {
 
 		// deny the login if the error times is greater than the limit and the last login time is less than the duration
 		if minutes > 0 {
-			return fmt.Errorf(i18n.Translate(lang, "check:You have entered the wrong password or code too many times, please wait for %d minutes and try again"), minutes)
+			return NewCodedError(ErrorCodeAuthTooManyAttempts, i18n.Translate(lang, "check:You have entered the wrong password or code too many times, please wait for %d minutes and try again"), minutes)
 		}
 
 		// reset the error times
@@ -208,6 +208,10 @@ func CheckPassword(user *User, password string, lang string, options ...bool) er
 }
 
 func CheckPasswordComplexityByOrg(organization *Organization, password string) string {
+	if inheritedOrganization, err := GetInheritedOrganization(organization); err == nil {
+		organization = inheritedOrganization
+	}
+
 	errorMsg := checkPasswordComplexity(password, organization.PasswordOptions)
 	return errorMsg
 }
@@ -285,7 +289,17 @@ func CheckUserPassword(organization string, username string, password string, la
 	}
 
 	if user.IsForbidden {
-		return nil, fmt.Errorf(i18n.Translate(lang, "check:The user is forbidden to sign in, please contact the administrator"))
+		return nil, NewCodedError(ErrorCodeUserForbidden, i18n.Translate(lang, "check:The user is forbidden to sign in, please contact the administrator"))
+	}
+
+	err = CheckUserSuspended(user, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	err = CheckUserLifecycle(user, lang)
+	if err != nil {
+		return nil, err
 	}
 
 	if user.Ldap != "" {
@@ -340,7 +354,7 @@ func CheckUserPermission(requestUserId, userId string, strict bool, lang string)
 		}
 
 		if requestUser == nil {
-			return false, fmt.Errorf(i18n.Translate(lang, "check:Session outdated, please login again"))
+			return false, NewCodedError(ErrorCodeSessionOutdated, i18n.Translate(lang, "check:Session outdated, please login again"))
 		}
 		if requestUser.IsGlobalAdmin() {
 			hasPermission = true
@@ -387,7 +401,7 @@ func CheckLoginPermission(userId string, application *Application) (bool, error)
 			continue
 		}
 
-		enforcer, err := getPermissionEnforcer(permission)
+		enforcer, err := getPermissionReadEnforcer(permission)
 		if err != nil {
 			return false, err
 		}
@@ -425,28 +439,20 @@ func CheckLoginPermission(userId string, application *Application) (bool, error)
 	return true, nil
 }
 
-func CheckUsername(username string, lang string) string {
-	if username == "" {
-		return i18n.Translate(lang, "check:Empty username.")
-	} else if len(username) > 39 {
-		return i18n.Translate(lang, "check:Username is too long (maximum is 39 characters).")
-	}
-
-	// https://stackoverflow.com/questions/58726546/github-username-convention-using-regex
-
-	if !util.ReUserName.MatchString(username) {
-		return i18n.Translate(lang, "check:The username may only contain alphanumeric characters, underlines or hyphens, cannot have consecutive hyphens or underlines, and cannot begin or end with a hyphen or underline.")
-	}
-
-	return ""
-}
-
-func CheckUpdateUser(oldUser, user *User, lang string) string {
+func CheckUpdateUser(oldUser, user *User, isAdmin bool, lang string) string {
 	if oldUser.Name != user.Name {
-		if msg := CheckUsername(user.Name, lang); msg != "" {
+		organization, err := getOrganization("admin", user.Owner)
+		if err != nil {
+			return err.Error()
+		}
+
+		if organization != nil && organization.UsernamePolicy != nil && organization.UsernamePolicy.IsImmutable && !isAdmin {
+			return i18n.Translate(lang, "check:The username cannot be changed")
+		}
+		if msg := CheckUsernameByOrg(organization, user.Name, isAdmin, lang); msg != "" {
 			return msg
 		}
-		if HasUserByField(user.Owner, "name", user.Name) {
+		if HasUserByField(user.Owner, "name", user.Name) || isUsernameTaken(organization, user.Name, oldUser.Name) {
 			return i18n.Translate(lang, "check:Username already exists")
 		}
 	}
@@ -464,7 +470,7 @@ func CheckUpdateUser(oldUser, user *User, lang string) string {
 	return ""
 }
 
-func CheckToEnableCaptcha(application *Application, organization, username string) (bool, error) {
+func CheckToEnableCaptcha(application *Application, organization, username string, botScore int) (bool, error) {
 	if len(application.Providers) == 0 {
 		return false, nil
 	}
@@ -474,14 +480,28 @@ func CheckToEnableCaptcha(application *Application, organization, username strin
 			continue
 		}
 		if providerItem.Provider.Category == "Captcha" {
-			if providerItem.Rule == "Dynamic" {
+			rule := providerItem.Rule
+			if len(application.AuthSteps) > 0 {
+				// the customized login flow decides whether the captcha is needed
+				captchaStep := application.GetAuthStep(AuthStepCaptcha)
+				if captchaStep == nil {
+					return false, nil
+				}
+				rule = captchaStep.Rule
+			}
+
+			if rule == "Dynamic" {
+				if botDetection := application.GetBotDetection(); botDetection != nil && botDetection.IsCaptchaRequired(botScore) {
+					return true, nil
+				}
+
 				user, err := GetUserByFields(organization, username)
 				if err != nil {
 					return false, err
 				}
 				return user != nil && user.SigninWrongTimes >= SigninWrongTimesLimit, nil
 			}
-			return providerItem.Rule == "Always", nil
+			return rule == "Always", nil
 		}
 	}
 
@@ -27,16 +27,25 @@ var (
 	regexSpecial   = regexp.MustCompile(`[!@#$%^&*]`)
 )
 
+// passwordOptionDescriptions are the requirements of the password options, as shown to the users
+var passwordOptionDescriptions = map[string]string{
+	"AtLeast6":    "The password must have at least 6 characters",
+	"AtLeast8":    "The password must have at least 8 characters",
+	"Aa123":       "The password must contain at least one uppercase letter, one lowercase letter and one digit",
+	"SpecialChar": "The password must contain at least one special character",
+	"NoRepeat":    "The password must not contain any repeated characters",
+}
+
 func isValidOption_AtLeast6(password string) string {
 	if len(password) < 6 {
-		return "The password must have at least 6 characters"
+		return passwordOptionDescriptions["AtLeast6"]
 	}
 	return ""
 }
 
 func isValidOption_AtLeast8(password string) string {
 	if len(password) < 8 {
-		return "The password must have at least 8 characters"
+		return passwordOptionDescriptions["AtLeast8"]
 	}
 	return ""
 }
@@ -47,14 +56,14 @@ func isValidOption_Aa123(password string) string {
 	hasDigit := regexDigit.MatchString(password)
 
 	if !hasLowerCase || !hasUpperCase || !hasDigit {
-		return "The password must contain at least one uppercase letter, one lowercase letter and one digit"
+		return passwordOptionDescriptions["Aa123"]
 	}
 	return ""
 }
 
 func isValidOption_SpecialChar(password string) string {
 	if !regexSpecial.MatchString(password) {
-		return "The password must contain at least one special character"
+		return passwordOptionDescriptions["SpecialChar"]
 	}
 	return ""
 }
@@ -62,7 +71,7 @@ func isValidOption_SpecialChar(password string) string {
 func isValidOption_NoRepeat(password string) string {
 	for i := 0; i < len(password)-1; i++ {
 		if password[i] == password[i+1] {
-			return "The password must not contain any repeated characters"
+			return passwordOptionDescriptions["NoRepeat"]
 		}
 	}
 	return ""
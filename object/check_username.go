@@ -0,0 +1,154 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+)
+
+const defaultUsernameMaxLength = 39
+
+// UsernamePolicy is the rules of the usernames of an organization replacing the default ones,
+// the reserved names and the blocked words don't apply to the users created or renamed by the admins.
+type UsernamePolicy struct {
+	// AllowedCharacters is the content of a regex character class, e.g. "a-z0-9._-"
+	AllowedCharacters string   `json:"allowedCharacters"`
+	MinLength         int      `json:"minLength"`
+	MaxLength         int      `json:"maxLength"`
+	IsCaseInsensitive bool     `json:"isCaseInsensitive"`
+	ReservedNames     []string `json:"reservedNames"`
+	BlockedWords      []string `json:"blockedWords"`
+	IsImmutable       bool     `json:"isImmutable"`
+}
+
+func (policy *UsernamePolicy) getAllowedCharactersRegex() (*regexp.Regexp, error) {
+	return regexp.Compile(fmt.Sprintf("^[%s]+$", policy.AllowedCharacters))
+}
+
+func (policy *UsernamePolicy) getMaxLength() int {
+	if policy.MaxLength <= 0 {
+		return defaultUsernameMaxLength
+	}
+	return policy.MaxLength
+}
+
+func checkUsernamePolicy(policy *UsernamePolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	if policy.MinLength < 0 || policy.MaxLength < 0 {
+		return fmt.Errorf("the username lengths should not be negative")
+	}
+	if policy.MinLength > policy.getMaxLength() {
+		return fmt.Errorf("the min username length should not be greater than the max one")
+	}
+
+	if policy.AllowedCharacters != "" {
+		_, err := policy.getAllowedCharactersRegex()
+		if err != nil {
+			return fmt.Errorf("the allowed username characters: %s are invalid, error: %s", policy.AllowedCharacters, err.Error())
+		}
+	}
+	return nil
+}
+
+func CheckUsername(username string, lang string) string {
+	if username == "" {
+		return i18n.Translate(lang, "check:Empty username.")
+	} else if len(username) > defaultUsernameMaxLength {
+		return i18n.Translate(lang, "check:Username is too long (maximum is 39 characters).")
+	}
+
+	// https://stackoverflow.com/questions/58726546/github-username-convention-using-regex
+
+	if !util.ReUserName.MatchString(username) {
+		return i18n.Translate(lang, "check:The username may only contain alphanumeric characters, underlines or hyphens, cannot have consecutive hyphens or underlines, and cannot begin or end with a hyphen or underline.")
+	}
+
+	return ""
+}
+
+// CheckUsernameByOrg checks the username by the username policy of the organization, or by the default rules
+// if the organization has none, the admins override the reserved names and the blocked words
+func CheckUsernameByOrg(organization *Organization, username string, isAdmin bool, lang string) string {
+	if organization == nil || organization.UsernamePolicy == nil {
+		return CheckUsername(username, lang)
+	}
+
+	policy := organization.UsernamePolicy
+	length := utf8.RuneCountInString(username)
+	if username == "" {
+		return i18n.Translate(lang, "check:Empty username.")
+	} else if length < policy.MinLength {
+		return fmt.Sprintf(i18n.Translate(lang, "check:Username must have at least %d characters"), policy.MinLength)
+	} else if length > policy.getMaxLength() {
+		return fmt.Sprintf(i18n.Translate(lang, "check:Username is too long (maximum is %d characters)."), policy.getMaxLength())
+	}
+
+	if policy.AllowedCharacters == "" {
+		if !util.ReUserName.MatchString(username) {
+			return i18n.Translate(lang, "check:The username may only contain alphanumeric characters, underlines or hyphens, cannot have consecutive hyphens or underlines, and cannot begin or end with a hyphen or underline.")
+		}
+	} else {
+		re, err := policy.getAllowedCharactersRegex()
+		if err != nil {
+			return err.Error()
+		}
+		if !re.MatchString(username) {
+			return fmt.Sprintf(i18n.Translate(lang, "check:The username may only contain the characters: %s"), policy.AllowedCharacters)
+		}
+	}
+
+	if isAdmin {
+		return ""
+	}
+
+	for _, reservedName := range policy.ReservedNames {
+		if strings.EqualFold(strings.TrimSpace(reservedName), username) {
+			return fmt.Sprintf(i18n.Translate(lang, "check:The username: %s is reserved"), username)
+		}
+	}
+
+	lowerUsername := strings.ToLower(username)
+	for _, word := range policy.BlockedWords {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && strings.Contains(lowerUsername, word) {
+			return i18n.Translate(lang, "check:The username contains a blocked word")
+		}
+	}
+
+	return ""
+}
+
+// isUsernameTaken checks whether another user of the organization has the username in a different case,
+// when the usernames of the organization are case-insensitive
+func isUsernameTaken(organization *Organization, username string, oldUsername string) bool {
+	if organization == nil || organization.UsernamePolicy == nil || !organization.UsernamePolicy.IsCaseInsensitive {
+		return false
+	}
+
+	count, err := ormer.Engine.Where("owner = ? and lower(name) = ? and name != ?", organization.Name, strings.ToLower(username), oldUsername).Count(&User{})
+	if err != nil {
+		panic(err)
+	}
+	return count != 0
+}
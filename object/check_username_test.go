@@ -0,0 +1,56 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckUsernameByOrg(t *testing.T) {
+	assert.Equal(t, "", CheckUsernameByOrg(nil, "alice", false, "en"))
+	assert.NotEqual(t, "", CheckUsernameByOrg(&Organization{}, "alice.smith", false, "en"))
+
+	organization := &Organization{
+		UsernamePolicy: &UsernamePolicy{
+			AllowedCharacters: "a-z0-9.",
+			MinLength:         3,
+			MaxLength:         12,
+			ReservedNames:     []string{"root", " Support "},
+			BlockedWords:      []string{"damn"},
+		},
+	}
+
+	assert.Equal(t, "", CheckUsernameByOrg(organization, "alice.smith", false, "en"))
+	assert.NotEqual(t, "", CheckUsernameByOrg(organization, "al", false, "en"))
+	assert.NotEqual(t, "", CheckUsernameByOrg(organization, "alice.smith.jr", false, "en"))
+	assert.NotEqual(t, "", CheckUsernameByOrg(organization, "alice_smith", false, "en"))
+	assert.NotEqual(t, "", CheckUsernameByOrg(organization, "support", false, "en"))
+	assert.NotEqual(t, "", CheckUsernameByOrg(organization, "godamnit", false, "en"))
+
+	// the admins override the reserved names and the blocked words, but not the format
+	assert.Equal(t, "", CheckUsernameByOrg(organization, "support", true, "en"))
+	assert.Equal(t, "", CheckUsernameByOrg(organization, "godamnit", true, "en"))
+	assert.NotEqual(t, "", CheckUsernameByOrg(organization, "al", true, "en"))
+}
+
+func TestCheckUsernamePolicy(t *testing.T) {
+	assert.Nil(t, checkUsernamePolicy(nil))
+	assert.Nil(t, checkUsernamePolicy(&UsernamePolicy{AllowedCharacters: "a-z0-9._-", MinLength: 3}))
+	assert.NotNil(t, checkUsernamePolicy(&UsernamePolicy{MinLength: 40}))
+	assert.NotNil(t, checkUsernamePolicy(&UsernamePolicy{MinLength: 5, MaxLength: 4}))
+	assert.NotNil(t, checkUsernamePolicy(&UsernamePolicy{AllowedCharacters: "z-a"}))
+}
@@ -15,7 +15,6 @@
 package object
 
 import (
-	"fmt"
 	"regexp"
 	"time"
 
@@ -71,11 +70,11 @@ func recordSigninErrorInfo(user *User, lang string, options ...bool) error {
 
 	leftChances := SigninWrongTimesLimit - user.SigninWrongTimes
 	if leftChances == 0 && enableCaptcha {
-		return fmt.Errorf(i18n.Translate(lang, "check:password or code is incorrect"))
+		return NewCodedError(ErrorCodeAuthInvalidPassword, i18n.Translate(lang, "check:password or code is incorrect"))
 	} else if leftChances >= 0 {
-		return fmt.Errorf(i18n.Translate(lang, "check:password or code is incorrect, you have %d remaining chances"), leftChances)
+		return NewCodedError(ErrorCodeAuthInvalidPassword, i18n.Translate(lang, "check:password or code is incorrect, you have %d remaining chances"), leftChances)
 	}
 
 	// don't show the chance error message if the user has no chance left
-	return fmt.Errorf(i18n.Translate(lang, "check:You have entered the wrong password or code too many times, please wait for %d minutes and try again"), int(LastSignWrongTimeDuration.Minutes()))
+	return NewCodedError(ErrorCodeAuthTooManyAttempts, i18n.Translate(lang, "check:You have entered the wrong password or code too many times, please wait for %d minutes and try again"), int(LastSignWrongTimeDuration.Minutes()))
 }
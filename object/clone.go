@@ -0,0 +1,291 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/thanhpk/randstr"
+	"github.com/xorm-io/xorm"
+)
+
+// getClonedName remaps a name of the source object into the namespace of the cloned object,
+// e.g. "app-acme" becomes "app-contoso" when "acme" is cloned to "contoso"
+func getClonedName(name string, oldName string, newName string) string {
+	if oldName != "" && strings.Contains(name, oldName) {
+		return strings.ReplaceAll(name, oldName, newName)
+	}
+
+	return fmt.Sprintf("%s-%s", name, newName)
+}
+
+func deepCopyApplication(application *Application) (*Application, error) {
+	res := &Application{}
+	err := util.JsonToStruct(util.StructToJson(application), res)
+	if err != nil {
+		return nil, err
+	}
+
+	res.OrganizationObj = nil
+	res.CertPublicKey = ""
+	for _, providerItem := range res.Providers {
+		providerItem.Provider = nil
+	}
+
+	return res, nil
+}
+
+func getClonedApplication(application *Application, name string, organization string, providerNameMap map[string]string) (*Application, error) {
+	res, err := deepCopyApplication(application)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Name = name
+	res.CreatedTime = util.GetCurrentTime()
+	if organization != "" && organization != res.Organization {
+		res.Organization = organization
+		// the subject applications of the delegation rules are of the source organization
+		res.DelegationRules = []*DelegationRule{}
+	}
+
+	// the secrets of the source application must never be shared with its clones
+	res.ClientId = util.GenerateClientId()
+	res.ClientSecret = util.GenerateClientSecret()
+	res.InvitationCodes = []string{}
+
+	for _, providerItem := range res.Providers {
+		if newProviderName, ok := providerNameMap[providerItem.Name]; ok {
+			providerItem.Name = newProviderName
+			providerItem.Owner = res.Organization
+		}
+	}
+
+	return res, nil
+}
+
+func insertClonedApplication(session *xorm.Session, application *Application, lang string) error {
+	existed, err := session.Exist(&Application{Owner: application.Owner, Name: application.Name})
+	if err != nil {
+		return err
+	}
+	if existed {
+		return fmt.Errorf(i18n.Translate(lang, "general:The application: %s already exists"), application.GetId())
+	}
+
+	err = checkAuthSteps(application)
+	if err != nil {
+		return err
+	}
+
+	err = checkLoginExperiment(application)
+	if err != nil {
+		return err
+	}
+
+	err = checkApplicationScopes(application)
+	if err != nil {
+		return err
+	}
+
+	err = checkDelegationRules(application)
+	if err != nil {
+		return err
+	}
+
+	err = checkAudienceRules(application)
+	if err != nil {
+		return err
+	}
+
+	err = checkSessionPolicy(application.SessionPolicy)
+	if err != nil {
+		return err
+	}
+
+	err = checkBotDetection(application.BotDetection)
+	if err != nil {
+		return err
+	}
+
+	err = checkLegalDocuments(application.LegalDocuments)
+	if err != nil {
+		return err
+	}
+
+	_, err = session.Insert(application)
+	return err
+}
+
+// CloneApplication copies the application with its provider bindings, signup items, theme and token settings
+// into a new application named newName, optionally moving it into another organization.
+func CloneApplication(id string, newName string, organization string, lang string) (*Application, error) {
+	application, err := GetApplication(id)
+	if err != nil {
+		return nil, err
+	}
+	if application == nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "auth:The application: %s does not exist"), id)
+	}
+
+	if organization != "" && organization != application.Organization {
+		org, err := getOrganization("admin", organization)
+		if err != nil {
+			return nil, err
+		}
+		if org == nil {
+			return nil, fmt.Errorf(i18n.Translate(lang, "general:The organization: %s does not exist"), organization)
+		}
+	}
+
+	res, err := getClonedApplication(application, newName, organization, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	session := ormer.Engine.NewSession()
+	defer session.Close()
+
+	err = insertClonedApplication(session, res, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// CloneOrganization copies the organization together with its applications and its own providers,
+// remapping their names to newName and regenerating the secrets.
+func CloneOrganization(id string, newName string, lang string) (*Organization, error) {
+	organization, err := GetOrganization(id)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "general:The organization: %s does not exist"), id)
+	}
+
+	existed, err := getOrganization(organization.Owner, newName)
+	if err != nil {
+		return nil, err
+	}
+	if existed != nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "general:The organization: %s already exists"), util.GetId(organization.Owner, newName))
+	}
+
+	res := &Organization{}
+	err = util.JsonToStruct(util.StructToJson(organization), res)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Name = newName
+	res.CreatedTime = util.GetCurrentTime()
+	res.MasterPassword = ""
+	res.MasterVerificationCode = ""
+	// a custom domain belongs to only one organization
+	res.CustomDomains = nil
+	if res.PasswordSalt != "" {
+		res.PasswordSalt = randstr.Hex(10)
+	}
+
+	providers, err := getRawProviders(organization.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	clonedProviders := []*Provider{}
+	providerNameMap := map[string]string{}
+	for _, provider := range providers {
+		if provider.Owner != organization.Name {
+			continue
+		}
+
+		clonedProvider := *provider
+		clonedProvider.Owner = newName
+		clonedProvider.Name = getClonedName(provider.Name, organization.Name, newName)
+		clonedProvider.CreatedTime = res.CreatedTime
+		clonedProviders = append(clonedProviders, &clonedProvider)
+		providerNameMap[provider.Name] = clonedProvider.Name
+	}
+
+	applications, err := GetOrganizationApplications("admin", organization.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	clonedApplications := []*Application{}
+	for _, application := range applications {
+		clonedApplication, err := getClonedApplication(application, getClonedName(application.Name, organization.Name, newName), newName, providerNameMap)
+		if err != nil {
+			return nil, err
+		}
+
+		if application.Name == organization.DefaultApplication {
+			res.DefaultApplication = clonedApplication.Name
+		}
+		clonedApplications = append(clonedApplications, clonedApplication)
+	}
+
+	session := ormer.Engine.NewSession()
+	defer session.Close()
+
+	err = session.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = session.Insert(res)
+	if err != nil {
+		session.Rollback()
+		return nil, err
+	}
+
+	for _, provider := range clonedProviders {
+		existed, err := session.Exist(&Provider{Name: provider.Name})
+		if err != nil {
+			session.Rollback()
+			return nil, err
+		}
+		if existed {
+			session.Rollback()
+			return nil, fmt.Errorf(i18n.Translate(lang, "general:The provider: %s already exists"), provider.Name)
+		}
+
+		_, err = session.Insert(provider)
+		if err != nil {
+			session.Rollback()
+			return nil, err
+		}
+	}
+
+	for _, application := range clonedApplications {
+		err = insertClonedApplication(session, application, lang)
+		if err != nil {
+			session.Rollback()
+			return nil, err
+		}
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
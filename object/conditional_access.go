@@ -0,0 +1,241 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	ConditionalAccessActionAllow        = "Allow"
+	ConditionalAccessActionDeny         = "Deny"
+	ConditionalAccessActionRequireMfa   = "RequireMfa"
+	ConditionalAccessActionLimitSession = "LimitSession"
+
+	DeviceTrustTrusted   = "Trusted"
+	DeviceTrustUntrusted = "Untrusted"
+
+	conditionalAccessTimeLayout = "15:04"
+)
+
+// ConditionalAccessPolicy is evaluated when a member of the organization signs in, the enabled policies
+// are evaluated in the ascending order of Priority and the first one whose conditions all match decides.
+// The empty conditions match any sign-in.
+type ConditionalAccessPolicy struct {
+	Name      string `json:"name"`
+	IsEnabled bool   `json:"isEnabled"`
+	Priority  int    `json:"priority"`
+
+	// Applications are the names of the client applications
+	Applications []string `json:"applications"`
+	// Groups are the ids of the groups of the user
+	Groups []string `json:"groups"`
+	// DeviceTrust is "Trusted", "Untrusted" or empty for any device
+	DeviceTrust string `json:"deviceTrust"`
+	// IpRanges and Countries are the network locations, the client matches if it is in any of them
+	IpRanges  []string `json:"ipRanges"`
+	Countries []string `json:"countries"`
+	// StartTime and EndTime are like "09:00" in TimeZone (the server's time zone if empty),
+	// the window wraps around midnight if EndTime is earlier
+	StartTime string   `json:"startTime"`
+	EndTime   string   `json:"endTime"`
+	Weekdays  []string `json:"weekdays"`
+	TimeZone  string   `json:"timeZone"`
+	// MinBotScore matches the sign-ins whose bot detection score of the application is at least it, 0 for any score
+	MinBotScore int `json:"minBotScore"`
+
+	Action string `json:"action"`
+	// SessionLifetime is the maximum lifetime of the session in minutes for the "LimitSession" action
+	SessionLifetime int `json:"sessionLifetime"`
+}
+
+// ConditionalAccessContext is the sign-in that the policies are evaluated against
+type ConditionalAccessContext struct {
+	Application     *Application
+	User            *User
+	ClientIp        string
+	IsDeviceTrusted bool
+	BotScore        int
+	Time            time.Time
+}
+
+type ConditionalAccessResult struct {
+	Policy          string `json:"policy"`
+	Action          string `json:"action"`
+	SessionLifetime int    `json:"sessionLifetime"`
+}
+
+func (policy *ConditionalAccessPolicy) getLocation() (*time.Location, error) {
+	if policy.TimeZone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(policy.TimeZone)
+}
+
+func checkConditionalAccessPolicies(policies []*ConditionalAccessPolicy) error {
+	for _, policy := range policies {
+		switch policy.Action {
+		case ConditionalAccessActionAllow, ConditionalAccessActionDeny, ConditionalAccessActionRequireMfa:
+		case ConditionalAccessActionLimitSession:
+			if policy.SessionLifetime <= 0 {
+				return fmt.Errorf("the conditional access policy: %s should have a positive session lifetime", policy.Name)
+			}
+		default:
+			return fmt.Errorf("the action: %s of the conditional access policy: %s is not supported", policy.Action, policy.Name)
+		}
+
+		if policy.DeviceTrust != "" && policy.DeviceTrust != DeviceTrustTrusted && policy.DeviceTrust != DeviceTrustUntrusted {
+			return fmt.Errorf("the device trust: %s of the conditional access policy: %s is not supported", policy.DeviceTrust, policy.Name)
+		}
+
+		if (policy.StartTime == "") != (policy.EndTime == "") {
+			return fmt.Errorf("the conditional access policy: %s should have both the start time and the end time", policy.Name)
+		}
+		for _, t := range []string{policy.StartTime, policy.EndTime} {
+			if _, err := time.Parse(conditionalAccessTimeLayout, t); t != "" && err != nil {
+				return fmt.Errorf("the time: %s of the conditional access policy: %s is invalid", t, policy.Name)
+			}
+		}
+
+		if _, err := policy.getLocation(); err != nil {
+			return fmt.Errorf("the time zone: %s of the conditional access policy: %s is invalid", policy.TimeZone, policy.Name)
+		}
+
+		if policy.MinBotScore < 0 || policy.MinBotScore > 100 {
+			return fmt.Errorf("the min bot score of the conditional access policy: %s should be between 0 and 100", policy.Name)
+		}
+	}
+	return nil
+}
+
+func (policy *ConditionalAccessPolicy) isTimeMatched(t time.Time) bool {
+	location, err := policy.getLocation()
+	if err != nil {
+		return false
+	}
+	t = t.In(location)
+
+	if len(policy.Weekdays) > 0 && !util.InSlice(policy.Weekdays, t.Weekday().String()[:3]) {
+		return false
+	}
+
+	if policy.StartTime == "" {
+		return true
+	}
+
+	current := t.Format(conditionalAccessTimeLayout)
+	if policy.StartTime <= policy.EndTime {
+		return current >= policy.StartTime && current < policy.EndTime
+	}
+	return current >= policy.StartTime || current < policy.EndTime
+}
+
+func (policy *ConditionalAccessPolicy) isMatched(ctx *ConditionalAccessContext, countryCode string) bool {
+	if len(policy.Applications) > 0 && (ctx.Application == nil || !util.InSlice(policy.Applications, ctx.Application.Name)) {
+		return false
+	}
+
+	if len(policy.Groups) > 0 && !isUserInGroups(ctx.User, policy.Groups) {
+		return false
+	}
+
+	if (policy.DeviceTrust == DeviceTrustTrusted && !ctx.IsDeviceTrusted) || (policy.DeviceTrust == DeviceTrustUntrusted && ctx.IsDeviceTrusted) {
+		return false
+	}
+
+	if len(policy.IpRanges) > 0 || len(policy.Countries) > 0 {
+		if !isIpInRanges(ctx.ClientIp, policy.IpRanges) && !isCountryInList(countryCode, policy.Countries) {
+			return false
+		}
+	}
+
+	if policy.MinBotScore > 0 && ctx.BotScore < policy.MinBotScore {
+		return false
+	}
+
+	return policy.isTimeMatched(ctx.Time)
+}
+
+func getSortedConditionalAccessPolicies(policies []*ConditionalAccessPolicy) []*ConditionalAccessPolicy {
+	res := []*ConditionalAccessPolicy{}
+	for _, policy := range policies {
+		if policy.IsEnabled {
+			res = append(res, policy)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Priority < res[j].Priority
+	})
+	return res
+}
+
+func evaluateConditionalAccessPolicies(policies []*ConditionalAccessPolicy, ctx *ConditionalAccessContext, getCountryCode func(ip string) string) *ConditionalAccessResult {
+	countryCode := ""
+	isCountryCodeLoaded := false
+	for _, policy := range getSortedConditionalAccessPolicies(policies) {
+		if len(policy.Countries) > 0 && !isCountryCodeLoaded {
+			countryCode = getCountryCode(ctx.ClientIp)
+			isCountryCodeLoaded = true
+		}
+
+		if policy.isMatched(ctx, countryCode) {
+			return &ConditionalAccessResult{
+				Policy:          policy.Name,
+				Action:          policy.Action,
+				SessionLifetime: policy.SessionLifetime,
+			}
+		}
+	}
+
+	return &ConditionalAccessResult{Action: ConditionalAccessActionAllow}
+}
+
+// EvaluateConditionalAccess returns the decision of the conditional access policies of the user's organization,
+// a denial record is added when the sign-in is denied.
+func EvaluateConditionalAccess(ctx *ConditionalAccessContext) (*ConditionalAccessResult, error) {
+	organization, err := getOrganization("admin", ctx.User.Owner)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil || len(organization.ConditionalAccessPolicies) == 0 {
+		return &ConditionalAccessResult{Action: ConditionalAccessActionAllow}, nil
+	}
+
+	res := evaluateConditionalAccessPolicies(organization.ConditionalAccessPolicies, ctx, GetCountryCodeByIp)
+	if res.Action == ConditionalAccessActionDeny {
+		addSigninDeniedRecord(ctx.User.Owner, ctx.User.Name, ctx.ClientIp, "login", fmt.Sprintf("denied by the conditional access policy: %s", res.Policy))
+	}
+	return res, nil
+}
+
+// IsDeviceTrusted tells whether the client is a trusted device, which presents a verified TLS client certificate,
+// or is attested by the "true" value of the header configured as "deviceTrustHeader" by one of the proxies
+// configured by "trustedProxies". The header sent by any other client is ignored.
+func IsDeviceTrusted(req *http.Request) bool {
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 {
+		return true
+	}
+
+	header := conf.GetConfigString("deviceTrustHeader")
+	return header != "" && util.IsFromTrustedProxy(req) && strings.EqualFold(req.Header.Get(header), "true")
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateConditionalAccessPolicies(t *testing.T) {
+	policies := []*ConditionalAccessPolicy{
+		{Name: "office-hours", IsEnabled: true, Priority: 30, StartTime: "22:00", EndTime: "06:00", TimeZone: "UTC", Action: ConditionalAccessActionDeny},
+		{Name: "admins-mfa", IsEnabled: true, Priority: 20, Groups: []string{"built-in/admins"}, Action: ConditionalAccessActionRequireMfa},
+		{Name: "office", IsEnabled: true, Priority: 10, IpRanges: []string{"10.0.0.0/8"}, Action: ConditionalAccessActionAllow},
+		{Name: "untrusted-app1", IsEnabled: true, Priority: 15, Applications: []string{"app1"}, DeviceTrust: DeviceTrustUntrusted, Action: ConditionalAccessActionLimitSession, SessionLifetime: 60},
+		{Name: "blocked-country", IsEnabled: true, Priority: 5, Countries: []string{"XX"}, Action: ConditionalAccessActionDeny},
+		{Name: "likely-bot", IsEnabled: true, Priority: 1, MinBotScore: 60, Action: ConditionalAccessActionRequireMfa},
+		{Name: "disabled", IsEnabled: false, Priority: 0, Action: ConditionalAccessActionDeny},
+	}
+	getCountryCode := func(ip string) string {
+		if ip == "203.0.113.1" {
+			return "XX"
+		}
+		return "US"
+	}
+
+	day := time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+	night := time.Date(2023, 8, 1, 23, 0, 0, 0, time.UTC)
+	admin := &User{Owner: "built-in", Name: "admin", Groups: []string{"built-in/admins"}}
+	user := &User{Owner: "built-in", Name: "alice"}
+	app1 := &Application{Name: "app1"}
+	app2 := &Application{Name: "app2"}
+
+	scenarios := []struct {
+		ctx    *ConditionalAccessContext
+		policy string
+		action string
+	}{
+		{&ConditionalAccessContext{Application: app2, User: user, ClientIp: "203.0.113.1", Time: day}, "blocked-country", ConditionalAccessActionDeny},
+		{&ConditionalAccessContext{Application: app2, User: admin, ClientIp: "10.1.1.1", Time: night}, "office", ConditionalAccessActionAllow},
+		{&ConditionalAccessContext{Application: app1, User: admin, ClientIp: "1.1.1.1", Time: day}, "untrusted-app1", ConditionalAccessActionLimitSession},
+		{&ConditionalAccessContext{Application: app1, User: admin, ClientIp: "1.1.1.1", IsDeviceTrusted: true, Time: day}, "admins-mfa", ConditionalAccessActionRequireMfa},
+		{&ConditionalAccessContext{Application: app2, User: user, ClientIp: "1.1.1.1", Time: night}, "office-hours", ConditionalAccessActionDeny},
+		{&ConditionalAccessContext{Application: app2, User: user, ClientIp: "1.1.1.1", Time: day}, "", ConditionalAccessActionAllow},
+		{&ConditionalAccessContext{Application: app2, User: user, ClientIp: "1.1.1.1", BotScore: 59, Time: day}, "", ConditionalAccessActionAllow},
+		{&ConditionalAccessContext{Application: app2, User: user, ClientIp: "10.1.1.1", BotScore: 60, Time: day}, "likely-bot", ConditionalAccessActionRequireMfa},
+	}
+
+	for _, scenario := range scenarios {
+		result := evaluateConditionalAccessPolicies(policies, scenario.ctx, getCountryCode)
+		assert.Equal(t, scenario.policy, result.Policy)
+		assert.Equal(t, scenario.action, result.Action)
+	}
+}
+
+func TestCheckConditionalAccessPolicies(t *testing.T) {
+	assert.Nil(t, checkConditionalAccessPolicies([]*ConditionalAccessPolicy{{Name: "p1", Action: ConditionalAccessActionAllow, StartTime: "09:00", EndTime: "18:00", TimeZone: "Asia/Shanghai"}}))
+	assert.NotNil(t, checkConditionalAccessPolicies([]*ConditionalAccessPolicy{{Name: "p1", Action: "Unknown"}}))
+	assert.NotNil(t, checkConditionalAccessPolicies([]*ConditionalAccessPolicy{{Name: "p1", Action: ConditionalAccessActionLimitSession}}))
+	assert.NotNil(t, checkConditionalAccessPolicies([]*ConditionalAccessPolicy{{Name: "p1", Action: ConditionalAccessActionAllow, StartTime: "09:00"}}))
+	assert.NotNil(t, checkConditionalAccessPolicies([]*ConditionalAccessPolicy{{Name: "p1", Action: ConditionalAccessActionAllow, TimeZone: "Mars/Base"}}))
+	assert.NotNil(t, checkConditionalAccessPolicies([]*ConditionalAccessPolicy{{Name: "p1", Action: ConditionalAccessActionAllow, MinBotScore: 101}}))
+}
+
+func TestIsDeviceTrusted(t *testing.T) {
+	os.Setenv("deviceTrustHeader", "X-Device-Trusted")
+	defer os.Unsetenv("deviceTrustHeader")
+	os.Setenv("trustedProxies", "10.0.0.0/8")
+	defer os.Unsetenv("trustedProxies")
+
+	req := httptest.NewRequest("POST", "/api/login", nil)
+	req.Header.Set("X-Device-Trusted", "true")
+
+	// the header spoofed by a client out of the trusted proxies is ignored
+	req.RemoteAddr = "203.0.113.1:1234"
+	assert.False(t, IsDeviceTrusted(req))
+
+	req.RemoteAddr = "10.0.0.2:1234"
+	assert.True(t, IsDeviceTrusted(req))
+
+	req.Header.Set("X-Device-Trusted", "false")
+	assert.False(t, IsDeviceTrusted(req))
+}
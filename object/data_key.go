@@ -0,0 +1,380 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	CacheTypeDataKey = "data-key"
+
+	dataKeySize             = 32
+	dataKeyCiphertextPrefix = "enc:v1:"
+	dataKeyUserBatchSize    = 1000
+)
+
+// encryptedUserColumns are the sensitive columns of the users encrypted by the data keys of their organizations
+var encryptedUserColumns = []string{"id_card", "birthday", "address"}
+
+// DataKey is the data-encryption key of the organization wrapped by the "dataEncryptionMasterKey" config.
+// The sensitive columns of the users are encrypted by it, so deleting the key shreds them (crypto-shredding).
+type DataKey struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	CreatedBy   string `xorm:"varchar(100)" json:"createdBy"`
+
+	WrappedKey string `xorm:"varchar(200)" json:"-"`
+}
+
+var (
+	dataKeyCache      = map[string][]byte{}
+	dataKeyCacheMutex sync.RWMutex
+)
+
+func init() {
+	RegisterCacheInvalidationHandler(CacheTypeDataKey, func(key string) {
+		clearDataKeyCache(key)
+	})
+}
+
+// clearDataKeyCache drops the cached data key of the organization, or all of them if it is empty
+func clearDataKeyCache(organization string) {
+	dataKeyCacheMutex.Lock()
+	defer dataKeyCacheMutex.Unlock()
+
+	if organization == "" {
+		dataKeyCache = map[string][]byte{}
+	} else {
+		delete(dataKeyCache, organization)
+	}
+}
+
+func getDataKeyMasterAead() (cipher.AEAD, error) {
+	masterKey := conf.GetConfigString("dataEncryptionMasterKey")
+	if masterKey == "" {
+		return nil, fmt.Errorf("the \"dataEncryptionMasterKey\" config is required by the data keys")
+	}
+
+	key := sha256.Sum256([]byte(masterKey))
+	return getDataKeyAead(key[:])
+}
+
+func getDataKeyAead(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func wrapDataKey(key []byte) (string, error) {
+	aead, err := getDataKeyMasterAead()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, key, nil)), nil
+}
+
+func unwrapDataKey(wrappedKey string) ([]byte, error) {
+	aead, err := getDataKeyMasterAead()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(wrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("the wrapped data key is invalid")
+	}
+	return aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+}
+
+func GetDataKey(organization string) (*DataKey, error) {
+	dataKey := DataKey{Owner: "admin", Name: organization}
+	existed, err := ormer.Engine.Get(&dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &dataKey, nil
+	}
+	return nil, nil
+}
+
+// getOrganizationDataKey returns the unwrapped data key of the organization, nil if it has none
+func getOrganizationDataKey(organization string) ([]byte, error) {
+	if organization == "" {
+		return nil, nil
+	}
+
+	dataKeyCacheMutex.RLock()
+	key, ok := dataKeyCache[organization]
+	dataKeyCacheMutex.RUnlock()
+	if ok {
+		return key, nil
+	}
+
+	dataKey, err := GetDataKey(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	if dataKey != nil {
+		key, err = unwrapDataKey(dataKey.WrappedKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	dataKeyCacheMutex.Lock()
+	dataKeyCache[organization] = key
+	dataKeyCacheMutex.Unlock()
+	return key, nil
+}
+
+// encryptUserValue encrypts the value deterministically with the nonce derived from it, so the encrypted
+// columns can still be looked up by their values
+func encryptUserValue(key []byte, value string) (string, error) {
+	if value == "" || strings.HasPrefix(value, dataKeyCiphertextPrefix) {
+		return value, nil
+	}
+
+	aead, err := getDataKeyAead(key)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	nonce := mac.Sum(nil)[:aead.NonceSize()]
+	return dataKeyCiphertextPrefix + base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(value), nil)), nil
+}
+
+// decryptUserValue decrypts the encrypted value, the value encrypted by a shredded key can't be decrypted
+// and is returned as empty
+func decryptUserValue(key []byte, value string) string {
+	if !strings.HasPrefix(value, dataKeyCiphertextPrefix) {
+		return value
+	}
+	if key == nil {
+		return ""
+	}
+
+	aead, err := getDataKeyAead(key)
+	if err != nil {
+		return ""
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(value, dataKeyCiphertextPrefix))
+	if err != nil || len(data) < aead.NonceSize() {
+		return ""
+	}
+
+	res, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return ""
+	}
+	return string(res)
+}
+
+// getEncryptedUserColumnValue returns the value stored in the column of the users of the organization, to look up
+// or set the column directly. The address is a JSON array encrypted by its items, so it isn't supported.
+func getEncryptedUserColumnValue(organization string, column string, value string) (string, error) {
+	if !util.InSlice(encryptedUserColumns, column) || column == "address" {
+		return value, nil
+	}
+
+	key, err := getOrganizationDataKey(organization)
+	if err != nil || key == nil {
+		return value, err
+	}
+	return encryptUserValue(key, value)
+}
+
+func (user *User) encryptSensitiveFields() error {
+	key, err := getOrganizationDataKey(user.Owner)
+	if err != nil || key == nil {
+		return err
+	}
+
+	user.IdCard, err = encryptUserValue(key, user.IdCard)
+	if err != nil {
+		return err
+	}
+	user.Birthday, err = encryptUserValue(key, user.Birthday)
+	if err != nil {
+		return err
+	}
+	for i, address := range user.Address {
+		user.Address[i], err = encryptUserValue(key, address)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (user *User) decryptSensitiveFields() error {
+	key, err := getOrganizationDataKey(user.Owner)
+	if err != nil {
+		return err
+	}
+
+	user.IdCard = decryptUserValue(key, user.IdCard)
+	user.Birthday = decryptUserValue(key, user.Birthday)
+	for i, address := range user.Address {
+		user.Address[i] = decryptUserValue(key, address)
+	}
+	return nil
+}
+
+// encryptSensitiveColumns encrypts the sensitive fields of the user when any of them is among the columns to write.
+// It is called explicitly before the writes instead of in the xorm hooks, which can't fail them, so the users are
+// never stored in plaintext when their data keys can't be read.
+func (user *User) encryptSensitiveColumns(columns []string) error {
+	for _, column := range columns {
+		if util.InSlice(encryptedUserColumns, column) {
+			return user.encryptSensitiveFields()
+		}
+	}
+	return nil
+}
+
+func (user *User) AfterInsert() {
+	user.AfterLoad()
+}
+
+func (user *User) AfterUpdate() {
+	user.AfterLoad()
+}
+
+func (user *User) AfterLoad() {
+	err := user.decryptSensitiveFields()
+	if err != nil {
+		logs.Error(fmt.Sprintf("failed to decrypt the user: %s, error: %s", user.GetId(), err.Error()))
+	}
+}
+
+// encryptOrganizationUsers rewrites the sensitive columns of the existing users of the organization by its
+// current data key, the values encrypted by a shredded key are cleared
+func encryptOrganizationUsers(organization string) error {
+	for offset := 0; ; offset += dataKeyUserBatchSize {
+		users := []*User{}
+		err := ormer.Engine.Where("owner = ?", organization).Asc("name").Limit(dataKeyUserBatchSize, offset).Find(&users)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			err = user.encryptSensitiveFields()
+			if err != nil {
+				return err
+			}
+
+			_, err = ormer.Engine.ID(core.PK{user.Owner, user.Name}).Cols(encryptedUserColumns...).Update(user)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(users) < dataKeyUserBatchSize {
+			return nil
+		}
+	}
+}
+
+// CreateDataKey creates the data key of the organization and encrypts the sensitive columns of its users by it
+func CreateDataKey(organization string, createdBy string, lang string) (*DataKey, error) {
+	dataKey, err := GetDataKey(organization)
+	if err != nil {
+		return nil, err
+	}
+	if dataKey != nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "organization:The data key of the organization: %s already exists"), organization)
+	}
+
+	key := make([]byte, dataKeySize)
+	_, err = rand.Read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := wrapDataKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey = &DataKey{
+		Owner:       "admin",
+		Name:        organization,
+		CreatedTime: util.GetCurrentTime(),
+		CreatedBy:   createdBy,
+		WrappedKey:  wrappedKey,
+	}
+	_, err = ormer.Engine.Insert(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clearDataKeyCache(organization)
+	publishCacheInvalidation(CacheTypeDataKey, organization)
+
+	err = encryptOrganizationUsers(organization)
+	if err != nil {
+		return nil, err
+	}
+	return dataKey, nil
+}
+
+// ShredDataKey deletes the data key of the organization, the sensitive columns of its users encrypted by it
+// can't be decrypted anymore, including the copies in the backups as the data keys aren't backed up
+func ShredDataKey(organization string, lang string) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{"admin", organization}).Delete(&DataKey{})
+	if err != nil {
+		return false, err
+	}
+	if affected == 0 {
+		return false, fmt.Errorf(i18n.Translate(lang, "organization:The organization: %s has no data key"), organization)
+	}
+
+	clearDataKeyCache(organization)
+	publishCacheInvalidation(CacheTypeDataKey, organization)
+	return true, nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapDataKey(t *testing.T) {
+	_, err := wrapDataKey([]byte("key"))
+	assert.NotNil(t, err)
+
+	os.Setenv("dataEncryptionMasterKey", "master-key")
+	defer os.Unsetenv("dataEncryptionMasterKey")
+
+	key := []byte(strings.Repeat("k", dataKeySize))
+	wrappedKey, err := wrapDataKey(key)
+	assert.Nil(t, err)
+
+	unwrappedKey, err := unwrapDataKey(wrappedKey)
+	assert.Nil(t, err)
+	assert.Equal(t, key, unwrappedKey)
+
+	os.Setenv("dataEncryptionMasterKey", "another-master-key")
+	_, err = unwrapDataKey(wrappedKey)
+	assert.NotNil(t, err)
+}
+
+func TestEncryptUserValue(t *testing.T) {
+	key := []byte(strings.Repeat("k", dataKeySize))
+
+	value, err := encryptUserValue(key, "110101199001011234")
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(value, dataKeyCiphertextPrefix))
+	assert.True(t, len(value) <= 100)
+
+	// the same value is encrypted the same way to be looked up
+	value2, err := encryptUserValue(key, "110101199001011234")
+	assert.Nil(t, err)
+	assert.Equal(t, value, value2)
+
+	value2, err = encryptUserValue(key, value)
+	assert.Nil(t, err)
+	assert.Equal(t, value, value2)
+
+	assert.Equal(t, "110101199001011234", decryptUserValue(key, value))
+	assert.Equal(t, "plain", decryptUserValue(key, "plain"))
+	assert.Equal(t, "", decryptUserValue(nil, value))
+	assert.Equal(t, "", decryptUserValue([]byte(strings.Repeat("x", dataKeySize)), value))
+}
+
+func TestUserSensitiveFields(t *testing.T) {
+	clearDataKeyCache("")
+	defer clearDataKeyCache("")
+
+	dataKeyCache["test-data-key"] = []byte(strings.Repeat("k", dataKeySize))
+
+	user := &User{Owner: "test-data-key", Name: "alice", IdCard: "123456", Birthday: "1990-01-01", Address: []string{"Street 1", ""}}
+	assert.Nil(t, user.encryptSensitiveFields())
+	assert.True(t, strings.HasPrefix(user.IdCard, dataKeyCiphertextPrefix))
+	assert.True(t, strings.HasPrefix(user.Birthday, dataKeyCiphertextPrefix))
+	assert.True(t, strings.HasPrefix(user.Address[0], dataKeyCiphertextPrefix))
+	assert.Equal(t, "", user.Address[1])
+
+	lookupValue, err := getEncryptedUserColumnValue("test-data-key", "id_card", "123456")
+	assert.Nil(t, err)
+	assert.Equal(t, user.IdCard, lookupValue)
+
+	lookupValue, err = getEncryptedUserColumnValue("test-data-key", "email", "alice@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, "alice@example.com", lookupValue)
+
+	assert.Nil(t, user.decryptSensitiveFields())
+	assert.Equal(t, "123456", user.IdCard)
+	assert.Equal(t, "1990-01-01", user.Birthday)
+	assert.Equal(t, []string{"Street 1", ""}, user.Address)
+
+	// the values encrypted by a shredded key are cleared
+	assert.Nil(t, user.encryptSensitiveFields())
+	dataKeyCache["test-data-key"] = nil
+	assert.Nil(t, user.decryptSensitiveFields())
+	assert.Equal(t, "", user.IdCard)
+	assert.Equal(t, "", user.Birthday)
+}
+
+func TestEncryptSensitiveColumnsFailClosed(t *testing.T) {
+	setTestOrmer(t, &User{}, &DataKey{}, &Syncer{})
+	clearDataKeyCache("")
+	defer clearDataKeyCache("")
+
+	// the data key can't be unwrapped without the "dataEncryptionMasterKey" config
+	_, err := ormer.Engine.Insert(&DataKey{Owner: "admin", Name: "test-data-key", WrappedKey: "wrapped-key"})
+	assert.Nil(t, err)
+
+	_, err = AddUsers([]*User{{Owner: "test-data-key", Name: "alice", IdCard: "123456"}})
+	assert.NotNil(t, err)
+
+	count, err := ormer.Engine.Count(&User{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(0), count)
+
+	_, err = ormer.Engine.Insert(&User{Owner: "test-data-key", Name: "bob"})
+	assert.Nil(t, err)
+
+	_, err = updateUser("test-data-key/bob", &User{Owner: "test-data-key", Name: "bob", IdCard: "123456"}, []string{"id_card"})
+	assert.NotNil(t, err)
+
+	idCard := ""
+	_, err = ormer.Engine.Table(&User{}).Where("owner = ? and name = ?", "test-data-key", "bob").Cols("id_card").Get(&idCard)
+	assert.Nil(t, err)
+	assert.Equal(t, "", idCard)
+
+	// the columns other than the sensitive ones are still written
+	affected, err := updateUser("test-data-key/bob", &User{Owner: "test-data-key", Name: "bob", DisplayName: "Bob"}, []string{"display_name"})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), affected)
+}
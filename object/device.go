@@ -0,0 +1,531 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	UserTypeDevice = "device"
+
+	defaultDeviceCertExpireInDays = 365
+)
+
+// Device is the identity of a thing like an IoT gateway or a piece of equipment. It enrolls an X.509 client
+// certificate signed by the cert of the device with its one-time enrollment secret, and gets the access tokens
+// bound to the certificate with the client credentials grant over mutual TLS (RFC 8705). It can be added to the
+// roles and permissions like a user with the id "<organization>/<name>", and gets the roles of its groups.
+type Device struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	DisplayName string   `xorm:"varchar(100)" json:"displayName"`
+	Description string   `xorm:"varchar(1000)" json:"description"`
+	Type        string   `xorm:"varchar(100)" json:"type"`
+	Groups      []string `xorm:"mediumtext" json:"groups"`
+	// Application is the application that issues the access tokens of the device
+	Application string `xorm:"varchar(100)" json:"application"`
+	// Cert is the cert signing the client certificates of the device
+	Cert             string `xorm:"varchar(100)" json:"cert"`
+	CertExpireInDays int    `json:"certExpireInDays"`
+	IsEnabled        bool   `json:"isEnabled"`
+
+	ClientId         string `xorm:"varchar(100) index" json:"clientId"`
+	EnrollmentSecret string `xorm:"varchar(100)" json:"enrollmentSecret"`
+	Certificate      string `xorm:"mediumtext" json:"certificate"`
+	CertThumbprint   string `xorm:"varchar(100) index" json:"certThumbprint"`
+	CertExpireTime   string `xorm:"varchar(100)" json:"certExpireTime"`
+	EnrolledTime     string `xorm:"varchar(100)" json:"enrolledTime"`
+	LastUsedTime     string `xorm:"varchar(100)" json:"lastUsedTime"`
+}
+
+func GetDeviceCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&Device{})
+}
+
+func GetDevices(owner string) ([]*Device, error) {
+	devices := []*Device{}
+	err := ormer.Engine.Desc("created_time").Find(&devices, &Device{Owner: owner})
+	if err != nil {
+		return devices, err
+	}
+
+	return devices, nil
+}
+
+func GetPaginationDevices(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*Device, error) {
+	devices := []*Device{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&devices)
+	if err != nil {
+		return devices, err
+	}
+
+	return devices, nil
+}
+
+func getDevice(owner string, name string) (*Device, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	device := Device{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&device)
+	if err != nil {
+		return &device, err
+	}
+
+	if existed {
+		return &device, nil
+	}
+
+	return nil, nil
+}
+
+func GetDevice(id string) (*Device, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getDevice(owner, name)
+}
+
+func GetDeviceByClientId(clientId string) (*Device, error) {
+	if clientId == "" {
+		return nil, nil
+	}
+
+	device := Device{}
+	existed, err := ormer.Engine.Where("client_id = ?", clientId).Get(&device)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &device, nil
+	}
+
+	return nil, nil
+}
+
+// GetDeviceByCertThumbprint returns the device whose current client certificate has the thumbprint
+func GetDeviceByCertThumbprint(certThumbprint string) (*Device, error) {
+	if certThumbprint == "" {
+		return nil, nil
+	}
+
+	device := Device{}
+	existed, err := ormer.Engine.Where("cert_thumbprint = ?", certThumbprint).Get(&device)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &device, nil
+	}
+
+	return nil, nil
+}
+
+func checkDevice(device *Device, lang string) error {
+	// the device shares the subject namespace of the users and service accounts in the permissions
+	user, err := getUser(device.Owner, device.Name)
+	if err != nil {
+		return err
+	}
+	if user != nil {
+		return fmt.Errorf(i18n.Translate(lang, "service:The name: %s is used by a user"), device.Name)
+	}
+
+	serviceAccount, err := getServiceAccount(device.Owner, device.Name)
+	if err != nil {
+		return err
+	}
+	if serviceAccount != nil {
+		return fmt.Errorf(i18n.Translate(lang, "service:The name: %s is used by a service account"), device.Name)
+	}
+
+	for _, groupId := range device.Groups {
+		group, err := GetGroup(groupId)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			return fmt.Errorf(i18n.Translate(lang, "service:The group: %s does not exist"), groupId)
+		}
+		if group.Owner != device.Owner {
+			return fmt.Errorf(i18n.Translate(lang, "service:The group: %s should belong to the organization: %s"), groupId, device.Owner)
+		}
+	}
+
+	// the access tokens of the device are only issued by an application of its own organization
+	if device.Application != "" {
+		application, err := getApplication("admin", device.Application)
+		if err != nil {
+			return err
+		}
+		if application == nil {
+			return fmt.Errorf(i18n.Translate(lang, "auth:The application: %s does not exist"), device.Application)
+		}
+		if application.Organization != device.Owner {
+			return fmt.Errorf(i18n.Translate(lang, "service:The application: %s should belong to the organization: %s"), device.Application, device.Owner)
+		}
+	}
+
+	if device.CertExpireInDays < 0 {
+		return fmt.Errorf("the certificate expire days of the device should not be negative")
+	}
+
+	return nil
+}
+
+func UpdateDevice(id string, device *Device, lang string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if d, err := getDevice(owner, name); err != nil {
+		return false, err
+	} else if d == nil {
+		return false, nil
+	}
+
+	err := checkDevice(device, lang)
+	if err != nil {
+		return false, err
+	}
+
+	device.UpdatedTime = util.GetCurrentTime()
+
+	// the enrollment secret and the certificate are only changed by the enrollment
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Omit("client_id", "enrollment_secret", "certificate", "cert_thumbprint", "cert_expire_time", "enrolled_time", "last_used_time").Update(device)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func AddDevice(device *Device, lang string) (bool, error) {
+	err := checkDevice(device, lang)
+	if err != nil {
+		return false, err
+	}
+
+	device.ClientId = util.GenerateClientId()
+	device.EnrollmentSecret = util.GenerateClientSecret()
+	device.Certificate = ""
+	device.CertThumbprint = ""
+	device.CertExpireTime = ""
+	device.EnrolledTime = ""
+	device.UpdatedTime = util.GetCurrentTime()
+
+	affected, err := ormer.Engine.Insert(device)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func DeleteDevice(device *Device) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{device.Owner, device.Name}).Delete(&Device{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// ResetDeviceEnrollment issues a new enrollment secret for the device and revokes its certificate,
+// the device has to enroll again
+func ResetDeviceEnrollment(id string) (*Device, error) {
+	device, err := GetDevice(id)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, nil
+	}
+
+	device.EnrollmentSecret = util.GenerateClientSecret()
+	device.Certificate = ""
+	device.CertThumbprint = ""
+	device.CertExpireTime = ""
+	_, err = ormer.Engine.ID(core.PK{device.Owner, device.Name}).Cols("enrollment_secret", "certificate", "cert_thumbprint", "cert_expire_time").Update(device)
+	if err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+func (device *Device) GetId() string {
+	return fmt.Sprintf("%s/%s", device.Owner, device.Name)
+}
+
+func (device *Device) getCertExpireInDays() int {
+	if device.CertExpireInDays <= 0 {
+		return defaultDeviceCertExpireInDays
+	}
+	return device.CertExpireInDays
+}
+
+func (device *Device) isCertExpired() bool {
+	expireTime, err := time.Parse(time.RFC3339, device.CertExpireTime)
+	return err != nil || time.Now().After(expireTime)
+}
+
+// parseCertificateRequest parses the PKCS#10 certificate request in PEM, or the base64-encoded DER of the EST requests
+func parseCertificateRequest(csr string) (*x509.CertificateRequest, error) {
+	var der []byte
+	if block, _ := pem.Decode([]byte(csr)); block != nil {
+		der = block.Bytes
+	} else {
+		var err error
+		der, err = base64.StdEncoding.DecodeString(strings.Join(strings.Fields(csr), ""))
+		if err != nil {
+			return nil, fmt.Errorf("the certificate request is neither PEM nor base64-encoded DER")
+		}
+	}
+
+	request, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, err
+	}
+
+	err = request.CheckSignature()
+	if err != nil {
+		return nil, fmt.Errorf("the signature of the certificate request is invalid: %s", err.Error())
+	}
+	return request, nil
+}
+
+// getCert returns the cert signing the certificates of the device, it's always a cert of the device's organization
+func (device *Device) getCert() (*Cert, error) {
+	return getCert(device.Owner, device.Cert)
+}
+
+// issueDeviceCertificate signs the client certificate of the device for the public key of the certificate request,
+// the subject is the id of the device whatever the request asks for
+func issueDeviceCertificate(device *Device, cert *Cert, request *x509.CertificateRequest, now time.Time) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(cert.Certificate))
+	if block == nil {
+		return nil, fmt.Errorf("the certificate of the cert: %s is not in PEM format", cert.GetId())
+	}
+	parent, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := getCertSigner(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   device.GetId(),
+			Organization: []string{device.Owner},
+		},
+		NotBefore:   now.Add(-5 * time.Minute),
+		NotAfter:    now.AddDate(0, 0, device.getCertExpireInDays()),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, request.PublicKey, signer)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+func enrollDevice(device *Device, csr string, lang string) (string, error) {
+	if !device.IsEnabled {
+		return "", fmt.Errorf(i18n.Translate(lang, "service:The device: %s is disabled"), device.GetId())
+	}
+
+	request, err := parseCertificateRequest(csr)
+	if err != nil {
+		return "", err
+	}
+
+	cert, err := device.getCert()
+	if err != nil {
+		return "", err
+	}
+	if cert == nil {
+		return "", fmt.Errorf(i18n.Translate(lang, "service:The cert: %s of the device does not exist"), device.Cert)
+	}
+
+	certificate, err := issueDeviceCertificate(device, cert, request, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	certificatePem := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw}))
+	device.Certificate = certificatePem
+	device.CertThumbprint = getCertificateThumbprint(certificate)
+	device.CertExpireTime = certificate.NotAfter.UTC().Format(time.RFC3339)
+	device.EnrolledTime = util.GetCurrentTime()
+	// the enrollment secret is used only once
+	device.EnrollmentSecret = ""
+	_, err = ormer.Engine.ID(core.PK{device.Owner, device.Name}).Cols("enrollment_secret", "certificate", "cert_thumbprint", "cert_expire_time", "enrolled_time").Update(device)
+	if err != nil {
+		return "", err
+	}
+
+	return certificatePem, nil
+}
+
+// EnrollDevice issues the client certificate of the device authenticated by its one-time enrollment secret (EST simpleenroll)
+func EnrollDevice(id string, enrollmentSecret string, csr string, lang string) (string, error) {
+	device, err := GetDevice(id)
+	if err != nil {
+		return "", err
+	}
+
+	if device == nil || device.EnrollmentSecret == "" || subtle.ConstantTimeCompare([]byte(device.EnrollmentSecret), []byte(enrollmentSecret)) != 1 {
+		return "", fmt.Errorf(i18n.Translate(lang, "service:The device id or the enrollment secret is invalid"))
+	}
+
+	return enrollDevice(device, csr, lang)
+}
+
+// ReenrollDevice renews the client certificate of the device authenticated by its current certificate (EST simplereenroll)
+func ReenrollDevice(certThumbprint string, csr string, lang string) (string, error) {
+	device, err := GetDeviceByCertThumbprint(certThumbprint)
+	if err != nil {
+		return "", err
+	}
+
+	if device == nil || device.isCertExpired() {
+		return "", fmt.Errorf(i18n.Translate(lang, "service:The client certificate is not the valid certificate of a device"))
+	}
+
+	return enrollDevice(device, csr, lang)
+}
+
+// GetDeviceToken issues the access token of the device with the client credentials grant, the device is authenticated
+// by its client certificate of the mutual TLS and the token is bound to the certificate
+func GetDeviceToken(device *Device, certThumbprint string, scope string, host string) (*TokenWrapper, *TokenError, error) {
+	if !device.IsEnabled {
+		return nil, &TokenError{
+			Error:            InvalidClient,
+			ErrorDescription: "the device is disabled",
+		}, nil
+	}
+
+	if certThumbprint == "" || certThumbprint != device.CertThumbprint || device.isCertExpired() {
+		return nil, &TokenError{
+			Error:            InvalidClient,
+			ErrorDescription: "the client certificate is not the valid certificate of the device",
+		}, nil
+	}
+
+	application, err := getApplication("admin", device.Application)
+	if err != nil {
+		return nil, nil, err
+	}
+	if application == nil {
+		return nil, &TokenError{
+			Error:            InvalidClient,
+			ErrorDescription: fmt.Sprintf("the application: %s of the device does not exist", device.Application),
+		}, nil
+	}
+	if application.Organization != device.Owner {
+		return nil, &TokenError{
+			Error:            InvalidClient,
+			ErrorDescription: fmt.Sprintf("the application: %s doesn't belong to the organization of the device", device.Application),
+		}, nil
+	}
+
+	user := &User{
+		Owner:       device.Owner,
+		Name:        device.Name,
+		Id:          device.GetId(),
+		DisplayName: device.DisplayName,
+		Type:        UserTypeDevice,
+		Groups:      device.Groups,
+	}
+	err = ExtendUserWithRolesAndPermissions(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scope = getGrantedScope(application, user, scope)
+	accessToken, _, tokenName, err := generateJwtToken(application, user, "", scope, host)
+	if err != nil {
+		return nil, &TokenError{
+			Error:            EndpointError,
+			ErrorDescription: fmt.Sprintf("generate jwt token error: %s", err.Error()),
+		}, nil
+	}
+
+	token := &Token{
+		Owner:        application.Owner,
+		Name:         tokenName,
+		CreatedTime:  util.GetCurrentTime(),
+		Application:  application.Name,
+		Organization: device.Owner,
+		User:         device.Name,
+		Code:         util.GenerateClientId(),
+		AccessToken:  accessToken,
+		ExpiresIn:    application.ExpireInHours * hourSeconds,
+		Scope:        scope,
+		TokenType:    "Bearer",
+		CodeIsUsed:   true,
+	}
+	_, err = AddToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = signCertificateBoundToken(application, token, certThumbprint, token.ExpiresIn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	device.LastUsedTime = util.GetCurrentTime()
+	_, err = ormer.Engine.ID(core.PK{device.Owner, device.Name}).Cols("last_used_time").Update(device)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenWrapper{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresIn:   token.ExpiresIn,
+		Scope:       token.Scope,
+	}, nil, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueDeviceCertificate(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	// the device asks for another subject, which is ignored
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{Subject: pkix.Name{CommonName: "built-in/admin"}}, key)
+	assert.Nil(t, err)
+
+	request, err := parseCertificateRequest(string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})))
+	assert.Nil(t, err)
+	_, err = parseCertificateRequest(base64.StdEncoding.EncodeToString(der))
+	assert.Nil(t, err)
+	_, err = parseCertificateRequest("not a request")
+	assert.NotNil(t, err)
+
+	certificatePem, privateKeyPem, err := generateRsaKeys(2048, 20, "Device CA", "org")
+	assert.Nil(t, err)
+	cert := &Cert{Owner: "org", Name: "device-ca", Certificate: certificatePem, PrivateKey: privateKeyPem}
+
+	now := time.Now()
+	device := &Device{Owner: "org", Name: "gateway-1", CertExpireInDays: 30}
+	certificate, err := issueDeviceCertificate(device, cert, request, now)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "org/gateway-1", certificate.Subject.CommonName)
+	assert.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, certificate.ExtKeyUsage)
+	assert.Equal(t, now.AddDate(0, 0, 30).Unix(), certificate.NotAfter.Unix())
+	assert.Equal(t, &key.PublicKey, certificate.PublicKey)
+
+	block, _ := pem.Decode([]byte(certificatePem))
+	parent, err := x509.ParseCertificate(block.Bytes)
+	assert.Nil(t, err)
+	assert.Nil(t, parent.CheckSignature(certificate.SignatureAlgorithm, certificate.RawTBSCertificate, certificate.Signature))
+}
+
+func TestCheckDeviceOrganization(t *testing.T) {
+	setTestOrmer(t, &User{}, &ServiceAccount{}, &Group{}, &Application{}, &Organization{}, &Provider{}, &Cert{})
+
+	for _, bean := range []interface{}{
+		&Group{Owner: "org", Name: "gateways"},
+		&Group{Owner: "other", Name: "other-gateways"},
+		&Application{Owner: "admin", Name: "app-org", Organization: "org"},
+		&Application{Owner: "admin", Name: "app-other", Organization: "other"},
+		&Cert{Owner: "admin", Name: "device-ca"},
+	} {
+		_, err := ormer.Engine.Insert(bean)
+		assert.Nil(t, err)
+	}
+
+	assert.Nil(t, checkDevice(&Device{Owner: "org", Name: "gateway-1", Groups: []string{"org/gateways"}, Application: "app-org"}, "en"))
+	assert.NotNil(t, checkDevice(&Device{Owner: "org", Name: "gateway-1", Groups: []string{"other/other-gateways"}}, "en"))
+	assert.NotNil(t, checkDevice(&Device{Owner: "org", Name: "gateway-1", Application: "app-other"}, "en"))
+	assert.NotNil(t, checkDevice(&Device{Owner: "org", Name: "gateway-1", Application: "app-missing"}, "en"))
+
+	device := &Device{Owner: "org", Name: "gateway-1", IsEnabled: true, Application: "app-other", CertThumbprint: "thumbprint", CertExpireTime: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)}
+	_, tokenError, err := GetDeviceToken(device, "thumbprint", "", "")
+	assert.Nil(t, err)
+	assert.NotNil(t, tokenError)
+	assert.Equal(t, InvalidClient, tokenError.Error)
+
+	// the global cert never signs the certificates of the devices of an organization
+	cert, err := (&Device{Owner: "org", Cert: "device-ca"}).getCert()
+	assert.Nil(t, err)
+	assert.Nil(t, cert)
+}
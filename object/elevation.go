@@ -0,0 +1,174 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+)
+
+const ElevationTokenHeader = "X-Elevation-Token"
+
+// Elevation proves a fresh MFA of the user for the destructive admin operations until it expires,
+// only the hash of its token is stored
+type Elevation struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User       string `xorm:"varchar(100) index" json:"user"`
+	MfaType    string `xorm:"varchar(100)" json:"mfaType"`
+	TokenHash  string `xorm:"varchar(100) index" json:"-"`
+	ExpireTime string `xorm:"varchar(100)" json:"expireTime"`
+
+	// Token is only responded when the elevation is created
+	Token string `xorm:"-" json:"token,omitempty"`
+}
+
+// getAdminElevationTimeout returns the minutes of the "adminElevationTimeout" config, the elevation isn't required if it's 0
+func getAdminElevationTimeout() int {
+	res, err := strconv.Atoi(conf.GetConfigString("adminElevationTimeout"))
+	if err != nil || res <= 0 {
+		return 0
+	}
+	return res
+}
+
+func newElevation(user *User, mfaType string, timeout int, now time.Time) *Elevation {
+	token := util.GenerateClientSecret()
+	return &Elevation{
+		Owner:       user.Owner,
+		Name:        util.GenerateId(),
+		CreatedTime: util.GetCurrentTime(),
+		User:        user.GetId(),
+		MfaType:     mfaType,
+		TokenHash:   getTokenHash(token),
+		ExpireTime:  now.Add(time.Duration(timeout) * time.Minute).UTC().Format(time.RFC3339),
+		Token:       token,
+	}
+}
+
+func (elevation *Elevation) isValid(userId string, token string, now time.Time) bool {
+	if elevation.User != userId || subtle.ConstantTimeCompare([]byte(elevation.TokenHash), []byte(getTokenHash(token))) != 1 {
+		return false
+	}
+
+	expireTime, err := time.Parse(time.RFC3339, elevation.ExpireTime)
+	return err == nil && now.Before(expireTime)
+}
+
+func verifyElevationMfa(user *User, elevationForm *form.ElevationForm, lang string) (string, error) {
+	if !user.IsMfaEnabled() {
+		return "", fmt.Errorf(i18n.Translate(lang, "auth:Please enable the multi-factor authentication before the elevation"))
+	}
+
+	if elevationForm.RecoveryCode != "" {
+		return "recovery", MfaRecover(user, elevationForm.RecoveryCode)
+	}
+
+	mfaType := elevationForm.MfaType
+	if mfaType == "" {
+		mfaType = user.PreferredMfaType
+	}
+
+	mfaProps := user.GetMfaProps(mfaType, false)
+	mfaUtil := GetMfaUtil(mfaType, mfaProps)
+	if mfaUtil == nil || !mfaProps.Enabled {
+		return "", fmt.Errorf(i18n.Translate(lang, "auth:The multi-factor authentication type: %s is not enabled"), mfaType)
+	}
+
+	return mfaType, mfaUtil.Verify(elevationForm.Passcode)
+}
+
+// Elevate verifies the MFA of the user again and issues the token of the elevation for "adminElevationTimeout" minutes
+func Elevate(user *User, elevationForm *form.ElevationForm, lang string) (*Elevation, error) {
+	timeout := getAdminElevationTimeout()
+	if timeout == 0 {
+		return nil, fmt.Errorf(i18n.Translate(lang, "auth:The elevation is not enabled"))
+	}
+
+	mfaType, err := verifyElevationMfa(user, elevationForm, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = ormer.Engine.Where("expire_time < ?", now.UTC().Format(time.RFC3339)).Delete(&Elevation{})
+	if err != nil {
+		return nil, err
+	}
+
+	elevation := newElevation(user, mfaType, timeout, now)
+	_, err = ormer.Engine.Insert(elevation)
+	if err != nil {
+		return nil, err
+	}
+
+	return elevation, nil
+}
+
+// CheckElevation checks the elevation token of the user for a destructive admin operation,
+// nothing is checked if the "adminElevationTimeout" config isn't set
+func CheckElevation(userId string, token string, lang string) error {
+	if getAdminElevationTimeout() == 0 {
+		return nil
+	}
+
+	return checkElevationToken(userId, token, lang)
+}
+
+func getElevationRequiredError(lang string) error {
+	return NewCodedError(ErrorCodeElevationRequired, i18n.Translate(lang, "auth:The operation requires a fresh multi-factor authentication, please elevate first"))
+}
+
+func checkElevationToken(userId string, token string, lang string) error {
+	elevationErr := getElevationRequiredError(lang)
+	if token == "" {
+		return elevationErr
+	}
+
+	elevation := Elevation{TokenHash: getTokenHash(token)}
+	existed, err := ormer.Engine.Get(&elevation)
+	if err != nil {
+		return err
+	}
+	if !existed || !elevation.isValid(userId, token, time.Now()) {
+		return elevationErr
+	}
+
+	return nil
+}
+
+// CheckFreshMfa checks that the user has just verified the MFA again, by the token of an elevation or by the passcode
+// or recovery code in the form. Unlike CheckElevation, it's checked even if the "adminElevationTimeout" config isn't set
+func CheckFreshMfa(user *User, token string, elevationForm *form.ElevationForm, lang string) error {
+	if token != "" {
+		return checkElevationToken(user.GetId(), token, lang)
+	}
+
+	if elevationForm == nil || (elevationForm.Passcode == "" && elevationForm.RecoveryCode == "") {
+		return getElevationRequiredError(lang)
+	}
+
+	_, err := verifyElevationMfa(user, elevationForm, lang)
+	return err
+}
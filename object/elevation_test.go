@@ -0,0 +1,67 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casdoor/casdoor/form"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElevationIsValid(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	user := &User{Owner: "built-in", Name: "admin"}
+	elevation := newElevation(user, TotpType, 5, now)
+
+	assert.NotEmpty(t, elevation.Token)
+	assert.Equal(t, getTokenHash(elevation.Token), elevation.TokenHash)
+	assert.Equal(t, "2024-01-01T12:05:00Z", elevation.ExpireTime)
+
+	assert.True(t, elevation.isValid("built-in/admin", elevation.Token, now.Add(4*time.Minute)))
+	assert.False(t, elevation.isValid("built-in/admin", elevation.Token, now.Add(5*time.Minute)))
+	assert.False(t, elevation.isValid("built-in/alice", elevation.Token, now))
+	assert.False(t, elevation.isValid("built-in/admin", "wrong", now))
+}
+
+func TestIsProviderSecretChanged(t *testing.T) {
+	oldProvider := &Provider{ClientSecret: "secret", WebhookSecret: "whsec"}
+
+	assert.False(t, IsProviderSecretChanged(nil, &Provider{ClientSecret: "secret"}))
+	assert.False(t, IsProviderSecretChanged(oldProvider, &Provider{ClientSecret: "***", WebhookSecret: "***"}))
+	assert.False(t, IsProviderSecretChanged(oldProvider, &Provider{ClientSecret: "secret", WebhookSecret: "whsec"}))
+	assert.True(t, IsProviderSecretChanged(oldProvider, &Provider{ClientSecret: "***", WebhookSecret: "new"}))
+	assert.True(t, IsProviderSecretChanged(oldProvider, &Provider{ClientSecret: "***", WebhookSecret: "***", DkimPrivateKey: "key"}))
+}
+
+func TestCheckFreshMfa(t *testing.T) {
+	setTestOrmer(t, &Elevation{})
+
+	user := &User{Owner: "built-in", Name: "admin"}
+	elevation := newElevation(user, TotpType, 5, time.Now())
+	_, err := ormer.Engine.Insert(elevation)
+	assert.Nil(t, err)
+
+	// checked even if the elevation isn't required by the config
+	assert.NotNil(t, CheckFreshMfa(user, "", &form.ElevationForm{}, "en"))
+	assert.NotNil(t, CheckFreshMfa(user, "", nil, "en"))
+	assert.NotNil(t, CheckFreshMfa(user, "wrong", nil, "en"))
+	assert.NotNil(t, CheckFreshMfa(&User{Owner: "built-in", Name: "alice"}, elevation.Token, nil, "en"))
+	assert.Nil(t, CheckFreshMfa(user, elevation.Token, nil, "en"))
+
+	// the passcode is verified by the enabled MFA of the user
+	assert.NotNil(t, CheckFreshMfa(user, "", &form.ElevationForm{Passcode: "123456"}, "en"))
+}
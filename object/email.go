@@ -18,6 +18,7 @@ package object
 
 import (
 	"crypto/tls"
+	"fmt"
 
 	"github.com/casdoor/casdoor/email"
 	"github.com/casdoor/gomail/v2"
@@ -35,14 +36,26 @@ func getDialer(provider *Provider) *gomail.Dialer {
 	return dialer
 }
 
+func getEmailFromAddress(provider *Provider) string {
+	if provider.ClientId2 != "" {
+		return provider.ClientId2
+	}
+	return provider.ClientId
+}
+
 func SendEmail(provider *Provider, title string, content string, dest string, sender string) error {
 	emailProvider := email.GetEmailProvider(provider.Type, provider.ClientId, provider.ClientSecret, provider.Host, provider.Port, provider.DisableSsl, provider.Endpoint, provider.Method)
 
-	fromAddress := provider.ClientId2
-	if fromAddress == "" {
-		fromAddress = provider.ClientId
+	if smtpProvider, ok := emailProvider.(*email.SmtpEmailProvider); ok && provider.DkimPrivateKey != "" {
+		signer, err := email.NewDkimSigner(getDkimDomain(provider), provider.DkimSelector, provider.DkimPrivateKey)
+		if err != nil {
+			return err
+		}
+		smtpProvider.Dkim = signer
 	}
 
+	fromAddress := getEmailFromAddress(provider)
+
 	fromName := provider.ClientSecret2
 	if fromName == "" {
 		fromName = sender
@@ -63,3 +76,26 @@ func DailSmtpServer(provider *Provider) error {
 
 	return nil
 }
+
+// GetOrganizationEmailProvider returns the Email provider of the organization if any, otherwise the one of the
+// application, so that the organizations sharing an application can send emails with their own identities
+func GetOrganizationEmailProvider(organization *Organization, application *Application) (*Provider, error) {
+	organization, err := GetInheritedOrganization(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	if organization == nil || organization.EmailProvider == "" {
+		return application.GetEmailProvider()
+	}
+
+	provider, err := getOrganizationProvider(organization, organization.EmailProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider == nil || provider.Category != "Email" {
+		return nil, fmt.Errorf("the Email provider: %s of the organization: %s is not found", organization.EmailProvider, organization.Name)
+	}
+	return provider, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/proxy"
+)
+
+const (
+	EmailBounceTypeBounce    = "Bounce"
+	EmailBounceTypeComplaint = "Complaint"
+)
+
+// EmailBounce is a hard bounce or spam complaint of an email address reported by the email service
+type EmailBounce struct {
+	Email  string `json:"email"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"`
+	Bounce           *struct {
+		BounceType        string `json:"bounceType"`
+		BouncedRecipients []struct {
+			EmailAddress   string `json:"emailAddress"`
+			DiagnosticCode string `json:"diagnosticCode"`
+		} `json:"bouncedRecipients"`
+	} `json:"bounce"`
+	Complaint *struct {
+		ComplainedRecipients []struct {
+			EmailAddress string `json:"emailAddress"`
+		} `json:"complainedRecipients"`
+		ComplaintFeedbackType string `json:"complaintFeedbackType"`
+	} `json:"complaint"`
+}
+
+func parseSesNotification(notification *sesNotification) []*EmailBounce {
+	res := []*EmailBounce{}
+	if notification.Bounce != nil && notification.Bounce.BounceType == "Permanent" {
+		for _, recipient := range notification.Bounce.BouncedRecipients {
+			res = append(res, &EmailBounce{Email: recipient.EmailAddress, Type: EmailBounceTypeBounce, Reason: recipient.DiagnosticCode})
+		}
+	}
+	if notification.Complaint != nil {
+		for _, recipient := range notification.Complaint.ComplainedRecipients {
+			res = append(res, &EmailBounce{Email: recipient.EmailAddress, Type: EmailBounceTypeComplaint, Reason: notification.Complaint.ComplaintFeedbackType})
+		}
+	}
+	return res
+}
+
+// parseEmailBounces parses the bounces of the Amazon SES notifications (directly or via SNS), the SendGrid events,
+// or the generic format: {"type": "bounce", "emails": ["alice@example.com"], "reason": "..."}. The soft bounces are
+// ignored. The SubscribeURL is returned for the SNS subscription confirmation.
+func parseEmailBounces(body []byte) ([]*EmailBounce, string, error) {
+	body = []byte(strings.TrimSpace(string(body)))
+	res := []*EmailBounce{}
+
+	if strings.HasPrefix(string(body), "[") {
+		events := []struct {
+			Email  string `json:"email"`
+			Event  string `json:"event"`
+			Reason string `json:"reason"`
+		}{}
+		err := json.Unmarshal(body, &events)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, event := range events {
+			if event.Event == "bounce" {
+				res = append(res, &EmailBounce{Email: event.Email, Type: EmailBounceTypeBounce, Reason: event.Reason})
+			} else if event.Event == "spamreport" {
+				res = append(res, &EmailBounce{Email: event.Email, Type: EmailBounceTypeComplaint})
+			}
+		}
+		return res, "", nil
+	}
+
+	var message struct {
+		Type         string   `json:"Type"`
+		Message      string   `json:"Message"`
+		SubscribeURL string   `json:"SubscribeURL"`
+		GenericType  string   `json:"type"`
+		Emails       []string `json:"emails"`
+		Reason       string   `json:"reason"`
+		sesNotification
+	}
+	err := json.Unmarshal(body, &message)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch {
+	case message.Type == "SubscriptionConfirmation":
+		return res, message.SubscribeURL, nil
+	case message.Type == "Notification":
+		notification := &sesNotification{}
+		err = json.Unmarshal([]byte(message.Message), notification)
+		if err != nil {
+			return nil, "", err
+		}
+		return parseSesNotification(notification), "", nil
+	case message.NotificationType != "" || message.EventType != "":
+		return parseSesNotification(&message.sesNotification), "", nil
+	case strings.EqualFold(message.GenericType, EmailBounceTypeBounce) || strings.EqualFold(message.GenericType, EmailBounceTypeComplaint):
+		bounceType := EmailBounceTypeBounce
+		if strings.EqualFold(message.GenericType, EmailBounceTypeComplaint) {
+			bounceType = EmailBounceTypeComplaint
+		}
+		for _, address := range message.Emails {
+			res = append(res, &EmailBounce{Email: address, Type: bounceType, Reason: message.Reason})
+		}
+		return res, "", nil
+	}
+
+	return nil, "", fmt.Errorf("the bounce notification is in an unknown format")
+}
+
+// confirmSnsSubscription visits the SubscribeURL of the SNS subscription, only the AWS hosts are visited
+func confirmSnsSubscription(subscribeUrl string) error {
+	u, err := url.Parse(subscribeUrl)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || !strings.HasSuffix(u.Hostname(), ".amazonaws.com") {
+		return fmt.Errorf("the SNS subscribe URL: %s is not an AWS URL", subscribeUrl)
+	}
+
+	resp, err := proxy.DefaultHttpClient.Get(subscribeUrl)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("failed to confirm the SNS subscription with status: %s", resp.Status)
+	}
+	return nil
+}
+
+// IsEmailBounceSecretValid checks the secret of the bounce webhook of the Email provider
+func IsEmailBounceSecretValid(provider *Provider, secret string) bool {
+	return provider.BounceSecret != "" && subtle.ConstantTimeCompare([]byte(provider.BounceSecret), []byte(secret)) == 1
+}
+
+// HandleEmailBounces marks the emails reported by the bounce webhook of the provider as undeliverable for the users
+// of the provider's organization (or all the organizations for a global provider), and returns the affected users
+func HandleEmailBounces(provider *Provider, body []byte) (int64, error) {
+	bounces, subscribeUrl, err := parseEmailBounces(body)
+	if err != nil {
+		return 0, err
+	}
+
+	if subscribeUrl != "" {
+		return 0, confirmSnsSubscription(subscribeUrl)
+	}
+
+	var res int64
+	for _, bounce := range bounces {
+		if bounce.Email == "" {
+			continue
+		}
+
+		session := ormer.Engine.Where("email = ?", bounce.Email)
+		if provider.Owner != "admin" {
+			session = session.And("owner = ?", provider.Owner)
+		}
+
+		affected, err := session.Cols("email_bounce").Update(&User{EmailBounce: bounce.Type})
+		if err != nil {
+			return res, err
+		}
+		res += affected
+
+		logs.Info(fmt.Sprintf("the email: %s is marked as %s by the provider: %s, reason: %s", bounce.Email, bounce.Type, provider.GetId(), bounce.Reason))
+	}
+	return res, nil
+}
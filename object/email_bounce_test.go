@@ -0,0 +1,72 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEmailBounces(t *testing.T) {
+	ses := `{"notificationType":"Bounce","bounce":{"bounceType":"Permanent","bouncedRecipients":[{"emailAddress":"alice@example.com","diagnosticCode":"550 5.1.1 user unknown"}]}}`
+	sns, _ := json.Marshal(map[string]string{"Type": "Notification", "Message": ses})
+
+	scenarios := []struct {
+		body     string
+		expected []*EmailBounce
+	}{
+		{ses, []*EmailBounce{{Email: "alice@example.com", Type: EmailBounceTypeBounce, Reason: "550 5.1.1 user unknown"}}},
+		{string(sns), []*EmailBounce{{Email: "alice@example.com", Type: EmailBounceTypeBounce, Reason: "550 5.1.1 user unknown"}}},
+		{`{"notificationType":"Bounce","bounce":{"bounceType":"Transient","bouncedRecipients":[{"emailAddress":"alice@example.com"}]}}`, []*EmailBounce{}},
+		{`{"notificationType":"Complaint","complaint":{"complaintFeedbackType":"abuse","complainedRecipients":[{"emailAddress":"bob@example.com"}]}}`, []*EmailBounce{{Email: "bob@example.com", Type: EmailBounceTypeComplaint, Reason: "abuse"}}},
+		{`[{"email":"alice@example.com","event":"bounce","reason":"550"},{"email":"bob@example.com","event":"delivered"},{"email":"carol@example.com","event":"spamreport"}]`, []*EmailBounce{
+			{Email: "alice@example.com", Type: EmailBounceTypeBounce, Reason: "550"},
+			{Email: "carol@example.com", Type: EmailBounceTypeComplaint},
+		}},
+		{`{"type":"complaint","emails":["bob@example.com"]}`, []*EmailBounce{{Email: "bob@example.com", Type: EmailBounceTypeComplaint}}},
+	}
+
+	for _, scenario := range scenarios {
+		bounces, subscribeUrl, err := parseEmailBounces([]byte(scenario.body))
+		assert.Nil(t, err, scenario.body)
+		assert.Equal(t, "", subscribeUrl)
+		assert.Equal(t, scenario.expected, bounces, scenario.body)
+	}
+
+	_, subscribeUrl, err := parseEmailBounces([]byte(`{"Type":"SubscriptionConfirmation","SubscribeURL":"https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription", subscribeUrl)
+
+	_, _, err = parseEmailBounces([]byte(`{"foo":"bar"}`))
+	assert.NotNil(t, err)
+}
+
+func TestConfirmSnsSubscription(t *testing.T) {
+	assert.NotNil(t, confirmSnsSubscription("http://sns.us-east-1.amazonaws.com/"))
+	assert.NotNil(t, confirmSnsSubscription("https://amazonaws.com.example.com/"))
+}
+
+func TestEmailBounceSecretAndDkimDomain(t *testing.T) {
+	assert.False(t, IsEmailBounceSecretValid(&Provider{}, ""))
+	assert.False(t, IsEmailBounceSecretValid(&Provider{BounceSecret: "secret"}, "wrong"))
+	assert.True(t, IsEmailBounceSecretValid(&Provider{BounceSecret: "secret"}, "secret"))
+
+	assert.Equal(t, "example.com", getDkimDomain(&Provider{ClientId: "smtp-user@example.com"}))
+	assert.Equal(t, "mail.example.org", getDkimDomain(&Provider{ClientId: "smtp-user", ClientId2: "noreply@mail.example.org"}))
+	assert.Equal(t, "example.net", getDkimDomain(&Provider{ClientId: "noreply@example.com", DkimDomain: "example.net"}))
+	assert.Equal(t, "", getDkimDomain(&Provider{ClientId: "smtp-user"}))
+}
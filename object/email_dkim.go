@@ -0,0 +1,98 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/email"
+	"github.com/xorm-io/core"
+)
+
+// DkimRecord is the DNS TXT record to be published for the DKIM key of the Email provider
+type DkimRecord struct {
+	Domain   string `json:"domain"`
+	Selector string `json:"selector"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Value    string `json:"value"`
+}
+
+// getDkimDomain returns the DKIM domain of the provider, which is the domain of the sender address by default
+func getDkimDomain(provider *Provider) string {
+	if provider.DkimDomain != "" {
+		return provider.DkimDomain
+	}
+
+	fromAddress := getEmailFromAddress(provider)
+	if i := strings.LastIndex(fromAddress, "@"); i != -1 {
+		return fromAddress[i+1:]
+	}
+	return ""
+}
+
+func getDkimRecord(domain string, selector string, publicKey string) *DkimRecord {
+	return &DkimRecord{
+		Domain:   domain,
+		Selector: selector,
+		Name:     fmt.Sprintf("%s._domainkey.%s", selector, domain),
+		Type:     "TXT",
+		Value:    fmt.Sprintf("v=DKIM1; k=rsa; p=%s", publicKey),
+	}
+}
+
+// GetDkimRecord returns the DNS record of the current DKIM key of the provider, or nil if it has no key
+func GetDkimRecord(provider *Provider) (*DkimRecord, error) {
+	if provider.DkimPrivateKey == "" {
+		return nil, nil
+	}
+
+	publicKey, err := email.GetDkimPublicKey(provider.DkimPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return getDkimRecord(getDkimDomain(provider), provider.DkimSelector, publicKey), nil
+}
+
+// RotateDkimKey generates a new DKIM key with a new selector for the Email provider. The DNS record of the new
+// selector should be published before the emails are sent, the record of the old selector can be removed a few
+// days later when the emails signed by it have been delivered.
+func RotateDkimKey(provider *Provider) (*DkimRecord, error) {
+	if provider.Category != "Email" {
+		return nil, fmt.Errorf("the provider: %s is not an Email provider", provider.GetId())
+	}
+
+	domain := getDkimDomain(provider)
+	if domain == "" {
+		return nil, fmt.Errorf("the DKIM domain of the provider: %s should not be empty", provider.GetId())
+	}
+
+	privateKey, publicKey, err := email.GenerateDkimKey()
+	if err != nil {
+		return nil, err
+	}
+
+	provider.DkimDomain = domain
+	provider.DkimSelector = fmt.Sprintf("casdoor%s", time.Now().Format("20060102150405"))
+	provider.DkimPrivateKey = privateKey
+	_, err = ormer.Engine.ID(core.PK{provider.Owner, provider.Name}).Cols("dkim_domain", "dkim_selector", "dkim_private_key").Update(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return getDkimRecord(domain, provider.DkimSelector, publicKey), nil
+}
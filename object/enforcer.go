@@ -16,6 +16,7 @@ package object
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/casdoor/casdoor/util"
@@ -100,6 +101,11 @@ func UpdateEnforcer(id string, enforcer *Enforcer) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		publishCacheInvalidation(CacheTypeEnforcer, id)
+		publishWatchEvent(WatchTypePolicy, WatchActionUpdate, id, nil, nil)
+	}
+
 	return affected != 0, nil
 }
 
@@ -118,6 +124,11 @@ func DeleteEnforcer(enforcer *Enforcer) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		publishCacheInvalidation(CacheTypeEnforcer, enforcer.GetId())
+		publishWatchEvent(WatchTypePolicy, WatchActionDelete, enforcer.GetId(), nil, nil)
+	}
+
 	return affected != 0, nil
 }
 
@@ -202,17 +213,47 @@ func GetPolicies(id string) ([]*xormadapter.CasbinRule, error) {
 	return res, nil
 }
 
+func checkPolicyConflict(enforcer *Enforcer, ptype string, policy []string) error {
+	var has bool
+	if ptype == "p" {
+		has = enforcer.HasPolicy(policy)
+	} else {
+		has = enforcer.HasGroupingPolicy(policy)
+	}
+	if has {
+		return NewCodedError(ErrorCodePolicyConflict, "the policy: %s already exists in the enforcer: %s", strings.Join(policy, ", "), enforcer.GetId())
+	}
+	return nil
+}
+
 func UpdatePolicy(id string, ptype string, oldPolicy []string, newPolicy []string) (bool, error) {
 	enforcer, err := GetInitializedEnforcer(id)
 	if err != nil {
 		return false, err
 	}
 
+	if strings.Join(oldPolicy, ",") != strings.Join(newPolicy, ",") {
+		err = checkPolicyConflict(enforcer, ptype, newPolicy)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	var affected bool
 	if ptype == "p" {
-		return enforcer.UpdatePolicy(oldPolicy, newPolicy)
+		affected, err = enforcer.UpdatePolicy(oldPolicy, newPolicy)
 	} else {
-		return enforcer.UpdateGroupingPolicy(oldPolicy, newPolicy)
+		affected, err = enforcer.UpdateGroupingPolicy(oldPolicy, newPolicy)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if affected {
+		publishPolicyWatchEvent(id, WatchActionUpdate, ptype, newPolicy)
 	}
+
+	return affected, nil
 }
 
 func AddPolicy(id string, ptype string, policy []string) (bool, error) {
@@ -221,11 +262,26 @@ func AddPolicy(id string, ptype string, policy []string) (bool, error) {
 		return false, err
 	}
 
+	err = checkPolicyConflict(enforcer, ptype, policy)
+	if err != nil {
+		return false, err
+	}
+
+	var affected bool
 	if ptype == "p" {
-		return enforcer.AddPolicy(policy)
+		affected, err = enforcer.AddPolicy(policy)
 	} else {
-		return enforcer.AddGroupingPolicy(policy)
+		affected, err = enforcer.AddGroupingPolicy(policy)
 	}
+	if err != nil {
+		return false, err
+	}
+
+	if affected {
+		publishPolicyWatchEvent(id, WatchActionAdd, ptype, policy)
+	}
+
+	return affected, nil
 }
 
 func RemovePolicy(id string, ptype string, policy []string) (bool, error) {
@@ -234,11 +290,21 @@ func RemovePolicy(id string, ptype string, policy []string) (bool, error) {
 		return false, err
 	}
 
+	var affected bool
 	if ptype == "p" {
-		return enforcer.RemovePolicy(policy)
+		affected, err = enforcer.RemovePolicy(policy)
 	} else {
-		return enforcer.RemoveGroupingPolicy(policy)
+		affected, err = enforcer.RemoveGroupingPolicy(policy)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if affected {
+		publishPolicyWatchEvent(id, WatchActionDelete, ptype, policy)
 	}
+
+	return affected, nil
 }
 
 func (enforcer *Enforcer) LoadModelCfg() error {
@@ -0,0 +1,318 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+	xormadapter "github.com/casdoor/xorm-adapter/v3"
+)
+
+const (
+	defaultEnforceSnapshotInterval     = 60  // in seconds
+	defaultEnforceSnapshotMaxStaleness = 300 // in seconds
+	localEnforcerSnapshotSuffix        = ".json"
+)
+
+// LocalEnforcerSnapshot is the policy snapshot of the enforcer persisted on the local disk of the instance for the
+// high-availability enforcement, the enforce APIs serve the decisions from it when the database is unreachable
+type LocalEnforcerSnapshot struct {
+	Enforcer  string                    `json:"enforcer"`
+	SavedTime string                    `json:"savedTime"`
+	ModelText string                    `json:"modelText"`
+	Policies  []*xormadapter.CasbinRule `json:"policies"`
+}
+
+type localSnapshotEnforcer struct {
+	savedTime string
+	enforcer  *casbin.Enforcer
+}
+
+type rememberedEnforceClient struct {
+	application  *Application
+	verifiedTime time.Time
+}
+
+var (
+	localSnapshotEnforcers     = map[string]*localSnapshotEnforcer{}
+	localSnapshotEnforcerMutex sync.Mutex
+
+	rememberedEnforceClients     = map[string]*rememberedEnforceClient{}
+	rememberedEnforceClientMutex sync.Mutex
+)
+
+// IsHaEnforcementEnabled returns whether the "enforceSnapshotDir" config is set for the high-availability enforcement
+func IsHaEnforcementEnabled() bool {
+	return getEnforceSnapshotDir() != ""
+}
+
+func getEnforceSnapshotDir() string {
+	return conf.GetConfigString("enforceSnapshotDir")
+}
+
+func getEnforceSnapshotMaxStaleness() time.Duration {
+	return time.Duration(getRecordWriterConfigInt("enforceSnapshotMaxStaleness", defaultEnforceSnapshotMaxStaleness)) * time.Second
+}
+
+func getLocalEnforcerSnapshotPath(enforcerId string) string {
+	return filepath.Join(getEnforceSnapshotDir(), url.PathEscape(enforcerId)+localEnforcerSnapshotSuffix)
+}
+
+func (snapshot *LocalEnforcerSnapshot) getAge(now time.Time) time.Duration {
+	savedTime, err := time.Parse(time.RFC3339, snapshot.SavedTime)
+	if err != nil {
+		return time.Duration(1<<63 - 1)
+	}
+	return now.Sub(savedTime)
+}
+
+func newLocalEnforcerSnapshot(enforcer *Enforcer, now time.Time) (*LocalEnforcerSnapshot, error) {
+	m, err := GetModel(enforcer.Model)
+	if err != nil {
+		return nil, err
+	} else if m == nil {
+		return nil, fmt.Errorf("the model: %s for enforcer: %s is not found", enforcer.Model, enforcer.GetId())
+	}
+
+	policies, err := getEnforcerStoredPolicies(enforcer.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	return &LocalEnforcerSnapshot{
+		Enforcer:  enforcer.GetId(),
+		SavedTime: now.UTC().Format(time.RFC3339),
+		ModelText: m.ModelText,
+		Policies:  policies,
+	}, nil
+}
+
+// writeLocalEnforcerSnapshot replaces the snapshot file by renaming, so the readers never see a partial snapshot
+func writeLocalEnforcerSnapshot(snapshot *LocalEnforcerSnapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	path := getLocalEnforcerSnapshotPath(snapshot.Enforcer)
+	tempPath := path + ".tmp"
+	err = os.WriteFile(tempPath, data, 0o600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tempPath, path)
+}
+
+func readLocalEnforcerSnapshot(enforcerId string) (*LocalEnforcerSnapshot, error) {
+	data, err := os.ReadFile(getLocalEnforcerSnapshotPath(enforcerId))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	snapshot := &LocalEnforcerSnapshot{}
+	err = json.Unmarshal(data, snapshot)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// saveLocalEnforcerSnapshots persists the snapshots of all the enforcers and removes the ones of the deleted enforcers,
+// the existing snapshots are kept if the database is unreachable
+func saveLocalEnforcerSnapshots(now time.Time) error {
+	enforcers := []*Enforcer{}
+	err := ormer.Engine.Find(&enforcers)
+	if err != nil {
+		return err
+	}
+
+	dir := getEnforceSnapshotDir()
+	err = os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return err
+	}
+
+	savedPaths := map[string]bool{}
+	for _, enforcer := range enforcers {
+		snapshot, err := newLocalEnforcerSnapshot(enforcer, now)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to get the policy snapshot of the enforcer: %s, error: %s", enforcer.GetId(), err.Error()))
+			continue
+		}
+
+		err = writeLocalEnforcerSnapshot(snapshot)
+		if err != nil {
+			return err
+		}
+		savedPaths[getLocalEnforcerSnapshotPath(snapshot.Enforcer)] = true
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+localEnforcerSnapshotSuffix))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if savedPaths[path] {
+			continue
+		}
+
+		err = os.Remove(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newSnapshotEnforcer(snapshot *LocalEnforcerSnapshot) (*casbin.Enforcer, error) {
+	m, err := model.NewModelFromString(snapshot.ModelText)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, policy := range snapshot.Policies {
+		if policy.Ptype == "" {
+			continue
+		}
+
+		err = persist.LoadPolicyArray(append([]string{policy.Ptype}, util.CasbinToSlice(*policy)...), m)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	enforcer, err := casbin.NewEnforcer(m)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enforcer.BuildRoleLinks()
+	if err != nil {
+		return nil, err
+	}
+	return enforcer, nil
+}
+
+// getLocalSnapshotEnforcer returns the Casbin enforcer built from the local policy snapshot of the enforcer when the
+// database is unreachable, the snapshot older than the "enforceSnapshotMaxStaleness" config (in seconds) isn't used.
+// The dbErr is returned if the decisions can't be served from the snapshot.
+func getLocalSnapshotEnforcer(enforcerId string, dbErr error) (*casbin.Enforcer, string, error) {
+	if !IsHaEnforcementEnabled() || ormer.Engine.Ping() == nil {
+		return nil, "", dbErr
+	}
+
+	snapshot, err := readLocalEnforcerSnapshot(enforcerId)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to read the local policy snapshot of the enforcer: %s, error: %s", enforcerId, err.Error()))
+		return nil, "", dbErr
+	}
+	if snapshot == nil || snapshot.getAge(time.Now()) > getEnforceSnapshotMaxStaleness() {
+		return nil, "", dbErr
+	}
+
+	localSnapshotEnforcerMutex.Lock()
+	defer localSnapshotEnforcerMutex.Unlock()
+
+	cached := localSnapshotEnforcers[enforcerId]
+	if cached == nil || cached.savedTime != snapshot.SavedTime {
+		enforcer, err := newSnapshotEnforcer(snapshot)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to build the enforcer: %s from the local policy snapshot, error: %s", enforcerId, err.Error()))
+			return nil, "", dbErr
+		}
+
+		cached = &localSnapshotEnforcer{savedTime: snapshot.SavedTime, enforcer: enforcer}
+		localSnapshotEnforcers[enforcerId] = cached
+	}
+
+	return cached.enforcer, cached.savedTime, nil
+}
+
+// GetServingEnforcer returns the initialized Casbin enforcer, or the one of the local policy snapshot with its saved time
+// if the database is unreachable
+func GetServingEnforcer(enforcerId string) (*casbin.Enforcer, string, error) {
+	enforcer, err := GetInitializedEnforcer(enforcerId)
+	if err != nil {
+		return getLocalSnapshotEnforcer(enforcerId, err)
+	}
+	return enforcer.Enforcer, "", nil
+}
+
+// RememberEnforceClient keeps the client verified by the database in memory, so the enforce APIs can authenticate it
+// when the database is unreachable
+func RememberEnforceClient(application *Application) {
+	if !IsHaEnforcementEnabled() {
+		return
+	}
+
+	rememberedEnforceClientMutex.Lock()
+	defer rememberedEnforceClientMutex.Unlock()
+
+	rememberedEnforceClients[application.ClientId] = &rememberedEnforceClient{
+		application:  application,
+		verifiedTime: time.Now(),
+	}
+}
+
+// GetRememberedEnforceClient returns the application of the client remembered within the max staleness when the
+// database is unreachable, the dbErr is returned otherwise. The client secret is still checked by the caller.
+func GetRememberedEnforceClient(clientId string, dbErr error) (*Application, error) {
+	if !IsHaEnforcementEnabled() || ormer.Engine.Ping() == nil {
+		return nil, dbErr
+	}
+
+	rememberedEnforceClientMutex.Lock()
+	defer rememberedEnforceClientMutex.Unlock()
+
+	client := rememberedEnforceClients[clientId]
+	if client == nil || time.Since(client.verifiedTime) > getEnforceSnapshotMaxStaleness() {
+		return nil, dbErr
+	}
+	return client.application, nil
+}
+
+// RunEnforceSnapshotJob persists the local policy snapshots every "enforceSnapshotInterval" seconds if the
+// high-availability enforcement is enabled
+func RunEnforceSnapshotJob() {
+	if !IsHaEnforcementEnabled() {
+		return
+	}
+
+	for {
+		err := saveLocalEnforcerSnapshots(time.Now())
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to save the local policy snapshots, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Duration(getRecordWriterConfigInt("enforceSnapshotInterval", defaultEnforceSnapshotInterval)) * time.Second)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	xormadapter "github.com/casdoor/xorm-adapter/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSnapshotModelText = `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && r.obj == p.obj && r.act == p.act`
+
+func TestLocalEnforcerSnapshot(t *testing.T) {
+	dir, err := os.MkdirTemp("", "enforce-snapshot")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv("enforceSnapshotDir", dir)
+	defer os.Unsetenv("enforceSnapshotDir")
+
+	now := time.Now()
+	snapshot := &LocalEnforcerSnapshot{
+		Enforcer:  "built-in/enforcer",
+		SavedTime: now.UTC().Format(time.RFC3339),
+		ModelText: testSnapshotModelText,
+		Policies: []*xormadapter.CasbinRule{
+			{Ptype: "p", V0: "admin", V1: "data1", V2: "read"},
+			{Ptype: "g", V0: "alice", V1: "admin"},
+		},
+	}
+	assert.Nil(t, writeLocalEnforcerSnapshot(snapshot))
+
+	snapshot, err = readLocalEnforcerSnapshot("built-in/enforcer")
+	assert.Nil(t, err)
+	assert.NotNil(t, snapshot)
+	assert.True(t, snapshot.getAge(now.Add(time.Minute)) < time.Minute+time.Second)
+
+	missing, err := readLocalEnforcerSnapshot("built-in/missing")
+	assert.Nil(t, err)
+	assert.Nil(t, missing)
+
+	enforcer, err := newSnapshotEnforcer(snapshot)
+	assert.Nil(t, err)
+
+	allowed, err := enforcer.Enforce("alice", "data1", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = enforcer.Enforce("bob", "data1", "read")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+}
@@ -0,0 +1,406 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casdoor/casdoor/util"
+	xormadapter "github.com/casdoor/xorm-adapter/v3"
+	"github.com/xorm-io/core"
+)
+
+type EnforcerSnapshot struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	Description string `xorm:"varchar(100)" json:"description"`
+
+	Enforcer    string `xorm:"varchar(100) index" json:"enforcer"`
+	Version     int    `json:"version"`
+	PolicyCount int    `json:"policyCount"`
+
+	// Data is the gzip compressed and base64 encoded JSON of the policies
+	Data string `xorm:"mediumtext" json:"-"`
+}
+
+type PolicyDiff struct {
+	Added   []*xormadapter.CasbinRule `json:"added"`
+	Removed []*xormadapter.CasbinRule `json:"removed"`
+}
+
+func GetEnforcerSnapshots(owner string, enforcerId string) ([]*EnforcerSnapshot, error) {
+	snapshots := []*EnforcerSnapshot{}
+	err := ormer.Engine.Desc("version").Omit("data").Find(&snapshots, &EnforcerSnapshot{Owner: owner, Enforcer: enforcerId})
+	if err != nil {
+		return snapshots, err
+	}
+
+	return snapshots, nil
+}
+
+func getEnforcerSnapshot(owner string, name string) (*EnforcerSnapshot, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	snapshot := EnforcerSnapshot{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&snapshot)
+	if err != nil {
+		return &snapshot, err
+	}
+
+	if existed {
+		return &snapshot, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetEnforcerSnapshot(id string) (*EnforcerSnapshot, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getEnforcerSnapshot(owner, name)
+}
+
+func DeleteEnforcerSnapshot(snapshot *EnforcerSnapshot) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{snapshot.Owner, snapshot.Name}).Delete(&EnforcerSnapshot{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func (snapshot *EnforcerSnapshot) GetId() string {
+	return fmt.Sprintf("%s/%s", snapshot.Owner, snapshot.Name)
+}
+
+func compressPolicies(policies []*xormadapter.CasbinRule) (string, error) {
+	data, err := json.Marshal(policies)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	_, err = writer.Write(data)
+	if err != nil {
+		return "", err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+func decompressPolicies(s string) ([]*xormadapter.CasbinRule, error) {
+	compressed, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []*xormadapter.CasbinRule{}
+	err = json.Unmarshal(data, &policies)
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func (snapshot *EnforcerSnapshot) GetPolicies() ([]*xormadapter.CasbinRule, error) {
+	return decompressPolicies(snapshot.Data)
+}
+
+// getModelPtypes returns the policy types (p, p2, g, g2...) defined by the model text
+func getModelPtypes(modelText string) ([]string, error) {
+	casbinModel, err := model.NewModelFromString(modelText)
+	if err != nil {
+		return nil, err
+	}
+
+	ptypes := []string{}
+	for _, sec := range []string{"p", "g"} {
+		for ptype := range casbinModel[sec] {
+			ptypes = append(ptypes, ptype)
+		}
+	}
+	sort.Strings(ptypes)
+	return ptypes, nil
+}
+
+func filterPoliciesByPtypes(policies []*xormadapter.CasbinRule, ptypes []string) []*xormadapter.CasbinRule {
+	res := []*xormadapter.CasbinRule{}
+	for _, policy := range policies {
+		if util.InSlice(ptypes, policy.Ptype) {
+			res = append(res, policy)
+		}
+	}
+	return res
+}
+
+// getEnforcerPolicyAdapter returns the adapter of the enforcer and the policy types of its model, the rows
+// of the other types in the adapter table don't belong to the enforcer
+func getEnforcerPolicyAdapter(enforcerId string) (*Adapter, []string, error) {
+	enforcer, err := GetEnforcer(enforcerId)
+	if err != nil {
+		return nil, nil, err
+	} else if enforcer == nil {
+		return nil, nil, fmt.Errorf("the enforcer: %s is not found", enforcerId)
+	}
+
+	m, err := GetModel(enforcer.Model)
+	if err != nil {
+		return nil, nil, err
+	} else if m == nil {
+		return nil, nil, fmt.Errorf("the model: %s for enforcer: %s is not found", enforcer.Model, enforcerId)
+	}
+
+	ptypes, err := getModelPtypes(m.ModelText)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	adapter, err := GetAdapter(enforcer.Adapter)
+	if err != nil {
+		return nil, nil, err
+	} else if adapter == nil {
+		return nil, nil, fmt.Errorf("the adapter: %s for enforcer: %s is not found", enforcer.Adapter, enforcerId)
+	}
+
+	err = adapter.InitAdapter()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return adapter, ptypes, nil
+}
+
+// getEnforcerStoredPolicies reads the rows of the enforcer from the adapter table directly,
+// so that the policies of every ptype (p, p2, g, g2...) of its model are included.
+func getEnforcerStoredPolicies(enforcerId string) ([]*xormadapter.CasbinRule, error) {
+	adapter, ptypes, err := getEnforcerPolicyAdapter(enforcerId)
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []*xormadapter.CasbinRule{}
+	err = adapter.engine.Table(adapter.policyTable).In("ptype", ptypes).Find(&policies)
+	if err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func getNextEnforcerSnapshotVersion(enforcerId string) (int, error) {
+	lastSnapshot := EnforcerSnapshot{}
+	existed, err := ormer.Engine.Where("enforcer = ?", enforcerId).Desc("version").Omit("data").Get(&lastSnapshot)
+	if err != nil {
+		return 0, err
+	}
+
+	if !existed {
+		return 1, nil
+	}
+	return lastSnapshot.Version + 1, nil
+}
+
+func AddEnforcerSnapshot(snapshot *EnforcerSnapshot) (bool, error) {
+	// the snapshot is listed and rolled back under its owner, so it can only be taken of the owner's enforcer
+	enforcerOwner, _ := util.GetOwnerAndNameFromIdNoCheck(snapshot.Enforcer)
+	if enforcerOwner != snapshot.Owner {
+		return false, fmt.Errorf("the enforcer: %s doesn't belong to the owner: %s", snapshot.Enforcer, snapshot.Owner)
+	}
+
+	policies, err := getEnforcerStoredPolicies(snapshot.Enforcer)
+	if err != nil {
+		return false, err
+	}
+
+	snapshot.Data, err = compressPolicies(policies)
+	if err != nil {
+		return false, err
+	}
+
+	snapshot.Version, err = getNextEnforcerSnapshotVersion(snapshot.Enforcer)
+	if err != nil {
+		return false, err
+	}
+
+	_, enforcerName := util.GetOwnerAndNameFromId(snapshot.Enforcer)
+	snapshot.Name = fmt.Sprintf("%s-v%d", enforcerName, snapshot.Version)
+	snapshot.CreatedTime = util.GetCurrentTime()
+	snapshot.PolicyCount = len(policies)
+
+	affected, err := ormer.Engine.Insert(snapshot)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func getPolicyKey(policy *xormadapter.CasbinRule) string {
+	return strings.Join(append([]string{policy.Ptype}, util.CasbinToSlice(*policy)...), ",")
+}
+
+func getPolicyDiff(oldPolicies []*xormadapter.CasbinRule, newPolicies []*xormadapter.CasbinRule) *PolicyDiff {
+	oldMap := map[string]bool{}
+	for _, policy := range oldPolicies {
+		oldMap[getPolicyKey(policy)] = true
+	}
+
+	newMap := map[string]bool{}
+	for _, policy := range newPolicies {
+		newMap[getPolicyKey(policy)] = true
+	}
+
+	diff := &PolicyDiff{Added: []*xormadapter.CasbinRule{}, Removed: []*xormadapter.CasbinRule{}}
+	for _, policy := range newPolicies {
+		if !oldMap[getPolicyKey(policy)] {
+			diff.Added = append(diff.Added, policy)
+		}
+	}
+	for _, policy := range oldPolicies {
+		if !newMap[getPolicyKey(policy)] {
+			diff.Removed = append(diff.Removed, policy)
+		}
+	}
+
+	return diff
+}
+
+// DiffEnforcerSnapshots returns the policies added and removed from the old snapshot to the new one,
+// an empty newId means comparing the old snapshot with the current policies of the enforcer.
+func DiffEnforcerSnapshots(oldId string, newId string) (*PolicyDiff, error) {
+	oldSnapshot, err := GetEnforcerSnapshot(oldId)
+	if err != nil {
+		return nil, err
+	} else if oldSnapshot == nil {
+		return nil, fmt.Errorf("the enforcer snapshot: %s is not found", oldId)
+	}
+
+	oldPolicies, err := oldSnapshot.GetPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	var newPolicies []*xormadapter.CasbinRule
+	if newId == "" {
+		newPolicies, err = getEnforcerStoredPolicies(oldSnapshot.Enforcer)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		newSnapshot, err := GetEnforcerSnapshot(newId)
+		if err != nil {
+			return nil, err
+		} else if newSnapshot == nil || newSnapshot.Owner != oldSnapshot.Owner || newSnapshot.Enforcer != oldSnapshot.Enforcer {
+			return nil, fmt.Errorf("the enforcer snapshot: %s is not found", newId)
+		}
+
+		newPolicies, err = newSnapshot.GetPolicies()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return getPolicyDiff(oldPolicies, newPolicies), nil
+}
+
+// RollbackEnforcerSnapshot replaces the policies of the enforcer with the ones in the snapshot in a single
+// transaction, only the rows of the policy types of the enforcer's model are replaced. The current policies
+// are snapshotted first, so the rollback can be undone.
+func RollbackEnforcerSnapshot(id string) (bool, error) {
+	snapshot, err := GetEnforcerSnapshot(id)
+	if err != nil {
+		return false, err
+	} else if snapshot == nil {
+		return false, fmt.Errorf("the enforcer snapshot: %s is not found", id)
+	}
+
+	policies, err := snapshot.GetPolicies()
+	if err != nil {
+		return false, err
+	}
+
+	backup := &EnforcerSnapshot{
+		Owner:       snapshot.Owner,
+		Enforcer:    snapshot.Enforcer,
+		Description: fmt.Sprintf("Before rollback to v%d", snapshot.Version),
+	}
+	_, err = AddEnforcerSnapshot(backup)
+	if err != nil {
+		return false, err
+	}
+
+	adapter, ptypes, err := getEnforcerPolicyAdapter(snapshot.Enforcer)
+	if err != nil {
+		return false, err
+	}
+	policies = filterPoliciesByPtypes(policies, ptypes)
+
+	session := adapter.engine.NewSession()
+	defer session.Close()
+
+	err = session.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = session.Table(adapter.policyTable).In("ptype", ptypes).Delete(&xormadapter.CasbinRule{})
+	if err != nil {
+		session.Rollback()
+		return false, err
+	}
+
+	if len(policies) > 0 {
+		_, err = session.Table(adapter.policyTable).Insert(&policies)
+		if err != nil {
+			session.Rollback()
+			return false, err
+		}
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	xormadapter "github.com/casdoor/xorm-adapter/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnforcerSnapshotPolicies(t *testing.T) {
+	oldPolicies := []*xormadapter.CasbinRule{
+		{Ptype: "p", V0: "alice", V1: "data1", V2: "read"},
+		{Ptype: "p", V0: "bob", V1: "data2", V2: "write"},
+		{Ptype: "g", V0: "alice", V1: "admin"},
+	}
+	newPolicies := []*xormadapter.CasbinRule{
+		{Ptype: "p", V0: "alice", V1: "data1", V2: "read"},
+		{Ptype: "g", V0: "alice", V1: "admin"},
+		{Ptype: "g", V0: "bob", V1: "admin"},
+	}
+
+	data, err := compressPolicies(oldPolicies)
+	assert.Nil(t, err)
+
+	policies, err := decompressPolicies(data)
+	assert.Nil(t, err)
+	assert.Equal(t, oldPolicies, policies)
+
+	diff := getPolicyDiff(oldPolicies, newPolicies)
+	assert.Equal(t, []*xormadapter.CasbinRule{newPolicies[2]}, diff.Added)
+	assert.Equal(t, []*xormadapter.CasbinRule{oldPolicies[1]}, diff.Removed)
+}
+
+func TestEnforcerSnapshotPtypes(t *testing.T) {
+	modelText := `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+p2 = sub, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && r.obj == p.obj && r.act == p.act`
+
+	ptypes, err := getModelPtypes(modelText)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"g", "p", "p2"}, ptypes)
+
+	// the rows of the other types in the shared adapter table are left alone
+	policies := []*xormadapter.CasbinRule{
+		{Ptype: "p", V0: "alice", V1: "data1", V2: "read"},
+		{Ptype: "p2", V0: "alice", V1: "read"},
+		{Ptype: "g2", V0: "alice", V1: "domain1"},
+	}
+	assert.Equal(t, policies[:2], filterPoliciesByPtypes(policies, ptypes))
+}
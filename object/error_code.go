@@ -0,0 +1,139 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/casdoor/casdoor/i18n"
+)
+
+// The error codes are stable and machine-readable, unlike the messages which are translated and may be reworded
+const (
+	ErrorCodeUnknown              = "UNKNOWN_ERROR"
+	ErrorCodeUnauthorized         = "UNAUTHORIZED"
+	ErrorCodeForbidden            = "FORBIDDEN"
+	ErrorCodeSessionOutdated      = "SESSION_OUTDATED"
+	ErrorCodeAuthInvalidPassword  = "AUTH_INVALID_PASSWORD"
+	ErrorCodeAuthTooManyAttempts  = "AUTH_TOO_MANY_ATTEMPTS"
+	ErrorCodeAuthInvalidCode      = "AUTH_INVALID_CODE"
+	ErrorCodeAuthCodeExpired      = "AUTH_CODE_EXPIRED"
+	ErrorCodeAuthCodeRateLimited  = "AUTH_CODE_RATE_LIMITED"
+	ErrorCodeUserNotFound         = "USER_NOT_FOUND"
+	ErrorCodeUserForbidden        = "USER_FORBIDDEN"
+	ErrorCodeUserSuspended        = "USER_SUSPENDED"
+	ErrorCodeUserInactive         = "USER_INACTIVE"
+	ErrorCodeOrganizationNotFound = "ORGANIZATION_NOT_FOUND"
+	ErrorCodeApplicationNotFound  = "APPLICATION_NOT_FOUND"
+	ErrorCodePolicyConflict       = "POLICY_CONFLICT"
+	ErrorCodeElevationRequired    = "ELEVATION_REQUIRED"
+)
+
+// ErrorCodeItem describes an error code of the catalog, the messages of the i18n keys are responded with the code
+// even if they are not returned as a CodedError
+type ErrorCodeItem struct {
+	Code        string   `json:"code"`
+	Description string   `json:"description"`
+	Keys        []string `json:"-"`
+}
+
+var errorCatalog = []*ErrorCodeItem{
+	{Code: ErrorCodeUnknown, Description: "The error has no specific code"},
+	{Code: ErrorCodeUnauthorized, Description: "The request requires a signed-in user", Keys: []string{"general:Please login first"}},
+	{Code: ErrorCodeForbidden, Description: "The signed-in user is not allowed to do the operation", Keys: []string{"auth:Unauthorized operation"}},
+	{Code: ErrorCodeSessionOutdated, Description: "The user of the session no longer exists", Keys: []string{"check:Session outdated, please login again"}},
+	{Code: ErrorCodeAuthInvalidPassword, Description: "The password or code to sign in is incorrect", Keys: []string{"check:password or code is incorrect", "check:password or code is incorrect, you have %d remaining chances"}},
+	{Code: ErrorCodeAuthTooManyAttempts, Description: "The user entered the wrong password or code too many times and has to wait", Keys: []string{"check:You have entered the wrong password or code too many times, please wait for %d minutes and try again"}},
+	{Code: ErrorCodeAuthInvalidCode, Description: "The verification code or token is wrong, used or not sent", Keys: []string{"verification:Wrong verification code!", "verification:Code has not been sent yet!", "verification:The verification token is invalid or expired"}},
+	{Code: ErrorCodeAuthCodeExpired, Description: "The verification code expired or has no attempts left, a new code should be requested", Keys: []string{"verification:You should verify your code in %d min!", "verification:Too many wrong attempts, please request a new code"}},
+	{Code: ErrorCodeAuthCodeRateLimited, Description: "The verification code can't be sent again yet, retry after the seconds in the data"},
+	{Code: ErrorCodeUserNotFound, Description: "The user doesn't exist", Keys: []string{"general:The user: %s doesn't exist"}},
+	{Code: ErrorCodeUserForbidden, Description: "The user is forbidden to sign in", Keys: []string{"check:The user is forbidden to sign in, please contact the administrator"}},
+	{Code: ErrorCodeUserSuspended, Description: "The user is suspended", Keys: []string{"check:The user is suspended, please contact the administrator"}},
+	{Code: ErrorCodeUserInactive, Description: "The user is blocked from signing in by the lifecycle policy", Keys: []string{"check:The user is not active, please contact the administrator"}},
+	{Code: ErrorCodeOrganizationNotFound, Description: "The organization doesn't exist", Keys: []string{"general:The organization: %s does not exist"}},
+	{Code: ErrorCodeApplicationNotFound, Description: "The application doesn't exist", Keys: []string{"auth:The application: %s does not exist"}},
+	{Code: ErrorCodePolicyConflict, Description: "The policy already exists in the enforcer"},
+	{Code: ErrorCodeElevationRequired, Description: "The destructive operation requires the token of a fresh MFA from /api/elevate in the X-Elevation-Token header", Keys: []string{"auth:The operation requires a fresh multi-factor authentication, please elevate first"}},
+}
+
+// CodedError is an error of the object layer with a code of the error catalog
+type CodedError struct {
+	Code string
+	Msg  string
+}
+
+func (e *CodedError) Error() string {
+	return e.Msg
+}
+
+func NewCodedError(code string, format string, a ...interface{}) error {
+	msg := format
+	if len(a) != 0 {
+		msg = fmt.Sprintf(format, a...)
+	}
+	return &CodedError{Code: code, Msg: msg}
+}
+
+func GetErrorCatalog() []*ErrorCodeItem {
+	return errorCatalog
+}
+
+var reFormatVerb = regexp.MustCompile(`%[0-9.]*[a-z]`)
+
+// isTranslatedMsg checks whether the message is the translation of the key with any formatted values
+func isTranslatedMsg(lang string, key string, msg string) bool {
+	translated := i18n.Translate(lang, key)
+	if !strings.Contains(translated, "%") {
+		return msg == translated
+	}
+
+	parts := reFormatVerb.Split(translated, -1)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	matched, err := regexp.MatchString("^"+strings.Join(parts, ".*")+"$", msg)
+	return err == nil && matched
+}
+
+// GetErrorCodeByMsg returns the code of the catalog for the translated message in the language
+func GetErrorCodeByMsg(lang string, msg string) string {
+	for _, item := range errorCatalog {
+		for _, key := range item.Keys {
+			if isTranslatedMsg(lang, key, msg) {
+				return item.Code
+			}
+		}
+	}
+	return ErrorCodeUnknown
+}
+
+// GetErrorCode returns the code of the error, which is looked up by its message if it isn't a CodedError
+func GetErrorCode(lang string, err error) string {
+	var codedErr *CodedError
+	if errors.As(err, &codedErr) {
+		return codedErr.Code
+	}
+
+	var codeErr *VerificationCodeError
+	if errors.As(err, &codeErr) && codeErr.RetryAfter > 0 {
+		return ErrorCodeAuthCodeRateLimited
+	}
+
+	return GetErrorCodeByMsg(lang, err.Error())
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetErrorCode(t *testing.T) {
+	err := NewCodedError(ErrorCodePolicyConflict, "the policy: %s already exists", "alice, data1, read")
+	assert.Equal(t, "the policy: alice, data1, read already exists", err.Error())
+	assert.Equal(t, ErrorCodePolicyConflict, GetErrorCode("en", err))
+	assert.Equal(t, ErrorCodePolicyConflict, GetErrorCode("en", fmt.Errorf("failed to add: %w", err)))
+
+	assert.Equal(t, ErrorCodeAuthCodeRateLimited, GetErrorCode("en", &VerificationCodeError{Msg: "you can only send one code in 60s", RetryAfter: 30}))
+	assert.Equal(t, ErrorCodeUnknown, GetErrorCode("en", fmt.Errorf("database is locked")))
+}
+
+func TestGetErrorCodeByMsg(t *testing.T) {
+	for _, lang := range []string{"en", "zh"} {
+		msg := i18n.Translate(lang, "check:The user is suspended, please contact the administrator")
+		assert.Equal(t, ErrorCodeUserSuspended, GetErrorCodeByMsg(lang, msg))
+
+		// the formatted values are not compared
+		msg = fmt.Sprintf(i18n.Translate(lang, "check:password or code is incorrect, you have %d remaining chances"), 3)
+		assert.Equal(t, ErrorCodeAuthInvalidPassword, GetErrorCodeByMsg(lang, msg))
+
+		msg = fmt.Sprintf(i18n.Translate(lang, "general:The user: %s doesn't exist"), "org-1/alice")
+		assert.Equal(t, ErrorCodeUserNotFound, GetErrorCodeByMsg(lang, msg))
+	}
+
+	assert.Equal(t, ErrorCodeUnknown, GetErrorCodeByMsg("en", "The user: org-1/alice is not an admin"))
+}
+
+func TestErrorCatalog(t *testing.T) {
+	codes := map[string]bool{}
+	for _, item := range GetErrorCatalog() {
+		assert.False(t, codes[item.Code], item.Code)
+		assert.NotEmpty(t, item.Description)
+		codes[item.Code] = true
+	}
+}
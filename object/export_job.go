@@ -0,0 +1,575 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
+)
+
+const (
+	ExportJobCategoryUser       = "User"
+	ExportJobCategoryRecord     = "Record"
+	ExportJobCategoryPermission = "Permission"
+
+	ExportJobStatePending   = "Pending"
+	ExportJobStateRunning   = "Running"
+	ExportJobStateCompleted = "Completed"
+	ExportJobStateFailed    = "Failed"
+
+	exportJobBatchSize = 1000
+	// a running job is resumed by another worker after the lock timeout if its worker crashed
+	exportJobLockTimeout = 5 * time.Minute
+)
+
+// ExportJob exports the objects of the organization as a CSV file in the background, so the large exports
+// don't time out. The rows are written to a local file batch by batch with the progress saved, a crashed or
+// failed job resumes from the last saved batch. The file is uploaded to the storage provider as a resource
+// when it is done, and the "complete-export-job" record triggers the webhooks.
+type ExportJob struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User     string `xorm:"varchar(100)" json:"user"`
+	Category string `xorm:"varchar(100)" json:"category"`
+	Provider string `xorm:"varchar(100)" json:"provider"`
+
+	State     string `xorm:"varchar(100) index" json:"state"`
+	Total     int    `json:"total"`
+	Processed int    `json:"processed"`
+	FileSize  int64  `json:"fileSize"`
+	LockTime  string `xorm:"varchar(100) index" json:"lockTime"`
+	Error     string `xorm:"varchar(1000)" json:"error"`
+
+	Resource     string `xorm:"varchar(180)" json:"resource"`
+	Url          string `xorm:"varchar(255)" json:"url"`
+	FinishedTime string `xorm:"varchar(100)" json:"finishedTime"`
+}
+
+var exportWorkerWakeup = make(chan struct{}, 1)
+
+func GetExportJobCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&ExportJob{})
+}
+
+func GetPaginationExportJobs(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*ExportJob, error) {
+	exportJobs := []*ExportJob{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&exportJobs)
+	if err != nil {
+		return exportJobs, err
+	}
+
+	return exportJobs, nil
+}
+
+func getExportJob(owner string, name string) (*ExportJob, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	exportJob := ExportJob{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&exportJob)
+	if err != nil {
+		return &exportJob, err
+	}
+
+	if existed {
+		return &exportJob, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetExportJob(id string) (*ExportJob, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getExportJob(owner, name)
+}
+
+func (exportJob *ExportJob) GetId() string {
+	return fmt.Sprintf("%s/%s", exportJob.Owner, exportJob.Name)
+}
+
+func checkExportJob(exportJob *ExportJob) error {
+	switch exportJob.Category {
+	case ExportJobCategoryUser, ExportJobCategoryRecord, ExportJobCategoryPermission:
+	default:
+		return fmt.Errorf("the category: %s of the export job is not supported", exportJob.Category)
+	}
+
+	provider, err := GetProvider(util.GetId("admin", exportJob.Provider))
+	if err != nil {
+		return err
+	}
+	if provider == nil || provider.Category != "Storage" {
+		return fmt.Errorf("the storage provider: %s of the export job does not exist", exportJob.Provider)
+	}
+	return nil
+}
+
+// AddExportJob queues the export job, the worker runs it in the background
+func AddExportJob(exportJob *ExportJob) (bool, error) {
+	err := checkExportJob(exportJob)
+	if err != nil {
+		return false, err
+	}
+
+	exportJob.Name = util.GenerateId()
+	exportJob.CreatedTime = util.GetCurrentTime()
+	exportJob.State = ExportJobStatePending
+	exportJob.Total = 0
+	exportJob.Processed = 0
+	exportJob.FileSize = 0
+	exportJob.LockTime = ""
+	exportJob.Error = ""
+
+	affected, err := ormer.Engine.Insert(exportJob)
+	if err != nil {
+		return false, err
+	}
+
+	wakeExportWorker()
+	return affected != 0, nil
+}
+
+// RetryExportJob resumes the failed export job from its last saved batch
+func RetryExportJob(exportJob *ExportJob) (bool, error) {
+	if exportJob.State != ExportJobStateFailed {
+		return false, fmt.Errorf("only the failed export jobs can be retried")
+	}
+
+	exportJob.State = ExportJobStatePending
+	exportJob.LockTime = ""
+	exportJob.Error = ""
+	affected, err := ormer.Engine.ID(core.PK{exportJob.Owner, exportJob.Name}).Cols("state", "lock_time", "error").Update(exportJob)
+	if err != nil {
+		return false, err
+	}
+
+	wakeExportWorker()
+	return affected != 0, nil
+}
+
+// DeleteExportJob deletes the export job and its unfinished file, the exported resource is kept
+func DeleteExportJob(exportJob *ExportJob) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{exportJob.Owner, exportJob.Name}).Delete(&ExportJob{})
+	if err != nil {
+		return false, err
+	}
+
+	err = os.Remove(getExportJobPath(exportJob))
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return affected != 0, nil
+}
+
+func wakeExportWorker() {
+	select {
+	case exportWorkerWakeup <- struct{}{}:
+	default:
+	}
+}
+
+func getExportJobTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func getExportJobPath(exportJob *ExportJob) string {
+	return filepath.Join(os.TempDir(), "casdoor-exports", fmt.Sprintf("%s-%s.csv", exportJob.Owner, exportJob.Name))
+}
+
+// openExportJobFile opens the file of the job to append the next batch, the rows written after the last saved
+// batch are discarded. The job restarts from the beginning if the file is lost, like when another instance resumes it.
+func openExportJobFile(exportJob *ExportJob) (*os.File, error) {
+	path := getExportJobPath(exportJob)
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if info.Size() < exportJob.FileSize {
+		exportJob.Processed = 0
+		exportJob.FileSize = 0
+	}
+
+	err = file.Truncate(exportJob.FileSize)
+	if err == nil {
+		_, err = file.Seek(exportJob.FileSize, io.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func getExportJobHeader(category string) []string {
+	switch category {
+	case ExportJobCategoryUser:
+		return []string{"owner", "name", "createdTime", "displayName", "email", "phone", "countryCode", "type", "groups", "isAdmin", "isForbidden", "isDeleted", "signupApplication"}
+	case ExportJobCategoryRecord:
+		return GetRecordsCsv(nil)[0]
+	default:
+		return []string{"owner", "name", "createdTime", "displayName", "users", "groups", "roles", "domains", "model", "resourceType", "resources", "actions", "effect", "isEnabled", "state"}
+	}
+}
+
+func getUserExportRow(user *User) []string {
+	return []string{
+		user.Owner,
+		user.Name,
+		user.CreatedTime,
+		user.DisplayName,
+		user.Email,
+		user.Phone,
+		user.CountryCode,
+		user.Type,
+		strings.Join(user.Groups, ";"),
+		strconv.FormatBool(user.IsAdmin),
+		strconv.FormatBool(user.IsForbidden),
+		strconv.FormatBool(user.IsDeleted),
+		user.SignupApplication,
+	}
+}
+
+func getPermissionExportRow(permission *Permission) []string {
+	return []string{
+		permission.Owner,
+		permission.Name,
+		permission.CreatedTime,
+		permission.DisplayName,
+		strings.Join(permission.Users, ";"),
+		strings.Join(permission.Groups, ";"),
+		strings.Join(permission.Roles, ";"),
+		strings.Join(permission.Domains, ";"),
+		permission.Model,
+		permission.ResourceType,
+		strings.Join(permission.Resources, ";"),
+		strings.Join(permission.Actions, ";"),
+		permission.Effect,
+		strconv.FormatBool(permission.IsEnabled),
+		permission.State,
+	}
+}
+
+func getOrganizationRecords(owner string) ([]*casvisorsdk.Record, error) {
+	if casvisorsdk.GetClient() == nil {
+		return nil, fmt.Errorf("the records are not available because Casvisor is not configured")
+	}
+
+	records, err := casvisorsdk.GetRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*casvisorsdk.Record{}
+	for _, record := range records {
+		if record.Organization == owner {
+			res = append(res, record)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Id < res[j].Id
+	})
+	return res, nil
+}
+
+// getExportJobRows returns the rows of the batch from the offset and the total count of the objects
+func getExportJobRows(exportJob *ExportJob, offset int) ([][]string, int, error) {
+	res := [][]string{}
+	switch exportJob.Category {
+	case ExportJobCategoryUser:
+		total, err := ormer.Engine.Count(&User{Owner: exportJob.Owner})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		users := []*User{}
+		err = ormer.Engine.Asc("name").Limit(exportJobBatchSize, offset).Find(&users, &User{Owner: exportJob.Owner})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, user := range users {
+			res = append(res, getUserExportRow(user))
+		}
+		return res, int(total), nil
+	case ExportJobCategoryPermission:
+		total, err := ormer.Engine.Count(&Permission{Owner: exportJob.Owner})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		permissions := []*Permission{}
+		err = ormer.Engine.Asc("name").Limit(exportJobBatchSize, offset).Find(&permissions, &Permission{Owner: exportJob.Owner})
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, permission := range permissions {
+			res = append(res, getPermissionExportRow(permission))
+		}
+		return res, int(total), nil
+	default:
+		records, err := getOrganizationRecords(exportJob.Owner)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if offset < len(records) {
+			end := offset + exportJobBatchSize
+			if end > len(records) {
+				end = len(records)
+			}
+			res = GetRecordsCsv(records[offset:end])[1:]
+		}
+		return res, len(records), nil
+	}
+}
+
+// writeExportJobBatch appends the next batch of the rows to the file and returns whether the export is done
+func writeExportJobBatch(exportJob *ExportJob, file *os.File) (bool, error) {
+	rows, total, err := getExportJobRows(exportJob, exportJob.Processed)
+	if err != nil {
+		return false, err
+	}
+
+	writer := csv.NewWriter(file)
+	if exportJob.Processed == 0 {
+		err = writer.Write(getExportJobHeader(exportJob.Category))
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = writer.WriteAll(rows)
+	if err != nil {
+		return false, err
+	}
+
+	fileSize, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, err
+	}
+
+	exportJob.Total = total
+	exportJob.Processed += len(rows)
+	exportJob.FileSize = fileSize
+	return len(rows) < exportJobBatchSize, nil
+}
+
+func getExportJobRecord(exportJob *ExportJob, action string) *casvisorsdk.Record {
+	return &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: exportJob.Owner,
+		User:         exportJob.User,
+		Method:       "POST",
+		Action:       action,
+		Object:       util.StructToJson(exportJob),
+	}
+}
+
+// uploadExportJobFile uploads the finished file as a resource of the organization
+func uploadExportJobFile(exportJob *ExportJob) error {
+	provider, err := GetProvider(util.GetId("admin", exportJob.Provider))
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return fmt.Errorf("the storage provider: %s of the export job does not exist", exportJob.Provider)
+	}
+
+	content, err := os.ReadFile(getExportJobPath(exportJob))
+	if err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%s-%s.csv", strings.ToLower(exportJob.Category), exportJob.Name)
+	fullFilePath := fmt.Sprintf("/export/%s/%s", exportJob.Owner, fileName)
+	fileUrl, objectKey, err := UploadFileSafe(provider, fullFilePath, bytes.NewBuffer(content), "en")
+	if err != nil {
+		return err
+	}
+
+	_, err = AddOrUpdateResource(&Resource{
+		Owner:       exportJob.Owner,
+		Name:        objectKey,
+		CreatedTime: util.GetCurrentTime(),
+		User:        exportJob.User,
+		Provider:    provider.Name,
+		Tag:         "export",
+		FileName:    fileName,
+		FileType:    "text",
+		FileFormat:  ".csv",
+		FileSize:    len(content),
+		Url:         fileUrl,
+		Description: fmt.Sprintf("The export of the %s objects", strings.ToLower(exportJob.Category)),
+	})
+	if err != nil {
+		return err
+	}
+
+	exportJob.Resource = objectKey
+	exportJob.Url = fileUrl
+	return nil
+}
+
+func updateExportJobProgress(exportJob *ExportJob, now time.Time) error {
+	exportJob.LockTime = getExportJobTime(now.Add(exportJobLockTimeout))
+	_, err := ormer.Engine.ID(core.PK{exportJob.Owner, exportJob.Name}).Cols("total", "processed", "file_size", "lock_time").Update(exportJob)
+	return err
+}
+
+// finishExportJob saves the result of the job with the record triggering the webhooks in the same transaction
+func finishExportJob(exportJob *ExportJob, err error) error {
+	action := "complete-export-job"
+	exportJob.State = ExportJobStateCompleted
+	if err != nil {
+		action = "fail-export-job"
+		exportJob.State = ExportJobStateFailed
+		exportJob.Error = err.Error()
+	}
+	exportJob.LockTime = ""
+	exportJob.FinishedTime = util.GetCurrentTime()
+
+	_, err = runWithRecord(getExportJobRecord(exportJob, action), func(session *xorm.Session) (bool, error) {
+		affected, err := session.ID(core.PK{exportJob.Owner, exportJob.Name}).
+			Cols("state", "total", "processed", "file_size", "lock_time", "error", "resource", "url", "finished_time").Update(exportJob)
+		return affected != 0, err
+	})
+	return err
+}
+
+func runExportJob(exportJob *ExportJob) error {
+	file, err := openExportJobFile(exportJob)
+	if err != nil {
+		return err
+	}
+
+	for {
+		var isDone bool
+		isDone, err = writeExportJobBatch(exportJob, file)
+		if err != nil {
+			break
+		}
+
+		err = updateExportJobProgress(exportJob, time.Now())
+		if err != nil || isDone {
+			break
+		}
+	}
+
+	closeErr := file.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return uploadExportJobFile(exportJob)
+}
+
+// claimExportJob locks the job for the lock timeout, so only one worker of the instances runs it
+func claimExportJob(exportJob *ExportJob, now time.Time) (bool, error) {
+	lockTime := getExportJobTime(now.Add(exportJobLockTimeout))
+	affected, err := ormer.Engine.Where("owner = ? and name = ? and state = ? and lock_time = ?", exportJob.Owner, exportJob.Name, exportJob.State, exportJob.LockTime).
+		Cols("state", "lock_time").Update(&ExportJob{State: ExportJobStateRunning, LockTime: lockTime})
+	if err != nil {
+		return false, err
+	}
+
+	exportJob.State = ExportJobStateRunning
+	exportJob.LockTime = lockTime
+	return affected != 0, nil
+}
+
+func runExportJobs(now time.Time) error {
+	exportJobs := []*ExportJob{}
+	err := ormer.Engine.Where("state = ? or (state = ? and lock_time < ?)", ExportJobStatePending, ExportJobStateRunning, getExportJobTime(now)).
+		Asc("created_time").Find(&exportJobs)
+	if err != nil {
+		return err
+	}
+
+	for _, exportJob := range exportJobs {
+		claimed, err := claimExportJob(exportJob, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		err = finishExportJob(exportJob, runExportJob(exportJob))
+		if err != nil {
+			return err
+		}
+
+		if exportJob.State == ExportJobStateCompleted {
+			err = os.Remove(getExportJobPath(exportJob))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunExportWorkerJob runs the queued export jobs until the process exits
+func RunExportWorkerJob() {
+	for {
+		err := runExportJobs(time.Now())
+		if err != nil {
+			logs.Warning(fmt.Sprintf("export job failed, error: %s", err.Error()))
+		}
+
+		select {
+		case <-exportWorkerWakeup:
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenExportJobFile(t *testing.T) {
+	exportJob := &ExportJob{Owner: "org-export", Name: "job-1"}
+	defer os.Remove(getExportJobPath(exportJob))
+
+	file, err := openExportJobFile(exportJob)
+	assert.Nil(t, err)
+	_, err = file.WriteString("name\nalice\nbob\n")
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	// the rows written after the last saved batch are discarded when the job resumes
+	exportJob.Processed, exportJob.FileSize = 1, int64(len("name\nalice\n"))
+	file, err = openExportJobFile(exportJob)
+	assert.Nil(t, err)
+	_, err = file.WriteString("carol\n")
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	content, err := os.ReadFile(getExportJobPath(exportJob))
+	assert.Nil(t, err)
+	assert.Equal(t, "name\nalice\ncarol\n", string(content))
+	assert.Equal(t, 1, exportJob.Processed)
+
+	// the job restarts if the file is lost
+	assert.Nil(t, os.Remove(getExportJobPath(exportJob)))
+	exportJob.Processed, exportJob.FileSize = 2, int64(len(content))
+	file, err = openExportJobFile(exportJob)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+	assert.Equal(t, 0, exportJob.Processed)
+	assert.Equal(t, int64(0), exportJob.FileSize)
+}
+
+func TestGetExportJobRows(t *testing.T) {
+	assert.Equal(t, len(getExportJobHeader(ExportJobCategoryUser)), len(getUserExportRow(&User{Owner: "org1", Name: "alice", Groups: []string{"org1/g1", "org1/g2"}})))
+	assert.Equal(t, "org1/g1;org1/g2", getUserExportRow(&User{Groups: []string{"org1/g1", "org1/g2"}})[8])
+	assert.Equal(t, len(getExportJobHeader(ExportJobCategoryPermission)), len(getPermissionExportRow(&Permission{Actions: []string{"Read", "Write"}})))
+	assert.Equal(t, "name", getExportJobHeader(ExportJobCategoryRecord)[0])
+}
@@ -0,0 +1,182 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/proxy"
+)
+
+const (
+	PdpCombiningDenyOverrides  = "Deny-overrides"
+	PdpCombiningAllowOverrides = "Allow-overrides"
+)
+
+const externalPdpTimeout = 5 * time.Second
+
+// ExternalPdp is an external policy decision point (like OPA) that the Casbin decisions are delegated to
+type ExternalPdp struct {
+	Url                string
+	CombiningAlgorithm string
+	PermissionId       string
+	ApplicationId      string
+}
+
+// ExternalPdpInput is posted to the external PDP as {"input": ...}, which is the format of the OPA data API
+type ExternalPdpInput struct {
+	Subject     interface{}            `json:"subject"`
+	Object      interface{}            `json:"object"`
+	Action      interface{}            `json:"action"`
+	Request     []interface{}          `json:"request"`
+	Context     map[string]interface{} `json:"context"`
+	LocalResult bool                   `json:"localResult"`
+}
+
+// getExternalPdp returns the external PDP of the permission, or the one of the application if the permission
+// has none, nil is returned if no external PDP is configured
+func getExternalPdp(permission *Permission, application *Application) *ExternalPdp {
+	res := &ExternalPdp{PermissionId: permission.GetId()}
+	if application != nil {
+		res.ApplicationId = application.GetId()
+	}
+
+	if permission.ExternalPdpUrl != "" {
+		res.Url = permission.ExternalPdpUrl
+		res.CombiningAlgorithm = permission.PdpCombiningAlgorithm
+	} else if application != nil && application.ExternalPdpUrl != "" {
+		res.Url = application.ExternalPdpUrl
+		res.CombiningAlgorithm = application.PdpCombiningAlgorithm
+	} else {
+		return nil
+	}
+
+	if res.CombiningAlgorithm == "" {
+		res.CombiningAlgorithm = PdpCombiningDenyOverrides
+	}
+	return res
+}
+
+// getExternalPdpInput maps the Casbin request like [sub, obj, act] or [sub, dom, obj, act] to the PDP input
+func getExternalPdpInput(pdp *ExternalPdp, request CasbinRequest, localResult bool) *ExternalPdpInput {
+	res := &ExternalPdpInput{
+		Request: request,
+		Context: map[string]interface{}{
+			"permission":  pdp.PermissionId,
+			"application": pdp.ApplicationId,
+			"time":        time.Now().Format(time.RFC3339),
+		},
+		LocalResult: localResult,
+	}
+
+	if len(request) > 0 {
+		res.Subject = request[0]
+	}
+	if len(request) > 1 {
+		res.Action = request[len(request)-1]
+	}
+	if len(request) > 2 {
+		res.Object = request[len(request)-2]
+	}
+	if len(request) > 3 {
+		res.Context["domain"] = request[1]
+	}
+	return res
+}
+
+// parseExternalPdpResult accepts {"result": true} and {"result": {"allow": true}} of OPA, or {"allow": true}
+func parseExternalPdpResult(body []byte) (bool, error) {
+	var response map[string]interface{}
+	err := json.Unmarshal(body, &response)
+	if err != nil {
+		return false, err
+	}
+
+	value, ok := response["result"]
+	if !ok {
+		value = response
+	}
+
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case map[string]interface{}:
+		if allow, ok := v["allow"].(bool); ok {
+			return allow, nil
+		}
+	}
+	return false, fmt.Errorf("the external PDP returns an unknown result: %s", string(body))
+}
+
+func queryExternalPdp(pdp *ExternalPdp, input *ExternalPdpInput) (bool, error) {
+	data, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), externalPdpTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", pdp.Url, bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := proxy.DefaultHttpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("the external PDP request failed with status: %s, body: %s", resp.Status, string(body))
+	}
+	return parseExternalPdpResult(body)
+}
+
+// combinePdpDecisions combines the local Casbin decision with the external PDP. The external PDP is only asked
+// when it can change the result, and its failures are treated as a denial.
+func combinePdpDecisions(pdp *ExternalPdp, request CasbinRequest, localResult bool, query func(*ExternalPdp, *ExternalPdpInput) (bool, error)) bool {
+	if pdp == nil {
+		return localResult
+	}
+
+	if pdp.CombiningAlgorithm == PdpCombiningAllowOverrides && localResult {
+		return true
+	}
+	if pdp.CombiningAlgorithm != PdpCombiningAllowOverrides && !localResult {
+		return false
+	}
+
+	externalResult, err := query(pdp, getExternalPdpInput(pdp, request, localResult))
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to query the external PDP: %s for permission: %s: %s", pdp.Url, pdp.PermissionId, err.Error()))
+		return false
+	}
+	return externalResult
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/casdoor/casdoor/proxy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetExternalPdp(t *testing.T) {
+	permission := &Permission{Owner: "org1", Name: "p1"}
+	application := &Application{Owner: "admin", Name: "app1", ExternalPdpUrl: "http://app-pdp", PdpCombiningAlgorithm: PdpCombiningAllowOverrides}
+
+	assert.Nil(t, getExternalPdp(permission, nil))
+
+	pdp := getExternalPdp(permission, application)
+	assert.Equal(t, "http://app-pdp", pdp.Url)
+	assert.Equal(t, PdpCombiningAllowOverrides, pdp.CombiningAlgorithm)
+	assert.Equal(t, "admin/app1", pdp.ApplicationId)
+
+	permission.ExternalPdpUrl = "http://permission-pdp"
+	pdp = getExternalPdp(permission, application)
+	assert.Equal(t, "http://permission-pdp", pdp.Url)
+	assert.Equal(t, PdpCombiningDenyOverrides, pdp.CombiningAlgorithm)
+	assert.Equal(t, "org1/p1", pdp.PermissionId)
+}
+
+func TestGetExternalPdpInput(t *testing.T) {
+	pdp := &ExternalPdp{PermissionId: "org1/p1"}
+
+	input := getExternalPdpInput(pdp, CasbinRequest{"org1/alice", "data1", "read"}, true)
+	assert.Equal(t, "org1/alice", input.Subject)
+	assert.Equal(t, "data1", input.Object)
+	assert.Equal(t, "read", input.Action)
+	assert.Equal(t, "org1/p1", input.Context["permission"])
+	assert.True(t, input.LocalResult)
+
+	input = getExternalPdpInput(pdp, CasbinRequest{"org1/alice", "domain1", "data1", "write"}, false)
+	assert.Equal(t, "data1", input.Object)
+	assert.Equal(t, "write", input.Action)
+	assert.Equal(t, "domain1", input.Context["domain"])
+}
+
+func TestParseExternalPdpResult(t *testing.T) {
+	scenarios := []struct {
+		body     string
+		expected bool
+		isError  bool
+	}{
+		{`{"result": true}`, true, false},
+		{`{"result": false}`, false, false},
+		{`{"result": {"allow": true}}`, true, false},
+		{`{"allow": true}`, true, false},
+		{`{}`, false, true},
+		{`{"result": "yes"}`, false, true},
+		{`not json`, false, true},
+	}
+
+	for _, scenario := range scenarios {
+		res, err := parseExternalPdpResult([]byte(scenario.body))
+		assert.Equal(t, scenario.expected, res, scenario.body)
+		assert.Equal(t, scenario.isError, err != nil, scenario.body)
+	}
+}
+
+func TestCombinePdpDecisions(t *testing.T) {
+	request := CasbinRequest{"org1/alice", "data1", "read"}
+	queried := 0
+	allow := func(*ExternalPdp, *ExternalPdpInput) (bool, error) {
+		queried++
+		return true, nil
+	}
+	deny := func(*ExternalPdp, *ExternalPdpInput) (bool, error) {
+		queried++
+		return false, nil
+	}
+	fail := func(*ExternalPdp, *ExternalPdpInput) (bool, error) {
+		queried++
+		return true, fmt.Errorf("unreachable")
+	}
+
+	assert.True(t, combinePdpDecisions(nil, request, true, deny))
+
+	denyOverrides := &ExternalPdp{CombiningAlgorithm: PdpCombiningDenyOverrides}
+	assert.False(t, combinePdpDecisions(denyOverrides, request, false, allow))
+	assert.Equal(t, 0, queried)
+	assert.True(t, combinePdpDecisions(denyOverrides, request, true, allow))
+	assert.False(t, combinePdpDecisions(denyOverrides, request, true, deny))
+	assert.False(t, combinePdpDecisions(denyOverrides, request, true, fail))
+
+	queried = 0
+	allowOverrides := &ExternalPdp{CombiningAlgorithm: PdpCombiningAllowOverrides}
+	assert.True(t, combinePdpDecisions(allowOverrides, request, true, deny))
+	assert.Equal(t, 0, queried)
+	assert.True(t, combinePdpDecisions(allowOverrides, request, false, allow))
+	assert.False(t, combinePdpDecisions(allowOverrides, request, false, deny))
+	assert.False(t, combinePdpDecisions(allowOverrides, request, false, fail))
+}
+
+func TestQueryExternalPdp(t *testing.T) {
+	proxy.InitHttpClient()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input ExternalPdpInput `json:"input"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"result": {"allow": %t}}`, body.Input.Action == "read")))
+	}))
+	defer server.Close()
+
+	pdp := &ExternalPdp{Url: server.URL}
+	res, err := queryExternalPdp(pdp, getExternalPdpInput(pdp, CasbinRequest{"org1/alice", "data1", "read"}, true))
+	assert.Nil(t, err)
+	assert.True(t, res)
+
+	res, err = queryExternalPdp(pdp, getExternalPdpInput(pdp, CasbinRequest{"org1/alice", "data1", "write"}, true))
+	assert.Nil(t, err)
+	assert.False(t, res)
+}
@@ -0,0 +1,131 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+)
+
+type geoIpRange struct {
+	start       net.IP
+	end         net.IP
+	countryCode string
+}
+
+var (
+	geoIpRanges []*geoIpRange
+	geoIpOnce   sync.Once
+)
+
+// loadGeoIpDatabase loads an IP-to-country CSV database with lines like "1.0.0.0,1.0.0.255,AU",
+// e.g. the "IP to Country Lite" database of DB-IP
+func loadGeoIpDatabase(path string) ([]*geoIpRange, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ranges := []*geoIpRange{}
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	for {
+		line, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(line) < 3 {
+			continue
+		}
+
+		start := net.ParseIP(strings.TrimSpace(line[0]))
+		end := net.ParseIP(strings.TrimSpace(line[1]))
+		if start == nil || end == nil {
+			continue
+		}
+
+		ranges = append(ranges, &geoIpRange{
+			start:       start.To16(),
+			end:         end.To16(),
+			countryCode: strings.ToUpper(strings.TrimSpace(line[2])),
+		})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].start, ranges[j].start) < 0
+	})
+	return ranges, nil
+}
+
+func getGeoIpRanges() []*geoIpRange {
+	geoIpOnce.Do(func() {
+		path := conf.GetConfigString("geoIpDatabase")
+		if path == "" {
+			return
+		}
+
+		ranges, err := loadGeoIpDatabase(path)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("getGeoIpRanges() failed to load the GeoIP database: %s, error: %s", path, err.Error()))
+			return
+		}
+
+		geoIpRanges = ranges
+	})
+
+	return geoIpRanges
+}
+
+func getCountryCodeFromRanges(ranges []*geoIpRange, ip string) string {
+	parsedIp := net.ParseIP(ip)
+	if parsedIp == nil {
+		return ""
+	}
+	parsedIp = parsedIp.To16()
+
+	i := sort.Search(len(ranges), func(i int) bool {
+		return bytes.Compare(ranges[i].start, parsedIp) > 0
+	})
+	if i == 0 {
+		return ""
+	}
+
+	ipRange := ranges[i-1]
+	if bytes.Compare(parsedIp, ipRange.end) > 0 {
+		return ""
+	}
+
+	return ipRange.countryCode
+}
+
+// GetCountryCodeByIp returns the ISO 3166-1 alpha-2 country code of the IP, or "" if it is unknown
+func GetCountryCodeByIp(ip string) string {
+	return getCountryCodeFromRanges(getGeoIpRanges(), ip)
+}
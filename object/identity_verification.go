@@ -0,0 +1,275 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/casdoor/casdoor/kyc"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
+)
+
+// the states of the identity verification of the users, the empty state of the user is Unverified
+const (
+	IdentityVerificationStateUnverified = "Unverified"
+	IdentityVerificationStatePending    = "Pending"
+	IdentityVerificationStateVerified   = "Verified"
+	IdentityVerificationStateRejected   = "Rejected"
+
+	PendingIdentityVerification = "PendingIdentityVerification"
+)
+
+// identityVerificationTransitions are the valid next states of the states, a new session may be started while
+// the previous one is still pending, e.g., after its link expired
+var identityVerificationTransitions = map[string][]string{
+	IdentityVerificationStateUnverified: {IdentityVerificationStatePending},
+	IdentityVerificationStatePending:    {IdentityVerificationStatePending, IdentityVerificationStateVerified, IdentityVerificationStateRejected},
+	IdentityVerificationStateRejected:   {IdentityVerificationStatePending},
+}
+
+// IdentityVerification is a session of the ID-document verification of the user in the "Identity Verification" provider
+type IdentityVerification struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	User      string `xorm:"varchar(100) index" json:"user"`
+	Provider  string `xorm:"varchar(100)" json:"provider"`
+	SessionId string `xorm:"varchar(200) index" json:"sessionId"`
+	Url       string `xorm:"varchar(1000)" json:"url"`
+	State     string `xorm:"varchar(100)" json:"state"`
+	Reason    string `xorm:"varchar(500)" json:"reason"`
+}
+
+func (identityVerification *IdentityVerification) GetId() string {
+	return fmt.Sprintf("%s/%s", identityVerification.Owner, identityVerification.Name)
+}
+
+func GetIdentityVerificationCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&IdentityVerification{})
+}
+
+func GetPaginationIdentityVerifications(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*IdentityVerification, error) {
+	identityVerifications := []*IdentityVerification{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&identityVerifications)
+	if err != nil {
+		return identityVerifications, err
+	}
+
+	return identityVerifications, nil
+}
+
+// GetIdentityVerificationState returns the state of the identity verification of the user
+func GetIdentityVerificationState(user *User) string {
+	if user.IdentityVerificationState == "" {
+		return IdentityVerificationStateUnverified
+	}
+	return user.IdentityVerificationState
+}
+
+func IsIdentityVerificationTransitionValid(state string, nextState string) bool {
+	for _, validState := range identityVerificationTransitions[state] {
+		if validState == nextState {
+			return true
+		}
+	}
+	return false
+}
+
+func getVerificationProvider(provider *Provider) (kyc.VerificationProvider, error) {
+	verificationProvider := kyc.GetVerificationProvider(provider.Type, provider.ClientSecret, provider.AppId, provider.Endpoint)
+	if verificationProvider == nil {
+		return nil, fmt.Errorf("the identity verification provider type: %s is not supported", provider.Type)
+	}
+	return verificationProvider, nil
+}
+
+// updateIdentityVerificationState saves the states of the verification and the user in the same transaction, the record
+// of the action triggers the webhooks
+func updateIdentityVerificationState(identityVerification *IdentityVerification, user *User, action string) error {
+	identityVerification.UpdatedTime = util.GetCurrentTime()
+
+	record := &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  identityVerification.UpdatedTime,
+		Organization: user.Owner,
+		User:         user.Name,
+		Method:       "POST",
+		Action:       action,
+		Object:       util.StructToJson(identityVerification),
+	}
+
+	_, err := runWithRecord(record, func(session *xorm.Session) (bool, error) {
+		_, err := session.ID(core.PK{identityVerification.Owner, identityVerification.Name}).AllCols().Update(identityVerification)
+		if err != nil {
+			return false, err
+		}
+
+		_, err = session.ID(core.PK{user.Owner, user.Name}).Cols("identity_verification_state").Update(user)
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+	return err
+}
+
+// StartIdentityVerification creates a session of the verification in the provider of the application, the user
+// completes the verification in the hosted flow of the returned Url and the result is reported by the callback
+func StartIdentityVerification(application *Application, user *User, redirectUrl string) (*IdentityVerification, error) {
+	provider, err := application.GetProviderByCategory("Identity Verification")
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("the application: %s has no identity verification provider", application.Name)
+	}
+
+	state := GetIdentityVerificationState(user)
+	if !IsIdentityVerificationTransitionValid(state, IdentityVerificationStatePending) {
+		return nil, fmt.Errorf("the identity verification of the user: %s can't be started in the state: %s", user.GetId(), state)
+	}
+
+	verificationProvider, err := getVerificationProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	verificationSession, err := verificationProvider.CreateSession(user.GetId(), user.FirstName, user.LastName, redirectUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	identityVerification := &IdentityVerification{
+		Owner:       user.Owner,
+		Name:        util.GenerateId(),
+		CreatedTime: util.GetCurrentTime(),
+		User:        user.Name,
+		Provider:    provider.Name,
+		SessionId:   verificationSession.Id,
+		Url:         verificationSession.Url,
+		State:       IdentityVerificationStatePending,
+	}
+	_, err = ormer.Engine.Insert(identityVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	user.IdentityVerificationState = IdentityVerificationStatePending
+	err = updateIdentityVerificationState(identityVerification, user, "start-identity-verification")
+	if err != nil {
+		return nil, err
+	}
+	return identityVerification, nil
+}
+
+// applyVerificationResult moves the verification into the state of the result and returns the record action, the
+// user follows it unless the user is already verified by another session
+func applyVerificationResult(identityVerification *IdentityVerification, user *User, result *kyc.VerificationResult) string {
+	identityVerification.Reason = result.Reason
+	identityVerification.State = IdentityVerificationStateRejected
+	action := "reject-identity-verification"
+	if result.Status == kyc.VerificationStatusApproved {
+		identityVerification.State = IdentityVerificationStateVerified
+		action = "verify-identity"
+	}
+
+	if IsIdentityVerificationTransitionValid(GetIdentityVerificationState(user), identityVerification.State) {
+		user.IdentityVerificationState = identityVerification.State
+	}
+	return action
+}
+
+// NotifyIdentityVerification handles the callback of the identity verification provider with the result of a session,
+// nil is returned for the other events
+func NotifyIdentityVerification(owner string, providerName string, header http.Header, body []byte) (*IdentityVerification, error) {
+	provider, err := getProvider(owner, providerName)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil || provider.Category != "Identity Verification" {
+		return nil, fmt.Errorf("the identity verification provider: %s does not exist", providerName)
+	}
+	if provider.WebhookSecret == "" {
+		return nil, fmt.Errorf("the webhook secret of the identity verification provider: %s is empty", providerName)
+	}
+
+	verificationProvider, err := getVerificationProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := verificationProvider.ParseCallback(header, body, provider.WebhookSecret)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, nil
+	}
+
+	identityVerification := IdentityVerification{Provider: provider.Name, SessionId: result.SessionId}
+	existed, err := ormer.Engine.Get(&identityVerification)
+	if err != nil {
+		return nil, err
+	}
+	if !existed {
+		return nil, fmt.Errorf("the identity verification of the provider: %s with the session id: %s does not exist", providerName, result.SessionId)
+	}
+
+	// the providers may deliver a callback more than once
+	if identityVerification.State != IdentityVerificationStatePending {
+		return &identityVerification, nil
+	}
+
+	user, err := getUser(identityVerification.Owner, identityVerification.User)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("the user: %s doesn't exist", util.GetId(identityVerification.Owner, identityVerification.User))
+	}
+
+	action := applyVerificationResult(&identityVerification, user, result)
+	err = updateIdentityVerificationState(&identityVerification, user, action)
+	if err != nil {
+		return nil, err
+	}
+	return &identityVerification, nil
+}
+
+// IsIdentityVerificationRequired returns whether the user needs to verify the identity to sign in to the application
+func IsIdentityVerificationRequired(application *Application, user *User) bool {
+	return application.RequireIdentityVerification && GetIdentityVerificationState(user) != IdentityVerificationStateVerified
+}
+
+// checkTokenIdentityVerification denies the token until the identity of the user is verified
+func checkTokenIdentityVerification(application *Application, user *User) *TokenError {
+	if !IsIdentityVerificationRequired(application, user) {
+		return nil
+	}
+
+	return &TokenError{
+		Error:            InvalidGrant,
+		ErrorDescription: fmt.Sprintf("the identity of the user is %s, the verification is required by the application", strings.ToLower(GetIdentityVerificationState(user))),
+	}
+}
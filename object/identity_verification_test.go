@@ -0,0 +1,103 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/casdoor/casdoor/kyc"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestHmacSignature(secret string, data string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(data))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestIdentityVerificationTransitions(t *testing.T) {
+	assert.Equal(t, IdentityVerificationStateUnverified, GetIdentityVerificationState(&User{}))
+
+	assert.True(t, IsIdentityVerificationTransitionValid(IdentityVerificationStateUnverified, IdentityVerificationStatePending))
+	assert.True(t, IsIdentityVerificationTransitionValid(IdentityVerificationStatePending, IdentityVerificationStateVerified))
+	assert.True(t, IsIdentityVerificationTransitionValid(IdentityVerificationStateRejected, IdentityVerificationStatePending))
+	assert.False(t, IsIdentityVerificationTransitionValid(IdentityVerificationStateUnverified, IdentityVerificationStateVerified))
+	assert.False(t, IsIdentityVerificationTransitionValid(IdentityVerificationStateVerified, IdentityVerificationStatePending))
+	assert.False(t, IsIdentityVerificationTransitionValid(IdentityVerificationStateVerified, IdentityVerificationStateRejected))
+}
+
+func TestApplyVerificationResult(t *testing.T) {
+	user := &User{IdentityVerificationState: IdentityVerificationStatePending}
+	identityVerification := &IdentityVerification{State: IdentityVerificationStatePending}
+	action := applyVerificationResult(identityVerification, user, &kyc.VerificationResult{Status: kyc.VerificationStatusDeclined, Reason: "the inquiry is declined"})
+	assert.Equal(t, "reject-identity-verification", action)
+	assert.Equal(t, IdentityVerificationStateRejected, identityVerification.State)
+	assert.Equal(t, IdentityVerificationStateRejected, user.IdentityVerificationState)
+
+	// a late rejection of another session doesn't revoke the verified user
+	user = &User{IdentityVerificationState: IdentityVerificationStateVerified}
+	identityVerification = &IdentityVerification{State: IdentityVerificationStatePending}
+	applyVerificationResult(identityVerification, user, &kyc.VerificationResult{Status: kyc.VerificationStatusDeclined})
+	assert.Equal(t, IdentityVerificationStateRejected, identityVerification.State)
+	assert.Equal(t, IdentityVerificationStateVerified, user.IdentityVerificationState)
+
+	user = &User{IdentityVerificationState: IdentityVerificationStatePending}
+	action = applyVerificationResult(identityVerification, user, &kyc.VerificationResult{Status: kyc.VerificationStatusApproved})
+	assert.Equal(t, "verify-identity", action)
+	assert.Equal(t, IdentityVerificationStateVerified, user.IdentityVerificationState)
+}
+
+func TestParseVerificationCallback(t *testing.T) {
+	secret := "webhook-secret"
+
+	body := `{"data":{"attributes":{"name":"inquiry.approved","payload":{"data":{"id":"inq_123","attributes":{"reference-id":"org/alice"}}}}}}`
+	header := http.Header{}
+	header.Set("Persona-Signature", "t=1700000000,v1="+getTestHmacSignature(secret, "1700000000."+body))
+	result, err := kyc.NewPersonaVerificationProvider("token", "itmpl_1", "").ParseCallback(header, []byte(body), secret)
+	assert.Nil(t, err)
+	assert.Equal(t, "inq_123", result.SessionId)
+	assert.Equal(t, "org/alice", result.ReferenceId)
+	assert.Equal(t, kyc.VerificationStatusApproved, result.Status)
+
+	_, err = kyc.NewPersonaVerificationProvider("token", "itmpl_1", "").ParseCallback(header, []byte(body), "wrong-secret")
+	assert.NotNil(t, err)
+
+	body = `{"payload":{"resource_type":"workflow_run","action":"workflow_run.completed","object":{"id":"run_1","status":"declined"}}}`
+	header = http.Header{}
+	header.Set("X-SHA2-Signature", getTestHmacSignature(secret, body))
+	result, err = kyc.NewOnfidoVerificationProvider("token", "workflow", "").ParseCallback(header, []byte(body), secret)
+	assert.Nil(t, err)
+	assert.Equal(t, "run_1", result.SessionId)
+	assert.Equal(t, kyc.VerificationStatusDeclined, result.Status)
+
+	// the other events are ignored
+	body = `{"payload":{"resource_type":"check","action":"check.completed","object":{"id":"chk_1","status":"complete"}}}`
+	header.Set("X-SHA2-Signature", getTestHmacSignature(secret, body))
+	result, err = kyc.NewOnfidoVerificationProvider("token", "workflow", "").ParseCallback(header, []byte(body), secret)
+	assert.Nil(t, err)
+	assert.Nil(t, result)
+}
+
+func TestCheckTokenIdentityVerification(t *testing.T) {
+	application := &Application{RequireIdentityVerification: true}
+	assert.NotNil(t, checkTokenIdentityVerification(application, &User{}))
+	assert.NotNil(t, checkTokenIdentityVerification(application, &User{IdentityVerificationState: IdentityVerificationStatePending}))
+	assert.Nil(t, checkTokenIdentityVerification(application, &User{IdentityVerificationState: IdentityVerificationStateVerified}))
+	assert.Nil(t, checkTokenIdentityVerification(&Application{}, &User{}))
+}
@@ -0,0 +1,210 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	LegalDocumentTermsOfService = "Terms of Service"
+	LegalDocumentPrivacyPolicy  = "Privacy Policy"
+
+	PendingLegalDocuments = "PendingLegalDocuments"
+)
+
+// LegalDocument is a versioned document of the application that its users accept, a new version makes
+// the users accept it again before the next token is issued
+type LegalDocument struct {
+	Type    string `json:"type"`
+	Version string `json:"version"`
+	Url     string `json:"url"`
+}
+
+// LegalAcceptance records that the user accepted the version of the legal document of the application
+type LegalAcceptance struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User         string `xorm:"varchar(100) index" json:"user"`
+	Application  string `xorm:"varchar(100) index" json:"application"`
+	DocumentType string `xorm:"varchar(100)" json:"documentType"`
+	Version      string `xorm:"varchar(100)" json:"version"`
+	ClientIp     string `xorm:"varchar(100)" json:"clientIp"`
+}
+
+type LegalAcceptanceReportItem struct {
+	Type     string `json:"type"`
+	Version  string `json:"version"`
+	Url      string `json:"url"`
+	Accepted int64  `json:"accepted"`
+	Users    int64  `json:"users"`
+}
+
+func checkLegalDocuments(documents []*LegalDocument) error {
+	types := map[string]bool{}
+	for _, document := range documents {
+		if document.Type != LegalDocumentTermsOfService && document.Type != LegalDocumentPrivacyPolicy {
+			return fmt.Errorf("the legal document type: %s is not supported", document.Type)
+		}
+		if types[document.Type] {
+			return fmt.Errorf("the legal document: %s is duplicated", document.Type)
+		}
+		if document.Version == "" || document.Url == "" {
+			return fmt.Errorf("the legal document: %s should have the version and the URL", document.Type)
+		}
+		types[document.Type] = true
+	}
+	return nil
+}
+
+func GetLegalAcceptanceCount(owner, application, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&LegalAcceptance{Application: application})
+}
+
+func GetPaginationLegalAcceptances(owner, application string, offset, limit int, field, value, sortField, sortOrder string) ([]*LegalAcceptance, error) {
+	legalAcceptances := []*LegalAcceptance{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&legalAcceptances, &LegalAcceptance{Application: application})
+	if err != nil {
+		return legalAcceptances, err
+	}
+
+	return legalAcceptances, nil
+}
+
+func getUserLegalAcceptances(application *Application, user *User) ([]*LegalAcceptance, error) {
+	legalAcceptances := []*LegalAcceptance{}
+	err := ormer.Engine.Find(&legalAcceptances, &LegalAcceptance{Owner: user.Owner, User: user.Name, Application: application.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	return legalAcceptances, nil
+}
+
+func getPendingLegalDocuments(documents []*LegalDocument, legalAcceptances []*LegalAcceptance) []*LegalDocument {
+	res := []*LegalDocument{}
+	for _, document := range documents {
+		isAccepted := false
+		for _, legalAcceptance := range legalAcceptances {
+			if legalAcceptance.DocumentType == document.Type && legalAcceptance.Version == document.Version {
+				isAccepted = true
+				break
+			}
+		}
+
+		if !isAccepted {
+			res = append(res, document)
+		}
+	}
+	return res
+}
+
+// GetPendingLegalDocuments returns the current versions of the legal documents of the application that the user hasn't accepted
+func GetPendingLegalDocuments(application *Application, user *User) ([]*LegalDocument, error) {
+	if len(application.LegalDocuments) == 0 || user.IsTransient() {
+		return []*LegalDocument{}, nil
+	}
+
+	legalAcceptances, err := getUserLegalAcceptances(application, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return getPendingLegalDocuments(application.LegalDocuments, legalAcceptances), nil
+}
+
+// AcceptLegalDocuments records the acceptances of the pending documents whose versions the user accepted,
+// the accepted versions are keyed by the document types. It returns the documents that are still pending.
+func AcceptLegalDocuments(application *Application, user *User, accepted map[string]string, clientIp string) ([]*LegalDocument, error) {
+	pendingDocuments, err := GetPendingLegalDocuments(application, user)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*LegalDocument{}
+	legalAcceptances := []*LegalAcceptance{}
+	for _, document := range pendingDocuments {
+		if accepted[document.Type] != document.Version {
+			res = append(res, document)
+			continue
+		}
+
+		legalAcceptances = append(legalAcceptances, &LegalAcceptance{
+			Owner:        user.Owner,
+			Name:         util.GenerateId(),
+			CreatedTime:  util.GetCurrentTime(),
+			User:         user.Name,
+			Application:  application.Name,
+			DocumentType: document.Type,
+			Version:      document.Version,
+			ClientIp:     clientIp,
+		})
+	}
+
+	if len(legalAcceptances) > 0 {
+		_, err = ormer.Engine.Insert(legalAcceptances)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// checkTokenLegalDocuments denies the token until the user accepts the new versions of the legal documents by signing in
+func checkTokenLegalDocuments(application *Application, user *User) (*TokenError, error) {
+	pendingDocuments, err := GetPendingLegalDocuments(application, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pendingDocuments) > 0 {
+		return &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: fmt.Sprintf("the user needs to sign in to accept the version: %s of the %s", pendingDocuments[0].Version, pendingDocuments[0].Type),
+		}, nil
+	}
+	return nil, nil
+}
+
+// GetLegalAcceptanceReport counts the users of the organization of the application who accepted the current versions of its legal documents
+func GetLegalAcceptanceReport(application *Application) ([]*LegalAcceptanceReportItem, error) {
+	users, err := ormer.Engine.Count(&User{Owner: application.Organization})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*LegalAcceptanceReportItem{}
+	for _, document := range application.LegalDocuments {
+		accepted, err := ormer.Engine.Count(&LegalAcceptance{Owner: application.Organization, Application: application.Name, DocumentType: document.Type, Version: document.Version})
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, &LegalAcceptanceReportItem{
+			Type:     document.Type,
+			Version:  document.Version,
+			Url:      document.Url,
+			Accepted: accepted,
+			Users:    users,
+		})
+	}
+	return res, nil
+}
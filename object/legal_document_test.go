@@ -0,0 +1,52 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPendingLegalDocuments(t *testing.T) {
+	documents := []*LegalDocument{
+		{Type: LegalDocumentTermsOfService, Version: "2.0", Url: "https://example.com/terms"},
+		{Type: LegalDocumentPrivacyPolicy, Version: "1.1", Url: "https://example.com/privacy"},
+	}
+	legalAcceptances := []*LegalAcceptance{
+		{DocumentType: LegalDocumentTermsOfService, Version: "1.0"},
+		{DocumentType: LegalDocumentPrivacyPolicy, Version: "1.1"},
+	}
+
+	// the new version of the terms needs to be accepted again
+	pendingDocuments := getPendingLegalDocuments(documents, legalAcceptances)
+	assert.Equal(t, 1, len(pendingDocuments))
+	assert.Equal(t, "2.0", pendingDocuments[0].Version)
+
+	legalAcceptances = append(legalAcceptances, &LegalAcceptance{DocumentType: LegalDocumentTermsOfService, Version: "2.0"})
+	assert.Equal(t, 0, len(getPendingLegalDocuments(documents, legalAcceptances)))
+	assert.Equal(t, 2, len(getPendingLegalDocuments(documents, nil)))
+}
+
+func TestCheckLegalDocuments(t *testing.T) {
+	assert.Nil(t, checkLegalDocuments(nil))
+	assert.Nil(t, checkLegalDocuments([]*LegalDocument{{Type: LegalDocumentTermsOfService, Version: "1.0", Url: "https://example.com/terms"}}))
+	assert.NotNil(t, checkLegalDocuments([]*LegalDocument{{Type: "Cookie Policy", Version: "1.0", Url: "https://example.com/cookies"}}))
+	assert.NotNil(t, checkLegalDocuments([]*LegalDocument{{Type: LegalDocumentPrivacyPolicy, Url: "https://example.com/privacy"}}))
+	assert.NotNil(t, checkLegalDocuments([]*LegalDocument{
+		{Type: LegalDocumentPrivacyPolicy, Version: "1.0", Url: "https://example.com/privacy"},
+		{Type: LegalDocumentPrivacyPolicy, Version: "2.0", Url: "https://example.com/privacy"},
+	}))
+}
@@ -0,0 +1,311 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// the users are looked up in chunks to keep the "in" clauses small
+const membershipBatchChunkSize = 500
+
+// MembershipReport is the result of adding or removing the users of a role or group in a batch. In the transactional
+// mode nothing is applied if any user fails, otherwise the other users are applied and the failed ones are reported.
+type MembershipReport struct {
+	Succeeded int      `json:"succeeded"`
+	Unchanged int      `json:"unchanged"`
+	Failed    int      `json:"failed"`
+	Errors    []string `json:"errors"`
+	IsApplied bool     `json:"isApplied"`
+}
+
+func (report *MembershipReport) addError(userId string, msg string) {
+	report.Failed++
+	report.Errors = append(report.Errors, fmt.Sprintf("%s: %s", userId, msg))
+}
+
+// getMembershipUserIds returns the distinct user ids, the names without the organization belong to the given one
+func getMembershipUserIds(owner string, users []string) []string {
+	res := []string{}
+	visited := map[string]bool{}
+	for _, user := range users {
+		user = strings.TrimSpace(user)
+		if user == "" {
+			continue
+		}
+		if !strings.Contains(user, "/") {
+			user = util.GetId(owner, user)
+		}
+
+		if !visited[user] {
+			visited[user] = true
+			res = append(res, user)
+		}
+	}
+	return res
+}
+
+// getMembershipUsers returns the existing users of the ids by id
+func getMembershipUsers(userIds []string) (map[string]*User, error) {
+	namesByOwner := map[string][]string{}
+	for _, userId := range userIds {
+		owner, name := util.GetOwnerAndNameFromIdNoCheck(userId)
+		namesByOwner[owner] = append(namesByOwner[owner], name)
+	}
+
+	res := map[string]*User{}
+	for owner, names := range namesByOwner {
+		for i := 0; i < len(names); i += membershipBatchChunkSize {
+			end := i + membershipBatchChunkSize
+			if end > len(names) {
+				end = len(names)
+			}
+
+			users := []*User{}
+			err := ormer.Engine.Where("owner = ? and is_deleted = ?", owner, false).In("name", names[i:end]).Find(&users)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, user := range users {
+				res[user.GetId()] = user
+			}
+		}
+	}
+	return res, nil
+}
+
+// BatchUpdateRoleUsers adds or removes the users of the role, the role and its permissions are updated once for all
+// the users
+func BatchUpdateRoleUsers(id string, users []string, isAdded bool, isTransactional bool) (*MembershipReport, error) {
+	role, err := GetRole(id)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, fmt.Errorf("the role: %s does not exist", id)
+	}
+
+	report := &MembershipReport{}
+	userIds := getMembershipUserIds(role.Owner, users)
+
+	existingUsers := map[string]*User{}
+	if isAdded {
+		existingUsers, err = getMembershipUsers(userIds)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	roleUsers := map[string]bool{}
+	for _, user := range role.Users {
+		roleUsers[user] = true
+	}
+
+	for _, userId := range userIds {
+		if isAdded {
+			if existingUsers[userId] == nil {
+				report.addError(userId, "the user does not exist")
+			} else if roleUsers[userId] {
+				report.Unchanged++
+			} else {
+				roleUsers[userId] = true
+				role.Users = append(role.Users, userId)
+				report.Succeeded++
+			}
+		} else {
+			if roleUsers[userId] {
+				delete(roleUsers, userId)
+				report.Succeeded++
+			} else {
+				report.Unchanged++
+			}
+		}
+	}
+
+	if isTransactional && report.Failed != 0 {
+		report.Succeeded = 0
+		return report, nil
+	}
+	if report.Succeeded == 0 {
+		return report, nil
+	}
+
+	if !isAdded {
+		remainingUsers := []string{}
+		for _, user := range role.Users {
+			if roleUsers[user] {
+				remainingUsers = append(remainingUsers, user)
+			}
+		}
+		role.Users = remainingUsers
+	}
+
+	_, err = UpdateRole(id, role)
+	if err != nil {
+		return nil, err
+	}
+
+	report.IsApplied = true
+	return report, nil
+}
+
+// BatchUpdateGroupUsers adds or removes the users of the group, the users should belong to the organization of the
+// group. In the transactional mode the users are updated in a database transaction.
+func BatchUpdateGroupUsers(id string, users []string, isAdded bool, isTransactional bool) (*MembershipReport, error) {
+	group, err := GetGroup(id)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, fmt.Errorf("the group: %s does not exist", id)
+	}
+
+	report := &MembershipReport{}
+	userIds := getMembershipUserIds(group.Owner, users)
+	existingUsers, err := getMembershipUsers(userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	// a user belongs to one physical group at most
+	physicalGroups := []string{}
+	if isAdded && group.Type == "Physical" {
+		groups, err := GetGroups(group.Owner)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			if g.Type == "Physical" {
+				physicalGroups = append(physicalGroups, g.GetId())
+			}
+		}
+	}
+
+	changedUsers := []*User{}
+	oldGroups := map[string][]string{}
+	for _, userId := range userIds {
+		user := existingUsers[userId]
+		if user == nil {
+			if isAdded {
+				report.addError(userId, "the user does not exist")
+			} else {
+				report.Unchanged++
+			}
+			continue
+		}
+		if user.Owner != group.Owner {
+			report.addError(userId, fmt.Sprintf("the user doesn't belong to the organization: %s", group.Owner))
+			continue
+		}
+
+		isMember := util.InSlice(user.Groups, id)
+		if isMember == isAdded {
+			report.Unchanged++
+			continue
+		}
+
+		if physicalGroup := getUserPhysicalGroup(user, physicalGroups); physicalGroup != "" {
+			report.addError(userId, fmt.Sprintf("the user already belongs to the physical group: %s", physicalGroup))
+			continue
+		}
+
+		oldGroups[userId] = user.Groups
+		if isAdded {
+			user.Groups = append(append([]string{}, user.Groups...), id)
+		} else {
+			user.Groups = util.DeleteVal(user.Groups, id)
+		}
+		changedUsers = append(changedUsers, user)
+	}
+
+	if len(changedUsers) == 0 || (isTransactional && report.Failed != 0) {
+		return report, nil
+	}
+
+	if isTransactional {
+		err = updateGroupUsersInTransaction(changedUsers)
+		if err != nil {
+			return nil, err
+		}
+		report.Succeeded = len(changedUsers)
+	} else {
+		succeededUsers := []*User{}
+		for _, user := range changedUsers {
+			_, err = updateUser(user.GetId(), user, []string{"groups"})
+			if err != nil {
+				report.addError(user.GetId(), err.Error())
+				continue
+			}
+			succeededUsers = append(succeededUsers, user)
+		}
+		changedUsers = succeededUsers
+		report.Succeeded = len(changedUsers)
+	}
+
+	for _, user := range changedUsers {
+		err = syncUserGroups(user.GetId(), oldGroups[user.GetId()], user.Groups)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the group is pushed once after its members
+	util.SafeGoroutine(func() {
+		for i, user := range changedUsers {
+			var groupIds []string
+			if i == len(changedUsers)-1 {
+				groupIds = []string{id}
+			}
+			provisionUserChange(user.Owner, user.Name, groupIds)
+		}
+	})
+
+	report.IsApplied = report.Succeeded != 0
+	return report, nil
+}
+
+func getUserPhysicalGroup(user *User, physicalGroups []string) string {
+	for _, group := range user.Groups {
+		if util.InSlice(physicalGroups, group) {
+			return group
+		}
+	}
+	return ""
+}
+
+func updateGroupUsersInTransaction(users []*User) error {
+	session := ormer.Engine.NewSession()
+	defer session.Close()
+
+	err := session.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		_, err = session.ID(core.PK{user.Owner, user.Name}).Cols("groups").Update(user)
+		if err != nil {
+			_ = session.Rollback()
+			return err
+		}
+	}
+
+	return session.Commit()
+}
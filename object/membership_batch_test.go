@@ -0,0 +1,43 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMembershipUserIds(t *testing.T) {
+	userIds := getMembershipUserIds("org1", []string{"alice", "org1/alice", " bob ", "", "org2/carol", "org2/carol"})
+	assert.Equal(t, []string{"org1/alice", "org1/bob", "org2/carol"}, userIds)
+}
+
+func TestMembershipReport(t *testing.T) {
+	report := &MembershipReport{}
+	report.addError("org1/alice", "the user does not exist")
+	report.addError("org1/bob", "the user does not exist")
+
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, []string{"org1/alice: the user does not exist", "org1/bob: the user does not exist"}, report.Errors)
+}
+
+func TestGetUserPhysicalGroup(t *testing.T) {
+	physicalGroups := []string{"org1/engineering", "org1/sales"}
+
+	assert.Equal(t, "org1/sales", getUserPhysicalGroup(&User{Groups: []string{"org1/admins", "org1/sales"}}, physicalGroups))
+	assert.Equal(t, "", getUserPhysicalGroup(&User{Groups: []string{"org1/admins"}}, physicalGroups))
+	assert.Equal(t, "", getUserPhysicalGroup(&User{Groups: []string{"org1/sales"}}, nil))
+}
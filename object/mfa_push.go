@@ -0,0 +1,328 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/context"
+	"github.com/casdoor/casdoor/util"
+	"github.com/google/uuid"
+)
+
+const (
+	MfaPushDeviceSession   = "mfa_push_device"
+	MfaPushProviderSession = "mfa_push_provider"
+	MfaPushCodeSession     = "mfa_push_code"
+
+	PushChallengeStatusPending  = "Pending"
+	PushChallengeStatusApproved = "Approved"
+	PushChallengeStatusDenied   = "Denied"
+
+	pushChallengeTimeout = 2 * time.Minute
+)
+
+// PushChallenge is a sign-in waiting for the approval on the user's enrolled device. The number is shown
+// on the sign-in page only, and the user has to enter it in the mobile app to approve, so that a push
+// that the user didn't trigger can't be approved by a single tap (MFA fatigue).
+type PushChallenge struct {
+	Id          string    `json:"id"`
+	UserId      string    `json:"userId"`
+	Application string    `json:"application"`
+	ClientIp    string    `json:"clientIp"`
+	Number      int       `json:"number,omitempty"`
+	Status      string    `json:"status"`
+	CreatedTime time.Time `json:"createdTime"`
+
+	deviceToken string
+}
+
+var (
+	pushChallenges     = map[string]*PushChallenge{}
+	pushChallengeMutex sync.Mutex
+)
+
+type PushMfa struct {
+	Config *MfaProps
+}
+
+func getPushProvider(user *User, providerId string) (*Provider, error) {
+	provider, err := GetProvider(providerId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = checkPushProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+	if provider.Owner != "admin" && provider.Owner != user.Owner {
+		return nil, fmt.Errorf("the push provider: %s is not available to the user", providerId)
+	}
+	return provider, nil
+}
+
+// Initiate enrolls the device of the "pushDeviceToken" form value with the "pushProvider" form value,
+// a code is pushed to the device to be entered in SetupVerify to prove that the device receives the pushes
+func (mfa *PushMfa) Initiate(ctx *context.Context, userId string) (*MfaProps, error) {
+	deviceToken := ctx.Request.Form.Get("pushDeviceToken")
+	providerId := ctx.Request.Form.Get("pushProvider")
+	if deviceToken == "" || providerId == "" {
+		return nil, errors.New("push device token or push provider is missing")
+	}
+
+	user, err := GetUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("the user: %s doesn't exist", userId)
+	}
+
+	provider, err := getPushProvider(user, providerId)
+	if err != nil {
+		return nil, err
+	}
+
+	code := getRandomCode(6)
+	err = SendPushNotification(provider, deviceToken, &PushNotification{
+		Title: "Casdoor",
+		Body:  fmt.Sprintf("Your code to set up push MFA is %s", code),
+		Data:  map[string]string{"type": "mfa-setup", "user": userId},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCode := uuid.NewString()
+	for key, value := range map[string]interface{}{
+		MfaPushDeviceSession:    deviceToken,
+		MfaPushProviderSession:  providerId,
+		MfaPushCodeSession:      code,
+		MfaRecoveryCodesSession: []string{recoveryCode},
+	} {
+		err = ctx.Input.CruSession.Set(key, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mfaProps := MfaProps{
+		MfaType:       mfa.Config.MfaType,
+		RecoveryCodes: []string{recoveryCode},
+	}
+	return &mfaProps, nil
+}
+
+func (mfa *PushMfa) SetupVerify(ctx *context.Context, passcode string) error {
+	code := ctx.Input.CruSession.Get(MfaPushCodeSession)
+	if code == nil {
+		return errors.New("push code is missing")
+	}
+
+	if passcode != code.(string) {
+		return errors.New("push code error")
+	}
+	return nil
+}
+
+func (mfa *PushMfa) Enable(ctx *context.Context, user *User) error {
+	recoveryCodes, _ := ctx.Input.CruSession.Get(MfaRecoveryCodesSession).([]string)
+	if len(recoveryCodes) == 0 {
+		return fmt.Errorf("recovery codes is missing")
+	}
+	deviceToken, _ := ctx.Input.CruSession.Get(MfaPushDeviceSession).(string)
+	providerId, _ := ctx.Input.CruSession.Get(MfaPushProviderSession).(string)
+	if deviceToken == "" || providerId == "" {
+		return fmt.Errorf("push device is missing")
+	}
+
+	user.RecoveryCodes = append(user.RecoveryCodes, recoveryCodes...)
+	user.MfaPushEnabled = true
+	user.MfaPushDevice = deviceToken
+	user.MfaPushProvider = providerId
+	if user.PreferredMfaType == "" {
+		user.PreferredMfaType = mfa.Config.MfaType
+	}
+
+	_, err := updateUser(user.GetId(), user, []string{"recovery_codes", "preferred_mfa_type", "mfa_push_enabled", "mfa_push_device", "mfa_push_provider"})
+	if err != nil {
+		return err
+	}
+
+	ctx.Input.CruSession.Delete(MfaRecoveryCodesSession)
+	ctx.Input.CruSession.Delete(MfaPushDeviceSession)
+	ctx.Input.CruSession.Delete(MfaPushProviderSession)
+	ctx.Input.CruSession.Delete(MfaPushCodeSession)
+
+	return nil
+}
+
+// Verify checks that the push challenge of the passcode (the challenge ID) has been approved on the
+// enrolled device of the config, the approved challenge is consumed
+func (mfa *PushMfa) Verify(passcode string) error {
+	pushChallengeMutex.Lock()
+	defer pushChallengeMutex.Unlock()
+
+	challenge := getPushChallenge(passcode)
+	if challenge == nil || challenge.deviceToken != mfa.Config.Secret {
+		return errors.New("push challenge not found or expired")
+	}
+
+	switch challenge.Status {
+	case PushChallengeStatusApproved:
+		delete(pushChallenges, challenge.Id)
+		return nil
+	case PushChallengeStatusDenied:
+		delete(pushChallenges, challenge.Id)
+		return errors.New("push challenge is denied")
+	default:
+		return errors.New("push challenge is pending")
+	}
+}
+
+func NewPushMfaUtil(config *MfaProps) *PushMfa {
+	if config == nil {
+		config = &MfaProps{
+			MfaType: PushType,
+		}
+	}
+	return &PushMfa{
+		Config: config,
+	}
+}
+
+// getPushChallenge returns the challenge of the ID if not expired, the mutex should be held by the caller
+func getPushChallenge(id string) *PushChallenge {
+	now := time.Now()
+	for challengeId, challenge := range pushChallenges {
+		if now.Sub(challenge.CreatedTime) > pushChallengeTimeout {
+			delete(pushChallenges, challengeId)
+		}
+	}
+
+	return pushChallenges[id]
+}
+
+func getPushChallengeNumber() (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(90))
+	if err != nil {
+		return 0, err
+	}
+	return int(n.Int64()) + 10, nil
+}
+
+// newPushChallenge replaces the pending challenges of the user with a new one, so that only the latest
+// sign-in can be approved
+func newPushChallenge(user *User, application string, clientIp string) (*PushChallenge, error) {
+	number, err := getPushChallengeNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := &PushChallenge{
+		Id:          util.GenerateId(),
+		UserId:      user.GetId(),
+		Application: application,
+		ClientIp:    clientIp,
+		Number:      number,
+		Status:      PushChallengeStatusPending,
+		CreatedTime: time.Now(),
+		deviceToken: user.MfaPushDevice,
+	}
+
+	pushChallengeMutex.Lock()
+	defer pushChallengeMutex.Unlock()
+
+	for id, c := range pushChallenges {
+		if c.UserId == challenge.UserId {
+			delete(pushChallenges, id)
+		}
+	}
+	pushChallenges[challenge.Id] = challenge
+	return challenge, nil
+}
+
+// SendPushChallenge creates a push challenge for the sign-in of the user and pushes it to the enrolled device,
+// the returned challenge has the number to be shown on the sign-in page.
+func SendPushChallenge(user *User, application string, clientIp string) (*PushChallenge, error) {
+	if !user.MfaPushEnabled || user.MfaPushDevice == "" {
+		return nil, fmt.Errorf("push MFA is not enabled for the user: %s", user.GetId())
+	}
+
+	provider, err := getPushProvider(user, user.MfaPushProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := newPushChallenge(user, application, clientIp)
+	if err != nil {
+		return nil, err
+	}
+
+	// the number is not pushed, the user has to read it from the sign-in page
+	err = SendPushNotification(provider, user.MfaPushDevice, &PushNotification{
+		Title: "Casdoor",
+		Body:  fmt.Sprintf("Are you trying to sign in to %s from %s?", application, clientIp),
+		Data: map[string]string{
+			"type":        "mfa-challenge",
+			"challengeId": challenge.Id,
+			"user":        challenge.UserId,
+			"application": application,
+			"clientIp":    clientIp,
+		},
+	})
+	if err != nil {
+		pushChallengeMutex.Lock()
+		delete(pushChallenges, challenge.Id)
+		pushChallengeMutex.Unlock()
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+// ApprovePushChallenge approves or denies the challenge of the user from the mobile app, a wrong number
+// denies the challenge so that the number can't be guessed
+func ApprovePushChallenge(userId string, challengeId string, number int, isApproved bool) error {
+	pushChallengeMutex.Lock()
+	defer pushChallengeMutex.Unlock()
+
+	challenge := getPushChallenge(challengeId)
+	if challenge == nil || challenge.UserId != userId {
+		return errors.New("push challenge not found or expired")
+	}
+	if challenge.Status != PushChallengeStatusPending {
+		return fmt.Errorf("push challenge is already %s", challenge.Status)
+	}
+
+	if !isApproved {
+		challenge.Status = PushChallengeStatusDenied
+		return nil
+	}
+
+	if number != challenge.Number {
+		challenge.Status = PushChallengeStatusDenied
+		return errors.New("the number doesn't match the sign-in page, the sign-in is denied")
+	}
+
+	challenge.Status = PushChallengeStatusApproved
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPushChallenge(t *testing.T) {
+	user := &User{Owner: "built-in", Name: "alice", MfaPushEnabled: true, MfaPushDevice: "device1"}
+	mfaUtil := NewPushMfaUtil(user.GetMfaProps(PushType, false))
+
+	challenge, err := newPushChallenge(user, "app1", "1.1.1.1")
+	assert.Nil(t, err)
+	assert.True(t, challenge.Number >= 10 && challenge.Number < 100)
+	assert.NotNil(t, mfaUtil.Verify(challenge.Id))
+	assert.NotNil(t, ApprovePushChallenge("built-in/bob", challenge.Id, challenge.Number, true))
+
+	assert.Nil(t, ApprovePushChallenge(user.GetId(), challenge.Id, challenge.Number, true))
+	assert.NotNil(t, NewPushMfaUtil(&MfaProps{MfaType: PushType, Secret: "device2"}).Verify(challenge.Id))
+	assert.Nil(t, mfaUtil.Verify(challenge.Id))
+	assert.NotNil(t, mfaUtil.Verify(challenge.Id))
+
+	// a wrong number denies the challenge
+	challenge, err = newPushChallenge(user, "app1", "1.1.1.1")
+	assert.Nil(t, err)
+	assert.NotNil(t, ApprovePushChallenge(user.GetId(), challenge.Id, challenge.Number+100, true))
+	assert.NotNil(t, ApprovePushChallenge(user.GetId(), challenge.Id, challenge.Number, true))
+	assert.NotNil(t, mfaUtil.Verify(challenge.Id))
+
+	// only the latest challenge of the user can be approved
+	oldChallenge, err := newPushChallenge(user, "app1", "1.1.1.1")
+	assert.Nil(t, err)
+	challenge, err = newPushChallenge(user, "app1", "1.1.1.1")
+	assert.Nil(t, err)
+	assert.NotNil(t, ApprovePushChallenge(user.GetId(), oldChallenge.Id, oldChallenge.Number, true))
+	assert.Nil(t, ApprovePushChallenge(user.GetId(), challenge.Id, challenge.Number, true))
+}
@@ -0,0 +1,365 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/form"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/google/uuid"
+	"github.com/xorm-io/xorm"
+)
+
+const (
+	defaultMfaBypassExpireInMinutes = 60
+	maxMfaBypassExpireInMinutes     = 24 * 60
+	mfaRecoveryCodeCount            = 10
+)
+
+// MfaChangeContent is the content of the change request of the MFA reset or bypass of a user, the reason is
+// mandatory for the audit
+type MfaChangeContent struct {
+	Reason          string `json:"reason"`
+	ExpireInMinutes int    `json:"expireInMinutes"`
+}
+
+// MfaBypass lets the locked-out user sign in without MFA once before it expires, it's issued when the change
+// request of the bypass is approved and its link is sent to the Email of the user
+type MfaBypass struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User          string `xorm:"varchar(100) index" json:"user"`
+	ChangeRequest string `xorm:"varchar(100)" json:"changeRequest"`
+	ExpireTime    string `xorm:"varchar(100)" json:"expireTime"`
+	TokenHash     string `xorm:"varchar(100) index" json:"-"`
+	UsedTime      string `xorm:"varchar(100)" json:"usedTime"`
+	UsedIp        string `xorm:"varchar(100)" json:"usedIp"`
+}
+
+func isMfaChangeRequestType(changeType string) bool {
+	return changeType == ChangeRequestTypeMfaReset || changeType == ChangeRequestTypeMfaBypass
+}
+
+func newMfaRecord(user *User, action string, clientIp string, object interface{}) *casvisorsdk.Record {
+	return &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: user.Owner,
+		User:         user.Name,
+		ClientIp:     clientIp,
+		Method:       "POST",
+		Action:       action,
+		Object:       util.StructToJson(object),
+	}
+}
+
+// SubmitMfaChangeRequest requests the reset or the bypass of the MFA of the user for the approval of another admin
+func SubmitMfaChangeRequest(changeType string, userId string, requester *User, content *MfaChangeContent, lang string) (*ChangeRequest, error) {
+	if !isMfaChangeRequestType(changeType) {
+		return nil, fmt.Errorf(i18n.Translate(lang, "general:Unknown type: %s"), changeType)
+	}
+
+	user, err := GetUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), userId)
+	}
+	if requester.GetId() == userId {
+		return nil, fmt.Errorf(i18n.Translate(lang, "auth:The MFA of your own account can't be reset or bypassed by yourself"))
+	}
+	if !user.IsMfaEnabled() {
+		return nil, fmt.Errorf(i18n.Translate(lang, "auth:The user: %s has no MFA enabled"), userId)
+	}
+
+	content.Reason = strings.TrimSpace(content.Reason)
+	if content.Reason == "" {
+		return nil, fmt.Errorf(i18n.Translate(lang, "auth:The reason of the MFA reset or bypass is required"))
+	}
+	if changeType == ChangeRequestTypeMfaBypass {
+		if content.ExpireInMinutes == 0 {
+			content.ExpireInMinutes = defaultMfaBypassExpireInMinutes
+		}
+		if content.ExpireInMinutes < 0 || content.ExpireInMinutes > maxMfaBypassExpireInMinutes {
+			return nil, fmt.Errorf(i18n.Translate(lang, "auth:The expire minutes of the MFA bypass should be between 1 and %d"), maxMfaBypassExpireInMinutes)
+		}
+		if user.Email == "" {
+			return nil, fmt.Errorf(i18n.Translate(lang, "auth:The user: %s has no Email to receive the MFA bypass link"), userId)
+		}
+		if _, err = getMfaBypassOrigin(lang); err != nil {
+			return nil, err
+		}
+	} else {
+		content.ExpireInMinutes = 0
+	}
+
+	count, err := ormer.Engine.Where("owner = ? and type = ? and target = ? and state = ?",
+		user.Owner, changeType, userId, ChangeRequestStatePending).Count(&ChangeRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf(i18n.Translate(lang, "general:There is already a pending change for the %s: %s"), changeType, userId)
+	}
+
+	changeRequest := &ChangeRequest{
+		Owner:       user.Owner,
+		Name:        util.GenerateId(),
+		CreatedTime: util.GetCurrentTime(),
+		User:        requester.GetId(),
+		Type:        changeType,
+		Target:      userId,
+		Fields:      []string{},
+		Content:     util.StructToJson(content),
+		State:       ChangeRequestStatePending,
+	}
+
+	_, err = runWithRecord(getChangeRequestRecord(changeRequest, "change-requested"), func(session *xorm.Session) (bool, error) {
+		affected, err := session.Insert(changeRequest)
+		return affected != 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changeRequest, nil
+}
+
+// applyMfaChangeRequest disables the MFA of the user for the approved reset, or issues the MFA bypass of the
+// approved bypass and sends its link to the user
+func applyMfaChangeRequest(changeRequest *ChangeRequest, lang string) error {
+	user, err := GetUser(changeRequest.Target)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), changeRequest.Target)
+	}
+
+	content := &MfaChangeContent{}
+	err = json.Unmarshal([]byte(changeRequest.Content), content)
+	if err != nil {
+		return err
+	}
+
+	if changeRequest.Type == ChangeRequestTypeMfaReset {
+		err = DisabledMultiFactorAuth(user)
+		if err != nil {
+			return err
+		}
+
+		record := newMfaRecord(user, "mfa-reset", "", changeRequest)
+		util.SafeGoroutine(func() { AddRecord(record) })
+		return nil
+	}
+
+	return issueMfaBypass(changeRequest, user, content, lang)
+}
+
+func getMfaBypassEmailContent(user *User, link string, expireTime string) string {
+	return fmt.Sprintf("The admins of your organization approved a bypass of the multi-factor authentication of your account %s. "+
+		"Open the link below before %s to sign in once without MFA, then set up MFA again:\n\n%s\n\n"+
+		"If you didn't ask for it, please contact your admin at once.", user.GetId(), expireTime, link)
+}
+
+// getMfaBypassOrigin returns the configured origin the MFA bypass links are opened on, it's the frontend origin so
+// the session of the bypass is the one of the login page. The Host of the request is never used because it can be forged
+func getMfaBypassOrigin(lang string) (string, error) {
+	origin := conf.GetConfigString("originFrontend")
+	if origin == "" {
+		origin = conf.GetConfigString("origin")
+	}
+	if origin == "" {
+		return "", fmt.Errorf(i18n.Translate(lang, "auth:The origin should be configured to send the MFA bypass link"))
+	}
+
+	return strings.TrimSuffix(origin, "/"), nil
+}
+
+func issueMfaBypass(changeRequest *ChangeRequest, user *User, content *MfaChangeContent, lang string) error {
+	if user.Email == "" {
+		return fmt.Errorf(i18n.Translate(lang, "auth:The user: %s has no Email to receive the MFA bypass link"), user.GetId())
+	}
+
+	organization, err := getOrganization("admin", user.Owner)
+	if err != nil {
+		return err
+	}
+	if organization == nil {
+		return fmt.Errorf(i18n.Translate(lang, "check:Organization does not exist"))
+	}
+
+	application, err := GetDefaultApplication(util.GetId("admin", organization.Name))
+	if err != nil {
+		return err
+	}
+
+	provider, err := GetOrganizationEmailProvider(organization, application)
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return fmt.Errorf(i18n.Translate(lang, "auth:The organization: %s has no Email provider"), organization.Name)
+	}
+
+	originFrontend, err := getMfaBypassOrigin(lang)
+	if err != nil {
+		return err
+	}
+
+	token := uuid.NewString() + uuid.NewString()
+	mfaBypass := &MfaBypass{
+		Owner:         user.Owner,
+		Name:          util.GenerateId(),
+		CreatedTime:   util.GetCurrentTime(),
+		User:          user.Name,
+		ChangeRequest: changeRequest.GetId(),
+		ExpireTime:    time.Now().Add(time.Duration(content.ExpireInMinutes) * time.Minute).Format(time.RFC3339),
+		TokenHash:     getTokenHash(token),
+	}
+
+	link := fmt.Sprintf("%s/api/use-mfa-bypass?token=%s", originFrontend, url.QueryEscape(token))
+	title := fmt.Sprintf("%s: sign in without MFA", organization.DisplayName)
+	err = SendEmail(provider, title, getMfaBypassEmailContent(user, link, mfaBypass.ExpireTime), user.Email, organization.DisplayName)
+	if err != nil {
+		return err
+	}
+
+	_, err = runWithRecord(newMfaRecord(user, "mfa-bypass-issued", "", mfaBypass), func(session *xorm.Session) (bool, error) {
+		affected, err := session.Insert(mfaBypass)
+		return affected != 0, err
+	})
+	return err
+}
+
+func GetMfaBypass(id string) (*MfaBypass, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	mfaBypass := MfaBypass{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&mfaBypass)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &mfaBypass, nil
+	}
+	return nil, nil
+}
+
+func (mfaBypass *MfaBypass) GetId() string {
+	return fmt.Sprintf("%s/%s", mfaBypass.Owner, mfaBypass.Name)
+}
+
+func (mfaBypass *MfaBypass) IsExpired() bool {
+	expireTime, err := time.Parse(time.RFC3339, mfaBypass.ExpireTime)
+	if err != nil {
+		return true
+	}
+	return !time.Now().Before(expireTime)
+}
+
+func getMfaBypassInvalidError(lang string) error {
+	return fmt.Errorf(i18n.Translate(lang, "auth:The MFA bypass link is invalid, used or expired"))
+}
+
+// GetUsableMfaBypass returns the unused and unexpired MFA bypass of the link without consuming it
+func GetUsableMfaBypass(token string, lang string) (*MfaBypass, error) {
+	if token == "" {
+		return nil, getMfaBypassInvalidError(lang)
+	}
+
+	mfaBypass := MfaBypass{TokenHash: getTokenHash(token)}
+	existed, err := ormer.Engine.Get(&mfaBypass)
+	if err != nil {
+		return nil, err
+	}
+	if !existed || mfaBypass.UsedTime != "" || mfaBypass.IsExpired() {
+		return nil, getMfaBypassInvalidError(lang)
+	}
+
+	return &mfaBypass, nil
+}
+
+// UseMfaBypass consumes the MFA bypass of the link, the bypass is single-use and expires in the approved minutes
+func UseMfaBypass(token string, clientIp string, lang string) (*MfaBypass, error) {
+	invalidErr := getMfaBypassInvalidError(lang)
+	mfaBypass, err := GetUsableMfaBypass(token, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := getUser(mfaBypass.Owner, mfaBypass.User)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, invalidErr
+	}
+
+	mfaBypass.UsedTime = util.GetCurrentTime()
+	mfaBypass.UsedIp = clientIp
+
+	// the bypass is consumed by a conditional update, so it can't be used twice by the concurrent requests
+	affected, err := runWithRecord(newMfaRecord(user, "mfa-bypass-used", clientIp, mfaBypass), func(session *xorm.Session) (bool, error) {
+		affected, err := session.Where("owner = ? and name = ? and used_time = ?", mfaBypass.Owner, mfaBypass.Name, "").
+			Cols("used_time", "used_ip").Update(mfaBypass)
+		return affected != 0, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !affected {
+		return nil, invalidErr
+	}
+
+	return mfaBypass, nil
+}
+
+// RegenerateMfaRecoveryCodes replaces the recovery codes of the user with a new set of single-use codes,
+// which are returned only this time. The user should verify the MFA again by the elevation token or the form
+func RegenerateMfaRecoveryCodes(user *User, elevationToken string, elevationForm *form.ElevationForm, lang string) ([]string, error) {
+	if !user.IsMfaEnabled() {
+		return nil, fmt.Errorf(i18n.Translate(lang, "auth:The user: %s has no MFA enabled"), user.GetId())
+	}
+
+	err := CheckFreshMfa(user, elevationToken, elevationForm, lang)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes := []string{}
+	for i := 0; i < mfaRecoveryCodeCount; i++ {
+		recoveryCodes = append(recoveryCodes, uuid.NewString())
+	}
+
+	user.RecoveryCodes = recoveryCodes
+	_, err = updateUser(user.GetId(), user, []string{"recovery_codes"})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMfaChangeRequestApproval(t *testing.T) {
+	assert.True(t, isMfaChangeRequestType(ChangeRequestTypeMfaReset))
+	assert.True(t, isMfaChangeRequestType(ChangeRequestTypeMfaBypass))
+	assert.False(t, isMfaChangeRequestType(ChangeRequestTypeApplication))
+
+	changeRequest := &ChangeRequest{Owner: "org", Type: ChangeRequestTypeMfaReset, User: "org/alice", Target: "org/bob"}
+
+	// neither the requester nor the locked-out admin approves the reset
+	assert.False(t, changeRequest.CanBeApprovedBy(&User{Owner: "org", Name: "alice", IsAdmin: true}))
+	assert.False(t, changeRequest.CanBeApprovedBy(&User{Owner: "org", Name: "bob", IsAdmin: true}))
+	assert.True(t, changeRequest.CanBeApprovedBy(&User{Owner: "org", Name: "carol", IsAdmin: true}))
+	assert.False(t, changeRequest.CanBeApprovedBy(&User{Owner: "org", Name: "dave"}))
+	assert.False(t, changeRequest.CanBeApprovedBy(&User{Owner: "other", Name: "erin", IsAdmin: true}))
+}
+
+func TestMfaBypass(t *testing.T) {
+	mfaBypass := &MfaBypass{ExpireTime: time.Now().Add(time.Hour).Format(time.RFC3339)}
+	assert.False(t, mfaBypass.IsExpired())
+
+	mfaBypass.ExpireTime = time.Now().Add(-time.Minute).Format(time.RFC3339)
+	assert.True(t, mfaBypass.IsExpired())
+
+	mfaBypass.ExpireTime = ""
+	assert.True(t, mfaBypass.IsExpired())
+
+	link := "https://door.example.com/api/use-mfa-bypass?token=abc"
+	content := getMfaBypassEmailContent(&User{Owner: "org", Name: "bob"}, link, mfaBypass.ExpireTime)
+	assert.True(t, strings.Contains(content, link))
+	assert.True(t, strings.Contains(content, "org/bob"))
+}
+
+func TestGetMfaBypassOrigin(t *testing.T) {
+	os.Setenv("origin", "")
+	defer os.Unsetenv("origin")
+	os.Setenv("originFrontend", "")
+	defer os.Unsetenv("originFrontend")
+
+	_, err := getMfaBypassOrigin("en")
+	assert.NotNil(t, err)
+
+	os.Setenv("origin", "https://door.example.com/")
+	origin, err := getMfaBypassOrigin("en")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://door.example.com", origin)
+
+	os.Setenv("originFrontend", "https://login.example.com")
+	origin, err = getMfaBypassOrigin("en")
+	assert.Nil(t, err)
+	assert.Equal(t, "https://login.example.com", origin)
+}
+
+func TestGetUsableMfaBypass(t *testing.T) {
+	setTestOrmer(t, &MfaBypass{})
+
+	expireTime := time.Now().Add(time.Hour).Format(time.RFC3339)
+	for _, mfaBypass := range []*MfaBypass{
+		{Owner: "org", Name: "bypass1", User: "alice", ExpireTime: expireTime, TokenHash: getTokenHash("token1")},
+		{Owner: "org", Name: "bypass2", User: "alice", ExpireTime: expireTime, TokenHash: getTokenHash("token2"), UsedTime: util.GetCurrentTime()},
+		{Owner: "org", Name: "bypass3", User: "alice", ExpireTime: time.Now().Add(-time.Minute).Format(time.RFC3339), TokenHash: getTokenHash("token3")},
+	} {
+		_, err := ormer.Engine.Insert(mfaBypass)
+		assert.Nil(t, err)
+	}
+
+	// showing the confirmation page doesn't use the bypass
+	for i := 0; i < 2; i++ {
+		mfaBypass, err := GetUsableMfaBypass("token1", "en")
+		assert.Nil(t, err)
+		assert.Equal(t, "bypass1", mfaBypass.Name)
+	}
+
+	for _, token := range []string{"", "token2", "token3", "token4"} {
+		_, err := GetUsableMfaBypass(token, "en")
+		assert.NotNil(t, err)
+	}
+}
@@ -28,6 +28,13 @@ const (
 	MfaDestSession        = "mfa_dest"
 )
 
+// the methods of the codes sent for setting up and passing the SMS and email MFA, a code of another method
+// (like a signup code sent to the same destination) can't be used as the passcode
+const (
+	MfaSetupVerification = "mfaSetup"
+	MfaAuthVerification  = "mfaAuth"
+)
+
 type SmsMfa struct {
 	Config *MfaProps
 }
@@ -64,7 +71,8 @@ func (mfa *SmsMfa) SetupVerify(ctx *context.Context, passCode string) error {
 		dest, _ = util.GetE164Number(dest, countryCode)
 	}
 
-	if result := CheckVerificationCode(dest, passCode, "en"); result.Code != VerificationSuccess {
+	scope := &VerificationScope{Method: MfaSetupVerification, Session: ctx.Input.CruSession.SessionID()}
+	if result := CheckVerificationCode(scope, dest, passCode, "en"); result.Code != VerificationSuccess {
 		return errors.New(result.Msg)
 	}
 	return nil
@@ -118,7 +126,8 @@ func (mfa *SmsMfa) Verify(passCode string) error {
 	if !util.IsEmailValid(mfa.Config.Secret) {
 		mfa.Config.Secret, _ = util.GetE164Number(mfa.Config.Secret, mfa.Config.CountryCode)
 	}
-	if result := CheckVerificationCode(mfa.Config.Secret, passCode, "en"); result.Code != VerificationSuccess {
+	scope := &VerificationScope{Method: MfaAuthVerification}
+	if result := CheckVerificationCode(scope, mfa.Config.Secret, passCode, "en"); result.Code != VerificationSuccess {
 		return errors.New(result.Msg)
 	}
 	return nil
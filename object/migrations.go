@@ -0,0 +1,728 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	xormadapter "github.com/casdoor/xorm-adapter/v3"
+	"github.com/xorm-io/xorm"
+	"github.com/xorm-io/xorm/schemas"
+)
+
+// migrations are all the schema changes in the order to be applied, a new migration should be appended
+// with a new id for any change of the tables instead of editing an existing one.
+var migrations = []*Migration{
+	{
+		Id:          "0001_initial_schema",
+		Description: "create the tables and columns of the schema before the versioned migrations",
+		Up:          migrateInitialSchema,
+	},
+	{
+		Id:          "0002_organization_conditional_access_policies",
+		Description: "add the conditional access policies of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Organization), "conditional_access_policies")
+		},
+	},
+	{
+		Id:          "0003_user_push_mfa",
+		Description: "add the enrolled devices of the push MFA of the users",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(User))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(User), "mfa_push_enabled", "mfa_push_device", "mfa_push_provider")
+		},
+	},
+	{
+		Id:          "0004_access_review_campaigns",
+		Description: "add the access review campaigns and their review items",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(AccessReviewCampaign), new(AccessReviewItem))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(AccessReviewCampaign), new(AccessReviewItem))
+		},
+	},
+	{
+		Id:          "0005_external_pdp",
+		Description: "add the external policy decision points of the permissions and applications",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Permission), new(Application))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := dropColumns(engine, new(Permission), "external_pdp_url", "pdp_combining_algorithm")
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(Application), "external_pdp_url", "pdp_combining_algorithm")
+		},
+	},
+	{
+		Id:          "0006_email_dkim_and_bounces",
+		Description: "add the DKIM keys and bounce secrets of the Email providers, the Email providers of the organizations and the email bounces of the users",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Provider), new(Organization), new(User))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := dropColumns(engine, new(Provider), "dkim_domain", "dkim_selector", "dkim_private_key", "bounce_secret")
+			if err != nil {
+				return err
+			}
+			err = dropColumns(engine, new(Organization), "email_provider")
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(User), "email_bounce")
+		},
+	},
+	{
+		Id:          "0007_organization_hierarchy",
+		Description: "add the parent organizations of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Organization), "parent_organization")
+		},
+	},
+	{
+		Id:          "0008_signup_item_rules",
+		Description: "widen the signup items of the applications for the validation rules of the items",
+		Up: func(engine *xorm.Engine) error {
+			return alterColumnType(engine, new(Application), "signup_items", schemas.SQLType{Name: schemas.MediumText})
+		},
+		Down: func(engine *xorm.Engine) error {
+			return alterColumnType(engine, new(Application), "signup_items", schemas.SQLType{Name: schemas.Varchar, DefaultLength: 2000})
+		},
+	},
+	{
+		Id:          "0009_provider_jit_provisioning",
+		Description: "add the JIT provisioning rules of the providers",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Provider))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Provider), "jit_provisioning")
+		},
+	},
+	{
+		Id:          "0010_application_redirect_uri_items",
+		Description: "add the redirect URI items of the applications with the wildcards and the usages",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Application))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Application), "redirect_uri_items")
+		},
+	},
+	{
+		Id:          "0011_user_suspension",
+		Description: "add the suspension state of the users",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(User))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(User), "is_suspended", "suspended_time", "suspended_reason", "reactivation_time")
+		},
+	},
+	{
+		Id:          "0012_certificate_bound_tokens",
+		Description: "add the certificate-bound access tokens of the applications (RFC 8705)",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Application), new(Token))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := dropColumns(engine, new(Application), "enable_certificate_bound_tokens", "bound_token_expire_in_seconds")
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(Token), "cert_thumbprint")
+		},
+	},
+	{
+		Id:          "0013_verification_code_policy",
+		Description: "add the verification code policies of the organizations and the attempts of the verification codes",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Organization), new(VerificationRecord))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := dropColumns(engine, new(Organization), "verification_code_policy")
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(VerificationRecord), "expire_in_minutes", "max_attempts", "failed_times")
+		},
+	},
+	{
+		Id:          "0014_provisioners",
+		Description: "add the provisioners pushing the users and groups to the downstream applications",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Provisioner), new(ProvisionerState))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(Provisioner), new(ProvisionerState))
+		},
+	},
+	{
+		Id:          "0015_login_experiments",
+		Description: "add the login flow experiments of the applications and the variant assignments of the login subjects",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Application), new(LoginExperimentAssignment))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := dropColumns(engine, new(Application), "login_experiment")
+			if err != nil {
+				return err
+			}
+			return engine.DropTables(new(LoginExperimentAssignment))
+		},
+	},
+	{
+		Id:          "0016_cert_key_backends",
+		Description: "add the external key backends of the certs",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Cert))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Cert), "key_backend", "key_uri", "key_region", "key_client_id", "key_client_secret")
+		},
+	},
+	{
+		Id:          "0017_user_lifecycle",
+		Description: "add the lifecycle states of the users and the lifecycle policies of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(User), new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := dropColumns(engine, new(User), "lifecycle_state", "lifecycle_state_time", "lifecycle_reason", "manager", "hire_time", "termination_time")
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(Organization), "lifecycle_policy")
+		},
+	},
+	{
+		Id:          "0018_permission_resource_matcher",
+		Description: "add the resource matchers of the permissions for the hierarchical resources",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Permission))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Permission), "resource_matcher")
+		},
+	},
+	{
+		Id:          "0019_verification_code_scope",
+		Description: "bind the verification codes to the scopes and add the verification tokens",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(VerificationRecord))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(VerificationRecord), "application", "method", "session_hash", "token_hash", "token_time", "is_token_used")
+		},
+	},
+	{
+		Id:          "0020_subscription_dunning",
+		Description: "add the provider subscriptions and the dunning of the subscriptions",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Subscription), new(Plan), new(Provider))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := dropColumns(engine, new(Subscription), "provider", "external_id", "last_event_id", "failed_invoice", "past_due_time", "retry_times", "next_retry_time")
+			if err != nil {
+				return err
+			}
+
+			err = dropColumns(engine, new(Plan), "dunning_schedule")
+			if err != nil {
+				return err
+			}
+
+			return dropColumns(engine, new(Provider), "webhook_secret")
+		},
+	},
+	{
+		Id:          "0021_usage_metering",
+		Description: "add the usage metering of the organizations and its metered billing",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Usage), new(UsageUser), new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := engine.DropTables(new(Usage), new(UsageUser))
+			if err != nil {
+				return err
+			}
+
+			return dropColumns(engine, new(Organization), "usage_billing")
+		},
+	},
+	{
+		Id:          "0022_user_ethereum",
+		Description: "add the wallet address of the Sign-In with Ethereum provider to the users",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(User))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(User), "ethereum")
+		},
+	},
+	{
+		Id:          "0023_webhook_filter_template",
+		Description: "add the application scopes, payload templates and signing secrets of the webhooks",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Webhook))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Webhook), "applications", "payload_template", "signing_secret", "previous_signing_secret", "secret_rotated_time")
+		},
+	},
+	{
+		Id:          "0024_user_revocation_epoch",
+		Description: "add the revocation epochs of the users for the offline token validation",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(User))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(User), "revocation_epoch")
+		},
+	},
+	{
+		Id:          "0025_delegation_token",
+		Description: "add the delegation rules of the applications and the actors of the delegation tokens",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Application), new(Token))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := dropColumns(engine, new(Application), "delegation_rules")
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(Token), "actor")
+		},
+	},
+	{
+		Id:          "0026_organization_retention_policy",
+		Description: "add the data retention policies of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Organization), "retention_policy")
+		},
+	},
+	{
+		Id:          "0027_application_session_policy",
+		Description: "add the session policies of the applications",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Application))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Application), "session_policy")
+		},
+	},
+	{
+		Id:          "0028_record_query_text",
+		Description: "add the full-text search of the record queries",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(RecordQuery))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(RecordQuery), "text")
+		},
+	},
+	{
+		Id:          "0029_organization_custom_domains",
+		Description: "add the custom domains of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Organization), "custom_domains")
+		},
+	},
+	{
+		Id:          "0030_change_approval",
+		Description: "add the change approval of the sensitive objects of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(ChangeRequest), new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := engine.DropTables(new(ChangeRequest))
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(Organization), "enable_change_approval")
+		},
+	},
+	{
+		Id:          "0031_devices",
+		Description: "add the devices enrolling the client certificates",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Device))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(Device))
+		},
+	},
+	{
+		Id:          "0032_webhook_outbox",
+		Description: "add the outbox of the webhook events delivered with retries",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(WebhookEvent))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(WebhookEvent))
+		},
+	},
+	{
+		Id:          "0033_username_policy",
+		Description: "add the username policies of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Organization), "username_policy")
+		},
+	},
+	{
+		Id:          "0034_translations",
+		Description: "add the translations managed by the organizations at runtime",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Translation))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(Translation))
+		},
+	},
+	{
+		Id:          "0035_ldap_base_dn",
+		Description: "add the LDAP base DNs of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Organization), "ldap_base_dn")
+		},
+	},
+	{
+		Id:          "0036_radius_clients",
+		Description: "add the RADIUS clients with their shared secrets and attribute mappings",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(RadiusClient))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(RadiusClient))
+		},
+	},
+	{
+		Id:          "0037_account_recovery",
+		Description: "add the account recoveries with multiple verified factors",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(AccountRecovery), new(Organization), new(User))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := engine.DropTables(new(AccountRecovery))
+			if err != nil {
+				return err
+			}
+			err = dropColumns(engine, new(Organization), "recovery_policy")
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(User), "backup_email")
+		},
+	},
+	{
+		Id:          "0038_provider_catalog",
+		Description: "add the catalog of the shared providers adopted by the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Provider))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Provider), "is_shared", "base_provider")
+		},
+	},
+	{
+		Id:          "0039_bot_detection",
+		Description: "add the bot detection of the applications",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Application))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Application), "bot_detection")
+		},
+	},
+	{
+		Id:          "0040_export_jobs",
+		Description: "add the background export jobs",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(ExportJob))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(ExportJob))
+		},
+	},
+	{
+		Id:          "0041_legal_documents",
+		Description: "add the versioned legal documents of the applications and their acceptances",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(LegalAcceptance), new(Application))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := engine.DropTables(new(LegalAcceptance))
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(Application), "legal_documents")
+		},
+	},
+	{
+		Id:          "0042_record_redaction",
+		Description: "add the record redaction rules of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Organization), "record_redaction")
+		},
+	},
+	{
+		Id:          "0043_identity_verification",
+		Description: "add the identity verifications of the users and the verification requirement of the applications",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(IdentityVerification), new(User), new(Application))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := engine.DropTables(new(IdentityVerification))
+			if err != nil {
+				return err
+			}
+
+			err = dropColumns(engine, new(User), "identity_verification_state")
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(Application), "require_identity_verification")
+		},
+	},
+	{
+		Id:          "0044_break_glass_keys",
+		Description: "add the single-use keys of the break-glass accounts",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(BreakGlassKey))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(BreakGlassKey))
+		},
+	},
+	{
+		Id:          "0045_workload_identities",
+		Description: "add the workload identities trusting the OIDC tokens of the CI platforms and their tokens",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(WorkloadIdentity), new(WorkloadToken))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(WorkloadIdentity), new(WorkloadToken))
+		},
+	},
+	{
+		Id:          "0046_mfa_bypasses",
+		Description: "add the single-use MFA bypasses issued by the approved change requests",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(MfaBypass))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(MfaBypass))
+		},
+	},
+	{
+		Id:          "0047_provider_healths",
+		Description: "add the results of the periodic health checks of the providers",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(ProviderHealth))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(ProviderHealth))
+		},
+	},
+	{
+		Id:          "0048_organization_auth_hooks",
+		Description: "add the hooks of the authentication pipeline of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Organization), "auth_hooks")
+		},
+	},
+	{
+		Id:          "0049_resource_policies",
+		Description: "add the tags and expire times of the resources and the resource policies of the applications",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Resource), new(Application))
+		},
+		Down: func(engine *xorm.Engine) error {
+			err := dropColumns(engine, new(Resource), "tags", "expire_time")
+			if err != nil {
+				return err
+			}
+			return dropColumns(engine, new(Application), "resource_policy")
+		},
+	},
+	{
+		Id:          "0050_data_keys",
+		Description: "add the data-encryption keys of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(DataKey))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(DataKey))
+		},
+	},
+	{
+		Id:          "0051_application_audience_rules",
+		Description: "add the audience rules of the client credentials tokens of the applications",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Application))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Application), "audience_rules")
+		},
+	},
+	{
+		Id:          "0052_organization_memberships",
+		Description: "add the memberships of the users in the organizations other than their home organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(OrganizationMembership))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(OrganizationMembership))
+		},
+	},
+	{
+		Id:          "0053_vault_credentials",
+		Description: "add the vault credentials of the users for the legacy applications",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(VaultCredential))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(VaultCredential))
+		},
+	},
+	{
+		Id:          "0054_session_devices",
+		Description: "add the devices of the session IDs of the sessions",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Session))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Session), "devices")
+		},
+	},
+	{
+		Id:          "0055_user_activations",
+		Description: "add the activations of the pre-registered users",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(UserActivation))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(UserActivation))
+		},
+	},
+	{
+		Id:          "0056_saml_metadata_refresh",
+		Description: "add the metadata refresh and the certificate pinning of the SAML providers",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Provider))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Provider), "metadata_url", "metadata_refresh_interval", "pinned_certs", "idp_certs", "metadata_refresh_time", "metadata_error")
+		},
+	},
+	{
+		Id:          "0057_elevations",
+		Description: "add the elevations of the fresh MFA for the destructive admin operations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Elevation))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return engine.DropTables(new(Elevation))
+		},
+	},
+	{
+		Id:          "0058_organization_claimed_domains",
+		Description: "add the claimed email domains of the organizations",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(Organization))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(Organization), "claimed_domains")
+		},
+	},
+	{
+		Id:          "0059_change_request_action",
+		Description: "add the action of the change requests for the additions and deletions",
+		Up: func(engine *xorm.Engine) error {
+			return engine.Sync2(new(ChangeRequest))
+		},
+		Down: func(engine *xorm.Engine) error {
+			return dropColumns(engine, new(ChangeRequest), "action")
+		},
+	},
+}
+
+// migrateInitialSchema creates the missing tables and columns of the existing databases
+// the same way as the automatic sync did before the versioned migrations.
+func migrateInitialSchema(engine *xorm.Engine) error {
+	return engine.Sync2(
+		new(Organization),
+		new(User),
+		new(Group),
+		new(Role),
+		new(Permission),
+		new(Model),
+		new(Adapter),
+		new(Enforcer),
+		new(EnforcerSnapshot),
+		new(Share),
+		new(UserContact),
+		new(AccessRequest),
+		new(SyncerUserState),
+		new(CacheInvalidation),
+		new(SmsMessage),
+		new(ServiceAccount),
+		new(Provider),
+		new(Application),
+		new(Resource),
+		new(Token),
+		new(VerificationRecord),
+		new(Webhook),
+		new(Syncer),
+		new(Cert),
+		new(Product),
+		new(Payment),
+		new(Ldap),
+		new(RadiusAccounting),
+		new(xormadapter.CasbinRule),
+		new(Session),
+		new(Subscription),
+		new(Plan),
+		new(Pricing),
+		new(RecordQuery),
+	)
+}
@@ -0,0 +1,209 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/xorm"
+	"github.com/xorm-io/xorm/schemas"
+)
+
+// Migration is a versioned change of the database schema, the migrations are applied in the order
+// of the list at startup and each of them is applied only once. A destructive migration (e.g. dropping
+// or altering a column) blocks the startup until "allowDestructiveMigrations" is enabled in the config.
+type Migration struct {
+	Id            string
+	Description   string
+	IsDestructive bool
+	Up            func(engine *xorm.Engine) error
+	// Down reverts Up, the migration can't be rolled back if it is nil
+	Down func(engine *xorm.Engine) error
+}
+
+// MigrationRecord is a migration that has been applied to the database
+type MigrationRecord struct {
+	Id          string `xorm:"varchar(100) notnull pk" json:"id"`
+	Description string `xorm:"varchar(1000)" json:"description"`
+	AppliedTime string `xorm:"varchar(100)" json:"appliedTime"`
+}
+
+func (a *Ormer) getMigrationRecords() (map[string]*MigrationRecord, error) {
+	err := a.Engine.Sync2(new(MigrationRecord))
+	if err != nil {
+		return nil, err
+	}
+
+	records := []*MigrationRecord{}
+	err = a.Engine.Find(&records)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]*MigrationRecord{}
+	for _, record := range records {
+		res[record.Id] = record
+	}
+	return res, nil
+}
+
+// getPendingMigrations is the pre-flight check before the migrations are applied, it fails if the database
+// has been migrated by a newer version of Casdoor, or if any pending migration is destructive but not allowed.
+func getPendingMigrations(migrations []*Migration, records map[string]*MigrationRecord, allowDestructive bool) ([]*Migration, error) {
+	knownIds := map[string]bool{}
+	for _, migration := range migrations {
+		knownIds[migration.Id] = true
+	}
+
+	unknownIds := []string{}
+	for id := range records {
+		if !knownIds[id] {
+			unknownIds = append(unknownIds, id)
+		}
+	}
+	if len(unknownIds) != 0 {
+		sort.Strings(unknownIds)
+		return nil, fmt.Errorf("the database has been migrated by a newer version of Casdoor, unknown migrations: %s", strings.Join(unknownIds, ", "))
+	}
+
+	res := []*Migration{}
+	destructiveIds := []string{}
+	for _, migration := range migrations {
+		if _, ok := records[migration.Id]; ok {
+			continue
+		}
+
+		res = append(res, migration)
+		if migration.IsDestructive {
+			destructiveIds = append(destructiveIds, migration.Id)
+		}
+	}
+
+	if len(destructiveIds) != 0 && !allowDestructive {
+		return nil, fmt.Errorf("the pending migrations: %s are destructive, please back up the database and set \"allowDestructiveMigrations = true\" in app.conf to apply them", strings.Join(destructiveIds, ", "))
+	}
+
+	return res, nil
+}
+
+func (a *Ormer) runMigrations() error {
+	a.Engine.ShowSQL(conf.GetConfigBool("showSql"))
+
+	records, err := a.getMigrationRecords()
+	if err != nil {
+		return err
+	}
+
+	pendingMigrations, err := getPendingMigrations(migrations, records, conf.GetConfigBool("allowDestructiveMigrations"))
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range pendingMigrations {
+		fmt.Printf("Applying the migration: %s (%s)\n", migration.Id, migration.Description)
+		err = migration.Up(a.Engine)
+		if err != nil {
+			return fmt.Errorf("failed to apply the migration: %s, error: %s", migration.Id, err.Error())
+		}
+
+		_, err = a.Engine.Insert(&MigrationRecord{
+			Id:          migration.Id,
+			Description: migration.Description,
+			AppliedTime: util.GetCurrentTime(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rollbackMigrations reverts the applied migrations after the target migration in the reverse order
+func (a *Ormer) rollbackMigrations(targetId string) error {
+	records, err := a.getMigrationRecords()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := records[targetId]; !ok {
+		return fmt.Errorf("the migration: %s has not been applied", targetId)
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		if migration.Id == targetId {
+			break
+		}
+		if _, ok := records[migration.Id]; !ok {
+			continue
+		}
+
+		if migration.Down == nil {
+			return fmt.Errorf("the migration: %s can't be rolled back", migration.Id)
+		}
+
+		fmt.Printf("Rolling back the migration: %s (%s)\n", migration.Id, migration.Description)
+		err = migration.Down(a.Engine)
+		if err != nil {
+			return fmt.Errorf("failed to roll back the migration: %s, error: %s", migration.Id, err.Error())
+		}
+
+		_, err = a.Engine.ID(migration.Id).Delete(&MigrationRecord{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropColumns drops the columns from the table of the bean, it reverts the migrations that add the columns
+func dropColumns(engine *xorm.Engine, bean interface{}, columns ...string) error {
+	tableName := engine.TableName(bean, true)
+	for _, column := range columns {
+		_, err := engine.Exec(fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s", engine.Quote(tableName), engine.Quote(column)))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// alterColumnType changes the type of the column, SQLite is skipped as it doesn't enforce the lengths of the types
+func alterColumnType(engine *xorm.Engine, bean interface{}, column string, sqlType schemas.SQLType) error {
+	tableName := engine.Quote(engine.TableName(bean, true))
+	columnType := engine.Dialect().SQLType(&schemas.Column{Name: column, SQLType: sqlType, Length: sqlType.DefaultLength})
+	column = engine.Quote(column)
+
+	var sql string
+	switch engine.Dialect().URI().DBType {
+	case schemas.MYSQL:
+		sql = fmt.Sprintf("ALTER TABLE %s MODIFY COLUMN %s %s", tableName, column, columnType)
+	case schemas.POSTGRES:
+		sql = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s", tableName, column, columnType)
+	case schemas.MSSQL:
+		sql = fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s %s", tableName, column, columnType)
+	default:
+		return nil
+	}
+
+	_, err := engine.Exec(sql)
+	return err
+}
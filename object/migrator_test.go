@@ -0,0 +1,52 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPendingMigrations(t *testing.T) {
+	migrationList := []*Migration{
+		{Id: "0001_a"},
+		{Id: "0002_b"},
+		{Id: "0003_c", IsDestructive: true},
+	}
+
+	pendingMigrations, err := getPendingMigrations(migrationList, map[string]*MigrationRecord{"0001_a": {Id: "0001_a"}}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, migrationList[1:], pendingMigrations)
+
+	_, err = getPendingMigrations(migrationList, map[string]*MigrationRecord{"0001_a": {Id: "0001_a"}}, false)
+	assert.NotNil(t, err)
+
+	pendingMigrations, err = getPendingMigrations(migrationList[:2], map[string]*MigrationRecord{}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, migrationList[:2], pendingMigrations)
+
+	_, err = getPendingMigrations(migrationList[:2], map[string]*MigrationRecord{"0004_d": {Id: "0004_d"}}, true)
+	assert.NotNil(t, err)
+}
+
+func TestMigrationIds(t *testing.T) {
+	ids := map[string]bool{}
+	for _, migration := range migrations {
+		assert.NotEmpty(t, migration.Id)
+		assert.False(t, ids[migration.Id], "the migration id: %s is duplicated", migration.Id)
+		ids[migration.Id] = true
+	}
+}
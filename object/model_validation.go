@@ -0,0 +1,458 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Knetic/govaluate"
+	"github.com/casbin/casbin/v2/constant"
+	"github.com/casbin/casbin/v2/model"
+	casbinutil "github.com/casbin/casbin/v2/util"
+	"github.com/casdoor/casdoor/util"
+	xormadapter "github.com/casdoor/xorm-adapter/v3"
+)
+
+const (
+	ModelDiagnosticError   = "Error"
+	ModelDiagnosticWarning = "Warning"
+)
+
+// ModelDiagnostic is a problem of the model text found by the validation, the errors break the enforcement at
+// runtime while the warnings are likely mistakes. Line is 0 if the problem isn't of a line.
+type ModelDiagnostic struct {
+	Severity string `json:"severity"`
+	Section  string `json:"section"`
+	Key      string `json:"key"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+}
+
+type ModelValidationResult struct {
+	IsValid     bool               `json:"isValid"`
+	Diagnostics []*ModelDiagnostic `json:"diagnostics"`
+}
+
+// modelSectionKeys are the key prefixes of the sections loaded by Casbin
+var modelSectionKeys = map[string]string{
+	"request_definition": "r",
+	"policy_definition":  "p",
+	"role_definition":    "g",
+	"policy_effect":      "e",
+	"matchers":           "m",
+}
+
+var requiredModelSections = []string{"request_definition", "policy_definition", "policy_effect", "matchers"}
+
+var modelEffects = []string{
+	constant.AllowOverrideEffect,
+	constant.DenyOverrideEffect,
+	constant.AllowAndDenyEffect,
+	constant.PriorityEffect,
+	constant.SubjectPriorityEffect,
+}
+
+var (
+	modelKeyRegex          = regexp.MustCompile(`^([rpgem])([0-9]*)$`)
+	modelMatcherTokenRegex = regexp.MustCompile(`\b([rp][0-9]*)\.([A-Za-z_][A-Za-z0-9_]*)`)
+	modelMatcherCallRegex  = regexp.MustCompile(`(^|[^.\w])([A-Za-z_]\w*)\s*\(`)
+)
+
+// modelEntry is an option of the model text with the line where it starts
+type modelEntry struct {
+	Section string
+	Key     string
+	Value   string
+	Line    int
+}
+
+type modelValidator struct {
+	entries     map[string]*modelEntry
+	diagnostics []*ModelDiagnostic
+}
+
+func (v *modelValidator) add(severity string, entry *modelEntry, section string, line int, format string, a ...interface{}) {
+	diagnostic := &ModelDiagnostic{Severity: severity, Section: section, Line: line, Message: fmt.Sprintf(format, a...)}
+	if entry != nil {
+		diagnostic.Section = entry.Section
+		diagnostic.Key = entry.Key
+		diagnostic.Line = entry.Line
+	}
+	v.diagnostics = append(v.diagnostics, diagnostic)
+}
+
+func removeModelComment(line string) string {
+	end := strings.IndexAny(line, "#;")
+	if end == -1 {
+		return line
+	}
+	return line[:end]
+}
+
+// parse reads the options of the model text the same way as the config of Casbin, including the comments and the
+// values continued by a trailing backslash
+func (v *modelValidator) parse(modelText string) {
+	section := ""
+	buffer := ""
+	startLine := 0
+
+	flush := func() {
+		if strings.TrimSpace(buffer) == "" {
+			buffer = ""
+			return
+		}
+
+		pair := strings.SplitN(buffer, "=", 2)
+		buffer = ""
+		if len(pair) != 2 {
+			v.add(ModelDiagnosticError, nil, section, startLine, "syntax error: the line should be in the format of: key = value")
+			return
+		}
+
+		entry := &modelEntry{Section: section, Key: strings.TrimSpace(pair[0]), Value: strings.TrimSpace(pair[1]), Line: startLine}
+		if section == "" {
+			v.add(ModelDiagnosticError, entry, "", 0, "the key: %s is not in any section", entry.Key)
+			return
+		}
+
+		prefix, ok := modelSectionKeys[section]
+		if !ok {
+			return
+		}
+
+		match := modelKeyRegex.FindStringSubmatch(entry.Key)
+		if match == nil || match[1] != prefix || match[2] == "1" {
+			v.add(ModelDiagnosticWarning, entry, "", 0, "the key: %s is ignored, the keys of the section: %s are %s, %s2, %s3...", entry.Key, section, prefix, prefix, prefix)
+			return
+		}
+		if _, ok := v.entries[entry.Key]; ok {
+			v.add(ModelDiagnosticWarning, entry, "", 0, "the key: %s is defined more than once, the last one is used", entry.Key)
+		}
+		if entry.Value == "" {
+			v.add(ModelDiagnosticError, entry, "", 0, "the value of the key: %s is empty", entry.Key)
+		}
+		v.entries[entry.Key] = entry
+	}
+
+	for i, line := range strings.Split(modelText, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			flush()
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			flush()
+			section = line[1 : len(line)-1]
+			if _, ok := modelSectionKeys[section]; !ok {
+				v.add(ModelDiagnosticWarning, nil, section, i+1, "unknown section: [%s], it's ignored", section)
+			}
+			continue
+		}
+
+		if buffer == "" {
+			startLine = i + 1
+		}
+		if strings.HasSuffix(line, "\\") {
+			buffer += removeModelComment(strings.TrimSpace(line[:len(line)-1])) + " "
+			continue
+		}
+
+		buffer += removeModelComment(line)
+		flush()
+	}
+	flush()
+}
+
+// getKeys returns the keys of the prefix loaded by Casbin, the keys after a missing one are reported and skipped
+func (v *modelValidator) getKeys(prefix string) []string {
+	keys := []string{}
+	for _, entry := range v.entries {
+		if strings.HasPrefix(entry.Key, prefix) {
+			keys = append(keys, entry.Key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return len(keys[i]) < len(keys[j]) || (len(keys[i]) == len(keys[j]) && keys[i] < keys[j])
+	})
+
+	res := []string{}
+	for i, key := range keys {
+		expected := prefix
+		if i > 0 {
+			expected = fmt.Sprintf("%s%d", prefix, i+1)
+		}
+		if key != expected {
+			v.add(ModelDiagnosticWarning, v.entries[key], "", 0, "the key: %s is ignored because the key: %s is missing", key, expected)
+			continue
+		}
+		res = append(res, key)
+	}
+	return res
+}
+
+// getDefinitionTokens returns the field names of the request or policy definition
+func (v *modelValidator) getDefinitionTokens(key string) []string {
+	entry := v.entries[key]
+	tokens := []string{}
+	seen := map[string]bool{}
+	for _, token := range strings.Split(entry.Value, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			v.add(ModelDiagnosticError, entry, "", 0, "the definition: %s has an empty field", key)
+		} else if seen[token] {
+			v.add(ModelDiagnosticError, entry, "", 0, "the definition: %s has the duplicated field: %s", key, token)
+		}
+		seen[token] = true
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// getRoleDefinitionArity returns the number of the fields of the role definition, excluding its parameters
+func (v *modelValidator) getRoleDefinitionArity(key string) int {
+	entry := v.entries[key]
+	value := entry.Value
+	if i := strings.Index(value, "("); i != -1 {
+		value = value[:i]
+	}
+
+	arity := 0
+	for _, token := range strings.Split(value, ",") {
+		token = strings.TrimSpace(token)
+		if token == "" {
+			continue
+		}
+		if token != "_" {
+			v.add(ModelDiagnosticError, entry, "", 0, "the fields of the role definition: %s should be \"_\", got: %s", key, token)
+		}
+		arity++
+	}
+	if arity < 2 {
+		v.add(ModelDiagnosticError, entry, "", 0, "the role definition: %s should have at least 2 fields, e.g., g = _, _", key)
+	}
+	return arity
+}
+
+func (v *modelValidator) checkEffect(key string, policyTokens map[string][]string) {
+	entry := v.entries[key]
+	effect := casbinutil.RemoveComments(casbinutil.EscapeAssertion(entry.Value))
+	if !util.InSlice(modelEffects, effect) {
+		v.add(ModelDiagnosticError, entry, "", 0, "unsupported policy effect: %s, the supported ones are: %s", entry.Value, strings.Join(modelEffects, ", "))
+		return
+	}
+
+	if strings.Contains(effect, "p_eft") && !util.InSlice(policyTokens["p"], "eft") && effect != constant.AllowOverrideEffect {
+		v.add(ModelDiagnosticWarning, entry, "", 0, "the policy effect uses p.eft but the policy definition has no eft field, every policy is treated as allow")
+	}
+}
+
+func (v *modelValidator) checkMatcher(key string, requestTokens map[string][]string, policyTokens map[string][]string, roleKeys []string) {
+	entry := v.entries[key]
+
+	for _, match := range modelMatcherTokenRegex.FindAllStringSubmatch(entry.Value, -1) {
+		tokens, ok := requestTokens[match[1]]
+		if !ok {
+			tokens, ok = policyTokens[match[1]]
+		}
+		if !ok {
+			v.add(ModelDiagnosticError, entry, "", 0, "unknown matcher token: %s, the definition: %s doesn't exist", match[0], match[1])
+		} else if !util.InSlice(tokens, match[2]) {
+			v.add(ModelDiagnosticError, entry, "", 0, "unknown matcher token: %s, the fields of the definition: %s are: %s", match[0], match[1], strings.Join(tokens, ", "))
+		}
+	}
+
+	functionMap := model.LoadFunctionMap()
+	functions := functionMap.GetFunctions()
+	for _, roleKey := range roleKeys {
+		functions[roleKey] = func(args ...interface{}) (interface{}, error) { return false, nil }
+	}
+	functions["eval"] = func(args ...interface{}) (interface{}, error) { return false, nil }
+
+	hasUnknownFunction := false
+	for _, match := range modelMatcherCallRegex.FindAllStringSubmatch(entry.Value, -1) {
+		if _, ok := functions[match[2]]; !ok && match[2] != "in" {
+			v.add(ModelDiagnosticError, entry, "", 0, "unknown function: %s in the matcher", match[2])
+			hasUnknownFunction = true
+		}
+	}
+	// the expression can't be compiled with an unknown function, which is already reported
+	if hasUnknownFunction {
+		return
+	}
+
+	matcher := casbinutil.RemoveComments(casbinutil.EscapeAssertion(entry.Value))
+	if strings.Contains(matcher, "in") {
+		matcher = strings.Replace(strings.Replace(matcher, "[", "(", -1), "]", ")", -1)
+	}
+	_, err := govaluate.NewEvaluableExpressionWithFunctions(matcher, functions)
+	if err != nil {
+		v.add(ModelDiagnosticError, entry, "", 0, "syntax error in the matcher: %s", err.Error())
+	}
+}
+
+func (v *modelValidator) validate(modelText string) (map[string]int, map[string]*modelEntry) {
+	v.parse(modelText)
+
+	sections := map[string]bool{}
+	for _, entry := range v.entries {
+		sections[entry.Section] = true
+	}
+	for _, section := range requiredModelSections {
+		if !sections[section] {
+			v.add(ModelDiagnosticError, nil, section, 0, "missing required section: [%s]", section)
+		}
+	}
+
+	// the arities of the policy types and the entries defining them
+	arities := map[string]int{}
+	definitions := map[string]*modelEntry{}
+
+	requestTokens := map[string][]string{}
+	for _, key := range v.getKeys("r") {
+		requestTokens[key] = v.getDefinitionTokens(key)
+	}
+
+	policyTokens := map[string][]string{}
+	for _, key := range v.getKeys("p") {
+		policyTokens[key] = v.getDefinitionTokens(key)
+		arities[key] = len(policyTokens[key])
+		definitions[key] = v.entries[key]
+	}
+
+	roleKeys := v.getKeys("g")
+	for _, key := range roleKeys {
+		arities[key] = v.getRoleDefinitionArity(key)
+		definitions[key] = v.entries[key]
+	}
+
+	for _, key := range v.getKeys("e") {
+		v.checkEffect(key, policyTokens)
+	}
+
+	for _, key := range v.getKeys("m") {
+		v.checkMatcher(key, requestTokens, policyTokens, roleKeys)
+	}
+
+	if len(v.diagnostics) == 0 {
+		_, err := model.NewModelFromString(modelText)
+		if err != nil {
+			v.add(ModelDiagnosticError, nil, "", 0, err.Error())
+		}
+	}
+
+	return arities, definitions
+}
+
+// checkPolicyArity reports the policies of the adapter that don't match the arities of the definitions of the model
+func (v *modelValidator) checkPolicyArity(arities map[string]int, definitions map[string]*modelEntry, policyCounts map[string]map[int]int) {
+	ptypes := []string{}
+	for ptype := range policyCounts {
+		ptypes = append(ptypes, ptype)
+	}
+	sort.Strings(ptypes)
+
+	for _, ptype := range ptypes {
+		arity, ok := arities[ptype]
+		if !ok {
+			total := 0
+			for _, count := range policyCounts[ptype] {
+				total += count
+			}
+			v.add(ModelDiagnosticError, nil, "", 0, "the adapter has %d policies of the type: %s which is not defined in the model", total, ptype)
+			continue
+		}
+
+		for size, count := range policyCounts[ptype] {
+			if size != arity {
+				v.add(ModelDiagnosticError, definitions[ptype], "", 0, "the adapter has %d policies of the type: %s with %d values, but the definition has %d fields", count, ptype, size, arity)
+			}
+		}
+	}
+}
+
+func newModelValidationResult(diagnostics []*ModelDiagnostic) *ModelValidationResult {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+
+	res := &ModelValidationResult{IsValid: true, Diagnostics: diagnostics}
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == ModelDiagnosticError {
+			res.IsValid = false
+		}
+	}
+	return res
+}
+
+// ValidateModelText checks the syntax, sections, definitions, effect and matchers of the model text, and the arities
+// of the policies of the adapter if policyCounts is not nil, which is the number of the policies by the policy type and
+// the number of the values
+func ValidateModelText(modelText string, policyCounts map[string]map[int]int) *ModelValidationResult {
+	v := &modelValidator{entries: map[string]*modelEntry{}, diagnostics: []*ModelDiagnostic{}}
+	arities, definitions := v.validate(modelText)
+	if policyCounts != nil {
+		v.checkPolicyArity(arities, definitions, policyCounts)
+	}
+	return newModelValidationResult(v.diagnostics)
+}
+
+// getAdapterPolicyCounts counts the policies stored in the adapter by the policy type and the number of the values
+func getAdapterPolicyCounts(adapterId string) (map[string]map[int]int, error) {
+	adapter, err := GetAdapter(adapterId)
+	if err != nil {
+		return nil, err
+	}
+	if adapter == nil {
+		return nil, fmt.Errorf("the adapter: %s is not found", adapterId)
+	}
+
+	err = adapter.InitAdapter()
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []*xormadapter.CasbinRule{}
+	err = adapter.engine.Table(adapter.policyTable).Find(&policies)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]map[int]int{}
+	for _, policy := range policies {
+		if res[policy.Ptype] == nil {
+			res[policy.Ptype] = map[int]int{}
+		}
+		res[policy.Ptype][len(util.CasbinToSlice(*policy))]++
+	}
+	return res, nil
+}
+
+// ValidateModel validates the model text, against the policies of the adapter if the adapter id is not empty
+func ValidateModel(modelText string, adapterId string) (*ModelValidationResult, error) {
+	var policyCounts map[string]map[int]int
+	if adapterId != "" {
+		var err error
+		policyCounts, err = getAdapterPolicyCounts(adapterId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ValidateModelText(modelText, policyCounts), nil
+}
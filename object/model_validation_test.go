@@ -0,0 +1,101 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testRbacModelText = `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[role_definition]
+g = _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub) && keyMatch(r.obj, p.obj) && r.act == p.act`
+
+func getModelDiagnosticMessages(result *ModelValidationResult, severity string) []string {
+	res := []string{}
+	for _, diagnostic := range result.Diagnostics {
+		if diagnostic.Severity == severity {
+			res = append(res, diagnostic.Message)
+		}
+	}
+	return res
+}
+
+func TestValidateModelText(t *testing.T) {
+	result := ValidateModelText(testRbacModelText, nil)
+	assert.True(t, result.IsValid)
+	assert.Empty(t, result.Diagnostics)
+
+	result = ValidateModelText("[request_definition]\nr = sub, obj\n\n[policy_definition]\np = sub, obj\n", nil)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, []string{"missing required section: [policy_effect]", "missing required section: [matchers]"}, getModelDiagnosticMessages(result, ModelDiagnosticError))
+
+	modelText := `[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == p.user && fooMatch(r.obj, p.obj) && r.act == p.act`
+	result = ValidateModelText(modelText, nil)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, 2, len(result.Diagnostics))
+	assert.Equal(t, 11, result.Diagnostics[0].Line)
+	assert.Equal(t, "m", result.Diagnostics[0].Key)
+	assert.Contains(t, result.Diagnostics[0].Message, "p.user")
+	assert.Contains(t, result.Diagnostics[1].Message, "fooMatch")
+
+	result = ValidateModelText("[request_definition]\nr = sub\nr3 = sub\n\n[policy_definition]\np = sub\n\n[policy_effect]\ne = some(where (p.eft == allow))\n\n[matchers]\nm = r.sub == p.sub && (\n", nil)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, 1, len(getModelDiagnosticMessages(result, ModelDiagnosticWarning)))
+	assert.Equal(t, 3, result.Diagnostics[0].Line)
+	assert.Contains(t, getModelDiagnosticMessages(result, ModelDiagnosticError)[0], "syntax error in the matcher")
+}
+
+func TestValidateModelTextWithPolicies(t *testing.T) {
+	policyCounts := map[string]map[int]int{
+		"p": {3: 10, 4: 2},
+		"g": {2: 5},
+	}
+	result := ValidateModelText(testRbacModelText, policyCounts)
+	assert.False(t, result.IsValid)
+	assert.Equal(t, 1, len(result.Diagnostics))
+	assert.Equal(t, "p", result.Diagnostics[0].Key)
+	assert.Equal(t, 5, result.Diagnostics[0].Line)
+
+	policyCounts = map[string]map[int]int{
+		"p":  {3: 10},
+		"g2": {2: 1},
+	}
+	result = ValidateModelText(testRbacModelText, policyCounts)
+	assert.False(t, result.IsValid)
+	assert.Contains(t, result.Diagnostics[0].Message, "g2")
+}
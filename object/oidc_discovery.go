@@ -19,6 +19,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"net"
+	"net/url"
 	"strings"
 
 	"github.com/casdoor/casdoor/conf"
@@ -32,6 +33,7 @@ type OidcDiscovery struct {
 	UserinfoEndpoint                       string   `json:"userinfo_endpoint"`
 	JwksUri                                string   `json:"jwks_uri"`
 	IntrospectionEndpoint                  string   `json:"introspection_endpoint"`
+	RevocationEndpoint                     string   `json:"revocation_endpoint"`
 	ResponseTypesSupported                 []string `json:"response_types_supported"`
 	ResponseModesSupported                 []string `json:"response_modes_supported"`
 	GrantTypesSupported                    []string `json:"grant_types_supported"`
@@ -42,6 +44,9 @@ type OidcDiscovery struct {
 	RequestParameterSupported              bool     `json:"request_parameter_supported"`
 	RequestObjectSigningAlgValuesSupported []string `json:"request_object_signing_alg_values_supported"`
 	EndSessionEndpoint                     string   `json:"end_session_endpoint"`
+	TokenMetadataEndpoint                  string   `json:"token_metadata_endpoint"`
+
+	TlsClientCertificateBoundAccessTokens bool `json:"tls_client_certificate_bound_access_tokens"`
 }
 
 func isIpAddress(host string) bool {
@@ -83,6 +88,12 @@ func getOriginFromHostInternal(host string) (string, string) {
 }
 
 func getOriginFromHost(host string) (string, string) {
+	// the custom domains serve both the frontend and the backend, e.g. the issuer is "https://auth.customer.com"
+	if isCustomDomainHost(host) {
+		origin := fmt.Sprintf("https://%s", host)
+		return origin, origin
+	}
+
 	originF, originB := getOriginFromHostInternal(host)
 
 	originFrontend := conf.GetConfigString("originFrontend")
@@ -93,6 +104,16 @@ func getOriginFromHost(host string) (string, string) {
 	return originF, originB
 }
 
+// GetFrontendHost returns the host serving the frontend pages, e.g. "door.casdoor.com"
+func GetFrontendHost(host string) string {
+	originFrontend, _ := getOriginFromHost(host)
+	u, err := url.Parse(originFrontend)
+	if err != nil || u.Host == "" {
+		return host
+	}
+	return u.Host
+}
+
 func GetOidcDiscovery(host string) OidcDiscovery {
 	originFrontend, originBackend := getOriginFromHost(host)
 
@@ -108,16 +129,20 @@ func GetOidcDiscovery(host string) OidcDiscovery {
 		UserinfoEndpoint:                       fmt.Sprintf("%s/api/userinfo", originBackend),
 		JwksUri:                                fmt.Sprintf("%s/.well-known/jwks", originBackend),
 		IntrospectionEndpoint:                  fmt.Sprintf("%s/api/login/oauth/introspect", originBackend),
+		RevocationEndpoint:                     fmt.Sprintf("%s/api/login/oauth/revoke", originBackend),
 		ResponseTypesSupported:                 []string{"code", "token", "id_token", "code token", "code id_token", "token id_token", "code token id_token", "none"},
 		ResponseModesSupported:                 []string{"query", "fragment", "login", "code", "link"},
 		GrantTypesSupported:                    []string{"password", "authorization_code"},
 		SubjectTypesSupported:                  []string{"public"},
 		IdTokenSigningAlgValuesSupported:       []string{"RS256"},
 		ScopesSupported:                        []string{"openid", "email", "profile", "address", "phone", "offline_access"},
-		ClaimsSupported:                        []string{"iss", "ver", "sub", "aud", "iat", "exp", "id", "type", "displayName", "avatar", "permanentAvatar", "email", "phone", "location", "affiliation", "title", "homepage", "bio", "tag", "region", "language", "score", "ranking", "isOnline", "isAdmin", "isForbidden", "signupApplication", "ldap"},
+		ClaimsSupported:                        []string{"iss", "ver", "sub", "aud", "iat", "exp", "id", "type", "displayName", "avatar", "permanentAvatar", "email", "phone", "location", "affiliation", "title", "homepage", "bio", "tag", "region", "language", "score", "ranking", "isOnline", "isAdmin", "isForbidden", "signupApplication", "ldap", "revocationEpoch"},
 		RequestParameterSupported:              true,
 		RequestObjectSigningAlgValuesSupported: []string{"HS256", "HS384", "HS512"},
 		EndSessionEndpoint:                     fmt.Sprintf("%s/api/logout", originBackend),
+		TokenMetadataEndpoint:                  fmt.Sprintf("%s/api/token-metadata", originBackend),
+
+		TlsClientCertificateBoundAccessTokens: true,
 	}
 
 	return oidcDiscovery
@@ -142,21 +167,43 @@ func GetJsonWebKeySet() (jose.JSONWebKeySet, error) {
 			return jwks, fmt.Errorf("the certificate field should not be empty for the cert: %v", cert)
 		}
 
-		certPemBlock := []byte(cert.Certificate)
-		certDerBlock, _ := pem.Decode(certPemBlock)
-		x509Cert, err := x509.ParseCertificate(certDerBlock.Bytes)
+		jwk, err := getJsonWebKey(cert.Certificate, cert.GetKeyId(), cert.CryptoAlgorithm)
 		if err != nil {
 			return jwks, err
 		}
-
-		var jwk jose.JSONWebKey
-		jwk.Key = x509Cert.PublicKey
-		jwk.Certificates = []*x509.Certificate{x509Cert}
-		jwk.KeyID = cert.Name
-		jwk.Algorithm = cert.CryptoAlgorithm
-		jwk.Use = "sig"
 		jwks.Keys = append(jwks.Keys, jwk)
+
+		// publish the rotated key during the grace period, so the tokens signed by it can still be verified
+		if cert.isPreviousKeyValid() {
+			jwk, err = getJsonWebKey(cert.PreviousCertificate, cert.PreviousKeyId, cert.CryptoAlgorithm)
+			if err != nil {
+				return jwks, err
+			}
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
 	}
 
 	return jwks, nil
 }
+
+func getJsonWebKey(certificate string, keyId string, algorithm string) (jose.JSONWebKey, error) {
+	var jwk jose.JSONWebKey
+
+	certPemBlock := []byte(certificate)
+	certDerBlock, _ := pem.Decode(certPemBlock)
+	if certDerBlock == nil {
+		return jwk, fmt.Errorf("failed to decode the certificate of the key: %s", keyId)
+	}
+
+	x509Cert, err := x509.ParseCertificate(certDerBlock.Bytes)
+	if err != nil {
+		return jwk, err
+	}
+
+	jwk.Key = x509Cert.PublicKey
+	jwk.Certificates = []*x509.Certificate{x509Cert}
+	jwk.KeyID = keyId
+	jwk.Algorithm = algorithm
+	jwk.Use = "sig"
+	return jwk, nil
+}
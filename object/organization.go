@@ -52,6 +52,7 @@ type Organization struct {
 	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
 
 	DisplayName            string     `xorm:"varchar(100)" json:"displayName"`
+	ParentOrganization     string     `xorm:"varchar(100)" json:"parentOrganization"`
 	WebsiteUrl             string     `xorm:"varchar(100)" json:"websiteUrl"`
 	Favicon                string     `xorm:"varchar(100)" json:"favicon"`
 	PasswordType           string     `xorm:"varchar(100)" json:"passwordType"`
@@ -69,9 +70,42 @@ type Organization struct {
 	InitScore              int        `json:"initScore"`
 	EnableSoftDeletion     bool       `json:"enableSoftDeletion"`
 	IsProfilePublic        bool       `json:"isProfilePublic"`
+	ShareEnforcer          string     `xorm:"varchar(100)" json:"shareEnforcer"`
 
-	MfaItems     []*MfaItem     `xorm:"varchar(300)" json:"mfaItems"`
-	AccountItems []*AccountItem `xorm:"varchar(5000)" json:"accountItems"`
+	MfaItems      []*MfaItem     `xorm:"varchar(300)" json:"mfaItems"`
+	MfaPolicy     *MfaPolicy     `xorm:"json" json:"mfaPolicy"`
+	AccountItems  []*AccountItem `xorm:"varchar(5000)" json:"accountItems"`
+	SmsProviders  []string       `xorm:"varchar(1000)" json:"smsProviders"`
+	EmailProvider string         `xorm:"varchar(100)" json:"emailProvider"`
+	SiemExporter  *SiemExporter  `xorm:"json" json:"siemExporter"`
+
+	ConditionalAccessPolicies []*ConditionalAccessPolicy `xorm:"mediumtext" json:"conditionalAccessPolicies"`
+	VerificationCodePolicy    *VerificationCodePolicy    `xorm:"json" json:"verificationCodePolicy"`
+
+	LifecyclePolicy *LifecyclePolicy `xorm:"json" json:"lifecyclePolicy"`
+
+	UsageBilling *UsageBilling `xorm:"json" json:"usageBilling"`
+
+	RetentionPolicy *RetentionPolicy `xorm:"json" json:"retentionPolicy"`
+
+	CustomDomains []*CustomDomain `xorm:"mediumtext" json:"customDomains"`
+	// ClaimedDomains are the email domains of the organization, the users signing up with the emails of the
+	// verified domains are routed into the organization
+	ClaimedDomains []*ClaimedDomain `xorm:"mediumtext" json:"claimedDomains"`
+
+	// EnableChangeApproval holds the changes of the sensitive objects for the approval of a second admin
+	EnableChangeApproval bool `json:"enableChangeApproval"`
+
+	UsernamePolicy *UsernamePolicy `xorm:"json" json:"usernamePolicy"`
+
+	// LdapBaseDn is the base DN of the organization served by the LDAP server, e.g. "dc=example,dc=com"
+	LdapBaseDn string `xorm:"varchar(200)" json:"ldapBaseDn"`
+
+	RecoveryPolicy *RecoveryPolicy `xorm:"json" json:"recoveryPolicy"`
+
+	RecordRedaction *RecordRedaction `xorm:"json" json:"recordRedaction"`
+
+	AuthHooks []*AuthHook `xorm:"mediumtext" json:"authHooks"`
 }
 
 func GetOrganizationCount(owner, field, value string) (int64, error) {
@@ -184,7 +218,8 @@ func GetMaskedOrganizations(organizations []*Organization, errs ...error) ([]*Or
 
 func UpdateOrganization(id string, organization *Organization) (bool, error) {
 	owner, name := util.GetOwnerAndNameFromId(id)
-	if org, err := getOrganization(owner, name); err != nil {
+	org, err := getOrganization(owner, name)
+	if err != nil {
 		return false, err
 	} else if org == nil {
 		return false, nil
@@ -201,6 +236,75 @@ func UpdateOrganization(id string, organization *Organization) (bool, error) {
 		}
 	}
 
+	setMfaPolicyEnabledTime(org.MfaPolicy, organization.MfaPolicy)
+	setCustomDomainVerification(org.CustomDomains, organization.CustomDomains)
+	setClaimedDomainVerification(org.ClaimedDomains, organization.ClaimedDomains)
+
+	err = checkConditionalAccessPolicies(organization.ConditionalAccessPolicies)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkParentOrganization(organization)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkVerificationCodePolicy(organization.VerificationCodePolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkLifecyclePolicy(organization.LifecyclePolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkUsageBilling(organization.UsageBilling)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkRetentionPolicy(organization.RetentionPolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkCustomDomains(organization)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkClaimedDomains(organization)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkUsernamePolicy(organization.UsernamePolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkLdapBaseDn(organization)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkRecoveryPolicy(organization.RecoveryPolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkRecordRedaction(organization.RecordRedaction)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkAuthHooks(organization.AuthHooks)
+	if err != nil {
+		return false, err
+	}
+
 	if organization.MasterPassword != "" && organization.MasterPassword != "***" {
 		credManager := cred.GetCredManager(organization.PasswordType)
 		if credManager != nil {
@@ -226,10 +330,84 @@ func UpdateOrganization(id string, organization *Organization) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		clearRecordRedactionCache(name)
+		publishCacheInvalidation(CacheTypeOrganization, id)
+	}
+
 	return affected != 0, nil
 }
 
 func AddOrganization(organization *Organization) (bool, error) {
+	setMfaPolicyEnabledTime(nil, organization.MfaPolicy)
+	setCustomDomainVerification(nil, organization.CustomDomains)
+	setClaimedDomainVerification(nil, organization.ClaimedDomains)
+
+	err := checkConditionalAccessPolicies(organization.ConditionalAccessPolicies)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkParentOrganization(organization)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkVerificationCodePolicy(organization.VerificationCodePolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkLifecyclePolicy(organization.LifecyclePolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkUsageBilling(organization.UsageBilling)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkRetentionPolicy(organization.RetentionPolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkCustomDomains(organization)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkClaimedDomains(organization)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkUsernamePolicy(organization.UsernamePolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkLdapBaseDn(organization)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkRecoveryPolicy(organization.RecoveryPolicy)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkRecordRedaction(organization.RecordRedaction)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkAuthHooks(organization.AuthHooks)
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.Insert(organization)
 	if err != nil {
 		return false, err
@@ -248,6 +426,17 @@ func DeleteOrganization(organization *Organization) (bool, error) {
 		return false, err
 	}
 
+	// the child organizations are moved up to the parent of the deleted organization
+	_, err = ormer.Engine.Where("parent_organization = ?", organization.Name).Cols("parent_organization").Update(&Organization{ParentOrganization: organization.ParentOrganization})
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 0 {
+		clearRecordRedactionCache(organization.Name)
+		publishCacheInvalidation(CacheTypeOrganization, util.GetId(organization.Owner, organization.Name))
+	}
+
 	return affected != 0, nil
 }
 
@@ -256,7 +445,12 @@ func GetOrganizationByUser(user *User) (*Organization, error) {
 		return nil, nil
 	}
 
-	return getOrganization("admin", user.Owner)
+	organization, err := getOrganization("admin", user.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetInheritedOrganization(organization)
 }
 
 func GetAccountItemByName(name string, organization *Organization) *AccountItem {
@@ -368,6 +562,13 @@ func organizationChangeTrigger(oldName string, newName string) error {
 		return err
 	}
 
+	organization := new(Organization)
+	organization.ParentOrganization = newName
+	_, err = session.Where("parent_organization=?", oldName).Update(organization)
+	if err != nil {
+		return err
+	}
+
 	group := new(Group)
 	group.Owner = newName
 	_, err = session.Where("owner=?", oldName).Update(group)
@@ -499,6 +700,9 @@ func IsNeedPromptMfa(org *Organization, user *User) bool {
 			if item.Name == TotpType && user.TotpSecret == "" {
 				return true
 			}
+			if item.Name == PushType && !user.MfaPushEnabled {
+				return true
+			}
 		}
 	}
 	return false
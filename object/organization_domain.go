@@ -0,0 +1,220 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const customDomainChallengePrefix = "_casdoor-challenge."
+
+var (
+	reCustomDomain = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+	lookupTxt = net.LookupTXT
+)
+
+// CustomDomain is a domain of the organization serving the login pages and the OIDC endpoints, e.g.
+// "auth.customer.com". The domain is only used after its ownership is verified by a DNS TXT record.
+type CustomDomain struct {
+	Domain            string `json:"domain"`
+	VerificationToken string `json:"verificationToken"`
+	IsVerified        bool   `json:"isVerified"`
+	VerifiedTime      string `json:"verifiedTime"`
+	// EnableAcme issues and renews the TLS certificate of the domain by ACME (Let's Encrypt)
+	EnableAcme bool `json:"enableAcme"`
+
+	Logo      string     `json:"logo"`
+	ThemeData *ThemeData `json:"themeData"`
+}
+
+// GetVerificationRecord returns the name and the value of the DNS TXT record proving the ownership of the domain
+func (domain *CustomDomain) GetVerificationRecord() (string, string) {
+	return customDomainChallengePrefix + domain.Domain, domain.VerificationToken
+}
+
+// getHostDomain returns the domain of the host without the port, e.g. "auth.customer.com" for "auth.customer.com:443"
+func getHostDomain(host string) string {
+	domain, _, err := net.SplitHostPort(host)
+	if err != nil {
+		domain = host
+	}
+	return strings.TrimSuffix(strings.ToLower(domain), ".")
+}
+
+func checkCustomDomains(organization *Organization) error {
+	domains := map[string]bool{}
+	for _, customDomain := range organization.CustomDomains {
+		customDomain.Domain = getHostDomain(strings.TrimSpace(customDomain.Domain))
+		if !reCustomDomain.MatchString(customDomain.Domain) {
+			return fmt.Errorf("the custom domain: %s is not a valid domain name", customDomain.Domain)
+		}
+		if domains[customDomain.Domain] {
+			return fmt.Errorf("the custom domain: %s is duplicated", customDomain.Domain)
+		}
+		domains[customDomain.Domain] = true
+
+		owner, _, err := getOrganizationByCustomDomain(customDomain.Domain, false)
+		if err != nil {
+			return err
+		}
+		if owner != nil && owner.Name != organization.Name {
+			return fmt.Errorf("the custom domain: %s is used by another organization", customDomain.Domain)
+		}
+	}
+
+	return nil
+}
+
+// setCustomDomainVerification keeps the verification of the existing domains, the new domains get new tokens
+// and need to be verified, so the domains can't be marked as verified by updating the organization
+func setCustomDomainVerification(oldDomains []*CustomDomain, newDomains []*CustomDomain) {
+	verifiedDomains := map[string]*CustomDomain{}
+	for _, domain := range oldDomains {
+		verifiedDomains[domain.Domain] = domain
+	}
+
+	for _, domain := range newDomains {
+		domain.Domain = getHostDomain(strings.TrimSpace(domain.Domain))
+		if oldDomain, ok := verifiedDomains[domain.Domain]; ok {
+			domain.VerificationToken = oldDomain.VerificationToken
+			domain.IsVerified = oldDomain.IsVerified
+			domain.VerifiedTime = oldDomain.VerifiedTime
+		} else {
+			domain.VerificationToken = fmt.Sprintf("casdoor-verification=%s", util.GenerateId())
+			domain.IsVerified = false
+			domain.VerifiedTime = ""
+		}
+	}
+}
+
+// getOrganizationByCustomDomain returns the organization with the custom domain, which is verified if required
+func getOrganizationByCustomDomain(domain string, isVerified bool) (*Organization, *CustomDomain, error) {
+	organizations := []*Organization{}
+	err := ormer.Engine.Where("custom_domains like ?", "%\""+domain+"\"%").Find(&organizations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, organization := range organizations {
+		for _, customDomain := range organization.CustomDomains {
+			if customDomain.Domain == domain && (customDomain.IsVerified || !isVerified) {
+				return organization, customDomain, nil
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// GetOrganizationByCustomDomain returns the organization whose verified custom domain is the host
+func GetOrganizationByCustomDomain(host string) (*Organization, *CustomDomain, error) {
+	domain := getHostDomain(host)
+	if !reCustomDomain.MatchString(domain) {
+		return nil, nil, nil
+	}
+	return getOrganizationByCustomDomain(domain, true)
+}
+
+func isCustomDomainHost(host string) bool {
+	organization, _, err := GetOrganizationByCustomDomain(host)
+	return err == nil && organization != nil
+}
+
+// IsAcmeDomainAllowed checks whether Casdoor issues the TLS certificate of the domain by ACME
+func IsAcmeDomainAllowed(host string) error {
+	_, customDomain, err := GetOrganizationByCustomDomain(host)
+	if err != nil {
+		return err
+	}
+
+	if customDomain == nil || !customDomain.EnableAcme {
+		return fmt.Errorf("the domain: %s is not a verified custom domain with ACME enabled", host)
+	}
+	return nil
+}
+
+// VerifyCustomDomain looks up the DNS TXT record of the custom domain and marks the domain as verified
+// if the record has the verification token
+func VerifyCustomDomain(id string, domain string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	organization, err := getOrganization(owner, name)
+	if err != nil {
+		return false, err
+	}
+	if organization == nil {
+		return false, fmt.Errorf("the organization: %s does not exist", id)
+	}
+
+	var customDomain *CustomDomain
+	for _, d := range organization.CustomDomains {
+		if d.Domain == getHostDomain(domain) {
+			customDomain = d
+		}
+	}
+	if customDomain == nil {
+		return false, fmt.Errorf("the custom domain: %s is not found in the organization: %s", domain, id)
+	}
+
+	recordName, recordValue := customDomain.GetVerificationRecord()
+	values, err := lookupTxt(recordName)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up the TXT record: %s, error: %s", recordName, err.Error())
+	}
+	if !util.InSlice(values, recordValue) {
+		return false, fmt.Errorf("the TXT record: %s should have the value: %s", recordName, recordValue)
+	}
+
+	if customDomain.IsVerified {
+		return false, nil
+	}
+
+	customDomain.IsVerified = true
+	customDomain.VerifiedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).Cols("custom_domains").Update(organization)
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 0 {
+		publishCacheInvalidation(CacheTypeOrganization, id)
+	}
+	return affected != 0, nil
+}
+
+// ApplyCustomDomain brands the application for the login pages served by the custom domain of its organization
+func ApplyCustomDomain(application *Application, host string) error {
+	if application == nil {
+		return nil
+	}
+
+	organization, customDomain, err := GetOrganizationByCustomDomain(host)
+	if err != nil || organization == nil || organization.Name != application.Organization {
+		return err
+	}
+
+	if customDomain.Logo != "" {
+		application.Logo = customDomain.Logo
+	}
+	if customDomain.ThemeData != nil && customDomain.ThemeData.IsEnabled {
+		application.ThemeData = customDomain.ThemeData
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHostDomain(t *testing.T) {
+	assert.Equal(t, "auth.customer.com", getHostDomain("auth.customer.com:443"))
+	assert.Equal(t, "auth.customer.com", getHostDomain("Auth.Customer.com."))
+	assert.Equal(t, "localhost", getHostDomain("localhost:8000"))
+}
+
+func TestSetCustomDomainVerification(t *testing.T) {
+	oldDomains := []*CustomDomain{
+		{Domain: "auth.customer.com", VerificationToken: "casdoor-verification=1", IsVerified: true, VerifiedTime: "2023-06-01T00:00:00Z"},
+	}
+	newDomains := []*CustomDomain{
+		{Domain: "Auth.Customer.com", IsVerified: false},
+		{Domain: "login.customer.com", VerificationToken: "forged", IsVerified: true, VerifiedTime: "2023-06-01T00:00:00Z"},
+	}
+	setCustomDomainVerification(oldDomains, newDomains)
+
+	// the existing domain keeps its verification
+	assert.Equal(t, "auth.customer.com", newDomains[0].Domain)
+	assert.Equal(t, "casdoor-verification=1", newDomains[0].VerificationToken)
+	assert.True(t, newDomains[0].IsVerified)
+
+	// the new domain can't be marked as verified by the client
+	assert.True(t, strings.HasPrefix(newDomains[1].VerificationToken, "casdoor-verification="))
+	assert.NotEqual(t, "casdoor-verification=1", newDomains[1].VerificationToken)
+	assert.False(t, newDomains[1].IsVerified)
+	assert.Empty(t, newDomains[1].VerifiedTime)
+
+	name, value := newDomains[1].GetVerificationRecord()
+	assert.Equal(t, "_casdoor-challenge.login.customer.com", name)
+	assert.Equal(t, newDomains[1].VerificationToken, value)
+}
+
+func TestCustomDomainPattern(t *testing.T) {
+	assert.True(t, reCustomDomain.MatchString("auth.customer.com"))
+	assert.True(t, reCustomDomain.MatchString("a-b.example.io"))
+	assert.False(t, reCustomDomain.MatchString("localhost"))
+	assert.False(t, reCustomDomain.MatchString("-auth.customer.com"))
+	assert.False(t, reCustomDomain.MatchString("auth.customer.com/path"))
+	assert.False(t, reCustomDomain.MatchString("192.168.0.1"))
+}
@@ -0,0 +1,226 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const claimedDomainChallengePrefix = "_casdoor-email-challenge."
+
+// ClaimedDomain is an email domain claimed by the organization, e.g. "customer.com". The claim is only enforced
+// after its ownership is verified by a DNS TXT record.
+type ClaimedDomain struct {
+	Domain            string `json:"domain"`
+	VerificationToken string `json:"verificationToken"`
+	IsVerified        bool   `json:"isVerified"`
+	VerifiedTime      string `json:"verifiedTime"`
+}
+
+// GetVerificationRecord returns the name and the value of the DNS TXT record proving the ownership of the domain
+func (domain *ClaimedDomain) GetVerificationRecord() (string, string) {
+	return claimedDomainChallengePrefix + domain.Domain, domain.VerificationToken
+}
+
+func getEmailDomain(email string) string {
+	i := strings.LastIndex(email, "@")
+	if i == -1 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(email[i+1:])), ".")
+}
+
+func checkClaimedDomains(organization *Organization) error {
+	domains := map[string]bool{}
+	for _, claimedDomain := range organization.ClaimedDomains {
+		claimedDomain.Domain = getHostDomain(strings.TrimSpace(claimedDomain.Domain))
+		if !reCustomDomain.MatchString(claimedDomain.Domain) {
+			return fmt.Errorf("the claimed domain: %s is not a valid domain name", claimedDomain.Domain)
+		}
+		if isPublicEmailDomain(claimedDomain.Domain) {
+			return fmt.Errorf("the claimed domain: %s is a public email domain", claimedDomain.Domain)
+		}
+		if domains[claimedDomain.Domain] {
+			return fmt.Errorf("the claimed domain: %s is duplicated", claimedDomain.Domain)
+		}
+		domains[claimedDomain.Domain] = true
+
+		owner, _, err := getOrganizationByClaimedDomain(claimedDomain.Domain, true)
+		if err != nil {
+			return err
+		}
+		if owner != nil && owner.Name != organization.Name {
+			return fmt.Errorf("the claimed domain: %s is verified by another organization", claimedDomain.Domain)
+		}
+	}
+
+	return nil
+}
+
+// setClaimedDomainVerification keeps the verification of the existing domains, the new domains get new tokens
+// and need to be verified
+func setClaimedDomainVerification(oldDomains []*ClaimedDomain, newDomains []*ClaimedDomain) {
+	verifiedDomains := map[string]*ClaimedDomain{}
+	for _, domain := range oldDomains {
+		verifiedDomains[domain.Domain] = domain
+	}
+
+	for _, domain := range newDomains {
+		domain.Domain = getHostDomain(strings.TrimSpace(domain.Domain))
+		if oldDomain, ok := verifiedDomains[domain.Domain]; ok {
+			domain.VerificationToken = oldDomain.VerificationToken
+			domain.IsVerified = oldDomain.IsVerified
+			domain.VerifiedTime = oldDomain.VerifiedTime
+		} else {
+			domain.VerificationToken = fmt.Sprintf("casdoor-verification=%s", util.GenerateId())
+			domain.IsVerified = false
+			domain.VerifiedTime = ""
+		}
+	}
+}
+
+// getOrganizationByClaimedDomain returns the organization claiming the email domain, which is verified if required
+func getOrganizationByClaimedDomain(domain string, isVerified bool) (*Organization, *ClaimedDomain, error) {
+	organizations := []*Organization{}
+	err := ormer.Engine.Where("claimed_domains like ?", "%\""+domain+"\"%").Find(&organizations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, organization := range organizations {
+		for _, claimedDomain := range organization.ClaimedDomains {
+			if claimedDomain.Domain == domain && (claimedDomain.IsVerified || !isVerified) {
+				return organization, claimedDomain, nil
+			}
+		}
+	}
+	return nil, nil, nil
+}
+
+// GetEmailDomainOrganization returns the organization the user with the email belongs to, which is the organization
+// verifying the domain of the email, or the organization as is if the domain isn't claimed
+func GetEmailDomainOrganization(organization string, email string) (string, error) {
+	domain := getEmailDomain(email)
+	if !reCustomDomain.MatchString(domain) {
+		return organization, nil
+	}
+
+	owner, _, err := getOrganizationByClaimedDomain(domain, true)
+	if err != nil {
+		return "", err
+	}
+	if owner == nil {
+		return organization, nil
+	}
+	return owner.Name, nil
+}
+
+// CheckEmailDomainClaim prevents the user with the email of a domain claimed by another organization from joining
+// the organization
+func CheckEmailDomainClaim(organization string, email string, lang string) error {
+	owner, err := GetEmailDomainOrganization(organization, email)
+	if err != nil {
+		return err
+	}
+
+	if owner != organization {
+		return fmt.Errorf(i18n.Translate(lang, "organization:The email domain: %s is claimed by the organization: %s"), getEmailDomain(email), owner)
+	}
+	return nil
+}
+
+// GetEmailDomainSignupApplication returns the application the user with the verified email signs up with. If the
+// email domain is verified by another organization, the user is routed into it with its default application,
+// which should allow signing up, so the signup policy of the claiming organization applies
+func GetEmailDomainSignupApplication(application *Application, organization string, email string, lang string) (*Application, error) {
+	owner, err := GetEmailDomainOrganization(organization, email)
+	if err != nil {
+		return nil, err
+	}
+	if owner == organization {
+		return application, nil
+	}
+
+	defaultApplication, err := GetDefaultApplication(util.GetId("admin", owner))
+	if err != nil {
+		return nil, err
+	}
+	if !defaultApplication.EnableSignUp {
+		return nil, fmt.Errorf(i18n.Translate(lang, "organization:The email domain: %s is claimed by the organization: %s"), getEmailDomain(email), owner)
+	}
+
+	return GetApplication(defaultApplication.GetId())
+}
+
+// VerifyClaimedDomain looks up the DNS TXT record of the claimed domain and marks the domain as verified
+// if the record has the verification token
+func VerifyClaimedDomain(id string, domain string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	organization, err := getOrganization(owner, name)
+	if err != nil {
+		return false, err
+	}
+	if organization == nil {
+		return false, fmt.Errorf("the organization: %s does not exist", id)
+	}
+
+	var claimedDomain *ClaimedDomain
+	for _, d := range organization.ClaimedDomains {
+		if d.Domain == getHostDomain(domain) {
+			claimedDomain = d
+		}
+	}
+	if claimedDomain == nil {
+		return false, fmt.Errorf("the claimed domain: %s is not found in the organization: %s", domain, id)
+	}
+
+	claimingOrganization, _, err := getOrganizationByClaimedDomain(claimedDomain.Domain, true)
+	if err != nil {
+		return false, err
+	}
+	if claimingOrganization != nil && claimingOrganization.Name != organization.Name {
+		return false, fmt.Errorf("the claimed domain: %s is verified by another organization", claimedDomain.Domain)
+	}
+
+	recordName, recordValue := claimedDomain.GetVerificationRecord()
+	values, err := lookupTxt(recordName)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up the TXT record: %s, error: %s", recordName, err.Error())
+	}
+	if !util.InSlice(values, recordValue) {
+		return false, fmt.Errorf("the TXT record: %s should have the value: %s", recordName, recordValue)
+	}
+
+	if claimedDomain.IsVerified {
+		return false, nil
+	}
+
+	claimedDomain.IsVerified = true
+	claimedDomain.VerifiedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).Cols("claimed_domains").Update(organization)
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 0 {
+		publishCacheInvalidation(CacheTypeOrganization, id)
+	}
+	return affected != 0, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEmailDomain(t *testing.T) {
+	assert.Equal(t, "customer.com", getEmailDomain("alice@Customer.com"))
+	assert.Equal(t, "customer.com", getEmailDomain("alice@customer.com."))
+	assert.Equal(t, "", getEmailDomain("alice"))
+}
+
+func TestSetClaimedDomainVerification(t *testing.T) {
+	oldDomains := []*ClaimedDomain{
+		{Domain: "customer.com", VerificationToken: "casdoor-verification=1", IsVerified: true, VerifiedTime: "2023-06-01T00:00:00Z"},
+	}
+	newDomains := []*ClaimedDomain{
+		{Domain: "Customer.com"},
+		{Domain: "customer.org", VerificationToken: "forged", IsVerified: true, VerifiedTime: "2023-06-01T00:00:00Z"},
+	}
+	setClaimedDomainVerification(oldDomains, newDomains)
+
+	assert.Equal(t, "customer.com", newDomains[0].Domain)
+	assert.Equal(t, "casdoor-verification=1", newDomains[0].VerificationToken)
+	assert.True(t, newDomains[0].IsVerified)
+
+	assert.True(t, strings.HasPrefix(newDomains[1].VerificationToken, "casdoor-verification="))
+	assert.False(t, newDomains[1].IsVerified)
+	assert.Empty(t, newDomains[1].VerifiedTime)
+
+	name, value := newDomains[1].GetVerificationRecord()
+	assert.Equal(t, "_casdoor-email-challenge.customer.org", name)
+	assert.Equal(t, newDomains[1].VerificationToken, value)
+}
+
+func TestCheckClaimedDomainsInvalid(t *testing.T) {
+	for _, domain := range []string{"localhost", "gmail.com"} {
+		organization := &Organization{ClaimedDomains: []*ClaimedDomain{{Domain: domain}}}
+		assert.NotNil(t, checkClaimedDomains(organization), domain)
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+)
+
+const maxOrganizationDepth = 10
+
+// getOrganizationAncestors returns the parent organizations of the organization, from the nearest one to the root
+func getOrganizationAncestors(organization *Organization) ([]*Organization, error) {
+	res := []*Organization{}
+	visited := map[string]bool{organization.Name: true}
+
+	parentName := organization.ParentOrganization
+	for parentName != "" {
+		if visited[parentName] {
+			return nil, fmt.Errorf("the parent organizations of the organization: %s form a cycle", organization.Name)
+		}
+		if len(res) >= maxOrganizationDepth {
+			return nil, fmt.Errorf("the organization: %s is nested deeper than %d levels", organization.Name, maxOrganizationDepth)
+		}
+		visited[parentName] = true
+
+		parent, err := getOrganization("admin", parentName)
+		if err != nil {
+			return nil, err
+		}
+		if parent == nil {
+			break
+		}
+
+		res = append(res, parent)
+		parentName = parent.ParentOrganization
+	}
+
+	return res, nil
+}
+
+// IsOrganizationInSubtree checks whether the organization is the given ancestor organization or one of its descendants
+func IsOrganizationInSubtree(ancestor string, name string) (bool, error) {
+	if ancestor == "" || name == "" {
+		return false, nil
+	}
+	if ancestor == name {
+		return true, nil
+	}
+
+	organization, err := getOrganization("admin", name)
+	if err != nil {
+		return false, err
+	}
+	if organization == nil || organization.ParentOrganization == "" {
+		return false, nil
+	}
+
+	ancestors, err := getOrganizationAncestors(organization)
+	if err != nil {
+		return false, err
+	}
+
+	for _, org := range ancestors {
+		if org.Name == ancestor {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// inheritOrganization returns a copy of the organization whose providers, password policy and theme
+// that are not set are inherited from the nearest ancestor that sets them
+func inheritOrganization(organization *Organization, ancestors []*Organization) *Organization {
+	res := *organization
+	for _, parent := range ancestors {
+		if res.PasswordType == "" && parent.PasswordType != "" {
+			res.PasswordType = parent.PasswordType
+			// the salt of the child is kept for the password hashes of its existing users
+			if res.PasswordSalt == "" {
+				res.PasswordSalt = parent.PasswordSalt
+			}
+		}
+		if len(res.PasswordOptions) == 0 {
+			res.PasswordOptions = parent.PasswordOptions
+		}
+		if res.ThemeData == nil || !res.ThemeData.IsEnabled {
+			res.ThemeData = parent.ThemeData
+		}
+		if res.Favicon == "" {
+			res.Favicon = parent.Favicon
+		}
+		if res.DefaultAvatar == "" {
+			res.DefaultAvatar = parent.DefaultAvatar
+		}
+		if len(res.SmsProviders) == 0 {
+			res.SmsProviders = parent.SmsProviders
+		}
+		if res.EmailProvider == "" {
+			res.EmailProvider = parent.EmailProvider
+		}
+		if res.VerificationCodePolicy == nil {
+			res.VerificationCodePolicy = parent.VerificationCodePolicy
+		}
+	}
+
+	return &res
+}
+
+// GetInheritedOrganization returns the effective settings of the organization with the ones inherited from its ancestors
+func GetInheritedOrganization(organization *Organization) (*Organization, error) {
+	if organization == nil || organization.ParentOrganization == "" {
+		return organization, nil
+	}
+
+	ancestors, err := getOrganizationAncestors(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	return inheritOrganization(organization, ancestors), nil
+}
+
+func checkParentOrganization(organization *Organization) error {
+	if organization.ParentOrganization == "" {
+		return nil
+	}
+
+	if organization.Name == "built-in" {
+		return fmt.Errorf("the built-in organization should not have a parent organization")
+	}
+	if organization.ParentOrganization == organization.Name {
+		return fmt.Errorf("the organization: %s should not be its own parent organization", organization.Name)
+	}
+
+	parent, err := getOrganization("admin", organization.ParentOrganization)
+	if err != nil {
+		return err
+	}
+	if parent == nil {
+		return fmt.Errorf("the parent organization: %s is not found", organization.ParentOrganization)
+	}
+
+	// the ancestors are checked as if the organization was saved, to reject the cycles and the too deep trees
+	_, err = getOrganizationAncestors(organization)
+	return err
+}
+
+// getProviderOwners returns the owners whose providers are available to the organization, in the order of precedence
+func getProviderOwners(owner string) ([]string, error) {
+	res := []string{owner}
+	if owner == "admin" {
+		return res, nil
+	}
+
+	organization, err := getOrganization("admin", owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if organization != nil && organization.ParentOrganization != "" {
+		ancestors, err := getOrganizationAncestors(organization)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, parent := range ancestors {
+			res = append(res, parent.Name)
+		}
+	}
+
+	return append(res, "admin"), nil
+}
+
+// getOrganizationProvider returns the provider by name that is available to the organization
+func getOrganizationProvider(organization *Organization, name string) (*Provider, error) {
+	owners, err := getProviderOwners(organization.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, owner := range owners {
+		provider, err := getProvider(owner, name)
+		if err != nil {
+			return nil, err
+		}
+		if provider != nil {
+			return provider, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInheritOrganization(t *testing.T) {
+	enterprise := &Organization{
+		Name:            "enterprise",
+		PasswordType:    "bcrypt",
+		PasswordSalt:    "salt",
+		PasswordOptions: []string{"AtLeast8"},
+		ThemeData:       &ThemeData{ColorPrimary: "#000000", IsEnabled: true},
+		Favicon:         "https://example.com/favicon.png",
+		SmsProviders:    []string{"sms-enterprise"},
+		EmailProvider:   "email-enterprise",
+	}
+	division := &Organization{
+		Name:               "division",
+		ParentOrganization: "enterprise",
+		PasswordOptions:    []string{"AtLeast6", "Aa123"},
+		ThemeData:          &ThemeData{ColorPrimary: "#ffffff"},
+		EmailProvider:      "email-division",
+	}
+	department := &Organization{
+		Name:               "department",
+		ParentOrganization: "division",
+		Favicon:            "https://example.com/department.png",
+	}
+
+	res := inheritOrganization(department, []*Organization{division, enterprise})
+	assert.Equal(t, "bcrypt", res.PasswordType)
+	assert.Equal(t, "salt", res.PasswordSalt)
+	assert.Equal(t, []string{"AtLeast6", "Aa123"}, res.PasswordOptions)
+	assert.Equal(t, "#000000", res.ThemeData.ColorPrimary)
+	assert.Equal(t, "https://example.com/department.png", res.Favicon)
+	assert.Equal(t, []string{"sms-enterprise"}, res.SmsProviders)
+	assert.Equal(t, "email-division", res.EmailProvider)
+	assert.Equal(t, "department", res.Name)
+	assert.Equal(t, "", department.PasswordType)
+
+	res = inheritOrganization(&Organization{Name: "child", PasswordType: "plain"}, []*Organization{enterprise})
+	assert.Equal(t, "plain", res.PasswordType)
+	assert.Equal(t, "", res.PasswordSalt)
+}
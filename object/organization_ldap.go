@@ -0,0 +1,75 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NormalizeLdapDn lowercases the DN and removes the spaces around its RDNs, e.g. "DC=Acme, DC=com" to "dc=acme,dc=com"
+func NormalizeLdapDn(dn string) string {
+	rdns := strings.Split(dn, ",")
+	for i, rdn := range rdns {
+		rdns[i] = strings.ToLower(strings.TrimSpace(rdn))
+	}
+	return strings.Join(rdns, ",")
+}
+
+// isLdapDnInBase checks whether the DN is the base DN or an entry under it
+func isLdapDnInBase(dn string, baseDn string) bool {
+	dn, baseDn = NormalizeLdapDn(dn), NormalizeLdapDn(baseDn)
+	return dn == baseDn || strings.HasSuffix(dn, ","+baseDn)
+}
+
+func checkLdapBaseDn(organization *Organization) error {
+	if organization.LdapBaseDn == "" {
+		return nil
+	}
+
+	organization.LdapBaseDn = NormalizeLdapDn(organization.LdapBaseDn)
+	for _, rdn := range strings.Split(organization.LdapBaseDn, ",") {
+		if !strings.Contains(rdn, "=") || strings.HasPrefix(rdn, "=") {
+			return fmt.Errorf("the LDAP base DN: %s is invalid, it should be like: dc=example,dc=com", organization.LdapBaseDn)
+		}
+	}
+
+	owner, err := GetOrganizationByLdapDn(organization.LdapBaseDn)
+	if err != nil {
+		return err
+	}
+	if owner != nil && owner.Name != organization.Name && owner.LdapBaseDn == organization.LdapBaseDn {
+		return fmt.Errorf("the LDAP base DN: %s is used by another organization", organization.LdapBaseDn)
+	}
+	return nil
+}
+
+// GetOrganizationByLdapDn returns the organization whose LDAP base DN contains the DN, the most specific
+// base DN wins, e.g. the organization of "dc=acme,dc=com" for "uid=alice,ou=people,dc=acme,dc=com"
+func GetOrganizationByLdapDn(dn string) (*Organization, error) {
+	organizations := []*Organization{}
+	err := ormer.Engine.Where("ldap_base_dn != ?", "").Find(&organizations)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *Organization
+	for _, organization := range organizations {
+		if isLdapDnInBase(dn, organization.LdapBaseDn) && (res == nil || len(organization.LdapBaseDn) > len(res.LdapBaseDn)) {
+			res = organization
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,355 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/builder"
+	"github.com/xorm-io/core"
+)
+
+// OrganizationMembership makes a user of another organization a member of the organization, so the user signs in to
+// the applications of the organization with the account of its home organization instead of a duplicated account.
+// The member is added to the roles of the membership, and the properties of the membership override the ones of
+// the user in the tokens of the applications of the organization.
+type OrganizationMembership struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	// User is the id of the member in its home organization
+	User       string            `xorm:"varchar(100) index" json:"user"`
+	IsEnabled  bool              `json:"isEnabled"`
+	Roles      []string          `xorm:"mediumtext" json:"roles"`
+	Properties map[string]string `xorm:"mediumtext" json:"properties"`
+}
+
+func GetOrganizationMembershipCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&OrganizationMembership{})
+}
+
+func GetOrganizationMemberships(owner string) ([]*OrganizationMembership, error) {
+	memberships := []*OrganizationMembership{}
+	err := ormer.Engine.Desc("created_time").Find(&memberships, &OrganizationMembership{Owner: owner})
+	if err != nil {
+		return memberships, err
+	}
+
+	return memberships, nil
+}
+
+func GetPaginationOrganizationMemberships(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*OrganizationMembership, error) {
+	memberships := []*OrganizationMembership{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&memberships)
+	if err != nil {
+		return memberships, err
+	}
+
+	return memberships, nil
+}
+
+func getOrganizationMembership(owner string, name string) (*OrganizationMembership, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	membership := OrganizationMembership{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&membership)
+	if err != nil {
+		return &membership, err
+	}
+
+	if existed {
+		return &membership, nil
+	}
+
+	return nil, nil
+}
+
+func GetOrganizationMembership(id string) (*OrganizationMembership, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getOrganizationMembership(owner, name)
+}
+
+// getUserOrganizationMembership returns the enabled membership of the user in the organization
+func getUserOrganizationMembership(organization string, userId string) (*OrganizationMembership, error) {
+	membership := OrganizationMembership{Owner: organization, User: userId}
+	existed, err := ormer.Engine.Where("is_enabled = ?", true).Get(&membership)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &membership, nil
+	}
+	return nil, nil
+}
+
+// GetUserOrganizations returns the home organization of the user followed by the ones it is an enabled member of
+func GetUserOrganizations(user *User) ([]string, error) {
+	memberships := []*OrganizationMembership{}
+	err := ormer.Engine.Where("is_enabled = ?", true).Asc("owner").Find(&memberships, &OrganizationMembership{User: user.GetId()})
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{user.Owner}
+	for _, membership := range memberships {
+		res = append(res, membership.Owner)
+	}
+	return res, nil
+}
+
+// getMembershipRoles returns the roles the member should be in, none if the membership is disabled
+func (membership *OrganizationMembership) getMembershipRoles() []string {
+	if membership == nil || !membership.IsEnabled {
+		return []string{}
+	}
+	return membership.Roles
+}
+
+func checkOrganizationMembership(membership *OrganizationMembership, lang string) error {
+	user, err := GetUser(membership.User)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), membership.User)
+	}
+	if user.Owner == membership.Owner {
+		return fmt.Errorf(i18n.Translate(lang, "organization:The user: %s already belongs to the organization"), membership.User)
+	}
+
+	err = CheckEmailDomainClaim(membership.Owner, user.Email, lang)
+	if err != nil {
+		return err
+	}
+
+	existingMembership := OrganizationMembership{Owner: membership.Owner, User: membership.User}
+	existed, err := ormer.Engine.Where(builder.Neq{"name": membership.Name}).Get(&existingMembership)
+	if err != nil {
+		return err
+	}
+	if existed {
+		return fmt.Errorf(i18n.Translate(lang, "organization:The user: %s is already a member of the organization"), membership.User)
+	}
+
+	for _, roleName := range membership.Roles {
+		role, err := getRole(membership.Owner, roleName)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			return fmt.Errorf("the role: %s does not exist", util.GetId(membership.Owner, roleName))
+		}
+	}
+
+	return nil
+}
+
+// updateOrganizationMembershipRoles adds the member to the new roles and removes it from the old ones
+func updateOrganizationMembershipRoles(owner string, userId string, oldRoles []string, newRoles []string) error {
+	for _, roleName := range newRoles {
+		if util.InSlice(oldRoles, roleName) {
+			continue
+		}
+
+		_, err := BatchUpdateRoleUsers(util.GetId(owner, roleName), []string{userId}, true, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, roleName := range oldRoles {
+		if util.InSlice(newRoles, roleName) {
+			continue
+		}
+
+		role, err := getRole(owner, roleName)
+		if err != nil {
+			return err
+		}
+		if role == nil {
+			continue
+		}
+
+		_, err = BatchUpdateRoleUsers(role.GetId(), []string{userId}, false, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func UpdateOrganizationMembership(id string, membership *OrganizationMembership, lang string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	oldMembership, err := getOrganizationMembership(owner, name)
+	if err != nil {
+		return false, err
+	} else if oldMembership == nil {
+		return false, nil
+	}
+
+	err = checkOrganizationMembership(membership, lang)
+	if err != nil {
+		return false, err
+	}
+
+	// the member of another user is moved out of the roles before the new member is added
+	if oldMembership.User != membership.User {
+		err = updateOrganizationMembershipRoles(owner, oldMembership.User, oldMembership.getMembershipRoles(), []string{})
+		if err != nil {
+			return false, err
+		}
+		oldMembership.Roles = []string{}
+	}
+
+	err = updateOrganizationMembershipRoles(membership.Owner, membership.User, oldMembership.getMembershipRoles(), membership.getMembershipRoles())
+	if err != nil {
+		return false, err
+	}
+
+	membership.UpdatedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(membership)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func AddOrganizationMembership(membership *OrganizationMembership, lang string) (bool, error) {
+	if membership.Name == "" {
+		membership.Name = util.GenerateId()
+	}
+	if membership.CreatedTime == "" {
+		membership.CreatedTime = util.GetCurrentTime()
+	}
+
+	err := checkOrganizationMembership(membership, lang)
+	if err != nil {
+		return false, err
+	}
+
+	membership.UpdatedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.Insert(membership)
+	if err != nil {
+		return false, err
+	}
+
+	err = updateOrganizationMembershipRoles(membership.Owner, membership.User, []string{}, membership.getMembershipRoles())
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func DeleteOrganizationMembership(membership *OrganizationMembership) (bool, error) {
+	oldMembership, err := getOrganizationMembership(membership.Owner, membership.Name)
+	if err != nil {
+		return false, err
+	} else if oldMembership == nil {
+		return false, nil
+	}
+
+	err = updateOrganizationMembershipRoles(oldMembership.Owner, oldMembership.User, oldMembership.getMembershipRoles(), []string{})
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.ID(core.PK{membership.Owner, membership.Name}).Delete(&OrganizationMembership{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// GetMemberOrganization returns the home organization of the member signing in to the organization by the username
+// (or Email, phone), or the organization itself if the user belongs to it or isn't a member
+func GetMemberOrganization(organization string, username string) (string, error) {
+	user, err := GetUserByFields(organization, username)
+	if err != nil || user != nil {
+		return organization, err
+	}
+
+	memberships, err := GetOrganizationMemberships(organization)
+	if err != nil {
+		return organization, err
+	}
+
+	visited := map[string]bool{}
+	for _, membership := range memberships {
+		homeOrganization, _ := util.GetOwnerAndNameFromIdNoCheck(membership.User)
+		if !membership.IsEnabled || visited[homeOrganization] {
+			continue
+		}
+		visited[homeOrganization] = true
+
+		user, err = GetUserByFields(homeOrganization, username)
+		if err != nil {
+			return organization, err
+		}
+		if user == nil {
+			continue
+		}
+
+		memberMembership, err := getUserOrganizationMembership(organization, user.GetId())
+		if err != nil {
+			return organization, err
+		}
+		if memberMembership != nil {
+			return homeOrganization, nil
+		}
+	}
+
+	return organization, nil
+}
+
+// GetMemberUser returns the user as a member of the organization with the properties of the membership applied,
+// the user itself for its home organization and nil if it isn't a member
+func GetMemberUser(organization string, user *User) (*User, error) {
+	if user == nil || organization == "" || organization == user.Owner {
+		return user, nil
+	}
+
+	membership, err := getUserOrganizationMembership(organization, user.GetId())
+	if err != nil || membership == nil {
+		return nil, err
+	}
+
+	return getMemberUser(user, membership), nil
+}
+
+// getMemberUser copies the user with the properties of the membership overriding its own ones
+func getMemberUser(user *User, membership *OrganizationMembership) *User {
+	memberUser := *user
+	memberUser.Properties = map[string]string{}
+	for key, value := range user.Properties {
+		memberUser.Properties[key] = value
+	}
+	for key, value := range membership.Properties {
+		memberUser.Properties[key] = value
+	}
+	return &memberUser
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMemberUser(t *testing.T) {
+	user := &User{Owner: "consulting", Name: "alice", Properties: map[string]string{"title": "Consultant", "team": "A"}}
+	membership := &OrganizationMembership{Owner: "customer", User: "consulting/alice", IsEnabled: true, Properties: map[string]string{"title": "Auditor"}}
+
+	memberUser := getMemberUser(user, membership)
+	assert.Equal(t, map[string]string{"title": "Auditor", "team": "A"}, memberUser.Properties)
+	assert.Equal(t, "consulting", memberUser.Owner)
+	assert.Equal(t, "Consultant", user.Properties["title"])
+
+	homeUser, err := GetMemberUser("consulting", user)
+	assert.Nil(t, err)
+	assert.Same(t, user, homeUser)
+}
+
+func TestGetMembershipRoles(t *testing.T) {
+	membership := &OrganizationMembership{IsEnabled: true, Roles: []string{"viewer"}}
+	assert.Equal(t, []string{"viewer"}, membership.getMembershipRoles())
+
+	membership.IsEnabled = false
+	assert.Equal(t, []string{}, membership.getMembershipRoles())
+
+	membership = nil
+	assert.Equal(t, []string{}, membership.getMembershipRoles())
+}
+
+func TestGetUserOrganizations(t *testing.T) {
+	setTestOrmer(t, new(OrganizationMembership))
+
+	memberships := []*OrganizationMembership{
+		{Owner: "customer-b", Name: "m1", User: "consulting/alice", IsEnabled: true},
+		{Owner: "customer-a", Name: "m2", User: "consulting/alice", IsEnabled: true},
+		{Owner: "customer-c", Name: "m3", User: "consulting/alice", IsEnabled: false},
+		{Owner: "customer-a", Name: "m4", User: "consulting/bob", IsEnabled: true},
+	}
+	_, err := ormer.Engine.Insert(&memberships)
+	assert.Nil(t, err)
+
+	organizations, err := GetUserOrganizations(&User{Owner: "consulting", Name: "alice"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"consulting", "customer-a", "customer-b"}, organizations)
+
+	membership, err := getUserOrganizationMembership("customer-a", "consulting/alice")
+	assert.Nil(t, err)
+	assert.Equal(t, "m2", membership.Name)
+
+	// the disabled membership doesn't count
+	membership, err = getUserOrganizationMembership("customer-c", "consulting/alice")
+	assert.Nil(t, err)
+	assert.Nil(t, membership)
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// MfaPolicy requires the members of the organization (or of the groups) to set up MFA,
+// the members who haven't set it up are prompted at login until the grace period ends,
+// and can't sign in without setting it up after that.
+type MfaPolicy struct {
+	IsEnabled bool `json:"isEnabled"`
+	// Groups are the ids of the groups that the policy applies to, it applies to all the members if empty
+	Groups          []string `json:"groups"`
+	GracePeriodDays int      `json:"gracePeriodDays"`
+	ExemptUsers     []string `json:"exemptUsers"`
+	ExemptGroups    []string `json:"exemptGroups"`
+	// EnabledTime is when the policy was enabled, the grace period of the existing members starts from it
+	EnabledTime string `json:"enabledTime"`
+}
+
+const (
+	MfaPolicyStatusNotApplicable = "NotApplicable"
+	MfaPolicyStatusExempt        = "Exempt"
+	MfaPolicyStatusEnrolled      = "Enrolled"
+	MfaPolicyStatusGrace         = "Grace"
+	MfaPolicyStatusRequired      = "Required"
+
+	PromptMfa = "PromptMfa"
+)
+
+// MfaPolicyUserStatus is the enrollment status of a member that the MFA policy applies to
+type MfaPolicyUserStatus struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Email       string `json:"email"`
+	Status      string `json:"status"`
+	Deadline    string `json:"deadline"`
+}
+
+func isUserInGroups(user *User, groups []string) bool {
+	for _, group := range groups {
+		if util.InSlice(user.Groups, group) || util.InSlice(user.Groups, util.GetId(user.Owner, group)) {
+			return true
+		}
+	}
+	return false
+}
+
+// setMfaPolicyEnabledTime keeps the time when the policy was enabled, or starts it when the policy is just enabled
+func setMfaPolicyEnabledTime(oldPolicy *MfaPolicy, policy *MfaPolicy) {
+	if policy == nil {
+		return
+	}
+
+	if !policy.IsEnabled {
+		policy.EnabledTime = ""
+	} else if oldPolicy != nil && oldPolicy.IsEnabled && oldPolicy.EnabledTime != "" {
+		policy.EnabledTime = oldPolicy.EnabledTime
+	} else {
+		policy.EnabledTime = util.GetCurrentTime()
+	}
+}
+
+// getMfaPolicyDeadline returns the end of the grace period of the user,
+// which starts from the later one of the time the policy was enabled and the time the user was created.
+func getMfaPolicyDeadline(policy *MfaPolicy, user *User) time.Time {
+	start, _ := time.Parse(time.RFC3339, policy.EnabledTime)
+	createdTime, err := time.Parse(time.RFC3339, user.CreatedTime)
+	if err == nil && createdTime.After(start) {
+		start = createdTime
+	}
+
+	return start.AddDate(0, 0, policy.GracePeriodDays)
+}
+
+// GetMfaPolicyStatus returns the status of the user under the MFA policy of the organization,
+// and the end of the user's grace period.
+func GetMfaPolicyStatus(organization *Organization, user *User) (string, time.Time) {
+	if organization == nil || user == nil || organization.MfaPolicy == nil || !organization.MfaPolicy.IsEnabled {
+		return MfaPolicyStatusNotApplicable, time.Time{}
+	}
+
+	policy := organization.MfaPolicy
+	if len(policy.Groups) > 0 && !isUserInGroups(user, policy.Groups) {
+		return MfaPolicyStatusNotApplicable, time.Time{}
+	}
+
+	if util.InSlice(policy.ExemptUsers, user.Name) || isUserInGroups(user, policy.ExemptGroups) {
+		return MfaPolicyStatusExempt, time.Time{}
+	}
+
+	if user.IsMfaEnabled() {
+		return MfaPolicyStatusEnrolled, time.Time{}
+	}
+
+	deadline := getMfaPolicyDeadline(policy, user)
+	if time.Now().Before(deadline) {
+		return MfaPolicyStatusGrace, deadline
+	}
+	return MfaPolicyStatusRequired, deadline
+}
+
+// GetMfaPolicyReport returns the members that the MFA policy of the organization applies to but haven't set up MFA
+func GetMfaPolicyReport(organization *Organization) ([]*MfaPolicyUserStatus, error) {
+	res := []*MfaPolicyUserStatus{}
+	if organization == nil || organization.MfaPolicy == nil || !organization.MfaPolicy.IsEnabled {
+		return res, nil
+	}
+
+	users, err := GetUsers(organization.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		status, deadline := GetMfaPolicyStatus(organization, user)
+		if status != MfaPolicyStatusGrace && status != MfaPolicyStatusRequired {
+			continue
+		}
+
+		res = append(res, &MfaPolicyUserStatus{
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+			Email:       user.Email,
+			Status:      status,
+			Deadline:    deadline.Format(time.RFC3339),
+		})
+	}
+
+	return res, nil
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMfaPolicyStatus(t *testing.T) {
+	now := time.Now()
+	organization := &Organization{
+		Name: "casbin",
+		MfaPolicy: &MfaPolicy{
+			IsEnabled:       true,
+			Groups:          []string{"casbin/dev", "ops"},
+			GracePeriodDays: 7,
+			ExemptUsers:     []string{"bot"},
+			EnabledTime:     now.AddDate(0, 0, -10).Format(time.RFC3339),
+		},
+	}
+	createdTime := now.AddDate(0, 0, -30).Format(time.RFC3339)
+
+	scenarios := []struct {
+		description string
+		user        *User
+		status      string
+	}{
+		{"not in the groups", &User{Owner: "casbin", Name: "alice", CreatedTime: createdTime, Groups: []string{"casbin/sales"}}, MfaPolicyStatusNotApplicable},
+		{"exempt", &User{Owner: "casbin", Name: "bot", CreatedTime: createdTime, Groups: []string{"casbin/dev"}}, MfaPolicyStatusExempt},
+		{"enrolled", &User{Owner: "casbin", Name: "bob", CreatedTime: createdTime, Groups: []string{"casbin/dev"}, PreferredMfaType: "app"}, MfaPolicyStatusEnrolled},
+		{"grace period ended", &User{Owner: "casbin", Name: "carol", CreatedTime: createdTime, Groups: []string{"casbin/ops"}}, MfaPolicyStatusRequired},
+		{"new member in grace period", &User{Owner: "casbin", Name: "dave", CreatedTime: now.AddDate(0, 0, -1).Format(time.RFC3339), Groups: []string{"casbin/dev"}}, MfaPolicyStatusGrace},
+	}
+
+	for _, scenery := range scenarios {
+		t.Run(scenery.description, func(t *testing.T) {
+			status, _ := GetMfaPolicyStatus(organization, scenery.user)
+			assert.Equal(t, scenery.status, status)
+		})
+	}
+
+	status, _ := GetMfaPolicyStatus(&Organization{}, &User{})
+	assert.Equal(t, MfaPolicyStatusNotApplicable, status)
+}
+
+func TestSetMfaPolicyEnabledTime(t *testing.T) {
+	policy := &MfaPolicy{IsEnabled: true}
+	setMfaPolicyEnabledTime(nil, policy)
+	assert.NotEmpty(t, policy.EnabledTime)
+
+	newPolicy := &MfaPolicy{IsEnabled: true}
+	setMfaPolicyEnabledTime(&MfaPolicy{IsEnabled: true, EnabledTime: "2023-01-01T00:00:00Z"}, newPolicy)
+	assert.Equal(t, "2023-01-01T00:00:00Z", newPolicy.EnabledTime)
+
+	newPolicy.IsEnabled = false
+	setMfaPolicyEnabledTime(policy, newPolicy)
+	assert.Empty(t, newPolicy.EnabledTime)
+}
@@ -0,0 +1,259 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+)
+
+type OnboardingItem struct {
+	Name     string `json:"name"`
+	IsPassed bool   `json:"isPassed"`
+	Hint     string `json:"hint"`
+}
+
+type OnboardingStatus struct {
+	Organization string            `json:"organization"`
+	IsReady      bool              `json:"isReady"`
+	Items        []*OnboardingItem `json:"items"`
+}
+
+func checkOnboardingCert(applications []*Application) (bool, error) {
+	if len(applications) == 0 {
+		return false, nil
+	}
+
+	for _, application := range applications {
+		cert, err := getCertByApplication(application)
+		if err != nil {
+			return false, err
+		}
+
+		if cert == nil || cert.Certificate == "" || (cert.PrivateKey == "" && !cert.isExternalKey()) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// checkOnboardingCertRotation checks that the signing keys are no longer the keys they were created with, e.g.
+// the key of the built-in cert shipped with the source, and their automatic rotations are not overdue
+func checkOnboardingCertRotation(applications []*Application) (bool, error) {
+	if len(applications) == 0 {
+		return false, nil
+	}
+
+	for _, application := range applications {
+		cert, err := getCertByApplication(application)
+		if err != nil {
+			return false, err
+		}
+
+		if cert == nil {
+			return false, nil
+		}
+		if !cert.isExternalKey() && (cert.RotatedTime == "" || cert.isRotationDue()) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func isLocalRedirectUri(redirectUri string) bool {
+	u, err := url.Parse(redirectUri)
+	if err != nil {
+		return true
+	}
+
+	host := u.Hostname()
+	return host == "" || host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// checkOnboardingRedirectUris checks that every application has a redirect URI other than the local ones
+func checkOnboardingRedirectUris(applications []*Application) bool {
+	if len(applications) == 0 {
+		return false
+	}
+
+	for _, application := range applications {
+		redirectUris := append([]string{}, application.RedirectUris...)
+		for _, item := range application.RedirectUriItems {
+			if item.isUsedFor(RedirectUriUsageLogin) {
+				redirectUris = append(redirectUris, item.Uri)
+			}
+		}
+
+		isConfigured := false
+		for _, redirectUri := range redirectUris {
+			if !isLocalRedirectUri(redirectUri) {
+				isConfigured = true
+				break
+			}
+		}
+		if !isConfigured {
+			return false
+		}
+	}
+
+	return true
+}
+
+func checkOnboardingEmailProvider(applications []*Application) bool {
+	for _, application := range applications {
+		for _, providerItem := range application.Providers {
+			provider := providerItem.Provider
+			if provider == nil || provider.Category != "Email" {
+				continue
+			}
+
+			// a live connection to the SMTP server, the same as the "Test SMTP Connection" button
+			if DailSmtpServer(provider) == nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func checkOnboardingMfa(organization *Organization) bool {
+	for _, item := range organization.MfaItems {
+		if item.Rule == "Required" || item.Rule == "Prompted" {
+			return true
+		}
+	}
+	return false
+}
+
+func getOnboardingAdmins(organization *Organization) ([]*User, error) {
+	users := []*User{}
+	err := ormer.Engine.Where("owner = ? and is_admin = ? and is_forbidden = ? and is_deleted = ?", organization.Name, true, false, false).Find(&users)
+	return users, err
+}
+
+// checkOnboardingAdminMfa checks that every active admin user has enrolled an MFA method
+func checkOnboardingAdminMfa(admins []*User) bool {
+	if len(admins) == 0 {
+		return false
+	}
+
+	for _, admin := range admins {
+		if !admin.IsMfaEnabled() {
+			return false
+		}
+	}
+	return true
+}
+
+func checkOnboardingPkce(organization *Organization) (bool, error) {
+	return ormer.Engine.Where("organization = ? and code_challenge != ?", organization.Name, "").Exist(&Token{})
+}
+
+func checkOnboardingWebhook(organization *Organization) (bool, error) {
+	webhooks, err := getWebhooksByOrganization(organization.Name)
+	if err != nil {
+		return false, err
+	}
+
+	for _, webhook := range webhooks {
+		if webhook.IsEnabled {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetOrganizationOnboardingStatus computes whether the organization is ready for production,
+// every failed item comes with a hint about how to fix it.
+func GetOrganizationOnboardingStatus(id string, lang string) (*OnboardingStatus, error) {
+	organization, err := GetOrganization(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if organization == nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "general:The organization: %s does not exist"), id)
+	}
+
+	applications, err := GetOrganizationApplications("admin", organization.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, application := range applications {
+		err = extendApplicationWithProviders(application)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	isCertPassed, err := checkOnboardingCert(applications)
+	if err != nil {
+		return nil, err
+	}
+
+	isPkcePassed, err := checkOnboardingPkce(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	isWebhookPassed, err := checkOnboardingWebhook(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	isCertRotationPassed, err := checkOnboardingCertRotation(applications)
+	if err != nil {
+		return nil, err
+	}
+
+	admins, err := getOnboardingAdmins(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []*OnboardingItem{
+		{Name: "Cert", IsPassed: isCertPassed, Hint: i18n.Translate(lang, "onboarding:Create an application and make sure its cert exists with both certificate and private key")},
+		{Name: "Email provider", IsPassed: checkOnboardingEmailProvider(applications), Hint: i18n.Translate(lang, "onboarding:Add an Email provider to an application and make sure its SMTP server can be connected")},
+		{Name: "MFA", IsPassed: checkOnboardingMfa(organization), Hint: i18n.Translate(lang, "onboarding:Set at least one MFA item of the organization to Prompted or Required")},
+		{Name: "PKCE", IsPassed: isPkcePassed, Hint: i18n.Translate(lang, "onboarding:Sign in to an application of the organization with the PKCE code challenge")},
+		{Name: "Webhook", IsPassed: isWebhookPassed, Hint: i18n.Translate(lang, "onboarding:Add an enabled webhook for the organization")},
+		{Name: "Backup admin", IsPassed: len(admins) >= 2, Hint: i18n.Translate(lang, "onboarding:Make sure the organization has at least two active admin users")},
+		{Name: "Signing key rotation", IsPassed: isCertRotationPassed, Hint: i18n.Translate(lang, "onboarding:Rotate the signing key of the cert of every application and keep its automatic rotation on schedule")},
+		{Name: "Admin MFA", IsPassed: checkOnboardingAdminMfa(admins), Hint: i18n.Translate(lang, "onboarding:Make sure every active admin user has enrolled an MFA method")},
+		{Name: "Redirect URIs", IsPassed: checkOnboardingRedirectUris(applications), Hint: i18n.Translate(lang, "onboarding:Add a redirect URI other than localhost to every application")},
+	}
+
+	status := &OnboardingStatus{
+		Organization: util.GetId(organization.Owner, organization.Name),
+		IsReady:      true,
+		Items:        items,
+	}
+	for _, item := range items {
+		if item.IsPassed {
+			item.Hint = ""
+		} else {
+			status.IsReady = false
+		}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,44 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckOnboardingRedirectUris(t *testing.T) {
+	assert.False(t, checkOnboardingRedirectUris([]*Application{}))
+	assert.False(t, checkOnboardingRedirectUris([]*Application{{RedirectUris: []string{"http://localhost:9000/callback", "http://127.0.0.1:8000/callback"}}}))
+	assert.True(t, checkOnboardingRedirectUris([]*Application{{RedirectUris: []string{"http://localhost:9000/callback", "https://app.example.com/callback"}}}))
+
+	// the disabled and logout-only redirect URI items are not counted
+	application := &Application{RedirectUriItems: []*RedirectUriItem{
+		{Uri: "https://app.example.com/callback", IsEnabled: false},
+		{Uri: "https://app.example.com/logout", IsEnabled: true, Usage: RedirectUriUsageLogout},
+	}}
+	assert.False(t, checkOnboardingRedirectUris([]*Application{application}))
+
+	application.RedirectUriItems[0].IsEnabled = true
+	assert.True(t, checkOnboardingRedirectUris([]*Application{application}))
+	assert.False(t, checkOnboardingRedirectUris([]*Application{application, {}}))
+}
+
+func TestCheckOnboardingAdminMfa(t *testing.T) {
+	assert.False(t, checkOnboardingAdminMfa([]*User{}))
+	assert.True(t, checkOnboardingAdminMfa([]*User{{PreferredMfaType: TotpType}, {PreferredMfaType: SmsType}}))
+	assert.False(t, checkOnboardingAdminMfa([]*User{{PreferredMfaType: TotpType}, {}}))
+}
@@ -22,11 +22,13 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/beego/beego"
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/util"
-	xormadapter "github.com/casdoor/xorm-adapter/v3"
 	_ "github.com/denisenkom/go-mssqldb" // db = mssql
 	_ "github.com/go-sql-driver/mysql"   // db = mysql
 	_ "github.com/lib/pq"                // db = postgres
@@ -39,19 +41,22 @@ var (
 	ormer                   *Ormer = nil
 	isCreateDatabaseDefined        = false
 	createDatabase                 = true
+	rollbackMigration              = ""
 )
 
 func InitFlag() {
 	if !isCreateDatabaseDefined {
 		isCreateDatabaseDefined = true
-		createDatabase = getCreateDatabaseFlag()
+		createDatabase, rollbackMigration = getFlags()
 	}
 }
 
-func getCreateDatabaseFlag() bool {
+func getFlags() (bool, string) {
 	res := flag.Bool("createDatabase", false, "true if you need to create database")
+	rollback := flag.String("rollbackMigration", "", "the id of the migration to roll back to, the later migrations are rolled back and Casdoor exits")
+	initBackupFlags()
 	flag.Parse()
-	return *res
+	return *res, *rollback
 }
 
 func InitConfig() {
@@ -91,9 +96,17 @@ func InitAdapter() {
 		panic(err)
 	}
 
+	err = ormer.openReplicas(conf.GetConfigReplicaDataSourceNames())
+	if err != nil {
+		panic(err)
+	}
+
 	tableNamePrefix := conf.GetConfigString("tableNamePrefix")
 	tbMapper := core.NewPrefixMapper(core.SnakeMapper{}, tableNamePrefix)
 	ormer.Engine.SetTableMapper(tbMapper)
+	for _, replica := range ormer.replicas {
+		replica.engine.SetTableMapper(tbMapper)
+	}
 }
 
 func CreateTables() {
@@ -104,7 +117,20 @@ func CreateTables() {
 		}
 	}
 
-	ormer.createTable()
+	if rollbackMigration != "" {
+		err := ormer.rollbackMigrations(rollbackMigration)
+		if err != nil {
+			panic(err)
+		}
+
+		fmt.Printf("The database has been rolled back to the migration: %s\n", rollbackMigration)
+		os.Exit(0)
+	}
+
+	err := ormer.runMigrations()
+	if err != nil {
+		panic(err)
+	}
 }
 
 // Ormer represents the MySQL adapter for policy storage.
@@ -113,14 +139,35 @@ type Ormer struct {
 	dataSourceName string
 	dbName         string
 	Engine         *xorm.Engine
+
+	replicas     []*replica
+	replicaIndex uint32
+}
+
+// replica is a read-only database that serves the read-heavy queries,
+// it is skipped until the next health check once it fails to respond.
+type replica struct {
+	engine      *xorm.Engine
+	isHealthy   bool
+	checkedTime time.Time
+	mutex       sync.Mutex
 }
 
+const replicaHealthCheckInterval = 10 * time.Second
+
 // finalizer is the destructor for Ormer.
 func finalizer(a *Ormer) {
 	err := a.Engine.Close()
 	if err != nil {
 		panic(err)
 	}
+
+	for _, replica := range a.replicas {
+		err = replica.engine.Close()
+		if err != nil {
+			panic(err)
+		}
+	}
 }
 
 // NewAdapter is the constructor for Ormer.
@@ -198,15 +245,14 @@ func (a *Ormer) CreateDatabase() error {
 	return err
 }
 
-func (a *Ormer) open() error {
-	dataSourceName := a.dataSourceName + a.dbName
-	if a.driverName != "mysql" {
-		dataSourceName = a.dataSourceName
+func (a *Ormer) newEngine(dataSourceName string) (*xorm.Engine, error) {
+	if a.driverName == "mysql" {
+		dataSourceName = dataSourceName + a.dbName
 	}
 
 	engine, err := xorm.NewEngine(a.driverName, dataSourceName)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if a.driverName == "postgres" {
@@ -216,141 +262,70 @@ func (a *Ormer) open() error {
 		}
 	}
 
-	a.Engine = engine
-	return nil
-}
-
-func (a *Ormer) close() {
-	_ = a.Engine.Close()
-	a.Engine = nil
+	return engine, nil
 }
 
-func (a *Ormer) createTable() {
-	showSql := conf.GetConfigBool("showSql")
-	a.Engine.ShowSQL(showSql)
-
-	err := a.Engine.Sync2(new(Organization))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(User))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Group))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Role))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Permission))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Model))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Adapter))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Enforcer))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Provider))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Application))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Resource))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Token))
+func (a *Ormer) open() error {
+	engine, err := a.newEngine(a.dataSourceName)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	err = a.Engine.Sync2(new(VerificationRecord))
-	if err != nil {
-		panic(err)
-	}
+	a.Engine = engine
+	return nil
+}
 
-	err = a.Engine.Sync2(new(Webhook))
-	if err != nil {
-		panic(err)
-	}
+func (a *Ormer) openReplicas(dataSourceNames []string) error {
+	for _, dataSourceName := range dataSourceNames {
+		engine, err := a.newEngine(dataSourceName)
+		if err != nil {
+			return err
+		}
 
-	err = a.Engine.Sync2(new(Syncer))
-	if err != nil {
-		panic(err)
+		engine.ShowSQL(conf.GetConfigBool("showSql"))
+		a.replicas = append(a.replicas, &replica{engine: engine, isHealthy: true, checkedTime: time.Now()})
 	}
 
-	err = a.Engine.Sync2(new(Cert))
-	if err != nil {
-		panic(err)
-	}
-
-	err = a.Engine.Sync2(new(Product))
-	if err != nil {
-		panic(err)
-	}
+	return nil
+}
 
-	err = a.Engine.Sync2(new(Payment))
-	if err != nil {
-		panic(err)
-	}
+func (r *replica) isAvailable() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
-	err = a.Engine.Sync2(new(Ldap))
-	if err != nil {
-		panic(err)
+	if time.Since(r.checkedTime) > replicaHealthCheckInterval {
+		r.isHealthy = r.engine.Ping() == nil
+		r.checkedTime = time.Now()
 	}
 
-	err = a.Engine.Sync2(new(RadiusAccounting))
-	if err != nil {
-		panic(err)
-	}
+	return r.isHealthy
+}
 
-	err = a.Engine.Sync2(new(xormadapter.CasbinRule))
-	if err != nil {
-		panic(err)
+// GetReadEngine returns a healthy read replica in round-robin order,
+// and falls back to the primary database when no replica is available.
+func (a *Ormer) GetReadEngine() *xorm.Engine {
+	count := len(a.replicas)
+	if count == 0 {
+		return a.Engine
 	}
 
-	err = a.Engine.Sync2(new(Session))
-	if err != nil {
-		panic(err)
+	start := int(atomic.AddUint32(&a.replicaIndex, 1))
+	for i := 0; i < count; i++ {
+		replica := a.replicas[(start+i)%count]
+		if replica.isAvailable() {
+			return replica.engine
+		}
 	}
 
-	err = a.Engine.Sync2(new(Subscription))
-	if err != nil {
-		panic(err)
-	}
+	return a.Engine
+}
 
-	err = a.Engine.Sync2(new(Plan))
-	if err != nil {
-		panic(err)
-	}
+func (a *Ormer) close() {
+	_ = a.Engine.Close()
+	a.Engine = nil
 
-	err = a.Engine.Sync2(new(Pricing))
-	if err != nil {
-		panic(err)
+	for _, replica := range a.replicas {
+		_ = replica.engine.Close()
 	}
+	a.replicas = nil
 }
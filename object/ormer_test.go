@@ -0,0 +1,41 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xorm-io/xorm"
+)
+
+// setTestOrmer replaces the database with an in-memory SQLite one having the tables of the beans until the test ends
+func setTestOrmer(t *testing.T, beans ...interface{}) {
+	engine, err := xorm.NewEngine("sqlite", ":memory:")
+	assert.Nil(t, err)
+
+	// every connection to ":memory:" opens a new empty database
+	engine.DB().SetMaxOpenConns(1)
+
+	err = engine.Sync2(beans...)
+	assert.Nil(t, err)
+
+	oldOrmer := ormer
+	ormer = &Ormer{Engine: engine}
+	t.Cleanup(func() {
+		ormer = oldOrmer
+		engine.Close()
+	})
+}
@@ -47,6 +47,15 @@ type Permission struct {
 	Approver    string `xorm:"varchar(100)" json:"approver"`
 	ApproveTime string `xorm:"varchar(100)" json:"approveTime"`
 	State       string `xorm:"varchar(100)" json:"state"`
+
+	Approvers []string `xorm:"mediumtext" json:"approvers"`
+
+	ExternalPdpUrl        string `xorm:"varchar(200)" json:"externalPdpUrl"`
+	PdpCombiningAlgorithm string `xorm:"varchar(100)" json:"pdpCombiningAlgorithm"`
+
+	// ResourceMatcher is the Casbin function like "keyMatch2" matching the requested resources against the
+	// resources of the permission, so that "/projects/alpha/*" grants "/projects/alpha/file1"
+	ResourceMatcher string `xorm:"varchar(100)" json:"resourceMatcher"`
 }
 
 const builtInAvailableField = 5 // Casdoor built-in adapter, use V5 to filter permission, so has 5 available field
@@ -102,6 +111,14 @@ func GetPermission(id string) (*Permission, error) {
 
 // checkPermissionValid verifies if the permission is valid
 func checkPermissionValid(permission *Permission) error {
+	if permission.PdpCombiningAlgorithm != "" && permission.PdpCombiningAlgorithm != PdpCombiningDenyOverrides && permission.PdpCombiningAlgorithm != PdpCombiningAllowOverrides {
+		return fmt.Errorf("the PDP combining algorithm: %s for permission: %s is not supported", permission.PdpCombiningAlgorithm, permission.GetId())
+	}
+
+	if permission.ResourceMatcher != "" && !util.InSlice(permissionResourceMatchers, permission.ResourceMatcher) {
+		return fmt.Errorf("the resource matcher: %s for permission: %s is not supported", permission.ResourceMatcher, permission.GetId())
+	}
+
 	enforcer, err := getPermissionEnforcer(permission)
 	if err != nil {
 		return err
@@ -200,6 +217,8 @@ func UpdatePermission(id string, permission *Permission) (bool, error) {
 		if err != nil {
 			return false, err
 		}
+
+		publishWatchEvent(WatchTypePermission, WatchActionUpdate, id, nil, nil)
 	}
 
 	return affected != 0, nil
@@ -221,6 +240,8 @@ func AddPermission(permission *Permission) (bool, error) {
 		if err != nil {
 			return false, err
 		}
+
+		publishWatchEvent(WatchTypePermission, WatchActionAdd, permission.GetId(), nil, nil)
 	}
 
 	return affected != 0, nil
@@ -250,6 +271,8 @@ func AddPermissions(permissions []*Permission) (bool, error) {
 			if err != nil {
 				return false, err
 			}
+
+			publishWatchEvent(WatchTypePermission, WatchActionAdd, permission.GetId(), nil, nil)
 		}
 	}
 	return affected != 0, nil
@@ -312,6 +335,8 @@ func DeletePermission(permission *Permission) (bool, error) {
 				}
 			}
 		}
+
+		publishWatchEvent(WatchTypePermission, WatchActionDelete, permission.GetId(), nil, nil)
 	}
 
 	return affected != 0, nil
@@ -448,7 +473,7 @@ func GroupPermissionsByModelAdapter(permissions []*Permission) map[string][]stri
 	m := make(map[string][]string)
 
 	for _, permission := range permissions {
-		key := permission.Model + permission.Adapter
+		key := permission.Model + permission.Adapter + permission.ExternalPdpUrl + permission.PdpCombiningAlgorithm + permission.ResourceMatcher
 		permissionIds, ok := m[key]
 		if !ok {
 			m[key] = []string{permission.GetId()}
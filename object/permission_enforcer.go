@@ -16,6 +16,7 @@ package object
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/casbin/casbin/v2"
@@ -25,9 +26,20 @@ import (
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/util"
 	xormadapter "github.com/casdoor/xorm-adapter/v3"
+	"github.com/xorm-io/xorm"
 )
 
 func getPermissionEnforcer(p *Permission, permissionIDs ...string) (*casbin.Enforcer, error) {
+	return newPermissionEnforcer(p, ormer.Engine, permissionIDs...)
+}
+
+// getPermissionReadEnforcer loads the policies from a read replica if there is any,
+// it must only be used for enforcing, the policy changes should go to the primary database.
+func getPermissionReadEnforcer(p *Permission, permissionIDs ...string) (*casbin.Enforcer, error) {
+	return newPermissionEnforcer(p, ormer.GetReadEngine(), permissionIDs...)
+}
+
+func newPermissionEnforcer(p *Permission, engine *xorm.Engine, permissionIDs ...string) (*casbin.Enforcer, error) {
 	// Init an enforcer instance without specifying a model or adapter.
 	// If you specify an adapter, it will load all policies, which is a
 	// heavy process that can slow down the application.
@@ -41,7 +53,7 @@ func getPermissionEnforcer(p *Permission, permissionIDs ...string) (*casbin.Enfo
 		return nil, err
 	}
 
-	err = p.setEnforcerAdapter(enforcer)
+	err = p.setEnforcerAdapter(enforcer, engine)
 	if err != nil {
 		return nil, err
 	}
@@ -67,20 +79,30 @@ func getPermissionEnforcer(p *Permission, permissionIDs ...string) (*casbin.Enfo
 	return enforcer, nil
 }
 
-func (p *Permission) setEnforcerAdapter(enforcer *casbin.Enforcer) error {
+func (p *Permission) getAdapterTableName() (string, error) {
 	tableName := "permission_rule"
 	if len(p.Adapter) != 0 {
 		adapterObj, err := getAdapter(p.Owner, p.Adapter)
 		if err != nil {
-			return err
+			return "", err
 		}
 
 		if adapterObj != nil && adapterObj.Table != "" {
 			tableName = adapterObj.Table
 		}
 	}
+
+	return tableName, nil
+}
+
+func (p *Permission) setEnforcerAdapter(enforcer *casbin.Enforcer, engine *xorm.Engine) error {
+	tableName, err := p.getAdapterTableName()
+	if err != nil {
+		return err
+	}
+
 	tableNamePrefix := conf.GetConfigString("tableNamePrefix")
-	adapter, err := xormadapter.NewAdapterByEngineWithTableName(ormer.Engine, tableName, tableNamePrefix)
+	adapter, err := xormadapter.NewAdapterByEngineWithTableName(engine, tableName, tableNamePrefix)
 	if err != nil {
 		return err
 	}
@@ -106,6 +128,11 @@ func (p *Permission) setEnforcerModel(enforcer *casbin.Enforcer) error {
 		return err
 	}
 
+	err = setModelResourceMatcher(m, p.ResourceMatcher)
+	if err != nil {
+		return err
+	}
+
 	err = enforcer.InitWithModelAndAdapter(m, nil)
 	if err != nil {
 		return err
@@ -113,6 +140,30 @@ func (p *Permission) setEnforcerModel(enforcer *casbin.Enforcer) error {
 	return nil
 }
 
+// the Casbin functions matching the hierarchical resources of the permissions
+var permissionResourceMatchers = []string{"keyMatch", "keyMatch2", "keyMatch3", "keyMatch4", "keyMatch5", "globMatch", "regexMatch"}
+
+// the equality of the resources in the matcher, the model keeps the "r.obj" as "r_obj" after being loaded
+var resourceEqualityRegex = regexp.MustCompile(`r[._]obj\s*==\s*p[._]obj`)
+
+// setModelResourceMatcher replaces the equality of the resources in the matcher of the model with the resource matcher
+func setModelResourceMatcher(m model.Model, resourceMatcher string) error {
+	if resourceMatcher == "" {
+		return nil
+	}
+
+	assertion, ok := m["m"]["m"]
+	if !ok {
+		return fmt.Errorf("the model has no matcher")
+	}
+	if !resourceEqualityRegex.MatchString(assertion.Value) {
+		return fmt.Errorf("the matcher: %s of the model should compare the resources by \"r.obj == p.obj\" to use the resource matcher: %s", assertion.Value, resourceMatcher)
+	}
+
+	m.AddDef("m", "m", resourceEqualityRegex.ReplaceAllString(assertion.Value, fmt.Sprintf("%s(r.obj, p.obj)", resourceMatcher)))
+	return nil
+}
+
 func getPolicies(permission *Permission) [][]string {
 	var policies [][]string
 
@@ -286,22 +337,31 @@ func removeGroupingPolicies(permission *Permission) error {
 
 type CasbinRequest = []interface{}
 
-func Enforce(permission *Permission, request *CasbinRequest, permissionIds ...string) (bool, error) {
-	enforcer, err := getPermissionEnforcer(permission, permissionIds...)
+// Enforce checks the request against the permissions, the decision is combined with the external PDP of the
+// permission or the calling application (which can be nil) if any
+func Enforce(permission *Permission, application *Application, request *CasbinRequest, permissionIds ...string) (bool, error) {
+	enforcer, err := getPermissionReadEnforcer(permission, permissionIds...)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := enforcer.Enforce(*request...)
 	if err != nil {
 		return false, err
 	}
 
-	return enforcer.Enforce(*request...)
+	return combinePdpDecisions(getExternalPdp(permission, application), *request, res, queryExternalPdp), nil
 }
 
-func BatchEnforce(permission *Permission, requests *[]CasbinRequest, permissionIds ...string) ([]bool, error) {
-	enforcer, err := getPermissionEnforcer(permission, permissionIds...)
+// BatchEnforce checks each of the requests against the permissions like Enforce, the error of a request
+// is returned in its own result instead of failing the whole batch
+func BatchEnforce(permission *Permission, application *Application, requests *[]CasbinRequest, permissionIds ...string) ([]*BatchEnforceResult, error) {
+	enforcer, err := getPermissionReadEnforcer(permission, permissionIds...)
 	if err != nil {
 		return nil, err
 	}
 
-	return enforcer.BatchEnforce(*requests)
+	return BatchEnforceRequests(enforcer, getExternalPdp(permission, application), *requests), nil
 }
 
 func getAllValues(userId string, fn func(enforcer *casbin.Enforcer) []string) ([]string, error) {
@@ -326,7 +386,7 @@ func getAllValues(userId string, fn func(enforcer *casbin.Enforcer) []string) ([
 
 	var values []string
 	for _, permission := range permissions {
-		enforcer, err := getPermissionEnforcer(permission)
+		enforcer, err := getPermissionReadEnforcer(permission)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,98 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newResourceMatcherEnforcer(t *testing.T, modelText string, resourceMatcher string) *casbin.Enforcer {
+	m, err := GetBuiltInModel(modelText)
+	assert.Nil(t, err)
+	assert.Nil(t, setModelResourceMatcher(m, resourceMatcher))
+
+	enforcer, err := casbin.NewEnforcer(m)
+	assert.Nil(t, err)
+	return enforcer
+}
+
+func TestSetModelResourceMatcher(t *testing.T) {
+	enforcer := newResourceMatcherEnforcer(t, "", "keyMatch")
+	_, err := enforcer.AddPolicy("alice", "doc:/projects/alpha/*", "read", "", "", "built-in/permission-1")
+	assert.Nil(t, err)
+
+	allowed, err := enforcer.Enforce("alice", "doc:/projects/alpha/file1", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = enforcer.Enforce("alice", "doc:/projects/beta/file1", "read")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+
+	// the exact matching is kept without the resource matcher
+	enforcer = newResourceMatcherEnforcer(t, "", "")
+	_, err = enforcer.AddPolicy("alice", "doc:/projects/alpha/*", "read", "", "", "built-in/permission-1")
+	assert.Nil(t, err)
+
+	allowed, err = enforcer.Enforce("alice", "doc:/projects/alpha/file1", "read")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+}
+
+func TestSetModelResourceMatcherWithCustomModel(t *testing.T) {
+	modelText := `[request_definition]
+r = sub, dom, obj, act
+
+[policy_definition]
+p = sub, dom, obj, act
+
+[role_definition]
+g = _, _, _
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = g(r.sub, p.sub, r.dom) && r.dom == p.dom && r.obj==p.obj && r.act == p.act`
+
+	enforcer := newResourceMatcherEnforcer(t, modelText, "keyMatch2")
+	_, err := enforcer.AddPolicy("alice", "tenant1", "/projects/:project/files", "read", "", "built-in/permission-1")
+	assert.Nil(t, err)
+
+	allowed, err := enforcer.Enforce("alice", "tenant1", "/projects/alpha/files", "read")
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+
+	allowed, err = enforcer.Enforce("alice", "tenant2", "/projects/alpha/files", "read")
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+
+	m, err := GetBuiltInModel(`[request_definition]
+r = sub, obj, act
+
+[policy_definition]
+p = sub, obj, act
+
+[policy_effect]
+e = some(where (p.eft == allow))
+
+[matchers]
+m = r.sub == p.sub && keyMatch(r.obj, p.obj) && r.act == p.act`)
+	assert.Nil(t, err)
+	assert.NotNil(t, setModelResourceMatcher(m, "keyMatch2"))
+}
@@ -0,0 +1,383 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+	xormadapter "github.com/casdoor/xorm-adapter/v3"
+)
+
+type StalePolicy struct {
+	Table  string `json:"table"`
+	Ptype  string `json:"ptype"`
+	V0     string `json:"v0"`
+	V1     string `json:"v1"`
+	V2     string `json:"v2"`
+	V3     string `json:"v3"`
+	V4     string `json:"v4"`
+	V5     string `json:"v5"`
+	Reason string `json:"reason"`
+}
+
+type StaleReference struct {
+	Permission string `json:"permission"`
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+}
+
+type PolicyGcReport struct {
+	StalePolicies    []*StalePolicy    `json:"stalePolicies"`
+	StaleReferences  []*StaleReference `json:"staleReferences"`
+	PrunedPolicies   int64             `json:"prunedPolicies"`
+	PrunedReferences int               `json:"prunedReferences"`
+}
+
+// policyGcChecker caches the existence of the objects referenced by policies,
+// so that a user or role shared by thousands of rules is only queried once.
+type policyGcChecker struct {
+	cache map[string]bool
+}
+
+func newPolicyGcChecker() *policyGcChecker {
+	return &policyGcChecker{cache: map[string]bool{}}
+}
+
+func (checker *policyGcChecker) isExisted(objectType string, id string) (bool, error) {
+	// wildcards like "built-in/*" are never stale
+	if id == "" || id == "*" || strings.HasSuffix(id, "/*") {
+		return true, nil
+	}
+
+	key := objectType + ":" + id
+	if existed, ok := checker.cache[key]; ok {
+		return existed, nil
+	}
+
+	if !strings.Contains(id, "/") {
+		checker.cache[key] = false
+		return false, nil
+	}
+
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+
+	existed := false
+	switch objectType {
+	case "User":
+		user, err := getUser(owner, name)
+		if err != nil {
+			return false, err
+		}
+		existed = user != nil
+	case "Role":
+		role, err := getRole(owner, name)
+		if err != nil {
+			return false, err
+		}
+		existed = role != nil
+	case "Group":
+		group, err := getGroup(owner, name)
+		if err != nil {
+			return false, err
+		}
+		existed = group != nil
+	case "Permission":
+		permission, err := getPermission(owner, name)
+		if err != nil {
+			return false, err
+		}
+		existed = permission != nil
+	default:
+		return false, fmt.Errorf("unknown object type: %s", objectType)
+	}
+
+	checker.cache[key] = existed
+	return existed, nil
+}
+
+func (checker *policyGcChecker) isSubjectExisted(id string) (bool, error) {
+	existed, err := checker.isExisted("User", id)
+	if err != nil || existed {
+		return existed, err
+	}
+
+	return checker.isExisted("Role", id)
+}
+
+func getFullPolicyTableName(tableName string) string {
+	tableNamePrefix := conf.GetConfigString("tableNamePrefix")
+	if tableNamePrefix != "" {
+		return tableNamePrefix + "_" + tableName
+	}
+	return tableName
+}
+
+func getPermissionStaleReferences(checker *policyGcChecker, permission *Permission) ([]*StaleReference, error) {
+	res := []*StaleReference{}
+	permissionId := permission.GetId()
+
+	fields := []struct {
+		objectType string
+		ids        []string
+	}{
+		{"User", permission.Users},
+		{"Role", permission.Roles},
+		{"Group", permission.Groups},
+	}
+	for _, field := range fields {
+		for _, id := range field.ids {
+			existed, err := checker.isExisted(field.objectType, id)
+			if err != nil {
+				return nil, err
+			}
+
+			if !existed {
+				res = append(res, &StaleReference{Permission: permissionId, Type: field.objectType, Value: id})
+			}
+		}
+	}
+
+	if permission.Model != "" {
+		model, err := getModel(permission.Owner, permission.Model)
+		if err != nil {
+			return nil, err
+		}
+
+		if model == nil {
+			res = append(res, &StaleReference{Permission: permissionId, Type: "Model", Value: permission.Model})
+		}
+	}
+
+	if permission.Adapter != "" {
+		adapter, err := getAdapter(permission.Owner, permission.Adapter)
+		if err != nil {
+			return nil, err
+		}
+
+		if adapter == nil {
+			res = append(res, &StaleReference{Permission: permissionId, Type: "Adapter", Value: permission.Adapter})
+		}
+	}
+
+	return res, nil
+}
+
+func getStalePoliciesInTable(checker *policyGcChecker, owner string, tableName string) ([]*StalePolicy, error) {
+	rules := []*xormadapter.CasbinRule{}
+	session := ormer.Engine.Table(getFullPolicyTableName(tableName))
+	if owner != "" {
+		session = session.Where("v5 like ?", owner+"/%")
+	}
+	err := session.Find(&rules)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*StalePolicy{}
+	for _, rule := range rules {
+		reason := ""
+
+		existed, err := checker.isExisted("Permission", rule.V5)
+		if err != nil {
+			return nil, err
+		}
+
+		if !existed {
+			reason = fmt.Sprintf("the permission: %s does not exist", rule.V5)
+		} else if rule.Ptype == "p" {
+			existed, err = checker.isSubjectExisted(rule.V0)
+			if err != nil {
+				return nil, err
+			}
+
+			if !existed {
+				reason = fmt.Sprintf("the user or role: %s does not exist", rule.V0)
+			}
+		} else if strings.HasPrefix(rule.Ptype, "g") {
+			existed, err = checker.isSubjectExisted(rule.V0)
+			if err != nil {
+				return nil, err
+			}
+
+			if !existed {
+				reason = fmt.Sprintf("the user or role: %s does not exist", rule.V0)
+			} else {
+				existed, err = checker.isExisted("Role", rule.V1)
+				if err != nil {
+					return nil, err
+				}
+
+				if !existed {
+					reason = fmt.Sprintf("the role: %s does not exist", rule.V1)
+				}
+			}
+		}
+
+		if reason != "" {
+			res = append(res, &StalePolicy{
+				Table:  tableName,
+				Ptype:  rule.Ptype,
+				V0:     rule.V0,
+				V1:     rule.V1,
+				V2:     rule.V2,
+				V3:     rule.V3,
+				V4:     rule.V4,
+				V5:     rule.V5,
+				Reason: reason,
+			})
+		}
+	}
+
+	return res, nil
+}
+
+// GetStalePolicies detects the policies and permissions that reference deleted
+// users, roles, groups, models or adapters. An empty owner scans all organizations.
+func GetStalePolicies(owner string) (*PolicyGcReport, error) {
+	permissions, err := GetPermissions(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	checker := newPolicyGcChecker()
+	report := &PolicyGcReport{
+		StalePolicies:   []*StalePolicy{},
+		StaleReferences: []*StaleReference{},
+	}
+
+	tableNames := []string{"permission_rule"}
+	for _, permission := range permissions {
+		staleReferences, err := getPermissionStaleReferences(checker, permission)
+		if err != nil {
+			return nil, err
+		}
+		report.StaleReferences = append(report.StaleReferences, staleReferences...)
+
+		tableName, err := permission.getAdapterTableName()
+		if err != nil {
+			return nil, err
+		}
+
+		if !util.InSlice(tableNames, tableName) {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+
+	for _, tableName := range tableNames {
+		stalePolicies, err := getStalePoliciesInTable(checker, owner, tableName)
+		if err != nil {
+			return nil, err
+		}
+		report.StalePolicies = append(report.StalePolicies, stalePolicies...)
+	}
+
+	return report, nil
+}
+
+func removeStaleReferences(ids []string, objectType string, staleReferences []*StaleReference) []string {
+	res := []string{}
+	for _, id := range ids {
+		isStale := false
+		for _, staleReference := range staleReferences {
+			if staleReference.Type == objectType && staleReference.Value == id {
+				isStale = true
+				break
+			}
+		}
+
+		if !isStale {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// PruneStalePolicies removes the stale users, roles and groups from permissions
+// and deletes the orphaned policy rows. Missing models and adapters are only
+// reported, because they can't be fixed without the administrator's decision.
+func PruneStalePolicies(owner string) (*PolicyGcReport, error) {
+	report, err := GetStalePolicies(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	referenceMap := map[string][]*StaleReference{}
+	for _, staleReference := range report.StaleReferences {
+		if staleReference.Type == "User" || staleReference.Type == "Role" || staleReference.Type == "Group" {
+			referenceMap[staleReference.Permission] = append(referenceMap[staleReference.Permission], staleReference)
+		}
+	}
+
+	for permissionId, staleReferences := range referenceMap {
+		permission, err := GetPermission(permissionId)
+		if err != nil {
+			return nil, err
+		}
+
+		if permission == nil {
+			continue
+		}
+
+		permission.Users = removeStaleReferences(permission.Users, "User", staleReferences)
+		permission.Roles = removeStaleReferences(permission.Roles, "Role", staleReferences)
+		permission.Groups = removeStaleReferences(permission.Groups, "Group", staleReferences)
+
+		_, err = UpdatePermission(permissionId, permission)
+		if err != nil {
+			return nil, err
+		}
+
+		report.PrunedReferences += len(staleReferences)
+	}
+
+	for _, stalePolicy := range report.StalePolicies {
+		affected, err := ormer.Engine.Table(getFullPolicyTableName(stalePolicy.Table)).
+			Where("ptype = ? and v0 = ? and v1 = ? and v2 = ? and v3 = ? and v4 = ? and v5 = ?",
+				stalePolicy.Ptype, stalePolicy.V0, stalePolicy.V1, stalePolicy.V2, stalePolicy.V3, stalePolicy.V4, stalePolicy.V5).
+			Delete(&xormadapter.CasbinRule{})
+		if err != nil {
+			return nil, err
+		}
+
+		report.PrunedPolicies += affected
+	}
+
+	return report, nil
+}
+
+// RunPolicyGcJob prunes the stale policies of all organizations periodically,
+// the interval is configured by "policyGcInterval" in hours, 0 disables the job.
+func RunPolicyGcJob() {
+	interval, err := conf.GetConfigInt64("policyGcInterval")
+	if err != nil || interval <= 0 {
+		return
+	}
+
+	for {
+		report, err := PruneStalePolicies("")
+		if err != nil {
+			logs.Warning(fmt.Sprintf("policy gc failed, error: %s", err.Error()))
+		} else {
+			logs.Info(fmt.Sprintf("policy gc finished, %d stale policies and %d stale references pruned", report.PrunedPolicies, report.PrunedReferences))
+		}
+
+		time.Sleep(time.Duration(interval) * time.Hour)
+	}
+}
@@ -38,6 +38,10 @@ type Plan struct {
 
 	Role    string   `xorm:"varchar(100)" json:"role"`
 	Options []string `xorm:"-" json:"options"`
+
+	// DunningSchedule is the days after the first failed renewal payment to retry it, the subscription is canceled
+	// if the payment still fails after the last retry
+	DunningSchedule []int `xorm:"varchar(100)" json:"dunningSchedule"`
 }
 
 const (
@@ -108,6 +112,15 @@ func GetPlan(id string) (*Plan, error) {
 	return getPlan(owner, name)
 }
 
+func checkDunningSchedule(schedule []int) error {
+	for i, days := range schedule {
+		if days < 0 || (i > 0 && days <= schedule[i-1]) {
+			return fmt.Errorf("the dunning schedule should be the increasing days after the failed payment, got: %v", schedule)
+		}
+	}
+	return nil
+}
+
 func UpdatePlan(id string, plan *Plan) (bool, error) {
 	owner, name := util.GetOwnerAndNameFromId(id)
 	if p, err := getPlan(owner, name); err != nil {
@@ -116,6 +129,11 @@ func UpdatePlan(id string, plan *Plan) (bool, error) {
 		return false, nil
 	}
 
+	err := checkDunningSchedule(plan.DunningSchedule)
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(plan)
 	if err != nil {
 		return false, err
@@ -125,6 +143,11 @@ func UpdatePlan(id string, plan *Plan) (bool, error) {
 }
 
 func AddPlan(plan *Plan) (bool, error) {
+	err := checkDunningSchedule(plan.DunningSchedule)
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.Insert(plan)
 	if err != nil {
 		return false, err
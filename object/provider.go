@@ -23,6 +23,7 @@ import (
 	"github.com/casdoor/casdoor/idp"
 	"github.com/casdoor/casdoor/pp"
 	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/builder"
 	"github.com/xorm-io/core"
 )
 
@@ -71,7 +72,33 @@ type Provider struct {
 	IssuerUrl              string `xorm:"varchar(100)" json:"issuerUrl"`
 	EnableSignAuthnRequest bool   `json:"enableSignAuthnRequest"`
 
+	// MetadataUrl is fetched every MetadataRefreshInterval hours to follow the certificate rotations of the SAML IdP,
+	// the IdP certificates are restricted to the SHA-256 fingerprints of PinnedCerts if any
+	MetadataUrl             string   `xorm:"varchar(200)" json:"metadataUrl"`
+	MetadataRefreshInterval int      `json:"metadataRefreshInterval"`
+	PinnedCerts             []string `xorm:"varchar(1000)" json:"pinnedCerts"`
+	IdpCerts                []string `xorm:"mediumtext" json:"idpCerts"`
+	MetadataRefreshTime     string   `xorm:"varchar(100)" json:"metadataRefreshTime"`
+	MetadataError           string   `xorm:"varchar(1000)" json:"metadataError"`
+
 	ProviderUrl string `xorm:"varchar(200)" json:"providerUrl"`
+
+	DkimDomain     string `xorm:"varchar(100)" json:"dkimDomain"`
+	DkimSelector   string `xorm:"varchar(100)" json:"dkimSelector"`
+	DkimPrivateKey string `xorm:"mediumtext" json:"dkimPrivateKey"`
+	BounceSecret   string `xorm:"varchar(100)" json:"bounceSecret"`
+
+	JitProvisioning *JitProvisioning `xorm:"json" json:"jitProvisioning"`
+
+	// WebhookSecret verifies the subscription webhooks of the payment provider, the signing secret for Stripe and
+	// the webhook id for PayPal
+	WebhookSecret string `xorm:"varchar(500)" json:"webhookSecret"`
+
+	// IsShared publishes the provider of the admin into the catalog adopted by the organizations
+	IsShared bool `json:"isShared"`
+	// BaseProvider is the name of the adopted catalog provider, whose settings and credentials are used
+	// except the overrides of the provider
+	BaseProvider string `xorm:"varchar(100) index" json:"baseProvider"`
 }
 
 func GetMaskedProvider(provider *Provider, isMaskEnabled bool) *Provider {
@@ -93,6 +120,16 @@ func GetMaskedProvider(provider *Provider, isMaskEnabled bool) *Provider {
 		}
 	}
 
+	if provider.DkimPrivateKey != "" {
+		provider.DkimPrivateKey = "***"
+	}
+	if provider.BounceSecret != "" {
+		provider.BounceSecret = "***"
+	}
+	if provider.WebhookSecret != "" {
+		provider.WebhookSecret = "***"
+	}
+
 	return provider
 }
 
@@ -117,9 +154,14 @@ func GetGlobalProviderCount(field, value string) (int64, error) {
 	return session.Count(&Provider{})
 }
 
-func GetProviders(owner string) ([]*Provider, error) {
+func getRawProviders(owner string) ([]*Provider, error) {
+	owners, err := getProviderOwners(owner)
+	if err != nil {
+		return nil, err
+	}
+
 	providers := []*Provider{}
-	err := ormer.Engine.Where("owner = ? or owner = ? ", "admin", owner).Desc("created_time").Find(&providers, &Provider{})
+	err = ormer.Engine.Where(builder.In("owner", owners)).Desc("created_time").Find(&providers, &Provider{})
 	if err != nil {
 		return providers, err
 	}
@@ -127,6 +169,16 @@ func GetProviders(owner string) ([]*Provider, error) {
 	return providers, nil
 }
 
+// GetProviders returns the providers available to the owner with the adopted catalog providers resolved
+func GetProviders(owner string) ([]*Provider, error) {
+	providers, err := getRawProviders(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveProviders(providers)
+}
+
 func GetGlobalProviders() ([]*Provider, error) {
 	providers := []*Provider{}
 	err := ormer.Engine.Desc("created_time").Find(&providers)
@@ -160,6 +212,15 @@ func GetPaginationGlobalProviders(offset, limit int, field, value, sortField, so
 }
 
 func getProvider(owner string, name string) (*Provider, error) {
+	provider, err := getRawProvider(owner, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return resolveProvider(provider)
+}
+
+func getRawProvider(owner string, name string) (*Provider, error) {
 	if owner == "" || name == "" {
 		return nil, nil
 	}
@@ -182,6 +243,12 @@ func GetProvider(id string) (*Provider, error) {
 	return getProvider(owner, name)
 }
 
+// GetRawProvider returns the provider as it is stored, without resolving the adopted catalog provider
+func GetRawProvider(id string) (*Provider, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getRawProvider(owner, name)
+}
+
 func GetWechatMiniProgramProvider(application *Application) *Provider {
 	providers := application.Providers
 	for _, provider := range providers {
@@ -194,9 +261,10 @@ func GetWechatMiniProgramProvider(application *Application) *Provider {
 
 func UpdateProvider(id string, provider *Provider) (bool, error) {
 	owner, name := util.GetOwnerAndNameFromId(id)
-	if p, err := getProvider(owner, name); err != nil {
+	oldProvider, err := getRawProvider(owner, name)
+	if err != nil {
 		return false, err
-	} else if p == nil {
+	} else if oldProvider == nil {
 		return false, nil
 	}
 
@@ -207,6 +275,16 @@ func UpdateProvider(id string, provider *Provider) (bool, error) {
 		}
 	}
 
+	err = checkJitProvisioning(provider)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkProviderCatalog(oldProvider, provider)
+	if err != nil {
+		return false, err
+	}
+
 	session := ormer.Engine.ID(core.PK{owner, name}).AllCols()
 	if provider.ClientSecret == "***" {
 		session = session.Omit("client_secret")
@@ -214,6 +292,15 @@ func UpdateProvider(id string, provider *Provider) (bool, error) {
 	if provider.ClientSecret2 == "***" {
 		session = session.Omit("client_secret2")
 	}
+	if provider.DkimPrivateKey == "***" {
+		session = session.Omit("dkim_private_key")
+	}
+	if provider.BounceSecret == "***" {
+		session = session.Omit("bounce_secret")
+	}
+	if provider.WebhookSecret == "***" {
+		session = session.Omit("webhook_secret")
+	}
 
 	if provider.Type == "Tencent Cloud COS" {
 		provider.Endpoint = util.GetEndPoint(provider.Endpoint)
@@ -228,12 +315,43 @@ func UpdateProvider(id string, provider *Provider) (bool, error) {
 	return affected != 0, nil
 }
 
+// IsProviderSecretChanged checks whether the update sets any secret of the provider to a new value
+func IsProviderSecretChanged(oldProvider *Provider, provider *Provider) bool {
+	if oldProvider == nil {
+		return false
+	}
+
+	secrets := [][2]string{
+		{oldProvider.ClientSecret, provider.ClientSecret},
+		{oldProvider.ClientSecret2, provider.ClientSecret2},
+		{oldProvider.DkimPrivateKey, provider.DkimPrivateKey},
+		{oldProvider.BounceSecret, provider.BounceSecret},
+		{oldProvider.WebhookSecret, provider.WebhookSecret},
+	}
+	for _, secret := range secrets {
+		if secret[1] != "***" && secret[1] != secret[0] {
+			return true
+		}
+	}
+	return false
+}
+
 func AddProvider(provider *Provider) (bool, error) {
 	if provider.Type == "Tencent Cloud COS" {
 		provider.Endpoint = util.GetEndPoint(provider.Endpoint)
 		provider.IntranetEndpoint = util.GetEndPoint(provider.IntranetEndpoint)
 	}
 
+	err := checkJitProvisioning(provider)
+	if err != nil {
+		return false, err
+	}
+
+	err = checkProviderCatalog(nil, provider)
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.Insert(provider)
 	if err != nil {
 		return false, err
@@ -243,6 +361,11 @@ func AddProvider(provider *Provider) (bool, error) {
 }
 
 func DeleteProvider(provider *Provider) (bool, error) {
+	err := checkProviderAdopted(provider.Name)
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.ID(core.PK{provider.Owner, provider.Name}).Delete(&Provider{})
 	if err != nil {
 		return false, err
@@ -393,6 +516,11 @@ func providerChangeTrigger(oldName string, newName string) error {
 		return err
 	}
 
+	_, err = session.Where("base_provider=?", oldName).Cols("base_provider").Update(&Provider{BaseProvider: newName})
+	if err != nil {
+		return err
+	}
+
 	return session.Commit()
 }
 
@@ -419,6 +547,13 @@ func FromProviderToIdpInfo(ctx *context.Context, provider *Provider) *idp.Provid
 		}
 	} else if provider.Type == "AzureAD" || provider.Type == "ADFS" || provider.Type == "Okta" {
 		providerInfo.HostUrl = provider.Domain
+	} else if provider.Type == "Ethereum" {
+		// the RPC URL of an Ethereum node for resolving the ENS names
+		providerInfo.HostUrl = provider.Endpoint
+	} else if provider.Type == "Custom" {
+		// If provider type is Custom, Method means the token endpoint auth method: "client_secret_basic" or "client_secret_post"
+		providerInfo.Scopes = strings.Fields(strings.ReplaceAll(provider.Scopes, ",", " "))
+		providerInfo.AuthMethod = provider.Method
 	}
 
 	return providerInfo
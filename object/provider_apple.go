@@ -0,0 +1,91 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/idp"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+)
+
+func getUsersByApple(provider *Provider, appleId string) ([]*User, error) {
+	users := []*User{}
+	session := ormer.Engine.Where("apple = ?", appleId)
+	if provider.Owner != "admin" {
+		session = session.And("owner = ?", provider.Owner)
+	}
+
+	err := session.Find(&users)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func addAppleNotificationRecord(user *User, event *idp.AppleNotificationEvent) {
+	record := &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: user.Owner,
+		User:         user.Name,
+		Method:       "POST",
+		Action:       "apple-notification",
+		Object:       util.StructToJson(event),
+	}
+	util.SafeGoroutine(func() { AddRecord(record) })
+}
+
+// HandleAppleNotification verifies Apple's server-to-server notification sent for the provider,
+// the users linked to the Apple account are forbidden when Apple reports that the credential is revoked,
+// and unlinked from the Apple account as well when the Apple account is deleted.
+func HandleAppleNotification(provider *Provider, payload string, lang string) (*idp.AppleNotificationEvent, error) {
+	if provider.Type != "Apple" {
+		return nil, fmt.Errorf(i18n.Translate(lang, "provider:the provider: %s is not an Apple provider"), provider.GetId())
+	}
+
+	event, err := idp.ParseAppleNotification(payload, provider.ClientId)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := getUsersByApple(provider, event.Sub)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		if event.Type == idp.AppleEventConsentRevoked || event.Type == idp.AppleEventAccountDelete {
+			user.IsForbidden = true
+			columns := []string{"is_forbidden"}
+			if event.Type == idp.AppleEventAccountDelete {
+				user.Apple = ""
+				columns = append(columns, "apple")
+			}
+
+			_, err = updateUser(user.GetId(), user, columns)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		addAppleNotificationRecord(user, event)
+	}
+
+	return event, nil
+}
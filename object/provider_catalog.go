@@ -0,0 +1,167 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+)
+
+// inheritProvider returns a copy of the adopted catalog provider with the identity and the overrides of the provider,
+// the credentials and the endpoints always come from the catalog so they are rotated in one place
+func inheritProvider(provider *Provider, base *Provider) *Provider {
+	res := *base
+	res.Owner = provider.Owner
+	res.Name = provider.Name
+	res.CreatedTime = provider.CreatedTime
+	res.IsShared = false
+	res.BaseProvider = provider.BaseProvider
+
+	if provider.DisplayName != "" {
+		res.DisplayName = provider.DisplayName
+	}
+	if provider.CustomLogo != "" {
+		res.CustomLogo = provider.CustomLogo
+	}
+	if provider.Scopes != "" {
+		res.Scopes = provider.Scopes
+	}
+	if len(provider.UserMapping) != 0 {
+		res.UserMapping = provider.UserMapping
+	}
+	if provider.Title != "" {
+		res.Title = provider.Title
+	}
+	if provider.Content != "" {
+		res.Content = provider.Content
+	}
+	if provider.Receiver != "" {
+		res.Receiver = provider.Receiver
+	}
+	if provider.SignName != "" {
+		res.SignName = provider.SignName
+	}
+	if provider.TemplateCode != "" {
+		res.TemplateCode = provider.TemplateCode
+	}
+	if provider.PathPrefix != "" {
+		res.PathPrefix = provider.PathPrefix
+	}
+	if provider.ProviderUrl != "" {
+		res.ProviderUrl = provider.ProviderUrl
+	}
+	if provider.JitProvisioning != nil {
+		res.JitProvisioning = provider.JitProvisioning
+	}
+
+	return &res
+}
+
+func getCatalogProvider(name string) (*Provider, error) {
+	provider, err := getRawProvider("admin", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if provider == nil || provider.Owner != "admin" || !provider.IsShared {
+		return nil, nil
+	}
+	return provider, nil
+}
+
+// resolveProvider returns the effective provider of the provider adopting a catalog provider
+func resolveProvider(provider *Provider) (*Provider, error) {
+	if provider == nil || provider.BaseProvider == "" {
+		return provider, nil
+	}
+
+	base, err := getCatalogProvider(provider.BaseProvider)
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		return nil, fmt.Errorf("the catalog provider: %s adopted by the provider: %s is not found", provider.BaseProvider, provider.GetId())
+	}
+
+	return inheritProvider(provider, base), nil
+}
+
+func resolveProviders(providers []*Provider) ([]*Provider, error) {
+	for i, provider := range providers {
+		res, err := resolveProvider(provider)
+		if err != nil {
+			return nil, err
+		}
+		providers[i] = res
+	}
+
+	return providers, nil
+}
+
+// GetCatalogProviders returns the providers published by the admin for the organizations to adopt
+func GetCatalogProviders() ([]*Provider, error) {
+	providers := []*Provider{}
+	err := ormer.Engine.Where("is_shared = ?", true).Desc("created_time").Find(&providers, &Provider{Owner: "admin"})
+	if err != nil {
+		return providers, err
+	}
+
+	return providers, nil
+}
+
+// checkProviderAdopted prevents removing the catalog provider from the catalog while organizations adopt it
+func checkProviderAdopted(name string) error {
+	count, err := ormer.Engine.Where("base_provider = ?", name).Count(&Provider{})
+	if err != nil {
+		return err
+	}
+
+	if count != 0 {
+		return fmt.Errorf("the catalog provider: %s is adopted by %d providers of the organizations", name, count)
+	}
+	return nil
+}
+
+func checkProviderCatalog(oldProvider *Provider, provider *Provider) error {
+	if provider.IsShared {
+		if provider.Owner != "admin" {
+			return fmt.Errorf("only the providers of the admin can be shared in the catalog")
+		}
+		if provider.BaseProvider != "" {
+			return fmt.Errorf("the provider adopting the catalog provider: %s can't be shared", provider.BaseProvider)
+		}
+	} else if oldProvider != nil && oldProvider.IsShared {
+		err := checkProviderAdopted(oldProvider.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if provider.BaseProvider == "" {
+		return nil
+	}
+
+	base, err := getCatalogProvider(provider.BaseProvider)
+	if err != nil {
+		return err
+	}
+	if base == nil {
+		return fmt.Errorf("the catalog provider: %s is not found", provider.BaseProvider)
+	}
+
+	// the category and the type are listed and filtered by the stored provider
+	provider.Category = base.Category
+	provider.Type = base.Type
+	return nil
+}
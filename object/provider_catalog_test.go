@@ -0,0 +1,70 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInheritProvider(t *testing.T) {
+	base := &Provider{
+		Owner:        "admin",
+		Name:         "provider_twilio",
+		Category:     "SMS",
+		Type:         "Twilio SMS",
+		ClientId:     "AC123",
+		ClientSecret: "secret",
+		SignName:     "Casdoor",
+		TemplateCode: "base template",
+		IsShared:     true,
+	}
+	provider := &Provider{
+		Owner:        "org",
+		Name:         "provider_twilio_org",
+		BaseProvider: "provider_twilio",
+		ClientSecret: "pinned",
+		TemplateCode: "org template",
+	}
+
+	res := inheritProvider(provider, base)
+	assert.Equal(t, "org", res.Owner)
+	assert.Equal(t, "provider_twilio_org", res.Name)
+	assert.Equal(t, "provider_twilio", res.BaseProvider)
+	assert.False(t, res.IsShared)
+	assert.Equal(t, "AC123", res.ClientId)
+	assert.Equal(t, "secret", res.ClientSecret)
+	assert.Equal(t, "Casdoor", res.SignName)
+	assert.Equal(t, "org template", res.TemplateCode)
+	assert.Equal(t, "provider_twilio", base.Name)
+}
+
+func TestCheckProviderCatalogShared(t *testing.T) {
+	assert.NotNil(t, checkProviderCatalog(nil, &Provider{Owner: "org", IsShared: true}))
+	assert.NotNil(t, checkProviderCatalog(nil, &Provider{Owner: "admin", IsShared: true, BaseProvider: "provider_twilio"}))
+	assert.Nil(t, checkProviderCatalog(nil, &Provider{Owner: "admin", IsShared: true}))
+}
+
+func TestResolveProviderWithoutBase(t *testing.T) {
+	provider := &Provider{Owner: "org", Name: "provider_google"}
+	res, err := resolveProvider(provider)
+	assert.Nil(t, err)
+	assert.Equal(t, provider, res)
+
+	res, err = resolveProvider(nil)
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+}
@@ -0,0 +1,342 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/storage"
+	"github.com/casdoor/casdoor/util"
+	sender "github.com/casdoor/go-sms-sender"
+	"github.com/xorm-io/core"
+)
+
+const (
+	ProviderHealthStateHealthy   = "Healthy"
+	ProviderHealthStateUnhealthy = "Unhealthy"
+
+	defaultProviderHealthCheckInterval = 10 // in minutes
+	defaultProviderHealthAlertFailures = 3
+	providerHealthCheckTimeout         = 10 * time.Second
+)
+
+// providerDiscoveryUrls are the OpenID configurations fetched for the health of the OAuth providers of the types
+var providerDiscoveryUrls = map[string]string{
+	"Google":  "https://accounts.google.com/.well-known/openid-configuration",
+	"Apple":   "https://appleid.apple.com/.well-known/openid-configuration",
+	"Line":    "https://access.line.me/.well-known/openid-configuration",
+	"AzureAD": "https://login.microsoftonline.com/common/v2.0/.well-known/openid-configuration",
+}
+
+// ProviderHealth is the result of the periodic health checks of the provider, the admins of the organization are
+// alerted once by the Notification providers after the consecutive failures reach the "providerHealthAlertFailures" config
+type ProviderHealth struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Category        string `xorm:"varchar(100)" json:"category"`
+	Type            string `xorm:"varchar(100)" json:"type"`
+	State           string `xorm:"varchar(100)" json:"state"`
+	LastCheckTime   string `xorm:"varchar(100)" json:"lastCheckTime"`
+	LastSuccessTime string `xorm:"varchar(100)" json:"lastSuccessTime"`
+	LastError       string `xorm:"varchar(1000)" json:"lastError"`
+	Failures        int    `json:"failures"`
+	AlertedTime     string `xorm:"varchar(100)" json:"alertedTime"`
+}
+
+func GetProviderHealths(owner string) ([]*ProviderHealth, error) {
+	providerHealths := []*ProviderHealth{}
+	err := ormer.Engine.Asc("name").Find(&providerHealths, &ProviderHealth{Owner: owner})
+	if err != nil {
+		return providerHealths, err
+	}
+
+	return providerHealths, nil
+}
+
+func getProviderHealth(owner string, name string) (*ProviderHealth, error) {
+	providerHealth := ProviderHealth{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&providerHealth)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &providerHealth, nil
+	}
+	return nil, nil
+}
+
+func (providerHealth *ProviderHealth) GetId() string {
+	return fmt.Sprintf("%s/%s", providerHealth.Owner, providerHealth.Name)
+}
+
+// isProviderHealthChecked returns whether the provider has a health check, the other providers are not listed
+func isProviderHealthChecked(provider *Provider) bool {
+	switch provider.Category {
+	case "Email":
+		return provider.Type != "Azure ACS" && provider.Type != "Custom HTTP Email"
+	case "SMS", "Storage":
+		return true
+	case "OAuth":
+		return provider.Type == "Custom" || providerDiscoveryUrls[provider.Type] != ""
+	default:
+		return false
+	}
+}
+
+// checkProviderHealth connects to the SMTP server of the Email provider, queries the balance of the SMS provider
+// if its API has one, fetches the OpenID configuration of the OAuth provider and lists the bucket of the Storage provider
+func checkProviderHealth(provider *Provider) error {
+	switch provider.Category {
+	case "Email":
+		return DailSmtpServer(provider)
+	case "SMS":
+		return checkSmsProviderHealth(provider)
+	case "OAuth":
+		return checkOAuthProviderHealth(provider)
+	case "Storage":
+		storageProvider := storage.GetStorageProvider(provider.Type, provider.ClientId, provider.ClientSecret, provider.RegionId, provider.Bucket, getProviderEndpoint(provider))
+		if storageProvider == nil {
+			return fmt.Errorf("the storage provider type: %s is not supported", provider.Type)
+		}
+
+		_, err := storageProvider.List(provider.PathPrefix)
+		return err
+	default:
+		return fmt.Errorf("the provider category: %s has no health check", provider.Category)
+	}
+}
+
+func getProviderHealthResponse(req *http.Request) error {
+	client := &http.Client{Timeout: providerHealthCheckTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("the request to: %s failed, status: %s", req.URL.String(), resp.Status)
+	}
+	return nil
+}
+
+// checkSmsProviderHealth queries the balance of the Twilio account, the clients of the other types are only created
+// since their APIs have no read-only call
+func checkSmsProviderHealth(provider *Provider) error {
+	if provider.Type != sender.Twilio {
+		_, err := getSmsClient(provider)
+		return err
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Balance.json", url.PathEscape(provider.ClientId)), nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(provider.ClientId, provider.ClientSecret)
+	return getProviderHealthResponse(req)
+}
+
+func getOAuthDiscoveryUrl(provider *Provider) (string, error) {
+	if provider.Type != "Custom" {
+		return providerDiscoveryUrls[provider.Type], nil
+	}
+
+	authUrl, err := url.Parse(provider.CustomAuthUrl)
+	if err != nil {
+		return "", err
+	}
+	if authUrl.Scheme == "" || authUrl.Host == "" {
+		return "", fmt.Errorf("the auth URL: %s of the provider is invalid", provider.CustomAuthUrl)
+	}
+	return fmt.Sprintf("%s://%s/.well-known/openid-configuration", authUrl.Scheme, authUrl.Host), nil
+}
+
+func checkOAuthProviderHealth(provider *Provider) error {
+	discoveryUrl, err := getOAuthDiscoveryUrl(provider)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: providerHealthCheckTimeout}
+	resp, err := client.Get(discoveryUrl)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to get the OpenID configuration: %s, status: %s", discoveryUrl, resp.Status)
+	}
+
+	var configuration struct {
+		Issuer string `json:"issuer"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&configuration)
+	if err != nil {
+		return err
+	}
+	if configuration.Issuer == "" {
+		return fmt.Errorf("the OpenID configuration: %s has no issuer", discoveryUrl)
+	}
+	return nil
+}
+
+// setProviderHealthResult records the result of the check, it returns whether the failures just reached the alert threshold
+func setProviderHealthResult(providerHealth *ProviderHealth, err error, now string) bool {
+	providerHealth.LastCheckTime = now
+	if err == nil {
+		providerHealth.State = ProviderHealthStateHealthy
+		providerHealth.LastSuccessTime = now
+		providerHealth.LastError = ""
+		providerHealth.Failures = 0
+		providerHealth.AlertedTime = ""
+		return false
+	}
+
+	providerHealth.State = ProviderHealthStateUnhealthy
+	providerHealth.LastError = err.Error()
+	if len(providerHealth.LastError) > 1000 {
+		providerHealth.LastError = providerHealth.LastError[:1000]
+	}
+	providerHealth.Failures += 1
+	return providerHealth.AlertedTime == "" && providerHealth.Failures >= getRecordWriterConfigInt("providerHealthAlertFailures", defaultProviderHealthAlertFailures)
+}
+
+// CheckProviderHealth checks the provider now and saves its health
+func CheckProviderHealth(provider *Provider) (*ProviderHealth, error) {
+	providerHealth, err := getProviderHealth(provider.Owner, provider.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	isNew := providerHealth == nil
+	if isNew {
+		providerHealth = &ProviderHealth{Owner: provider.Owner, Name: provider.Name, CreatedTime: util.GetCurrentTime()}
+	}
+	providerHealth.Category = provider.Category
+	providerHealth.Type = provider.Type
+
+	isAlerted := setProviderHealthResult(providerHealth, checkProviderHealth(provider), util.GetCurrentTime())
+	if isAlerted {
+		providerHealth.AlertedTime = providerHealth.LastCheckTime
+	}
+
+	if isNew {
+		_, err = ormer.Engine.Insert(providerHealth)
+	} else {
+		_, err = ormer.Engine.ID(core.PK{providerHealth.Owner, providerHealth.Name}).AllCols().Update(providerHealth)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if isAlerted {
+		util.SafeGoroutine(func() { alertProviderHealth(providerHealth) })
+	}
+	return providerHealth, nil
+}
+
+func getProviderHealthAlertContent(providerHealth *ProviderHealth) string {
+	return fmt.Sprintf("The provider %s (%s, %s) failed its last %d health checks, the last success was at %s. The last error: %s",
+		providerHealth.GetId(), providerHealth.Category, providerHealth.Type, providerHealth.Failures, providerHealth.LastSuccessTime, providerHealth.LastError)
+}
+
+// alertProviderHealth sends the alert of the unhealthy provider by the Notification providers of its owner
+func alertProviderHealth(providerHealth *ProviderHealth) {
+	content := getProviderHealthAlertContent(providerHealth)
+	logs.Warning(content)
+
+	providers, err := GetProviders(providerHealth.Owner)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to get the providers of the organization: %s, error: %s", providerHealth.Owner, err.Error()))
+		return
+	}
+
+	for _, provider := range providers {
+		if provider.Category != "Notification" {
+			continue
+		}
+
+		err = SendNotification(provider, content)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to send the provider health alert by the provider: %s, error: %s", provider.Name, err.Error()))
+		}
+	}
+}
+
+func checkProviderHealths() error {
+	providers, err := GetGlobalProviders()
+	if err != nil {
+		return err
+	}
+
+	providers, err = resolveProviders(providers)
+	if err != nil {
+		return err
+	}
+
+	checkedIds := map[string]bool{}
+	for _, provider := range providers {
+		if !isProviderHealthChecked(provider) {
+			continue
+		}
+
+		checkedIds[provider.GetId()] = true
+		_, err = CheckProviderHealth(provider)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the healths of the deleted, renamed or unchecked providers are removed
+	providerHealths := []*ProviderHealth{}
+	err = ormer.Engine.Find(&providerHealths)
+	if err != nil {
+		return err
+	}
+
+	for _, providerHealth := range providerHealths {
+		if checkedIds[providerHealth.GetId()] {
+			continue
+		}
+
+		_, err = ormer.Engine.ID(core.PK{providerHealth.Owner, providerHealth.Name}).Delete(&ProviderHealth{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunProviderHealthJob checks the providers every "providerHealthCheckInterval" minutes
+func RunProviderHealthJob() {
+	for {
+		err := checkProviderHealths()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("provider health check failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Duration(getRecordWriterConfigInt("providerHealthCheckInterval", defaultProviderHealthCheckInterval)) * time.Minute)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsProviderHealthChecked(t *testing.T) {
+	assert.True(t, isProviderHealthChecked(&Provider{Category: "Email", Type: "Default"}))
+	assert.False(t, isProviderHealthChecked(&Provider{Category: "Email", Type: "Custom HTTP Email"}))
+	assert.True(t, isProviderHealthChecked(&Provider{Category: "SMS", Type: "Twilio SMS"}))
+	assert.True(t, isProviderHealthChecked(&Provider{Category: "Storage", Type: "AWS S3"}))
+	assert.True(t, isProviderHealthChecked(&Provider{Category: "OAuth", Type: "Google"}))
+	assert.True(t, isProviderHealthChecked(&Provider{Category: "OAuth", Type: "Custom"}))
+	assert.False(t, isProviderHealthChecked(&Provider{Category: "OAuth", Type: "GitHub"}))
+	assert.False(t, isProviderHealthChecked(&Provider{Category: "Captcha", Type: "Default"}))
+}
+
+func TestSetProviderHealthResult(t *testing.T) {
+	providerHealth := &ProviderHealth{}
+
+	// the alert is sent once when the consecutive failures reach the threshold
+	assert.False(t, setProviderHealthResult(providerHealth, fmt.Errorf("dial tcp: i/o timeout"), "t1"))
+	assert.False(t, setProviderHealthResult(providerHealth, fmt.Errorf("dial tcp: i/o timeout"), "t2"))
+	assert.True(t, setProviderHealthResult(providerHealth, fmt.Errorf("dial tcp: i/o timeout"), "t3"))
+	assert.Equal(t, ProviderHealthStateUnhealthy, providerHealth.State)
+	assert.Equal(t, 3, providerHealth.Failures)
+	assert.Equal(t, "dial tcp: i/o timeout", providerHealth.LastError)
+
+	providerHealth.AlertedTime = "t3"
+	assert.False(t, setProviderHealthResult(providerHealth, fmt.Errorf("dial tcp: i/o timeout"), "t4"))
+
+	// a success resets the failures and re-arms the alert
+	assert.False(t, setProviderHealthResult(providerHealth, nil, "t5"))
+	assert.Equal(t, ProviderHealthStateHealthy, providerHealth.State)
+	assert.Equal(t, "t5", providerHealth.LastSuccessTime)
+	assert.Equal(t, "t5", providerHealth.LastCheckTime)
+	assert.Equal(t, 0, providerHealth.Failures)
+	assert.Equal(t, "", providerHealth.AlertedTime)
+}
+
+func TestCheckOAuthProviderHealth(t *testing.T) {
+	issuer := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(fmt.Sprintf(`{"issuer": "%s"}`, issuer)))
+	}))
+	defer server.Close()
+
+	provider := &Provider{Category: "OAuth", Type: "Custom", CustomAuthUrl: server.URL + "/oauth/authorize"}
+	discoveryUrl, err := getOAuthDiscoveryUrl(provider)
+	assert.Nil(t, err)
+	assert.Equal(t, server.URL+"/.well-known/openid-configuration", discoveryUrl)
+
+	assert.NotNil(t, checkOAuthProviderHealth(provider))
+
+	issuer = server.URL
+	assert.Nil(t, checkOAuthProviderHealth(provider))
+
+	_, err = getOAuthDiscoveryUrl(&Provider{Category: "OAuth", Type: "Custom", CustomAuthUrl: "/oauth/authorize"})
+	assert.NotNil(t, err)
+}
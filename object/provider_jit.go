@@ -0,0 +1,306 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/idp"
+	"github.com/casdoor/casdoor/util"
+	"github.com/google/uuid"
+)
+
+// JitDomainRoute provisions the users whose emails are of the domain into the organization
+type JitDomainRoute struct {
+	Domain       string `json:"domain"`
+	Organization string `json:"organization"`
+}
+
+// JitProvisioning is the just-in-time provisioning rule for the users who sign in via an OAuth or SAML provider
+// without an existing account
+type JitProvisioning struct {
+	// IsBlocked disables the provisioning, only the pre-created accounts can sign in via the provider
+	IsBlocked bool `json:"isBlocked"`
+	// AttributeMapping maps the user fields like "affiliation" or "properties.<key>" to the upstream attributes
+	AttributeMapping map[string]string `json:"attributeMapping"`
+	Organization     string            `json:"organization"`
+	Groups           []string          `json:"groups"`
+	Roles            []string          `json:"roles"`
+	DomainRoutes     []*JitDomainRoute `json:"domainRoutes"`
+}
+
+var jitUserFields = map[string]string{
+	"email":       "Email",
+	"phone":       "Phone",
+	"countryCode": "CountryCode",
+	"avatar":      "Avatar",
+}
+
+func getJitUserField(field string) (string, bool) {
+	if goField, ok := jitUserFields[field]; ok {
+		return goField, true
+	}
+	goField, ok := authStepAttributeFields[field]
+	return goField, ok
+}
+
+func getJitAttribute(userInfo *idp.UserInfo, attribute string) string {
+	switch attribute {
+	case "id":
+		return userInfo.Id
+	case "username":
+		return userInfo.Username
+	case "displayName":
+		return userInfo.DisplayName
+	case "email":
+		return userInfo.Email
+	case "phone":
+		return userInfo.Phone
+	case "countryCode":
+		return userInfo.CountryCode
+	case "avatarUrl":
+		return userInfo.AvatarUrl
+	default:
+		return userInfo.Extra[attribute]
+	}
+}
+
+// IsJitProvisioningBlocked checks whether the users must have been created before they sign in via the provider
+func IsJitProvisioningBlocked(provider *Provider) bool {
+	return provider.JitProvisioning != nil && provider.JitProvisioning.IsBlocked
+}
+
+// isJitOrganizationAllowed checks whether the provider can provision the users into the organization, only the
+// global providers owned by "admin" can provision into the other organizations than their own
+func isJitOrganizationAllowed(provider *Provider, organization string) bool {
+	return provider.Owner == "admin" || organization == provider.Owner
+}
+
+// GetJitOrganization returns the organization of the user signing in via the provider, which is routed by
+// the domain of the email if the identity provider asserts the email is verified, or the default organization
+// of the provider, or the organization of the application
+func GetJitOrganization(provider *Provider, application *Application, userInfo *idp.UserInfo) string {
+	jit := provider.JitProvisioning
+	if jit == nil {
+		return application.Organization
+	}
+
+	if i := strings.LastIndex(userInfo.Email, "@"); i != -1 && userInfo.EmailVerified {
+		domain := userInfo.Email[i+1:]
+		for _, route := range jit.DomainRoutes {
+			if strings.EqualFold(route.Domain, domain) && route.Organization != "" && isJitOrganizationAllowed(provider, route.Organization) {
+				return route.Organization
+			}
+		}
+	}
+
+	if jit.Organization != "" && isJitOrganizationAllowed(provider, jit.Organization) {
+		return jit.Organization
+	}
+	return application.Organization
+}
+
+func applyJitAttributeMapping(user *User, jit *JitProvisioning, userInfo *idp.UserInfo) {
+	for field, attribute := range jit.AttributeMapping {
+		value := getJitAttribute(userInfo, attribute)
+		if value == "" {
+			continue
+		}
+
+		if strings.HasPrefix(field, requiredAttributePrefix) {
+			setUserProperty(user, strings.TrimPrefix(field, requiredAttributePrefix), value)
+		} else if goField, ok := getJitUserField(field); ok {
+			reflect.ValueOf(user).Elem().FieldByName(goField).SetString(value)
+		}
+	}
+}
+
+func getJitUserName(organization *Organization, userInfo *idp.UserInfo) (string, error) {
+	name := userInfo.Username
+	if name == "" {
+		// the SAML providers identify the users by the NameID only if no username is mapped
+		if util.IsEmailValid(userInfo.Id) {
+			name = util.GetUsernameFromEmail(userInfo.Id)
+		} else {
+			name = userInfo.Id
+		}
+	}
+
+	// Handle username conflicts
+	tmpUser, err := getUser(organization.Name, name)
+	if err != nil {
+		return "", err
+	}
+
+	if tmpUser != nil {
+		uid, err := uuid.NewRandom()
+		if err != nil {
+			return "", err
+		}
+
+		uidStr := strings.Split(uid.String(), "-")
+		name = fmt.Sprintf("%s_%s", name, uidStr[1])
+	}
+
+	return name, nil
+}
+
+// ProvisionJitUser creates the user signing in via the provider for the first time with the provisioning rule
+// of the provider, the signup group of the application's provider item is assigned as well
+func ProvisionJitUser(application *Application, organization *Organization, provider *Provider, providerItem *ProviderItem, userInfo *idp.UserInfo, lang string) (*User, error) {
+	name, err := getJitUserName(organization, userInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := GetUserCount(organization.Name, "", "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	initScore, err := organization.GetInitScore()
+	if err != nil {
+		return nil, err
+	}
+
+	userId := userInfo.Id
+	if userId == "" {
+		userId = util.GenerateId()
+	}
+
+	user := &User{
+		Owner:             organization.Name,
+		Name:              name,
+		CreatedTime:       util.GetCurrentTime(),
+		Id:                userId,
+		Type:              "normal-user",
+		DisplayName:       userInfo.DisplayName,
+		Avatar:            userInfo.AvatarUrl,
+		Address:           []string{},
+		Email:             userInfo.Email,
+		Phone:             userInfo.Phone,
+		CountryCode:       userInfo.CountryCode,
+		Region:            userInfo.CountryCode,
+		Score:             initScore,
+		IsAdmin:           false,
+		IsForbidden:       false,
+		IsDeleted:         false,
+		SignupApplication: application.Name,
+		Properties:        map[string]string{"no": strconv.Itoa(int(count + 2))},
+	}
+
+	if provider.Category == "SAML" && user.Email == "" && util.IsEmailValid(userInfo.Id) {
+		user.Email = userInfo.Id
+	}
+
+	groups := []string{}
+	if providerItem != nil && providerItem.SignupGroup != "" {
+		groups = append(groups, providerItem.SignupGroup)
+	}
+
+	jit := provider.JitProvisioning
+	if jit != nil {
+		applyJitAttributeMapping(user, jit, userInfo)
+		for _, group := range jit.Groups {
+			if !util.InSlice(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	affected, err := AddUser(user)
+	if err != nil {
+		return nil, err
+	}
+	if !affected {
+		return nil, fmt.Errorf(i18n.Translate(lang, "auth:Failed to create user, user information is invalid: %s"), util.StructToJson(user))
+	}
+
+	if len(groups) > 0 {
+		user.Groups = groups
+		_, err = UpdateUser(user.GetId(), user, []string{"groups"}, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if jit != nil {
+		for _, role := range jit.Roles {
+			// the rules saved before the owners were checked can still name the roles of another organization
+			if !isJitOrganizationAllowed(provider, strings.Split(role, "/")[0]) {
+				continue
+			}
+
+			err = updateAccessTargetUsers(AccessRequestTypeRole, role, user.GetId(), true)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return user, nil
+}
+
+func checkJitProvisioning(provider *Provider) error {
+	jit := provider.JitProvisioning
+	if jit == nil {
+		return nil
+	}
+
+	for field := range jit.AttributeMapping {
+		if strings.HasPrefix(field, requiredAttributePrefix) {
+			if field == requiredAttributePrefix {
+				return fmt.Errorf("the property name of the JIT attribute mapping: %s is invalid", field)
+			}
+		} else if _, ok := getJitUserField(field); !ok {
+			return fmt.Errorf("the user field: %s of the JIT attribute mapping is not supported", field)
+		}
+	}
+
+	if jit.Organization != "" && !isJitOrganizationAllowed(provider, jit.Organization) {
+		return fmt.Errorf("the organization: %s of the JIT provisioning should be the owner of the provider: %s", jit.Organization, provider.Owner)
+	}
+
+	for _, route := range jit.DomainRoutes {
+		if route.Domain == "" || route.Organization == "" {
+			return fmt.Errorf("the domain route of the JIT provisioning should have both the domain and the organization")
+		}
+		if !isJitOrganizationAllowed(provider, route.Organization) {
+			return fmt.Errorf("the organization: %s of the domain route should be the owner of the provider: %s", route.Organization, provider.Owner)
+		}
+	}
+
+	for _, role := range jit.Roles {
+		tokens := strings.Split(role, "/")
+		if len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" {
+			return fmt.Errorf("the role: %s of the JIT provisioning should be in the format of: owner/name", role)
+		}
+		if !isJitOrganizationAllowed(provider, tokens[0]) {
+			return fmt.Errorf("the role: %s of the JIT provisioning should belong to the owner of the provider: %s", role, provider.Owner)
+		}
+	}
+
+	for _, group := range jit.Groups {
+		if i := strings.Index(group, "/"); i != -1 && !isJitOrganizationAllowed(provider, group[:i]) {
+			return fmt.Errorf("the group: %s of the JIT provisioning should belong to the owner of the provider: %s", group, provider.Owner)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/idp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetJitOrganization(t *testing.T) {
+	application := &Application{Organization: "built-in"}
+	provider := &Provider{}
+	assert.Equal(t, "built-in", GetJitOrganization(provider, application, &idp.UserInfo{Email: "alice@acme.com"}))
+
+	provider = &Provider{Owner: "admin", JitProvisioning: &JitProvisioning{DomainRoutes: []*JitDomainRoute{{Domain: "acme.com", Organization: "acme"}}}}
+	assert.Equal(t, "acme", GetJitOrganization(provider, application, &idp.UserInfo{Email: "alice@ACME.com", EmailVerified: true}))
+	assert.Equal(t, "built-in", GetJitOrganization(provider, application, &idp.UserInfo{Email: "bob@example.com", EmailVerified: true}))
+
+	// the unverified emails are not routed by the domain
+	assert.Equal(t, "built-in", GetJitOrganization(provider, application, &idp.UserInfo{Email: "alice@acme.com"}))
+
+	provider.JitProvisioning.Organization = "guests"
+	assert.Equal(t, "guests", GetJitOrganization(provider, application, &idp.UserInfo{Email: "bob@example.com", EmailVerified: true}))
+	assert.Equal(t, "acme", GetJitOrganization(provider, application, &idp.UserInfo{Email: "alice@acme.com", EmailVerified: true}))
+	assert.Equal(t, "guests", GetJitOrganization(provider, application, &idp.UserInfo{Email: "alice@acme.com"}))
+
+	// a provider of an organization never provisions into another organization
+	provider.Owner = "acme"
+	assert.Equal(t, "acme", GetJitOrganization(provider, application, &idp.UserInfo{Email: "alice@acme.com", EmailVerified: true}))
+	assert.Equal(t, "built-in", GetJitOrganization(provider, application, &idp.UserInfo{Email: "bob@example.com", EmailVerified: true}))
+}
+
+func TestApplyJitAttributeMapping(t *testing.T) {
+	jit := &JitProvisioning{AttributeMapping: map[string]string{
+		"affiliation":         "department",
+		"title":               "jobTitle",
+		"phone":               "mobile",
+		"properties.employee": "employeeNumber",
+		"bio":                 "missing",
+	}}
+	userInfo := &idp.UserInfo{Extra: map[string]string{"department": "R&D", "jobTitle": "Engineer", "mobile": "12345", "employeeNumber": "E001"}}
+
+	user := &User{Bio: "bio"}
+	applyJitAttributeMapping(user, jit, userInfo)
+	assert.Equal(t, "R&D", user.Affiliation)
+	assert.Equal(t, "Engineer", user.Title)
+	assert.Equal(t, "12345", user.Phone)
+	assert.Equal(t, "bio", user.Bio)
+	assert.Equal(t, map[string]string{"employee": "E001"}, user.Properties)
+}
+
+func TestCheckJitProvisioning(t *testing.T) {
+	assert.Nil(t, checkJitProvisioning(&Provider{}))
+	assert.Nil(t, checkJitProvisioning(&Provider{Owner: "acme", JitProvisioning: &JitProvisioning{AttributeMapping: map[string]string{"email": "mail", "properties.team": "team"}, Roles: []string{"acme/staff"}}}))
+	assert.NotNil(t, checkJitProvisioning(&Provider{JitProvisioning: &JitProvisioning{AttributeMapping: map[string]string{"password": "pwd"}}}))
+	assert.NotNil(t, checkJitProvisioning(&Provider{JitProvisioning: &JitProvisioning{AttributeMapping: map[string]string{"properties.": "team"}}}))
+	assert.NotNil(t, checkJitProvisioning(&Provider{JitProvisioning: &JitProvisioning{DomainRoutes: []*JitDomainRoute{{Domain: "acme.com"}}}}))
+	assert.NotNil(t, checkJitProvisioning(&Provider{JitProvisioning: &JitProvisioning{Roles: []string{"staff"}}}))
+
+	// the organizations, roles and groups of another organization than the provider's
+	assert.Nil(t, checkJitProvisioning(&Provider{Owner: "acme", JitProvisioning: &JitProvisioning{Organization: "acme", Roles: []string{"acme/staff"}, Groups: []string{"acme/dev", "ops"}, DomainRoutes: []*JitDomainRoute{{Domain: "acme.com", Organization: "acme"}}}}))
+	assert.Nil(t, checkJitProvisioning(&Provider{Owner: "admin", JitProvisioning: &JitProvisioning{Organization: "other", Roles: []string{"other/staff"}, DomainRoutes: []*JitDomainRoute{{Domain: "other.com", Organization: "other"}}}}))
+	assert.NotNil(t, checkJitProvisioning(&Provider{Owner: "acme", JitProvisioning: &JitProvisioning{Organization: "other"}}))
+	assert.NotNil(t, checkJitProvisioning(&Provider{Owner: "acme", JitProvisioning: &JitProvisioning{DomainRoutes: []*JitDomainRoute{{Domain: "other.com", Organization: "other"}}}}))
+	assert.NotNil(t, checkJitProvisioning(&Provider{Owner: "acme", JitProvisioning: &JitProvisioning{Roles: []string{"other/staff"}}}))
+	assert.NotNil(t, checkJitProvisioning(&Provider{Owner: "acme", JitProvisioning: &JitProvisioning{Groups: []string{"other/dev"}}}))
+}
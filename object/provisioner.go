@@ -0,0 +1,215 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	ProvisionerTypeScim    = "SCIM"
+	ProvisionerTypeWebhook = "Webhook"
+)
+
+// Provisioner pushes the users and groups of the organization to a downstream application, over SCIM 2.0 or as
+// the JSON events of a custom webhook
+type Provisioner struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	Organization string `xorm:"varchar(100) index" json:"organization"`
+	Type         string `xorm:"varchar(100)" json:"type"`
+
+	// Endpoint is the SCIM base URL like "https://gitlab.example.com/api/scim/v2" or the URL of the webhook
+	Endpoint string    `xorm:"varchar(500)" json:"endpoint"`
+	Token    string    `xorm:"varchar(500)" json:"token"`
+	Headers  []*Header `xorm:"mediumtext" json:"headers"`
+
+	// FieldMapping maps the downstream attributes like "title", "name.givenName" or
+	// "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:department" to the user fields like
+	// "affiliation" or "properties.<key>"
+	FieldMapping map[string]string `xorm:"mediumtext" json:"fieldMapping"`
+
+	// the users in any of the scope groups and with any of the scope tags are provisioned, empty means all
+	ScopeGroups []string `xorm:"mediumtext" json:"scopeGroups"`
+	ScopeTags   []string `xorm:"mediumtext" json:"scopeTags"`
+
+	IsGroupProvisioned bool `json:"isGroupProvisioned"`
+	IsEnabled          bool `json:"isEnabled"`
+
+	// ReconcileInterval is the interval in minutes of the reconciliation runs, 0 means manual runs only
+	ReconcileInterval int                `json:"reconcileInterval"`
+	LastReconcileTime string             `xorm:"varchar(100)" json:"lastReconcileTime"`
+	LastReport        *ProvisionerReport `xorm:"mediumtext" json:"lastReport"`
+}
+
+func GetProvisionerCount(owner, organization, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&Provisioner{Organization: organization})
+}
+
+func GetProvisioners(owner string, organization string) ([]*Provisioner, error) {
+	provisioners := []*Provisioner{}
+	err := ormer.Engine.Desc("created_time").Find(&provisioners, &Provisioner{Owner: owner, Organization: organization})
+	if err != nil {
+		return provisioners, err
+	}
+
+	return provisioners, nil
+}
+
+func GetPaginationProvisioners(owner, organization string, offset, limit int, field, value, sortField, sortOrder string) ([]*Provisioner, error) {
+	provisioners := []*Provisioner{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&provisioners, &Provisioner{Organization: organization})
+	if err != nil {
+		return nil, err
+	}
+
+	return provisioners, nil
+}
+
+func getEnabledProvisioners(organization string) ([]*Provisioner, error) {
+	provisioners := []*Provisioner{}
+	err := ormer.Engine.Where("is_enabled = ?", true).Find(&provisioners, &Provisioner{Organization: organization})
+	if err != nil {
+		return nil, err
+	}
+
+	return provisioners, nil
+}
+
+func getProvisioner(owner string, name string) (*Provisioner, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	provisioner := Provisioner{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&provisioner)
+	if err != nil {
+		return &provisioner, err
+	}
+
+	if existed {
+		return &provisioner, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetProvisioner(id string) (*Provisioner, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getProvisioner(owner, name)
+}
+
+func GetMaskedProvisioner(provisioner *Provisioner) *Provisioner {
+	if provisioner == nil {
+		return nil
+	}
+
+	if provisioner.Token != "" {
+		provisioner.Token = "***"
+	}
+	return provisioner
+}
+
+func GetMaskedProvisioners(provisioners []*Provisioner) []*Provisioner {
+	for _, provisioner := range provisioners {
+		GetMaskedProvisioner(provisioner)
+	}
+	return provisioners
+}
+
+func checkProvisioner(provisioner *Provisioner) error {
+	if provisioner.Type != ProvisionerTypeScim && provisioner.Type != ProvisionerTypeWebhook {
+		return fmt.Errorf("unknown provisioner type: %s", provisioner.Type)
+	}
+
+	u, err := url.Parse(provisioner.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("the endpoint: %s of the provisioner should be an HTTP(S) URL", provisioner.Endpoint)
+	}
+
+	if provisioner.ReconcileInterval < 0 {
+		return fmt.Errorf("the reconcile interval of the provisioner should not be negative")
+	}
+
+	return nil
+}
+
+func UpdateProvisioner(id string, provisioner *Provisioner) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if p, err := getProvisioner(owner, name); err != nil {
+		return false, err
+	} else if p == nil {
+		return false, nil
+	}
+
+	err := checkProvisioner(provisioner)
+	if err != nil {
+		return false, err
+	}
+
+	session := ormer.Engine.ID(core.PK{owner, name}).AllCols()
+	if provisioner.Token == "***" {
+		session.Omit("token")
+	}
+	affected, err := session.Update(provisioner)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func AddProvisioner(provisioner *Provisioner) (bool, error) {
+	err := checkProvisioner(provisioner)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.Insert(provisioner)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func DeleteProvisioner(provisioner *Provisioner) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{provisioner.Owner, provisioner.Name}).Delete(&Provisioner{})
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 1 {
+		err = deleteProvisionerStates(provisioner)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return affected != 0, nil
+}
+
+func (provisioner *Provisioner) GetId() string {
+	return fmt.Sprintf("%s/%s", provisioner.Owner, provisioner.Name)
+}
@@ -0,0 +1,330 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	scimUserSchema  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimGroupSchema = "urn:ietf:params:scim:schemas:core:2.0:Group"
+
+	ProvisioningActionUpsert = "upsert"
+	ProvisioningActionDelete = "delete"
+
+	provisioningTimeout = 10 * time.Second
+)
+
+// getProvisioningUserField returns the value of the user field named by its JSON name or "properties.<key>"
+func getProvisioningUserField(user *User, field string) interface{} {
+	if strings.HasPrefix(field, "properties.") {
+		return user.Properties[strings.TrimPrefix(field, "properties.")]
+	}
+
+	v := reflect.ValueOf(user).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == field {
+			return v.Field(i).Interface()
+		}
+	}
+	return nil
+}
+
+// setScimAttribute sets the attribute like "title", "name.givenName" or "<extension schema URN>:<attribute>"
+func setScimAttribute(payload map[string]interface{}, attribute string, value interface{}) {
+	var path []string
+	if strings.HasPrefix(attribute, "urn:") {
+		i := strings.LastIndex(attribute, ":")
+		schema := attribute[:i]
+		path = []string{schema, attribute[i+1:]}
+
+		schemas, _ := payload["schemas"].([]string)
+		if !util.InSlice(schemas, schema) {
+			payload["schemas"] = append(schemas, schema)
+		}
+	} else {
+		path = strings.Split(attribute, ".")
+	}
+
+	m := payload
+	for _, key := range path[:len(path)-1] {
+		child, ok := m[key].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			m[key] = child
+		}
+		m = child
+	}
+	m[path[len(path)-1]] = value
+}
+
+// isUserInScope checks whether the user is in any of the scope groups and has any of the scope tags
+func (provisioner *Provisioner) isUserInScope(user *User) bool {
+	if user.Owner != provisioner.Organization {
+		return false
+	}
+
+	if len(provisioner.ScopeGroups) != 0 {
+		inGroup := false
+		for _, group := range user.Groups {
+			if util.InSlice(provisioner.ScopeGroups, group) {
+				inGroup = true
+				break
+			}
+		}
+		if !inGroup {
+			return false
+		}
+	}
+
+	if len(provisioner.ScopeTags) != 0 && !util.InSlice(provisioner.ScopeTags, user.Tag) {
+		return false
+	}
+
+	return true
+}
+
+func (provisioner *Provisioner) isGroupInScope(groupId string) bool {
+	return len(provisioner.ScopeGroups) == 0 || util.InSlice(provisioner.ScopeGroups, groupId)
+}
+
+// getUserPayload returns the SCIM user resource of the user with the field mapping applied, the webhooks get
+// the same resource
+func (provisioner *Provisioner) getUserPayload(user *User, isActive bool) map[string]interface{} {
+	payload := map[string]interface{}{
+		"schemas":     []string{scimUserSchema},
+		"externalId":  user.GetId(),
+		"userName":    user.Name,
+		"displayName": user.DisplayName,
+		"active":      isActive,
+	}
+
+	if user.FirstName != "" || user.LastName != "" {
+		payload["name"] = map[string]interface{}{
+			"givenName":  user.FirstName,
+			"familyName": user.LastName,
+		}
+	}
+	if user.Email != "" {
+		payload["emails"] = []map[string]interface{}{{"value": user.Email, "primary": true}}
+	}
+	if user.Phone != "" {
+		payload["phoneNumbers"] = []map[string]interface{}{{"value": user.Phone}}
+	}
+
+	for attribute, field := range provisioner.FieldMapping {
+		setScimAttribute(payload, attribute, getProvisioningUserField(user, field))
+	}
+
+	return payload
+}
+
+func (provisioner *Provisioner) getGroupPayload(group *Group, memberIds []string) map[string]interface{} {
+	members := []map[string]interface{}{}
+	for _, memberId := range memberIds {
+		members = append(members, map[string]interface{}{"value": memberId})
+	}
+
+	displayName := group.DisplayName
+	if displayName == "" {
+		displayName = group.Name
+	}
+
+	return map[string]interface{}{
+		"schemas":     []string{scimGroupSchema},
+		"externalId":  group.GetId(),
+		"displayName": displayName,
+		"members":     members,
+	}
+}
+
+func (provisioner *Provisioner) doRequest(method string, path string, payload interface{}) (int, []byte, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return 0, nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(provisioner.Endpoint, "/")+path, body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if provisioner.Type == ProvisionerTypeScim {
+		req.Header.Set("Content-Type", "application/scim+json")
+		req.Header.Set("Accept", "application/scim+json")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if provisioner.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+provisioner.Token)
+	}
+	for _, header := range provisioner.Headers {
+		req.Header.Set(header.Name, header.Value)
+	}
+
+	client := &http.Client{Timeout: provisioningTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return resp.StatusCode, respBody, nil
+}
+
+func isHttpSuccess(status int) bool {
+	return status >= 200 && status < 300
+}
+
+func getScimResourceId(body []byte) (string, error) {
+	var resource struct {
+		Id string `json:"id"`
+	}
+	err := json.Unmarshal(body, &resource)
+	if err != nil {
+		return "", err
+	}
+	if resource.Id == "" {
+		return "", fmt.Errorf("the SCIM resource id is missing in the response")
+	}
+	return resource.Id, nil
+}
+
+// findScimResource looks up the id of the existing resource by the unique attribute like "userName"
+func (provisioner *Provisioner) findScimResource(resourceType string, attribute string, value string) (string, error) {
+	filter := fmt.Sprintf("%s eq \"%s\"", attribute, strings.ReplaceAll(value, "\"", "\\\""))
+	status, body, err := provisioner.doRequest(http.MethodGet, fmt.Sprintf("/%s?filter=%s", resourceType, url.QueryEscape(filter)), nil)
+	if err != nil {
+		return "", err
+	}
+	if !isHttpSuccess(status) {
+		return "", fmt.Errorf("failed to find the SCIM %s: %s, status: %d, body: %s", resourceType, value, status, body)
+	}
+
+	var list struct {
+		Resources []struct {
+			Id string `json:"id"`
+		} `json:"Resources"`
+	}
+	err = json.Unmarshal(body, &list)
+	if err != nil {
+		return "", err
+	}
+	if len(list.Resources) == 0 {
+		return "", nil
+	}
+	return list.Resources[0].Id, nil
+}
+
+// pushScimResource replaces the resource if it has been provisioned, creates it otherwise and adopts the existing
+// resource with the same unique attribute on conflict. It returns the id of the resource in the downstream app.
+func (provisioner *Provisioner) pushScimResource(resourceType string, externalId string, payload map[string]interface{}, attribute string) (string, error) {
+	if externalId != "" {
+		status, body, err := provisioner.doRequest(http.MethodPut, fmt.Sprintf("/%s/%s", resourceType, url.PathEscape(externalId)), payload)
+		if err != nil {
+			return "", err
+		}
+		if isHttpSuccess(status) {
+			return externalId, nil
+		}
+		// the resource has been deleted in the downstream app, create it again
+		if status != http.StatusNotFound {
+			return "", fmt.Errorf("failed to update the SCIM %s: %s, status: %d, body: %s", resourceType, externalId, status, body)
+		}
+	}
+
+	status, body, err := provisioner.doRequest(http.MethodPost, "/"+resourceType, payload)
+	if err != nil {
+		return "", err
+	}
+	if isHttpSuccess(status) {
+		return getScimResourceId(body)
+	}
+	if status != http.StatusConflict {
+		return "", fmt.Errorf("failed to create the SCIM %s, status: %d, body: %s", resourceType, status, body)
+	}
+
+	value := fmt.Sprintf("%v", payload[attribute])
+	externalId, err = provisioner.findScimResource(resourceType, attribute, value)
+	if err != nil {
+		return "", err
+	}
+	if externalId == "" {
+		return "", fmt.Errorf("the SCIM %s: %s conflicts but can't be found", resourceType, value)
+	}
+
+	status, body, err = provisioner.doRequest(http.MethodPut, fmt.Sprintf("/%s/%s", resourceType, url.PathEscape(externalId)), payload)
+	if err != nil {
+		return "", err
+	}
+	if !isHttpSuccess(status) {
+		return "", fmt.Errorf("failed to update the SCIM %s: %s, status: %d, body: %s", resourceType, externalId, status, body)
+	}
+	return externalId, nil
+}
+
+func (provisioner *Provisioner) deleteScimResource(resourceType string, externalId string) error {
+	status, body, err := provisioner.doRequest(http.MethodDelete, fmt.Sprintf("/%s/%s", resourceType, url.PathEscape(externalId)), nil)
+	if err != nil {
+		return err
+	}
+	if !isHttpSuccess(status) && status != http.StatusNotFound {
+		return fmt.Errorf("failed to delete the SCIM %s: %s, status: %d, body: %s", resourceType, externalId, status, body)
+	}
+	return nil
+}
+
+// sendWebhookEvent posts the change to the webhook of the provisioner, the object id in Casdoor is used as the
+// external id
+func (provisioner *Provisioner) sendWebhookEvent(action string, objectType string, id string, payload map[string]interface{}) error {
+	event := map[string]interface{}{
+		"action":      action,
+		"type":        objectType,
+		"id":          id,
+		"provisioner": provisioner.GetId(),
+		"data":        payload,
+	}
+
+	status, body, err := provisioner.doRequest(http.MethodPost, "", event)
+	if err != nil {
+		return err
+	}
+	if !isHttpSuccess(status) {
+		return fmt.Errorf("failed to send the provisioning event to the webhook, status: %d, body: %s", status, body)
+	}
+	return nil
+}
@@ -0,0 +1,432 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/builder"
+	"github.com/xorm-io/core"
+)
+
+const (
+	ProvisioningObjectUser  = "user"
+	ProvisioningObjectGroup = "group"
+)
+
+// ProvisionerState keeps the id in the downstream app and the hash of the last pushed resource of a user or group
+type ProvisionerState struct {
+	Provisioner string `xorm:"varchar(100) notnull pk" json:"provisioner"`
+	Type        string `xorm:"varchar(100) notnull pk" json:"type"`
+	Name        string `xorm:"varchar(255) notnull pk" json:"name"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	ExternalId string `xorm:"varchar(255)" json:"externalId"`
+	Hash       string `xorm:"varchar(100)" json:"hash"`
+}
+
+type ProvisionerReport struct {
+	Provisioner string   `json:"provisioner"`
+	StartTime   string   `json:"startTime"`
+	Created     int      `json:"created"`
+	Updated     int      `json:"updated"`
+	Disabled    int      `json:"disabled"`
+	Deleted     int      `json:"deleted"`
+	Skipped     int      `json:"skipped"`
+	Errors      []string `json:"errors"`
+}
+
+func (report *ProvisionerReport) addError(id string, err error) {
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %s", id, err.Error()))
+	}
+}
+
+func getProvisionerStateKey(objectType string, name string) string {
+	return fmt.Sprintf("%s:%s", objectType, name)
+}
+
+// getProvisionerStates returns the states of the users and groups with the names, or all the states if names is nil
+func getProvisionerStates(provisioner *Provisioner, names []string) (map[string]*ProvisionerState, error) {
+	states := []*ProvisionerState{}
+	session := ormer.Engine.Where("provisioner = ?", provisioner.GetId())
+	if names != nil {
+		session = session.In("name", names)
+	}
+	err := session.Find(&states)
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]*ProvisionerState{}
+	for _, state := range states {
+		m[getProvisionerStateKey(state.Type, state.Name)] = state
+	}
+	return m, nil
+}
+
+func saveProvisionerState(state *ProvisionerState) error {
+	state.UpdatedTime = util.GetCurrentTime()
+
+	existed, err := ormer.Engine.Exist(&ProvisionerState{Provisioner: state.Provisioner, Type: state.Type, Name: state.Name})
+	if err != nil {
+		return err
+	}
+
+	if existed {
+		_, err = ormer.Engine.ID(core.PK{state.Provisioner, state.Type, state.Name}).AllCols().Update(state)
+	} else {
+		_, err = ormer.Engine.Insert(state)
+	}
+	return err
+}
+
+func deleteProvisionerState(state *ProvisionerState) error {
+	_, err := ormer.Engine.ID(core.PK{state.Provisioner, state.Type, state.Name}).Delete(&ProvisionerState{})
+	return err
+}
+
+func deleteProvisionerStates(provisioner *Provisioner) error {
+	_, err := ormer.Engine.Delete(&ProvisionerState{Provisioner: provisioner.GetId()})
+	return err
+}
+
+func getScimResourceType(objectType string) (string, string) {
+	if objectType == ProvisioningObjectGroup {
+		return "Groups", "displayName"
+	}
+	return "Users", "userName"
+}
+
+// pushObject pushes the changed resource and saves its state, the unchanged resources are skipped
+func (provisioner *Provisioner) pushObject(objectType string, id string, payload map[string]interface{}, states map[string]*ProvisionerState) (bool, error) {
+	key := getProvisionerStateKey(objectType, id)
+	state := states[key]
+
+	hash := util.GetMd5Hash(util.StructToJson(payload))
+	if state != nil && state.Hash == hash {
+		return false, nil
+	}
+
+	if state == nil {
+		state = &ProvisionerState{Provisioner: provisioner.GetId(), Type: objectType, Name: id}
+	}
+
+	externalId := id
+	var err error
+	if provisioner.Type == ProvisionerTypeScim {
+		resourceType, attribute := getScimResourceType(objectType)
+		externalId, err = provisioner.pushScimResource(resourceType, state.ExternalId, payload, attribute)
+	} else {
+		err = provisioner.sendWebhookEvent(ProvisioningActionUpsert, objectType, id, payload)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	state.ExternalId = externalId
+	state.Hash = hash
+	err = saveProvisionerState(state)
+	if err != nil {
+		return false, err
+	}
+
+	states[key] = state
+	return true, nil
+}
+
+// deleteObject deletes the provisioned resource of the user or group which has been deleted in Casdoor
+func (provisioner *Provisioner) deleteObject(objectType string, id string, states map[string]*ProvisionerState, report *ProvisionerReport) error {
+	key := getProvisionerStateKey(objectType, id)
+	state := states[key]
+	if state == nil {
+		report.Skipped++
+		return nil
+	}
+
+	var err error
+	if provisioner.Type == ProvisionerTypeScim {
+		resourceType, _ := getScimResourceType(objectType)
+		err = provisioner.deleteScimResource(resourceType, state.ExternalId)
+	} else {
+		err = provisioner.sendWebhookEvent(ProvisioningActionDelete, objectType, id, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = deleteProvisionerState(state)
+	if err != nil {
+		return err
+	}
+
+	delete(states, key)
+	report.Deleted++
+	return nil
+}
+
+// provisionUser creates or updates the user in the downstream app, the forbidden, suspended and out-of-scope users
+// are disabled there and the deleted users are deleted there
+func (provisioner *Provisioner) provisionUser(id string, user *User, states map[string]*ProvisionerState, report *ProvisionerReport) error {
+	if user == nil || user.IsDeleted {
+		return provisioner.deleteObject(ProvisioningObjectUser, id, states, report)
+	}
+
+	isInScope := provisioner.isUserInScope(user)
+	isProvisioned := states[getProvisionerStateKey(ProvisioningObjectUser, id)] != nil
+	if !isInScope && !isProvisioned {
+		report.Skipped++
+		return nil
+	}
+
+	isActive := isInScope && !user.IsForbidden && !user.IsSuspensionActive()
+	pushed, err := provisioner.pushObject(ProvisioningObjectUser, id, provisioner.getUserPayload(user, isActive), states)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !pushed:
+		report.Skipped++
+	case !isProvisioned:
+		report.Created++
+	case !isActive:
+		report.Disabled++
+	default:
+		report.Updated++
+	}
+	return nil
+}
+
+// getGroupMemberIds returns the sorted downstream ids of the provisioned members of the group
+func (provisioner *Provisioner) getGroupMemberIds(groupId string, users []*User, states map[string]*ProvisionerState) []string {
+	memberIds := []string{}
+	for _, user := range users {
+		if !util.InSlice(user.Groups, groupId) || !provisioner.isUserInScope(user) {
+			continue
+		}
+
+		if state := states[getProvisionerStateKey(ProvisioningObjectUser, user.GetId())]; state != nil {
+			memberIds = append(memberIds, state.ExternalId)
+		}
+	}
+
+	sort.Strings(memberIds)
+	return memberIds
+}
+
+func (provisioner *Provisioner) provisionGroup(id string, group *Group, users []*User, states map[string]*ProvisionerState, report *ProvisionerReport) error {
+	if group == nil || !provisioner.isGroupInScope(id) {
+		return provisioner.deleteObject(ProvisioningObjectGroup, id, states, report)
+	}
+
+	isProvisioned := states[getProvisionerStateKey(ProvisioningObjectGroup, id)] != nil
+	payload := provisioner.getGroupPayload(group, provisioner.getGroupMemberIds(id, users, states))
+	pushed, err := provisioner.pushObject(ProvisioningObjectGroup, id, payload, states)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !pushed:
+		report.Skipped++
+	case !isProvisioned:
+		report.Created++
+	default:
+		report.Updated++
+	}
+	return nil
+}
+
+// provisionGroupById pushes the group with its current members
+func (provisioner *Provisioner) provisionGroupById(groupId string, report *ProvisionerReport) error {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(groupId)
+	group, err := getGroup(owner, name)
+	if err != nil {
+		return err
+	}
+
+	users := []*User{}
+	err = ormer.Engine.Where("owner = ?", owner).And(builder.Like{"`groups`", groupId}).Find(&users)
+	if err != nil {
+		return err
+	}
+
+	names := []string{groupId}
+	for _, user := range users {
+		names = append(names, user.GetId())
+	}
+	states, err := getProvisionerStates(provisioner, names)
+	if err != nil {
+		return err
+	}
+
+	return provisioner.provisionGroup(groupId, group, users, states, report)
+}
+
+// provisionUserChange pushes the user and its groups to the enabled provisioners of the organization
+func provisionUserChange(owner string, name string, groupIds []string) {
+	provisioners, err := getEnabledProvisioners(owner)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to get the provisioners, error: %s", err.Error()))
+		return
+	}
+	if len(provisioners) == 0 {
+		return
+	}
+
+	user, err := getUser(owner, name)
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to get the user: %s/%s for provisioning, error: %s", owner, name, err.Error()))
+		return
+	}
+
+	id := util.GetId(owner, name)
+	for _, provisioner := range provisioners {
+		report := &ProvisionerReport{Provisioner: provisioner.GetId()}
+
+		states, err := getProvisionerStates(provisioner, []string{id})
+		if err == nil {
+			err = provisioner.provisionUser(id, user, states, report)
+		}
+		report.addError(id, err)
+
+		if provisioner.IsGroupProvisioned {
+			for _, groupId := range groupIds {
+				if provisioner.isGroupInScope(groupId) {
+					report.addError(groupId, provisioner.provisionGroupById(groupId, report))
+				}
+			}
+		}
+
+		for _, line := range report.Errors {
+			logs.Warning(fmt.Sprintf("provisioner: %s failed to provision %s", provisioner.GetId(), line))
+		}
+	}
+}
+
+// triggerUserProvisioning pushes the change of the user in the background, the users added in batches by the
+// syncers and uploads are provisioned by the reconciliation runs
+func triggerUserProvisioning(owner string, name string, groupIds []string) {
+	util.SafeGoroutine(func() { provisionUserChange(owner, name, groupIds) })
+}
+
+// ReconcileProvisioner pushes all the users and groups of the organization, so that the downstream app catches up
+// with the changes missed by the real-time provisioning
+func ReconcileProvisioner(provisioner *Provisioner) (*ProvisionerReport, error) {
+	report := &ProvisionerReport{Provisioner: provisioner.GetId(), StartTime: util.GetCurrentTime()}
+
+	states, err := getProvisionerStates(provisioner, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := GetUsers(provisioner.Organization)
+	if err != nil {
+		return nil, err
+	}
+
+	userIds := map[string]bool{}
+	for _, user := range users {
+		id := user.GetId()
+		userIds[id] = true
+		report.addError(id, provisioner.provisionUser(id, user, states, report))
+	}
+
+	if provisioner.IsGroupProvisioned {
+		groups, err := GetGroups(provisioner.Organization)
+		if err != nil {
+			return nil, err
+		}
+
+		groupIds := map[string]bool{}
+		for _, group := range groups {
+			id := group.GetId()
+			groupIds[id] = true
+			report.addError(id, provisioner.provisionGroup(id, group, users, states, report))
+		}
+
+		for _, state := range states {
+			if state.Type == ProvisioningObjectGroup && !groupIds[state.Name] {
+				report.addError(state.Name, provisioner.deleteObject(ProvisioningObjectGroup, state.Name, states, report))
+			}
+		}
+	}
+
+	// the groups are deleted first as they may refer to the deleted users
+	for _, state := range states {
+		if state.Type == ProvisioningObjectUser && !userIds[state.Name] {
+			report.addError(state.Name, provisioner.deleteObject(ProvisioningObjectUser, state.Name, states, report))
+		}
+	}
+
+	provisioner.LastReconcileTime = util.GetCurrentTime()
+	provisioner.LastReport = report
+	_, err = ormer.Engine.ID(core.PK{provisioner.Owner, provisioner.Name}).Cols("last_reconcile_time", "last_report").Update(provisioner)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (provisioner *Provisioner) isReconcileDue() bool {
+	if provisioner.ReconcileInterval <= 0 {
+		return false
+	}
+
+	lastReconcileTime, err := time.Parse(time.RFC3339, provisioner.LastReconcileTime)
+	if err != nil {
+		return true
+	}
+	return time.Since(lastReconcileTime) >= time.Duration(provisioner.ReconcileInterval)*time.Minute
+}
+
+func reconcileDueProvisioners() error {
+	provisioners := []*Provisioner{}
+	err := ormer.Engine.Where("is_enabled = ? and reconcile_interval > ?", true, 0).Find(&provisioners)
+	if err != nil {
+		return err
+	}
+
+	for _, provisioner := range provisioners {
+		if !provisioner.isReconcileDue() {
+			continue
+		}
+
+		_, err = ReconcileProvisioner(provisioner)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunProvisionerReconcileJob runs the reconciliation of the provisioners at their intervals
+func RunProvisionerReconcileJob() {
+	for {
+		err := reconcileDueProvisioners()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("provisioner reconciliation failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvisionerUserPayload(t *testing.T) {
+	provisioner := &Provisioner{
+		Organization: "org",
+		FieldMapping: map[string]string{
+			"title":          "affiliation",
+			"name.givenName": "displayName",
+			"urn:ietf:params:scim:schemas:extension:enterprise:2.0:User:employeeNumber": "properties.employeeId",
+		},
+	}
+	user := &User{
+		Owner:       "org",
+		Name:        "alice",
+		DisplayName: "Alice",
+		LastName:    "Liddell",
+		Email:       "alice@example.com",
+		Affiliation: "Engineering",
+		Properties:  map[string]string{"employeeId": "42"},
+	}
+
+	payload := provisioner.getUserPayload(user, false)
+	assert.Equal(t, "org/alice", payload["externalId"])
+	assert.Equal(t, "alice", payload["userName"])
+	assert.Equal(t, false, payload["active"])
+	assert.Equal(t, "Engineering", payload["title"])
+	assert.Equal(t, map[string]interface{}{"givenName": "Alice", "familyName": "Liddell"}, payload["name"])
+	assert.Equal(t, []string{scimUserSchema, "urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"}, payload["schemas"])
+	assert.Equal(t, map[string]interface{}{"employeeNumber": "42"}, payload["urn:ietf:params:scim:schemas:extension:enterprise:2.0:User"])
+}
+
+func TestProvisionerScope(t *testing.T) {
+	provisioner := &Provisioner{Organization: "org"}
+	assert.True(t, provisioner.isUserInScope(&User{Owner: "org"}))
+	assert.False(t, provisioner.isUserInScope(&User{Owner: "other"}))
+
+	provisioner.ScopeGroups = []string{"org/dev", "org/ops"}
+	provisioner.ScopeTags = []string{"staff"}
+	assert.True(t, provisioner.isUserInScope(&User{Owner: "org", Groups: []string{"org/qa", "org/ops"}, Tag: "staff"}))
+	assert.False(t, provisioner.isUserInScope(&User{Owner: "org", Groups: []string{"org/qa"}, Tag: "staff"}))
+	assert.False(t, provisioner.isUserInScope(&User{Owner: "org", Groups: []string{"org/dev"}, Tag: "contractor"}))
+	assert.True(t, provisioner.isGroupInScope("org/dev"))
+	assert.False(t, provisioner.isGroupInScope("org/qa"))
+}
+
+func TestProvisionerPushScimResource(t *testing.T) {
+	requests := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.RequestURI())
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		switch {
+		case r.Method == http.MethodPost:
+			var payload map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&payload)
+			if payload["userName"] == "bob" {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id":"u-1"}`))
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`{"Resources":[{"id":"u-2"}]}`))
+		case r.Method == http.MethodPut && r.URL.Path == "/scim/Users/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer server.Close()
+
+	provisioner := &Provisioner{Type: ProvisionerTypeScim, Endpoint: server.URL + "/scim/", Token: "secret"}
+
+	id, err := provisioner.pushScimResource("Users", "", map[string]interface{}{"userName": "alice"}, "userName")
+	assert.Nil(t, err)
+	assert.Equal(t, "u-1", id)
+
+	// the existing user is adopted on conflict
+	id, err = provisioner.pushScimResource("Users", "", map[string]interface{}{"userName": "bob"}, "userName")
+	assert.Nil(t, err)
+	assert.Equal(t, "u-2", id)
+
+	// the user deleted in the downstream app is created again
+	id, err = provisioner.pushScimResource("Users", "gone", map[string]interface{}{"userName": "carol"}, "userName")
+	assert.Nil(t, err)
+	assert.Equal(t, "u-1", id)
+
+	assert.Nil(t, provisioner.deleteScimResource("Users", "u-3"))
+
+	assert.Equal(t, []string{
+		"POST /scim/Users",
+		"POST /scim/Users",
+		"GET /scim/Users?filter=userName+eq+%22bob%22",
+		"PUT /scim/Users/u-2",
+		"PUT /scim/Users/gone",
+		"POST /scim/Users",
+		"DELETE /scim/Users/u-3",
+	}, requests)
+}
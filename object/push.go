@@ -0,0 +1,241 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/proxy"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	PushProviderFcm  = "Firebase Cloud Messaging"
+	PushProviderApns = "APNs"
+
+	fcmScope        = "https://www.googleapis.com/auth/firebase.messaging"
+	apnsDefaultHost = "https://api.push.apple.com"
+)
+
+// PushNotification is the notification sent to a device, Data is delivered to the mobile app along with it
+type PushNotification struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// fcmServiceAccount is the service account key of the Firebase project, it is the client secret of the FCM provider
+type fcmServiceAccount struct {
+	ProjectId   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenUri    string `json:"token_uri"`
+}
+
+func checkPushProvider(provider *Provider) error {
+	if provider == nil || provider.Category != "Push" {
+		return fmt.Errorf("the push provider is not found")
+	}
+	if provider.Type != PushProviderFcm && provider.Type != PushProviderApns {
+		return fmt.Errorf("the push provider type: %s is not supported", provider.Type)
+	}
+	return nil
+}
+
+// SendPushNotification sends the notification to the device of the token via the push provider
+func SendPushNotification(provider *Provider, deviceToken string, notification *PushNotification) error {
+	err := checkPushProvider(provider)
+	if err != nil {
+		return err
+	}
+
+	if provider.Type == PushProviderFcm {
+		return sendFcmNotification(provider, deviceToken, notification)
+	}
+	return sendApnsNotification(provider, deviceToken, notification)
+}
+
+func doPushRequest(req *http.Request) ([]byte, error) {
+	resp, err := proxy.DefaultHttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the push request failed with status: %s, body: %s", resp.Status, string(body))
+	}
+	return body, nil
+}
+
+// getFcmAccessToken exchanges the signed assertion of the service account for an access token (RFC 7523)
+func getFcmAccessToken(account *fcmServiceAccount) (string, error) {
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(account.PrivateKey))
+	if err != nil {
+		return "", err
+	}
+
+	tokenUri := account.TokenUri
+	if tokenUri == "" {
+		tokenUri = "https://oauth2.googleapis.com/token"
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   account.ClientEmail,
+		"scope": fcmScope,
+		"aud":   tokenUri,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(privateKey)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+	req, err := http.NewRequest("POST", tokenUri, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	body, err := doPushRequest(req)
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// sendFcmNotification sends the notification via the FCM HTTP v1 API, the client secret of the provider
+// is the service account key (JSON) and the client ID overrides its project ID if not empty
+func sendFcmNotification(provider *Provider, deviceToken string, notification *PushNotification) error {
+	var account fcmServiceAccount
+	err := json.Unmarshal([]byte(provider.ClientSecret), &account)
+	if err != nil {
+		return fmt.Errorf("the service account key of the FCM provider is invalid: %s", err.Error())
+	}
+
+	projectId := account.ProjectId
+	if provider.ClientId != "" {
+		projectId = provider.ClientId
+	}
+
+	accessToken, err := getFcmAccessToken(&account)
+	if err != nil {
+		return err
+	}
+
+	message := map[string]interface{}{
+		"message": map[string]interface{}{
+			"token": deviceToken,
+			"notification": map[string]string{
+				"title": notification.Title,
+				"body":  notification.Body,
+			},
+			"data": notification.Data,
+		},
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", projectId), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = doPushRequest(req)
+	return err
+}
+
+// sendApnsNotification sends the notification via the APNs token-based connection, the client ID and client ID 2
+// of the provider are the key ID and the team ID, the client secret is the .p8 key, the app ID is the bundle ID,
+// and the endpoint can be "https://api.sandbox.push.apple.com" for the development builds
+func sendApnsNotification(provider *Provider, deviceToken string, notification *PushNotification) error {
+	privateKey, err := jwt.ParseECPrivateKeyFromPEM([]byte(provider.ClientSecret))
+	if err != nil {
+		return fmt.Errorf("the .p8 key of the APNs provider is invalid: %s", err.Error())
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": provider.ClientId2,
+		"iat": time.Now().Unix(),
+	})
+	token.Header["kid"] = provider.ClientId
+	authToken, err := token.SignedString(privateKey)
+	if err != nil {
+		return err
+	}
+
+	message := map[string]interface{}{
+		"aps": map[string]interface{}{
+			"alert": map[string]string{
+				"title": notification.Title,
+				"body":  notification.Body,
+			},
+			"sound": "default",
+		},
+	}
+	for k, v := range notification.Data {
+		message[k] = v
+	}
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	host := apnsDefaultHost
+	if provider.Endpoint != "" {
+		host = strings.TrimSuffix(provider.Endpoint, "/")
+	}
+
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/3/device/%s", host, deviceToken), bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+authToken)
+	req.Header.Set("apns-topic", provider.AppId)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err = doPushRequest(req)
+	return err
+}
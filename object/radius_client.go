@@ -0,0 +1,301 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const RadiusFieldStatic = "static"
+
+var radiusAttributeFields = []string{"name", "displayName", "email", "phone", "tag", "groups", RadiusFieldStatic}
+
+// RadiusClient is a NAS (network access server) like a VPN gateway or a switch, which authenticates the users
+// of the organization by RADIUS with its own shared secret. It is matched by the source IP of the requests.
+type RadiusClient struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	// IpAddress is the IP address or the CIDR of the client, e.g. "10.0.0.1" or "10.0.0.0/24"
+	IpAddress string `xorm:"varchar(100)" json:"ipAddress"`
+	Secret    string `xorm:"varchar(100)" json:"secret"`
+	IsEnabled bool   `json:"isEnabled"`
+	// EnableMfa challenges all the users for their TOTP codes, the users with MFA enabled are always challenged
+	EnableMfa bool `json:"enableMfa"`
+
+	AttributeMappings []*RadiusAttributeMapping `xorm:"mediumtext" json:"attributeMappings"`
+}
+
+// RadiusAttributeMapping sends a field of the user in the Access-Accept, as a vendor-specific attribute if the vendor
+// ID is set. E.g. the vendor 9 (Cisco) type 1 (Cisco-AVPair) with the static value "shell:priv-lvl=15" for the
+// members of the group "network-admins". The "groups" field sends an attribute for each group of the user.
+type RadiusAttributeMapping struct {
+	VendorId int    `json:"vendorId"`
+	Type     int    `json:"type"`
+	Field    string `json:"field"`
+	Value    string `json:"value"`
+	Group    string `json:"group"`
+}
+
+func GetRadiusClientCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&RadiusClient{})
+}
+
+func GetRadiusClients(owner string) ([]*RadiusClient, error) {
+	radiusClients := []*RadiusClient{}
+	err := ormer.Engine.Desc("created_time").Find(&radiusClients, &RadiusClient{Owner: owner})
+	if err != nil {
+		return radiusClients, err
+	}
+
+	return radiusClients, nil
+}
+
+func GetPaginationRadiusClients(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*RadiusClient, error) {
+	radiusClients := []*RadiusClient{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&radiusClients)
+	if err != nil {
+		return radiusClients, err
+	}
+
+	return radiusClients, nil
+}
+
+func getRadiusClient(owner string, name string) (*RadiusClient, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	radiusClient := RadiusClient{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&radiusClient)
+	if err != nil {
+		return &radiusClient, err
+	}
+
+	if existed {
+		return &radiusClient, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetRadiusClient(id string) (*RadiusClient, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getRadiusClient(owner, name)
+}
+
+func GetMaskedRadiusClient(radiusClient *RadiusClient, errs ...error) (*RadiusClient, error) {
+	if len(errs) > 0 && errs[0] != nil {
+		return nil, errs[0]
+	}
+
+	if radiusClient == nil {
+		return nil, nil
+	}
+
+	if radiusClient.Secret != "" {
+		radiusClient.Secret = "***"
+	}
+	return radiusClient, nil
+}
+
+func GetMaskedRadiusClients(radiusClients []*RadiusClient, errs ...error) ([]*RadiusClient, error) {
+	if len(errs) > 0 && errs[0] != nil {
+		return nil, errs[0]
+	}
+
+	for _, radiusClient := range radiusClients {
+		_, err := GetMaskedRadiusClient(radiusClient)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return radiusClients, nil
+}
+
+// matchIp checks whether the IP is the IP address or in the CIDR of the client
+func (radiusClient *RadiusClient) matchIp(ip net.IP) bool {
+	if strings.Contains(radiusClient.IpAddress, "/") {
+		_, ipNet, err := net.ParseCIDR(radiusClient.IpAddress)
+		return err == nil && ipNet.Contains(ip)
+	}
+
+	clientIp := net.ParseIP(radiusClient.IpAddress)
+	return clientIp != nil && clientIp.Equal(ip)
+}
+
+// GetRadiusClientByIp returns the enabled client of the IP, the client of an IP address wins over the ones of CIDRs
+func GetRadiusClientByIp(ip net.IP) (*RadiusClient, error) {
+	radiusClients := []*RadiusClient{}
+	err := ormer.Engine.Where("is_enabled = ?", true).Find(&radiusClients)
+	if err != nil {
+		return nil, err
+	}
+
+	var res *RadiusClient
+	for _, radiusClient := range radiusClients {
+		if !radiusClient.matchIp(ip) {
+			continue
+		}
+
+		if !strings.Contains(radiusClient.IpAddress, "/") {
+			return radiusClient, nil
+		}
+		if res == nil {
+			res = radiusClient
+		}
+	}
+	return res, nil
+}
+
+func checkRadiusClient(radiusClient *RadiusClient) error {
+	if _, _, err := net.ParseCIDR(radiusClient.IpAddress); err != nil && net.ParseIP(radiusClient.IpAddress) == nil {
+		return fmt.Errorf("the IP address: %s of the RADIUS client is invalid", radiusClient.IpAddress)
+	}
+	if radiusClient.Secret == "" {
+		return fmt.Errorf("the secret of the RADIUS client should not be empty")
+	}
+
+	for _, mapping := range radiusClient.AttributeMappings {
+		if mapping.Type <= 0 || mapping.Type > 255 {
+			return fmt.Errorf("the RADIUS attribute type: %d should be between 1 and 255", mapping.Type)
+		}
+		if mapping.VendorId < 0 {
+			return fmt.Errorf("the RADIUS vendor ID: %d should not be negative", mapping.VendorId)
+		}
+		if !util.InSlice(radiusAttributeFields, mapping.Field) {
+			return fmt.Errorf("the RADIUS attribute field: %s is not supported", mapping.Field)
+		}
+	}
+	return nil
+}
+
+func UpdateRadiusClient(id string, radiusClient *RadiusClient) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	oldRadiusClient, err := getRadiusClient(owner, name)
+	if err != nil {
+		return false, err
+	} else if oldRadiusClient == nil {
+		return false, nil
+	}
+
+	if radiusClient.Secret == "***" {
+		radiusClient.Secret = oldRadiusClient.Secret
+	}
+
+	err = checkRadiusClient(radiusClient)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(radiusClient)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func AddRadiusClient(radiusClient *RadiusClient) (bool, error) {
+	err := checkRadiusClient(radiusClient)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.Insert(radiusClient)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func DeleteRadiusClient(radiusClient *RadiusClient) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{radiusClient.Owner, radiusClient.Name}).Delete(&RadiusClient{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func (radiusClient *RadiusClient) GetId() string {
+	return fmt.Sprintf("%s/%s", radiusClient.Owner, radiusClient.Name)
+}
+
+func isUserInGroup(user *User, group string) bool {
+	if !strings.Contains(group, "/") {
+		group = util.GetId(user.Owner, group)
+	}
+	return util.InSlice(user.Groups, group)
+}
+
+// GetRadiusAttributeValues returns the values of the attribute mapped from the user, none if the user isn't in its group
+func GetRadiusAttributeValues(mapping *RadiusAttributeMapping, user *User) []string {
+	if mapping.Group != "" && !isUserInGroup(user, mapping.Group) {
+		return nil
+	}
+
+	var value string
+	switch mapping.Field {
+	case RadiusFieldStatic:
+		value = mapping.Value
+	case "name":
+		value = user.Name
+	case "displayName":
+		value = user.DisplayName
+	case "email":
+		value = user.Email
+	case "phone":
+		value = user.Phone
+	case "tag":
+		value = user.Tag
+	case "groups":
+		res := []string{}
+		for _, group := range user.Groups {
+			tokens := strings.SplitN(group, "/", 2)
+			res = append(res, tokens[len(tokens)-1])
+		}
+		return res
+	}
+
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// IsRadiusMfaRequired checks whether the user is challenged for the TOTP code by the client
+func IsRadiusMfaRequired(radiusClient *RadiusClient, user *User) bool {
+	return (radiusClient != nil && radiusClient.EnableMfa) || user.IsMfaEnabled()
+}
+
+// VerifyRadiusMfaCode verifies the TOTP code of the user answering the Access-Challenge
+func VerifyRadiusMfaCode(user *User, passcode string) error {
+	if user.TotpSecret == "" {
+		return fmt.Errorf("the user: %s has no TOTP MFA set up", user.GetId())
+	}
+
+	return GetMfaUtil(TotpType, user.GetMfaProps(TotpType, false)).Verify(passcode)
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRadiusClientMatchIp(t *testing.T) {
+	radiusClient := &RadiusClient{IpAddress: "10.0.0.1"}
+	assert.True(t, radiusClient.matchIp(net.ParseIP("10.0.0.1")))
+	assert.False(t, radiusClient.matchIp(net.ParseIP("10.0.0.2")))
+
+	radiusClient.IpAddress = "10.0.0.0/24"
+	assert.True(t, radiusClient.matchIp(net.ParseIP("10.0.0.2")))
+	assert.False(t, radiusClient.matchIp(net.ParseIP("10.0.1.2")))
+}
+
+func TestCheckRadiusClient(t *testing.T) {
+	radiusClient := &RadiusClient{IpAddress: "10.0.0.0/24", Secret: "secret"}
+	assert.Nil(t, checkRadiusClient(radiusClient))
+
+	radiusClient.AttributeMappings = []*RadiusAttributeMapping{{VendorId: 9, Type: 1, Field: RadiusFieldStatic, Value: "shell:priv-lvl=15"}}
+	assert.Nil(t, checkRadiusClient(radiusClient))
+
+	radiusClient.AttributeMappings[0].Type = 256
+	assert.NotNil(t, checkRadiusClient(radiusClient))
+
+	radiusClient.AttributeMappings[0].Type = 1
+	radiusClient.AttributeMappings[0].Field = "password"
+	assert.NotNil(t, checkRadiusClient(radiusClient))
+
+	assert.NotNil(t, checkRadiusClient(&RadiusClient{IpAddress: "10.0.0", Secret: "secret"}))
+	assert.NotNil(t, checkRadiusClient(&RadiusClient{IpAddress: "10.0.0.1"}))
+}
+
+func TestGetRadiusAttributeValues(t *testing.T) {
+	user := &User{Owner: "org", Name: "alice", Email: "alice@example.com", Groups: []string{"org/network-admins", "org/staff"}}
+
+	assert.Equal(t, []string{"alice@example.com"}, GetRadiusAttributeValues(&RadiusAttributeMapping{Field: "email"}, user))
+	assert.Nil(t, GetRadiusAttributeValues(&RadiusAttributeMapping{Field: "phone"}, user))
+	assert.Equal(t, []string{"network-admins", "staff"}, GetRadiusAttributeValues(&RadiusAttributeMapping{Field: "groups"}, user))
+
+	mapping := &RadiusAttributeMapping{Field: RadiusFieldStatic, Value: "shell:priv-lvl=15", Group: "network-admins"}
+	assert.Equal(t, []string{"shell:priv-lvl=15"}, GetRadiusAttributeValues(mapping, user))
+
+	mapping.Group = "org/guests"
+	assert.Nil(t, GetRadiusAttributeValues(mapping, user))
+}
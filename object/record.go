@@ -61,6 +61,8 @@ func NewRecord(ctx *context.Context) *casvisorsdk.Record {
 	return &record
 }
 
+// AddRecord puts the record into the queue of the record writer, it returns false if the record is filtered out
+// or dropped for the full queue
 func AddRecord(record *casvisorsdk.Record) bool {
 	if logPostOnly {
 		if record.Method == "GET" {
@@ -72,13 +74,33 @@ func AddRecord(record *casvisorsdk.Record) bool {
 		return false
 	}
 
+	if !redactRecordByOrganization(record) {
+		return false
+	}
+
 	record.Owner = record.Organization
 
-	errWebhook := SendWebhooks(record)
-	if errWebhook == nil {
-		record.IsTriggered = true
-	} else {
-		fmt.Println(errWebhook)
+	if globalRecordWriter != nil {
+		return globalRecordWriter.enqueue(record)
+	}
+
+	return writeRecord(record)
+}
+
+func writeRecord(record *casvisorsdk.Record) bool {
+	// the webhook events of the records of the transactional mutations are already in the outbox
+	if !record.IsTriggered {
+		errWebhook := SendWebhooks(record)
+		if errWebhook == nil {
+			record.IsTriggered = true
+		} else {
+			fmt.Println(errWebhook)
+		}
+	}
+
+	err := exportRecordToSiem(record)
+	if err != nil {
+		fmt.Printf("exportRecordToSiem() error: %s\n", err.Error())
 	}
 
 	if casvisorsdk.GetClient() == nil {
@@ -93,8 +115,9 @@ func AddRecord(record *casvisorsdk.Record) bool {
 	return affected
 }
 
-func getFilteredWebhooks(webhooks []*Webhook, action string) []*Webhook {
+func getFilteredWebhooks(webhooks []*Webhook, record *casvisorsdk.Record) []*Webhook {
 	res := []*Webhook{}
+	application := ""
 	for _, webhook := range webhooks {
 		if !webhook.IsEnabled {
 			continue
@@ -102,56 +125,37 @@ func getFilteredWebhooks(webhooks []*Webhook, action string) []*Webhook {
 
 		matched := false
 		for _, event := range webhook.Events {
-			if action == event {
+			if record.Action == event {
 				matched = true
 				break
 			}
 		}
+		if !matched {
+			continue
+		}
 
-		if matched {
-			res = append(res, webhook)
+		// the webhooks scoped to the applications only receive the events of them
+		if len(webhook.Applications) != 0 {
+			if application == "" {
+				application = getRecordApplication(record)
+			}
+			if !util.InSlice(webhook.Applications, application) {
+				continue
+			}
 		}
+
+		res = append(res, webhook)
 	}
 	return res
 }
 
+// SendWebhooks writes the events of the record into the outbox, which the dispatcher delivers to the webhooks
 func SendWebhooks(record *casvisorsdk.Record) error {
-	webhooks, err := getWebhooksByOrganization(record.Organization)
+	err := addWebhookEvents(ormer.Engine, record)
 	if err != nil {
 		return err
 	}
 
-	errs := []error{}
-	webhooks = getFilteredWebhooks(webhooks, record.Action)
-	for _, webhook := range webhooks {
-		var user *User
-		if webhook.IsUserExtended {
-			user, err = getUser(record.Organization, record.User)
-			if err != nil {
-				errs = append(errs, err)
-				continue
-			}
-
-			user, err = GetMaskedUser(user, false, err)
-			if err != nil {
-				errs = append(errs, err)
-				continue
-			}
-		}
-
-		err = sendWebhook(webhook, record, user)
-		if err != nil {
-			errs = append(errs, err)
-			continue
-		}
-	}
-
-	if len(errs) > 0 {
-		errStrings := []string{}
-		for _, err := range errs {
-			errStrings = append(errStrings, err.Error())
-		}
-		return fmt.Errorf(strings.Join(errStrings, " | "))
-	}
+	wakeWebhookDispatcher()
 	return nil
 }
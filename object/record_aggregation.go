@@ -0,0 +1,101 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"sort"
+	"time"
+
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+)
+
+// RecordCount is the number of the records with the same key, e.g. the action or the hour
+type RecordCount struct {
+	Key   string `json:"key"`
+	Count int    `json:"count"`
+}
+
+// RecordAggregation counts the records of a record query, so the dashboard doesn't count the paged records itself
+type RecordAggregation struct {
+	Total         int            `json:"total"`
+	UniqueUsers   int            `json:"uniqueUsers"`
+	ByAction      []*RecordCount `json:"byAction"`
+	ByApplication []*RecordCount `json:"byApplication"`
+	ByHour        []*RecordCount `json:"byHour"`
+}
+
+// getRecordHour returns the hour of the record in UTC, e.g. "2023-06-01T08:00:00Z"
+func getRecordHour(record *casvisorsdk.Record) string {
+	createdTime, err := time.Parse(time.RFC3339, record.CreatedTime)
+	if err != nil {
+		return ""
+	}
+	return createdTime.UTC().Truncate(time.Hour).Format(time.RFC3339)
+}
+
+// getRecordCounts sorts the counts by the key for the hours, or the most records first for the others
+func getRecordCounts(counts map[string]int, isSortedByKey bool) []*RecordCount {
+	res := []*RecordCount{}
+	for key, count := range counts {
+		res = append(res, &RecordCount{Key: key, Count: count})
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if !isSortedByKey && res[i].Count != res[j].Count {
+			return res[i].Count > res[j].Count
+		}
+		return res[i].Key < res[j].Key
+	})
+	return res
+}
+
+func AggregateRecords(records []*casvisorsdk.Record) *RecordAggregation {
+	users := map[string]bool{}
+	actions := map[string]int{}
+	applications := map[string]int{}
+	hours := map[string]int{}
+	for _, record := range records {
+		if record.User != "" {
+			users[record.User] = true
+		}
+
+		actions[record.Action]++
+
+		if application := getRecordApplication(record); application != "" {
+			applications[application]++
+		}
+
+		if hour := getRecordHour(record); hour != "" {
+			hours[hour]++
+		}
+	}
+
+	return &RecordAggregation{
+		Total:         len(records),
+		UniqueUsers:   len(users),
+		ByAction:      getRecordCounts(actions, false),
+		ByApplication: getRecordCounts(applications, false),
+		ByHour:        getRecordCounts(hours, true),
+	}
+}
+
+func GetRecordAggregation(recordQuery *RecordQuery) (*RecordAggregation, error) {
+	records, err := SearchRecords(recordQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	return AggregateRecords(records), nil
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregateRecords(t *testing.T) {
+	records := []*casvisorsdk.Record{
+		{User: "alice", Action: "login", CreatedTime: "2023-06-01T08:10:00+08:00", Object: `{"application":"app-1","username":"alice"}`},
+		{User: "bob", Action: "login", CreatedTime: "2023-06-01T08:50:00+08:00", Object: `{"application":"app-2","username":"bob"}`},
+		{User: "alice", Action: "update-user", CreatedTime: "2023-06-01T09:05:00+08:00", RequestUri: "/api/update-user?id=org-1/alice"},
+		{User: "", Action: "login", CreatedTime: "2023-06-01T01:20:00Z", RequestUri: "/api/login?application=app-1"},
+	}
+
+	aggregation := AggregateRecords(records)
+	assert.Equal(t, 4, aggregation.Total)
+	assert.Equal(t, 2, aggregation.UniqueUsers)
+	assert.Equal(t, []*RecordCount{{Key: "login", Count: 3}, {Key: "update-user", Count: 1}}, aggregation.ByAction)
+	assert.Equal(t, []*RecordCount{{Key: "app-1", Count: 2}, {Key: "app-2", Count: 1}}, aggregation.ByApplication)
+	assert.Equal(t, []*RecordCount{{Key: "2023-06-01T00:00:00Z", Count: 2}, {Key: "2023-06-01T01:00:00Z", Count: 2}}, aggregation.ByHour)
+
+	aggregation = AggregateRecords([]*casvisorsdk.Record{})
+	assert.Equal(t, 0, aggregation.Total)
+	assert.Empty(t, aggregation.ByAction)
+}
+
+func TestRecordQueryText(t *testing.T) {
+	record := &casvisorsdk.Record{Action: "update-user", Object: `{"displayName":"Alice Smith","email":"alice@example.com"}`}
+
+	assert.True(t, (&RecordQuery{}).IsMatched(record))
+	assert.True(t, (&RecordQuery{Text: "alice@EXAMPLE.com"}).IsMatched(record))
+	assert.True(t, (&RecordQuery{Text: "smith  alice"}).IsMatched(record))
+	assert.False(t, (&RecordQuery{Text: "alice bob"}).IsMatched(record))
+	assert.False(t, (&RecordQuery{Actions: []string{"login"}, Text: "alice"}).IsMatched(record))
+}
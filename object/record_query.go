@@ -0,0 +1,296 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/xorm-io/builder"
+	"github.com/xorm-io/core"
+)
+
+type RecordQuery struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+
+	// User is the creator of the query, a query that is not shared is only visible to its creator
+	User     string `xorm:"varchar(100) index" json:"user"`
+	IsShared bool   `json:"isShared"`
+
+	Actions   []string `xorm:"varchar(1000)" json:"actions"`
+	Actor     string   `xorm:"varchar(100)" json:"actor"`
+	Object    string   `xorm:"varchar(100)" json:"object"`
+	ClientIp  string   `xorm:"varchar(100)" json:"clientIp"`
+	Method    string   `xorm:"varchar(100)" json:"method"`
+	StartTime string   `xorm:"varchar(100)" json:"startTime"`
+	EndTime   string   `xorm:"varchar(100)" json:"endTime"`
+
+	// Text is the full-text search on the detail (the object) of the records, all the words should be found
+	Text string `xorm:"varchar(1000)" json:"text"`
+}
+
+// getRecordQueryUserCond matches the queries created by the user or shared in the organization,
+// "user" is a reserved word in some databases so the column is quoted
+func getRecordQueryUserCond(user string) builder.Cond {
+	return builder.Or(builder.Eq{"`user`": user}, builder.Eq{"is_shared": true})
+}
+
+func GetRecordQueryCount(owner, user, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	if user != "" {
+		session = session.And(getRecordQueryUserCond(user))
+	}
+	return session.Count(&RecordQuery{})
+}
+
+func GetRecordQueries(owner, user string) ([]*RecordQuery, error) {
+	recordQueries := []*RecordQuery{}
+	session := ormer.GetReadEngine().Desc("created_time")
+	if user != "" {
+		session = session.Where(getRecordQueryUserCond(user))
+	}
+	err := session.Find(&recordQueries, &RecordQuery{Owner: owner})
+	if err != nil {
+		return recordQueries, err
+	}
+
+	return recordQueries, nil
+}
+
+func GetPaginationRecordQueries(owner, user string, offset, limit int, field, value, sortField, sortOrder string) ([]*RecordQuery, error) {
+	recordQueries := []*RecordQuery{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	if user != "" {
+		session = session.And(getRecordQueryUserCond(user))
+	}
+	err := session.Find(&recordQueries)
+	if err != nil {
+		return recordQueries, err
+	}
+
+	return recordQueries, nil
+}
+
+func getRecordQuery(owner string, name string) (*RecordQuery, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	recordQuery := RecordQuery{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&recordQuery)
+	if err != nil {
+		return &recordQuery, err
+	}
+
+	if existed {
+		return &recordQuery, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetRecordQuery(id string) (*RecordQuery, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getRecordQuery(owner, name)
+}
+
+func UpdateRecordQuery(id string, recordQuery *RecordQuery) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if q, err := getRecordQuery(owner, name); err != nil {
+		return false, err
+	} else if q == nil {
+		return false, nil
+	}
+
+	err := recordQuery.checkTimeRange()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(recordQuery)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func AddRecordQuery(recordQuery *RecordQuery) (bool, error) {
+	err := recordQuery.checkTimeRange()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.Insert(recordQuery)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func DeleteRecordQuery(recordQuery *RecordQuery) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{recordQuery.Owner, recordQuery.Name}).Delete(&RecordQuery{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func (recordQuery *RecordQuery) GetId() string {
+	return fmt.Sprintf("%s/%s", recordQuery.Owner, recordQuery.Name)
+}
+
+func (recordQuery *RecordQuery) checkTimeRange() error {
+	for _, t := range []string{recordQuery.StartTime, recordQuery.EndTime} {
+		if t == "" {
+			continue
+		}
+
+		if _, err := time.Parse(time.RFC3339, t); err != nil {
+			return fmt.Errorf("the time: %s is not in RFC3339 format", t)
+		}
+	}
+	return nil
+}
+
+func isClientIpMatched(clientIp string, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	_, ipNet, err := net.ParseCIDR(pattern)
+	if err != nil {
+		return strings.Contains(clientIp, pattern)
+	}
+
+	// the client IP can be a proxy chain like "1.1.1.1 -> 2.2.2.2"
+	for _, ip := range strings.Split(clientIp, "->") {
+		parsedIp := net.ParseIP(strings.TrimSpace(ip))
+		if parsedIp != nil && ipNet.Contains(parsedIp) {
+			return true
+		}
+	}
+	return false
+}
+
+// isRecordTextMatched checks whether the detail of the record contains all the words of the text, case-insensitively
+func isRecordTextMatched(record *casvisorsdk.Record, text string) bool {
+	detail := strings.ToLower(record.Object)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		if !strings.Contains(detail, word) {
+			return false
+		}
+	}
+	return true
+}
+
+func (recordQuery *RecordQuery) IsMatched(record *casvisorsdk.Record) bool {
+	if len(recordQuery.Actions) != 0 && !util.InSlice(recordQuery.Actions, record.Action) {
+		return false
+	}
+	if recordQuery.Actor != "" && record.User != recordQuery.Actor {
+		return false
+	}
+	if recordQuery.Object != "" && !strings.Contains(record.Object, recordQuery.Object) {
+		return false
+	}
+	if recordQuery.Method != "" && !strings.EqualFold(record.Method, recordQuery.Method) {
+		return false
+	}
+	if !isClientIpMatched(record.ClientIp, recordQuery.ClientIp) {
+		return false
+	}
+	if !isRecordTextMatched(record, recordQuery.Text) {
+		return false
+	}
+
+	if recordQuery.StartTime != "" || recordQuery.EndTime != "" {
+		createdTime, err := time.Parse(time.RFC3339, record.CreatedTime)
+		if err != nil {
+			return false
+		}
+		if recordQuery.StartTime != "" && createdTime.Before(util.String2Time(recordQuery.StartTime)) {
+			return false
+		}
+		if recordQuery.EndTime != "" && createdTime.After(util.String2Time(recordQuery.EndTime)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SearchRecords executes the query against the records of the query's organization, newest first
+func SearchRecords(recordQuery *RecordQuery) ([]*casvisorsdk.Record, error) {
+	if casvisorsdk.GetClient() == nil {
+		return nil, fmt.Errorf("the records are not available because Casvisor is not configured")
+	}
+
+	err := recordQuery.checkTimeRange()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := casvisorsdk.GetRecords()
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*casvisorsdk.Record{}
+	for _, record := range records {
+		if record.Organization != recordQuery.Owner {
+			continue
+		}
+
+		if recordQuery.IsMatched(record) {
+			res = append(res, record)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].CreatedTime > res[j].CreatedTime
+	})
+	return res, nil
+}
+
+func GetRecordsCsv(records []*casvisorsdk.Record) [][]string {
+	res := [][]string{{"name", "createdTime", "organization", "user", "clientIp", "method", "requestUri", "action", "object", "isTriggered"}}
+	for _, record := range records {
+		res = append(res, []string{
+			record.Name,
+			record.CreatedTime,
+			record.Organization,
+			record.User,
+			record.ClientIp,
+			record.Method,
+			record.RequestUri,
+			record.Action,
+			record.Object,
+			strconv.FormatBool(record.IsTriggered),
+		})
+	}
+	return res
+}
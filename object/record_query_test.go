@@ -0,0 +1,60 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRecordQueriesOfUser(t *testing.T) {
+	setTestOrmer(t, &RecordQuery{})
+
+	for _, recordQuery := range []*RecordQuery{
+		{Owner: "org", Name: "own", User: "alice"},
+		{Owner: "org", Name: "shared", User: "bob", IsShared: true},
+		{Owner: "org", Name: "private", User: "bob"},
+		{Owner: "other", Name: "other", User: "alice"},
+	} {
+		affected, err := AddRecordQuery(recordQuery)
+		assert.Nil(t, err)
+		assert.True(t, affected)
+	}
+
+	getNames := func(recordQueries []*RecordQuery) []string {
+		names := []string{}
+		for _, recordQuery := range recordQueries {
+			names = append(names, recordQuery.Name)
+		}
+		return names
+	}
+
+	recordQueries, err := GetRecordQueries("org", "alice")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"own", "shared"}, getNames(recordQueries))
+
+	recordQueries, err = GetRecordQueries("org", "")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"own", "shared", "private"}, getNames(recordQueries))
+
+	recordQueries, err = GetPaginationRecordQueries("org", "alice", 0, 10, "", "", "", "")
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{"own", "shared"}, getNames(recordQueries))
+
+	count, err := GetRecordQueryCount("org", "alice", "", "")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+)
+
+const redactedValue = "***"
+
+var (
+	defaultRedactedFields = []string{"password", "oldPassword", "newPassword", "code", "emailCode", "phoneCode", "passcode", "recoveryCode", "captchaToken", "clientSecret", "accessToken", "refreshToken", "totpSecret"}
+
+	reRecordEmail = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(\.[A-Za-z0-9\-]+)+`)
+
+	recordRedactionCache      = map[string]*RecordRedaction{}
+	recordRedactionCacheMutex sync.RWMutex
+)
+
+// RecordRedaction is applied to the records of the organization before they are persisted or sent out
+type RecordRedaction struct {
+	IsEnabled bool `json:"isEnabled"`
+	// RedactedFields are the fields of the request bodies and the queries replaced by "***", the default credential fields if empty
+	RedactedFields []string `json:"redactedFields"`
+	MaskEmails     bool     `json:"maskEmails"`
+	// DroppedActions are the actions like "login" whose records are not kept at all, "send-*" matches the actions by the prefix
+	DroppedActions []string `json:"droppedActions"`
+}
+
+func init() {
+	RegisterCacheInvalidationHandler(CacheTypeOrganization, func(key string) {
+		_, name := util.GetOwnerAndNameFromIdNoCheck(key)
+		clearRecordRedactionCache(name)
+	})
+}
+
+func checkRecordRedaction(redaction *RecordRedaction) error {
+	if redaction == nil {
+		return nil
+	}
+
+	for _, action := range redaction.DroppedActions {
+		if action == "" || strings.Contains(strings.TrimSuffix(action, "*"), "*") {
+			return fmt.Errorf("the dropped action: %s of the record redaction is invalid", action)
+		}
+	}
+	return nil
+}
+
+// clearRecordRedactionCache drops the cached redaction of the organization, or all of them if it is empty
+func clearRecordRedactionCache(organization string) {
+	recordRedactionCacheMutex.Lock()
+	defer recordRedactionCacheMutex.Unlock()
+
+	if organization == "" {
+		recordRedactionCache = map[string]*RecordRedaction{}
+	} else {
+		delete(recordRedactionCache, organization)
+	}
+}
+
+func getCachedRecordRedaction(organization string) (*RecordRedaction, error) {
+	recordRedactionCacheMutex.RLock()
+	redaction, ok := recordRedactionCache[organization]
+	recordRedactionCacheMutex.RUnlock()
+	if ok {
+		return redaction, nil
+	}
+
+	org, err := getOrganization("admin", organization)
+	if err != nil {
+		return nil, err
+	}
+
+	if org != nil && org.RecordRedaction != nil && org.RecordRedaction.IsEnabled {
+		redaction = org.RecordRedaction
+	}
+
+	recordRedactionCacheMutex.Lock()
+	recordRedactionCache[organization] = redaction
+	recordRedactionCacheMutex.Unlock()
+	return redaction, nil
+}
+
+func (redaction *RecordRedaction) isDropped(action string) bool {
+	for _, droppedAction := range redaction.DroppedActions {
+		if prefix := strings.TrimSuffix(droppedAction, "*"); prefix != droppedAction {
+			if strings.HasPrefix(action, prefix) {
+				return true
+			}
+		} else if action == droppedAction {
+			return true
+		}
+	}
+	return false
+}
+
+func (redaction *RecordRedaction) isRedactedField(field string) bool {
+	fields := redaction.RedactedFields
+	if len(fields) == 0 {
+		fields = defaultRedactedFields
+	}
+
+	for _, redactedField := range fields {
+		if strings.EqualFold(redactedField, field) {
+			return true
+		}
+	}
+	return false
+}
+
+func (redaction *RecordRedaction) redactString(s string) string {
+	if !redaction.MaskEmails {
+		return s
+	}
+	return reRecordEmail.ReplaceAllStringFunc(s, util.GetMaskedEmail)
+}
+
+func (redaction *RecordRedaction) redactJsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if redaction.isRedactedField(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redaction.redactJsonValue(child)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redaction.redactJsonValue(child)
+		}
+		return v
+	case string:
+		return redaction.redactString(v)
+	default:
+		return v
+	}
+}
+
+func (redaction *RecordRedaction) redactValues(values url.Values) {
+	for key, items := range values {
+		for i, item := range items {
+			if redaction.isRedactedField(key) {
+				items[i] = redactedValue
+			} else {
+				items[i] = redaction.redactString(item)
+			}
+		}
+	}
+}
+
+// redactObject redacts the request body of the record, which is JSON, a form or anything else
+func (redaction *RecordRedaction) redactObject(object string) string {
+	if object == "" {
+		return object
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(object))
+	decoder.UseNumber()
+	if decoder.Decode(&value) == nil && !decoder.More() {
+		data, err := json.Marshal(redaction.redactJsonValue(value))
+		if err == nil {
+			return string(data)
+		}
+	}
+
+	if values, err := url.ParseQuery(object); err == nil && strings.Contains(object, "=") {
+		redaction.redactValues(values)
+		return values.Encode()
+	}
+
+	return redaction.redactString(object)
+}
+
+func (redaction *RecordRedaction) redactRequestUri(requestUri string) string {
+	tokens := strings.SplitN(requestUri, "?", 2)
+	if len(tokens) != 2 {
+		return requestUri
+	}
+
+	values, err := url.ParseQuery(tokens[1])
+	if err != nil {
+		return tokens[0]
+	}
+
+	redaction.redactValues(values)
+	return tokens[0] + "?" + strings.ReplaceAll(values.Encode(), "%2F", "/")
+}
+
+// redactRecord applies the redaction to the record, it returns false if the record should be dropped
+func (redaction *RecordRedaction) redactRecord(record *casvisorsdk.Record) bool {
+	if redaction.isDropped(record.Action) {
+		return false
+	}
+
+	record.Object = redaction.redactObject(record.Object)
+	record.RequestUri = redaction.redactRequestUri(record.RequestUri)
+	record.User = redaction.redactString(record.User)
+	return true
+}
+
+// redactRecordByOrganization applies the redaction of the organization of the record
+func redactRecordByOrganization(record *casvisorsdk.Record) bool {
+	if record.Organization == "" {
+		return true
+	}
+
+	redaction, err := getCachedRecordRedaction(record.Organization)
+	if err != nil {
+		fmt.Printf("redactRecordByOrganization() error: %s\n", err.Error())
+		return true
+	}
+
+	if redaction == nil {
+		return true
+	}
+	return redaction.redactRecord(record)
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactRecord(t *testing.T) {
+	redaction := &RecordRedaction{IsEnabled: true, MaskEmails: true, DroppedActions: []string{"send-*", "get-account"}}
+
+	record := &casvisorsdk.Record{
+		Action:     "login",
+		RequestUri: "/api/login?code=123456&application=app1",
+		Object:     `{"username":"alice","password":"secret","emailCode":"654321","attributes":{"email":"alice@example.com"},"age":30}`,
+	}
+	assert.True(t, redaction.redactRecord(record))
+	assert.Equal(t, `{"age":30,"attributes":{"email":"a***e@e*****e.com"},"emailCode":"***","password":"***","username":"alice"}`, record.Object)
+	assert.Equal(t, "/api/login?application=app1&code=%2A%2A%2A", record.RequestUri)
+
+	// the form bodies are redacted by the fields too
+	record = &casvisorsdk.Record{Action: "set-password", Object: "userOwner=org1&newPassword=secret"}
+	assert.True(t, redaction.redactRecord(record))
+	assert.Equal(t, "newPassword=%2A%2A%2A&userOwner=org1", record.Object)
+
+	assert.False(t, redaction.redactRecord(&casvisorsdk.Record{Action: "send-verification-code"}))
+	assert.False(t, redaction.redactRecord(&casvisorsdk.Record{Action: "get-account"}))
+	assert.True(t, redaction.redactRecord(&casvisorsdk.Record{Action: "get-account-recovery-status"}))
+
+	// the configured fields replace the default ones
+	redaction = &RecordRedaction{IsEnabled: true, RedactedFields: []string{"Phone"}}
+	record = &casvisorsdk.Record{Object: `{"phone":"123","password":"secret","email":"alice@example.com"}`}
+	assert.True(t, redaction.redactRecord(record))
+	assert.Equal(t, `{"email":"alice@example.com","password":"secret","phone":"***"}`, record.Object)
+}
+
+func TestCheckRecordRedaction(t *testing.T) {
+	assert.Nil(t, checkRecordRedaction(nil))
+	assert.Nil(t, checkRecordRedaction(&RecordRedaction{DroppedActions: []string{"login", "send-*"}}))
+	assert.NotNil(t, checkRecordRedaction(&RecordRedaction{DroppedActions: []string{"*-code"}}))
+	assert.NotNil(t, checkRecordRedaction(&RecordRedaction{DroppedActions: []string{""}}))
+}
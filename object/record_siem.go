@@ -0,0 +1,395 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+)
+
+const (
+	SiemFormatCef  = "CEF"
+	SiemFormatLeef = "LEEF"
+
+	SiemProtocolTcp = "tcp"
+	SiemProtocolTls = "tls"
+
+	siemVendor               = "Casdoor"
+	siemProduct              = "Casdoor"
+	siemProductVersion       = "1.0"
+	siemDefaultBufferSize    = 10000
+	siemDialTimeout          = 10 * time.Second
+	siemWriteTimeout         = 10 * time.Second
+	siemMinRetryInterval     = time.Second
+	siemMaxRetryInterval     = time.Minute
+	siemSyslogPriorityPrefix = "<110>1" // facility: log audit (13), severity: informational (6)
+)
+
+// SiemExporter streams the records of the organization to an external SIEM (e.g. Splunk, QRadar)
+// over syslog in CEF or LEEF format. The records are buffered in memory while the SIEM is unreachable,
+// and the oldest ones are dropped when the buffer is full so that the login flow is never blocked.
+type SiemExporter struct {
+	IsEnabled     bool   `json:"isEnabled"`
+	Format        string `json:"format"`
+	Protocol      string `json:"protocol"`
+	Host          string `json:"host"`
+	Port          int    `json:"port"`
+	SkipTlsVerify bool   `json:"skipTlsVerify"`
+	// Actions are the record actions to be exported, all the records are exported if empty
+	Actions    []string `json:"actions"`
+	BufferSize int      `json:"bufferSize"`
+}
+
+type SiemExporterStatus struct {
+	IsEnabled     bool   `json:"isEnabled"`
+	IsConnected   bool   `json:"isConnected"`
+	Buffered      int    `json:"buffered"`
+	Sent          int64  `json:"sent"`
+	Dropped       int64  `json:"dropped"`
+	LastError     string `json:"lastError"`
+	LastErrorTime string `json:"lastErrorTime"`
+}
+
+type siemStream struct {
+	exporter SiemExporter
+	config   string
+	queue    chan string
+	stopCh   chan struct{}
+
+	mutex  sync.Mutex
+	status SiemExporterStatus
+}
+
+var (
+	siemStreams      = map[string]*siemStream{}
+	siemStreamsMutex sync.Mutex
+	siemHostname     = getSiemHostname()
+)
+
+func getSiemHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "-"
+	}
+	return hostname
+}
+
+func (exporter *SiemExporter) getBufferSize() int {
+	if exporter.BufferSize <= 0 {
+		return siemDefaultBufferSize
+	}
+	return exporter.BufferSize
+}
+
+func (exporter *SiemExporter) isActionExported(action string) bool {
+	return len(exporter.Actions) == 0 || util.InSlice(exporter.Actions, action)
+}
+
+func escapeCefHeader(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func escapeCefExtension(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "=", "\\=")
+	return strings.NewReplacer("\r\n", "\\n", "\r", "\\r", "\n", "\\n").Replace(s)
+}
+
+func escapeLeefValue(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ").Replace(s)
+}
+
+func getRecordTime(record *casvisorsdk.Record) time.Time {
+	t, err := time.Parse(time.RFC3339, record.CreatedTime)
+	if err != nil {
+		return time.Now()
+	}
+	return t
+}
+
+// getCefMessage formats the record as an ArcSight Common Event Format message
+func getCefMessage(record *casvisorsdk.Record) string {
+	extensions := [][2]string{
+		{"rt", strconv.FormatInt(getRecordTime(record).UnixNano()/int64(time.Millisecond), 10)},
+		{"externalId", record.Name},
+		{"src", record.ClientIp},
+		{"suser", record.User},
+		{"requestMethod", record.Method},
+		{"request", record.RequestUri},
+		{"act", record.Action},
+		{"cs1Label", "organization"},
+		{"cs1", record.Organization},
+	}
+
+	extensionStrings := []string{}
+	for _, extension := range extensions {
+		if extension[1] == "" {
+			continue
+		}
+		extensionStrings = append(extensionStrings, fmt.Sprintf("%s=%s", extension[0], escapeCefExtension(extension[1])))
+	}
+
+	return fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s", siemVendor, siemProduct, siemProductVersion,
+		escapeCefHeader(record.Action), escapeCefHeader(record.Action), 3, strings.Join(extensionStrings, " "))
+}
+
+// getLeefMessage formats the record as an IBM QRadar Log Event Extended Format 1.0 message
+func getLeefMessage(record *casvisorsdk.Record) string {
+	attributes := [][2]string{
+		{"devTime", getRecordTime(record).Format("Jan 02 2006 15:04:05")},
+		{"devTimeFormat", "MMM dd yyyy HH:mm:ss"},
+		{"cat", record.Action},
+		{"src", record.ClientIp},
+		{"usrName", record.User},
+		{"url", record.RequestUri},
+		{"method", record.Method},
+		{"organization", record.Organization},
+		{"recordId", record.Name},
+	}
+
+	attributeStrings := []string{}
+	for _, attribute := range attributes {
+		if attribute[1] == "" {
+			continue
+		}
+		attributeStrings = append(attributeStrings, fmt.Sprintf("%s=%s", attribute[0], escapeLeefValue(attribute[1])))
+	}
+
+	return fmt.Sprintf("LEEF:1.0|%s|%s|%s|%s|%s", siemVendor, siemProduct, siemProductVersion,
+		escapeCefHeader(record.Action), strings.Join(attributeStrings, "\t"))
+}
+
+// getSiemSyslogMessage wraps the CEF or LEEF message into a newline-framed RFC 5424 syslog message
+func getSiemSyslogMessage(format string, record *casvisorsdk.Record) string {
+	var msg string
+	if format == SiemFormatLeef {
+		msg = getLeefMessage(record)
+	} else {
+		msg = getCefMessage(record)
+	}
+
+	timestamp := getRecordTime(record).Format(time.RFC3339)
+	return fmt.Sprintf("%s %s %s casdoor - - - %s\n", siemSyslogPriorityPrefix, timestamp, siemHostname, msg)
+}
+
+func newSiemStream(exporter *SiemExporter, config string, queue chan string) *siemStream {
+	if queue == nil || cap(queue) != exporter.getBufferSize() {
+		queue = make(chan string, exporter.getBufferSize())
+	}
+
+	return &siemStream{
+		exporter: *exporter,
+		config:   config,
+		queue:    queue,
+		stopCh:   make(chan struct{}),
+		status:   SiemExporterStatus{IsEnabled: true},
+	}
+}
+
+// enqueue buffers the message without blocking, the oldest message is dropped when the buffer is full
+func (stream *siemStream) enqueue(msg string) {
+	for {
+		select {
+		case stream.queue <- msg:
+			return
+		default:
+		}
+
+		select {
+		case <-stream.queue:
+			stream.mutex.Lock()
+			stream.status.Dropped += 1
+			stream.mutex.Unlock()
+		default:
+		}
+	}
+}
+
+func (stream *siemStream) setError(err error) {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	stream.status.IsConnected = false
+	stream.status.LastError = err.Error()
+	stream.status.LastErrorTime = util.GetCurrentTime()
+}
+
+func (stream *siemStream) dial() (net.Conn, error) {
+	address := net.JoinHostPort(stream.exporter.Host, strconv.Itoa(stream.exporter.Port))
+	dialer := &net.Dialer{Timeout: siemDialTimeout}
+	if stream.exporter.Protocol == SiemProtocolTls {
+		return tls.DialWithDialer(dialer, "tcp", address, &tls.Config{
+			ServerName:         stream.exporter.Host,
+			InsecureSkipVerify: stream.exporter.SkipTlsVerify,
+		})
+	}
+	return dialer.Dial("tcp", address)
+}
+
+// sleep waits for the retry interval, it returns false if the stream is stopped meanwhile
+func (stream *siemStream) sleep(interval time.Duration) bool {
+	select {
+	case <-stream.stopCh:
+		return false
+	case <-time.After(interval):
+		return true
+	}
+}
+
+// run sends the buffered messages to the SIEM, the message being sent is kept and retried
+// with an exponential backoff until the SIEM is reachable again.
+func (stream *siemStream) run() {
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	retryInterval := siemMinRetryInterval
+	for {
+		// the queue may be taken over by a restarted stream, so the stop is checked first
+		select {
+		case <-stream.stopCh:
+			return
+		default:
+		}
+
+		var msg string
+		select {
+		case <-stream.stopCh:
+			return
+		case msg = <-stream.queue:
+		}
+
+		for {
+			var err error
+			if conn == nil {
+				conn, err = stream.dial()
+			}
+			if err == nil {
+				err = conn.SetWriteDeadline(time.Now().Add(siemWriteTimeout))
+			}
+			if err == nil {
+				_, err = conn.Write([]byte(msg))
+			}
+
+			if err == nil {
+				stream.mutex.Lock()
+				stream.status.IsConnected = true
+				stream.status.Sent += 1
+				stream.mutex.Unlock()
+
+				retryInterval = siemMinRetryInterval
+				break
+			}
+
+			stream.setError(err)
+			logs.Warning(fmt.Sprintf("siemStream.run() error: %s", err.Error()))
+			if conn != nil {
+				conn.Close()
+				conn = nil
+			}
+
+			if !stream.sleep(retryInterval) {
+				return
+			}
+			retryInterval *= 2
+			if retryInterval > siemMaxRetryInterval {
+				retryInterval = siemMaxRetryInterval
+			}
+		}
+	}
+}
+
+func (stream *siemStream) getStatus() *SiemExporterStatus {
+	stream.mutex.Lock()
+	defer stream.mutex.Unlock()
+
+	status := stream.status
+	status.Buffered = len(stream.queue)
+	return &status
+}
+
+// getSiemStream returns the running stream of the organization, the stream is restarted when
+// the exporter is changed, and the records still buffered are kept if the buffer size is not changed.
+func getSiemStream(organization string, exporter *SiemExporter) *siemStream {
+	siemStreamsMutex.Lock()
+	defer siemStreamsMutex.Unlock()
+
+	stream := siemStreams[organization]
+	if exporter == nil || !exporter.IsEnabled || exporter.Host == "" {
+		if stream != nil {
+			close(stream.stopCh)
+			delete(siemStreams, organization)
+		}
+		return nil
+	}
+
+	config := util.StructToJson(exporter)
+	if stream != nil && stream.config == config {
+		return stream
+	}
+
+	var queue chan string
+	if stream != nil {
+		close(stream.stopCh)
+		queue = stream.queue
+	}
+
+	stream = newSiemStream(exporter, config, queue)
+	siemStreams[organization] = stream
+	go stream.run()
+	return stream
+}
+
+// exportRecordToSiem queues the record to the SIEM exporter of its organization if there is one
+func exportRecordToSiem(record *casvisorsdk.Record) error {
+	organization, err := getOrganization("admin", record.Organization)
+	if err != nil {
+		return err
+	}
+	if organization == nil {
+		return nil
+	}
+
+	stream := getSiemStream(organization.Name, organization.SiemExporter)
+	if stream == nil || !stream.exporter.isActionExported(record.Action) {
+		return nil
+	}
+
+	stream.enqueue(getSiemSyslogMessage(stream.exporter.Format, record))
+	return nil
+}
+
+func GetSiemExporterStatus(organization *Organization) *SiemExporterStatus {
+	stream := getSiemStream(organization.Name, organization.SiemExporter)
+	if stream == nil {
+		return &SiemExporterStatus{IsEnabled: false}
+	}
+	return stream.getStatus()
+}
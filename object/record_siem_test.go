@@ -0,0 +1,57 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSiemMessage(t *testing.T) {
+	record := &casvisorsdk.Record{
+		Name:         "record1",
+		CreatedTime:  "2023-08-01T10:00:00Z",
+		Organization: "built-in",
+		ClientIp:     "127.0.0.1",
+		User:         "ad=min",
+		Method:       "POST",
+		RequestUri:   "/api/login?a=b",
+		Action:       "login|sso",
+	}
+
+	assert.Equal(t, `CEF:0|Casdoor|Casdoor|1.0|login\|sso|login\|sso|3|rt=1690884000000 externalId=record1 src=127.0.0.1 suser=ad\=min requestMethod=POST request=/api/login?a\=b act=login|sso cs1Label=organization cs1=built-in`, getCefMessage(record))
+	assert.Equal(t, "LEEF:1.0|Casdoor|Casdoor|1.0|login\\|sso|devTime=Aug 01 2023 10:00:00\tdevTimeFormat=MMM dd yyyy HH:mm:ss\tcat=login|sso\tsrc=127.0.0.1\tusrName=ad=min\turl=/api/login?a=b\tmethod=POST\torganization=built-in\trecordId=record1", getLeefMessage(record))
+
+	msg := getSiemSyslogMessage(SiemFormatLeef, record)
+	assert.True(t, strings.HasPrefix(msg, "<110>1 2023-08-01T10:00:00Z "))
+	assert.True(t, strings.HasSuffix(msg, "recordId=record1\n"))
+	assert.Equal(t, 1, strings.Count(msg, "\n"))
+}
+
+func TestSiemStreamEnqueue(t *testing.T) {
+	stream := newSiemStream(&SiemExporter{BufferSize: 2}, "", nil)
+	stream.enqueue("a")
+	stream.enqueue("b")
+	stream.enqueue("c")
+
+	status := stream.getStatus()
+	assert.Equal(t, 2, status.Buffered)
+	assert.Equal(t, int64(1), status.Dropped)
+	assert.Equal(t, "b", <-stream.queue)
+	assert.Equal(t, "c", <-stream.queue)
+}
@@ -0,0 +1,165 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+)
+
+const (
+	RecordQueuePolicyDrop  = "drop"
+	RecordQueuePolicyBlock = "block"
+
+	recordFlushInterval = time.Second
+)
+
+// recordWriter writes the records in batches on its own goroutine, so the requests only pay for putting
+// the records into a bounded queue. When the queue is full, the records are dropped or the requests are
+// blocked until there is room, according to the policy.
+type recordWriter struct {
+	queue     chan *casvisorsdk.Record
+	flushes   chan chan struct{}
+	policy    string
+	batchSize int
+	interval  time.Duration
+	write     func(records []*casvisorsdk.Record)
+	dropped   int64
+}
+
+var globalRecordWriter *recordWriter
+
+func newRecordWriter(queueSize int, batchSize int, interval time.Duration, policy string, write func(records []*casvisorsdk.Record)) *recordWriter {
+	return &recordWriter{
+		queue:     make(chan *casvisorsdk.Record, queueSize),
+		flushes:   make(chan chan struct{}),
+		policy:    policy,
+		batchSize: batchSize,
+		interval:  interval,
+		write:     write,
+	}
+}
+
+func getRecordWriterConfigInt(key string, defaultValue int) int {
+	res, err := strconv.Atoi(conf.GetConfigString(key))
+	if err != nil || res <= 0 {
+		return defaultValue
+	}
+	return res
+}
+
+// InitRecordWriter creates the record writer with the "recordQueueSize", "recordBatchSize" and "recordQueuePolicy"
+// configs, the records are written synchronously before it is created
+func InitRecordWriter() {
+	policy := conf.GetConfigString("recordQueuePolicy")
+	if policy != RecordQueuePolicyBlock {
+		policy = RecordQueuePolicyDrop
+	}
+
+	globalRecordWriter = newRecordWriter(getRecordWriterConfigInt("recordQueueSize", 10000), getRecordWriterConfigInt("recordBatchSize", 100), recordFlushInterval, policy, writeRecords)
+}
+
+// RunRecordWriterJob writes the queued records until the process exits
+func RunRecordWriterJob() {
+	if globalRecordWriter == nil {
+		return
+	}
+
+	globalRecordWriter.run()
+}
+
+func (w *recordWriter) enqueue(record *casvisorsdk.Record) bool {
+	if w.policy == RecordQueuePolicyBlock {
+		w.queue <- record
+		return true
+	}
+
+	select {
+	case w.queue <- record:
+		return true
+	default:
+		atomic.AddInt64(&w.dropped, 1)
+		return false
+	}
+}
+
+func (w *recordWriter) writeBatch(batch []*casvisorsdk.Record) []*casvisorsdk.Record {
+	if dropped := atomic.SwapInt64(&w.dropped, 0); dropped > 0 {
+		logs.Warning(fmt.Sprintf("the record queue is full, %d records have been dropped", dropped))
+	}
+
+	if len(batch) > 0 {
+		w.write(batch)
+	}
+	return batch[:0]
+}
+
+func (w *recordWriter) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	batch := make([]*casvisorsdk.Record, 0, w.batchSize)
+	for {
+		select {
+		case record := <-w.queue:
+			batch = append(batch, record)
+			if len(batch) >= w.batchSize {
+				batch = w.writeBatch(batch)
+			}
+		case <-ticker.C:
+			batch = w.writeBatch(batch)
+		case done := <-w.flushes:
+			// the records queued before the flush are written as well
+			for len(w.queue) > 0 {
+				batch = append(batch, <-w.queue)
+				if len(batch) >= w.batchSize {
+					batch = w.writeBatch(batch)
+				}
+			}
+			batch = w.writeBatch(batch)
+			close(done)
+		}
+	}
+}
+
+// flush waits until the records queued so far are written
+func (w *recordWriter) flush() {
+	done := make(chan struct{})
+	w.flushes <- done
+	<-done
+}
+
+// writeRecords triggers the webhooks and the SIEM export of the records, then saves them to Casvisor.
+// Casvisor has no bulk API, so the records of a batch are posted one by one on the writer goroutine.
+func writeRecords(records []*casvisorsdk.Record) {
+	for _, record := range records {
+		func() {
+			// a panic of a record shouldn't stop the writer, otherwise the queue would never be drained
+			defer func() {
+				if r := recover(); r != nil {
+					logs.Error(fmt.Sprintf("writeRecord() panic: %v", r))
+				}
+			}()
+
+			writeRecord(record)
+		}()
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordWriterBatches(t *testing.T) {
+	batches := make(chan []string, 10)
+	writer := newRecordWriter(10, 3, time.Hour, RecordQueuePolicyBlock, func(records []*casvisorsdk.Record) {
+		names := []string{}
+		for _, record := range records {
+			names = append(names, record.Name)
+		}
+		batches <- names
+	})
+	go writer.run()
+
+	for _, name := range []string{"1", "2", "3", "4"} {
+		assert.True(t, writer.enqueue(&casvisorsdk.Record{Name: name}))
+	}
+	assert.Equal(t, []string{"1", "2", "3"}, <-batches)
+
+	writer.flush()
+	assert.Equal(t, []string{"4"}, <-batches)
+}
+
+func TestRecordWriterDropsWhenFull(t *testing.T) {
+	writer := newRecordWriter(2, 10, time.Hour, RecordQueuePolicyDrop, func(records []*casvisorsdk.Record) {})
+
+	assert.True(t, writer.enqueue(&casvisorsdk.Record{Name: "1"}))
+	assert.True(t, writer.enqueue(&casvisorsdk.Record{Name: "2"}))
+	assert.False(t, writer.enqueue(&casvisorsdk.Record{Name: "3"}))
+	assert.Equal(t, int64(1), writer.dropped)
+}
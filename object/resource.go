@@ -38,6 +38,10 @@ type Resource struct {
 	FileSize    int    `json:"fileSize"`
 	Url         string `xorm:"varchar(255)" json:"url"`
 	Description string `xorm:"varchar(255)" json:"description"`
+
+	Tags []string `xorm:"varchar(1000)" json:"tags"`
+	// ExpireTime is set by the lifecycle rule of the application, the resource is deleted after it
+	ExpireTime string `xorm:"varchar(100) index" json:"expireTime"`
 }
 
 func GetResourceCount(owner, user, field, value string) (int64, error) {
@@ -0,0 +1,227 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const resourceLifecycleBatchSize = 100
+
+// ResourcePolicy limits the uploads of the application, so one application can't fill the shared bucket
+type ResourcePolicy struct {
+	// StorageQuota is the total size of the resources of the application in MB, 0 for no limit
+	StorageQuota int `json:"storageQuota"`
+	// AllowedContentTypes are like "image/png" or "image/*", empty for any content type
+	AllowedContentTypes []string                 `json:"allowedContentTypes"`
+	LifecycleRules      []*ResourceLifecycleRule `json:"lifecycleRules"`
+}
+
+// ResourceLifecycleRule expires the uploads with the tag after the days, like the "temp" uploads after 7 days
+type ResourceLifecycleRule struct {
+	Tag          string `json:"tag"`
+	ExpireInDays int    `json:"expireInDays"`
+}
+
+type ResourceUsage struct {
+	Application  string           `json:"application"`
+	Count        int              `json:"count"`
+	UsedSize     int64            `json:"usedSize"`
+	StorageQuota int64            `json:"storageQuota"`
+	TagSizes     map[string]int64 `json:"tagSizes"`
+}
+
+func checkResourcePolicy(policy *ResourcePolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	if policy.StorageQuota < 0 {
+		return fmt.Errorf("the storage quota of the resource policy should not be negative")
+	}
+
+	for _, contentType := range policy.AllowedContentTypes {
+		if !strings.Contains(contentType, "/") {
+			return fmt.Errorf("the content type: %s of the resource policy should be like \"image/png\" or \"image/*\"", contentType)
+		}
+	}
+
+	for _, rule := range policy.LifecycleRules {
+		if rule.Tag == "" || rule.ExpireInDays <= 0 {
+			return fmt.Errorf("the lifecycle rule of the resource policy should have a tag and positive expire days")
+		}
+	}
+	return nil
+}
+
+func (policy *ResourcePolicy) isContentTypeAllowed(contentType string) bool {
+	if policy == nil || len(policy.AllowedContentTypes) == 0 {
+		return true
+	}
+
+	contentType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	for _, allowedType := range policy.AllowedContentTypes {
+		allowedType = strings.ToLower(allowedType)
+		if allowedType == contentType || (strings.HasSuffix(allowedType, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(allowedType, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// getExpireTime returns the expire time of the upload with the tags by the first matched lifecycle rule, empty if
+// the upload doesn't expire, the time is in UTC so the expired resources are queried by comparing the strings
+func (policy *ResourcePolicy) getExpireTime(tags []string, now time.Time) string {
+	if policy == nil {
+		return ""
+	}
+
+	for _, rule := range policy.LifecycleRules {
+		if util.InSlice(tags, rule.Tag) {
+			return now.Add(time.Duration(rule.ExpireInDays) * 24 * time.Hour).UTC().Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+func getResourceApplication(application string) (*Application, error) {
+	if application == "" {
+		return nil, nil
+	}
+	return getApplication("admin", application)
+}
+
+func getResourceUsedSize(application string) (int64, error) {
+	return ormer.Engine.Where("application = ?", application).SumInt(&Resource{}, "file_size")
+}
+
+// CheckResourceUpload checks the upload to the application against its resource policy, and returns the expire time
+// of the upload with the tags
+func CheckResourceUpload(application string, contentType string, fileSize int64, tags []string, lang string) (string, error) {
+	app, err := getResourceApplication(application)
+	if err != nil {
+		return "", err
+	}
+	if app == nil || app.ResourcePolicy == nil {
+		return "", nil
+	}
+
+	policy := app.ResourcePolicy
+	if !policy.isContentTypeAllowed(contentType) {
+		return "", fmt.Errorf(i18n.Translate(lang, "resource:The content type: %s is not allowed by the application: %s"), contentType, application)
+	}
+
+	if policy.StorageQuota > 0 {
+		usedSize, err := getResourceUsedSize(application)
+		if err != nil {
+			return "", err
+		}
+
+		if usedSize+fileSize > int64(policy.StorageQuota)*1024*1024 {
+			return "", fmt.Errorf(i18n.Translate(lang, "resource:The storage quota: %d MB of the application: %s is exceeded"), policy.StorageQuota, application)
+		}
+	}
+
+	return policy.getExpireTime(tags, time.Now()), nil
+}
+
+// GetResourceUsage returns the storage used by the resources of the application in bytes, in total and by the tags
+func GetResourceUsage(application *Application) (*ResourceUsage, error) {
+	resources := []*Resource{}
+	err := ormer.Engine.Cols("tag", "tags", "file_size").Where("application = ?", application.Name).Find(&resources)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ResourceUsage{Application: application.GetId(), Count: len(resources), TagSizes: map[string]int64{}}
+	if application.ResourcePolicy != nil {
+		res.StorageQuota = int64(application.ResourcePolicy.StorageQuota) * 1024 * 1024
+	}
+
+	for _, resource := range resources {
+		res.UsedSize += int64(resource.FileSize)
+		for _, tag := range resource.getTags() {
+			res.TagSizes[tag] += int64(resource.FileSize)
+		}
+	}
+	return res, nil
+}
+
+// getTags returns the tag of the resource with its tags
+func (resource *Resource) getTags() []string {
+	res := []string{}
+	if resource.Tag != "" {
+		res = append(res, resource.Tag)
+	}
+	for _, tag := range resource.Tags {
+		if tag != "" && !util.InSlice(res, tag) {
+			res = append(res, tag)
+		}
+	}
+	return res
+}
+
+func deleteExpiredResource(resource *Resource) error {
+	provider, err := GetProvider(util.GetId("admin", resource.Provider))
+	if err != nil {
+		return err
+	}
+
+	if provider != nil {
+		err = DeleteFile(provider, resource.Name, "en")
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = ormer.Engine.ID(core.PK{resource.Owner, resource.Name}).Delete(&Resource{})
+	return err
+}
+
+func deleteExpiredResources(now time.Time) error {
+	resources := []*Resource{}
+	err := ormer.Engine.Where("expire_time != ? and expire_time <= ?", "", now.UTC().Format(time.RFC3339)).
+		Limit(resourceLifecycleBatchSize).Find(&resources)
+	if err != nil {
+		return err
+	}
+
+	for _, resource := range resources {
+		err = deleteExpiredResource(resource)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to delete the expired resource: %s, error: %s", resource.GetId(), err.Error()))
+		}
+	}
+	return nil
+}
+
+// RunResourceLifecycleJob deletes the expired uploads from the storage every hour
+func RunResourceLifecycleJob() {
+	for {
+		err := deleteExpiredResources(time.Now())
+		if err != nil {
+			logs.Warning(fmt.Sprintf("resource lifecycle failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Hour)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckResourcePolicy(t *testing.T) {
+	assert.Nil(t, checkResourcePolicy(nil))
+	assert.Nil(t, checkResourcePolicy(&ResourcePolicy{
+		StorageQuota:        100,
+		AllowedContentTypes: []string{"image/*", "application/pdf"},
+		LifecycleRules:      []*ResourceLifecycleRule{{Tag: "temp", ExpireInDays: 7}},
+	}))
+
+	assert.NotNil(t, checkResourcePolicy(&ResourcePolicy{StorageQuota: -1}))
+	assert.NotNil(t, checkResourcePolicy(&ResourcePolicy{AllowedContentTypes: []string{"pdf"}}))
+	assert.NotNil(t, checkResourcePolicy(&ResourcePolicy{LifecycleRules: []*ResourceLifecycleRule{{Tag: "temp"}}}))
+	assert.NotNil(t, checkResourcePolicy(&ResourcePolicy{LifecycleRules: []*ResourceLifecycleRule{{ExpireInDays: 7}}}))
+}
+
+func TestIsContentTypeAllowed(t *testing.T) {
+	var policy *ResourcePolicy
+	assert.True(t, policy.isContentTypeAllowed("application/zip"))
+
+	policy = &ResourcePolicy{AllowedContentTypes: []string{"image/*", "application/pdf"}}
+	assert.True(t, policy.isContentTypeAllowed("image/png"))
+	assert.True(t, policy.isContentTypeAllowed("Application/PDF; charset=binary"))
+	assert.False(t, policy.isContentTypeAllowed("application/zip"))
+	assert.False(t, policy.isContentTypeAllowed("imagex/png"))
+	assert.False(t, policy.isContentTypeAllowed(""))
+}
+
+func TestGetResourceExpireTime(t *testing.T) {
+	now := time.Date(2023, 6, 1, 8, 0, 0, 0, time.FixedZone("UTC+8", 8*3600))
+	policy := &ResourcePolicy{LifecycleRules: []*ResourceLifecycleRule{{Tag: "temp", ExpireInDays: 7}, {Tag: "draft", ExpireInDays: 30}}}
+
+	assert.Equal(t, "2023-06-08T00:00:00Z", policy.getExpireTime([]string{"", "temp"}, now))
+	assert.Equal(t, "2023-07-01T00:00:00Z", policy.getExpireTime([]string{"draft"}, now))
+	assert.Equal(t, "", policy.getExpireTime([]string{"avatar"}, now))
+
+	policy = nil
+	assert.Equal(t, "", policy.getExpireTime([]string{"temp"}, now))
+}
+
+func TestGetResourceTags(t *testing.T) {
+	resource := &Resource{Tag: "custom", Tags: []string{"temp", "custom", ""}}
+	assert.Equal(t, []string{"custom", "temp"}, resource.getTags())
+
+	resource = &Resource{}
+	assert.Equal(t, []string{}, resource.getTags())
+}
@@ -0,0 +1,75 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/scan"
+)
+
+const quarantineBaseFolder = "quarantine"
+
+// ScanUploadedFile scans the file with the scan provider and returns an error if the file is infected.
+// If the provider method is "Quarantine", the infected file is kept in a local folder that is not publicly served.
+func ScanUploadedFile(provider *Provider, owner string, fileName string, content []byte, lang string) error {
+	if provider == nil {
+		return nil
+	}
+
+	// Endpoint is the ICAP service name, e.g. "avscan"
+	result, err := scan.ScanFileByProviderType(provider.Type, provider.Host, provider.Port, provider.Endpoint, fileName, content)
+	if err != nil {
+		return fmt.Errorf(i18n.Translate(lang, "resource:Failed to scan the file: %s"), err.Error())
+	}
+
+	if !result.IsInfected {
+		return nil
+	}
+
+	if provider.Method == "Quarantine" {
+		quarantinePath, err := quarantineFile(owner, fileName, content)
+		if err != nil {
+			return err
+		}
+
+		logs.Warning(fmt.Sprintf("ScanUploadedFile() quarantined infected file: %s to: %s, signature: %s", fileName, quarantinePath, result.Signature))
+		return fmt.Errorf(i18n.Translate(lang, "resource:The file: %s is infected (%s) and has been quarantined"), fileName, result.Signature)
+	}
+
+	logs.Warning(fmt.Sprintf("ScanUploadedFile() rejected infected file: %s, signature: %s", fileName, result.Signature))
+	return fmt.Errorf(i18n.Translate(lang, "resource:The file: %s is infected (%s) and has been rejected"), fileName, result.Signature)
+}
+
+func quarantineFile(owner string, fileName string, content []byte) (string, error) {
+	folder := filepath.Join(quarantineBaseFolder, filepath.Base(owner))
+	err := os.MkdirAll(folder, 0o700)
+	if err != nil {
+		return "", err
+	}
+
+	quarantinePath := filepath.Join(folder, fmt.Sprintf("%d-%s", time.Now().UnixNano(), filepath.Base(fileName)))
+	err = os.WriteFile(quarantinePath, content, 0o600)
+	if err != nil {
+		return "", err
+	}
+
+	return quarantinePath, nil
+}
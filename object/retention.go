@@ -0,0 +1,320 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	RetentionTypeRecord       = "record"
+	RetentionTypeVerification = "verification"
+	RetentionTypeToken        = "token"
+	RetentionTypeDeletedUser  = "deleted-user"
+
+	// the rows are deleted in small batches with pauses between them, so the purge never locks the tables for long
+	retentionBatchSize     = 500
+	retentionBatchInterval = 100 * time.Millisecond
+	retentionMaxBatches    = 100
+	retentionPurgeInterval = time.Hour
+)
+
+var (
+	RetentionPurgedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "casdoor_retention_purged_total",
+		Help: "The number of the rows purged by the retention policies",
+	}, []string{"organization", "type"})
+
+	RetentionLastPurgeTime = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "casdoor_retention_last_purge_timestamp",
+		Help: "The unix time when the retention policy was last enforced",
+	}, []string{"organization", "type"})
+)
+
+// RetentionPolicy purges the data of the organization older than the given days, the data is kept forever for 0 days.
+// The tokens are counted from when they and their refresh tokens expire, the deleted users from their last update.
+type RetentionPolicy struct {
+	IsEnabled        bool `json:"isEnabled"`
+	RecordDays       int  `json:"recordDays"`
+	VerificationDays int  `json:"verificationDays"`
+	ExpiredTokenDays int  `json:"expiredTokenDays"`
+	DeletedUserDays  int  `json:"deletedUserDays"`
+}
+
+func checkRetentionPolicy(policy *RetentionPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	if policy.RecordDays < 0 || policy.VerificationDays < 0 || policy.ExpiredTokenDays < 0 || policy.DeletedUserDays < 0 {
+		return fmt.Errorf("the retention days should not be negative")
+	}
+	return nil
+}
+
+func getRetentionCutoff(days int, now time.Time) time.Time {
+	return now.Add(-time.Duration(days) * 24 * time.Hour)
+}
+
+// purgeInBatches calls the purge with the batch size until it examines fewer rows than that, or the max batches are run.
+// The purge returns the rows it examined and purged, the examined rows not purged are skipped by the next batches.
+func purgeInBatches(organization string, retentionType string, purge func(limit int, offset int) (int, int, error)) (int, error) {
+	total := 0
+	offset := 0
+	for i := 0; i < retentionMaxBatches; i++ {
+		examined, purged, err := purge(retentionBatchSize, offset)
+		total += purged
+		RetentionPurgedTotal.WithLabelValues(organization, retentionType).Add(float64(purged))
+		if err != nil {
+			return total, err
+		}
+		if examined < retentionBatchSize {
+			break
+		}
+
+		offset += examined - purged
+
+		time.Sleep(retentionBatchInterval)
+	}
+
+	RetentionLastPurgeTime.WithLabelValues(organization, retentionType).SetToCurrentTime()
+	return total, nil
+}
+
+// isVerificationOfOrganization checks whether the code was sent by the provider of the organization or to its user
+func isVerificationOfOrganization(record *VerificationRecord, organization string) bool {
+	return record.Owner == organization || strings.HasPrefix(record.User, organization+"/")
+}
+
+func purgeVerifications(organization string, cutoff time.Time, limit int, offset int) (int, int, error) {
+	records := []*VerificationRecord{}
+	err := ormer.Engine.Cols("owner", "name", "user").
+		Where("(owner = ? or `user` like ?) and time < ?", organization, organization+"/%", cutoff.Unix()).
+		Asc("time").Limit(limit, offset).Find(&records)
+	if err != nil || len(records) == 0 {
+		return 0, 0, err
+	}
+
+	names := []string{}
+	for _, record := range records {
+		if isVerificationOfOrganization(record, organization) {
+			names = append(names, record.Name)
+		}
+	}
+
+	if len(names) != 0 {
+		_, err = ormer.Engine.In("name", names).Delete(&VerificationRecord{})
+		if err != nil {
+			return len(records), 0, err
+		}
+	}
+	return len(records), len(names), nil
+}
+
+// getTokenExpireTime returns when the token can no longer be used, the refresh token may outlive the access token
+func getTokenExpireTime(token *Token, application *Application) time.Time {
+	createdTime, err := time.Parse(time.RFC3339, token.CreatedTime)
+	if err != nil {
+		return time.Time{}
+	}
+
+	expireTime := createdTime.Add(time.Duration(token.ExpiresIn) * time.Second)
+	if token.RefreshToken != "" && application != nil {
+		refreshExpireTime := createdTime.Add(time.Duration(application.RefreshExpireInHours) * time.Hour)
+		if refreshExpireTime.After(expireTime) {
+			expireTime = refreshExpireTime
+		}
+	}
+	return expireTime
+}
+
+func purgeExpiredTokens(organization string, cutoff time.Time, limit int, offset int) (int, int, error) {
+	tokens := []*Token{}
+	err := ormer.Engine.Cols("owner", "name", "created_time", "application", "expires_in", "refresh_token").
+		Where("organization = ? and created_time < ?", organization, cutoff.Format(time.RFC3339)).
+		Asc("created_time").Limit(limit, offset).Find(&tokens)
+	if err != nil || len(tokens) == 0 {
+		return 0, 0, err
+	}
+
+	applications := map[string]*Application{}
+	names := []string{}
+	for _, token := range tokens {
+		applicationId := util.GetId(token.Owner, token.Application)
+		application, ok := applications[applicationId]
+		if !ok {
+			application, err = getApplication(token.Owner, token.Application)
+			if err != nil {
+				return len(tokens), 0, err
+			}
+			applications[applicationId] = application
+		}
+
+		if getTokenExpireTime(token, application).Before(cutoff) {
+			names = append(names, token.Name)
+		}
+	}
+
+	if len(names) != 0 {
+		_, err = ormer.Engine.Where("organization = ?", organization).In("name", names).Delete(&Token{})
+		if err != nil {
+			return len(tokens), 0, err
+		}
+	}
+	return len(tokens), len(names), nil
+}
+
+func purgeDeletedUsers(organization string, cutoff time.Time, limit int, offset int) (int, int, error) {
+	users := []*User{}
+	err := ormer.Engine.Where("owner = ? and is_deleted = ? and updated_time < ?", organization, true, cutoff.Format(time.RFC3339)).
+		Asc("updated_time").Limit(limit, offset).Find(&users)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for i, user := range users {
+		_, err = DeleteUser(user)
+		if err != nil {
+			return len(users), i, err
+		}
+	}
+	return len(users), len(users), nil
+}
+
+// purgeRecords deletes the records of the organizations in Casvisor, which are fetched once for all of them
+func purgeRecords(policies map[string]*RetentionPolicy, now time.Time) error {
+	if casvisorsdk.GetClient() == nil {
+		return nil
+	}
+
+	records, err := casvisorsdk.GetRecords()
+	if err != nil {
+		return err
+	}
+
+	expiredRecords := map[string][]*casvisorsdk.Record{}
+	for _, record := range records {
+		policy, ok := policies[record.Organization]
+		if !ok || policy.RecordDays == 0 {
+			continue
+		}
+
+		createdTime, err := time.Parse(time.RFC3339, record.CreatedTime)
+		if err == nil && createdTime.Before(getRetentionCutoff(policy.RecordDays, now)) {
+			expiredRecords[record.Organization] = append(expiredRecords[record.Organization], record)
+		}
+	}
+
+	for organization, records := range expiredRecords {
+		_, err = purgeInBatches(organization, RetentionTypeRecord, func(limit int, offset int) (int, int, error) {
+			if len(records) < limit {
+				limit = len(records)
+			}
+
+			for i, record := range records[:limit] {
+				_, err := casvisorsdk.DeleteRecord(record)
+				if err != nil {
+					return limit, i, err
+				}
+			}
+			records = records[limit:]
+			return limit, limit, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func purgeOrganization(organization string, policy *RetentionPolicy, now time.Time) error {
+	purges := []struct {
+		retentionType string
+		days          int
+		purge         func(string, time.Time, int, int) (int, int, error)
+	}{
+		{RetentionTypeVerification, policy.VerificationDays, purgeVerifications},
+		{RetentionTypeToken, policy.ExpiredTokenDays, purgeExpiredTokens},
+		{RetentionTypeDeletedUser, policy.DeletedUserDays, purgeDeletedUsers},
+	}
+
+	for _, p := range purges {
+		if p.days == 0 {
+			continue
+		}
+
+		cutoff := getRetentionCutoff(p.days, now)
+		purge := p.purge
+		count, err := purgeInBatches(organization, p.retentionType, func(limit int, offset int) (int, int, error) {
+			return purge(organization, cutoff, limit, offset)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to purge the %ss of the organization: %s, error: %s", p.retentionType, organization, err.Error())
+		}
+		if count != 0 {
+			logs.Info(fmt.Sprintf("purged %d %ss of the organization: %s", count, p.retentionType, organization))
+		}
+	}
+
+	return nil
+}
+
+func purgeRetainedData(now time.Time) error {
+	organizations := []*Organization{}
+	err := ormer.Engine.Find(&organizations)
+	if err != nil {
+		return err
+	}
+
+	policies := map[string]*RetentionPolicy{}
+	for _, organization := range organizations {
+		policy := organization.RetentionPolicy
+		if policy == nil || !policy.IsEnabled {
+			continue
+		}
+
+		policies[organization.Name] = policy
+		err = purgeOrganization(organization.Name, policy, now)
+		if err != nil {
+			logs.Warning(err.Error())
+		}
+	}
+
+	if len(policies) == 0 {
+		return nil
+	}
+	return purgeRecords(policies, now)
+}
+
+// RunRetentionJob enforces the retention policies of the organizations every hour
+func RunRetentionJob() {
+	for {
+		err := purgeRetainedData(time.Now())
+		if err != nil {
+			logs.Warning(fmt.Sprintf("retention purge failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(retentionPurgeInterval)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRetentionPolicy(t *testing.T) {
+	assert.Nil(t, checkRetentionPolicy(nil))
+	assert.Nil(t, checkRetentionPolicy(&RetentionPolicy{IsEnabled: true, RecordDays: 90}))
+	assert.NotNil(t, checkRetentionPolicy(&RetentionPolicy{IsEnabled: true, ExpiredTokenDays: -1}))
+}
+
+func TestGetTokenExpireTime(t *testing.T) {
+	createdTime := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	token := &Token{CreatedTime: createdTime.Format(time.RFC3339), ExpiresIn: 3600}
+
+	assert.Equal(t, createdTime.Add(time.Hour), getTokenExpireTime(token, &Application{RefreshExpireInHours: 24}))
+
+	// the refresh token outlives the access token
+	token.RefreshToken = "refresh-token"
+	assert.Equal(t, createdTime.Add(24*time.Hour), getTokenExpireTime(token, &Application{RefreshExpireInHours: 24}))
+	assert.Equal(t, createdTime.Add(time.Hour), getTokenExpireTime(token, nil))
+}
+
+func TestIsVerificationOfOrganization(t *testing.T) {
+	assert.True(t, isVerificationOfOrganization(&VerificationRecord{Owner: "org_1"}, "org_1"))
+	assert.True(t, isVerificationOfOrganization(&VerificationRecord{Owner: "admin", User: "org_1/alice"}, "org_1"))
+	assert.False(t, isVerificationOfOrganization(&VerificationRecord{Owner: "admin", User: "orgX1/alice"}, "org_1"))
+	assert.False(t, isVerificationOfOrganization(&VerificationRecord{Owner: "admin"}, "org_1"))
+}
+
+func TestPurgeInBatches(t *testing.T) {
+	// the rows are purged except every tenth one, which is still in use
+	rows := []int{}
+	for i := 0; i < retentionBatchSize*2+10; i++ {
+		rows = append(rows, i)
+	}
+
+	offsets := []int{}
+	total, err := purgeInBatches("org-1", RetentionTypeToken, func(limit int, offset int) (int, int, error) {
+		offsets = append(offsets, offset)
+
+		end := offset + limit
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		kept := rows[:offset]
+		purged := 0
+		for _, row := range rows[offset:end] {
+			if row%10 == 0 {
+				kept = append(kept, row)
+			} else {
+				purged++
+			}
+		}
+		examined := end - offset
+		rows = append(kept, rows[end:]...)
+		return examined, purged, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 909, total)
+	assert.Equal(t, []int{0, 50, 100}, offsets)
+	assert.Len(t, rows, 101)
+
+	total, err = purgeInBatches("org-1", RetentionTypeToken, func(limit int, offset int) (int, int, error) {
+		return limit, 3, fmt.Errorf("database is locked")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 3, total)
+}
+
+func TestPurgeVerifications(t *testing.T) {
+	setTestOrmer(t, &VerificationRecord{})
+
+	now := time.Now()
+	for i, record := range []*VerificationRecord{
+		{Owner: "org", User: "", Time: now.Add(-48 * time.Hour).Unix()},
+		{Owner: "admin", User: "org/alice", Time: now.Add(-48 * time.Hour).Unix()},
+		{Owner: "admin", User: "org2/bob", Time: now.Add(-48 * time.Hour).Unix()},
+		{Owner: "org", User: "org/alice", Time: now.Unix()},
+	} {
+		record.Name = fmt.Sprintf("record%d", i)
+		_, err := ormer.Engine.Insert(record)
+		assert.Nil(t, err)
+	}
+
+	examined, purged, err := purgeVerifications("org", now.Add(-24*time.Hour), 10, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, examined)
+	assert.Equal(t, 2, purged)
+
+	count, err := ormer.Engine.Count(&VerificationRecord{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+}
@@ -35,6 +35,7 @@ type Role struct {
 	Groups    []string `xorm:"mediumtext" json:"groups"`
 	Roles     []string `xorm:"mediumtext" json:"roles"`
 	Domains   []string `xorm:"mediumtext" json:"domains"`
+	Approvers []string `xorm:"mediumtext" json:"approvers"`
 	IsEnabled bool     `json:"isEnabled"`
 }
 
@@ -272,8 +273,22 @@ func getRolesByUserInternal(userId string) ([]*Role, error) {
 		return roles, err
 	}
 
+	// the user is nil for the service accounts, which don't join the groups, and for the devices
+	groups := []string{}
+	if user != nil {
+		groups = user.Groups
+	} else {
+		device, err := GetDevice(userId)
+		if err != nil {
+			return roles, err
+		}
+		if device != nil {
+			groups = device.Groups
+		}
+	}
+
 	query := ormer.Engine.Alias("r").Where("r.users like ?", fmt.Sprintf("%%%s%%", userId))
-	for _, group := range user.Groups {
+	for _, group := range groups {
 		query = query.Or("r.groups like ?", fmt.Sprintf("%%%s%%", group))
 	}
 
@@ -284,7 +299,7 @@ func getRolesByUserInternal(userId string) ([]*Role, error) {
 
 	res := []*Role{}
 	for _, role := range roles {
-		if util.InSlice(role.Users, userId) || util.HaveIntersection(role.Groups, user.Groups) {
+		if util.InSlice(role.Users, userId) || util.HaveIntersection(role.Groups, groups) {
 			res = append(res, role)
 		}
 	}
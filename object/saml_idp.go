@@ -318,12 +318,17 @@ func GetSamlResponse(application *Application, user *User, samlRequest string, h
 	_, originBackend := getOriginFromHost(host)
 	// build signedResponse
 	samlResponse, _ := NewSamlResponse(application, user, originBackend, certificate, authnRequest.AssertionConsumerServiceURL, authnRequest.Issuer.Url, authnRequest.ID, application.RedirectUris)
-	randomKeyStore := &X509Key{
-		PrivateKey:      cert.PrivateKey,
-		X509Certificate: certificate,
+	signer, err := getCertSigner(cert)
+	if err != nil {
+		return "", "", "", err
 	}
-	ctx := dsig.NewDefaultSigningContext(randomKeyStore)
+	keyStore := &signerKeyStore{signer: signer, certificate: block.Bytes}
+	ctx := dsig.NewDefaultSigningContext(keyStore)
 	ctx.Hash = crypto.SHA1
+	if cert.isExternalKey() {
+		// the KMS backends don't sign SHA-1 digests
+		ctx.Hash = crypto.SHA256
+	}
 
 	if application.EnableSamlC14n10 {
 		ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
@@ -333,7 +338,10 @@ func GetSamlResponse(application *Application, user *User, samlRequest string, h
 	//if err != nil {
 	//	return "", "", fmt.Errorf("err: %s", err.Error())
 	//}
-	sig, err := ctx.ConstructSignature(samlResponse, true)
+	sig, err := constructXmlSignature(ctx, keyStore, samlResponse)
+	if err != nil {
+		return "", "", method, fmt.Errorf("err: Failed to sign the SAML response, %s", err.Error())
+	}
 	samlResponse.InsertChildAt(1, sig)
 
 	doc := etree.NewDocument()
@@ -0,0 +1,372 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/beevik/etree"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/proxy"
+	"github.com/casdoor/casdoor/util"
+	"github.com/russellhaering/gosaml2/types"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/xorm-io/core"
+)
+
+const (
+	defaultSamlMetadataRefreshInterval = 24
+	samlMetadataMaxSize                = 10 << 20
+	samlMetadataTimeout                = 30 * time.Second
+)
+
+var certWhiteSpaceRegex = regexp.MustCompile(`\s+`)
+
+type samlMetadata struct {
+	EntityId string
+	SsoUrl   string
+	Certs    []string
+}
+
+func normalizeCertFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(fingerprint), ":", ""))
+}
+
+// getCertFingerprint returns the SHA-256 fingerprint in hex of the base64 encoded DER certificate
+func getCertFingerprint(cert string) (string, error) {
+	certData, err := base64.StdEncoding.DecodeString(certWhiteSpaceRegex.ReplaceAllString(cert, ""))
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(certData)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func parseCert(cert string) (*x509.Certificate, error) {
+	certData, err := base64.StdEncoding.DecodeString(certWhiteSpaceRegex.ReplaceAllString(cert, ""))
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(certData)
+}
+
+// getProviderIdpCerts returns the IdP certificates of the refreshed metadata, or the configured one
+func getProviderIdpCerts(provider *Provider) []string {
+	if len(provider.IdpCerts) != 0 {
+		return provider.IdpCerts
+	}
+	if provider.IdP != "" {
+		return []string{provider.IdP}
+	}
+	return []string{}
+}
+
+func isCertPinned(provider *Provider, cert string) bool {
+	fingerprint, err := getCertFingerprint(cert)
+	if err != nil {
+		return false
+	}
+
+	for _, pinnedCert := range provider.PinnedCerts {
+		if normalizeCertFingerprint(pinnedCert) == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// getTrustedCertFingerprints returns the pinned fingerprints, or the fingerprints of the current IdP certificates
+// when nothing is pinned, so the first metadata is trusted on first use and the later ones must be vouched by it
+func getTrustedCertFingerprints(provider *Provider) map[string]bool {
+	res := map[string]bool{}
+	if len(provider.PinnedCerts) != 0 {
+		for _, pinnedCert := range provider.PinnedCerts {
+			res[normalizeCertFingerprint(pinnedCert)] = true
+		}
+		return res
+	}
+
+	for _, cert := range getProviderIdpCerts(provider) {
+		fingerprint, err := getCertFingerprint(cert)
+		if err == nil {
+			res[fingerprint] = true
+		}
+	}
+	return res
+}
+
+// validateSamlMetadataSignature verifies the enveloped signature of the metadata by a trusted certificate and
+// returns the signed content, the signing certificate is embedded in the signature
+func validateSamlMetadataSignature(root *etree.Element, signature *etree.Element, trustedFingerprints map[string]bool) ([]byte, error) {
+	certElement := signature.FindElement(".//X509Certificate")
+	if certElement == nil {
+		return nil, fmt.Errorf("the signature of the metadata has no certificate")
+	}
+
+	fingerprint, err := getCertFingerprint(certElement.Text())
+	if err != nil {
+		return nil, err
+	}
+	if len(trustedFingerprints) != 0 && !trustedFingerprints[fingerprint] {
+		return nil, fmt.Errorf("the metadata is signed by the untrusted certificate: %s", fingerprint)
+	}
+
+	cert, err := parseCert(certElement.Text())
+	if err != nil {
+		return nil, err
+	}
+
+	validationContext := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: []*x509.Certificate{cert}})
+	signedElement, err := validationContext.Validate(root)
+	if err != nil {
+		return nil, fmt.Errorf("the signature of the metadata is invalid: %s", err.Error())
+	}
+
+	doc := etree.NewDocument()
+	doc.SetRoot(signedElement)
+	return doc.WriteToBytes()
+}
+
+// parseSamlMetadata parses the IdP metadata, a signed metadata may roll over to the new certificates it lists,
+// an unsigned metadata is rejected if it lists any certificate not trusted yet
+func parseSamlMetadata(data []byte, trustedFingerprints map[string]bool, isPostBinding bool, now time.Time) (*samlMetadata, error) {
+	doc := etree.NewDocument()
+	err := doc.ReadFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	root := doc.Root()
+	if root == nil || root.Tag != "EntityDescriptor" {
+		return nil, fmt.Errorf("the metadata has no EntityDescriptor")
+	}
+
+	var signature *etree.Element
+	for _, child := range root.ChildElements() {
+		if child.Tag == "Signature" {
+			signature = child
+		}
+	}
+
+	isSigned := signature != nil
+	if isSigned {
+		data, err = validateSamlMetadataSignature(root, signature, trustedFingerprints)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var entityDescriptor types.EntityDescriptor
+	err = xml.Unmarshal(data, &entityDescriptor)
+	if err != nil {
+		return nil, err
+	}
+	if !entityDescriptor.ValidUntil.IsZero() && now.After(entityDescriptor.ValidUntil) {
+		return nil, fmt.Errorf("the metadata expired at: %s", entityDescriptor.ValidUntil.Format(time.RFC3339))
+	}
+
+	idpDescriptor := entityDescriptor.IDPSSODescriptor
+	if idpDescriptor == nil {
+		return nil, fmt.Errorf("the metadata has no IDPSSODescriptor")
+	}
+
+	res := &samlMetadata{EntityId: entityDescriptor.EntityID, Certs: []string{}}
+	for _, keyDescriptor := range idpDescriptor.KeyDescriptors {
+		if keyDescriptor.Use != "" && keyDescriptor.Use != "signing" {
+			continue
+		}
+
+		for _, x509Cert := range keyDescriptor.KeyInfo.X509Data.X509Certificates {
+			cert := certWhiteSpaceRegex.ReplaceAllString(x509Cert.Data, "")
+			if cert == "" || util.InSlice(res.Certs, cert) {
+				continue
+			}
+
+			fingerprint, err := getCertFingerprint(cert)
+			if err != nil {
+				return nil, err
+			}
+			if !isSigned && len(trustedFingerprints) != 0 && !trustedFingerprints[fingerprint] {
+				return nil, fmt.Errorf("the unsigned metadata lists the untrusted certificate: %s", fingerprint)
+			}
+
+			res.Certs = append(res.Certs, cert)
+		}
+	}
+	if len(res.Certs) == 0 {
+		return nil, fmt.Errorf("the metadata has no signing certificate")
+	}
+
+	binding := "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	if isPostBinding {
+		binding = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+	}
+	for _, service := range idpDescriptor.SingleSignOnServices {
+		if res.SsoUrl == "" || service.Binding == binding {
+			res.SsoUrl = service.Location
+		}
+		if service.Binding == binding {
+			break
+		}
+	}
+	if res.SsoUrl == "" {
+		return nil, fmt.Errorf("the metadata has no SingleSignOnService")
+	}
+
+	return res, nil
+}
+
+func getSamlMetadata(metadataUrl string) ([]byte, error) {
+	req, err := http.NewRequest("GET", metadataUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := *proxy.GetHttpClient(metadataUrl)
+	client.Timeout = samlMetadataTimeout
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("the request to: %s failed, status: %s", metadataUrl, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, samlMetadataMaxSize))
+}
+
+// refreshSamlMetadata applies the fetched metadata to the provider, the current configuration is kept on error
+// so the logins go on with the current certificates
+func refreshSamlMetadata(provider *Provider, now time.Time) error {
+	data, err := getSamlMetadata(provider.MetadataUrl)
+	if err != nil {
+		return err
+	}
+
+	metadata, err := parseSamlMetadata(data, getTrustedCertFingerprints(provider), provider.EnableSignAuthnRequest, now)
+	if err != nil {
+		return err
+	}
+
+	provider.Metadata = string(data)
+	provider.IdpCerts = metadata.Certs
+	provider.IdP = metadata.Certs[0]
+	provider.Endpoint = metadata.SsoUrl
+	provider.IssuerUrl = metadata.EntityId
+	return nil
+}
+
+func updateSamlMetadata(provider *Provider, now time.Time) error {
+	refreshErr := refreshSamlMetadata(provider, now)
+	provider.MetadataRefreshTime = util.GetCurrentTime()
+	provider.MetadataError = ""
+	if refreshErr != nil {
+		provider.MetadataError = refreshErr.Error()
+	}
+
+	_, err := ormer.Engine.ID(core.PK{provider.Owner, provider.Name}).
+		Cols("metadata", "id_p", "idp_certs", "endpoint", "issuer_url", "metadata_refresh_time", "metadata_error").Update(provider)
+	if err != nil {
+		return err
+	}
+
+	return refreshErr
+}
+
+// RefreshSamlMetadata fetches the metadata of the SAML provider now
+func RefreshSamlMetadata(id string, lang string) (*Provider, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	provider, err := getRawProvider(owner, name)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "util:The provider: %s is not found"), id)
+	}
+	if provider.Category != "SAML" {
+		return nil, fmt.Errorf(i18n.Translate(lang, "saml_sp:provider %s's category is not SAML"), provider.Name)
+	}
+	if provider.MetadataUrl == "" {
+		return nil, fmt.Errorf(i18n.Translate(lang, "saml_sp:The metadata URL of the provider: %s is empty"), provider.Name)
+	}
+
+	err = updateSamlMetadata(provider, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return provider, nil
+}
+
+func isSamlMetadataRefreshDue(provider *Provider, now time.Time) bool {
+	refreshTime, err := time.Parse(time.RFC3339, provider.MetadataRefreshTime)
+	if err != nil {
+		return true
+	}
+
+	interval := provider.MetadataRefreshInterval
+	if interval <= 0 {
+		interval = defaultSamlMetadataRefreshInterval
+	}
+	return !now.Before(refreshTime.Add(time.Duration(interval) * time.Hour))
+}
+
+func refreshSamlMetadatas(now time.Time) error {
+	providers := []*Provider{}
+	err := ormer.Engine.Where("category = ? and metadata_url <> ?", "SAML", "").Find(&providers)
+	if err != nil {
+		return err
+	}
+
+	for _, provider := range providers {
+		if !isSamlMetadataRefreshDue(provider, now) {
+			continue
+		}
+
+		err = updateSamlMetadata(provider, now)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to refresh the SAML metadata of the provider: %s, error: %s", provider.GetId(), err.Error()))
+		}
+	}
+
+	return nil
+}
+
+// RunSamlMetadataRefreshJob refreshes the metadata of the SAML providers with the metadata URL when they are due
+func RunSamlMetadataRefreshJob() {
+	for {
+		err := refreshSamlMetadatas(time.Now())
+		if err != nil {
+			logs.Warning(fmt.Sprintf("SAML metadata refresh failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
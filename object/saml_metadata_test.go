@@ -0,0 +1,130 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/stretchr/testify/assert"
+)
+
+func getTestSamlMetadata(certs ...string) string {
+	keyDescriptors := ""
+	for _, cert := range certs {
+		keyDescriptors += fmt.Sprintf(`<md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data><ds:X509Certificate>%s</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>`, cert)
+	}
+
+	return fmt.Sprintf(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://idp.example.com" ID="metadata">`+
+		`<md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">%s`+
+		`<md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://idp.example.com/sso/post"/>`+
+		`<md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://idp.example.com/sso/redirect"/>`+
+		`</md:IDPSSODescriptor></md:EntityDescriptor>`, keyDescriptors)
+}
+
+func getTestSamlCert(t *testing.T) (dsig.X509KeyStore, string) {
+	keyStore := dsig.RandomKeyStoreForTest()
+	_, certData, err := keyStore.GetKeyPair()
+	assert.Nil(t, err)
+	return keyStore, base64.StdEncoding.EncodeToString(certData)
+}
+
+func signTestSamlMetadata(t *testing.T, keyStore dsig.X509KeyStore, metadata string) []byte {
+	doc := etree.NewDocument()
+	assert.Nil(t, doc.ReadFromString(metadata))
+
+	signedElement, err := dsig.NewDefaultSigningContext(keyStore).SignEnveloped(doc.Root())
+	assert.Nil(t, err)
+
+	doc.SetRoot(signedElement)
+	data, err := doc.WriteToBytes()
+	assert.Nil(t, err)
+	return data
+}
+
+func TestParseSamlMetadata(t *testing.T) {
+	now := time.Now()
+	oldKeyStore, oldCert := getTestSamlCert(t)
+	_, newCert := getTestSamlCert(t)
+	oldFingerprint, err := getCertFingerprint(oldCert)
+	assert.Nil(t, err)
+	trusted := map[string]bool{oldFingerprint: true}
+
+	// the first unsigned metadata is trusted on first use
+	metadata, err := parseSamlMetadata([]byte(getTestSamlMetadata(oldCert)), map[string]bool{}, false, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://idp.example.com", metadata.EntityId)
+	assert.Equal(t, "https://idp.example.com/sso/redirect", metadata.SsoUrl)
+	assert.Equal(t, []string{oldCert}, metadata.Certs)
+
+	metadata, err = parseSamlMetadata([]byte(getTestSamlMetadata(oldCert)), trusted, true, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://idp.example.com/sso/post", metadata.SsoUrl)
+
+	// an unsigned metadata can't introduce a new certificate
+	_, err = parseSamlMetadata([]byte(getTestSamlMetadata(oldCert, newCert)), trusted, false, now)
+	assert.NotNil(t, err)
+
+	// the metadata signed by the trusted certificate rolls over to the new one
+	data := signTestSamlMetadata(t, oldKeyStore, getTestSamlMetadata(oldCert, newCert))
+	metadata, err = parseSamlMetadata(data, trusted, false, now)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{oldCert, newCert}, metadata.Certs)
+
+	newFingerprint, err := getCertFingerprint(newCert)
+	assert.Nil(t, err)
+	_, err = parseSamlMetadata(data, map[string]bool{newFingerprint: true}, false, now)
+	assert.NotNil(t, err)
+}
+
+func TestBuildSpCertificateStorePinned(t *testing.T) {
+	_, cert := getTestSamlCert(t)
+	_, anotherCert := getTestSamlCert(t)
+	fingerprint, err := getCertFingerprint(cert)
+	assert.Nil(t, err)
+
+	provider := &Provider{Name: "saml", IdpCerts: []string{cert, anotherCert}}
+	certStore, err := buildSpCertificateStore(provider, "")
+	assert.Nil(t, err)
+	assert.Len(t, certStore.Roots, 2)
+
+	provider.PinnedCerts = []string{fmt.Sprintf("%s:%s", fingerprint[:2], fingerprint[2:])}
+	certStore, err = buildSpCertificateStore(provider, "")
+	assert.Nil(t, err)
+	assert.Len(t, certStore.Roots, 1)
+
+	provider.PinnedCerts = []string{"00"}
+	_, err = buildSpCertificateStore(provider, "")
+	assert.NotNil(t, err)
+}
+
+func TestIsSamlMetadataRefreshDue(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	provider := &Provider{}
+	assert.True(t, isSamlMetadataRefreshDue(provider, now))
+
+	provider.MetadataRefreshTime = "2024-01-01T08:00:00+08:00"
+	assert.True(t, isSamlMetadataRefreshDue(provider, now))
+
+	provider.MetadataRefreshTime = "2024-01-01T12:00:00Z"
+	assert.False(t, isSamlMetadataRefreshDue(provider, now))
+
+	provider.MetadataRefreshInterval = 6
+	assert.True(t, isSamlMetadataRefreshDue(provider, now))
+}
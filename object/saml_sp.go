@@ -53,6 +53,13 @@ func ParseSamlResponse(samlResponse string, provider *Provider, host string) (*i
 	}
 	userInfoMap["id"] = assertionInfo.NameID
 
+	extra := map[string]string{}
+	for _, attr := range assertionInfo.Values {
+		if len(attr.Values) > 0 {
+			extra[attr.Name] = attr.Values[0].Value
+		}
+	}
+
 	customUserInfo := &idp.CustomUserInfo{}
 	err = mapstructure.Decode(userInfoMap, customUserInfo)
 	if err != nil {
@@ -64,6 +71,7 @@ func ParseSamlResponse(samlResponse string, provider *Provider, host string) (*i
 		DisplayName: customUserInfo.DisplayName,
 		Email:       customUserInfo.Email,
 		AvatarUrl:   customUserInfo.AvatarUrl,
+		Extra:       extra,
 	}
 	return userInfo, err
 }
@@ -141,28 +149,46 @@ func buildSpKeyStore() (dsig.X509KeyStore, error) {
 	}, nil
 }
 
+// buildSpCertificateStore trusts the IdP certificates of the provider, the certificate of the response is only
+// trusted if it's pinned, or if the provider has neither the pinned nor the refreshed certificates
 func buildSpCertificateStore(provider *Provider, samlResponse string) (certStore dsig.MemoryX509CertificateStore, err error) {
-	certEncodedData := ""
-	if samlResponse != "" {
-		certEncodedData, err = getCertificateFromSamlResponse(samlResponse, provider.Type)
+	isPinned := len(provider.PinnedCerts) != 0
+	certs := []string{}
+	if samlResponse != "" && !isPinned && len(provider.IdpCerts) == 0 {
+		cert, err := getCertificateFromSamlResponse(samlResponse, provider.Type)
 		if err != nil {
-			return
+			return dsig.MemoryX509CertificateStore{}, err
+		}
+		certs = append(certs, cert)
+	} else {
+		certs = append(certs, getProviderIdpCerts(provider)...)
+		if samlResponse != "" && isPinned {
+			cert, err := getCertificateFromSamlResponse(samlResponse, provider.Type)
+			if err != nil {
+				return dsig.MemoryX509CertificateStore{}, err
+			}
+			certs = append(certs, cert)
 		}
-	} else if provider.IdP != "" {
-		certEncodedData = provider.IdP
 	}
 
-	certData, err := base64.StdEncoding.DecodeString(certEncodedData)
-	if err != nil {
-		return dsig.MemoryX509CertificateStore{}, err
+	roots := []*x509.Certificate{}
+	for _, cert := range certs {
+		if isPinned && !isCertPinned(provider, cert) {
+			continue
+		}
+
+		idpCert, err := parseCert(cert)
+		if err != nil {
+			return dsig.MemoryX509CertificateStore{}, err
+		}
+		roots = append(roots, idpCert)
 	}
-	idpCert, err := x509.ParseCertificate(certData)
-	if err != nil {
-		return dsig.MemoryX509CertificateStore{}, err
+	if len(roots) == 0 {
+		return dsig.MemoryX509CertificateStore{}, fmt.Errorf("the provider: %s has no trusted IdP certificate", provider.Name)
 	}
 
 	certStore = dsig.MemoryX509CertificateStore{
-		Roots: []*x509.Certificate{idpCert},
+		Roots: roots,
 	}
 	return certStore, nil
 }
@@ -0,0 +1,453 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/xorm-io/core"
+)
+
+const (
+	UserTypeServiceAccount = "service-account"
+
+	clientAssertionTypeJwtBearer = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	maxClientAssertionLifetime   = time.Hour
+	clientAssertionTokenType     = "client_assertion"
+)
+
+// ServiceAccount is a non-interactive identity for bots and services. It can't sign in with a password,
+// and gets the access tokens with the client credentials grant, authenticated by the client secret
+// or a JWT signed by the private key of the public key (private_key_jwt). It can be added to the roles
+// and permissions like a user with the id "<organization>/<name>", but isn't counted as a user.
+type ServiceAccount struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+	Description string `xorm:"varchar(1000)" json:"description"`
+	// Team is the id of the group that owns the service account
+	Team string `xorm:"varchar(100) index" json:"team"`
+	// Application is the application that issues the access tokens of the service account
+	Application string `xorm:"varchar(100)" json:"application"`
+	IsEnabled   bool   `json:"isEnabled"`
+
+	ClientId          string `xorm:"varchar(100) index" json:"clientId"`
+	ClientSecret      string `xorm:"varchar(100)" json:"clientSecret"`
+	PublicKey         string `xorm:"mediumtext" json:"publicKey"`
+	SecretRotatedTime string `xorm:"varchar(100)" json:"secretRotatedTime"`
+	LastUsedTime      string `xorm:"varchar(100)" json:"lastUsedTime"`
+}
+
+func GetServiceAccountCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&ServiceAccount{})
+}
+
+func GetServiceAccounts(owner string) ([]*ServiceAccount, error) {
+	serviceAccounts := []*ServiceAccount{}
+	err := ormer.Engine.Desc("created_time").Find(&serviceAccounts, &ServiceAccount{Owner: owner})
+	if err != nil {
+		return serviceAccounts, err
+	}
+
+	return serviceAccounts, nil
+}
+
+func GetPaginationServiceAccounts(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*ServiceAccount, error) {
+	serviceAccounts := []*ServiceAccount{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&serviceAccounts)
+	if err != nil {
+		return serviceAccounts, err
+	}
+
+	return serviceAccounts, nil
+}
+
+func getServiceAccount(owner string, name string) (*ServiceAccount, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	serviceAccount := ServiceAccount{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&serviceAccount)
+	if err != nil {
+		return &serviceAccount, err
+	}
+
+	if existed {
+		return &serviceAccount, nil
+	}
+
+	return nil, nil
+}
+
+func GetServiceAccount(id string) (*ServiceAccount, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getServiceAccount(owner, name)
+}
+
+func GetServiceAccountByClientId(clientId string) (*ServiceAccount, error) {
+	if clientId == "" {
+		return nil, nil
+	}
+
+	serviceAccount := ServiceAccount{}
+	existed, err := ormer.Engine.Where("client_id = ?", clientId).Get(&serviceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &serviceAccount, nil
+	}
+
+	return nil, nil
+}
+
+func checkServiceAccount(serviceAccount *ServiceAccount, lang string) error {
+	// the service account shares the subject namespace of the users in the permissions
+	user, err := getUser(serviceAccount.Owner, serviceAccount.Name)
+	if err != nil {
+		return err
+	}
+	if user != nil {
+		return fmt.Errorf(i18n.Translate(lang, "service:The name: %s is used by a user"), serviceAccount.Name)
+	}
+
+	device, err := getDevice(serviceAccount.Owner, serviceAccount.Name)
+	if err != nil {
+		return err
+	}
+	if device != nil {
+		return fmt.Errorf(i18n.Translate(lang, "service:The name: %s is used by a device"), serviceAccount.Name)
+	}
+
+	if serviceAccount.Team != "" {
+		group, err := GetGroup(serviceAccount.Team)
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			return fmt.Errorf(i18n.Translate(lang, "service:The group: %s does not exist"), serviceAccount.Team)
+		}
+	}
+
+	// the access tokens of the service account are only issued by an application of its own organization
+	if serviceAccount.Application != "" {
+		application, err := getApplication("admin", serviceAccount.Application)
+		if err != nil {
+			return err
+		}
+		if application == nil {
+			return fmt.Errorf(i18n.Translate(lang, "auth:The application: %s does not exist"), serviceAccount.Application)
+		}
+		if application.Organization != serviceAccount.Owner {
+			return fmt.Errorf(i18n.Translate(lang, "service:The application: %s should belong to the organization: %s"), serviceAccount.Application, serviceAccount.Owner)
+		}
+	}
+
+	if serviceAccount.PublicKey != "" {
+		_, err = parseServiceAccountPublicKey(serviceAccount.PublicKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func UpdateServiceAccount(id string, serviceAccount *ServiceAccount, lang string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if s, err := getServiceAccount(owner, name); err != nil {
+		return false, err
+	} else if s == nil {
+		return false, nil
+	}
+
+	err := checkServiceAccount(serviceAccount, lang)
+	if err != nil {
+		return false, err
+	}
+
+	serviceAccount.UpdatedTime = util.GetCurrentTime()
+
+	// the client secret is only changed by the rotation
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Omit("client_id", "client_secret", "secret_rotated_time", "last_used_time").Update(serviceAccount)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func AddServiceAccount(serviceAccount *ServiceAccount, lang string) (bool, error) {
+	err := checkServiceAccount(serviceAccount, lang)
+	if err != nil {
+		return false, err
+	}
+
+	serviceAccount.ClientId = util.GenerateClientId()
+	serviceAccount.ClientSecret = util.GenerateClientSecret()
+	serviceAccount.SecretRotatedTime = util.GetCurrentTime()
+	serviceAccount.UpdatedTime = util.GetCurrentTime()
+
+	affected, err := ormer.Engine.Insert(serviceAccount)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func DeleteServiceAccount(serviceAccount *ServiceAccount) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{serviceAccount.Owner, serviceAccount.Name}).Delete(&ServiceAccount{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// RotateServiceAccountSecret replaces the client secret of the service account, the old secret stops working at once
+func RotateServiceAccountSecret(id string) (*ServiceAccount, error) {
+	serviceAccount, err := GetServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	if serviceAccount == nil {
+		return nil, nil
+	}
+
+	serviceAccount.ClientSecret = util.GenerateClientSecret()
+	serviceAccount.SecretRotatedTime = util.GetCurrentTime()
+	_, err = ormer.Engine.ID(core.PK{serviceAccount.Owner, serviceAccount.Name}).Cols("client_secret", "secret_rotated_time").Update(serviceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	return serviceAccount, nil
+}
+
+// RotateServiceAccountKey replaces the public key that verifies the client assertions of the service account
+func RotateServiceAccountKey(id string, publicKey string) (*ServiceAccount, error) {
+	serviceAccount, err := GetServiceAccount(id)
+	if err != nil {
+		return nil, err
+	}
+	if serviceAccount == nil {
+		return nil, nil
+	}
+
+	if publicKey != "" {
+		_, err = parseServiceAccountPublicKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	serviceAccount.PublicKey = publicKey
+	_, err = ormer.Engine.ID(core.PK{serviceAccount.Owner, serviceAccount.Name}).Cols("public_key").Update(serviceAccount)
+	if err != nil {
+		return nil, err
+	}
+
+	return serviceAccount, nil
+}
+
+func (serviceAccount *ServiceAccount) GetId() string {
+	return fmt.Sprintf("%s/%s", serviceAccount.Owner, serviceAccount.Name)
+}
+
+func parseServiceAccountPublicKey(publicKey string) (interface{}, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM([]byte(publicKey)); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM([]byte(publicKey)); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM([]byte(publicKey)); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("the public key should be a PEM encoded RSA, ECDSA or Ed25519 public key")
+}
+
+// GetClientIdFromClientAssertion returns the issuer of the client assertion without verifying it,
+// the issuer is the client id of the client that signs the assertion.
+func GetClientIdFromClientAssertion(clientAssertion string) string {
+	claims := jwt.RegisteredClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(clientAssertion, &claims)
+	if err != nil {
+		return ""
+	}
+	return claims.Issuer
+}
+
+// verifyClientAssertion verifies the JWT that the service account signs with its private key (RFC 7523), the
+// assertion should be addressed to the token endpoint and is only accepted once until it expires
+func (serviceAccount *ServiceAccount) verifyClientAssertion(clientAssertionType string, clientAssertion string, tokenEndpoint string) error {
+	if clientAssertionType != clientAssertionTypeJwtBearer {
+		return fmt.Errorf("client_assertion_type: %s is not supported", clientAssertionType)
+	}
+	if serviceAccount.PublicKey == "" {
+		return fmt.Errorf("the service account has no public key")
+	}
+
+	publicKey, err := parseServiceAccountPublicKey(serviceAccount.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	claims := jwt.RegisteredClaims{}
+	_, err = jwt.ParseWithClaims(clientAssertion, &claims, func(token *jwt.Token) (interface{}, error) {
+		switch token.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS, *jwt.SigningMethodECDSA, *jwt.SigningMethodEd25519:
+			return publicKey, nil
+		default:
+			return nil, fmt.Errorf("unexpected signing method: %s", token.Header["alg"])
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if claims.Issuer != serviceAccount.ClientId || claims.Subject != serviceAccount.ClientId {
+		return fmt.Errorf("the issuer and the subject of the client assertion should be the client id")
+	}
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Time.After(time.Now().Add(maxClientAssertionLifetime)) {
+		return fmt.Errorf("the client assertion should expire in %s", maxClientAssertionLifetime)
+	}
+	if !claims.VerifyAudience(tokenEndpoint, true) {
+		return fmt.Errorf("the audience of the client assertion should be the token endpoint: %s", tokenEndpoint)
+	}
+	if claims.ID == "" {
+		return fmt.Errorf("the client assertion should have a JWT ID")
+	}
+
+	// the used assertions are kept in the token denylist until they expire
+	key := getTokenDenylistKey(clientAssertionTokenType, util.GetId(serviceAccount.ClientId, claims.ID))
+	isUsed, err := getTokenDenylist().Contains(key)
+	if err != nil {
+		return err
+	}
+	if isUsed {
+		return fmt.Errorf("the client assertion has been used")
+	}
+
+	return getTokenDenylist().Add(key, time.Until(claims.ExpiresAt.Time))
+}
+
+// GetServiceAccountToken issues the access token of the service account with the client credentials grant
+func GetServiceAccountToken(serviceAccount *ServiceAccount, clientSecret string, clientAssertionType string, clientAssertion string, scope string, host string) (*TokenWrapper, *TokenError, error) {
+	if !serviceAccount.IsEnabled {
+		return nil, &TokenError{
+			Error:            InvalidClient,
+			ErrorDescription: "the service account is disabled",
+		}, nil
+	}
+
+	if clientAssertion != "" {
+		_, originBackend := getOriginFromHost(host)
+		err := serviceAccount.verifyClientAssertion(clientAssertionType, clientAssertion, fmt.Sprintf("%s/api/login/oauth/access_token", originBackend))
+		if err != nil {
+			return nil, &TokenError{
+				Error:            InvalidClient,
+				ErrorDescription: fmt.Sprintf("client_assertion is invalid: %s", err.Error()),
+			}, nil
+		}
+	} else if clientSecret == "" || serviceAccount.ClientSecret != clientSecret {
+		return nil, &TokenError{
+			Error:            InvalidClient,
+			ErrorDescription: "client_secret is invalid",
+		}, nil
+	}
+
+	application, err := getApplication("admin", serviceAccount.Application)
+	if err != nil {
+		return nil, nil, err
+	}
+	if application == nil {
+		return nil, &TokenError{
+			Error:            InvalidClient,
+			ErrorDescription: fmt.Sprintf("the application: %s of the service account does not exist", serviceAccount.Application),
+		}, nil
+	}
+	if application.Organization != serviceAccount.Owner {
+		return nil, &TokenError{
+			Error:            InvalidClient,
+			ErrorDescription: fmt.Sprintf("the application: %s doesn't belong to the organization of the service account", serviceAccount.Application),
+		}, nil
+	}
+
+	user := &User{
+		Owner:       serviceAccount.Owner,
+		Name:        serviceAccount.Name,
+		Id:          serviceAccount.GetId(),
+		DisplayName: serviceAccount.DisplayName,
+		Type:        UserTypeServiceAccount,
+	}
+	err = ExtendUserWithRolesAndPermissions(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scope = getGrantedScope(application, user, scope)
+	accessToken, _, tokenName, err := generateJwtToken(application, user, "", scope, host)
+	if err != nil {
+		return nil, &TokenError{
+			Error:            EndpointError,
+			ErrorDescription: fmt.Sprintf("generate jwt token error: %s", err.Error()),
+		}, nil
+	}
+
+	token := &Token{
+		Owner:        application.Owner,
+		Name:         tokenName,
+		CreatedTime:  util.GetCurrentTime(),
+		Application:  application.Name,
+		Organization: serviceAccount.Owner,
+		User:         serviceAccount.Name,
+		Code:         util.GenerateClientId(),
+		AccessToken:  accessToken,
+		ExpiresIn:    application.ExpireInHours * hourSeconds,
+		Scope:        scope,
+		TokenType:    "Bearer",
+		CodeIsUsed:   true,
+	}
+	_, err = AddToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serviceAccount.LastUsedTime = util.GetCurrentTime()
+	_, err = ormer.Engine.ID(core.PK{serviceAccount.Owner, serviceAccount.Name}).Cols("last_used_time").Update(serviceAccount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &TokenWrapper{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		ExpiresIn:   token.ExpiresIn,
+		Scope:       token.Scope,
+	}, nil, nil
+}
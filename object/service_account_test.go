@@ -0,0 +1,80 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyClientAssertion(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.Nil(t, err)
+
+	serviceAccount := &ServiceAccount{
+		ClientId:  "bot-client-id",
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes})),
+	}
+
+	tokenEndpoint := "https://door.casdoor.com/api/login/oauth/access_token"
+	getAssertionWithAudience := func(issuer string, expiresAt time.Time, audience string) string {
+		claims := jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   issuer,
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			ID:        util.GenerateId(),
+		}
+		assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+		assert.Nil(t, err)
+		return assertion
+	}
+	getAssertion := func(issuer string, expiresAt time.Time) string {
+		return getAssertionWithAudience(issuer, expiresAt, tokenEndpoint)
+	}
+
+	assertion := getAssertion("bot-client-id", time.Now().Add(5*time.Minute))
+	assert.Nil(t, serviceAccount.verifyClientAssertion(clientAssertionTypeJwtBearer, assertion, tokenEndpoint))
+	assert.Equal(t, "bot-client-id", GetClientIdFromClientAssertion(assertion))
+
+	// the assertion can't be replayed
+	assert.NotNil(t, serviceAccount.verifyClientAssertion(clientAssertionTypeJwtBearer, assertion, tokenEndpoint))
+
+	// the assertion addressed to another server or without the audience
+	assert.NotNil(t, serviceAccount.verifyClientAssertion(clientAssertionTypeJwtBearer, getAssertionWithAudience("bot-client-id", time.Now().Add(5*time.Minute), "https://other.com/token"), tokenEndpoint))
+	assert.NotNil(t, serviceAccount.verifyClientAssertion(clientAssertionTypeJwtBearer, getAssertionWithAudience("bot-client-id", time.Now().Add(5*time.Minute), ""), tokenEndpoint))
+
+	assert.NotNil(t, serviceAccount.verifyClientAssertion("password", getAssertion("bot-client-id", time.Now().Add(5*time.Minute)), tokenEndpoint))
+	assert.NotNil(t, serviceAccount.verifyClientAssertion(clientAssertionTypeJwtBearer, getAssertion("other-client-id", time.Now().Add(5*time.Minute)), tokenEndpoint))
+	assert.NotNil(t, serviceAccount.verifyClientAssertion(clientAssertionTypeJwtBearer, getAssertion("bot-client-id", time.Now().Add(-time.Minute)), tokenEndpoint))
+	assert.NotNil(t, serviceAccount.verifyClientAssertion(clientAssertionTypeJwtBearer, getAssertion("bot-client-id", time.Now().Add(24*time.Hour)), tokenEndpoint))
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	forged, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.RegisteredClaims{Issuer: "bot-client-id", Subject: "bot-client-id", Audience: jwt.ClaimStrings{tokenEndpoint}, ID: util.GenerateId(), ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))}).SignedString(otherKey)
+	assert.Nil(t, err)
+	assert.NotNil(t, serviceAccount.verifyClientAssertion(clientAssertionTypeJwtBearer, forged, tokenEndpoint))
+}
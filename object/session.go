@@ -33,7 +33,8 @@ type Session struct {
 	Application string `xorm:"varchar(100) notnull pk" json:"application"`
 	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
 
-	SessionId []string `json:"sessionId"`
+	SessionId []string                  `json:"sessionId"`
+	Devices   map[string]*SessionDevice `xorm:"mediumtext" json:"devices"`
 }
 
 func GetSessions(owner string) ([]*Session, error) {
@@ -91,7 +92,8 @@ func UpdateSession(id string, session *Session) (bool, error) {
 		return false, nil
 	}
 
-	affected, err := ormer.Engine.ID(core.PK{owner, name, application}).Update(session)
+	session.mergeSessionDevices(nil)
+	affected, err := ormer.Engine.ID(core.PK{owner, name, application}).MustCols("devices").Update(session)
 	if err != nil {
 		return false, err
 	}
@@ -113,6 +115,7 @@ func AddSession(session *Session) (bool, error) {
 
 	if dbSession == nil {
 		session.CreatedTime = util.GetCurrentTime()
+		session.mergeSessionDevices(nil)
 
 		affected, err := ormer.Engine.Insert(session)
 		if err != nil {
@@ -132,6 +135,7 @@ func AddSession(session *Session) (bool, error) {
 		}
 
 		removeExtraSessionIds(dbSession)
+		dbSession.mergeSessionDevices(session.Devices)
 
 		return UpdateSession(dbSession.GetId(), dbSession)
 	}
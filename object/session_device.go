@@ -0,0 +1,219 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	SessionDeviceTypeDesktop = "Desktop"
+	SessionDeviceTypeMobile  = "Mobile"
+	SessionDeviceTypeTablet  = "Tablet"
+	SessionDeviceTypeBot     = "Bot"
+)
+
+// SessionDevice is the device of a signed-in session parsed from the user agent and the client hints of the login
+// request, the display name is given by the user to recognize the device when reviewing the sessions
+type SessionDevice struct {
+	DisplayName    string `json:"displayName"`
+	Browser        string `json:"browser"`
+	BrowserVersion string `json:"browserVersion"`
+	Os             string `json:"os"`
+	OsVersion      string `json:"osVersion"`
+	DeviceType     string `json:"deviceType"`
+	DeviceModel    string `json:"deviceModel"`
+	ClientIp       string `json:"clientIp"`
+	CountryCode    string `json:"countryCode"`
+	CreatedTime    string `json:"createdTime"`
+}
+
+var (
+	userAgentBrowserRegexes = []struct {
+		name  string
+		regex *regexp.Regexp
+	}{
+		{"Edge", regexp.MustCompile(`Edg(?:e|A|iOS)?/([\d.]+)`)},
+		{"Opera", regexp.MustCompile(`(?:OPR|Opera)/([\d.]+)`)},
+		{"Samsung Internet", regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+		{"Firefox", regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+		{"Chrome", regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+		{"Safari", regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+		{"Internet Explorer", regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+	}
+	userAgentOsRegexes = []struct {
+		name  string
+		regex *regexp.Regexp
+	}{
+		{"Windows", regexp.MustCompile(`Windows NT ([\d.]+)`)},
+		{"iOS", regexp.MustCompile(`(?:iPhone|CPU) OS ([\d_]+)`)},
+		{"Android", regexp.MustCompile(`Android ([\d.]+)`)},
+		{"macOS", regexp.MustCompile(`Mac OS X ([\d_.]+)`)},
+		{"ChromeOS", regexp.MustCompile(`CrOS \S+ ([\d.]+)`)},
+		{"Linux", regexp.MustCompile(`Linux()`)},
+	}
+	userAgentAndroidModelRegex = regexp.MustCompile(`Android [\d.]+; ([^;)]+?)(?: Build/[^;)]*)?\)`)
+	userAgentBotRegex          = regexp.MustCompile(`(?i)bot|crawler|spider|curl|wget`)
+
+	windowsNtVersions = map[string]string{"10.0": "10", "6.3": "8.1", "6.2": "8", "6.1": "7"}
+)
+
+// parseUserAgent fills the browser, OS and device type of the device from the user agent
+func (device *SessionDevice) parseUserAgent(userAgent string) {
+	for _, browser := range userAgentBrowserRegexes {
+		if match := browser.regex.FindStringSubmatch(userAgent); match != nil {
+			device.Browser, device.BrowserVersion = browser.name, match[1]
+			break
+		}
+	}
+
+	for _, os := range userAgentOsRegexes {
+		if match := os.regex.FindStringSubmatch(userAgent); match != nil {
+			device.Os, device.OsVersion = os.name, strings.ReplaceAll(match[1], "_", ".")
+			break
+		}
+	}
+	if device.Os == "Windows" {
+		device.OsVersion = windowsNtVersions[device.OsVersion]
+	} else if device.Os == "iOS" && strings.Contains(userAgent, "iPad") {
+		device.Os = "iPadOS"
+	}
+
+	switch {
+	case userAgentBotRegex.MatchString(userAgent):
+		device.DeviceType = SessionDeviceTypeBot
+	case strings.Contains(userAgent, "iPad") || strings.Contains(userAgent, "Tablet") || (device.Os == "Android" && !strings.Contains(userAgent, "Mobile")):
+		device.DeviceType = SessionDeviceTypeTablet
+	case strings.Contains(userAgent, "Mobi") || strings.Contains(userAgent, "iPhone"):
+		device.DeviceType = SessionDeviceTypeMobile
+	default:
+		device.DeviceType = SessionDeviceTypeDesktop
+	}
+
+	if match := userAgentAndroidModelRegex.FindStringSubmatch(userAgent); match != nil && match[1] != "K" {
+		device.DeviceModel = match[1]
+	} else if strings.Contains(userAgent, "iPhone") {
+		device.DeviceModel = "iPhone"
+	} else if strings.Contains(userAgent, "iPad") {
+		device.DeviceModel = "iPad"
+	}
+}
+
+func getClientHint(header http.Header, key string) string {
+	return strings.Trim(header.Get(key), `"`)
+}
+
+// parseClientHints overrides the parsed user agent with the more accurate client hints (Sec-CH-UA-*) sent by the
+// Chromium-based browsers, the platform version and model are only sent if they are asked by the Accept-CH header
+func (device *SessionDevice) parseClientHints(header http.Header) {
+	for _, brand := range strings.Split(header.Get("Sec-CH-UA"), ",") {
+		parts := strings.SplitN(strings.TrimSpace(brand), ";v=", 2)
+		name := strings.Trim(parts[0], `"`)
+		if len(parts) != 2 || name == "Chromium" || strings.Contains(name, "Not") {
+			continue
+		}
+
+		device.Browser = strings.TrimPrefix(strings.TrimPrefix(name, "Google "), "Microsoft ")
+		device.BrowserVersion = strings.Trim(parts[1], `"`)
+		break
+	}
+
+	if platform := getClientHint(header, "Sec-CH-UA-Platform"); platform != "" {
+		if platform == "macOS" && device.Os == "macOS" {
+			// the OS version in the user agent of the Chromium-based browsers on macOS is frozen
+			device.OsVersion = ""
+		}
+		device.Os = platform
+	}
+	if platformVersion := getClientHint(header, "Sec-CH-UA-Platform-Version"); platformVersion != "" {
+		device.OsVersion = platformVersion
+		// Windows 11 reports the platform version 13 or later, the user agent can't tell it from Windows 10
+		if device.Os == "Windows" {
+			if util.ParseInt(strings.Split(platformVersion, ".")[0]) >= 13 {
+				device.OsVersion = "11"
+			} else {
+				device.OsVersion = "10"
+			}
+		}
+	}
+
+	if header.Get("Sec-CH-UA-Mobile") == "?1" && device.DeviceType == SessionDeviceTypeDesktop {
+		device.DeviceType = SessionDeviceTypeMobile
+	}
+	if model := getClientHint(header, "Sec-CH-UA-Model"); model != "" {
+		device.DeviceModel = model
+	}
+}
+
+// NewSessionDevice returns the device of the request signing in, the country is looked up by the GeoIP database
+func NewSessionDevice(header http.Header, clientIp string) *SessionDevice {
+	device := &SessionDevice{
+		ClientIp:    clientIp,
+		CountryCode: GetCountryCodeByIp(clientIp),
+		CreatedTime: util.GetCurrentTime(),
+	}
+	device.parseUserAgent(header.Get("User-Agent"))
+	device.parseClientHints(header)
+	return device
+}
+
+// mergeSessionDevices adds the devices of the new session IDs, the names given by the user are kept for the session
+// IDs signed in again, and the devices of the removed session IDs are dropped
+func (session *Session) mergeSessionDevices(devices map[string]*SessionDevice) {
+	if session.Devices == nil {
+		session.Devices = map[string]*SessionDevice{}
+	}
+
+	for sessionId, device := range devices {
+		if oldDevice, ok := session.Devices[sessionId]; ok && device.DisplayName == "" {
+			device.DisplayName = oldDevice.DisplayName
+		}
+		session.Devices[sessionId] = device
+	}
+
+	for sessionId := range session.Devices {
+		if !util.InSlice(session.SessionId, sessionId) {
+			delete(session.Devices, sessionId)
+		}
+	}
+}
+
+func GetUserSessions(owner string, name string) ([]*Session, error) {
+	sessions := []*Session{}
+	err := ormer.Engine.Desc("created_time").Find(&sessions, &Session{Owner: owner, Name: name})
+	if err != nil {
+		return sessions, err
+	}
+
+	return sessions, nil
+}
+
+// SetSessionDeviceName names the device of the session ID of the session
+func SetSessionDeviceName(id string, sessionId string, displayName string) (bool, error) {
+	session, err := GetSingleSession(id)
+	if err != nil {
+		return false, err
+	}
+	if session == nil || session.Devices[sessionId] == nil {
+		return false, nil
+	}
+
+	session.Devices[sessionId].DisplayName = displayName
+	return UpdateSession(id, session)
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	scenarios := []struct {
+		userAgent string
+		expected  SessionDevice
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.2478.67",
+			SessionDevice{Browser: "Edge", BrowserVersion: "124.0.2478.67", Os: "Windows", OsVersion: "10", DeviceType: SessionDeviceTypeDesktop},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_4_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4.1 Mobile/15E148 Safari/604.1",
+			SessionDevice{Browser: "Safari", BrowserVersion: "17.4.1", Os: "iOS", OsVersion: "17.4.1", DeviceType: SessionDeviceTypeMobile, DeviceModel: "iPhone"},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/AP1A.240405.002) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.6367.82 Mobile Safari/537.36",
+			SessionDevice{Browser: "Chrome", BrowserVersion: "124.0.6367.82", Os: "Android", OsVersion: "14", DeviceType: SessionDeviceTypeMobile, DeviceModel: "Pixel 8"},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:125.0) Gecko/20100101 Firefox/125.0",
+			SessionDevice{Browser: "Firefox", BrowserVersion: "125.0", Os: "macOS", OsVersion: "10.15", DeviceType: SessionDeviceTypeDesktop},
+		},
+		{
+			"curl/8.4.0",
+			SessionDevice{DeviceType: SessionDeviceTypeBot},
+		},
+	}
+
+	for _, scenario := range scenarios {
+		device := SessionDevice{}
+		device.parseUserAgent(scenario.userAgent)
+		assert.Equal(t, scenario.expected, device, scenario.userAgent)
+	}
+}
+
+func TestParseClientHints(t *testing.T) {
+	device := SessionDevice{}
+	device.parseUserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36")
+
+	header := http.Header{}
+	header.Set("Sec-CH-UA", `"Chromium";v="124", "Google Chrome";v="124", "Not-A.Brand";v="99"`)
+	header.Set("Sec-CH-UA-Platform", `"Windows"`)
+	header.Set("Sec-CH-UA-Platform-Version", `"15.0.0"`)
+	device.parseClientHints(header)
+
+	assert.Equal(t, "Chrome", device.Browser)
+	assert.Equal(t, "124", device.BrowserVersion)
+	assert.Equal(t, "Windows", device.Os)
+	assert.Equal(t, "11", device.OsVersion)
+	assert.Equal(t, SessionDeviceTypeDesktop, device.DeviceType)
+}
+
+func TestMergeSessionDevices(t *testing.T) {
+	session := &Session{
+		SessionId: []string{"s1", "s2"},
+		Devices:   map[string]*SessionDevice{"s1": {DisplayName: "Work laptop"}, "s0": {}},
+	}
+
+	session.mergeSessionDevices(map[string]*SessionDevice{"s1": {Browser: "Chrome"}, "s2": {Browser: "Safari"}})
+	assert.Equal(t, 2, len(session.Devices))
+	assert.Equal(t, "Work laptop", session.Devices["s1"].DisplayName)
+	assert.Equal(t, "Chrome", session.Devices["s1"].Browser)
+	assert.Equal(t, "Safari", session.Devices["s2"].Browser)
+}
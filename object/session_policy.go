@@ -0,0 +1,96 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// SessionPolicy limits the signin sessions of the application, the session ends after it has been idle for
+// the idle timeout or lived for the absolute lifetime in minutes, the oldest sessions of the user are signed out
+// when the user has more concurrent sessions than the limit. The limits of 0 are not enforced.
+type SessionPolicy struct {
+	IdleTimeout           int `json:"idleTimeout"`
+	AbsoluteLifetime      int `json:"absoluteLifetime"`
+	MaxConcurrentSessions int `json:"maxConcurrentSessions"`
+}
+
+func checkSessionPolicy(policy *SessionPolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	if policy.IdleTimeout < 0 || policy.AbsoluteLifetime < 0 || policy.MaxConcurrentSessions < 0 {
+		return fmt.Errorf("the limits of the session policy should not be negative")
+	}
+	return nil
+}
+
+// GetExpireTime caps the expire time (unix seconds) of the session by the absolute lifetime,
+// the expire time of 0 means the session doesn't expire by itself
+func (policy *SessionPolicy) GetExpireTime(expireTime int64, now time.Time) int64 {
+	if policy == nil || policy.AbsoluteLifetime == 0 {
+		return expireTime
+	}
+
+	lifetimeExpireTime := now.Add(time.Duration(policy.AbsoluteLifetime) * time.Minute).Unix()
+	if expireTime == 0 || lifetimeExpireTime < expireTime {
+		return lifetimeExpireTime
+	}
+	return expireTime
+}
+
+func (policy *SessionPolicy) GetIdleTimeout() time.Duration {
+	if policy == nil {
+		return 0
+	}
+	return time.Duration(policy.IdleTimeout) * time.Minute
+}
+
+// splitExtraSessionIds returns the session IDs to keep and the oldest ones beyond the max, the newest session IDs
+// are at the end, the current session is the newest one even if it was signed in again
+func splitExtraSessionIds(sessionIds []string, currentSessionId string, max int) ([]string, []string) {
+	sessionIds = append(util.DeleteVal(sessionIds, currentSessionId), currentSessionId)
+	if max <= 0 || len(sessionIds) <= max {
+		return sessionIds, []string{}
+	}
+	return sessionIds[len(sessionIds)-max:], sessionIds[:len(sessionIds)-max]
+}
+
+// LimitConcurrentSessions signs out the oldest sessions of the user in the application beyond the max
+func LimitConcurrentSessions(id string, currentSessionId string, max int) error {
+	if max <= 0 {
+		return nil
+	}
+
+	session, err := GetSingleSession(id)
+	if err != nil || session == nil {
+		return err
+	}
+
+	sessionIds, extraSessionIds := splitExtraSessionIds(session.SessionId, currentSessionId, max)
+	if len(extraSessionIds) == 0 {
+		return nil
+	}
+
+	DeleteBeegoSession(extraSessionIds)
+
+	session.SessionId = sessionIds
+	_, err = UpdateSession(id, session)
+	return err
+}
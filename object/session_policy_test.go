@@ -0,0 +1,55 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionPolicyGetExpireTime(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	policy := &SessionPolicy{AbsoluteLifetime: 15}
+
+	// the auto signin session doesn't expire by itself
+	assert.Equal(t, now.Add(15*time.Minute).Unix(), policy.GetExpireTime(0, now))
+	assert.Equal(t, now.Add(15*time.Minute).Unix(), policy.GetExpireTime(now.Add(24*time.Hour).Unix(), now))
+	assert.Equal(t, now.Add(10*time.Minute).Unix(), policy.GetExpireTime(now.Add(10*time.Minute).Unix(), now))
+
+	assert.Equal(t, int64(0), (&SessionPolicy{IdleTimeout: 15}).GetExpireTime(0, now))
+	assert.Equal(t, int64(0), (*SessionPolicy)(nil).GetExpireTime(0, now))
+}
+
+func TestSplitExtraSessionIds(t *testing.T) {
+	sessionIds, extraSessionIds := splitExtraSessionIds([]string{"s1", "s2", "s3"}, "s4", 2)
+	assert.Equal(t, []string{"s3", "s4"}, sessionIds)
+	assert.Equal(t, []string{"s1", "s2"}, extraSessionIds)
+
+	// the session signed in again is the newest one
+	sessionIds, extraSessionIds = splitExtraSessionIds([]string{"s1", "s2", "s3"}, "s1", 2)
+	assert.Equal(t, []string{"s3", "s1"}, sessionIds)
+	assert.Equal(t, []string{"s2"}, extraSessionIds)
+
+	_, extraSessionIds = splitExtraSessionIds([]string{"s1", "s2"}, "s2", 0)
+	assert.Empty(t, extraSessionIds)
+}
+
+func TestCheckSessionPolicy(t *testing.T) {
+	assert.Nil(t, checkSessionPolicy(nil))
+	assert.Nil(t, checkSessionPolicy(&SessionPolicy{IdleTimeout: 15, AbsoluteLifetime: 60, MaxConcurrentSessions: 1}))
+	assert.NotNil(t, checkSessionPolicy(&SessionPolicy{IdleTimeout: -1}))
+}
@@ -0,0 +1,280 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// Share is a grant made by an end user, who lets another user of the same organization
+// access a resource owned by the grantor. The grant is enforced by the policies
+// ( grantee, resource, action ) in the share enforcer of the organization.
+type Share struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Grantor    string   `xorm:"varchar(100) index" json:"grantor"`
+	Grantee    string   `xorm:"varchar(100) index" json:"grantee"`
+	Resource   string   `xorm:"varchar(255)" json:"resource"`
+	Actions    []string `xorm:"varchar(1000)" json:"actions"`
+	ExpireTime string   `xorm:"varchar(100)" json:"expireTime"`
+	Enforcer   string   `xorm:"varchar(100)" json:"enforcer"`
+}
+
+func GetOutgoingShares(grantor string) ([]*Share, error) {
+	shares := []*Share{}
+	err := ormer.Engine.Desc("created_time").Find(&shares, &Share{Grantor: grantor})
+	if err != nil {
+		return shares, err
+	}
+
+	return shares, nil
+}
+
+func GetIncomingShares(grantee string) ([]*Share, error) {
+	shares := []*Share{}
+	err := ormer.Engine.Desc("created_time").Find(&shares, &Share{Grantee: grantee})
+	if err != nil {
+		return shares, err
+	}
+
+	return shares, nil
+}
+
+func getShare(owner string, name string) (*Share, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	share := Share{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&share)
+	if err != nil {
+		return &share, err
+	}
+
+	if existed {
+		return &share, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetShare(id string) (*Share, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getShare(owner, name)
+}
+
+func (share *Share) GetId() string {
+	return fmt.Sprintf("%s/%s", share.Owner, share.Name)
+}
+
+func (share *Share) IsExpired() bool {
+	if share.ExpireTime == "" {
+		return false
+	}
+
+	expireTime, err := time.Parse(time.RFC3339, share.ExpireTime)
+	if err != nil {
+		return true
+	}
+	return time.Now().After(expireTime)
+}
+
+func (share *Share) getPolicies() [][]string {
+	policies := [][]string{}
+	for _, action := range share.Actions {
+		policies = append(policies, []string{share.Grantee, share.Resource, action})
+	}
+	return policies
+}
+
+func getShareEnforcerId(organizationName string) (string, error) {
+	organization, err := getOrganization("admin", organizationName)
+	if err != nil {
+		return "", err
+	}
+
+	if organization == nil || organization.ShareEnforcer == "" {
+		return "", fmt.Errorf("the sharing is not enabled for the organization: %s, please set its share enforcer first", organizationName)
+	}
+
+	return organization.ShareEnforcer, nil
+}
+
+// isResourceOwnedBy checks whether the user can share the resource, which is either
+// a Casdoor resource uploaded by the user or an object on which the user has the "own" action
+func isResourceOwnedBy(enforcer *Enforcer, resourceName string, user *User) (bool, error) {
+	resource, err := getResource(user.Owner, resourceName)
+	if err != nil {
+		return false, err
+	}
+
+	if resource != nil {
+		return resource.User == user.Name, nil
+	}
+
+	return enforcer.Enforce(user.GetId(), resourceName, "own")
+}
+
+func AddShare(share *Share, grantor *User) (bool, error) {
+	if share.Resource == "" || len(share.Actions) == 0 {
+		return false, fmt.Errorf("the resource and actions of the share should not be empty")
+	}
+
+	if util.ContainsString(share.Actions, "own") {
+		return false, fmt.Errorf("the action: own can't be shared")
+	}
+
+	if share.ExpireTime != "" {
+		if _, err := time.Parse(time.RFC3339, share.ExpireTime); err != nil {
+			return false, fmt.Errorf("the expire time: %s should be in RFC3339 format", share.ExpireTime)
+		}
+	}
+
+	grantee, err := GetUser(share.Grantee)
+	if err != nil {
+		return false, err
+	}
+
+	if grantee == nil || grantee.Owner != grantor.Owner {
+		return false, fmt.Errorf("the grantee: %s does not exist in the organization: %s", share.Grantee, grantor.Owner)
+	}
+
+	share.Owner = grantor.Owner
+	share.Name = util.GenerateId()
+	share.CreatedTime = util.GetCurrentTime()
+	share.Grantor = grantor.GetId()
+	share.Enforcer, err = getShareEnforcerId(grantor.Owner)
+	if err != nil {
+		return false, err
+	}
+
+	enforcer, err := GetInitializedEnforcer(share.Enforcer)
+	if err != nil {
+		return false, err
+	}
+
+	isOwned, err := isResourceOwnedBy(enforcer, share.Resource, grantor)
+	if err != nil {
+		return false, err
+	}
+
+	if !isOwned {
+		return false, fmt.Errorf("the resource: %s is not owned by the user: %s", share.Resource, grantor.GetId())
+	}
+
+	affected, err := ormer.Engine.Insert(share)
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 0 {
+		for _, policy := range share.getPolicies() {
+			_, err = enforcer.AddPolicy(policy)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return affected != 0, nil
+}
+
+// isPolicyGrantedByOtherShares returns true if the same permission is still granted by another valid share,
+// in which case the policy must be kept when this share is revoked.
+func isPolicyGrantedByOtherShares(share *Share, action string) (bool, error) {
+	shares := []*Share{}
+	err := ormer.Engine.Where("owner = ? and name != ? and grantee = ? and resource = ? and enforcer = ?",
+		share.Owner, share.Name, share.Grantee, share.Resource, share.Enforcer).Find(&shares)
+	if err != nil {
+		return false, err
+	}
+
+	for _, otherShare := range shares {
+		if !otherShare.IsExpired() && util.ContainsString(otherShare.Actions, action) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func DeleteShare(share *Share) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{share.Owner, share.Name}).Delete(&Share{})
+	if err != nil {
+		return false, err
+	}
+
+	if affected == 0 {
+		return false, nil
+	}
+
+	enforcer, err := GetInitializedEnforcer(share.Enforcer)
+	if err != nil {
+		return false, err
+	}
+
+	for _, action := range share.Actions {
+		isGranted, err := isPolicyGrantedByOtherShares(share, action)
+		if err != nil {
+			return false, err
+		}
+
+		if !isGranted {
+			_, err = enforcer.RemovePolicy(share.Grantee, share.Resource, action)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+
+	return true, nil
+}
+
+func deleteExpiredShares() error {
+	shares := []*Share{}
+	err := ormer.Engine.Where("expire_time != ?", "").Find(&shares)
+	if err != nil {
+		return err
+	}
+
+	for _, share := range shares {
+		if share.IsExpired() {
+			_, err = DeleteShare(share)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunShareExpirationJob revokes the expired shares every minute
+func RunShareExpirationJob() {
+	for {
+		err := deleteExpiredShares()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("share expiration failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
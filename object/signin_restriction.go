@@ -0,0 +1,178 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+)
+
+type SigninRestriction struct {
+	AllowedIpRanges  []string `json:"allowedIpRanges"`
+	DeniedIpRanges   []string `json:"deniedIpRanges"`
+	AllowedCountries []string `json:"allowedCountries"`
+	DeniedCountries  []string `json:"deniedCountries"`
+}
+
+func (restriction *SigninRestriction) isEmpty() bool {
+	return restriction == nil || (len(restriction.AllowedIpRanges) == 0 && len(restriction.DeniedIpRanges) == 0 &&
+		len(restriction.AllowedCountries) == 0 && len(restriction.DeniedCountries) == 0)
+}
+
+// isIpInRanges checks the IP against a list of CIDR ranges like "10.0.0.0/8", plain IPs are also accepted
+func isIpInRanges(ip string, ipRanges []string) bool {
+	parsedIp := net.ParseIP(ip)
+	if parsedIp == nil {
+		return false
+	}
+
+	for _, ipRange := range ipRanges {
+		ipRange = strings.TrimSpace(ipRange)
+		if _, ipNet, err := net.ParseCIDR(ipRange); err == nil {
+			if ipNet.Contains(parsedIp) {
+				return true
+			}
+		} else if rangeIp := net.ParseIP(ipRange); rangeIp != nil && rangeIp.Equal(parsedIp) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCountryInList(countryCode string, countryCodes []string) bool {
+	if countryCode == "" {
+		return false
+	}
+
+	for _, code := range countryCodes {
+		if strings.EqualFold(strings.TrimSpace(code), countryCode) {
+			return true
+		}
+	}
+	return false
+}
+
+// getDeniedReason returns why the client is not allowed to sign in, or "" if it is allowed.
+// The deny lists take precedence, and when any allow list is set the client must match one of them.
+func (restriction *SigninRestriction) getDeniedReason(clientIp string, countryCode string) string {
+	if restriction.isEmpty() {
+		return ""
+	}
+
+	if isIpInRanges(clientIp, restriction.DeniedIpRanges) {
+		return fmt.Sprintf("IP: %s is in the denied IP ranges", clientIp)
+	}
+	if isCountryInList(countryCode, restriction.DeniedCountries) {
+		return fmt.Sprintf("country: %s of IP: %s is in the denied countries", countryCode, clientIp)
+	}
+
+	if len(restriction.AllowedIpRanges) == 0 && len(restriction.AllowedCountries) == 0 {
+		return ""
+	}
+	if isIpInRanges(clientIp, restriction.AllowedIpRanges) || isCountryInList(countryCode, restriction.AllowedCountries) {
+		return ""
+	}
+
+	if countryCode == "" {
+		countryCode = "unknown"
+	}
+	return fmt.Sprintf("IP: %s (country: %s) is not in the allowed IP ranges or countries", clientIp, countryCode)
+}
+
+func addSigninDeniedRecord(organization string, username string, clientIp string, action string, reason string) {
+	record := &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: organization,
+		ClientIp:     clientIp,
+		User:         username,
+		Method:       "POST",
+		Action:       "signin-denied",
+		Object: util.StructToJson(map[string]string{
+			"action": action,
+			"reason": reason,
+		}),
+	}
+	util.SafeGoroutine(func() { AddRecord(record) })
+}
+
+// CheckSigninRestriction checks the client IP against the sign-in restrictions of the application and the user,
+// the user can be nil when it is not known yet. A denial record is added when the client is rejected.
+func CheckSigninRestriction(application *Application, user *User, clientIp string, action string, lang string) error {
+	var appRestriction, userRestriction *SigninRestriction
+	if application != nil {
+		appRestriction = application.SigninRestriction
+	}
+	if user != nil {
+		userRestriction = user.SigninRestriction
+	}
+
+	if appRestriction.isEmpty() && userRestriction.isEmpty() {
+		return nil
+	}
+
+	countryCode := GetCountryCodeByIp(clientIp)
+
+	reason := appRestriction.getDeniedReason(clientIp, countryCode)
+	if reason == "" {
+		reason = userRestriction.getDeniedReason(clientIp, countryCode)
+	}
+	if reason == "" {
+		return nil
+	}
+
+	organization, username := "", ""
+	if application != nil {
+		organization = application.Organization
+	}
+	if user != nil {
+		organization, username = user.Owner, user.Name
+	}
+	addSigninDeniedRecord(organization, username, clientIp, action, reason)
+
+	return fmt.Errorf(i18n.Translate(lang, "check:Sign-in is not allowed from your location: %s"), clientIp)
+}
+
+// checkTokenSigninRestriction revokes the issued token if the client is not allowed to sign in
+func checkTokenSigninRestriction(application *Application, token *Token, clientIp string, lang string) (*TokenError, error) {
+	var user *User
+	if token.User != "" {
+		var err error
+		user, err = getUser(token.Organization, token.User)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := CheckSigninRestriction(application, user, clientIp, "get-token", lang)
+	if err == nil {
+		return nil, nil
+	}
+
+	_, err2 := DeleteToken(token)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	return &TokenError{
+		Error:            InvalidGrant,
+		ErrorDescription: err.Error(),
+	}, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCountryCodeFromRanges(t *testing.T) {
+	ranges := []*geoIpRange{
+		{start: net.ParseIP("1.0.0.0").To16(), end: net.ParseIP("1.0.0.255").To16(), countryCode: "AU"},
+		{start: net.ParseIP("2.16.0.0").To16(), end: net.ParseIP("2.16.255.255").To16(), countryCode: "FR"},
+		{start: net.ParseIP("2001:200::").To16(), end: net.ParseIP("2001:200:ffff:ffff:ffff:ffff:ffff:ffff").To16(), countryCode: "JP"},
+	}
+
+	assert.Equal(t, "AU", getCountryCodeFromRanges(ranges, "1.0.0.8"))
+	assert.Equal(t, "FR", getCountryCodeFromRanges(ranges, "2.16.3.4"))
+	assert.Equal(t, "JP", getCountryCodeFromRanges(ranges, "2001:200::1"))
+	assert.Equal(t, "", getCountryCodeFromRanges(ranges, "1.0.1.0"))
+	assert.Equal(t, "", getCountryCodeFromRanges(ranges, "0.0.0.1"))
+	assert.Equal(t, "", getCountryCodeFromRanges(ranges, "invalid"))
+}
+
+func TestSigninRestrictionGetDeniedReason(t *testing.T) {
+	var restriction *SigninRestriction
+	assert.Equal(t, "", restriction.getDeniedReason("1.1.1.1", "AU"))
+
+	restriction = &SigninRestriction{
+		AllowedIpRanges:  []string{"10.0.0.0/8"},
+		AllowedCountries: []string{"DE"},
+		DeniedIpRanges:   []string{"10.1.0.0/16", "192.168.1.1"},
+		DeniedCountries:  []string{"KP"},
+	}
+
+	assert.Equal(t, "", restriction.getDeniedReason("10.2.3.4", ""))
+	assert.Equal(t, "", restriction.getDeniedReason("5.6.7.8", "de"))
+	assert.NotEqual(t, "", restriction.getDeniedReason("10.1.2.3", ""))
+	assert.NotEqual(t, "", restriction.getDeniedReason("192.168.1.1", "DE"))
+	assert.NotEqual(t, "", restriction.getDeniedReason("10.2.3.4", "KP"))
+	assert.NotEqual(t, "", restriction.getDeniedReason("5.6.7.8", "FR"))
+	assert.NotEqual(t, "", restriction.getDeniedReason("5.6.7.8", ""))
+
+	restriction = &SigninRestriction{DeniedCountries: []string{"KP"}}
+	assert.Equal(t, "", restriction.getDeniedReason("5.6.7.8", ""))
+	assert.NotEqual(t, "", restriction.getDeniedReason("5.6.7.8", "KP"))
+}
@@ -15,11 +15,16 @@
 package object
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/beego/beego/logs"
 	sender "github.com/casdoor/go-sms-sender"
 )
 
+const smsSendTimeout = 10 * time.Second
+
 func getSmsClient(provider *Provider) (sender.SmsClient, error) {
 	var client sender.SmsClient
 	var err error
@@ -64,3 +69,80 @@ func SendSms(provider *Provider, content string, phoneNumbers ...string) error {
 	err = client.SendMessage(params, phoneNumbers...)
 	return err
 }
+
+func sendSmsWithTimeout(provider *Provider, content string, phoneNumbers ...string) error {
+	// the SMS clients don't support the timeout, so the send is abandoned instead of being cancelled
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- SendSms(provider, content, append([]string{}, phoneNumbers...)...)
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-time.After(smsSendTimeout):
+		return fmt.Errorf("sending SMS with the provider: %s timed out", provider.GetId())
+	}
+}
+
+// getSmsProviderChain returns the SMS providers to try in order, which are the given provider
+// followed by the failover providers configured in the organization.
+func getSmsProviderChain(organization *Organization, provider *Provider) ([]*Provider, error) {
+	res := []*Provider{provider}
+	if organization == nil {
+		return res, nil
+	}
+
+	organization, err := GetInheritedOrganization(organization)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range organization.SmsProviders {
+		p, err := getOrganizationProvider(organization, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if p == nil || p.Category != "SMS" || p.GetId() == provider.GetId() {
+			continue
+		}
+
+		res = append(res, p)
+	}
+
+	return res, nil
+}
+
+// SendSmsWithFailover sends the SMS with the providers in the chain one by one until a provider succeeds,
+// it returns the provider that has sent the SMS. Every attempt is saved as SMS messages of the organization.
+func SendSmsWithFailover(organization *Organization, provider *Provider, content string, phoneNumbers ...string) (*Provider, error) {
+	providers, err := getSmsProviderChain(organization, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := provider.Owner
+	if organization != nil {
+		owner = organization.Name
+	}
+
+	errs := []string{}
+	for _, p := range providers {
+		sendErr := sendSmsWithTimeout(p, content, phoneNumbers...)
+
+		err = addSmsMessages(owner, p, phoneNumbers, sendErr)
+		if err != nil {
+			return nil, err
+		}
+
+		if sendErr == nil {
+			return p, nil
+		}
+
+		logs.Warning(fmt.Sprintf("failed to send SMS with the provider: %s, error: %s", p.GetId(), sendErr.Error()))
+		errs = append(errs, sendErr.Error())
+	}
+
+	return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+}
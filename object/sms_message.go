@@ -0,0 +1,258 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+	sender "github.com/casdoor/go-sms-sender"
+	"github.com/xorm-io/core"
+)
+
+const (
+	SmsMessageStateSent        = "Sent"
+	SmsMessageStateFailed      = "Failed"
+	SmsMessageStateDelivered   = "Delivered"
+	SmsMessageStateUndelivered = "Undelivered"
+)
+
+// SmsMessage is a send attempt of an SMS to a receiver with a provider, its state is updated
+// by the delivery receipts that the provider sends to /api/notify-sms.
+type SmsMessage struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	Provider string `xorm:"varchar(100) index" json:"provider"`
+	Receiver string `xorm:"varchar(100) index" json:"receiver"`
+	State    string `xorm:"varchar(100)" json:"state"`
+	Message  string `xorm:"varchar(1000)" json:"message"`
+}
+
+// SmsReceipt is the delivery result of an SMS reported by the provider
+type SmsReceipt struct {
+	Receiver    string
+	IsDelivered bool
+	Message     string
+}
+
+func GetSmsMessageCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&SmsMessage{})
+}
+
+func GetSmsMessages(owner string) ([]*SmsMessage, error) {
+	smsMessages := []*SmsMessage{}
+	err := ormer.Engine.Desc("created_time").Find(&smsMessages, &SmsMessage{Owner: owner})
+	if err != nil {
+		return smsMessages, err
+	}
+
+	return smsMessages, nil
+}
+
+func GetPaginationSmsMessages(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*SmsMessage, error) {
+	smsMessages := []*SmsMessage{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&smsMessages)
+	if err != nil {
+		return smsMessages, err
+	}
+
+	return smsMessages, nil
+}
+
+func getSmsMessage(owner string, name string) (*SmsMessage, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	smsMessage := SmsMessage{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&smsMessage)
+	if err != nil {
+		return &smsMessage, err
+	}
+
+	if existed {
+		return &smsMessage, nil
+	}
+
+	return nil, nil
+}
+
+func GetSmsMessage(id string) (*SmsMessage, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getSmsMessage(owner, name)
+}
+
+func addSmsMessages(owner string, provider *Provider, receivers []string, sendErr error) error {
+	for _, receiver := range receivers {
+		smsMessage := &SmsMessage{
+			Owner:       owner,
+			Name:        util.GenerateId(),
+			CreatedTime: util.GetCurrentTime(),
+			UpdatedTime: util.GetCurrentTime(),
+			Provider:    provider.GetId(),
+			Receiver:    receiver,
+			State:       SmsMessageStateSent,
+		}
+		if sendErr != nil {
+			smsMessage.State = SmsMessageStateFailed
+			smsMessage.Message = sendErr.Error()
+		}
+
+		_, err := ormer.Engine.Insert(smsMessage)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (smsMessage *SmsMessage) GetId() string {
+	return fmt.Sprintf("%s/%s", smsMessage.Owner, smsMessage.Name)
+}
+
+// parseSmsReceipts parses the delivery receipts in the format of the provider, the receipts of
+// the providers without a known format are expected as JSON objects like {"receiver": "+1...", "status": "delivered"}.
+func parseSmsReceipts(provider *Provider, body []byte) ([]*SmsReceipt, error) {
+	receipts := []*SmsReceipt{}
+	switch provider.Type {
+	case sender.Twilio:
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, err
+		}
+
+		status := values.Get("MessageStatus")
+		if status != "delivered" && status != "undelivered" && status != "failed" {
+			// queued, sending and sent are not final
+			return receipts, nil
+		}
+
+		receipts = append(receipts, &SmsReceipt{Receiver: values.Get("To"), IsDelivered: status == "delivered", Message: values.Get("ErrorCode")})
+	case sender.Aliyun:
+		var reports []struct {
+			PhoneNumber string `json:"phone_number"`
+			Success     bool   `json:"success"`
+			ErrMsg      string `json:"err_msg"`
+		}
+		err := json.Unmarshal(body, &reports)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, report := range reports {
+			receipts = append(receipts, &SmsReceipt{Receiver: report.PhoneNumber, IsDelivered: report.Success, Message: report.ErrMsg})
+		}
+	case sender.TencentCloud:
+		var reports []struct {
+			Mobile       string `json:"mobile"`
+			ReportStatus string `json:"report_status"`
+			Description  string `json:"description"`
+		}
+		err := json.Unmarshal(body, &reports)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, report := range reports {
+			receipts = append(receipts, &SmsReceipt{Receiver: report.Mobile, IsDelivered: report.ReportStatus == "SUCCESS", Message: report.Description})
+		}
+	default:
+		type smsReport struct {
+			Receiver string `json:"receiver"`
+			Status   string `json:"status"`
+			Message  string `json:"message"`
+		}
+
+		reports := []*smsReport{}
+		if strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+			err := json.Unmarshal(body, &reports)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			report := &smsReport{}
+			err := json.Unmarshal(body, report)
+			if err != nil {
+				return nil, err
+			}
+			reports = append(reports, report)
+		}
+
+		for _, report := range reports {
+			receipts = append(receipts, &SmsReceipt{Receiver: report.Receiver, IsDelivered: strings.EqualFold(report.Status, "delivered"), Message: report.Message})
+		}
+	}
+
+	return receipts, nil
+}
+
+func isSameReceiver(a string, b string) bool {
+	a = strings.TrimPrefix(a, "+")
+	b = strings.TrimPrefix(b, "+")
+	return a == b || strings.HasSuffix(a, b) || strings.HasSuffix(b, a)
+}
+
+// NotifySms updates the states of the latest sent messages of the provider with the delivery receipts in the body
+func NotifySms(provider *Provider, body []byte) (int, error) {
+	receipts, err := parseSmsReceipts(provider, body)
+	if err != nil {
+		return 0, err
+	}
+
+	smsMessages := []*SmsMessage{}
+	err = ormer.Engine.Desc("created_time").Where("provider = ? and state = ?", provider.GetId(), SmsMessageStateSent).Limit(1000).Find(&smsMessages)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, receipt := range receipts {
+		if receipt.Receiver == "" {
+			continue
+		}
+
+		for _, smsMessage := range smsMessages {
+			if smsMessage.State != SmsMessageStateSent || !isSameReceiver(smsMessage.Receiver, receipt.Receiver) {
+				continue
+			}
+
+			smsMessage.State = SmsMessageStateUndelivered
+			if receipt.IsDelivered {
+				smsMessage.State = SmsMessageStateDelivered
+			}
+			smsMessage.Message = receipt.Message
+			smsMessage.UpdatedTime = util.GetCurrentTime()
+
+			_, err = ormer.Engine.ID(core.PK{smsMessage.Owner, smsMessage.Name}).Cols("state", "message", "updated_time").Update(smsMessage)
+			if err != nil {
+				return count, err
+			}
+
+			count++
+			break
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	sender "github.com/casdoor/go-sms-sender"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSmsReceipts(t *testing.T) {
+	receipts, err := parseSmsReceipts(&Provider{Type: sender.Twilio}, []byte("MessageSid=SM1&MessageStatus=delivered&To=%2B15551234567"))
+	assert.Nil(t, err)
+	assert.Equal(t, []*SmsReceipt{{Receiver: "+15551234567", IsDelivered: true}}, receipts)
+
+	receipts, err = parseSmsReceipts(&Provider{Type: sender.Twilio}, []byte("MessageSid=SM1&MessageStatus=sent&To=%2B15551234567"))
+	assert.Nil(t, err)
+	assert.Empty(t, receipts)
+
+	receipts, err = parseSmsReceipts(&Provider{Type: sender.Aliyun}, []byte(`[{"phone_number":"13800000000","success":false,"err_msg":"MOBILE_NOT_ON_SERVICE"}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, []*SmsReceipt{{Receiver: "13800000000", IsDelivered: false, Message: "MOBILE_NOT_ON_SERVICE"}}, receipts)
+
+	receipts, err = parseSmsReceipts(&Provider{Type: sender.TencentCloud}, []byte(`[{"mobile":"13800000000","report_status":"SUCCESS"}]`))
+	assert.Nil(t, err)
+	assert.Equal(t, []*SmsReceipt{{Receiver: "13800000000", IsDelivered: true}}, receipts)
+
+	receipts, err = parseSmsReceipts(&Provider{Type: "Custom HTTP SMS"}, []byte(`{"receiver":"+15551234567","status":"Delivered"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []*SmsReceipt{{Receiver: "+15551234567", IsDelivered: true}}, receipts)
+
+	_, err = parseSmsReceipts(&Provider{Type: sender.Aliyun}, []byte("invalid"))
+	assert.NotNil(t, err)
+}
+
+func TestIsSameReceiver(t *testing.T) {
+	assert.True(t, isSameReceiver("+8613800000000", "13800000000"))
+	assert.True(t, isSameReceiver("+15551234567", "15551234567"))
+	assert.False(t, isSameReceiver("+15551234567", "+15557654321"))
+}
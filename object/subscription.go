@@ -34,6 +34,9 @@ const (
 	SubStateActive   SubscriptionState = "Active"
 	SubStateUpcoming SubscriptionState = "Upcoming"
 	SubStateExpired  SubscriptionState = "Expired"
+
+	SubStatePastDue  SubscriptionState = "PastDue" // the renewal payment failed, waiting for the retries
+	SubStateCanceled SubscriptionState = "Canceled"
 )
 
 type Subscription struct {
@@ -52,6 +55,15 @@ type Subscription struct {
 	EndTime   time.Time         `json:"endTime"`
 	Period    string            `xorm:"varchar(100)" json:"period"`
 	State     SubscriptionState `xorm:"varchar(100)" json:"state"`
+
+	// the recurring subscription of the payment provider, kept in sync by its webhooks
+	Provider      string    `xorm:"varchar(100)" json:"provider"`
+	ExternalId    string    `xorm:"varchar(100)" json:"externalId"`
+	LastEventId   string    `xorm:"varchar(100)" json:"lastEventId"`
+	FailedInvoice string    `xorm:"varchar(100)" json:"failedInvoice"`
+	PastDueTime   time.Time `json:"pastDueTime"`
+	RetryTimes    int       `json:"retryTimes"`
+	NextRetryTime time.Time `json:"nextRetryTime"`
 }
 
 func (sub *Subscription) GetId() string {
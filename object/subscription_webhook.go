@@ -0,0 +1,283 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/pp"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+)
+
+// the record actions of the subscription changes, which the webhooks can subscribe to
+const (
+	SubscriptionActionRenewed       = "subscription-renewed"
+	SubscriptionActionPaymentFailed = "subscription-payment-failed"
+	SubscriptionActionRetried       = "subscription-payment-retried"
+	SubscriptionActionCanceled      = "subscription-canceled"
+)
+
+func getSubscriptionProvider(owner string, providerName string) (*Provider, pp.SubscriptionProvider, error) {
+	provider, err := getProvider(owner, providerName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if provider == nil {
+		return nil, nil, fmt.Errorf("the payment provider: %s does not exist", providerName)
+	}
+
+	paymentProvider, err := GetPaymentProvider(provider)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	subscriptionProvider, ok := paymentProvider.(pp.SubscriptionProvider)
+	if !ok {
+		return nil, nil, fmt.Errorf("the payment provider: %s doesn't support the subscriptions", providerName)
+	}
+	return provider, subscriptionProvider, nil
+}
+
+// getSubscriptionByEvent returns the subscription by the reference of the event, or by the subscription id of the
+// provider for the subscriptions linked before
+func getSubscriptionByEvent(owner string, providerName string, event *pp.SubscriptionEvent) (*Subscription, error) {
+	if event.Reference != "" {
+		referenceOwner, referenceName := util.GetOwnerAndNameFromIdNoCheck(event.Reference)
+		if referenceOwner == owner {
+			subscription, err := getSubscription(owner, referenceName)
+			if err != nil || subscription != nil {
+				return subscription, err
+			}
+		}
+	}
+
+	if event.SubscriptionId == "" {
+		return nil, nil
+	}
+
+	subscription := Subscription{Owner: owner, Provider: providerName, ExternalId: event.SubscriptionId}
+	existed, err := ormer.Engine.Get(&subscription)
+	if err != nil {
+		return nil, err
+	}
+	if !existed {
+		return nil, nil
+	}
+	return &subscription, nil
+}
+
+func addSubscriptionPeriod(t time.Time, period string) time.Time {
+	if period == PeriodYearly {
+		return t.AddDate(1, 0, 0)
+	}
+	return t.AddDate(0, 1, 0)
+}
+
+// failSubscriptionPayment moves the subscription into the dunning and schedules the next retry of the plan, the
+// subscription is canceled if the payment fails after the last retry
+func failSubscriptionPayment(subscription *Subscription, plan *Plan, now time.Time) {
+	var schedule []int
+	if plan != nil {
+		schedule = plan.DunningSchedule
+	}
+
+	subscription.NextRetryTime = time.Time{}
+	if subscription.State != SubStatePastDue {
+		subscription.State = SubStatePastDue
+		subscription.PastDueTime = now
+		subscription.RetryTimes = 0
+	} else if len(schedule) != 0 && subscription.RetryTimes >= len(schedule) {
+		subscription.State = SubStateCanceled
+		subscription.Description = fmt.Sprintf("the payment still failed after %d retries", subscription.RetryTimes)
+		return
+	}
+
+	if subscription.RetryTimes < len(schedule) {
+		subscription.NextRetryTime = subscription.PastDueTime.AddDate(0, 0, schedule[subscription.RetryTimes])
+	}
+}
+
+// applySubscriptionEvent updates the subscription by the event of the provider, the record action is returned
+func applySubscriptionEvent(subscription *Subscription, plan *Plan, event *pp.SubscriptionEvent, now time.Time) string {
+	subscription.ExternalId = event.SubscriptionId
+	subscription.LastEventId = event.Id
+
+	switch event.Type {
+	case pp.SubscriptionEventRenewed:
+		subscription.State = SubStateActive
+		if !event.PeriodEnd.IsZero() {
+			subscription.EndTime = event.PeriodEnd
+		} else if subscription.EndTime.Before(now) {
+			subscription.EndTime = addSubscriptionPeriod(now, subscription.Period)
+		} else {
+			subscription.EndTime = addSubscriptionPeriod(subscription.EndTime, subscription.Period)
+		}
+
+		subscription.FailedInvoice = ""
+		subscription.PastDueTime = time.Time{}
+		subscription.RetryTimes = 0
+		subscription.NextRetryTime = time.Time{}
+		return SubscriptionActionRenewed
+	case pp.SubscriptionEventPaymentFailed:
+		subscription.FailedInvoice = event.InvoiceId
+		subscription.Description = event.Message
+		failSubscriptionPayment(subscription, plan, now)
+		if subscription.State == SubStateCanceled {
+			return SubscriptionActionCanceled
+		}
+		return SubscriptionActionPaymentFailed
+	default:
+		subscription.State = SubStateCanceled
+		subscription.Description = event.Message
+		subscription.NextRetryTime = time.Time{}
+		return SubscriptionActionCanceled
+	}
+}
+
+func addSubscriptionRecord(subscription *Subscription, action string) {
+	record := &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: subscription.Owner,
+		User:         subscription.User,
+		Method:       "POST",
+		Action:       action,
+		Object:       util.StructToJson(subscription),
+	}
+	util.SafeGoroutine(func() { AddRecord(record) })
+}
+
+// NotifySubscription handles the webhook of the payment provider for the renewal, failed payment or cancellation of
+// a recurring subscription, nil is returned for the other events
+func NotifySubscription(owner string, providerName string, header http.Header, body []byte) (*Subscription, error) {
+	provider, subscriptionProvider, err := getSubscriptionProvider(owner, providerName)
+	if err != nil {
+		return nil, err
+	}
+	if provider.WebhookSecret == "" {
+		return nil, fmt.Errorf("the webhook secret of the payment provider: %s is empty", providerName)
+	}
+
+	event, err := subscriptionProvider.ParseSubscriptionEvent(header, body, provider.WebhookSecret)
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return nil, nil
+	}
+
+	subscription, err := getSubscriptionByEvent(owner, provider.Name, event)
+	if err != nil {
+		return nil, err
+	}
+	if subscription == nil {
+		return nil, fmt.Errorf("the subscription of the payment provider: %s with the id: %s does not exist", providerName, event.SubscriptionId)
+	}
+
+	// the providers may deliver an event more than once
+	if event.Id != "" && subscription.LastEventId == event.Id {
+		return subscription, nil
+	}
+
+	plan, err := getPlan(owner, subscription.Plan)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.Provider = provider.Name
+	action := applySubscriptionEvent(subscription, plan, event, time.Now())
+	_, err = UpdateSubscription(subscription.GetId(), subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	addSubscriptionRecord(subscription, action)
+	return subscription, nil
+}
+
+func retrySubscriptionPayment(subscription *Subscription, now time.Time) error {
+	plan, err := getPlan(subscription.Owner, subscription.Plan)
+	if err != nil {
+		return err
+	}
+
+	subscription.RetryTimes++
+	subscription.NextRetryTime = time.Time{}
+
+	// the renewal or another failure is reported by the webhook later if the retry is accepted
+	action := SubscriptionActionRetried
+	_, subscriptionProvider, err := getSubscriptionProvider(subscription.Owner, subscription.Provider)
+	if err == nil {
+		var price float64
+		var currency string
+		if plan != nil {
+			price, currency = plan.Price, plan.Currency
+		}
+		err = subscriptionProvider.RetrySubscriptionPayment(subscription.ExternalId, subscription.FailedInvoice, price, currency)
+	}
+	if err != nil {
+		subscription.Description = err.Error()
+		failSubscriptionPayment(subscription, plan, now)
+		action = SubscriptionActionPaymentFailed
+		if subscription.State == SubStateCanceled {
+			action = SubscriptionActionCanceled
+		}
+	}
+
+	_, err = UpdateSubscription(subscription.GetId(), subscription)
+	if err != nil {
+		return err
+	}
+
+	addSubscriptionRecord(subscription, action)
+	return nil
+}
+
+func runSubscriptionDunning() error {
+	subscriptions := []*Subscription{}
+	err := ormer.Engine.Where("state = ?", SubStatePastDue).Find(&subscriptions)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, subscription := range subscriptions {
+		if subscription.NextRetryTime.IsZero() || subscription.NextRetryTime.After(now) {
+			continue
+		}
+
+		err = retrySubscriptionPayment(subscription, now)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to retry the payment of the subscription: %s, error: %s", subscription.GetId(), err.Error()))
+		}
+	}
+	return nil
+}
+
+// RunSubscriptionDunningJob retries the failed renewal payments of the subscriptions by the dunning schedules
+func RunSubscriptionDunningJob() {
+	for {
+		err := runSubscriptionDunning()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("subscription dunning failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Hour)
+	}
+}
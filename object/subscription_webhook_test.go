@@ -0,0 +1,96 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casdoor/casdoor/pp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySubscriptionRenewal(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	subscription := &Subscription{
+		State:      SubStatePastDue,
+		Period:     PeriodMonthly,
+		EndTime:    now.AddDate(0, 0, -3),
+		RetryTimes: 1,
+	}
+
+	action := applySubscriptionEvent(subscription, nil, &pp.SubscriptionEvent{Id: "evt_1", Type: pp.SubscriptionEventRenewed, SubscriptionId: "sub_1"}, now)
+	assert.Equal(t, SubscriptionActionRenewed, action)
+	assert.Equal(t, SubStateActive, subscription.State)
+	assert.Equal(t, now.AddDate(0, 1, 0), subscription.EndTime)
+	assert.Equal(t, "sub_1", subscription.ExternalId)
+	assert.Equal(t, "evt_1", subscription.LastEventId)
+	assert.Equal(t, 0, subscription.RetryTimes)
+
+	// the period reported by the provider is used as is
+	periodEnd := now.AddDate(0, 2, 0)
+	applySubscriptionEvent(subscription, nil, &pp.SubscriptionEvent{Type: pp.SubscriptionEventRenewed, PeriodEnd: periodEnd}, now)
+	assert.Equal(t, periodEnd, subscription.EndTime)
+
+	subscription.EndTime = now.AddDate(0, 0, 3)
+	applySubscriptionEvent(subscription, nil, &pp.SubscriptionEvent{Type: pp.SubscriptionEventRenewed}, now)
+	assert.Equal(t, now.AddDate(0, 1, 3), subscription.EndTime)
+}
+
+func TestApplySubscriptionPaymentFailure(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	plan := &Plan{DunningSchedule: []int{1, 3}}
+	subscription := &Subscription{State: SubStateActive}
+	event := &pp.SubscriptionEvent{Type: pp.SubscriptionEventPaymentFailed, InvoiceId: "in_1"}
+
+	assert.Equal(t, SubscriptionActionPaymentFailed, applySubscriptionEvent(subscription, plan, event, now))
+	assert.Equal(t, SubStatePastDue, subscription.State)
+	assert.Equal(t, "in_1", subscription.FailedInvoice)
+	assert.Equal(t, now, subscription.PastDueTime)
+	assert.Equal(t, now.AddDate(0, 0, 1), subscription.NextRetryTime)
+
+	// the retries are scheduled from the first failure
+	subscription.RetryTimes = 1
+	failSubscriptionPayment(subscription, plan, now.AddDate(0, 0, 1))
+	assert.Equal(t, SubStatePastDue, subscription.State)
+	assert.Equal(t, now.AddDate(0, 0, 3), subscription.NextRetryTime)
+
+	subscription.RetryTimes = 2
+	assert.Equal(t, SubscriptionActionCanceled, applySubscriptionEvent(subscription, plan, event, now.AddDate(0, 0, 3)))
+	assert.Equal(t, SubStateCanceled, subscription.State)
+	assert.True(t, subscription.NextRetryTime.IsZero())
+
+	// without the dunning schedule the provider decides when to cancel
+	subscription = &Subscription{State: SubStatePastDue}
+	failSubscriptionPayment(subscription, nil, now)
+	assert.Equal(t, SubStatePastDue, subscription.State)
+	assert.True(t, subscription.NextRetryTime.IsZero())
+}
+
+func TestApplySubscriptionCancellation(t *testing.T) {
+	subscription := &Subscription{State: SubStatePastDue, NextRetryTime: time.Now()}
+	action := applySubscriptionEvent(subscription, nil, &pp.SubscriptionEvent{Type: pp.SubscriptionEventCanceled, Message: "cancellation_requested"}, time.Now())
+	assert.Equal(t, SubscriptionActionCanceled, action)
+	assert.Equal(t, SubStateCanceled, subscription.State)
+	assert.Equal(t, "cancellation_requested", subscription.Description)
+	assert.True(t, subscription.NextRetryTime.IsZero())
+}
+
+func TestCheckDunningSchedule(t *testing.T) {
+	assert.Nil(t, checkDunningSchedule(nil))
+	assert.Nil(t, checkDunningSchedule([]int{1, 3, 7}))
+	assert.NotNil(t, checkDunningSchedule([]int{3, 1}))
+	assert.NotNil(t, checkDunningSchedule([]int{-1}))
+}
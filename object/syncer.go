@@ -21,6 +21,16 @@ import (
 	"github.com/xorm-io/core"
 )
 
+const (
+	SyncerDirectionBoth = "Both"
+	SyncerDirectionPull = "Pull"
+	SyncerDirectionPush = "Push"
+
+	SyncerConflictRuleSourceWins = "SourceWins"
+	SyncerConflictRuleTargetWins = "TargetWins"
+	SyncerConflictRuleNewestWins = "NewestWins"
+)
+
 type TableColumn struct {
 	Name        string   `json:"name"`
 	Type        string   `json:"type"`
@@ -28,6 +38,11 @@ type TableColumn struct {
 	IsKey       bool     `json:"isKey"`
 	IsHashed    bool     `json:"isHashed"`
 	Values      []string `json:"values"`
+
+	// Direction is "Both" (default), "Pull" (from the original database to Casdoor) or "Push" (from Casdoor to the original database)
+	Direction string `json:"direction"`
+	// ConflictRule overrides the conflict rule of the syncer for this column
+	ConflictRule string `json:"conflictRule"`
 }
 
 type Syncer struct {
@@ -53,6 +68,7 @@ type Syncer struct {
 	SyncInterval     int            `json:"syncInterval"`
 	IsReadOnly       bool           `json:"isReadOnly"`
 	IsEnabled        bool           `json:"isEnabled"`
+	ConflictRule     string         `xorm:"varchar(100)" json:"conflictRule"`
 
 	Ormer *Ormer `xorm:"-" json:"-"`
 }
@@ -205,6 +221,11 @@ func DeleteSyncer(syncer *Syncer) (bool, error) {
 
 	if affected == 1 {
 		deleteSyncerJob(syncer)
+
+		err = deleteSyncerUserStates(syncer)
+		if err != nil {
+			return false, err
+		}
 	}
 
 	return affected != 0, nil
@@ -258,11 +279,21 @@ func (syncer *Syncer) getKey() string {
 	return util.CamelToSnakeCase(column.CasdoorName)
 }
 
-func RunSyncer(syncer *Syncer) error {
+func RunSyncer(syncer *Syncer) (*SyncerReport, error) {
+	err := syncer.initAdapter()
+	if err != nil {
+		return nil, err
+	}
+
+	return syncer.syncUsersWithReport(false)
+}
+
+// DryRunSyncer reports the changes that the syncer would make without applying them
+func DryRunSyncer(syncer *Syncer) (*SyncerReport, error) {
 	err := syncer.initAdapter()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return syncer.syncUsers()
+	return syncer.syncUsersWithReport(true)
 }
@@ -0,0 +1,65 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// SyncerUserState keeps the hashes of the field values of a user at the last sync,
+// so that the syncer can tell which side has changed a field since then.
+type SyncerUserState struct {
+	Syncer      string `xorm:"varchar(100) notnull pk" json:"syncer"`
+	Name        string `xorm:"varchar(255) notnull pk" json:"name"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	FieldHashes map[string]string `xorm:"mediumtext" json:"fieldHashes"`
+}
+
+func getSyncerUserStates(syncer *Syncer) (map[string]*SyncerUserState, error) {
+	states := []*SyncerUserState{}
+	err := ormer.Engine.Find(&states, &SyncerUserState{Syncer: syncer.GetId()})
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[string]*SyncerUserState{}
+	for _, state := range states {
+		m[state.Name] = state
+	}
+	return m, nil
+}
+
+func saveSyncerUserState(state *SyncerUserState) error {
+	state.UpdatedTime = util.GetCurrentTime()
+
+	existed, err := ormer.Engine.Exist(&SyncerUserState{Syncer: state.Syncer, Name: state.Name})
+	if err != nil {
+		return err
+	}
+
+	if existed {
+		_, err = ormer.Engine.ID(core.PK{state.Syncer, state.Name}).AllCols().Update(state)
+	} else {
+		_, err = ormer.Engine.Insert(state)
+	}
+	return err
+}
+
+func deleteSyncerUserStates(syncer *Syncer) error {
+	_, err := ormer.Engine.Delete(&SyncerUserState{Syncer: syncer.GetId()})
+	return err
+}
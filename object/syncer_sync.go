@@ -16,37 +16,237 @@ package object
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
 )
 
-func (syncer *Syncer) syncUsers() error {
+type SyncerChange struct {
+	Key        string `json:"key"`
+	Action     string `json:"action"`
+	Direction  string `json:"direction"`
+	Field      string `json:"field"`
+	OldValue   string `json:"oldValue"`
+	NewValue   string `json:"newValue"`
+	IsConflict bool   `json:"isConflict"`
+}
+
+type SyncerReport struct {
+	Syncer   string          `json:"syncer"`
+	IsDryRun bool            `json:"isDryRun"`
+	Changes  []*SyncerChange `json:"changes"`
+}
+
+var syncerMaskedColumns = []string{"Password", "PasswordSalt", "TotpSecret"}
+
+func (tableColumn *TableColumn) getDirection() string {
+	// a column combined from several original columns like "first_name+last_name" can't be written back
+	if strings.Contains(tableColumn.Name, "+") {
+		return SyncerDirectionPull
+	}
+
+	if tableColumn.Direction == "" {
+		return SyncerDirectionBoth
+	}
+	return tableColumn.Direction
+}
+
+func (syncer *Syncer) getConflictRule(tableColumn *TableColumn) string {
+	if tableColumn.ConflictRule != "" {
+		return tableColumn.ConflictRule
+	}
+	if syncer.ConflictRule != "" {
+		return syncer.ConflictRule
+	}
+	return SyncerConflictRuleSourceWins
+}
+
+func isNewerTime(time1 string, time2 string) bool {
+	t1, err := time.Parse(time.RFC3339, time1)
+	if err != nil {
+		return false
+	}
+
+	t2, err := time.Parse(time.RFC3339, time2)
+	if err != nil {
+		return true
+	}
+
+	return t1.After(t2)
+}
+
+// getFieldDirection decides how a field whose values differ should be synced. The side that has changed
+// the field since the last sync wins, and the conflict rule is applied when both sides have changed it.
+func (syncer *Syncer) getFieldDirection(tableColumn *TableColumn, baseHash string, value string, oValue string, user *User, oUser *OriginalUser) (string, bool) {
+	direction := tableColumn.getDirection()
+	if direction != SyncerDirectionBoth {
+		return direction, false
+	}
+
+	isChanged := baseHash == "" || util.GetMd5Hash(value) != baseHash
+	isOChanged := baseHash == "" || util.GetMd5Hash(oValue) != baseHash
+	if isOChanged && !isChanged {
+		return SyncerDirectionPull, false
+	} else if isChanged && !isOChanged {
+		return SyncerDirectionPush, false
+	}
+
+	switch syncer.getConflictRule(tableColumn) {
+	case SyncerConflictRuleTargetWins:
+		return SyncerDirectionPush, true
+	case SyncerConflictRuleNewestWins:
+		if isNewerTime(user.UpdatedTime, oUser.UpdatedTime) {
+			return SyncerDirectionPush, true
+		}
+		return SyncerDirectionPull, true
+	default:
+		return SyncerDirectionPull, true
+	}
+}
+
+func (syncer *Syncer) getMaskedValue(tableColumn *TableColumn, value string) string {
+	if value != "" && util.InSlice(syncerMaskedColumns, tableColumn.CasdoorName) {
+		return "***"
+	}
+	return value
+}
+
+func (syncer *Syncer) getFieldHashes(m map[string]string) map[string]string {
+	res := map[string]string{}
+	for name, value := range m {
+		res[name] = util.GetMd5Hash(value)
+	}
+	return res
+}
+
+// syncUser syncs the fields of a user that exists on both sides, and returns the changes
+func (syncer *Syncer) syncUser(primary string, user *User, oUser *OriginalUser, state *SyncerUserState, affiliationMap map[int]string, isDryRun bool) ([]*SyncerChange, error) {
+	keyColumn := syncer.getKeyColumn()
+	m := syncer.getMapFromOriginalUser(syncer.createOriginalUserFromUser(user))
+	oM := syncer.getMapFromOriginalUser(oUser)
+
+	changes := []*SyncerChange{}
+	pulledColumns := []string{}
+	pushedMap := map[string]string{}
+	updatedUser := *user
+	for _, tableColumn := range syncer.TableColumns {
+		if tableColumn == keyColumn || tableColumn.CasdoorName == "Id" {
+			continue
+		}
+
+		value, oValue := m[tableColumn.Name], oM[tableColumn.Name]
+		if value == oValue {
+			continue
+		}
+
+		direction, isConflict := syncer.getFieldDirection(tableColumn, state.FieldHashes[tableColumn.Name], value, oValue, user, oUser)
+		if direction == SyncerDirectionPush && syncer.IsReadOnly {
+			continue
+		}
+
+		change := &SyncerChange{
+			Key:        primary,
+			Action:     "update",
+			Direction:  strings.ToLower(direction),
+			Field:      tableColumn.CasdoorName,
+			IsConflict: isConflict,
+		}
+		if direction == SyncerDirectionPull {
+			change.OldValue, change.NewValue = syncer.getMaskedValue(tableColumn, value), syncer.getMaskedValue(tableColumn, oValue)
+			syncer.setUserByKeyValue(&updatedUser, tableColumn.CasdoorName, oValue)
+			pulledColumns = append(pulledColumns, util.CamelToSnakeCase(tableColumn.CasdoorName))
+			m[tableColumn.Name] = oValue
+
+			if tableColumn.CasdoorName == "Avatar" {
+				updatedUser.Avatar = syncer.getFullAvatarUrl(updatedUser.Avatar)
+			} else if tableColumn.CasdoorName == "Score" && affiliationMap != nil {
+				updatedUser.Affiliation = affiliationMap[updatedUser.Score]
+				pulledColumns = append(pulledColumns, "affiliation")
+			}
+		} else {
+			change.OldValue, change.NewValue = syncer.getMaskedValue(tableColumn, oValue), syncer.getMaskedValue(tableColumn, value)
+			pushedMap[tableColumn.Name] = value
+			oM[tableColumn.Name] = value
+		}
+		changes = append(changes, change)
+	}
+
+	if isDryRun {
+		return changes, nil
+	}
+
+	if len(pulledColumns) != 0 {
+		if util.InSlice(pulledColumns, "avatar") && updatedUser.Avatar != "" {
+			var err error
+			updatedUser.PermanentAvatar, err = getPermanentAvatarUrl(updatedUser.Owner, updatedUser.Name, updatedUser.Avatar, true)
+			if err != nil {
+				return nil, err
+			}
+			pulledColumns = append(pulledColumns, "permanent_avatar")
+		}
+
+		err := updatedUser.encryptSensitiveColumns(pulledColumns)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = ormer.Engine.ID(core.PK{user.Owner, user.Name}).Cols(pulledColumns...).Update(&updatedUser)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pushedMap) != 0 {
+		_, err := syncer.Ormer.Engine.Table(syncer.getTable()).Where(fmt.Sprintf("%s = ?", keyColumn.Name), oM[keyColumn.Name]).Update(&pushedMap)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// both sides have the same values now
+	state.FieldHashes = syncer.getFieldHashes(m)
+	err := saveSyncerUserState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (syncer *Syncer) logSyncError(err error) {
+	line := fmt.Sprintf("[%s] %s\n", util.GetCurrentTime(), err.Error())
+	_, err2 := updateSyncerErrorText(syncer, line)
+	if err2 != nil {
+		panic(err2)
+	}
+}
+
+// syncUsersWithReport syncs the users between Casdoor and the original database field by field.
+// In dry-run mode, nothing is written and the report tells the changes that would be made.
+func (syncer *Syncer) syncUsersWithReport(isDryRun bool) (*SyncerReport, error) {
 	if len(syncer.TableColumns) == 0 {
-		return fmt.Errorf("The syncer table columns should not be empty")
+		return nil, fmt.Errorf("The syncer table columns should not be empty")
 	}
 
 	fmt.Printf("Running syncUsers()..\n")
 
 	users, err := GetUsers(syncer.Organization)
 	if err != nil {
-		line := fmt.Sprintf("[%s] %s\n", util.GetCurrentTime(), err.Error())
-		_, err2 := updateSyncerErrorText(syncer, line)
-		if err2 != nil {
-			panic(err2)
-		}
-
-		return err
+		syncer.logSyncError(err)
+		return nil, err
 	}
 
 	oUsers, err := syncer.getOriginalUsers()
 	if err != nil {
-		line := fmt.Sprintf("[%s] %s\n", util.GetCurrentTime(), err.Error())
-		_, err2 := updateSyncerErrorText(syncer, line)
-		if err2 != nil {
-			panic(err2)
-		}
+		syncer.logSyncError(err)
+		return nil, err
+	}
 
-		return err
+	states, err := getSyncerUserStates(syncer)
+	if err != nil {
+		return nil, err
 	}
 
 	fmt.Printf("Users: %d, oUsers: %d\n", len(users), len(oUsers))
@@ -68,92 +268,90 @@ func (syncer *Syncer) syncUsers() error {
 		myOUsers[syncer.getUserValue(m, key)] = m
 	}
 
+	report := &SyncerReport{
+		Syncer:   syncer.GetId(),
+		IsDryRun: isDryRun,
+		Changes:  []*SyncerChange{},
+	}
+
 	newUsers := []*User{}
+	newStates := []*SyncerUserState{}
 	for _, oUser := range oUsers {
 		primary := syncer.getUserValue(oUser, key)
 
-		if _, ok := myUsers[primary]; !ok {
+		if user, ok := myUsers[primary]; !ok {
 			newUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
 			fmt.Printf("New user: %v\n", newUser)
 			newUsers = append(newUsers, newUser)
+			newStates = append(newStates, &SyncerUserState{
+				Syncer:      syncer.GetId(),
+				Name:        primary,
+				FieldHashes: syncer.getFieldHashes(syncer.getMapFromOriginalUser(oUser)),
+			})
+			report.Changes = append(report.Changes, &SyncerChange{Key: primary, Action: "add", Direction: "pull"})
 		} else {
-			user := myUsers[primary]
-			oHash := syncer.calculateHash(oUser)
-			if user.Hash == user.PreHash {
-				if user.Hash != oHash {
-					updatedUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
-					updatedUser.Hash = oHash
-					updatedUser.PreHash = oHash
-
-					fmt.Printf("Update from oUser to user: %v\n", updatedUser)
-					_, err = syncer.updateUserForOriginalFields(updatedUser, key)
-					if err != nil {
-						return err
-					}
-				}
-			} else {
-				if user.PreHash == oHash {
-					if !syncer.IsReadOnly {
-						updatedOUser := syncer.createOriginalUserFromUser(user)
-
-						fmt.Printf("Update from user to oUser: %v\n", updatedOUser)
-						_, err = syncer.updateUser(updatedOUser)
-						if err != nil {
-							return err
-						}
-					}
-
-					// update preHash
-					user.PreHash = user.Hash
-					_, err = SetUserField(user, "pre_hash", user.PreHash)
-					if err != nil {
-						return err
-					}
-				} else {
-					if user.Hash == oHash {
-						// update preHash
-						user.PreHash = user.Hash
-						_, err = SetUserField(user, "pre_hash", user.PreHash)
-						if err != nil {
-							return err
-						}
-					} else {
-						updatedUser := syncer.createUserFromOriginalUser(oUser, affiliationMap)
-						updatedUser.Hash = oHash
-						updatedUser.PreHash = oHash
-
-						fmt.Printf("Update from oUser to user (2nd condition): %v\n", updatedUser)
-						_, err = syncer.updateUserForOriginalFields(updatedUser, key)
-						if err != nil {
-							return err
-						}
-					}
-				}
+			state, ok := states[primary]
+			if !ok {
+				state = &SyncerUserState{Syncer: syncer.GetId(), Name: primary, FieldHashes: map[string]string{}}
 			}
+
+			changes, err := syncer.syncUser(primary, user, oUser, state, affiliationMap, isDryRun)
+			if err != nil {
+				return nil, err
+			}
+			report.Changes = append(report.Changes, changes...)
 		}
 	}
 
-	_, err = AddUsersInBatch(newUsers)
-	if err != nil {
-		return err
+	if !isDryRun {
+		_, err = AddUsersInBatch(newUsers)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, state := range newStates {
+			err = saveSyncerUserState(state)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if !syncer.IsReadOnly {
 		for _, user := range users {
 			primary := syncer.getUserValue(user, key)
 			if _, ok := myOUsers[primary]; !ok {
+				report.Changes = append(report.Changes, &SyncerChange{Key: primary, Action: "add", Direction: "push"})
+				if isDryRun {
+					continue
+				}
+
 				newOUser := syncer.createOriginalUserFromUser(user)
 
 				fmt.Printf("New oUser: %v\n", newOUser)
 				_, err = syncer.addUser(newOUser)
 				if err != nil {
-					return err
+					return nil, err
+				}
+
+				err = saveSyncerUserState(&SyncerUserState{
+					Syncer:      syncer.GetId(),
+					Name:        primary,
+					FieldHashes: syncer.getFieldHashes(syncer.getMapFromOriginalUser(newOUser)),
+				})
+				if err != nil {
+					return nil, err
 				}
 			}
 		}
 	}
 
-	return nil
+	return report, nil
+}
+
+func (syncer *Syncer) syncUsers() error {
+	_, err := syncer.syncUsersWithReport(false)
+	return err
 }
 
 func (syncer *Syncer) syncUsersNoError() {
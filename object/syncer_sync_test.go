@@ -0,0 +1,60 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncerGetFieldDirection(t *testing.T) {
+	syncer := &Syncer{}
+	column := &TableColumn{Name: "email", CasdoorName: "Email"}
+	user := &User{UpdatedTime: "2023-10-02T00:00:00Z"}
+	oUser := &User{UpdatedTime: "2023-10-01T00:00:00Z"}
+	baseHash := util.GetMd5Hash("old@example.com")
+
+	scenarios := []struct {
+		description  string
+		conflictRule string
+		direction    string
+		baseHash     string
+		value        string
+		oValue       string
+		expected     string
+		isConflict   bool
+	}{
+		{"only the original database changed", "", "", baseHash, "old@example.com", "new@example.com", SyncerDirectionPull, false},
+		{"only Casdoor changed", "", "", baseHash, "new@example.com", "old@example.com", SyncerDirectionPush, false},
+		{"both changed, source wins", "", "", baseHash, "a@example.com", "b@example.com", SyncerDirectionPull, true},
+		{"both changed, target wins", SyncerConflictRuleTargetWins, "", baseHash, "a@example.com", "b@example.com", SyncerDirectionPush, true},
+		{"both changed, newest wins", SyncerConflictRuleNewestWins, "", baseHash, "a@example.com", "b@example.com", SyncerDirectionPush, true},
+		{"never synced", SyncerConflictRuleTargetWins, "", "", "a@example.com", "b@example.com", SyncerDirectionPush, true},
+		{"pull only column", "", SyncerDirectionPull, baseHash, "new@example.com", "old@example.com", SyncerDirectionPull, false},
+		{"push only column", "", SyncerDirectionPush, baseHash, "old@example.com", "new@example.com", SyncerDirectionPush, false},
+	}
+
+	for _, scenery := range scenarios {
+		t.Run(scenery.description, func(t *testing.T) {
+			syncer.ConflictRule = scenery.conflictRule
+			column.Direction = scenery.direction
+			direction, isConflict := syncer.getFieldDirection(column, scenery.baseHash, scenery.value, scenery.oValue, user, oUser)
+			assert.Equal(t, scenery.expected, direction)
+			assert.Equal(t, scenery.isConflict, isConflict)
+		})
+	}
+}
@@ -82,35 +82,6 @@ func (syncer *Syncer) updateUser(user *OriginalUser) (bool, error) {
 	return affected != 0, nil
 }
 
-func (syncer *Syncer) updateUserForOriginalFields(user *User, key string) (bool, error) {
-	var err error
-	oldUser := User{}
-
-	existed, err := ormer.Engine.Where(key+" = ? and owner = ?", syncer.getUserValue(user, key), user.Owner).Get(&oldUser)
-	if err != nil {
-		return false, err
-	}
-	if !existed {
-		return false, nil
-	}
-
-	if user.Avatar != oldUser.Avatar && user.Avatar != "" {
-		user.PermanentAvatar, err = getPermanentAvatarUrl(user.Owner, user.Name, user.Avatar, true)
-		if err != nil {
-			return false, err
-		}
-	}
-
-	columns := syncer.getCasdoorColumns()
-	columns = append(columns, "affiliation", "hash", "pre_hash")
-	affected, err := ormer.Engine.Where(key+" = ? and owner = ?", syncer.getUserValue(&oldUser, key), oldUser.Owner).Cols(columns...).Update(user)
-	if err != nil {
-		return false, err
-	}
-
-	return affected != 0, nil
-}
-
 func (syncer *Syncer) calculateHash(user *OriginalUser) string {
 	values := []string{}
 	m := syncer.getMapFromOriginalUser(user)
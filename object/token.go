@@ -63,6 +63,9 @@ type Token struct {
 	CodeChallenge    string `xorm:"varchar(100)" json:"codeChallenge"`
 	CodeIsUsed       bool   `json:"codeIsUsed"`
 	CodeExpireIn     int64  `json:"codeExpireIn"`
+	CertThumbprint   string `xorm:"varchar(100)" json:"certThumbprint"`
+
+	Actor string `xorm:"varchar(100)" json:"actor"`
 }
 
 type TokenWrapper struct {
@@ -72,6 +75,8 @@ type TokenWrapper struct {
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
 	Scope        string `json:"scope"`
+
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
 }
 
 type TokenError struct {
@@ -92,6 +97,9 @@ type IntrospectionResponse struct {
 	Aud       []string `json:"aud,omitempty"`
 	Iss       string   `json:"iss,omitempty"`
 	Jti       string   `json:"jti,omitempty"`
+
+	Cnf *ClaimsConfirmation `json:"cnf,omitempty"`
+	Act *ActorClaims        `json:"act,omitempty"`
 }
 
 func GetTokenCount(owner, organization, field, value string) (int64, error) {
@@ -248,6 +256,10 @@ func AddToken(token *Token) (bool, error) {
 		return false, err
 	}
 
+	if affected != 0 {
+		recordTokenUsage(token)
+	}
+
 	return affected != 0, nil
 }
 
@@ -280,6 +292,13 @@ func ExpireTokenByAccessToken(accessToken string) (bool, *Application, *Token, e
 		return false, nil, nil, err
 	}
 
+	if application != nil {
+		err = denylistToken(application, token, false)
+		if err != nil {
+			return false, nil, nil, err
+		}
+	}
+
 	return affected != 0, application, token, nil
 }
 
@@ -315,6 +334,15 @@ func CheckOAuthLogin(clientId string, responseType string, redirectUri string, s
 		return fmt.Sprintf(i18n.Translate(lang, "token:Redirect URI: %s doesn't exist in the allowed Redirect URI list"), redirectUri), application, nil
 	}
 
+	if !application.IsResponseTypeAllowed(responseType) {
+		return fmt.Sprintf(i18n.Translate(lang, "token:Response_type: %s is not allowed in this application"), responseType), application, nil
+	}
+
+	// the implicit flow needs to be enabled explicitly in the grant types
+	if responseType != "code" && !IsGrantTypeValid(responseType, application.GrantTypes) {
+		return fmt.Sprintf(i18n.Translate(lang, "token:Grant_type: %s is not supported in this application"), responseType), application, nil
+	}
+
 	// Mask application for /api/get-app-login
 	application.ClientSecret = ""
 	return "", application, nil
@@ -332,6 +360,12 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 			Code:    "",
 		}, nil
 	}
+
+	return GetOAuthCodeByUser(user, clientId, responseType, redirectUri, scope, state, nonce, challenge, host, lang)
+}
+
+// GetOAuthCodeByUser issues the authorization code for the given user, who may be a transient user of a broker mode application
+func GetOAuthCodeByUser(user *User, clientId string, responseType string, redirectUri string, scope string, state string, nonce string, challenge string, host string, lang string) (*Code, error) {
 	if user.IsForbidden {
 		return &Code{
 			Message: "error: the user is forbidden to sign in, please contact the administrator",
@@ -339,6 +373,13 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 		}, nil
 	}
 
+	if user.IsSuspensionActive() {
+		return &Code{
+			Message: "error: the user is suspended, please contact the administrator",
+			Code:    "",
+		}, nil
+	}
+
 	msg, application, err := CheckOAuthLogin(clientId, responseType, redirectUri, scope, state, lang)
 	if err != nil {
 		return nil, err
@@ -351,19 +392,35 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 		}, nil
 	}
 
+	if challenge == "null" {
+		challenge = ""
+	}
+
+	if application.RequirePkce && challenge == "" {
+		return &Code{
+			Message: i18n.Translate(lang, "token:The PKCE code challenge is required by this application"),
+			Code:    "",
+		}, nil
+	}
+
+	if user.IsTransient() && !application.EnableBrokerMode {
+		return &Code{
+			Message: "error: the transient user can only sign in to broker mode applications",
+			Code:    "",
+		}, nil
+	}
+
 	err = ExtendUserWithRolesAndPermissions(user)
 	if err != nil {
 		return nil, err
 	}
+
+	scope = getGrantedScope(application, user, scope)
 	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, nonce, scope, host)
 	if err != nil {
 		return nil, err
 	}
 
-	if challenge == "null" {
-		challenge = ""
-	}
-
 	token := &Token{
 		Owner:         application.Owner,
 		Name:          tokenName,
@@ -392,7 +449,7 @@ func GetOAuthCode(userId string, clientId string, responseType string, redirectU
 	}, nil
 }
 
-func GetOAuthToken(grantType string, clientId string, clientSecret string, code string, verifier string, scope string, username string, password string, host string, refreshToken string, tag string, avatar string, lang string) (interface{}, error) {
+func GetOAuthToken(grantType string, clientId string, clientSecret string, code string, verifier string, scope string, username string, password string, host string, refreshToken string, tag string, avatar string, clientIp string, certThumbprint string, subjectToken string, subjectTokenType string, audience string, lang string) (interface{}, error) {
 	application, err := GetApplicationByClientId(clientId)
 	if err != nil {
 		return nil, err
@@ -422,9 +479,11 @@ func GetOAuthToken(grantType string, clientId string, clientSecret string, code
 	case "password": //	Resource Owner Password Credentials Grant
 		token, tokenError, err = GetPasswordToken(application, username, password, scope, host)
 	case "client_credentials": // Client Credentials Grant
-		token, tokenError, err = GetClientCredentialsToken(application, clientSecret, scope, host)
+		token, tokenError, err = GetClientCredentialsToken(application, clientSecret, scope, audience, host)
+	case TokenExchangeGrantType: // Token Exchange (RFC 8693) for the delegation tokens
+		token, tokenError, err = GetDelegationToken(application, clientSecret, subjectToken, subjectTokenType, scope, host)
 	case "refresh_token":
-		refreshToken2, err := RefreshToken(grantType, refreshToken, scope, clientId, clientSecret, host)
+		refreshToken2, err := RefreshToken(grantType, refreshToken, scope, clientId, clientSecret, host, clientIp, certThumbprint, lang)
 		if err != nil {
 			return nil, err
 		}
@@ -447,6 +506,22 @@ func GetOAuthToken(grantType string, clientId string, clientSecret string, code
 		return tokenError, nil
 	}
 
+	tokenError, err = checkTokenSigninRestriction(application, token, clientIp, lang)
+	if err != nil {
+		return nil, err
+	}
+	if tokenError != nil {
+		return tokenError, nil
+	}
+
+	tokenError, err = bindTokenToCertificate(application, token, certThumbprint)
+	if err != nil {
+		return nil, err
+	}
+	if tokenError != nil {
+		return tokenError, nil
+	}
+
 	token.CodeIsUsed = true
 
 	go updateUsedByCode(token)
@@ -459,11 +534,14 @@ func GetOAuthToken(grantType string, clientId string, clientSecret string, code
 		ExpiresIn:    token.ExpiresIn,
 		Scope:        token.Scope,
 	}
+	if token.Actor != "" {
+		tokenWrapper.IssuedTokenType = AccessTokenType
+	}
 
 	return tokenWrapper, nil
 }
 
-func RefreshToken(grantType string, refreshToken string, scope string, clientId string, clientSecret string, host string) (interface{}, error) {
+func RefreshToken(grantType string, refreshToken string, scope string, clientId string, clientSecret string, host string, clientIp string, certThumbprint string, lang string) (interface{}, error) {
 	// check parameters
 	if grantType != "refresh_token" {
 		return &TokenError{
@@ -523,6 +601,13 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 	if err != nil {
 		return nil, err
 	}
+	if user == nil {
+		// transient users of the broker mode are not stored, so their tokens cannot be refreshed
+		return &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: fmt.Sprintf("the user: %s doesn't exist", util.GetId(application.Organization, token.User)),
+		}, nil
+	}
 
 	if user.IsForbidden {
 		return &TokenError{
@@ -531,11 +616,37 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 		}, nil
 	}
 
+	if user.IsSuspensionActive() {
+		return &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the user is suspended, please contact the administrator",
+		}, nil
+	}
+
+	err = CheckSigninRestriction(application, user, clientIp, "refresh-token", lang)
+	if err != nil {
+		return &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: err.Error(),
+		}, nil
+	}
+
+	tokenError, err := checkTokenLegalDocuments(application, user)
+	if tokenError != nil || err != nil {
+		return tokenError, err
+	}
+
+	tokenError = checkTokenIdentityVerification(application, user)
+	if tokenError != nil {
+		return tokenError, nil
+	}
+
 	err = ExtendUserWithRolesAndPermissions(user)
 	if err != nil {
 		return nil, err
 	}
 
+	scope = getGrantedScope(application, user, scope)
 	newAccessToken, newRefreshToken, tokenName, err := generateJwtToken(application, user, "", scope, host)
 	if err != nil {
 		return &TokenError{
@@ -568,6 +679,14 @@ func RefreshToken(grantType string, refreshToken string, scope string, clientId
 		return nil, err
 	}
 
+	tokenError, err = bindTokenToCertificate(application, newToken, certThumbprint)
+	if err != nil {
+		return nil, err
+	}
+	if tokenError != nil {
+		return tokenError, nil
+	}
+
 	tokenWrapper := &TokenWrapper{
 		AccessToken:  newToken.AccessToken,
 		IdToken:      newToken.AccessToken,
@@ -630,6 +749,13 @@ func GetAuthorizationCodeToken(application *Application, clientSecret string, co
 		}, nil
 	}
 
+	if application.RequirePkce && token.CodeChallenge == "" {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the PKCE code challenge is required by this application",
+		}, nil
+	}
+
 	if token.CodeChallenge != "" && pkceChallenge(verifier) != token.CodeChallenge {
 		return nil, &TokenError{
 			Error:            InvalidGrant,
@@ -705,11 +831,36 @@ func GetPasswordToken(application *Application, username string, password string
 		}, nil
 	}
 
+	if user.IsSuspensionActive() {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the user is suspended, please contact the administrator",
+		}, nil
+	}
+
+	if user.IsBreakGlass() {
+		return nil, &TokenError{
+			Error:            InvalidGrant,
+			ErrorDescription: "the break-glass account can only sign in with its break-glass key",
+		}, nil
+	}
+
+	tokenError, err := checkTokenLegalDocuments(application, user)
+	if tokenError != nil || err != nil {
+		return nil, tokenError, err
+	}
+
+	tokenError = checkTokenIdentityVerification(application, user)
+	if tokenError != nil {
+		return nil, tokenError, nil
+	}
+
 	err = ExtendUserWithRolesAndPermissions(user)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	scope = getGrantedScope(application, user, scope)
 	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, "", scope, host)
 	if err != nil {
 		return nil, &TokenError{
@@ -742,7 +893,9 @@ func GetPasswordToken(application *Application, username string, password string
 
 // GetClientCredentialsToken
 // Client Credentials flow
-func GetClientCredentialsToken(application *Application, clientSecret string, scope string, host string) (*Token, *TokenError, error) {
+// The token is issued for the audience application (its client ID) if any, which the application should be allowed
+// to call by its audience rules
+func GetClientCredentialsToken(application *Application, clientSecret string, scope string, audience string, host string) (*Token, *TokenError, error) {
 	if application.ClientSecret != clientSecret {
 		return nil, &TokenError{
 			Error:            InvalidClient,
@@ -756,7 +909,30 @@ func GetClientCredentialsToken(application *Application, clientSecret string, sc
 		Type:  "application",
 	}
 
-	accessToken, _, tokenName, err := generateJwtToken(application, nullUser, "", scope, host)
+	var accessToken, tokenName string
+	var err error
+	if audience == "" || audience == application.ClientId {
+		scope = getGrantedScope(application, nullUser, scope)
+		accessToken, _, tokenName, err = generateJwtToken(application, nullUser, "", scope, host)
+	} else {
+		var audienceApplication *Application
+		var rule *AudienceRule
+		var tokenError *TokenError
+		audienceApplication, rule, tokenError, err = getAudienceApplication(application, audience)
+		if tokenError != nil || err != nil {
+			return nil, tokenError, err
+		}
+
+		scope, err = getAudienceScope(rule, audienceApplication, scope)
+		if err != nil {
+			return nil, &TokenError{
+				Error:            InvalidScope,
+				ErrorDescription: err.Error(),
+			}, nil
+		}
+
+		accessToken, tokenName, err = generateAudienceJwtToken(application, nullUser, audience, scope, host)
+	}
 	if err != nil {
 		return nil, &TokenError{
 			Error:            EndpointError,
@@ -793,6 +969,7 @@ func GetTokenByUser(application *Application, user *User, scope string, nonce st
 		return nil, err
 	}
 
+	scope = getGrantedScope(application, user, scope)
 	accessToken, refreshToken, tokenName, err := generateJwtToken(application, user, nonce, scope, host)
 	if err != nil {
 		return nil, err
@@ -864,9 +1041,14 @@ func GetWechatMiniProgramToken(application *Application, code string, host strin
 				ErrorDescription: "the application does not allow to sign up new account",
 			}, nil
 		}
+		organization, err := getOrganization("admin", application.Organization)
+		if err != nil {
+			return nil, nil, err
+		}
+
 		// Add new user
 		var name string
-		if CheckUsername(username, lang) == "" {
+		if CheckUsernameByOrg(organization, username, false, lang) == "" {
 			name = username
 		} else {
 			name = fmt.Sprintf("wechat-%s", openId)
@@ -16,7 +16,6 @@ package object
 
 import (
 	"crypto"
-	"encoding/base64"
 	"encoding/json"
 	"encoding/pem"
 	"encoding/xml"
@@ -294,18 +293,24 @@ func GetValidationBySaml(samlRequest string, host string) (string, string, error
 	}
 
 	block, _ := pem.Decode([]byte(cert.Certificate))
-	certificate := base64.StdEncoding.EncodeToString(block.Bytes)
-	randomKeyStore := &X509Key{
-		PrivateKey:      cert.PrivateKey,
-		X509Certificate: certificate,
+	signer, err := getCertSigner(cert)
+	if err != nil {
+		return "", "", err
 	}
+	keyStore := &signerKeyStore{signer: signer, certificate: block.Bytes}
 
-	ctx := dsig.NewDefaultSigningContext(randomKeyStore)
+	ctx := dsig.NewDefaultSigningContext(keyStore)
 	ctx.Hash = crypto.SHA1
-	signedXML, err := ctx.SignEnveloped(samlResponse)
+	if cert.isExternalKey() {
+		// the KMS backends don't sign SHA-1 digests
+		ctx.Hash = crypto.SHA256
+	}
+	sig, err := constructXmlSignature(ctx, keyStore, samlResponse)
 	if err != nil {
 		return "", "", fmt.Errorf("err: %s", err.Error())
 	}
+	signedXML := samlResponse.Copy()
+	signedXML.Child = append(signedXML.Child, sig)
 
 	doc := etree.NewDocument()
 	doc.SetRoot(signedXML)
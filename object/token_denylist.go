@@ -0,0 +1,142 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/gomodule/redigo/redis"
+)
+
+const tokenDenylistKeyPrefix = "casdoor:token-denylist:"
+
+// tokenDenylist keeps the revoked tokens until they expire, it is shared by all the Casdoor instances
+// via Redis if "redisEndpoint" is configured, otherwise it is kept in memory
+type tokenDenylist interface {
+	Add(key string, ttl time.Duration) error
+	Contains(key string) (bool, error)
+}
+
+type memoryTokenDenylist struct {
+	mutex   sync.Mutex
+	entries map[string]time.Time
+}
+
+func (d *memoryTokenDenylist) Add(key string, ttl time.Duration) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := time.Now()
+	for k, expireTime := range d.entries {
+		if now.After(expireTime) {
+			delete(d.entries, k)
+		}
+	}
+
+	d.entries[key] = now.Add(ttl)
+	return nil
+}
+
+func (d *memoryTokenDenylist) Contains(key string) (bool, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	expireTime, ok := d.entries[key]
+	return ok && time.Now().Before(expireTime), nil
+}
+
+type redisTokenDenylist struct {
+	pool *redis.Pool
+}
+
+// newRedisTokenDenylist uses the same "redisEndpoint" as the session, like "127.0.0.1:6379,100,password,0"
+func newRedisTokenDenylist(endpoint string) *redisTokenDenylist {
+	parts := strings.Split(endpoint, ",")
+	options := []redis.DialOption{}
+	if len(parts) > 2 && parts[2] != "" {
+		options = append(options, redis.DialPassword(parts[2]))
+	}
+	if len(parts) > 3 && parts[3] != "" {
+		if db, err := strconv.Atoi(parts[3]); err == nil {
+			options = append(options, redis.DialDatabase(db))
+		}
+	}
+
+	return &redisTokenDenylist{
+		pool: &redis.Pool{
+			MaxIdle:     10,
+			IdleTimeout: 5 * time.Minute,
+			Dial: func() (redis.Conn, error) {
+				return redis.Dial("tcp", parts[0], options...)
+			},
+		},
+	}
+}
+
+func (d *redisTokenDenylist) Add(key string, ttl time.Duration) error {
+	conn := d.pool.Get()
+	defer conn.Close()
+
+	seconds := int64(ttl / time.Second)
+	if seconds <= 0 {
+		seconds = 1
+	}
+	_, err := conn.Do("SET", tokenDenylistKeyPrefix+key, "1", "EX", seconds)
+	return err
+}
+
+func (d *redisTokenDenylist) Contains(key string) (bool, error) {
+	conn := d.pool.Get()
+	defer conn.Close()
+
+	return redis.Bool(conn.Do("EXISTS", tokenDenylistKeyPrefix+key))
+}
+
+var (
+	denylist     tokenDenylist
+	denylistOnce sync.Once
+)
+
+func getTokenDenylist() tokenDenylist {
+	denylistOnce.Do(func() {
+		if endpoint := conf.GetConfigString("redisEndpoint"); endpoint != "" {
+			denylist = newRedisTokenDenylist(endpoint)
+		} else {
+			denylist = &memoryTokenDenylist{entries: map[string]time.Time{}}
+		}
+	})
+	return denylist
+}
+
+func getTokenDenylistKey(tokenType string, jti string) string {
+	return tokenType + "/" + jti
+}
+
+// IsTokenDenylisted returns whether the access token or refresh token of the JWT ID has been revoked,
+// the revoked tokens are also expired or deleted in the database, so an unreachable denylist is skipped
+func IsTokenDenylisted(tokenType string, jti string) bool {
+	res, err := getTokenDenylist().Contains(getTokenDenylistKey(tokenType, jti))
+	if err != nil {
+		logs.Warning(fmt.Sprintf("failed to check the token denylist: %s", err.Error()))
+		return false
+	}
+	return res
+}
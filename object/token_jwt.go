@@ -15,6 +15,8 @@
 package object
 
 import (
+	"crypto"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -28,6 +30,13 @@ type Claims struct {
 	Nonce     string `json:"nonce,omitempty"`
 	Tag       string `json:"tag"`
 	Scope     string `json:"scope,omitempty"`
+
+	RevocationEpoch int          `json:"revocationEpoch,omitempty"`
+	Act             *ActorClaims `json:"act,omitempty"`
+	// Azp is the client ID of the application requesting the token for another audience
+	Azp string `json:"azp,omitempty"`
+	// IdentityVerificationState lets the resource servers gate on the identity verification of the user
+	IdentityVerificationState string `json:"identityVerificationState,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -134,6 +143,11 @@ type ClaimsShort struct {
 	TokenType string `json:"tokenType,omitempty"`
 	Nonce     string `json:"nonce,omitempty"`
 	Scope     string `json:"scope,omitempty"`
+
+	RevocationEpoch           int          `json:"revocationEpoch,omitempty"`
+	Act                       *ActorClaims `json:"act,omitempty"`
+	Azp                       string       `json:"azp,omitempty"`
+	IdentityVerificationState string       `json:"identityVerificationState,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -143,6 +157,11 @@ type ClaimsWithoutThirdIdp struct {
 	Nonce     string `json:"nonce,omitempty"`
 	Tag       string `json:"tag"`
 	Scope     string `json:"scope,omitempty"`
+
+	RevocationEpoch           int          `json:"revocationEpoch,omitempty"`
+	Act                       *ActorClaims `json:"act,omitempty"`
+	Azp                       string       `json:"azp,omitempty"`
+	IdentityVerificationState string       `json:"identityVerificationState,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -249,23 +268,31 @@ func getUserWithoutThirdIdp(user *User) *UserWithoutThirdIdp {
 
 func getShortClaims(claims Claims) ClaimsShort {
 	res := ClaimsShort{
-		UserShort:        getShortUser(claims.User),
-		TokenType:        claims.TokenType,
-		Nonce:            claims.Nonce,
-		Scope:            claims.Scope,
-		RegisteredClaims: claims.RegisteredClaims,
+		UserShort:                 getShortUser(claims.User),
+		TokenType:                 claims.TokenType,
+		Nonce:                     claims.Nonce,
+		Scope:                     claims.Scope,
+		RevocationEpoch:           claims.RevocationEpoch,
+		Act:                       claims.Act,
+		Azp:                       claims.Azp,
+		RegisteredClaims:          claims.RegisteredClaims,
+		IdentityVerificationState: claims.IdentityVerificationState,
 	}
 	return res
 }
 
 func getClaimsWithoutThirdIdp(claims Claims) ClaimsWithoutThirdIdp {
 	res := ClaimsWithoutThirdIdp{
-		UserWithoutThirdIdp: getUserWithoutThirdIdp(claims.User),
-		TokenType:           claims.TokenType,
-		Nonce:               claims.Nonce,
-		Tag:                 claims.Tag,
-		Scope:               claims.Scope,
-		RegisteredClaims:    claims.RegisteredClaims,
+		UserWithoutThirdIdp:       getUserWithoutThirdIdp(claims.User),
+		TokenType:                 claims.TokenType,
+		Nonce:                     claims.Nonce,
+		Tag:                       claims.Tag,
+		Scope:                     claims.Scope,
+		RevocationEpoch:           claims.RevocationEpoch,
+		Act:                       claims.Act,
+		Azp:                       claims.Azp,
+		RegisteredClaims:          claims.RegisteredClaims,
+		IdentityVerificationState: claims.IdentityVerificationState,
 	}
 	return res
 }
@@ -295,11 +322,45 @@ func refineUser(user *User) *User {
 func generateJwtToken(application *Application, user *User, nonce string, scope string, host string) (string, string, string, error) {
 	nowTime := time.Now()
 	expireTime := nowTime.Add(time.Duration(application.ExpireInHours) * time.Hour)
+	return signJwtToken(application, user, nonce, scope, host, nil, "", nowTime, expireTime)
+}
+
+// generateAudienceJwtToken generates the client credentials token of the application for the audience application,
+// the "aud" claim is the client ID of the audience and the "azp" claim is the one of the application
+func generateAudienceJwtToken(application *Application, user *User, audience string, scope string, host string) (string, string, error) {
+	nowTime := time.Now()
+	expireTime := nowTime.Add(time.Duration(application.ExpireInHours) * time.Hour)
+	accessToken, _, name, err := signJwtToken(application, user, "", scope, host, nil, audience, nowTime, expireTime)
+	return accessToken, name, err
+}
+
+// generateDelegationJwtToken generates the access token of the user carrying the "act" claim of the actor,
+// the delegation token has no refresh token
+func generateDelegationJwtToken(application *Application, user *User, actor *ActorClaims, scope string, host string, expireTime time.Time) (string, string, error) {
+	accessToken, _, name, err := signJwtToken(application, user, "", scope, host, actor, "", time.Now(), expireTime)
+	return accessToken, name, err
+}
+
+func signJwtToken(application *Application, user *User, nonce string, scope string, host string, actor *ActorClaims, audience string, nowTime time.Time, expireTime time.Time) (string, string, string, error) {
 	refreshExpireTime := nowTime.Add(time.Duration(application.RefreshExpireInHours) * time.Hour)
 	if application.RefreshExpireInHours == 0 {
 		refreshExpireTime = expireTime
 	}
 
+	// the member of the organization of the application gets the properties of its membership
+	memberUser, err := GetMemberUser(application.Organization, user)
+	if err != nil {
+		return "", "", "", err
+	}
+	if memberUser != nil {
+		user = memberUser
+	}
+
+	hookClaims, err := RunAuthHooks(AuthHookEventPreTokenIssuance, application, user, "", scope, "en")
+	if err != nil {
+		return "", "", "", err
+	}
+
 	user = refineUser(user)
 
 	_, originBackend := getOriginFromHost(host)
@@ -307,6 +368,13 @@ func generateJwtToken(application *Application, user *User, nonce string, scope
 	name := util.GenerateId()
 	jti := util.GetId(application.Owner, name)
 
+	azp := ""
+	if audience == "" {
+		audience = application.ClientId
+	} else {
+		azp = application.ClientId
+	}
+
 	claims := Claims{
 		User:      user,
 		TokenType: "access-token",
@@ -314,57 +382,62 @@ func generateJwtToken(application *Application, user *User, nonce string, scope
 		// FIXME: A workaround for custom claim by reusing `tag` in user info
 		Tag:   user.Tag,
 		Scope: scope,
+		// the resource servers validating the token offline compare it with the user's epoch in the token metadata
+		RevocationEpoch: user.RevocationEpoch,
+		Act:             actor,
+		Azp:             azp,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    originBackend,
 			Subject:   user.Id,
-			Audience:  []string{application.ClientId},
+			Audience:  []string{audience},
 			ExpiresAt: jwt.NewNumericDate(expireTime),
 			NotBefore: jwt.NewNumericDate(nowTime),
 			IssuedAt:  jwt.NewNumericDate(nowTime),
 			ID:        jti,
 		},
+		IdentityVerificationState: GetIdentityVerificationState(user),
 	}
 
-	var token *jwt.Token
-	var refreshToken *jwt.Token
+	var accessClaims jwt.Claims
+	var refreshClaims jwt.Claims
 
 	// the JWT token length in "JWT-Empty" mode will be very short, as User object only has two properties: owner and name
 	if application.TokenFormat == "JWT-Empty" {
 		claimsShort := getShortClaims(claims)
 
-		token = jwt.NewWithClaims(jwt.SigningMethodRS256, claimsShort)
+		accessClaims = claimsShort
 		claimsShort.ExpiresAt = jwt.NewNumericDate(refreshExpireTime)
 		claimsShort.TokenType = "refresh-token"
-		refreshToken = jwt.NewWithClaims(jwt.SigningMethodRS256, claimsShort)
+		refreshClaims = claimsShort
 	} else {
 		claimsWithoutThirdIdp := getClaimsWithoutThirdIdp(claims)
 
-		token = jwt.NewWithClaims(jwt.SigningMethodRS256, claimsWithoutThirdIdp)
+		accessClaims = claimsWithoutThirdIdp
 		claimsWithoutThirdIdp.ExpiresAt = jwt.NewNumericDate(refreshExpireTime)
 		claimsWithoutThirdIdp.TokenType = "refresh-token"
-		refreshToken = jwt.NewWithClaims(jwt.SigningMethodRS256, claimsWithoutThirdIdp)
+		refreshClaims = claimsWithoutThirdIdp
 	}
 
-	cert, err := getCertByApplication(application)
+	accessClaims, err = addHookClaims(accessClaims, hookClaims)
 	if err != nil {
 		return "", "", "", err
 	}
-
-	if cert == nil {
-		if application.Cert == "" {
-			return "", "", "", fmt.Errorf("The cert field of the application \"%s\" should not be empty", application.GetId())
-		} else {
-			return "", "", "", fmt.Errorf("The cert \"%s\" does not exist", application.Cert)
-		}
+	refreshClaims, err = addHookClaims(refreshClaims, hookClaims)
+	if err != nil {
+		return "", "", "", err
 	}
 
-	// RSA private key
-	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(cert.PrivateKey))
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, accessClaims)
+	refreshToken := jwt.NewWithClaims(jwt.SigningMethodRS256, refreshClaims)
+
+	key, keyId, err := getJwtSigningKey(application)
 	if err != nil {
 		return "", "", "", err
 	}
 
-	token.Header["kid"] = cert.Name
+	token.Method = getJwtSigningMethod(key)
+	refreshToken.Method = token.Method
+	token.Header["kid"] = keyId
 	tokenString, err := token.SignedString(key)
 	if err != nil {
 		return "", "", "", err
@@ -374,18 +447,69 @@ func generateJwtToken(application *Application, user *User, nonce string, scope
 	return tokenString, refreshTokenString, name, err
 }
 
+// addHookClaims adds the claims of the auth hooks to the top level of the token, the claims of the token are not overridden
+func addHookClaims(claims jwt.Claims, hookClaims map[string]interface{}) (jwt.Claims, error) {
+	if len(hookClaims) == 0 {
+		return claims, nil
+	}
+
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	res := jwt.MapClaims{}
+	err = json.Unmarshal(data, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range hookClaims {
+		if _, ok := res[key]; !ok {
+			res[key] = value
+		}
+	}
+	return res, nil
+}
+
+// getJwtSigningKey returns the signer of the application's cert and its key ID
+func getJwtSigningKey(application *Application) (crypto.Signer, string, error) {
+	cert, err := getCertByApplication(application)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if cert == nil {
+		if application.Cert == "" {
+			return nil, "", fmt.Errorf("The cert field of the application \"%s\" should not be empty", application.GetId())
+		} else {
+			return nil, "", fmt.Errorf("The cert \"%s\" does not exist", application.Cert)
+		}
+	}
+
+	key, err := getCertSigner(cert)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return key, cert.GetKeyId(), nil
+}
+
 func ParseJwtToken(token string, cert *Cert) (*Claims, error) {
 	t, err := jwt.ParseWithClaims(token, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
-		if cert.Certificate == "" {
+		// the tokens signed by the rotated key are still valid during the grace period
+		kid, _ := token.Header["kid"].(string)
+		certificatePem := cert.getCertificateByKeyId(kid)
+		if certificatePem == "" {
 			return nil, fmt.Errorf("the certificate field should not be empty for the cert: %v", cert)
 		}
 
 		// RSA certificate
-		certificate, err := jwt.ParseRSAPublicKeyFromPEM([]byte(cert.Certificate))
+		certificate, err := jwt.ParseRSAPublicKeyFromPEM([]byte(certificatePem))
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,130 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/xorm-io/core"
+)
+
+const (
+	// TokenMetadataMaxCacheSeconds is how long the resource servers may cache the token metadata,
+	// so a revoked token is accepted offline for at most that long
+	TokenMetadataMaxCacheSeconds = 60
+	TokenMetadataMaxSubjects     = 100
+
+	TokenKeyStatusCurrent  = "current"
+	TokenKeyStatusPrevious = "previous"
+)
+
+type TokenKeyMetadata struct {
+	Kid        string `json:"kid"`
+	Alg        string `json:"alg"`
+	Cert       string `json:"cert"`
+	Status     string `json:"status"`
+	ExpireTime string `json:"expireTime,omitempty"`
+}
+
+// TokenMetadata is what the resource servers need to validate the JWTs offline: the signing keys in the JWKS
+// with their versions, and the revocation epochs of the subjects, a JWT whose "revocationEpoch" claim is lower
+// than its subject's epoch has been revoked, a subject missing from the epochs no longer exists.
+type TokenMetadata struct {
+	Issuer  string              `json:"issuer"`
+	JwksUri string              `json:"jwksUri"`
+	Keys    []*TokenKeyMetadata `json:"keys"`
+	Epochs  map[string]int      `json:"epochs"`
+}
+
+func getTokenKeyMetadatas(certs []*Cert) []*TokenKeyMetadata {
+	keys := []*TokenKeyMetadata{}
+	for _, cert := range certs {
+		if cert.Type != "x509" || cert.Certificate == "" {
+			continue
+		}
+
+		keys = append(keys, &TokenKeyMetadata{
+			Kid:    cert.GetKeyId(),
+			Alg:    cert.CryptoAlgorithm,
+			Cert:   cert.Name,
+			Status: TokenKeyStatusCurrent,
+		})
+
+		if cert.isPreviousKeyValid() {
+			keys = append(keys, &TokenKeyMetadata{
+				Kid:        cert.PreviousKeyId,
+				Alg:        cert.CryptoAlgorithm,
+				Cert:       cert.Name,
+				Status:     TokenKeyStatusPrevious,
+				ExpireTime: cert.PreviousExpireTime,
+			})
+		}
+	}
+	return keys
+}
+
+func getRevocationEpochs(subjects []string) (map[string]int, error) {
+	epochs := map[string]int{}
+	if len(subjects) == 0 {
+		return epochs, nil
+	}
+
+	users := []*User{}
+	err := ormer.Engine.Cols("id", "revocation_epoch").In("id", subjects).Find(&users)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, user := range users {
+		epochs[user.Id] = user.RevocationEpoch
+	}
+	return epochs, nil
+}
+
+// GetTokenMetadata returns the signing keys and the revocation epochs of the subjects (the "sub" claims)
+func GetTokenMetadata(host string, subjects []string) (*TokenMetadata, error) {
+	if len(subjects) > TokenMetadataMaxSubjects {
+		return nil, fmt.Errorf("at most %d subjects can be queried at once", TokenMetadataMaxSubjects)
+	}
+
+	certs, err := GetCerts("admin")
+	if err != nil {
+		return nil, err
+	}
+
+	epochs, err := getRevocationEpochs(subjects)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcDiscovery := GetOidcDiscovery(host)
+	return &TokenMetadata{
+		Issuer:  oidcDiscovery.Issuer,
+		JwksUri: oidcDiscovery.JwksUri,
+		Keys:    getTokenKeyMetadatas(certs),
+		Epochs:  epochs,
+	}, nil
+}
+
+// bumpRevocationEpoch revokes all the JWTs issued to the user so far for the offline validators
+func bumpRevocationEpoch(user *User) error {
+	_, err := ormer.Engine.ID(core.PK{user.Owner, user.Name}).Incr("revocation_epoch").Update(&User{})
+	if err != nil {
+		return err
+	}
+
+	user.RevocationEpoch++
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTokenKeyMetadatas(t *testing.T) {
+	expireTime := time.Now().Add(time.Hour).Format(time.RFC3339)
+	certs := []*Cert{
+		{Name: "cert-never-rotated", Type: "x509", CryptoAlgorithm: "RS256", Certificate: "pem"},
+		{Name: "cert-rotated", Type: "x509", CryptoAlgorithm: "RS256", Certificate: "pem", KeyId: "cert-rotated-2", PreviousKeyId: "cert-rotated-1", PreviousCertificate: "pem", PreviousExpireTime: expireTime},
+		{Name: "cert-expired", Type: "x509", CryptoAlgorithm: "RS256", Certificate: "pem", KeyId: "cert-expired-2", PreviousKeyId: "cert-expired-1", PreviousCertificate: "pem", PreviousExpireTime: "2023-01-01T00:00:00Z"},
+		{Name: "cert-jwt", Type: "JWT", Certificate: "pem"},
+	}
+
+	assert.Equal(t, []*TokenKeyMetadata{
+		{Kid: "cert-never-rotated", Alg: "RS256", Cert: "cert-never-rotated", Status: TokenKeyStatusCurrent},
+		{Kid: "cert-rotated-2", Alg: "RS256", Cert: "cert-rotated", Status: TokenKeyStatusCurrent},
+		{Kid: "cert-rotated-1", Alg: "RS256", Cert: "cert-rotated", Status: TokenKeyStatusPrevious, ExpireTime: expireTime},
+		{Kid: "cert-expired-2", Alg: "RS256", Cert: "cert-expired", Status: TokenKeyStatusCurrent},
+	}, getTokenKeyMetadatas(certs))
+}
+
+func TestRevocationEpochClaim(t *testing.T) {
+	claims := Claims{User: &User{Owner: "org-1", Name: "alice", RevocationEpoch: 1}, RevocationEpoch: 2}
+
+	for _, c := range []interface{}{getShortClaims(claims), getClaimsWithoutThirdIdp(claims)} {
+		data, err := json.Marshal(c)
+		assert.Nil(t, err)
+
+		parsed := Claims{}
+		assert.Nil(t, json.Unmarshal(data, &parsed))
+		assert.Equal(t, 2, parsed.RevocationEpoch)
+	}
+
+	// the tokens issued before the first revocation don't carry the claim
+	data, err := json.Marshal(getShortClaims(Claims{User: &User{}}))
+	assert.Nil(t, err)
+	assert.NotContains(t, string(data), "revocationEpoch")
+}
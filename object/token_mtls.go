@@ -0,0 +1,166 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/xorm-io/core"
+)
+
+const (
+	defaultBoundTokenExpireInSeconds = 300
+
+	// IntrospectionMaxCacheSeconds caps how long the gateways may cache an active introspection result,
+	// so a revoked token isn't accepted for longer than that
+	IntrospectionMaxCacheSeconds = 60
+)
+
+// ClaimsConfirmation is the confirmation claim binding the access token to the client certificate (RFC 8705)
+type ClaimsConfirmation struct {
+	X5tS256 string `json:"x5t#S256"`
+}
+
+func getCertificateThumbprint(certificate *x509.Certificate) string {
+	sum := sha256.Sum256(certificate.Raw)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// parseForwardedClientCertificate parses the client certificate forwarded by the proxy, which is the URL-encoded
+// PEM like nginx's $ssl_client_escaped_cert, or the base64-encoded DER
+func parseForwardedClientCertificate(value string) (*x509.Certificate, error) {
+	unescaped, err := url.PathUnescape(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if block, _ := pem.Decode([]byte(unescaped)); block != nil {
+		return x509.ParseCertificate(block.Bytes)
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(unescaped))
+	if err != nil {
+		return nil, fmt.Errorf("the forwarded client certificate is neither PEM nor base64-encoded DER")
+	}
+	return x509.ParseCertificate(der)
+}
+
+// GetClientCertificateThumbprint returns the SHA-256 thumbprint of the client certificate of the mutual TLS
+// connection, or of the certificate forwarded by a trusted TLS-terminating proxy in the header configured as
+// "mtlsClientCertHeader". It returns an empty string if there is no client certificate.
+func GetClientCertificateThumbprint(req *http.Request) (string, error) {
+	if req.TLS != nil && len(req.TLS.PeerCertificates) > 0 {
+		return getCertificateThumbprint(req.TLS.PeerCertificates[0]), nil
+	}
+
+	// the certificates are public, so the header is only honored from the trusted proxies configured by "trustedProxies"
+	header := conf.GetConfigString("mtlsClientCertHeader")
+	if header == "" || !util.IsFromTrustedProxy(req) {
+		return "", nil
+	}
+
+	value := req.Header.Get(header)
+	if value == "" {
+		return "", nil
+	}
+
+	certificate, err := parseForwardedClientCertificate(value)
+	if err != nil {
+		return "", err
+	}
+	return getCertificateThumbprint(certificate), nil
+}
+
+func (application *Application) getBoundTokenExpireInSeconds() int {
+	if application.BoundTokenExpireInSeconds <= 0 {
+		return defaultBoundTokenExpireInSeconds
+	}
+	return application.BoundTokenExpireInSeconds
+}
+
+// bindTokenToCertificate re-signs the access token of the application requiring the certificate-bound tokens with
+// the confirmation claim of the client certificate and the short lifetime of the application
+func bindTokenToCertificate(application *Application, token *Token, certThumbprint string) (*TokenError, error) {
+	if !application.EnableCertificateBoundTokens {
+		return nil, nil
+	}
+
+	if certThumbprint == "" {
+		return &TokenError{
+			Error:            InvalidRequest,
+			ErrorDescription: "the client certificate is required for the certificate-bound access tokens of the application",
+		}, nil
+	}
+
+	expireInSeconds := application.getBoundTokenExpireInSeconds()
+	// the delegation tokens keep their shorter lifetime
+	if token.Actor != "" && token.ExpiresIn < expireInSeconds {
+		expireInSeconds = token.ExpiresIn
+	}
+
+	return nil, signCertificateBoundToken(application, token, certThumbprint, expireInSeconds)
+}
+
+// signCertificateBoundToken re-signs the access token with the confirmation claim of the client certificate
+// and the lifetime in seconds
+func signCertificateBoundToken(application *Application, token *Token, certThumbprint string, expireInSeconds int) error {
+	claims := jwt.MapClaims{}
+	_, _, err := new(jwt.Parser).ParseUnverified(token.AccessToken, claims)
+	if err != nil {
+		return err
+	}
+
+	nowTime := time.Now()
+	claims["cnf"] = &ClaimsConfirmation{X5tS256: certThumbprint}
+	claims["iat"] = jwt.NewNumericDate(nowTime)
+	claims["nbf"] = jwt.NewNumericDate(nowTime)
+	claims["exp"] = jwt.NewNumericDate(nowTime.Add(time.Duration(expireInSeconds) * time.Second))
+
+	key, keyId, err := getJwtSigningKey(application)
+	if err != nil {
+		return err
+	}
+
+	jwtToken := jwt.NewWithClaims(getJwtSigningMethod(key), claims)
+	jwtToken.Header["kid"] = keyId
+	accessToken, err := jwtToken.SignedString(key)
+	if err != nil {
+		return err
+	}
+
+	token.AccessToken = accessToken
+	token.AccessTokenHash = getTokenHash(accessToken)
+	token.ExpiresIn = expireInSeconds
+	token.CertThumbprint = certThumbprint
+	_, err = ormer.Engine.ID(core.PK{token.Owner, token.Name}).Cols("access_token", "access_token_hash", "expires_in", "cert_thumbprint").Update(token)
+	return err
+}
+
+// IsTokenCertificateMatched checks whether the access token is presented with the client certificate it is bound to,
+// the tokens not bound to a certificate match any request
+func IsTokenCertificateMatched(token *Token, certThumbprint string) bool {
+	return token.CertThumbprint == "" || token.CertThumbprint == certThumbprint
+}
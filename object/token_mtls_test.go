@@ -0,0 +1,105 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClientCertificate(t *testing.T) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.Nil(t, err)
+
+	certificate, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return certificate
+}
+
+func TestGetClientCertificateThumbprint(t *testing.T) {
+	certificate := newTestClientCertificate(t)
+	thumbprint := getCertificateThumbprint(certificate)
+	assert.Len(t, thumbprint, 43)
+
+	req := httptest.NewRequest("POST", "/api/login/oauth/access_token", nil)
+	res, err := GetClientCertificateThumbprint(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "", res)
+
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{certificate}}
+	res, err = GetClientCertificateThumbprint(req)
+	assert.Nil(t, err)
+	assert.Equal(t, thumbprint, res)
+
+	pemCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})
+	for _, value := range []string{url.PathEscape(string(pemCert)), base64.StdEncoding.EncodeToString(certificate.Raw)} {
+		forwarded, err := parseForwardedClientCertificate(value)
+		assert.Nil(t, err)
+		assert.Equal(t, thumbprint, getCertificateThumbprint(forwarded))
+	}
+
+	_, err = parseForwardedClientCertificate("not a certificate")
+	assert.NotNil(t, err)
+
+	assert.True(t, IsTokenCertificateMatched(&Token{}, ""))
+	assert.True(t, IsTokenCertificateMatched(&Token{CertThumbprint: thumbprint}, thumbprint))
+	assert.False(t, IsTokenCertificateMatched(&Token{CertThumbprint: thumbprint}, ""))
+}
+
+func TestGetForwardedClientCertificateThumbprint(t *testing.T) {
+	os.Setenv("mtlsClientCertHeader", "X-Client-Cert")
+	defer os.Unsetenv("mtlsClientCertHeader")
+	os.Setenv("trustedProxies", "10.0.0.0/8")
+	defer os.Unsetenv("trustedProxies")
+
+	certificate := newTestClientCertificate(t)
+	value := base64.StdEncoding.EncodeToString(certificate.Raw)
+
+	// anyone can put a public certificate in the header, only the trusted proxies are honored
+	req := httptest.NewRequest("POST", "/api/login/oauth/access_token", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+	req.Header.Set("X-Client-Cert", value)
+	res, err := GetClientCertificateThumbprint(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "", res)
+
+	req.RemoteAddr = "10.0.0.2:1234"
+	res, err = GetClientCertificateThumbprint(req)
+	assert.Nil(t, err)
+	assert.Equal(t, getCertificateThumbprint(certificate), res)
+}
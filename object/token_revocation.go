@@ -0,0 +1,130 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	AccessTokenTypeHint  = "access_token"
+	RefreshTokenTypeHint = "refresh_token"
+)
+
+// getTokenRemainingLifetime returns how long the token issued at the created time lasts for the hours,
+// the revoked token is kept in the denylist for that long
+func getTokenRemainingLifetime(createdTime string, hours int) time.Duration {
+	created, err := time.Parse(time.RFC3339, createdTime)
+	if err != nil {
+		return time.Duration(hours) * time.Hour
+	}
+	return time.Until(created.Add(time.Duration(hours) * time.Hour))
+}
+
+func denylistToken(application *Application, token *Token, isRefreshToken bool) error {
+	denylist := getTokenDenylist()
+
+	ttl := getTokenRemainingLifetime(token.CreatedTime, application.ExpireInHours)
+	if ttl > 0 {
+		err := denylist.Add(getTokenDenylistKey(AccessTokenTypeHint, token.GetId()), ttl)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !isRefreshToken {
+		return nil
+	}
+
+	refreshExpireInHours := application.RefreshExpireInHours
+	if refreshExpireInHours == 0 {
+		refreshExpireInHours = application.ExpireInHours
+	}
+	ttl = getTokenRemainingLifetime(token.CreatedTime, refreshExpireInHours)
+	if ttl > 0 {
+		return denylist.Add(getTokenDenylistKey(RefreshTokenTypeHint, token.GetId()), ttl)
+	}
+	return nil
+}
+
+func getTokenByValue(tokenValue string, tokenTypeHint string) (*Token, bool, error) {
+	getters := []func(string) (*Token, error){GetTokenByAccessToken, GetTokenByRefreshToken}
+	if tokenTypeHint == RefreshTokenTypeHint {
+		getters = []func(string) (*Token, error){GetTokenByRefreshToken, GetTokenByAccessToken}
+	}
+
+	for _, getter := range getters {
+		token, err := getter(tokenValue)
+		if err != nil {
+			return nil, false, err
+		}
+		if token != nil {
+			return token, token.RefreshToken == tokenValue, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// RevokeToken revokes the access token or refresh token of the application (RFC 7009). Revoking a refresh token
+// deletes the token and revokes the access token issued with it, revoking an access token expires it only.
+// The revoked tokens are added to the denylist until they expire, an unknown token is ignored.
+func RevokeToken(application *Application, tokenValue string, tokenTypeHint string) (*TokenError, error) {
+	token, isRefreshToken, err := getTokenByValue(tokenValue, tokenTypeHint)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, nil
+	}
+
+	if token.Owner != application.Owner || token.Application != application.Name {
+		return &TokenError{
+			Error:            UnauthorizedClient,
+			ErrorDescription: "the token was not issued to the client",
+		}, nil
+	}
+
+	if isRefreshToken {
+		_, err = DeleteToken(token)
+	} else {
+		token.ExpiresIn = 0
+		_, err = ormer.Engine.ID(core.PK{token.Owner, token.Name}).Cols("expires_in").Update(token)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = denylistToken(application, token, isRefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// IsJwtTokenRevoked returns whether the parsed JWT of the stored token has been revoked or expired by logout
+func IsJwtTokenRevoked(token *Token, jwtToken *Claims) bool {
+	if jwtToken.TokenType == "refresh-token" {
+		return IsTokenDenylisted(RefreshTokenTypeHint, jwtToken.ID)
+	}
+
+	if isExpired, _ := util.IsTokenExpired(token.CreatedTime, token.ExpiresIn); isExpired {
+		return true
+	}
+	return IsTokenDenylisted(AccessTokenTypeHint, jwtToken.ID)
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryTokenDenylist(t *testing.T) {
+	denylist := &memoryTokenDenylist{entries: map[string]time.Time{}}
+
+	assert.Nil(t, denylist.Add("access_token/admin/token1", time.Hour))
+	assert.Nil(t, denylist.Add("access_token/admin/token2", -time.Second))
+
+	res, _ := denylist.Contains("access_token/admin/token1")
+	assert.True(t, res)
+	res, _ = denylist.Contains("access_token/admin/token2")
+	assert.False(t, res)
+	res, _ = denylist.Contains("refresh_token/admin/token1")
+	assert.False(t, res)
+}
+
+func TestDenylistToken(t *testing.T) {
+	application := &Application{Owner: "admin", Name: "app1", ExpireInHours: 1, RefreshExpireInHours: 24}
+
+	accessOnly := &Token{Owner: "admin", Name: "revoke-test-1", CreatedTime: util.GetCurrentTime(), ExpiresIn: 3600}
+	assert.Nil(t, denylistToken(application, accessOnly, false))
+	assert.True(t, IsJwtTokenRevoked(accessOnly, &Claims{TokenType: "access-token", RegisteredClaims: jwt.RegisteredClaims{ID: accessOnly.GetId()}}))
+	assert.False(t, IsJwtTokenRevoked(accessOnly, &Claims{TokenType: "refresh-token", RegisteredClaims: jwt.RegisteredClaims{ID: accessOnly.GetId()}}))
+
+	// revoking the refresh token also revokes the access token issued with it
+	pair := &Token{Owner: "admin", Name: "revoke-test-2", CreatedTime: time.Now().Add(-2 * time.Hour).Format(time.RFC3339), ExpiresIn: 3600}
+	assert.Nil(t, denylistToken(application, pair, true))
+	assert.False(t, IsTokenDenylisted(AccessTokenTypeHint, pair.GetId()))
+	assert.True(t, IsTokenDenylisted(RefreshTokenTypeHint, pair.GetId()))
+
+	active := &Token{Owner: "admin", Name: "revoke-test-3", CreatedTime: util.GetCurrentTime(), ExpiresIn: 3600}
+	assert.False(t, IsJwtTokenRevoked(active, &Claims{TokenType: "access-token", RegisteredClaims: jwt.RegisteredClaims{ID: active.GetId()}}))
+	active.ExpiresIn = 0
+	assert.True(t, IsJwtTokenRevoked(active, &Claims{TokenType: "access-token", RegisteredClaims: jwt.RegisteredClaims{ID: active.GetId()}}))
+}
@@ -0,0 +1,337 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	TranslationCategoryBackend  = "backend"
+	TranslationCategoryFrontend = "frontend"
+
+	CacheTypeTranslation = "translation"
+)
+
+// Translation overrides the translation of a key of the locale files, or adds a new key, for an organization,
+// e.g. "organization:Organization" translated to "Company". The backend translations are used by the API
+// messages and the frontend ones are loaded by the web UI.
+type Translation struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	Category  string `xorm:"varchar(100) index" json:"category"`
+	Language  string `xorm:"varchar(100) index" json:"language"`
+	Namespace string `xorm:"varchar(100)" json:"namespace"`
+	Key       string `xorm:"varchar(1000)" json:"key"`
+	Value     string `xorm:"mediumtext" json:"value"`
+}
+
+// TranslationPack is the translations of an organization for a category and a language, as exported and imported
+type TranslationPack struct {
+	Owner    string        `json:"owner"`
+	Category string        `json:"category"`
+	Language string        `json:"language"`
+	Data     i18n.I18nData `json:"data"`
+}
+
+var (
+	// translationCache caches the translations by the organization, the category and the language
+	translationCache      = map[string]i18n.I18nData{}
+	translationCacheMutex sync.RWMutex
+)
+
+type translationBackend struct{}
+
+func (b *translationBackend) GetTranslation(organization string, language string, namespace string, key string) (string, bool) {
+	data, err := getCachedTranslationData(organization, TranslationCategoryBackend, language)
+	if err != nil {
+		return "", false
+	}
+
+	res, ok := data[namespace][key]
+	return res, ok
+}
+
+// InitTranslationBackend overrides the translations of the API messages by the ones of the organizations
+func InitTranslationBackend() {
+	i18n.SetBackend(&translationBackend{})
+
+	RegisterCacheInvalidationHandler(CacheTypeTranslation, func(key string) {
+		clearTranslationCache(key)
+	})
+}
+
+func getTranslationCacheKey(owner string, category string, language string) string {
+	return fmt.Sprintf("%s/%s/%s", owner, category, language)
+}
+
+func getCachedTranslationData(owner string, category string, language string) (i18n.I18nData, error) {
+	cacheKey := getTranslationCacheKey(owner, category, language)
+	translationCacheMutex.RLock()
+	data, ok := translationCache[cacheKey]
+	translationCacheMutex.RUnlock()
+	if ok {
+		return data, nil
+	}
+
+	data, err := GetTranslationData(owner, category, language)
+	if err != nil {
+		return nil, err
+	}
+
+	translationCacheMutex.Lock()
+	translationCache[cacheKey] = data
+	translationCacheMutex.Unlock()
+	return data, nil
+}
+
+// clearTranslationCache drops the cached translations of the organization, or all of them if it is empty
+func clearTranslationCache(owner string) {
+	translationCacheMutex.Lock()
+	defer translationCacheMutex.Unlock()
+
+	for cacheKey := range translationCache {
+		if owner == "" || strings.HasPrefix(cacheKey, owner+"/") {
+			delete(translationCache, cacheKey)
+		}
+	}
+}
+
+func onTranslationChanged(owner string) {
+	clearTranslationCache(owner)
+	publishCacheInvalidation(CacheTypeTranslation, owner)
+}
+
+func GetTranslationCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&Translation{})
+}
+
+func GetTranslations(owner string) ([]*Translation, error) {
+	translations := []*Translation{}
+	err := ormer.Engine.Desc("created_time").Find(&translations, &Translation{Owner: owner})
+	if err != nil {
+		return translations, err
+	}
+
+	return translations, nil
+}
+
+func GetPaginationTranslations(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*Translation, error) {
+	translations := []*Translation{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&translations)
+	if err != nil {
+		return translations, err
+	}
+
+	return translations, nil
+}
+
+func getTranslation(owner string, name string) (*Translation, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	translation := Translation{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&translation)
+	if err != nil {
+		return &translation, err
+	}
+
+	if existed {
+		return &translation, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetTranslation(id string) (*Translation, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getTranslation(owner, name)
+}
+
+func getTranslationByKey(owner string, category string, language string, namespace string, key string) (*Translation, error) {
+	translation := Translation{Owner: owner, Category: category, Language: language, Namespace: namespace, Key: key}
+	existed, err := ormer.Engine.Get(&translation)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &translation, nil
+	}
+	return nil, nil
+}
+
+// GetTranslationData returns the translations of the organization for the category and the language
+func GetTranslationData(owner string, category string, language string) (i18n.I18nData, error) {
+	translations := []*Translation{}
+	err := ormer.Engine.Find(&translations, &Translation{Owner: owner, Category: category, Language: language})
+	if err != nil {
+		return nil, err
+	}
+
+	data := i18n.I18nData{}
+	for _, translation := range translations {
+		if data[translation.Namespace] == nil {
+			data[translation.Namespace] = map[string]string{}
+		}
+		data[translation.Namespace][translation.Key] = translation.Value
+	}
+	return data, nil
+}
+
+func checkTranslation(translation *Translation) error {
+	if translation.Category != TranslationCategoryBackend && translation.Category != TranslationCategoryFrontend {
+		return fmt.Errorf("the translation category: %s is not supported", translation.Category)
+	}
+	if translation.Language == "" || translation.Namespace == "" || translation.Key == "" {
+		return fmt.Errorf("the language, the namespace and the key of the translation should not be empty")
+	}
+
+	existing, err := getTranslationByKey(translation.Owner, translation.Category, translation.Language, translation.Namespace, translation.Key)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Name != translation.Name {
+		return fmt.Errorf("the translation of the key: %s:%s already exists", translation.Namespace, translation.Key)
+	}
+	return nil
+}
+
+func UpdateTranslation(id string, translation *Translation) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if t, err := getTranslation(owner, name); err != nil {
+		return false, err
+	} else if t == nil {
+		return false, nil
+	}
+
+	err := checkTranslation(translation)
+	if err != nil {
+		return false, err
+	}
+
+	translation.UpdatedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(translation)
+	if err != nil {
+		return false, err
+	}
+
+	onTranslationChanged(owner)
+	if translation.Owner != owner {
+		onTranslationChanged(translation.Owner)
+	}
+	return affected != 0, nil
+}
+
+func AddTranslation(translation *Translation) (bool, error) {
+	err := checkTranslation(translation)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.Insert(translation)
+	if err != nil {
+		return false, err
+	}
+
+	onTranslationChanged(translation.Owner)
+	return affected != 0, nil
+}
+
+func DeleteTranslation(translation *Translation) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{translation.Owner, translation.Name}).Delete(&Translation{})
+	if err != nil {
+		return false, err
+	}
+
+	onTranslationChanged(translation.Owner)
+	return affected != 0, nil
+}
+
+func (translation *Translation) GetId() string {
+	return fmt.Sprintf("%s/%s", translation.Owner, translation.Name)
+}
+
+// ExportTranslations returns the translations of the organization as a pack, which can be imported into another one
+func ExportTranslations(owner string, category string, language string) (*TranslationPack, error) {
+	data, err := GetTranslationData(owner, category, language)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TranslationPack{Owner: owner, Category: category, Language: language, Data: data}, nil
+}
+
+// ImportTranslations adds the translations of the pack to the organization, the existing keys are overridden
+func ImportTranslations(pack *TranslationPack) (int, error) {
+	count := 0
+	for namespace, pairs := range pack.Data {
+		for key, value := range pairs {
+			translation, err := getTranslationByKey(pack.Owner, pack.Category, pack.Language, namespace, key)
+			if err != nil {
+				return count, err
+			}
+
+			isNew := translation == nil
+			if isNew {
+				translation = &Translation{
+					Owner:       pack.Owner,
+					Name:        util.GenerateId(),
+					CreatedTime: util.GetCurrentTime(),
+					Category:    pack.Category,
+					Language:    pack.Language,
+					Namespace:   namespace,
+					Key:         key,
+				}
+			} else if translation.Value == value {
+				continue
+			}
+
+			translation.Value = value
+			translation.UpdatedTime = util.GetCurrentTime()
+			err = checkTranslation(translation)
+			if err != nil {
+				return count, err
+			}
+
+			if isNew {
+				_, err = ormer.Engine.Insert(translation)
+			} else {
+				_, err = ormer.Engine.ID(core.PK{translation.Owner, translation.Name}).AllCols().Update(translation)
+			}
+			if err != nil {
+				return count, err
+			}
+			count += 1
+		}
+	}
+
+	if count != 0 {
+		onTranslationChanged(pack.Owner)
+	}
+	return count, nil
+}
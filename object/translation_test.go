@@ -0,0 +1,40 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClearTranslationCache(t *testing.T) {
+	translationCache[getTranslationCacheKey("acme", TranslationCategoryBackend, "en")] = i18n.I18nData{}
+	translationCache[getTranslationCacheKey("acme", TranslationCategoryFrontend, "en")] = i18n.I18nData{}
+	translationCache[getTranslationCacheKey("acme-2", TranslationCategoryBackend, "en")] = i18n.I18nData{}
+
+	clearTranslationCache("acme")
+	assert.Equal(t, 1, len(translationCache))
+	assert.NotNil(t, translationCache["acme-2/backend/en"])
+
+	clearTranslationCache("")
+	assert.Equal(t, 0, len(translationCache))
+}
+
+func TestCheckTranslationCategory(t *testing.T) {
+	err := checkTranslation(&Translation{Owner: "acme", Category: "mobile", Language: "en", Namespace: "general", Key: "Organization"})
+	assert.NotNil(t, err)
+}
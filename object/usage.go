@@ -0,0 +1,456 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/pp"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// the usage metrics of the organizations, which can be billed by the payment providers
+const (
+	UsageMetricMau     = "MAU"
+	UsageMetricToken   = "Token"
+	UsageMetricEnforce = "Enforce"
+)
+
+const (
+	usagePeriodLayout   = "2006-01"
+	usageFlushInterval  = time.Minute
+	usageReportInterval = time.Hour
+)
+
+// Usage is the usage of an organization (the owner) in a monthly period (the name, e.g. "2023-06")
+type Usage struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	Mau          int64 `json:"mau"`
+	TokenCount   int64 `json:"tokenCount"`
+	EnforceCount int64 `json:"enforceCount"`
+
+	ReportedTime time.Time `json:"reportedTime"`
+	ReportError  string    `xorm:"varchar(1000)" json:"reportError"`
+}
+
+// UsageUser is a user active in a usage period, so that each user is counted only once in the MAU
+type UsageUser struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Period      string `xorm:"varchar(100) notnull pk" json:"period"`
+	User        string `xorm:"varchar(100) notnull pk" json:"user"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+}
+
+// UsageBilling reports the usage of the organization to the payment provider as metered billing records
+type UsageBilling struct {
+	IsEnabled bool   `json:"isEnabled"`
+	Provider  string `json:"provider"`
+	// SubscriptionItems maps the usage metrics to the metered subscription items of the provider
+	SubscriptionItems map[string]string `json:"subscriptionItems"`
+}
+
+type usageKey struct {
+	owner  string
+	period string
+}
+
+type usageCounter struct {
+	tokenCount   int64
+	enforceCount int64
+	users        map[string]bool
+}
+
+var (
+	usageMutex    sync.Mutex
+	usageCounters = map[usageKey]*usageCounter{}
+	// the users already counted by this instance in the current period
+	usagePeriod      string
+	usageActiveUsers = map[string]bool{}
+)
+
+func getUsagePeriod(t time.Time) string {
+	return t.UTC().Format(usagePeriodLayout)
+}
+
+func getUsagePeriodRange(period string) (time.Time, time.Time, error) {
+	start, err := time.Parse(usagePeriodLayout, period)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("the usage period: %s is invalid, it should be like: 2023-06", period)
+	}
+	return start, start.AddDate(0, 1, 0), nil
+}
+
+func getUsageCounter(key usageKey) *usageCounter {
+	counter, ok := usageCounters[key]
+	if !ok {
+		counter = &usageCounter{users: map[string]bool{}}
+		usageCounters[key] = counter
+	}
+	return counter
+}
+
+func recordUsage(owner string, user string, tokenCount int64, enforceCount int64) {
+	if owner == "" {
+		return
+	}
+
+	key := usageKey{owner: owner, period: getUsagePeriod(time.Now())}
+
+	usageMutex.Lock()
+	defer usageMutex.Unlock()
+
+	counter := getUsageCounter(key)
+	counter.tokenCount += tokenCount
+	counter.enforceCount += enforceCount
+
+	if user != "" {
+		if usagePeriod != key.period {
+			usagePeriod = key.period
+			usageActiveUsers = map[string]bool{}
+		}
+
+		userId := util.GetId(owner, user)
+		if !usageActiveUsers[userId] {
+			usageActiveUsers[userId] = true
+			counter.users[user] = true
+		}
+	}
+}
+
+// RecordActiveUser counts the user signing in to the MAU of the organization
+func RecordActiveUser(owner string, user string) {
+	recordUsage(owner, user, 0, 0)
+}
+
+// RecordEnforceUsage counts the enforce calls against the permissions of the organization
+func RecordEnforceUsage(owner string, count int) {
+	recordUsage(owner, "", 0, int64(count))
+}
+
+func recordTokenUsage(token *Token) {
+	// the client credentials tokens are issued to the application itself
+	user := token.User
+	if user == token.Application {
+		user = ""
+	}
+
+	recordUsage(token.Organization, user, 1, 0)
+}
+
+func takeUsageCounters() map[usageKey]*usageCounter {
+	usageMutex.Lock()
+	defer usageMutex.Unlock()
+
+	counters := usageCounters
+	usageCounters = map[usageKey]*usageCounter{}
+	return counters
+}
+
+// restoreUsageCounter puts back the counter failed to be flushed, to be flushed again later
+func restoreUsageCounter(key usageKey, counter *usageCounter) {
+	usageMutex.Lock()
+	defer usageMutex.Unlock()
+
+	current := getUsageCounter(key)
+	current.tokenCount += counter.tokenCount
+	current.enforceCount += counter.enforceCount
+	for user := range counter.users {
+		current.users[user] = true
+	}
+}
+
+// addUsageUsers adds the active users of the period, the added ones are removed from the users
+func addUsageUsers(key usageKey, users map[string]bool) error {
+	for user := range users {
+		usageUser := &UsageUser{Owner: key.owner, Period: key.period, User: user}
+		existed, err := ormer.Engine.Exist(usageUser)
+		if err != nil {
+			return err
+		}
+
+		if !existed {
+			usageUser.CreatedTime = util.GetCurrentTime()
+			_, err = ormer.Engine.Insert(usageUser)
+			if err != nil {
+				return err
+			}
+		}
+
+		delete(users, user)
+	}
+	return nil
+}
+
+func addUsage(key usageKey, counter *usageCounter) error {
+	err := addUsageUsers(key, counter.users)
+	if err != nil {
+		return err
+	}
+
+	// the MAU is counted from the active users, so that it is right with multiple instances
+	mau, err := ormer.Engine.Count(&UsageUser{Owner: key.owner, Period: key.period})
+	if err != nil {
+		return err
+	}
+
+	existed, err := ormer.Engine.Exist(&Usage{Owner: key.owner, Name: key.period})
+	if err != nil {
+		return err
+	}
+	if !existed {
+		_, err = ormer.Engine.Insert(&Usage{Owner: key.owner, Name: key.period, CreatedTime: util.GetCurrentTime()})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = ormer.Engine.ID(core.PK{key.owner, key.period}).
+		Incr("token_count", counter.tokenCount).
+		Incr("enforce_count", counter.enforceCount).
+		Cols("mau", "updated_time").
+		Update(&Usage{Mau: mau, UpdatedTime: util.GetCurrentTime()})
+	return err
+}
+
+// flushUsage adds the usage counted in memory to the database
+func flushUsage() error {
+	var res error
+	for key, counter := range takeUsageCounters() {
+		err := addUsage(key, counter)
+		if err != nil {
+			restoreUsageCounter(key, counter)
+			res = err
+		}
+	}
+	return res
+}
+
+func getUsage(owner string, period string) (*Usage, error) {
+	usage := Usage{Owner: owner, Name: period}
+	existed, err := ormer.Engine.Get(&usage)
+	if err != nil {
+		return nil, err
+	}
+	if !existed {
+		return nil, nil
+	}
+	return &usage, nil
+}
+
+// GetUsage returns the usage of the organization in the period, the current period is used if empty
+func GetUsage(owner string, period string) (*Usage, error) {
+	if period == "" {
+		period = getUsagePeriod(time.Now())
+	}
+
+	_, _, err := getUsagePeriodRange(period)
+	if err != nil {
+		return nil, err
+	}
+
+	err = flushUsage()
+	if err != nil {
+		return nil, err
+	}
+
+	usage, err := getUsage(owner, period)
+	if err != nil {
+		return nil, err
+	}
+	if usage == nil {
+		usage = &Usage{Owner: owner, Name: period}
+	}
+	return usage, nil
+}
+
+func (usage *Usage) getMetric(metric string) (int64, error) {
+	switch metric {
+	case UsageMetricMau:
+		return usage.Mau, nil
+	case UsageMetricToken:
+		return usage.TokenCount, nil
+	case UsageMetricEnforce:
+		return usage.EnforceCount, nil
+	default:
+		return 0, fmt.Errorf("the usage metric: %s is not supported", metric)
+	}
+}
+
+func checkUsageBilling(usageBilling *UsageBilling) error {
+	if usageBilling == nil {
+		return nil
+	}
+
+	for metric := range usageBilling.SubscriptionItems {
+		_, err := (&Usage{}).getMetric(metric)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getUsageReporter(owner string, providerName string) (pp.UsageReporter, error) {
+	provider, err := getProvider(owner, providerName)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, fmt.Errorf("the payment provider: %s does not exist", providerName)
+	}
+
+	paymentProvider, err := GetPaymentProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	usageReporter, ok := paymentProvider.(pp.UsageReporter)
+	if !ok {
+		return nil, fmt.Errorf("the payment provider: %s doesn't support the metered billing", providerName)
+	}
+	return usageReporter, nil
+}
+
+// getUsageReportTime returns the time of the usage record, which must be within the period
+func getUsageReportTime(period string, now time.Time) (time.Time, error) {
+	_, end, err := getUsagePeriodRange(period)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if now.Before(end) {
+		return now, nil
+	}
+	return end.Add(-time.Second), nil
+}
+
+// reportUsage sets the total usage of the period to the metered subscription items
+func reportUsage(usageReporter pp.UsageReporter, usageBilling *UsageBilling, usage *Usage, now time.Time) error {
+	timestamp, err := getUsageReportTime(usage.Name, now)
+	if err != nil {
+		return err
+	}
+
+	errs := []string{}
+	for metric, subscriptionItem := range usageBilling.SubscriptionItems {
+		if subscriptionItem == "" {
+			continue
+		}
+
+		quantity, err := usage.getMetric(metric)
+		if err == nil {
+			err = usageReporter.ReportUsage(subscriptionItem, quantity, timestamp)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", metric, err.Error()))
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func reportOrganizationUsage(organization *Organization, now time.Time) error {
+	usageReporter, err := getUsageReporter(organization.Name, organization.UsageBilling.Provider)
+	if err != nil {
+		return err
+	}
+
+	// the previous period is reported once more after its end, for the usage flushed after the last report
+	currentPeriod := getUsagePeriod(now)
+	currentStart, _, err := getUsagePeriodRange(currentPeriod)
+	if err != nil {
+		return err
+	}
+
+	for _, period := range []string{getUsagePeriod(currentStart.Add(-time.Second)), currentPeriod} {
+		usage, err := getUsage(organization.Name, period)
+		if err != nil {
+			return err
+		}
+		if usage == nil || (period != currentPeriod && !usage.ReportedTime.Before(currentStart)) {
+			continue
+		}
+
+		usage.ReportError = ""
+		reportErr := reportUsage(usageReporter, organization.UsageBilling, usage, now)
+		if reportErr != nil {
+			usage.ReportError = reportErr.Error()
+		} else {
+			usage.ReportedTime = now
+		}
+
+		_, err = ormer.Engine.ID(core.PK{usage.Owner, usage.Name}).Cols("reported_time", "report_error").Update(usage)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func reportUsages(now time.Time) error {
+	organizations := []*Organization{}
+	err := ormer.Engine.Find(&organizations)
+	if err != nil {
+		return err
+	}
+
+	for _, organization := range organizations {
+		if organization.UsageBilling == nil || !organization.UsageBilling.IsEnabled {
+			continue
+		}
+
+		err = reportOrganizationUsage(organization, now)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to report the usage of the organization: %s, error: %s", organization.Name, err.Error()))
+		}
+	}
+	return nil
+}
+
+// RunUsageJob flushes the usage counted in memory to the database and reports it to the payment providers
+// of the organizations with the metered billing enabled
+func RunUsageJob() {
+	lastReportTime := time.Time{}
+	for {
+		time.Sleep(usageFlushInterval)
+
+		err := flushUsage()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to flush the usage, error: %s", err.Error()))
+		}
+
+		if time.Since(lastReportTime) < usageReportInterval {
+			continue
+		}
+
+		lastReportTime = time.Now()
+		err = reportUsages(lastReportTime)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to report the usage, error: %s", err.Error()))
+		}
+	}
+}
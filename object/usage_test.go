@@ -0,0 +1,101 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeUsageReporter struct {
+	records map[string]int64
+	times   []time.Time
+}
+
+func (r *fakeUsageReporter) ReportUsage(subscriptionItem string, quantity int64, timestamp time.Time) error {
+	if subscriptionItem == "si_broken" {
+		return fmt.Errorf("no such subscription item")
+	}
+
+	r.records[subscriptionItem] = quantity
+	r.times = append(r.times, timestamp)
+	return nil
+}
+
+func TestRecordUsage(t *testing.T) {
+	takeUsageCounters()
+
+	RecordActiveUser("org-1", "alice")
+	RecordActiveUser("org-1", "alice")
+	RecordEnforceUsage("org-1", 3)
+	RecordEnforceUsage("", 1)
+	recordTokenUsage(&Token{Organization: "org-1", Application: "app-1", User: "bob"})
+	recordTokenUsage(&Token{Organization: "org-1", Application: "app-1", User: "app-1"})
+
+	counters := takeUsageCounters()
+	assert.Len(t, counters, 1)
+
+	counter := counters[usageKey{owner: "org-1", period: getUsagePeriod(time.Now())}]
+	assert.Equal(t, int64(2), counter.tokenCount)
+	assert.Equal(t, int64(3), counter.enforceCount)
+	assert.Equal(t, map[string]bool{"alice": true, "bob": true}, counter.users)
+
+	// the users are counted only once per period
+	RecordActiveUser("org-1", "alice")
+	restoreUsageCounter(usageKey{owner: "org-1", period: "2023-06"}, &usageCounter{tokenCount: 1, users: map[string]bool{"carol": true}})
+	counters = takeUsageCounters()
+	assert.Len(t, counters, 2)
+	assert.Empty(t, counters[usageKey{owner: "org-1", period: getUsagePeriod(time.Now())}].users)
+	assert.Equal(t, map[string]bool{"carol": true}, counters[usageKey{owner: "org-1", period: "2023-06"}].users)
+}
+
+func TestGetUsageReportTime(t *testing.T) {
+	now := time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	reportTime, err := getUsageReportTime("2023-06", now)
+	assert.Nil(t, err)
+	assert.Equal(t, now, reportTime)
+
+	// the final report of the previous period is at its last second
+	reportTime, err = getUsageReportTime("2023-05", now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2023, 5, 31, 23, 59, 59, 0, time.UTC), reportTime)
+
+	_, err = getUsageReportTime("2023-6", now)
+	assert.NotNil(t, err)
+}
+
+func TestReportUsage(t *testing.T) {
+	now := time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC)
+	usage := &Usage{Owner: "org-1", Name: "2023-06", Mau: 10, TokenCount: 20, EnforceCount: 30}
+	reporter := &fakeUsageReporter{records: map[string]int64{}}
+
+	usageBilling := &UsageBilling{SubscriptionItems: map[string]string{UsageMetricMau: "si_mau", UsageMetricEnforce: "si_enforce", UsageMetricToken: ""}}
+	assert.Nil(t, reportUsage(reporter, usageBilling, usage, now))
+	assert.Equal(t, map[string]int64{"si_mau": 10, "si_enforce": 30}, reporter.records)
+	assert.Equal(t, []time.Time{now, now}, reporter.times)
+
+	usageBilling.SubscriptionItems[UsageMetricToken] = "si_broken"
+	assert.NotNil(t, reportUsage(reporter, usageBilling, usage, now))
+}
+
+func TestCheckUsageBilling(t *testing.T) {
+	assert.Nil(t, checkUsageBilling(nil))
+	assert.Nil(t, checkUsageBilling(&UsageBilling{SubscriptionItems: map[string]string{UsageMetricMau: "si_1"}}))
+	assert.NotNil(t, checkUsageBilling(&UsageBilling{SubscriptionItems: map[string]string{"Unknown": "si_1"}}))
+}
@@ -19,6 +19,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/beego/beego/logs"
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/util"
 	"github.com/go-webauthn/webauthn/webauthn"
@@ -42,6 +43,13 @@ func InitUserManager() {
 	}
 
 	userEnforcer = NewUserGroupEnforcer(enforcer.Enforcer)
+
+	RegisterCacheInvalidationHandler(CacheTypeUserGroup, func(key string) {
+		err := userEnforcer.enforcer.LoadPolicy()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to reload the user groups: %v", err))
+		}
+	})
 }
 
 type User struct {
@@ -64,6 +72,8 @@ type User struct {
 	PermanentAvatar   string   `xorm:"varchar(500)" json:"permanentAvatar"`
 	Email             string   `xorm:"varchar(100) index" json:"email"`
 	EmailVerified     bool     `json:"emailVerified"`
+	EmailBounce       string   `xorm:"varchar(100)" json:"emailBounce"`
+	BackupEmail       string   `xorm:"varchar(100)" json:"backupEmail"`
 	Phone             string   `xorm:"varchar(20) index" json:"phone"`
 	CountryCode       string   `xorm:"varchar(6)" json:"countryCode"`
 	Region            string   `xorm:"varchar(100)" json:"region"`
@@ -173,6 +183,7 @@ type User struct {
 	Zoom            string `xorm:"zoom varchar(100)" json:"zoom"`
 	MetaMask        string `xorm:"metamask varchar(100)" json:"metamask"`
 	Web3Onboard     string `xorm:"web3onboard varchar(100)" json:"web3onboard"`
+	Ethereum        string `xorm:"ethereum varchar(100)" json:"ethereum"`
 	Custom          string `xorm:"custom varchar(100)" json:"custom"`
 
 	WebauthnCredentials []webauthn.Credential `xorm:"webauthnCredentials blob" json:"webauthnCredentials"`
@@ -181,6 +192,9 @@ type User struct {
 	TotpSecret          string                `xorm:"varchar(100)" json:"totpSecret"`
 	MfaPhoneEnabled     bool                  `json:"mfaPhoneEnabled"`
 	MfaEmailEnabled     bool                  `json:"mfaEmailEnabled"`
+	MfaPushEnabled      bool                  `json:"mfaPushEnabled"`
+	MfaPushDevice       string                `xorm:"varchar(500)" json:"mfaPushDevice"`
+	MfaPushProvider     string                `xorm:"varchar(100)" json:"mfaPushProvider"`
 	MultiFactorAuths    []*MfaProps           `xorm:"-" json:"multiFactorAuths,omitempty"`
 
 	Ldap       string            `xorm:"ldap varchar(100)" json:"ldap"`
@@ -190,10 +204,27 @@ type User struct {
 	Permissions []*Permission `json:"permissions"`
 	Groups      []string      `xorm:"groups varchar(1000)" json:"groups"`
 
-	LastSigninWrongTime string `xorm:"varchar(100)" json:"lastSigninWrongTime"`
-	SigninWrongTimes    int    `json:"signinWrongTimes"`
+	LastSigninWrongTime string             `xorm:"varchar(100)" json:"lastSigninWrongTime"`
+	SigninWrongTimes    int                `json:"signinWrongTimes"`
+	SigninRestriction   *SigninRestriction `xorm:"json" json:"signinRestriction"`
+
+	IsSuspended      bool   `json:"isSuspended"`
+	SuspendedTime    string `xorm:"varchar(100)" json:"suspendedTime"`
+	SuspendedReason  string `xorm:"varchar(500)" json:"suspendedReason"`
+	ReactivationTime string `xorm:"varchar(100)" json:"reactivationTime"`
 
 	ManagedAccounts []ManagedAccount `xorm:"managedAccounts blob" json:"managedAccounts"`
+
+	LifecycleState     string `xorm:"varchar(100) index" json:"lifecycleState"`
+	LifecycleStateTime string `xorm:"varchar(100)" json:"lifecycleStateTime"`
+	LifecycleReason    string `xorm:"varchar(500)" json:"lifecycleReason"`
+	Manager            string `xorm:"varchar(100)" json:"manager"`
+	HireTime           string `xorm:"varchar(100)" json:"hireTime"`
+	TerminationTime    string `xorm:"varchar(100)" json:"terminationTime"`
+
+	RevocationEpoch int `json:"revocationEpoch"`
+
+	IdentityVerificationState string `xorm:"varchar(100)" json:"identityVerificationState"`
 }
 
 type Userinfo struct {
@@ -224,7 +255,7 @@ func GetGlobalUserCount(field, value string) (int64, error) {
 
 func GetGlobalUsers() ([]*User, error) {
 	users := []*User{}
-	err := ormer.Engine.Desc("created_time").Find(&users)
+	err := ormer.GetReadEngine().Desc("created_time").Find(&users)
 	if err != nil {
 		return nil, err
 	}
@@ -273,7 +304,7 @@ func GetOnlineUserCount(owner string, isOnline int) (int64, error) {
 
 func GetUsers(owner string) ([]*User, error) {
 	users := []*User{}
-	err := ormer.Engine.Desc("created_time").Find(&users, &User{Owner: owner})
+	err := ormer.GetReadEngine().Desc("created_time").Find(&users, &User{Owner: owner})
 	if err != nil {
 		return nil, err
 	}
@@ -401,7 +432,7 @@ func GetUserByEmail(owner string, email string) (*User, error) {
 	if existed {
 		return &user, nil
 	} else {
-		return nil, nil
+		return getUserByContact(owner, VerifyTypeEmail, email)
 	}
 }
 
@@ -437,7 +468,7 @@ func GetUserByPhone(owner string, phone string) (*User, error) {
 	if existed {
 		return &user, nil
 	} else {
-		return nil, nil
+		return getUserByContact(owner, VerifyTypePhone, phone)
 	}
 }
 
@@ -586,6 +617,24 @@ func getLastUser(owner string) (*User, error) {
 	return nil, nil
 }
 
+// defaultUserColumns are the columns updated by UpdateUser if the columns are not specified
+var defaultUserColumns = []string{
+	"owner", "display_name", "avatar", "first_name", "last_name",
+	"location", "address", "country_code", "region", "language", "affiliation", "title", "homepage", "bio", "tag", "language", "gender", "birthday", "education", "score", "karma", "ranking", "signup_application",
+	"is_admin", "is_forbidden", "is_deleted", "hash", "is_default_avatar", "properties", "webauthnCredentials", "managedAccounts",
+	"signin_wrong_times", "last_signin_wrong_time", "groups", "access_key", "access_secret",
+	"github", "google", "qq", "wechat", "facebook", "dingtalk", "weibo", "gitee", "linkedin", "wecom", "lark", "gitlab", "adfs",
+	"baidu", "alipay", "casdoor", "infoflow", "apple", "azuread", "slack", "steam", "bilibili", "okta", "douyin", "line", "amazon",
+	"auth0", "battlenet", "bitbucket", "box", "cloudfoundry", "dailymotion", "deezer", "digitalocean", "discord", "dropbox",
+	"eveonline", "fitbit", "gitea", "heroku", "influxcloud", "instagram", "intercom", "kakao", "lastfm", "mailru", "meetup",
+	"microsoftonline", "naver", "nextcloud", "onedrive", "oura", "patreon", "paypal", "salesforce", "shopify", "soundcloud",
+	"spotify", "strava", "stripe", "type", "tiktok", "tumblr", "twitch", "twitter", "typetalk", "uber", "vk", "wepay", "xero", "yahoo",
+	"yammer", "yandex", "zoom", "custom",
+}
+
+// adminUserColumns are the columns that only the admins can update
+var adminUserColumns = []string{"name", "email", "email_bounce", "backup_email", "phone", "country_code", "type", "signin_restriction", "manager", "hire_time", "termination_time"}
+
 func UpdateUser(id string, user *User, columns []string, isAdmin bool) (bool, error) {
 	var err error
 	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
@@ -616,29 +665,23 @@ func UpdateUser(id string, user *User, columns []string, isAdmin bool) (bool, er
 	}
 
 	if len(columns) == 0 {
-		columns = []string{
-			"owner", "display_name", "avatar", "first_name", "last_name",
-			"location", "address", "country_code", "region", "language", "affiliation", "title", "homepage", "bio", "tag", "language", "gender", "birthday", "education", "score", "karma", "ranking", "signup_application",
-			"is_admin", "is_forbidden", "is_deleted", "hash", "is_default_avatar", "properties", "webauthnCredentials", "managedAccounts",
-			"signin_wrong_times", "last_signin_wrong_time", "groups", "access_key", "access_secret",
-			"github", "google", "qq", "wechat", "facebook", "dingtalk", "weibo", "gitee", "linkedin", "wecom", "lark", "gitlab", "adfs",
-			"baidu", "alipay", "casdoor", "infoflow", "apple", "azuread", "slack", "steam", "bilibili", "okta", "douyin", "line", "amazon",
-			"auth0", "battlenet", "bitbucket", "box", "cloudfoundry", "dailymotion", "deezer", "digitalocean", "discord", "dropbox",
-			"eveonline", "fitbit", "gitea", "heroku", "influxcloud", "instagram", "intercom", "kakao", "lastfm", "mailru", "meetup",
-			"microsoftonline", "naver", "nextcloud", "onedrive", "oura", "patreon", "paypal", "salesforce", "shopify", "soundcloud",
-			"spotify", "strava", "stripe", "type", "tiktok", "tumblr", "twitch", "twitter", "typetalk", "uber", "vk", "wepay", "xero", "yahoo",
-			"yammer", "yandex", "zoom", "custom",
-		}
+		columns = append([]string{}, defaultUserColumns...)
 	}
 	if isAdmin {
-		columns = append(columns, "name", "email", "phone", "country_code", "type")
+		columns = append(columns, adminUserColumns...)
+	}
+
+	// the bounce of the old email doesn't apply to the new one
+	if user.Email != oldUser.Email && util.ContainsString(columns, "email") {
+		user.EmailBounce = ""
+		columns = append(columns, "email_bounce")
 	}
 
 	columns = append(columns, "updated_time")
 	user.UpdatedTime = util.GetCurrentTime()
 
 	if util.ContainsString(columns, "groups") {
-		_, err := userEnforcer.UpdateGroupsForUser(user.GetId(), user.Groups)
+		err = syncUserGroups(user.GetId(), oldUser.Groups, user.Groups)
 		if err != nil {
 			return false, err
 		}
@@ -649,6 +692,13 @@ func UpdateUser(id string, user *User, columns []string, isAdmin bool) (bool, er
 		return false, err
 	}
 
+	if affected != 0 {
+		if name != user.Name {
+			triggerUserProvisioning(owner, name, nil)
+		}
+		triggerUserProvisioning(user.Owner, user.Name, util.UnionStrings(oldUser.Groups, user.Groups))
+	}
+
 	return affected != 0, nil
 }
 
@@ -659,6 +709,11 @@ func updateUser(id string, user *User, columns []string) (int64, error) {
 		return 0, err
 	}
 
+	err = user.encryptSensitiveColumns(columns)
+	if err != nil {
+		return 0, err
+	}
+
 	affected, err := ormer.Engine.ID(core.PK{owner, name}).Cols(columns...).Update(user)
 	if err != nil {
 		return 0, err
@@ -666,6 +721,18 @@ func updateUser(id string, user *User, columns []string) (int64, error) {
 	return affected, nil
 }
 
+// syncUserGroups updates the groups of the user in the user group enforcer, which answers the group membership queries
+func syncUserGroups(userId string, oldGroups []string, groups []string) error {
+	_, err := userEnforcer.UpdateGroupsForUser(userId, groups)
+	if err != nil {
+		return err
+	}
+
+	publishCacheInvalidation(CacheTypeUserGroup, userId)
+	publishUserGroupWatchEvent(userId, oldGroups, groups)
+	return nil
+}
+
 func UpdateUserForAllFields(id string, user *User) (bool, error) {
 	var err error
 	owner, name := util.GetOwnerAndNameFromId(id)
@@ -699,11 +766,23 @@ func UpdateUserForAllFields(id string, user *User) (bool, error) {
 
 	user.UpdatedTime = util.GetCurrentTime()
 
+	err = user.encryptSensitiveFields()
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(user)
 	if err != nil {
 		return false, err
 	}
 
+	if affected != 0 {
+		if name != user.Name {
+			triggerUserProvisioning(owner, name, nil)
+		}
+		triggerUserProvisioning(user.Owner, user.Name, util.UnionStrings(oldUser.Groups, user.Groups))
+	}
+
 	return affected != 0, nil
 }
 
@@ -767,11 +846,20 @@ func AddUser(user *User) (bool, error) {
 	}
 	user.Ranking = int(count + 1)
 
+	err = user.encryptSensitiveFields()
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.Insert(user)
 	if err != nil {
 		return false, err
 	}
 
+	if affected != 0 {
+		triggerUserProvisioning(user.Owner, user.Name, user.Groups)
+	}
+
 	return affected != 0, nil
 }
 
@@ -796,6 +884,11 @@ func AddUsers(users []*User) (bool, error) {
 		if err != nil {
 			return false, err
 		}
+
+		err = user.encryptSensitiveFields()
+		if err != nil {
+			return false, err
+		}
 	}
 
 	affected, err := ormer.Engine.Insert(users)
@@ -847,6 +940,20 @@ func DeleteUser(user *User) (bool, error) {
 		return false, err
 	}
 
+	err = deleteUserContacts(user.Owner, user.Name)
+	if err != nil {
+		return false, err
+	}
+
+	_, err = ormer.Engine.ID(core.PK{user.Owner, user.Name}).Delete(&UserActivation{})
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 0 {
+		triggerUserProvisioning(user.Owner, user.Name, user.Groups)
+	}
+
 	return affected != 0, nil
 }
 
@@ -907,7 +1014,17 @@ func ExtendUserWithRolesAndPermissions(user *User) (err error) {
 }
 
 func DeleteGroupForUser(user string, group string) (bool, error) {
-	return userEnforcer.DeleteGroupForUser(user, group)
+	affected, err := userEnforcer.DeleteGroupForUser(user, group)
+	if err != nil {
+		return false, err
+	}
+
+	if affected {
+		publishCacheInvalidation(CacheTypeUserGroup, user)
+		publishUserGroupWatchEvent(user, []string{group}, nil)
+	}
+
+	return affected, nil
 }
 
 func userChangeTrigger(oldName string, newName string) error {
@@ -0,0 +1,279 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"sort"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+// UserAccess is everything a user can access in the organization, for the access reviews. Via tells how a role
+// or permission is granted: "user" if directly, "group:<id>" via a group, or "role:<id>" via a role.
+type UserAccess struct {
+	User             string                `json:"user"`
+	Roles            []*UserAccessRole     `json:"roles"`
+	Permissions      []*UserAccessItem     `json:"permissions"`
+	Enforcers        []*UserAccessEnforcer `json:"enforcers"`
+	EffectiveAccess  []*UserAccessResource `json:"effectiveAccess"`
+	PermissionsCount int                   `json:"permissionsCount"`
+}
+
+type UserAccessRole struct {
+	Id          string   `json:"id"`
+	DisplayName string   `json:"displayName"`
+	IsEnabled   bool     `json:"isEnabled"`
+	Via         []string `json:"via"`
+}
+
+type UserAccessItem struct {
+	Id           string   `json:"id"`
+	DisplayName  string   `json:"displayName"`
+	Model        string   `json:"model"`
+	ResourceType string   `json:"resourceType"`
+	Resources    []string `json:"resources"`
+	Actions      []string `json:"actions"`
+	Effect       string   `json:"effect"`
+	IsEnabled    bool     `json:"isEnabled"`
+	Via          []string `json:"via"`
+}
+
+type UserAccessEnforcer struct {
+	Id          string `json:"id"`
+	DisplayName string `json:"displayName"`
+	Model       string `json:"model"`
+	Adapter     string `json:"adapter"`
+}
+
+// UserAccessResource is the allowed actions on a resource after the denials of the enabled permissions
+type UserAccessResource struct {
+	ResourceType string   `json:"resourceType"`
+	Resource     string   `json:"resource"`
+	Actions      []string `json:"actions"`
+}
+
+func getViaGroups(groups []string, user *User) []string {
+	res := []string{}
+	for _, group := range groups {
+		if util.InSlice(user.Groups, group) || util.InSlice(user.Groups, util.GetId(user.Owner, group)) {
+			res = append(res, "group:"+group)
+		}
+	}
+	return res
+}
+
+// getUserAccessRoles returns the roles of the user, including the roles that contain them
+func getUserAccessRoles(user *User, roles []*Role) []*UserAccessRole {
+	userId := user.GetId()
+	roleMap := map[string]*UserAccessRole{}
+	queue := []string{}
+	for _, role := range roles {
+		via := getViaGroups(role.Groups, user)
+		if util.InSlice(role.Users, userId) {
+			via = append([]string{"user"}, via...)
+		}
+		if len(via) != 0 {
+			roleMap[role.GetId()] = &UserAccessRole{Id: role.GetId(), DisplayName: role.DisplayName, IsEnabled: role.IsEnabled, Via: via}
+			queue = append(queue, role.GetId())
+		}
+	}
+
+	for len(queue) != 0 {
+		subRoleId := queue[0]
+		queue = queue[1:]
+		for _, role := range roles {
+			if !util.InSlice(role.Roles, subRoleId) {
+				continue
+			}
+
+			if accessRole, ok := roleMap[role.GetId()]; ok {
+				if !util.InSlice(accessRole.Via, "role:"+subRoleId) {
+					accessRole.Via = append(accessRole.Via, "role:"+subRoleId)
+				}
+				continue
+			}
+
+			roleMap[role.GetId()] = &UserAccessRole{Id: role.GetId(), DisplayName: role.DisplayName, IsEnabled: role.IsEnabled, Via: []string{"role:" + subRoleId}}
+			queue = append(queue, role.GetId())
+		}
+	}
+
+	res := []*UserAccessRole{}
+	for _, role := range roleMap {
+		res = append(res, role)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Id < res[j].Id
+	})
+	return res
+}
+
+func getUserAccessItems(user *User, permissions []*Permission, roles []*UserAccessRole) []*UserAccessItem {
+	roleIds := []string{}
+	for _, role := range roles {
+		roleIds = append(roleIds, role.Id)
+	}
+
+	res := []*UserAccessItem{}
+	for _, permission := range permissions {
+		via := []string{}
+		if permission.isUserHit(user.GetId()) {
+			via = append(via, "user")
+		}
+		via = append(via, getViaGroups(permission.Groups, user)...)
+		for _, role := range permission.Roles {
+			if util.InSlice(roleIds, role) {
+				via = append(via, "role:"+role)
+			}
+		}
+		if len(via) == 0 {
+			continue
+		}
+
+		res = append(res, &UserAccessItem{
+			Id:           permission.GetId(),
+			DisplayName:  permission.DisplayName,
+			Model:        permission.Model,
+			ResourceType: permission.ResourceType,
+			Resources:    permission.Resources,
+			Actions:      permission.Actions,
+			Effect:       permission.Effect,
+			IsEnabled:    permission.IsEnabled,
+			Via:          via,
+		})
+	}
+	return res
+}
+
+func getUserAccessEnforcers(items []*UserAccessItem, enforcers []*Enforcer) []*UserAccessEnforcer {
+	res := []*UserAccessEnforcer{}
+	for _, enforcer := range enforcers {
+		for _, item := range items {
+			owner, _ := util.GetOwnerAndNameFromIdNoCheck(item.Id)
+			if enforcer.Model == util.GetId(owner, item.Model) || enforcer.Model == item.Model {
+				res = append(res, &UserAccessEnforcer{
+					Id:          enforcer.GetId(),
+					DisplayName: enforcer.DisplayName,
+					Model:       enforcer.Model,
+					Adapter:     enforcer.Adapter,
+				})
+				break
+			}
+		}
+	}
+	return res
+}
+
+// getEffectiveAccess returns the allowed actions of the enabled permissions per resource type and resource,
+// the denied actions (or "*" for all of them) are removed from the same resource, or all the resources for "*"
+func getEffectiveAccess(items []*UserAccessItem) []*UserAccessResource {
+	allowed := map[string]map[string][]string{}
+	for _, item := range items {
+		if !item.IsEnabled || item.Effect == "Deny" {
+			continue
+		}
+		if allowed[item.ResourceType] == nil {
+			allowed[item.ResourceType] = map[string][]string{}
+		}
+		for _, resource := range item.Resources {
+			for _, action := range item.Actions {
+				if !util.InSlice(allowed[item.ResourceType][resource], action) {
+					allowed[item.ResourceType][resource] = append(allowed[item.ResourceType][resource], action)
+				}
+			}
+		}
+	}
+
+	for _, item := range items {
+		if !item.IsEnabled || item.Effect != "Deny" {
+			continue
+		}
+		for resource, actions := range allowed[item.ResourceType] {
+			if !util.InSlice(item.Resources, "*") && !util.InSlice(item.Resources, resource) {
+				continue
+			}
+
+			remained := []string{}
+			if !util.InSlice(item.Actions, "*") {
+				for _, action := range actions {
+					if !util.InSlice(item.Actions, action) {
+						remained = append(remained, action)
+					}
+				}
+			}
+			allowed[item.ResourceType][resource] = remained
+		}
+	}
+
+	res := []*UserAccessResource{}
+	for resourceType, resources := range allowed {
+		for resource, actions := range resources {
+			if len(actions) == 0 {
+				continue
+			}
+			sort.Strings(actions)
+			res = append(res, &UserAccessResource{ResourceType: resourceType, Resource: resource, Actions: actions})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].ResourceType != res[j].ResourceType {
+			return res[i].ResourceType < res[j].ResourceType
+		}
+		return res[i].Resource < res[j].Resource
+	})
+	return res
+}
+
+// GetUserAccess returns the roles, permissions, enforcers and the effective access of the user in the organization,
+// the permissions are paginated by the offset and limit if the limit is positive.
+func GetUserAccess(user *User, offset int, limit int) (*UserAccess, error) {
+	roles, err := GetRoles(user.Owner)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := GetPermissions(user.Owner)
+	if err != nil {
+		return nil, err
+	}
+	enforcers, err := GetEnforcers(user.Owner)
+	if err != nil {
+		return nil, err
+	}
+
+	accessRoles := getUserAccessRoles(user, roles)
+	items := getUserAccessItems(user, permissions, accessRoles)
+
+	res := &UserAccess{
+		User:             user.GetId(),
+		Roles:            accessRoles,
+		Permissions:      items,
+		Enforcers:        getUserAccessEnforcers(items, enforcers),
+		EffectiveAccess:  getEffectiveAccess(items),
+		PermissionsCount: len(items),
+	}
+
+	if limit > 0 {
+		if offset > len(items) {
+			offset = len(items)
+		}
+		end := offset + limit
+		if end > len(items) {
+			end = len(items)
+		}
+		res.Permissions = items[offset:end]
+	}
+
+	return res, nil
+}
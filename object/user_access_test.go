@@ -0,0 +1,70 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUserAccess(t *testing.T) {
+	user := &User{Owner: "org1", Name: "alice", Groups: []string{"org1/dev"}}
+	roles := []*Role{
+		{Owner: "org1", Name: "reader", Users: []string{"org1/alice"}, IsEnabled: true},
+		{Owner: "org1", Name: "developer", Groups: []string{"org1/dev"}, IsEnabled: true},
+		{Owner: "org1", Name: "lead", Roles: []string{"org1/developer"}, IsEnabled: true},
+		{Owner: "org1", Name: "other", Users: []string{"org1/bob"}, IsEnabled: true},
+	}
+	permissions := []*Permission{
+		{Owner: "org1", Name: "p-direct", Users: []string{"org1/*"}, Model: "rbac", ResourceType: "Application", Resources: []string{"app1"}, Actions: []string{"Read", "Write"}, Effect: "Allow", IsEnabled: true},
+		{Owner: "org1", Name: "p-lead", Roles: []string{"org1/lead"}, Model: "rbac", ResourceType: "Application", Resources: []string{"app2"}, Actions: []string{"Admin"}, Effect: "Allow", IsEnabled: true},
+		{Owner: "org1", Name: "p-group", Groups: []string{"org1/dev"}, ResourceType: "Custom", Resources: []string{"/data"}, Actions: []string{"Read"}, Effect: "Allow", IsEnabled: true},
+		{Owner: "org1", Name: "p-deny", Roles: []string{"org1/reader"}, ResourceType: "Application", Resources: []string{"*"}, Actions: []string{"Write"}, Effect: "Deny", IsEnabled: true},
+		{Owner: "org1", Name: "p-disabled", Users: []string{"org1/alice"}, ResourceType: "Application", Resources: []string{"app3"}, Actions: []string{"Read"}, Effect: "Allow", IsEnabled: false},
+		{Owner: "org1", Name: "p-other", Roles: []string{"org1/other"}, ResourceType: "Application", Resources: []string{"app4"}, Actions: []string{"Read"}, Effect: "Allow", IsEnabled: true},
+	}
+	enforcers := []*Enforcer{
+		{Owner: "org1", Name: "e-rbac", Model: "org1/rbac"},
+		{Owner: "org1", Name: "e-abac", Model: "org1/abac"},
+	}
+
+	accessRoles := getUserAccessRoles(user, roles)
+	assert.Equal(t, []*UserAccessRole{
+		{Id: "org1/developer", IsEnabled: true, Via: []string{"group:org1/dev"}},
+		{Id: "org1/lead", IsEnabled: true, Via: []string{"role:org1/developer"}},
+		{Id: "org1/reader", IsEnabled: true, Via: []string{"user"}},
+	}, accessRoles)
+
+	items := getUserAccessItems(user, permissions, accessRoles)
+	ids := []string{}
+	for _, item := range items {
+		ids = append(ids, item.Id)
+	}
+	assert.Equal(t, []string{"org1/p-direct", "org1/p-lead", "org1/p-group", "org1/p-deny", "org1/p-disabled"}, ids)
+	assert.Equal(t, []string{"role:org1/lead"}, items[1].Via)
+
+	enforcerIds := []string{}
+	for _, enforcer := range getUserAccessEnforcers(items, enforcers) {
+		enforcerIds = append(enforcerIds, enforcer.Id)
+	}
+	assert.Equal(t, []string{"org1/e-rbac"}, enforcerIds)
+
+	assert.Equal(t, []*UserAccessResource{
+		{ResourceType: "Application", Resource: "app1", Actions: []string{"Read"}},
+		{ResourceType: "Application", Resource: "app2", Actions: []string{"Admin"}},
+		{ResourceType: "Custom", Resource: "/data", Actions: []string{"Read"}},
+	}, getEffectiveAccess(items))
+}
@@ -0,0 +1,300 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+const (
+	UserActivationStatePending   = "Pending"
+	UserActivationStateActivated = "Activated"
+	UserActivationStateExpired   = "Expired"
+
+	defaultUserActivationExpireInDays = 7
+)
+
+// UserActivation is the activation of a user pre-registered by the admin. The user gets an activation link by Email to
+// set its own password, and can't sign in until then. The unactivated user is forbidden once the link expires.
+// After the activation the user has to enroll MFA if it is required, and to accept the legal documents of the
+// application like any other user.
+type UserActivation struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	CreatedBy   string `xorm:"varchar(100)" json:"createdBy"`
+
+	Application   string `xorm:"varchar(100)" json:"application"`
+	CodeHash      string `xorm:"varchar(100)" json:"-"`
+	ExpireInDays  int    `json:"expireInDays"`
+	ExpireTime    string `xorm:"varchar(100) index" json:"expireTime"`
+	RequireMfa    bool   `json:"requireMfa"`
+	State         string `xorm:"varchar(100) index" json:"state"`
+	ActivatedTime string `xorm:"varchar(100)" json:"activatedTime"`
+}
+
+func GetUserActivationCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&UserActivation{})
+}
+
+func GetPaginationUserActivations(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*UserActivation, error) {
+	userActivations := []*UserActivation{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&userActivations)
+	if err != nil {
+		return userActivations, err
+	}
+
+	return userActivations, nil
+}
+
+func getUserActivation(owner string, name string) (*UserActivation, error) {
+	userActivation := UserActivation{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&userActivation)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &userActivation, nil
+	}
+	return nil, nil
+}
+
+func GetUserActivation(id string) (*UserActivation, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getUserActivation(owner, name)
+}
+
+func (userActivation *UserActivation) GetId() string {
+	return fmt.Sprintf("%s/%s", userActivation.Owner, userActivation.Name)
+}
+
+func (userActivation *UserActivation) isExpired(now time.Time) bool {
+	expireTime, err := time.Parse(time.RFC3339, userActivation.ExpireTime)
+	return err != nil || now.After(expireTime)
+}
+
+// renew generates a new activation code and restarts the expiration, the code is returned to be sent once
+func (userActivation *UserActivation) renew(now time.Time) string {
+	if userActivation.ExpireInDays <= 0 {
+		userActivation.ExpireInDays = defaultUserActivationExpireInDays
+	}
+
+	code := util.GenerateClientSecret()
+	userActivation.CodeHash = getTokenHash(code)
+	userActivation.ExpireTime = now.UTC().AddDate(0, 0, userActivation.ExpireInDays).Format(time.RFC3339)
+	userActivation.State = UserActivationStatePending
+	return code
+}
+
+func getUserActivationLink(application *Application, user *User, code string, host string) string {
+	originFrontend, _ := getOriginFromHost(host)
+	query := url.Values{}
+	query.Set("id", user.GetId())
+	query.Set("code", code)
+	return fmt.Sprintf("%s/activate/%s?%s", originFrontend, url.PathEscape(application.Name), query.Encode())
+}
+
+func sendUserActivationEmail(application *Application, user *User, userActivation *UserActivation, code string, host string) error {
+	organization, err := getOrganization("admin", user.Owner)
+	if err != nil {
+		return err
+	}
+	if organization == nil {
+		return fmt.Errorf("the organization: %s is not found", user.Owner)
+	}
+
+	provider, err := GetOrganizationEmailProvider(organization, application)
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return fmt.Errorf("please add an Email provider to the \"Providers\" list for the application: %s", application.Name)
+	}
+
+	title := fmt.Sprintf("%s: activate your account", organization.DisplayName)
+	content := fmt.Sprintf("An account %s has been created for you. Please set your password by the link below before %s to activate it: %s",
+		user.Name, userActivation.ExpireTime, getUserActivationLink(application, user, code, host))
+	return SendEmail(provider, title, content, user.Email, organization.DisplayName)
+}
+
+// PreRegisterUser adds the user without a usable password and sends it the activation link, the password of the user
+// is only set by the activation
+func PreRegisterUser(user *User, application *Application, createdBy string, expireInDays int, requireMfa bool, host string, lang string) (*UserActivation, error) {
+	if application.Organization != user.Owner {
+		return nil, fmt.Errorf(i18n.Translate(lang, "auth:The application: %s does not exist"), application.Name)
+	}
+	if user.Email == "" {
+		return nil, fmt.Errorf(i18n.Translate(lang, "check:Email cannot be empty"))
+	}
+	if !util.IsEmailValid(user.Email) {
+		return nil, fmt.Errorf(i18n.Translate(lang, "check:Email is invalid"))
+	}
+
+	user.Password = util.GenerateClientSecret()
+	user.SignupApplication = application.Name
+	affected, err := AddUser(user)
+	if err != nil {
+		return nil, err
+	}
+	if !affected {
+		return nil, fmt.Errorf("failed to add the user: %s", user.GetId())
+	}
+
+	userActivation := &UserActivation{
+		Owner:        user.Owner,
+		Name:         user.Name,
+		CreatedTime:  util.GetCurrentTime(),
+		CreatedBy:    createdBy,
+		Application:  application.Name,
+		ExpireInDays: expireInDays,
+		RequireMfa:   requireMfa,
+	}
+	code := userActivation.renew(time.Now())
+	_, err = ormer.Engine.Insert(userActivation)
+	if err != nil {
+		return nil, err
+	}
+
+	return userActivation, sendUserActivationEmail(application, user, userActivation, code, host)
+}
+
+// ResendUserActivation sends a new activation link to the unactivated user, the user forbidden for the expired
+// activation is allowed again
+func ResendUserActivation(userActivation *UserActivation, host string, lang string) (bool, error) {
+	if userActivation.State == UserActivationStateActivated {
+		return false, fmt.Errorf(i18n.Translate(lang, "user:The user: %s has been activated"), userActivation.GetId())
+	}
+
+	user, err := getUser(userActivation.Owner, userActivation.Name)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), userActivation.GetId())
+	}
+
+	application, err := getApplication("admin", userActivation.Application)
+	if err != nil {
+		return false, err
+	}
+	if application == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "auth:The application: %s does not exist"), userActivation.Application)
+	}
+
+	code := userActivation.renew(time.Now())
+	_, err = ormer.Engine.ID(core.PK{userActivation.Owner, userActivation.Name}).Cols("code_hash", "expire_time", "state").Update(userActivation)
+	if err != nil {
+		return false, err
+	}
+
+	if user.IsForbidden {
+		user.IsForbidden = false
+		_, err = ormer.Engine.ID(core.PK{user.Owner, user.Name}).Cols("is_forbidden").Update(user)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	err = sendUserActivationEmail(application, user, userActivation, code, host)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CompleteUserActivation activates the user by the code of the activation link, the caller sets the password
+func CompleteUserActivation(user *User, code string, lang string) error {
+	userActivation, err := getUserActivation(user.Owner, user.Name)
+	if err != nil {
+		return err
+	}
+
+	if userActivation == nil || userActivation.State != UserActivationStatePending || userActivation.isExpired(time.Now()) || userActivation.CodeHash != getTokenHash(code) {
+		return fmt.Errorf(i18n.Translate(lang, "user:The activation link is invalid or expired"))
+	}
+
+	userActivation.State = UserActivationStateActivated
+	userActivation.ActivatedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{userActivation.Owner, userActivation.Name}).Where("state = ?", UserActivationStatePending).
+		Cols("state", "activated_time").Update(userActivation)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return fmt.Errorf(i18n.Translate(lang, "user:The activation link is invalid or expired"))
+	}
+	return nil
+}
+
+// CheckUserActivation returns an error if the pre-registered user hasn't been activated, and whether the activated
+// user still has to enroll MFA before signing in
+func CheckUserActivation(user *User, lang string) (bool, error) {
+	userActivation, err := getUserActivation(user.Owner, user.Name)
+	if err != nil || userActivation == nil {
+		return false, err
+	}
+
+	if userActivation.State != UserActivationStateActivated {
+		return false, fmt.Errorf(i18n.Translate(lang, "user:The user is not activated, please set the password by the activation link sent to your Email"))
+	}
+
+	return userActivation.RequireMfa && !user.IsMfaEnabled(), nil
+}
+
+// expireUserActivations forbids the users not activated before their activations expire, the expire times are in
+// UTC to be compared as strings
+func expireUserActivations(now time.Time) error {
+	userActivations := []*UserActivation{}
+	err := ormer.Engine.Where("state = ? and expire_time < ?", UserActivationStatePending, now.UTC().Format(time.RFC3339)).Find(&userActivations)
+	if err != nil {
+		return err
+	}
+
+	for _, userActivation := range userActivations {
+		userActivation.State = UserActivationStateExpired
+		_, err = ormer.Engine.ID(core.PK{userActivation.Owner, userActivation.Name}).Where("state = ?", UserActivationStatePending).Cols("state").Update(userActivation)
+		if err != nil {
+			return err
+		}
+
+		_, err = ormer.Engine.ID(core.PK{userActivation.Owner, userActivation.Name}).Cols("is_forbidden").Update(&User{Owner: userActivation.Owner, Name: userActivation.Name, IsForbidden: true})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func RunUserActivationJob() {
+	for {
+		err := expireUserActivations(time.Now())
+		if err != nil {
+			logs.Warning(fmt.Sprintf("user activation expiration failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
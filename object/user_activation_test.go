@@ -0,0 +1,40 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenewUserActivation(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.FixedZone("UTC+8", 8*3600))
+	userActivation := &UserActivation{State: UserActivationStateExpired}
+
+	code := userActivation.renew(now)
+	assert.NotEmpty(t, code)
+	assert.Equal(t, getTokenHash(code), userActivation.CodeHash)
+	assert.Equal(t, UserActivationStatePending, userActivation.State)
+	assert.Equal(t, defaultUserActivationExpireInDays, userActivation.ExpireInDays)
+	assert.Equal(t, "2024-01-08T04:00:00Z", userActivation.ExpireTime)
+
+	assert.False(t, userActivation.isExpired(now.AddDate(0, 0, 6)))
+	assert.True(t, userActivation.isExpired(now.AddDate(0, 0, 8)))
+
+	anotherCode := userActivation.renew(now)
+	assert.NotEqual(t, code, anotherCode)
+}
@@ -0,0 +1,312 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// ContactVerification is the method of the codes sent for verifying the additional contacts
+const ContactVerification = "contact"
+
+// UserContact is an additional email address or phone number of a user, the primary ones are
+// still stored in the Email and Phone fields of the user
+type UserContact struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	User         string `xorm:"varchar(100) index" json:"user"`
+	Type         string `xorm:"varchar(100)" json:"type"`
+	Value        string `xorm:"varchar(100) index" json:"value"`
+	CountryCode  string `xorm:"varchar(6)" json:"countryCode"`
+	IsVerified   bool   `json:"isVerified"`
+	VerifiedTime string `xorm:"varchar(100)" json:"verifiedTime"`
+}
+
+func GetUserContacts(owner string, user string) ([]*UserContact, error) {
+	contacts := []*UserContact{}
+	err := ormer.Engine.Desc("created_time").Find(&contacts, &UserContact{Owner: owner, User: user})
+	if err != nil {
+		return contacts, err
+	}
+
+	return contacts, nil
+}
+
+func getUserContact(owner string, name string) (*UserContact, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	contact := UserContact{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&contact)
+	if err != nil {
+		return &contact, err
+	}
+
+	if existed {
+		return &contact, nil
+	} else {
+		return nil, nil
+	}
+}
+
+func GetUserContact(id string) (*UserContact, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getUserContact(owner, name)
+}
+
+func (contact *UserContact) GetId() string {
+	return fmt.Sprintf("%s/%s", contact.Owner, contact.Name)
+}
+
+// GetDest returns the destination that the verification code is sent to
+func (contact *UserContact) GetDest() string {
+	if contact.Type == VerifyTypePhone {
+		if phone, ok := util.GetE164Number(contact.Value, contact.CountryCode); ok {
+			return phone
+		}
+	}
+
+	return contact.Value
+}
+
+func getVerifiedUserContact(owner string, contactType string, value string) (*UserContact, error) {
+	if owner == "" || value == "" {
+		return nil, nil
+	}
+
+	contact := UserContact{}
+	existed, err := ormer.Engine.Where("owner = ? and type = ? and value = ? and is_verified = ?", owner, contactType, value, true).Get(&contact)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &contact, nil
+	} else {
+		return nil, nil
+	}
+}
+
+// getUserByContact finds the user by one of the verified additional email addresses or phone numbers
+func getUserByContact(owner string, contactType string, value string) (*User, error) {
+	contact, err := getVerifiedUserContact(owner, contactType, value)
+	if err != nil || contact == nil {
+		return nil, err
+	}
+
+	return getUser(contact.Owner, contact.User)
+}
+
+func isUserContactUsed(owner string, contactType string, value string) (bool, error) {
+	user, err := GetUserByField(owner, contactType, value)
+	if err != nil {
+		return false, err
+	}
+	if user != nil {
+		return true, nil
+	}
+
+	contact, err := getVerifiedUserContact(owner, contactType, value)
+	if err != nil {
+		return false, err
+	}
+
+	return contact != nil, nil
+}
+
+func checkUserContact(contact *UserContact, lang string) error {
+	switch contact.Type {
+	case VerifyTypeEmail:
+		if !util.IsEmailValid(contact.Value) {
+			return fmt.Errorf(i18n.Translate(lang, "check:Email is invalid"))
+		}
+	case VerifyTypePhone:
+		if _, ok := util.GetE164Number(contact.Value, contact.CountryCode); !ok {
+			return fmt.Errorf(i18n.Translate(lang, "verification:Phone number is invalid in your region %s"), contact.CountryCode)
+		}
+	default:
+		return fmt.Errorf(i18n.Translate(lang, "verification:Unknown type"))
+	}
+
+	used, err := isUserContactUsed(contact.Owner, contact.Type, contact.Value)
+	if err != nil {
+		return err
+	}
+
+	if used {
+		if contact.Type == VerifyTypeEmail {
+			return fmt.Errorf(i18n.Translate(lang, "check:Email already exists"))
+		}
+		return fmt.Errorf(i18n.Translate(lang, "check:Phone already exists"))
+	}
+
+	return nil
+}
+
+// AddUserContact adds an unverified contact to the user, it can't be used for login until it is verified
+func AddUserContact(user *User, contact *UserContact, lang string) (bool, error) {
+	contact.Owner = user.Owner
+	contact.Name = util.GenerateId()
+	contact.CreatedTime = util.GetCurrentTime()
+	contact.User = user.Name
+	contact.Value = strings.TrimSpace(contact.Value)
+	contact.IsVerified = false
+	contact.VerifiedTime = ""
+	if contact.Type == VerifyTypePhone && contact.CountryCode == "" {
+		contact.CountryCode = user.GetCountryCode("")
+	}
+
+	err := checkUserContact(contact, lang)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.Insert(contact)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// VerifyUserContact checks the code sent to the contact via /api/send-verification-code with the method "contact"
+// in the same session
+func VerifyUserContact(contact *UserContact, session string, code string, lang string) (bool, error) {
+	if contact.IsVerified {
+		return true, nil
+	}
+
+	// another user may have verified the same value in the meantime
+	err := checkUserContact(contact, lang)
+	if err != nil {
+		return false, err
+	}
+
+	scope := &VerificationScope{Method: ContactVerification, Session: session}
+	result := CheckVerificationCode(scope, contact.GetDest(), code, lang)
+	if result.Code != VerificationSuccess {
+		return false, fmt.Errorf(result.Msg)
+	}
+
+	contact.IsVerified = true
+	contact.VerifiedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{contact.Owner, contact.Name}).Cols("is_verified", "verified_time").Update(contact)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// SetPrimaryUserContact swaps the verified contact with the primary email or phone of the user,
+// the previous primary one is kept as an additional contact.
+func SetPrimaryUserContact(contact *UserContact, lang string) (bool, error) {
+	if !contact.IsVerified {
+		return false, fmt.Errorf(i18n.Translate(lang, "user:The contact: %s is not verified"), contact.Value)
+	}
+
+	user, err := getUser(contact.Owner, contact.User)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), util.GetId(contact.Owner, contact.User))
+	}
+
+	oldContact := &UserContact{
+		Owner:        contact.Owner,
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		User:         contact.User,
+		Type:         contact.Type,
+		IsVerified:   true,
+		VerifiedTime: util.GetCurrentTime(),
+	}
+
+	var columns []string
+	if contact.Type == VerifyTypeEmail {
+		oldContact.Value = user.Email
+		oldContact.IsVerified = user.EmailVerified
+		user.Email = contact.Value
+		user.EmailVerified = true
+		columns = []string{"email", "email_verified"}
+	} else {
+		oldContact.Value = user.Phone
+		oldContact.CountryCode = user.CountryCode
+		user.Phone = contact.Value
+		user.CountryCode = contact.CountryCode
+		columns = []string{"phone", "country_code"}
+	}
+	if !oldContact.IsVerified {
+		oldContact.VerifiedTime = ""
+	}
+
+	session := ormer.Engine.NewSession()
+	defer session.Close()
+
+	err = session.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = session.ID(core.PK{user.Owner, user.Name}).Cols(columns...).Update(user)
+	if err != nil {
+		session.Rollback()
+		return false, err
+	}
+
+	_, err = session.ID(core.PK{contact.Owner, contact.Name}).Delete(&UserContact{})
+	if err != nil {
+		session.Rollback()
+		return false, err
+	}
+
+	if oldContact.Value != "" {
+		_, err = session.Insert(oldContact)
+		if err != nil {
+			session.Rollback()
+			return false, err
+		}
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func DeleteUserContact(contact *UserContact) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{contact.Owner, contact.Name}).Delete(&UserContact{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func deleteUserContacts(owner string, user string) error {
+	_, err := ormer.Engine.Delete(&UserContact{Owner: owner, User: user})
+	return err
+}
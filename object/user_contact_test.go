@@ -0,0 +1,50 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyUserContactScope(t *testing.T) {
+	setTestOrmer(t, &User{}, &UserContact{}, &VerificationRecord{})
+
+	contact := &UserContact{Owner: "org", Name: "contact1", User: "alice", Type: VerifyTypeEmail, Value: "alice2@example.com"}
+	_, err := ormer.Engine.Insert(contact)
+	assert.Nil(t, err)
+
+	addRecord := func(name string, method string, session string) {
+		record := &VerificationRecord{Owner: "admin", Name: name, Receiver: contact.Value, Code: "123456", Time: time.Now().Unix()}
+		record.setScope(&VerificationScope{Application: "admin/app-1", Method: method, Session: session})
+		_, err := ormer.Engine.Insert(record)
+		assert.Nil(t, err)
+	}
+
+	// the codes of another method or session don't verify the contact
+	addRecord("record1", "signup", "session-1")
+	addRecord("record2", ContactVerification, "session-2")
+	_, err = VerifyUserContact(contact, "session-1", "123456", "en")
+	assert.NotNil(t, err)
+	assert.False(t, contact.IsVerified)
+
+	addRecord("record3", ContactVerification, "session-1")
+	affected, err := VerifyUserContact(contact, "session-1", "123456", "en")
+	assert.Nil(t, err)
+	assert.True(t, affected)
+	assert.True(t, contact.IsVerified)
+}
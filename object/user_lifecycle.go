@@ -0,0 +1,475 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+)
+
+const (
+	LifecycleStatePreHire     = "PreHire"
+	LifecycleStateActive      = "Active"
+	LifecycleStateOnLeave     = "OnLeave"
+	LifecycleStateOffboarding = "Offboarding"
+	LifecycleStateArchived    = "Archived"
+
+	LifecycleActionBlockSignin      = "BlockSignin"
+	LifecycleActionRevokeSessions   = "RevokeSessions"
+	LifecycleActionRemoveFromGroups = "RemoveFromGroups"
+	LifecycleActionNotifyManager    = "NotifyManager"
+)
+
+var (
+	lifecycleStates  = []string{LifecycleStatePreHire, LifecycleStateActive, LifecycleStateOnLeave, LifecycleStateOffboarding, LifecycleStateArchived}
+	lifecycleActions = []string{LifecycleActionBlockSignin, LifecycleActionRevokeSessions, LifecycleActionRemoveFromGroups, LifecycleActionNotifyManager}
+
+	userLifecycleColumns = []string{"lifecycle_state", "lifecycle_state_time", "lifecycle_reason"}
+)
+
+// the transitions allowed when the policy doesn't configure its own
+var defaultLifecycleTransitions = []*LifecycleTransition{
+	{From: LifecycleStatePreHire, To: LifecycleStateActive},
+	{From: LifecycleStatePreHire, To: LifecycleStateArchived},
+	{From: LifecycleStateActive, To: LifecycleStateOnLeave},
+	{From: LifecycleStateActive, To: LifecycleStateOffboarding},
+	{From: LifecycleStateOnLeave, To: LifecycleStateActive},
+	{From: LifecycleStateOnLeave, To: LifecycleStateOffboarding},
+	{From: LifecycleStateOffboarding, To: LifecycleStateActive},
+	{From: LifecycleStateOffboarding, To: LifecycleStateArchived},
+	{From: LifecycleStateArchived, To: LifecycleStateActive},
+}
+
+// the actions run when the users enter the states whose actions are not configured by the policy
+var defaultLifecycleStateActions = map[string][]string{
+	LifecycleStatePreHire:     {LifecycleActionBlockSignin},
+	LifecycleStateOffboarding: {LifecycleActionBlockSignin, LifecycleActionRevokeSessions, LifecycleActionNotifyManager},
+	LifecycleStateArchived:    {LifecycleActionBlockSignin, LifecycleActionRevokeSessions, LifecycleActionRemoveFromGroups},
+}
+
+type LifecycleTransition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type LifecycleStateActions struct {
+	State   string   `json:"state"`
+	Actions []string `json:"actions"`
+}
+
+// LifecyclePolicy moves the users of the organization through the lifecycle states. The users enter "Active" at their
+// hire time and "Offboarding" at their termination time, or follow the state attribute filled by the syncers, in which
+// case the dates are ignored. The offboarding users are archived after the given days.
+type LifecyclePolicy struct {
+	IsEnabled        bool                     `json:"isEnabled"`
+	Transitions      []*LifecycleTransition   `json:"transitions"`
+	StateActions     []*LifecycleStateActions `json:"stateActions"`
+	StateAttribute   string                   `json:"stateAttribute"`
+	StateMapping     map[string]string        `json:"stateMapping"`
+	ArchiveAfterDays int                      `json:"archiveAfterDays"`
+}
+
+// getLifecyclePolicy returns the lifecycle policy of the organization, which may be inherited from its ancestors
+func getLifecyclePolicy(organization *Organization) (*LifecyclePolicy, error) {
+	organization, err := GetInheritedOrganization(organization)
+	if err != nil {
+		return nil, err
+	}
+	if organization == nil || organization.LifecyclePolicy == nil {
+		return &LifecyclePolicy{}, nil
+	}
+	return organization.LifecyclePolicy, nil
+}
+
+func (policy *LifecyclePolicy) isTransitionAllowed(from string, to string) bool {
+	transitions := policy.Transitions
+	if len(transitions) == 0 {
+		transitions = defaultLifecycleTransitions
+	}
+
+	for _, transition := range transitions {
+		if transition.From == from && transition.To == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (policy *LifecyclePolicy) getStateActions(state string) []string {
+	for _, stateActions := range policy.StateActions {
+		if stateActions.State == state {
+			return stateActions.Actions
+		}
+	}
+	return defaultLifecycleStateActions[state]
+}
+
+// getAttributeState returns the state given by the state attribute of the user, the values of the attribute are
+// either mapped by the state mapping or the state names themselves
+func (policy *LifecyclePolicy) getAttributeState(user *User) string {
+	if policy.StateAttribute == "" {
+		return ""
+	}
+
+	value := getProvisioningUserField(user, policy.StateAttribute)
+	if value == nil {
+		return ""
+	}
+
+	state := fmt.Sprintf("%v", value)
+	if mapped, ok := policy.StateMapping[state]; ok {
+		state = mapped
+	}
+	if !util.InSlice(lifecycleStates, state) {
+		return ""
+	}
+	return state
+}
+
+// getLifecycleState returns the lifecycle state of the user, the users without a state are active
+func (user *User) getLifecycleState() string {
+	if user.LifecycleState == "" {
+		return LifecycleStateActive
+	}
+	return user.LifecycleState
+}
+
+// parseLifecycleTime parses the hire and termination times, which are either in RFC3339 or date only
+func parseLifecycleTime(t string) (time.Time, error) {
+	res, err := time.Parse(time.RFC3339, t)
+	if err != nil {
+		return time.Parse("2006-01-02", t)
+	}
+	return res, nil
+}
+
+func isLifecycleTimeReached(t string, now time.Time) bool {
+	if t == "" {
+		return false
+	}
+
+	parsed, err := parseLifecycleTime(t)
+	return err == nil && !now.Before(parsed)
+}
+
+// getDueLifecycleState returns the state the user should be in now by the state attribute or the dates
+func getDueLifecycleState(policy *LifecyclePolicy, user *User, now time.Time) string {
+	state := user.getLifecycleState()
+
+	if attributeState := policy.getAttributeState(user); attributeState != "" {
+		if attributeState != state {
+			return attributeState
+		}
+	} else {
+		if user.LifecycleState == "" && user.HireTime != "" && !isLifecycleTimeReached(user.HireTime, now) {
+			return LifecycleStatePreHire
+		}
+		if isLifecycleTimeReached(user.TerminationTime, now) {
+			// the pre-hire users leaving before their hire time are archived directly
+			if state == LifecycleStatePreHire {
+				return LifecycleStateArchived
+			}
+			if state == LifecycleStateActive || state == LifecycleStateOnLeave {
+				return LifecycleStateOffboarding
+			}
+		}
+		if state == LifecycleStatePreHire && isLifecycleTimeReached(user.HireTime, now) {
+			return LifecycleStateActive
+		}
+	}
+
+	if state == LifecycleStateOffboarding && policy.ArchiveAfterDays > 0 && user.LifecycleStateTime != "" {
+		offboardingTime, err := time.Parse(time.RFC3339, user.LifecycleStateTime)
+		if err == nil && !now.Before(offboardingTime.AddDate(0, 0, policy.ArchiveAfterDays)) {
+			return LifecycleStateArchived
+		}
+	}
+
+	return state
+}
+
+func checkLifecyclePolicy(policy *LifecyclePolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	for _, transition := range policy.Transitions {
+		if !util.InSlice(lifecycleStates, transition.From) || !util.InSlice(lifecycleStates, transition.To) {
+			return fmt.Errorf("the lifecycle transition from: %s to: %s is invalid", transition.From, transition.To)
+		}
+	}
+
+	for _, stateActions := range policy.StateActions {
+		if !util.InSlice(lifecycleStates, stateActions.State) {
+			return fmt.Errorf("unknown lifecycle state: %s", stateActions.State)
+		}
+		for _, action := range stateActions.Actions {
+			if !util.InSlice(lifecycleActions, action) {
+				return fmt.Errorf("unknown lifecycle action: %s", action)
+			}
+		}
+	}
+
+	for value, state := range policy.StateMapping {
+		if !util.InSlice(lifecycleStates, state) {
+			return fmt.Errorf("the lifecycle state: %s mapped from: %s is unknown", state, value)
+		}
+	}
+
+	if policy.ArchiveAfterDays < 0 {
+		return fmt.Errorf("the archive after days of the lifecycle policy should not be negative")
+	}
+
+	return nil
+}
+
+// CheckUserLifecycle returns an error if the user tries to authenticate in a lifecycle state blocking the sign-in
+func CheckUserLifecycle(user *User, lang string) error {
+	if user == nil || user.getLifecycleState() == LifecycleStateActive {
+		return nil
+	}
+
+	organization, err := getOrganization("admin", user.Owner)
+	if err != nil {
+		return err
+	}
+
+	policy, err := getLifecyclePolicy(organization)
+	if err != nil {
+		return err
+	}
+
+	if policy.IsEnabled && util.InSlice(policy.getStateActions(user.getLifecycleState()), LifecycleActionBlockSignin) {
+		return NewCodedError(ErrorCodeUserInactive, i18n.Translate(lang, "check:The user is not active, please contact the administrator"))
+	}
+	return nil
+}
+
+// SetUserLifecycleState moves the user to the lifecycle state by the admin, the transition should be allowed by the
+// lifecycle policy of the organization
+func SetUserLifecycleState(id string, state string, reason string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	user, err := getUser(owner, name)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, fmt.Errorf("the user: %s is not found", id)
+	}
+
+	organization, err := getOrganization("admin", owner)
+	if err != nil {
+		return false, err
+	}
+
+	policy, err := getLifecyclePolicy(organization)
+	if err != nil {
+		return false, err
+	}
+	if !policy.IsEnabled {
+		return false, fmt.Errorf("the lifecycle policy of the organization: %s is not enabled", owner)
+	}
+
+	if !util.InSlice(lifecycleStates, state) {
+		return false, fmt.Errorf("unknown lifecycle state: %s", state)
+	}
+	if state == user.getLifecycleState() {
+		return false, nil
+	}
+	if !policy.isTransitionAllowed(user.getLifecycleState(), state) {
+		return false, fmt.Errorf("the lifecycle transition from: %s to: %s is not allowed", user.getLifecycleState(), state)
+	}
+
+	return transitionUserLifecycle(policy, user, state, reason)
+}
+
+// transitionUserLifecycle moves the user to the state and runs the actions of the state
+func transitionUserLifecycle(policy *LifecyclePolicy, user *User, state string, reason string) (bool, error) {
+	oldState := user.getLifecycleState()
+	oldGroups := user.Groups
+	actions := policy.getStateActions(state)
+
+	user.LifecycleState = state
+	user.LifecycleStateTime = util.GetCurrentTime()
+	user.LifecycleReason = reason
+	columns := append([]string{}, userLifecycleColumns...)
+
+	isGroupsRemoved := util.InSlice(actions, LifecycleActionRemoveFromGroups) && len(user.Groups) != 0
+	if isGroupsRemoved {
+		user.Groups = []string{}
+		columns = append(columns, "groups")
+
+		err := syncUserGroups(user.GetId(), oldGroups, user.Groups)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	affected, err := updateUser(user.GetId(), user, columns)
+	if err != nil {
+		return false, err
+	}
+
+	if util.InSlice(actions, LifecycleActionRevokeSessions) {
+		err = deleteUserSessions(user)
+		if err != nil {
+			return false, err
+		}
+
+		err = revokeUserTokens(user)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if isGroupsRemoved {
+		triggerUserProvisioning(user.Owner, user.Name, oldGroups)
+	} else {
+		triggerUserProvisioning(user.Owner, user.Name, nil)
+	}
+
+	record := &casvisorsdk.Record{
+		Name:         util.GenerateId(),
+		CreatedTime:  util.GetCurrentTime(),
+		Organization: user.Owner,
+		User:         user.Name,
+		Method:       "POST",
+		Action:       "user-lifecycle-transition",
+		Object:       util.StructToJson(map[string]string{"from": oldState, "to": state, "reason": reason}),
+	}
+	util.SafeGoroutine(func() { AddRecord(record) })
+
+	if util.InSlice(actions, LifecycleActionNotifyManager) {
+		util.SafeGoroutine(func() {
+			err := notifyLifecycleManager(user, oldState)
+			if err != nil {
+				logs.Warning(fmt.Sprintf("failed to notify the manager of the user: %s, error: %s", user.GetId(), err.Error()))
+			}
+		})
+	}
+
+	return affected != 0, nil
+}
+
+// notifyLifecycleManager emails the manager of the user about the lifecycle transition of the user
+func notifyLifecycleManager(user *User, oldState string) error {
+	if user.Manager == "" {
+		return nil
+	}
+
+	manager, err := getUser(user.Owner, user.Manager)
+	if err != nil {
+		return err
+	}
+	if manager == nil || manager.Email == "" {
+		return fmt.Errorf("the manager: %s has no email", util.GetId(user.Owner, user.Manager))
+	}
+
+	organization, err := getOrganization("admin", user.Owner)
+	if err != nil {
+		return err
+	}
+	if organization == nil {
+		return fmt.Errorf("the organization: %s is not found", user.Owner)
+	}
+
+	application, err := GetDefaultApplication(util.GetId("admin", organization.Name))
+	if err != nil {
+		return err
+	}
+
+	provider, err := GetOrganizationEmailProvider(organization, application)
+	if err != nil {
+		return err
+	}
+	if provider == nil {
+		return fmt.Errorf("the organization: %s has no Email provider", user.Owner)
+	}
+
+	displayName := user.DisplayName
+	if displayName == "" {
+		displayName = user.Name
+	}
+
+	title := fmt.Sprintf("%s: %s is %s", organization.DisplayName, displayName, user.LifecycleState)
+	content := fmt.Sprintf("The lifecycle state of %s (%s) changed from %s to %s.", displayName, user.GetId(), oldState, user.LifecycleState)
+	if user.LifecycleReason != "" {
+		content += fmt.Sprintf(" Reason: %s", user.LifecycleReason)
+	}
+	return SendEmail(provider, title, content, manager.Email, organization.DisplayName)
+}
+
+func runUserLifecycles() error {
+	organizations := []*Organization{}
+	err := ormer.Engine.Find(&organizations)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, organization := range organizations {
+		policy, err := getLifecyclePolicy(organization)
+		if err != nil {
+			return err
+		}
+		if !policy.IsEnabled {
+			continue
+		}
+
+		users := []*User{}
+		err = ormer.Engine.Where("owner = ? and is_deleted = ?", organization.Name, false).Find(&users)
+		if err != nil {
+			return err
+		}
+
+		for _, user := range users {
+			state := getDueLifecycleState(policy, user, now)
+			if state == user.getLifecycleState() {
+				continue
+			}
+
+			// the initial pre-hire state is not a transition
+			if user.LifecycleState != "" || state != LifecycleStatePreHire {
+				if !policy.isTransitionAllowed(user.getLifecycleState(), state) {
+					continue
+				}
+			}
+
+			_, err = transitionUserLifecycle(policy, user, state, "")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunUserLifecycleJob moves the users through the lifecycle states by their dates and state attributes every minute
+func RunUserLifecycleJob() {
+	for {
+		err := runUserLifecycles()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("user lifecycle failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDueLifecycleStateByDates(t *testing.T) {
+	policy := &LifecyclePolicy{IsEnabled: true, ArchiveAfterDays: 30}
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, LifecycleStateActive, getDueLifecycleState(policy, &User{}, now))
+	assert.Equal(t, LifecycleStatePreHire, getDueLifecycleState(policy, &User{HireTime: "2023-07-01"}, now))
+	assert.Equal(t, LifecycleStateActive, getDueLifecycleState(policy, &User{LifecycleState: LifecycleStatePreHire, HireTime: "2023-06-01"}, now))
+	assert.Equal(t, LifecycleStateArchived, getDueLifecycleState(policy, &User{LifecycleState: LifecycleStatePreHire, HireTime: "2023-07-01", TerminationTime: "2023-05-31"}, now))
+
+	assert.Equal(t, LifecycleStateOffboarding, getDueLifecycleState(policy, &User{TerminationTime: "2023-05-31T18:00:00Z"}, now))
+	assert.Equal(t, LifecycleStateOffboarding, getDueLifecycleState(policy, &User{LifecycleState: LifecycleStateOnLeave, TerminationTime: "2023-06-01"}, now))
+	assert.Equal(t, LifecycleStateActive, getDueLifecycleState(policy, &User{TerminationTime: "2023-06-02"}, now))
+
+	offboarding := &User{LifecycleState: LifecycleStateOffboarding, LifecycleStateTime: "2023-05-01T00:00:00Z", TerminationTime: "2023-05-01"}
+	assert.Equal(t, LifecycleStateArchived, getDueLifecycleState(policy, offboarding, now))
+	offboarding.LifecycleStateTime = "2023-05-15T00:00:00Z"
+	assert.Equal(t, LifecycleStateOffboarding, getDueLifecycleState(policy, offboarding, now))
+}
+
+func TestGetDueLifecycleStateByAttribute(t *testing.T) {
+	policy := &LifecyclePolicy{
+		IsEnabled:        true,
+		StateAttribute:   "properties.employmentStatus",
+		StateMapping:     map[string]string{"Terminated": LifecycleStateOffboarding, "Leave": LifecycleStateOnLeave},
+		ArchiveAfterDays: 30,
+	}
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// the attribute overrides the dates
+	user := &User{Properties: map[string]string{"employmentStatus": "Leave"}, TerminationTime: "2023-05-01"}
+	assert.Equal(t, LifecycleStateOnLeave, getDueLifecycleState(policy, user, now))
+
+	user = &User{Properties: map[string]string{"employmentStatus": "Terminated"}}
+	assert.Equal(t, LifecycleStateOffboarding, getDueLifecycleState(policy, user, now))
+
+	user.LifecycleState = LifecycleStateOffboarding
+	user.LifecycleStateTime = "2023-04-01T00:00:00Z"
+	assert.Equal(t, LifecycleStateArchived, getDueLifecycleState(policy, user, now))
+
+	// the archived user stays archived as the transition back to offboarding is not allowed
+	user.LifecycleState = LifecycleStateArchived
+	state := getDueLifecycleState(policy, user, now)
+	assert.Equal(t, LifecycleStateOffboarding, state)
+	assert.False(t, policy.isTransitionAllowed(LifecycleStateArchived, state))
+
+	user = &User{Properties: map[string]string{"employmentStatus": "Unknown"}, TerminationTime: "2023-05-01"}
+	assert.Equal(t, LifecycleStateOffboarding, getDueLifecycleState(policy, user, now))
+}
+
+func TestLifecyclePolicyActions(t *testing.T) {
+	policy := &LifecyclePolicy{
+		Transitions:  []*LifecycleTransition{{From: LifecycleStateActive, To: LifecycleStateArchived}},
+		StateActions: []*LifecycleStateActions{{State: LifecycleStateArchived, Actions: []string{LifecycleActionRemoveFromGroups}}},
+	}
+
+	assert.True(t, policy.isTransitionAllowed(LifecycleStateActive, LifecycleStateArchived))
+	assert.False(t, policy.isTransitionAllowed(LifecycleStateActive, LifecycleStateOffboarding))
+	assert.Equal(t, []string{LifecycleActionRemoveFromGroups}, policy.getStateActions(LifecycleStateArchived))
+	assert.Contains(t, policy.getStateActions(LifecycleStateOffboarding), LifecycleActionRevokeSessions)
+	assert.Empty(t, policy.getStateActions(LifecycleStateActive))
+}
+
+func TestCheckLifecyclePolicy(t *testing.T) {
+	assert.Nil(t, checkLifecyclePolicy(nil))
+	assert.Nil(t, checkLifecyclePolicy(&LifecyclePolicy{StateMapping: map[string]string{"Terminated": LifecycleStateOffboarding}}))
+	assert.NotNil(t, checkLifecyclePolicy(&LifecyclePolicy{Transitions: []*LifecycleTransition{{From: LifecycleStateActive, To: "Fired"}}}))
+	assert.NotNil(t, checkLifecyclePolicy(&LifecyclePolicy{StateActions: []*LifecycleStateActions{{State: LifecycleStateArchived, Actions: []string{"Delete"}}}}))
+	assert.NotNil(t, checkLifecyclePolicy(&LifecyclePolicy{StateMapping: map[string]string{"Terminated": "Gone"}}))
+	assert.NotNil(t, checkLifecyclePolicy(&LifecyclePolicy{ArchiveAfterDays: -1}))
+}
@@ -0,0 +1,85 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+)
+
+// getUserFieldByJsonName returns the name of the User field with the JSON name
+func getUserFieldByJsonName(jsonName string) string {
+	t := reflect.TypeOf(User{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.Split(field.Tag.Get("json"), ",")[0] == jsonName {
+			return field.Name
+		}
+	}
+	return ""
+}
+
+// getUserPatchColumns converts the JSON fields changed by the patch into the columns to update,
+// only the columns that UpdateUser updates by default (and the admin-only ones for admins) can be patched.
+func getUserPatchColumns(fields []string, isAdmin bool, lang string) ([]string, error) {
+	columns := []string{}
+	for _, field := range fields {
+		goField := getUserFieldByJsonName(field)
+		column := util.SnakeString(goField)
+		if goField == "" || !(util.InSlice(defaultUserColumns, column) || (isAdmin && util.InSlice(adminUserColumns, column))) {
+			return nil, fmt.Errorf(i18n.Translate(lang, "user:The field: %s can't be patched"), field)
+		}
+
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+// GetPatchedUser applies the JSON Patch operations to the user, and returns the patched user
+// and the columns to update, so that the fields not in the patch are not overwritten.
+func GetPatchedUser(user *User, operations []*util.JsonPatchOperation, isAdmin bool, lang string) (*User, []string, error) {
+	fields, err := util.GetJsonPatchFields(operations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns, err := getUserPatchColumns(fields, isAdmin, lang)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err = util.ApplyJsonPatch(data, operations)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	patchedUser := &User{}
+	err = json.Unmarshal(data, patchedUser)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return patchedUser, columns, nil
+}
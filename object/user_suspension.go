@@ -0,0 +1,216 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+var userSuspensionColumns = []string{"is_suspended", "suspended_time", "suspended_reason", "reactivation_time"}
+
+// IsSuspensionActive checks whether the user is suspended now, the suspension ends by itself at the reactivation time.
+// Unlike IsForbidden, the suspension also kills the sessions and tokens of the user when it starts.
+func (user *User) IsSuspensionActive() bool {
+	if !user.IsSuspended {
+		return false
+	}
+	if user.ReactivationTime == "" {
+		return true
+	}
+
+	reactivationTime, err := time.Parse(time.RFC3339, user.ReactivationTime)
+	if err != nil {
+		return true
+	}
+	return time.Now().Before(reactivationTime)
+}
+
+// CheckUserSuspended returns an error if the suspended user tries to authenticate
+func CheckUserSuspended(user *User, lang string) error {
+	if user != nil && user.IsSuspensionActive() {
+		return NewCodedError(ErrorCodeUserSuspended, i18n.Translate(lang, "check:The user is suspended, please contact the administrator"))
+	}
+	return nil
+}
+
+func deleteUserSessions(user *User) error {
+	sessions := []*Session{}
+	err := ormer.Engine.Where("owner = ? and name = ?", user.Owner, user.Name).Find(&sessions)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		DeleteBeegoSession(session.SessionId)
+
+		_, err = ormer.Engine.ID(core.PK{session.Owner, session.Name, session.Application}).Delete(&Session{})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revokeUserTokens expires the access tokens of the user and adds them and their refresh tokens to the denylist,
+// the revocation epoch of the user is bumped for the resource servers validating the tokens offline
+func revokeUserTokens(user *User) error {
+	err := bumpRevocationEpoch(user)
+	if err != nil {
+		return err
+	}
+
+	tokens := []*Token{}
+	err = ormer.Engine.Find(&tokens, &Token{Organization: user.Owner, User: user.Name})
+	if err != nil {
+		return err
+	}
+
+	applications := map[string]*Application{}
+	for _, token := range tokens {
+		token.ExpiresIn = 0
+		_, err = ormer.Engine.ID(core.PK{token.Owner, token.Name}).Cols("expires_in").Update(token)
+		if err != nil {
+			return err
+		}
+
+		applicationId := util.GetId(token.Owner, token.Application)
+		application, ok := applications[applicationId]
+		if !ok {
+			application, err = getApplication(token.Owner, token.Application)
+			if err != nil {
+				return err
+			}
+			applications[applicationId] = application
+		}
+
+		if application != nil {
+			err = denylistToken(application, token, true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SuspendUser blocks the authentication of the user until it is reactivated by the admin or at the reactivation time
+// if it is not empty, the sessions and tokens of the user are revoked while the data of the user is kept
+func SuspendUser(id string, reason string, reactivationTime string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	user, err := getUser(owner, name)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, fmt.Errorf("the user: %s is not found", id)
+	}
+
+	if reactivationTime != "" {
+		t, err := time.Parse(time.RFC3339, reactivationTime)
+		if err != nil {
+			return false, fmt.Errorf("the reactivation time: %s should be in RFC3339 format", reactivationTime)
+		}
+		if !t.After(time.Now()) {
+			return false, fmt.Errorf("the reactivation time: %s should be in the future", reactivationTime)
+		}
+	}
+
+	user.IsSuspended = true
+	user.SuspendedTime = util.GetCurrentTime()
+	user.SuspendedReason = reason
+	user.ReactivationTime = reactivationTime
+	affected, err := updateUser(id, user, userSuspensionColumns)
+	if err != nil {
+		return false, err
+	}
+
+	err = deleteUserSessions(user)
+	if err != nil {
+		return false, err
+	}
+
+	err = revokeUserTokens(user)
+	if err != nil {
+		return false, err
+	}
+
+	triggerUserProvisioning(user.Owner, user.Name, nil)
+
+	return affected != 0, nil
+}
+
+// ReactivateUser ends the suspension of the user
+func ReactivateUser(id string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromIdNoCheck(id)
+	user, err := getUser(owner, name)
+	if err != nil {
+		return false, err
+	}
+	if user == nil {
+		return false, fmt.Errorf("the user: %s is not found", id)
+	}
+
+	user.IsSuspended = false
+	user.SuspendedTime = ""
+	user.SuspendedReason = ""
+	user.ReactivationTime = ""
+	affected, err := updateUser(id, user, userSuspensionColumns)
+	if err != nil {
+		return false, err
+	}
+
+	triggerUserProvisioning(user.Owner, user.Name, nil)
+
+	return affected != 0, nil
+}
+
+func reactivateExpiredSuspensions() error {
+	users := []*User{}
+	err := ormer.Engine.Where("is_suspended = ? and reactivation_time != ?", true, "").Find(&users)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		if !user.IsSuspensionActive() {
+			_, err = ReactivateUser(user.GetId())
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunUserReactivationJob reactivates the suspended users at their reactivation time every minute
+func RunUserReactivationJob() {
+	for {
+		err := reactivateExpiredSuspensions()
+		if err != nil {
+			logs.Warning(fmt.Sprintf("user reactivation failed, error: %s", err.Error()))
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
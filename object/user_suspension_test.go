@@ -0,0 +1,34 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSuspensionActive(t *testing.T) {
+	assert.False(t, (&User{}).IsSuspensionActive())
+	assert.True(t, (&User{IsSuspended: true}).IsSuspensionActive())
+	assert.True(t, (&User{IsSuspended: true, ReactivationTime: time.Now().Add(time.Hour).Format(time.RFC3339)}).IsSuspensionActive())
+	assert.False(t, (&User{IsSuspended: true, ReactivationTime: time.Now().Add(-time.Hour).Format(time.RFC3339)}).IsSuspensionActive())
+	assert.False(t, (&User{IsSuspended: false, ReactivationTime: time.Now().Add(time.Hour).Format(time.RFC3339)}).IsSuspensionActive())
+
+	assert.Nil(t, CheckUserSuspended(nil, "en"))
+	assert.Nil(t, CheckUserSuspended(&User{IsForbidden: true}, "en"))
+	assert.EqualError(t, CheckUserSuspended(&User{IsSuspended: true}, "en"), "The user is suspended, please contact the administrator")
+}
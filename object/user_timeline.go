@@ -0,0 +1,180 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+)
+
+const (
+	TimelineEventLogin            = "Login"
+	TimelineEventProfileChange    = "Profile change"
+	TimelineEventMfa              = "MFA"
+	TimelineEventTokenGrant       = "Token grant"
+	TimelineEventPermissionChange = "Permission change"
+)
+
+var timelineEventActions = map[string][]string{
+	TimelineEventLogin: {"login", "logout", "signup", "signin-denied", "break-glass-login", "break-glass-login-failed", "webauthn/signin/finish"},
+	TimelineEventProfileChange: {
+		"add-user", "update-user", "patch-user", "delete-user", "upload-users", "set-password", "reset-email-or-phone",
+		"add-user-keys", "suspend-user", "reactivate-user", "set-user-lifecycle-state", "user-lifecycle-transition",
+		"add-user-contact", "verify-user-contact", "set-primary-user-contact", "delete-user-contact", "issue-break-glass-key",
+	},
+	TimelineEventMfa: {
+		"mfa/setup/initiate", "mfa/setup/verify", "mfa/setup/enable", "mfa/push/send", "mfa/approve", "delete-mfa", "set-preferred-mfa", "webauthn/signup/finish",
+		"regenerate-mfa-recovery-codes", "mfa-reset", "mfa-bypass-issued", "mfa-bypass-used",
+	},
+	TimelineEventPermissionChange: {
+		"add-permission", "update-permission", "delete-permission", "add-role", "update-role", "delete-role",
+		"add-role-users", "remove-role-users", "add-group-users", "remove-group-users", "remove-user-from-group",
+		"access-requested", "access-request-reviewed", "access-request-expired",
+	},
+}
+
+// TimelineEvent is an event of the user's activity timeline, the actor is the one who performed it, which
+// is not the user for the changes made by the admins
+type TimelineEvent struct {
+	Time        string `json:"time"`
+	Type        string `json:"type"`
+	Action      string `json:"action"`
+	Actor       string `json:"actor"`
+	ClientIp    string `json:"clientIp"`
+	Application string `json:"application"`
+	Detail      string `json:"detail"`
+}
+
+func getTimelineEventType(action string) string {
+	for eventType, actions := range timelineEventActions {
+		if util.InSlice(actions, action) {
+			return eventType
+		}
+	}
+	return ""
+}
+
+// isRecordAboutUser checks whether the record targets the user by the id in the query, the owner and name of
+// the object, or the user id quoted in the object such as the users of a role
+func isRecordAboutUser(record *casvisorsdk.Record, user *User) bool {
+	userId := user.GetId()
+	if u, err := url.Parse(record.RequestUri); err == nil && u.Query().Get("id") == userId {
+		return true
+	}
+
+	if strings.Contains(record.Object, fmt.Sprintf("%q", userId)) {
+		return true
+	}
+
+	var obj struct {
+		Owner string `json:"owner"`
+		Name  string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(record.Object), &obj); err != nil {
+		values, err := url.ParseQuery(record.Object)
+		if err != nil {
+			return false
+		}
+		obj.Owner, obj.Name = values.Get("owner"), values.Get("name")
+	}
+	return obj.Owner == user.Owner && obj.Name == user.Name
+}
+
+// getRecordTimelineEvent returns the event of the record performed by or on the user, or nil if the record
+// isn't about the user or not a timeline event
+func getRecordTimelineEvent(record *casvisorsdk.Record, user *User) *TimelineEvent {
+	eventType := getTimelineEventType(record.Action)
+	if eventType == "" {
+		return nil
+	}
+
+	isActor := record.Organization == user.Owner && record.User == user.Name
+	if !isActor && !isRecordAboutUser(record, user) {
+		return nil
+	}
+
+	return &TimelineEvent{
+		Time:        record.CreatedTime,
+		Type:        eventType,
+		Action:      record.Action,
+		Actor:       util.GetId(record.Organization, record.User),
+		ClientIp:    record.ClientIp,
+		Application: getRecordApplication(record),
+		Detail:      record.Object,
+	}
+}
+
+func getTokenTimelineEvent(token *Token, user *User) *TimelineEvent {
+	actor := token.Actor
+	if actor == "" {
+		actor = user.GetId()
+	}
+
+	return &TimelineEvent{
+		Time:        token.CreatedTime,
+		Type:        TimelineEventTokenGrant,
+		Action:      "token",
+		Actor:       actor,
+		Application: token.Application,
+		Detail:      util.StructToJson(map[string]interface{}{"name": token.Name, "scope": token.Scope, "expiresIn": token.ExpiresIn}),
+	}
+}
+
+// GetUserTimeline merges the logins, profile changes, MFA events and permission changes in the records with
+// the token grants of the user into a timeline, newest first. The records are skipped if Casvisor is not
+// configured. The types filter the events if not empty.
+func GetUserTimeline(user *User, types []string) ([]*TimelineEvent, error) {
+	res := []*TimelineEvent{}
+	isTypeIncluded := func(eventType string) bool {
+		return len(types) == 0 || util.InSlice(types, eventType)
+	}
+
+	if casvisorsdk.GetClient() != nil {
+		records, err := casvisorsdk.GetRecords()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			event := getRecordTimelineEvent(record, user)
+			if event != nil && isTypeIncluded(event.Type) {
+				res = append(res, event)
+			}
+		}
+	}
+
+	if isTypeIncluded(TimelineEventTokenGrant) {
+		tokens := []*Token{}
+		err := ormer.Engine.Find(&tokens, &Token{Organization: user.Owner, User: user.Name})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, token := range tokens {
+			res = append(res, getTokenTimelineEvent(token, user))
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Time > res[j].Time
+	})
+	return res, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetRecordTimelineEvent(t *testing.T) {
+	user := &User{Owner: "org", Name: "alice"}
+
+	event := getRecordTimelineEvent(&casvisorsdk.Record{Organization: "org", User: "alice", Action: "login", CreatedTime: "2023-01-01T00:00:00Z"}, user)
+	assert.NotNil(t, event)
+	assert.Equal(t, TimelineEventLogin, event.Type)
+	assert.Equal(t, "org/alice", event.Actor)
+
+	// the login of another user
+	assert.Nil(t, getRecordTimelineEvent(&casvisorsdk.Record{Organization: "org", User: "bob", Action: "login"}, user))
+	// not a timeline event
+	assert.Nil(t, getRecordTimelineEvent(&casvisorsdk.Record{Organization: "org", User: "alice", Action: "update-application"}, user))
+
+	event = getRecordTimelineEvent(&casvisorsdk.Record{Organization: "built-in", User: "admin", Action: "update-user", RequestUri: "/api/update-user?id=org%2Falice"}, user)
+	assert.NotNil(t, event)
+	assert.Equal(t, TimelineEventProfileChange, event.Type)
+	assert.Equal(t, "built-in/admin", event.Actor)
+
+	event = getRecordTimelineEvent(&casvisorsdk.Record{Organization: "org", User: "admin", Action: "add-role-users", Object: `{"users":["org/alice"]}`}, user)
+	assert.NotNil(t, event)
+	assert.Equal(t, TimelineEventPermissionChange, event.Type)
+	assert.Nil(t, getRecordTimelineEvent(&casvisorsdk.Record{Organization: "org", User: "admin", Action: "add-role-users", Object: `{"users":["org/alice2"]}`}, user))
+
+	event = getRecordTimelineEvent(&casvisorsdk.Record{Organization: "org", User: "admin", Action: "delete-mfa", Object: "owner=org&name=alice"}, user)
+	assert.NotNil(t, event)
+	assert.Equal(t, TimelineEventMfa, event.Type)
+	assert.Nil(t, getRecordTimelineEvent(&casvisorsdk.Record{Organization: "org", User: "admin", Action: "delete-user", Object: `{"owner":"org","name":"bob"}`}, user))
+}
+
+func TestGetTokenTimelineEvent(t *testing.T) {
+	user := &User{Owner: "org", Name: "alice"}
+
+	event := getTokenTimelineEvent(&Token{Name: "token", Application: "app", Scope: "openid"}, user)
+	assert.Equal(t, TimelineEventTokenGrant, event.Type)
+	assert.Equal(t, "org/alice", event.Actor)
+	assert.Equal(t, "app", event.Application)
+
+	event = getTokenTimelineEvent(&Token{Name: "token", Application: "app", Actor: "org/service"}, user)
+	assert.Equal(t, "org/service", event.Actor)
+}
+
+func TestGetUserTimelineTokens(t *testing.T) {
+	setTestOrmer(t, &Token{})
+
+	for _, token := range []*Token{
+		{Owner: "admin", Name: "token1", Organization: "org", User: "alice", CreatedTime: "2023-01-01T00:00:00Z"},
+		{Owner: "admin", Name: "token2", Organization: "org", User: "alice", CreatedTime: "2023-01-02T00:00:00Z"},
+		{Owner: "admin", Name: "token3", Organization: "org", User: "bob", CreatedTime: "2023-01-03T00:00:00Z"},
+		{Owner: "admin", Name: "token4", Organization: "other", User: "alice", CreatedTime: "2023-01-04T00:00:00Z"},
+	} {
+		_, err := ormer.Engine.Insert(token)
+		assert.Nil(t, err)
+	}
+
+	events, err := GetUserTimeline(&User{Owner: "org", Name: "alice"}, []string{TimelineEventTokenGrant})
+	assert.Nil(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, "2023-01-02T00:00:00Z", events[0].Time)
+	assert.Equal(t, "2023-01-01T00:00:00Z", events[1].Time)
+}
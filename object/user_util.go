@@ -32,6 +32,11 @@ func GetUserByField(organizationName string, field string, value string) (*User,
 		return nil, nil
 	}
 
+	value, err := getEncryptedUserColumnValue(organizationName, strings.ToLower(field), value)
+	if err != nil {
+		return nil, err
+	}
+
 	user := User{Owner: organizationName}
 	existed, err := ormer.Engine.Where(fmt.Sprintf("%s=?", strings.ToLower(field)), value).Get(&user)
 	if err != nil {
@@ -80,7 +85,8 @@ func GetUserByFields(organization string, field string) (*User, error) {
 		return user, err
 	}
 
-	return nil, nil
+	// check the verified additional emails and phones
+	return getUserByContact(organization, GetVerifyType(field), field)
 }
 
 func SetUserField(user *User, field string, value string) (bool, error) {
@@ -95,6 +101,10 @@ func SetUserField(user *User, field string, value string) (bool, error) {
 		bean[strings.ToLower(field)] = user.Password
 		bean["password_type"] = user.PasswordType
 	} else {
+		value, err := getEncryptedUserColumnValue(user.Owner, strings.ToLower(field), value)
+		if err != nil {
+			return false, err
+		}
 		bean[strings.ToLower(field)] = value
 	}
 
@@ -395,6 +405,21 @@ func (user *User) GetCountryCode(countryCode string) string {
 	return ""
 }
 
+// IsVerificationDest returns whether the verification code destination is the Email or phone of the user
+func (user *User) IsVerificationDest(dest string) bool {
+	if dest == "" {
+		return false
+	}
+	if user.Email != "" && strings.EqualFold(user.Email, dest) {
+		return true
+	}
+	if user.Phone != "" {
+		phone, ok := util.GetE164Number(user.Phone, user.GetCountryCode(""))
+		return ok && phone == dest
+	}
+	return false
+}
+
 func (user *User) IsAdminUser() bool {
 	if user == nil {
 		return false
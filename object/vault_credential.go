@@ -0,0 +1,258 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/xorm-io/core"
+)
+
+// VaultCredential is the credential of a user for a legacy application supporting no federation protocol.
+// The browser extension or the proxy retrieves it after the SSO to fill the login form of the application
+// (password vaulting). The password is encrypted by the "dataEncryptionMasterKey" config and only returned
+// by the retrieval.
+type VaultCredential struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	User         string `xorm:"varchar(100) index" json:"user"`
+	Application  string `xorm:"varchar(100)" json:"application"`
+	Username     string `xorm:"varchar(100)" json:"username"`
+	Password     string `xorm:"varchar(500)" json:"password"`
+	LastUsedTime string `xorm:"varchar(100)" json:"lastUsedTime"`
+}
+
+func GetVaultCredentials(owner string, user string) ([]*VaultCredential, error) {
+	credentials := []*VaultCredential{}
+	err := ormer.Engine.Desc("created_time").Find(&credentials, &VaultCredential{Owner: owner, User: user})
+	if err != nil {
+		return credentials, err
+	}
+
+	return GetMaskedVaultCredentials(credentials), nil
+}
+
+func getVaultCredential(owner string, name string) (*VaultCredential, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	credential := VaultCredential{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&credential)
+	if err != nil {
+		return &credential, err
+	}
+
+	if existed {
+		return &credential, nil
+	}
+	return nil, nil
+}
+
+func GetVaultCredential(id string) (*VaultCredential, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getVaultCredential(owner, name)
+}
+
+func getUserVaultCredential(owner string, user string, application string) (*VaultCredential, error) {
+	credential := VaultCredential{Owner: owner, User: user, Application: application}
+	existed, err := ormer.Engine.Get(&credential)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &credential, nil
+	}
+	return nil, nil
+}
+
+func (credential *VaultCredential) GetId() string {
+	return fmt.Sprintf("%s/%s", credential.Owner, credential.Name)
+}
+
+func GetMaskedVaultCredentials(credentials []*VaultCredential) []*VaultCredential {
+	for _, credential := range credentials {
+		if credential.Password != "" {
+			credential.Password = "***"
+		}
+	}
+	return credentials
+}
+
+// sealVaultPassword encrypts the password bound to the id of the credential, so it can't be moved to another credential
+func sealVaultPassword(id string, password string) (string, error) {
+	aead, err := getDataKeyMasterAead()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(password), []byte(id))), nil
+}
+
+func openVaultPassword(id string, sealedPassword string) (string, error) {
+	aead, err := getDataKeyMasterAead()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sealedPassword)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("the password of the vault credential: %s is invalid", id)
+	}
+
+	password, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("the password of the vault credential: %s is invalid", id)
+	}
+	return string(password), nil
+}
+
+func checkVaultCredential(credential *VaultCredential, lang string) error {
+	application, err := getApplication("admin", credential.Application)
+	if err != nil {
+		return err
+	}
+	if application == nil || application.Organization != credential.Owner {
+		return fmt.Errorf(i18n.Translate(lang, "auth:The application: %s does not exist"), credential.Application)
+	}
+
+	if credential.Username == "" {
+		return fmt.Errorf(i18n.Translate(lang, "general:Missing parameter"))
+	}
+
+	existingCredential, err := getUserVaultCredential(credential.Owner, credential.User, credential.Application)
+	if err != nil {
+		return err
+	}
+	if existingCredential != nil && existingCredential.Name != credential.Name {
+		return fmt.Errorf(i18n.Translate(lang, "user:The vault credential of the application: %s already exists"), credential.Application)
+	}
+
+	return nil
+}
+
+func AddVaultCredential(user *User, credential *VaultCredential, lang string) (bool, error) {
+	credential.Owner = user.Owner
+	credential.Name = util.GenerateId()
+	credential.CreatedTime = util.GetCurrentTime()
+	credential.UpdatedTime = credential.CreatedTime
+	credential.User = user.Name
+	credential.LastUsedTime = ""
+
+	err := checkVaultCredential(credential, lang)
+	if err != nil {
+		return false, err
+	}
+
+	credential.Password, err = sealVaultPassword(credential.GetId(), credential.Password)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := ormer.Engine.Insert(credential)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// UpdateVaultCredential updates the application, the username and the password of the credential,
+// the password is kept if it is empty or masked
+func UpdateVaultCredential(oldCredential *VaultCredential, credential *VaultCredential, lang string) (bool, error) {
+	credential.Owner = oldCredential.Owner
+	credential.Name = oldCredential.Name
+	credential.User = oldCredential.User
+
+	err := checkVaultCredential(credential, lang)
+	if err != nil {
+		return false, err
+	}
+
+	columns := []string{"application", "username", "updated_time"}
+	if credential.Password != "" && credential.Password != "***" {
+		credential.Password, err = sealVaultPassword(credential.GetId(), credential.Password)
+		if err != nil {
+			return false, err
+		}
+		columns = append(columns, "password")
+	}
+
+	credential.UpdatedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{credential.Owner, credential.Name}).Cols(columns...).Update(credential)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func DeleteVaultCredential(credential *VaultCredential) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{credential.Owner, credential.Name}).Delete(&VaultCredential{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// RetrieveVaultCredential returns the credential of the user for the application with the decrypted password,
+// the user should be allowed to sign in to the application
+func RetrieveVaultCredential(user *User, application *Application, lang string) (*VaultCredential, error) {
+	allowed, err := CheckLoginPermission(user.GetId(), application)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, fmt.Errorf(i18n.Translate(lang, "auth:Unauthorized operation"))
+	}
+
+	credential, err := getUserVaultCredential(user.Owner, user.Name, application.Name)
+	if err != nil {
+		return nil, err
+	}
+	if credential == nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "user:The vault credential of the application: %s does not exist"), application.Name)
+	}
+
+	credential.Password, err = openVaultPassword(credential.GetId(), credential.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	credential.LastUsedTime = util.GetCurrentTime()
+	_, err = ormer.Engine.ID(core.PK{credential.Owner, credential.Name}).Cols("last_used_time").Update(&VaultCredential{LastUsedTime: credential.LastUsedTime})
+	if err != nil {
+		return nil, err
+	}
+
+	return credential, nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSealVaultPassword(t *testing.T) {
+	os.Setenv("dataEncryptionMasterKey", "master-key")
+	defer os.Unsetenv("dataEncryptionMasterKey")
+
+	sealedPassword, err := sealVaultPassword("built-in/alice-erp", "secret")
+	assert.Nil(t, err)
+	assert.NotContains(t, sealedPassword, "secret")
+
+	anotherSealedPassword, err := sealVaultPassword("built-in/alice-erp", "secret")
+	assert.Nil(t, err)
+	assert.NotEqual(t, sealedPassword, anotherSealedPassword)
+
+	password, err := openVaultPassword("built-in/alice-erp", sealedPassword)
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", password)
+
+	_, err = openVaultPassword("built-in/bob-erp", sealedPassword)
+	assert.NotNil(t, err)
+}
+
+func TestGetMaskedVaultCredentials(t *testing.T) {
+	credentials := GetMaskedVaultCredentials([]*VaultCredential{{Password: "sealed"}, {}})
+	assert.Equal(t, "***", credentials[0].Password)
+	assert.Equal(t, "", credentials[1].Password)
+}
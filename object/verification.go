@@ -15,13 +15,12 @@
 package object
 
 import (
-	"errors"
+	"crypto/subtle"
 	"fmt"
 	"math/rand"
 	"strings"
 	"time"
 
-	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/i18n"
 	"github.com/casdoor/casdoor/util"
 	"github.com/xorm-io/core"
@@ -30,6 +29,18 @@ import (
 type VerifyResult struct {
 	Code int
 	Msg  string
+
+	RemainingAttempts int
+
+	record *VerificationRecord
+}
+
+// VerificationScope binds a code to the application, the purpose (like "signup" and "forget") and the session
+// requesting it, a code can only be checked in the same scope. The empty fields are not bound.
+type VerificationScope struct {
+	Application string
+	Method      string
+	Session     string
 }
 
 const (
@@ -37,8 +48,12 @@ const (
 	wrongCodeError
 	noRecordError
 	timeoutError
+	tooManyAttemptsError
 )
 
+// the verification tokens can be presented within the minutes after the codes are verified
+const verificationTokenExpireInMinutes = 10
+
 const (
 	VerifyTypePhone = "phone"
 	VerifyTypeEmail = "email"
@@ -57,33 +72,40 @@ type VerificationRecord struct {
 	Code       string `xorm:"varchar(10) notnull"`
 	Time       int64  `xorm:"notnull"`
 	IsUsed     bool
+
+	// the policy of the organization when the code is sent
+	ExpireInMinutes int
+	MaxAttempts     int
+	FailedTimes     int
+
+	Application string `xorm:"varchar(100)"`
+	Method      string `xorm:"varchar(100)"`
+	SessionHash string `xorm:"varchar(100)"`
+	TokenHash   string `xorm:"varchar(100)"`
+	TokenTime   int64
+	IsTokenUsed bool
 }
 
+// IsAllowSend checks whether the code can be sent now with the default policy
 func IsAllowSend(user *User, remoteAddr, recordType string) error {
-	var record VerificationRecord
-	record.RemoteAddr = remoteAddr
-	record.Type = recordType
-	if user != nil {
-		record.User = user.GetId()
-	}
-	has, err := ormer.Engine.Desc("created_time").Get(&record)
+	policy, err := getVerificationCodePolicy(nil)
 	if err != nil {
 		return err
 	}
 
-	now := time.Now().Unix()
-	if has && now-record.Time < 60 {
-		return errors.New("you can only send one code in 60s")
-	}
-
-	return nil
+	return checkVerificationCodeSendable(policy, user, remoteAddr, recordType, "")
 }
 
-func SendVerificationCodeToEmail(organization *Organization, user *User, provider *Provider, remoteAddr string, dest string) error {
+func SendVerificationCodeToEmail(organization *Organization, user *User, provider *Provider, remoteAddr string, dest string, scope *VerificationScope) error {
 	sender := organization.DisplayName
 	title := provider.Title
 
-	code := getRandomCode(6)
+	policy, err := getVerificationCodePolicy(organization)
+	if err != nil {
+		return err
+	}
+
+	code := policy.generateCode()
 	if organization.MasterVerificationCode != "" {
 		code = organization.MasterVerificationCode
 	}
@@ -91,36 +113,46 @@ func SendVerificationCodeToEmail(organization *Organization, user *User, provide
 	// "You have requested a verification code at Casdoor. Here is your code: %s, please enter in 5 minutes."
 	content := fmt.Sprintf(provider.Content, code)
 
-	if err := IsAllowSend(user, remoteAddr, provider.Category); err != nil {
+	if err := checkVerificationCodeSendable(policy, user, remoteAddr, provider.Category, dest); err != nil {
 		return err
 	}
 
+	if user != nil && user.EmailBounce != "" && user.Email == dest {
+		return fmt.Errorf("the email: %s is undeliverable because of the %s, please use another email", dest, strings.ToLower(user.EmailBounce))
+	}
+
 	if err := SendEmail(provider, title, content, dest, sender); err != nil {
 		return err
 	}
 
-	if err := AddToVerificationRecord(user, provider, remoteAddr, provider.Category, dest, code); err != nil {
+	if err := addVerificationRecord(policy, scope, user, provider, remoteAddr, provider.Category, dest, code); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func SendVerificationCodeToPhone(organization *Organization, user *User, provider *Provider, remoteAddr string, dest string) error {
-	if err := IsAllowSend(user, remoteAddr, provider.Category); err != nil {
+func SendVerificationCodeToPhone(organization *Organization, user *User, provider *Provider, remoteAddr string, dest string, scope *VerificationScope) error {
+	policy, err := getVerificationCodePolicy(organization)
+	if err != nil {
 		return err
 	}
 
-	code := getRandomCode(6)
+	if err := checkVerificationCodeSendable(policy, user, remoteAddr, provider.Category, dest); err != nil {
+		return err
+	}
+
+	code := policy.generateCode()
 	if organization.MasterVerificationCode != "" {
 		code = organization.MasterVerificationCode
 	}
 
-	if err := SendSms(provider, code, dest); err != nil {
+	provider, err = SendSmsWithFailover(organization, provider, code, dest)
+	if err != nil {
 		return err
 	}
 
-	if err := AddToVerificationRecord(user, provider, remoteAddr, provider.Category, dest, code); err != nil {
+	if err := addVerificationRecord(policy, scope, user, provider, remoteAddr, provider.Category, dest, code); err != nil {
 		return err
 	}
 
@@ -128,6 +160,15 @@ func SendVerificationCodeToPhone(organization *Organization, user *User, provide
 }
 
 func AddToVerificationRecord(user *User, provider *Provider, remoteAddr, recordType, dest, code string) error {
+	policy, err := getVerificationCodePolicy(nil)
+	if err != nil {
+		return err
+	}
+
+	return addVerificationRecord(policy, nil, user, provider, remoteAddr, recordType, dest, code)
+}
+
+func addVerificationRecord(policy *VerificationCodePolicy, scope *VerificationScope, user *User, provider *Provider, remoteAddr, recordType, dest, code string) error {
 	var record VerificationRecord
 	record.RemoteAddr = remoteAddr
 	record.Type = recordType
@@ -143,6 +184,9 @@ func AddToVerificationRecord(user *User, provider *Provider, remoteAddr, recordT
 	record.Code = code
 	record.Time = time.Now().Unix()
 	record.IsUsed = false
+	record.ExpireInMinutes = policy.ExpireInMinutes
+	record.MaxAttempts = policy.MaxAttempts
+	record.setScope(scope)
 
 	_, err := ormer.Engine.Insert(record)
 	if err != nil {
@@ -152,9 +196,23 @@ func AddToVerificationRecord(user *User, provider *Provider, remoteAddr, recordT
 	return nil
 }
 
-func getVerificationRecord(dest string) (*VerificationRecord, error) {
+func (record *VerificationRecord) setScope(scope *VerificationScope) {
+	if scope == nil {
+		return
+	}
+
+	record.Application = scope.Application
+	record.Method = scope.Method
+	if scope.Session != "" {
+		record.SessionHash = getTokenHash(scope.Session)
+	}
+}
+
+// getVerificationRecord returns the latest unused code sent to the destination in the scope
+func getVerificationRecord(scope *VerificationScope, dest string) (*VerificationRecord, error) {
 	var record VerificationRecord
 	record.Receiver = dest
+	record.setScope(scope)
 	has, err := ormer.Engine.Desc("time").Where("is_used = false").Get(&record)
 	if err != nil {
 		return nil, err
@@ -165,52 +223,127 @@ func getVerificationRecord(dest string) (*VerificationRecord, error) {
 	return &record, nil
 }
 
-func CheckVerificationCode(dest string, code string, lang string) *VerifyResult {
-	record, err := getVerificationRecord(dest)
+// isVerificationCodeEqual compares the codes in constant time, the alphanumeric codes are case-insensitive
+func isVerificationCodeEqual(code1 string, code2 string) bool {
+	return subtle.ConstantTimeCompare([]byte(strings.ToUpper(code1)), []byte(strings.ToUpper(code2))) == 1
+}
+
+// CheckVerificationCode checks the code sent to the destination in the scope, a nil scope accepts the code of any
+// scope. The code is used up once it passes.
+func CheckVerificationCode(scope *VerificationScope, dest string, code string, lang string) *VerifyResult {
+	record, err := getVerificationRecord(scope, dest)
 	if err != nil {
 		panic(err)
 	}
 
+	noRecordResult := &VerifyResult{Code: noRecordError, Msg: i18n.Translate(lang, "verification:Code has not been sent yet!")}
 	if record == nil {
-		return &VerifyResult{noRecordError, i18n.Translate(lang, "verification:Code has not been sent yet!")}
+		return noRecordResult
 	}
 
-	timeout, err := conf.GetConfigInt64("verificationCodeTimeout")
-	if err != nil {
-		panic(err)
+	// the records sent before the policies have the default TTL
+	timeout := int64(record.ExpireInMinutes)
+	if timeout == 0 {
+		timeout = int64(getDefaultVerificationCodeExpireInMinutes())
 	}
 
 	now := time.Now().Unix()
 	if now-record.Time > timeout*60 {
-		return &VerifyResult{timeoutError, fmt.Sprintf(i18n.Translate(lang, "verification:You should verify your code in %d min!"), timeout)}
+		return &VerifyResult{Code: timeoutError, Msg: fmt.Sprintf(i18n.Translate(lang, "verification:You should verify your code in %d min!"), timeout)}
+	}
+
+	if record.MaxAttempts > 0 && record.FailedTimes >= record.MaxAttempts {
+		return &VerifyResult{Code: tooManyAttemptsError, Msg: i18n.Translate(lang, "verification:Too many wrong attempts, please request a new code")}
+	}
+
+	if !isVerificationCodeEqual(record.Code, code) {
+		record.FailedTimes++
+		_, err = ormer.Engine.ID(core.PK{record.Owner, record.Name}).Cols("failed_times").Update(record)
+		if err != nil {
+			panic(err)
+		}
+
+		result := &VerifyResult{Code: wrongCodeError, Msg: i18n.Translate(lang, "verification:Wrong verification code!")}
+		if record.MaxAttempts > 0 {
+			result.RemainingAttempts = record.MaxAttempts - record.FailedTimes
+		}
+		return result
 	}
 
-	if record.Code != code {
-		return &VerifyResult{wrongCodeError, i18n.Translate(lang, "verification:Wrong verification code!")}
+	// only one of the concurrent requests with the same code passes
+	record.IsUsed = true
+	affected, err := ormer.Engine.ID(core.PK{record.Owner, record.Name}).Where("is_used = ?", false).Cols("is_used").Update(record)
+	if err != nil {
+		panic(err)
+	}
+	if affected == 0 {
+		return noRecordResult
 	}
 
-	return &VerifyResult{VerificationSuccess, ""}
+	return &VerifyResult{Code: VerificationSuccess, record: record}
 }
 
-func DisableVerificationCode(dest string) error {
-	record, err := getVerificationRecord(dest)
-	if record == nil || err != nil {
-		return nil
+// GetError returns the structured error of the failed verification for the clients
+func (result *VerifyResult) GetError() *VerificationCodeError {
+	return &VerificationCodeError{Msg: result.Msg, RemainingAttempts: result.RemainingAttempts}
+}
+
+// IssueVerificationToken returns a single-use token proving the ownership of the destination of the verified code,
+// the later steps of the flow present it in the same scope instead of the code
+func IssueVerificationToken(result *VerifyResult) (string, error) {
+	if result.Code != VerificationSuccess || result.record == nil {
+		return "", fmt.Errorf("the verification code is not verified")
 	}
 
-	record.IsUsed = true
-	_, err = ormer.Engine.ID(core.PK{record.Owner, record.Name}).AllCols().Update(record)
-	return err
+	token := util.GenerateId()
+	record := result.record
+	record.TokenHash = getTokenHash(token)
+	record.TokenTime = time.Now().Unix()
+	_, err := ormer.Engine.ID(core.PK{record.Owner, record.Name}).Cols("token_hash", "token_time").Update(record)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// ConsumeVerificationToken checks the token issued in the scope and uses it up, the verified code is returned
+func ConsumeVerificationToken(scope *VerificationScope, token string, lang string) (*VerificationRecord, error) {
+	invalidErr := fmt.Errorf(i18n.Translate(lang, "verification:The verification token is invalid or expired"))
+	if token == "" {
+		return nil, invalidErr
+	}
+
+	record := &VerificationRecord{TokenHash: getTokenHash(token)}
+	record.setScope(scope)
+	has, err := ormer.Engine.Where("is_token_used = ?", false).Get(record)
+	if err != nil {
+		return nil, err
+	}
+	if !has || time.Now().Unix()-record.TokenTime > verificationTokenExpireInMinutes*60 {
+		return nil, invalidErr
+	}
+
+	record.IsTokenUsed = true
+	affected, err := ormer.Engine.ID(core.PK{record.Owner, record.Name}).Where("is_token_used = ?", false).Cols("is_token_used").Update(record)
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, invalidErr
+	}
+
+	return record, nil
 }
 
-func CheckSigninCode(user *User, dest, code, lang string) error {
+func CheckSigninCode(scope *VerificationScope, user *User, dest, code, lang string) error {
 	// check the login error times
 	err := checkSigninErrorTimes(user, lang)
 	if err != nil {
 		return err
 	}
 
-	result := CheckVerificationCode(dest, code, lang)
+	result := CheckVerificationCode(scope, dest, code, lang)
 	switch result.Code {
 	case VerificationSuccess:
 		return resetUserSigninErrorTimes(user)
@@ -230,13 +363,17 @@ func GetVerifyType(username string) (verificationCodeType string) {
 }
 
 // From Casnode/object/validateCode.go line 116
-var stdNums = []byte("0123456789")
+var stdNums = "0123456789"
 
 func getRandomCode(length int) string {
+	return getRandomCodeByCharset(stdNums, length)
+}
+
+func getRandomCodeByCharset(charset string, length int) string {
 	var result []byte
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	for i := 0; i < length; i++ {
-		result = append(result, stdNums[r.Intn(len(stdNums))])
+		result = append(result, charset[r.Intn(len(charset))])
 	}
 	return string(result)
 }
@@ -0,0 +1,172 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/casdoor/casdoor/conf"
+)
+
+const (
+	VerificationCodeCharsetNumeric      = "Numeric"
+	VerificationCodeCharsetAlphanumeric = "Alphanumeric"
+
+	defaultVerificationCodeLength        = 6
+	defaultVerificationCodeResendSeconds = 60
+	minVerificationCodeLength            = 4
+	maxVerificationCodeLength            = 10
+)
+
+// the letters that look like digits are left out of the alphanumeric codes
+var verificationCodeCharsets = map[string]string{
+	VerificationCodeCharsetNumeric:      "0123456789",
+	VerificationCodeCharsetAlphanumeric: "23456789ABCDEFGHJKLMNPQRSTUVWXYZ",
+}
+
+// VerificationCodePolicy is the policy of the email and phone verification codes of the organization, the zero
+// values fall back to the defaults: 6 numeric characters, the "verificationCodeTimeout" config as the TTL,
+// unlimited attempts, a 60 seconds resend cooldown and no daily quota
+type VerificationCodePolicy struct {
+	Length                int    `json:"length"`
+	Charset               string `json:"charset"`
+	ExpireInMinutes       int    `json:"expireInMinutes"`
+	MaxAttempts           int    `json:"maxAttempts"`
+	ResendCooldownSeconds int    `json:"resendCooldownSeconds"`
+	DailyQuota            int    `json:"dailyQuota"`
+}
+
+// VerificationCodeError is the error of sending or checking a verification code with the details for the clients
+type VerificationCodeError struct {
+	Msg               string `json:"-"`
+	RemainingAttempts int    `json:"remainingAttempts,omitempty"`
+	RetryAfter        int64  `json:"retryAfter,omitempty"`
+}
+
+func (e *VerificationCodeError) Error() string {
+	return e.Msg
+}
+
+func getDefaultVerificationCodeExpireInMinutes() int {
+	timeout, err := conf.GetConfigInt64("verificationCodeTimeout")
+	if err != nil || timeout <= 0 {
+		return 10
+	}
+	return int(timeout)
+}
+
+// getVerificationCodePolicy returns the effective policy of the organization, which may be inherited from its
+// ancestors, with the defaults filled in
+func getVerificationCodePolicy(organization *Organization) (*VerificationCodePolicy, error) {
+	res := VerificationCodePolicy{}
+
+	organization, err := GetInheritedOrganization(organization)
+	if err != nil {
+		return nil, err
+	}
+	if organization != nil && organization.VerificationCodePolicy != nil {
+		res = *organization.VerificationCodePolicy
+	}
+
+	if res.Length == 0 {
+		res.Length = defaultVerificationCodeLength
+	}
+	if res.Charset == "" {
+		res.Charset = VerificationCodeCharsetNumeric
+	}
+	if res.ExpireInMinutes == 0 {
+		res.ExpireInMinutes = getDefaultVerificationCodeExpireInMinutes()
+	}
+	if res.ResendCooldownSeconds == 0 {
+		res.ResendCooldownSeconds = defaultVerificationCodeResendSeconds
+	}
+
+	return &res, nil
+}
+
+func (policy *VerificationCodePolicy) generateCode() string {
+	return getRandomCodeByCharset(verificationCodeCharsets[policy.Charset], policy.Length)
+}
+
+// checkVerificationCodeSendable checks the resend cooldown of the client and the daily quota of the user, or of
+// the receiver if the user is unknown
+func checkVerificationCodeSendable(policy *VerificationCodePolicy, user *User, remoteAddr string, recordType string, dest string) error {
+	var record VerificationRecord
+	record.RemoteAddr = remoteAddr
+	record.Type = recordType
+	if user != nil {
+		record.User = user.GetId()
+	}
+	has, err := ormer.Engine.Desc("created_time").Get(&record)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	cooldown := int64(policy.ResendCooldownSeconds)
+	if has && now-record.Time < cooldown {
+		return &VerificationCodeError{
+			Msg:        fmt.Sprintf("you can only send one code in %ds", policy.ResendCooldownSeconds),
+			RetryAfter: cooldown - (now - record.Time),
+		}
+	}
+
+	if policy.DailyQuota <= 0 {
+		return nil
+	}
+
+	session := ormer.Engine.Where("time > ?", now-24*60*60)
+	if user != nil {
+		session = session.And("`user` = ?", user.GetId())
+	} else {
+		session = session.And("receiver = ?", dest)
+	}
+
+	records := []*VerificationRecord{}
+	err = session.Asc("time").Cols("time").Find(&records)
+	if err != nil {
+		return err
+	}
+
+	if len(records) >= policy.DailyQuota {
+		// the quota is freed when the oldest code of the last 24 hours gets out of the window
+		oldest := records[len(records)-policy.DailyQuota]
+		return &VerificationCodeError{
+			Msg:        fmt.Sprintf("you can only send %d codes in 24 hours", policy.DailyQuota),
+			RetryAfter: oldest.Time + 24*60*60 - now,
+		}
+	}
+
+	return nil
+}
+
+func checkVerificationCodePolicy(policy *VerificationCodePolicy) error {
+	if policy == nil {
+		return nil
+	}
+
+	if policy.Length != 0 && (policy.Length < minVerificationCodeLength || policy.Length > maxVerificationCodeLength) {
+		return fmt.Errorf("the length of the verification code should be between %d and %d", minVerificationCodeLength, maxVerificationCodeLength)
+	}
+	if _, ok := verificationCodeCharsets[policy.Charset]; policy.Charset != "" && !ok {
+		return fmt.Errorf("the charset: %s of the verification code is not supported", policy.Charset)
+	}
+	if policy.ExpireInMinutes < 0 || policy.MaxAttempts < 0 || policy.ResendCooldownSeconds < 0 || policy.DailyQuota < 0 {
+		return fmt.Errorf("the TTL, max attempts, resend cooldown and daily quota of the verification code should not be negative")
+	}
+
+	return nil
+}
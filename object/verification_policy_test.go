@@ -0,0 +1,76 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVerificationCodePolicy(t *testing.T) {
+	policy, err := getVerificationCodePolicy(&Organization{Name: "org"})
+	assert.Nil(t, err)
+	assert.Equal(t, 6, policy.Length)
+	assert.Equal(t, VerificationCodeCharsetNumeric, policy.Charset)
+	assert.Equal(t, 60, policy.ResendCooldownSeconds)
+	assert.Equal(t, 0, policy.MaxAttempts)
+	assert.Greater(t, policy.ExpireInMinutes, 0)
+
+	policy, err = getVerificationCodePolicy(&Organization{Name: "org", VerificationCodePolicy: &VerificationCodePolicy{Length: 8, Charset: VerificationCodeCharsetAlphanumeric, MaxAttempts: 3}})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, policy.MaxAttempts)
+
+	code := policy.generateCode()
+	assert.Len(t, code, 8)
+	for _, c := range code {
+		assert.True(t, strings.ContainsRune(verificationCodeCharsets[VerificationCodeCharsetAlphanumeric], c))
+	}
+}
+
+func TestCheckVerificationCodePolicy(t *testing.T) {
+	assert.Nil(t, checkVerificationCodePolicy(nil))
+	assert.Nil(t, checkVerificationCodePolicy(&VerificationCodePolicy{Length: 4, Charset: VerificationCodeCharsetAlphanumeric, DailyQuota: 10}))
+	assert.NotNil(t, checkVerificationCodePolicy(&VerificationCodePolicy{Length: 11}))
+	assert.NotNil(t, checkVerificationCodePolicy(&VerificationCodePolicy{Charset: "Emoji"}))
+	assert.NotNil(t, checkVerificationCodePolicy(&VerificationCodePolicy{MaxAttempts: -1}))
+
+	result := &VerifyResult{Code: wrongCodeError, Msg: "Wrong verification code!", RemainingAttempts: 2}
+	assert.EqualError(t, result.GetError(), "Wrong verification code!")
+	assert.Equal(t, 2, result.GetError().RemainingAttempts)
+}
+
+func TestCheckVerificationCodeSendableDailyQuota(t *testing.T) {
+	setTestOrmer(t, &VerificationRecord{})
+
+	user := &User{Owner: "org", Name: "alice"}
+	policy := &VerificationCodePolicy{ResendCooldownSeconds: 60, DailyQuota: 2}
+	now := time.Now().Unix()
+	for i, userId := range []string{"org/alice", "org/alice", "org/bob"} {
+		record := &VerificationRecord{Owner: "admin", Name: fmt.Sprintf("record%d", i), RemoteAddr: "1.1.1.1", User: userId, Time: now - int64(3600*(i+1))}
+		_, err := ormer.Engine.Insert(record)
+		assert.Nil(t, err)
+	}
+
+	err := checkVerificationCodeSendable(policy, user, "2.2.2.2", VerifyTypeEmail, "alice@example.com")
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "24 hours"))
+
+	err = checkVerificationCodeSendable(policy, &User{Owner: "org", Name: "bob"}, "2.2.2.2", VerifyTypeEmail, "bob@example.com")
+	assert.Nil(t, err)
+}
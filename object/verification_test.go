@@ -0,0 +1,84 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsVerificationCodeEqual(t *testing.T) {
+	assert.True(t, isVerificationCodeEqual("123456", "123456"))
+	assert.True(t, isVerificationCodeEqual("aB3dE9", "AB3DE9"))
+	assert.False(t, isVerificationCodeEqual("123456", "123457"))
+	assert.False(t, isVerificationCodeEqual("123456", "12345"))
+	assert.False(t, isVerificationCodeEqual("123456", ""))
+}
+
+func TestVerificationRecordSetScope(t *testing.T) {
+	record := &VerificationRecord{}
+	record.setScope(nil)
+	assert.Equal(t, &VerificationRecord{}, record)
+
+	record.setScope(&VerificationScope{Application: "admin/app-1", Method: "forget", Session: "session-1"})
+	assert.Equal(t, "admin/app-1", record.Application)
+	assert.Equal(t, "forget", record.Method)
+	assert.Equal(t, getTokenHash("session-1"), record.SessionHash)
+	assert.NotEqual(t, "session-1", record.SessionHash)
+
+	// the empty fields are not bound
+	record = &VerificationRecord{}
+	record.setScope(&VerificationScope{Method: "reset"})
+	assert.Equal(t, "", record.SessionHash)
+}
+
+func TestIssueVerificationToken(t *testing.T) {
+	_, err := IssueVerificationToken(&VerifyResult{Code: wrongCodeError})
+	assert.NotNil(t, err)
+
+	_, err = IssueVerificationToken(&VerifyResult{Code: VerificationSuccess})
+	assert.NotNil(t, err)
+}
+
+func TestIsVerificationDest(t *testing.T) {
+	user := &User{Email: "Alice@example.com"}
+	assert.True(t, user.IsVerificationDest("alice@example.com"))
+	assert.False(t, user.IsVerificationDest("bob@example.com"))
+	assert.False(t, user.IsVerificationDest(""))
+	assert.False(t, (&User{}).IsVerificationDest(""))
+}
+
+func TestSmsMfaVerifyScope(t *testing.T) {
+	setTestOrmer(t, &VerificationRecord{})
+
+	addRecord := func(name string, method string, code string) {
+		record := &VerificationRecord{Owner: "admin", Name: name, Receiver: "alice@example.com", Code: code, Time: time.Now().Unix()}
+		record.setScope(&VerificationScope{Application: "admin/app-1", Method: method, Session: "session-1"})
+		_, err := ormer.Engine.Insert(record)
+		assert.Nil(t, err)
+	}
+
+	mfaUtil := NewEmailMfaUtil(&MfaProps{MfaType: EmailType, Secret: "alice@example.com"})
+
+	// a signup code sent to the same email is not a passcode
+	addRecord("record1", "signup", "123456")
+	assert.NotNil(t, mfaUtil.Verify("123456"))
+
+	addRecord("record2", MfaAuthVerification, "654321")
+	assert.Nil(t, mfaUtil.Verify("654321"))
+	assert.NotNil(t, mfaUtil.Verify("654321"))
+}
@@ -0,0 +1,184 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	WatchTypePolicy     = "policy"
+	WatchTypePermission = "permission"
+	WatchTypeUserGroup  = "user-group"
+
+	WatchActionAdd    = "add"
+	WatchActionUpdate = "update"
+	WatchActionDelete = "delete"
+
+	watchBufferSize = 100
+)
+
+// WatchEvent is a change notification streamed to the watchers, Key is the id of the changed
+// enforcer, permission or user. Groups are the groups of the user before and after the change,
+// they are empty if the change is made by another instance and the groups are unknown.
+type WatchEvent struct {
+	Id          int64    `json:"id"`
+	CreatedTime string   `json:"createdTime"`
+	Type        string   `json:"type"`
+	Action      string   `json:"action"`
+	Key         string   `json:"key"`
+	Groups      []string `json:"groups,omitempty"`
+	Data        []string `json:"data,omitempty"`
+}
+
+// WatchFilter selects the events of a watcher, the empty fields match everything
+type WatchFilter struct {
+	Types    []string
+	Owner    string
+	Enforcer string
+	Group    string
+}
+
+// Watcher receives the events matching its filter, the events are dropped and the watcher is closed
+// with IsOverflowed set if it can't keep up, so that its client reloads instead of missing changes.
+type Watcher struct {
+	Events       chan *WatchEvent
+	IsOverflowed bool
+
+	filter   *WatchFilter
+	isClosed bool
+}
+
+var (
+	watchers      = map[*Watcher]bool{}
+	watchersMutex sync.Mutex
+	watchEventId  int64
+)
+
+func init() {
+	// the changes made by the other instances sharing the database
+	RegisterCacheInvalidationHandler(CacheTypeEnforcer, func(key string) {
+		publishWatchEvent(WatchTypePolicy, WatchActionUpdate, key, nil, nil)
+	})
+	RegisterCacheInvalidationHandler(CacheTypeUserGroup, func(key string) {
+		publishWatchEvent(WatchTypeUserGroup, WatchActionUpdate, key, nil, nil)
+	})
+}
+
+func (filter *WatchFilter) isMatched(event *WatchEvent) bool {
+	if len(filter.Types) != 0 && !util.InSlice(filter.Types, event.Type) {
+		return false
+	}
+
+	// an empty key means all the objects of the type are changed
+	if event.Key == "" {
+		return true
+	}
+
+	if filter.Owner != "" && !strings.HasPrefix(event.Key, filter.Owner+"/") {
+		return false
+	}
+
+	if filter.Enforcer != "" && event.Type == WatchTypePolicy && event.Key != filter.Enforcer {
+		return false
+	}
+
+	if filter.Group != "" && event.Type == WatchTypeUserGroup && len(event.Groups) != 0 && !util.InSlice(event.Groups, filter.Group) {
+		return false
+	}
+
+	return true
+}
+
+func AddWatcher(filter *WatchFilter) *Watcher {
+	watcher := &Watcher{
+		Events: make(chan *WatchEvent, watchBufferSize),
+		filter: filter,
+	}
+
+	watchersMutex.Lock()
+	defer watchersMutex.Unlock()
+
+	watchers[watcher] = true
+	return watcher
+}
+
+func (watcher *Watcher) close() {
+	if watcher.isClosed {
+		return
+	}
+
+	watcher.isClosed = true
+	delete(watchers, watcher)
+	close(watcher.Events)
+}
+
+func (watcher *Watcher) Close() {
+	watchersMutex.Lock()
+	defer watchersMutex.Unlock()
+
+	watcher.close()
+}
+
+func publishWatchEvent(typ string, action string, key string, groups []string, data []string) {
+	watchersMutex.Lock()
+	defer watchersMutex.Unlock()
+
+	if len(watchers) == 0 {
+		return
+	}
+
+	watchEventId += 1
+	event := &WatchEvent{
+		Id:          watchEventId,
+		CreatedTime: util.GetCurrentTime(),
+		Type:        typ,
+		Action:      action,
+		Key:         key,
+		Groups:      groups,
+		Data:        data,
+	}
+
+	for watcher := range watchers {
+		if !watcher.filter.isMatched(event) {
+			continue
+		}
+
+		select {
+		case watcher.Events <- event:
+		default:
+			watcher.IsOverflowed = true
+			watcher.close()
+		}
+	}
+}
+
+func publishPolicyWatchEvent(id string, action string, ptype string, policy []string) {
+	publishWatchEvent(WatchTypePolicy, action, id, nil, append([]string{ptype}, policy...))
+}
+
+func publishUserGroupWatchEvent(userId string, oldGroups []string, newGroups []string) {
+	groups := append([]string{}, oldGroups...)
+	for _, group := range newGroups {
+		if !util.InSlice(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+
+	publishWatchEvent(WatchTypeUserGroup, WatchActionUpdate, userId, groups, newGroups)
+}
@@ -0,0 +1,51 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchFilter(t *testing.T) {
+	filter := &WatchFilter{Types: []string{WatchTypePolicy, WatchTypeUserGroup}, Owner: "built-in", Enforcer: "built-in/enforcer1", Group: "built-in/group1"}
+
+	assert.True(t, filter.isMatched(&WatchEvent{Type: WatchTypePolicy, Key: "built-in/enforcer1"}))
+	assert.True(t, filter.isMatched(&WatchEvent{Type: WatchTypePolicy, Key: ""}))
+	assert.False(t, filter.isMatched(&WatchEvent{Type: WatchTypePolicy, Key: "built-in/enforcer2"}))
+	assert.False(t, filter.isMatched(&WatchEvent{Type: WatchTypePermission, Key: "built-in/permission1"}))
+	assert.True(t, filter.isMatched(&WatchEvent{Type: WatchTypeUserGroup, Key: "built-in/alice", Groups: []string{"built-in/group1"}}))
+	assert.True(t, filter.isMatched(&WatchEvent{Type: WatchTypeUserGroup, Key: "built-in/alice"}))
+	assert.False(t, filter.isMatched(&WatchEvent{Type: WatchTypeUserGroup, Key: "built-in/alice", Groups: []string{"built-in/group2"}}))
+	assert.False(t, filter.isMatched(&WatchEvent{Type: WatchTypeUserGroup, Key: "org2/bob", Groups: []string{"built-in/group1"}}))
+}
+
+func TestWatcherOverflow(t *testing.T) {
+	watcher := AddWatcher(&WatchFilter{Types: []string{WatchTypePermission}})
+	defer watcher.Close()
+
+	publishWatchEvent(WatchTypePermission, WatchActionAdd, "built-in/permission1", nil, nil)
+	event := <-watcher.Events
+	assert.Equal(t, "built-in/permission1", event.Key)
+	assert.Equal(t, WatchActionAdd, event.Action)
+
+	for i := 0; i <= watchBufferSize; i++ {
+		publishWatchEvent(WatchTypePermission, WatchActionUpdate, "built-in/permission1", nil, nil)
+	}
+	for range watcher.Events {
+	}
+	assert.True(t, watcher.IsOverflowed)
+}
@@ -15,12 +15,18 @@
 package object
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/casdoor/casdoor/util"
 	"github.com/xorm-io/core"
 )
 
+// the previous signing secret still signs the payloads for a while after the rotation, so that the receivers
+// can switch to the new secret without missing any event
+const webhookSecretRotationPeriod = 24 * time.Hour
+
 type Header struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
@@ -40,6 +46,12 @@ type Webhook struct {
 	Events         []string  `xorm:"varchar(1000)" json:"events"`
 	IsUserExtended bool      `json:"isUserExtended"`
 	IsEnabled      bool      `json:"isEnabled"`
+
+	Applications          []string `xorm:"varchar(1000)" json:"applications"`
+	PayloadTemplate       string   `xorm:"mediumtext" json:"payloadTemplate"`
+	SigningSecret         string   `xorm:"varchar(100)" json:"signingSecret"`
+	PreviousSigningSecret string   `xorm:"varchar(100)" json:"previousSigningSecret"`
+	SecretRotatedTime     string   `xorm:"varchar(100)" json:"secretRotatedTime"`
 }
 
 func GetWebhookCount(owner, organization, field, value string) (int64, error) {
@@ -109,6 +121,11 @@ func UpdateWebhook(id string, webhook *Webhook) (bool, error) {
 		return false, nil
 	}
 
+	err := checkWebhookPayloadTemplate(webhook.PayloadTemplate)
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(webhook)
 	if err != nil {
 		return false, err
@@ -118,6 +135,11 @@ func UpdateWebhook(id string, webhook *Webhook) (bool, error) {
 }
 
 func AddWebhook(webhook *Webhook) (bool, error) {
+	err := checkWebhookPayloadTemplate(webhook.PayloadTemplate)
+	if err != nil {
+		return false, err
+	}
+
 	affected, err := ormer.Engine.Insert(webhook)
 	if err != nil {
 		return false, err
@@ -138,3 +160,50 @@ func DeleteWebhook(webhook *Webhook) (bool, error) {
 func (p *Webhook) GetId() string {
 	return fmt.Sprintf("%s/%s", p.Owner, p.Name)
 }
+
+// RotateWebhookSecret generates a new signing secret for the webhook, the current one is kept as the previous secret
+func RotateWebhookSecret(id string) (*Webhook, error) {
+	webhook, err := GetWebhook(id)
+	if err != nil || webhook == nil {
+		return webhook, err
+	}
+
+	webhook.PreviousSigningSecret = webhook.SigningSecret
+	webhook.SigningSecret = fmt.Sprintf("whsec_%s", util.GenerateClientSecret())
+	webhook.SecretRotatedTime = util.GetCurrentTime()
+
+	_, err = ormer.Engine.ID(core.PK{webhook.Owner, webhook.Name}).Cols("signing_secret", "previous_signing_secret", "secret_rotated_time").Update(webhook)
+	if err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// getSigningSecrets returns the secrets signing the payloads, the previous one is included during the rotation
+func (webhook *Webhook) getSigningSecrets(now time.Time) []string {
+	res := []string{}
+	if webhook.SigningSecret != "" {
+		res = append(res, webhook.SigningSecret)
+	}
+
+	if webhook.PreviousSigningSecret != "" && webhook.SecretRotatedTime != "" {
+		rotatedTime, err := time.Parse(time.RFC3339, webhook.SecretRotatedTime)
+		if err == nil && now.Before(rotatedTime.Add(webhookSecretRotationPeriod)) {
+			res = append(res, webhook.PreviousSigningSecret)
+		}
+	}
+	return res
+}
+
+func checkWebhookPayloadTemplate(payloadTemplate string) error {
+	if payloadTemplate == "" {
+		return nil
+	}
+
+	var template interface{}
+	err := json.Unmarshal([]byte(payloadTemplate), &template)
+	if err != nil {
+		return fmt.Errorf("the payload template is not a valid JSON: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,327 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/util"
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/xorm-io/core"
+	"github.com/xorm-io/xorm"
+)
+
+const (
+	WebhookEventStatePending   = "Pending"
+	WebhookEventStateDelivered = "Delivered"
+	WebhookEventStateFailed    = "Failed"
+
+	defaultWebhookMaxAttempts = 10
+	webhookEventBatchSize     = 100
+	webhookEventMinBackoff    = 10 * time.Second
+	webhookEventMaxBackoff    = time.Hour
+	// a claimed event is delivered again after the lock timeout if the dispatcher crashed while sending it
+	webhookEventLockTimeout = 5 * time.Minute
+	webhookEventKeepTime    = 7 * 24 * time.Hour
+)
+
+// WebhookEvent is an event of the transactional outbox, it is written with the mutation that originates it and
+// delivered to the webhook by the dispatcher with retries, so no event is lost if the process crashes between
+// the commit and the HTTP call. The events are delivered at least once.
+type WebhookEvent struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	Organization string `xorm:"varchar(100) index" json:"organization"`
+	Webhook      string `xorm:"varchar(200) index" json:"webhook"`
+	Action       string `xorm:"varchar(100)" json:"action"`
+	Record       string `xorm:"mediumtext" json:"record"`
+
+	State         string `xorm:"varchar(100) index" json:"state"`
+	Attempts      int    `json:"attempts"`
+	NextTime      string `xorm:"varchar(100) index" json:"nextTime"`
+	LastError     string `xorm:"varchar(1000)" json:"lastError"`
+	DeliveredTime string `xorm:"varchar(100)" json:"deliveredTime"`
+}
+
+var webhookDispatcherWakeup = make(chan struct{}, 1)
+
+func GetWebhookEventCount(owner, organization, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&WebhookEvent{Organization: organization})
+}
+
+func GetPaginationWebhookEvents(owner, organization string, offset, limit int, field, value, sortField, sortOrder string) ([]*WebhookEvent, error) {
+	webhookEvents := []*WebhookEvent{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&webhookEvents, &WebhookEvent{Organization: organization})
+	if err != nil {
+		return webhookEvents, err
+	}
+
+	return webhookEvents, nil
+}
+
+func GetWebhookEvent(id string) (*WebhookEvent, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	webhookEvent := WebhookEvent{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&webhookEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &webhookEvent, nil
+	}
+	return nil, nil
+}
+
+// RetryWebhookEvent delivers the failed event again with the attempts reset
+func RetryWebhookEvent(webhookEvent *WebhookEvent) (bool, error) {
+	webhookEvent.State = WebhookEventStatePending
+	webhookEvent.Attempts = 0
+	webhookEvent.NextTime = getWebhookEventTime(time.Now())
+	affected, err := ormer.Engine.ID(core.PK{webhookEvent.Owner, webhookEvent.Name}).Cols("state", "attempts", "next_time").Update(webhookEvent)
+	if err != nil {
+		return false, err
+	}
+
+	wakeWebhookDispatcher()
+	return affected != 0, nil
+}
+
+// getWebhookEventTime formats the time in UTC, so the times of the events are compared as strings in the queries
+func getWebhookEventTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// addWebhookEvents writes the events of the record for the matched webhooks into the outbox,
+// the db is the session of the transaction of the mutation originating the record
+func addWebhookEvents(db xorm.Interface, record *casvisorsdk.Record) error {
+	webhooks, err := getWebhooksByOrganization(record.Organization)
+	if err != nil {
+		return err
+	}
+
+	webhooks = getFilteredWebhooks(webhooks, record)
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	webhookEvents := []*WebhookEvent{}
+	for _, webhook := range webhooks {
+		webhookEvents = append(webhookEvents, &WebhookEvent{
+			Owner:        webhook.Owner,
+			Name:         util.GenerateId(),
+			CreatedTime:  util.GetCurrentTime(),
+			Organization: record.Organization,
+			Webhook:      webhook.GetId(),
+			Action:       record.Action,
+			Record:       util.StructToJson(record),
+			State:        WebhookEventStatePending,
+			NextTime:     getWebhookEventTime(now),
+		})
+	}
+
+	_, err = db.Insert(webhookEvents)
+	return err
+}
+
+// runWithRecord runs the mutation and writes the webhook events of its record in the same transaction,
+// the record is added after the commit without triggering the webhooks again
+func runWithRecord(record *casvisorsdk.Record, mutate func(session *xorm.Session) (bool, error)) (bool, error) {
+	session := ormer.Engine.NewSession()
+	defer session.Close()
+
+	err := session.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := mutate(session)
+	if err == nil && affected {
+		err = addWebhookEvents(session, record)
+	}
+	if err != nil {
+		rollbackErr := session.Rollback()
+		if rollbackErr != nil {
+			return false, rollbackErr
+		}
+		return false, err
+	}
+
+	err = session.Commit()
+	if err != nil {
+		return false, err
+	}
+
+	if affected {
+		record.IsTriggered = true
+		wakeWebhookDispatcher()
+		util.SafeGoroutine(func() { AddRecord(record) })
+	}
+	return affected, nil
+}
+
+func wakeWebhookDispatcher() {
+	select {
+	case webhookDispatcherWakeup <- struct{}{}:
+	default:
+	}
+}
+
+func getWebhookMaxAttempts() int {
+	return getRecordWriterConfigInt("webhookMaxAttempts", defaultWebhookMaxAttempts)
+}
+
+// getWebhookEventBackoff returns the exponential delay before the next attempt after the failed attempts
+func getWebhookEventBackoff(attempts int) time.Duration {
+	backoff := webhookEventMinBackoff
+	for i := 1; i < attempts && backoff < webhookEventMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookEventMaxBackoff {
+		return webhookEventMaxBackoff
+	}
+	return backoff
+}
+
+// claimWebhookEvent locks the event for the lock timeout, so only one dispatcher of the instances delivers it
+func claimWebhookEvent(webhookEvent *WebhookEvent, now time.Time) (bool, error) {
+	nextTime := getWebhookEventTime(now.Add(webhookEventLockTimeout))
+	affected, err := ormer.Engine.Where("owner = ? and name = ? and state = ? and next_time = ?", webhookEvent.Owner, webhookEvent.Name, WebhookEventStatePending, webhookEvent.NextTime).
+		Cols("next_time").Update(&WebhookEvent{NextTime: nextTime})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func sendWebhookEvent(webhook *Webhook, webhookEvent *WebhookEvent) error {
+	var record casvisorsdk.Record
+	err := json.Unmarshal([]byte(webhookEvent.Record), &record)
+	if err != nil {
+		return err
+	}
+
+	var user *User
+	if webhook.IsUserExtended {
+		user, err = getUser(record.Organization, record.User)
+		if err != nil {
+			return err
+		}
+
+		user, err = GetMaskedUser(user, false, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	return sendWebhook(webhook, &record, user)
+}
+
+// setWebhookEventResult records the result of the attempt, the failed event is retried with the backoff
+// until it runs out of the attempts
+func setWebhookEventResult(webhookEvent *WebhookEvent, err error, now time.Time) {
+	webhookEvent.Attempts += 1
+	if err == nil {
+		webhookEvent.State = WebhookEventStateDelivered
+		webhookEvent.DeliveredTime = util.GetCurrentTime()
+		webhookEvent.LastError = ""
+		return
+	}
+
+	webhookEvent.LastError = err.Error()
+	if webhookEvent.Attempts >= getWebhookMaxAttempts() {
+		webhookEvent.State = WebhookEventStateFailed
+	} else {
+		webhookEvent.NextTime = getWebhookEventTime(now.Add(getWebhookEventBackoff(webhookEvent.Attempts)))
+	}
+}
+
+func deliverWebhookEvent(webhookEvent *WebhookEvent, now time.Time) error {
+	webhook, err := GetWebhook(webhookEvent.Webhook)
+	if err != nil {
+		return err
+	}
+
+	if webhook == nil || !webhook.IsEnabled {
+		webhookEvent.State = WebhookEventStateFailed
+		webhookEvent.LastError = fmt.Sprintf("the webhook: %s does not exist or is disabled", webhookEvent.Webhook)
+	} else {
+		setWebhookEventResult(webhookEvent, sendWebhookEvent(webhook, webhookEvent), now)
+	}
+
+	_, err = ormer.Engine.ID(core.PK{webhookEvent.Owner, webhookEvent.Name}).Cols("state", "attempts", "next_time", "last_error", "delivered_time").Update(webhookEvent)
+	return err
+}
+
+func dispatchWebhookEvents(now time.Time) error {
+	webhookEvents := []*WebhookEvent{}
+	err := ormer.Engine.Where("state = ? and next_time <= ?", WebhookEventStatePending, getWebhookEventTime(now)).
+		Asc("next_time").Limit(webhookEventBatchSize).Find(&webhookEvents)
+	if err != nil {
+		return err
+	}
+
+	for _, webhookEvent := range webhookEvents {
+		claimed, err := claimWebhookEvent(webhookEvent, now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+
+		err = deliverWebhookEvent(webhookEvent, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteDeliveredWebhookEvents(now time.Time) error {
+	_, err := ormer.Engine.Where("state = ? and next_time < ?", WebhookEventStateDelivered, getWebhookEventTime(now.Add(-webhookEventKeepTime))).Delete(&WebhookEvent{})
+	return err
+}
+
+// RunWebhookDispatcherJob delivers the pending events of the outbox until the process exits
+func RunWebhookDispatcherJob() {
+	for {
+		now := time.Now()
+		err := dispatchWebhookEvents(now)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("webhook dispatch failed, error: %s", err.Error()))
+		}
+
+		err = deleteDeliveredWebhookEvents(now)
+		if err != nil {
+			logs.Warning(fmt.Sprintf("failed to delete the delivered webhook events, error: %s", err.Error()))
+		}
+
+		select {
+		case <-webhookDispatcherWakeup:
+		case <-time.After(time.Second):
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWebhookEventBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, getWebhookEventBackoff(1))
+	assert.Equal(t, 20*time.Second, getWebhookEventBackoff(2))
+	assert.Equal(t, 80*time.Second, getWebhookEventBackoff(4))
+	assert.Equal(t, time.Hour, getWebhookEventBackoff(20))
+}
+
+func TestGetWebhookEventTime(t *testing.T) {
+	now := time.Date(2023, 9, 1, 23, 0, 0, 0, time.FixedZone("UTC+8", 8*3600))
+	assert.Equal(t, "2023-09-01T15:00:00Z", getWebhookEventTime(now))
+	assert.True(t, getWebhookEventTime(now) < getWebhookEventTime(now.Add(time.Second)))
+}
+
+func TestSetWebhookEventResult(t *testing.T) {
+	now := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	webhookEvent := &WebhookEvent{State: WebhookEventStatePending, LastError: "timeout"}
+	setWebhookEventResult(webhookEvent, nil, now)
+	assert.Equal(t, WebhookEventStateDelivered, webhookEvent.State)
+	assert.Equal(t, 1, webhookEvent.Attempts)
+	assert.Equal(t, "", webhookEvent.LastError)
+
+	webhookEvent = &WebhookEvent{State: WebhookEventStatePending}
+	setWebhookEventResult(webhookEvent, fmt.Errorf("timeout"), now)
+	assert.Equal(t, WebhookEventStatePending, webhookEvent.State)
+	assert.Equal(t, "timeout", webhookEvent.LastError)
+	assert.Equal(t, "2023-09-01T00:00:10Z", webhookEvent.NextTime)
+
+	webhookEvent = &WebhookEvent{State: WebhookEventStatePending, Attempts: defaultWebhookMaxAttempts - 1}
+	setWebhookEventResult(webhookEvent, fmt.Errorf("timeout"), now)
+	assert.Equal(t, WebhookEventStateFailed, webhookEvent.State)
+}
@@ -15,16 +15,102 @@
 package object
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/casdoor/casdoor/util"
 	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
 )
 
-func sendWebhook(webhook *Webhook, record *casvisorsdk.Record, extendedUser *User) error {
-	client := &http.Client{}
+const (
+	webhookSignatureHeader = "X-Casdoor-Signature"
+	webhookEventHeader     = "X-Casdoor-Event"
+)
+
+var (
+	webhookPathRegex        = regexp.MustCompile(`^\$(\.[A-Za-z0-9_-]+|\[\d+\])*$`)
+	webhookPathSegmentRegex = regexp.MustCompile(`\.([A-Za-z0-9_-]+)|\[(\d+)\]`)
+	webhookPlaceholderRegex = regexp.MustCompile(`\{\{\s*(\$[^}\s]*)\s*\}\}`)
+)
+
+// getJsonPathValue returns the value of the data by a JSONPath like "$.extendedUser.emails[0]"
+func getJsonPathValue(data interface{}, path string) (interface{}, bool) {
+	if !webhookPathRegex.MatchString(path) {
+		return nil, false
+	}
+
+	current := data
+	for _, match := range webhookPathSegmentRegex.FindAllStringSubmatch(path, -1) {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[match[1]]
+			if match[1] == "" || !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(match[2])
+			if match[2] == "" || err != nil || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// renderWebhookTemplate replaces the strings of the template being a JSONPath with the values of the data, and
+// the "{{$.path}}" placeholders in the other strings with the values as text
+func renderWebhookTemplate(template interface{}, data interface{}) interface{} {
+	switch node := template.(type) {
+	case map[string]interface{}:
+		res := map[string]interface{}{}
+		for key, value := range node {
+			res[key] = renderWebhookTemplate(value, data)
+		}
+		return res
+	case []interface{}:
+		res := []interface{}{}
+		for _, value := range node {
+			res = append(res, renderWebhookTemplate(value, data))
+		}
+		return res
+	case string:
+		if webhookPathRegex.MatchString(node) {
+			value, _ := getJsonPathValue(data, node)
+			return value
+		}
+
+		return webhookPlaceholderRegex.ReplaceAllStringFunc(node, func(placeholder string) string {
+			path := webhookPlaceholderRegex.FindStringSubmatch(placeholder)[1]
+			value, ok := getJsonPathValue(data, path)
+			if !ok || value == nil {
+				return ""
+			}
+			if text, ok := value.(string); ok {
+				return text
+			}
+			return util.StructToJson(value)
+		})
+	default:
+		return node
+	}
+}
 
+// getWebhookPayload returns the record extended with the user, shaped by the payload template of the webhook if any
+func getWebhookPayload(webhook *Webhook, record *casvisorsdk.Record, extendedUser *User) ([]byte, error) {
 	type RecordEx struct {
 		casvisorsdk.Record
 		ExtendedUser *User `xorm:"-" json:"extendedUser"`
@@ -34,19 +120,99 @@ func sendWebhook(webhook *Webhook, record *casvisorsdk.Record, extendedUser *Use
 		ExtendedUser: extendedUser,
 	}
 
-	body := strings.NewReader(util.StructToJson(recordEx))
+	if webhook.PayloadTemplate == "" {
+		return []byte(util.StructToJson(recordEx)), nil
+	}
+
+	var template interface{}
+	err := json.Unmarshal([]byte(webhook.PayloadTemplate), &template)
+	if err != nil {
+		return nil, err
+	}
+
+	var data map[string]interface{}
+	err = json.Unmarshal([]byte(util.StructToJson(recordEx)), &data)
+	if err != nil {
+		return nil, err
+	}
+
+	// the object is the request body, which can be accessed by the template as JSON
+	var object interface{}
+	if json.Unmarshal([]byte(record.Object), &object) == nil {
+		data["object"] = object
+	}
+
+	return json.Marshal(renderWebhookTemplate(template, data))
+}
+
+// getWebhookSignature signs the timestamp and payload with HMAC-SHA256 like "t=1686000000,v1=5257a8...", with a
+// signature for each secret during the secret rotation
+func getWebhookSignature(secrets []string, timestamp int64, payload []byte) string {
+	res := []string{fmt.Sprintf("t=%d", timestamp)}
+	for _, secret := range secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(fmt.Sprintf("%d.", timestamp)))
+		mac.Write(payload)
+		res = append(res, fmt.Sprintf("v1=%s", hex.EncodeToString(mac.Sum(nil))))
+	}
+	return strings.Join(res, ",")
+}
+
+// getRecordApplication returns the application of the record, from the request of the login and signup or the
+// object of the application APIs, empty if unknown
+func getRecordApplication(record *casvisorsdk.Record) string {
+	var object map[string]interface{}
+	if json.Unmarshal([]byte(record.Object), &object) == nil {
+		key := "application"
+		if strings.HasSuffix(record.Action, "-application") {
+			key = "name"
+		}
+		if application, ok := object[key].(string); ok && application != "" {
+			return application
+		}
+	}
+
+	u, err := url.Parse(record.RequestUri)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("application")
+}
+
+func sendWebhook(webhook *Webhook, record *casvisorsdk.Record, extendedUser *User) error {
+	client := &http.Client{}
+
+	payload, err := getWebhookPayload(webhook, record, extendedUser)
+	if err != nil {
+		return err
+	}
 
-	req, err := http.NewRequest(webhook.Method, webhook.Url, body)
+	req, err := http.NewRequest(webhook.Method, webhook.Url, bytes.NewReader(payload))
 	if err != nil {
 		return err
 	}
 
 	req.Header.Set("Content-Type", webhook.ContentType)
+	req.Header.Set(webhookEventHeader, record.Action)
 
 	for _, header := range webhook.Headers {
 		req.Header.Set(header.Name, header.Value)
 	}
 
-	_, err = client.Do(req)
-	return err
+	now := time.Now()
+	if secrets := webhook.getSigningSecrets(now); len(secrets) != 0 {
+		req.Header.Set(webhookSignatureHeader, getWebhookSignature(secrets, now.Unix(), payload))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// the event is delivered again if the receiver fails to handle it
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the webhook: %s responded with the status: %s", webhook.GetId(), resp.Status)
+	}
+	return nil
 }
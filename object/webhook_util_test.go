@@ -0,0 +1,99 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/casvisor/casvisor-go-sdk/casvisorsdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetWebhookPayload(t *testing.T) {
+	record := &casvisorsdk.Record{
+		Organization: "org-1",
+		User:         "alice",
+		Action:       "login",
+		Object:       `{"application":"app-1","username":"alice"}`,
+	}
+	webhook := &Webhook{PayloadTemplate: `{
+		"event": "$.action",
+		"text": "{{$.user}} signed in to {{ $.object.application }} as {{$.extendedUser.groups[0]}}",
+		"user": {"name": "$.user", "group": "$.extendedUser.groups[0]", "missing": "$.extendedUser.phone.number"},
+		"static": ["$5", 1, true]
+	}`}
+
+	payload, err := getWebhookPayload(webhook, record, &User{Name: "alice", Groups: []string{"org-1/admins"}})
+	assert.Nil(t, err)
+
+	var res map[string]interface{}
+	assert.Nil(t, json.Unmarshal(payload, &res))
+	assert.Equal(t, map[string]interface{}{
+		"event":  "login",
+		"text":   "alice signed in to app-1 as org-1/admins",
+		"user":   map[string]interface{}{"name": "alice", "group": "org-1/admins", "missing": nil},
+		"static": []interface{}{"$5", float64(1), true},
+	}, res)
+
+	// the record is sent as is without the template
+	payload, err = getWebhookPayload(&Webhook{}, record, nil)
+	assert.Nil(t, err)
+	assert.Contains(t, string(payload), `"action":"login"`)
+
+	assert.NotNil(t, checkWebhookPayloadTemplate(`{"event": `))
+}
+
+func TestGetWebhookSignature(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	webhook := &Webhook{
+		SigningSecret:         "whsec_new",
+		PreviousSigningSecret: "whsec_old",
+		SecretRotatedTime:     now.Add(-time.Hour).Format(time.RFC3339),
+	}
+	assert.Equal(t, []string{"whsec_new", "whsec_old"}, webhook.getSigningSecrets(now))
+	assert.Equal(t, []string{"whsec_new"}, webhook.getSigningSecrets(now.Add(webhookSecretRotationPeriod)))
+	assert.Equal(t, []string{}, (&Webhook{}).getSigningSecrets(now))
+
+	mac := hmac.New(sha256.New, []byte("whsec_new"))
+	mac.Write([]byte("1685577600.{}"))
+	assert.Equal(t, "t=1685577600,v1="+hex.EncodeToString(mac.Sum(nil)), getWebhookSignature([]string{"whsec_new"}, now.Unix(), []byte("{}")))
+}
+
+func TestGetFilteredWebhooks(t *testing.T) {
+	webhooks := []*Webhook{
+		{Name: "all", Events: []string{"login"}, IsEnabled: true},
+		{Name: "app-1", Events: []string{"login", "update-application"}, Applications: []string{"app-1"}, IsEnabled: true},
+		{Name: "disabled", Events: []string{"login"}},
+	}
+
+	getNames := func(webhooks []*Webhook) []string {
+		res := []string{}
+		for _, webhook := range webhooks {
+			res = append(res, webhook.Name)
+		}
+		return res
+	}
+
+	assert.Equal(t, []string{"all", "app-1"}, getNames(getFilteredWebhooks(webhooks, &casvisorsdk.Record{Action: "login", Object: `{"application":"app-1"}`})))
+	assert.Equal(t, []string{"all"}, getNames(getFilteredWebhooks(webhooks, &casvisorsdk.Record{Action: "login", Object: `{"application":"app-2"}`})))
+	assert.Equal(t, []string{"all"}, getNames(getFilteredWebhooks(webhooks, &casvisorsdk.Record{Action: "login"})))
+	assert.Equal(t, []string{"app-1"}, getNames(getFilteredWebhooks(webhooks, &casvisorsdk.Record{Action: "update-application", RequestUri: "/api/update-application?id=admin/app-1", Object: `{"owner":"admin","name":"app-1"}`})))
+	assert.Equal(t, []string{"all", "app-1"}, getNames(getFilteredWebhooks(webhooks, &casvisorsdk.Record{Action: "login", RequestUri: "/api/login?application=app-1"})))
+}
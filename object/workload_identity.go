@@ -0,0 +1,440 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/casdoor/casdoor/i18n"
+	"github.com/casdoor/casdoor/util"
+	"github.com/lestrrat-go/jwx/jwk"
+	"github.com/lestrrat-go/jwx/jwt"
+	"github.com/xorm-io/core"
+)
+
+const (
+	workloadTokenPrefix = "wlt_"
+
+	defaultWorkloadTokenExpireInMinutes = 15
+	maxWorkloadTokenExpireInMinutes     = 60
+)
+
+// the fixed prefix of a subject before its first wildcard should name the owner of the workloads, like "repo:org/" of
+// GitHub Actions or "project_path:group/" of GitLab CI, so a subject like "*" or "repo:*" can't trust the jobs of
+// everyone on the CI platform
+var workloadSubjectPrefixRegex = regexp.MustCompile(`^[^*:/]+[:/][^*:/]+[:/]`)
+
+// WorkloadClaimRule requires the claim of the OIDC token of the workload to match the value, "*" matches any characters
+type WorkloadClaimRule struct {
+	Claim string `json:"claim"`
+	Value string `json:"value"`
+}
+
+// WorkloadIdentity trusts the OIDC tokens that a CI platform such as GitHub Actions or GitLab CI issues to its jobs.
+// The jobs exchange them for short-lived management tokens acting as the user of the workload identity and limited
+// to its APIs, so the pipelines need no long-lived secrets.
+type WorkloadIdentity struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+	UpdatedTime string `xorm:"varchar(100)" json:"updatedTime"`
+
+	DisplayName string `xorm:"varchar(100)" json:"displayName"`
+	Description string `xorm:"varchar(1000)" json:"description"`
+	IsEnabled   bool   `json:"isEnabled"`
+
+	Issuer string `xorm:"varchar(200)" json:"issuer"`
+	// JwksUrl is discovered from the OpenID configuration of the issuer if it's empty
+	JwksUrl    string               `xorm:"varchar(200)" json:"jwksUrl"`
+	Audience   string               `xorm:"varchar(200)" json:"audience"`
+	Subject    string               `xorm:"varchar(500)" json:"subject"`
+	ClaimRules []*WorkloadClaimRule `xorm:"mediumtext" json:"claimRules"`
+
+	User            string   `xorm:"varchar(100)" json:"user"`
+	Apis            []string `xorm:"mediumtext" json:"apis"`
+	ExpireInMinutes int      `json:"expireInMinutes"`
+}
+
+// WorkloadToken is a management token issued for the OIDC token of a workload, only the hash of the token is stored
+type WorkloadToken struct {
+	Owner       string `xorm:"varchar(100) notnull pk" json:"owner"`
+	Name        string `xorm:"varchar(100) notnull pk" json:"name"`
+	CreatedTime string `xorm:"varchar(100)" json:"createdTime"`
+
+	WorkloadIdentity string   `xorm:"varchar(100) index" json:"workloadIdentity"`
+	User             string   `xorm:"varchar(100)" json:"user"`
+	Subject          string   `xorm:"varchar(500)" json:"subject"`
+	Apis             []string `xorm:"mediumtext" json:"apis"`
+	ClientIp         string   `xorm:"varchar(100)" json:"clientIp"`
+	ExpireTime       string   `xorm:"varchar(100)" json:"expireTime"`
+	TokenHash        string   `xorm:"varchar(100) index" json:"-"`
+}
+
+func GetWorkloadIdentityCount(owner, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&WorkloadIdentity{})
+}
+
+func GetWorkloadIdentities(owner string) ([]*WorkloadIdentity, error) {
+	workloadIdentities := []*WorkloadIdentity{}
+	err := ormer.Engine.Desc("created_time").Find(&workloadIdentities, &WorkloadIdentity{Owner: owner})
+	if err != nil {
+		return workloadIdentities, err
+	}
+
+	return workloadIdentities, nil
+}
+
+func GetPaginationWorkloadIdentities(owner string, offset, limit int, field, value, sortField, sortOrder string) ([]*WorkloadIdentity, error) {
+	workloadIdentities := []*WorkloadIdentity{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&workloadIdentities)
+	if err != nil {
+		return workloadIdentities, err
+	}
+
+	return workloadIdentities, nil
+}
+
+func getWorkloadIdentity(owner string, name string) (*WorkloadIdentity, error) {
+	if owner == "" || name == "" {
+		return nil, nil
+	}
+
+	workloadIdentity := WorkloadIdentity{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&workloadIdentity)
+	if err != nil {
+		return &workloadIdentity, err
+	}
+
+	if existed {
+		return &workloadIdentity, nil
+	}
+
+	return nil, nil
+}
+
+func GetWorkloadIdentity(id string) (*WorkloadIdentity, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	return getWorkloadIdentity(owner, name)
+}
+
+func checkWorkloadIdentity(workloadIdentity *WorkloadIdentity, lang string) error {
+	if !strings.HasPrefix(workloadIdentity.Issuer, "https://") {
+		return fmt.Errorf(i18n.Translate(lang, "service:The issuer of the workload identity should be an HTTPS URL"))
+	}
+	if workloadIdentity.Audience == "" || workloadIdentity.Subject == "" {
+		return fmt.Errorf(i18n.Translate(lang, "service:The audience and the subject of the workload identity should not be empty"))
+	}
+	if !isWorkloadSubjectScoped(workloadIdentity.Subject) {
+		return fmt.Errorf(i18n.Translate(lang, "service:The subject of the workload identity should start with a fixed prefix like repo:org/ before any wildcard"))
+	}
+	if len(workloadIdentity.Apis) == 0 {
+		return fmt.Errorf(i18n.Translate(lang, "service:The workload identity should allow at least one API"))
+	}
+	if workloadIdentity.ExpireInMinutes < 0 || workloadIdentity.ExpireInMinutes > maxWorkloadTokenExpireInMinutes {
+		return fmt.Errorf(i18n.Translate(lang, "service:The expire minutes of the workload identity should be between 0 and %d"), maxWorkloadTokenExpireInMinutes)
+	}
+
+	for _, rule := range workloadIdentity.ClaimRules {
+		if rule.Claim == "" {
+			return fmt.Errorf(i18n.Translate(lang, "service:The claim of the claim rule should not be empty"))
+		}
+	}
+
+	user, err := getUser(workloadIdentity.Owner, workloadIdentity.User)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return fmt.Errorf(i18n.Translate(lang, "general:The user: %s doesn't exist"), util.GetId(workloadIdentity.Owner, workloadIdentity.User))
+	}
+
+	return nil
+}
+
+func UpdateWorkloadIdentity(id string, workloadIdentity *WorkloadIdentity, lang string) (bool, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	if w, err := getWorkloadIdentity(owner, name); err != nil {
+		return false, err
+	} else if w == nil {
+		return false, nil
+	}
+
+	err := checkWorkloadIdentity(workloadIdentity, lang)
+	if err != nil {
+		return false, err
+	}
+
+	workloadIdentity.UpdatedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.ID(core.PK{owner, name}).AllCols().Update(workloadIdentity)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func AddWorkloadIdentity(workloadIdentity *WorkloadIdentity, lang string) (bool, error) {
+	err := checkWorkloadIdentity(workloadIdentity, lang)
+	if err != nil {
+		return false, err
+	}
+
+	workloadIdentity.UpdatedTime = util.GetCurrentTime()
+	affected, err := ormer.Engine.Insert(workloadIdentity)
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+// DeleteWorkloadIdentity deletes the workload identity with its tokens, which stop working at once
+func DeleteWorkloadIdentity(workloadIdentity *WorkloadIdentity) (bool, error) {
+	affected, err := ormer.Engine.ID(core.PK{workloadIdentity.Owner, workloadIdentity.Name}).Delete(&WorkloadIdentity{})
+	if err != nil {
+		return false, err
+	}
+
+	_, err = ormer.Engine.Where("owner = ? and workload_identity = ?", workloadIdentity.Owner, workloadIdentity.Name).Delete(&WorkloadToken{})
+	if err != nil {
+		return false, err
+	}
+
+	return affected != 0, nil
+}
+
+func (workloadIdentity *WorkloadIdentity) GetId() string {
+	return fmt.Sprintf("%s/%s", workloadIdentity.Owner, workloadIdentity.Name)
+}
+
+func GetWorkloadTokenCount(owner, workloadIdentity, field, value string) (int64, error) {
+	session := GetSession(owner, -1, -1, field, value, "", "")
+	return session.Count(&WorkloadToken{WorkloadIdentity: workloadIdentity})
+}
+
+func GetPaginationWorkloadTokens(owner, workloadIdentity string, offset, limit int, field, value, sortField, sortOrder string) ([]*WorkloadToken, error) {
+	workloadTokens := []*WorkloadToken{}
+	session := GetSession(owner, offset, limit, field, value, sortField, sortOrder)
+	err := session.Find(&workloadTokens, &WorkloadToken{WorkloadIdentity: workloadIdentity})
+	if err != nil {
+		return workloadTokens, err
+	}
+
+	return workloadTokens, nil
+}
+
+func GetWorkloadToken(id string) (*WorkloadToken, error) {
+	owner, name := util.GetOwnerAndNameFromId(id)
+	workloadToken := WorkloadToken{Owner: owner, Name: name}
+	existed, err := ormer.Engine.Get(&workloadToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &workloadToken, nil
+	}
+	return nil, nil
+}
+
+// IsWorkloadAccessToken tells whether the access token is a workload token rather than an OAuth access token
+func IsWorkloadAccessToken(accessToken string) bool {
+	return strings.HasPrefix(accessToken, workloadTokenPrefix)
+}
+
+func GetWorkloadTokenByAccessToken(accessToken string) (*WorkloadToken, error) {
+	workloadToken := WorkloadToken{}
+	existed, err := ormer.Engine.Where("token_hash = ?", getTokenHash(accessToken)).Get(&workloadToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if existed {
+		return &workloadToken, nil
+	}
+	return nil, nil
+}
+
+func (workloadToken *WorkloadToken) GetId() string {
+	return fmt.Sprintf("%s/%s", workloadToken.Owner, workloadToken.Name)
+}
+
+func (workloadToken *WorkloadToken) IsExpired() bool {
+	expireTime, err := time.Parse(time.RFC3339, workloadToken.ExpireTime)
+	if err != nil {
+		return true
+	}
+	return !time.Now().Before(expireTime)
+}
+
+// matchWorkloadPattern matches the value against the pattern, in which "*" matches any characters
+func matchWorkloadPattern(pattern string, value string) bool {
+	expr := "^" + strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1) + "$"
+	matched, err := regexp.MatchString(expr, value)
+	return err == nil && matched
+}
+
+// isWorkloadSubjectScoped tells whether the subject pattern is limited to the workloads of one owner
+func isWorkloadSubjectScoped(subject string) bool {
+	index := strings.Index(subject, "*")
+	if index == -1 {
+		return subject != ""
+	}
+	return workloadSubjectPrefixRegex.MatchString(subject[:index])
+}
+
+// IsWorkloadApiAllowed tells whether the URL path is one of the APIs, an API ending with "*" matches the paths of its prefix
+func IsWorkloadApiAllowed(apis []string, urlPath string) bool {
+	for _, api := range apis {
+		if api == urlPath || (strings.HasSuffix(api, "*") && strings.HasPrefix(urlPath, strings.TrimSuffix(api, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWorkloadClaims checks the subject and the claim rules of the workload identity against the verified claims
+func checkWorkloadClaims(workloadIdentity *WorkloadIdentity, claims map[string]interface{}) error {
+	// the workload identities saved before the subjects were checked can still have a wildcard-only subject
+	subject, _ := claims["sub"].(string)
+	if !isWorkloadSubjectScoped(workloadIdentity.Subject) || !matchWorkloadPattern(workloadIdentity.Subject, subject) {
+		return fmt.Errorf("the subject: %s doesn't match the workload identity", subject)
+	}
+
+	for _, rule := range workloadIdentity.ClaimRules {
+		value, ok := claims[rule.Claim]
+		if !ok || !matchWorkloadPattern(rule.Value, fmt.Sprint(value)) {
+			return fmt.Errorf("the claim: %s doesn't match the workload identity", rule.Claim)
+		}
+	}
+
+	return nil
+}
+
+func getWorkloadJwksUrl(workloadIdentity *WorkloadIdentity) (string, error) {
+	if workloadIdentity.JwksUrl != "" {
+		return workloadIdentity.JwksUrl, nil
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimSuffix(workloadIdentity.Issuer, "/") + "/.well-known/openid-configuration")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get the OpenID configuration of the issuer: %s, status: %s", workloadIdentity.Issuer, resp.Status)
+	}
+
+	var configuration struct {
+		JwksUri string `json:"jwks_uri"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&configuration)
+	if err != nil {
+		return "", err
+	}
+	if configuration.JwksUri == "" {
+		return "", fmt.Errorf("the OpenID configuration of the issuer: %s has no jwks_uri", workloadIdentity.Issuer)
+	}
+
+	return configuration.JwksUri, nil
+}
+
+// verifyWorkloadOidcToken verifies the signature, issuer, audience and lifetime of the OIDC token and returns its claims
+func verifyWorkloadOidcToken(workloadIdentity *WorkloadIdentity, oidcToken string) (map[string]interface{}, error) {
+	jwksUrl, err := getWorkloadJwksUrl(workloadIdentity)
+	if err != nil {
+		return nil, err
+	}
+
+	keySet, err := jwk.Fetch(context.Background(), jwksUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse([]byte(oidcToken), jwt.WithKeySet(keySet), jwt.WithValidate(true), jwt.WithIssuer(workloadIdentity.Issuer), jwt.WithAudience(workloadIdentity.Audience))
+	if err != nil {
+		return nil, err
+	}
+
+	return token.AsMap(context.Background())
+}
+
+// ExchangeWorkloadToken issues a management token for the OIDC token of the workload trusted by the workload identity,
+// the token acts as the user of the workload identity on its APIs only and expires in its minutes
+func ExchangeWorkloadToken(id string, oidcToken string, clientIp string, lang string) (*TokenWrapper, error) {
+	workloadIdentity, err := GetWorkloadIdentity(id)
+	if err != nil {
+		return nil, err
+	}
+	if workloadIdentity == nil || !workloadIdentity.IsEnabled {
+		return nil, fmt.Errorf(i18n.Translate(lang, "service:The workload identity: %s does not exist or is disabled"), id)
+	}
+
+	claims, err := verifyWorkloadOidcToken(workloadIdentity, oidcToken)
+	if err == nil {
+		err = checkWorkloadClaims(workloadIdentity, claims)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(i18n.Translate(lang, "service:The OIDC token is rejected: %s"), err.Error())
+	}
+
+	user, err := getUser(workloadIdentity.Owner, workloadIdentity.User)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.IsForbidden || user.IsSuspensionActive() {
+		return nil, fmt.Errorf(i18n.Translate(lang, "service:The user of the workload identity: %s is unavailable"), id)
+	}
+
+	expireInMinutes := workloadIdentity.ExpireInMinutes
+	if expireInMinutes == 0 {
+		expireInMinutes = defaultWorkloadTokenExpireInMinutes
+	}
+
+	subject, _ := claims["sub"].(string)
+	accessToken := workloadTokenPrefix + util.GenerateClientSecret() + util.GenerateClientSecret()
+	workloadToken := &WorkloadToken{
+		Owner:            workloadIdentity.Owner,
+		Name:             util.GenerateId(),
+		CreatedTime:      util.GetCurrentTime(),
+		WorkloadIdentity: workloadIdentity.Name,
+		User:             workloadIdentity.User,
+		Subject:          subject,
+		Apis:             workloadIdentity.Apis,
+		ClientIp:         clientIp,
+		ExpireTime:       time.Now().Add(time.Duration(expireInMinutes) * time.Minute).Format(time.RFC3339),
+		TokenHash:        getTokenHash(accessToken),
+	}
+	_, err = ormer.Engine.Insert(workloadToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenWrapper{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   expireInMinutes * 60,
+	}, nil
+}
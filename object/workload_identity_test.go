@@ -0,0 +1,86 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchWorkloadPattern(t *testing.T) {
+	assert.True(t, matchWorkloadPattern("repo:casdoor/casdoor:ref:refs/heads/master", "repo:casdoor/casdoor:ref:refs/heads/master"))
+	assert.True(t, matchWorkloadPattern("repo:casdoor/casdoor:*", "repo:casdoor/casdoor:environment:production"))
+	assert.False(t, matchWorkloadPattern("repo:casdoor/casdoor:*", "repo:casdoor/casdoor-evil:ref:refs/heads/master"))
+	assert.False(t, matchWorkloadPattern("project_path:group/project", "project_path:group/project2"))
+	assert.False(t, matchWorkloadPattern("repo:a.b", "repo:axb"))
+}
+
+func TestIsWorkloadSubjectScoped(t *testing.T) {
+	assert.True(t, isWorkloadSubjectScoped("repo:casdoor/casdoor:ref:refs/heads/master"))
+	assert.True(t, isWorkloadSubjectScoped("repo:casdoor/*"))
+	assert.True(t, isWorkloadSubjectScoped("repo:casdoor/casdoor:*"))
+	assert.True(t, isWorkloadSubjectScoped("project_path:group/*"))
+	assert.False(t, isWorkloadSubjectScoped(""))
+	assert.False(t, isWorkloadSubjectScoped("*"))
+	assert.False(t, isWorkloadSubjectScoped("repo:*"))
+	assert.False(t, isWorkloadSubjectScoped("repo:casdoor*"))
+	assert.False(t, isWorkloadSubjectScoped("repo:*/casdoor:*"))
+	assert.False(t, isWorkloadSubjectScoped("*:casdoor/*"))
+}
+
+func TestIsWorkloadApiAllowed(t *testing.T) {
+	apis := []string{"/api/update-application", "/api/*-policy"}
+	assert.True(t, IsWorkloadApiAllowed(apis, "/api/update-application"))
+	assert.False(t, IsWorkloadApiAllowed(apis, "/api/update-application2"))
+	assert.False(t, IsWorkloadApiAllowed(apis, "/api/add-policy"))
+	assert.True(t, IsWorkloadApiAllowed([]string{"/api/get-*"}, "/api/get-policies"))
+	assert.False(t, IsWorkloadApiAllowed(nil, "/api/get-policies"))
+}
+
+func TestCheckWorkloadClaims(t *testing.T) {
+	workloadIdentity := &WorkloadIdentity{
+		Subject: "repo:casdoor/casdoor:*",
+		ClaimRules: []*WorkloadClaimRule{
+			{Claim: "ref", Value: "refs/heads/master"},
+			{Claim: "run_attempt", Value: "*"},
+		},
+	}
+
+	claims := map[string]interface{}{"sub": "repo:casdoor/casdoor:ref:refs/heads/master", "ref": "refs/heads/master", "run_attempt": 1}
+	assert.Nil(t, checkWorkloadClaims(workloadIdentity, claims))
+
+	claims["ref"] = "refs/heads/feature"
+	assert.NotNil(t, checkWorkloadClaims(workloadIdentity, claims))
+
+	delete(claims, "run_attempt")
+	claims["ref"] = "refs/heads/master"
+	assert.NotNil(t, checkWorkloadClaims(workloadIdentity, claims))
+
+	assert.NotNil(t, checkWorkloadClaims(workloadIdentity, map[string]interface{}{"sub": "repo:other/repo:ref:refs/heads/master"}))
+
+	// a wildcard-only subject saved before the check trusts nothing
+	workloadIdentity = &WorkloadIdentity{Subject: "*"}
+	assert.NotNil(t, checkWorkloadClaims(workloadIdentity, map[string]interface{}{"sub": "repo:other/repo:ref:refs/heads/master"}))
+}
+
+func TestWorkloadTokenIsExpired(t *testing.T) {
+	assert.False(t, (&WorkloadToken{ExpireTime: time.Now().Add(time.Minute).Format(time.RFC3339)}).IsExpired())
+	assert.True(t, (&WorkloadToken{ExpireTime: time.Now().Add(-time.Minute).Format(time.RFC3339)}).IsExpired())
+	assert.True(t, (&WorkloadToken{}).IsExpired())
+	assert.True(t, IsWorkloadAccessToken("wlt_abc"))
+	assert.False(t, IsWorkloadAccessToken("eyJhbGciOi"))
+}
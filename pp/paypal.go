@@ -15,9 +15,13 @@
 package pp
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"strconv"
 
 	"github.com/casdoor/casdoor/conf"
@@ -173,3 +177,155 @@ func (pp *PaypalPaymentProvider) GetResponseError(err error) string {
 		return "fail"
 	}
 }
+
+type paypalAmount struct {
+	CurrencyCode string `json:"currency_code"`
+	Value        string `json:"value"`
+}
+
+type paypalWebhookEvent struct {
+	Id        string `json:"id"`
+	EventType string `json:"event_type"`
+	Resource  struct {
+		Id                 string `json:"id"`
+		BillingAgreementId string `json:"billing_agreement_id"`
+		CustomId           string `json:"custom_id"`
+		Custom             string `json:"custom"`
+		StatusChangeNote   string `json:"status_change_note"`
+		Amount             struct {
+			Total    string `json:"total"`
+			Currency string `json:"currency"`
+		} `json:"amount"`
+		BillingInfo struct {
+			OutstandingBalance paypalAmount `json:"outstanding_balance"`
+		} `json:"billing_info"`
+	} `json:"resource"`
+}
+
+func (pp *PaypalPaymentProvider) doPost(path string, data interface{}, result interface{}) error {
+	url := "https://api-m.sandbox.paypal.com" + path
+	if pp.Client.IsProd {
+		url = "https://api-m.paypal.com" + path
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+pp.Client.AccessToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("paypal: %s, %s", resp.Status, string(respBody))
+	}
+
+	if result == nil || len(respBody) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, result)
+}
+
+// ParseSubscriptionEvent verifies the webhook by PayPal with the secret as the webhook id
+func (pp *PaypalPaymentProvider) ParseSubscriptionEvent(header http.Header, body []byte, secret string) (*SubscriptionEvent, error) {
+	verification := map[string]interface{}{
+		"auth_algo":         header.Get("PAYPAL-AUTH-ALGO"),
+		"cert_url":          header.Get("PAYPAL-CERT-URL"),
+		"transmission_id":   header.Get("PAYPAL-TRANSMISSION-ID"),
+		"transmission_sig":  header.Get("PAYPAL-TRANSMISSION-SIG"),
+		"transmission_time": header.Get("PAYPAL-TRANSMISSION-TIME"),
+		"webhook_id":        secret,
+		"webhook_event":     json.RawMessage(body),
+	}
+	var verificationResult struct {
+		VerificationStatus string `json:"verification_status"`
+	}
+	err := pp.doPost("/v1/notifications/verify-webhook-signature", verification, &verificationResult)
+	if err != nil {
+		return nil, err
+	}
+	if verificationResult.VerificationStatus != "SUCCESS" {
+		return nil, fmt.Errorf("the signature of the paypal webhook is invalid")
+	}
+
+	return parsePaypalSubscriptionEvent(body)
+}
+
+func parsePaypalSubscriptionEvent(body []byte) (*SubscriptionEvent, error) {
+	var event paypalWebhookEvent
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return nil, err
+	}
+
+	resource := event.Resource
+	switch event.EventType {
+	case "PAYMENT.SALE.COMPLETED":
+		// the sales not of the subscriptions have no billing agreements
+		if resource.BillingAgreementId == "" {
+			return nil, nil
+		}
+
+		price, err := strconv.ParseFloat(resource.Amount.Total, 64)
+		if err != nil {
+			return nil, err
+		}
+		return &SubscriptionEvent{
+			Id:             event.Id,
+			Type:           SubscriptionEventRenewed,
+			SubscriptionId: resource.BillingAgreementId,
+			Reference:      resource.Custom,
+			Price:          price,
+			Currency:       resource.Amount.Currency,
+		}, nil
+	case "BILLING.SUBSCRIPTION.PAYMENT.FAILED":
+		res := &SubscriptionEvent{
+			Id:             event.Id,
+			Type:           SubscriptionEventPaymentFailed,
+			SubscriptionId: resource.Id,
+			Reference:      resource.CustomId,
+			Currency:       resource.BillingInfo.OutstandingBalance.CurrencyCode,
+			Message:        fmt.Sprintf("the payment of the paypal subscription: %s failed", resource.Id),
+		}
+		if value := resource.BillingInfo.OutstandingBalance.Value; value != "" {
+			res.Price, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case "BILLING.SUBSCRIPTION.CANCELLED":
+		return &SubscriptionEvent{
+			Id:             event.Id,
+			Type:           SubscriptionEventCanceled,
+			SubscriptionId: resource.Id,
+			Reference:      resource.CustomId,
+			Message:        resource.StatusChangeNote,
+		}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (pp *PaypalPaymentProvider) RetrySubscriptionPayment(subscriptionId string, invoiceId string, price float64, currency string) error {
+	capture := map[string]interface{}{
+		"note":         "Charging the outstanding balance",
+		"capture_type": "OUTSTANDING_BALANCE",
+		"amount":       paypalAmount{CurrencyCode: currency, Value: priceFloat64ToString(price)},
+	}
+	return pp.doPost(fmt.Sprintf("/v1/billing/subscriptions/%s/capture", subscriptionId), capture, nil)
+}
@@ -15,17 +15,25 @@
 package pp
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/casdoor/casdoor/conf"
 	"github.com/stripe/stripe-go/v74"
 	stripeCheckout "github.com/stripe/stripe-go/v74/checkout/session"
+	stripeInvoice "github.com/stripe/stripe-go/v74/invoice"
 	stripeIntent "github.com/stripe/stripe-go/v74/paymentintent"
 	stripePrice "github.com/stripe/stripe-go/v74/price"
 	stripeProduct "github.com/stripe/stripe-go/v74/product"
+	stripeUsageRecord "github.com/stripe/stripe-go/v74/usagerecord"
+	"github.com/stripe/stripe-go/v74/webhook"
 )
 
+// the metadata key of the Stripe subscriptions for the ids of the Casdoor subscriptions
+const stripeSubscriptionMetadataKey = "casdoor_subscription"
+
 type StripePaymentProvider struct {
 	PublishableKey string
 	SecretKey      string
@@ -170,3 +178,86 @@ func (pp *StripePaymentProvider) GetResponseError(err error) string {
 		return "fail"
 	}
 }
+
+func (pp *StripePaymentProvider) ParseSubscriptionEvent(header http.Header, body []byte, secret string) (*SubscriptionEvent, error) {
+	event, err := webhook.ConstructEventWithOptions(body, header.Get("Stripe-Signature"), secret, webhook.ConstructEventOptions{IgnoreAPIVersionMismatch: true})
+	if err != nil {
+		return nil, err
+	}
+
+	switch event.Type {
+	case "invoice.paid", "invoice.payment_failed":
+		var invoice stripe.Invoice
+		err = json.Unmarshal(event.Data.Raw, &invoice)
+		if err != nil {
+			return nil, err
+		}
+		// the one-off invoices are not of the subscriptions
+		if invoice.Subscription == nil {
+			return nil, nil
+		}
+
+		res := &SubscriptionEvent{
+			Id:             event.ID,
+			SubscriptionId: invoice.Subscription.ID,
+			InvoiceId:      invoice.ID,
+			Currency:       string(invoice.Currency),
+		}
+		if invoice.SubscriptionDetails != nil {
+			res.Reference = invoice.SubscriptionDetails.Metadata[stripeSubscriptionMetadataKey]
+		}
+
+		if event.Type == "invoice.paid" {
+			res.Type = SubscriptionEventRenewed
+			res.Price = priceInt64ToFloat64(invoice.AmountPaid)
+			if invoice.Lines != nil && len(invoice.Lines.Data) > 0 && invoice.Lines.Data[0].Period != nil {
+				res.PeriodEnd = time.Unix(invoice.Lines.Data[0].Period.End, 0)
+			}
+		} else {
+			res.Type = SubscriptionEventPaymentFailed
+			res.Price = priceInt64ToFloat64(invoice.AmountDue)
+			res.Message = fmt.Sprintf("the payment of the invoice: %s failed after %d attempts", invoice.ID, invoice.AttemptCount)
+		}
+		return res, nil
+	case "customer.subscription.deleted":
+		var subscription stripe.Subscription
+		err = json.Unmarshal(event.Data.Raw, &subscription)
+		if err != nil {
+			return nil, err
+		}
+
+		res := &SubscriptionEvent{
+			Id:             event.ID,
+			Type:           SubscriptionEventCanceled,
+			SubscriptionId: subscription.ID,
+			Reference:      subscription.Metadata[stripeSubscriptionMetadataKey],
+		}
+		if subscription.CancellationDetails != nil {
+			res.Message = string(subscription.CancellationDetails.Reason)
+		}
+		return res, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (pp *StripePaymentProvider) RetrySubscriptionPayment(subscriptionId string, invoiceId string, price float64, currency string) error {
+	if invoiceId == "" {
+		return fmt.Errorf("the failed invoice of the stripe subscription: %s is unknown", subscriptionId)
+	}
+
+	_, err := stripeInvoice.Pay(invoiceId, nil)
+	return err
+}
+
+func (pp *StripePaymentProvider) ReportUsage(subscriptionItem string, quantity int64, timestamp time.Time) error {
+	// the total is set instead of incremented, so reporting it again is harmless
+	params := &stripe.UsageRecordParams{
+		SubscriptionItem: stripe.String(subscriptionItem),
+		Action:           stripe.String(stripe.UsageRecordActionSet),
+		Quantity:         stripe.Int64(quantity),
+		Timestamp:        stripe.Int64(timestamp.Unix()),
+	}
+	_, err := stripeUsageRecord.New(params)
+	return err
+}
@@ -0,0 +1,59 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pp
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	SubscriptionEventRenewed       = "Renewed"
+	SubscriptionEventPaymentFailed = "PaymentFailed"
+	SubscriptionEventCanceled      = "Canceled"
+)
+
+// SubscriptionEvent is a renewal, failed payment or cancellation of a recurring subscription, reported by the
+// webhook of the payment provider
+type SubscriptionEvent struct {
+	Id   string
+	Type string
+
+	// SubscriptionId is the id of the subscription in the payment provider, Reference is the id ( owner/name ) of
+	// the Casdoor subscription set as its metadata "casdoor_subscription" (Stripe) or custom id (PayPal)
+	SubscriptionId string
+	Reference      string
+	InvoiceId      string
+
+	// PeriodEnd is the end of the renewed period, zero if the provider doesn't report it
+	PeriodEnd time.Time
+	Price     float64
+	Currency  string
+	Message   string
+}
+
+// SubscriptionProvider is implemented by the payment providers supporting the recurring subscriptions
+type SubscriptionProvider interface {
+	// ParseSubscriptionEvent verifies the webhook with the secret and returns the event, nil for the other events
+	ParseSubscriptionEvent(header http.Header, body []byte, secret string) (*SubscriptionEvent, error)
+	// RetrySubscriptionPayment charges the outstanding invoice or balance of the subscription again
+	RetrySubscriptionPayment(subscriptionId string, invoiceId string, price float64, currency string) error
+}
+
+// UsageReporter is implemented by the payment providers supporting the metered billing
+type UsageReporter interface {
+	// ReportUsage sets the total usage of the metered subscription item in the billing period of the timestamp
+	ReportUsage(subscriptionItem string, quantity int64, timestamp time.Time) error
+}
@@ -0,0 +1,73 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package radius
+
+import (
+	"sync"
+	"time"
+
+	"github.com/casdoor/casdoor/util"
+)
+
+const challengeTimeout = 2 * time.Minute
+
+// challenge is an Access-Challenge waiting for the OTP of the user, which is answered by the next
+// Access-Request carrying its State
+type challenge struct {
+	Organization string
+	Username     string
+	ExpireTime   time.Time
+}
+
+var (
+	challenges     = map[string]*challenge{}
+	challengesLock sync.Mutex
+)
+
+func addChallenge(organization string, username string, now time.Time) string {
+	challengesLock.Lock()
+	defer challengesLock.Unlock()
+
+	for state, c := range challenges {
+		if now.After(c.ExpireTime) {
+			delete(challenges, state)
+		}
+	}
+
+	state := util.GenerateId()
+	challenges[state] = &challenge{
+		Organization: organization,
+		Username:     username,
+		ExpireTime:   now.Add(challengeTimeout),
+	}
+	return state
+}
+
+// popChallenge returns the unexpired challenge of the state, each challenge is answered only once
+func popChallenge(state string, now time.Time) *challenge {
+	challengesLock.Lock()
+	defer challengesLock.Unlock()
+
+	c, ok := challenges[state]
+	if !ok {
+		return nil
+	}
+
+	delete(challenges, state)
+	if now.After(c.ExpireTime) {
+		return nil
+	}
+	return c
+}
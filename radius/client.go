@@ -0,0 +1,101 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package radius
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/object"
+	"layeh.com/radius"
+)
+
+const vendorSpecificType = 26
+
+// clientSecretSource returns the shared secret of the RADIUS client of the remote address,
+// the "radiusSecret" config is used for the clients not stored as objects
+type clientSecretSource struct{}
+
+func (clientSecretSource) RADIUSSecret(ctx context.Context, remoteAddr net.Addr) ([]byte, error) {
+	radiusClient, err := getRadiusClient(remoteAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if radiusClient != nil {
+		return []byte(radiusClient.Secret), nil
+	}
+	return []byte(conf.GetConfigString("radiusSecret")), nil
+}
+
+func getRemoteIp(remoteAddr net.Addr) net.IP {
+	switch addr := remoteAddr.(type) {
+	case *net.UDPAddr:
+		return addr.IP
+	case *net.TCPAddr:
+		return addr.IP
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+func getRadiusClient(remoteAddr net.Addr) (*object.RadiusClient, error) {
+	ip := getRemoteIp(remoteAddr)
+	if ip == nil {
+		return nil, nil
+	}
+	return object.GetRadiusClientByIp(ip)
+}
+
+// newAttribute encodes the value as the attribute of the mapping, wrapped in a Vendor-Specific attribute
+// if the mapping has the vendor ID
+func newAttribute(mapping *object.RadiusAttributeMapping, value string) (radius.Type, radius.Attribute, error) {
+	if mapping.VendorId == 0 {
+		attribute, err := radius.NewString(value)
+		return radius.Type(mapping.Type), attribute, err
+	}
+
+	if len(value) > 247 {
+		return 0, nil, fmt.Errorf("the value of the vendor-specific attribute: %d/%d is too long", mapping.VendorId, mapping.Type)
+	}
+
+	vsa := append([]byte{byte(mapping.Type), byte(len(value) + 2)}, value...)
+	attribute, err := radius.NewVendorSpecific(uint32(mapping.VendorId), vsa)
+	return vendorSpecificType, attribute, err
+}
+
+// addUserAttributes adds the attributes mapped from the user by the client to the Access-Accept
+func addUserAttributes(packet *radius.Packet, radiusClient *object.RadiusClient, user *object.User) error {
+	if radiusClient == nil {
+		return nil
+	}
+
+	for _, mapping := range radiusClient.AttributeMappings {
+		for _, value := range object.GetRadiusAttributeValues(mapping, user) {
+			typ, attribute, err := newAttribute(mapping, value)
+			if err != nil {
+				return err
+			}
+			packet.Add(typ, attribute)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package radius
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/casdoor/casdoor/object"
+	"github.com/stretchr/testify/assert"
+	"layeh.com/radius"
+)
+
+func TestNewAttribute(t *testing.T) {
+	typ, attribute, err := newAttribute(&object.RadiusAttributeMapping{Type: 25}, "admins")
+	assert.Nil(t, err)
+	assert.Equal(t, radius.Type(25), typ)
+	assert.Equal(t, radius.Attribute("admins"), attribute)
+
+	typ, attribute, err = newAttribute(&object.RadiusAttributeMapping{VendorId: 9, Type: 1}, "shell:priv-lvl=15")
+	assert.Nil(t, err)
+	assert.Equal(t, radius.Type(vendorSpecificType), typ)
+
+	vendorId, vsa, err := radius.VendorSpecific(attribute)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(9), vendorId)
+	assert.Equal(t, append([]byte{1, 19}, "shell:priv-lvl=15"...), []byte(vsa))
+}
+
+func TestChallenge(t *testing.T) {
+	now := time.Now()
+	state := addChallenge("org", "alice", now)
+
+	c := popChallenge(state, now.Add(time.Minute))
+	assert.NotNil(t, c)
+	assert.Equal(t, "alice", c.Username)
+	assert.Nil(t, popChallenge(state, now.Add(time.Minute)))
+
+	state = addChallenge("org", "alice", now)
+	assert.Nil(t, popChallenge(state, now.Add(challengeTimeout+time.Second)))
+}
+
+func TestGetRemoteIp(t *testing.T) {
+	assert.Equal(t, "10.0.0.1", getRemoteIp(&net.UDPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1812}).String())
+}
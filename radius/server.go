@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/casdoor/casdoor/conf"
 	"github.com/casdoor/casdoor/object"
@@ -28,11 +29,10 @@ import (
 )
 
 func StartRadiusServer() {
-	secret := conf.GetConfigString("radiusSecret")
 	server := radius.PacketServer{
 		Addr:         "0.0.0.0:" + conf.GetConfigString("radiusServerPort"),
 		Handler:      radius.HandlerFunc(handlerRadius),
-		SecretSource: radius.StaticSecretSource([]byte(secret)),
+		SecretSource: clientSecretSource{},
 	}
 	log.Printf("Starting Radius server on %s", server.Addr)
 	if err := server.ListenAndServe(); err != nil {
@@ -55,20 +55,91 @@ func handleAccessRequest(w radius.ResponseWriter, r *radius.Request) {
 	username := rfc2865.UserName_GetString(r.Packet)
 	password := rfc2865.UserPassword_GetString(r.Packet)
 	organization := rfc2865.Class_GetString(r.Packet)
-	log.Printf("handleAccessRequest() username=%v, org=%v, password=%v", username, organization, password)
+
+	radiusClient, err := getRadiusClient(r.RemoteAddr)
+	if err != nil {
+		log.Printf("handleAccessRequest() failed to get the RADIUS client, err = %v", err)
+		w.Write(r.Response(radius.CodeAccessReject))
+		return
+	}
+	if radiusClient != nil {
+		organization = radiusClient.Owner
+	}
+	log.Printf("handleAccessRequest() username=%v, org=%v", username, organization)
 
 	if organization == "" {
 		w.Write(r.Response(radius.CodeAccessReject))
 		return
 	}
 
-	_, err := object.CheckUserPassword(organization, username, password, "en")
+	var user *object.User
+	if state := rfc2865.State_GetString(r.Packet); state != "" {
+		user, err = checkChallengeResponse(state, organization, username, password)
+	} else {
+		user, err = object.CheckUserPassword(organization, username, password, "en")
+		if err == nil && object.IsRadiusMfaRequired(radiusClient, user) {
+			writeChallenge(w, r, user)
+			return
+		}
+	}
+	if err != nil {
+		log.Printf("handleAccessRequest() rejected, err = %v", err)
+		w.Write(r.Response(radius.CodeAccessReject))
+		return
+	}
+
+	response := r.Response(radius.CodeAccessAccept)
+	err = addUserAttributes(response, radiusClient, user)
 	if err != nil {
+		log.Printf("handleAccessRequest() failed to add the attributes, err = %v", err)
 		w.Write(r.Response(radius.CodeAccessReject))
 		return
 	}
+	w.Write(response)
+}
 
-	w.Write(r.Response(radius.CodeAccessAccept))
+// writeChallenge prompts the user for the TOTP code after the password is checked,
+// the users without TOTP set up are rejected
+func writeChallenge(w radius.ResponseWriter, r *radius.Request, user *object.User) {
+	if user.TotpSecret == "" {
+		log.Printf("handleAccessRequest() rejected, the user: %s has no TOTP MFA set up", user.GetId())
+		w.Write(r.Response(radius.CodeAccessReject))
+		return
+	}
+
+	response := r.Response(radius.CodeAccessChallenge)
+	state := addChallenge(user.Owner, user.Name, time.Now())
+	if err := rfc2865.State_SetString(response, state); err != nil {
+		w.Write(r.Response(radius.CodeAccessReject))
+		return
+	}
+	if err := rfc2865.ReplyMessage_SetString(response, "Please enter the passcode of your authenticator app"); err != nil {
+		w.Write(r.Response(radius.CodeAccessReject))
+		return
+	}
+	w.Write(response)
+}
+
+// checkChallengeResponse verifies the TOTP code answering the Access-Challenge of the state
+func checkChallengeResponse(state string, organization string, username string, passcode string) (*object.User, error) {
+	c := popChallenge(state, time.Now())
+	if c == nil || c.Organization != organization || !strings.EqualFold(c.Username, username) {
+		return nil, fmt.Errorf("the RADIUS state is invalid or expired")
+	}
+
+	user, err := object.GetUser(util.GetId(c.Organization, c.Username))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, fmt.Errorf("the user: %s does not exist", util.GetId(c.Organization, c.Username))
+	}
+
+	err = object.VerifyRadiusMfaCode(user, passcode)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
 func handleAccountingRequest(w radius.ResponseWriter, r *radius.Request) {
@@ -0,0 +1,63 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/beego/beego"
+	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/object"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// StartAcmeServer serves HTTPS for the custom domains of the organizations with ACME enabled, their certificates
+// are issued by the TLS-ALPN-01 challenge on the first request and renewed before they expire. The port should
+// be reachable as 443 from the internet.
+func StartAcmeServer() {
+	port := conf.GetConfigString("acmeHttpsPort")
+	if port == "" {
+		return
+	}
+
+	cacheDir := conf.GetConfigString("acmeCacheDir")
+	if cacheDir == "" {
+		cacheDir = "./acme"
+	}
+
+	manager := &autocert.Manager{
+		Prompt: autocert.AcceptTOS,
+		Cache:  autocert.DirCache(cacheDir),
+		Email:  conf.GetConfigString("acmeEmail"),
+		HostPolicy: func(ctx context.Context, host string) error {
+			return object.IsAcmeDomainAllowed(host)
+		},
+	}
+
+	server := &http.Server{
+		Addr:      fmt.Sprintf(":%s", port),
+		Handler:   beego.BeeApp.Handlers,
+		TLSConfig: manager.TLSConfig(),
+	}
+
+	logs.Info(fmt.Sprintf("ACME server is listening on port: %s", port))
+	err := server.ListenAndServeTLS("", "")
+	if err != nil {
+		logs.Error(fmt.Sprintf("ACME server failed, error: %s", err.Error()))
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/beego/beego/context"
 	"github.com/casdoor/casdoor/authz"
+	"github.com/casdoor/casdoor/object"
 	"github.com/casdoor/casdoor/util"
 )
 
@@ -86,7 +87,12 @@ func getObject(ctx *context.Context) (string, string) {
 
 		return "", ""
 	} else {
-		if path == "/api/add-policy" || path == "/api/remove-policy" || path == "/api/update-policy" {
+		if path == "/api/add-policy" || path == "/api/remove-policy" || path == "/api/update-policy" || path == "/api/patch-user" ||
+			path == "/api/add-role-users" || path == "/api/remove-role-users" || path == "/api/add-group-users" || path == "/api/remove-group-users" ||
+			path == "/api/verify-custom-domain" || path == "/api/verify-claimed-domain" || path == "/api/retry-webhook-event" || path == "/api/approve-account-recovery" ||
+			path == "/api/retry-export-job" || path == "/api/issue-break-glass-key" || path == "/api/request-mfa-reset" || path == "/api/request-mfa-bypass" ||
+			path == "/api/check-provider-health" || path == "/api/resend-user-activation" ||
+			path == "/api/refresh-saml-metadata" {
 			id := ctx.Input.Query("id")
 			if id != "" {
 				return util.GetOwnerAndNameFromIdNoCheck(id)
@@ -167,6 +173,22 @@ func getUrlPath(urlPath string) string {
 	return urlPath
 }
 
+// isWorkloadTokenAllowed checks the session authenticated by a workload token, which is limited to the APIs of the token
+// until it expires or its workload identity is deleted
+func isWorkloadTokenAllowed(ctx *context.Context) bool {
+	id := getSessionWorkloadToken(ctx)
+	if id == "" {
+		return true
+	}
+
+	workloadToken, err := object.GetWorkloadToken(id)
+	if err != nil {
+		panic(err)
+	}
+
+	return workloadToken != nil && !workloadToken.IsExpired() && object.IsWorkloadApiAllowed(workloadToken.Apis, ctx.Request.URL.Path)
+}
+
 func ApiFilter(ctx *context.Context) {
 	subOwner, subName := getSubject(ctx)
 	method := ctx.Request.Method
@@ -180,8 +202,20 @@ func ApiFilter(ctx *context.Context) {
 	if strings.HasPrefix(urlPath, "/api/notify-payment") {
 		urlPath = "/api/notify-payment"
 	}
+	if strings.HasPrefix(urlPath, "/api/notify-subscription") {
+		urlPath = "/api/notify-subscription"
+	}
+	if strings.HasPrefix(urlPath, "/api/notify-apple") {
+		urlPath = "/api/notify-apple"
+	}
+	if strings.HasPrefix(urlPath, "/api/notify-sms") {
+		urlPath = "/api/notify-sms"
+	}
+	if strings.HasPrefix(urlPath, "/api/notify-identity-verification") {
+		urlPath = "/api/notify-identity-verification"
+	}
 
-	isAllowed := authz.IsAllowed(subOwner, subName, method, urlPath, objOwner, objName)
+	isAllowed := isWorkloadTokenAllowed(ctx) && authz.IsAllowed(subOwner, subName, method, urlPath, objOwner, objName)
 
 	result := "deny"
 	if isAllowed {
@@ -16,6 +16,7 @@ package routers
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/beego/beego/context"
 	"github.com/casdoor/casdoor/object"
@@ -37,6 +38,29 @@ func AutoSigninFilter(ctx *context.Context) {
 		accessToken = parseBearerToken(ctx)
 	}
 
+	if accessToken != "" && object.IsWorkloadAccessToken(accessToken) {
+		workloadToken, err := object.GetWorkloadTokenByAccessToken(accessToken)
+		if err != nil {
+			responseError(ctx, err.Error())
+			return
+		}
+
+		if workloadToken == nil {
+			responseError(ctx, "Access token doesn't exist in database")
+			return
+		}
+
+		if workloadToken.IsExpired() {
+			responseError(ctx, fmt.Sprintf("Access token has expired, expireTime = %s", workloadToken.ExpireTime))
+			return
+		}
+
+		setSessionWorkloadToken(ctx, workloadToken.GetId())
+		setSessionUser(ctx, util.GetId(workloadToken.Owner, workloadToken.User))
+		setTokenAuthenticated(ctx)
+		return
+	}
+
 	if accessToken != "" {
 		token, err := object.GetTokenByAccessToken(accessToken)
 		if err != nil {
@@ -55,6 +79,22 @@ func AutoSigninFilter(ctx *context.Context) {
 			return
 		}
 
+		if object.IsTokenDenylisted(object.AccessTokenTypeHint, token.GetId()) {
+			responseError(ctx, "Access token has been revoked")
+			return
+		}
+
+		certThumbprint, err := object.GetClientCertificateThumbprint(ctx.Request)
+		if err != nil {
+			responseError(ctx, err.Error())
+			return
+		}
+
+		if !object.IsTokenCertificateMatched(token, certThumbprint) {
+			responseError(ctx, "Access token is bound to another client certificate")
+			return
+		}
+
 		userId := util.GetId(token.Organization, token.User)
 		application, err := object.GetApplicationByUserId(fmt.Sprintf("app/%s", token.Application))
 		if err != nil {
@@ -62,8 +102,15 @@ func AutoSigninFilter(ctx *context.Context) {
 			return
 		}
 
+		setSessionWorkloadToken(ctx, "")
 		setSessionUser(ctx, userId)
 		setSessionOidc(ctx, token.Scope, application.ClientId)
+		setTokenAuthenticated(ctx)
+		return
+	}
+
+	// the EST enrollment authenticates the devices by the basic auth of their enrollment secrets
+	if strings.HasPrefix(ctx.Request.URL.Path, "/.well-known/est/") {
 		return
 	}
 
@@ -75,6 +122,7 @@ func AutoSigninFilter(ctx *context.Context) {
 	}
 	if userId != "" {
 		setSessionUser(ctx, userId)
+		setTokenAuthenticated(ctx)
 		return
 	}
 
@@ -90,5 +138,6 @@ func AutoSigninFilter(ctx *context.Context) {
 		}
 
 		setSessionUser(ctx, userId)
+		setTokenAuthenticated(ctx)
 	}
 }
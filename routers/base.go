@@ -28,6 +28,9 @@ import (
 	"github.com/casdoor/casdoor/util"
 )
 
+// the request data flag set by AutoSigninFilter when the request is authenticated by its own token or credentials
+const tokenAuthenticatedData = "TokenAuthenticated"
+
 type Response struct {
 	Status string      `json:"status"`
 	Msg    string      `json:"msg"`
@@ -79,7 +82,15 @@ func getUsernameByClientIdSecret(ctx *context.Context) (string, error) {
 
 	application, err := object.GetApplicationByClientId(clientId)
 	if err != nil {
-		return "", err
+		if !isEnforcePath(ctx) {
+			return "", err
+		}
+
+		// the enforce APIs keep serving the remembered clients while the database is unreachable
+		application, err = object.GetRememberedEnforceClient(clientId, err)
+		if err != nil {
+			return "", err
+		}
 	}
 	if application == nil {
 		return "", fmt.Errorf("Application not found for client ID: %s", clientId)
@@ -89,9 +100,17 @@ func getUsernameByClientIdSecret(ctx *context.Context) (string, error) {
 		return "", fmt.Errorf("Incorrect client secret for application: %s", application.Name)
 	}
 
+	if isEnforcePath(ctx) {
+		object.RememberEnforceClient(application)
+	}
+
 	return fmt.Sprintf("app/%s", application.Name), nil
 }
 
+func isEnforcePath(ctx *context.Context) bool {
+	return ctx.Request.URL.Path == "/api/enforce" || ctx.Request.URL.Path == "/api/batch-enforce"
+}
+
 func getUsernameByKeys(ctx *context.Context) string {
 	accessKey, accessSecret := getKeys(ctx)
 	user, err := object.GetUserByAccessKey(accessKey)
@@ -99,7 +118,7 @@ func getUsernameByKeys(ctx *context.Context) string {
 		panic(err)
 	}
 
-	if user != nil && accessSecret == user.AccessSecret {
+	if user != nil && accessSecret == user.AccessSecret && !user.IsSuspensionActive() {
 		return user.GetId()
 	}
 	return ""
@@ -124,6 +143,28 @@ func setSessionUser(ctx *context.Context, user string) {
 	ctx.Input.CruSession.SessionRelease(ctx.ResponseWriter)
 }
 
+// setSessionWorkloadToken marks the session as authenticated by the workload token, which limits it to the APIs of the token
+func setTokenAuthenticated(ctx *context.Context) {
+	ctx.Input.SetData(tokenAuthenticatedData, true)
+}
+
+func setSessionWorkloadToken(ctx *context.Context, workloadToken string) {
+	err := ctx.Input.CruSession.Set("workloadToken", workloadToken)
+	if err != nil {
+		panic(err)
+	}
+	ctx.Input.CruSession.SessionRelease(ctx.ResponseWriter)
+}
+
+func getSessionWorkloadToken(ctx *context.Context) string {
+	if ctx.Input.CruSession == nil {
+		return ""
+	}
+
+	workloadToken, _ := ctx.Input.CruSession.Get("workloadToken").(string)
+	return workloadToken
+}
+
 func setSessionExpire(ctx *context.Context, ExpireTime int64) {
 	SessionData := struct{ ExpireTime int64 }{ExpireTime: ExpireTime}
 	err := ctx.Input.CruSession.Set("SessionData", util.StructToJson(SessionData))
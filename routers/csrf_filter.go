@@ -0,0 +1,134 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/beego/beego/context"
+	"github.com/casdoor/casdoor/conf"
+	"github.com/casdoor/casdoor/util"
+)
+
+const (
+	csrfCookieName = "casdoor_csrf_token"
+	csrfHeaderName = "X-CSRF-Token"
+)
+
+var (
+	enableCsrfProtection = conf.GetConfigBool("enableCsrfProtection")
+
+	// the endpoints called by other servers or posted by the identity providers, which carry no CSRF token, and the
+	// MFA bypass posted by its plain confirmation page, which carries the secret token of the link instead
+	csrfExemptPaths = []string{"/api/acs", "/api/login/oauth/", "/api/notify-", "/api/email-bounce", "/cas/", "/scim/", "/api/use-mfa-bypass"}
+)
+
+func isStateChangingMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch || method == http.MethodDelete
+}
+
+// isTokenAuthenticated checks whether AutoSigninFilter has authenticated the request by a token or credentials
+// carried in the request itself, which the browsers never attach to the forged requests by themselves. The mere
+// presence of such a parameter or header exempts nothing. The access keys checked later by ApiFilter only apply
+// when there is no session user, so such requests are never protected by the session cookie either
+func isTokenAuthenticated(ctx *context.Context) bool {
+	isAuthenticated, _ := ctx.Input.GetData(tokenAuthenticatedData).(bool)
+	return isAuthenticated
+}
+
+func isCsrfExemptPath(urlPath string) bool {
+	exemptPaths := csrfExemptPaths
+	if paths := conf.GetConfigString("csrfExemptPaths"); paths != "" {
+		exemptPaths = append(append([]string{}, exemptPaths...), strings.Split(paths, ",")...)
+	}
+
+	for _, exemptPath := range exemptPaths {
+		exemptPath = strings.TrimSpace(exemptPath)
+		if exemptPath != "" && strings.HasPrefix(urlPath, exemptPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// getCsrfToken returns the CSRF token of the signed-in user's session, a new token is issued for each user
+// signed in the session, so the token of one user can't be replayed after another one signs in
+func getCsrfToken(ctx *context.Context, user string) string {
+	token, _ := ctx.Input.CruSession.Get("csrfToken").(string)
+	tokenUser, _ := ctx.Input.CruSession.Get("csrfUser").(string)
+	if token != "" && tokenUser == user {
+		return token
+	}
+
+	token = util.GenerateId()
+	err := ctx.Input.CruSession.Set("csrfToken", token)
+	if err != nil {
+		panic(err)
+	}
+	err = ctx.Input.CruSession.Set("csrfUser", user)
+	if err != nil {
+		panic(err)
+	}
+	ctx.Input.CruSession.SessionRelease(ctx.ResponseWriter)
+	return token
+}
+
+// setCsrfCookie passes the token to the frontend by a cookie readable by the scripts, the frontend submits it back
+// in the header, which the forged requests can't set
+func setCsrfCookie(ctx *context.Context, token string) {
+	if ctx.GetCookie(csrfCookieName) == token {
+		return
+	}
+
+	http.SetCookie(ctx.ResponseWriter, &http.Cookie{
+		Name:     csrfCookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   ctx.Input.IsSecure(),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// CsrfFilter issues the session-bound CSRF tokens and checks them on the state-changing APIs authenticated by
+// the session cookie, the requests authenticated by tokens or keys are exempted
+func CsrfFilter(ctx *context.Context) {
+	if !enableCsrfProtection || ctx.Input.CruSession == nil {
+		return
+	}
+
+	urlPath := ctx.Request.URL.Path
+	if !strings.HasPrefix(urlPath, "/api/") || isTokenAuthenticated(ctx) {
+		return
+	}
+
+	user := getSessionUser(ctx)
+	if user == "" {
+		return
+	}
+
+	token := getCsrfToken(ctx, user)
+	setCsrfCookie(ctx, token)
+
+	if !isStateChangingMethod(ctx.Request.Method) || isCsrfExemptPath(urlPath) {
+		return
+	}
+
+	headerToken := ctx.Request.Header.Get(csrfHeaderName)
+	if subtle.ConstantTimeCompare([]byte(headerToken), []byte(token)) != 1 {
+		responseError(ctx, T(ctx, "auth:The CSRF token is missing or invalid, please refresh the page and try again"))
+	}
+}
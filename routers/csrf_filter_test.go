@@ -0,0 +1,74 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/beego/beego/context"
+	"github.com/beego/beego/session"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCsrfTestContext(t *testing.T, method string, url string) (*context.Context, *httptest.ResponseRecorder) {
+	manager, err := session.NewManager("memory", &session.ManagerConfig{CookieName: "casdoor_session_id", Gclifetime: 3600})
+	assert.Nil(t, err)
+
+	recorder := httptest.NewRecorder()
+	ctx := context.NewContext()
+	ctx.Reset(recorder, httptest.NewRequest(method, url, nil))
+	ctx.Input.CruSession, err = manager.SessionStart(recorder, ctx.Request)
+	assert.Nil(t, err)
+	return ctx, recorder
+}
+
+func TestCsrfFilter(t *testing.T) {
+	oldEnableCsrfProtection := enableCsrfProtection
+	enableCsrfProtection = true
+	defer func() { enableCsrfProtection = oldEnableCsrfProtection }()
+
+	// a forged request riding on the session cookie with a made-up token parameter, header or keys
+	for _, url := range []string{"/api/update-user?accessToken=123", "/api/update-user?clientSecret=123", "/api/update-user?accessKey=1&accessSecret=2"} {
+		ctx, recorder := newCsrfTestContext(t, http.MethodPost, url)
+		ctx.Request.Header.Set("Authorization", "Bearer 123")
+		setSessionUser(ctx, "built-in/admin")
+		assert.False(t, isTokenAuthenticated(ctx))
+
+		CsrfFilter(ctx)
+		assert.Equal(t, http.StatusForbidden, recorder.Code, url)
+	}
+
+	// the request really authenticated by its token
+	ctx, recorder := newCsrfTestContext(t, http.MethodPost, "/api/update-user?accessToken=123")
+	setSessionUser(ctx, "built-in/admin")
+	setTokenAuthenticated(ctx)
+	CsrfFilter(ctx)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// the session request carrying the token of its session
+	ctx, recorder = newCsrfTestContext(t, http.MethodPost, "/api/update-user")
+	setSessionUser(ctx, "built-in/admin")
+	ctx.Request.Header.Set(csrfHeaderName, getCsrfToken(ctx, "built-in/admin"))
+	CsrfFilter(ctx)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	// the state-changing session request without the token
+	ctx, recorder = newCsrfTestContext(t, http.MethodPost, "/api/update-user")
+	setSessionUser(ctx, "built-in/admin")
+	CsrfFilter(ctx)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
@@ -44,6 +44,9 @@ func getUserByClientIdSecret(ctx *context.Context) string {
 	}
 
 	application, err := object.GetApplicationByClientId(clientId)
+	if err != nil && isEnforcePath(ctx) {
+		application, err = object.GetRememberedEnforceClient(clientId, err)
+	}
 	if err != nil {
 		panic(err)
 	}
@@ -67,5 +70,5 @@ func RecordMessage(ctx *context.Context) {
 		record.Organization, record.User = util.GetOwnerAndNameFromId(userId)
 	}
 
-	util.SafeGoroutine(func() { object.AddRecord(record) })
+	object.AddRecord(record)
 }
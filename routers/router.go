@@ -51,18 +51,22 @@ func initAPI() {
 	beego.Router("/api/signup", &controllers.ApiController{}, "POST:Signup")
 	beego.Router("/api/login", &controllers.ApiController{}, "POST:Login")
 	beego.Router("/api/get-app-login", &controllers.ApiController{}, "GET:GetApplicationLogin")
+	beego.Router("/api/get-pending-requirements", &controllers.ApiController{}, "GET:GetPendingRequirements")
 	beego.Router("/api/get-dashboard", &controllers.ApiController{}, "GET:GetDashboard")
 	beego.Router("/api/logout", &controllers.ApiController{}, "GET,POST:Logout")
 	beego.Router("/api/get-account", &controllers.ApiController{}, "GET:GetAccount")
+	beego.Router("/api/elevate", &controllers.ApiController{}, "POST:Elevate")
 	beego.Router("/api/userinfo", &controllers.ApiController{}, "GET:GetUserinfo")
 	beego.Router("/api/user", &controllers.ApiController{}, "GET:GetUserinfo2")
 	beego.Router("/api/unlink", &controllers.ApiController{}, "POST:Unlink")
 	beego.Router("/api/get-saml-login", &controllers.ApiController{}, "GET:GetSamlLogin")
+	beego.Router("/api/get-web3-nonce", &controllers.ApiController{}, "GET:GetWeb3Nonce")
 	beego.Router("/api/acs", &controllers.ApiController{}, "POST:HandleSamlLogin")
 	beego.Router("/api/saml/metadata", &controllers.ApiController{}, "GET:GetSamlMeta")
 	beego.Router("/api/webhook", &controllers.ApiController{}, "POST:HandleOfficialAccountEvent")
 	beego.Router("/api/get-webhook-event", &controllers.ApiController{}, "GET:GetWebhookEventType")
 	beego.Router("/api/get-captcha-status", &controllers.ApiController{}, "GET:GetCaptchaStatus")
+	beego.Router("/api/get-bot-token", &controllers.ApiController{}, "GET:GetBotToken")
 	beego.Router("/api/callback", &controllers.ApiController{}, "POST:Callback")
 
 	beego.Router("/api/get-organizations", &controllers.ApiController{}, "GET:GetOrganizations")
@@ -70,6 +74,17 @@ func initAPI() {
 	beego.Router("/api/update-organization", &controllers.ApiController{}, "POST:UpdateOrganization")
 	beego.Router("/api/add-organization", &controllers.ApiController{}, "POST:AddOrganization")
 	beego.Router("/api/delete-organization", &controllers.ApiController{}, "POST:DeleteOrganization")
+	beego.Router("/api/get-mfa-policy-report", &controllers.ApiController{}, "GET:GetMfaPolicyReport")
+	beego.Router("/api/get-siem-exporter-status", &controllers.ApiController{}, "GET:GetSiemExporterStatus")
+	beego.Router("/api/get-usage", &controllers.ApiController{}, "GET:GetUsage")
+	beego.Router("/api/get-data-key", &controllers.ApiController{}, "GET:GetDataKey")
+	beego.Router("/api/create-data-key", &controllers.ApiController{}, "POST:CreateDataKey")
+	beego.Router("/api/shred-data-key", &controllers.ApiController{}, "POST:ShredDataKey")
+	beego.Router("/api/export-access-graph", &controllers.ApiController{}, "GET:ExportAccessGraph")
+	beego.Router("/api/clone-organization", &controllers.ApiController{}, "POST:CloneOrganization")
+	beego.Router("/api/verify-custom-domain", &controllers.ApiController{}, "POST:VerifyCustomDomain")
+	beego.Router("/api/verify-claimed-domain", &controllers.ApiController{}, "POST:VerifyClaimedDomain")
+	beego.Router("/api/get-organization-onboarding-status", &controllers.ApiController{}, "GET:GetOrganizationOnboardingStatus")
 	beego.Router("/api/get-default-application", &controllers.ApiController{}, "GET:GetDefaultApplication")
 	beego.Router("/api/get-organization-names", &controllers.ApiController{}, "GET:GetOrganizationNames")
 
@@ -78,18 +93,63 @@ func initAPI() {
 	beego.Router("/api/get-sorted-users", &controllers.ApiController{}, "GET:GetSortedUsers")
 	beego.Router("/api/get-user-count", &controllers.ApiController{}, "GET:GetUserCount")
 	beego.Router("/api/get-user", &controllers.ApiController{}, "GET:GetUser")
+	beego.Router("/api/get-user-access", &controllers.ApiController{}, "GET:GetUserAccess")
+	beego.Router("/api/get-user-timeline", &controllers.ApiController{}, "GET:GetUserTimeline")
 	beego.Router("/api/update-user", &controllers.ApiController{}, "POST:UpdateUser")
+	beego.Router("/api/patch-user", &controllers.ApiController{}, "POST,PATCH:PatchUser")
 	beego.Router("/api/add-user-keys", &controllers.ApiController{}, "POST:AddUserKeys")
 	beego.Router("/api/add-user", &controllers.ApiController{}, "POST:AddUser")
+	beego.Router("/api/pre-register-user", &controllers.ApiController{}, "POST:PreRegisterUser")
+	beego.Router("/api/get-user-activations", &controllers.ApiController{}, "GET:GetUserActivations")
+	beego.Router("/api/resend-user-activation", &controllers.ApiController{}, "POST:ResendUserActivation")
 	beego.Router("/api/delete-user", &controllers.ApiController{}, "POST:DeleteUser")
+	beego.Router("/api/suspend-user", &controllers.ApiController{}, "POST:SuspendUser")
+	beego.Router("/api/reactivate-user", &controllers.ApiController{}, "POST:ReactivateUser")
+	beego.Router("/api/set-user-lifecycle-state", &controllers.ApiController{}, "POST:SetUserLifecycleState")
 	beego.Router("/api/upload-users", &controllers.ApiController{}, "POST:UploadUsers")
+	beego.Router("/api/get-user-contacts", &controllers.ApiController{}, "GET:GetUserContacts")
+	beego.Router("/api/add-user-contact", &controllers.ApiController{}, "POST:AddUserContact")
+	beego.Router("/api/verify-user-contact", &controllers.ApiController{}, "POST:VerifyUserContact")
+	beego.Router("/api/set-primary-user-contact", &controllers.ApiController{}, "POST:SetPrimaryUserContact")
+	beego.Router("/api/delete-user-contact", &controllers.ApiController{}, "POST:DeleteUserContact")
+	beego.Router("/api/get-vault-credentials", &controllers.ApiController{}, "GET:GetVaultCredentials")
+	beego.Router("/api/add-vault-credential", &controllers.ApiController{}, "POST:AddVaultCredential")
+	beego.Router("/api/update-vault-credential", &controllers.ApiController{}, "POST:UpdateVaultCredential")
+	beego.Router("/api/delete-vault-credential", &controllers.ApiController{}, "POST:DeleteVaultCredential")
+	beego.Router("/api/retrieve-vault-credential", &controllers.ApiController{}, "POST:RetrieveVaultCredential")
+
+	beego.Router("/api/get-access-requests", &controllers.ApiController{}, "GET:GetAccessRequests")
+	beego.Router("/api/get-access-request", &controllers.ApiController{}, "GET:GetAccessRequest")
+	beego.Router("/api/add-access-request", &controllers.ApiController{}, "POST:AddAccessRequest")
+	beego.Router("/api/approve-access-request", &controllers.ApiController{}, "POST:ApproveAccessRequest")
+	beego.Router("/api/reject-access-request", &controllers.ApiController{}, "POST:RejectAccessRequest")
+	beego.Router("/api/cancel-access-request", &controllers.ApiController{}, "POST:CancelAccessRequest")
+	beego.Router("/api/get-change-requests", &controllers.ApiController{}, "GET:GetChangeRequests")
+	beego.Router("/api/get-change-request", &controllers.ApiController{}, "GET:GetChangeRequest")
+	beego.Router("/api/approve-change-request", &controllers.ApiController{}, "POST:ApproveChangeRequest")
+	beego.Router("/api/reject-change-request", &controllers.ApiController{}, "POST:RejectChangeRequest")
+	beego.Router("/api/cancel-change-request", &controllers.ApiController{}, "POST:CancelChangeRequest")
 	beego.Router("/api/remove-user-from-group", &controllers.ApiController{}, "POST:RemoveUserFromGroup")
 
+	beego.Router("/api/get-access-review-campaigns", &controllers.ApiController{}, "GET:GetAccessReviewCampaigns")
+	beego.Router("/api/get-access-review-campaign", &controllers.ApiController{}, "GET:GetAccessReviewCampaign")
+	beego.Router("/api/update-access-review-campaign", &controllers.ApiController{}, "POST:UpdateAccessReviewCampaign")
+	beego.Router("/api/add-access-review-campaign", &controllers.ApiController{}, "POST:AddAccessReviewCampaign")
+	beego.Router("/api/delete-access-review-campaign", &controllers.ApiController{}, "POST:DeleteAccessReviewCampaign")
+	beego.Router("/api/start-access-review-campaign", &controllers.ApiController{}, "POST:StartAccessReviewCampaign")
+	beego.Router("/api/get-access-review-items", &controllers.ApiController{}, "GET:GetAccessReviewItems")
+	beego.Router("/api/export-access-review-items", &controllers.ApiController{}, "GET:ExportAccessReviewItems")
+	beego.Router("/api/get-access-review-tasks", &controllers.ApiController{}, "GET:GetAccessReviewTasks")
+	beego.Router("/api/certify-access-review-item", &controllers.ApiController{}, "POST:CertifyAccessReviewItem")
+	beego.Router("/api/revoke-access-review-item", &controllers.ApiController{}, "POST:RevokeAccessReviewItem")
+
 	beego.Router("/api/get-groups", &controllers.ApiController{}, "GET:GetGroups")
 	beego.Router("/api/get-group", &controllers.ApiController{}, "GET:GetGroup")
 	beego.Router("/api/update-group", &controllers.ApiController{}, "POST:UpdateGroup")
 	beego.Router("/api/add-group", &controllers.ApiController{}, "POST:AddGroup")
 	beego.Router("/api/delete-group", &controllers.ApiController{}, "POST:DeleteGroup")
+	beego.Router("/api/add-group-users", &controllers.ApiController{}, "POST:AddGroupUsers")
+	beego.Router("/api/remove-group-users", &controllers.ApiController{}, "POST:RemoveGroupUsers")
 
 	beego.Router("/api/get-roles", &controllers.ApiController{}, "GET:GetRoles")
 	beego.Router("/api/get-role", &controllers.ApiController{}, "GET:GetRole")
@@ -97,6 +157,8 @@ func initAPI() {
 	beego.Router("/api/add-role", &controllers.ApiController{}, "POST:AddRole")
 	beego.Router("/api/delete-role", &controllers.ApiController{}, "POST:DeleteRole")
 	beego.Router("/api/upload-roles", &controllers.ApiController{}, "POST:UploadRoles")
+	beego.Router("/api/add-role-users", &controllers.ApiController{}, "POST:AddRoleUsers")
+	beego.Router("/api/remove-role-users", &controllers.ApiController{}, "POST:RemoveRoleUsers")
 
 	beego.Router("/api/get-permissions", &controllers.ApiController{}, "GET:GetPermissions")
 	beego.Router("/api/get-permissions-by-submitter", &controllers.ApiController{}, "GET:GetPermissionsBySubmitter")
@@ -106,6 +168,8 @@ func initAPI() {
 	beego.Router("/api/add-permission", &controllers.ApiController{}, "POST:AddPermission")
 	beego.Router("/api/delete-permission", &controllers.ApiController{}, "POST:DeletePermission")
 	beego.Router("/api/upload-permissions", &controllers.ApiController{}, "POST:UploadPermissions")
+	beego.Router("/api/get-stale-policies", &controllers.ApiController{}, "GET:GetStalePolicies")
+	beego.Router("/api/prune-stale-policies", &controllers.ApiController{}, "POST:PruneStalePolicies")
 
 	beego.Router("/api/enforce", &controllers.ApiController{}, "POST:Enforce")
 	beego.Router("/api/batch-enforce", &controllers.ApiController{}, "POST:BatchEnforce")
@@ -117,6 +181,7 @@ func initAPI() {
 	beego.Router("/api/get-model", &controllers.ApiController{}, "GET:GetModel")
 	beego.Router("/api/update-model", &controllers.ApiController{}, "POST:UpdateModel")
 	beego.Router("/api/add-model", &controllers.ApiController{}, "POST:AddModel")
+	beego.Router("/api/validate-model", &controllers.ApiController{}, "POST:ValidateModel")
 	beego.Router("/api/delete-model", &controllers.ApiController{}, "POST:DeleteModel")
 
 	beego.Router("/api/get-adapters", &controllers.ApiController{}, "GET:GetAdapters")
@@ -128,6 +193,7 @@ func initAPI() {
 	beego.Router("/api/update-policy", &controllers.ApiController{}, "POST:UpdatePolicy")
 	beego.Router("/api/add-policy", &controllers.ApiController{}, "POST:AddPolicy")
 	beego.Router("/api/remove-policy", &controllers.ApiController{}, "POST:RemovePolicy")
+	beego.Router("/api/watch", &controllers.ApiController{}, "GET:Watch")
 
 	beego.Router("/api/get-enforcers", &controllers.ApiController{}, "GET:GetEnforcers")
 	beego.Router("/api/get-enforcer", &controllers.ApiController{}, "GET:GetEnforcer")
@@ -135,7 +201,20 @@ func initAPI() {
 	beego.Router("/api/add-enforcer", &controllers.ApiController{}, "POST:AddEnforcer")
 	beego.Router("/api/delete-enforcer", &controllers.ApiController{}, "POST:DeleteEnforcer")
 
+	beego.Router("/api/get-enforcer-snapshots", &controllers.ApiController{}, "GET:GetEnforcerSnapshots")
+	beego.Router("/api/get-enforcer-snapshot", &controllers.ApiController{}, "GET:GetEnforcerSnapshot")
+	beego.Router("/api/add-enforcer-snapshot", &controllers.ApiController{}, "POST:AddEnforcerSnapshot")
+	beego.Router("/api/delete-enforcer-snapshot", &controllers.ApiController{}, "POST:DeleteEnforcerSnapshot")
+	beego.Router("/api/diff-enforcer-snapshots", &controllers.ApiController{}, "GET:DiffEnforcerSnapshots")
+	beego.Router("/api/rollback-enforcer-snapshot", &controllers.ApiController{}, "POST:RollbackEnforcerSnapshot")
+
 	beego.Router("/api/set-password", &controllers.ApiController{}, "POST:SetPassword")
+	beego.Router("/api/start-account-recovery", &controllers.ApiController{}, "POST:StartAccountRecovery")
+	beego.Router("/api/get-account-recovery-status", &controllers.ApiController{}, "GET:GetAccountRecoveryStatus")
+	beego.Router("/api/send-account-recovery-code", &controllers.ApiController{}, "POST:SendAccountRecoveryCode")
+	beego.Router("/api/verify-account-recovery-factor", &controllers.ApiController{}, "POST:VerifyAccountRecoveryFactor")
+	beego.Router("/api/approve-account-recovery", &controllers.ApiController{}, "POST:ApproveAccountRecovery")
+	beego.Router("/api/get-account-recoveries", &controllers.ApiController{}, "GET:GetAccountRecoveries")
 	beego.Router("/api/check-user-password", &controllers.ApiController{}, "POST:CheckUserPassword")
 	beego.Router("/api/get-email-and-phone", &controllers.ApiController{}, "GET:GetEmailAndPhone")
 	beego.Router("/api/send-verification-code", &controllers.ApiController{}, "POST:SendVerificationCode")
@@ -153,19 +232,29 @@ func initAPI() {
 	beego.Router("/api/sync-ldap-users", &controllers.ApiController{}, "POST:SyncLdapUsers")
 
 	beego.Router("/api/get-providers", &controllers.ApiController{}, "GET:GetProviders")
+	beego.Router("/api/get-catalog-providers", &controllers.ApiController{}, "GET:GetCatalogProviders")
 	beego.Router("/api/get-provider", &controllers.ApiController{}, "GET:GetProvider")
 	beego.Router("/api/get-global-providers", &controllers.ApiController{}, "GET:GetGlobalProviders")
 	beego.Router("/api/update-provider", &controllers.ApiController{}, "POST:UpdateProvider")
+	beego.Router("/api/get-dkim-record", &controllers.ApiController{}, "GET:GetDkimRecord")
+	beego.Router("/api/rotate-dkim-key", &controllers.ApiController{}, "POST:RotateDkimKey")
+	beego.Router("/api/get-provider-health", &controllers.ApiController{}, "GET:GetProviderHealth")
+	beego.Router("/api/check-provider-health", &controllers.ApiController{}, "POST:CheckProviderHealth")
+	beego.Router("/api/refresh-saml-metadata", &controllers.ApiController{}, "POST:RefreshSamlMetadata")
 	beego.Router("/api/add-provider", &controllers.ApiController{}, "POST:AddProvider")
 	beego.Router("/api/delete-provider", &controllers.ApiController{}, "POST:DeleteProvider")
 
 	beego.Router("/api/get-applications", &controllers.ApiController{}, "GET:GetApplications")
 	beego.Router("/api/get-application", &controllers.ApiController{}, "GET:GetApplication")
+	beego.Router("/api/get-app-login-config", &controllers.ApiController{}, "GET:GetAppLoginConfig")
 	beego.Router("/api/get-user-application", &controllers.ApiController{}, "GET:GetUserApplication")
 	beego.Router("/api/get-organization-applications", &controllers.ApiController{}, "GET:GetOrganizationApplications")
 	beego.Router("/api/update-application", &controllers.ApiController{}, "POST:UpdateApplication")
 	beego.Router("/api/add-application", &controllers.ApiController{}, "POST:AddApplication")
 	beego.Router("/api/delete-application", &controllers.ApiController{}, "POST:DeleteApplication")
+	beego.Router("/api/clone-application", &controllers.ApiController{}, "POST:CloneApplication")
+	beego.Router("/api/get-login-experiment-metrics", &controllers.ApiController{}, "GET:GetLoginExperimentMetrics")
+	beego.Router("/api/validate-redirect-uri", &controllers.ApiController{}, "GET:ValidateRedirectUri")
 
 	beego.Router("/api/get-resources", &controllers.ApiController{}, "GET:GetResources")
 	beego.Router("/api/get-resource", &controllers.ApiController{}, "GET:GetResource")
@@ -173,6 +262,11 @@ func initAPI() {
 	beego.Router("/api/add-resource", &controllers.ApiController{}, "POST:AddResource")
 	beego.Router("/api/delete-resource", &controllers.ApiController{}, "POST:DeleteResource")
 	beego.Router("/api/upload-resource", &controllers.ApiController{}, "POST:UploadResource")
+	beego.Router("/api/get-resource-usage", &controllers.ApiController{}, "GET:GetResourceUsage")
+
+	beego.Router("/api/get-shares", &controllers.ApiController{}, "GET:GetShares")
+	beego.Router("/api/add-share", &controllers.ApiController{}, "POST:AddShare")
+	beego.Router("/api/delete-share", &controllers.ApiController{}, "POST:DeleteShare")
 
 	beego.Router("/api/get-tokens", &controllers.ApiController{}, "GET:GetTokens")
 	beego.Router("/api/get-token", &controllers.ApiController{}, "GET:GetToken")
@@ -182,6 +276,8 @@ func initAPI() {
 	beego.Router("/api/login/oauth/access_token", &controllers.ApiController{}, "POST:GetOAuthToken")
 	beego.Router("/api/login/oauth/refresh_token", &controllers.ApiController{}, "POST:RefreshToken")
 	beego.Router("/api/login/oauth/introspect", &controllers.ApiController{}, "POST:IntrospectToken")
+	beego.Router("/api/login/oauth/revoke", &controllers.ApiController{}, "POST:RevokeToken")
+	beego.Router("/api/token-metadata", &controllers.ApiController{}, "GET:GetTokenMetadata")
 
 	beego.Router("/api/get-sessions", &controllers.ApiController{}, "GET:GetSessions")
 	beego.Router("/api/get-session", &controllers.ApiController{}, "GET:GetSingleSession")
@@ -189,12 +285,48 @@ func initAPI() {
 	beego.Router("/api/add-session", &controllers.ApiController{}, "POST:AddSession")
 	beego.Router("/api/delete-session", &controllers.ApiController{}, "POST:DeleteSession")
 	beego.Router("/api/is-session-duplicated", &controllers.ApiController{}, "GET:IsSessionDuplicated")
+	beego.Router("/api/get-user-sessions", &controllers.ApiController{}, "GET:GetUserSessions")
+	beego.Router("/api/set-session-device-name", &controllers.ApiController{}, "POST:SetSessionDeviceName")
 
 	beego.Router("/api/get-webhooks", &controllers.ApiController{}, "GET:GetWebhooks")
 	beego.Router("/api/get-webhook", &controllers.ApiController{}, "GET:GetWebhook")
 	beego.Router("/api/update-webhook", &controllers.ApiController{}, "POST:UpdateWebhook")
 	beego.Router("/api/add-webhook", &controllers.ApiController{}, "POST:AddWebhook")
 	beego.Router("/api/delete-webhook", &controllers.ApiController{}, "POST:DeleteWebhook")
+	beego.Router("/api/rotate-webhook-secret", &controllers.ApiController{}, "POST:RotateWebhookSecret")
+	beego.Router("/api/get-webhook-events", &controllers.ApiController{}, "GET:GetWebhookEvents")
+	beego.Router("/api/retry-webhook-event", &controllers.ApiController{}, "POST:RetryWebhookEvent")
+
+	beego.Router("/api/get-export-jobs", &controllers.ApiController{}, "GET:GetExportJobs")
+	beego.Router("/api/get-export-job", &controllers.ApiController{}, "GET:GetExportJob")
+	beego.Router("/api/add-export-job", &controllers.ApiController{}, "POST:AddExportJob")
+	beego.Router("/api/retry-export-job", &controllers.ApiController{}, "POST:RetryExportJob")
+	beego.Router("/api/delete-export-job", &controllers.ApiController{}, "POST:DeleteExportJob")
+
+	beego.Router("/api/get-legal-acceptances", &controllers.ApiController{}, "GET:GetLegalAcceptances")
+	beego.Router("/api/get-legal-acceptance-report", &controllers.ApiController{}, "GET:GetLegalAcceptanceReport")
+	beego.Router("/api/start-identity-verification", &controllers.ApiController{}, "POST:StartIdentityVerification")
+	beego.Router("/api/get-identity-verification-state", &controllers.ApiController{}, "GET:GetIdentityVerificationState")
+	beego.Router("/api/get-identity-verifications", &controllers.ApiController{}, "GET:GetIdentityVerifications")
+	beego.Router("/api/break-glass-login", &controllers.ApiController{}, "POST:BreakGlassLogin")
+	beego.Router("/api/issue-break-glass-key", &controllers.ApiController{}, "POST:IssueBreakGlassKey")
+	beego.Router("/api/get-break-glass-keys", &controllers.ApiController{}, "GET:GetBreakGlassKeys")
+
+	beego.Router("/api/get-radius-clients", &controllers.ApiController{}, "GET:GetRadiusClients")
+	beego.Router("/api/get-radius-client", &controllers.ApiController{}, "GET:GetRadiusClient")
+	beego.Router("/api/update-radius-client", &controllers.ApiController{}, "POST:UpdateRadiusClient")
+	beego.Router("/api/add-radius-client", &controllers.ApiController{}, "POST:AddRadiusClient")
+	beego.Router("/api/delete-radius-client", &controllers.ApiController{}, "POST:DeleteRadiusClient")
+
+	beego.Router("/api/get-record-queries", &controllers.ApiController{}, "GET:GetRecordQueries")
+	beego.Router("/api/get-record-query", &controllers.ApiController{}, "GET:GetRecordQuery")
+	beego.Router("/api/update-record-query", &controllers.ApiController{}, "POST:UpdateRecordQuery")
+	beego.Router("/api/add-record-query", &controllers.ApiController{}, "POST:AddRecordQuery")
+	beego.Router("/api/delete-record-query", &controllers.ApiController{}, "POST:DeleteRecordQuery")
+	beego.Router("/api/run-record-query", &controllers.ApiController{}, "GET:RunRecordQuery")
+	beego.Router("/api/export-record-query", &controllers.ApiController{}, "GET:ExportRecordQuery")
+	beego.Router("/api/search-records", &controllers.ApiController{}, "GET:SearchRecords")
+	beego.Router("/api/get-record-aggregation", &controllers.ApiController{}, "GET:GetRecordAggregation")
 
 	beego.Router("/api/get-syncers", &controllers.ApiController{}, "GET:GetSyncers")
 	beego.Router("/api/get-syncer", &controllers.ApiController{}, "GET:GetSyncer")
@@ -203,12 +335,57 @@ func initAPI() {
 	beego.Router("/api/delete-syncer", &controllers.ApiController{}, "POST:DeleteSyncer")
 	beego.Router("/api/run-syncer", &controllers.ApiController{}, "GET:RunSyncer")
 
+	beego.Router("/api/get-provisioners", &controllers.ApiController{}, "GET:GetProvisioners")
+	beego.Router("/api/get-provisioner", &controllers.ApiController{}, "GET:GetProvisioner")
+	beego.Router("/api/update-provisioner", &controllers.ApiController{}, "POST:UpdateProvisioner")
+	beego.Router("/api/add-provisioner", &controllers.ApiController{}, "POST:AddProvisioner")
+	beego.Router("/api/delete-provisioner", &controllers.ApiController{}, "POST:DeleteProvisioner")
+	beego.Router("/api/run-provisioner", &controllers.ApiController{}, "GET:RunProvisioner")
+
 	beego.Router("/api/get-certs", &controllers.ApiController{}, "GET:GetCerts")
 	beego.Router("/api/get-global-certs", &controllers.ApiController{}, "GET:GetGlobalCerts")
 	beego.Router("/api/get-cert", &controllers.ApiController{}, "GET:GetCert")
 	beego.Router("/api/update-cert", &controllers.ApiController{}, "POST:UpdateCert")
 	beego.Router("/api/add-cert", &controllers.ApiController{}, "POST:AddCert")
 	beego.Router("/api/delete-cert", &controllers.ApiController{}, "POST:DeleteCert")
+	beego.Router("/api/rotate-cert", &controllers.ApiController{}, "POST:RotateCert")
+
+	beego.Router("/api/get-service-accounts", &controllers.ApiController{}, "GET:GetServiceAccounts")
+	beego.Router("/api/get-service-account", &controllers.ApiController{}, "GET:GetServiceAccount")
+	beego.Router("/api/update-service-account", &controllers.ApiController{}, "POST:UpdateServiceAccount")
+	beego.Router("/api/add-service-account", &controllers.ApiController{}, "POST:AddServiceAccount")
+	beego.Router("/api/delete-service-account", &controllers.ApiController{}, "POST:DeleteServiceAccount")
+	beego.Router("/api/rotate-service-account-secret", &controllers.ApiController{}, "POST:RotateServiceAccountSecret")
+	beego.Router("/api/rotate-service-account-key", &controllers.ApiController{}, "POST:RotateServiceAccountKey")
+	beego.Router("/api/get-workload-identities", &controllers.ApiController{}, "GET:GetWorkloadIdentities")
+	beego.Router("/api/get-workload-identity", &controllers.ApiController{}, "GET:GetWorkloadIdentity")
+	beego.Router("/api/update-workload-identity", &controllers.ApiController{}, "POST:UpdateWorkloadIdentity")
+	beego.Router("/api/add-workload-identity", &controllers.ApiController{}, "POST:AddWorkloadIdentity")
+	beego.Router("/api/delete-workload-identity", &controllers.ApiController{}, "POST:DeleteWorkloadIdentity")
+	beego.Router("/api/exchange-workload-token", &controllers.ApiController{}, "POST:ExchangeWorkloadToken")
+	beego.Router("/api/get-workload-tokens", &controllers.ApiController{}, "GET:GetWorkloadTokens")
+	beego.Router("/api/get-devices", &controllers.ApiController{}, "GET:GetDevices")
+	beego.Router("/api/get-device", &controllers.ApiController{}, "GET:GetDevice")
+	beego.Router("/api/update-device", &controllers.ApiController{}, "POST:UpdateDevice")
+	beego.Router("/api/add-device", &controllers.ApiController{}, "POST:AddDevice")
+	beego.Router("/api/delete-device", &controllers.ApiController{}, "POST:DeleteDevice")
+	beego.Router("/api/reset-device-enrollment", &controllers.ApiController{}, "POST:ResetDeviceEnrollment")
+
+	beego.Router("/api/get-organization-memberships", &controllers.ApiController{}, "GET:GetOrganizationMemberships")
+	beego.Router("/api/get-organization-membership", &controllers.ApiController{}, "GET:GetOrganizationMembership")
+	beego.Router("/api/update-organization-membership", &controllers.ApiController{}, "POST:UpdateOrganizationMembership")
+	beego.Router("/api/add-organization-membership", &controllers.ApiController{}, "POST:AddOrganizationMembership")
+	beego.Router("/api/delete-organization-membership", &controllers.ApiController{}, "POST:DeleteOrganizationMembership")
+	beego.Router("/api/get-user-organizations", &controllers.ApiController{}, "GET:GetUserOrganizations")
+	beego.Router("/api/switch-organization", &controllers.ApiController{}, "POST:SwitchOrganization")
+
+	beego.Router("/api/get-translations", &controllers.ApiController{}, "GET:GetTranslations")
+	beego.Router("/api/get-translation", &controllers.ApiController{}, "GET:GetTranslation")
+	beego.Router("/api/update-translation", &controllers.ApiController{}, "POST:UpdateTranslation")
+	beego.Router("/api/add-translation", &controllers.ApiController{}, "POST:AddTranslation")
+	beego.Router("/api/delete-translation", &controllers.ApiController{}, "POST:DeleteTranslation")
+	beego.Router("/api/export-translations", &controllers.ApiController{}, "GET:ExportTranslations")
+	beego.Router("/api/import-translations", &controllers.ApiController{}, "POST:ImportTranslations")
 
 	beego.Router("/api/get-subscriptions", &controllers.ApiController{}, "GET:GetSubscriptions")
 	beego.Router("/api/get-subscription", &controllers.ApiController{}, "GET:GetSubscription")
@@ -242,10 +419,17 @@ func initAPI() {
 	beego.Router("/api/add-payment", &controllers.ApiController{}, "POST:AddPayment")
 	beego.Router("/api/delete-payment", &controllers.ApiController{}, "POST:DeletePayment")
 	beego.Router("/api/notify-payment/?:owner/?:payment", &controllers.ApiController{}, "POST:NotifyPayment")
+	beego.Router("/api/notify-subscription/?:owner/?:provider", &controllers.ApiController{}, "POST:NotifySubscription")
+	beego.Router("/api/notify-apple/?:owner/?:provider", &controllers.ApiController{}, "POST:NotifyApple")
+	beego.Router("/api/notify-sms/?:owner/?:provider", &controllers.ApiController{}, "POST:NotifySms")
+	beego.Router("/api/notify-identity-verification/?:owner/?:provider", &controllers.ApiController{}, "POST:NotifyIdentityVerification")
 	beego.Router("/api/invoice-payment", &controllers.ApiController{}, "POST:InvoicePayment")
 
 	beego.Router("/api/send-email", &controllers.ApiController{}, "POST:SendEmail")
+	beego.Router("/api/email-bounce", &controllers.ApiController{}, "POST:HandleEmailBounce")
 	beego.Router("/api/send-sms", &controllers.ApiController{}, "POST:SendSms")
+	beego.Router("/api/get-sms-messages", &controllers.ApiController{}, "GET:GetSmsMessages")
+	beego.Router("/api/get-sms-message", &controllers.ApiController{}, "GET:GetSmsMessage")
 	beego.Router("/api/send-notification", &controllers.ApiController{}, "POST:SendNotification")
 
 	beego.Router("/api/webauthn/signup/begin", &controllers.ApiController{}, "GET:WebAuthnSignupBegin")
@@ -256,18 +440,29 @@ func initAPI() {
 	beego.Router("/api/mfa/setup/initiate", &controllers.ApiController{}, "POST:MfaSetupInitiate")
 	beego.Router("/api/mfa/setup/verify", &controllers.ApiController{}, "POST:MfaSetupVerify")
 	beego.Router("/api/mfa/setup/enable", &controllers.ApiController{}, "POST:MfaSetupEnable")
+	beego.Router("/api/mfa/push/send", &controllers.ApiController{}, "POST:MfaPushSend")
+	beego.Router("/api/mfa/approve", &controllers.ApiController{}, "POST:MfaApprove")
 	beego.Router("/api/delete-mfa", &controllers.ApiController{}, "POST:DeleteMfa")
 	beego.Router("/api/set-preferred-mfa", &controllers.ApiController{}, "POST:SetPreferredMfa")
+	beego.Router("/api/request-mfa-reset", &controllers.ApiController{}, "POST:RequestMfaReset")
+	beego.Router("/api/request-mfa-bypass", &controllers.ApiController{}, "POST:RequestMfaBypass")
+	beego.Router("/api/use-mfa-bypass", &controllers.ApiController{}, "GET,POST:UseMfaBypass")
+	beego.Router("/api/regenerate-mfa-recovery-codes", &controllers.ApiController{}, "POST:RegenerateMfaRecoveryCodes")
 
 	beego.Router("/api/get-system-info", &controllers.ApiController{}, "GET:GetSystemInfo")
 	beego.Router("/api/get-version-info", &controllers.ApiController{}, "GET:GetVersionInfo")
 	beego.Router("/api/health", &controllers.ApiController{}, "GET:Health")
+	beego.Router("/api/get-error-codes", &controllers.ApiController{}, "GET:GetErrorCodes")
 	beego.Router("/api/get-prometheus-info", &controllers.ApiController{}, "GET:GetPrometheusInfo")
+	beego.Router("/api/export-backup", &controllers.ApiController{}, "POST:ExportBackup")
+	beego.Router("/api/import-backup", &controllers.ApiController{}, "POST:ImportBackup")
 
 	beego.Handler("/api/metrics", promhttp.Handler())
 
 	beego.Router("/.well-known/openid-configuration", &controllers.RootController{}, "GET:GetOidcDiscovery")
 	beego.Router("/.well-known/jwks", &controllers.RootController{}, "*:GetJwks")
+	beego.Router("/.well-known/est/simpleenroll", &controllers.ApiController{}, "POST:EstSimpleEnroll")
+	beego.Router("/.well-known/est/simplereenroll", &controllers.ApiController{}, "POST:EstSimpleReenroll")
 
 	beego.Router("/cas/:organization/:application/serviceValidate", &controllers.RootController{}, "GET:CasServiceValidate")
 	beego.Router("/cas/:organization/:application/proxyValidate", &controllers.RootController{}, "GET:CasProxyValidate")
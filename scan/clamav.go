@@ -0,0 +1,109 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	clamAvDefaultPort = 3310
+	clamAvChunkSize   = 64 * 1024
+	scanTimeout       = 60 * time.Second
+)
+
+type ClamAvScanProvider struct {
+	address string
+}
+
+func NewClamAvScanProvider(host string, port int) *ClamAvScanProvider {
+	if port == 0 {
+		port = clamAvDefaultPort
+	}
+
+	return &ClamAvScanProvider{
+		address: net.JoinHostPort(host, fmt.Sprintf("%d", port)),
+	}
+}
+
+// Scan streams the content to clamd with the INSTREAM command, see: https://docs.clamav.net/manual/Usage/Scanning.html#clamd
+func (p *ClamAvScanProvider) Scan(fileName string, content []byte) (*ScanResult, error) {
+	conn, err := net.DialTimeout("tcp", p.address, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(scanTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Write([]byte("zINSTREAM\x00"))
+	if err != nil {
+		return nil, err
+	}
+
+	for start := 0; start < len(content); start += clamAvChunkSize {
+		end := start + clamAvChunkSize
+		if end > len(content) {
+			end = len(content)
+		}
+
+		size := make([]byte, 4)
+		binary.BigEndian.PutUint32(size, uint32(end-start))
+		if _, err = conn.Write(size); err != nil {
+			return nil, err
+		}
+		if _, err = conn.Write(content[start:end]); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = conn.Write([]byte{0, 0, 0, 0})
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && reply == "" {
+		return nil, err
+	}
+
+	return parseClamAvReply(reply)
+}
+
+// parseClamAvReply parses replies like "stream: OK" or "stream: Eicar-Signature FOUND"
+func parseClamAvReply(reply string) (*ScanResult, error) {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream:")
+	reply = strings.TrimSpace(reply)
+
+	if reply == "OK" {
+		return &ScanResult{IsInfected: false}, nil
+	}
+
+	if strings.HasSuffix(reply, "FOUND") {
+		signature := strings.TrimSpace(strings.TrimSuffix(reply, "FOUND"))
+		return &ScanResult{IsInfected: true, Signature: signature}, nil
+	}
+
+	return nil, fmt.Errorf("ClamAV scan error: %s", reply)
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const icapDefaultPort = 1344
+
+type IcapScanProvider struct {
+	host    string
+	address string
+	service string
+}
+
+func NewIcapScanProvider(host string, port int, service string) *IcapScanProvider {
+	if port == 0 {
+		port = icapDefaultPort
+	}
+	if service == "" {
+		service = "avscan"
+	}
+
+	return &IcapScanProvider{
+		host:    host,
+		address: net.JoinHostPort(host, fmt.Sprintf("%d", port)),
+		service: strings.TrimPrefix(service, "/"),
+	}
+}
+
+// Scan sends the content to the ICAP server as a RESPMOD request, see: https://www.rfc-editor.org/rfc/rfc3507
+func (p *IcapScanProvider) Scan(fileName string, content []byte) (*ScanResult, error) {
+	conn, err := net.DialTimeout("tcp", p.address, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(scanTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Write(p.getRespModRequest(fileName, content))
+	if err != nil {
+		return nil, err
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(conn))
+	statusLine, err := reader.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := reader.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return parseIcapResponse(statusLine, header)
+}
+
+func (p *IcapScanProvider) getRespModRequest(fileName string, content []byte) []byte {
+	httpHeader := fmt.Sprintf("HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\nContent-Disposition: attachment; filename=%s\r\nContent-Length: %d\r\n\r\n",
+		strconv.Quote(fileName), len(content))
+
+	buffer := bytes.NewBuffer(nil)
+	buffer.WriteString(fmt.Sprintf("RESPMOD icap://%s/%s ICAP/1.0\r\n", p.address, p.service))
+	buffer.WriteString(fmt.Sprintf("Host: %s\r\n", p.host))
+	buffer.WriteString("Allow: 204\r\n")
+	buffer.WriteString("Connection: close\r\n")
+	buffer.WriteString(fmt.Sprintf("Encapsulated: res-hdr=0, res-body=%d\r\n", len(httpHeader)))
+	buffer.WriteString("\r\n")
+	buffer.WriteString(httpHeader)
+	if len(content) > 0 {
+		buffer.WriteString(fmt.Sprintf("%x\r\n", len(content)))
+		buffer.Write(content)
+		buffer.WriteString("\r\n")
+	}
+	buffer.WriteString("0\r\n\r\n")
+	return buffer.Bytes()
+}
+
+func parseIcapResponse(statusLine string, header textproto.MIMEHeader) (*ScanResult, error) {
+	tokens := strings.SplitN(statusLine, " ", 3)
+	if len(tokens) < 2 || !strings.HasPrefix(tokens[0], "ICAP/") {
+		return nil, fmt.Errorf("invalid ICAP response: %s", statusLine)
+	}
+
+	signature := header.Get("X-Virus-ID")
+	if signature == "" {
+		signature = header.Get("X-Infection-Found")
+	}
+
+	switch tokens[1] {
+	case "204":
+		return &ScanResult{IsInfected: false}, nil
+	case "200", "403":
+		// the server blocked or replaced the content
+		if signature == "" {
+			signature = "unknown"
+		}
+		return &ScanResult{IsInfected: true, Signature: signature}, nil
+	}
+
+	return nil, fmt.Errorf("ICAP scan error: %s", statusLine)
+}
@@ -0,0 +1,46 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scan
+
+import "fmt"
+
+type ScanResult struct {
+	IsInfected bool
+	Signature  string
+}
+
+type ScanProvider interface {
+	Scan(fileName string, content []byte) (*ScanResult, error)
+}
+
+func GetScanProvider(providerType string, host string, port int, path string) ScanProvider {
+	switch providerType {
+	case "ClamAV":
+		return NewClamAvScanProvider(host, port)
+	case "ICAP":
+		return NewIcapScanProvider(host, port, path)
+	}
+
+	return nil
+}
+
+func ScanFileByProviderType(providerType string, host string, port int, path string, fileName string, content []byte) (*ScanResult, error) {
+	provider := GetScanProvider(providerType, host, port, path)
+	if provider == nil {
+		return nil, fmt.Errorf("invalid scan provider: %s", providerType)
+	}
+
+	return provider.Scan(fileName, content)
+}
@@ -163,7 +163,7 @@ func user2resource(user *object.User) *scim.Resource {
 	attrs["nickName"] = user.DisplayName
 	attrs["userType"] = user.Type
 	attrs["profileUrl"] = user.Homepage
-	attrs["active"] = !user.IsForbidden && !user.IsDeleted
+	attrs["active"] = !user.IsForbidden && !user.IsDeleted && !user.IsSuspensionActive()
 
 	// Multi-Valued attributes
 	attrs["emails"] = []scim.ResourceAttributes{
@@ -0,0 +1,102 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+type jsonFieldTree map[string]jsonFieldTree
+
+// ParseJsonFields parses the comma separated fields, a nested field is selected by the dotted path like "properties.team"
+func ParseJsonFields(fields string) []string {
+	res := []string{}
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if field != "" {
+			res = append(res, field)
+		}
+	}
+	return res
+}
+
+func newJsonFieldTree(fields []string) jsonFieldTree {
+	res := jsonFieldTree{}
+	for _, field := range fields {
+		tree := res
+		for _, key := range strings.Split(field, ".") {
+			subTree, ok := tree[key]
+			if !ok {
+				subTree = jsonFieldTree{}
+				tree[key] = subTree
+			}
+			tree = subTree
+		}
+	}
+	return res
+}
+
+func selectJsonFields(value interface{}, tree jsonFieldTree) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		res := map[string]interface{}{}
+		for key, subTree := range tree {
+			subValue, ok := v[key]
+			if !ok {
+				continue
+			}
+
+			if len(subTree) == 0 {
+				res[key] = subValue
+			} else {
+				res[key] = selectJsonFields(subValue, subTree)
+			}
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, item := range v {
+			res[i] = selectJsonFields(item, tree)
+		}
+		return res
+	default:
+		return value
+	}
+}
+
+// SelectJsonFields returns the JSON value of the data with only the fields, the fields are selected for each element
+// if the data is an array, the data is returned as is if there is no field
+func SelectJsonFields(data interface{}, fields []string) (interface{}, error) {
+	if len(fields) == 0 {
+		return data, nil
+	}
+
+	bs, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(bs))
+	decoder.UseNumber()
+	err = decoder.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+
+	return selectJsonFields(value, newJsonFieldTree(fields)), nil
+}
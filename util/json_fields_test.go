@@ -0,0 +1,60 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectJsonFields(t *testing.T) {
+	type testUser struct {
+		Name       string            `json:"name"`
+		Avatar     string            `json:"avatar"`
+		Score      int64             `json:"score"`
+		Properties map[string]string `json:"properties"`
+	}
+
+	users := []*testUser{
+		{Name: "alice", Avatar: "https://cdn.example.com/alice.png", Score: 9007199254740993, Properties: map[string]string{"team": "rd", "site": "cn"}},
+		{Name: "bob", Avatar: "https://cdn.example.com/bob.png", Properties: map[string]string{}},
+	}
+
+	scenarios := []struct {
+		description string
+		data        interface{}
+		fields      string
+		expected    string
+	}{
+		{"no field", users[1], "", `{"name":"bob","avatar":"https://cdn.example.com/bob.png","score":0,"properties":{}}`},
+		{"array", users, "name, score", `[{"name":"alice","score":9007199254740993},{"name":"bob","score":0}]`},
+		{"nested field", users[0], "name,properties.team", `{"name":"alice","properties":{"team":"rd"}}`},
+		{"missing field", users[1], "name,properties.team,title", `{"name":"bob","properties":{}}`},
+		{"null", nil, "name", `null`},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			res, err := SelectJsonFields(scenario.data, ParseJsonFields(scenario.fields))
+			assert.Nil(t, err)
+
+			data, err := json.Marshal(res)
+			assert.Nil(t, err)
+			assert.JSONEq(t, scenario.expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,311 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// JsonPatchOperation is an operation of a JSON Patch document (RFC 6902)
+type JsonPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+func decodeJson(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var v interface{}
+	err := decoder.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// parseJsonPointer splits a JSON Pointer (RFC 6901) into the reference tokens
+func parseJsonPointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer: %s", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// GetJsonPatchFields returns the top-level fields of the document that the operations change
+func GetJsonPatchFields(operations []*JsonPatchOperation) ([]string, error) {
+	fields := []string{}
+	for _, operation := range operations {
+		pointers := []string{operation.Path}
+		if operation.Op == "move" {
+			pointers = append(pointers, operation.From)
+		} else if operation.Op == "test" {
+			continue
+		}
+
+		for _, pointer := range pointers {
+			tokens, err := parseJsonPointer(pointer)
+			if err != nil {
+				return nil, err
+			}
+			if len(tokens) == 0 {
+				return nil, fmt.Errorf("the whole document can't be patched")
+			}
+
+			if !InSlice(fields, tokens[0]) {
+				fields = append(fields, tokens[0])
+			}
+		}
+	}
+	return fields, nil
+}
+
+func getArrayIndex(token string, length int, isAppendAllowed bool) (int, error) {
+	if token == "-" && isAppendAllowed {
+		return length, nil
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil || index < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("invalid array index: %s", token)
+	}
+
+	limit := length - 1
+	if isAppendAllowed {
+		limit = length
+	}
+	if index > limit {
+		return 0, fmt.Errorf("array index out of range: %s", token)
+	}
+	return index, nil
+}
+
+func getJsonValue(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("the member: %s doesn't exist", token)
+			}
+			node = child
+		case []interface{}:
+			index, err := getArrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[index]
+		default:
+			return nil, fmt.Errorf("the value at: %s is not a container", token)
+		}
+	}
+	return node, nil
+}
+
+// patchJsonValue applies the operation to the parent of the last token, and returns the patched node
+func patchJsonValue(node interface{}, tokens []string, op func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return op(node, tokens[0])
+	}
+
+	child, err := getJsonValue(node, tokens[:1])
+	if err != nil {
+		return nil, err
+	}
+
+	child, err = patchJsonValue(child, tokens[1:], op)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		n[tokens[0]] = child
+	case []interface{}:
+		index, _ := getArrayIndex(tokens[0], len(n), false)
+		n[index] = child
+	}
+	return node, nil
+}
+
+func addJsonValue(doc interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+
+	return patchJsonValue(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			n[token] = value
+			return n, nil
+		case []interface{}:
+			index, err := getArrayIndex(token, len(n), true)
+			if err != nil {
+				return nil, err
+			}
+
+			n = append(n, nil)
+			copy(n[index+1:], n[index:])
+			n[index] = value
+			return n, nil
+		default:
+			return nil, fmt.Errorf("the parent of: %s is not a container", token)
+		}
+	})
+}
+
+func removeJsonValue(doc interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("the whole document can't be removed")
+	}
+
+	return patchJsonValue(doc, tokens, func(parent interface{}, token string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := n[token]; !ok {
+				return nil, fmt.Errorf("the member: %s doesn't exist", token)
+			}
+
+			delete(n, token)
+			return n, nil
+		case []interface{}:
+			index, err := getArrayIndex(token, len(n), false)
+			if err != nil {
+				return nil, err
+			}
+
+			return append(n[:index], n[index+1:]...), nil
+		default:
+			return nil, fmt.Errorf("the parent of: %s is not a container", token)
+		}
+	})
+}
+
+func applyJsonPatchOperation(doc interface{}, operation *JsonPatchOperation) (interface{}, error) {
+	tokens, err := parseJsonPointer(operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if operation.Op == "add" || operation.Op == "replace" || operation.Op == "test" {
+		if len(operation.Value) == 0 {
+			return nil, fmt.Errorf("the value of the operation: %s is missing", operation.Op)
+		}
+
+		value, err = decodeJson(operation.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch operation.Op {
+	case "add":
+		return addJsonValue(doc, tokens, value)
+	case "remove":
+		return removeJsonValue(doc, tokens)
+	case "replace":
+		if len(tokens) == 0 {
+			return value, nil
+		}
+
+		doc, err = removeJsonValue(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+		return addJsonValue(doc, tokens, value)
+	case "move", "copy":
+		fromTokens, err := parseJsonPointer(operation.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err = getJsonValue(doc, fromTokens)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation.Op == "move" {
+			if operation.Path == operation.From {
+				return doc, nil
+			}
+			if strings.HasPrefix(operation.Path, operation.From+"/") {
+				return nil, fmt.Errorf("the value at: %s can't be moved into itself", operation.From)
+			}
+
+			doc, err = removeJsonValue(doc, fromTokens)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			// deep copy the value so that the copies don't share the containers
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+
+			value, err = decodeJson(data)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return addJsonValue(doc, tokens, value)
+	case "test":
+		current, err := getJsonValue(doc, tokens)
+		if err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("the test of: %s failed", operation.Path)
+		}
+		return doc, nil
+	default:
+		return nil, fmt.Errorf("unknown JSON patch operation: %s", operation.Op)
+	}
+}
+
+// ApplyJsonPatch applies the JSON Patch operations to the JSON document, the document is unchanged if any operation fails
+func ApplyJsonPatch(data []byte, operations []*JsonPatchOperation) ([]byte, error) {
+	doc, err := decodeJson(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, operation := range operations {
+		doc, err = applyJsonPatchOperation(doc, operation)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(doc)
+}
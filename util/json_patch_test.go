@@ -0,0 +1,78 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyJsonPatch(t *testing.T) {
+	doc := `{"displayName":"Alice","tags":["a","b"],"properties":{"team":"rd"},"score":1}`
+
+	scenarios := []struct {
+		description string
+		patch       string
+		expected    string
+		isError     bool
+	}{
+		{"replace", `[{"op":"replace","path":"/displayName","value":"Bob"}]`, `{"displayName":"Bob","tags":["a","b"],"properties":{"team":"rd"},"score":1}`, false},
+		{"add member", `[{"op":"add","path":"/properties/site","value":"cn"}]`, `{"displayName":"Alice","tags":["a","b"],"properties":{"team":"rd","site":"cn"},"score":1}`, false},
+		{"add array element", `[{"op":"add","path":"/tags/1","value":"c"},{"op":"add","path":"/tags/-","value":"d"}]`, `{"displayName":"Alice","tags":["a","c","b","d"],"properties":{"team":"rd"},"score":1}`, false},
+		{"remove", `[{"op":"remove","path":"/tags/0"},{"op":"remove","path":"/properties/team"}]`, `{"displayName":"Alice","tags":["b"],"properties":{},"score":1}`, false},
+		{"move and copy", `[{"op":"copy","from":"/properties/team","path":"/title"},{"op":"move","from":"/score","path":"/karma"}]`, `{"displayName":"Alice","tags":["a","b"],"properties":{"team":"rd"},"title":"rd","karma":1}`, false},
+		{"test passed", `[{"op":"test","path":"/score","value":1},{"op":"replace","path":"/score","value":2}]`, `{"displayName":"Alice","tags":["a","b"],"properties":{"team":"rd"},"score":2}`, false},
+		{"test failed", `[{"op":"test","path":"/displayName","value":"Bob"}]`, "", true},
+		{"remove missing member", `[{"op":"remove","path":"/title"}]`, "", true},
+		{"index out of range", `[{"op":"replace","path":"/tags/2","value":"c"}]`, "", true},
+		{"missing value", `[{"op":"add","path":"/title"}]`, "", true},
+		{"unknown operation", `[{"op":"merge","path":"/title","value":"x"}]`, "", true},
+	}
+
+	for _, scenery := range scenarios {
+		t.Run(scenery.description, func(t *testing.T) {
+			var operations []*JsonPatchOperation
+			err := json.Unmarshal([]byte(scenery.patch), &operations)
+			assert.Nil(t, err)
+
+			res, err := ApplyJsonPatch([]byte(doc), operations)
+			if scenery.isError {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.JSONEq(t, scenery.expected, string(res))
+		})
+	}
+}
+
+func TestGetJsonPatchFields(t *testing.T) {
+	operations := []*JsonPatchOperation{
+		{Op: "replace", Path: "/displayName"},
+		{Op: "add", Path: "/properties/a~1b"},
+		{Op: "test", Path: "/score"},
+		{Op: "move", From: "/title", Path: "/displayName"},
+	}
+
+	fields, err := GetJsonPatchFields(operations)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"displayName", "properties", "title"}, fields)
+
+	_, err = GetJsonPatchFields([]*JsonPatchOperation{{Op: "replace", Path: ""}})
+	assert.NotNil(t, err)
+}
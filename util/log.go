@@ -16,11 +16,13 @@ package util
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
 	"github.com/beego/beego/context"
 	"github.com/beego/beego/logs"
+	"github.com/casdoor/casdoor/conf"
 )
 
 func GetIPInfo(clientIP string) string {
@@ -61,6 +63,74 @@ func GetIPFromRequest(req *http.Request) string {
 	return GetIPInfo(clientIP)
 }
 
+func getRemoteIp(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+func isTrustedProxy(ip string, trustedProxies []string) bool {
+	parsedIp := net.ParseIP(ip)
+	if parsedIp == nil {
+		return false
+	}
+
+	for _, trustedProxy := range trustedProxies {
+		trustedProxy = strings.TrimSpace(trustedProxy)
+		if strings.Contains(trustedProxy, "/") {
+			_, ipNet, err := net.ParseCIDR(trustedProxy)
+			if err == nil && ipNet.Contains(parsedIp) {
+				return true
+			}
+		} else if parsedIp.Equal(net.ParseIP(trustedProxy)) {
+			return true
+		}
+	}
+	return false
+}
+
+// getClientIp walks the X-Forwarded-For chain from the right only while the hop is a trusted proxy, the entries
+// left of the first untrusted hop are set by the client itself and can be forged at will
+func getClientIp(req *http.Request, trustedProxies []string) string {
+	clientIp := getRemoteIp(req)
+
+	forwardedIps := []string{}
+	for _, value := range req.Header.Values("X-Forwarded-For") {
+		forwardedIps = append(forwardedIps, strings.Split(value, ",")...)
+	}
+
+	for i := len(forwardedIps) - 1; i >= 0 && isTrustedProxy(clientIp, trustedProxies); i-- {
+		forwardedIp := strings.TrimSpace(forwardedIps[i])
+		if net.ParseIP(forwardedIp) == nil {
+			break
+		}
+		clientIp = forwardedIp
+	}
+	return clientIp
+}
+
+func getTrustedProxies() []string {
+	trustedProxies := []string{}
+	if value := conf.GetConfigString("trustedProxies"); value != "" {
+		trustedProxies = strings.Split(value, ",")
+	}
+	return trustedProxies
+}
+
+// GetClientIpFromRequest returns the IP of the client connected to the first trusted proxy configured by
+// "trustedProxies", or the remote address if the request doesn't come from a trusted proxy
+func GetClientIpFromRequest(req *http.Request) string {
+	return getClientIp(req, getTrustedProxies())
+}
+
+// IsFromTrustedProxy checks whether the request is sent by one of the proxies configured by "trustedProxies",
+// only such requests can carry the headers set by the proxies, the clients can set any header themselves
+func IsFromTrustedProxy(req *http.Request) bool {
+	return isTrustedProxy(getRemoteIp(req), getTrustedProxies())
+}
+
 func LogInfo(ctx *context.Context, f string, v ...interface{}) {
 	ipString := fmt.Sprintf("(%s) ", GetIPFromRequest(ctx.Request))
 	logs.Info(ipString+f, v...)
@@ -0,0 +1,68 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetClientIp(t *testing.T) {
+	trustedProxies := []string{"10.0.0.0/8", "192.168.1.1"}
+
+	scenarios := []struct {
+		description   string
+		remoteAddr    string
+		forwardedFor  string
+		expectedValue string
+	}{
+		{"no proxy", "1.2.3.4:5678", "", "1.2.3.4"},
+		{"forged header from an untrusted client", "1.2.3.4:5678", "5.6.7.8", "1.2.3.4"},
+		{"trusted proxy", "10.0.0.1:5678", "1.2.3.4", "1.2.3.4"},
+		{"forged entry left of the proxy", "10.0.0.1:5678", "5.6.7.8, 1.2.3.4", "1.2.3.4"},
+		{"chain of trusted proxies", "192.168.1.1:5678", "5.6.7.8, 1.2.3.4, 10.1.1.1", "1.2.3.4"},
+		{"invalid entry", "10.0.0.1:5678", "1.2.3.4, unknown", "10.0.0.1"},
+		{"IPv6", "[::1]:5678", "1.2.3.4", "::1"},
+	}
+
+	for _, scenario := range scenarios {
+		t.Run(scenario.description, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = scenario.remoteAddr
+			if scenario.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", scenario.forwardedFor)
+			}
+			assert.Equal(t, scenario.expectedValue, getClientIp(req, trustedProxies))
+		})
+	}
+}
+
+func TestIsFromTrustedProxy(t *testing.T) {
+	os.Setenv("trustedProxies", "10.0.0.0/8,192.168.1.1")
+	defer os.Unsetenv("trustedProxies")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.1.2.3:1234"
+	assert.True(t, IsFromTrustedProxy(req))
+
+	req.RemoteAddr = "192.168.1.1:1234"
+	assert.True(t, IsFromTrustedProxy(req))
+
+	req.RemoteAddr = "203.0.113.1:1234"
+	assert.False(t, IsFromTrustedProxy(req))
+}
@@ -76,3 +76,13 @@ func HaveIntersection(arr1 []string, arr2 []string) bool {
 
 	return false
 }
+
+func UnionStrings(arr1 []string, arr2 []string) []string {
+	res := []string{}
+	for _, str := range append(append([]string{}, arr1...), arr2...) {
+		if !InSlice(res, str) {
+			res = append(res, str)
+		}
+	}
+	return res
+}
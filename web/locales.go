@@ -0,0 +1,96 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+//go:embed src/locales/*/data.json
+var f embed.FS
+
+const defaultLanguage = "en"
+
+var (
+	localeMap   = map[string]map[string]map[string]string{} // for example : localeMap[en][login][Sign In] = Sign In
+	localeMutex sync.Mutex
+)
+
+// GetLanguage returns the supported frontend language of the tag like "zh-CN" or "en_US", or English if not supported
+func GetLanguage(tag string) string {
+	language := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(language, "-_"); i != -1 {
+		language = language[:i]
+	}
+
+	if _, err := f.Open(fmt.Sprintf("src/locales/%s/data.json", language)); language == "" || err != nil {
+		return defaultLanguage
+	}
+	return language
+}
+
+func getLocale(language string) (map[string]map[string]string, error) {
+	localeMutex.Lock()
+	defer localeMutex.Unlock()
+
+	if localeMap[language] == nil {
+		file, err := f.ReadFile(fmt.Sprintf("src/locales/%s/data.json", language))
+		if err != nil {
+			return nil, err
+		}
+
+		data := map[string]map[string]string{}
+		err = json.Unmarshal(file, &data)
+		if err != nil {
+			return nil, err
+		}
+		localeMap[language] = data
+	}
+	return localeMap[language], nil
+}
+
+// GetLocale returns the frontend strings of the namespaces in the language, the strings not translated yet are
+// returned in English
+func GetLocale(language string, namespaces []string) (map[string]map[string]string, error) {
+	language = GetLanguage(language)
+
+	enData, err := getLocale(defaultLanguage)
+	if err != nil {
+		return nil, err
+	}
+	data, err := getLocale(language)
+	if err != nil {
+		return nil, err
+	}
+
+	res := map[string]map[string]string{}
+	for _, namespace := range namespaces {
+		pairs := map[string]string{}
+		for key, value := range enData[namespace] {
+			pairs[key] = value
+		}
+		for key, value := range data[namespace] {
+			if value != "" {
+				pairs[key] = value
+			}
+		}
+		res[namespace] = pairs
+	}
+	return res, nil
+}
@@ -77,6 +77,7 @@ import * as Conf from "./Conf";
 import * as Auth from "./auth/Auth";
 import EntryPage from "./EntryPage";
 import * as AuthBackend from "./auth/AuthBackend";
+import * as TranslationBackend from "./backend/TranslationBackend";
 import AuthCallback from "./auth/AuthCallback";
 import OdicDiscoveryPage from "./auth/OidcDiscoveryPage";
 import SamlCallback from "./auth/SamlCallback";
@@ -219,6 +220,19 @@ class App extends Component {
     }
   }
 
+  loadTranslations(organization) {
+    const language = Setting.getLanguage();
+    TranslationBackend.exportTranslations(organization, "frontend", language)
+      .then((res) => {
+        if (res.status === "ok") {
+          Object.entries(res.data.data ?? {}).forEach(([namespace, pairs]) => {
+            i18next.addResourceBundle(language, namespace, pairs, true, true);
+          });
+          this.forceUpdate();
+        }
+      });
+  }
+
   setTheme = (theme, initThemeAlgorithm) => {
     this.setState({
       themeData: theme,
@@ -258,6 +272,7 @@ class App extends Component {
           account.organization = res.data2;
 
           this.setLanguage(account);
+          this.loadTranslations(account.owner);
           this.setTheme(Setting.getThemeData(account.organization), Conf.InitThemeAlgorithm);
         } else {
           if (res.data !== "Please login first") {
@@ -707,6 +722,7 @@ class App extends Component {
     return window.location.pathname.startsWith("/signup") ||
         window.location.pathname.startsWith("/login") ||
         window.location.pathname.startsWith("/forget") ||
+        window.location.pathname.startsWith("/activate") ||
         window.location.pathname.startsWith("/prompt") ||
         window.location.pathname.startsWith("/result") ||
         window.location.pathname.startsWith("/cas") ||
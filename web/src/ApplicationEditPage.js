@@ -13,7 +13,7 @@
 // limitations under the License.
 
 import React from "react";
-import {Button, Card, Col, ConfigProvider, Input, List, Popover, Radio, Result, Row, Select, Space, Switch, Upload} from "antd";
+import {Button, Card, Col, ConfigProvider, Input, InputNumber, List, Popover, Radio, Result, Row, Select, Space, Switch, Upload} from "antd";
 import {CopyOutlined, LinkOutlined, UploadOutlined} from "@ant-design/icons";
 import * as ApplicationBackend from "./backend/ApplicationBackend";
 import * as CertBackend from "./backend/CertBackend";
@@ -29,6 +29,9 @@ import UrlTable from "./table/UrlTable";
 import ProviderTable from "./table/ProviderTable";
 import SignupTable from "./table/SignupTable";
 import SamlAttributeTable from "./table/SamlAttributeTable";
+import DelegationRuleTable from "./table/DelegationRuleTable";
+import AudienceRuleTable from "./table/AudienceRuleTable";
+import LegalDocumentTable from "./table/LegalDocumentTable";
 import PromptPage from "./auth/PromptPage";
 import copy from "copy-to-clipboard";
 import ThemeEditor from "./common/theme/ThemeEditor";
@@ -103,6 +106,7 @@ class ApplicationEditPage extends React.Component {
       organizations: [],
       certs: [],
       providers: [],
+      applications: [],
       uploading: false,
       mode: props.location.mode !== undefined ? props.location.mode : "edit",
       samlAttributes: [],
@@ -115,6 +119,7 @@ class ApplicationEditPage extends React.Component {
     this.getApplication();
     this.getOrganizations();
     this.getProviders();
+    this.getApplications();
     this.getSamlMetadata();
   }
 
@@ -189,6 +194,19 @@ class ApplicationEditPage extends React.Component {
       });
   }
 
+  getApplications() {
+    ApplicationBackend.getApplications("admin")
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            applications: res.data,
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
   getSamlMetadata() {
     ApplicationBackend.getSamlMetadata("admin", this.state.applicationName)
       .then((data) => {
@@ -214,6 +232,24 @@ class ApplicationEditPage extends React.Component {
     });
   }
 
+  updateSessionPolicyField(key, value) {
+    const sessionPolicy = {...(this.state.application.sessionPolicy ?? {})};
+    sessionPolicy[key] = value ?? 0;
+    this.updateApplicationField("sessionPolicy", sessionPolicy);
+  }
+
+  updateResourcePolicyField(key, value) {
+    const resourcePolicy = {...(this.state.application.resourcePolicy ?? {})};
+    resourcePolicy[key] = value;
+    this.updateApplicationField("resourcePolicy", resourcePolicy);
+  }
+
+  updateBotDetectionField(key, value) {
+    const botDetection = {...(this.state.application.botDetection ?? {})};
+    botDetection[key] = value;
+    this.updateApplicationField("botDetection", botDetection);
+  }
+
   handleUpload(info) {
     if (info.file.type !== "text/html") {
       Setting.showMessage("error", i18next.t("application:Please select a HTML file"));
@@ -409,6 +445,102 @@ class ApplicationEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:Session idle timeout"), i18next.t("application:Session idle timeout - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber style={{width: "150px"}} min={0} value={this.state.application.sessionPolicy?.idleTimeout ?? 0} addonAfter="Minutes" onChange={value => {
+              this.updateSessionPolicyField("idleTimeout", value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:Session lifetime"), i18next.t("application:Session lifetime - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber style={{width: "150px"}} min={0} value={this.state.application.sessionPolicy?.absoluteLifetime ?? 0} addonAfter="Minutes" onChange={value => {
+              this.updateSessionPolicyField("absoluteLifetime", value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:Max concurrent sessions"), i18next.t("application:Max concurrent sessions - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber style={{width: "150px"}} min={0} value={this.state.application.sessionPolicy?.maxConcurrentSessions ?? 0} onChange={value => {
+              this.updateSessionPolicyField("maxConcurrentSessions", value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:Storage quota"), i18next.t("application:Storage quota - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <InputNumber style={{width: "150px"}} min={0} value={this.state.application.resourcePolicy?.storageQuota ?? 0} addonAfter="MB" onChange={value => {
+              this.updateResourcePolicyField("storageQuota", value ?? 0);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:Allowed content types"), i18next.t("application:Allowed content types - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} mode="tags" style={{width: "100%"}} value={this.state.application.resourcePolicy?.allowedContentTypes ?? []} onChange={value => {
+              this.updateResourcePolicyField("allowedContentTypes", value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:Enable bot detection"), i18next.t("application:Enable bot detection - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.botDetection?.isEnabled ?? false} onChange={checked => {
+              this.updateBotDetectionField("isEnabled", checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.application.botDetection?.isEnabled ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("application:Bot block score"), i18next.t("application:Bot block score - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <InputNumber style={{width: "150px"}} min={0} max={100} value={this.state.application.botDetection?.blockScore ?? 0} onChange={value => {
+                    this.updateBotDetectionField("blockScore", value ?? 0);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("application:Bot captcha score"), i18next.t("application:Bot captcha score - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <InputNumber style={{width: "150px"}} min={0} max={100} value={this.state.application.botDetection?.captchaScore ?? 0} onChange={value => {
+                    this.updateBotDetectionField("captchaScore", value ?? 0);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("application:Max attempts per minute"), i18next.t("application:Max attempts per minute - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <InputNumber style={{width: "150px"}} min={0} value={this.state.application.botDetection?.maxAttemptsPerMinute ?? 0} onChange={value => {
+                    this.updateBotDetectionField("maxAttemptsPerMinute", value ?? 0);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
             {Setting.getLabel(i18next.t("application:Enable password"), i18next.t("application:Enable password - Tooltip"))} :
@@ -615,11 +747,70 @@ class ApplicationEditPage extends React.Component {
                   {id: "token", name: "Token"},
                   {id: "id_token", name: "ID Token"},
                   {id: "refresh_token", name: "Refresh Token"},
+                  {id: "urn:ietf:params:oauth:grant-type:token-exchange", name: "Token Exchange"},
                 ].map((item, index) => <Option key={index} value={item.id}>{item.name}</Option>)
               }
             </Select>
           </Col>
         </Row>
+        {
+          !this.state.application.grantTypes?.includes("urn:ietf:params:oauth:grant-type:token-exchange") ? null : (
+            <Row style={{marginTop: "20px"}} >
+              <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("application:Delegation rules"), i18next.t("application:Delegation rules - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <DelegationRuleTable
+                  title={i18next.t("application:Delegation rules")}
+                  table={this.state.application.delegationRules}
+                  application={this.state.application}
+                  applications={this.state.applications}
+                  onUpdateTable={(value) => {this.updateApplicationField("delegationRules", value);}}
+                />
+              </Col>
+            </Row>
+          )
+        }
+        {
+          !this.state.application.grantTypes?.includes("client_credentials") ? null : (
+            <Row style={{marginTop: "20px"}} >
+              <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("application:Audience rules"), i18next.t("application:Audience rules - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <AudienceRuleTable
+                  title={i18next.t("application:Audience rules")}
+                  table={this.state.application.audienceRules}
+                  application={this.state.application}
+                  applications={this.state.applications}
+                  onUpdateTable={(value) => {this.updateApplicationField("audienceRules", value);}}
+                />
+              </Col>
+            </Row>
+          )
+        }
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("application:Legal documents"), i18next.t("application:Legal documents - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <LegalDocumentTable
+              title={i18next.t("application:Legal documents")}
+              table={this.state.application.legalDocuments}
+              onUpdateTable={(value) => {this.updateApplicationField("legalDocuments", value);}}
+            />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("application:Require identity verification"), i18next.t("application:Require identity verification - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.application.requireIdentityVerification} onChange={checked => {
+              this.updateApplicationField("requireIdentityVerification", checked);
+            }} />
+          </Col>
+        </Row>
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("application:SAML reply URL"), i18next.t("application:Redirect URL (Assertion Consumer Service POST Binding URL) - Tooltip"))} :
@@ -1033,7 +1224,7 @@ class ApplicationEditPage extends React.Component {
     ApplicationBackend.updateApplication("admin", this.state.applicationName, application)
       .then((res) => {
         if (res.status === "ok") {
-          Setting.showMessage("success", i18next.t("general:Successfully saved"));
+          Setting.showMessage("success", res.data === "Pending" ? i18next.t("general:Saved, the change is waiting for the approval of another admin") : i18next.t("general:Successfully saved"));
           this.setState({
             applicationName: this.state.application.name,
           });
@@ -279,7 +279,7 @@ class CertEditPage extends React.Component {
     CertBackend.updateCert(this.state.owner, this.state.certName, cert)
       .then((res) => {
         if (res.status === "ok") {
-          Setting.showMessage("success", i18next.t("general:Successfully saved"));
+          Setting.showMessage("success", res.data === "Pending" ? i18next.t("general:Saved, the change is waiting for the approval of another admin") : i18next.t("general:Successfully saved"));
           this.setState({
             certName: this.state.cert.name,
           });
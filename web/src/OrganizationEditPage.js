@@ -111,6 +111,12 @@ class OrganizationEditPage extends React.Component {
     });
   }
 
+  updateRecordRedactionField(key, value) {
+    const recordRedaction = {...(this.state.organization.recordRedaction ?? {})};
+    recordRedaction[key] = value;
+    this.updateOrganizationField("recordRedaction", recordRedaction);
+  }
+
   renderOrganization() {
     return (
       <Card size="small" title={
@@ -363,6 +369,72 @@ class OrganizationEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("organization:Change approval"), i18next.t("organization:Change approval - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.organization.enableChangeApproval} onChange={checked => {
+              this.updateOrganizationField("enableChangeApproval", checked);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("organization:LDAP base DN"), i18next.t("organization:LDAP base DN - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input value={this.state.organization.ldapBaseDn} placeholder="dc=example,dc=com" onChange={e => {
+              this.updateOrganizationField("ldapBaseDn", e.target.value);
+            }} />
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
+            {Setting.getLabel(i18next.t("organization:Redact records"), i18next.t("organization:Redact records - Tooltip"))} :
+          </Col>
+          <Col span={1} >
+            <Switch checked={this.state.organization.recordRedaction?.isEnabled ?? false} onChange={checked => {
+              this.updateRecordRedactionField("isEnabled", checked);
+            }} />
+          </Col>
+        </Row>
+        {
+          !this.state.organization.recordRedaction?.isEnabled ? null : (
+            <React.Fragment>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("organization:Redacted fields"), i18next.t("organization:Redacted fields - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Select virtual={false} mode="tags" style={{width: "100%"}} value={this.state.organization.recordRedaction?.redactedFields ?? []} onChange={value => {
+                    this.updateRecordRedactionField("redactedFields", value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
+                  {Setting.getLabel(i18next.t("organization:Mask emails"), i18next.t("organization:Mask emails - Tooltip"))} :
+                </Col>
+                <Col span={1} >
+                  <Switch checked={this.state.organization.recordRedaction?.maskEmails ?? false} onChange={checked => {
+                    this.updateRecordRedactionField("maskEmails", checked);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("organization:Dropped actions"), i18next.t("organization:Dropped actions - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Select virtual={false} mode="tags" style={{width: "100%"}} value={this.state.organization.recordRedaction?.droppedActions ?? []} onChange={value => {
+                    this.updateRecordRedactionField("droppedActions", value);
+                  }} />
+                </Col>
+              </Row>
+            </React.Fragment>
+          )
+        }
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("organization:Account items"), i18next.t("organization:Account items - Tooltip"))} :
@@ -438,7 +510,7 @@ class OrganizationEditPage extends React.Component {
     OrganizationBackend.updateOrganization(this.state.organization.owner, this.state.organizationName, organization)
       .then((res) => {
         if (res.status === "ok") {
-          Setting.showMessage("success", i18next.t("general:Successfully saved"));
+          Setting.showMessage("success", res.data === "Pending" ? i18next.t("general:Saved, the change is waiting for the approval of another admin") : i18next.t("general:Successfully saved"));
 
           if (this.props.account.organization.name === this.state.organizationName) {
             this.props.onChangeTheme(Setting.getThemeData(this.state.organization));
@@ -42,6 +42,7 @@ class ProviderEditPage extends React.Component {
       provider: null,
       certs: [],
       organizations: [],
+      catalogProviders: [],
       mode: props.location.mode !== undefined ? props.location.mode : "edit",
     };
   }
@@ -50,6 +51,7 @@ class ProviderEditPage extends React.Component {
     this.getOrganizations();
     this.getProvider();
     this.getCerts(this.state.owner);
+    this.getCatalogProviders(this.state.owner);
   }
 
   getProvider() {
@@ -83,6 +85,17 @@ class ProviderEditPage extends React.Component {
     }
   }
 
+  getCatalogProviders(owner) {
+    ProviderBackend.getCatalogProviders(owner)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            catalogProviders: res.data || [],
+          });
+        }
+      });
+  }
+
   getCerts(owner) {
     CertBackend.getCerts(owner)
       .then((res) => {
@@ -229,6 +242,8 @@ class ProviderEditPage extends React.Component {
       } else {
         return Setting.getLabel(i18next.t("provider:Secret key"), i18next.t("provider:Secret key - Tooltip"));
       }
+    case "Identity Verification":
+      return Setting.getLabel(i18next.t("provider:Api Key"), i18next.t("provider:Api Key - Tooltip"));
     case "Notification":
       if (provider.type === "Line" || provider.type === "Telegram" || provider.type === "Bark" || provider.type === "DingTalk" || provider.type === "Discord" || provider.type === "Slack" || provider.type === "Pushover" || provider.type === "Pushbullet") {
         return Setting.getLabel(i18next.t("provider:Secret key"), i18next.t("provider:Secret key - Tooltip"));
@@ -351,6 +366,9 @@ class ProviderEditPage extends React.Component {
         text = i18next.t("provider:App Key");
         tooltip = i18next.t("provider:App Key - Tooltip");
       }
+     else if (provider.category === "Identity Verification") {
+      text = i18next.t("provider:Template ID");
+      tooltip = i18next.t("provider:Template ID - Tooltip");
     }
 
     if (text === "" && tooltip === "") {
@@ -419,6 +437,20 @@ class ProviderEditPage extends React.Component {
     this.updateProviderField("issuerUrl", issuerUrl);
   }
 
+  refreshSamlMetadata() {
+    ProviderBackend.refreshSamlMetadata(this.state.provider.owner, this.state.provider.name)
+      .then((res) => {
+        if (res.status === "ok") {
+          this.setState({
+            provider: {...res.data, userMapping: res.data.userMapping || {}},
+          });
+          Setting.showMessage("success", i18next.t("provider:Parse metadata successfully"));
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
   renderProvider() {
     return (
       <Card size="small" title={
@@ -462,6 +494,41 @@ class ProviderEditPage extends React.Component {
             </Select>
           </Col>
         </Row>
+        {
+          this.state.provider.owner === "admin" ? (
+            <Row style={{marginTop: "20px"}} >
+              <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("provider:Share in catalog"), i18next.t("provider:Share in catalog - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <Switch checked={this.state.provider.isShared} onChange={checked => {
+                  this.updateProviderField("isShared", checked);
+                }} />
+              </Col>
+            </Row>
+          ) : (
+            <Row style={{marginTop: "20px"}} >
+              <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("provider:Catalog provider"), i18next.t("provider:Catalog provider - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <Select virtual={false} style={{width: "100%"}} value={this.state.provider.baseProvider} onChange={(value => {
+                  this.updateProviderField("baseProvider", value);
+                  const catalogProvider = this.state.catalogProviders.find(provider => provider.name === value);
+                  if (catalogProvider !== undefined) {
+                    this.updateProviderField("category", catalogProvider.category);
+                    this.updateProviderField("type", catalogProvider.type);
+                  }
+                })}>
+                  <Option key={""} value={""}>{i18next.t("general:None")}</Option>
+                  {
+                    this.state.catalogProviders.map((provider, index) => <Option key={index} value={provider.name}>{`${provider.displayName} (${provider.category}/${provider.type})`}</Option>)
+                  }
+                </Select>
+              </Col>
+            </Row>
+          )
+        }
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("provider:Category"), i18next.t("provider:Category - Tooltip"))} :
@@ -493,12 +560,15 @@ class ProviderEditPage extends React.Component {
                 this.updateProviderField("type", "MetaMask");
               } else if (value === "Notification") {
                 this.updateProviderField("type", "Telegram");
+              } else if (value === "Identity Verification") {
+                this.updateProviderField("type", "Persona");
               }
             })}>
               {
                 [
                   {id: "Captcha", name: "Captcha"},
                   {id: "Email", name: "Email"},
+                  {id: "Identity Verification", name: "Identity Verification"},
                   {id: "Notification", name: "Notification"},
                   {id: "OAuth", name: "OAuth"},
                   {id: "Payment", name: "Payment"},
@@ -527,6 +597,7 @@ class ProviderEditPage extends React.Component {
                 this.updateProviderField("scopes", "openid profile email");
                 this.updateProviderField("customTokenUrl", "https://door.casdoor.com/api/login/oauth/access_token");
                 this.updateProviderField("customUserInfoUrl", "https://door.casdoor.com/api/userinfo");
+                this.updateProviderField("method", "");
               } else if (value === "Custom HTTP SMS") {
                 this.updateProviderField("endpoint", "https://example.com/send-custom-http-sms");
                 this.updateProviderField("method", "GET");
@@ -616,6 +687,24 @@ class ProviderEditPage extends React.Component {
                         }} />
                       </Col>
                     </Row>
+                    <Row style={{marginTop: "20px"}} >
+                      <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                        {Setting.getLabel(i18next.t("provider:Token auth method"), i18next.t("provider:Token auth method - Tooltip"))}
+                      </Col>
+                      <Col span={22} >
+                        <Select virtual={false} style={{width: "100%"}} value={this.state.provider.method} onChange={value => {
+                          this.updateProviderField("method", value);
+                        }}>
+                          {
+                            [
+                              {id: "", name: i18next.t("provider:Auto detect")},
+                              {id: "client_secret_basic", name: "client_secret_basic"},
+                              {id: "client_secret_post", name: "client_secret_post"},
+                            ].map((method, index) => <Option key={index} value={method.id}>{method.name}</Option>)
+                          }
+                        </Select>
+                      </Col>
+                    </Row>
                     <Row style={{marginTop: "20px"}} >
                       <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
                         {Setting.getLabel(i18next.t("provider:Scope"), i18next.t("provider:Scope - Tooltip"))}
@@ -687,6 +776,7 @@ class ProviderEditPage extends React.Component {
                 {
                   (this.state.provider.category === "Storage" && this.state.provider.type === "Google Cloud Storage") ||
                   (this.state.provider.category === "Email" && this.state.provider.type === "Azure ACS") ||
+                  (this.state.provider.category === "Identity Verification") ||
                   (this.state.provider.category === "Notification" && (this.state.provider.type === "Line" || this.state.provider.type === "Telegram" || this.state.provider.type === "Bark" || this.state.provider.type === "Discord" || this.state.provider.type === "Slack" || this.state.provider.type === "Pushbullet" || this.state.provider.type === "Pushover" || this.state.provider.type === "Lark" || this.state.provider.type === "Microsoft Teams")) ? null : (
                       <Row style={{marginTop: "20px"}} >
                         <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
@@ -848,6 +938,40 @@ class ProviderEditPage extends React.Component {
           </div>
         ) : null}
         {this.getAppIdRow(this.state.provider)}
+        {
+          this.state.provider.category === "Identity Verification" ? (
+            <React.Fragment>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Endpoint"), i18next.t("provider:Endpoint - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.provider.endpoint} onChange={e => {
+                    this.updateProviderField("endpoint", e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Webhook secret"), i18next.t("provider:Webhook secret - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={this.state.provider.webhookSecret} onChange={e => {
+                    this.updateProviderField("webhookSecret", e.target.value);
+                  }} />
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Callback URL"), i18next.t("provider:Callback URL - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Input value={`${authConfig.serverUrl}/api/notify-identity-verification/${this.state.provider.owner}/${this.state.provider.name}`} readOnly="readonly" />
+                </Col>
+              </Row>
+            </React.Fragment>
+          ) : null
+        }
         {
           this.state.provider.category === "Notification" ? (
             <React.Fragment>
@@ -1150,6 +1274,48 @@ class ProviderEditPage extends React.Component {
                   }} />
                 </Col>
               </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Metadata URL"), i18next.t("provider:Metadata URL - Tooltip"))} :
+                </Col>
+                <Col span={20} >
+                  <Input value={this.state.provider.metadataUrl} onChange={e => {
+                    this.updateProviderField("metadataUrl", e.target.value);
+                  }} />
+                </Col>
+                <Col span={2} >
+                  <Button type="primary" disabled={this.state.mode === "add" || !this.state.provider.metadataUrl} onClick={() => this.refreshSamlMetadata()}>
+                    {i18next.t("provider:Refresh")}
+                  </Button>
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Refresh interval"), i18next.t("provider:Refresh interval - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <InputNumber min={0} value={this.state.provider.metadataRefreshInterval} addonAfter={i18next.t("provider:Hours")} onChange={value => {
+                    this.updateProviderField("metadataRefreshInterval", value);
+                  }} />
+                  {
+                    !this.state.provider.metadataRefreshTime ? null : (
+                      <span style={{marginLeft: "10px", color: this.state.provider.metadataError ? "red" : undefined}}>
+                        {`${Setting.getFormattedDate(this.state.provider.metadataRefreshTime)} ${this.state.provider.metadataError}`}
+                      </span>
+                    )
+                  }
+                </Col>
+              </Row>
+              <Row style={{marginTop: "20px"}} >
+                <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                  {Setting.getLabel(i18next.t("provider:Pinned certs"), i18next.t("provider:Pinned certs - Tooltip"))} :
+                </Col>
+                <Col span={22} >
+                  <Select virtual={false} mode="tags" style={{width: "100%"}} value={this.state.provider.pinnedCerts ?? []} onChange={value => {
+                    this.updateProviderField("pinnedCerts", value);
+                  }} />
+                </Col>
+              </Row>
               <Row style={{marginTop: "20px"}} >
                 <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
                   {Setting.getLabel(i18next.t("provider:SP ACS URL"), i18next.t("provider:SP ACS URL - Tooltip"))} :
@@ -1217,6 +1383,20 @@ class ProviderEditPage extends React.Component {
             </Row>
           ) : null
         }
+        {
+          this.state.provider.type === "Ethereum" ? (
+            <Row style={{marginTop: "20px"}} >
+              <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+                {Setting.getLabel(i18next.t("provider:RPC URL"), i18next.t("provider:RPC URL - Tooltip"))} :
+              </Col>
+              <Col span={22} >
+                <Input prefix={<LinkOutlined />} value={this.state.provider.endpoint} onChange={e => {
+                  this.updateProviderField("endpoint", e.target.value);
+                }} />
+              </Col>
+            </Row>
+          ) : null
+        }
         {
           this.state.provider.type === "Web3Onboard" ? (
             <Row style={{marginTop: "20px"}} >
@@ -1284,7 +1464,7 @@ class ProviderEditPage extends React.Component {
     ProviderBackend.updateProvider(this.state.owner, this.state.providerName, provider)
       .then((res) => {
         if (res.status === "ok") {
-          Setting.showMessage("success", i18next.t("general:Successfully saved"));
+          Setting.showMessage("success", res.data === "Pending" ? i18next.t("general:Saved, the change is waiting for the approval of another admin") : i18next.t("general:Successfully saved"));
           this.setState({
             owner: this.state.provider.owner,
             providerName: this.state.provider.name,
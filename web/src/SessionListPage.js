@@ -88,6 +88,20 @@ class SessionListPage extends BaseListPage {
           );
         },
       },
+      {
+        title: i18next.t("general:Devices"),
+        dataIndex: "devices",
+        key: "devices",
+        width: "240px",
+        render: (text, record, index) => {
+          return Object.entries(text ?? {}).map(([sessionId, device]) =>
+            <Tag key={sessionId} title={`${sessionId}, ${device.clientIp}`}>
+              {device.displayName !== "" ? device.displayName : `${device.browser} ${device.browserVersion} / ${device.os} ${device.osVersion}`}
+              {device.countryCode !== "" ? ` (${device.countryCode})` : ""}
+            </Tag>
+          );
+        },
+      },
       {
         title: i18next.t("general:Action"),
         dataIndex: "",
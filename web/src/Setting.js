@@ -289,6 +289,10 @@ export const OtherProviderInfo = {
       logo: `${StaticBaseUrl}/img/social_web3onboard.svg`,
       url: "https://onboard.blocknative.com/",
     },
+    "Ethereum": {
+      logo: `${StaticBaseUrl}/img/social_ethereum.svg`,
+      url: "https://login.xyz/",
+    },
   },
   Notification: {
     "Telegram": {
@@ -363,6 +367,15 @@ export const OtherProviderInfo = {
       logo: `${StaticBaseUrl}/img/social_viber.png`,
       url: "https://www.viber.com/",
     },
+  },  "Identity Verification": {
+    "Persona": {
+      logo: `${StaticBaseUrl}/img/social_persona.png`,
+      url: "https://withpersona.com/",
+    },
+    "Onfido": {
+      logo: `${StaticBaseUrl}/img/social_onfido.png`,
+      url: "https://onfido.com/",
+    },
   },
 };
 
@@ -1053,6 +1066,7 @@ export function getProviderTypeOptions(category) {
     return ([
       {id: "MetaMask", name: "MetaMask"},
       {id: "Web3Onboard", name: "Web3-Onboard"},
+      {id: "Ethereum", name: "Sign-In with Ethereum"},
     ]);
   } else if (category === "Notification") {
     return ([
@@ -1075,6 +1089,11 @@ export function getProviderTypeOptions(category) {
       {id: "Rocket Chat", name: "Rocket Chat"},
       {id: "Viber", name: "Viber"},
     ]);
+  } else if (category === "Identity Verification") {
+    return ([
+      {id: "Persona", name: "Persona"},
+      {id: "Onfido", name: "Onfido"},
+    ]);
   } else {
     return [];
   }
@@ -286,6 +286,8 @@ class SubscriptionEditPage extends React.Component {
               {value: "Expired", name: i18next.t("permission:Expired")},
               {value: "Error", name: i18next.t("permission:Error")},
               {value: "Suspended", name: i18next.t("permission:Suspended")},
+              {value: "PastDue", name: i18next.t("subscription:Past due")},
+              {value: "Canceled", name: i18next.t("subscription:Canceled")},
             ].map((item) => Setting.getOption(item.name, item.value))}
             />
           </Col>
@@ -212,6 +212,10 @@ class SubscriptionListPage extends BaseListPage {
             return Setting.getTag("error", i18next.t("permission:Error"), <CloseCircleOutlined />);
           case "Suspended":
             return Setting.getTag("default", i18next.t("permission:Suspended"), <MinusCircleOutlined />);
+          case "PastDue":
+            return Setting.getTag("error", i18next.t("subscription:Past due"), <ExclamationCircleOutlined />);
+          case "Canceled":
+            return Setting.getTag("default", i18next.t("subscription:Canceled"), <CloseCircleOutlined />);
           default:
             return null;
           }
@@ -13,7 +13,7 @@
 // limitations under the License.
 
 import React from "react";
-import {Button, Card, Col, Input, InputNumber, List, Result, Row, Select, Space, Spin, Switch, Tag} from "antd";
+import {Button, Card, Col, Input, InputNumber, List, Modal, Result, Row, Select, Space, Spin, Switch, Tag} from "antd";
 import {withRouter} from "react-router-dom";
 import {TotpMfaType} from "./auth/MfaSetupPage";
 import * as GroupBackend from "./backend/GroupBackend";
@@ -107,6 +107,25 @@ class UserEditPage extends React.Component {
       });
   }
 
+  issueBreakGlassKey() {
+    UserBackend.issueBreakGlassKey(this.state.user.owner, this.state.user.name)
+      .then((res) => {
+        if (res.status === "ok") {
+          Modal.info({
+            title: i18next.t("user:Break-glass key"),
+            content: (
+              <div>
+                <p>{i18next.t("user:The key is shown only once, please store it offline in a safe place")}</p>
+                <Input.TextArea value={res.data} readOnly autoSize />
+              </div>
+            ),
+          });
+        } else {
+          Setting.showMessage("error", res.msg);
+        }
+      });
+  }
+
   getOrganizations() {
     OrganizationBackend.getOrganizations("admin")
       .then((res) => {
@@ -385,11 +404,20 @@ class UserEditPage extends React.Component {
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("general:User type"), i18next.t("general:User type - Tooltip"))} :
           </Col>
-          <Col span={22} >
+          <Col span={this.state.user.type === "break-glass-user" && !this.isSelf() ? 18 : 22} >
             <Select virtual={false} style={{width: "100%"}} value={this.state.user.type} onChange={(value => {this.updateUserField("type", value);})}
-              options={["normal-user", "paid-user"].map(item => Setting.getOption(item, item))}
+              options={["normal-user", "paid-user", "break-glass-user"].map(item => Setting.getOption(item, item))}
             />
           </Col>
+          {
+            this.state.user.type === "break-glass-user" && !this.isSelf() ? (
+              <Col span={4} >
+                <Button style={{marginLeft: "10px"}} onClick={() => this.issueBreakGlassKey()}>
+                  {i18next.t("user:Issue break-glass key")}
+                </Button>
+              </Col>
+            ) : null
+          }
         </Row>
       );
     } else if (accountItem.name === "Password") {
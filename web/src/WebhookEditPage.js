@@ -17,6 +17,7 @@ import {Button, Card, Col, Input, Row, Select, Switch} from "antd";
 import {LinkOutlined} from "@ant-design/icons";
 import * as WebhookBackend from "./backend/WebhookBackend";
 import * as OrganizationBackend from "./backend/OrganizationBackend";
+import * as ApplicationBackend from "./backend/ApplicationBackend";
 import * as Setting from "./Setting";
 import i18next from "i18next";
 import WebhookHeaderTable from "./table/WebhookHeaderTable";
@@ -110,6 +111,7 @@ class WebhookEditPage extends React.Component {
       webhookName: props.match.params.webhookName,
       webhook: null,
       organizations: [],
+      applications: [],
       mode: props.location.mode !== undefined ? props.location.mode : "edit",
     };
   }
@@ -130,6 +132,38 @@ class WebhookEditPage extends React.Component {
         this.setState({
           webhook: res.data,
         });
+
+        this.getApplications(res.data.organization);
+      });
+  }
+
+  getApplications(organization) {
+    ApplicationBackend.getApplicationsByOrganization("admin", organization)
+      .then((res) => {
+        this.setState({
+          applications: res.data || [],
+        });
+      });
+  }
+
+  rotateWebhookSecret() {
+    WebhookBackend.rotateWebhookSecret(this.state.webhook.owner, this.state.webhookName)
+      .then((res) => {
+        if (res.status === "ok") {
+          const webhook = this.state.webhook;
+          webhook.signingSecret = res.data.signingSecret;
+          webhook.previousSigningSecret = res.data.previousSigningSecret;
+          webhook.secretRotatedTime = res.data.secretRotatedTime;
+          this.setState({
+            webhook: webhook,
+          });
+          Setting.showMessage("success", i18next.t("general:Successfully saved"));
+        } else {
+          Setting.showMessage("error", `${i18next.t("general:Failed to save")}: ${res.msg}`);
+        }
+      })
+      .catch(error => {
+        Setting.showMessage("error", `${i18next.t("general:Failed to connect to server")}: ${error}`);
       });
   }
 
@@ -191,7 +225,11 @@ class WebhookEditPage extends React.Component {
             {Setting.getLabel(i18next.t("general:Organization"), i18next.t("general:Organization - Tooltip"))} :
           </Col>
           <Col span={22} >
-            <Select virtual={false} style={{width: "100%"}} disabled={!Setting.isAdminUser(this.props.account)} value={this.state.webhook.organization} onChange={(value => {this.updateWebhookField("organization", value);})}>
+            <Select virtual={false} style={{width: "100%"}} disabled={!Setting.isAdminUser(this.props.account)} value={this.state.webhook.organization} onChange={(value => {
+              this.updateWebhookField("organization", value);
+              this.updateWebhookField("applications", []);
+              this.getApplications(value);
+            })}>
               {
                 this.state.organizations.map((organization, index) => <Option key={index} value={organization.name}>{organization.name}</Option>)
               }
@@ -284,6 +322,22 @@ class WebhookEditPage extends React.Component {
             </Select>
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("general:Applications"), i18next.t("webhook:Applications - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Select virtual={false} mode="multiple" style={{width: "100%"}}
+              value={this.state.webhook.applications ?? []}
+              onChange={value => {
+                this.updateWebhookField("applications", value);
+              }} >
+              {
+                this.state.applications.map((application, index) => <Option key={index} value={application.name}>{application.name}</Option>)
+              }
+            </Select>
+          </Col>
+        </Row>
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 19 : 2}>
             {Setting.getLabel(i18next.t("webhook:Is user extended"), i18next.t("webhook:Is user extended - Tooltip"))} :
@@ -294,6 +348,31 @@ class WebhookEditPage extends React.Component {
             }} />
           </Col>
         </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Payload template"), i18next.t("webhook:Payload template - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <div style={{width: "900px", height: "300px"}} >
+              <CodeMirror
+                value={this.state.webhook.payloadTemplate}
+                options={{mode: "javascript", theme: "material-darker"}}
+                onBeforeChange={(editor, data, value) => {
+                  this.updateWebhookField("payloadTemplate", value);
+                }}
+              />
+            </div>
+          </Col>
+        </Row>
+        <Row style={{marginTop: "20px"}} >
+          <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
+            {Setting.getLabel(i18next.t("webhook:Signing secret"), i18next.t("webhook:Signing secret - Tooltip"))} :
+          </Col>
+          <Col span={22} >
+            <Input.Password value={this.state.webhook.signingSecret} disabled={true} style={{width: "500px"}} />
+            <Button style={{marginLeft: "20px"}} onClick={() => this.rotateWebhookSecret()}>{i18next.t("webhook:Rotate secret")}</Button>
+          </Col>
+        </Row>
         <Row style={{marginTop: "20px"}} >
           <Col style={{marginTop: "5px"}} span={(Setting.isMobile()) ? 22 : 2}>
             {Setting.getLabel(i18next.t("general:Preview"), i18next.t("general:Preview - Tooltip"))} :
@@ -0,0 +1,169 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import React from "react";
+import {Button, Col, Form, Input, Row} from "antd";
+import {CheckCircleOutlined, LockOutlined} from "@ant-design/icons";
+import {withRouter} from "react-router-dom";
+import i18next from "i18next";
+import * as ApplicationBackend from "../backend/ApplicationBackend";
+import * as UserBackend from "../backend/UserBackend";
+import * as PasswordChecker from "../common/PasswordChecker";
+import * as Setting from "../Setting";
+import * as Util from "./Util";
+
+class ActivationPage extends React.Component {
+  constructor(props) {
+    super(props);
+    const params = new URLSearchParams(props.location.search);
+    this.state = {
+      applicationName: props.match.params?.applicationName,
+      userId: params.get("id") ?? "",
+      code: params.get("code") ?? "",
+      msg: null,
+    };
+  }
+
+  componentDidMount() {
+    if (this.getApplicationObj() === undefined) {
+      this.getApplication();
+    }
+  }
+
+  getApplication() {
+    ApplicationBackend.getApplication("admin", this.state.applicationName)
+      .then((res) => {
+        if (res.status === "error") {
+          this.props.onUpdateApplication(null);
+          this.setState({msg: res.msg});
+          return;
+        }
+        this.props.onUpdateApplication(res.data);
+      });
+  }
+
+  getApplicationObj() {
+    return this.props.application;
+  }
+
+  onFinish(values) {
+    const [userOwner, userName] = this.state.userId.split("/");
+    UserBackend.setPassword(userOwner, userName, "", values.newPassword, "", this.state.code).then(res => {
+      if (res.status === "ok") {
+        Setting.showMessage("success", i18next.t("user:Your account has been activated"));
+        Setting.redirectToLoginPage(this.getApplicationObj(), this.props.history);
+      } else {
+        Setting.showMessage("error", res.msg);
+      }
+    });
+  }
+
+  render() {
+    const application = this.getApplicationObj();
+    if (application === undefined) {
+      return null;
+    }
+    if (application === null) {
+      return Util.renderMessageLarge(this, this.state.msg);
+    }
+
+    return (
+      <div className="forget-content" style={{padding: Setting.isMobile() ? "0" : null, boxShadow: Setting.isMobile() ? "none" : null}}>
+        <Row>
+          <Col span={24} style={{justifyContent: "center"}}>
+            <div style={{marginTop: "80px", marginBottom: "10px", textAlign: "center"}}>
+              {
+                Setting.renderHelmet(application)
+              }
+              {
+                Setting.renderLogo(application)
+              }
+            </div>
+            <div style={{textAlign: "center", fontSize: "28px"}}>
+              {i18next.t("user:Activate account")}
+            </div>
+            <div style={{textAlign: "center", marginTop: "10px"}}>
+              {this.state.userId}
+            </div>
+          </Col>
+          <Col span={24} style={{display: "flex", justifyContent: "center"}}>
+            <Form
+              name="activation"
+              style={{width: "300px", marginTop: "40px"}}
+              onFinish={(values) => this.onFinish(values)}
+              size="large"
+            >
+              <Form.Item
+                name="newPassword"
+                rules={[
+                  {
+                    required: true,
+                    validateTrigger: "onChange",
+                    validator: (rule, value) => {
+                      const errorMsg = PasswordChecker.checkPasswordComplexity(value, application.organizationObj.passwordOptions);
+                      if (errorMsg === "") {
+                        return Promise.resolve();
+                      } else {
+                        return Promise.reject(errorMsg);
+                      }
+                    },
+                  },
+                ]}
+                hasFeedback
+              >
+                <Input.Password
+                  prefix={<LockOutlined />}
+                  placeholder={i18next.t("general:Password")}
+                />
+              </Form.Item>
+              <Form.Item
+                name="confirm"
+                dependencies={["newPassword"]}
+                hasFeedback
+                rules={[
+                  {
+                    required: true,
+                    message: i18next.t("signup:Please confirm your password!"),
+                  },
+                  ({getFieldValue}) => ({
+                    validator(rule, value) {
+                      if (!value || getFieldValue("newPassword") === value) {
+                        return Promise.resolve();
+                      }
+                      return Promise.reject(
+                        i18next.t("signup:Your confirmed password is inconsistent with the password!")
+                      );
+                    },
+                  }),
+                ]}
+              >
+                <Input.Password
+                  prefix={<CheckCircleOutlined />}
+                  placeholder={i18next.t("signup:Confirm")}
+                />
+              </Form.Item>
+              <Form.Item>
+                <Button block type="primary" htmlType="submit">
+                  {i18next.t("user:Activate account")}
+                </Button>
+              </Form.Item>
+            </Form>
+          </Col>
+        </Row>
+      </div>
+    );
+  }
+}
+
+export default withRouter(ActivationPage);
@@ -145,8 +145,28 @@ export function getWechatMessageEvent() {
   }).then(res => res.json());
 }
 
+export function getWeb3Nonce() {
+  return fetch(`${authConfig.serverUrl}/api/get-web3-nonce`, {
+    method: "GET",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
 export function getCaptchaStatus(values) {
-  return fetch(`${Setting.ServerUrl}/api/get-captcha-status?organization=${values["organization"]}&user_id=${values["username"]}`, {
+  return fetch(`${Setting.ServerUrl}/api/get-captcha-status?organization=${values["organization"]}&user_id=${values["username"]}&application=${values["application"] ?? ""}&botToken=${values["botToken"] ?? ""}`, {
+    method: "GET",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
+export function getBotToken(application, fingerprint) {
+  return fetch(`${Setting.ServerUrl}/api/get-bot-token?application=${application}&fingerprint=${fingerprint}`, {
     method: "GET",
     credentials: "include",
     headers: {
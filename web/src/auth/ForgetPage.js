@@ -136,7 +136,7 @@ class ForgetPage extends React.Component {
         type: "login",
       }).then(res => {
         if (res.status === "ok") {
-          this.setState({current: 2, code: forms.step2.getFieldValue("code")});
+          this.setState({current: 2, code: res.data});
         } else {
           Setting.showMessage("error", res.msg);
         }
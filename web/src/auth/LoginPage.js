@@ -88,6 +88,7 @@ class LoginPage extends React.Component {
     }
     if (prevProps.application !== this.props.application) {
       this.setState({loginMethod: this.getDefaultLoginMethod(this.props.application)});
+      Util.fetchBotToken(this.props.application).then(botToken => this.setState({botToken: botToken}));
 
       const captchaProviderItems = this.getCaptchaProviderItems(this.props.application);
       if (captchaProviderItems) {
@@ -129,7 +130,7 @@ class LoginPage extends React.Component {
   }
 
   checkCaptchaStatus(values) {
-    AuthBackend.getCaptchaStatus(values)
+    AuthBackend.getCaptchaStatus({...values, application: this.getApplicationObj()?.name})
       .then((res) => {
         if (res.status === "ok") {
           if (res.data) {
@@ -166,7 +167,7 @@ class LoginPage extends React.Component {
     }
 
     if (this.state.owner === null || this.state.type === "saml") {
-      ApplicationBackend.getApplication("admin", this.state.applicationName)
+      ApplicationBackend.getApplication("admin", this.state.applicationName, true)
         .then((res) => {
           if (res.status === "error") {
             this.onUpdateApplication(null);
@@ -178,7 +179,7 @@ class LoginPage extends React.Component {
           this.onUpdateApplication(res.data);
         });
     } else {
-      OrganizationBackend.getDefaultApplication("admin", this.state.owner)
+      OrganizationBackend.getDefaultApplication("admin", this.state.owner, true)
         .then((res) => {
           if (res.status === "ok") {
             const application = res.data;
@@ -306,6 +307,7 @@ class LoginPage extends React.Component {
   }
 
   onFinish(values) {
+    values["botToken"] = this.state.botToken;
     if (this.state.loginMethod === "webAuthn") {
       let username = this.state.username;
       if (username === null || username === "") {
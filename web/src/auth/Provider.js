@@ -325,6 +325,10 @@ const authInfo = {
     scope: "",
     endpoint: "",
   },
+  Ethereum: {
+    scope: "",
+    endpoint: "",
+  },
 };
 
 export function getProviderUrl(provider) {
@@ -473,5 +477,7 @@ export function getAuthUrl(application, provider, method) {
     return `${redirectUri}?state=${state}`;
   } else if (provider.type === "Web3Onboard") {
     return `${redirectUri}?state=${state}`;
+  } else if (provider.type === "Ethereum") {
+    return `${redirectUri}?state=${state}`;
   }
 }
@@ -159,6 +159,7 @@ class SignupPage extends React.Component {
 
   onUpdateApplication(application) {
     this.props.onUpdateApplication(application);
+    Util.fetchBotToken(application).then(botToken => this.setState({botToken: botToken}));
   }
 
   parseOffset(offset) {
@@ -179,6 +180,7 @@ class SignupPage extends React.Component {
     const params = new URLSearchParams(window.location.search);
     values.plan = params.get("plan");
     values.pricing = params.get("pricing");
+    values.botToken = this.state.botToken;
     AuthBackend.signup(values)
       .then((res) => {
         if (res.status === "ok") {
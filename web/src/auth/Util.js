@@ -15,7 +15,7 @@
 import React from "react";
 import {Alert, Button, Result} from "antd";
 import i18next from "i18next";
-import {getWechatMessageEvent} from "./AuthBackend";
+import {getBotToken, getWechatMessageEvent} from "./AuthBackend";
 import * as Setting from "../Setting";
 import * as Provider from "./Provider";
 
@@ -196,3 +196,30 @@ export function getEvent(application, provider) {
       }
     });
 }
+
+function getDeviceFingerprint() {
+  const signals = [
+    navigator.userAgent,
+    navigator.language,
+    navigator.platform,
+    navigator.hardwareConcurrency,
+    `${window.screen.width}x${window.screen.height}x${window.screen.colorDepth}`,
+    Intl.DateTimeFormat().resolvedOptions().timeZone,
+    navigator.webdriver ? "webdriver" : "",
+  ].join("|");
+
+  return crypto.subtle.digest("SHA-256", new TextEncoder().encode(signals))
+    .then(digest => Array.from(new Uint8Array(digest)).map(b => b.toString(16).padStart(2, "0")).join(""));
+}
+
+// fetchBotToken gets the signed fingerprint token of the bot detection of the application, "" if it isn't enabled
+export function fetchBotToken(application) {
+  if (!application?.botDetection?.isEnabled || !window.crypto?.subtle) {
+    return Promise.resolve("");
+  }
+
+  return getDeviceFingerprint()
+    .then(fingerprint => getBotToken(application.name, fingerprint))
+    .then(res => res.status === "ok" ? res.data : "")
+    .catch(() => "");
+}
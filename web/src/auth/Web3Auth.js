@@ -18,6 +18,8 @@ import {v4 as uuidv4} from "uuid";
 import {SignTypedDataVersion, recoverTypedSignature} from "@metamask/eth-sig-util";
 import {getAuthUrl} from "./Provider";
 import {Buffer} from "buffer";
+import {ethers} from "ethers";
+import * as AuthBackend from "./AuthBackend";
 import Onboard from "@web3-onboard/core";
 import injectedModule from "@web3-onboard/injected-wallets";
 import infinityWalletModule from "@web3-onboard/infinity-wallet";
@@ -166,6 +168,48 @@ export async function authViaMetaMask(application, provider, method) {
   }
 }
 
+export function createSiweMessage(application, address, chainId, nonce) {
+  // https://eips.ethereum.org/EIPS/eip-4361
+  return `${window.location.host} wants you to sign in with your Ethereum account:
+${address}
+
+Sign in to ${application.displayName}
+
+URI: ${window.location.origin}
+Version: 1
+Chain ID: ${chainId}
+Nonce: ${nonce}
+Issued At: ${new Date().toISOString()}`;
+}
+
+export async function authViaEthereum(application, provider, method) {
+  if (!window.ethereum) {
+    showMessage("error", `${i18next.t("login:Ethereum wallet not detected")}`);
+    return;
+  }
+  try {
+    const account = ethers.utils.getAddress(await requestEthereumAccount());
+    const chainId = parseInt(await window.ethereum.request({method: "eth_chainId"}), 16);
+    // the nonce is bound to the session and can be used only once
+    const res = await AuthBackend.getWeb3Nonce();
+    if (res.status !== "ok") {
+      showMessage("error", res.msg);
+      return;
+    }
+
+    const message = createSiweMessage(application, account, chainId, res.data);
+    const signature = await window.ethereum.request({
+      method: "personal_sign",
+      params: [ethers.utils.hexlify(ethers.utils.toUtf8Bytes(message)), account],
+    });
+    setWeb3AuthToken({address: account, message: message, signature: signature});
+    const redirectUri = `${getAuthUrl(application, provider, method)}&web3AuthTokenKey=${getWeb3AuthTokenKey(account)}`;
+    goToLink(redirectUri);
+  } catch (err) {
+    showMessage("error", `${i18next.t("login:Failed to obtain Ethereum authorization")}: ${err.message}`);
+  }
+}
+
 const web3Wallets = {
   // injected wallets
   injected: {
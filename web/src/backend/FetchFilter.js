@@ -12,11 +12,12 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-import {Modal} from "antd";
+import {Input, Modal} from "antd";
 import {ExclamationCircleFilled} from "@ant-design/icons";
 import i18next from "i18next";
 import * as Conf from "../Conf";
 import * as Setting from "../Setting";
+import * as MfaBackend from "./MfaBackend";
 
 const {confirm} = Modal;
 const {fetch: originalFetch} = window;
@@ -39,8 +40,91 @@ const demoModeCallback = (res) => {
   });
 };
 
-const requestFilters = [];
-const responseFilters = [];
+const getCsrfToken = () => {
+  const cookie = document.cookie.split("; ").find(item => item.startsWith("casdoor_csrf_token="));
+  return cookie ? decodeURIComponent(cookie.split("=")[1]) : "";
+};
+
+const isBackendUrl = (url) => {
+  if (Setting.ServerUrl !== "" && url.startsWith(Setting.ServerUrl)) {
+    return true;
+  }
+  return new URL(url, window.location.href).origin === window.location.origin;
+};
+
+// submits the CSRF token of the session back in the header for the state-changing requests to the backend
+const csrfCallback = (url, option) => {
+  const method = (option.method ?? "GET").toUpperCase();
+  if (["GET", "HEAD", "OPTIONS"].includes(method) || !isBackendUrl(url)) {
+    return;
+  }
+
+  const token = getCsrfToken();
+  if (token === "") {
+    return;
+  }
+
+  if (option.headers instanceof Headers) {
+    option.headers.set("X-CSRF-Token", token);
+  } else {
+    option.headers = {...option.headers, "X-CSRF-Token": token};
+  }
+};
+
+// submits the token of the last elevation for the destructive admin operations requiring a fresh MFA
+const elevationCallback = (url, option) => {
+  const token = sessionStorage.getItem("elevationToken");
+  if (!token || (option.method ?? "GET").toUpperCase() !== "POST" || !isBackendUrl(url)) {
+    return;
+  }
+
+  if (option.headers instanceof Headers) {
+    option.headers.set("X-Elevation-Token", token);
+  } else {
+    option.headers = {...option.headers, "X-Elevation-Token": token};
+  }
+};
+
+// asks for the MFA passcode to elevate when the operation requires a fresh MFA, the operation is retried by the user
+const elevationRequiredCallback = (res, option) => {
+  if ((option.method ?? "GET").toUpperCase() !== "POST") {
+    return;
+  }
+
+  res.json().then(data => {
+    if (data.code !== "ELEVATION_REQUIRED") {
+      return;
+    }
+
+    let passcode = "";
+    confirm({
+      title: i18next.t("mfa:Elevation required"),
+      icon: <ExclamationCircleFilled />,
+      content: (
+        <>
+          <p>{data.msg}</p>
+          <Input placeholder={i18next.t("mfa:Passcode")} onChange={e => {passcode = e.target.value;}} />
+        </>
+      ),
+      okText: i18next.t("general:OK"),
+      cancelText: i18next.t("general:Cancel"),
+      onOk() {
+        return MfaBackend.elevate({passcode: passcode}).then(res => {
+          if (res.status === "ok") {
+            sessionStorage.setItem("elevationToken", res.data.token);
+            Setting.showMessage("success", i18next.t("mfa:Elevated successfully, please retry the operation"));
+          } else {
+            Setting.showMessage("error", res.msg);
+          }
+        });
+      },
+      onCancel() {},
+    });
+  }).catch(() => {});
+};
+
+const requestFilters = [csrfCallback, elevationCallback];
+const responseFilters = [elevationRequiredCallback];
 
 if (Conf.IsDemoMode) {
   responseFilters.push(demoModeCallback);
@@ -53,7 +137,7 @@ window.fetch = async(url, option = {}) => {
     originalFetch(url, option)
       .then(res => {
         if (!url.startsWith("/api/get-organizations")) {
-          responseFilters.forEach(filter => filter(res.clone()));
+          responseFilters.forEach(filter => filter(res.clone(), option));
         }
         resolve(res);
       })
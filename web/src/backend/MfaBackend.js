@@ -73,3 +73,14 @@ export function SetPreferredMfa(values) {
     body: formData,
   }).then((res) => res.json());
 }
+
+export function elevate(values) {
+  return fetch(`${Setting.ServerUrl}/api/elevate`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify(values),
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then((res) => res.json());
+}
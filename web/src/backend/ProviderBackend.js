@@ -34,6 +34,16 @@ export function getGlobalProviders(page = "", pageSize = "", field = "", value =
   }).then(res => res.json());
 }
 
+export function getCatalogProviders(owner) {
+  return fetch(`${Setting.ServerUrl}/api/get-catalog-providers?owner=${owner}`, {
+    method: "GET",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
 export function getProvider(owner, name) {
   return fetch(`${Setting.ServerUrl}/api/get-provider?id=${owner}/${encodeURIComponent(name)}`, {
     method: "GET",
@@ -79,3 +89,13 @@ export function deleteProvider(provider) {
     },
   }).then(res => res.json());
 }
+
+export function refreshSamlMetadata(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/refresh-saml-metadata?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "POST",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
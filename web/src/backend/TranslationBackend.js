@@ -0,0 +1,93 @@
+// Copyright 2023 The Casdoor Authors. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+import * as Setting from "../Setting";
+
+export function getTranslations(owner, page = "", pageSize = "", field = "", value = "", sortField = "", sortOrder = "") {
+  return fetch(`${Setting.ServerUrl}/api/get-translations?owner=${owner}&p=${page}&pageSize=${pageSize}&field=${field}&value=${value}&sortField=${sortField}&sortOrder=${sortOrder}`, {
+    method: "GET",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
+export function getTranslation(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/get-translation?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "GET",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
+export function updateTranslation(owner, name, translation) {
+  const newTranslation = Setting.deepCopy(translation);
+  return fetch(`${Setting.ServerUrl}/api/update-translation?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify(newTranslation),
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
+export function addTranslation(translation) {
+  const newTranslation = Setting.deepCopy(translation);
+  return fetch(`${Setting.ServerUrl}/api/add-translation`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify(newTranslation),
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
+export function deleteTranslation(translation) {
+  const newTranslation = Setting.deepCopy(translation);
+  return fetch(`${Setting.ServerUrl}/api/delete-translation`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify(newTranslation),
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
+export function exportTranslations(owner, category, language) {
+  return fetch(`${Setting.ServerUrl}/api/export-translations?owner=${owner}&category=${category}&language=${language}`, {
+    method: "GET",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
+export function importTranslations(pack) {
+  const newPack = Setting.deepCopy(pack);
+  return fetch(`${Setting.ServerUrl}/api/import-translations`, {
+    method: "POST",
+    credentials: "include",
+    body: JSON.stringify(newPack),
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
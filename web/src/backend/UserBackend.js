@@ -56,6 +56,16 @@ export function addUserKeys(user) {
   }).then(res => res.json());
 }
 
+export function issueBreakGlassKey(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/issue-break-glass-key?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "POST",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}
+
 export function updateUser(owner, name, user) {
   const newUser = Setting.deepCopy(user);
   return fetch(`${Setting.ServerUrl}/api/update-user?id=${owner}/${encodeURIComponent(name)}`, {
@@ -104,7 +114,7 @@ export function getAffiliationOptions(url, code) {
   }).then(res => res.json());
 }
 
-export function setPassword(userOwner, userName, oldPassword, newPassword, code = "") {
+export function setPassword(userOwner, userName, oldPassword, newPassword, code = "", activationCode = "") {
   const formData = new FormData();
   formData.append("userOwner", userOwner);
   formData.append("userName", userName);
@@ -113,6 +123,9 @@ export function setPassword(userOwner, userName, oldPassword, newPassword, code
   if (code) {
     formData.append("code", code);
   }
+  if (activationCode) {
+    formData.append("activationCode", activationCode);
+  }
 
   return fetch(`${Setting.ServerUrl}/api/set-password`, {
     method: "POST",
@@ -69,3 +69,13 @@ export function deleteWebhook(webhook) {
     },
   }).then(res => res.json());
 }
+
+export function rotateWebhookSecret(owner, name) {
+  return fetch(`${Setting.ServerUrl}/api/rotate-webhook-secret?id=${owner}/${encodeURIComponent(name)}`, {
+    method: "POST",
+    credentials: "include",
+    headers: {
+      "Accept-Language": Setting.getAcceptLanguage(),
+    },
+  }).then(res => res.json());
+}